@@ -95,6 +95,11 @@ type Authorizer interface {
 	// NodeRead checks for permission to read (discover) a given node.
 	NodeRead(string) bool
 
+	// NodeReadPrefix checks for permission to read all the nodes whose
+	// name starts with a prefix. This means there must be no sub-policies
+	// that deny a read.
+	NodeReadPrefix(string) bool
+
 	// NodeWrite checks for permission to create or update (register) a
 	// given node.
 	NodeWrite(string, sentinel.ScopeFn) bool
@@ -205,6 +210,10 @@ func (s *StaticAuthorizer) NodeRead(string) bool {
 	return s.defaultAllow
 }
 
+func (s *StaticAuthorizer) NodeReadPrefix(string) bool {
+	return s.defaultAllow
+}
+
 func (s *StaticAuthorizer) NodeWrite(string, sentinel.ScopeFn) bool {
 	return s.defaultAllow
 }
@@ -845,6 +854,67 @@ func (p *PolicyAuthorizer) NodeRead(name string) bool {
 	return p.parent.NodeRead(name)
 }
 
+// NodeReadPrefix checks if all the nodes with the given name prefix can be
+// read, meaning no rule denies the read of any of them.
+func (p *PolicyAuthorizer) NodeReadPrefix(prefix string) bool {
+	// Look for a prefix rule that would apply to the prefix we are checking
+	// WalkPath starts at the root and walks down to the given prefix.
+	// Therefore the last prefix rule we see is the one that matters
+	prefixAllowed := true
+	found := false
+	p.nodeRules.WalkPath(prefix, func(path string, leaf interface{}) bool {
+		rule := leaf.(*policyAuthorizerRadixLeaf)
+
+		if rule.prefix != nil {
+			if allow, recurse := enforce(rule.prefix.(RulePolicy).aclPolicy, PolicyRead); !recurse {
+				found = true
+				prefixAllowed = allow
+			}
+		}
+		return false
+	})
+
+	if !prefixAllowed {
+		return false
+	}
+
+	// Look if any of our children do not allow read access. This loop takes
+	// into account both prefix and exact match rules.
+	deny := false
+	p.nodeRules.WalkPrefix(prefix, func(path string, leaf interface{}) bool {
+		rule := leaf.(*policyAuthorizerRadixLeaf)
+
+		if rule.prefix != nil {
+			if allow, recurse := enforce(rule.prefix.(RulePolicy).aclPolicy, PolicyRead); !allow && !recurse {
+				deny = true
+				return true
+			}
+		}
+		if rule.exact != nil {
+			if allow, recurse := enforce(rule.exact.(RulePolicy).aclPolicy, PolicyRead); !allow && !recurse {
+				deny = true
+				return true
+			}
+		}
+
+		return false
+	})
+
+	// Deny the read if any sub-rules may be violated
+	if deny {
+		return false
+	}
+
+	// If a prefix rule covers all the nodes, done. Exact rules alone don't
+	// say anything about the other nodes with the prefix.
+	if found {
+		return true
+	}
+
+	// No covering rule, use the parent.
+	return p.parent.NodeReadPrefix(prefix)
+}
+
 // NodeWrite checks if writing (registering) a node is allowed
 func (p *PolicyAuthorizer) NodeWrite(name string, scope sentinel.ScopeFn) bool {
 	// Check for an exact rule or catch-all
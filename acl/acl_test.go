@@ -98,6 +98,10 @@ func checkAllowNodeRead(t *testing.T, authz Authorizer, prefix string) {
 	require.True(t, authz.NodeRead(prefix))
 }
 
+func checkAllowNodeReadPrefix(t *testing.T, authz Authorizer, prefix string) {
+	require.True(t, authz.NodeReadPrefix(prefix))
+}
+
 func checkAllowNodeWrite(t *testing.T, authz Authorizer, prefix string) {
 	require.True(t, authz.NodeWrite(prefix, nil))
 }
@@ -202,6 +206,10 @@ func checkDenyNodeRead(t *testing.T, authz Authorizer, prefix string) {
 	require.False(t, authz.NodeRead(prefix))
 }
 
+func checkDenyNodeReadPrefix(t *testing.T, authz Authorizer, prefix string) {
+	require.False(t, authz.NodeReadPrefix(prefix))
+}
+
 func checkDenyNodeWrite(t *testing.T, authz Authorizer, prefix string) {
 	require.False(t, authz.NodeWrite(prefix, nil))
 }
@@ -275,6 +283,7 @@ func TestACL(t *testing.T) {
 				{name: "DenyKeyringWrite", check: checkDenyKeyringWrite},
 				{name: "DenyKeyWrite", check: checkDenyKeyWrite},
 				{name: "DenyNodeRead", check: checkDenyNodeRead},
+				{name: "DenyNodeReadPrefix", check: checkDenyNodeReadPrefix},
 				{name: "DenyNodeWrite", check: checkDenyNodeWrite},
 				{name: "DenyOperatorRead", check: checkDenyOperatorRead},
 				{name: "DenyOperatorWrite", check: checkDenyOperatorWrite},
@@ -305,6 +314,7 @@ func TestACL(t *testing.T) {
 				{name: "AllowKeyringWrite", check: checkAllowKeyringWrite},
 				{name: "AllowKeyWrite", check: checkAllowKeyWrite},
 				{name: "AllowNodeRead", check: checkAllowNodeRead},
+				{name: "AllowNodeReadPrefix", check: checkAllowNodeReadPrefix},
 				{name: "AllowNodeWrite", check: checkAllowNodeWrite},
 				{name: "AllowOperatorRead", check: checkAllowOperatorRead},
 				{name: "AllowOperatorWrite", check: checkAllowOperatorWrite},
@@ -335,6 +345,7 @@ func TestACL(t *testing.T) {
 				{name: "AllowKeyringWrite", check: checkAllowKeyringWrite},
 				{name: "AllowKeyWrite", check: checkAllowKeyWrite},
 				{name: "AllowNodeRead", check: checkAllowNodeRead},
+				{name: "AllowNodeReadPrefix", check: checkAllowNodeReadPrefix},
 				{name: "AllowNodeWrite", check: checkAllowNodeWrite},
 				{name: "AllowOperatorRead", check: checkAllowOperatorRead},
 				{name: "AllowOperatorWrite", check: checkAllowOperatorWrite},
@@ -875,6 +886,14 @@ func TestACL(t *testing.T) {
 				{name: "ChildRWSuffixWriteAllowed", prefix: "child-rw-prefix", check: checkAllowNodeWrite},
 				{name: "ChildOverrideReadAllowed", prefix: "override", check: checkAllowNodeRead},
 				{name: "ChildOverrideWriteAllowed", prefix: "override", check: checkAllowNodeWrite},
+				{name: "DefaultReadPrefixDenied", prefix: "nope", check: checkDenyNodeReadPrefix},
+				{name: "AllReadPrefixDenied", prefix: "", check: checkDenyNodeReadPrefix},
+				{name: "DenyReadPrefixDenied", prefix: "root-nope", check: checkDenyNodeReadPrefix},
+				{name: "ROReadPrefixAllowed", prefix: "root-ro", check: checkAllowNodeReadPrefix},
+				{name: "RWReadPrefixAllowed", prefix: "root-rw", check: checkAllowNodeReadPrefix},
+				{name: "ChildDenyReadPrefixDenied", prefix: "child-nope", check: checkDenyNodeReadPrefix},
+				{name: "ChildROReadPrefixAllowed", prefix: "child-ro", check: checkAllowNodeReadPrefix},
+				{name: "ChildOverrideReadPrefixAllowed", prefix: "override", check: checkAllowNodeReadPrefix},
 			},
 		},
 		{
@@ -951,6 +970,12 @@ func TestACL(t *testing.T) {
 				{name: "ChildRWSuffixWriteAllowed", prefix: "child-rw-prefix", check: checkAllowNodeWrite},
 				{name: "ChildOverrideReadAllowed", prefix: "override", check: checkAllowNodeRead},
 				{name: "ChildOverrideWriteAllowed", prefix: "override", check: checkAllowNodeWrite},
+				{name: "DefaultReadPrefixAllowed", prefix: "nope", check: checkAllowNodeReadPrefix},
+				{name: "AllReadPrefixDenied", prefix: "", check: checkDenyNodeReadPrefix},
+				{name: "DenyReadPrefixDenied", prefix: "root-nope", check: checkDenyNodeReadPrefix},
+				{name: "ROReadPrefixAllowed", prefix: "root-ro", check: checkAllowNodeReadPrefix},
+				{name: "ChildDenyReadPrefixDenied", prefix: "child-nope", check: checkDenyNodeReadPrefix},
+				{name: "ChildROReadPrefixAllowed", prefix: "child-ro", check: checkAllowNodeReadPrefix},
 			},
 		},
 		{
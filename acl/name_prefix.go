@@ -0,0 +1,56 @@
+package acl
+
+import (
+	"strings"
+
+	"github.com/hashicorp/consul/sentinel"
+)
+
+// NamePrefixAuthorizer wraps an Authorizer to restrict the KV and service
+// writes to the keys and service names starting with a prefix. All the other
+// checks are delegated to the wrapped Authorizer.
+type NamePrefixAuthorizer struct {
+	Authorizer
+
+	prefix string
+}
+
+// NewNamePrefixAuthorizer returns an Authorizer restricting the writes of
+// the given parent to the given prefix. The parent is returned as is if the
+// prefix is empty.
+func NewNamePrefixAuthorizer(prefix string, parent Authorizer) Authorizer {
+	if prefix == "" {
+		return parent
+	}
+	return &NamePrefixAuthorizer{
+		Authorizer: parent,
+		prefix:     prefix,
+	}
+}
+
+// KeyWrite checks the key is under the prefix before delegating to the
+// parent.
+func (p *NamePrefixAuthorizer) KeyWrite(key string, scope sentinel.ScopeFn) bool {
+	if !strings.HasPrefix(key, p.prefix) {
+		return false
+	}
+	return p.Authorizer.KeyWrite(key, scope)
+}
+
+// KeyWritePrefix checks the whole key prefix is under the prefix before
+// delegating to the parent.
+func (p *NamePrefixAuthorizer) KeyWritePrefix(prefix string) bool {
+	if !strings.HasPrefix(prefix, p.prefix) {
+		return false
+	}
+	return p.Authorizer.KeyWritePrefix(prefix)
+}
+
+// ServiceWrite checks the service name starts with the prefix before
+// delegating to the parent.
+func (p *NamePrefixAuthorizer) ServiceWrite(name string, scope sentinel.ScopeFn) bool {
+	if !strings.HasPrefix(name, p.prefix) {
+		return false
+	}
+	return p.Authorizer.ServiceWrite(name, scope)
+}
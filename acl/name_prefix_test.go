@@ -0,0 +1,32 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamePrefixAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, ManageAll(), NewNamePrefixAuthorizer("", ManageAll()))
+
+	authz := NewNamePrefixAuthorizer("team-a/", ManageAll())
+	require.True(t, authz.KeyWrite("team-a/foo", nil))
+	require.False(t, authz.KeyWrite("team-b/foo", nil))
+	require.True(t, authz.KeyWritePrefix("team-a/foo/"))
+	require.False(t, authz.KeyWritePrefix(""))
+	require.True(t, authz.ServiceWrite("team-a/web", nil))
+	require.False(t, authz.ServiceWrite("web", nil))
+
+	// The other checks are delegated
+	require.True(t, authz.KeyRead("team-b/foo"))
+	require.True(t, authz.ServiceRead("web"))
+	require.True(t, authz.NodeWrite("node1", nil))
+	require.True(t, authz.ACLWrite())
+
+	// The parent still has to allow the writes
+	authz = NewNamePrefixAuthorizer("team-a/", DenyAll())
+	require.False(t, authz.KeyWrite("team-a/foo", nil))
+	require.False(t, authz.ServiceWrite("team-a/web", nil))
+}
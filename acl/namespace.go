@@ -0,0 +1,50 @@
+package acl
+
+// NamespaceAuthorizer wraps the Authorizer of a token bound to an ACL
+// namespace. Namespaces only scope the ACL objects, so the permissions over
+// the whole cluster, which would let the token reach the objects of the other
+// namespaces, are denied. All the other checks are delegated to the wrapped
+// Authorizer.
+type NamespaceAuthorizer struct {
+	Authorizer
+}
+
+// NewNamespaceAuthorizer returns an Authorizer for the tokens of the given
+// namespace. The parent is returned as is for the tokens which aren't bound
+// to a namespace, for which the namespace is empty.
+func NewNamespaceAuthorizer(namespace string, parent Authorizer) Authorizer {
+	if namespace == "" {
+		return parent
+	}
+	return &NamespaceAuthorizer{Authorizer: parent}
+}
+
+// KeyringRead always returns false since the keyring is shared by the whole
+// cluster.
+func (n *NamespaceAuthorizer) KeyringRead() bool {
+	return false
+}
+
+// KeyringWrite always returns false since the keyring is shared by the whole
+// cluster.
+func (n *NamespaceAuthorizer) KeyringWrite() bool {
+	return false
+}
+
+// OperatorRead always returns false since the operator endpoints cover the
+// whole cluster.
+func (n *NamespaceAuthorizer) OperatorRead() bool {
+	return false
+}
+
+// OperatorWrite always returns false since the operator endpoints cover the
+// whole cluster.
+func (n *NamespaceAuthorizer) OperatorWrite() bool {
+	return false
+}
+
+// Snapshot always returns false since the snapshots hold the state of the
+// whole cluster, including the tokens of every namespace.
+func (n *NamespaceAuthorizer) Snapshot() bool {
+	return false
+}
@@ -0,0 +1,26 @@
+package acl
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, ManageAll(), NewNamespaceAuthorizer("", ManageAll()))
+
+	authz := NewNamespaceAuthorizer("team-a", ManageAll())
+	require.False(t, authz.KeyringRead())
+	require.False(t, authz.KeyringWrite())
+	require.False(t, authz.OperatorRead())
+	require.False(t, authz.OperatorWrite())
+	require.False(t, authz.Snapshot())
+
+	// The other checks are delegated
+	require.True(t, authz.ACLWrite())
+	require.True(t, authz.KeyWrite("foo", nil))
+	require.True(t, authz.ServiceWrite("web", nil))
+	require.False(t, NewNamespaceAuthorizer("team-a", DenyAll()).ACLRead())
+}
@@ -186,6 +186,18 @@ func (s *HTTPServer) ACLPolicyList(resp http.ResponseWriter, req *http.Request)
 	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
+	s.parseNamespace(req, &args.Namespace)
+	if err := parseLabelSelector(req, &args.QueryOptions); err != nil {
+		return nil, err
+	}
+
+	if within := req.URL.Query().Get("expires-within"); within != "" {
+		dur, err := time.ParseDuration(within)
+		if err != nil {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Invalid expires-within: %v", err)}
+		}
+		args.ExpiresWithin = dur
+	}
 
 	if args.Datacenter == "" {
 		args.Datacenter = s.agent.config.Datacenter
@@ -242,6 +254,7 @@ func (s *HTTPServer) ACLPolicyRead(resp http.ResponseWriter, req *http.Request,
 	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
+	s.parseNamespace(req, &args.Namespace)
 
 	if args.Datacenter == "" {
 		args.Datacenter = s.agent.config.Datacenter
@@ -268,8 +281,8 @@ func (s *HTTPServer) ACLPolicyCreate(resp http.ResponseWriter, req *http.Request
 	return s.ACLPolicyWrite(resp, req, "")
 }
 
-// fixCreateTimeAndHash is used to help in decoding the CreateTime and Hash
-// attributes from the ACL Token/Policy create/update requests. It is needed
+// fixCreateTimeAndHash is used to help in decoding the CreateTime, DeleteAfter
+// and Hash attributes from the ACL Token/Policy create/update requests. It is needed
 // to help mapstructure decode things properly when decodeBody is used.
 func fixCreateTimeAndHash(raw interface{}) error {
 	rawMap, ok := raw.(map[string]interface{})
@@ -277,13 +290,15 @@ func fixCreateTimeAndHash(raw interface{}) error {
 		return nil
 	}
 
-	if val, ok := rawMap["CreateTime"]; ok {
-		if sval, ok := val.(string); ok {
-			t, err := time.Parse(time.RFC3339, sval)
-			if err != nil {
-				return err
+	for _, field := range []string{"CreateTime", "DeleteAfter"} {
+		if val, ok := rawMap[field]; ok {
+			if sval, ok := val.(string); ok {
+				t, err := time.Parse(time.RFC3339, sval)
+				if err != nil {
+					return err
+				}
+				rawMap[field] = t
 			}
-			rawMap["CreateTime"] = t
 		}
 	}
 
@@ -304,6 +319,7 @@ func (s *HTTPServer) ACLPolicyWrite(resp http.ResponseWriter, req *http.Request,
 	if err := decodeBody(req, &args.Policy, fixCreateTimeAndHash); err != nil {
 		return nil, BadRequestError{Reason: fmt.Sprintf("Policy decoding failed: %v", err)}
 	}
+	s.parseNamespace(req, &args.Policy.Namespace)
 
 	args.Policy.Syntax = acl.SyntaxCurrent
 
@@ -327,6 +343,7 @@ func (s *HTTPServer) ACLPolicyDelete(resp http.ResponseWriter, req *http.Request
 		PolicyID:   policyID,
 	}
 	s.parseToken(req, &args.Token)
+	s.parseNamespace(req, &args.Namespace)
 
 	var ignored string
 	if err := s.agent.RPC("ACL.PolicyDelete", args, &ignored); err != nil {
@@ -354,6 +371,10 @@ func (s *HTTPServer) ACLTokenList(resp http.ResponseWriter, req *http.Request) (
 	}
 
 	args.Policy = req.URL.Query().Get("policy")
+	s.parseNamespace(req, &args.Namespace)
+	if err := parseLabelSelector(req, &args.QueryOptions); err != nil {
+		return nil, err
+	}
 
 	var out structs.ACLTokenListResponse
 	defer setMeta(resp, &out.QueryMeta)
@@ -448,6 +469,7 @@ func (s *HTTPServer) ACLTokenGet(resp http.ResponseWriter, req *http.Request, to
 	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
+	s.parseNamespace(req, &args.Namespace)
 
 	if args.Datacenter == "" {
 		args.Datacenter = s.agent.config.Datacenter
@@ -475,6 +497,7 @@ func (s *HTTPServer) ACLTokenSet(resp http.ResponseWriter, req *http.Request, to
 	if err := decodeBody(req, &args.ACLToken, fixCreateTimeAndHash); err != nil {
 		return nil, BadRequestError{Reason: fmt.Sprintf("Token decoding failed: %v", err)}
 	}
+	s.parseNamespace(req, &args.ACLToken.Namespace)
 
 	if args.ACLToken.AccessorID != "" && args.ACLToken.AccessorID != tokenID {
 		return nil, BadRequestError{Reason: "Token Accessor ID in URL and payload do not match"}
@@ -496,6 +519,7 @@ func (s *HTTPServer) ACLTokenDelete(resp http.ResponseWriter, req *http.Request,
 		TokenID:    tokenID,
 	}
 	s.parseToken(req, &args.Token)
+	s.parseNamespace(req, &args.Namespace)
 
 	var ignored string
 	if err := s.agent.RPC("ACL.TokenDelete", args, &ignored); err != nil {
@@ -517,6 +541,7 @@ func (s *HTTPServer) ACLTokenClone(resp http.ResponseWriter, req *http.Request,
 		return nil, BadRequestError{Reason: fmt.Sprintf("Token decoding failed: %v", err)}
 	}
 	s.parseToken(req, &args.Token)
+	s.parseNamespace(req, &args.ACLToken.Namespace)
 
 	// Set this for the ID to clone
 	args.ACLToken.AccessorID = tokenID
@@ -3,10 +3,12 @@ package agent
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/testrpc"
@@ -353,6 +355,44 @@ func TestACL_HTTP(t *testing.T) {
 			require.True(t, ok)
 			require.Equal(t, policyMap[idMap["policy-read-all-nodes"]], policy)
 		})
+
+		t.Run("Delete After", func(t *testing.T) {
+			deleteAfter := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+			body := bytes.NewBufferString(fmt.Sprintf(`{
+				"Name": "temporary",
+				"Rules": "key_prefix \"\" { policy = \"write\" }",
+				"DeleteAfter": %q
+			}`, deleteAfter.Format(time.RFC3339)))
+			req, _ := http.NewRequest("PUT", "/v1/acl/policy?token=root", body)
+			resp := httptest.NewRecorder()
+			obj, err := a.srv.ACLPolicyCreate(resp, req)
+			require.NoError(t, err)
+			policy := obj.(*structs.ACLPolicy)
+			require.NotNil(t, policy.DeleteAfter)
+			require.True(t, deleteAfter.Equal(*policy.DeleteAfter))
+
+			list := func(within string) structs.ACLPolicyListStubs {
+				req, _ := http.NewRequest("GET", "/v1/acl/policies?token=root&expires-within="+within, nil)
+				resp := httptest.NewRecorder()
+				raw, err := a.srv.ACLPolicyList(resp, req)
+				require.NoError(t, err)
+				return raw.(structs.ACLPolicyListStubs)
+			}
+			require.Empty(t, list("10m"))
+			policies := list("2h")
+			require.Len(t, policies, 1)
+			require.Equal(t, policy.ID, policies[0].ID)
+
+			req, _ = http.NewRequest("GET", "/v1/acl/policies?token=root&expires-within=soon", nil)
+			_, err = a.srv.ACLPolicyList(httptest.NewRecorder(), req)
+			require.Error(t, err)
+			_, ok := err.(BadRequestError)
+			require.True(t, ok)
+
+			req, _ = http.NewRequest("DELETE", "/v1/acl/policy/"+policy.ID+"?token=root", nil)
+			_, err = a.srv.ACLPolicyCRUD(httptest.NewRecorder(), req)
+			require.NoError(t, err)
+		})
 	})
 
 	t.Run("Token", func(t *testing.T) {
@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -14,6 +15,7 @@ import (
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/local"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/subscribe"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/logger"
 	"github.com/hashicorp/consul/types"
@@ -137,6 +139,9 @@ func (a *TestACLAgent) RPC(method string, args interface{}, reply interface{}) e
 func (a *TestACLAgent) SnapshotRPC(args *structs.SnapshotRequest, in io.Reader, out io.Writer, replyFn structs.SnapshotReplyFn) error {
 	return fmt.Errorf("Unimplemented")
 }
+func (a *TestACLAgent) Subscribe(ctx context.Context, req *subscribe.Request, fn func(*subscribe.Event) error) error {
+	return fmt.Errorf("Unimplemented")
+}
 func (a *TestACLAgent) Shutdown() error {
 	return fmt.Errorf("Unimplemented")
 }
@@ -13,6 +13,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strconv"
 	"strings"
 	"sync"
@@ -32,6 +33,7 @@ import (
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/proxyprocess"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/subscribe"
 	"github.com/hashicorp/consul/agent/systemd"
 	"github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/agent/xds"
@@ -63,6 +65,9 @@ const (
 	checksDir     = "checks"
 	checkStateDir = "checks/state"
 
+	// Path to spool the snapshots saved through the agent
+	snapshotSpoolDir = "snapshot-spool"
+
 	// Name of the file tokens will be persisted within
 	tokensPath = "acl-tokens.json"
 
@@ -71,6 +76,9 @@ const (
 		"but no reason was provided. This is a default message."
 	defaultServiceMaintReason = "Maintenance mode is enabled for this " +
 		"service, but no reason was provided. This is a default message."
+
+	// How often the built-in ACL replication check polls the leader
+	aclReplicationCheckInterval = 30 * time.Second
 )
 
 type configSource int
@@ -97,6 +105,7 @@ type delegate interface {
 	ACLsEnabled() bool
 	UseLegacyACLs() bool
 	SnapshotRPC(args *structs.SnapshotRequest, in io.Reader, out io.Writer, replyFn structs.SnapshotReplyFn) error
+	Subscribe(ctx context.Context, req *subscribe.Request, fn func(*subscribe.Event) error) error
 	Shutdown() error
 	Stats() map[string]map[string]string
 	ReloadConfig(config *consul.Config) error
@@ -127,6 +136,10 @@ type Agent struct {
 	// Used for streaming logs to
 	LogWriter *logger.LogWriter
 
+	// LogFilter filters the logs by level, and is used to change the levels
+	// at runtime. It's nil if the levels can't be changed.
+	LogFilter *logger.SubsystemFilter
+
 	// In-memory sink used for collecting metrics
 	MemSink *metrics.InmemSink
 
@@ -153,6 +166,10 @@ type Agent struct {
 	// cache is the in-memory cache for data the Agent requests.
 	cache *cache.Cache
 
+	// snapshots keeps the snapshots saved through the agent on disk so
+	// that their downloads can be resumed.
+	snapshots *snapshotSpool
+
 	// checkReapAfter maps the check ID to a timeout after which we should
 	// reap its associated service
 	checkReapAfter map[types.CheckID]time.Duration
@@ -178,6 +195,14 @@ type Agent struct {
 	// checkAliases maps the check ID to an associated Alias checks
 	checkAliases map[types.CheckID]*checks.CheckAlias
 
+	// checkComposites maps the check ID to an associated Composite check
+	checkComposites map[types.CheckID]*checks.CheckComposite
+
+	// checkACLReplication is the built-in check reporting the health of the
+	// ACL replication, only registered on the servers of the secondary
+	// datacenters.
+	checkACLReplication *checks.CheckACLReplication
+
 	// stateLock protects the agent state
 	stateLock sync.Mutex
 
@@ -198,6 +223,17 @@ type Agent struct {
 
 	reloadCh chan chan error
 
+	// reloadResult is the result of the last successful configuration
+	// reload, reported by the reload endpoint.
+	reloadResult *ReloadResult
+
+	// reloadedConfig is the configuration with the fields applied by the
+	// last successful reload, nil until the first one. The config field
+	// isn't replaced since it is read without any lock, use currentConfig
+	// to read the reloadable fields.
+	reloadedConfig *config.RuntimeConfig
+	reloadLock     sync.Mutex
+
 	shutdown     bool
 	shutdownCh   chan struct{}
 	shutdownLock sync.Mutex
@@ -218,6 +254,23 @@ type Agent struct {
 	// dnsServer provides the DNS API
 	dnsServers []*DNSServer
 
+	// memberChanges tracks the changes of the gossip pools for the delta
+	// mode of the members endpoint.
+	memberChanges *memberChanges
+
+	// intentionObservations holds the connections authorized without
+	// matching any intention until they are reported to the servers, when
+	// intention suggestions are enabled.
+	intentionObservations intentionObservations
+
+	// provisionalServices tracks the services registered with a deadline
+	// for their checks to pass.
+	provisionalServices provisionalServices
+
+	// dnsRecursors holds the recursors of the DNS servers along with their
+	// health, shared by the servers and updated on reload.
+	dnsRecursors *dnsRecursors
+
 	// httpServers provides the HTTP API on various endpoints
 	httpServers []*HTTPServer
 
@@ -243,6 +296,10 @@ type Agent struct {
 	// directly.
 	proxyConfig *proxycfg.Manager
 
+	// serviceManager merges the centrally managed config of the registered
+	// proxies into their local state.
+	serviceManager *serviceManager
+
 	// xdsServer is the Server instance that serves xDS gRPC API.
 	xdsServer *xds.Server
 
@@ -254,6 +311,18 @@ type Agent struct {
 	// based on the current consul configuration.
 	tlsConfigurator *tlsutil.Configurator
 
+	// autoEncrypt holds the outcome of the last request of the certificate
+	// of the RPC connections with auto-encrypt.
+	autoEncrypt autoEncryptState
+
+	// autoConfig is the configuration requested from the servers with
+	// auto-config, nil if auto-config isn't enabled.
+	autoConfig *persistedAutoConfig
+
+	// gcTuner applies the garbage collector tuning, which can be updated
+	// at runtime through the operator API.
+	gcTuner *gcTuner
+
 	// persistedTokensLock is used to synchronize access to the persisted token
 	// store within the data directory. This will prevent loading while writing as
 	// well as multiple concurrent writes.
@@ -278,6 +347,7 @@ func New(c *config.RuntimeConfig) (*Agent, error) {
 		checkGRPCs:      make(map[types.CheckID]*checks.CheckGRPC),
 		checkDockers:    make(map[types.CheckID]*checks.CheckDocker),
 		checkAliases:    make(map[types.CheckID]*checks.CheckAlias),
+		checkComposites: make(map[types.CheckID]*checks.CheckComposite),
 		eventCh:         make(chan serf.UserEvent, 1024),
 		eventBuf:        make([]*UserEvent, 256),
 		joinLANNotifier: &systemd.Notifier{},
@@ -286,6 +356,7 @@ func New(c *config.RuntimeConfig) (*Agent, error) {
 		shutdownCh:      make(chan struct{}),
 		endpoints:       make(map[string]string),
 		tokens:          new(token.Store),
+		memberChanges:   newMemberChanges(),
 	}
 
 	if err := a.initializeACLs(); err != nil {
@@ -368,10 +439,33 @@ func (a *Agent) Start() error {
 			"1 and 63 bytes.", a.config.NodeName)
 	}
 
+	// Request the configuration from the servers, which sets the gossip
+	// encryption key and the agent token, before they are used.
+	if c.AutoConfigEnabled {
+		if err := a.setupAutoConfig(); err != nil {
+			return fmt.Errorf("AutoConfig failed: %v", err)
+		}
+	}
+
 	// load the tokens - this requires the logger to be setup
 	// which is why we can't do this in New
 	a.loadTokens(a.config)
 
+	// Tune the garbage collector. The runtime defaults are left alone
+	// when no tuning is configured.
+	a.gcTuner = newGCTuner()
+	gcConf := api.GCConfiguration{
+		Percent:          c.GCPercent,
+		BallastBytes:     int64(c.GCBallastBytes),
+		MemoryLimitBytes: int64(c.GCMemoryLimitBytes),
+	}
+	if gcConf != (api.GCConfiguration{}) {
+		if err := a.gcTuner.Apply(gcConf); err != nil {
+			return fmt.Errorf("Failed to tune the garbage collector: %v", err)
+		}
+	}
+	go a.gcTuner.emitMetrics(a.shutdownCh)
+
 	// create the local state
 	a.State = local.NewState(LocalConfig(c), a.logger, a.tokens)
 
@@ -382,6 +476,12 @@ func (a *Agent) Start() error {
 	// create the cache
 	a.cache = cache.New(nil)
 
+	// create the manager of the centrally managed service config
+	a.serviceManager = newServiceManager(a)
+
+	// create the spool of the snapshots saved through the agent
+	a.snapshots = newSnapshotSpool(filepath.Join(a.config.DataDir, snapshotSpoolDir), a.logger)
+
 	// create the config for the rpc server/client
 	consulCfg, err := a.consulConfig()
 	if err != nil {
@@ -398,6 +498,12 @@ func (a *Agent) Start() error {
 		return err
 	}
 	a.tlsConfigurator = tlsConfigurator
+	if err := a.applyAutoConfigTLS(); err != nil {
+		return fmt.Errorf("AutoConfig failed: %v", err)
+	}
+	if c.TLSReloadInterval > 0 {
+		go a.watchTLSFiles(c.TLSReloadInterval)
+	}
 
 	// Setup either the client or the server.
 	if c.ServerMode {
@@ -428,6 +534,17 @@ func (a *Agent) Start() error {
 	// populated from above.
 	a.registerCache()
 
+	// Request the certificate of the RPC connections from the servers,
+	// or keep the Connect CA roots trusted for the ones they issue.
+	if client, ok := a.delegate.(*consul.Client); ok && c.AutoEncryptTLS {
+		if err := a.setupAutoEncrypt(client); err != nil {
+			return fmt.Errorf("AutoEncrypt failed: %v", err)
+		}
+	}
+	if c.AutoEncryptAllowTLS || c.AutoConfigAuthzEnabled {
+		go a.watchAutoEncryptRoots()
+	}
+
 	// Load checks/services/metadata.
 	if err := a.loadServices(c); err != nil {
 		return err
@@ -477,6 +594,9 @@ func (a *Agent) Start() error {
 	// checks.
 	go a.reapServices()
 
+	// Start confirming or rolling back the provisional services.
+	go a.watchProvisionalServices()
+
 	// Start handling events.
 	go a.handleEvents()
 
@@ -485,6 +605,11 @@ func (a *Agent) Start() error {
 		go a.sendCoordinate()
 	}
 
+	// Start reporting the connections authorized by default.
+	if c.ConnectIntentionSuggestions {
+		go a.sendIntentionObservations()
+	}
+
 	// Write out the PID file if necessary.
 	if err := a.storePid(); err != nil {
 		return err
@@ -510,6 +635,11 @@ func (a *Agent) Start() error {
 		a.httpServers = append(a.httpServers, srv)
 	}
 
+	// Write out the API bootstrap file now that the HTTP servers are up.
+	if err := a.storeAPIBootstrapFile(); err != nil {
+		return err
+	}
+
 	// Start gRPC server.
 	if err := a.listenAndServeGRPC(); err != nil {
 		return err
@@ -541,17 +671,27 @@ func (a *Agent) listenAndServeGRPC() error {
 	a.xdsServer.Initialize()
 
 	var err error
-	if a.config.HTTPSPort > 0 {
+	if a.config.HTTPSPort > 0 && a.config.CertFile != "" && a.config.KeyFile != "" {
 		// gRPC uses the same TLS settings as the HTTPS API. If HTTPS is
-		// enabled then gRPC will require HTTPS as well.
-		a.grpcServer, err = a.xdsServer.GRPCServer(a.config.CertFile, a.config.KeyFile)
+		// enabled then gRPC will require HTTPS as well. The certificate
+		// follows the updates of the TLS configuration.
+		a.grpcServer, err = a.xdsServer.GRPCServer(a.tlsConfigurator.IncomingGRPCConfig())
 	} else {
-		a.grpcServer, err = a.xdsServer.GRPCServer("", "")
+		a.grpcServer, err = a.xdsServer.GRPCServer(nil)
 	}
 	if err != nil {
 		return err
 	}
 
+	subscribeServer := &subscribe.Server{
+		Logger:     a.logger,
+		RPC:        a,
+		Datacenter: a.config.Datacenter,
+		UserToken:  a.tokens.UserToken,
+		Streamer:   a.streamer(),
+	}
+	subscribeServer.Register(a.grpcServer)
+
 	ln, err := a.startListeners(a.config.GRPCAddrs)
 	if err != nil {
 		return err
@@ -570,6 +710,15 @@ func (a *Agent) listenAndServeGRPC() error {
 	return nil
 }
 
+// streamer returns the streamer of the health subscriptions, or nil unless
+// the streaming backend is enabled.
+func (a *Agent) streamer() subscribe.Streamer {
+	if !a.config.UseStreamingBackend {
+		return nil
+	}
+	return a.delegate
+}
+
 func (a *Agent) listenAndServeDNS() error {
 	notif := make(chan net.Addr, len(a.config.DNSAddrs))
 	errCh := make(chan error, len(a.config.DNSAddrs))
@@ -984,6 +1133,12 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	if a.config.RaftSnapshotInterval != 0 {
 		base.RaftConfig.SnapshotInterval = a.config.RaftSnapshotInterval
 	}
+	if a.config.RaftApplyBatchMaxSize != 0 {
+		base.RaftConfig.MaxAppendEntries = a.config.RaftApplyBatchMaxSize
+	}
+	base.RaftApplyBatchMaxLatency = a.config.RaftApplyBatchMaxLatency
+	base.RaftApplyBatchAdaptive = a.config.RaftApplyBatchAdaptive
+	base.KeyringRotationInterval = a.config.EncryptRotationInterval
 	if a.config.ACLMasterToken != "" {
 		base.ACLMasterToken = a.config.ACLMasterToken
 	}
@@ -996,6 +1151,7 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	if a.config.ACLPolicyTTL != 0 {
 		base.ACLPolicyTTL = a.config.ACLPolicyTTL
 	}
+	base.ACLFilterMemoSize = a.config.ACLFilterMemoSize
 	if a.config.ACLDefaultPolicy != "" {
 		base.ACLDefaultPolicy = a.config.ACLDefaultPolicy
 	}
@@ -1079,6 +1235,13 @@ func (a *Agent) consulConfig() (*consul.Config, error) {
 	// Copy the Connect CA bootstrap config
 	if a.config.ConnectEnabled {
 		base.ConnectEnabled = true
+		base.AutoEncryptAllowTLS = a.config.AutoEncryptAllowTLS
+		base.AutoConfigAuthzEnabled = a.config.AutoConfigAuthzEnabled
+		base.AutoConfigAuthzJWTValidationPubKeys = a.config.AutoConfigAuthzJWTValidationPubKeys
+		base.AutoConfigAuthzBoundIssuer = a.config.AutoConfigAuthzBoundIssuer
+		base.AutoConfigAuthzBoundAudiences = a.config.AutoConfigAuthzBoundAudiences
+		base.AutoConfigAuthzClaimAssertions = a.config.AutoConfigAuthzClaimAssertions
+		base.AutoConfigAuthzAgentTokenPolicies = a.config.AutoConfigAuthzAgentTokenPolicies
 
 		// Allow config to specify cluster_id provided it's a valid UUID. This is
 		// meant only for tests where a deterministic ID makes fixtures much simpler
@@ -1462,6 +1625,12 @@ func (a *Agent) ShutdownAgent() error {
 	for _, chk := range a.checkAliases {
 		chk.Stop()
 	}
+	for _, chk := range a.checkComposites {
+		chk.Stop()
+	}
+	if a.checkACLReplication != nil {
+		a.checkACLReplication.Stop()
+	}
 
 	// Stop gRPC
 	if a.grpcServer != nil {
@@ -1491,11 +1660,21 @@ func (a *Agent) ShutdownAgent() error {
 		}
 	}
 
+	// Stop watching the centrally managed service config
+	if a.serviceManager != nil {
+		a.serviceManager.Stop()
+	}
+
 	// Stop the cache background work
 	if a.cache != nil {
 		a.cache.Close()
 	}
 
+	// Remove the spooled snapshots
+	if a.snapshots != nil {
+		a.snapshots.Close()
+	}
+
 	var err error
 	if a.delegate != nil {
 		err = a.delegate.Shutdown()
@@ -1511,6 +1690,10 @@ func (a *Agent) ShutdownAgent() error {
 		a.logger.Println("[WARN] agent: could not delete pid file ", pidErr)
 	}
 
+	if err := a.deleteAPIBootstrapFile(); err != nil {
+		a.logger.Println("[WARN] agent: could not delete API bootstrap file ", err)
+	}
+
 	a.logger.Println("[INFO] agent: shutdown complete")
 	a.shutdown = true
 	close(a.shutdownCh)
@@ -1762,7 +1945,7 @@ func (a *Agent) reapServicesInternal() {
 		// this is so that we won't try to remove it again.
 		if timeout > 0 && cs.CriticalFor() > timeout {
 			reaped[serviceID] = true
-			if err := a.RemoveService(serviceID, true); err != nil {
+			if err := a.removeServiceWithToken(serviceID); err != nil {
 				a.logger.Printf("[ERR] agent: unable to deregister service %q after check %q has been critical for too long: %s",
 					serviceID, checkID, err)
 			} else {
@@ -2016,6 +2199,17 @@ func (a *Agent) addServiceLocked(service *structs.NodeService, chkTypes []*struc
 		}
 	}
 
+	// Expose the checks of the service through its proxy, or the checks of
+	// the proxied service if this is its proxy
+	exposeID := service.ID
+	if service.Kind == structs.ServiceKindConnectProxy && service.Proxy.DestinationServiceID != "" {
+		exposeID = service.Proxy.DestinationServiceID
+	}
+	if err := a.exposeChecksLocked(exposeID); err != nil {
+		a.cleanupRegistration(cleanupServices, cleanupChecks)
+		return err
+	}
+
 	// Persist the service to a file
 	if persist && a.config.DataDir != "" {
 		if err := a.persistService(service); err != nil {
@@ -2024,6 +2218,11 @@ func (a *Agent) addServiceLocked(service *structs.NodeService, chkTypes []*struc
 		}
 	}
 
+	// Merge the centrally managed config into the service
+	if err := a.serviceManager.AddService(service, token); err != nil {
+		a.logger.Printf("[WARN] agent: failed to watch the central config of service %q: %s", service.ID, err)
+	}
+
 	return nil
 }
 
@@ -2086,6 +2285,15 @@ func (a *Agent) removeServiceLocked(serviceID string, persist bool) error {
 		}
 	}
 
+	// Remember the service proxied by a Connect proxy to stop exposing its
+	// checks through it
+	var destinationServiceID string
+	if s := a.State.Service(serviceID); s != nil && s.Kind == structs.ServiceKindConnectProxy {
+		destinationServiceID = s.Proxy.DestinationServiceID
+	}
+
+	a.serviceManager.RemoveService(serviceID)
+
 	// Remove service immediately
 	if err := a.State.RemoveServiceWithChecks(serviceID, checkIDs); err != nil {
 		a.logger.Printf("[WARN] agent: Failed to deregister service %q: %s", serviceID, err)
@@ -2111,6 +2319,17 @@ func (a *Agent) removeServiceLocked(serviceID string, persist bool) error {
 
 	a.logger.Printf("[DEBUG] agent: removed service %q", serviceID)
 
+	// Stop exposing the checks of the service through its proxy, and the
+	// checks of the proxied service if this was its proxy
+	for _, id := range []string{serviceID, destinationServiceID} {
+		if id == "" {
+			continue
+		}
+		if err := a.exposeChecksLocked(id); err != nil {
+			a.logger.Printf("[WARN] agent: Failed to update the exposed checks of service %q: %s", id, err)
+		}
+	}
+
 	// If any Sidecar services exist for the removed service ID, remove them too.
 	if sidecar := a.State.Service(a.sidecarServiceID(serviceID)); sidecar != nil {
 		// Double check that it's not just an ID collision and we actually added
@@ -2167,6 +2386,13 @@ func (a *Agent) addCheckLocked(check *structs.HealthCheck, chkType *structs.Chec
 		return err
 	}
 
+	// Expose the check through the proxy of the service if needed
+	if check.ServiceID != "" {
+		if err := a.exposeChecksLocked(check.ServiceID); err != nil {
+			return err
+		}
+	}
+
 	// Persist the check
 	if persist && a.config.DataDir != "" {
 		return a.persistCheck(check, chkType)
@@ -2238,7 +2464,10 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 				chkType.Interval = checks.MinInterval
 			}
 
-			tlsClientConfig := a.tlsConfigurator.OutgoingTLSConfigForCheck(chkType.TLSSkipVerify)
+			tlsClientConfig, err := a.checkTLSConfig(chkType)
+			if err != nil {
+				return fmt.Errorf("Failed to set up TLS for check %q: %v", check.CheckID, err)
+			}
 
 			http := &checks.CheckHTTP{
 				Notify:          a.State,
@@ -2265,13 +2494,23 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 				chkType.Interval = checks.MinInterval
 			}
 
+			var tlsClientConfig *tls.Config
+			if chkType.TCPUseTLS {
+				var err error
+				tlsClientConfig, err = a.checkTLSConfig(chkType)
+				if err != nil {
+					return fmt.Errorf("Failed to set up TLS for check %q: %v", check.CheckID, err)
+				}
+			}
+
 			tcp := &checks.CheckTCP{
-				Notify:   a.State,
-				CheckID:  check.CheckID,
-				TCP:      chkType.TCP,
-				Interval: chkType.Interval,
-				Timeout:  chkType.Timeout,
-				Logger:   a.logger,
+				Notify:          a.State,
+				CheckID:         check.CheckID,
+				TCP:             chkType.TCP,
+				Interval:        chkType.Interval,
+				Timeout:         chkType.Timeout,
+				Logger:          a.logger,
+				TLSClientConfig: tlsClientConfig,
 			}
 			tcp.Start()
 			a.checkTCPs[check.CheckID] = tcp
@@ -2289,7 +2528,11 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 
 			var tlsClientConfig *tls.Config
 			if chkType.GRPCUseTLS {
-				tlsClientConfig = a.tlsConfigurator.OutgoingTLSConfigForCheck(chkType.TLSSkipVerify)
+				var err error
+				tlsClientConfig, err = a.checkTLSConfig(chkType)
+				if err != nil {
+					return fmt.Errorf("Failed to set up TLS for check %q: %v", check.CheckID, err)
+				}
 			}
 
 			grpc := &checks.CheckGRPC{
@@ -2392,6 +2635,33 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 			chkImpl.Start()
 			a.checkAliases[check.CheckID] = chkImpl
 
+		case chkType.IsComposite():
+			expr, err := checks.ParseCompositeExpr(chkType.Composite)
+			if err != nil {
+				return fmt.Errorf("Check %q has an invalid composite expression: %v", check.CheckID, err)
+			}
+			for _, id := range expr.CheckIDs() {
+				if id == check.CheckID {
+					return fmt.Errorf("Check %q cannot reference itself in its composite expression", check.CheckID)
+				}
+			}
+			if a.compositeCycle(check.CheckID, expr.CheckIDs()) {
+				return fmt.Errorf("Check %q forms a cycle through its composite expression", check.CheckID)
+			}
+
+			if existing, ok := a.checkComposites[check.CheckID]; ok {
+				existing.Stop()
+				delete(a.checkComposites, check.CheckID)
+			}
+
+			composite := &checks.CheckComposite{
+				Notify:  a.State,
+				CheckID: check.CheckID,
+				Expr:    expr,
+			}
+			composite.Start()
+			a.checkComposites[check.CheckID] = composite
+
 		default:
 			return fmt.Errorf("Check type is not valid")
 		}
@@ -2412,6 +2682,42 @@ func (a *Agent) addCheck(check *structs.HealthCheck, chkType *structs.CheckType,
 	return nil
 }
 
+// compositeCycle returns whether a composite check referencing the given
+// checks would be referenced back by them, directly or through other
+// composite checks, which would make their status flap forever.
+func (a *Agent) compositeCycle(checkID types.CheckID, ids []types.CheckID) bool {
+	seen := make(map[types.CheckID]struct{})
+	for len(ids) > 0 {
+		id := ids[0]
+		ids = ids[1:]
+		if id == checkID {
+			return true
+		}
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		if composite, ok := a.checkComposites[id]; ok {
+			ids = append(ids, composite.Expr.CheckIDs()...)
+		}
+	}
+	return false
+}
+
+// checkTLSConfig returns the TLS client config of an HTTP, TCP or gRPC check,
+// with the TLS options of the check taking precedence over the ones of the
+// agent.
+func (a *Agent) checkTLSConfig(chkType *structs.CheckType) (*tls.Config, error) {
+	return a.tlsConfigurator.OutgoingTLSConfigForCheckOpts(tlsutil.CheckTLSOptions{
+		SkipVerify: chkType.TLSSkipVerify,
+		ServerName: chkType.TLSServerName,
+		CAFile:     chkType.TLSCAFile,
+		CertFile:   chkType.TLSCertFile,
+		KeyFile:    chkType.TLSKeyFile,
+	})
+}
+
 // RemoveCheck is used to remove a health check.
 // The agent will make a best effort to ensure it is deregistered
 func (a *Agent) RemoveCheck(checkID types.CheckID, persist bool) error {
@@ -2428,9 +2734,21 @@ func (a *Agent) removeCheckLocked(checkID types.CheckID, persist bool) error {
 		return fmt.Errorf("CheckID missing")
 	}
 
+	var serviceID string
+	if check := a.State.Check(checkID); check != nil {
+		serviceID = check.ServiceID
+	}
+
 	a.cancelCheckMonitors(checkID)
 	a.State.RemoveCheck(checkID)
 
+	// Stop exposing the check through the proxy of the service
+	if serviceID != "" {
+		if err := a.exposeChecksLocked(serviceID); err != nil {
+			a.logger.Printf("[WARN] agent: Failed to update the exposed checks of service %q: %s", serviceID, err)
+		}
+	}
+
 	if persist {
 		if err := a.purgeCheck(checkID); err != nil {
 			return err
@@ -2816,6 +3134,14 @@ func (a *Agent) cancelCheckMonitors(checkID types.CheckID) {
 		check.Stop()
 		delete(a.checkDockers, checkID)
 	}
+	if check, ok := a.checkComposites[checkID]; ok {
+		check.Stop()
+		delete(a.checkComposites, checkID)
+	}
+	if check := a.checkACLReplication; check != nil && check.CheckID == checkID {
+		check.Stop()
+		a.checkACLReplication = nil
+	}
 }
 
 // updateTTLCheck is used to update the status of a TTL check via the Agent API.
@@ -2952,6 +3278,9 @@ func (a *Agent) Stats() map[string]map[string]string {
 		"version":    a.config.Version,
 		"prerelease": a.config.VersionPrerelease,
 	}
+	if a.cache != nil {
+		stats["cache"] = a.cache.Stats()
+	}
 	return stats
 }
 
@@ -3003,6 +3332,56 @@ func (a *Agent) deletePid() error {
 	return nil
 }
 
+// storeAPIBootstrapFile is used to write out the local HTTP API connection
+// settings for API clients if necessary
+func (a *Agent) storeAPIBootstrapFile() error {
+	path := a.config.APIBootstrapFile
+	if path == "" {
+		return nil
+	}
+
+	cfg, err := a.config.APIConfig(false)
+	if err != nil {
+		return fmt.Errorf("Could not write API bootstrap file: %v", err)
+	}
+	bf := api.BootstrapFile{
+		Address:    cfg.Address,
+		Scheme:     cfg.Scheme,
+		Datacenter: cfg.Datacenter,
+		CAFile:     cfg.TLSConfig.CAFile,
+		CAPath:     cfg.TLSConfig.CAPath,
+		TokenFile:  a.config.APIBootstrapTokenFile,
+	}
+	raw, err := json.MarshalIndent(bf, "", "  ")
+	if err != nil {
+		return err
+	}
+	// The file holds no secrets, the token stays protected by the
+	// permissions of the token file, so any local API client can read it.
+	// WriteAtomicWithPerms only applies the permissions to the directories
+	// it creates, so the file is made readable afterwards.
+	if err := file.WriteAtomicWithPerms(path, raw, 0755); err != nil {
+		return fmt.Errorf("Could not write API bootstrap file: %v", err)
+	}
+	if err := os.Chmod(path, 0644); err != nil {
+		return fmt.Errorf("Could not write API bootstrap file: %v", err)
+	}
+	return nil
+}
+
+// deleteAPIBootstrapFile is used to delete the API bootstrap file on exit
+func (a *Agent) deleteAPIBootstrapFile() error {
+	path := a.config.APIBootstrapFile
+	if path == "" {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
 // loadServices will load service definitions from configuration and persisted
 // definitions on disk, and load them into the local agent.
 func (a *Agent) loadServices(conf *config.RuntimeConfig) error {
@@ -3115,6 +3494,11 @@ func (a *Agent) unloadServices() error {
 // loadChecks loads check definitions and/or persisted check definitions from
 // disk and re-registers them with the local agent.
 func (a *Agent) loadChecks(conf *config.RuntimeConfig) error {
+	// Register the built-in checks
+	if err := a.addACLReplicationCheck(conf); err != nil {
+		return fmt.Errorf("Failed to register the ACL replication check: %v", err)
+	}
+
 	// Register the checks from config
 	for _, check := range conf.Checks {
 		health := check.HealthCheck(conf.NodeName)
@@ -3190,6 +3574,53 @@ func (a *Agent) loadChecks(conf *config.RuntimeConfig) error {
 	return nil
 }
 
+// addACLReplicationCheck registers the built-in check reporting the health
+// of the ACL replication when the agent is a server of a secondary
+// datacenter, so that broken replication is caught by the regular health
+// monitoring. The check belongs to the dedicated acl-replication service
+// rather than to the node, which would make every service of the node
+// critical, including consul.
+func (a *Agent) addACLReplicationCheck(conf *config.RuntimeConfig) error {
+	if !conf.ServerMode || !conf.ACLsEnabled ||
+		conf.ACLDatacenter == "" || conf.ACLDatacenter == conf.Datacenter {
+		return nil
+	}
+
+	service := &structs.NodeService{
+		ID:      structs.ACLReplicationServiceID,
+		Service: structs.ACLReplicationServiceName,
+	}
+	if err := a.State.AddService(service, ""); err != nil {
+		return err
+	}
+
+	check := &structs.HealthCheck{
+		Node:        conf.NodeName,
+		CheckID:     structs.ACLReplicationCheckID,
+		Name:        structs.ACLReplicationCheckName,
+		Notes:       fmt.Sprintf("Replication of the ACLs from the %q datacenter", conf.ACLDatacenter),
+		Status:      api.HealthCritical,
+		ServiceID:   service.ID,
+		ServiceName: service.Service,
+	}
+	if err := a.State.AddCheck(check, ""); err != nil {
+		return err
+	}
+
+	a.cancelCheckMonitors(check.CheckID)
+	a.checkACLReplication = &checks.CheckACLReplication{
+		CheckID:      check.CheckID,
+		RPC:          a.delegate,
+		RPCReq:       structs.DCSpecificRequest{Datacenter: conf.Datacenter},
+		Interval:     aclReplicationCheckInterval,
+		LagThreshold: conf.ACLReplicationLagThreshold,
+		Notify:       a.State,
+		Logger:       a.logger,
+	}
+	a.checkACLReplication.Start()
+	return nil
+}
+
 // unloadChecks will deregister all checks known to the local agent.
 func (a *Agent) unloadChecks() error {
 	for id := range a.State.Checks() {
@@ -3550,6 +3981,15 @@ func (a *Agent) ReloadConfig(newCfg *config.RuntimeConfig) error {
 	}
 	a.unloadMetadata()
 
+	// Keep the configuration requested with auto-config, and the node ID
+	// generated or persisted when the agent started.
+	a.applyAutoConfig(newCfg)
+	if newCfg.NodeID == "" {
+		newCfg.NodeID = a.config.NodeID
+	}
+	current := a.currentConfig()
+	result := reloadResult(current, newCfg)
+
 	// Reload tokens - should be done before all the other loading
 	// to ensure the correct tokens are available for attaching to
 	// the checks and service registrations.
@@ -3579,6 +4019,12 @@ func (a *Agent) ReloadConfig(newCfg *config.RuntimeConfig) error {
 
 	a.loadLimits(newCfg)
 
+	if a.dnsRecursors != nil {
+		if err := a.dnsRecursors.update(newCfg.DNSRecursors); err != nil {
+			return fmt.Errorf("Failed reloading DNS recursors: %v", err)
+		}
+	}
+
 	// create the config for the rpc server/client
 	consulCfg, err := a.consulConfig()
 	if err != nil {
@@ -3589,15 +4035,53 @@ func (a *Agent) ReloadConfig(newCfg *config.RuntimeConfig) error {
 		return err
 	}
 
-	// Update filtered metrics
-	metrics.UpdateFilter(newCfg.Telemetry.AllowedPrefixes,
-		newCfg.Telemetry.BlockedPrefixes)
+	// Replace the telemetry sinks if they changed, otherwise only update
+	// the filtered metrics.
+	if a.MemSink != nil && !reflect.DeepEqual(current.Telemetry, newCfg.Telemetry) {
+		if err := lib.ReloadTelemetry(newCfg.Telemetry, a.MemSink); err != nil {
+			return fmt.Errorf("Failed reloading telemetry: %v", err)
+		}
+	} else {
+		metrics.UpdateFilter(newCfg.Telemetry.AllowedPrefixes,
+			newCfg.Telemetry.BlockedPrefixes)
+	}
 
 	a.State.SetDiscardCheckOutput(newCfg.DiscardCheckOutput)
 
+	if len(result.RestartRequired) > 0 {
+		a.logger.Printf("[WARN] agent: Changes to %s require a restart of the agent",
+			strings.Join(result.RestartRequired, ", "))
+	}
+
+	a.reloadLock.Lock()
+	a.reloadedConfig = reloadedConfig(current, newCfg)
+	a.reloadResult = result
+	a.reloadLock.Unlock()
 	return nil
 }
 
+// currentConfig returns the configuration with the fields applied by the
+// last successful reload. The returned configuration must not be modified.
+func (a *Agent) currentConfig() *config.RuntimeConfig {
+	a.reloadLock.Lock()
+	defer a.reloadLock.Unlock()
+	if a.reloadedConfig == nil {
+		return a.config
+	}
+	return a.reloadedConfig
+}
+
+// lastReloadResult returns the result of the last successful configuration
+// reload, which is empty if the configuration wasn't reloaded.
+func (a *Agent) lastReloadResult() *ReloadResult {
+	a.reloadLock.Lock()
+	defer a.reloadLock.Unlock()
+	if a.reloadResult == nil {
+		return &ReloadResult{Applied: []string{}, RestartRequired: []string{}}
+	}
+	return a.reloadResult
+}
+
 // registerCache configures the cache and registers all the supported
 // types onto the cache. This is NOT safe to call multiple times so
 // care should be taken to call this exactly once after the cache
@@ -3655,6 +4139,21 @@ func (a *Agent) registerCache() {
 		RefreshTimeout: 10 * time.Minute,
 	})
 
+	a.cache.RegisterType(cachetype.StreamingHealthServicesName, &cachetype.StreamingHealthServices{
+		Server: &subscribe.Server{
+			Logger:     a.logger,
+			RPC:        a,
+			Datacenter: a.config.Datacenter,
+			Streamer:   a.streamer(),
+		},
+	}, &cache.RegisterOptions{
+		// Keep the materialized views up to date, the fetches only wait
+		// for the next change of the view
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
 	a.cache.RegisterType(cachetype.PreparedQueryName, &cachetype.PreparedQuery{
 		RPC: a,
 	}, &cache.RegisterOptions{
@@ -3670,6 +4169,58 @@ func (a *Agent) registerCache() {
 		RefreshTimer:   0 * time.Second,
 		RefreshTimeout: 10 * time.Minute,
 	})
+
+	a.cache.RegisterType(cachetype.CompiledDiscoveryChainName, &cachetype.CompiledDiscoveryChain{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query, retry dropped connections quickly
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
+	a.cache.RegisterType(cachetype.CatalogListServicesName, &cachetype.CatalogListServices{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query, retry dropped connections quickly
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
+	a.cache.RegisterType(cachetype.InternalServiceDumpName, &cachetype.InternalServiceDump{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query, retry dropped connections quickly
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
+	a.cache.RegisterType(cachetype.ConfigEntryName, &cachetype.ConfigEntry{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query, retry dropped connections quickly
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
+	a.cache.RegisterType(cachetype.ResolvedServiceConfigName, &cachetype.ResolvedServiceConfig{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// Maintain a blocking query, retry dropped connections quickly
+		Refresh:        true,
+		RefreshTimer:   0 * time.Second,
+		RefreshTimeout: 10 * time.Minute,
+	})
+
+	a.cache.RegisterType(cachetype.CatalogDatacentersName, &cachetype.CatalogDatacenters{
+		RPC: a,
+	}, &cache.RegisterOptions{
+		// The list of datacenters doesn't support blocking
+		Refresh: false,
+	})
 }
 
 // defaultProxyCommand returns the default Connect managed proxy command.
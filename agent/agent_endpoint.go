@@ -8,6 +8,7 @@ import (
 	"net"
 	"net/http"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -100,6 +101,44 @@ func enablePrometheusOutput(req *http.Request) bool {
 	return false
 }
 
+// AgentDNSRecursors returns the health of the recursors of the DNS
+// interface, in their configured order.
+func (s *HTTPServer) AgentDNSRecursors(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Fetch the ACL token, if any, and enforce agent policy.
+	var token string
+	s.parseToken(req, &token)
+	rule, err := s.agent.resolveToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil && !rule.AgentRead(s.agent.config.NodeName) {
+		return nil, acl.ErrPermissionDenied
+	}
+
+	// The recursors aren't set up when the DNS interface is disabled.
+	if s.agent.dnsRecursors == nil {
+		return []*api.AgentDNSRecursor{}, nil
+	}
+	return s.agent.dnsRecursors.health(), nil
+}
+
+// AgentAutoEncrypt returns the status of the certificate of the RPC
+// connections issued with auto-encrypt.
+func (s *HTTPServer) AgentAutoEncrypt(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Fetch the ACL token, if any, and enforce agent policy.
+	var token string
+	s.parseToken(req, &token)
+	rule, err := s.agent.resolveToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil && !rule.AgentRead(s.agent.config.NodeName) {
+		return nil, acl.ErrPermissionDenied
+	}
+
+	return s.agent.autoEncryptStatus(), nil
+}
+
 func (s *HTTPServer) AgentMetrics(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	// Fetch the ACL token, if any, and enforce agent policy.
 	var token string
@@ -112,7 +151,7 @@ func (s *HTTPServer) AgentMetrics(resp http.ResponseWriter, req *http.Request) (
 		return nil, acl.ErrPermissionDenied
 	}
 	if enablePrometheusOutput(req) {
-		if s.agent.config.Telemetry.PrometheusRetentionTime < 1 {
+		if s.agent.currentConfig().Telemetry.PrometheusRetentionTime < 1 {
 			resp.WriteHeader(http.StatusUnsupportedMediaType)
 			fmt.Fprint(resp, "Prometheus is not enabled since its retention time is not positive")
 			return nil, nil
@@ -154,8 +193,13 @@ func (s *HTTPServer) AgentReload(resp http.ResponseWriter, req *http.Request) (i
 	case <-s.agent.shutdownCh:
 		return nil, fmt.Errorf("Agent was shutdown before reload could be completed")
 	case err := <-errCh:
-		return nil, err
+		if err != nil {
+			return nil, err
+		}
 	}
+
+	// Report which changes were applied and which require a restart.
+	return s.agent.lastReloadResult(), nil
 }
 
 func buildAgentService(s *structs.NodeService, proxies map[string]*local.ManagedProxy) api.AgentService {
@@ -172,8 +216,10 @@ func buildAgentService(s *structs.NodeService, proxies map[string]*local.Managed
 		Service:           s.Service,
 		Tags:              s.Tags,
 		Meta:              s.Meta,
+		Labels:            s.Labels,
 		Port:              s.Port,
 		Address:           s.Address,
+		TaggedAddresses:   structs.ServiceAddressesToAPI(s.TaggedAddresses),
 		EnableTagOverride: s.EnableTagOverride,
 		CreateIndex:       s.CreateIndex,
 		ModifyIndex:       s.ModifyIndex,
@@ -193,6 +239,10 @@ func buildAgentService(s *structs.NodeService, proxies map[string]*local.Managed
 		// Also set the deprecated ProxyDestination
 		as.ProxyDestination = as.Proxy.DestinationServiceName
 	}
+	// Gateways list the services they front as upstreams
+	if s.Kind.IsGateway() {
+		as.Proxy = s.Proxy.ToAPI()
+	}
 
 	// Attach Connect configs if they exist. We use the actual proxy state since
 	// that may have had defaults filled in compared to the config that was
@@ -219,11 +269,23 @@ func (s *HTTPServer) AgentServices(resp http.ResponseWriter, req *http.Request)
 	var token string
 	s.parseToken(req, &token)
 
+	selector, err := structs.ParseLabelSelector(req.URL.Query().Get("label-selector"))
+	if err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("Invalid label selector: %v", err)}
+	}
+
 	services := s.agent.State.Services()
 	if err := s.agent.filterServices(token, &services); err != nil {
 		return nil, err
 	}
 
+	// Keep the services matching the label selector, if any
+	for id, svc := range services {
+		if !selector.Matches(svc.Labels) {
+			delete(services, id)
+		}
+	}
+
 	proxies := s.agent.State.Proxies()
 
 	// Convert into api.AgentService since that includes Connect config but so far
@@ -238,6 +300,19 @@ func (s *HTTPServer) AgentServices(resp http.ResponseWriter, req *http.Request)
 		agentSvcs[id] = &agentService
 	}
 
+	// Keep the services matching the filter, if any
+	if filterExpr := req.URL.Query().Get("filter"); filterExpr != "" {
+		filter, err := structs.CreateFilter(filterExpr, agentSvcs)
+		if err != nil {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Invalid filter: %v", err)}
+		}
+		raw, err := filter.Execute(agentSvcs)
+		if err != nil {
+			return nil, err
+		}
+		agentSvcs = raw.(map[string]*api.AgentService)
+	}
+
 	return agentSvcs, nil
 }
 
@@ -359,7 +434,7 @@ func (s *HTTPServer) AgentService(resp http.ResponseWriter, req *http.Request) (
 				}
 			}
 
-			if svc.Kind == structs.ServiceKindConnectProxy {
+			if svc.Kind == structs.ServiceKindConnectProxy || svc.Kind.IsGateway() {
 				proxy = svc.Proxy.ToAPI()
 			}
 
@@ -378,8 +453,10 @@ func (s *HTTPServer) AgentService(resp http.ResponseWriter, req *http.Request) (
 				Service:           svc.Service,
 				Tags:              svc.Tags,
 				Meta:              svc.Meta,
+				Labels:            svc.Labels,
 				Port:              svc.Port,
 				Address:           svc.Address,
+				TaggedAddresses:   structs.ServiceAddressesToAPI(svc.TaggedAddresses),
 				EnableTagOverride: svc.EnableTagOverride,
 				Weights:           weights,
 				Proxy:             proxy,
@@ -445,7 +522,25 @@ func (s *HTTPServer) AgentMembers(resp http.ResponseWriter, req *http.Request) (
 		}
 	}
 
+	// Parse the delta and pagination parameters
+	var since uint64
+	if raw := req.URL.Query().Get("since"); raw != "" {
+		var err error
+		if since, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Invalid since index: %v", err)}
+		}
+	}
+	limit := 0
+	if raw := req.URL.Query().Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit < 0 {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Invalid limit: %q", raw)}
+		}
+	}
+	after := req.URL.Query().Get("after")
+
 	var members []serf.Member
+	pool := "wan"
 	if wan {
 		members = s.agent.WANMembers()
 	} else {
@@ -458,10 +553,31 @@ func (s *HTTPServer) AgentMembers(resp http.ResponseWriter, req *http.Request) (
 		if err != nil {
 			return nil, err
 		}
+		pool = "lan:" + segment
 	}
+
+	// Track the changes of the pool so the members can be listed as a delta
+	index := s.agent.memberChanges.update(pool, members)
+	resp.Header().Set("X-Consul-Members-Index", strconv.FormatUint(index, 10))
+	reset := true
+	if since > 0 {
+		members, reset = s.agent.memberChanges.since(pool, since)
+	}
+	resp.Header().Set("X-Consul-Members-Reset", strconv.FormatBool(reset))
+
 	if err := s.agent.filterMembers(token, &members); err != nil {
 		return nil, err
 	}
+
+	// Page through the members by name
+	if limit > 0 || after != "" {
+		sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+		start := sort.Search(len(members), func(i int) bool { return members[i].Name > after })
+		members = members[start:]
+		if limit > 0 && len(members) > limit {
+			members = members[:limit]
+		}
+	}
 	return members, nil
 }
 
@@ -845,6 +961,7 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 		// and why we should get rid of it.
 		config.TranslateKeys(rawMap, map[string]string{
 			"enable_tag_override": "EnableTagOverride",
+			"tagged_addresses":    "TaggedAddresses",
 			// Managed Proxy Config
 			"exec_mode": "ExecMode",
 			// Proxy Upstreams
@@ -858,6 +975,10 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 			"destination_service_id":   "DestinationServiceID",
 			"local_service_port":       "LocalServicePort",
 			"local_service_address":    "LocalServiceAddress",
+			"mesh_gateway":             "MeshGateway",
+			// Proxy Expose
+			"listener_port":   "ListenerPort",
+			"local_path_port": "LocalPathPort",
 			// SidecarService
 			"sidecar_service": "SidecarService",
 
@@ -935,6 +1056,11 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 		fmt.Fprint(resp, fmt.Errorf("Invalid Service Meta: %v", err))
 		return nil, nil
 	}
+	if err := structs.ValidateLabels(ns.Labels); err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, fmt.Errorf("Invalid Service Labels: %v", err))
+		return nil, nil
+	}
 
 	// Run validation. This is the same validation that would happen on
 	// the catalog endpoint so it helps ensure the sync will work properly.
@@ -976,6 +1102,19 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 		}
 	}
 
+	// A deadline makes the registration provisional, which requires checks
+	// to wait for.
+	var deadline time.Duration
+	if raw := req.URL.Query().Get("deadline"); raw != "" {
+		var err error
+		if deadline, err = time.ParseDuration(raw); err != nil || deadline <= 0 {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Invalid deadline %q, must be a positive duration", raw)}
+		}
+		if len(chkTypes) == 0 {
+			return nil, BadRequestError{Reason: "A deadline requires the service to have at least one check"}
+		}
+	}
+
 	// Get the provided token, if any, and vet against any ACL policies.
 	var token string
 	s.parseToken(req, &token)
@@ -1017,10 +1156,37 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 			Reason: "Managed proxy registration via the API is disallowed."}
 	}
 
+	// Use a new token scoped to the service if enabled. The sidecar shares
+	// it unless it was given its own.
+	var provisioned bool
+	var prevService *structs.NodeService
+	var prevToken string
+	if s.agent.serviceTokenEnabled(ns, sidecar != nil) {
+		serviceToken, err := s.agent.provisionServiceToken(ns, sidecar, token)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to provision service token: %v", err)
+		}
+		if sidecarToken == token {
+			sidecarToken = serviceToken
+		}
+		prevService = s.agent.State.Service(ns.ID)
+		prevToken = s.agent.State.ServiceToken(ns.ID)
+		provisioned = true
+		token = serviceToken
+	}
+
 	// Add the service.
 	if err := s.agent.AddService(ns, chkTypes, true, token, ConfigSourceRemote); err != nil {
+		if provisioned {
+			s.agent.deleteServiceToken(ns.Service, token)
+		}
 		return nil, err
 	}
+
+	// The token the service was registered with before is replaced.
+	if provisioned && prevService != nil && prevToken != token {
+		s.agent.deleteServiceToken(prevService.Service, prevToken)
+	}
 	// Add proxy (which will add proxy service so do it before we trigger sync)
 	if proxy != nil {
 		if err := s.agent.AddProxy(proxy, true, false, "", ConfigSourceRemote); err != nil {
@@ -1028,15 +1194,45 @@ func (s *HTTPServer) AgentRegisterService(resp http.ResponseWriter, req *http.Re
 		}
 	}
 	// Add sidecar.
+	var sidecarID string
 	if sidecar != nil {
 		if err := s.agent.AddService(sidecar, sidecarChecks, true, sidecarToken, ConfigSourceRemote); err != nil {
 			return nil, err
 		}
+		sidecarID = sidecar.ID
+	}
+
+	// Registering the service again without a deadline confirms it.
+	if deadline > 0 {
+		s.agent.provisionalServices.add(ns, sidecarID, time.Now().Add(deadline))
+	} else {
+		s.agent.provisionalServices.confirm(ns.ID)
 	}
 	s.syncChanges()
 	return nil, nil
 }
 
+// AgentProvisionalServices returns the services registered with a deadline
+// that are still pending, and the recent ones that were rolled back.
+func (s *HTTPServer) AgentProvisionalServices(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var token string
+	s.parseToken(req, &token)
+	rule, err := s.agent.resolveToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	services := s.agent.provisionalServices.list()
+	out := make([]*api.AgentProvisionalService, 0, len(services))
+	for _, svc := range services {
+		if rule != nil && !rule.ServiceRead(svc.ServiceName) {
+			continue
+		}
+		out = append(out, svc)
+	}
+	return out, nil
+}
+
 func (s *HTTPServer) AgentDeregisterService(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	serviceID := strings.TrimPrefix(req.URL.Path, "/v1/agent/service/deregister/")
 
@@ -1055,7 +1251,7 @@ func (s *HTTPServer) AgentDeregisterService(resp http.ResponseWriter, req *http.
 				"deregister the managed proxy itself."}
 	}
 
-	if err := s.agent.RemoveService(serviceID, true); err != nil {
+	if err := s.agent.removeServiceWithToken(serviceID); err != nil {
 		return nil, err
 	}
 
@@ -1150,6 +1346,64 @@ func (s *HTTPServer) AgentNodeMaintenance(resp http.ResponseWriter, req *http.Re
 	return nil, nil
 }
 
+// AgentLogLevel returns the log levels of the agent, and changes the default
+// level or the level of a subsystem on PUT. The levels are reset to the ones
+// of the configuration when it's reloaded.
+func (s *HTTPServer) AgentLogLevel(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	// Fetch the ACL token, if any, and enforce agent policy.
+	var token string
+	s.parseToken(req, &token)
+	rule, err := s.agent.resolveToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.agent.LogFilter == nil {
+		return nil, fmt.Errorf("Log levels can't be changed on this agent")
+	}
+
+	switch req.Method {
+	case "GET":
+		if rule != nil && !rule.AgentRead(s.agent.config.NodeName) {
+			return nil, acl.ErrPermissionDenied
+		}
+
+	case "PUT":
+		if rule != nil && !rule.AgentWrite(s.agent.config.NodeName) {
+			return nil, acl.ErrPermissionDenied
+		}
+
+		var args api.AgentLogLevelRequest
+		if err := decodeBody(req, &args, nil); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Request decode failed: %v", err)
+			return nil, nil
+		}
+
+		// An empty level resets the level of the subsystem to the default.
+		if args.Subsystem == "" {
+			err = s.agent.LogFilter.SetLevel(args.Level)
+		} else {
+			err = s.agent.LogFilter.SetSubsystemLevel(args.Subsystem, args.Level)
+		}
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(resp, err.Error())
+			return nil, nil
+		}
+		if args.Subsystem == "" {
+			s.agent.logger.Printf("[INFO] agent: Set the log level to %s", args.Level)
+		} else {
+			s.agent.logger.Printf("[INFO] agent: Set the log level of %s to %q", args.Subsystem, args.Level)
+		}
+	}
+
+	return &api.AgentLogLevels{
+		Level:      s.agent.LogFilter.Level(),
+		Subsystems: s.agent.LogFilter.SubsystemLevels(),
+	}, nil
+}
+
 func (s *HTTPServer) AgentMonitor(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	// Fetch the ACL token, if any, and enforce agent policy.
 	var token string
@@ -1179,6 +1433,18 @@ func (s *HTTPServer) AgentMonitor(resp http.ResponseWriter, req *http.Request) (
 		fmt.Fprintf(resp, "Unknown log level: %s", filter.MinLevel)
 		return nil, nil
 	}
+
+	// Get the format of the logs and the subsystems to stream the logs of.
+	var logJSON bool
+	if raw := req.URL.Query().Get("logjson"); raw != "" {
+		if logJSON, err = strconv.ParseBool(raw); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid value for logjson: %q", raw)
+			return nil, nil
+		}
+	}
+	subsystems := req.URL.Query()["subsystem"]
+
 	flusher, ok := resp.(http.Flusher)
 	if !ok {
 		return nil, fmt.Errorf("Streaming not supported")
@@ -1186,9 +1452,11 @@ func (s *HTTPServer) AgentMonitor(resp http.ResponseWriter, req *http.Request) (
 
 	// Set up a log handler.
 	handler := &httpLogHandler{
-		filter: filter,
-		logCh:  make(chan string, 512),
-		logger: s.agent.logger,
+		filter:     filter,
+		subsystems: subsystems,
+		json:       logJSON,
+		logCh:      make(chan string, 512),
+		logger:     s.agent.logger,
 	}
 	s.agent.LogWriter.RegisterHandler(handler)
 	defer s.agent.LogWriter.DeregisterHandler(handler)
@@ -1220,6 +1488,8 @@ func (s *HTTPServer) AgentMonitor(resp http.ResponseWriter, req *http.Request) (
 
 type httpLogHandler struct {
 	filter       *logutils.LevelFilter
+	subsystems   []string
+	json         bool
 	logCh        chan string
 	logger       *log.Logger
 	droppedCount int
@@ -1231,6 +1501,18 @@ func (h *httpLogHandler) HandleLog(log string) {
 		return
 	}
 
+	// Check the subsystem, if the logs are restricted to some
+	if len(h.subsystems) > 0 {
+		_, module, _ := logger.ParseLine([]byte(log))
+		if !logger.InSubsystems(module, h.subsystems) {
+			return
+		}
+	}
+
+	if h.json {
+		log = strings.TrimSuffix(string(logger.FormatJSON([]byte(log))), "\n")
+	}
+
 	// Do a non-blocking send
 	select {
 	case h.logCh <- log:
@@ -1492,7 +1774,7 @@ func (s *HTTPServer) AgentConnectProxyConfig(resp http.ResponseWriter, req *http
 			if isProxyToken {
 				// Add telemetry config. Copy the global config so we can customize the
 				// prefix.
-				telemetryCfg := s.agent.config.Telemetry
+				telemetryCfg := s.agent.currentConfig().Telemetry
 				telemetryCfg.MetricsPrefix = telemetryCfg.MetricsPrefix + ".proxy." + target.ID
 
 				// First see if the user has specified telemetry
@@ -1634,6 +1916,20 @@ type connectAuthorizeResp struct {
 	Reason     string // Reason for the Authorized value (whether true or false)
 }
 
+// AgentFeatures
+//
+// GET /v1/agent/features
+//
+// Returns the API features supported by the agent so clients can adapt to
+// the agent version. It doesn't require any ACL since it only exposes which
+// features are available.
+func (s *HTTPServer) AgentFeatures(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	return Features{
+		Version:  s.agent.config.Version,
+		Features: s.agent.features(),
+	}, nil
+}
+
 // AgentHost
 //
 // GET /v1/agent/host
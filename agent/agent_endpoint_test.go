@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/tls"
 	"crypto/x509"
@@ -10,8 +11,10 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -101,6 +104,85 @@ func TestAgent_Services(t *testing.T) {
 	assert.Equal(t, prxy1.Upstreams.ToAPI(), val["mysql"].Connect.Proxy.Upstreams)
 }
 
+func TestAgent_Services_Filter(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+	for _, srv := range []*structs.NodeService{
+		{ID: "mysql", Service: "mysql", Tags: []string{"master"}, Port: 5000},
+		{ID: "redis", Service: "redis", Tags: []string{"replica"}, Port: 6000},
+		{
+			Kind:    structs.ServiceKindConnectProxy,
+			ID:      "mysql-proxy",
+			Service: "mysql-proxy",
+			Port:    21000,
+			Proxy: structs.ConnectProxyConfig{
+				DestinationServiceName: "mysql",
+				DestinationServiceID:   "mysql",
+			},
+		},
+	} {
+		require.NoError(t, a.State.AddService(srv, ""))
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/agent/services?filter="+url.QueryEscape(`"master" in Tags`), nil)
+	obj, err := a.srv.AgentServices(nil, req)
+	require.NoError(t, err)
+	val := obj.(map[string]*api.AgentService)
+	require.Len(t, val, 1)
+	require.Equal(t, 5000, val["mysql"].Port)
+
+	req, _ = http.NewRequest("GET", "/v1/agent/services?filter="+url.QueryEscape(`Proxy.DestinationServiceID == "mysql"`), nil)
+	obj, err = a.srv.AgentServices(nil, req)
+	require.NoError(t, err)
+	val = obj.(map[string]*api.AgentService)
+	require.Len(t, val, 1)
+	require.Contains(t, val, "mysql-proxy")
+
+	// Invalid filters are rejected
+	req, _ = http.NewRequest("GET", "/v1/agent/services?filter="+url.QueryEscape(`Unknown == "x"`), nil)
+	_, err = a.srv.AgentServices(nil, req)
+	require.Error(t, err)
+	require.IsType(t, BadRequestError{}, err)
+}
+
+func TestAgent_Services_LabelSelector(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+	for _, srv := range []*structs.NodeService{
+		{ID: "mysql", Service: "mysql", Port: 5000, Labels: map[string]string{"team": "payments", "env": "prod"}},
+		{ID: "redis", Service: "redis", Port: 6000, Labels: map[string]string{"team": "payments", "env": "dev"}},
+		{ID: "web", Service: "web", Port: 8080},
+	} {
+		require.NoError(t, a.State.AddService(srv, ""))
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/agent/services?label-selector="+url.QueryEscape("team=payments,env!=dev"), nil)
+	obj, err := a.srv.AgentServices(nil, req)
+	require.NoError(t, err)
+	val := obj.(map[string]*api.AgentService)
+	require.Len(t, val, 1)
+	require.Equal(t, map[string]string{"team": "payments", "env": "prod"}, val["mysql"].Labels)
+
+	req, _ = http.NewRequest("GET", "/v1/agent/services?label-selector="+url.QueryEscape("!team"), nil)
+	obj, err = a.srv.AgentServices(nil, req)
+	require.NoError(t, err)
+	val = obj.(map[string]*api.AgentService)
+	require.Len(t, val, 1)
+	require.Contains(t, val, "web")
+
+	// Invalid selectors are rejected
+	req, _ = http.NewRequest("GET", "/v1/agent/services?label-selector="+url.QueryEscape("team=pay ments"), nil)
+	_, err = a.srv.AgentServices(nil, req)
+	require.Error(t, err)
+	require.IsType(t, BadRequestError{}, err)
+}
+
 // This tests that the agent services endpoint (/v1/agent/services) returns
 // Connect proxies.
 func TestAgent_Services_ExternalConnectProxy(t *testing.T) {
@@ -235,6 +317,12 @@ func TestAgent_Service(t *testing.T) {
 	services {
 		name = "web"
 		port = 8181
+		tagged_addresses {
+			wan {
+				address = "198.18.0.1"
+				port = 80
+			}
+		}
 	}
 	`)
 	defer a.Shutdown()
@@ -275,7 +363,7 @@ func TestAgent_Service(t *testing.T) {
 		Service:     "web-sidecar-proxy",
 		Port:        8000,
 		Proxy:       expectProxy.ToAPI(),
-		ContentHash: "3442362e971c43d1",
+		ContentHash: "f99d20fa704c8c30",
 		Weights: api.AgentWeights{
 			Passing: 1,
 			Warning: 1,
@@ -285,14 +373,17 @@ func TestAgent_Service(t *testing.T) {
 	// Copy and modify
 	updatedResponse := *expectedResponse
 	updatedResponse.Port = 9999
-	updatedResponse.ContentHash = "90b5c19bf0f5073"
+	updatedResponse.ContentHash = "c4d44acd585f9f92"
 
 	// Simple response for non-proxy service registered in TestAgent config
 	expectWebResponse := &api.AgentService{
 		ID:          "web",
 		Service:     "web",
 		Port:        8181,
-		ContentHash: "69351c1ac865b034",
+		ContentHash: "ba16b0cbfedbca85",
+		TaggedAddresses: map[string]api.ServiceAddress{
+			"wan": {Address: "198.18.0.1", Port: 80},
+		},
 		Weights: api.AgentWeights{
 			Passing: 1,
 			Warning: 1,
@@ -582,7 +673,7 @@ func TestAgent_Service_DeprecatedManagedProxy(t *testing.T) {
 		Service:     "web-proxy",
 		Port:        9999,
 		Address:     "10.10.10.10",
-		ContentHash: "e24f099e42e88317",
+		ContentHash: "245d12541a0e7e84",
 		Proxy: &api.AgentServiceConnectProxyConfig{
 			DestinationServiceID:   "web",
 			DestinationServiceName: "web",
@@ -1202,6 +1293,69 @@ func TestAgent_Metrics_ACLDeny(t *testing.T) {
 	})
 }
 
+func TestAgent_AutoEncrypt(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	srv := NewTestAgent(t, t.Name()+"-server", `
+		connect { enabled = true }
+		auto_encrypt { allow_tls = true }
+		ca_file = "../test/ca/root.cer"
+		cert_file = "../test/key/ourdomain.cer"
+		key_file = "../test/key/ourdomain.key"
+	`)
+	defer srv.Shutdown()
+	testrpc.WaitForTestAgent(t, srv.RPC, "dc1")
+
+	// The server doesn't request a certificate.
+	req, _ := http.NewRequest("GET", "/v1/agent/auto-encrypt", nil)
+	obj, err := srv.srv.AgentAutoEncrypt(nil, req)
+	require.NoError(err)
+	status := obj.(*api.AutoEncryptStatus)
+	require.False(status.Enabled)
+	require.Empty(status.SerialNumber)
+
+	client := NewTestAgent(t, t.Name()+"-client", fmt.Sprintf(`
+		server = false
+		bootstrap = false
+		auto_encrypt { tls = true }
+		retry_join = ["127.0.0.1:%d"]
+		ports { server = %d }
+	`, srv.Config.SerfPortLAN, srv.Config.ServerPort))
+	defer client.Shutdown()
+
+	// The client started with the certificate issued by the server.
+	obj, err = client.srv.AgentAutoEncrypt(nil, req)
+	require.NoError(err)
+	status = obj.(*api.AutoEncryptStatus)
+	require.True(status.Enabled)
+	require.NotEmpty(status.SerialNumber)
+	require.True(status.ValidBefore.After(time.Now()))
+	require.Empty(status.LastError)
+}
+
+func TestAgent_AutoEncrypt_ACLDeny(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), TestACLConfig())
+	defer a.Shutdown()
+
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+	t.Run("no token", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/auto-encrypt", nil)
+		if _, err := a.srv.AgentAutoEncrypt(nil, req); !acl.IsErrPermissionDenied(err) {
+			t.Fatalf("err: %v", err)
+		}
+	})
+
+	t.Run("read-only token", func(t *testing.T) {
+		ro := makeReadOnlyAgentACL(t, a.srv)
+		req, _ := http.NewRequest("GET", fmt.Sprintf("/v1/agent/auto-encrypt?token=%s", ro), nil)
+		if _, err := a.srv.AgentAutoEncrypt(nil, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+}
+
 func TestAgent_Reload(t *testing.T) {
 	t.Parallel()
 	dc1 := "dc1"
@@ -1275,6 +1429,47 @@ func TestAgent_Reload(t *testing.T) {
 	}
 }
 
+func TestAgent_Reload_result(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	cfg2 := TestConfig(config.Source{
+		Name:   "reload",
+		Format: "hcl",
+		Data: `
+			data_dir = "` + a.Config.DataDir + `"
+			node_id = "` + string(a.Config.NodeID) + `"
+			node_name = "` + a.Config.NodeName + `"
+			services = [
+				{
+					name = "redis-reloaded"
+				}
+			]
+		`,
+	})
+
+	// Do the reload like the agent command does.
+	go func() {
+		errCh := <-a.ReloadCh()
+		errCh <- a.ReloadConfig(cfg2)
+	}()
+
+	req, _ := http.NewRequest("PUT", "/v1/agent/reload", nil)
+	obj, err := a.srv.AgentReload(nil, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	result := obj.(*ReloadResult)
+	if !reflect.DeepEqual(result.Applied, []string{"Services"}) {
+		t.Fatalf("bad: %v", result.Applied)
+	}
+	if a.State.Service("redis-reloaded") == nil {
+		t.Fatal("missing redis-reloaded service")
+	}
+}
+
 func TestAgent_Reload_ACLDeny(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), TestACLConfig())
@@ -1309,7 +1504,7 @@ func TestAgent_Members(t *testing.T) {
 
 	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
 	req, _ := http.NewRequest("GET", "/v1/agent/members", nil)
-	obj, err := a.srv.AgentMembers(nil, req)
+	obj, err := a.srv.AgentMembers(httptest.NewRecorder(), req)
 	if err != nil {
 		t.Fatalf("Err: %v", err)
 	}
@@ -1330,7 +1525,7 @@ func TestAgent_Members_WAN(t *testing.T) {
 
 	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
 	req, _ := http.NewRequest("GET", "/v1/agent/members?wan=true", nil)
-	obj, err := a.srv.AgentMembers(nil, req)
+	obj, err := a.srv.AgentMembers(httptest.NewRecorder(), req)
 	if err != nil {
 		t.Fatalf("Err: %v", err)
 	}
@@ -1344,6 +1539,73 @@ func TestAgent_Members_WAN(t *testing.T) {
 	}
 }
 
+func TestAgent_Members_Paginate(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	members := a.WANMembers()
+	require.Len(t, members, 1)
+
+	t.Run("limit", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/members?wan=1&limit=1", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.AgentMembers(resp, req)
+		require.NoError(t, err)
+		require.Len(t, obj.([]serf.Member), 1)
+		require.NotEmpty(t, resp.Header().Get("X-Consul-Members-Index"))
+	})
+
+	t.Run("after", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/members?wan=1&after="+url.QueryEscape(members[0].Name), nil)
+		obj, err := a.srv.AgentMembers(httptest.NewRecorder(), req)
+		require.NoError(t, err)
+		require.Empty(t, obj.([]serf.Member))
+	})
+
+	t.Run("since", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/agent/members?wan=1", nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.AgentMembers(resp, req)
+		require.NoError(t, err)
+		index := resp.Header().Get("X-Consul-Members-Index")
+		require.NotEmpty(t, index)
+
+		require.Equal(t, "true", resp.Header().Get("X-Consul-Members-Reset"))
+
+		req, _ = http.NewRequest("GET", "/v1/agent/members?wan=1&since="+index, nil)
+		resp = httptest.NewRecorder()
+		obj, err := a.srv.AgentMembers(resp, req)
+		require.NoError(t, err)
+		require.Empty(t, obj.([]serf.Member))
+		require.Equal(t, "false", resp.Header().Get("X-Consul-Members-Reset"))
+
+		req, _ = http.NewRequest("GET", "/v1/agent/members?wan=1&since=0", nil)
+		obj, err = a.srv.AgentMembers(httptest.NewRecorder(), req)
+		require.NoError(t, err)
+		require.Len(t, obj.([]serf.Member), 1)
+
+		// An index from before the agent started resets the members.
+		req, _ = http.NewRequest("GET", "/v1/agent/members?wan=1&since=1", nil)
+		resp = httptest.NewRecorder()
+		obj, err = a.srv.AgentMembers(resp, req)
+		require.NoError(t, err)
+		require.Len(t, obj.([]serf.Member), 1)
+		require.Equal(t, "true", resp.Header().Get("X-Consul-Members-Reset"))
+	})
+
+	t.Run("bad params", func(t *testing.T) {
+		for _, q := range []string{"since=nope", "limit=-1", "limit=nope"} {
+			req, _ := http.NewRequest("GET", "/v1/agent/members?"+q, nil)
+			_, err := a.srv.AgentMembers(httptest.NewRecorder(), req)
+			require.Error(t, err, q)
+			_, ok := err.(BadRequestError)
+			require.True(t, ok, q)
+		}
+	})
+}
+
 func TestAgent_Members_ACLFilter(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), TestACLConfig())
@@ -1352,7 +1614,7 @@ func TestAgent_Members_ACLFilter(t *testing.T) {
 	testrpc.WaitForLeader(t, a.RPC, "dc1")
 	t.Run("no token", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/v1/agent/members", nil)
-		obj, err := a.srv.AgentMembers(nil, req)
+		obj, err := a.srv.AgentMembers(httptest.NewRecorder(), req)
 		if err != nil {
 			t.Fatalf("Err: %v", err)
 		}
@@ -1364,7 +1626,7 @@ func TestAgent_Members_ACLFilter(t *testing.T) {
 
 	t.Run("root token", func(t *testing.T) {
 		req, _ := http.NewRequest("GET", "/v1/agent/members?token=root", nil)
-		obj, err := a.srv.AgentMembers(nil, req)
+		obj, err := a.srv.AgentMembers(httptest.NewRecorder(), req)
 		if err != nil {
 			t.Fatalf("Err: %v", err)
 		}
@@ -2304,6 +2566,116 @@ func TestAgent_RegisterService(t *testing.T) {
 	}
 }
 
+func TestAgent_RegisterService_Deadline(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	register := func(id, deadline string) {
+		t.Helper()
+		args := &structs.ServiceDefinition{
+			ID:    id,
+			Name:  "test",
+			Port:  8000,
+			Check: structs.CheckType{TTL: time.Hour},
+		}
+		req, _ := http.NewRequest("PUT", "/v1/agent/service/register?deadline="+deadline, jsonReader(args))
+		if _, err := a.srv.AgentRegisterService(nil, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	provisional := func() []*api.AgentProvisionalService {
+		t.Helper()
+		req, _ := http.NewRequest("GET", "/v1/agent/service/provisional", nil)
+		obj, err := a.srv.AgentProvisionalServices(nil, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return obj.([]*api.AgentProvisionalService)
+	}
+
+	// The checks of the service pass before the deadline
+	register("confirmed", "1h")
+	list := provisional()
+	if len(list) != 1 || list[0].ServiceID != "confirmed" || list[0].Status != api.ProvisionalServicePending {
+		t.Fatalf("bad: %#v", list)
+	}
+	if err := a.updateTTLCheck("service:confirmed", api.HealthPassing, "ok"); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	a.checkProvisionalServices(time.Now())
+	if list := provisional(); len(list) != 0 {
+		t.Fatalf("bad: %#v", list)
+	}
+	if a.State.Service("confirmed") == nil {
+		t.Fatalf("missing service")
+	}
+
+	// The checks of the service don't pass before the deadline
+	register("failed", "1h")
+	a.checkProvisionalServices(time.Now())
+	if a.State.Service("failed") == nil {
+		t.Fatalf("missing service")
+	}
+	a.checkProvisionalServices(time.Now().Add(2 * time.Hour))
+	if a.State.Service("failed") != nil {
+		t.Fatalf("service should be deregistered")
+	}
+	list = provisional()
+	if len(list) != 1 || list[0].ServiceID != "failed" || list[0].Status != api.ProvisionalServiceRolledBack {
+		t.Fatalf("bad: %#v", list)
+	}
+	if !strings.Contains(list[0].Output, `"service:failed" is critical`) {
+		t.Fatalf("bad: %#v", list[0])
+	}
+
+	// The rollback happens without intervention once the deadline passed
+	register("expired", "100ms")
+	retry.Run(t, func(r *retry.R) {
+		if a.State.Service("expired") != nil {
+			r.Fatalf("service should be deregistered")
+		}
+	})
+
+	// Registering the service again without a deadline confirms it
+	register("again", "1h")
+	register("again", "")
+	a.checkProvisionalServices(time.Now().Add(2 * time.Hour))
+	if a.State.Service("again") == nil {
+		t.Fatalf("missing service")
+	}
+}
+
+func TestAgent_RegisterService_DeadlineInvalid(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	cases := map[string]struct {
+		deadline string
+		check    structs.CheckType
+		err      string
+	}{
+		"bad duration": {"soon", structs.CheckType{TTL: time.Hour}, "Invalid deadline"},
+		"negative":     {"-1s", structs.CheckType{TTL: time.Hour}, "Invalid deadline"},
+		"no checks":    {"1m", structs.CheckType{}, "at least one check"},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			args := &structs.ServiceDefinition{Name: "test", Check: tc.check}
+			req, _ := http.NewRequest("PUT", "/v1/agent/service/register?deadline="+tc.deadline, jsonReader(args))
+			_, err := a.srv.AgentRegisterService(nil, req)
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("err: %v", err)
+			}
+			if _, ok := err.(BadRequestError); !ok {
+				t.Fatalf("bad: %#v", err)
+			}
+		})
+	}
+}
+
 func TestAgent_RegisterService_TranslateKeys(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), `
@@ -2320,6 +2692,12 @@ func TestAgent_RegisterService_TranslateKeys(t *testing.T) {
 	{
 		"name":"test",
 		"port":8000,
+		"tagged_addresses": {
+			"wan": {
+				"address": "198.18.0.1",
+				"port": 80
+			}
+		},
 		"enable_tag_override": true,
 		"meta": {
 			"some": "meta",
@@ -2417,7 +2795,10 @@ func TestAgent_RegisterService_TranslateKeys(t *testing.T) {
 			"some":                "meta",
 			"enable_tag_override": "meta is 'opaque' so should not get translated",
 		},
-		Port:              8000,
+		Port: 8000,
+		TaggedAddresses: map[string]structs.ServiceAddress{
+			"wan": {Address: "198.18.0.1", Port: 80},
+		},
 		EnableTagOverride: true,
 		Weights:           &structs.Weights{Passing: 16, Warning: 0},
 		Kind:              structs.ServiceKindConnectProxy,
@@ -2901,11 +3282,74 @@ func testCreatePolicy(t *testing.T, a *TestAgent, name, rules string) string {
 	return aclResp.ID
 }
 
-// This tests local agent service registration with a sidecar service. Note we
-// only test simple defaults for the sidecar here since the actual logic for
-// handling sidecar defaults and port assignment is tested thoroughly in
-// TestAgent_sidecarServiceFromNodeService. Note it also tests Deregister
-// explicitly too since setup is identical.
+func TestAgent_RegisterService_ServiceToken(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), TestACLConfig()+`
+		acl {
+			enable_service_tokens = true
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	register := func(t require.TestingT, body string) error {
+		req, _ := http.NewRequest("PUT", "/v1/agent/service/register?token=root", strings.NewReader(body))
+		_, err := a.srv.AgentRegisterService(nil, req)
+		return err
+	}
+	web := `{"name": "web", "port": 8080, "connect": {"sidecar_service": {}}}`
+
+	// The servers start in legacy ACL mode so retry until the token can
+	// be created.
+	retry.Run(t, func(r *retry.R) {
+		if err := register(r, web); err != nil {
+			r.Fatal(err)
+		}
+	})
+
+	// The service and its sidecar use a token scoped to the service.
+	token := a.State.ServiceToken("web")
+	require.NotEqual(t, "root", token)
+	require.Equal(t, token, a.State.ServiceToken("web-sidecar-proxy"))
+
+	rule, err := a.resolveToken(token)
+	require.NoError(t, err)
+	require.True(t, rule.ServiceWrite("web", nil))
+	require.True(t, rule.ServiceWrite("web-sidecar-proxy", nil))
+	require.False(t, rule.ServiceWrite("db", nil))
+
+	tokenExists := func(secret string) bool {
+		args := structs.ACLTokenGetRequest{
+			Datacenter:   "dc1",
+			TokenID:      secret,
+			TokenIDType:  structs.ACLTokenSecret,
+			QueryOptions: structs.QueryOptions{Token: "root"},
+		}
+		var out structs.ACLTokenResponse
+		require.NoError(t, a.RPC("ACL.TokenRead", &args, &out))
+		return out.Token != nil
+	}
+
+	// Registering the service again replaces the token and deletes the
+	// previous one.
+	require.NoError(t, register(t, web))
+	prev := token
+	token = a.State.ServiceToken("web")
+	require.NotEqual(t, prev, token)
+	require.True(t, tokenExists(token))
+	require.False(t, tokenExists(prev))
+
+	// Services that don't use Connect keep the registration token.
+	require.NoError(t, register(t, `{"name": "db", "port": 5432}`))
+	require.Equal(t, "root", a.State.ServiceToken("db"))
+
+	// Deregistering the service deletes the token.
+	req, _ := http.NewRequest("PUT", "/v1/agent/service/deregister/web?token=root", nil)
+	_, err = a.srv.AgentDeregisterService(nil, req)
+	require.NoError(t, err)
+	require.False(t, tokenExists(token))
+}
+
 func TestAgent_RegisterServiceDeregisterService_Sidecar(t *testing.T) {
 	t.Parallel()
 
@@ -3986,6 +4430,82 @@ func TestAgent_RegisterCheck_Service(t *testing.T) {
 	}
 }
 
+func TestAgent_LogLevel(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// The levels can't be changed without a filter.
+	req, _ := http.NewRequest("GET", "/v1/agent/loglevel", nil)
+	if _, err := a.srv.AgentLogLevel(httptest.NewRecorder(), req); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	a.LogFilter = logger.NewSubsystemFilter(logger.LevelFilter(), ioutil.Discard)
+
+	t.Run("subsystem", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/agent/loglevel", jsonReader(api.AgentLogLevelRequest{
+			Subsystem: "raft",
+			Level:     "debug",
+		}))
+		obj, err := a.srv.AgentLogLevel(httptest.NewRecorder(), req)
+		require.NoError(t, err)
+		require.Equal(t, &api.AgentLogLevels{
+			Level:      "INFO",
+			Subsystems: map[string]string{"raft": "DEBUG"},
+		}, obj)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/agent/loglevel", jsonReader(api.AgentLogLevelRequest{
+			Level: "WARN",
+		}))
+		_, err := a.srv.AgentLogLevel(httptest.NewRecorder(), req)
+		require.NoError(t, err)
+
+		req, _ = http.NewRequest("GET", "/v1/agent/loglevel", nil)
+		obj, err := a.srv.AgentLogLevel(httptest.NewRecorder(), req)
+		require.NoError(t, err)
+		require.Equal(t, &api.AgentLogLevels{
+			Level:      "WARN",
+			Subsystems: map[string]string{"raft": "DEBUG"},
+		}, obj)
+	})
+
+	t.Run("invalid level", func(t *testing.T) {
+		req, _ := http.NewRequest("PUT", "/v1/agent/loglevel", jsonReader(api.AgentLogLevelRequest{
+			Level: "bogus",
+		}))
+		resp := httptest.NewRecorder()
+		_, err := a.srv.AgentLogLevel(resp, req)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusBadRequest, resp.Code)
+	})
+}
+
+func TestAgent_LogLevel_ACLDeny(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), TestACLConfig())
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+	a.LogFilter = logger.NewSubsystemFilter(logger.LevelFilter(), ioutil.Discard)
+
+	ro := makeReadOnlyAgentACL(t, a.srv)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/v1/agent/loglevel?token=%s", ro), nil)
+	if _, err := a.srv.AgentLogLevel(httptest.NewRecorder(), req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req, _ = http.NewRequest("PUT", fmt.Sprintf("/v1/agent/loglevel?token=%s", ro), jsonReader(api.AgentLogLevelRequest{
+		Level: "DEBUG",
+	}))
+	if _, err := a.srv.AgentLogLevel(httptest.NewRecorder(), req); !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestAgent_Monitor(t *testing.T) {
 	t.Parallel()
 	logWriter := logger.NewLogWriter(512)
@@ -4035,6 +4555,63 @@ func TestAgent_Monitor(t *testing.T) {
 	})
 }
 
+func TestAgent_Monitor_JSON(t *testing.T) {
+	t.Parallel()
+	logWriter := logger.NewLogWriter(512)
+	a := &TestAgent{
+		Name:      t.Name(),
+		LogWriter: logWriter,
+		LogOutput: io.MultiWriter(os.Stderr, logWriter),
+	}
+	a.Start(t)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Try passing an invalid logjson value
+	req, _ := http.NewRequest("GET", "/v1/agent/monitor?logjson=maybe", nil)
+	resp := newClosableRecorder()
+	if _, err := a.srv.AgentMonitor(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 400 {
+		t.Fatalf("bad: %v", resp.Code)
+	}
+
+	// Stream the JSON logs of raft only
+	retry.Run(t, func(r *retry.R) {
+		req, _ = http.NewRequest("GET", "/v1/agent/monitor?loglevel=debug&logjson=true&subsystem=raft", nil)
+		resp = newClosableRecorder()
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := a.srv.AgentMonitor(resp, req)
+			errCh <- err
+		}()
+
+		resp.Close()
+		if err := <-errCh; err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		var found bool
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var entry map[string]string
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				r.Fatalf("bad JSON line %q: %v", scanner.Text(), err)
+			}
+			if entry["@module"] != "raft" {
+				r.Fatalf("got a line of another subsystem: %q", scanner.Text())
+			}
+			if strings.HasPrefix(entry["@message"], "Initial configuration (index=1)") {
+				found = true
+			}
+		}
+		if !found {
+			r.Fatalf("did not find the initial raft configuration")
+		}
+	})
+}
+
 type closableRecorder struct {
 	*httptest.ResponseRecorder
 	closer chan bool
@@ -4962,7 +5539,7 @@ func TestAgentConnectProxyConfig_Blocking(t *testing.T) {
 		ProxyServiceID:    "test-proxy",
 		TargetServiceID:   "test",
 		TargetServiceName: "test",
-		ContentHash:       "a7c93585b6d70445",
+		ContentHash:       "cd9fae3f744900f3",
 		ExecMode:          "daemon",
 		Command:           []string{"tubes.sh"},
 		Config: map[string]interface{}{
@@ -4983,7 +5560,7 @@ func TestAgentConnectProxyConfig_Blocking(t *testing.T) {
 	ur, err := copystructure.Copy(expectedResponse)
 	require.NoError(t, err)
 	updatedResponse := ur.(*api.ConnectProxyConfig)
-	updatedResponse.ContentHash = "aedc0ca0f3f7794e"
+	updatedResponse.ContentHash = "59b052e51c1dada3"
 	updatedResponse.Upstreams = append(updatedResponse.Upstreams, api.Upstream{
 		DestinationType: "service",
 		DestinationName: "cache",
@@ -5908,6 +6485,68 @@ func TestAgentConnectAuthorize_deny(t *testing.T) {
 	assert.Contains(obj.Reason, "Matched")
 }
 
+// Test an intention with L7 permissions only allowing the GET requests.
+func TestAgentConnectAuthorize_permissions(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+	target := "db"
+
+	{
+		req := structs.IntentionRequest{
+			Datacenter: "dc1",
+			Op:         structs.IntentionOpCreate,
+			Intention:  structs.TestIntention(t),
+		}
+		req.Intention.SourceNS = structs.IntentionDefaultNamespace
+		req.Intention.SourceName = "web"
+		req.Intention.DestinationNS = structs.IntentionDefaultNamespace
+		req.Intention.DestinationName = target
+		req.Intention.Action = structs.IntentionActionDeny
+		req.Intention.Permissions = []*structs.IntentionPermission{
+			{
+				Action: structs.IntentionActionAllow,
+				HTTP: &structs.IntentionHTTPPermission{
+					PathPrefix: "/v1/",
+					Methods:    []string{"GET", "HEAD"},
+				},
+			},
+		}
+
+		var reply string
+		require.NoError(a.RPC("Intention.Apply", &req, &reply))
+	}
+
+	authorize := func(httpReq *structs.ConnectAuthorizeHTTPRequest) *connectAuthorizeResp {
+		args := &structs.ConnectAuthorizeRequest{
+			Target:        target,
+			ClientCertURI: connect.TestSpiffeIDService(t, "web").URI().String(),
+			HTTP:          httpReq,
+		}
+		req, _ := http.NewRequest("POST", "/v1/agent/connect/authorize", jsonReader(args))
+		respRaw, err := a.srv.AgentConnectAuthorize(httptest.NewRecorder(), req)
+		require.NoError(err)
+		return respRaw.(*connectAuthorizeResp)
+	}
+
+	obj := authorize(&structs.ConnectAuthorizeHTTPRequest{Method: "GET", Path: "/v1/users"})
+	require.True(obj.Authorized)
+	require.Contains(obj.Reason, "matched permission: ALLOW GET,HEAD /v1/*")
+
+	obj = authorize(&structs.ConnectAuthorizeHTTPRequest{Method: "POST", Path: "/v1/users"})
+	require.False(obj.Authorized)
+	require.Contains(obj.Reason, "no permission matched")
+
+	// A connection that is not HTTP can't be checked against the permissions
+	obj = authorize(nil)
+	require.False(obj.Authorized)
+	require.Contains(obj.Reason, "require an HTTP request")
+}
+
 // Test when there is an intention allowing service with a different trust
 // domain. We allow this because migration between trust domains shouldn't cause
 // an outage even if we have stale info about current trusted domains. It's safe
@@ -6135,6 +6774,65 @@ func TestAgentConnectAuthorize_defaultAllow(t *testing.T) {
 	assert.Contains(obj.Reason, "Default behavior")
 }
 
+func TestAgentConnectAuthorize_intentionSuggestions(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), `
+		connect {
+			intention_suggestions = true
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Create an intention covering web => db
+	{
+		req := structs.IntentionRequest{
+			Datacenter: "dc1",
+			Op:         structs.IntentionOpCreate,
+			Intention:  structs.TestIntention(t),
+		}
+		req.Intention.SourceNS = structs.IntentionDefaultNamespace
+		req.Intention.SourceName = "web"
+		req.Intention.DestinationNS = structs.IntentionDefaultNamespace
+		req.Intention.DestinationName = "db"
+		req.Intention.Action = structs.IntentionActionAllow
+
+		var reply string
+		require.NoError(a.RPC("Intention.Apply", &req, &reply))
+	}
+
+	// The servers only keep the observations of the local services
+	for _, name := range []string{"db", "api"} {
+		require.NoError(a.AddService(&structs.NodeService{ID: name, Service: name}, nil, false, "", ConfigSourceLocal))
+	}
+	require.NoError(a.State.SyncFull())
+
+	// Only the connections allowed by default are reported
+	for _, target := range []string{"db", "api", "api"} {
+		args := &structs.ConnectAuthorizeRequest{
+			Target:        target,
+			ClientCertURI: connect.TestSpiffeIDService(t, "web").URI().String(),
+		}
+		req, _ := http.NewRequest("POST", "/v1/agent/connect/authorize", jsonReader(args))
+		respRaw, err := a.srv.AgentConnectAuthorize(httptest.NewRecorder(), req)
+		require.NoError(err)
+		require.True(respRaw.(*connectAuthorizeResp).Authorized)
+	}
+	a.flushIntentionObservations()
+
+	req, _ := http.NewRequest("GET", "/v1/connect/intentions/suggestions", nil)
+	obj, err := a.srv.IntentionSuggestions(httptest.NewRecorder(), req)
+	require.NoError(err)
+	suggestions := obj.([]*structs.IntentionObservation)
+	require.Len(suggestions, 1)
+	require.Equal("web", suggestions[0].SourceName)
+	require.Equal("api", suggestions[0].DestinationName)
+	require.Equal(uint64(2), suggestions[0].Allowed)
+	require.Equal(uint64(0), suggestions[0].Denied)
+}
+
 // testAllowProxyConfig returns agent config to allow managed proxy API
 // registration.
 func testAllowProxyConfig() string {
@@ -6200,3 +6898,62 @@ func TestAgent_HostBadACL(t *testing.T) {
 	assert.Equal(http.StatusOK, resp.Code)
 	assert.Nil(respRaw)
 }
+
+func TestAgent_Features(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	req, _ := http.NewRequest("GET", "/v1/agent/features", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.AgentFeatures(resp, req)
+	require.NoError(t, err)
+
+	features := obj.(Features)
+	require.Equal(t, a.config.Version, features.Version)
+	require.Contains(t, features.Features, FeatureAgentCache)
+	require.Contains(t, features.Features, FeatureConfigEntries)
+	require.Contains(t, features.Features, FeatureConnect)
+	require.Contains(t, features.Features, FeatureConnectIngressGateways)
+	require.Contains(t, features.Features, FeatureConnectMeshGateways)
+	require.Contains(t, features.Features, FeatureLabels)
+	require.Contains(t, features.Features, FeatureConnectL7Intentions)
+	require.Contains(t, features.Features, FeatureConnectDiscoveryChain)
+	require.Contains(t, features.Features, FeatureHealthStream)
+	require.Contains(t, features.Features, FeatureKVChunked)
+	require.Contains(t, features.Features, FeatureKVTTL)
+	require.Contains(t, features.Features, FeatureKVFilter)
+	require.Contains(t, features.Features, FeatureTxnCatalogConnect)
+	require.Contains(t, features.Features, FeatureKVDeleteTreeCAS)
+	require.NotContains(t, features.Features, FeatureACLNamespaces)
+	require.True(t, sort.StringsAreSorted(features.Features))
+
+	a2 := NewTestAgent(t, t.Name(), TestACLConfig()+`
+		acl {
+			enable_service_tokens = true
+		}
+	`)
+	defer a2.Shutdown()
+
+	obj, err = a2.srv.AgentFeatures(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	features = obj.(Features)
+	require.Contains(t, features.Features, FeatureACLNamePrefix)
+	require.Contains(t, features.Features, FeatureACLNamespaces)
+	require.Contains(t, features.Features, FeatureACLServiceTokens)
+
+	// A client that doesn't know any server only reports its local features.
+	a3 := NewTestAgent(t, t.Name(), `
+		server = false
+		bootstrap = false
+	`)
+	defer a3.Shutdown()
+
+	obj, err = a3.srv.AgentFeatures(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	features = obj.(Features)
+	require.Contains(t, features.Features, FeatureAgentCache)
+	require.NotContains(t, features.Features, FeatureConfigEntries)
+	require.NotContains(t, features.Features, FeaturePreparedQueryStats)
+}
@@ -141,6 +141,34 @@ func TestAgent_RPCPing(t *testing.T) {
 	}
 }
 
+func TestAgent_APIBootstrapFile(t *testing.T) {
+	t.Parallel()
+	dir := testutil.TempDir(t, "bootstrap")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bootstrap.json")
+
+	a := NewTestAgent(t, t.Name(), `
+		api_bootstrap_file = "`+path+`"
+		api_bootstrap_token_file = "/etc/consul/token"
+	`)
+	defer a.Shutdown()
+
+	bf, err := api.ReadBootstrapFile(path)
+	require.NoError(t, err)
+	require.Equal(t, a.HTTPAddr(), bf.Address)
+	require.Equal(t, "http", bf.Scheme)
+	require.Equal(t, "dc1", bf.Datacenter)
+	require.Equal(t, "/etc/consul/token", bf.TokenFile)
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0644), fi.Mode().Perm())
+
+	require.NoError(t, a.Shutdown())
+	_, err = os.Stat(path)
+	require.True(t, os.IsNotExist(err))
+}
+
 func TestAgent_TokenStore(t *testing.T) {
 	t.Parallel()
 
@@ -1247,6 +1275,123 @@ acl_token = "hello"
 	require.Equal("goodbye", chkImpl.RPCReq.Token)
 }
 
+func TestAgent_AddCheck_Composite(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	for _, id := range []types.CheckID{"db", "cache1", "cache2"} {
+		health := &structs.HealthCheck{
+			Node:    "foo",
+			CheckID: id,
+			Name:    string(id),
+			Status:  api.HealthCritical,
+		}
+		chk := &structs.CheckType{TTL: time.Minute}
+		require.NoError(a.AddCheck(health, chk, false, "", ConfigSourceLocal))
+	}
+
+	health := &structs.HealthCheck{
+		Node:    "foo",
+		CheckID: "composite",
+		Name:    "Composite health check",
+		Status:  api.HealthPassing,
+	}
+	chk := &structs.CheckType{
+		Composite: "db AND (cache1 OR cache2)",
+	}
+	require.NoError(a.AddCheck(health, chk, false, "", ConfigSourceLocal))
+
+	_, ok := a.checkComposites["composite"]
+	require.True(ok, "missing composite check")
+
+	status := func() string {
+		return a.State.Checks()["composite"].Status
+	}
+	retry.Run(t, func(r *retry.R) {
+		if got, want := status(), api.HealthCritical; got != want {
+			r.Fatalf("got %q want %q", got, want)
+		}
+	})
+
+	a.State.UpdateCheck("db", api.HealthPassing, "")
+	a.State.UpdateCheck("cache2", api.HealthPassing, "")
+	retry.Run(t, func(r *retry.R) {
+		if got, want := status(), api.HealthPassing; got != want {
+			r.Fatalf("got %q want %q", got, want)
+		}
+	})
+
+	require.NoError(a.RemoveCheck("db", false))
+	retry.Run(t, func(r *retry.R) {
+		if got, want := status(), api.HealthCritical; got != want {
+			r.Fatalf("got %q want %q", got, want)
+		}
+	})
+
+	require.NoError(a.RemoveCheck("composite", false))
+	_, ok = a.checkComposites["composite"]
+	require.False(ok, "composite check not stopped")
+}
+
+func TestAgent_AddCheck_CompositeInvalid(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	cases := map[string]string{
+		"syntax":         "db AND",
+		"self reference": "db OR composite",
+	}
+	for name, expr := range cases {
+		t.Run(name, func(t *testing.T) {
+			health := &structs.HealthCheck{
+				Node:    "foo",
+				CheckID: "composite",
+				Name:    "Composite health check",
+				Status:  api.HealthCritical,
+			}
+			chk := &structs.CheckType{Composite: expr}
+			require.Error(t, a.AddCheck(health, chk, false, "", ConfigSourceLocal))
+			require.Nil(t, a.State.Checks()["composite"])
+		})
+	}
+}
+
+func TestAgent_AddCheck_CompositeCycle(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	addComposite := func(id types.CheckID, expr string) error {
+		health := &structs.HealthCheck{
+			Node:    "foo",
+			CheckID: id,
+			Name:    "Composite health check",
+			Status:  api.HealthCritical,
+		}
+		chk := &structs.CheckType{Composite: expr}
+		return a.AddCheck(health, chk, false, "", ConfigSourceLocal)
+	}
+
+	require.NoError(t, addComposite("a", "NOT b"))
+	require.Error(t, addComposite("b", "NOT a"))
+	require.Nil(t, a.State.Checks()["b"])
+	_, ok := a.checkComposites["b"]
+	require.False(t, ok, "cyclic composite check started")
+
+	// Longer cycles are rejected too, while checks sharing references
+	// without a cycle are fine.
+	require.NoError(t, addComposite("b", "c AND d"))
+	require.NoError(t, addComposite("e", "a OR b"))
+	require.Error(t, addComposite("c", "db OR e"))
+	require.Nil(t, a.State.Checks()["c"])
+}
+
 func TestAgent_RemoveCheck(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), `
@@ -1336,6 +1481,134 @@ func TestAgent_HTTPCheck_TLSSkipVerify(t *testing.T) {
 
 }
 
+func TestAgent_TCPCheck_TLS(t *testing.T) {
+	t.Parallel()
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "GOOD")
+	})
+	server := httptest.NewTLSServer(handler)
+	defer server.Close()
+
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	health := &structs.HealthCheck{
+		Node:    "foo",
+		CheckID: "tls",
+		Name:    "tls check",
+		Status:  api.HealthCritical,
+	}
+	chk := &structs.CheckType{
+		TCP:           server.Listener.Addr().String(),
+		TCPUseTLS:     true,
+		Interval:      20 * time.Millisecond,
+		TLSSkipVerify: true,
+		TLSServerName: "example.com",
+	}
+
+	err := a.AddCheck(health, chk, false, "", ConfigSourceLocal)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	retry.Run(t, func(r *retry.R) {
+		status := a.State.Checks()["tls"]
+		if status.Status != api.HealthPassing {
+			r.Fatalf("bad: %v", status.Status)
+		}
+		if !strings.Contains(status.Output, "TCP+TLS connect") {
+			r.Fatalf("bad: %v", status.Output)
+		}
+	})
+
+	// A client certificate requires its key
+	chk.TLSCertFile = "../test/client_certs/client.crt"
+	err = a.AddCheck(health, chk, false, "", ConfigSourceLocal)
+	if err == nil || !strings.Contains(err.Error(), "Failed to set up TLS") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestAgent_ExposeChecks(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	web := &structs.NodeService{
+		ID:      "web",
+		Service: "web",
+		Port:    8080,
+	}
+	chk := &structs.CheckType{
+		CheckID:  "health",
+		HTTP:     "http://127.0.0.1:8080/health?full=1",
+		Interval: 10 * time.Second,
+	}
+	require.NoError(a.AddService(web, []*structs.CheckType{chk}, false, "", ConfigSourceLocal))
+
+	proxyHTTP := func(id types.CheckID) string {
+		a.stateLock.Lock()
+		defer a.stateLock.Unlock()
+		return a.checkHTTPs[id].ProxyHTTP
+	}
+	require.Empty(proxyHTTP("health"))
+
+	// Registering a proxy exposing the checks points them at the proxy
+	proxy := &structs.NodeService{
+		Kind:    structs.ServiceKindConnectProxy,
+		ID:      "web-proxy",
+		Service: "web-proxy",
+		Address: "10.0.0.1",
+		Port:    21000,
+		Proxy: structs.ConnectProxyConfig{
+			DestinationServiceName: "web",
+			DestinationServiceID:   "web",
+			Expose: structs.ExposeConfig{
+				Checks: true,
+				Paths: []structs.ExposePath{
+					{ListenerPort: 21500, Path: "/metrics", LocalPathPort: 9090, Protocol: "http"},
+				},
+			},
+		},
+	}
+	require.NoError(a.AddService(proxy, nil, false, "", ConfigSourceLocal))
+	require.Equal("http://10.0.0.1:21501/health?full=1", proxyHTTP("health"))
+	require.Equal([]structs.ExposePath{
+		{ListenerPort: 21500, Path: "/metrics", LocalPathPort: 9090, Protocol: "http"},
+		{ListenerPort: 21501, Path: "/health", LocalPathPort: 8080, Protocol: "http", ParsedFromCheck: true},
+	}, a.State.Service("web-proxy").Proxy.Expose.Paths)
+
+	// Checks added later are exposed too
+	health := &structs.HealthCheck{
+		Node:      a.Config.NodeName,
+		CheckID:   "ready",
+		Name:      "ready",
+		Status:    api.HealthCritical,
+		ServiceID: "web",
+	}
+	chk = &structs.CheckType{
+		HTTP:     "http://127.0.0.1:8080/ready",
+		Interval: 10 * time.Second,
+	}
+	require.NoError(a.AddCheck(health, chk, false, "", ConfigSourceLocal))
+	require.Equal("http://10.0.0.1:21502/ready", proxyHTTP("ready"))
+
+	// Removing a check removes its path and keeps the others on their port
+	require.NoError(a.RemoveCheck("health", false))
+	require.Equal([]structs.ExposePath{
+		{ListenerPort: 21500, Path: "/metrics", LocalPathPort: 9090, Protocol: "http"},
+		{ListenerPort: 21502, Path: "/ready", LocalPathPort: 8080, Protocol: "http", ParsedFromCheck: true},
+	}, a.State.Service("web-proxy").Proxy.Expose.Paths)
+	require.Equal("http://10.0.0.1:21502/ready", proxyHTTP("ready"))
+
+	// Removing the proxy runs the checks against their own URL again
+	require.NoError(a.RemoveService("web-proxy", false))
+	require.Empty(proxyHTTP("ready"))
+}
+
 func TestAgent_HTTPCheck_EnableAgentTLSForChecks(t *testing.T) {
 	t.Parallel()
 
@@ -2067,6 +2340,59 @@ func TestAgent_loadChecks_token(t *testing.T) {
 	}
 }
 
+func TestAgent_loadChecks_aclReplication(t *testing.T) {
+	t.Parallel()
+
+	// The check is not registered in the primary datacenter
+	a1 := NewTestAgent(t, t.Name()+"-dc1", `
+		primary_datacenter = "dc1"
+		acl {
+			enabled = true
+		}
+	`)
+	defer a1.Shutdown()
+	if _, ok := a1.State.Checks()[structs.ACLReplicationCheckID]; ok {
+		t.Fatalf("unexpected ACL replication check")
+	}
+
+	a2 := NewTestAgent(t, t.Name()+"-dc2", `
+		datacenter = "dc2"
+		primary_datacenter = "dc1"
+		acl {
+			enabled = true
+			replication_lag_threshold = "3m"
+		}
+	`)
+	defer a2.Shutdown()
+	chk, ok := a2.State.Checks()[structs.ACLReplicationCheckID]
+	if !ok {
+		t.Fatalf("missing ACL replication check")
+	}
+	if chk.Name != structs.ACLReplicationCheckName || chk.ServiceID != structs.ACLReplicationServiceID {
+		t.Fatalf("bad: %#v", chk)
+	}
+	if a2.State.Service(structs.ACLReplicationServiceID) == nil {
+		t.Fatalf("missing ACL replication service")
+	}
+	if got, want := a2.checkACLReplication.LagThreshold, 3*time.Minute; got != want {
+		t.Fatalf("got threshold %v want %v", got, want)
+	}
+
+	// The check survives a reload of the configuration
+	if err := a2.ReloadConfig(a2.Config); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := a2.State.Checks()[structs.ACLReplicationCheckID]; !ok {
+		t.Fatalf("missing ACL replication check")
+	}
+	if a2.State.Service(structs.ACLReplicationServiceID) == nil {
+		t.Fatalf("missing ACL replication service")
+	}
+	if a2.checkACLReplication == nil {
+		t.Fatalf("missing ACL replication check runner")
+	}
+}
+
 func TestAgent_unloadChecks(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -0,0 +1,207 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/consul/agent/config"
+	"github.com/hashicorp/consul/agent/consul"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/lib/file"
+	"github.com/hashicorp/consul/tlsutil"
+)
+
+const (
+	// autoConfigFileName is the name of the file in the data directory
+	// where the configuration requested with auto-config is saved, so that
+	// the agent doesn't request it again when restarting. The file is never
+	// refreshed, only the certificate is renewed with auto-encrypt, so it
+	// must be removed for the agent to request a new configuration.
+	autoConfigFileName = "auto-config.json"
+)
+
+// persistedAutoConfig is the configuration requested with auto-config along
+// with the private key of the certificate handed by the servers.
+type persistedAutoConfig struct {
+	Config *structs.AutoConfigResponse
+	KeyPEM string
+}
+
+// setupAutoConfig loads the configuration requested with auto-config when
+// the agent first started, or requests it from the servers, and applies it
+// to the configuration of the agent. It blocks until one of the servers
+// answered.
+func (a *Agent) setupAutoConfig() error {
+	persisted, err := a.loadAutoConfig()
+	if err != nil {
+		return err
+	}
+	if persisted == nil {
+		if persisted, err = a.requestAutoConfig(); err != nil {
+			return err
+		}
+		a.logger.Printf("[INFO] agent: Requested the configuration with AutoConfig")
+
+		if err := a.persistAutoConfig(persisted); err != nil {
+			return err
+		}
+	}
+
+	a.autoConfig = persisted
+	a.applyAutoConfig(a.config)
+	return nil
+}
+
+// requestAutoConfig requests the configuration from the servers, presenting
+// the introduction token of the agent.
+func (a *Agent) requestAutoConfig() (*persistedAutoConfig, error) {
+	jwt, err := a.autoConfigIntroToken()
+	if err != nil {
+		return nil, err
+	}
+
+	servers, err := a.discoverServers(a.config.AutoConfigServerAddresses)
+	if err != nil {
+		return nil, err
+	}
+
+	// The agent has no certificate yet, so the servers are verified against
+	// the CAs configured for the agent, without their hostname. The JWT is
+	// never sent to servers which aren't verified.
+	if a.config.CAFile == "" && a.config.CAPath == "" {
+		return nil, fmt.Errorf("AutoConfig requires ca_file or ca_path to verify the servers")
+	}
+	tlsConf := a.config.ToTLSUtilConfig()
+	tlsConf.AutoEncryptTLS = true
+	tlsConfigurator, err := tlsutil.NewConfigurator(tlsConf, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	args := structs.AutoConfigRequest{
+		Datacenter: a.config.Datacenter,
+		Node:       a.config.NodeName,
+		Segment:    a.config.SegmentName,
+		JWT:        jwt,
+	}
+	reply, key, err := consul.RequestAutoConfig(a.logger, tlsConfigurator, servers,
+		a.config.ServerPort, &args, a.shutdownCh)
+	if err != nil {
+		return nil, err
+	}
+	return &persistedAutoConfig{Config: reply, KeyPEM: key}, nil
+}
+
+// autoConfigIntroToken returns the JWT presented to the servers.
+func (a *Agent) autoConfigIntroToken() (string, error) {
+	if a.config.AutoConfigIntroTokenFile == "" {
+		return a.config.AutoConfigIntroToken, nil
+	}
+
+	raw, err := ioutil.ReadFile(a.config.AutoConfigIntroTokenFile)
+	if err != nil {
+		return "", fmt.Errorf("Failed to read the AutoConfig intro token file: %v", err)
+	}
+	jwt := strings.TrimSpace(string(raw))
+	if jwt == "" {
+		return "", fmt.Errorf("AutoConfig intro token file %q is empty", a.config.AutoConfigIntroTokenFile)
+	}
+	return jwt, nil
+}
+
+// loadAutoConfig loads the configuration saved in the data directory, and
+// returns nil if there is none.
+func (a *Agent) loadAutoConfig() (*persistedAutoConfig, error) {
+	if a.config.DataDir == "" {
+		return nil, nil
+	}
+
+	raw, err := ioutil.ReadFile(filepath.Join(a.config.DataDir, autoConfigFileName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read the AutoConfig file: %v", err)
+	}
+
+	var persisted persistedAutoConfig
+	if err := json.Unmarshal(raw, &persisted); err != nil {
+		return nil, fmt.Errorf("Failed to decode the AutoConfig file: %v", err)
+	}
+	if persisted.Config == nil {
+		return nil, fmt.Errorf("AutoConfig file is missing the configuration")
+	}
+	return &persisted, nil
+}
+
+// persistAutoConfig saves the configuration in the data directory. The file
+// holds the agent token and the private key, so only the agent can read it.
+func (a *Agent) persistAutoConfig(persisted *persistedAutoConfig) error {
+	if a.config.DataDir == "" {
+		return nil
+	}
+
+	raw, err := json.Marshal(persisted)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(a.config.DataDir, autoConfigFileName)
+	if err := file.WriteAtomicWithPerms(path, raw, 0600); err != nil {
+		return fmt.Errorf("Failed to save the AutoConfig file: %v", err)
+	}
+	return nil
+}
+
+// applyAutoConfig applies the configuration handed by the servers to the
+// given configuration of the agent. The settings of the configuration files
+// are kept for the gossip encryption key, the agent token and the servers to
+// join.
+func (a *Agent) applyAutoConfig(cfg *config.RuntimeConfig) {
+	if a.autoConfig == nil {
+		return
+	}
+	resp := a.autoConfig.Config
+
+	if cfg.EncryptKey == "" {
+		cfg.EncryptKey = resp.GossipEncryptionKey
+	}
+
+	if resp.ACL.Enabled {
+		cfg.ACLsEnabled = true
+		cfg.ACLDatacenter = resp.ACL.PrimaryDatacenter
+		cfg.PrimaryDatacenter = resp.ACL.PrimaryDatacenter
+		cfg.ACLDefaultPolicy = resp.ACL.DefaultPolicy
+		cfg.ACLDownPolicy = resp.ACL.DownPolicy
+		cfg.ACLTokenTTL = resp.ACL.TokenTTL
+		cfg.ACLPolicyTTL = resp.ACL.PolicyTTL
+		cfg.ACLEnableKeyListPolicy = resp.ACL.EnableKeyListPolicy
+		if cfg.ACLAgentToken == "" {
+			cfg.ACLAgentToken = resp.ACL.AgentToken
+		}
+	}
+
+	// The certificate is renewed with auto-encrypt.
+	if resp.TLS != nil {
+		cfg.AutoEncryptTLS = true
+	}
+
+	if len(cfg.StartJoinAddrsLAN) == 0 && len(cfg.RetryJoinLAN) == 0 {
+		cfg.RetryJoinLAN = cfg.AutoConfigServerAddresses
+	}
+}
+
+// applyAutoConfigTLS loads the certificate handed by the servers into the TLS
+// configurator of the agent.
+func (a *Agent) applyAutoConfigTLS() error {
+	if a.autoConfig == nil || a.autoConfig.Config.TLS == nil {
+		return nil
+	}
+	resp := a.autoConfig.Config.TLS
+	return a.tlsConfigurator.UpdateAutoEncrypt(resp.ManualCARoots,
+		caRootPems(&resp.ConnectCARoots), resp.IssuedCert.CertPEM,
+		a.autoConfig.KeyPEM, resp.VerifyServerHostname)
+}
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/serf/serf"
+	"github.com/stretchr/testify/require"
+)
+
+// testAutoConfigJWT returns the PEM encoded public key of a new key along
+// with a JWT for the node signed with the key.
+func testAutoConfigJWT(t *testing.T, node string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	pubKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	header, err := json.Marshal(map[string]string{"alg": "ES256", "typ": "JWT"})
+	require.NoError(t, err)
+	claims, err := json.Marshal(map[string]interface{}{
+		"sub": node,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(claims)
+
+	h := crypto.SHA256.New()
+	h.Write([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, key, h.Sum(nil))
+	require.NoError(t, err)
+	sig := make([]byte, 64)
+	rb, sb := r.Bytes(), s.Bytes()
+	copy(sig[32-len(rb):32], rb)
+	copy(sig[64-len(sb):], sb)
+	return pubKey, signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestAgent_AutoConfig(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	node := t.Name() + "-client"
+	pubKey, jwt := testAutoConfigJWT(t, node)
+	gossipKey := "pUqJrVyVRj5jsiYEkM/tFQYfWyJIv4s3XkvDwy7Cu5s="
+
+	srv := NewTestAgent(t, t.Name()+"-server", fmt.Sprintf(`
+		encrypt = %q
+		connect { enabled = true }
+		auto_config {
+			authorization {
+				enabled = true
+				static {
+					jwt_validation_pub_keys = [%q]
+					claim_assertions = {
+						sub = "${node}"
+					}
+				}
+			}
+		}
+		ca_file = "../test/ca/root.cer"
+		cert_file = "../test/key/ourdomain.cer"
+		key_file = "../test/key/ourdomain.key"
+	`, gossipKey, pubKey))
+	defer srv.Shutdown()
+	testrpc.WaitForTestAgent(t, srv.RPC, "dc1")
+
+	client := NewTestAgent(t, node, fmt.Sprintf(`
+		node_name = %q
+		server = false
+		bootstrap = false
+		auto_config {
+			enabled = true
+			intro_token = %q
+			server_addresses = ["127.0.0.1:%d"]
+		}
+		ports { server = %d }
+		ca_file = "../test/ca/root.cer"
+	`, node, jwt, srv.Config.SerfPortLAN, srv.Config.ServerPort))
+	defer client.Shutdown()
+
+	// The client started with the gossip encryption key and the certificate
+	// handed by the server.
+	require.Equal(gossipKey, client.config.EncryptKey)
+	require.True(client.config.AutoEncryptTLS)
+	cert := client.tlsConfigurator.AutoEncryptCert()
+	require.NotNil(cert)
+	require.True(cert.NotAfter.After(time.Now()))
+
+	// The configuration is saved for the next start of the agent.
+	_, err := os.Stat(filepath.Join(client.config.DataDir, autoConfigFileName))
+	require.NoError(err)
+
+	// The client joins the server with the encrypted gossip.
+	retry.Run(t, func(r *retry.R) {
+		members := client.LANMembers()
+		if len(members) != 2 {
+			r.Fatalf("got %d members", len(members))
+		}
+		for _, m := range members {
+			if m.Status != serf.StatusAlive {
+				r.Fatalf("member %s is %s", m.Name, m.Status)
+			}
+		}
+	})
+}
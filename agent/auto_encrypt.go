@@ -0,0 +1,205 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/cache"
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/consul"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	// autoEncryptRetryInterval is the time between two attempts to renew
+	// the certificate of auto-encrypt when applying it failed.
+	autoEncryptRetryInterval = time.Minute
+
+	autoEncryptRootsWatchID = "roots"
+)
+
+// autoEncryptState holds the outcome of the last request of the certificate
+// of the RPC connections of a client agent with auto-encrypt.
+type autoEncryptState struct {
+	lock      sync.Mutex
+	lastError string
+}
+
+// setupAutoEncrypt requests the certificate of the RPC connections of the
+// client agent from the servers it joins, and keeps renewing it until the
+// agent shuts down. It blocks until one of the servers answered.
+func (a *Agent) setupAutoEncrypt(client *consul.Client) error {
+	servers, err := a.autoEncryptServers()
+	if err != nil {
+		return err
+	}
+
+	// The certificate handed with auto-config is only renewed.
+	if a.tlsConfigurator.AutoEncryptCert() == nil {
+		if err := a.requestAutoEncryptCerts(client, servers); err != nil {
+			return err
+		}
+		a.logger.Printf("[INFO] agent: Requested the RPC certificate with AutoEncrypt")
+	}
+
+	go a.renewAutoEncrypt(client, servers)
+	go a.watchAutoEncryptRoots()
+	return nil
+}
+
+// autoEncryptServers returns the addresses of the servers to request the
+// certificate from, which are the ones the agent joins on start.
+func (a *Agent) autoEncryptServers() ([]string, error) {
+	var addrs []string
+	addrs = append(addrs, a.config.StartJoinAddrsLAN...)
+	addrs = append(addrs, a.config.RetryJoinLAN...)
+	addrs = append(addrs, a.config.AutoConfigServerAddresses...)
+	servers, err := a.discoverServers(addrs)
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		return nil, fmt.Errorf("AutoEncrypt requires start_join or retry_join addresses")
+	}
+	return servers, nil
+}
+
+// discoverServers resolves the given addresses of servers, which may be
+// go-discover configurations, into the addresses of the servers.
+func (a *Agent) discoverServers(addrs []string) ([]string, error) {
+	var servers []string
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "provider=") {
+			servers = append(servers, addr)
+			continue
+		}
+
+		disco, err := newDiscover()
+		if err != nil {
+			return nil, err
+		}
+		discovered, err := disco.Addrs(addr, a.logger)
+		if err != nil {
+			a.logger.Printf("[ERR] agent: Failed to discover servers: %s", err)
+			continue
+		}
+		servers = append(servers, discovered...)
+	}
+	return servers, nil
+}
+
+// requestAutoEncryptCerts requests a certificate from the servers and
+// applies it along with the CA certificates returned by the servers.
+func (a *Agent) requestAutoEncryptCerts(client *consul.Client, servers []string) error {
+	reply, key, err := client.RequestAutoEncryptCerts(servers, a.config.ServerPort, a.tokens.AgentToken(), a.shutdownCh)
+	if err == nil {
+		err = a.tlsConfigurator.UpdateAutoEncrypt(reply.ManualCARoots,
+			caRootPems(&reply.ConnectCARoots), reply.IssuedCert.CertPEM, key,
+			reply.VerifyServerHostname)
+	}
+
+	a.autoEncrypt.lock.Lock()
+	defer a.autoEncrypt.lock.Unlock()
+	if err != nil {
+		a.autoEncrypt.lastError = err.Error()
+	} else {
+		a.autoEncrypt.lastError = ""
+	}
+	return err
+}
+
+// renewAutoEncrypt requests a new certificate once half of the validity of
+// the current one elapsed, until the agent shuts down.
+func (a *Agent) renewAutoEncrypt(client *consul.Client, servers []string) {
+	var lastErr error
+	for {
+		wait := autoEncryptRetryInterval
+		if lastErr == nil {
+			cert := a.tlsConfigurator.AutoEncryptCert()
+			if cert == nil {
+				return
+			}
+			wait = time.Until(cert.NotBefore.Add(cert.NotAfter.Sub(cert.NotBefore) / 2))
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-a.shutdownCh:
+			return
+		}
+
+		lastErr = a.requestAutoEncryptCerts(client, servers)
+		if lastErr != nil {
+			a.logger.Printf("[ERR] agent: Failed to renew the RPC certificate with AutoEncrypt: %v", lastErr)
+		} else {
+			a.logger.Printf("[INFO] agent: Renewed the RPC certificate with AutoEncrypt")
+		}
+	}
+}
+
+// watchAutoEncryptRoots updates the Connect CA certificates trusted for the
+// RPC connections when the roots change, so that the agents keep verifying
+// the certificates issued with auto-encrypt after a rotation of the CA.
+func (a *Agent) watchAutoEncryptRoots() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan cache.UpdateEvent, 1)
+	err := a.cache.Notify(ctx, cachetype.ConnectCARootName, &structs.DCSpecificRequest{
+		Datacenter:   a.config.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: a.tokens.AgentToken()},
+	}, autoEncryptRootsWatchID, ch)
+	if err != nil {
+		a.logger.Printf("[ERR] agent: AutoEncrypt: failed to watch the Connect CA roots: %v", err)
+		return
+	}
+
+	for {
+		select {
+		case u := <-ch:
+			if u.Err != nil {
+				continue
+			}
+			roots, ok := u.Result.(*structs.IndexedCARoots)
+			if !ok {
+				continue
+			}
+			if err := a.tlsConfigurator.UpdateAutoEncryptCA(caRootPems(roots)); err != nil {
+				a.logger.Printf("[ERR] agent: AutoEncrypt: failed to update the Connect CA roots: %v", err)
+			}
+		case <-a.shutdownCh:
+			return
+		}
+	}
+}
+
+// autoEncryptStatus returns the status of the certificate of auto-encrypt.
+func (a *Agent) autoEncryptStatus() *api.AutoEncryptStatus {
+	status := &api.AutoEncryptStatus{
+		Enabled: a.config.AutoEncryptTLS,
+	}
+	if cert := a.tlsConfigurator.AutoEncryptCert(); cert != nil {
+		status.SerialNumber = connect.HexString(cert.SerialNumber.Bytes())
+		status.ValidAfter = cert.NotBefore
+		status.ValidBefore = cert.NotAfter
+	}
+
+	a.autoEncrypt.lock.Lock()
+	status.LastError = a.autoEncrypt.lastError
+	a.autoEncrypt.lock.Unlock()
+	return status
+}
+
+// caRootPems returns the PEM encoded certificates of the given roots.
+func caRootPems(roots *structs.IndexedCARoots) []string {
+	var pems []string
+	for _, r := range roots.Roots {
+		pems = append(pems, r.RootCert)
+	}
+	return pems
+}
@@ -0,0 +1,42 @@
+package cachetype
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Recommended name for registration.
+const CatalogDatacentersName = "catalog-datacenters"
+
+// CatalogDatacenters supports fetching the list of the known datacenters,
+// sorted by their estimated distance.
+type CatalogDatacenters struct {
+	RPC RPC
+}
+
+func (c *CatalogDatacenters) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a DCSpecificRequest. None of its fields are used
+	// by the RPC, it's only needed to be cached.
+	if _, ok := req.(*structs.DCSpecificRequest); !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Fetch
+	var reply []string
+	if err := c.RPC.RPC("Catalog.ListDatacenters", &struct{}{}, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+	return result, nil
+}
+
+func (c *CatalogDatacenters) SupportsBlocking() bool {
+	// The list of datacenters doesn't support blocking.
+	return false
+}
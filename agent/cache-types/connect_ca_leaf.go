@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -116,6 +117,10 @@ type fetchState struct {
 	// use this to choose a new window for the next retry. See comment on
 	// caChangeJitterWindow above for more.
 	consecutiveRateLimitErrs int
+
+	// sanDomains is the trust domain and the DNS domains of the SANs of the
+	// current cert, to renew it when the roots report other ones.
+	sanDomains string
 }
 
 // fetchStart is called on each fetch that is about to block and wait for
@@ -416,9 +421,9 @@ func (c *ConnectCALeaf) Fetch(opts cache.FetchOptions, req cache.Request) (cache
 			// rootsWatcher didn't know about the CA we were signed by. We also rely
 			// on this on every request to do the initial check that the current roots
 			// are the same ones the current cert was signed by.
-			if activeRootHasKey(roots, state.authorityKeyID) {
-				// Current active CA is the same one that signed our current cert so
-				// keep waiting for a change.
+			if activeRootHasKey(roots, state.authorityKeyID) && state.sanDomains == leafSANDomains(roots) {
+				// Current active CA is the same one that signed our current cert, and
+				// the SANs it needs are the same, so keep waiting for a change.
 				continue
 			}
 			state.activeRootRotationStart = time.Now()
@@ -461,6 +466,12 @@ func activeRootHasKey(roots *structs.IndexedCARoots, currentSigningKeyID string)
 	return false
 }
 
+// leafSANDomains returns the trust domain and the DNS domains of the SANs of
+// the leaf certs signed under the given roots.
+func leafSANDomains(roots *structs.IndexedCARoots) string {
+	return strings.Join(append([]string{roots.TrustDomain}, roots.LeafCertDNSDomains...), ",")
+}
+
 func (c *ConnectCALeaf) rootsFromCache() (*structs.IndexedCARoots, error) {
 	rawRoots, _, err := c.Cache.Get(ConnectCARootName, &structs.DCSpecificRequest{
 		Datacenter: c.Datacenter,
@@ -516,7 +527,8 @@ func (c *ConnectCALeaf) generateNewLeaf(req *ConnectCALeafRequest,
 	}
 
 	// Create a CSR.
-	csr, err := connect.CreateCSR(serviceID, pk)
+	dnsNames := connect.ServiceDNSNames(req.Service, roots.LeafCertDNSDomains)
+	csr, err := connect.CreateCSR(serviceID, pk, dnsNames)
 	if err != nil {
 		return result, err
 	}
@@ -585,6 +597,7 @@ func (c *ConnectCALeaf) generateNewLeaf(req *ConnectCALeafRequest,
 	}
 	// Set the CA key ID so we can easily tell when a active root has changed.
 	state.authorityKeyID = connect.HexString(cert.AuthorityKeyId)
+	state.sanDomains = leafSANDomains(roots)
 
 	result.Value = &reply
 	// Store value not pointer so we don't accidentally mutate the cache entry
@@ -0,0 +1,50 @@
+package cachetype
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Recommended name for registration.
+const CompiledDiscoveryChainName = "compiled-discovery-chain"
+
+// CompiledDiscoveryChain supports fetching the compiled discovery chain of a
+// service.
+type CompiledDiscoveryChain struct {
+	RPC RPC
+}
+
+func (c *CompiledDiscoveryChain) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a DiscoveryChainRequest.
+	reqReal, ok := req.(*structs.DiscoveryChainRequest)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Set the minimum query index to our current index so we block
+	reqReal.QueryOptions.MinQueryIndex = opts.MinIndex
+	reqReal.QueryOptions.MaxQueryTime = opts.Timeout
+
+	// Allow stale reads like for the service discovery, the chains are served
+	// from the cache anyway.
+	reqReal.AllowStale = true
+
+	// Fetch
+	var reply structs.DiscoveryChainResponse
+	if err := c.RPC.RPC("DiscoveryChain.Get", reqReal, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+	result.Index = reply.QueryMeta.Index
+	return result, nil
+}
+
+func (c *CompiledDiscoveryChain) SupportsBlocking() bool {
+	return true
+}
@@ -0,0 +1,258 @@
+package cachetype
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/subscribe"
+)
+
+// Recommended name for registration.
+const StreamingHealthServicesName = "streaming-health-services"
+
+const (
+	// streamingViewIdleTimeout is how long a materialized view is kept
+	// subscribed without being fetched. The cache refreshes the entries
+	// continuously, so this only happens once an entry is evicted.
+	streamingViewIdleTimeout = 10 * time.Minute
+
+	// streamingDefaultTimeout is the timeout of the fetches which don't
+	// specify one.
+	streamingDefaultTimeout = 10 * time.Minute
+)
+
+// StreamingHealthServices supports fetching the healthy instances of a
+// service from a materialized view fed by the event streams of the servers,
+// instead of running blocking queries against them. It only supports the
+// requests of the local datacenter without filters.
+type StreamingHealthServices struct {
+	// Server runs the subscriptions of the views. Its Streamer must be
+	// set.
+	Server *subscribe.Server
+}
+
+func (c *StreamingHealthServices) Fetch(opts cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a ServiceSpecificRequest.
+	reqReal, ok := req.(*structs.ServiceSpecificRequest)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Reuse the view of the entry unless it was stopped.
+	var view *healthView
+	if opts.LastResult != nil {
+		view, _ = opts.LastResult.State.(*healthView)
+	}
+	if view == nil || view.isStopped() {
+		view = newHealthView(c.Server, reqReal)
+	}
+
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = streamingDefaultTimeout
+	}
+	value, err := view.fetch(opts.MinIndex, timeout)
+	if err != nil {
+		return result, err
+	}
+
+	result.State = view
+	if value != nil {
+		result.Value = value
+		result.Index = value.Index
+	}
+	return result, nil
+}
+
+func (c *StreamingHealthServices) SupportsBlocking() bool {
+	return true
+}
+
+// healthView is the materialized view of the health of the instances of a
+// service, kept up to date by a subscription.
+type healthView struct {
+	cancel context.CancelFunc
+
+	lock      sync.Mutex
+	instances map[string]*structs.CheckServiceNode
+	index     uint64
+	ready     bool
+
+	// updateCh is closed and replaced on each update of the view.
+	updateCh chan struct{}
+
+	// fetching is the number of fetches in progress and lastFetch the time
+	// the last one ended, used to stop idle views.
+	fetching  int
+	lastFetch time.Time
+
+	stopped bool
+	err     error
+}
+
+// newHealthView starts the subscription of a new view.
+func newHealthView(srv *subscribe.Server, req *structs.ServiceSpecificRequest) *healthView {
+	ctx, cancel := context.WithCancel(context.Background())
+	v := &healthView{
+		cancel:    cancel,
+		instances: make(map[string]*structs.CheckServiceNode),
+		updateCh:  make(chan struct{}),
+		lastFetch: time.Now(),
+	}
+
+	sreq := &subscribe.Request{
+		Topic:      subscribe.TopicHealth,
+		Key:        req.ServiceName,
+		Datacenter: req.Datacenter,
+		Token:      req.Token,
+	}
+	go func() {
+		err := srv.Stream(ctx, sreq, v.apply)
+		v.stop(err)
+	}()
+	go v.expire(ctx)
+	return v
+}
+
+// apply updates the view with the given event.
+func (v *healthView) apply(e *subscribe.Event) error {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.stopped {
+		return nil
+	}
+	if e.ServiceHealth != nil {
+		k := e.ServiceHealth.Node.Node + "/" + e.ServiceHealth.Service.ID
+		if e.Op == subscribe.OpDelete {
+			delete(v.instances, k)
+		} else {
+			v.instances[k] = e.ServiceHealth
+		}
+	}
+	if e.EndOfSnapshot {
+		v.ready = true
+	}
+	v.index = e.Index
+	if v.ready {
+		close(v.updateCh)
+		v.updateCh = make(chan struct{})
+	}
+	return nil
+}
+
+// stop stops the view, which reports the given error, if any, to the
+// fetches in progress.
+func (v *healthView) stop(err error) {
+	v.cancel()
+
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.stopped {
+		return
+	}
+	v.stopped, v.err = true, err
+	close(v.updateCh)
+}
+
+func (v *healthView) isStopped() bool {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return v.stopped
+}
+
+// expire stops the view once it hasn't been fetched for a while.
+func (v *healthView) expire(ctx context.Context) {
+	ticker := time.NewTicker(streamingViewIdleTimeout / 10)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			v.lock.Lock()
+			idle := v.fetching == 0 && time.Since(v.lastFetch) > streamingViewIdleTimeout
+			v.lock.Unlock()
+			if idle {
+				v.stop(nil)
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fetch waits until the index of the view is past minIndex and returns its
+// content. It returns a nil result if the view isn't ready before the
+// timeout.
+func (v *healthView) fetch(minIndex uint64, timeout time.Duration) (*structs.IndexedCheckServiceNodes, error) {
+	v.lock.Lock()
+	v.fetching++
+	defer func() {
+		v.fetching--
+		v.lastFetch = time.Now()
+		v.lock.Unlock()
+	}()
+
+	timeoutCh := time.After(timeout)
+	for {
+		if v.stopped {
+			if v.err != nil {
+				return nil, v.err
+			}
+			return nil, fmt.Errorf("streaming view of service stopped")
+		}
+		if v.ready && v.index > minIndex {
+			return v.result(), nil
+		}
+
+		updateCh := v.updateCh
+		v.lock.Unlock()
+		select {
+		case <-updateCh:
+			v.lock.Lock()
+		case <-timeoutCh:
+			v.lock.Lock()
+			if !v.ready {
+				return nil, nil
+			}
+			return v.result(), nil
+		}
+	}
+}
+
+// result returns a copy of the content of the view, which can be modified
+// by the caller, such as to translate the addresses. The lock must be held.
+func (v *healthView) result() *structs.IndexedCheckServiceNodes {
+	nodes := make(structs.CheckServiceNodes, 0, len(v.instances))
+	for _, n := range v.instances {
+		node, svc := *n.Node, *n.Service
+		nodes = append(nodes, structs.CheckServiceNode{
+			Node:    &node,
+			Service: &svc,
+			Checks:  append(structs.HealthChecks(nil), n.Checks...),
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		if nodes[i].Node.Node != nodes[j].Node.Node {
+			return nodes[i].Node.Node < nodes[j].Node.Node
+		}
+		return nodes[i].Service.ID < nodes[j].Service.ID
+	})
+
+	return &structs.IndexedCheckServiceNodes{
+		Nodes: nodes,
+		QueryMeta: structs.QueryMeta{
+			Index:       v.index,
+			KnownLeader: true,
+		},
+	}
+}
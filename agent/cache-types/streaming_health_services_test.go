@@ -0,0 +1,112 @@
+package cachetype
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/subscribe"
+	"github.com/stretchr/testify/require"
+)
+
+// testStreamer streams the events sent to its channel.
+type testStreamer struct {
+	eventCh chan *subscribe.Event
+	err     error
+}
+
+func (s *testStreamer) Subscribe(ctx context.Context, req *subscribe.Request, fn func(*subscribe.Event) error) error {
+	if s.err != nil {
+		return s.err
+	}
+	for {
+		select {
+		case e := <-s.eventCh:
+			if err := fn(e); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func testHealthEvent(index uint64, op, node string) *subscribe.Event {
+	return &subscribe.Event{
+		Index: index,
+		Op:    op,
+		ServiceHealth: &structs.CheckServiceNode{
+			Node:    &structs.Node{Node: node},
+			Service: &structs.NodeService{ID: "web", Service: "web"},
+		},
+	}
+}
+
+func TestStreamingHealthServices(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	streamer := &testStreamer{eventCh: make(chan *subscribe.Event, 10)}
+	typ := &StreamingHealthServices{Server: &subscribe.Server{
+		Logger:     log.New(os.Stderr, "", log.LstdFlags),
+		Datacenter: "dc1",
+		Streamer:   streamer,
+	}}
+	req := &structs.ServiceSpecificRequest{Datacenter: "dc1", ServiceName: "web"}
+
+	streamer.eventCh <- testHealthEvent(5, subscribe.OpUpsert, "n2")
+	streamer.eventCh <- testHealthEvent(5, subscribe.OpUpsert, "n1")
+	streamer.eventCh <- &subscribe.Event{Index: 5, EndOfSnapshot: true}
+
+	// The first fetch returns the snapshot.
+	result, err := typ.Fetch(cache.FetchOptions{Timeout: time.Second}, req)
+	require.NoError(err)
+	require.Equal(uint64(5), result.Index)
+	nodes := result.Value.(*structs.IndexedCheckServiceNodes).Nodes
+	require.Len(nodes, 2)
+	require.Equal("n1", nodes[0].Node.Node)
+	require.Equal("n2", nodes[1].Node.Node)
+
+	// A blocking fetch returns the next change.
+	streamer.eventCh <- testHealthEvent(7, subscribe.OpDelete, "n2")
+	result, err = typ.Fetch(cache.FetchOptions{
+		MinIndex:   5,
+		Timeout:    time.Second,
+		LastResult: &result,
+	}, req)
+	require.NoError(err)
+	require.Equal(uint64(7), result.Index)
+	nodes = result.Value.(*structs.IndexedCheckServiceNodes).Nodes
+	require.Len(nodes, 1)
+	require.Equal("n1", nodes[0].Node.Node)
+
+	// A blocking fetch without changes times out with the current state.
+	result, err = typ.Fetch(cache.FetchOptions{
+		MinIndex:   7,
+		Timeout:    50 * time.Millisecond,
+		LastResult: &result,
+	}, req)
+	require.NoError(err)
+	require.Equal(uint64(7), result.Index)
+
+	result.State.(*healthView).stop(nil)
+}
+
+func TestStreamingHealthServices_aclDenied(t *testing.T) {
+	t.Parallel()
+
+	typ := &StreamingHealthServices{Server: &subscribe.Server{
+		Logger:     log.New(os.Stderr, "", log.LstdFlags),
+		Datacenter: "dc1",
+		Streamer:   &testStreamer{err: acl.ErrPermissionDenied},
+	}}
+	req := &structs.ServiceSpecificRequest{Datacenter: "dc1", ServiceName: "web"}
+
+	_, err := typ.Fetch(cache.FetchOptions{Timeout: time.Second}, req)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+}
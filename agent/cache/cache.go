@@ -17,6 +17,7 @@ package cache
 import (
 	"container/heap"
 	"fmt"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -53,6 +54,16 @@ const (
 // searching for "metrics." to see the various metrics exposed. These can be
 // used to explore the performance of the cache.
 type Cache struct {
+	// The counters below back Stats. They are only accessed atomically and
+	// are kept first in the struct to ensure 64-bit alignment.
+	hits         uint64
+	missesNew    uint64
+	missesBlock  uint64
+	bypass       uint64
+	fetchSuccess uint64
+	fetchError   uint64
+	evictions    uint64
+
 	// types stores the list of data types that the cache knows how to service.
 	// These can be dynamically registered with RegisterType.
 	typesLock sync.RWMutex
@@ -221,6 +232,7 @@ func (c *Cache) getWithIndex(t string, r Request, minIndex uint64) (interface{},
 	info := r.CacheInfo()
 	if info.Key == "" {
 		metrics.IncrCounter([]string{"consul", "cache", "bypass"}, 1)
+		atomic.AddUint64(&c.bypass, 1)
 
 		// If no key is specified, then we do not cache this request.
 		// Pass directly through to the backend.
@@ -283,6 +295,7 @@ RETRY_GET:
 		meta := ResultMeta{Index: entry.Index}
 		if first {
 			metrics.IncrCounter([]string{"consul", "cache", t, "hit"}, 1)
+			atomic.AddUint64(&c.hits, 1)
 			meta.Hit = true
 		}
 
@@ -339,8 +352,10 @@ RETRY_GET:
 		// or if we're missing because we're blocking on a set index.
 		if minIndex == 0 {
 			metrics.IncrCounter([]string{"consul", "cache", t, "miss_new"}, 1)
+			atomic.AddUint64(&c.missesNew, 1)
 		} else {
 			metrics.IncrCounter([]string{"consul", "cache", t, "miss_block"}, 1)
+			atomic.AddUint64(&c.missesBlock, 1)
 		}
 	}
 
@@ -516,6 +531,7 @@ func (c *Cache) fetch(t, key string, r Request, allowNew bool, attempt uint) (<-
 		if err == nil {
 			metrics.IncrCounter([]string{"consul", "cache", "fetch_success"}, 1)
 			metrics.IncrCounter([]string{"consul", "cache", t, "fetch_success"}, 1)
+			atomic.AddUint64(&c.fetchSuccess, 1)
 
 			if result.Index > 0 {
 				// Reset the attempts counter so we don't have any backoff
@@ -545,6 +561,7 @@ func (c *Cache) fetch(t, key string, r Request, allowNew bool, attempt uint) (<-
 		} else {
 			metrics.IncrCounter([]string{"consul", "cache", "fetch_error"}, 1)
 			metrics.IncrCounter([]string{"consul", "cache", t, "fetch_error"}, 1)
+			atomic.AddUint64(&c.fetchError, 1)
 
 			// Increment attempt counter
 			attempt++
@@ -700,6 +717,7 @@ func (c *Cache) runExpiryLoop() {
 
 			// Set some metrics
 			metrics.IncrCounter([]string{"consul", "cache", "evict_expired"}, 1)
+			atomic.AddUint64(&c.evictions, 1)
 			metrics.SetGauge([]string{"consul", "cache", "entries_count"}, float32(len(c.entries)))
 
 			c.entriesLock.Unlock()
@@ -707,6 +725,28 @@ func (c *Cache) runExpiryLoop() {
 	}
 }
 
+// Stats returns counters describing the cache usage since the agent started,
+// as well as the current number of entries.
+func (c *Cache) Stats() map[string]string {
+	c.entriesLock.RLock()
+	entries := len(c.entries)
+	c.entriesLock.RUnlock()
+
+	toString := func(v *uint64) string {
+		return strconv.FormatUint(atomic.LoadUint64(v), 10)
+	}
+	return map[string]string{
+		"entries":       strconv.Itoa(entries),
+		"hits":          toString(&c.hits),
+		"miss_new":      toString(&c.missesNew),
+		"miss_block":    toString(&c.missesBlock),
+		"bypass":        toString(&c.bypass),
+		"fetch_success": toString(&c.fetchSuccess),
+		"fetch_error":   toString(&c.fetchError),
+		"evict_expired": toString(&c.evictions),
+	}
+}
+
 // Close stops any background work and frees all resources for the cache.
 // Current Fetch requests are allowed to continue to completion and callers may
 // still access the current cache values so coordination isn't needed with
@@ -47,6 +47,39 @@ func TestCacheGet_noIndex(t *testing.T) {
 	typ.AssertExpectations(t)
 }
 
+// Test that the stats reflect hits, misses and bypassed requests.
+func TestCacheStats(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+
+	typ := TestType(t)
+	defer typ.AssertExpectations(t)
+	c := TestCache(t)
+	c.RegisterType("t", typ, nil)
+
+	typ.Static(FetchResult{Value: 42}, nil).Times(2)
+
+	// A miss followed by a hit
+	req := TestRequest(t, RequestInfo{Key: "hello"})
+	_, _, err := c.Get("t", req)
+	require.NoError(err)
+	_, _, err = c.Get("t", req)
+	require.NoError(err)
+
+	// Requests without a key bypass the cache
+	_, _, err = c.Get("t", TestRequest(t, RequestInfo{}))
+	require.NoError(err)
+
+	stats := c.Stats()
+	require.Equal("1", stats["entries"])
+	require.Equal("1", stats["hits"])
+	require.Equal("1", stats["miss_new"])
+	require.Equal("1", stats["bypass"])
+	require.Equal("1", stats["fetch_success"])
+	require.Equal("0", stats["fetch_error"])
+}
+
 // Test a basic Get with no index and a failed fetch.
 func TestCacheGet_initError(t *testing.T) {
 	t.Parallel()
@@ -8,9 +8,10 @@ import (
 	metrics "github.com/armon/go-metrics"
 	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/subscribe"
 )
 
-var durations = NewDurationFixer("interval", "timeout", "deregistercriticalserviceafter")
+var durations = NewDurationFixer("ttl", "interval", "timeout", "deregistercriticalserviceafter")
 
 func (s *HTTPServer) CatalogRegister(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	metrics.IncrCounterWithLabels([]string{"client", "api", "catalog_register"}, 1,
@@ -98,6 +99,9 @@ func (s *HTTPServer) CatalogNodes(resp http.ResponseWriter, req *http.Request) (
 			[]metrics.Label{{Name: "node", Value: s.nodeName()}})
 		return nil, nil
 	}
+	if err := parseFilter(req, &args.QueryOptions, structs.Nodes(nil)); err != nil {
+		return nil, err
+	}
 
 	var out structs.IndexedNodes
 	defer setMeta(resp, &out.QueryMeta)
@@ -134,6 +138,14 @@ func (s *HTTPServer) CatalogServices(resp http.ResponseWriter, req *http.Request
 		return nil, nil
 	}
 
+	// The filter and the label selector select the service instances
+	if err := parseFilter(req, &args.QueryOptions, structs.ServiceNodes(nil)); err != nil {
+		return nil, err
+	}
+	if err := parseLabelSelector(req, &args.QueryOptions); err != nil {
+		return nil, err
+	}
+
 	var out structs.IndexedServices
 	defer setMeta(resp, &out.QueryMeta)
 RETRY_ONCE:
@@ -158,6 +170,15 @@ RETRY_ONCE:
 	return out.Services, nil
 }
 
+// CatalogServicesStream streams the changes to the services of the catalog
+// and their tags as server-sent events, starting with the current services.
+// Each event is a JSON encoded subscribe.Event with the index of the change
+// as its ID.
+func (s *HTTPServer) CatalogServicesStream(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := subscribe.Request{Topic: subscribe.TopicCatalog}
+	return s.streamSubscription(resp, req, &args)
+}
+
 func (s *HTTPServer) CatalogConnectServiceNodes(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	return s.catalogServiceNodes(resp, req, true)
 }
@@ -184,6 +205,9 @@ func (s *HTTPServer) catalogServiceNodes(resp http.ResponseWriter, req *http.Req
 	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
+	if err := parseLabelSelector(req, &args.QueryOptions); err != nil {
+		return nil, err
+	}
 
 	// Check for a tag
 	params := req.URL.Query()
@@ -251,6 +275,49 @@ func (s *HTTPServer) catalogServiceNodes(resp http.ResponseWriter, req *http.Req
 	return out.ServiceNodes, nil
 }
 
+func (s *HTTPServer) CatalogGatewayServices(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	metrics.IncrCounterWithLabels([]string{"client", "api", "catalog_gateway_services"}, 1,
+		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+
+	// Set default DC
+	args := structs.ServiceSpecificRequest{}
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	// Pull out the gateway name
+	args.ServiceName = strings.TrimPrefix(req.URL.Path, "/v1/catalog/gateway-services/")
+	if args.ServiceName == "" {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Missing gateway name")
+		return nil, nil
+	}
+
+	// Make the RPC request
+	var out structs.IndexedGatewayServices
+	defer setMeta(resp, &out.QueryMeta)
+RETRY_ONCE:
+	if err := s.agent.RPC("Catalog.GatewayServices", &args, &out); err != nil {
+		metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_gateway_services"}, 1,
+			[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+		return nil, err
+	}
+	if args.QueryOptions.AllowStale && args.MaxStaleDuration > 0 && args.MaxStaleDuration < out.LastContact {
+		args.AllowStale = false
+		args.MaxStaleDuration = 0
+		goto RETRY_ONCE
+	}
+	out.ConsistencyLevel = args.QueryOptions.ConsistencyLevel()
+
+	// Use empty list instead of nil
+	if out.Services == nil {
+		out.Services = make(structs.GatewayServices, 0)
+	}
+	metrics.IncrCounterWithLabels([]string{"client", "api", "success", "catalog_gateway_services"}, 1,
+		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
+	return out.Services, nil
+}
+
 func (s *HTTPServer) CatalogNodeServices(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	metrics.IncrCounterWithLabels([]string{"client", "api", "catalog_node_services"}, 1,
 		[]metrics.Label{{Name: "node", Value: s.nodeName()}})
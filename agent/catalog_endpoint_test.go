@@ -1,13 +1,18 @@
 package agent
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/subscribe"
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/hashicorp/serf/coordinate"
@@ -442,6 +447,68 @@ func TestCatalogServices(t *testing.T) {
 	}
 }
 
+
+func TestCatalogServicesStream(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	httpResp, err := http.Get("http://" + a.HTTPAddr() + "/v1/catalog/stream/services")
+	require.NoError(t, err)
+	defer httpResp.Body.Close()
+	require.Equal(t, http.StatusOK, httpResp.StatusCode)
+	require.Equal(t, "text/event-stream", httpResp.Header.Get("Content-Type"))
+
+	events := make(chan *subscribe.Event, 10)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(httpResp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var e subscribe.Event
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e); err != nil {
+				return
+			}
+			events <- &e
+		}
+	}()
+	next := func() *subscribe.Event {
+		select {
+		case e := <-events:
+			require.NotNil(t, e)
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event")
+		}
+		return nil
+	}
+
+	// The consul service is in the initial snapshot.
+	e := next()
+	require.Equal(t, "consul", e.CatalogService.Name)
+	require.True(t, next().EndOfSnapshot)
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "api",
+			Tags:    []string{"a"},
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	e = next()
+	require.Equal(t, subscribe.OpUpsert, e.Op)
+	require.Equal(t, "api", e.CatalogService.Name)
+	require.Equal(t, []string{"a"}, e.CatalogService.Tags)
+}
 func TestCatalogServices_NodeMetaFilter(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -483,6 +550,46 @@ func TestCatalogServices_NodeMetaFilter(t *testing.T) {
 	}
 }
 
+func TestCatalogServices_Filter(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "api",
+			Meta:    map[string]string{"version": "2"},
+		},
+	}
+	var out struct{}
+	if err := a.RPC("Catalog.Register", args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/catalog/services?filter="+url.QueryEscape("ServiceMeta.version == 2"), nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.CatalogServices(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	services := obj.(structs.Services)
+	if _, ok := services["api"]; !ok || len(services) != 1 {
+		t.Fatalf("bad: %v", services)
+	}
+
+	// Invalid filters are rejected before making the RPC
+	req, _ = http.NewRequest("GET", "/v1/catalog/services?filter="+url.QueryEscape("Missing == 2"), nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.CatalogServices(resp, req)
+	if _, ok := err.(BadRequestError); !ok {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestCatalogServiceNodes(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -955,6 +1062,65 @@ func TestCatalogNodeServices_ConnectProxy(t *testing.T) {
 	assert.Equal(structs.ServiceKindConnectProxy, v.Kind)
 }
 
+func TestCatalogGatewayServices(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Unknown gateways front no services
+	req, _ := http.NewRequest("GET", "/v1/catalog/gateway-services/ingress", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.CatalogGatewayServices(resp, req)
+	assert.Nil(err)
+	assert.Len(obj.(structs.GatewayServices), 0)
+
+	// Configure the gateway
+	args := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Op:         structs.ConfigEntryUpsert,
+		Entry: &structs.IngressGatewayConfigEntry{
+			Name: "ingress",
+			Listeners: []structs.IngressListener{
+				{
+					Port:     9191,
+					Protocol: structs.IngressListenerProtocolTCP,
+					Services: []structs.IngressService{{Name: "db"}},
+				},
+				{
+					Port:     8080,
+					Protocol: structs.IngressListenerProtocolHTTP,
+					Services: []structs.IngressService{{Name: "web"}},
+				},
+			},
+		},
+	}
+	var out struct{}
+	assert.Nil(a.RPC("ConfigEntry.Apply", &args, &out))
+
+	req, _ = http.NewRequest("GET", "/v1/catalog/gateway-services/ingress", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.CatalogGatewayServices(resp, req)
+	assert.Nil(err)
+	assertIndex(t, resp)
+
+	services := obj.(structs.GatewayServices)
+	assert.Len(services, 2)
+	assert.Equal("db", services[0].Service)
+	assert.Equal(structs.ServiceKindIngressGateway, services[0].GatewayKind)
+	assert.Equal("web", services[1].Service)
+	assert.Equal(8080, services[1].Port)
+
+	// The gateway name is required
+	req, _ = http.NewRequest("GET", "/v1/catalog/gateway-services/", nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.CatalogGatewayServices(resp, req)
+	assert.Nil(err)
+	assert.Equal(http.StatusBadRequest, resp.Code)
+}
+
 func TestCatalogNodeServices_WanTranslation(t *testing.T) {
 	t.Parallel()
 	a1 := NewTestAgent(t, t.Name(), `
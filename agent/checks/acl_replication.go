@@ -0,0 +1,129 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/consul/types"
+)
+
+// CheckACLReplication is the built-in check of the servers of the secondary
+// datacenters reporting the health of the replication of the ACLs from the
+// primary datacenter. It periodically polls the replication status of the
+// leader and is critical when the replication is not running, or when it has
+// not succeeded within the lag threshold. A replication that keeps retrying
+// after errors is reported as warning until the threshold is crossed.
+type CheckACLReplication struct {
+	CheckID      types.CheckID             // ID of this check
+	RPC          RPC                       // Used to query the leader
+	RPCReq       structs.DCSpecificRequest // Base request
+	Interval     time.Duration             // How often the status is polled
+	LagThreshold time.Duration             // Maximum time since the last success
+	Notify       CheckNotifier             // For updating the check state
+	Logger       *log.Logger
+
+	// start is used as the reference when the replication never succeeded
+	// yet, so that a replication stuck before its first round is caught.
+	start time.Time
+
+	stop     bool
+	stopCh   chan struct{}
+	stopLock sync.Mutex
+}
+
+// Start is used to start the check, runs until Stop()
+func (c *CheckACLReplication) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	c.stop = false
+	c.stopCh = make(chan struct{})
+	c.start = time.Now()
+	go c.run(c.stopCh)
+}
+
+// Stop is used to stop the check.
+func (c *CheckACLReplication) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	if !c.stop {
+		c.stop = true
+		close(c.stopCh)
+	}
+}
+
+// run is invoked in a goroutine until Stop() is called.
+func (c *CheckACLReplication) run(stopCh chan struct{}) {
+	// Get the initial state soon after the start, the leader may not be
+	// known yet when the agent registers the check.
+	initialPauseTime := lib.RandomStagger(c.Interval)
+	next := time.After(initialPauseTime)
+	for {
+		select {
+		case <-next:
+			c.check()
+			next = time.After(c.Interval)
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// check queries the replication status and updates the check state.
+func (c *CheckACLReplication) check() {
+	var status structs.ACLReplicationStatus
+	args := c.RPCReq
+	if err := c.RPC.RPC("ACL.ReplicationStatus", &args, &status); err != nil {
+		c.Logger.Printf("[WARN] agent: Check %q failed to query the ACL replication status: %s", c.CheckID, err)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical,
+			fmt.Sprintf("Failed to query the ACL replication status: %s", err))
+		return
+	}
+
+	health, output := c.evaluate(status, time.Now())
+	c.Notify.UpdateCheck(c.CheckID, health, output)
+}
+
+// evaluate computes the health of the replication from its status.
+func (c *CheckACLReplication) evaluate(status structs.ACLReplicationStatus, now time.Time) (string, string) {
+	if !status.Enabled || !status.Running {
+		return api.HealthCritical, "ACL replication is not running"
+	}
+
+	last := status.LastSuccess
+	if last.IsZero() {
+		last = c.start
+	}
+	lag := now.Sub(last)
+	failing := status.LastError.After(status.LastSuccess)
+
+	switch {
+	case lag > c.LagThreshold && failing:
+		return api.HealthCritical, fmt.Sprintf(
+			"ACL replication from %q is failing, last error at %s, last success at %s",
+			status.SourceDatacenter, formatReplicationTime(status.LastError), formatReplicationTime(status.LastSuccess))
+	case lag > c.LagThreshold:
+		return api.HealthCritical, fmt.Sprintf(
+			"ACL replication from %q did not succeed for more than %s, last success at %s",
+			status.SourceDatacenter, c.LagThreshold, formatReplicationTime(status.LastSuccess))
+	case failing:
+		return api.HealthWarning, fmt.Sprintf(
+			"ACL replication from %q is retrying after an error at %s, last success at %s",
+			status.SourceDatacenter, formatReplicationTime(status.LastError), formatReplicationTime(status.LastSuccess))
+	}
+
+	return api.HealthPassing, fmt.Sprintf(
+		"ACL replication from %q is healthy, last success at %s, replicated index %d",
+		status.SourceDatacenter, formatReplicationTime(status.LastSuccess), status.ReplicatedIndex)
+}
+
+func formatReplicationTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
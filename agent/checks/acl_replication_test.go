@@ -0,0 +1,172 @@
+package checks
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/mock"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/types"
+)
+
+func TestCheckACLReplication_evaluate(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	chk := &CheckACLReplication{
+		LagThreshold: 10 * time.Minute,
+		start:        now.Add(-time.Minute),
+	}
+
+	cases := []struct {
+		name   string
+		status structs.ACLReplicationStatus
+		health string
+		output string
+	}{
+		{
+			name:   "not running",
+			status: structs.ACLReplicationStatus{Enabled: true},
+			health: api.HealthCritical,
+			output: "not running",
+		},
+		{
+			name: "healthy",
+			status: structs.ACLReplicationStatus{
+				Enabled:         true,
+				Running:         true,
+				ReplicatedIndex: 42,
+				LastSuccess:     now.Add(-5 * time.Minute),
+				LastError:       now.Add(-time.Hour),
+			},
+			health: api.HealthPassing,
+			output: "replicated index 42",
+		},
+		{
+			name: "starting",
+			status: structs.ACLReplicationStatus{
+				Enabled: true,
+				Running: true,
+			},
+			health: api.HealthPassing,
+			output: "last success at never",
+		},
+		{
+			name: "retrying",
+			status: structs.ACLReplicationStatus{
+				Enabled:     true,
+				Running:     true,
+				LastSuccess: now.Add(-5 * time.Minute),
+				LastError:   now.Add(-time.Minute),
+			},
+			health: api.HealthWarning,
+			output: "retrying",
+		},
+		{
+			name: "failing",
+			status: structs.ACLReplicationStatus{
+				Enabled:     true,
+				Running:     true,
+				LastSuccess: now.Add(-time.Hour),
+				LastError:   now.Add(-time.Minute),
+			},
+			health: api.HealthCritical,
+			output: "is failing",
+		},
+		{
+			name: "lagging",
+			status: structs.ACLReplicationStatus{
+				Enabled:     true,
+				Running:     true,
+				LastSuccess: now.Add(-time.Hour),
+			},
+			health: api.HealthCritical,
+			output: "did not succeed for more than 10m0s",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			health, output := chk.evaluate(tc.status, now)
+			if health != tc.health {
+				t.Fatalf("got health %q want %q (%s)", health, tc.health, output)
+			}
+			if !strings.Contains(output, tc.output) {
+				t.Fatalf("got output %q want it to contain %q", output, tc.output)
+			}
+		})
+	}
+
+	// A replication that never succeeded is critical once the threshold
+	// passed since the start of the check.
+	chk.start = now.Add(-time.Hour)
+	health, _ := chk.evaluate(structs.ACLReplicationStatus{Enabled: true, Running: true}, now)
+	if health != api.HealthCritical {
+		t.Fatalf("got health %q want %q", health, api.HealthCritical)
+	}
+}
+
+func TestCheckACLReplication(t *testing.T) {
+	t.Parallel()
+
+	notify := mock.NewNotify()
+	chkID := types.CheckID("acl")
+	rpc := &mockRPC{}
+	chk := &CheckACLReplication{
+		CheckID:      chkID,
+		RPC:          rpc,
+		RPCReq:       structs.DCSpecificRequest{Datacenter: "dc2"},
+		Interval:     10 * time.Millisecond,
+		LagThreshold: time.Minute,
+		Notify:       notify,
+		Logger:       log.New(os.Stderr, "", log.LstdFlags),
+	}
+
+	rpc.Reply.Store(structs.ACLReplicationStatus{
+		Enabled:          true,
+		Running:          true,
+		SourceDatacenter: "dc1",
+		LastSuccess:      time.Now(),
+	})
+
+	chk.Start()
+	defer chk.Stop()
+
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notify.State(chkID), api.HealthPassing; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+	})
+	if args := rpc.Args.Load().(*structs.DCSpecificRequest); args.Datacenter != "dc2" {
+		t.Fatalf("bad: %#v", args)
+	}
+
+	// Failing to reach the leader is critical
+	chk.Stop()
+	rpc = &mockRPC{}
+	rpc.Reply.Store(fmt.Errorf("No cluster leader"))
+	chk = &CheckACLReplication{
+		CheckID:      chkID,
+		RPC:          rpc,
+		Interval:     10 * time.Millisecond,
+		LagThreshold: time.Minute,
+		Notify:       notify,
+		Logger:       log.New(os.Stderr, "", log.LstdFlags),
+	}
+	chk.Start()
+	defer chk.Stop()
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notify.State(chkID), api.HealthCritical; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+		if got := notify.Output(chkID); !strings.Contains(got, "No cluster leader") {
+			r.Fatalf("bad output: %q", got)
+		}
+	})
+}
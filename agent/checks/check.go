@@ -304,6 +304,11 @@ type CheckHTTP struct {
 	Logger          *log.Logger
 	TLSClientConfig *tls.Config
 
+	// ProxyHTTP is the URL of the path of the check exposed through the
+	// Connect proxy of the service. The check is run against it instead of
+	// HTTP if set.
+	ProxyHTTP string
+
 	httpClient *http.Client
 	stop       bool
 	stopCh     chan struct{}
@@ -379,7 +384,12 @@ func (c *CheckHTTP) check() {
 		method = "GET"
 	}
 
-	req, err := http.NewRequest(method, c.HTTP, nil)
+	target := c.HTTP
+	if c.ProxyHTTP != "" {
+		target = c.ProxyHTTP
+	}
+
+	req, err := http.NewRequest(method, target, nil)
 	if err != nil {
 		c.Logger.Printf("[WARN] agent: Check %q HTTP request failed: %s", c.CheckID, err)
 		c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
@@ -419,7 +429,7 @@ func (c *CheckHTTP) check() {
 	}
 
 	// Format the response body
-	result := fmt.Sprintf("HTTP %s %s: %s Output: %s", method, c.HTTP, resp.Status, output.String())
+	result := fmt.Sprintf("HTTP %s %s: %s Output: %s", method, target, resp.Status, output.String())
 
 	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
 		// PASSING (2xx)
@@ -442,15 +452,17 @@ func (c *CheckHTTP) check() {
 
 // CheckTCP is used to periodically make an TCP/UDP connection to
 // determine the health of a given check.
-// The check is passing if the connection succeeds
+// The check is passing if the connection succeeds, including the TLS
+// handshake if a TLS client config is provided
 // The check is critical if the connection returns an error
 type CheckTCP struct {
-	Notify   CheckNotifier
-	CheckID  types.CheckID
-	TCP      string
-	Interval time.Duration
-	Timeout  time.Duration
-	Logger   *log.Logger
+	Notify          CheckNotifier
+	CheckID         types.CheckID
+	TCP             string
+	Interval        time.Duration
+	Timeout         time.Duration
+	Logger          *log.Logger
+	TLSClientConfig *tls.Config
 
 	dialer   *net.Dialer
 	stop     bool
@@ -511,6 +523,19 @@ func (c *CheckTCP) run() {
 
 // check is invoked periodically to perform the TCP check
 func (c *CheckTCP) check() {
+	if c.TLSClientConfig != nil {
+		conn, err := tls.DialWithDialer(c.dialer, `tcp`, c.TCP, c.TLSClientConfig)
+		if err != nil {
+			c.Logger.Printf("[WARN] agent: Check %q TLS connection failed: %s", c.CheckID, err)
+			c.Notify.UpdateCheck(c.CheckID, api.HealthCritical, err.Error())
+			return
+		}
+		conn.Close()
+		c.Logger.Printf("[DEBUG] agent: Check %q is passing", c.CheckID)
+		c.Notify.UpdateCheck(c.CheckID, api.HealthPassing, fmt.Sprintf("TCP+TLS connect %s: Success", c.TCP))
+		return
+	}
+
 	conn, err := c.dialer.Dial(`tcp`, c.TCP)
 	if err != nil {
 		c.Logger.Printf("[WARN] agent: Check %q socket connection failed: %s", c.CheckID, err)
@@ -2,6 +2,7 @@ package checks
 
 import (
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -352,6 +353,37 @@ func TestCheckHTTPTimeout(t *testing.T) {
 	})
 }
 
+func TestCheckHTTP_ProxiedPath(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	notif := mock.NewNotify()
+	check := &CheckHTTP{
+		Notify:    notif,
+		CheckID:   types.CheckID("proxied"),
+		HTTP:      "http://127.0.0.1:1/health",
+		ProxyHTTP: server.URL + "/health",
+		Interval:  10 * time.Millisecond,
+		Logger:    log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+	}
+
+	check.Start()
+	defer check.Stop()
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notif.State("proxied"), api.HealthPassing; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+		if !strings.Contains(notif.Output("proxied"), server.URL+"/health") {
+			r.Fatalf("bad: %v", notif.Output("proxied"))
+		}
+	})
+}
+
 func TestCheckHTTP_disablesKeepAlives(t *testing.T) {
 	t.Parallel()
 	check := &CheckHTTP{
@@ -525,6 +557,52 @@ func TestCheckTCPPassing(t *testing.T) {
 	tcpServer.Close()
 }
 
+func TestCheckTCP_TLS(t *testing.T) {
+	t.Parallel()
+	server := httptest.NewTLSServer(largeBodyHandler(200))
+	defer server.Close()
+	addr := server.Listener.Addr().String()
+
+	notif := mock.NewNotify()
+	check := &CheckTCP{
+		Notify:          notif,
+		CheckID:         types.CheckID("skipverify_true"),
+		TCP:             addr,
+		Interval:        25 * time.Millisecond,
+		Logger:          log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	}
+	check.Start()
+	defer check.Stop()
+
+	check2 := &CheckTCP{
+		Notify:          notif,
+		CheckID:         types.CheckID("skipverify_false"),
+		TCP:             addr,
+		Interval:        25 * time.Millisecond,
+		Logger:          log.New(ioutil.Discard, uniqueID(), log.LstdFlags),
+		TLSClientConfig: &tls.Config{},
+	}
+	check2.Start()
+	defer check2.Stop()
+
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notif.State("skipverify_true"), api.HealthPassing; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+		if got, want := notif.Output("skipverify_true"), "TCP+TLS connect "+addr+": Success"; got != want {
+			r.Fatalf("got output %q want %q", got, want)
+		}
+		// This should fail due to an invalid SSL cert
+		if got, want := notif.State("skipverify_false"), api.HealthCritical; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+		if !strings.Contains(notif.Output("skipverify_false"), "certificate signed by unknown authority") {
+			r.Fatalf("should fail with certificate error %v", notif.OutputMap())
+		}
+	})
+}
+
 func TestCheck_Docker(t *testing.T) {
 	tests := []struct {
 		desc     string
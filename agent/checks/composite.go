@@ -0,0 +1,342 @@
+package checks
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/types"
+)
+
+// CheckComposite is a check type whose status is computed from a boolean
+// expression over other checks registered with the local agent, for example
+// "db AND (cache1 OR cache2)".
+//
+// The expression is evaluated using the three health states rather than
+// plain booleans: AND takes the worst status of its operands, OR takes the
+// best, and NOT swaps passing and critical while leaving warning untouched.
+// Checks that are referenced but not registered are treated as critical.
+type CheckComposite struct {
+	CheckID types.CheckID     // ID of this check
+	Expr    *CompositeExpr    // Parsed expression to evaluate
+	Notify  CompositeNotifier // For updating the check state
+
+	stop     bool
+	stopCh   chan struct{}
+	stopLock sync.Mutex
+}
+
+// CompositeNotifier is a CheckNotifier specifically for the Composite check.
+// This requires additional methods that are satisfied by the agent local
+// state.
+type CompositeNotifier interface {
+	CheckNotifier
+
+	AddCompositeCheck(types.CheckID, []types.CheckID, chan<- struct{}) error
+	RemoveCompositeCheck(types.CheckID, []types.CheckID)
+	Checks() map[types.CheckID]*structs.HealthCheck
+}
+
+// Start is used to start the check, runs until Stop()
+func (c *CheckComposite) Start() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	c.stop = false
+	c.stopCh = make(chan struct{})
+	go c.run(c.stopCh)
+}
+
+// Stop is used to stop the check.
+func (c *CheckComposite) Stop() {
+	c.stopLock.Lock()
+	defer c.stopLock.Unlock()
+	if !c.stop {
+		c.stop = true
+		close(c.stopCh)
+	}
+}
+
+// run is invoked in a goroutine until Stop() is called.
+func (c *CheckComposite) run(stopCh chan struct{}) {
+	// Buffered as 1 for the same reason as the alias check: any queued
+	// notification causes the full local check state to be reloaded so
+	// a single pending update is enough to never lose a change.
+	notifyCh := make(chan struct{}, 1)
+	refs := c.Expr.CheckIDs()
+	c.Notify.AddCompositeCheck(c.CheckID, refs, notifyCh)
+	defer c.Notify.RemoveCompositeCheck(c.CheckID, refs)
+
+	// Immediately run to get the current state of the referenced checks
+	c.processChecks(c.Notify.Checks())
+
+	for {
+		select {
+		case <-notifyCh:
+			c.processChecks(c.Notify.Checks())
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// processChecks evaluates the expression against the given checks and
+// updates the state of the composite check.
+func (c *CheckComposite) processChecks(checks map[types.CheckID]*structs.HealthCheck) {
+	status := c.Expr.Eval(checks)
+
+	var failing []string
+	for _, id := range c.Expr.CheckIDs() {
+		chk, ok := checks[id]
+		switch {
+		case !ok:
+			failing = append(failing, fmt.Sprintf("%q (missing)", id))
+		case chk.Status != api.HealthPassing:
+			failing = append(failing, fmt.Sprintf("%q (%s)", id, chk.Status))
+		}
+	}
+
+	msg := fmt.Sprintf("Composite expression %q is %s.", c.Expr.String(), status)
+	if len(failing) > 0 {
+		msg += " Non-passing checks: " + strings.Join(failing, ", ")
+	}
+	c.Notify.UpdateCheck(c.CheckID, status, msg)
+}
+
+// CompositeExpr is a parsed composite check expression.
+type CompositeExpr struct {
+	raw  string
+	root compositeNode
+}
+
+// ParseCompositeExpr parses a composite check expression. The grammar is:
+//
+//   expr   = term { "OR" term }
+//   term   = factor { "AND" factor }
+//   factor = "NOT" factor | "(" expr ")" | checkID
+//
+// Operators are case-insensitive. A check ID is any run of characters that
+// are not whitespace or parentheses.
+func ParseCompositeExpr(s string) (*CompositeExpr, error) {
+	p := &compositeParser{tokens: tokenizeComposite(s)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("composite expression is empty")
+	}
+
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q in composite expression", tok)
+	}
+	return &CompositeExpr{raw: s, root: root}, nil
+}
+
+// String returns the expression as originally given.
+func (e *CompositeExpr) String() string {
+	return e.raw
+}
+
+// CheckIDs returns the sorted, de-duplicated set of check IDs referenced by
+// the expression.
+func (e *CompositeExpr) CheckIDs() []types.CheckID {
+	seen := make(map[types.CheckID]struct{})
+	e.root.collect(seen)
+
+	ids := make([]types.CheckID, 0, len(seen))
+	for id := range seen {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// Eval computes the health status of the expression given the current set
+// of checks.
+func (e *CompositeExpr) Eval(checks map[types.CheckID]*structs.HealthCheck) string {
+	return e.root.eval(checks)
+}
+
+// compositeNode is a node of the expression tree.
+type compositeNode interface {
+	eval(map[types.CheckID]*structs.HealthCheck) string
+	collect(map[types.CheckID]struct{})
+}
+
+type compositeRef types.CheckID
+
+func (n compositeRef) eval(checks map[types.CheckID]*structs.HealthCheck) string {
+	chk, ok := checks[types.CheckID(n)]
+	if !ok {
+		return api.HealthCritical
+	}
+	switch chk.Status {
+	case api.HealthPassing, api.HealthWarning:
+		return chk.Status
+	default:
+		return api.HealthCritical
+	}
+}
+
+func (n compositeRef) collect(m map[types.CheckID]struct{}) {
+	m[types.CheckID(n)] = struct{}{}
+}
+
+type compositeNot struct {
+	operand compositeNode
+}
+
+func (n *compositeNot) eval(checks map[types.CheckID]*structs.HealthCheck) string {
+	switch n.operand.eval(checks) {
+	case api.HealthPassing:
+		return api.HealthCritical
+	case api.HealthCritical:
+		return api.HealthPassing
+	default:
+		return api.HealthWarning
+	}
+}
+
+func (n *compositeNot) collect(m map[types.CheckID]struct{}) {
+	n.operand.collect(m)
+}
+
+type compositeBinary struct {
+	and         bool
+	left, right compositeNode
+}
+
+func (n *compositeBinary) eval(checks map[types.CheckID]*structs.HealthCheck) string {
+	l, r := compositeRank(n.left.eval(checks)), compositeRank(n.right.eval(checks))
+	if (n.and && r < l) || (!n.and && r > l) {
+		l = r
+	}
+	return compositeStatuses[l]
+}
+
+func (n *compositeBinary) collect(m map[types.CheckID]struct{}) {
+	n.left.collect(m)
+	n.right.collect(m)
+}
+
+// compositeStatuses orders the health states from worst to best.
+var compositeStatuses = []string{api.HealthCritical, api.HealthWarning, api.HealthPassing}
+
+func compositeRank(status string) int {
+	for i, s := range compositeStatuses {
+		if s == status {
+			return i
+		}
+	}
+	return 0
+}
+
+func tokenizeComposite(s string) []string {
+	var tokens []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for _, r := range s {
+		switch {
+		case unicode.IsSpace(r):
+			flush()
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+type compositeParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *compositeParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *compositeParser) accept(op string) bool {
+	if tok, ok := p.peek(); ok && strings.EqualFold(tok, op) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *compositeParser) parseExpr() (compositeNode, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = &compositeBinary{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *compositeParser) parseTerm() (compositeNode, error) {
+	left, err := p.parseFactor()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		left = &compositeBinary{and: true, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *compositeParser) parseFactor() (compositeNode, error) {
+	if p.accept("NOT") {
+		operand, err := p.parseFactor()
+		if err != nil {
+			return nil, err
+		}
+		return &compositeNot{operand: operand}, nil
+	}
+
+	if p.accept("(") {
+		node, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing closing parenthesis in composite expression")
+		}
+		return node, nil
+	}
+
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("unexpected end of composite expression")
+	}
+	switch strings.ToUpper(tok) {
+	case "AND", "OR", ")":
+		return nil, fmt.Errorf("unexpected %q in composite expression", tok)
+	}
+	p.pos++
+	return compositeRef(tok), nil
+}
@@ -0,0 +1,186 @@
+package checks
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/mock"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/types"
+)
+
+func TestParseCompositeExpr(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		expr string
+		ids  []types.CheckID
+		err  bool
+	}{
+		{"db", []types.CheckID{"db"}, false},
+		{"db AND (cache1 OR cache2)", []types.CheckID{"cache1", "cache2", "db"}, false},
+		{"db and not service:web", []types.CheckID{"db", "service:web"}, false},
+		{"(a OR b) AND a", []types.CheckID{"a", "b"}, false},
+		{"", nil, true},
+		{"db AND", nil, true},
+		{"AND db", nil, true},
+		{"(db OR cache", nil, true},
+		{"db cache", nil, true},
+		{"db)", nil, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			expr, err := ParseCompositeExpr(tc.expr)
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error for %q", tc.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if got := expr.CheckIDs(); !reflect.DeepEqual(got, tc.ids) {
+				t.Fatalf("got %v want %v", got, tc.ids)
+			}
+		})
+	}
+}
+
+func TestCompositeExpr_Eval(t *testing.T) {
+	t.Parallel()
+
+	checks := map[types.CheckID]*structs.HealthCheck{
+		"pass": &structs.HealthCheck{CheckID: "pass", Status: api.HealthPassing},
+		"warn": &structs.HealthCheck{CheckID: "warn", Status: api.HealthWarning},
+		"crit": &structs.HealthCheck{CheckID: "crit", Status: api.HealthCritical},
+	}
+
+	cases := []struct {
+		expr string
+		want string
+	}{
+		{"pass", api.HealthPassing},
+		{"missing", api.HealthCritical},
+		{"pass AND warn", api.HealthWarning},
+		{"pass AND crit", api.HealthCritical},
+		{"crit OR warn", api.HealthWarning},
+		{"crit OR pass", api.HealthPassing},
+		{"NOT crit", api.HealthPassing},
+		{"NOT pass", api.HealthCritical},
+		{"NOT warn", api.HealthWarning},
+		{"pass AND (crit OR warn)", api.HealthWarning},
+		{"crit OR pass AND warn", api.HealthWarning},
+		{"(crit OR pass) AND pass", api.HealthPassing},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expr, func(t *testing.T) {
+			expr, err := ParseCompositeExpr(tc.expr)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if got := expr.Eval(checks); got != tc.want {
+				t.Fatalf("got %q want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// The composite check should re-evaluate whenever it is notified.
+func TestCheckComposite_notify(t *testing.T) {
+	t.Parallel()
+
+	expr, err := ParseCompositeExpr("db AND (cache1 OR cache2)")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	notify := newMockCompositeNotify()
+	chkID := types.CheckID("composite")
+	chk := &CheckComposite{
+		CheckID: chkID,
+		Expr:    expr,
+		Notify:  notify,
+	}
+
+	notify.setStatus("db", api.HealthPassing)
+	notify.setStatus("cache1", api.HealthCritical)
+
+	chk.Start()
+	defer chk.Stop()
+
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notify.State(chkID), api.HealthCritical; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+	})
+
+	notify.setStatus("cache2", api.HealthPassing)
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notify.State(chkID), api.HealthPassing; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+	})
+
+	notify.setStatus("db", api.HealthWarning)
+	retry.Run(t, func(r *retry.R) {
+		if got, want := notify.State(chkID), api.HealthWarning; got != want {
+			r.Fatalf("got state %q want %q", got, want)
+		}
+	})
+}
+
+type mockCompositeNotify struct {
+	*mock.Notify
+
+	lock     sync.Mutex
+	checks   map[types.CheckID]*structs.HealthCheck
+	notifyCh chan<- struct{}
+}
+
+func newMockCompositeNotify() *mockCompositeNotify {
+	return &mockCompositeNotify{
+		Notify: mock.NewNotify(),
+		checks: make(map[types.CheckID]*structs.HealthCheck),
+	}
+}
+
+func (m *mockCompositeNotify) AddCompositeCheck(chkID types.CheckID, ids []types.CheckID, ch chan<- struct{}) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.notifyCh = ch
+	return nil
+}
+
+func (m *mockCompositeNotify) RemoveCompositeCheck(chkID types.CheckID, ids []types.CheckID) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.notifyCh = nil
+}
+
+func (m *mockCompositeNotify) Checks() map[types.CheckID]*structs.HealthCheck {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	out := make(map[types.CheckID]*structs.HealthCheck, len(m.checks))
+	for id, chk := range m.checks {
+		out[id] = chk
+	}
+	return out
+}
+
+func (m *mockCompositeNotify) setStatus(id types.CheckID, status string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.checks[id] = &structs.HealthCheck{CheckID: id, Status: status}
+	if m.notifyCh != nil {
+		select {
+		case m.notifyCh <- struct{}{}:
+		default:
+		}
+	}
+}
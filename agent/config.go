@@ -27,6 +27,11 @@ func FixupCheckType(raw interface{}) error {
 		"deregister_critical_service_after": "DeregisterCriticalServiceAfter",
 		"docker_container_id":               "DockerContainerID",
 		"tls_skip_verify":                   "TLSSkipVerify",
+		"tls_server_name":                   "TLSServerName",
+		"tls_ca_file":                       "TLSCAFile",
+		"tls_cert_file":                     "TLSCertFile",
+		"tls_key_file":                      "TLSKeyFile",
+		"tcp_use_tls":                       "TCPUseTLS",
 		"service_id":                        "ServiceID",
 	})
 
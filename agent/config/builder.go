@@ -363,6 +363,8 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 	proxyMaxPort := b.portVal("ports.proxy_max_port", c.Ports.ProxyMaxPort)
 	sidecarMinPort := b.portVal("ports.sidecar_min_port", c.Ports.SidecarMinPort)
 	sidecarMaxPort := b.portVal("ports.sidecar_max_port", c.Ports.SidecarMaxPort)
+	exposeMinPort := b.portVal("ports.expose_min_port", c.Ports.ExposeMinPort)
+	exposeMaxPort := b.portVal("ports.expose_max_port", c.Ports.ExposeMaxPort)
 	if proxyMaxPort < proxyMinPort {
 		return RuntimeConfig{}, fmt.Errorf(
 			"proxy_min_port must be less than proxy_max_port. To disable, set both to zero.")
@@ -371,6 +373,10 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		return RuntimeConfig{}, fmt.Errorf(
 			"sidecar_min_port must be less than sidecar_max_port. To disable, set both to zero.")
 	}
+	if exposeMaxPort < exposeMinPort {
+		return RuntimeConfig{}, fmt.Errorf(
+			"expose_min_port must be less than expose_max_port. To disable, set both to zero.")
+	}
 
 	// determine the default bind and advertise address
 	//
@@ -576,9 +582,11 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 			"tls_skip_verify":       "TLSSkipVerify",
 
 			// Common CA config
-			"leaf_cert_ttl":      "LeafCertTTL",
-			"csr_max_per_second": "CSRMaxPerSecond",
-			"csr_max_concurrent": "CSRMaxConcurrent",
+			"leaf_cert_ttl":         "LeafCertTTL",
+			"csr_max_per_second":    "CSRMaxPerSecond",
+			"csr_max_concurrent":    "CSRMaxConcurrent",
+			"trust_domain":          "TrustDomain",
+			"leaf_cert_dns_domains": "LeafCertDNSDomains",
 		})
 	}
 
@@ -674,21 +682,24 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		GossipWANRetransmitMult: b.intVal(c.GossipWAN.RetransmitMult),
 
 		// ACL
-		ACLEnforceVersion8:        b.boolValWithDefault(c.ACLEnforceVersion8, true),
-		ACLsEnabled:               aclsEnabled,
-		ACLAgentMasterToken:       b.stringValWithDefault(c.ACL.Tokens.AgentMaster, b.stringVal(c.ACLAgentMasterToken)),
-		ACLAgentToken:             b.stringValWithDefault(c.ACL.Tokens.Agent, b.stringVal(c.ACLAgentToken)),
-		ACLDatacenter:             aclDC,
-		ACLDefaultPolicy:          b.stringValWithDefault(c.ACL.DefaultPolicy, b.stringVal(c.ACLDefaultPolicy)),
-		ACLDownPolicy:             b.stringValWithDefault(c.ACL.DownPolicy, b.stringVal(c.ACLDownPolicy)),
-		ACLEnableKeyListPolicy:    b.boolValWithDefault(c.ACL.EnableKeyListPolicy, b.boolVal(c.ACLEnableKeyListPolicy)),
-		ACLMasterToken:            b.stringValWithDefault(c.ACL.Tokens.Master, b.stringVal(c.ACLMasterToken)),
-		ACLReplicationToken:       b.stringValWithDefault(c.ACL.Tokens.Replication, b.stringVal(c.ACLReplicationToken)),
-		ACLTokenTTL:               b.durationValWithDefault("acl.token_ttl", c.ACL.TokenTTL, b.durationVal("acl_ttl", c.ACLTTL)),
-		ACLPolicyTTL:              b.durationVal("acl.policy_ttl", c.ACL.PolicyTTL),
-		ACLToken:                  b.stringValWithDefault(c.ACL.Tokens.Default, b.stringVal(c.ACLToken)),
-		ACLTokenReplication:       b.boolValWithDefault(c.ACL.TokenReplication, b.boolValWithDefault(c.EnableACLReplication, enableTokenReplication)),
-		ACLEnableTokenPersistence: b.boolValWithDefault(c.ACL.EnableTokenPersistence, false),
+		ACLEnforceVersion8:         b.boolValWithDefault(c.ACLEnforceVersion8, true),
+		ACLsEnabled:                aclsEnabled,
+		ACLAgentMasterToken:        b.stringValWithDefault(c.ACL.Tokens.AgentMaster, b.stringVal(c.ACLAgentMasterToken)),
+		ACLAgentToken:              b.stringValWithDefault(c.ACL.Tokens.Agent, b.stringVal(c.ACLAgentToken)),
+		ACLDatacenter:              aclDC,
+		ACLDefaultPolicy:           b.stringValWithDefault(c.ACL.DefaultPolicy, b.stringVal(c.ACLDefaultPolicy)),
+		ACLDownPolicy:              b.stringValWithDefault(c.ACL.DownPolicy, b.stringVal(c.ACLDownPolicy)),
+		ACLEnableKeyListPolicy:     b.boolValWithDefault(c.ACL.EnableKeyListPolicy, b.boolVal(c.ACLEnableKeyListPolicy)),
+		ACLEnableServiceTokens:     b.boolVal(c.ACL.EnableServiceTokens),
+		ACLMasterToken:             b.stringValWithDefault(c.ACL.Tokens.Master, b.stringVal(c.ACLMasterToken)),
+		ACLReplicationToken:        b.stringValWithDefault(c.ACL.Tokens.Replication, b.stringVal(c.ACLReplicationToken)),
+		ACLTokenTTL:                b.durationValWithDefault("acl.token_ttl", c.ACL.TokenTTL, b.durationVal("acl_ttl", c.ACLTTL)),
+		ACLPolicyTTL:               b.durationVal("acl.policy_ttl", c.ACL.PolicyTTL),
+		ACLReplicationLagThreshold: b.durationVal("acl.replication_lag_threshold", c.ACL.ReplicationLagThreshold),
+		ACLFilterMemoSize:          b.intVal(c.Performance.ACLFilterMemoSize),
+		ACLToken:                   b.stringValWithDefault(c.ACL.Tokens.Default, b.stringVal(c.ACLToken)),
+		ACLTokenReplication:        b.boolValWithDefault(c.ACL.TokenReplication, b.boolValWithDefault(c.EnableACLReplication, enableTokenReplication)),
+		ACLEnableTokenPersistence:  b.boolValWithDefault(c.ACL.EnableTokenPersistence, false),
 
 		// Autopilot
 		AutopilotCleanupDeadServers:      b.boolVal(c.Autopilot.CleanupDeadServers),
@@ -699,6 +710,22 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		AutopilotServerStabilizationTime: b.durationVal("autopilot.server_stabilization_time", c.Autopilot.ServerStabilizationTime),
 		AutopilotUpgradeVersionTag:       b.stringVal(c.Autopilot.UpgradeVersionTag),
 
+		// Auto-Config
+		AutoConfigEnabled:                   b.boolVal(c.AutoConfig.Enabled),
+		AutoConfigIntroToken:                b.stringVal(c.AutoConfig.IntroToken),
+		AutoConfigIntroTokenFile:            b.stringVal(c.AutoConfig.IntroTokenFile),
+		AutoConfigServerAddresses:           b.expandAllOptionalAddrs("auto_config.server_addresses", c.AutoConfig.ServerAddresses),
+		AutoConfigAuthzEnabled:              b.boolVal(c.AutoConfig.Authorization.Enabled),
+		AutoConfigAuthzAgentTokenPolicies:   c.AutoConfig.Authorization.AgentTokenPolicies,
+		AutoConfigAuthzJWTValidationPubKeys: c.AutoConfig.Authorization.Static.JWTValidationPubKeys,
+		AutoConfigAuthzBoundIssuer:          b.stringVal(c.AutoConfig.Authorization.Static.BoundIssuer),
+		AutoConfigAuthzBoundAudiences:       c.AutoConfig.Authorization.Static.BoundAudiences,
+		AutoConfigAuthzClaimAssertions:      c.AutoConfig.Authorization.Static.ClaimAssertions,
+
+		// Auto-Encrypt
+		AutoEncryptTLS:      b.boolVal(c.AutoEncrypt.TLS),
+		AutoEncryptAllowTLS: b.boolVal(c.AutoEncrypt.AllowTLS),
+
 		// DNS
 		DNSAddrs:              dnsAddrs,
 		DNSAllowStale:         b.boolVal(c.DNS.AllowStale),
@@ -724,9 +751,15 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		HTTPSPort:           httpsPort,
 		HTTPAddrs:           httpAddrs,
 		HTTPSAddrs:          httpsAddrs,
-		HTTPBlockEndpoints:  c.HTTPConfig.BlockEndpoints,
-		HTTPResponseHeaders: c.HTTPConfig.ResponseHeaders,
-		AllowWriteHTTPFrom:  b.cidrsVal("allow_write_http_from", c.HTTPConfig.AllowWriteHTTPFrom),
+		HTTPBlockEndpoints:       c.HTTPConfig.BlockEndpoints,
+		HTTPResponseHeaders:      c.HTTPConfig.ResponseHeaders,
+		HTTPEndpointProfile:      b.stringVal(c.HTTPConfig.EndpointProfile),
+		HTTPAllowedEndpoints:     b.allowedEndpointsVal(c.HTTPConfig.EndpointProfile, c.HTTPConfig.AllowedEndpoints),
+		HTTPCORSAllowedOrigins:   c.HTTPConfig.CORSAllowedOrigins,
+		HTTPCORSAllowedHeaders:   c.HTTPConfig.CORSAllowedHeaders,
+		HTTPCORSAllowCredentials: b.boolVal(c.HTTPConfig.CORSAllowCredentials),
+		HTTPCORSMaxAge:           b.durationVal("http_config.cors_max_age", c.HTTPConfig.CORSMaxAge),
+		AllowWriteHTTPFrom:       b.cidrsVal("allow_write_http_from", c.HTTPConfig.AllowWriteHTTPFrom),
 
 		// Telemetry
 		Telemetry: lib.TelemetryConfig{
@@ -758,6 +791,8 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		// Agent
 		AdvertiseAddrLAN:                        advertiseAddrLAN,
 		AdvertiseAddrWAN:                        advertiseAddrWAN,
+		APIBootstrapFile:                        b.stringVal(c.APIBootstrapFile),
+		APIBootstrapTokenFile:                   b.stringVal(c.APIBootstrapTokenFile),
 		BindAddr:                                bindAddr,
 		Bootstrap:                               b.boolVal(c.Bootstrap),
 		BootstrapExpect:                         b.intVal(c.BootstrapExpect),
@@ -770,12 +805,15 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		ConnectEnabled:                          connectEnabled,
 		ConnectCAProvider:                       connectCAProvider,
 		ConnectCAConfig:                         connectCAConfig,
+		ConnectIntentionSuggestions:             b.boolVal(c.Connect.IntentionSuggestions),
 		ConnectProxyAllowManagedRoot:            b.boolVal(c.Connect.Proxy.AllowManagedRoot),
 		ConnectProxyAllowManagedAPIRegistration: b.boolVal(c.Connect.Proxy.AllowManagedAPIRegistration),
 		ConnectProxyBindMinPort:                 proxyMinPort,
 		ConnectProxyBindMaxPort:                 proxyMaxPort,
 		ConnectSidecarMinPort:                   sidecarMinPort,
 		ConnectSidecarMaxPort:                   sidecarMaxPort,
+		ExposeMinPort:                           exposeMinPort,
+		ExposeMaxPort:                           exposeMaxPort,
 		ConnectProxyDefaultExecMode:             proxyDefaultExecMode,
 		ConnectProxyDefaultDaemonCommand:        proxyDefaultDaemonCommand,
 		ConnectProxyDefaultScriptCommand:        proxyDefaultScriptCommand,
@@ -799,14 +837,20 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		EnableSyslog:                            b.boolVal(c.EnableSyslog),
 		EnableUI:                                b.boolVal(c.UI),
 		EncryptKey:                              b.stringVal(c.EncryptKey),
+		EncryptRotationInterval:                 b.durationVal("encrypt_rotation_interval", c.EncryptRotationInterval),
 		EncryptVerifyIncoming:                   b.boolVal(c.EncryptVerifyIncoming),
 		EncryptVerifyOutgoing:                   b.boolVal(c.EncryptVerifyOutgoing),
+		GCBallastBytes:                          b.intVal(c.Performance.GCBallastBytes),
+		GCMemoryLimitBytes:                      b.intVal(c.Performance.GCMemoryLimitBytes),
+		GCPercent:                               b.intVal(c.Performance.GCPercent),
 		GRPCPort:                                grpcPort,
 		GRPCAddrs:                               grpcAddrs,
 		KeyFile:                                 b.stringVal(c.KeyFile),
 		LeaveDrainTime:                          b.durationVal("performance.leave_drain_time", c.Performance.LeaveDrainTime),
 		LeaveOnTerm:                             leaveOnTerm,
+		LogJSON:                                 b.boolVal(c.LogJSON),
 		LogLevel:                                b.stringVal(c.LogLevel),
+		LogSubsystemLevels:                      c.LogSubsystemLevels,
 		LogFile:                                 b.stringVal(c.LogFile),
 		LogRotateBytes:                          b.intVal(c.LogRotateBytes),
 		LogRotateDuration:                       b.durationVal("log_rotate_duration", c.LogRotateDuration),
@@ -822,6 +866,9 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		RPCMaxBurst:                             b.intVal(c.Limits.RPCMaxBurst),
 		RPCProtocol:                             b.intVal(c.RPCProtocol),
 		RPCRateLimit:                            rate.Limit(b.float64Val(c.Limits.RPCRate)),
+		RaftApplyBatchAdaptive:                  b.boolVal(c.Performance.RaftApplyBatchAdaptive),
+		RaftApplyBatchMaxLatency:                b.durationVal("performance.raft_apply_batch_max_latency", c.Performance.RaftApplyBatchMaxLatency),
+		RaftApplyBatchMaxSize:                   b.intVal(c.Performance.RaftApplyBatchMaxSize),
 		RaftProtocol:                            b.intVal(c.RaftProtocol),
 		RaftSnapshotThreshold:                   b.intVal(c.RaftSnapshotThreshold),
 		RaftSnapshotInterval:                    b.durationVal("raft_snapshot_interval", c.RaftSnapshotInterval),
@@ -854,12 +901,14 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		TLSCipherSuites:                         b.tlsCipherSuites("tls_cipher_suites", c.TLSCipherSuites),
 		TLSMinVersion:                           b.stringVal(c.TLSMinVersion),
 		TLSPreferServerCipherSuites:             b.boolVal(c.TLSPreferServerCipherSuites),
+		TLSReloadInterval:                       b.durationVal("tls_reload_interval", c.TLSReloadInterval),
 		TaggedAddresses:                         c.TaggedAddresses,
 		TranslateWANAddrs:                       b.boolVal(c.TranslateWANAddrs),
 		UIDir:                                   b.stringVal(c.UIDir),
 		UnixSocketGroup:                         b.stringVal(c.UnixSocket.Group),
 		UnixSocketMode:                          b.stringVal(c.UnixSocket.Mode),
 		UnixSocketUser:                          b.stringVal(c.UnixSocket.User),
+		UseStreamingBackend:                     b.boolVal(c.UseStreamingBackend),
 		VerifyIncoming:                          b.boolVal(c.VerifyIncoming),
 		VerifyIncomingHTTPS:                     b.boolVal(c.VerifyIncomingHTTPS),
 		VerifyIncomingRPC:                       b.boolVal(c.VerifyIncomingRPC),
@@ -874,6 +923,27 @@ func (b *Builder) Build() (rt RuntimeConfig, err error) {
 		b.warn(`BootstrapExpect is set to 1; this is the same as Bootstrap mode.`)
 	}
 
+	// render the secrets sourced from files or commands
+	secrets := []struct {
+		name string
+		v    *string
+	}{
+		{"acl.tokens.agent", &rt.ACLAgentToken},
+		{"acl.tokens.agent_master", &rt.ACLAgentMasterToken},
+		{"acl.tokens.default", &rt.ACLToken},
+		{"acl.tokens.master", &rt.ACLMasterToken},
+		{"acl.tokens.replication", &rt.ACLReplicationToken},
+		{"encrypt", &rt.EncryptKey},
+		{"key_file", &rt.KeyFile},
+	}
+	for _, s := range secrets {
+		v, err := renderSecret(*s.v)
+		if err != nil {
+			return RuntimeConfig{}, fmt.Errorf("%s: %v", s.name, err)
+		}
+		*s.v = v
+	}
+
 	return rt, nil
 }
 
@@ -941,6 +1011,51 @@ func (b *Builder) Validate(rt RuntimeConfig) error {
 	if rt.BootstrapExpect > 0 && rt.Bootstrap {
 		return fmt.Errorf("'bootstrap_expect > 0' and 'bootstrap = true' are mutually exclusive")
 	}
+	if rt.AutoEncryptTLS && rt.ServerMode {
+		return fmt.Errorf("'auto_encrypt.tls = true' is only allowed on client agents")
+	}
+	if rt.AutoEncryptAllowTLS && !rt.ServerMode {
+		return fmt.Errorf("'auto_encrypt.allow_tls = true' requires 'server = true'")
+	}
+	if rt.AutoEncryptAllowTLS && !rt.ConnectEnabled {
+		return fmt.Errorf("'auto_encrypt.allow_tls = true' requires 'connect.enabled = true'")
+	}
+	if rt.AutoConfigEnabled {
+		if rt.ServerMode {
+			return fmt.Errorf("'auto_config.enabled = true' is only allowed on client agents")
+		}
+		if rt.AutoConfigIntroToken == "" && rt.AutoConfigIntroTokenFile == "" {
+			return fmt.Errorf("'auto_config.enabled = true' requires 'auto_config.intro_token' or 'auto_config.intro_token_file'")
+		}
+		if rt.AutoConfigIntroToken != "" && rt.AutoConfigIntroTokenFile != "" {
+			return fmt.Errorf("'auto_config.intro_token' and 'auto_config.intro_token_file' are mutually exclusive")
+		}
+		if len(rt.AutoConfigServerAddresses) == 0 {
+			return fmt.Errorf("'auto_config.enabled = true' requires 'auto_config.server_addresses'")
+		}
+		// The servers must be verified before the intro token is sent.
+		if rt.CAFile == "" && rt.CAPath == "" {
+			return fmt.Errorf("'auto_config.enabled = true' requires 'ca_file' or 'ca_path'")
+		}
+		if rt.AutoEncryptTLS {
+			return fmt.Errorf("'auto_config.enabled = true' and 'auto_encrypt.tls = true' are mutually exclusive")
+		}
+	}
+	if rt.AutoConfigAuthzEnabled {
+		if !rt.ServerMode {
+			return fmt.Errorf("'auto_config.authorization.enabled = true' requires 'server = true'")
+		}
+		if !rt.ConnectEnabled {
+			return fmt.Errorf("'auto_config.authorization.enabled = true' requires 'connect.enabled = true'")
+		}
+		if len(rt.AutoConfigAuthzJWTValidationPubKeys) == 0 {
+			return fmt.Errorf("'auto_config.authorization.enabled = true' requires 'auto_config.authorization.static.jwt_validation_pub_keys'")
+		}
+		// Otherwise the JWT of any node would be accepted for every node.
+		if !claimAssertionsBindNode(rt.AutoConfigAuthzClaimAssertions) {
+			return fmt.Errorf("'auto_config.authorization.enabled = true' requires a 'auto_config.authorization.static.claim_assertions' value with '${node}'")
+		}
+	}
 	if rt.AEInterval <= 0 {
 		return fmt.Errorf("ae_interval cannot be %s. Must be positive", rt.AEInterval)
 	}
@@ -962,6 +1077,59 @@ func (b *Builder) Validate(rt RuntimeConfig) error {
 	if rt.DNSARecordLimit < 0 {
 		return fmt.Errorf("dns_config.a_record_limit cannot be %d. Must be greater than or equal to zero", rt.DNSARecordLimit)
 	}
+	if rt.ACLFilterMemoSize < 0 {
+		return fmt.Errorf("performance.acl_filter_memo_size cannot be %d. Must be greater than or equal to zero", rt.ACLFilterMemoSize)
+	}
+	if rt.GCPercent < -1 {
+		return fmt.Errorf("performance.gc_percent cannot be %d. Must be -1 or greater", rt.GCPercent)
+	}
+	if rt.GCBallastBytes < 0 {
+		return fmt.Errorf("performance.gc_ballast_bytes cannot be %d. Must be greater than or equal to zero", rt.GCBallastBytes)
+	}
+	if rt.GCMemoryLimitBytes < 0 {
+		return fmt.Errorf("performance.gc_memory_limit_bytes cannot be %d. Must be greater than or equal to zero", rt.GCMemoryLimitBytes)
+	}
+	if rt.GCPercent == -1 && rt.GCMemoryLimitBytes == 0 {
+		return fmt.Errorf("performance.gc_percent can only be -1 with performance.gc_memory_limit_bytes set")
+	}
+	if rt.RaftApplyBatchMaxSize < 0 || rt.RaftApplyBatchMaxSize > consul.MaxRaftApplyBatchSize {
+		return fmt.Errorf("performance.raft_apply_batch_max_size cannot be %d. Must be between 0 and %d", rt.RaftApplyBatchMaxSize, consul.MaxRaftApplyBatchSize)
+	}
+	if rt.RaftApplyBatchMaxLatency < 0 {
+		return fmt.Errorf("performance.raft_apply_batch_max_latency cannot be %s. Must be greater than or equal to zero", rt.RaftApplyBatchMaxLatency)
+	}
+	if rt.RaftApplyBatchAdaptive && rt.RaftApplyBatchMaxLatency == 0 {
+		return fmt.Errorf("performance.raft_apply_batch_adaptive requires performance.raft_apply_batch_max_latency to be set")
+	}
+	if rt.EncryptRotationInterval < 0 {
+		return fmt.Errorf("encrypt_rotation_interval cannot be %s. Must be greater than or equal to zero", rt.EncryptRotationInterval)
+	}
+	if rt.TLSReloadInterval < 0 {
+		return fmt.Errorf("tls_reload_interval cannot be %s. Must be greater than or equal to zero", rt.TLSReloadInterval)
+	}
+	if rt.HTTPCORSAllowCredentials {
+		for _, origin := range rt.HTTPCORSAllowedOrigins {
+			if origin == "*" {
+				return fmt.Errorf("http_config.cors_allow_credentials cannot be used with the \"*\" origin. Please list the allowed origins")
+			}
+		}
+	}
+	if rt.HTTPEndpointProfile != "" {
+		if _, ok := HTTPEndpointProfiles[rt.HTTPEndpointProfile]; !ok {
+			return fmt.Errorf("http_config.endpoint_profile %q is unknown. Must be one of %s", rt.HTTPEndpointProfile, strings.Join(HTTPEndpointProfileNames(), ", "))
+		}
+	}
+	for _, endpoint := range rt.HTTPAllowedEndpoints {
+		methods, pattern := ParseHTTPAllowedEndpoint(endpoint)
+		if !strings.HasPrefix(pattern, "/") {
+			return fmt.Errorf("http_config.allowed_endpoints entry %q is invalid. Must start with a \"/\", optionally preceded by the allowed methods", endpoint)
+		}
+		for _, method := range methods {
+			if strings.Trim(method, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" {
+				return fmt.Errorf("http_config.allowed_endpoints entry %q has an invalid method %q", endpoint, method)
+			}
+		}
+	}
 	if err := structs.ValidateMetadata(rt.NodeMeta, false); err != nil {
 		return fmt.Errorf("node_meta invalid: %v", err)
 	}
@@ -1140,14 +1308,20 @@ func (b *Builder) checkVal(v *CheckDefinition) *structs.CheckDefinition {
 		Header:                         v.Header,
 		Method:                         b.stringVal(v.Method),
 		TCP:                            b.stringVal(v.TCP),
+		TCPUseTLS:                      b.boolVal(v.TCPUseTLS),
 		Interval:                       b.durationVal(fmt.Sprintf("check[%s].interval", id), v.Interval),
 		DockerContainerID:              b.stringVal(v.DockerContainerID),
 		Shell:                          b.stringVal(v.Shell),
 		GRPC:                           b.stringVal(v.GRPC),
 		GRPCUseTLS:                     b.boolVal(v.GRPCUseTLS),
 		TLSSkipVerify:                  b.boolVal(v.TLSSkipVerify),
+		TLSServerName:                  b.stringVal(v.TLSServerName),
+		TLSCAFile:                      b.stringVal(v.TLSCAFile),
+		TLSCertFile:                    b.stringVal(v.TLSCertFile),
+		TLSKeyFile:                     b.stringVal(v.TLSKeyFile),
 		AliasNode:                      b.stringVal(v.AliasNode),
 		AliasService:                   b.stringVal(v.AliasService),
+		Composite:                      b.stringVal(v.Composite),
 		Timeout:                        b.durationVal(fmt.Sprintf("check[%s].timeout", id), v.Timeout),
 		TTL:                            b.durationVal(fmt.Sprintf("check[%s].ttl", id), v.TTL),
 		DeregisterCriticalServiceAfter: b.durationVal(fmt.Sprintf("check[%s].deregister_critical_service_after", id), v.DeregisterCriticalServiceAfter),
@@ -1186,13 +1360,31 @@ func (b *Builder) serviceVal(v *ServiceDefinition) *structs.ServiceDefinition {
 	if err := structs.ValidateWeights(serviceWeights); err != nil {
 		b.err = multierror.Append(fmt.Errorf("Invalid weight definition for service %s: %s", b.stringVal(v.Name), err))
 	}
+
+	if err := structs.ValidateLabels(v.Labels); err != nil {
+		b.err = multierror.Append(b.err, fmt.Errorf("invalid labels for service %s: %v", b.stringVal(v.Name), err))
+	}
+
+	var taggedAddrs map[string]structs.ServiceAddress
+	if len(v.TaggedAddresses) > 0 {
+		taggedAddrs = make(map[string]structs.ServiceAddress)
+		for tag, addr := range v.TaggedAddresses {
+			taggedAddrs[tag] = structs.ServiceAddress{
+				Address: b.stringVal(addr.Address),
+				Port:    b.intVal(addr.Port),
+			}
+		}
+	}
+
 	return &structs.ServiceDefinition{
 		Kind:              b.serviceKindVal(v.Kind),
 		ID:                b.stringVal(v.ID),
 		Name:              b.stringVal(v.Name),
 		Tags:              v.Tags,
 		Address:           b.stringVal(v.Address),
+		TaggedAddresses:   taggedAddrs,
 		Meta:              meta,
+		Labels:            v.Labels,
 		Port:              b.intVal(v.Port),
 		Token:             b.stringVal(v.Token),
 		EnableTagOverride: b.boolVal(v.EnableTagOverride),
@@ -1212,6 +1404,12 @@ func (b *Builder) serviceKindVal(v *string) structs.ServiceKind {
 	switch *v {
 	case string(structs.ServiceKindConnectProxy):
 		return structs.ServiceKindConnectProxy
+	case string(structs.ServiceKindIngressGateway):
+		return structs.ServiceKindIngressGateway
+	case string(structs.ServiceKindTerminatingGateway):
+		return structs.ServiceKindTerminatingGateway
+	case string(structs.ServiceKindMeshGateway):
+		return structs.ServiceKindMeshGateway
 	default:
 		return structs.ServiceKindTypical
 	}
@@ -1234,7 +1432,37 @@ func (b *Builder) serviceProxyVal(v *ServiceProxy, deprecatedDest *string) *stru
 		LocalServicePort:       b.intVal(v.LocalServicePort),
 		Config:                 v.Config,
 		Upstreams:              b.upstreamsVal(v.Upstreams),
+		Expose:                 b.exposeConfVal(v.Expose),
+		MeshGateway:            b.meshGatewayConfVal(v.MeshGateway),
+	}
+}
+
+func (b *Builder) meshGatewayConfVal(v *MeshGatewayConfig) structs.MeshGatewayConfig {
+	var out structs.MeshGatewayConfig
+	if v == nil {
+		return out
+	}
+
+	out.Mode = structs.MeshGatewayMode(b.stringVal(v.Mode))
+	return out
+}
+
+func (b *Builder) exposeConfVal(v *ExposeConfig) structs.ExposeConfig {
+	var out structs.ExposeConfig
+	if v == nil {
+		return out
+	}
+
+	out.Checks = b.boolVal(v.Checks)
+	for _, p := range v.Paths {
+		out.Paths = append(out.Paths, structs.ExposePath{
+			ListenerPort:  b.intVal(p.ListenerPort),
+			Path:          b.stringVal(p.Path),
+			LocalPathPort: b.intVal(p.LocalPathPort),
+			Protocol:      b.stringVal(p.Protocol),
+		})
 	}
+	return out
 }
 
 func (b *Builder) upstreamsVal(v []Upstream) structs.Upstreams {
@@ -1248,6 +1476,7 @@ func (b *Builder) upstreamsVal(v []Upstream) structs.Upstreams {
 			LocalBindAddress:     b.stringVal(u.LocalBindAddress),
 			LocalBindPort:        b.intVal(u.LocalBindPort),
 			Config:               u.Config,
+			MeshGateway:          b.meshGatewayConfVal(u.MeshGateway),
 		}
 		if ups[i].DestinationType == "" {
 			ups[i].DestinationType = structs.UpstreamDestTypeService
@@ -1376,6 +1605,33 @@ func (b *Builder) cidrsVal(name string, v []string) (nets []*net.IPNet) {
 	return
 }
 
+// allowedEndpointsVal generates the list of endpoints allowed in the HTTP
+// API from the endpoints of the profile and the explicitly allowed ones. Nil
+// is returned when neither is set, which allows all endpoints.
+func (b *Builder) allowedEndpointsVal(profile *string, v []string) []string {
+	var endpoints []string
+	if p := b.stringVal(profile); p != "" {
+		endpoints = append(endpoints, HTTPEndpointProfiles[p]...)
+	}
+	endpoints = append(endpoints, v...)
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var allowed []string
+	for _, e := range endpoints {
+		e = strings.TrimSpace(e)
+		if seen[e] {
+			continue
+		}
+		seen[e] = true
+		allowed = append(allowed, e)
+	}
+	sort.Strings(allowed)
+	return allowed
+}
+
 func (b *Builder) tlsCipherSuites(name string, v *string) []uint16 {
 	if v == nil {
 		return nil
@@ -1639,3 +1895,14 @@ func isUnixAddr(a net.Addr) bool {
 	_, ok := a.(*net.UnixAddr)
 	return ok
 }
+
+// claimAssertionsBindNode returns true when one of the claim assertions
+// matches the name of the node presenting the JWT.
+func claimAssertionsBindNode(assertions map[string]string) bool {
+	for _, v := range assertions {
+		if strings.Contains(v, "${node}") {
+			return true
+		}
+	}
+	return false
+}
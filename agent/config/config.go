@@ -90,6 +90,8 @@ func Parse(data string, format string) (c Config, err error) {
 		"services.connect.proxy.upstreams",
 		"service.proxy.upstreams",
 		"services.proxy.upstreams",
+		"service.proxy.expose.paths",
+		"services.proxy.expose.paths",
 
 		// Need all the service(s) exceptions also for nested sidecar service except
 		// managed proxy which is explicitly not supported there.
@@ -97,6 +99,8 @@ func Parse(data string, format string) (c Config, err error) {
 		"services.connect.sidecar_service.checks",
 		"service.connect.sidecar_service.proxy.upstreams",
 		"services.connect.sidecar_service.proxy.upstreams",
+		"service.connect.sidecar_service.proxy.expose.paths",
+		"services.connect.sidecar_service.proxy.expose.paths",
 	})
 
 	// There is a difference of representation of some fields depending on
@@ -173,6 +177,10 @@ type Config struct {
 	Addresses                        Addresses                `json:"addresses,omitempty" hcl:"addresses" mapstructure:"addresses"`
 	AdvertiseAddrLAN                 *string                  `json:"advertise_addr,omitempty" hcl:"advertise_addr" mapstructure:"advertise_addr"`
 	AdvertiseAddrWAN                 *string                  `json:"advertise_addr_wan,omitempty" hcl:"advertise_addr_wan" mapstructure:"advertise_addr_wan"`
+	APIBootstrapFile                 *string                  `json:"api_bootstrap_file,omitempty" hcl:"api_bootstrap_file" mapstructure:"api_bootstrap_file"`
+	APIBootstrapTokenFile            *string                  `json:"api_bootstrap_token_file,omitempty" hcl:"api_bootstrap_token_file" mapstructure:"api_bootstrap_token_file"`
+	AutoConfig                       AutoConfig               `json:"auto_config,omitempty" hcl:"auto_config" mapstructure:"auto_config"`
+	AutoEncrypt                      AutoEncrypt              `json:"auto_encrypt,omitempty" hcl:"auto_encrypt" mapstructure:"auto_encrypt"`
 	Autopilot                        Autopilot                `json:"autopilot,omitempty" hcl:"autopilot" mapstructure:"autopilot"`
 	BindAddr                         *string                  `json:"bind_addr,omitempty" hcl:"bind_addr" mapstructure:"bind_addr"`
 	Bootstrap                        *bool                    `json:"bootstrap,omitempty" hcl:"bootstrap" mapstructure:"bootstrap"`
@@ -206,6 +214,7 @@ type Config struct {
 	EnableLocalScriptChecks          *bool                    `json:"enable_local_script_checks,omitempty" hcl:"enable_local_script_checks" mapstructure:"enable_local_script_checks"`
 	EnableSyslog                     *bool                    `json:"enable_syslog,omitempty" hcl:"enable_syslog" mapstructure:"enable_syslog"`
 	EncryptKey                       *string                  `json:"encrypt,omitempty" hcl:"encrypt" mapstructure:"encrypt"`
+	EncryptRotationInterval          *string                  `json:"encrypt_rotation_interval,omitempty" hcl:"encrypt_rotation_interval" mapstructure:"encrypt_rotation_interval"`
 	EncryptVerifyIncoming            *bool                    `json:"encrypt_verify_incoming,omitempty" hcl:"encrypt_verify_incoming" mapstructure:"encrypt_verify_incoming"`
 	EncryptVerifyOutgoing            *bool                    `json:"encrypt_verify_outgoing,omitempty" hcl:"encrypt_verify_outgoing" mapstructure:"encrypt_verify_outgoing"`
 	GossipLAN                        GossipLANConfig          `json:"gossip_lan,omitempty" hcl:"gossip_lan" mapstructure:"gossip_lan"`
@@ -214,7 +223,9 @@ type Config struct {
 	KeyFile                          *string                  `json:"key_file,omitempty" hcl:"key_file" mapstructure:"key_file"`
 	LeaveOnTerm                      *bool                    `json:"leave_on_terminate,omitempty" hcl:"leave_on_terminate" mapstructure:"leave_on_terminate"`
 	Limits                           Limits                   `json:"limits,omitempty" hcl:"limits" mapstructure:"limits"`
+	LogJSON                          *bool                    `json:"log_json,omitempty" hcl:"log_json" mapstructure:"log_json"`
 	LogLevel                         *string                  `json:"log_level,omitempty" hcl:"log_level" mapstructure:"log_level"`
+	LogSubsystemLevels               map[string]string        `json:"log_subsystem_levels,omitempty" hcl:"log_subsystem_levels" mapstructure:"log_subsystem_levels"`
 	LogFile                          *string                  `json:"log_file,omitempty" hcl:"log_file" mapstructure:"log_file"`
 	LogRotateDuration                *string                  `json:"log_rotate_duration,omitempty" hcl:"log_rotate_duration" mapstructure:"log_rotate_duration"`
 	LogRotateBytes                   *int                     `json:"log_rotate_bytes,omitempty" hcl:"log_rotate_bytes" mapstructure:"log_rotate_bytes"`
@@ -255,12 +266,14 @@ type Config struct {
 	TLSCipherSuites                  *string                  `json:"tls_cipher_suites,omitempty" hcl:"tls_cipher_suites" mapstructure:"tls_cipher_suites"`
 	TLSMinVersion                    *string                  `json:"tls_min_version,omitempty" hcl:"tls_min_version" mapstructure:"tls_min_version"`
 	TLSPreferServerCipherSuites      *bool                    `json:"tls_prefer_server_cipher_suites,omitempty" hcl:"tls_prefer_server_cipher_suites" mapstructure:"tls_prefer_server_cipher_suites"`
+	TLSReloadInterval                *string                  `json:"tls_reload_interval,omitempty" hcl:"tls_reload_interval" mapstructure:"tls_reload_interval"`
 	TaggedAddresses                  map[string]string        `json:"tagged_addresses,omitempty" hcl:"tagged_addresses" mapstructure:"tagged_addresses"`
 	Telemetry                        Telemetry                `json:"telemetry,omitempty" hcl:"telemetry" mapstructure:"telemetry"`
 	TranslateWANAddrs                *bool                    `json:"translate_wan_addrs,omitempty" hcl:"translate_wan_addrs" mapstructure:"translate_wan_addrs"`
 	UI                               *bool                    `json:"ui,omitempty" hcl:"ui" mapstructure:"ui"`
 	UIDir                            *string                  `json:"ui_dir,omitempty" hcl:"ui_dir" mapstructure:"ui_dir"`
 	UnixSocket                       UnixSocket               `json:"unix_sockets,omitempty" hcl:"unix_sockets" mapstructure:"unix_sockets"`
+	UseStreamingBackend              *bool                    `json:"use_streaming_backend,omitempty" hcl:"use_streaming_backend" mapstructure:"use_streaming_backend"`
 	VerifyIncoming                   *bool                    `json:"verify_incoming,omitempty" hcl:"verify_incoming" mapstructure:"verify_incoming"`
 	VerifyIncomingHTTPS              *bool                    `json:"verify_incoming_https,omitempty" hcl:"verify_incoming_https" mapstructure:"verify_incoming_https"`
 	VerifyIncomingRPC                *bool                    `json:"verify_incoming_rpc,omitempty" hcl:"verify_incoming_rpc" mapstructure:"verify_incoming_rpc"`
@@ -339,6 +352,42 @@ type AdvertiseAddrsConfig struct {
 	SerfWAN *string `json:"serf_wan,omitempty" hcl:"serf_wan" mapstructure:"serf_wan"`
 }
 
+type AutoConfig struct {
+	// Enabled enables the client agents to request their configuration
+	// from the servers, presenting the JWT of IntroToken or IntroTokenFile.
+	Enabled         *bool    `json:"enabled,omitempty" hcl:"enabled" mapstructure:"enabled"`
+	IntroToken      *string  `json:"intro_token,omitempty" hcl:"intro_token" mapstructure:"intro_token"`
+	IntroTokenFile  *string  `json:"intro_token_file,omitempty" hcl:"intro_token_file" mapstructure:"intro_token_file"`
+	ServerAddresses []string `json:"server_addresses,omitempty" hcl:"server_addresses" mapstructure:"server_addresses"`
+
+	// Authorization enables the servers to hand their configuration to
+	// the client agents.
+	Authorization AutoConfigAuthorization `json:"authorization,omitempty" hcl:"authorization" mapstructure:"authorization"`
+}
+
+type AutoConfigAuthorization struct {
+	Enabled            *bool                         `json:"enabled,omitempty" hcl:"enabled" mapstructure:"enabled"`
+	AgentTokenPolicies []string                      `json:"agent_token_policies,omitempty" hcl:"agent_token_policies" mapstructure:"agent_token_policies"`
+	Static             AutoConfigAuthorizationStatic `json:"static,omitempty" hcl:"static" mapstructure:"static"`
+}
+
+type AutoConfigAuthorizationStatic struct {
+	JWTValidationPubKeys []string          `json:"jwt_validation_pub_keys,omitempty" hcl:"jwt_validation_pub_keys" mapstructure:"jwt_validation_pub_keys"`
+	BoundIssuer          *string           `json:"bound_issuer,omitempty" hcl:"bound_issuer" mapstructure:"bound_issuer"`
+	BoundAudiences       []string          `json:"bound_audiences,omitempty" hcl:"bound_audiences" mapstructure:"bound_audiences"`
+	ClaimAssertions      map[string]string `json:"claim_assertions,omitempty" hcl:"claim_assertions" mapstructure:"claim_assertions"`
+}
+
+type AutoEncrypt struct {
+	// TLS enables the client agents to request the certificates of their
+	// RPC connections to the servers from the servers.
+	TLS *bool `json:"tls,omitempty" hcl:"tls" mapstructure:"tls"`
+
+	// AllowTLS enables the servers to issue the certificates requested by
+	// the client agents with auto-encrypt.
+	AllowTLS *bool `json:"allow_tls,omitempty" hcl:"allow_tls" mapstructure:"allow_tls"`
+}
+
 type Autopilot struct {
 	CleanupDeadServers      *bool   `json:"cleanup_dead_servers,omitempty" hcl:"cleanup_dead_servers" mapstructure:"cleanup_dead_servers"`
 	DisableUpgradeMigration *bool   `json:"disable_upgrade_migration,omitempty" hcl:"disable_upgrade_migration" mapstructure:"disable_upgrade_migration"`
@@ -356,24 +405,31 @@ type ServiceWeights struct {
 }
 
 type ServiceDefinition struct {
-	Kind              *string           `json:"kind,omitempty" hcl:"kind" mapstructure:"kind"`
-	ID                *string           `json:"id,omitempty" hcl:"id" mapstructure:"id"`
-	Name              *string           `json:"name,omitempty" hcl:"name" mapstructure:"name"`
-	Tags              []string          `json:"tags,omitempty" hcl:"tags" mapstructure:"tags"`
-	Address           *string           `json:"address,omitempty" hcl:"address" mapstructure:"address"`
-	Meta              map[string]string `json:"meta,omitempty" hcl:"meta" mapstructure:"meta"`
-	Port              *int              `json:"port,omitempty" hcl:"port" mapstructure:"port"`
-	Check             *CheckDefinition  `json:"check,omitempty" hcl:"check" mapstructure:"check"`
-	Checks            []CheckDefinition `json:"checks,omitempty" hcl:"checks" mapstructure:"checks"`
-	Token             *string           `json:"token,omitempty" hcl:"token" mapstructure:"token"`
-	Weights           *ServiceWeights   `json:"weights,omitempty" hcl:"weights" mapstructure:"weights"`
-	EnableTagOverride *bool             `json:"enable_tag_override,omitempty" hcl:"enable_tag_override" mapstructure:"enable_tag_override"`
+	Kind              *string                   `json:"kind,omitempty" hcl:"kind" mapstructure:"kind"`
+	ID                *string                   `json:"id,omitempty" hcl:"id" mapstructure:"id"`
+	Name              *string                   `json:"name,omitempty" hcl:"name" mapstructure:"name"`
+	Tags              []string                  `json:"tags,omitempty" hcl:"tags" mapstructure:"tags"`
+	Address           *string                   `json:"address,omitempty" hcl:"address" mapstructure:"address"`
+	TaggedAddresses   map[string]ServiceAddress `json:"tagged_addresses,omitempty" hcl:"tagged_addresses" mapstructure:"tagged_addresses"`
+	Meta              map[string]string         `json:"meta,omitempty" hcl:"meta" mapstructure:"meta"`
+	Labels            map[string]string         `json:"labels,omitempty" hcl:"labels" mapstructure:"labels"`
+	Port              *int                      `json:"port,omitempty" hcl:"port" mapstructure:"port"`
+	Check             *CheckDefinition          `json:"check,omitempty" hcl:"check" mapstructure:"check"`
+	Checks            []CheckDefinition         `json:"checks,omitempty" hcl:"checks" mapstructure:"checks"`
+	Token             *string                   `json:"token,omitempty" hcl:"token" mapstructure:"token"`
+	Weights           *ServiceWeights           `json:"weights,omitempty" hcl:"weights" mapstructure:"weights"`
+	EnableTagOverride *bool                     `json:"enable_tag_override,omitempty" hcl:"enable_tag_override" mapstructure:"enable_tag_override"`
 	// DEPRECATED (ProxyDestination) - remove this when removing ProxyDestination
 	ProxyDestination *string         `json:"proxy_destination,omitempty" hcl:"proxy_destination" mapstructure:"proxy_destination"`
 	Proxy            *ServiceProxy   `json:"proxy,omitempty" hcl:"proxy" mapstructure:"proxy"`
 	Connect          *ServiceConnect `json:"connect,omitempty" hcl:"connect" mapstructure:"connect"`
 }
 
+type ServiceAddress struct {
+	Address *string `json:"address,omitempty" hcl:"address" mapstructure:"address"`
+	Port    *int    `json:"port,omitempty" hcl:"port" mapstructure:"port"`
+}
+
 type CheckDefinition struct {
 	ID                             *string             `json:"id,omitempty" hcl:"id" mapstructure:"id"`
 	Name                           *string             `json:"name,omitempty" hcl:"name" mapstructure:"name"`
@@ -386,14 +442,20 @@ type CheckDefinition struct {
 	Header                         map[string][]string `json:"header,omitempty" hcl:"header" mapstructure:"header"`
 	Method                         *string             `json:"method,omitempty" hcl:"method" mapstructure:"method"`
 	TCP                            *string             `json:"tcp,omitempty" hcl:"tcp" mapstructure:"tcp"`
+	TCPUseTLS                      *bool               `json:"tcp_use_tls,omitempty" hcl:"tcp_use_tls" mapstructure:"tcp_use_tls"`
 	Interval                       *string             `json:"interval,omitempty" hcl:"interval" mapstructure:"interval"`
 	DockerContainerID              *string             `json:"docker_container_id,omitempty" hcl:"docker_container_id" mapstructure:"docker_container_id"`
 	Shell                          *string             `json:"shell,omitempty" hcl:"shell" mapstructure:"shell"`
 	GRPC                           *string             `json:"grpc,omitempty" hcl:"grpc" mapstructure:"grpc"`
 	GRPCUseTLS                     *bool               `json:"grpc_use_tls,omitempty" hcl:"grpc_use_tls" mapstructure:"grpc_use_tls"`
 	TLSSkipVerify                  *bool               `json:"tls_skip_verify,omitempty" hcl:"tls_skip_verify" mapstructure:"tls_skip_verify"`
+	TLSServerName                  *string             `json:"tls_server_name,omitempty" hcl:"tls_server_name" mapstructure:"tls_server_name"`
+	TLSCAFile                      *string             `json:"tls_ca_file,omitempty" hcl:"tls_ca_file" mapstructure:"tls_ca_file"`
+	TLSCertFile                    *string             `json:"tls_cert_file,omitempty" hcl:"tls_cert_file" mapstructure:"tls_cert_file"`
+	TLSKeyFile                     *string             `json:"tls_key_file,omitempty" hcl:"tls_key_file" mapstructure:"tls_key_file"`
 	AliasNode                      *string             `json:"alias_node,omitempty" hcl:"alias_node" mapstructure:"alias_node"`
 	AliasService                   *string             `json:"alias_service,omitempty" hcl:"alias_service" mapstructure:"alias_service"`
+	Composite                      *string             `json:"composite,omitempty" hcl:"composite" mapstructure:"composite"`
 	Timeout                        *string             `json:"timeout,omitempty" hcl:"timeout" mapstructure:"timeout"`
 	TTL                            *string             `json:"ttl,omitempty" hcl:"ttl" mapstructure:"ttl"`
 	DeregisterCriticalServiceAfter *string             `json:"deregister_critical_service_after,omitempty" hcl:"deregister_critical_service_after" mapstructure:"deregister_critical_service_after"`
@@ -458,6 +520,40 @@ type ServiceProxy struct {
 	// Upstreams describes any upstream dependencies the proxy instance should
 	// setup.
 	Upstreams []Upstream `json:"upstreams,omitempty" hcl:"upstreams" mapstructure:"upstreams"`
+
+	// Expose defines whether checks or paths are exposed through the proxy.
+	Expose *ExposeConfig `json:"expose,omitempty" hcl:"expose" mapstructure:"expose"`
+
+	// MeshGateway defines how the upstreams of other datacenters are reached
+	// through the mesh gateways.
+	MeshGateway *MeshGatewayConfig `json:"mesh_gateway,omitempty" hcl:"mesh_gateway" mapstructure:"mesh_gateway"`
+}
+
+// MeshGatewayConfig controls how the upstreams of other datacenters are
+// reached through the mesh gateways.
+type MeshGatewayConfig struct {
+	// Mode is one of "none", "local" or "remote".
+	Mode *string `json:"mode,omitempty" hcl:"mode" mapstructure:"mode"`
+}
+
+// ExposeConfig describes the HTTP paths of the local service instance that
+// are exposed through the proxy without Connect.
+type ExposeConfig struct {
+	// Checks defines whether the paths of the HTTP checks of the service
+	// instance are exposed.
+	Checks *bool `json:"checks,omitempty" hcl:"checks" mapstructure:"checks"`
+
+	// Paths are the paths exposed through the proxy.
+	Paths []ExposePath `json:"paths,omitempty" hcl:"paths" mapstructure:"paths"`
+}
+
+// ExposePath is a path of the local service instance exposed through the
+// proxy.
+type ExposePath struct {
+	ListenerPort  *int    `json:"listener_port,omitempty" hcl:"listener_port" mapstructure:"listener_port"`
+	Path          *string `json:"path,omitempty" hcl:"path" mapstructure:"path"`
+	LocalPathPort *int    `json:"local_path_port,omitempty" hcl:"local_path_port" mapstructure:"local_path_port"`
+	Protocol      *string `json:"protocol,omitempty" hcl:"protocol" mapstructure:"protocol"`
 }
 
 // Upstream represents a single upstream dependency for a service or proxy. It
@@ -493,6 +589,10 @@ type Upstream struct {
 	// It can be used to pass arbitrary configuration for this specific upstream
 	// to the proxy.
 	Config map[string]interface{} `json:"config,omitempty" hcl:"config" mapstructure:"config"`
+
+	// MeshGateway overrides how the upstream is reached through the mesh
+	// gateways.
+	MeshGateway *MeshGatewayConfig `json:"mesh_gateway,omitempty" hcl:"mesh_gateway" mapstructure:"mesh_gateway"`
 }
 
 // Connect is the agent-global connect configuration.
@@ -504,6 +604,10 @@ type Connect struct {
 	ProxyDefaults ConnectProxyDefaults   `json:"proxy_defaults,omitempty" hcl:"proxy_defaults" mapstructure:"proxy_defaults"`
 	CAProvider    *string                `json:"ca_provider,omitempty" hcl:"ca_provider" mapstructure:"ca_provider"`
 	CAConfig      map[string]interface{} `json:"ca_config,omitempty" hcl:"ca_config" mapstructure:"ca_config"`
+
+	// IntentionSuggestions opts the agent into reporting the connections it
+	// authorizes without matching any intention to the servers.
+	IntentionSuggestions *bool `json:"intention_suggestions,omitempty" hcl:"intention_suggestions" mapstructure:"intention_suggestions"`
 }
 
 // ConnectProxy is the agent-global connect proxy configuration.
@@ -560,15 +664,31 @@ type DNS struct {
 }
 
 type HTTPConfig struct {
-	BlockEndpoints     []string          `json:"block_endpoints,omitempty" hcl:"block_endpoints" mapstructure:"block_endpoints"`
-	AllowWriteHTTPFrom []string          `json:"allow_write_http_from,omitempty" hcl:"allow_write_http_from" mapstructure:"allow_write_http_from"`
-	ResponseHeaders    map[string]string `json:"response_headers,omitempty" hcl:"response_headers" mapstructure:"response_headers"`
+	BlockEndpoints       []string          `json:"block_endpoints,omitempty" hcl:"block_endpoints" mapstructure:"block_endpoints"`
+	AllowWriteHTTPFrom   []string          `json:"allow_write_http_from,omitempty" hcl:"allow_write_http_from" mapstructure:"allow_write_http_from"`
+	ResponseHeaders      map[string]string `json:"response_headers,omitempty" hcl:"response_headers" mapstructure:"response_headers"`
+	CORSAllowedOrigins   []string          `json:"cors_allowed_origins,omitempty" hcl:"cors_allowed_origins" mapstructure:"cors_allowed_origins"`
+	CORSAllowedHeaders   []string          `json:"cors_allowed_headers,omitempty" hcl:"cors_allowed_headers" mapstructure:"cors_allowed_headers"`
+	CORSAllowCredentials *bool             `json:"cors_allow_credentials,omitempty" hcl:"cors_allow_credentials" mapstructure:"cors_allow_credentials"`
+	CORSMaxAge           *string           `json:"cors_max_age,omitempty" hcl:"cors_max_age" mapstructure:"cors_max_age"`
+	EndpointProfile      *string           `json:"endpoint_profile,omitempty" hcl:"endpoint_profile" mapstructure:"endpoint_profile"`
+	AllowedEndpoints     []string          `json:"allowed_endpoints,omitempty" hcl:"allowed_endpoints" mapstructure:"allowed_endpoints"`
 }
 
 type Performance struct {
 	LeaveDrainTime *string `json:"leave_drain_time,omitempty" hcl:"leave_drain_time" mapstructure:"leave_drain_time"`
 	RaftMultiplier *int    `json:"raft_multiplier,omitempty" hcl:"raft_multiplier" mapstructure:"raft_multiplier"` // todo(fs): validate as uint
 	RPCHoldTimeout *string `json:"rpc_hold_timeout" hcl:"rpc_hold_timeout" mapstructure:"rpc_hold_timeout"`
+
+	ACLFilterMemoSize *int `json:"acl_filter_memo_size,omitempty" hcl:"acl_filter_memo_size" mapstructure:"acl_filter_memo_size"`
+
+	GCPercent          *int `json:"gc_percent,omitempty" hcl:"gc_percent" mapstructure:"gc_percent"`
+	GCBallastBytes     *int `json:"gc_ballast_bytes,omitempty" hcl:"gc_ballast_bytes" mapstructure:"gc_ballast_bytes"`
+	GCMemoryLimitBytes *int `json:"gc_memory_limit_bytes,omitempty" hcl:"gc_memory_limit_bytes" mapstructure:"gc_memory_limit_bytes"`
+
+	RaftApplyBatchMaxSize    *int    `json:"raft_apply_batch_max_size,omitempty" hcl:"raft_apply_batch_max_size" mapstructure:"raft_apply_batch_max_size"`
+	RaftApplyBatchMaxLatency *string `json:"raft_apply_batch_max_latency,omitempty" hcl:"raft_apply_batch_max_latency" mapstructure:"raft_apply_batch_max_latency"`
+	RaftApplyBatchAdaptive   *bool   `json:"raft_apply_batch_adaptive,omitempty" hcl:"raft_apply_batch_adaptive" mapstructure:"raft_apply_batch_adaptive"`
 }
 
 type Telemetry struct {
@@ -608,6 +728,8 @@ type Ports struct {
 	ProxyMaxPort   *int `json:"proxy_max_port,omitempty" hcl:"proxy_max_port" mapstructure:"proxy_max_port"`
 	SidecarMinPort *int `json:"sidecar_min_port,omitempty" hcl:"sidecar_min_port" mapstructure:"sidecar_min_port"`
 	SidecarMaxPort *int `json:"sidecar_max_port,omitempty" hcl:"sidecar_max_port" mapstructure:"sidecar_max_port"`
+	ExposeMinPort  *int `json:"expose_min_port,omitempty" hcl:"expose_min_port" mapstructure:"expose_min_port"`
+	ExposeMaxPort  *int `json:"expose_max_port,omitempty" hcl:"expose_max_port" mapstructure:"expose_max_port"`
 }
 
 type UnixSocket struct {
@@ -630,16 +752,18 @@ type Segment struct {
 }
 
 type ACL struct {
-	Enabled                *bool   `json:"enabled,omitempty" hcl:"enabled" mapstructure:"enabled"`
-	TokenReplication       *bool   `json:"enable_token_replication,omitempty" hcl:"enable_token_replication" mapstructure:"enable_token_replication"`
-	PolicyTTL              *string `json:"policy_ttl,omitempty" hcl:"policy_ttl" mapstructure:"policy_ttl"`
-	TokenTTL               *string `json:"token_ttl,omitempty" hcl:"token_ttl" mapstructure:"token_ttl"`
-	DownPolicy             *string `json:"down_policy,omitempty" hcl:"down_policy" mapstructure:"down_policy"`
-	DefaultPolicy          *string `json:"default_policy,omitempty" hcl:"default_policy" mapstructure:"default_policy"`
-	EnableKeyListPolicy    *bool   `json:"enable_key_list_policy,omitempty" hcl:"enable_key_list_policy" mapstructure:"enable_key_list_policy"`
-	Tokens                 Tokens  `json:"tokens,omitempty" hcl:"tokens" mapstructure:"tokens"`
-	DisabledTTL            *string `json:"disabled_ttl,omitempty" hcl:"disabled_ttl" mapstructure:"disabled_ttl"`
-	EnableTokenPersistence *bool   `json:"enable_token_persistence" hcl:"enable_token_persistence" mapstructure:"enable_token_persistence"`
+	Enabled                 *bool   `json:"enabled,omitempty" hcl:"enabled" mapstructure:"enabled"`
+	TokenReplication        *bool   `json:"enable_token_replication,omitempty" hcl:"enable_token_replication" mapstructure:"enable_token_replication"`
+	PolicyTTL               *string `json:"policy_ttl,omitempty" hcl:"policy_ttl" mapstructure:"policy_ttl"`
+	TokenTTL                *string `json:"token_ttl,omitempty" hcl:"token_ttl" mapstructure:"token_ttl"`
+	DownPolicy              *string `json:"down_policy,omitempty" hcl:"down_policy" mapstructure:"down_policy"`
+	DefaultPolicy           *string `json:"default_policy,omitempty" hcl:"default_policy" mapstructure:"default_policy"`
+	EnableKeyListPolicy     *bool   `json:"enable_key_list_policy,omitempty" hcl:"enable_key_list_policy" mapstructure:"enable_key_list_policy"`
+	EnableServiceTokens     *bool   `json:"enable_service_tokens,omitempty" hcl:"enable_service_tokens" mapstructure:"enable_service_tokens"`
+	Tokens                  Tokens  `json:"tokens,omitempty" hcl:"tokens" mapstructure:"tokens"`
+	DisabledTTL             *string `json:"disabled_ttl,omitempty" hcl:"disabled_ttl" mapstructure:"disabled_ttl"`
+	EnableTokenPersistence  *bool   `json:"enable_token_persistence" hcl:"enable_token_persistence" mapstructure:"enable_token_persistence"`
+	ReplicationLagThreshold *string `json:"replication_lag_threshold,omitempty" hcl:"replication_lag_threshold" mapstructure:"replication_lag_threshold"`
 }
 
 type Tokens struct {
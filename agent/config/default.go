@@ -45,6 +45,7 @@ func DefaultSource() Source {
 		acl_ttl = "30s"
 		acl = {
 			policy_ttl = "30s"
+			replication_lag_threshold = "10m"
 		}
 		bind_addr = "0.0.0.0"
 		bootstrap = false
@@ -102,6 +103,7 @@ func DefaultSource() Source {
 			rpc_max_burst = 1000
 		}
 		performance = {
+			acl_filter_memo_size = 1024
 			leave_drain_time = "5s"
 			raft_multiplier = ` + strconv.Itoa(int(consul.DefaultRaftMultiplier)) + `
 			rpc_hold_timeout = "7s"
@@ -118,6 +120,8 @@ func DefaultSource() Source {
 			proxy_max_port = 20255
 			sidecar_min_port = 21000
 			sidecar_max_port = 21255
+			expose_min_port = 21500
+			expose_max_port = 21755
 		}
 		telemetry = {
 			metrics_prefix = "consul"
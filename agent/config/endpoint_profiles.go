@@ -0,0 +1,94 @@
+package config
+
+import (
+	"sort"
+	"strings"
+)
+
+// HTTPEndpointProfiles maps the names of the endpoint profiles to the
+// patterns of the HTTP API endpoints they allow. Setting a profile makes the
+// agent serve only these endpoints, which shrinks the surface of the HTTP API
+// on agents with a single purpose. Like the allowed endpoints, the patterns
+// can be preceded by the methods they allow, see ParseHTTPAllowedEndpoint.
+var HTTPEndpointProfiles = map[string][]string{
+	// discovery-only allows the catalog, health and prepared query reads
+	// used to discover services. Only GET is allowed since some of these
+	// endpoints also write, such as /v1/query/.
+	"discovery-only": {
+		"GET /v1/agent/health/service/id/",
+		"GET /v1/agent/health/service/name/",
+		"GET /v1/catalog/connect/",
+		"GET /v1/catalog/datacenters",
+		"GET /v1/catalog/node/",
+		"GET /v1/catalog/nodes",
+		"GET /v1/catalog/service/",
+		"GET /v1/catalog/services",
+		"GET /v1/catalog/stream/services",
+		"GET /v1/coordinate/datacenters",
+		"GET /v1/coordinate/node/",
+		"GET /v1/coordinate/nodes",
+		"GET /v1/health/checks/",
+		"GET /v1/health/connect/",
+		"GET /v1/health/node/",
+		"GET /v1/health/service/",
+		"GET /v1/health/state/",
+		"GET /v1/health/stream/service/",
+		"GET /v1/query/",
+		"GET /v1/status/leader",
+	},
+
+	// mesh-sidecar allows the endpoints used by Connect proxies and the
+	// services they front to register themselves, update their checks and
+	// get their certificates and upstreams.
+	"mesh-sidecar": {
+		"/v1/agent/check/fail/",
+		"/v1/agent/check/pass/",
+		"/v1/agent/check/update/",
+		"/v1/agent/check/warn/",
+		"/v1/agent/checks",
+		"/v1/agent/connect/authorize",
+		"/v1/agent/connect/ca/leaf/",
+		"/v1/agent/connect/ca/roots",
+		"/v1/agent/connect/proxy/",
+		"/v1/agent/self",
+		"/v1/agent/service/",
+		"/v1/agent/service/deregister/",
+		"/v1/agent/service/register",
+		"/v1/agent/services",
+		"/v1/catalog/connect/",
+		"/v1/connect/ca/roots",
+		"/v1/connect/intentions/check",
+		"/v1/connect/intentions/match",
+		"/v1/health/connect/",
+		"/v1/status/leader",
+	},
+}
+
+// HTTPEndpointProfileNames returns the sorted names of the endpoint profiles.
+func HTTPEndpointProfileNames() []string {
+	var names []string
+	for name := range HTTPEndpointProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseHTTPAllowedEndpoint splits an allowed endpoint entry into the methods
+// it allows and the endpoint pattern. The methods are an optional comma
+// separated list before the pattern, such as "GET,HEAD /v1/kv/". No methods
+// are returned when the entry allows all of them.
+func ParseHTTPAllowedEndpoint(entry string) ([]string, string) {
+	entry = strings.TrimSpace(entry)
+	i := strings.IndexAny(entry, " \t")
+	if i < 0 {
+		return nil, entry
+	}
+	var methods []string
+	for _, method := range strings.Split(entry[:i], ",") {
+		if method = strings.TrimSpace(method); method != "" {
+			methods = append(methods, strings.ToUpper(method))
+		}
+	}
+	return methods, strings.TrimSpace(entry[i:])
+}
@@ -55,6 +55,8 @@ func AddFlags(fs *flag.FlagSet, f *Flags) {
 	// command line flags ordered by flag name
 	add(&f.Config.AdvertiseAddrLAN, "advertise", "Sets the advertise address to use.")
 	add(&f.Config.AdvertiseAddrWAN, "advertise-wan", "Sets address to advertise on WAN instead of -advertise address.")
+	add(&f.Config.APIBootstrapFile, "api-bootstrap-file", "Path to a file to write the local HTTP API connection settings to for API clients.")
+	add(&f.Config.AutoEncrypt.TLS, "auto-encrypt-tls", "Enables requesting the certificate of the RPC connections to the servers from the servers.")
 	add(&f.Config.BindAddr, "bind", "Sets the bind address for cluster communication.")
 	add(&f.Config.Ports.Server, "server-port", "Sets the server port to listen on.")
 	add(&f.Config.Bootstrap, "bootstrap", "Sets server to bootstrap mode.")
@@ -79,6 +81,7 @@ func AddFlags(fs *flag.FlagSet, f *Flags) {
 	add(&f.Config.StartJoinAddrsLAN, "join", "Address of an agent to join at start time. Can be specified multiple times.")
 	add(&f.Config.StartJoinAddrsWAN, "join-wan", "Address of an agent to join -wan at start time. Can be specified multiple times.")
 	add(&f.Config.LogLevel, "log-level", "Log level of the agent.")
+	add(&f.Config.LogJSON, "log-json", "Output logs in JSON format.")
 	add(&f.Config.LogFile, "log-file", "Path to the file the logs get written to")
 	add(&f.Config.LogRotateBytes, "log-rotate-bytes", "Maximum number of bytes that should be written to a log file")
 	add(&f.Config.LogRotateDuration, "log-rotate-duration", "Time after which log rotation needs to be performed")
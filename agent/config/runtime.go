@@ -120,6 +120,13 @@ type RuntimeConfig struct {
 	// hcl: acl.enable_key_list_policy = (true|false)
 	ACLEnableKeyListPolicy bool
 
+	// ACLEnableServiceTokens is used to opt-in to requesting a token scoped
+	// to each Connect-enabled service registered through the HTTP API. The
+	// token is then used for the service instead of the registration token.
+	//
+	// hcl: acl.enable_service_tokens = (true|false)
+	ACLEnableServiceTokens bool
+
 	// ACLMasterToken is used to bootstrap the ACL system. It should be specified
 	// on the servers in the ACLDatacenter. When the leader comes online, it ensures
 	// that the Master token is available. This provides the initial token.
@@ -155,6 +162,22 @@ type RuntimeConfig struct {
 	// hcl: acl.token_ttl = "duration"
 	ACLPolicyTTL time.Duration
 
+	// ACLReplicationLagThreshold is the time since the last successful ACL
+	// replication after which the servers of the secondary datacenters
+	// report their ACL replication health check as critical. By default, it
+	// is set to 10 minutes.
+	//
+	// hcl: acl.replication_lag_threshold = "duration"
+	ACLReplicationLagThreshold time.Duration
+
+	// ACLFilterMemoSize is the maximum number of ACL filtered results of the
+	// catalog and health list queries a server memoizes, to share them
+	// between the requests with tokens linked to the same policies. Zero
+	// disables the memoization. By default, it is set to 1024.
+	//
+	// hcl: performance { acl_filter_memo_size = int }
+	ACLFilterMemoSize int
+
 	// ACLToken is the default token used to make requests if a per-request
 	// token is not provided. If not configured the 'anonymous' token is used.
 	//
@@ -212,6 +235,80 @@ type RuntimeConfig struct {
 	// hcl: autopilot { upgrade_version_tag = string }
 	AutopilotUpgradeVersionTag string
 
+	// AutoConfigEnabled enables the client agent to request its gossip
+	// encryption key, ACL settings, agent token and the certificate of its
+	// RPC connections from the servers, presenting the JWT of
+	// AutoConfigIntroToken or AutoConfigIntroTokenFile.
+	//
+	// hcl: auto_config { enabled = (true|false) }
+	AutoConfigEnabled bool
+
+	// AutoConfigIntroToken is the JWT presented to the servers.
+	//
+	// hcl: auto_config { intro_token = string }
+	AutoConfigIntroToken string
+
+	// AutoConfigIntroTokenFile is the path of the file holding the JWT
+	// presented to the servers.
+	//
+	// hcl: auto_config { intro_token_file = string }
+	AutoConfigIntroTokenFile string
+
+	// AutoConfigServerAddresses are the addresses of the servers the
+	// configuration is requested from.
+	//
+	// hcl: auto_config { server_addresses = []string }
+	AutoConfigServerAddresses []string
+
+	// AutoConfigAuthzEnabled enables the server to hand their configuration
+	// to the client agents presenting a valid JWT.
+	//
+	// hcl: auto_config { authorization { enabled = (true|false) } }
+	AutoConfigAuthzEnabled bool
+
+	// AutoConfigAuthzAgentTokenPolicies are the names of the policies
+	// linked to the agent tokens created for the client agents.
+	//
+	// hcl: auto_config { authorization { agent_token_policies = []string } }
+	AutoConfigAuthzAgentTokenPolicies []string
+
+	// AutoConfigAuthzJWTValidationPubKeys are the PEM encoded public keys
+	// verifying the signature of the JWTs.
+	//
+	// hcl: auto_config { authorization { static { jwt_validation_pub_keys = []string } } }
+	AutoConfigAuthzJWTValidationPubKeys []string
+
+	// AutoConfigAuthzBoundIssuer is the issuer the JWTs must have.
+	//
+	// hcl: auto_config { authorization { static { bound_issuer = string } } }
+	AutoConfigAuthzBoundIssuer string
+
+	// AutoConfigAuthzBoundAudiences are the audiences one of which the JWTs
+	// must have.
+	//
+	// hcl: auto_config { authorization { static { bound_audiences = []string } } }
+	AutoConfigAuthzBoundAudiences []string
+
+	// AutoConfigAuthzClaimAssertions maps the claims of the JWTs to the
+	// values they must have, which can refer to the node name and segment of
+	// the agent with ${node} and ${segment}.
+	//
+	// hcl: auto_config { authorization { static { claim_assertions = map[string]string } } }
+	AutoConfigAuthzClaimAssertions map[string]string
+
+	// AutoEncryptTLS enables the client agent to request the certificate of
+	// its RPC connections to the servers from the servers, which sign it
+	// with the Connect CA.
+	//
+	// hcl: auto_encrypt { tls = (true|false) }
+	AutoEncryptTLS bool
+
+	// AutoEncryptAllowTLS enables the server to issue the certificates
+	// requested by the client agents with auto-encrypt.
+	//
+	// hcl: auto_encrypt { allow_tls = (true|false) }
+	AutoEncryptAllowTLS bool
+
 	// DNSAllowStale is used to enable lookups with stale
 	// data. This gives horizontal read scalability since
 	// any Consul server can service the query instead of
@@ -343,11 +440,50 @@ type RuntimeConfig struct {
 	// hcl: http_config { allow_write_http_from = []string }
 	AllowWriteHTTPFrom []*net.IPNet
 
+	// HTTPEndpointProfile is the name of the profile the allowed endpoints
+	// of the HTTP API were generated from, if any.
+	//
+	// hcl: http_config { endpoint_profile = string }
+	HTTPEndpointProfile string
+
+	// HTTPAllowedEndpoints is the list of endpoint patterns the HTTP API
+	// serves, generated from the endpoint profile and the explicitly
+	// allowed endpoints. Any request to other endpoints will get a 404
+	// response. An empty slice means all endpoints are served.
+	//
+	// hcl: http_config { allowed_endpoints = []string }
+	HTTPAllowedEndpoints []string
+
 	// HTTPResponseHeaders are used to add HTTP header response fields to the HTTP API responses.
 	//
 	// hcl: http_config { response_headers = map[string]string }
 	HTTPResponseHeaders map[string]string
 
+	// HTTPCORSAllowedOrigins is the list of origins browsers are allowed
+	// to call the HTTP API from. "*" allows any origin. An empty list
+	// disables CORS.
+	//
+	// hcl: http_config { cors_allowed_origins = []string }
+	HTTPCORSAllowedOrigins []string
+
+	// HTTPCORSAllowedHeaders is the list of request headers allowed in
+	// cross-origin requests on top of Content-Type and X-Consul-Token.
+	//
+	// hcl: http_config { cors_allowed_headers = []string }
+	HTTPCORSAllowedHeaders []string
+
+	// HTTPCORSAllowCredentials allows cross-origin requests to include
+	// cookies and HTTP authentication.
+	//
+	// hcl: http_config { cors_allow_credentials = (true|false) }
+	HTTPCORSAllowCredentials bool
+
+	// HTTPCORSMaxAge is how long browsers may cache the response to a
+	// preflight request. Zero lets the browser use its default.
+	//
+	// hcl: http_config { cors_max_age = "duration" }
+	HTTPCORSMaxAge time.Duration
+
 	// Embed Telemetry Config
 	Telemetry lib.TelemetryConfig
 
@@ -395,6 +531,23 @@ type RuntimeConfig struct {
 	// hcl: advertise_addr_wan = string
 	AdvertiseAddrWAN *net.IPAddr
 
+	// APIBootstrapFile is the path of the file the agent writes its local
+	// HTTP API connection settings to once the HTTP servers are started.
+	// API clients on the host load it when CONSUL_API_BOOTSTRAP_FILE points
+	// to it. The file is removed on shutdown.
+	//
+	// hcl: api_bootstrap_file = string
+	// flag: -api-bootstrap-file string
+	APIBootstrapFile string
+
+	// APIBootstrapTokenFile is the path of a file holding the ACL token the
+	// API clients loading the APIBootstrapFile should use. The agent only
+	// writes the path to the bootstrap file, the token is maintained by the
+	// operator or a token provisioning tool.
+	//
+	// hcl: api_bootstrap_token_file = string
+	APIBootstrapTokenFile string
+
 	// BindAddr is used to control the address we bind to.
 	// If not specified, the first private IP we find is used.
 	// This controls the address we use for cluster facing
@@ -520,6 +673,16 @@ type RuntimeConfig struct {
 	// specified
 	ConnectSidecarMaxPort int
 
+	// ExposeMinPort is the inclusive start of the range of ports allocated to
+	// the agent for the listeners of the check paths exposed through Connect
+	// proxies.
+	ExposeMinPort int
+
+	// ExposeMaxPort is the inclusive end of the range of ports allocated to
+	// the agent for the listeners of the check paths exposed through Connect
+	// proxies.
+	ExposeMaxPort int
+
 	// ConnectProxyAllowManagedRoot is true if Consul can execute managed
 	// proxies when running as root (EUID == 0).
 	ConnectProxyAllowManagedRoot bool
@@ -551,6 +714,13 @@ type RuntimeConfig struct {
 	// ConnectCAConfig is the config to use for the CA provider.
 	ConnectCAConfig map[string]interface{}
 
+	// ConnectIntentionSuggestions enables reporting the connections the agent
+	// authorizes without matching any intention to the servers, which
+	// aggregate them into suggested intentions.
+	//
+	// hcl: connect { intention_suggestions = (true|false) }
+	ConnectIntentionSuggestions bool
+
 	// ConnectTestDisableManagedProxies is not exposed to public config but is
 	// used by TestAgent to prevent self-executing the test binary in the
 	// background if a managed proxy is created for a test. The only place we
@@ -710,6 +880,13 @@ type RuntimeConfig struct {
 	// flag: -encrypt string
 	EncryptKey string
 
+	// EncryptRotationInterval is the interval at which the leader rotates
+	// the gossip encryption key of the LAN pool of its datacenter. Zero
+	// disables the rotation.
+	//
+	// hcl: encrypt_rotation_interval = "duration"
+	EncryptRotationInterval time.Duration
+
 	// EncryptVerifyIncoming enforces incoming gossip encryption and can be
 	// used to upshift to encrypted gossip on a running cluster.
 	//
@@ -722,6 +899,26 @@ type RuntimeConfig struct {
 	// hcl: encrypt_verify_outgoing = (true|false)
 	EncryptVerifyOutgoing bool
 
+	// GCPercent is the garbage collection target percentage, as with the
+	// GOGC environment variable. Zero leaves the runtime default and -1
+	// turns off the collections triggered by the heap growth, which
+	// requires GCMemoryLimitBytes.
+	//
+	// hcl: performance { gc_percent = int }
+	GCPercent int
+
+	// GCBallastBytes is the size of an allocation kept alive to raise the
+	// heap size the next collection is triggered at.
+	//
+	// hcl: performance { gc_ballast_bytes = int }
+	GCBallastBytes int
+
+	// GCMemoryLimitBytes is a soft limit on the memory used by the agent,
+	// making the collections more frequent as it is approached.
+	//
+	// hcl: performance { gc_memory_limit_bytes = int }
+	GCMemoryLimitBytes int
+
 	// GRPCPort is the port the gRPC server listens on. Currently this only
 	// exposes the xDS and ext_authz APIs for Envoy and it is disabled by default.
 	//
@@ -805,11 +1002,25 @@ type RuntimeConfig struct {
 	// hcl: leave_on_terminate = (true|false)
 	LeaveOnTerm bool
 
+	// LogJSON formats the logs written to the console and to the log file as
+	// JSON objects.
+	//
+	// hcl: log_json = (true|false)
+	// flag: -log-json
+	LogJSON bool
+
 	// LogLevel is the level of the logs to write. Defaults to "INFO".
 	//
 	// hcl: log_level = string
 	LogLevel string
 
+	// LogSubsystemLevels are the levels of the logs to write for the
+	// subsystems, such as raft, serf, acl, dns or http, which don't log at
+	// LogLevel. (reloadable)
+	//
+	// hcl: log_subsystem_levels = map[string]string
+	LogSubsystemLevels map[string]string
+
 	// LogFile is the path to the file where the logs get written to. Defaults to empty string.
 	//
 	// hcl: log_file = string
@@ -905,6 +1116,27 @@ type RuntimeConfig struct {
 	// hcl: protocol = int
 	RPCProtocol int
 
+	// RaftApplyBatchMaxSize is the maximum number of writes the leader
+	// appends to its log and replicates in a single batch. Defaults to the
+	// Raft library default of 64.
+	//
+	// hcl: performance { raft_apply_batch_max_size = int }
+	RaftApplyBatchMaxSize int
+
+	// RaftApplyBatchMaxLatency is how long the server may hold a write to
+	// batch it with the concurrent ones. Writes are not held when zero,
+	// which is the default.
+	//
+	// hcl: performance { raft_apply_batch_max_latency = "duration" }
+	RaftApplyBatchMaxLatency time.Duration
+
+	// RaftApplyBatchAdaptive enables the adaptive batching of the writes.
+	// The writes are then only held when they are applied concurrently, for
+	// a duration growing with the load up to RaftApplyBatchMaxLatency.
+	//
+	// hcl: performance { raft_apply_batch_adaptive = (true|false) }
+	RaftApplyBatchAdaptive bool
+
 	// RaftProtocol sets the Raft protocol version to use on this server.
 	// Defaults to 3.
 	//
@@ -1342,6 +1574,13 @@ type RuntimeConfig struct {
 	// hcl: tls_prefer_server_cipher_suites = (true|false)
 	TLSPreferServerCipherSuites bool
 
+	// TLSReloadInterval is the interval at which the certificate, key and
+	// CA files are checked for changes, which are then loaded without
+	// reloading the agent. Zero disables the checks.
+	//
+	// hcl: tls_reload_interval = "duration"
+	TLSReloadInterval time.Duration
+
 	// TaggedAddresses are used to publish a set of addresses for
 	// for a node, which can be used by the remote agent. We currently
 	// populate only the "wan" tag based on the SerfWan advertise address,
@@ -1384,6 +1623,15 @@ type RuntimeConfig struct {
 	// hcl: unix_sockets { user = string }
 	UnixSocketUser string
 
+	// UseStreamingBackend enables the health of the services of the local
+	// datacenter to be streamed from the event publisher of the servers
+	// instead of long polling them with blocking queries. The streams are
+	// materialized by the agent, which serves the health endpoints and the
+	// subscriptions of its clients from them.
+	//
+	// hcl: use_streaming_backend = (true|false)
+	UseStreamingBackend bool
+
 	// VerifyIncoming is used to verify the authenticity of incoming
 	// connections. This means that TCP requests are forbidden, only allowing
 	// for TLS. TLS connections must match a provided certificate authority.
@@ -1598,6 +1846,7 @@ func (c *RuntimeConfig) ToTLSUtilConfig() tlsutil.Config {
 		CipherSuites:             c.TLSCipherSuites,
 		PreferServerCipherSuites: c.TLSPreferServerCipherSuites,
 		EnableAgentTLSForChecks:  c.EnableAgentTLSForChecks,
+		AutoEncryptTLS:           c.AutoEncryptTLS,
 	}
 }
 
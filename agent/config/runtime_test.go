@@ -13,6 +13,7 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -111,6 +112,28 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "-api-bootstrap-file",
+			args: []string{
+				`-api-bootstrap-file=a`,
+				`-data-dir=` + dataDir,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.APIBootstrapFile = "a"
+				rt.DataDir = dataDir
+			},
+		},
+		{
+			desc: "-auto-encrypt-tls",
+			args: []string{
+				`-auto-encrypt-tls`,
+				`-data-dir=` + dataDir,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.AutoEncryptTLS = true
+				rt.DataDir = dataDir
+			},
+		},
 		{
 			desc: "-bind",
 			args: []string{
@@ -1532,6 +1555,144 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{`bootstrap_expect = 3`},
 			err:  "'bootstrap_expect > 0' requires 'server = true'",
 		},
+		{
+			desc: "auto_encrypt.tls on server",
+			args: []string{
+				`-data-dir=` + dataDir,
+				`-server`,
+			},
+			json: []string{`{ "auto_encrypt": { "tls": true } }`},
+			hcl:  []string{`auto_encrypt { tls = true }`},
+			err:  "'auto_encrypt.tls = true' is only allowed on client agents",
+		},
+		{
+			desc: "auto_encrypt.allow_tls without server",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "auto_encrypt": { "allow_tls": true } }`},
+			hcl:  []string{`auto_encrypt { allow_tls = true }`},
+			err:  "'auto_encrypt.allow_tls = true' requires 'server = true'",
+		},
+		{
+			desc: "auto_encrypt.allow_tls without connect",
+			args: []string{
+				`-data-dir=` + dataDir,
+				`-server`,
+			},
+			json: []string{`{ "auto_encrypt": { "allow_tls": true } }`},
+			hcl:  []string{`auto_encrypt { allow_tls = true }`},
+			err:  "'auto_encrypt.allow_tls = true' requires 'connect.enabled = true'",
+		},
+		{
+			desc: "auto_config.enabled",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "ca_file": "/tmp/ca.pem", "auto_config": { "enabled": true, "intro_token_file": "/tmp/jwt", "server_addresses": ["10.0.0.1", "10.0.0.2"] } }`},
+			hcl:  []string{`ca_file = "/tmp/ca.pem" auto_config { enabled = true intro_token_file = "/tmp/jwt" server_addresses = ["10.0.0.1", "10.0.0.2"] }`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.CAFile = "/tmp/ca.pem"
+				rt.AutoConfigEnabled = true
+				rt.AutoConfigIntroTokenFile = "/tmp/jwt"
+				rt.AutoConfigServerAddresses = []string{"10.0.0.1", "10.0.0.2"}
+			},
+		},
+		{
+			desc: "auto_config.enabled on server",
+			args: []string{
+				`-data-dir=` + dataDir,
+				`-server`,
+			},
+			json: []string{`{ "auto_config": { "enabled": true, "intro_token": "abc", "server_addresses": ["10.0.0.1"] } }`},
+			hcl:  []string{`auto_config { enabled = true intro_token = "abc" server_addresses = ["10.0.0.1"] }`},
+			err:  "'auto_config.enabled = true' is only allowed on client agents",
+		},
+		{
+			desc: "auto_config.enabled without intro token",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "auto_config": { "enabled": true, "server_addresses": ["10.0.0.1"] } }`},
+			hcl:  []string{`auto_config { enabled = true server_addresses = ["10.0.0.1"] }`},
+			err:  "'auto_config.enabled = true' requires 'auto_config.intro_token' or 'auto_config.intro_token_file'",
+		},
+		{
+			desc: "auto_config.intro_token and intro_token_file",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "auto_config": { "enabled": true, "intro_token": "abc", "intro_token_file": "/tmp/jwt", "server_addresses": ["10.0.0.1"] } }`},
+			hcl:  []string{`auto_config { enabled = true intro_token = "abc" intro_token_file = "/tmp/jwt" server_addresses = ["10.0.0.1"] }`},
+			err:  "'auto_config.intro_token' and 'auto_config.intro_token_file' are mutually exclusive",
+		},
+		{
+			desc: "auto_config.enabled without server addresses",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "auto_config": { "enabled": true, "intro_token": "abc" } }`},
+			hcl:  []string{`auto_config { enabled = true intro_token = "abc" }`},
+			err:  "'auto_config.enabled = true' requires 'auto_config.server_addresses'",
+		},
+		{
+			desc: "auto_config.enabled without CA",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "auto_config": { "enabled": true, "intro_token": "abc", "server_addresses": ["10.0.0.1"] } }`},
+			hcl:  []string{`auto_config { enabled = true intro_token = "abc" server_addresses = ["10.0.0.1"] }`},
+			err:  "'auto_config.enabled = true' requires 'ca_file' or 'ca_path'",
+		},
+		{
+			desc: "auto_config.enabled with auto_encrypt.tls",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "ca_path": "/tmp/ca", "auto_config": { "enabled": true, "intro_token": "abc", "server_addresses": ["10.0.0.1"] }, "auto_encrypt": { "tls": true } }`},
+			hcl:  []string{`ca_path = "/tmp/ca" auto_config { enabled = true intro_token = "abc" server_addresses = ["10.0.0.1"] } auto_encrypt { tls = true }`},
+			err:  "'auto_config.enabled = true' and 'auto_encrypt.tls = true' are mutually exclusive",
+		},
+		{
+			desc: "auto_config.authorization without server",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "auto_config": { "authorization": { "enabled": true } } }`},
+			hcl:  []string{`auto_config { authorization { enabled = true } }`},
+			err:  "'auto_config.authorization.enabled = true' requires 'server = true'",
+		},
+		{
+			desc: "auto_config.authorization without connect",
+			args: []string{
+				`-data-dir=` + dataDir,
+				`-server`,
+			},
+			json: []string{`{ "auto_config": { "authorization": { "enabled": true } } }`},
+			hcl:  []string{`auto_config { authorization { enabled = true } }`},
+			err:  "'auto_config.authorization.enabled = true' requires 'connect.enabled = true'",
+		},
+		{
+			desc: "auto_config.authorization without public keys",
+			args: []string{
+				`-data-dir=` + dataDir,
+				`-server`,
+			},
+			json: []string{`{ "connect": { "enabled": true }, "auto_config": { "authorization": { "enabled": true } } }`},
+			hcl:  []string{`connect { enabled = true } auto_config { authorization { enabled = true } }`},
+			err:  "'auto_config.authorization.enabled = true' requires 'auto_config.authorization.static.jwt_validation_pub_keys'",
+		},
+		{
+			desc: "auto_config.authorization without node claim assertion",
+			args: []string{
+				`-data-dir=` + dataDir,
+				`-server`,
+			},
+			json: []string{`{ "connect": { "enabled": true }, "auto_config": { "authorization": { "enabled": true, "static": { "jwt_validation_pub_keys": ["abc"], "claim_assertions": { "sub": "web" } } } } }`},
+			hcl:  []string{`connect { enabled = true } auto_config { authorization { enabled = true static { jwt_validation_pub_keys = ["abc"] claim_assertions = { sub = "web" } } } }`},
+			err:  "'auto_config.authorization.enabled = true' requires a 'auto_config.authorization.static.claim_assertions' value with '${node}'",
+		},
 		{
 			desc: "bootstrap-expect invalid",
 			args: []string{
@@ -1719,6 +1880,68 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{`dns_config = { a_record_limit = -1 }`},
 			err:  "dns_config.a_record_limit cannot be -1. Must be greater than or equal to zero",
 		},
+		{
+			desc: "http_config.cors_allow_credentials with any origin",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "http_config": { "cors_allowed_origins": ["*"], "cors_allow_credentials": true } }`},
+			hcl:  []string{`http_config = { cors_allowed_origins = ["*"] cors_allow_credentials = true }`},
+			err:  `http_config.cors_allow_credentials cannot be used with the "*" origin. Please list the allowed origins`,
+		},
+		{
+			desc: "http_config.endpoint_profile and allowed_endpoints",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "http_config": { "endpoint_profile": "discovery-only", "allowed_endpoints": ["/v1/kv/", "/v1/status/leader"] } }`},
+			hcl:  []string{`http_config = { endpoint_profile = "discovery-only" allowed_endpoints = ["/v1/kv/", "/v1/status/leader"] }`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.HTTPEndpointProfile = "discovery-only"
+				rt.HTTPAllowedEndpoints = append([]string{"/v1/kv/", "/v1/status/leader"}, HTTPEndpointProfiles["discovery-only"]...)
+				sort.Strings(rt.HTTPAllowedEndpoints)
+			},
+		},
+		{
+			desc: "http_config.endpoint_profile unknown",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "http_config": { "endpoint_profile": "edge" } }`},
+			hcl:  []string{`http_config = { endpoint_profile = "edge" }`},
+			err:  `http_config.endpoint_profile "edge" is unknown. Must be one of discovery-only, mesh-sidecar`,
+		},
+		{
+			desc: "http_config.allowed_endpoints without leading slash",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "http_config": { "allowed_endpoints": ["v1/kv/"] } }`},
+			hcl:  []string{`http_config = { allowed_endpoints = ["v1/kv/"] }`},
+			err:  `http_config.allowed_endpoints entry "v1/kv/" is invalid. Must start with a "/"`,
+		},
+		{
+			desc: "http_config.allowed_endpoints with methods",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "http_config": { "allowed_endpoints": ["GET,HEAD /v1/kv/"] } }`},
+			hcl:  []string{`http_config = { allowed_endpoints = ["GET,HEAD /v1/kv/"] }`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.HTTPAllowedEndpoints = []string{"GET,HEAD /v1/kv/"}
+			},
+		},
+		{
+			desc: "http_config.allowed_endpoints with invalid method",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "http_config": { "allowed_endpoints": ["GET;PUT /v1/kv/"] } }`},
+			hcl:  []string{`http_config = { allowed_endpoints = ["GET;PUT /v1/kv/"] }`},
+			err:  `http_config.allowed_endpoints entry "GET;PUT /v1/kv/" has an invalid method "GET;PUT"`,
+		},
 		{
 			desc: "performance.raft_multiplier < 0",
 			args: []string{
@@ -1746,6 +1969,69 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{`performance = { raft_multiplier = 20 }`},
 			err:  `performance.raft_multiplier cannot be 20. Must be between 1 and 10`,
 		},
+		{
+			desc: "performance.raft_apply_batch_max_size > 1024",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "raft_apply_batch_max_size": 2048 } }`},
+			hcl:  []string{`performance = { raft_apply_batch_max_size = 2048 }`},
+			err:  `performance.raft_apply_batch_max_size cannot be 2048. Must be between 0 and 1024`,
+		},
+		{
+			desc: "performance.acl_filter_memo_size < 0",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "acl_filter_memo_size": -1 } }`},
+			hcl:  []string{`performance = { acl_filter_memo_size = -1 }`},
+			err:  `performance.acl_filter_memo_size cannot be -1. Must be greater than or equal to zero`,
+		},
+		{
+			desc: "performance.gc_percent < -1",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "gc_percent": -2 } }`},
+			hcl:  []string{`performance = { gc_percent = -2 }`},
+			err:  `performance.gc_percent cannot be -2. Must be -1 or greater`,
+		},
+		{
+			desc: "performance.gc_percent -1 without memory limit",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "gc_percent": -1 } }`},
+			hcl:  []string{`performance = { gc_percent = -1 }`},
+			err:  `performance.gc_percent can only be -1 with performance.gc_memory_limit_bytes set`,
+		},
+		{
+			desc: "performance.gc_ballast_bytes < 0",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "gc_ballast_bytes": -1 } }`},
+			hcl:  []string{`performance = { gc_ballast_bytes = -1 }`},
+			err:  `performance.gc_ballast_bytes cannot be -1. Must be greater than or equal to zero`,
+		},
+		{
+			desc: "performance.raft_apply_batch_max_latency < 0",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "raft_apply_batch_max_latency": "-1s" } }`},
+			hcl:  []string{`performance = { raft_apply_batch_max_latency = "-1s" }`},
+			err:  `performance.raft_apply_batch_max_latency cannot be -1s. Must be greater than or equal to zero`,
+		},
+		{
+			desc: "performance.raft_apply_batch_adaptive without max latency",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "performance": { "raft_apply_batch_adaptive": true } }`},
+			hcl:  []string{`performance = { raft_apply_batch_adaptive = true }`},
+			err:  `performance.raft_apply_batch_adaptive requires performance.raft_apply_batch_max_latency to be set`,
+		},
 		{
 			desc: "node_name invalid",
 			args: []string{
@@ -2026,6 +2312,24 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			hcl:  []string{` encrypt = "this is not a valid key" `},
 			err:  "encrypt has invalid key: illegal base64 data at input byte 4",
 		},
+		{
+			desc: "encrypt_rotation_interval < 0",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "encrypt_rotation_interval": "-1s" }`},
+			hcl:  []string{` encrypt_rotation_interval = "-1s" `},
+			err:  "encrypt_rotation_interval cannot be -1s. Must be greater than or equal to zero",
+		},
+		{
+			desc: "tls_reload_interval < 0",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "tls_reload_interval": "-1s" }`},
+			hcl:  []string{` tls_reload_interval = "-1s" `},
+			err:  "tls_reload_interval cannot be -1s. Must be greater than or equal to zero",
+		},
 		{
 			desc: "encrypt given but LAN keyring exists",
 			args: []string{
@@ -2061,6 +2365,37 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 			},
 			warns: []string{`WARNING: WAN keyring exists but -encrypt given, using keyring`},
 		},
+		{
+			desc: "secrets rendered from files and commands",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{
+				"acl": { "tokens": { "agent": "{{ file \"` + filepath.Join(dataDir, "agent-token") + `\" }}" } },
+				"encrypt": "{{ exec \"echo i0P+gFTkLPg0h53eNYjydg==\" }}"
+			}`},
+			hcl: []string{`
+				acl { tokens { agent = "{{ file \"` + filepath.Join(dataDir, "agent-token") + `\" }}" } }
+				encrypt = "{{ exec \"echo i0P+gFTkLPg0h53eNYjydg==\" }}"
+			`},
+			pre: func() {
+				writeFile(filepath.Join(dataDir, "agent-token"), []byte("6a3c7ba0-0b5e-4bb4-9d1c-d38a0e1d3c2a\n"))
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.ACLAgentToken = "6a3c7ba0-0b5e-4bb4-9d1c-d38a0e1d3c2a"
+				rt.EncryptKey = "i0P+gFTkLPg0h53eNYjydg=="
+				rt.DataDir = dataDir
+			},
+		},
+		{
+			desc: "secret command fails",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{ "acl": { "tokens": { "master": "{{ exec \"exit 3\" }}" } } }`},
+			hcl:  []string{`acl { tokens { master = "{{ exec \"exit 3\" }}" } }`},
+			err:  `acl.tokens.master: template: secret:1:3: executing "secret" at <exec "exit 3">: error calling exec: command "exit 3" failed: exit status 3`,
+		},
 		{
 			desc: "multiple check files",
 			args: []string{
@@ -2100,6 +2435,32 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "tcp check with tls",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{
+				`{ "check": { "name": "a", "tcp": "localhost:12345", "tcp_use_tls": true, "tls_server_name": "foo.example.com", "tls_ca_file": "ca.pem", "tls_cert_file": "cert.pem", "tls_key_file": "key.pem" } }`,
+			},
+			hcl: []string{
+				`check = { name = "a" tcp = "localhost:12345" tcp_use_tls = true tls_server_name = "foo.example.com" tls_ca_file = "ca.pem" tls_cert_file = "cert.pem" tls_key_file = "key.pem" }`,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.Checks = []*structs.CheckDefinition{
+					&structs.CheckDefinition{
+						Name:          "a",
+						TCP:           "localhost:12345",
+						TCPUseTLS:     true,
+						TLSServerName: "foo.example.com",
+						TLSCAFile:     "ca.pem",
+						TLSCertFile:   "cert.pem",
+						TLSKeyFile:    "key.pem",
+					},
+				}
+				rt.DataDir = dataDir
+			},
+		},
 		{
 			desc: "alias check with no node",
 			args: []string{
@@ -2118,6 +2479,24 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				rt.DataDir = dataDir
 			},
 		},
+		{
+			desc: "composite check",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{
+				`{ "check": { "name": "a", "composite": "db AND (cache1 OR cache2)" } }`,
+			},
+			hcl: []string{
+				`check = { name = "a", composite = "db AND (cache1 OR cache2)" }`,
+			},
+			patch: func(rt *RuntimeConfig) {
+				rt.Checks = []*structs.CheckDefinition{
+					&structs.CheckDefinition{Name: "a", Composite: "db AND (cache1 OR cache2)"},
+				}
+				rt.DataDir = dataDir
+			},
+		},
 		{
 			desc: "multiple service files",
 			args: []string{
@@ -2524,6 +2903,235 @@ func TestConfigFlagsAndEdgecases(t *testing.T) {
 				}
 			},
 		},
+		{
+			desc: "service.connect.sidecar_service with exposed paths",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{
+				  "service": {
+						"name": "web",
+						"port": 1234,
+						"connect": {
+							"sidecar_service": {
+								"proxy": {
+									"expose": {
+										"checks": true,
+										"paths": [
+											{
+												"path": "/metrics",
+												"local_path_port": 9090,
+												"listener_port": 21500
+											},
+											{
+												"path": "/grpc.health.v1.Health/Check",
+												"local_path_port": 9091,
+												"listener_port": 21501,
+												"protocol": "http2"
+											}
+										]
+									}
+								}
+							}
+						}
+					}
+				}`},
+			hcl: []string{`
+				service {
+					name = "web"
+					port = 1234
+					connect {
+						sidecar_service {
+							proxy {
+								expose {
+									checks = true
+									paths = [
+										{
+											path = "/metrics"
+											local_path_port = 9090
+											listener_port = 21500
+										},
+										{
+											path = "/grpc.health.v1.Health/Check"
+											local_path_port = 9091
+											listener_port = 21501
+											protocol = "http2"
+										}
+									]
+								}
+							}
+						}
+					}
+				}
+			`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.Services = []*structs.ServiceDefinition{
+					{
+						Name: "web",
+						Port: 1234,
+						Connect: &structs.ServiceConnect{
+							SidecarService: &structs.ServiceDefinition{
+								Proxy: &structs.ConnectProxyConfig{
+									Upstreams: structs.Upstreams{},
+									Expose: structs.ExposeConfig{
+										Checks: true,
+										Paths: []structs.ExposePath{
+											{
+												Path:          "/metrics",
+												LocalPathPort: 9090,
+												ListenerPort:  21500,
+											},
+											{
+												Path:          "/grpc.health.v1.Health/Check",
+												LocalPathPort: 9091,
+												ListenerPort:  21501,
+												Protocol:      "http2",
+											},
+										},
+									},
+								},
+								Weights: &structs.Weights{
+									Passing: 1,
+									Warning: 1,
+								},
+							},
+						},
+						Weights: &structs.Weights{
+							Passing: 1,
+							Warning: 1,
+						},
+					},
+				}
+			},
+		},
+		{
+			desc: "service.connect.sidecar_service with mesh gateway modes",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{
+				  "service": {
+						"name": "web",
+						"port": 1234,
+						"connect": {
+							"sidecar_service": {
+								"proxy": {
+									"mesh_gateway": {
+										"mode": "remote"
+									},
+									"upstreams": [
+										{
+											"destination_name": "db",
+											"datacenter": "dc2",
+											"local_bind_port": 7000,
+											"mesh_gateway": {
+												"mode": "local"
+											}
+										}
+									]
+								}
+							}
+						}
+					}
+				}`},
+			hcl: []string{`
+				service {
+					name = "web"
+					port = 1234
+					connect {
+						sidecar_service {
+							proxy {
+								mesh_gateway {
+									mode = "remote"
+								}
+								upstreams = [
+									{
+										destination_name = "db"
+										datacenter = "dc2"
+										local_bind_port = 7000
+										mesh_gateway {
+											mode = "local"
+										}
+									}
+								]
+							}
+						}
+					}
+				}
+			`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.Services = []*structs.ServiceDefinition{
+					{
+						Name: "web",
+						Port: 1234,
+						Connect: &structs.ServiceConnect{
+							SidecarService: &structs.ServiceDefinition{
+								Proxy: &structs.ConnectProxyConfig{
+									MeshGateway: structs.MeshGatewayConfig{
+										Mode: structs.MeshGatewayModeRemote,
+									},
+									Upstreams: structs.Upstreams{
+										structs.Upstream{
+											DestinationType: "service",
+											DestinationName: "db",
+											Datacenter:      "dc2",
+											LocalBindPort:   7000,
+											MeshGateway: structs.MeshGatewayConfig{
+												Mode: structs.MeshGatewayModeLocal,
+											},
+										},
+									},
+								},
+								Weights: &structs.Weights{
+									Passing: 1,
+									Warning: 1,
+								},
+							},
+						},
+						Weights: &structs.Weights{
+							Passing: 1,
+							Warning: 1,
+						},
+					},
+				}
+			},
+		},
+		{
+			desc: "service with mesh-gateway kind",
+			args: []string{
+				`-data-dir=` + dataDir,
+			},
+			json: []string{`{
+				  "service": {
+						"name": "mesh-gateway",
+						"kind": "mesh-gateway",
+						"port": 8443
+					}
+				}`},
+			hcl: []string{`
+				service {
+					name = "mesh-gateway"
+					kind = "mesh-gateway"
+					port = 8443
+				}
+			`},
+			patch: func(rt *RuntimeConfig) {
+				rt.DataDir = dataDir
+				rt.Services = []*structs.ServiceDefinition{
+					{
+						Kind: structs.ServiceKindMeshGateway,
+						Name: "mesh-gateway",
+						Port: 8443,
+						Weights: &structs.Weights{
+							Passing: 1,
+							Warning: 1,
+						},
+					},
+				}
+			},
+		},
 		{
 			// This tests that we correct added the nested paths to arrays of objects
 			// to the exceptions in patchSliceOfMaps in config.go (for service*s*)
@@ -2899,8 +3507,10 @@ func TestFullConfig(t *testing.T) {
 				"down_policy" : "03eb2aee",
 				"default_policy" : "72c2e7a0",
 				"enable_key_list_policy": false,
+				"enable_service_tokens": true,
 				"enable_token_persistence": true,
 				"policy_ttl": "1123s",
+				"replication_lag_threshold": "2139s",
 				"token_ttl": "3321s",
 				"enable_token_replication" : true,
 				"tokens" : {
@@ -2919,6 +3529,25 @@ func TestFullConfig(t *testing.T) {
 			},
 			"advertise_addr": "17.99.29.16",
 			"advertise_addr_wan": "78.63.37.19",
+			"api_bootstrap_file": "9pBnwMM3",
+			"api_bootstrap_token_file": "Wq7kRzB1",
+			"auto_config": {
+				"authorization": {
+					"enabled": true,
+					"agent_token_policies": ["Kq7Dv0Sx"],
+					"static": {
+						"jwt_validation_pub_keys": ["wOFGqm8V"],
+						"bound_issuer": "s6mI5xOv",
+						"bound_audiences": ["F6g4sR4J"],
+						"claim_assertions": {
+							"rL3bP2Hp": "${node}"
+						}
+					}
+				}
+			},
+			"auto_encrypt": {
+				"allow_tls": true
+			},
 			"autopilot": {
 				"cleanup_dead_servers": true,
 				"disable_upgrade_migration": true,
@@ -3013,9 +3642,12 @@ func TestFullConfig(t *testing.T) {
 					"rotation_period": "90h",
 					"leaf_cert_ttl": "1h",
 					"csr_max_per_second": 100,
-					"csr_max_concurrent": 2
+					"csr_max_concurrent": 2,
+					"trust_domain": "example.org",
+					"leaf_cert_dns_domains": ["svc.example.org"]
 				},
 				"enabled": true,
+				"intention_suggestions": true,
 				"proxy_defaults": {
 					"exec_mode": "script",
 					"daemon_command": ["consul", "connect", "proxy"],
@@ -3078,6 +3710,7 @@ func TestFullConfig(t *testing.T) {
 			"enable_local_script_checks": true,
 			"enable_syslog": true,
 			"encrypt": "A4wELWqH",
+			"encrypt_rotation_interval": "26h",
 			"encrypt_verify_incoming": true,
 			"encrypt_verify_outgoing": true,
 			"http_config": {
@@ -3086,7 +3719,13 @@ func TestFullConfig(t *testing.T) {
 				"response_headers": {
 					"M6TKa9NP": "xjuxjOzQ",
 					"JRCrHZed": "rl0mTx81"
-				}
+				},
+				"cors_allowed_origins": [ "https://dV1q4Wbk.example", "https://kf0Rh5Tc.example" ],
+				"cors_allowed_headers": [ "Oe2R8xCq" ],
+				"cors_allow_credentials": true,
+				"cors_max_age": "6917s",
+				"endpoint_profile": "mesh-sidecar",
+				"allowed_endpoints": [ "/v1/lJ8xq2Ta" ]
 			},
 			"key_file": "IEkkwgIA",
 			"leave_on_terminate": true,
@@ -3094,7 +3733,11 @@ func TestFullConfig(t *testing.T) {
 				"rpc_rate": 12029.43,
 				"rpc_max_burst": 44848
 			},
+			"log_json": true,
 			"log_level": "k1zo9Spt",
+			"log_subsystem_levels": {
+				"raft": "Cs7I2ZmF"
+			},
 			"node_id": "AsUIlw99",
 			"node_meta": {
 				"5mgGQMBk": "mJLtVMSG",
@@ -3103,9 +3746,16 @@ func TestFullConfig(t *testing.T) {
 			"node_name": "otlLxGaI",
 			"non_voting_server": true,
 			"performance": {
+				"acl_filter_memo_size": 6723,
 				"leave_drain_time": "8265s",
 				"raft_multiplier": 5,
-				"rpc_hold_timeout": "15707s"
+				"rpc_hold_timeout": "15707s",
+				"raft_apply_batch_max_size": 512,
+				"raft_apply_batch_max_latency": "7ms",
+				"raft_apply_batch_adaptive": true,
+				"gc_percent": 150,
+				"gc_ballast_bytes": 24904,
+				"gc_memory_limit_bytes": 77311
 			},
 			"pid_file": "43xN80Km",
 			"ports": {
@@ -3117,7 +3767,9 @@ func TestFullConfig(t *testing.T) {
 				"proxy_min_port": 2000,
 				"proxy_max_port": 3000,
 				"sidecar_min_port": 8888,
-				"sidecar_max_port": 9999
+				"sidecar_max_port": 9999,
+				"expose_min_port": 1111,
+				"expose_max_port": 2222
 			},
 			"protocol": 30793,
 			"primary_datacenter": "ejtmd43d",
@@ -3245,6 +3897,15 @@ func TestFullConfig(t *testing.T) {
 					"name": "7IszXMQ1",
 					"tags": ["0Zwg8l6v", "zebELdN5"],
 					"address": "9RhqPSPB",
+					"tagged_addresses": {
+						"wan": {
+							"address": "198.18.2.4",
+							"port": 6428
+						}
+					},
+					"labels": {
+						"team": "aGd2Nmqu"
+					},
 					"token": "myjKJkWH",
 					"port": 72219,
 					"enable_tag_override": true,
@@ -3407,6 +4068,7 @@ func TestFullConfig(t *testing.T) {
 			"tls_cipher_suites": "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
 			"tls_min_version": "pAOWafkR",
 			"tls_prefer_server_cipher_suites": true,
+			"tls_reload_interval": "37s",
 			"translate_wan_addrs": true,
 			"ui": true,
 			"ui_dir": "11IFzAUn",
@@ -3415,6 +4077,7 @@ func TestFullConfig(t *testing.T) {
 				"mode": "E8sAwOv4",
 				"user": "E0nB1DwA"
 			},
+			"use_streaming_backend": true,
 			"verify_incoming": true,
 			"verify_incoming_https": true,
 			"verify_incoming_rpc": true,
@@ -3451,8 +4114,10 @@ func TestFullConfig(t *testing.T) {
 				down_policy = "03eb2aee"
 				default_policy = "72c2e7a0"
 				enable_key_list_policy = false
+				enable_service_tokens = true
 				enable_token_persistence = true
 				policy_ttl = "1123s"
+				replication_lag_threshold = "2139s"
 				token_ttl = "3321s"
 				enable_token_replication = true
 				tokens = {
@@ -3471,6 +4136,25 @@ func TestFullConfig(t *testing.T) {
 			}
 			advertise_addr = "17.99.29.16"
 			advertise_addr_wan = "78.63.37.19"
+			api_bootstrap_file = "9pBnwMM3"
+			api_bootstrap_token_file = "Wq7kRzB1"
+			auto_config = {
+				authorization = {
+					enabled = true
+					agent_token_policies = ["Kq7Dv0Sx"]
+					static = {
+						jwt_validation_pub_keys = ["wOFGqm8V"]
+						bound_issuer = "s6mI5xOv"
+						bound_audiences = ["F6g4sR4J"]
+						claim_assertions = {
+							rL3bP2Hp = "${node}"
+						}
+					}
+				}
+			}
+			auto_encrypt = {
+				allow_tls = true
+			}
 			autopilot = {
 				cleanup_dead_servers = true
 				disable_upgrade_migration = true
@@ -3568,8 +4252,11 @@ func TestFullConfig(t *testing.T) {
 					# assert against the same thing
 					csr_max_per_second = 100.0
 					csr_max_concurrent = 2.0
+					trust_domain = "example.org"
+					leaf_cert_dns_domains = ["svc.example.org"]
 				}
 				enabled = true
+				intention_suggestions = true
 				proxy_defaults {
 					exec_mode = "script"
 					daemon_command = ["consul", "connect", "proxy"]
@@ -3634,6 +4321,7 @@ func TestFullConfig(t *testing.T) {
 			enable_local_script_checks = true
 			enable_syslog = true
 			encrypt = "A4wELWqH"
+			encrypt_rotation_interval = "26h"
 			encrypt_verify_incoming = true
 			encrypt_verify_outgoing = true
 			http_config {
@@ -3643,6 +4331,12 @@ func TestFullConfig(t *testing.T) {
 					"M6TKa9NP" = "xjuxjOzQ"
 					"JRCrHZed" = "rl0mTx81"
 				}
+				cors_allowed_origins = [ "https://dV1q4Wbk.example", "https://kf0Rh5Tc.example" ]
+				cors_allowed_headers = [ "Oe2R8xCq" ]
+				cors_allow_credentials = true
+				cors_max_age = "6917s"
+				endpoint_profile = "mesh-sidecar"
+				allowed_endpoints = [ "/v1/lJ8xq2Ta" ]
 			}
 			key_file = "IEkkwgIA"
 			leave_on_terminate = true
@@ -3650,7 +4344,11 @@ func TestFullConfig(t *testing.T) {
 				rpc_rate = 12029.43
 				rpc_max_burst = 44848
 			}
+			log_json = true
 			log_level = "k1zo9Spt"
+			log_subsystem_levels {
+				raft = "Cs7I2ZmF"
+			}
 			node_id = "AsUIlw99"
 			node_meta {
 				"5mgGQMBk" = "mJLtVMSG"
@@ -3659,9 +4357,16 @@ func TestFullConfig(t *testing.T) {
 			node_name = "otlLxGaI"
 			non_voting_server = true
 			performance {
+				acl_filter_memo_size = 6723
 				leave_drain_time = "8265s"
 				raft_multiplier = 5
 				rpc_hold_timeout = "15707s"
+				raft_apply_batch_max_size = 512
+				raft_apply_batch_max_latency = "7ms"
+				raft_apply_batch_adaptive = true
+				gc_percent = 150
+				gc_ballast_bytes = 24904
+				gc_memory_limit_bytes = 77311
 			}
 			pid_file = "43xN80Km"
 			ports {
@@ -3674,6 +4379,8 @@ func TestFullConfig(t *testing.T) {
 				proxy_max_port = 3000
 				sidecar_min_port = 8888
 				sidecar_max_port = 9999
+				expose_min_port = 1111
+				expose_max_port = 2222
 			}
 			protocol = 30793
 			primary_datacenter = "ejtmd43d"
@@ -3801,6 +4508,15 @@ func TestFullConfig(t *testing.T) {
 					name = "7IszXMQ1"
 					tags = ["0Zwg8l6v", "zebELdN5"]
 					address = "9RhqPSPB"
+					tagged_addresses = {
+						wan = {
+							address = "198.18.2.4"
+							port = 6428
+						}
+					}
+					labels = {
+						team = "aGd2Nmqu"
+					}
 					token = "myjKJkWH"
 					port = 72219
 					enable_tag_override = true
@@ -3963,6 +4679,7 @@ func TestFullConfig(t *testing.T) {
 			tls_cipher_suites = "TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"
 			tls_min_version = "pAOWafkR"
 			tls_prefer_server_cipher_suites = true
+			tls_reload_interval = "37s"
 			translate_wan_addrs = true
 			ui = true
 			ui_dir = "11IFzAUn"
@@ -3971,6 +4688,7 @@ func TestFullConfig(t *testing.T) {
 				mode = "E8sAwOv4"
 				user = "E0nB1DwA"
 			}
+			use_streaming_backend = true
 			verify_incoming = true
 			verify_incoming_https = true
 			verify_incoming_rpc = true
@@ -4114,36 +4832,48 @@ func TestFullConfig(t *testing.T) {
 
 		// user configurable values
 
-		ACLAgentMasterToken:              "64fd0e08",
-		ACLAgentToken:                    "bed2377c",
-		ACLsEnabled:                      true,
-		ACLDatacenter:                    "ejtmd43d",
-		ACLDefaultPolicy:                 "72c2e7a0",
-		ACLDownPolicy:                    "03eb2aee",
-		ACLEnforceVersion8:               true,
-		ACLEnableKeyListPolicy:           false,
-		ACLEnableTokenPersistence:        true,
-		ACLMasterToken:                   "8a19ac27",
-		ACLReplicationToken:              "5795983a",
-		ACLTokenTTL:                      3321 * time.Second,
-		ACLPolicyTTL:                     1123 * time.Second,
-		ACLToken:                         "418fdff1",
-		ACLTokenReplication:              true,
-		AdvertiseAddrLAN:                 ipAddr("17.99.29.16"),
-		AdvertiseAddrWAN:                 ipAddr("78.63.37.19"),
-		AutopilotCleanupDeadServers:      true,
-		AutopilotDisableUpgradeMigration: true,
-		AutopilotLastContactThreshold:    12705 * time.Second,
-		AutopilotMaxTrailingLogs:         17849,
-		AutopilotRedundancyZoneTag:       "3IsufDJf",
-		AutopilotServerStabilizationTime: 23057 * time.Second,
-		AutopilotUpgradeVersionTag:       "W9pDwFAL",
-		BindAddr:                         ipAddr("16.99.34.17"),
-		Bootstrap:                        true,
-		BootstrapExpect:                  53,
-		CAFile:                           "erA7T0PM",
-		CAPath:                           "mQEN1Mfp",
-		CertFile:                         "7s4QAzDk",
+		ACLAgentMasterToken:                 "64fd0e08",
+		ACLAgentToken:                       "bed2377c",
+		ACLsEnabled:                         true,
+		ACLDatacenter:                       "ejtmd43d",
+		ACLDefaultPolicy:                    "72c2e7a0",
+		ACLDownPolicy:                       "03eb2aee",
+		ACLEnforceVersion8:                  true,
+		ACLEnableKeyListPolicy:              false,
+		ACLEnableServiceTokens:              true,
+		ACLEnableTokenPersistence:           true,
+		ACLMasterToken:                      "8a19ac27",
+		ACLReplicationToken:                 "5795983a",
+		ACLReplicationLagThreshold:          2139 * time.Second,
+		ACLTokenTTL:                         3321 * time.Second,
+		ACLPolicyTTL:                        1123 * time.Second,
+		ACLFilterMemoSize:                   6723,
+		ACLToken:                            "418fdff1",
+		ACLTokenReplication:                 true,
+		AdvertiseAddrLAN:                    ipAddr("17.99.29.16"),
+		AdvertiseAddrWAN:                    ipAddr("78.63.37.19"),
+		APIBootstrapFile:                    "9pBnwMM3",
+		APIBootstrapTokenFile:               "Wq7kRzB1",
+		AutoConfigAuthzEnabled:              true,
+		AutoConfigAuthzAgentTokenPolicies:   []string{"Kq7Dv0Sx"},
+		AutoConfigAuthzJWTValidationPubKeys: []string{"wOFGqm8V"},
+		AutoConfigAuthzBoundIssuer:          "s6mI5xOv",
+		AutoConfigAuthzBoundAudiences:       []string{"F6g4sR4J"},
+		AutoConfigAuthzClaimAssertions:      map[string]string{"rL3bP2Hp": "${node}"},
+		AutoEncryptAllowTLS:                 true,
+		AutopilotCleanupDeadServers:         true,
+		AutopilotDisableUpgradeMigration:    true,
+		AutopilotLastContactThreshold:       12705 * time.Second,
+		AutopilotMaxTrailingLogs:            17849,
+		AutopilotRedundancyZoneTag:          "3IsufDJf",
+		AutopilotServerStabilizationTime:    23057 * time.Second,
+		AutopilotUpgradeVersionTag:          "W9pDwFAL",
+		BindAddr:                            ipAddr("16.99.34.17"),
+		Bootstrap:                           true,
+		BootstrapExpect:                     53,
+		CAFile:                              "erA7T0PM",
+		CAPath:                              "mQEN1Mfp",
+		CertFile:                            "7s4QAzDk",
 		Checks: []*structs.CheckDefinition{
 			&structs.CheckDefinition{
 				ID:         "uAjE6m9Z",
@@ -4222,13 +4952,20 @@ func TestFullConfig(t *testing.T) {
 		ConnectProxyBindMaxPort: 3000,
 		ConnectSidecarMinPort:   8888,
 		ConnectSidecarMaxPort:   9999,
+		ExposeMinPort:           1111,
+		ExposeMaxPort:           2222,
 		ConnectCAProvider:       "consul",
 		ConnectCAConfig: map[string]interface{}{
 			"RotationPeriod":   "90h",
 			"LeafCertTTL":      "1h",
 			"CSRMaxPerSecond":  float64(100),
 			"CSRMaxConcurrent": float64(2),
+			"TrustDomain":      "example.org",
+			"LeafCertDNSDomains": []interface{}{
+				"svc.example.org",
+			},
 		},
+		ConnectIntentionSuggestions:             true,
 		ConnectProxyAllowManagedRoot:            false,
 		ConnectProxyAllowManagedAPIRegistration: false,
 		ConnectProxyDefaultExecMode:             "script",
@@ -4276,8 +5013,12 @@ func TestFullConfig(t *testing.T) {
 		EnableSyslog:                     true,
 		EnableUI:                         true,
 		EncryptKey:                       "A4wELWqH",
+		EncryptRotationInterval:          26 * time.Hour,
 		EncryptVerifyIncoming:            true,
 		EncryptVerifyOutgoing:            true,
+		GCBallastBytes:                   24904,
+		GCMemoryLimitBytes:               77311,
+		GCPercent:                        150,
 		GRPCPort:                         4881,
 		GRPCAddrs:                        []net.Addr{tcpAddr("32.31.61.91:4881")},
 		HTTPAddrs:                        []net.Addr{tcpAddr("83.39.91.39:7999")},
@@ -4285,12 +5026,24 @@ func TestFullConfig(t *testing.T) {
 		AllowWriteHTTPFrom:               []*net.IPNet{cidr("127.0.0.0/8"), cidr("22.33.44.55/32"), cidr("0.0.0.0/0")},
 		HTTPPort:                         7999,
 		HTTPResponseHeaders:              map[string]string{"M6TKa9NP": "xjuxjOzQ", "JRCrHZed": "rl0mTx81"},
+		HTTPCORSAllowedOrigins:           []string{"https://dV1q4Wbk.example", "https://kf0Rh5Tc.example"},
+		HTTPCORSAllowedHeaders:           []string{"Oe2R8xCq"},
+		HTTPCORSAllowCredentials:         true,
+		HTTPCORSMaxAge:                   6917 * time.Second,
+		HTTPEndpointProfile:              "mesh-sidecar",
+		HTTPAllowedEndpoints: func() []string {
+			e := append([]string{"/v1/lJ8xq2Ta"}, HTTPEndpointProfiles["mesh-sidecar"]...)
+			sort.Strings(e)
+			return e
+		}(),
 		HTTPSAddrs:                       []net.Addr{tcpAddr("95.17.17.19:15127")},
 		HTTPSPort:                        15127,
 		KeyFile:                          "IEkkwgIA",
 		LeaveDrainTime:                   8265 * time.Second,
 		LeaveOnTerm:                      true,
+		LogJSON:                          true,
 		LogLevel:                         "k1zo9Spt",
+		LogSubsystemLevels:               map[string]string{"raft": "Cs7I2ZmF"},
 		NodeID:                           types.NodeID("AsUIlw99"),
 		NodeMeta:                         map[string]string{"5mgGQMBk": "mJLtVMSG", "A7ynFMJB": "0Nx6RGab"},
 		NodeName:                         "otlLxGaI",
@@ -4303,6 +5056,9 @@ func TestFullConfig(t *testing.T) {
 		RPCProtocol:                      30793,
 		RPCRateLimit:                     12029.43,
 		RPCMaxBurst:                      44848,
+		RaftApplyBatchAdaptive:           true,
+		RaftApplyBatchMaxLatency:         7 * time.Millisecond,
+		RaftApplyBatchMaxSize:            512,
 		RaftProtocol:                     19016,
 		RaftSnapshotThreshold:            16384,
 		RaftSnapshotInterval:             30 * time.Second,
@@ -4341,8 +5097,12 @@ func TestFullConfig(t *testing.T) {
 				Name:    "7IszXMQ1",
 				Tags:    []string{"0Zwg8l6v", "zebELdN5"},
 				Address: "9RhqPSPB",
-				Token:   "myjKJkWH",
-				Port:    72219,
+				TaggedAddresses: map[string]structs.ServiceAddress{
+					"wan": {Address: "198.18.2.4", Port: 6428},
+				},
+				Labels: map[string]string{"team": "aGd2Nmqu"},
+				Token:  "myjKJkWH",
+				Port:   72219,
 				Weights: &structs.Weights{
 					Passing: 1,
 					Warning: 1,
@@ -4606,6 +5366,7 @@ func TestFullConfig(t *testing.T) {
 		TLSCipherSuites:             []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305, tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384},
 		TLSMinVersion:               "pAOWafkR",
 		TLSPreferServerCipherSuites: true,
+		TLSReloadInterval:           37 * time.Second,
 		TaggedAddresses: map[string]string{
 			"7MYgHrYH": "dALJAhLD",
 			"h6DdBy6K": "ebrr9zZ8",
@@ -4617,6 +5378,7 @@ func TestFullConfig(t *testing.T) {
 		UnixSocketUser:       "E0nB1DwA",
 		UnixSocketGroup:      "8pFodrV8",
 		UnixSocketMode:       "E8sAwOv4",
+		UseStreamingBackend:  true,
 		VerifyIncoming:       true,
 		VerifyIncomingHTTPS:  true,
 		VerifyIncomingRPC:    true,
@@ -4940,18 +5702,35 @@ func TestSanitize(t *testing.T) {
 		"ACLDisabledTTL": "0s",
 		"ACLDownPolicy": "",
 		"ACLEnableKeyListPolicy": false,
+		"ACLEnableServiceTokens": false,
 		"ACLEnableTokenPersistence": false,
 		"ACLEnforceVersion8": false,
+		"ACLFilterMemoSize": 0,
 		"ACLMasterToken": "hidden",
 		"ACLPolicyTTL": "0s",
+		"ACLReplicationLagThreshold": "0s",
 		"ACLReplicationToken": "hidden",
 		"ACLTokenReplication": false,
 		"ACLTokenTTL": "0s",
 		"ACLToken": "hidden",
 		"ACLsEnabled": false,
 		"AEInterval": "0s",
+		"APIBootstrapFile": "",
+		"APIBootstrapTokenFile": "hidden",
 		"AdvertiseAddrLAN": "",
 		"AdvertiseAddrWAN": "",
+		"AutoConfigAuthzAgentTokenPolicies": [],
+		"AutoConfigAuthzBoundAudiences": [],
+		"AutoConfigAuthzBoundIssuer": "",
+		"AutoConfigAuthzClaimAssertions": {},
+		"AutoConfigAuthzEnabled": false,
+		"AutoConfigAuthzJWTValidationPubKeys": [],
+		"AutoConfigEnabled": false,
+		"AutoConfigIntroToken": "hidden",
+		"AutoConfigIntroTokenFile": "hidden",
+		"AutoConfigServerAddresses": [],
+		"AutoEncryptAllowTLS": false,
+		"AutoEncryptTLS": false,
 		"AutopilotCleanupDeadServers": false,
 		"AutopilotDisableUpgradeMigration": false,
 		"AutopilotLastContactThreshold": "0s",
@@ -4971,6 +5750,7 @@ func TestSanitize(t *testing.T) {
 		"Checks": [{
 			"AliasNode": "",
 			"AliasService": "",
+			"Composite": "",
 			"DeregisterCriticalServiceAfter": "0s",
 			"DockerContainerID": "",
 			"GRPC": "",
@@ -4987,6 +5767,11 @@ func TestSanitize(t *testing.T) {
 			"Shell": "",
 			"Status": "",
 			"TCP": "",
+			"TCPUseTLS": false,
+			"TLSCAFile": "",
+			"TLSCertFile": "",
+			"TLSKeyFile": "hidden",
+			"TLSServerName": "",
 			"TLSSkipVerify": false,
 			"TTL": "0s",
 			"Timeout": "0s",
@@ -4996,6 +5781,7 @@ func TestSanitize(t *testing.T) {
 		"ConnectCAConfig": {},
 		"ConnectCAProvider": "",
 		"ConnectEnabled": false,
+		"ConnectIntentionSuggestions": false,
 		"ConnectProxyAllowManagedAPIRegistration": false,
 		"ConnectProxyAllowManagedRoot": false,
 		"ConnectProxyBindMaxPort": 0,
@@ -5006,6 +5792,8 @@ func TestSanitize(t *testing.T) {
 		"ConnectProxyDefaultScriptCommand": [],
 		"ConnectSidecarMaxPort": 0,
 		"ConnectSidecarMinPort": 0,
+		"ExposeMaxPort": 0,
+		"ExposeMinPort": 0,
 		"ConnectTestCALeafRootChangeSpread": "0s",
 		"ConnectTestDisableManagedProxies": false,
 		"ConsulCoordinateUpdateBatchSize": 0,
@@ -5072,15 +5860,25 @@ func TestSanitize(t *testing.T) {
 		"EnableSyslog": false,
 		"EnableUI": false,
 		"EncryptKey": "hidden",
+		"EncryptRotationInterval": "0s",
 		"EncryptVerifyIncoming": false,
 		"EncryptVerifyOutgoing": false,
+		"GCBallastBytes": 0,
+		"GCMemoryLimitBytes": 0,
+		"GCPercent": 0,
 		"GRPCAddrs": [],
 		"GRPCPort": 0,
 		"HTTPAddrs": [
 			"tcp://1.2.3.4:5678",
 			"unix:///var/run/foo"
 		],
+		"HTTPAllowedEndpoints": [],
 		"HTTPBlockEndpoints": [],
+		"HTTPCORSAllowCredentials": false,
+		"HTTPCORSAllowedHeaders": [],
+		"HTTPCORSAllowedOrigins": [],
+		"HTTPCORSMaxAge": "0s",
+		"HTTPEndpointProfile": "",
 		"HTTPPort": 0,
 		"HTTPResponseHeaders": {},
 		"HTTPSAddrs": [],
@@ -5088,7 +5886,9 @@ func TestSanitize(t *testing.T) {
 		"KeyFile": "hidden",
 		"LeaveDrainTime": "0s",
 		"LeaveOnTerm": false,
+		"LogJSON": false,
 		"LogLevel": "",
+		"LogSubsystemLevels": {},
 		"LogFile": "",
 		"LogRotateBytes": 0,
 		"LogRotateDuration": "0s",
@@ -5104,6 +5904,9 @@ func TestSanitize(t *testing.T) {
 		"RPCMaxBurst": 0,
 		"RPCProtocol": 0,
 		"RPCRateLimit": 0,
+		"RaftApplyBatchAdaptive": false,
+		"RaftApplyBatchMaxLatency": "0s",
+		"RaftApplyBatchMaxSize": 0,
 		"RaftProtocol": 0,
 		"RaftSnapshotInterval": "0s",
 		"RaftSnapshotThreshold": 0,
@@ -5140,6 +5943,7 @@ func TestSanitize(t *testing.T) {
 				"AliasNode": "",
 				"AliasService": "",
 				"CheckID": "",
+				"Composite": "",
 				"DeregisterCriticalServiceAfter": "0s",
 				"DockerContainerID": "",
 				"GRPC": "",
@@ -5154,6 +5958,11 @@ func TestSanitize(t *testing.T) {
 				"Shell": "",
 				"Status": "",
 				"TCP": "",
+				"TCPUseTLS": false,
+				"TLSCAFile": "",
+				"TLSCertFile": "",
+				"TLSKeyFile": "hidden",
+				"TLSServerName": "",
 				"TLSSkipVerify": false,
 				"TTL": "0s",
 				"Timeout": "0s"
@@ -5163,11 +5972,13 @@ func TestSanitize(t *testing.T) {
 			"EnableTagOverride": false,
 			"ID": "",
 			"Kind": "",
+			"Labels": {},
 			"Meta": {},
 			"Name": "foo",
 			"Port": 0,
 			"Proxy": null,
 			"ProxyDestination": "",
+			"TaggedAddresses": {},
 			"Tags": [],
 			"Token": "hidden",
 			"Weights": {
@@ -5185,6 +5996,7 @@ func TestSanitize(t *testing.T) {
 		"TLSCipherSuites": [],
 		"TLSMinVersion": "",
 		"TLSPreferServerCipherSuites": false,
+		"TLSReloadInterval": "0s",
 		"TaggedAddresses": {},
 		"Telemetry": {
 			"AllowedPrefixes": [],
@@ -5216,6 +6028,7 @@ func TestSanitize(t *testing.T) {
 		"UnixSocketGroup": "",
 		"UnixSocketMode": "",
 		"UnixSocketUser": "",
+		"UseStreamingBackend": false,
 		"VerifyIncoming": false,
 		"VerifyIncomingHTTPS": false,
 		"VerifyIncomingRPC": false,
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/hashicorp/consul/agent/exec"
+)
+
+// secretCommandTimeout is how long a command producing a secret may run
+// before it's killed.
+const secretCommandTimeout = 30 * time.Second
+
+// renderSecret renders a configuration value that may hold a secret. Values
+// containing a template action are rendered with the `file` and `exec`
+// functions, which return the content of a file or the output of a command
+// run through a shell with the surrounding whitespace trimmed, so that the
+// secrets don't have to be written to the configuration files. Other values
+// are returned as is.
+//
+// The values are rendered each time the configuration is built, so the
+// secrets are fetched again when the configuration is reloaded.
+func renderSecret(v string) (string, error) {
+	if !strings.Contains(v, "{{") {
+		return v, nil
+	}
+
+	tmpl, err := template.New("secret").Funcs(template.FuncMap{
+		"file": secretFile,
+		"exec": secretCommand,
+	}).Parse(v)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// secretFile returns the content of the file with the surrounding
+// whitespace trimmed.
+func secretFile(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// secretCommand runs the command through a shell and returns its output
+// with the surrounding whitespace trimmed.
+func secretCommand(script string) (string, error) {
+	cmd, err := exec.Script(script)
+	if err != nil {
+		return "", err
+	}
+	exec.SetSysProcAttr(cmd)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return "", err
+	}
+
+	waitCh := make(chan error, 1)
+	go func() {
+		waitCh <- cmd.Wait()
+	}()
+	select {
+	case err := <-waitCh:
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("command %q failed: %v: %s", script, err, msg)
+			}
+			return "", fmt.Errorf("command %q failed: %v", script, err)
+		}
+	case <-time.After(secretCommandTimeout):
+		exec.KillCommandSubtree(cmd)
+		<-waitCh
+		return "", fmt.Errorf("command %q timed out after %s", script, secretCommandTimeout)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
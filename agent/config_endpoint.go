@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Config switches on the different CRUD operations for config entries.
+func (s *HTTPServer) Config(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		return s.configGet(resp, req)
+
+	case "DELETE":
+		return s.configDelete(resp, req)
+
+	default:
+		return nil, MethodNotAllowedError{req.Method, []string{"GET", "DELETE"}}
+	}
+}
+
+// GET /v1/config/:kind
+// GET /v1/config/:kind/:name
+func (s *HTTPServer) configGet(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.ConfigEntryQuery
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	pathArgs := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/v1/config/"), "/", 2)
+
+	switch len(pathArgs) {
+	case 2:
+		// Both kind/name provided.
+		args.Kind = pathArgs[0]
+		args.Name = pathArgs[1]
+
+		var reply structs.ConfigEntryResponse
+		defer setMeta(resp, &reply.QueryMeta)
+		if err := s.agent.RPC("ConfigEntry.Get", &args, &reply); err != nil {
+			return nil, err
+		}
+		if reply.Entry == nil {
+			setMeta(resp, &reply.QueryMeta)
+			resp.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(resp, "Config entry not found for %q / %q", args.Kind, args.Name)
+			return nil, nil
+		}
+
+		return reply.Entry, nil
+	case 1:
+		if pathArgs[0] == "" {
+			return nil, BadRequestError{Reason: "Must provide a config entry kind"}
+		}
+
+		// Only kind provided, list entries.
+		args.Kind = pathArgs[0]
+
+		var reply structs.IndexedConfigEntries
+		defer setMeta(resp, &reply.QueryMeta)
+		if err := s.agent.RPC("ConfigEntry.List", &args, &reply); err != nil {
+			return nil, err
+		}
+
+		return reply.Entries, nil
+	default:
+		return nil, BadRequestError{Reason: "Must provide either a kind or both kind and name"}
+	}
+}
+
+// DELETE /v1/config/:kind/:name
+func (s *HTTPServer) configDelete(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.ConfigEntryRequest
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+	pathArgs := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/v1/config/"), "/", 2)
+
+	if len(pathArgs) != 2 || pathArgs[1] == "" {
+		return nil, BadRequestError{Reason: "Must provide both a kind and name to delete"}
+	}
+
+	entry, err := structs.MakeConfigEntry(pathArgs[0], pathArgs[1])
+	if err != nil {
+		return nil, BadRequestError{Reason: err.Error()}
+	}
+	args.Op = structs.ConfigEntryDelete
+	args.Entry = entry
+
+	var reply struct{}
+	if err := s.agent.RPC("ConfigEntry.Apply", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
+
+// PUT /v1/config
+func (s *HTTPServer) ConfigApply(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := structs.ConfigEntryRequest{
+		Op: structs.ConfigEntryUpsert,
+	}
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+
+	if req.Body == nil {
+		return nil, BadRequestError{Reason: "Request decoding failed: missing body"}
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("Request decoding failed: %v", err)}
+	}
+	entry, err := structs.DecodeConfigEntryJSON(body)
+	if err != nil {
+		return nil, BadRequestError{Reason: fmt.Sprintf("Request decoding failed: %v", err)}
+	}
+	args.Entry = entry
+
+	var reply struct{}
+	if err := s.agent.RPC("ConfigEntry.Apply", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,161 @@
+package agent
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Apply(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	body := bytes.NewBufferString(`
+	{
+		"Kind": "service-defaults",
+		"Name": "web",
+		"Protocol": "HTTP"
+	}`)
+	req, _ := http.NewRequest("PUT", "/v1/config", body)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.ConfigApply(resp, req)
+	require.NoError(err)
+
+	args := structs.ConfigEntryQuery{
+		Datacenter: "dc1",
+		Kind:       structs.ServiceDefaults,
+		Name:       "web",
+	}
+	var out structs.ConfigEntryResponse
+	require.NoError(a.RPC("ConfigEntry.Get", &args, &out))
+	entry := out.Entry.(*structs.ServiceConfigEntry)
+	require.Equal("http", entry.Protocol)
+
+	// An unknown kind is rejected
+	body = bytes.NewBufferString(`{ "Kind": "foo", "Name": "web" }`)
+	req, _ = http.NewRequest("PUT", "/v1/config", body)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.ConfigApply(resp, req)
+	require.Error(err)
+	require.IsType(BadRequestError{}, err)
+}
+
+func TestConfig_Get(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Create some config entries.
+	reqs := []structs.ConfigEntryRequest{
+		{
+			Datacenter: "dc1",
+			Op:         structs.ConfigEntryUpsert,
+			Entry:      &structs.ServiceConfigEntry{Name: "bar"},
+		},
+		{
+			Datacenter: "dc1",
+			Op:         structs.ConfigEntryUpsert,
+			Entry:      &structs.ServiceConfigEntry{Name: "foo"},
+		},
+		{
+			Datacenter: "dc1",
+			Op:         structs.ConfigEntryUpsert,
+			Entry: &structs.ProxyConfigEntry{
+				Name: structs.ProxyConfigGlobal,
+				Config: map[string]interface{}{
+					"local_connect_timeout_ms": 1000,
+				},
+			},
+		},
+	}
+	for _, req := range reqs {
+		var out struct{}
+		require.NoError(a.RPC("ConfigEntry.Apply", &req, &out))
+	}
+
+	t.Run("get a single service entry", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/service-defaults/foo", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.Config(resp, req)
+		require.NoError(err)
+		value := obj.(structs.ConfigEntry)
+		require.Equal(structs.ServiceDefaults, value.GetKind())
+		require.Equal("foo", value.GetName())
+		require.NotEmpty(resp.Header().Get("X-Consul-Index"))
+	})
+	t.Run("list both service entries", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/service-defaults", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.Config(resp, req)
+		require.NoError(err)
+		value := obj.([]structs.ConfigEntry)
+		require.Len(value, 2)
+		require.Equal("bar", value[0].GetName())
+		require.Equal("foo", value[1].GetName())
+	})
+	t.Run("get global proxy config", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/proxy-defaults/global", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.Config(resp, req)
+		require.NoError(err)
+		value := obj.(structs.ConfigEntry)
+		require.Equal(structs.ProxyDefaults, value.GetKind())
+		require.Equal(structs.ProxyConfigGlobal, value.GetName())
+	})
+	t.Run("missing entry", func(t *testing.T) {
+		req, _ := http.NewRequest("GET", "/v1/config/service-defaults/baz", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.Config(resp, req)
+		require.NoError(err)
+		require.Nil(obj)
+		require.Equal(http.StatusNotFound, resp.Code)
+	})
+}
+
+func TestConfig_Delete(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	args := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Op:         structs.ConfigEntryUpsert,
+		Entry:      &structs.ServiceConfigEntry{Name: "foo"},
+	}
+	var out struct{}
+	require.NoError(a.RPC("ConfigEntry.Apply", &args, &out))
+
+	req, _ := http.NewRequest("DELETE", "/v1/config/service-defaults/foo", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.Config(resp, req)
+	require.NoError(err)
+
+	query := structs.ConfigEntryQuery{
+		Datacenter: "dc1",
+		Kind:       structs.ServiceDefaults,
+	}
+	var list structs.IndexedConfigEntries
+	require.NoError(a.RPC("ConfigEntry.List", &query, &list))
+	require.Len(list.Entries, 0)
+
+	// The name is required
+	req, _ = http.NewRequest("DELETE", "/v1/config/service-defaults", nil)
+	resp = httptest.NewRecorder()
+	_, err = a.srv.Config(resp, req)
+	require.IsType(BadRequestError{}, err)
+}
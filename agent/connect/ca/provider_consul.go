@@ -47,11 +47,17 @@ func (c *ConsulProvider) Configure(clusterID string, isRoot bool, rawConfig map[
 		return err
 	}
 	c.config = config
-	hash := sha256.Sum256([]byte(fmt.Sprintf("%s,%s,%v", config.PrivateKey, config.RootCert, isRoot)))
+	idInput := fmt.Sprintf("%s,%s,%v", config.PrivateKey, config.RootCert, isRoot)
+	if config.TrustDomain != "" {
+		// The root carries the trust domain, so a new one needs a new root.
+		// The ID is unchanged without one so existing roots are kept.
+		idInput += "," + strings.ToLower(config.TrustDomain)
+	}
+	hash := sha256.Sum256([]byte(idInput))
 	c.id = strings.Replace(fmt.Sprintf("% x", hash), " ", ":", -1)
 	c.clusterID = clusterID
 	c.isRoot = isRoot
-	c.spiffeID = connect.SpiffeIDSigningForCluster(&structs.CAConfiguration{ClusterID: clusterID})
+	c.spiffeID = connect.SpiffeIDSigningForCluster(&structs.CAConfiguration{ClusterID: clusterID, Config: rawConfig})
 
 	// Exit early if the state store has an entry for this provider's config.
 	_, providerState, err := c.Delegate.State().CAProviderState(c.id)
@@ -314,8 +320,8 @@ func (c *ConsulProvider) Cleanup() error {
 	return nil
 }
 
-// Sign returns a new certificate valid for the given SpiffeIDService
-// using the current CA.
+// Sign returns a new certificate valid for the given SpiffeIDService or
+// SpiffeIDAgent using the current CA.
 func (c *ConsulProvider) Sign(csr *x509.CertificateRequest) (string, error) {
 	// Lock during the signing so we don't use the same index twice
 	// for different cert serial numbers.
@@ -349,9 +355,14 @@ func (c *ConsulProvider) Sign(csr *x509.CertificateRequest) (string, error) {
 	if err != nil {
 		return "", err
 	}
-	serviceId, ok := spiffeId.(*connect.SpiffeIDService)
-	if !ok {
-		return "", fmt.Errorf("SPIFFE ID in CSR must be a service ID")
+	var commonName string
+	switch id := spiffeId.(type) {
+	case *connect.SpiffeIDService:
+		commonName = id.Service
+	case *connect.SpiffeIDAgent:
+		commonName = id.Agent
+	default:
+		return "", fmt.Errorf("SPIFFE ID in CSR must be a service or agent ID")
 	}
 
 	// Parse the CA cert
@@ -373,8 +384,9 @@ func (c *ConsulProvider) Sign(csr *x509.CertificateRequest) (string, error) {
 	effectiveNow := time.Now().Add(-1 * time.Minute)
 	template := x509.Certificate{
 		SerialNumber:          sn,
-		Subject:               pkix.Name{CommonName: serviceId.Service},
+		Subject:               pkix.Name{CommonName: commonName},
 		URIs:                  csr.URIs,
+		DNSNames:              csr.DNSNames,
 		Signature:             csr.Signature,
 		SignatureAlgorithm:    csr.SignatureAlgorithm,
 		PublicKeyAlgorithm:    csr.PublicKeyAlgorithm,
@@ -600,12 +612,6 @@ func (c *ConsulProvider) incrementProviderIndex(providerState *structs.CAConsulP
 
 // generateCA makes a new root CA using the current private key
 func (c *ConsulProvider) generateCA(privateKey string, sn uint64) (string, error) {
-	state := c.Delegate.State()
-	_, config, err := state.CAConfig()
-	if err != nil {
-		return "", err
-	}
-
 	privKey, err := connect.ParseSigner(privateKey)
 	if err != nil {
 		return "", fmt.Errorf("error parsing private key %q: %s", privateKey, err)
@@ -614,7 +620,7 @@ func (c *ConsulProvider) generateCA(privateKey string, sn uint64) (string, error
 	name := fmt.Sprintf("Consul CA %d", sn)
 
 	// The URI (SPIFFE compatible) for the cert
-	id := connect.SpiffeIDSigningForCluster(config)
+	id := c.spiffeID
 	keyId, err := connect.KeyId(privKey.Public())
 	if err != nil {
 		return "", err
@@ -67,6 +67,15 @@ func (v *VaultProvider) Configure(clusterId string, isRoot bool, rawConfig map[s
 	return nil
 }
 
+// spiffeID returns the SPIFFE ID of the CA certificates of the provider.
+func (v *VaultProvider) spiffeID() *connect.SpiffeIDSigning {
+	return &connect.SpiffeIDSigning{
+		ClusterID:   v.clusterId,
+		Domain:      "consul",
+		TrustDomain: v.config.TrustDomain,
+	}
+}
+
 // ActiveRoot returns the active root CA certificate.
 func (v *VaultProvider) ActiveRoot() (string, error) {
 	return v.getCA(v.config.RootPKIPath)
@@ -96,7 +105,7 @@ func (v *VaultProvider) GenerateRoot() error {
 
 		fallthrough
 	case ErrBackendNotInitialized:
-		spiffeID := connect.SpiffeIDSigning{ClusterID: v.clusterId, Domain: "consul"}
+		spiffeID := v.spiffeID()
 		uuid, err := uuid.GenerateUUID()
 		if err != nil {
 			return err
@@ -156,7 +165,7 @@ func (v *VaultProvider) generateIntermediateCSR() (string, error) {
 	if err != nil {
 		return "", err
 	}
-	spiffeID := connect.SpiffeIDSigning{ClusterID: v.clusterId, Domain: "consul"}
+	spiffeID := v.spiffeID()
 	if role == nil {
 		_, err := v.client.Logical().Write(rolePath, map[string]interface{}{
 			"allow_any_name":   true,
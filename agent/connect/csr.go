@@ -9,13 +9,20 @@ import (
 	"encoding/asn1"
 	"encoding/pem"
 	"net/url"
+	"regexp"
+	"strings"
 )
 
+// dnsLabelRe matches a valid DNS label.
+var dnsLabelRe = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
 // CreateCSR returns a CSR to sign the given service along with the PEM-encoded
-// private key for this certificate.
-func CreateCSR(uri CertURI, privateKey crypto.Signer, extensions ...pkix.Extension) (string, error) {
+// private key for this certificate. The CSR requests the given DNS SANs
+// besides the URI.
+func CreateCSR(uri CertURI, privateKey crypto.Signer, dnsNames []string, extensions ...pkix.Extension) (string, error) {
 	template := &x509.CertificateRequest{
 		URIs:               []*url.URL{uri.URI()},
+		DNSNames:           dnsNames,
 		SignatureAlgorithm: x509.ECDSAWithSHA256,
 		ExtraExtensions:    extensions,
 	}
@@ -35,6 +42,22 @@ func CreateCSR(uri CertURI, privateKey crypto.Signer, extensions ...pkix.Extensi
 	return csrBuf.String(), nil
 }
 
+// ServiceDNSNames returns the DNS SANs of the leaf certificates of the given
+// service, "<service>.<domain>" for each of the domains. A service whose name
+// isn't a valid DNS label has none.
+func ServiceDNSNames(service string, domains []string) []string {
+	service = strings.ToLower(service)
+	if !dnsLabelRe.MatchString(service) {
+		return nil
+	}
+
+	var names []string
+	for _, domain := range domains {
+		names = append(names, service+"."+strings.ToLower(domain))
+	}
+	return names
+}
+
 // CreateCSR returns a CA CSR to sign the given service along with the PEM-encoded
 // private key for this certificate.
 func CreateCACSR(uri CertURI, privateKey crypto.Signer) (string, error) {
@@ -43,7 +66,7 @@ func CreateCACSR(uri CertURI, privateKey crypto.Signer) (string, error) {
 		return "", err
 	}
 
-	return CreateCSR(uri, privateKey, ext)
+	return CreateCSR(uri, privateKey, nil, ext)
 }
 
 // CreateCAExtension creates a pkix.Extension for the x509 Basic Constraints
@@ -0,0 +1,28 @@
+package connect
+
+import (
+	"fmt"
+)
+
+const (
+	// internal is the label separating the service and datacenter parts of
+	// the SNI from the trust domain.
+	internal = "internal"
+)
+
+// ServiceSNI returns the SNI the proxies present when connecting to the
+// given service of the given datacenter, which the mesh gateways route the
+// connections by. An empty namespace is the default one.
+func ServiceSNI(service, namespace, datacenter, trustDomain string) string {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return fmt.Sprintf("%s.%s.%s", service, namespace, DatacenterSNI(datacenter, trustDomain))
+}
+
+// DatacenterSNI returns the suffix of the SNI of the services of the given
+// datacenter. The mesh gateways forward the connections whose SNI has the
+// suffix of another datacenter to the mesh gateways of that datacenter.
+func DatacenterSNI(datacenter, trustDomain string) string {
+	return fmt.Sprintf("%s.%s.%s", datacenter, internal, trustDomain)
+}
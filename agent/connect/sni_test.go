@@ -0,0 +1,24 @@
+package connect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceSNI(t *testing.T) {
+	trustDomain := TestClusterID + ".consul"
+
+	require.Equal(t,
+		"web.default.dc1.internal."+trustDomain,
+		ServiceSNI("web", "", "dc1", trustDomain))
+	require.Equal(t,
+		"web.ns1.dc2.internal."+trustDomain,
+		ServiceSNI("web", "ns1", "dc2", trustDomain))
+}
+
+func TestDatacenterSNI(t *testing.T) {
+	trustDomain := TestClusterID + ".consul"
+
+	require.Equal(t, "dc1.internal."+trustDomain, DatacenterSNI("dc1", trustDomain))
+}
@@ -31,6 +31,8 @@ type CertURI interface {
 var (
 	spiffeIDServiceRegexp = regexp.MustCompile(
 		`^/ns/([^/]+)/dc/([^/]+)/svc/([^/]+)$`)
+	spiffeIDAgentRegexp = regexp.MustCompile(
+		`^/agent/client/dc/([^/]+)/id/([^/]+)$`)
 )
 
 // ParseCertURIFromString attempts to parse a string representation of a
@@ -87,6 +89,27 @@ func ParseCertURI(input *url.URL) (CertURI, error) {
 		}, nil
 	}
 
+	// Test for agent IDs
+	if v := spiffeIDAgentRegexp.FindStringSubmatch(path); v != nil {
+		dc := v[1]
+		agent := v[2]
+		if input.RawPath != "" {
+			var err error
+			if dc, err = url.PathUnescape(v[1]); err != nil {
+				return nil, fmt.Errorf("Invalid datacenter: %s", err)
+			}
+			if agent, err = url.PathUnescape(v[2]); err != nil {
+				return nil, fmt.Errorf("Invalid agent: %s", err)
+			}
+		}
+
+		return &SpiffeIDAgent{
+			Host:       input.Host,
+			Datacenter: dc,
+			Agent:      agent,
+		}, nil
+	}
+
 	// Test for signing ID
 	if input.Path == "" {
 		idx := strings.Index(input.Host, ".")
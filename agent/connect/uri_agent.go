@@ -0,0 +1,30 @@
+package connect
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// SpiffeIDAgent is the structure to represent the SPIFFE ID for a client
+// agent, used for the certificates of auto-encrypt.
+type SpiffeIDAgent struct {
+	Host       string
+	Datacenter string
+	Agent      string
+}
+
+// URI returns the *url.URL for this SPIFFE ID.
+func (id *SpiffeIDAgent) URI() *url.URL {
+	var result url.URL
+	result.Scheme = "spiffe"
+	result.Host = id.Host
+	result.Path = fmt.Sprintf("/agent/client/dc/%s/id/%s", id.Datacenter, id.Agent)
+	return &result
+}
+
+// CertURI impl. Agents are never the source of intentions.
+func (id *SpiffeIDAgent) Authorize(ixn *structs.Intention) (bool, bool) {
+	return false, false
+}
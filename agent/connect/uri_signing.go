@@ -13,6 +13,10 @@ import (
 type SpiffeIDSigning struct {
 	ClusterID string // Unique cluster ID
 	Domain    string // The domain, usually "consul"
+
+	// TrustDomain overrides the trust domain built from the ClusterID and
+	// Domain when it is set.
+	TrustDomain string
 }
 
 // URI returns the *url.URL for this SPIFFE ID.
@@ -25,6 +29,9 @@ func (id *SpiffeIDSigning) URI() *url.URL {
 
 // Host is the canonical representation as a DNS-compatible hostname.
 func (id *SpiffeIDSigning) Host() string {
+	if id.TrustDomain != "" {
+		return strings.ToLower(id.TrustDomain)
+	}
 	return strings.ToLower(fmt.Sprintf("%s.%s", id.ClusterID, id.Domain))
 }
 
@@ -56,6 +63,8 @@ func (id *SpiffeIDSigning) CanSign(cu CertURI) bool {
 		// worry about Unicode domains if we start allowing customisation beyond the
 		// built-in cluster ids.
 		return strings.ToLower(other.Host) == id.Host()
+	case *SpiffeIDAgent:
+		return strings.ToLower(other.Host) == id.Host()
 	default:
 		return false
 	}
@@ -70,6 +79,17 @@ func (id *SpiffeIDSigning) CanSign(cu CertURI) bool {
 // break all certificate validation. That does mean that DNS prefix might not
 // match the identity URIs and so the trust domain might not actually resolve
 // which we would like but don't actually need.
+//
+// The TrustDomain of the provider config replaces the trust domain built from
+// the cluster ID when it is set.
 func SpiffeIDSigningForCluster(config *structs.CAConfiguration) *SpiffeIDSigning {
-	return &SpiffeIDSigning{ClusterID: config.ClusterID, Domain: "consul"}
+	id := &SpiffeIDSigning{ClusterID: config.ClusterID, Domain: "consul"}
+
+	// The config was validated when it was set so only the trust domain is of
+	// interest here.
+	if common, err := config.GetCommonConfig(); err == nil {
+		id.TrustDomain = common.TrustDomain
+	}
+
+	return id
 }
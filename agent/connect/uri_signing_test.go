@@ -27,6 +27,26 @@ func TestSpiffeIDSigningForCluster(t *testing.T) {
 	assert.Equal(t, id.URI().String(), "spiffe://"+TestClusterID+".consul")
 }
 
+func TestSpiffeIDSigningForCluster_TrustDomain(t *testing.T) {
+	config := &structs.CAConfiguration{
+		ClusterID: TestClusterID,
+		Config: map[string]interface{}{
+			"TrustDomain": "Example.org",
+		},
+	}
+	id := SpiffeIDSigningForCluster(config)
+	assert.Equal(t, "example.org", id.Host())
+	assert.Equal(t, "spiffe://example.org", id.URI().String())
+
+	assert.True(t, id.CanSign(&SpiffeIDService{"example.org", "default", "dc1", "web"}))
+	assert.False(t, id.CanSign(&SpiffeIDService{TestClusterID + ".consul", "default", "dc1", "web"}))
+
+	// The signing ID parsed back from the URI must be the same one.
+	parsed, err := ParseCertURI(id.URI())
+	assert.NoError(t, err)
+	assert.True(t, id.CanSign(parsed))
+}
+
 // fakeCertURI is a CertURI implementation that our implementation doesn't know
 // about
 type fakeCertURI string
@@ -112,6 +132,18 @@ func TestSpiffeIDSigning_CanSign(t *testing.T) {
 			input: &SpiffeIDService{TestClusterID + ".fake", "default", "dc1", "web"},
 			want:  false,
 		},
+		{
+			name:  "agent - good",
+			id:    testSigning,
+			input: &SpiffeIDAgent{TestClusterID + ".consul", "dc1", "node1"},
+			want:  true,
+		},
+		{
+			name:  "agent - different cluster",
+			id:    testSigning,
+			input: &SpiffeIDAgent{"55555555-4444-3333-2222-111111111111.consul", "dc1", "node1"},
+			want:  false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -45,6 +45,17 @@ var testCertURICases = []struct {
 		"",
 	},
 
+	{
+		"basic agent ID",
+		"spiffe://1234.consul/agent/client/dc/dc1/id/node1",
+		&SpiffeIDAgent{
+			Host:       "1234.consul",
+			Datacenter: "dc1",
+			Agent:      "node1",
+		},
+		"",
+	},
+
 	{
 		"signing ID",
 		"spiffe://1234.consul",
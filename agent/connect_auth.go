@@ -96,10 +96,27 @@ func (a *Agent) ConnectAuthorize(token string,
 
 	// Test the authorization for each match
 	for _, ixn := range reply.Matches[0] {
-		if auth, ok := uriService.Authorize(ixn); ok {
-			reason = fmt.Sprintf("Matched intention: %s", ixn.String())
-			return auth, reason, &meta, nil
+		auth, ok := uriService.Authorize(ixn)
+		if !ok {
+			continue
 		}
+		reason = fmt.Sprintf("Matched intention: %s", ixn.String())
+
+		// The L7 permissions can only be enforced on HTTP requests, any other
+		// connection is denied rather than bypassing them.
+		if len(ixn.Permissions) > 0 {
+			if req.HTTP == nil {
+				return false, reason + ", its permissions require an HTTP request", &meta, nil
+			}
+			action, perm := ixn.HTTPAction(req.HTTP)
+			auth = action == structs.IntentionActionAllow
+			if perm != nil {
+				reason = fmt.Sprintf("%s, matched permission: %s", reason, perm.String())
+			} else {
+				reason += ", no permission matched"
+			}
+		}
+		return auth, reason, &meta, nil
 	}
 
 	// No match, we need to determine the default behavior. We do this by
@@ -110,10 +127,17 @@ func (a *Agent) ConnectAuthorize(token string,
 	if err != nil {
 		return returnErr(err)
 	}
-	if rule == nil {
-		// ACLs not enabled at all, the default is allow all.
-		return true, "ACLs disabled, access is allowed by default", &meta, nil
+	authz = true
+	reason = "ACLs disabled, access is allowed by default"
+	if rule != nil {
+		authz = rule.IntentionDefaultAllow()
+		reason = "Default behavior configured by ACLs"
+	}
+
+	// Record the connection to suggest the intentions covering it.
+	if a.config.ConnectIntentionSuggestions {
+		a.intentionObservations.observe(uriService.Namespace, uriService.Service,
+			structs.IntentionDefaultNamespace, req.Target, authz)
 	}
-	reason = "Default behavior configured by ACLs"
-	return rule.IntentionDefaultAllow(), reason, &meta, nil
+	return authz, reason, &meta, nil
 }
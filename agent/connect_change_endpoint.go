@@ -0,0 +1,25 @@
+package agent
+
+import (
+	"net/http"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// GET /v1/connect/changes
+func (s *HTTPServer) ConnectChanges(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.ConnectChangesRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+	args.Kind = req.URL.Query().Get("kind")
+	args.Name = req.URL.Query().Get("name")
+
+	var reply structs.IndexedConnectChanges
+	defer setMeta(resp, &reply.QueryMeta)
+	if err := s.agent.RPC("ConnectChange.List", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return reply.Changes, nil
+}
@@ -0,0 +1,61 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectChanges(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	// Make sure an empty list is non-nil.
+	req, _ := http.NewRequest("GET", "/v1/connect/changes", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.ConnectChanges(resp, req)
+	require.NoError(err)
+	require.NotNil(obj.(structs.ConnectChanges))
+	require.Len(obj.(structs.ConnectChanges), 0)
+
+	// Create two intentions
+	var ids []string
+	for _, v := range []string{"foo", "bar"} {
+		ixn := structs.IntentionRequest{
+			Datacenter: "dc1",
+			Op:         structs.IntentionOpCreate,
+			Intention:  structs.TestIntention(t),
+		}
+		ixn.Intention.SourceName = v
+
+		var reply string
+		require.NoError(a.RPC("Intention.Apply", &ixn, &reply))
+		ids = append(ids, reply)
+	}
+
+	req, _ = http.NewRequest("GET", "/v1/connect/changes?kind=intention", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.ConnectChanges(resp, req)
+	require.NoError(err)
+	changes := obj.(structs.ConnectChanges)
+	require.Len(changes, 2)
+	require.Equal(ids[1], changes[0].Name)
+	require.Equal(ids[0], changes[1].Name)
+	require.NotEmpty(resp.Header().Get("X-Consul-Index"))
+
+	// Filter by name
+	req, _ = http.NewRequest("GET", "/v1/connect/changes?kind=intention&name="+ids[0], nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.ConnectChanges(resp, req)
+	require.NoError(err)
+	changes = obj.(structs.ConnectChanges)
+	require.Len(changes, 1)
+	require.Equal("foo", changes[0].SourceName)
+	require.Equal(structs.ConnectChangeCreate, changes[0].Op)
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"sync"
 	"time"
 
@@ -203,6 +204,11 @@ func NewACLResolver(config *ACLResolverConfig) (*ACLResolver, error) {
 	}, nil
 }
 
+// Stats returns statistics about the ACL caches.
+func (r *ACLResolver) Stats() map[string]string {
+	return r.cache.Stats()
+}
+
 func (r *ACLResolver) fetchAndCacheTokenLegacy(token string, cached *structs.AuthorizerCacheEntry) (acl.Authorizer, error) {
 	req := structs.ACLPolicyResolveLegacyRequest{
 		Datacenter: r.delegate.ACLDatacenter(true),
@@ -476,8 +482,14 @@ func (r *ACLResolver) fetchAndCachePoliciesForIdentity(identity structs.ACLIdent
 }
 
 func (r *ACLResolver) filterPoliciesByScope(policies structs.ACLPolicies) structs.ACLPolicies {
+	now := time.Now()
 	var out structs.ACLPolicies
 	for _, policy := range policies {
+		// Expired policies may not have been deleted yet
+		if policy.IsExpired(now) {
+			continue
+		}
+
 		if len(policy.Datacenters) == 0 {
 			out = append(out, policy)
 			continue
@@ -592,6 +604,196 @@ func (r *ACLResolver) resolvePoliciesForIdentity(identity structs.ACLIdentity) (
 	return r.filterPoliciesByScope(policies), nil
 }
 
+// filterPoliciesByNamespace drops the policies outside of the namespace of
+// the identity, which never grant it any permission.
+func filterPoliciesByNamespace(identity structs.ACLIdentity, policies structs.ACLPolicies) structs.ACLPolicies {
+	token, ok := identity.(*structs.ACLToken)
+	if !ok {
+		return policies
+	}
+
+	out := make(structs.ACLPolicies, 0, len(policies))
+	for _, policy := range policies {
+		if structs.ACLNamespaceMatches(token.Namespace, policy.Namespace) {
+			out = append(out, policy)
+		}
+	}
+	return out
+}
+
+// resolveTokenNamespace returns the namespace the token is bound to. The
+// tokens of the default namespace are not bound to any namespace, which is
+// returned as an empty string, as it is when ACLs are disabled or legacy.
+func (r *ACLResolver) resolveTokenNamespace(token string) (string, error) {
+	if !r.ACLsEnabled() || r.delegate.UseLegacyACLs() {
+		return "", nil
+	}
+
+	if token == "" {
+		token = anonymousToken
+	}
+
+	identity, err := r.resolveIdentityFromToken(token)
+	if err != nil {
+		return "", err
+	} else if identity == nil {
+		return "", acl.ErrNotFound
+	}
+
+	t, ok := identity.(*structs.ACLToken)
+	if !ok {
+		return "", nil
+	}
+	if ns := structs.ACLNamespaceOrDefault(t.Namespace); ns != structs.ACLDefaultNamespace {
+		return ns, nil
+	}
+	return "", nil
+}
+
+// bindNamespace returns the namespace a request made with the token works
+// on. The requested namespace is used as is for the tokens of the default
+// namespace, the other tokens can only work on their own namespace, which is
+// also what the wildcard namespace stands for them.
+func (r *ACLResolver) bindNamespace(token, requested string) (string, error) {
+	bound, err := r.resolveTokenNamespace(token)
+	if err != nil {
+		return "", err
+	}
+	if bound == "" {
+		return requested, nil
+	}
+	if requested != "" && requested != structs.ACLWildcardNamespace && !strings.EqualFold(requested, bound) {
+		return "", acl.ErrPermissionDenied
+	}
+	return bound, nil
+}
+
+// namedPermissions are the permissions checked for every name by
+// vetNamespacePolicy.
+var namedPermissions = map[string]func(acl.Authorizer, string) bool{
+	"agent:read":      acl.Authorizer.AgentRead,
+	"agent:write":     acl.Authorizer.AgentWrite,
+	"event:read":      acl.Authorizer.EventRead,
+	"event:write":     acl.Authorizer.EventWrite,
+	"intention:read":  acl.Authorizer.IntentionRead,
+	"intention:write": acl.Authorizer.IntentionWrite,
+	"key:list":        acl.Authorizer.KeyList,
+	"key:read":        acl.Authorizer.KeyRead,
+	"key:write": func(a acl.Authorizer, name string) bool {
+		return a.KeyWrite(name, nil)
+	},
+	"node:read": acl.Authorizer.NodeRead,
+	"node:write": func(a acl.Authorizer, name string) bool {
+		return a.NodeWrite(name, nil)
+	},
+	"query:read":   acl.Authorizer.PreparedQueryRead,
+	"query:write":  acl.Authorizer.PreparedQueryWrite,
+	"service:read": acl.Authorizer.ServiceRead,
+	"service:write": func(a acl.Authorizer, name string) bool {
+		return a.ServiceWrite(name, nil)
+	},
+	"session:read":  acl.Authorizer.SessionRead,
+	"session:write": acl.Authorizer.SessionWrite,
+}
+
+// vetNamespacePolicy verifies the rules of a policy of the given namespace.
+// Only the ACL objects are namespaced, so the policies outside the default
+// namespace can't have operator and keyring rules, which cover the whole
+// cluster. The tokens bound to a namespace can also only grant the
+// permissions they have, so they can't give themselves access to the
+// resources the operators didn't give them.
+func (r *ACLResolver) vetNamespacePolicy(token, namespace string, authz acl.Authorizer, rules *acl.Policy) error {
+	if structs.ACLNamespaceOrDefault(namespace) == structs.ACLDefaultNamespace {
+		return nil
+	}
+	if rules.Operator != "" || rules.Keyring != "" {
+		return fmt.Errorf("Invalid Policy: operator and keyring rules are only allowed in the %q namespace", structs.ACLDefaultNamespace)
+	}
+
+	bound, err := r.resolveTokenNamespace(token)
+	if err != nil || bound == "" || authz == nil {
+		return err
+	}
+
+	policies, err := r.resolveTokenToPolicies(token)
+	if err != nil {
+		return err
+	}
+	held, err := policies.Merge(r.cache, r.sentinel)
+	if err != nil {
+		return err
+	}
+	granted, err := acl.NewPolicyAuthorizer(acl.DenyAll(), []*acl.Policy{rules}, r.sentinel)
+	if err != nil {
+		return err
+	}
+
+	if (granted.ACLRead() && !authz.ACLRead()) || (granted.ACLWrite() && !authz.ACLWrite()) {
+		return acl.ErrPermissionDenied
+	}
+
+	// The decisions only change at the names of the rules, so checking
+	// those names, and a name right after each of them for the prefix
+	// rules, covers every name.
+	for _, name := range policyRuleNames(rules, held) {
+		for _, candidate := range []string{name, name + "\x00"} {
+			for _, allowed := range namedPermissions {
+				if allowed(granted, candidate) && !allowed(authz, candidate) {
+					return acl.ErrPermissionDenied
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// policyRuleNames returns the names of all the rules of the policies, along
+// with the empty name.
+func policyRuleNames(policies ...*acl.Policy) []string {
+	names := []string{""}
+	for _, p := range policies {
+		if p == nil {
+			continue
+		}
+		for _, rules := range [][]*acl.AgentPolicy{p.Agents, p.AgentPrefixes} {
+			for _, rule := range rules {
+				names = append(names, rule.Node)
+			}
+		}
+		for _, rules := range [][]*acl.EventPolicy{p.Events, p.EventPrefixes} {
+			for _, rule := range rules {
+				names = append(names, rule.Event)
+			}
+		}
+		for _, rules := range [][]*acl.KeyPolicy{p.Keys, p.KeyPrefixes} {
+			for _, rule := range rules {
+				names = append(names, rule.Prefix)
+			}
+		}
+		for _, rules := range [][]*acl.NodePolicy{p.Nodes, p.NodePrefixes} {
+			for _, rule := range rules {
+				names = append(names, rule.Name)
+			}
+		}
+		for _, rules := range [][]*acl.PreparedQueryPolicy{p.PreparedQueries, p.PreparedQueryPrefixes} {
+			for _, rule := range rules {
+				names = append(names, rule.Prefix)
+			}
+		}
+		for _, rules := range [][]*acl.ServicePolicy{p.Services, p.ServicePrefixes} {
+			for _, rule := range rules {
+				names = append(names, rule.Name)
+			}
+		}
+		for _, rules := range [][]*acl.SessionPolicy{p.Sessions, p.SessionPrefixes} {
+			for _, rule := range rules {
+				names = append(names, rule.Node)
+			}
+		}
+	}
+	return names
+}
+
 func (r *ACLResolver) resolveTokenToPolicies(token string) (structs.ACLPolicies, error) {
 	_, policies, err := r.resolveTokenToIdentityAndPolicies(token)
 	return policies, err
@@ -614,7 +816,7 @@ func (r *ACLResolver) resolveTokenToIdentityAndPolicies(token string) (structs.A
 
 		policies, err := r.resolvePoliciesForIdentity(identity)
 		if err == nil {
-			return identity, policies, nil
+			return identity, filterPoliciesByNamespace(identity, policies), nil
 		}
 		lastErr = err
 
@@ -648,12 +850,23 @@ func (r *ACLResolver) disableACLsWhenUpstreamDisabled(err error) error {
 }
 
 func (r *ACLResolver) ResolveToken(token string) (acl.Authorizer, error) {
+	authorizer, _, err := r.ResolveTokenToScope(token)
+	return authorizer, err
+}
+
+// ResolveTokenToScope resolves the token like ResolveToken, and also returns
+// a key identifying the read permissions of the authorizer. The key is the
+// same for all the tokens linked to the same policies, and changes when any
+// of them is modified. It is empty when the permissions can't be shared
+// with other tokens, such as with the legacy ACLs or when the policies
+// could not be resolved.
+func (r *ACLResolver) ResolveTokenToScope(token string) (acl.Authorizer, string, error) {
 	if !r.ACLsEnabled() {
-		return nil, nil
+		return nil, "", nil
 	}
 
 	if acl.RootAuthorizer(token) != nil {
-		return nil, acl.ErrRootDenied
+		return nil, "", acl.ErrRootDenied
 	}
 
 	// handle the anonymous token
@@ -663,26 +876,40 @@ func (r *ACLResolver) ResolveToken(token string) (acl.Authorizer, error) {
 
 	if r.delegate.UseLegacyACLs() {
 		authorizer, err := r.resolveTokenLegacy(token)
-		return authorizer, r.disableACLsWhenUpstreamDisabled(err)
+		return authorizer, "", r.disableACLsWhenUpstreamDisabled(err)
 	}
 
 	defer metrics.MeasureSince([]string{"acl", "ResolveToken"}, time.Now())
 
-	policies, err := r.resolveTokenToPolicies(token)
+	identity, policies, err := r.resolveTokenToIdentityAndPolicies(token)
 	if err != nil {
 		r.disableACLsWhenUpstreamDisabled(err)
 		if IsACLRemoteError(err) {
 			r.logger.Printf("[ERR] consul.acl: %v", err)
-			return r.down, nil
+			return r.down, "", nil
 		}
 
-		return nil, err
+		return nil, "", err
 	}
 
 	// Build the Authorizer
 	authorizer, err := policies.Compile(acl.RootAuthorizer(r.config.ACLDefaultPolicy), r.cache, r.sentinel)
-	return authorizer, err
-
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Restrict the writes to the name prefix of the token, if any. This
+	// leaves the read permissions, and so the scope, unchanged. The tokens
+	// bound to a namespace also lose their permissions over the whole
+	// cluster. Since the policies of a namespace can only be linked to its
+	// tokens, the scope is still shared by the same tokens.
+	if t, ok := identity.(*structs.ACLToken); ok {
+		authorizer = acl.NewNamePrefixAuthorizer(t.NamePrefix, authorizer)
+		if ns := structs.ACLNamespaceOrDefault(t.Namespace); ns != structs.ACLDefaultNamespace {
+			authorizer = acl.NewNamespaceAuthorizer(ns, authorizer)
+		}
+	}
+	return authorizer, policies.HashKey(), nil
 }
 
 func (r *ACLResolver) ACLsEnabled() bool {
@@ -719,6 +946,11 @@ type aclFilter struct {
 	authorizer      acl.Authorizer
 	logger          *log.Logger
 	enforceVersion8 bool
+
+	// namespace is the namespace the token is bound to, the ACL tokens and
+	// policies of the other namespaces are filtered. It is empty when the
+	// token can see every namespace.
+	namespace string
 }
 
 // newACLFilter constructs a new aclFilter.
@@ -762,6 +994,12 @@ func (f *aclFilter) allowSession(node string) bool {
 	return f.authorizer.SessionRead(node)
 }
 
+// allowNamespace is used to determine if the ACL tokens and policies of a
+// namespace are accessible for an ACL.
+func (f *aclFilter) allowNamespace(ns string) bool {
+	return f.namespace == "" || structs.ACLNamespaceMatches(f.namespace, ns)
+}
+
 // filterHealthChecks is used to filter a set of health checks down based on
 // the configured ACL rules for a token.
 func (f *aclFilter) filterHealthChecks(checks *structs.HealthChecks) {
@@ -805,6 +1043,43 @@ func (f *aclFilter) filterServiceNodes(nodes *structs.ServiceNodes) {
 	*nodes = sn
 }
 
+// filterGatewayServices is used to filter the services fronted by a gateway
+// based on the configured ACL rules.
+func (f *aclFilter) filterGatewayServices(services *structs.GatewayServices) {
+	gs := *services
+	for i := 0; i < len(gs); i++ {
+		if f.allowService(gs[i].Service) {
+			continue
+		}
+		f.logger.Printf("[DEBUG] consul: dropping service %q from result due to ACLs", gs[i].Service)
+		gs = append(gs[:i], gs[i+1:]...)
+		i--
+	}
+	*services = gs
+}
+
+// filterConnectChanges is used to filter the changelog based on the
+// configured ACL rules. The changes of intentions require read access to
+// their destination, and the changes of config entries require operator read
+// access.
+func (f *aclFilter) filterConnectChanges(changes *structs.ConnectChanges) {
+	cs := *changes
+	for i := 0; i < len(cs); i++ {
+		change := cs[i]
+		if change.Kind == structs.ConnectChangeIntention {
+			if f.authorizer.IntentionRead(change.DestinationName) {
+				continue
+			}
+		} else if f.authorizer.OperatorRead() {
+			continue
+		}
+		f.logger.Printf("[DEBUG] consul: dropping connect change %d from result due to ACLs", change.Index)
+		cs = append(cs[:i], cs[i+1:]...)
+		i--
+	}
+	*changes = cs
+}
+
 // filterNodeServices is used to filter services on a given node base on ACLs.
 func (f *aclFilter) filterNodeServices(services **structs.NodeServices) {
 	if *services == nil {
@@ -1013,7 +1288,14 @@ func (f *aclFilter) filterPreparedQueries(queries *structs.PreparedQueries) {
 }
 
 func (f *aclFilter) redactTokenSecret(token **structs.ACLToken) {
-	if token == nil || *token == nil || f == nil || f.authorizer.ACLWrite() {
+	if token == nil || *token == nil || f == nil {
+		return
+	}
+	if !f.allowNamespace((*token).Namespace) {
+		*token = nil
+		return
+	}
+	if f.authorizer.ACLWrite() {
 		return
 	}
 	clone := *(*token)
@@ -1026,17 +1308,77 @@ func (f *aclFilter) redactTokenSecrets(tokens *structs.ACLTokens) {
 	for _, token := range *tokens {
 		final := token
 		f.redactTokenSecret(&final)
-		ret = append(ret, final)
+		if final != nil {
+			ret = append(ret, final)
+		}
 	}
 	*tokens = ret
 }
 
+// filterTokenStubs is used to filter the listed ACL tokens down to the
+// namespace of the token.
+func (f *aclFilter) filterTokenStubs(stubs *structs.ACLTokenListStubs) {
+	ret := make(structs.ACLTokenListStubs, 0, len(*stubs))
+	for _, stub := range *stubs {
+		if !f.allowNamespace(stub.Namespace) {
+			f.logger.Printf("[DEBUG] consul: dropping token %q from result due to ACLs", stub.AccessorID)
+			continue
+		}
+		ret = append(ret, stub)
+	}
+	*stubs = ret
+}
+
+// filterPolicy is used to hide an ACL policy outside of the namespace of the
+// token.
+func (f *aclFilter) filterPolicy(policy **structs.ACLPolicy) {
+	if policy == nil || *policy == nil || f.allowNamespace((*policy).Namespace) {
+		return
+	}
+	*policy = nil
+}
+
+// filterPolicies is used to filter a set of ACL policies down to the
+// namespace of the token.
+func (f *aclFilter) filterPolicies(policies *structs.ACLPolicies) {
+	ret := make(structs.ACLPolicies, 0, len(*policies))
+	for _, policy := range *policies {
+		if !f.allowNamespace(policy.Namespace) {
+			f.logger.Printf("[DEBUG] consul: dropping policy %q from result due to ACLs", policy.ID)
+			continue
+		}
+		ret = append(ret, policy)
+	}
+	*policies = ret
+}
+
+// filterPolicyStubs is used to filter the listed ACL policies down to the
+// namespace of the token.
+func (f *aclFilter) filterPolicyStubs(stubs *structs.ACLPolicyListStubs) {
+	ret := make(structs.ACLPolicyListStubs, 0, len(*stubs))
+	for _, stub := range *stubs {
+		if !f.allowNamespace(stub.Namespace) {
+			f.logger.Printf("[DEBUG] consul: dropping policy %q from result due to ACLs", stub.ID)
+			continue
+		}
+		ret = append(ret, stub)
+	}
+	*stubs = ret
+}
+
 func (r *ACLResolver) filterACLWithAuthorizer(authorizer acl.Authorizer, subj interface{}) error {
+	return r.filterACLInNamespace(authorizer, "", subj)
+}
+
+// filterACLInNamespace filters like filterACLWithAuthorizer, and also drops
+// the ACL tokens and policies outside of the namespace, if any.
+func (r *ACLResolver) filterACLInNamespace(authorizer acl.Authorizer, namespace string, subj interface{}) error {
 	if authorizer == nil {
 		return nil
 	}
 	// Create the filter
 	filt := newACLFilter(authorizer, r.logger, r.config.ACLEnforceVersion8)
+	filt.namespace = namespace
 
 	switch v := subj.(type) {
 	case *structs.CheckServiceNodes:
@@ -1069,6 +1411,12 @@ func (r *ACLResolver) filterACLWithAuthorizer(authorizer acl.Authorizer, subj in
 	case *structs.IndexedServices:
 		filt.filterServices(v.Services)
 
+	case *structs.IndexedGatewayServices:
+		filt.filterGatewayServices(&v.Services)
+
+	case *structs.IndexedConnectChanges:
+		filt.filterConnectChanges(&v.Changes)
+
 	case *structs.IndexedSessions:
 		filt.filterSessions(&v.Sessions)
 
@@ -1084,6 +1432,18 @@ func (r *ACLResolver) filterACLWithAuthorizer(authorizer acl.Authorizer, subj in
 	case **structs.ACLToken:
 		filt.redactTokenSecret(v)
 
+	case *structs.ACLTokenListStubs:
+		filt.filterTokenStubs(v)
+
+	case **structs.ACLPolicy:
+		filt.filterPolicy(v)
+
+	case *structs.ACLPolicies:
+		filt.filterPolicies(v)
+
+	case *structs.ACLPolicyListStubs:
+		filt.filterPolicyStubs(v)
+
 	default:
 		panic(fmt.Errorf("Unhandled type passed to ACL filter: %#v", subj))
 	}
@@ -1105,7 +1465,11 @@ func (r *ACLResolver) filterACL(token string, subj interface{}) error {
 		return nil
 	}
 
-	return r.filterACLWithAuthorizer(authorizer, subj)
+	namespace, err := r.resolveTokenNamespace(token)
+	if err != nil {
+		return err
+	}
+	return r.filterACLInNamespace(authorizer, namespace, subj)
 }
 
 // vetRegisterWithACL applies the given ACL's policy to the catalog update and
@@ -1361,6 +1725,13 @@ func vetServiceTxnOp(op *structs.TxnServiceOp, rule acl.Authorizer) error {
 		return acl.ErrPermissionDenied
 	}
 
+	// Proxies must have write permission on their destination
+	if service.Kind == structs.ServiceKindConnectProxy {
+		if !rule.ServiceWrite(service.Proxy.DestinationServiceName, nil) {
+			return acl.ErrPermissionDenied
+		}
+	}
+
 	return nil
 }
 
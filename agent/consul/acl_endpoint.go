@@ -1,11 +1,15 @@
 package consul
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -25,6 +29,14 @@ const (
 
 // Regex for matching
 var validPolicyName = regexp.MustCompile(`^[A-Za-z0-9\-_]{1,128}$`)
+var validNamespaceName = regexp.MustCompile(`^[A-Za-z0-9\-_]{1,64}$`)
+var invalidPolicyNameChars = regexp.MustCompile(`[^A-Za-z0-9\-_]`)
+
+var (
+	// ErrNotServiceToken is returned by ServiceTokenDelete if the request
+	// token wasn't created by ServiceTokenCreate for the given service.
+	ErrNotServiceToken = errors.New("Token is not a service token")
+)
 
 // ACL endpoint is used to manipulate ACLs
 type ACL struct {
@@ -201,6 +213,9 @@ func (a *ACL) TokenRead(args *structs.ACLTokenGetRequest, reply *structs.ACLToke
 		} else if rule == nil || !rule.ACLRead() {
 			return acl.ErrPermissionDenied
 		}
+		if args.Namespace, err = a.srv.bindNamespace(args.Token, args.Namespace); err != nil {
+			return err
+		}
 	}
 
 	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
@@ -211,9 +226,14 @@ func (a *ACL) TokenRead(args *structs.ACLTokenGetRequest, reply *structs.ACLToke
 
 			if args.TokenIDType == structs.ACLTokenAccessor {
 				index, token, err = state.ACLTokenGetByAccessor(ws, args.TokenID)
+				if token != nil && !structs.ACLNamespaceMatches(args.Namespace, token.Namespace) {
+					token = nil
+				}
 				if token != nil {
-					a.srv.filterACLWithAuthorizer(rule, &token)
-					if !rule.ACLWrite() {
+					if err := a.srv.filterACL(args.Token, &token); err != nil {
+						return err
+					}
+					if token != nil && !rule.ACLWrite() {
 						reply.Redacted = true
 					}
 				}
@@ -253,10 +273,16 @@ func (a *ACL) TokenClone(args *structs.ACLTokenSetRequest, reply *structs.ACLTok
 		return acl.ErrPermissionDenied
 	}
 
+	ns, err := a.srv.bindNamespace(args.Token, args.ACLToken.Namespace)
+	if err != nil {
+		return err
+	}
+	args.ACLToken.Namespace = ns
+
 	_, token, err := a.srv.fsm.State().ACLTokenGetByAccessor(nil, args.ACLToken.AccessorID)
 	if err != nil {
 		return err
-	} else if token == nil {
+	} else if token == nil || !structs.ACLNamespaceMatches(args.ACLToken.Namespace, token.Namespace) {
 		return acl.ErrNotFound
 	} else if !a.srv.InACLDatacenter() && !token.Local {
 		// global token writes must be forwarded to the primary DC
@@ -274,6 +300,9 @@ func (a *ACL) TokenClone(args *structs.ACLTokenSetRequest, reply *structs.ACLTok
 			Policies:    token.Policies,
 			Local:       token.Local,
 			Description: token.Description,
+			Namespace:   token.Namespace,
+			NamePrefix:  token.NamePrefix,
+			Labels:      token.Labels,
 		},
 		WriteRequest: args.WriteRequest,
 	}
@@ -281,6 +310,9 @@ func (a *ACL) TokenClone(args *structs.ACLTokenSetRequest, reply *structs.ACLTok
 	if args.ACLToken.Description != "" {
 		cloneReq.ACLToken.Description = args.ACLToken.Description
 	}
+	if args.ACLToken.Labels != nil {
+		cloneReq.ACLToken.Labels = args.ACLToken.Labels
+	}
 
 	return a.tokenSetInternal(&cloneReq, reply, false)
 }
@@ -310,6 +342,12 @@ func (a *ACL) TokenSet(args *structs.ACLTokenSetRequest, reply *structs.ACLToken
 		return acl.ErrPermissionDenied
 	}
 
+	ns, err := a.srv.bindNamespace(args.Token, args.ACLToken.Namespace)
+	if err != nil {
+		return err
+	}
+	args.ACLToken.Namespace = ns
+
 	return a.tokenSetInternal(args, reply, false)
 }
 
@@ -329,6 +367,11 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 		// Token Create
 		var err error
 
+		token.Namespace = structs.ACLNamespaceOrDefault(token.Namespace)
+		if !validNamespaceName.MatchString(token.Namespace) {
+			return fmt.Errorf("Invalid Namespace. Only alphanumeric characters, '-' and '_' are allowed")
+		}
+
 		// Generate the AccessorID
 		token.AccessorID, err = lib.GenerateUUID(a.srv.checkTokenUUID)
 		if err != nil {
@@ -365,9 +408,10 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 		if err != nil {
 			return fmt.Errorf("Failed to lookup the acl token %q: %v", token.AccessorID, err)
 		}
-		if existing == nil {
+		if existing == nil || !structs.ACLNamespaceMatches(token.Namespace, existing.Namespace) {
 			return fmt.Errorf("Cannot find token %q", token.AccessorID)
 		}
+		token.Namespace = structs.ACLNamespaceOrDefault(existing.Namespace)
 		if token.SecretID == "" {
 			token.SecretID = existing.SecretID
 		} else if existing.SecretID != token.SecretID {
@@ -389,10 +433,11 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 	policyIDs := make(map[string]struct{})
 	var policies []structs.ACLTokenPolicyLink
 
-	// Validate all the policy names and convert them to policy IDs
+	// Validate all the policy names and convert them to policy IDs. Tokens
+	// can only use the policies of their own namespace.
 	for _, link := range token.Policies {
 		if link.ID == "" {
-			_, policy, err := state.ACLPolicyGetByName(nil, link.Name)
+			_, policy, err := state.ACLPolicyGetByName(nil, token.Namespace, link.Name)
 			if err != nil {
 				return fmt.Errorf("Error looking up policy for name %q: %v", link.Name, err)
 			}
@@ -400,6 +445,14 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 				return fmt.Errorf("No such ACL policy with name %q", link.Name)
 			}
 			link.ID = policy.ID
+		} else {
+			_, policy, err := state.ACLPolicyGetByID(nil, link.ID)
+			if err != nil {
+				return fmt.Errorf("Error looking up policy for id %q: %v", link.ID, err)
+			}
+			if policy != nil && !structs.ACLNamespaceMatches(token.Namespace, policy.Namespace) {
+				return fmt.Errorf("ACL policy %q is not in namespace %q", link.ID, token.Namespace)
+			}
 		}
 
 		// Do not store the policy name within raft/memdb as the policy could be renamed in the future.
@@ -421,6 +474,10 @@ func (a *ACL) tokenSetInternal(args *structs.ACLTokenSetRequest, reply *structs.
 		return fmt.Errorf("Type cannot be specified for this token")
 	}
 
+	if err := structs.ValidateLabels(token.Labels); err != nil {
+		return fmt.Errorf("Invalid Labels: %v", err)
+	}
+
 	token.SetHash(true)
 
 	req := &structs.ACLTokenBatchSetRequest{
@@ -471,6 +528,12 @@ func (a *ACL) TokenDelete(args *structs.ACLTokenDeleteRequest, reply *string) er
 		return acl.ErrPermissionDenied
 	}
 
+	ns, err := a.srv.bindNamespace(args.Token, args.Namespace)
+	if err != nil {
+		return err
+	}
+	args.Namespace = ns
+
 	if _, err := uuid.ParseUUID(args.TokenID); err != nil {
 		return fmt.Errorf("Accessor ID is missing or an invalid UUID")
 	}
@@ -485,6 +548,11 @@ func (a *ACL) TokenDelete(args *structs.ACLTokenDeleteRequest, reply *string) er
 		return err
 	}
 
+	if token != nil && !structs.ACLNamespaceMatches(args.Namespace, token.Namespace) {
+		// Tokens of other namespaces are treated as missing.
+		return nil
+	}
+
 	if token != nil {
 		if args.Token == token.SecretID {
 			return fmt.Errorf("Deletion of the request's authorization token is not permitted")
@@ -545,6 +613,14 @@ func (a *ACL) TokenList(args *structs.ACLTokenListRequest, reply *structs.ACLTok
 	} else if rule == nil || !rule.ACLRead() {
 		return acl.ErrPermissionDenied
 	}
+	if args.Namespace, err = a.srv.bindNamespace(args.Token, args.Namespace); err != nil {
+		return err
+	}
+
+	selector, err := structs.ParseLabelSelector(args.LabelSelector)
+	if err != nil {
+		return err
+	}
 
 	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -553,10 +629,19 @@ func (a *ACL) TokenList(args *structs.ACLTokenListRequest, reply *structs.ACLTok
 				return err
 			}
 
-			stubs := make([]*structs.ACLTokenListStub, 0, len(tokens))
+			stubs := make(structs.ACLTokenListStubs, 0, len(tokens))
 			for _, token := range tokens {
+				if !structs.ACLNamespaceMatches(args.Namespace, token.Namespace) {
+					continue
+				}
+				if !selector.Matches(token.Labels) {
+					continue
+				}
 				stubs = append(stubs, token.Stub())
 			}
+			if err := a.srv.filterACL(args.Token, &stubs); err != nil {
+				return err
+			}
 			reply.Index, reply.Tokens = index, stubs
 			return nil
 		})
@@ -589,7 +674,9 @@ func (a *ACL) TokenBatchRead(args *structs.ACLTokenBatchGetRequest, reply *struc
 				return err
 			}
 
-			a.srv.filterACLWithAuthorizer(rule, &tokens)
+			if err := a.srv.filterACL(args.Token, &tokens); err != nil {
+				return err
+			}
 
 			reply.Index, reply.Tokens = index, tokens
 			reply.Redacted = !rule.ACLWrite()
@@ -612,6 +699,12 @@ func (a *ACL) PolicyRead(args *structs.ACLPolicyGetRequest, reply *structs.ACLPo
 		return acl.ErrPermissionDenied
 	}
 
+	ns, err := a.srv.bindNamespace(args.Token, args.Namespace)
+	if err != nil {
+		return err
+	}
+	args.Namespace = ns
+
 	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
 			index, policy, err := state.ACLPolicyGetByID(ws, args.PolicyID)
@@ -619,6 +712,12 @@ func (a *ACL) PolicyRead(args *structs.ACLPolicyGetRequest, reply *structs.ACLPo
 			if err != nil {
 				return err
 			}
+			if policy != nil && !structs.ACLNamespaceMatches(args.Namespace, policy.Namespace) {
+				policy = nil
+			}
+			if err := a.srv.filterACL(args.Token, &policy); err != nil {
+				return err
+			}
 
 			reply.Index, reply.Policy = index, policy
 			return nil
@@ -646,6 +745,9 @@ func (a *ACL) PolicyBatchRead(args *structs.ACLPolicyBatchGetRequest, reply *str
 			if err != nil {
 				return err
 			}
+			if err := a.srv.filterACL(args.Token, &policies); err != nil {
+				return err
+			}
 
 			reply.Index, reply.Policies = index, policies
 			return nil
@@ -668,7 +770,8 @@ func (a *ACL) PolicySet(args *structs.ACLPolicySetRequest, reply *structs.ACLPol
 	defer metrics.MeasureSince([]string{"acl", "policy", "upsert"}, time.Now())
 
 	// Verify token is permitted to modify ACLs
-	if rule, err := a.srv.ResolveToken(args.Token); err != nil {
+	rule, err := a.srv.ResolveToken(args.Token)
+	if err != nil {
 		return err
 	} else if rule == nil || !rule.ACLWrite() {
 		return acl.ErrPermissionDenied
@@ -677,6 +780,12 @@ func (a *ACL) PolicySet(args *structs.ACLPolicySetRequest, reply *structs.ACLPol
 	policy := &args.Policy
 	state := a.srv.fsm.State()
 
+	ns, err := a.srv.bindNamespace(args.Token, policy.Namespace)
+	if err != nil {
+		return err
+	}
+	policy.Namespace = ns
+
 	// Almost all of the checks here are also done in the state store. However,
 	// we want to prevent the raft operations when we know they are going to fail
 	// so we still do them here.
@@ -694,13 +803,18 @@ func (a *ACL) PolicySet(args *structs.ACLPolicySetRequest, reply *structs.ACLPol
 		// with no policy ID one will be generated
 		var err error
 
+		policy.Namespace = structs.ACLNamespaceOrDefault(policy.Namespace)
+		if !validNamespaceName.MatchString(policy.Namespace) {
+			return fmt.Errorf("Invalid Policy: invalid Namespace. Only alphanumeric characters, '-' and '_' are allowed")
+		}
+
 		policy.ID, err = lib.GenerateUUID(a.srv.checkPolicyUUID)
 		if err != nil {
 			return err
 		}
 
 		// validate the name is unique
-		if _, existing, err := state.ACLPolicyGetByName(nil, policy.Name); err != nil {
+		if _, existing, err := state.ACLPolicyGetByName(nil, policy.Namespace, policy.Name); err != nil {
 			return fmt.Errorf("acl policy lookup by name failed: %v", err)
 		} else if existing != nil {
 			return fmt.Errorf("Invalid Policy: A Policy with Name %q already exists", policy.Name)
@@ -714,12 +828,13 @@ func (a *ACL) PolicySet(args *structs.ACLPolicySetRequest, reply *structs.ACLPol
 		_, existing, err := state.ACLPolicyGetByID(nil, policy.ID)
 		if err != nil {
 			return fmt.Errorf("acl policy lookup failed: %v", err)
-		} else if existing == nil {
+		} else if existing == nil || !structs.ACLNamespaceMatches(policy.Namespace, existing.Namespace) {
 			return fmt.Errorf("cannot find policy %s", policy.ID)
 		}
+		policy.Namespace = structs.ACLNamespaceOrDefault(existing.Namespace)
 
 		if existing.Name != policy.Name {
-			if _, nameMatch, err := state.ACLPolicyGetByName(nil, policy.Name); err != nil {
+			if _, nameMatch, err := state.ACLPolicyGetByName(nil, policy.Namespace, policy.Name); err != nil {
 				return fmt.Errorf("acl policy lookup by name failed: %v", err)
 			} else if nameMatch != nil {
 				return fmt.Errorf("Invalid Policy: A policy with name %q already exists", policy.Name)
@@ -734,14 +849,29 @@ func (a *ACL) PolicySet(args *structs.ACLPolicySetRequest, reply *structs.ACLPol
 			if policy.Rules != existing.Rules {
 				return fmt.Errorf("Changing the Rules for the builtin global-management policy is not permitted")
 			}
+
+			if policy.DeleteAfter != nil {
+				return fmt.Errorf("Deleting the builtin global-management policy is not permitted")
+			}
 		}
 	}
 
+	if policy.IsExpired(time.Now()) {
+		return fmt.Errorf("Invalid Policy: DeleteAfter must be in the future")
+	}
+
+	if err := structs.ValidateLabels(policy.Labels); err != nil {
+		return fmt.Errorf("Invalid Policy: %v", err)
+	}
+
 	// validate the rules
-	_, err := acl.NewPolicyFromSource("", 0, policy.Rules, policy.Syntax, a.srv.sentinel)
+	rules, err := acl.NewPolicyFromSource("", 0, policy.Rules, policy.Syntax, a.srv.sentinel)
 	if err != nil {
 		return err
 	}
+	if err := a.srv.vetNamespacePolicy(args.Token, policy.Namespace, rule, rules); err != nil {
+		return err
+	}
 
 	// calculate the hash for this policy
 	policy.SetHash(true)
@@ -791,12 +921,18 @@ func (a *ACL) PolicyDelete(args *structs.ACLPolicyDeleteRequest, reply *string)
 		return acl.ErrPermissionDenied
 	}
 
+	ns, err := a.srv.bindNamespace(args.Token, args.Namespace)
+	if err != nil {
+		return err
+	}
+	args.Namespace = ns
+
 	_, policy, err := a.srv.fsm.State().ACLPolicyGetByID(nil, args.PolicyID)
 	if err != nil {
 		return err
 	}
 
-	if policy == nil {
+	if policy == nil || !structs.ACLNamespaceMatches(args.Namespace, policy.Namespace) {
 		return nil
 	}
 
@@ -841,6 +977,17 @@ func (a *ACL) PolicyList(args *structs.ACLPolicyListRequest, reply *structs.ACLP
 		return acl.ErrPermissionDenied
 	}
 
+	ns, err := a.srv.bindNamespace(args.Token, args.Namespace)
+	if err != nil {
+		return err
+	}
+	args.Namespace = ns
+
+	selector, err := structs.ParseLabelSelector(args.LabelSelector)
+	if err != nil {
+		return err
+	}
+
 	return a.srv.blockingQuery(&args.QueryOptions, &reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
 			index, policies, err := state.ACLPolicyList(ws)
@@ -848,10 +995,23 @@ func (a *ACL) PolicyList(args *structs.ACLPolicyListRequest, reply *structs.ACLP
 				return err
 			}
 
+			now := time.Now()
 			var stubs structs.ACLPolicyListStubs
 			for _, policy := range policies {
+				if !structs.ACLNamespaceMatches(args.Namespace, policy.Namespace) {
+					continue
+				}
+				if args.ExpiresWithin > 0 && !policy.IsExpired(now.Add(args.ExpiresWithin)) {
+					continue
+				}
+				if !selector.Matches(policy.Labels) {
+					continue
+				}
 				stubs = append(stubs, policy.Stub())
 			}
+			if err := a.srv.filterACL(args.Token, &stubs); err != nil {
+				return err
+			}
 
 			reply.Index, reply.Policies = index, stubs
 			return nil
@@ -966,3 +1126,313 @@ func (a *ACL) ReplicationStatus(args *structs.DCSpecificRequest,
 	a.srv.aclReplicationStatusLock.RUnlock()
 	return nil
 }
+
+// serviceTokenPolicyName returns the name of the policy linked to the tokens
+// created by ServiceTokenCreate to register the given service.
+func serviceTokenPolicyName(service string) string {
+	return serviceTokenScopedPolicyName(serviceTokenPolicyPrefix, service)
+}
+
+// serviceTokenScopedPolicyName returns the name of a policy linked to the
+// tokens created by ServiceTokenCreate, made of the given prefix and the name
+// of the service or node it's about. Service and node names can have
+// characters that policy names can't, such as dots, so those are replaced and
+// a hash of the name is added to keep the names unique.
+func serviceTokenScopedPolicyName(prefix, name string) string {
+	full := prefix + name
+	if validPolicyName.MatchString(full) {
+		return full
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	suffix := "-" + hex.EncodeToString(sum[:4])
+	full = invalidPolicyNameChars.ReplaceAllString(full, "-")
+	if max := 128 - len(suffix); len(full) > max {
+		full = full[:max]
+	}
+	return full + suffix
+}
+
+// serviceTokenPolicyRules returns the rules of the policy linked to the tokens
+// created by ServiceTokenCreate to register the given service.
+func serviceTokenPolicyRules(service string) string {
+	return fmt.Sprintf("service %q {\n  policy = \"write\"\n}\n", service)
+}
+
+const (
+	// serviceTokenPolicyPrefix is the prefix of the names of the policies
+	// letting the tokens created by ServiceTokenCreate register a service.
+	serviceTokenPolicyPrefix = "service-token-"
+
+	// serviceTokenServiceReadPrefix and serviceTokenNodeReadPrefix are the
+	// prefixes of the names of the policies letting the tokens created by
+	// ServiceTokenCreate read a service or a node, so that the sidecar
+	// proxies using them can discover their upstreams and look up their
+	// intentions.
+	serviceTokenServiceReadPrefix = "service-read-"
+	serviceTokenNodeReadPrefix    = "node-read-"
+
+	// serviceTokenNodesReadPolicy is the name of the policy letting the
+	// tokens created by ServiceTokenCreate read all the nodes, so the
+	// sidecar proxies keep finding their upstreams wherever they move.
+	serviceTokenNodesReadPolicy = "service-tokens-node-read"
+
+	// serviceTokenNodesReadRules are the rules of the
+	// serviceTokenNodesReadPolicy policy.
+	serviceTokenNodesReadRules = "node_prefix \"\" {\n  policy = \"read\"\n}\n"
+)
+
+// serviceTokenReadRules returns the rules of a policy letting the tokens
+// created by ServiceTokenCreate read the given service or node.
+func serviceTokenReadRules(kind, name string) string {
+	return fmt.Sprintf("%s %q {\n  policy = \"read\"\n}\n", kind, name)
+}
+
+// isServiceTokenPolicyName returns whether the given policy name is one of
+// the names of the policies linked to the tokens created by
+// ServiceTokenCreate.
+func isServiceTokenPolicyName(name string) bool {
+	return strings.HasPrefix(name, serviceTokenPolicyPrefix) ||
+		strings.HasPrefix(name, serviceTokenServiceReadPrefix) ||
+		strings.HasPrefix(name, serviceTokenNodeReadPrefix) ||
+		name == serviceTokenNodesReadPolicy
+}
+
+// ServiceTokenCreate creates a token that is only allowed to register the
+// given service, and its sidecar proxy if the request token can register it
+// too. The token can also read the service and its upstreams, each only if
+// the request token can read it. It can read all the nodes if the request
+// token can, so it doesn't depend on where the upstreams run, and otherwise
+// only the node the service is registered on. The request
+// token needs service:write on the service rather than acl:write so agents
+// can provision a token for each service they register, and the created
+// token never has a permission the request token doesn't. The token is
+// created in the namespace of the request token.
+func (a *ACL) ServiceTokenCreate(args *structs.ACLServiceTokenRequest, reply *structs.ACLToken) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	// The token and its policy are global so they are always created in
+	// the ACL DC.
+	args.Datacenter = a.srv.config.ACLDatacenter
+	if done, err := a.srv.forward("ACL.ServiceTokenCreate", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "service_token", "create"}, time.Now())
+
+	if args.ServiceName == "" {
+		return fmt.Errorf("Missing service name")
+	}
+
+	// Verify token is permitted to register the service
+	rule, err := a.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	} else if rule == nil || !rule.ServiceWrite(args.ServiceName, nil) {
+		return acl.ErrPermissionDenied
+	}
+
+	ns, err := a.srv.bindNamespace(args.Token, "")
+	if err != nil {
+		return err
+	}
+
+	services := []string{args.ServiceName}
+	if sidecar := args.ServiceName + "-sidecar-proxy"; rule.ServiceWrite(sidecar, nil) {
+		services = append(services, sidecar)
+	}
+
+	req := &structs.ACLTokenSetRequest{
+		ACLToken: structs.ACLToken{
+			Description: fmt.Sprintf("Service token for %q", args.ServiceName),
+			Namespace:   ns,
+		},
+	}
+	link := func(name, rules, description string) error {
+		policy, err := a.serviceTokenPolicy(ns, name, rules, description)
+		if err != nil {
+			return err
+		}
+		req.ACLToken.Policies = append(req.ACLToken.Policies, structs.ACLTokenPolicyLink{ID: policy.ID})
+		return nil
+	}
+	for _, service := range services {
+		if err := link(serviceTokenPolicyName(service), serviceTokenPolicyRules(service),
+			fmt.Sprintf("Policy for the service tokens of %q", service)); err != nil {
+			return err
+		}
+	}
+
+	for _, service := range serviceTokenReads(args) {
+		if !rule.ServiceRead(service) {
+			continue
+		}
+		if err := link(serviceTokenScopedPolicyName(serviceTokenServiceReadPrefix, service),
+			serviceTokenReadRules("service", service),
+			fmt.Sprintf("Policy for the service tokens to read the service %q", service)); err != nil {
+			return err
+		}
+	}
+	switch {
+	case args.Node == "" && len(args.Upstreams) == 0:
+		// There is nothing to discover, so no node to read.
+	case rule.NodeReadPrefix(""):
+		if err := link(serviceTokenNodesReadPolicy, serviceTokenNodesReadRules,
+			"Policy for the service tokens to read all the nodes"); err != nil {
+			return err
+		}
+	case args.Node != "" && rule.NodeRead(args.Node):
+		if err := link(serviceTokenScopedPolicyName(serviceTokenNodeReadPrefix, args.Node),
+			serviceTokenReadRules("node", args.Node),
+			fmt.Sprintf("Policy for the service tokens to read the node %q", args.Node)); err != nil {
+			return err
+		}
+	}
+	return a.tokenSetInternal(req, reply, false)
+}
+
+// serviceTokenReads returns the services the token created by
+// ServiceTokenCreate needs to read: the service and its upstreams.
+func serviceTokenReads(args *structs.ACLServiceTokenRequest) []string {
+	services := []string{args.ServiceName}
+	seen := map[string]struct{}{args.ServiceName: struct{}{}}
+	for _, upstream := range args.Upstreams {
+		if _, ok := seen[upstream]; upstream == "" || ok {
+			continue
+		}
+		seen[upstream] = struct{}{}
+		services = append(services, upstream)
+	}
+	return services
+}
+
+// serviceTokenPolicy returns the policy with the given name and rules for the
+// tokens created by ServiceTokenCreate, creating it if needed.
+func (a *ACL) serviceTokenPolicy(ns, name, rules, description string) (*structs.ACLPolicy, error) {
+	state := a.srv.fsm.State()
+
+	lookup := func() (*structs.ACLPolicy, error) {
+		_, existing, err := state.ACLPolicyGetByName(nil, ns, name)
+		if err != nil {
+			return nil, fmt.Errorf("acl policy lookup by name failed: %v", err)
+		}
+		// Don't hand out tokens linked to a policy someone else created
+		// with the same name.
+		if existing != nil && existing.Rules != rules {
+			return nil, fmt.Errorf("Policy %q already exists with different rules", name)
+		}
+		return existing, nil
+	}
+	existing, err := lookup()
+	if err != nil || existing != nil {
+		return existing, err
+	}
+
+	policy := &structs.ACLPolicy{
+		Name:        name,
+		Description: description,
+		Namespace:   structs.ACLNamespaceOrDefault(ns),
+		Rules:       rules,
+	}
+	policy.ID, err = lib.GenerateUUID(a.srv.checkPolicyUUID)
+	if err != nil {
+		return nil, err
+	}
+	policy.SetHash(true)
+
+	req := &structs.ACLPolicyBatchSetRequest{
+		Policies: structs.ACLPolicies{policy},
+	}
+	resp, err := a.srv.raftApply(structs.ACLPolicySetRequestType, req)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to apply policy upsert request: %v", err)
+	}
+	if respErr, ok := resp.(error); ok {
+		// Another request for a token may have created the policy since
+		// the lookup above, in which case it can be used as well.
+		if existing, err := lookup(); err != nil || existing != nil {
+			return existing, err
+		}
+		return nil, respErr
+	}
+
+	_, policy, err = state.ACLPolicyGetByID(nil, policy.ID)
+	if err != nil || policy == nil {
+		return nil, fmt.Errorf("Failed to retrieve the policy after insertion")
+	}
+	return policy, nil
+}
+
+// ServiceTokenDelete deletes the request token, which must have been created
+// by ServiceTokenCreate for the given service. It's used by agents to clean
+// up the token of a service when it's deregistered.
+func (a *ACL) ServiceTokenDelete(args *structs.ACLServiceTokenRequest, reply *string) error {
+	if err := a.aclPreCheck(); err != nil {
+		return err
+	}
+
+	args.Datacenter = a.srv.config.ACLDatacenter
+	if done, err := a.srv.forward("ACL.ServiceTokenDelete", args, args, reply); done {
+		return err
+	}
+
+	defer metrics.MeasureSince([]string{"acl", "service_token", "delete"}, time.Now())
+
+	state := a.srv.fsm.State()
+	_, token, err := state.ACLTokenGetBySecret(nil, args.Token)
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return acl.ErrNotFound
+	}
+
+	// The token must only be linked to the policy of the service and to
+	// the other policies ServiceTokenCreate links.
+	_, policy, err := state.ACLPolicyGetByName(nil, token.Namespace, serviceTokenPolicyName(args.ServiceName))
+	if err != nil {
+		return fmt.Errorf("acl policy lookup by name failed: %v", err)
+	}
+	if policy == nil || token.Local || len(token.Policies) == 0 {
+		return ErrNotServiceToken
+	}
+	var linked bool
+	for _, link := range token.Policies {
+		if link.ID == policy.ID {
+			linked = true
+			continue
+		}
+		_, other, err := state.ACLPolicyGetByID(nil, link.ID)
+		if err != nil {
+			return fmt.Errorf("acl policy lookup by id failed: %v", err)
+		}
+		if other == nil || !isServiceTokenPolicyName(other.Name) {
+			return ErrNotServiceToken
+		}
+	}
+	if !linked {
+		return ErrNotServiceToken
+	}
+
+	req := &structs.ACLTokenBatchDeleteRequest{
+		TokenIDs: []string{token.AccessorID},
+	}
+	resp, err := a.srv.raftApply(structs.ACLTokenDeleteRequestType, req)
+	if err != nil {
+		return fmt.Errorf("Failed to apply token delete request: %v", err)
+	}
+
+	// Purge the identity from the cache to prevent using the previous definition of the identity
+	a.srv.acls.cache.RemoveIdentity(token.SecretID)
+
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	if reply != nil {
+		*reply = token.AccessorID
+	}
+	return nil
+}
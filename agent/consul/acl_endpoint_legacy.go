@@ -165,6 +165,14 @@ func (a *ACL) Apply(args *structs.ACLRequest, reply *string) error {
 		return acl.ErrPermissionDenied
 	}
 
+	// The legacy ACLs aren't namespaced, so they are out of reach of the
+	// tokens bound to a namespace.
+	if ns, err := a.srv.bindNamespace(args.Token, ""); err != nil {
+		return err
+	} else if ns != "" {
+		return acl.ErrPermissionDenied
+	}
+
 	// If no ID is provided, generate a new ID. This must be done prior to
 	// appending to the Raft log, because the ID is not deterministic. Once
 	// the entry is in the log, the state update MUST be deterministic or
@@ -246,6 +254,14 @@ func (a *ACL) List(args *structs.DCSpecificRequest,
 		return acl.ErrPermissionDenied
 	}
 
+	// The legacy ACLs aren't namespaced, so they are out of reach of the
+	// tokens bound to a namespace.
+	if ns, err := a.srv.bindNamespace(args.Token, ""); err != nil {
+		return err
+	} else if ns != "" {
+		return acl.ErrPermissionDenied
+	}
+
 	return a.srv.blockingQuery(&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
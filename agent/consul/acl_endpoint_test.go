@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
 	tokenStore "github.com/hashicorp/consul/agent/token"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
@@ -1386,6 +1387,417 @@ func TestACLEndpoint_PolicyDelete_globalManagement(t *testing.T) {
 	require.EqualError(t, err, "Delete operation not permitted on the builtin global-management policy")
 }
 
+func TestACLEndpoint_Namespaces(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	endpoint := ACL{srv: s1}
+	write := structs.WriteRequest{Token: "root"}
+
+	// Policies with the same name in two namespaces
+	var defaultPolicy, teamPolicy structs.ACLPolicy
+	require.NoError(t, endpoint.PolicySet(&structs.ACLPolicySetRequest{
+		Datacenter:   "dc1",
+		Policy:       structs.ACLPolicy{Name: "reader", Rules: `node_prefix "" { policy = "read" }`},
+		WriteRequest: write,
+	}, &defaultPolicy))
+	require.Equal(t, structs.ACLDefaultNamespace, defaultPolicy.Namespace)
+
+	require.NoError(t, endpoint.PolicySet(&structs.ACLPolicySetRequest{
+		Datacenter:   "dc1",
+		Policy:       structs.ACLPolicy{Name: "reader", Namespace: "team-a", Rules: `node_prefix "" { policy = "read" }`},
+		WriteRequest: write,
+	}, &teamPolicy))
+	require.Equal(t, "team-a", teamPolicy.Namespace)
+
+	err := endpoint.PolicySet(&structs.ACLPolicySetRequest{
+		Datacenter:   "dc1",
+		Policy:       structs.ACLPolicy{Name: "reader", Namespace: "bad namespace"},
+		WriteRequest: write,
+	}, &structs.ACLPolicy{})
+	require.Error(t, err)
+
+	// Tokens resolve policy names in their own namespace
+	var token structs.ACLToken
+	require.NoError(t, endpoint.TokenSet(&structs.ACLTokenSetRequest{
+		Datacenter: "dc1",
+		ACLToken: structs.ACLToken{
+			Namespace: "team-a",
+			Policies:  []structs.ACLTokenPolicyLink{{Name: "reader"}},
+		},
+		WriteRequest: write,
+	}, &token))
+	require.Equal(t, teamPolicy.ID, token.Policies[0].ID)
+
+	err = endpoint.TokenSet(&structs.ACLTokenSetRequest{
+		Datacenter: "dc1",
+		ACLToken: structs.ACLToken{
+			Namespace: "team-a",
+			Policies:  []structs.ACLTokenPolicyLink{{ID: defaultPolicy.ID}},
+		},
+		WriteRequest: write,
+	}, &structs.ACLToken{})
+	require.Error(t, err)
+
+	// Reads are scoped to the namespace
+	var readResp structs.ACLTokenResponse
+	require.NoError(t, endpoint.TokenRead(&structs.ACLTokenGetRequest{
+		Datacenter:   "dc1",
+		TokenID:      token.AccessorID,
+		TokenIDType:  structs.ACLTokenAccessor,
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}, &readResp))
+	require.Nil(t, readResp.Token)
+
+	require.NoError(t, endpoint.TokenRead(&structs.ACLTokenGetRequest{
+		Datacenter:   "dc1",
+		TokenID:      token.AccessorID,
+		TokenIDType:  structs.ACLTokenAccessor,
+		Namespace:    "team-a",
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}, &readResp))
+	require.NotNil(t, readResp.Token)
+
+	var policyList structs.ACLPolicyListResponse
+	require.NoError(t, endpoint.PolicyList(&structs.ACLPolicyListRequest{
+		Datacenter:   "dc1",
+		Namespace:    "team-a",
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}, &policyList))
+	require.Len(t, policyList.Policies, 1)
+	require.Equal(t, teamPolicy.ID, policyList.Policies[0].ID)
+
+	require.NoError(t, endpoint.PolicyList(&structs.ACLPolicyListRequest{
+		Datacenter:   "dc1",
+		Namespace:    structs.ACLWildcardNamespace,
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}, &policyList))
+	require.Len(t, policyList.Policies, 3)
+
+	// Deleting from the wrong namespace is a no-op
+	var ignored string
+	require.NoError(t, endpoint.PolicyDelete(&structs.ACLPolicyDeleteRequest{
+		Datacenter:   "dc1",
+		PolicyID:     teamPolicy.ID,
+		WriteRequest: write,
+	}, &ignored))
+	_, policy, err := s1.fsm.State().ACLPolicyGetByID(nil, teamPolicy.ID)
+	require.NoError(t, err)
+	require.NotNil(t, policy)
+}
+
+func TestACLEndpoint_TokenNamePrefix(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	endpoint := ACL{srv: s1}
+	write := structs.WriteRequest{Token: "root"}
+
+	var policy structs.ACLPolicy
+	require.NoError(t, endpoint.PolicySet(&structs.ACLPolicySetRequest{
+		Datacenter: "dc1",
+		Policy: structs.ACLPolicy{
+			Name:  "writer",
+			Rules: `key_prefix "" { policy = "write" } service_prefix "" { policy = "write" }`,
+		},
+		WriteRequest: write,
+	}, &policy))
+
+	var token structs.ACLToken
+	require.NoError(t, endpoint.TokenSet(&structs.ACLTokenSetRequest{
+		Datacenter: "dc1",
+		ACLToken: structs.ACLToken{
+			NamePrefix: "team-a/",
+			Policies:   []structs.ACLTokenPolicyLink{{ID: policy.ID}},
+		},
+		WriteRequest: write,
+	}, &token))
+	require.Equal(t, "team-a/", token.NamePrefix)
+
+	authz, err := s1.ResolveToken(token.SecretID)
+	require.NoError(t, err)
+	require.True(t, authz.KeyWrite("team-a/config", nil))
+	require.False(t, authz.KeyWrite("team-b/config", nil))
+	require.True(t, authz.KeyWritePrefix("team-a/"))
+	require.False(t, authz.KeyWritePrefix("team"))
+	require.True(t, authz.ServiceWrite("team-a/web", nil))
+	require.False(t, authz.ServiceWrite("web", nil))
+	require.True(t, authz.KeyRead("team-b/config"))
+
+	// The prefix is enforced on the writes going through the endpoints
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	var out bool
+	err = msgpackrpc.CallWithCodec(codec, "KVS.Apply", &structs.KVSRequest{
+		Datacenter:   "dc1",
+		Op:           api.KVSet,
+		DirEnt:       structs.DirEntry{Key: "team-b/config", Value: []byte("nope")},
+		WriteRequest: structs.WriteRequest{Token: token.SecretID},
+	}, &out)
+	require.True(t, acl.IsErrPermissionDenied(err))
+
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "KVS.Apply", &structs.KVSRequest{
+		Datacenter:   "dc1",
+		Op:           api.KVSet,
+		DirEnt:       structs.DirEntry{Key: "team-a/config", Value: []byte("ok")},
+		WriteRequest: structs.WriteRequest{Token: token.SecretID},
+	}, &out))
+	_, entry, err := s1.fsm.State().KVSGet(nil, "team-a/config")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+}
+
+func TestACLEndpoint_Namespaces_BoundToken(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	endpoint := ACL{srv: s1}
+
+	// An ACL manager of the team-a namespace
+	var adminPolicy structs.ACLPolicy
+	require.NoError(t, endpoint.PolicySet(&structs.ACLPolicySetRequest{
+		Datacenter:   "dc1",
+		Policy:       structs.ACLPolicy{Name: "admin", Namespace: "team-a", Rules: `acl = "write"`},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}, &adminPolicy))
+	var admin structs.ACLToken
+	require.NoError(t, endpoint.TokenSet(&structs.ACLTokenSetRequest{
+		Datacenter: "dc1",
+		ACLToken: structs.ACLToken{
+			Namespace: "team-a",
+			Policies:  []structs.ACLTokenPolicyLink{{ID: adminPolicy.ID}},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}, &admin))
+
+	// Its writes go to its own namespace
+	var token structs.ACLToken
+	require.NoError(t, endpoint.TokenSet(&structs.ACLTokenSetRequest{
+		Datacenter:   "dc1",
+		ACLToken:     structs.ACLToken{Policies: []structs.ACLTokenPolicyLink{{Name: "admin"}}},
+		WriteRequest: structs.WriteRequest{Token: admin.SecretID},
+	}, &token))
+	require.Equal(t, "team-a", token.Namespace)
+
+	err := endpoint.TokenSet(&structs.ACLTokenSetRequest{
+		Datacenter:   "dc1",
+		ACLToken:     structs.ACLToken{Namespace: structs.ACLDefaultNamespace},
+		WriteRequest: structs.WriteRequest{Token: admin.SecretID},
+	}, &structs.ACLToken{})
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	err = endpoint.PolicySet(&structs.ACLPolicySetRequest{
+		Datacenter:   "dc1",
+		Policy:       structs.ACLPolicy{ID: structs.ACLPolicyGlobalManagementID, Name: "global-management", Namespace: structs.ACLDefaultNamespace},
+		WriteRequest: structs.WriteRequest{Token: admin.SecretID},
+	}, &structs.ACLPolicy{})
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	// The wildcard only lists its own namespace
+	var policyList structs.ACLPolicyListResponse
+	require.NoError(t, endpoint.PolicyList(&structs.ACLPolicyListRequest{
+		Datacenter:   "dc1",
+		Namespace:    structs.ACLWildcardNamespace,
+		QueryOptions: structs.QueryOptions{Token: admin.SecretID},
+	}, &policyList))
+	require.Len(t, policyList.Policies, 1)
+	require.Equal(t, adminPolicy.ID, policyList.Policies[0].ID)
+
+	var tokenList structs.ACLTokenListResponse
+	require.NoError(t, endpoint.TokenList(&structs.ACLTokenListRequest{
+		Datacenter:    "dc1",
+		Namespace:     structs.ACLWildcardNamespace,
+		IncludeLocal:  true,
+		IncludeGlobal: true,
+		QueryOptions:  structs.QueryOptions{Token: admin.SecretID},
+	}, &tokenList))
+	require.Len(t, tokenList.Tokens, 2)
+	for _, stub := range tokenList.Tokens {
+		require.Equal(t, "team-a", stub.Namespace)
+	}
+
+	err = endpoint.TokenList(&structs.ACLTokenListRequest{
+		Datacenter:   "dc1",
+		Namespace:    structs.ACLDefaultNamespace,
+		QueryOptions: structs.QueryOptions{Token: admin.SecretID},
+	}, &tokenList)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	// The objects of the other namespaces can't be read by ID
+	var tokenResp structs.ACLTokenResponse
+	require.NoError(t, endpoint.TokenRead(&structs.ACLTokenGetRequest{
+		Datacenter:   "dc1",
+		TokenID:      structs.ACLTokenAnonymousID,
+		TokenIDType:  structs.ACLTokenAccessor,
+		QueryOptions: structs.QueryOptions{Token: admin.SecretID},
+	}, &tokenResp))
+	require.Nil(t, tokenResp.Token)
+
+	var policyResp structs.ACLPolicyResponse
+	require.NoError(t, endpoint.PolicyRead(&structs.ACLPolicyGetRequest{
+		Datacenter:   "dc1",
+		PolicyID:     structs.ACLPolicyGlobalManagementID,
+		QueryOptions: structs.QueryOptions{Token: admin.SecretID},
+	}, &policyResp))
+	require.Nil(t, policyResp.Policy)
+
+	var batchResp structs.ACLPolicyBatchResponse
+	require.NoError(t, endpoint.PolicyBatchRead(&structs.ACLPolicyBatchGetRequest{
+		Datacenter:   "dc1",
+		PolicyIDs:    []string{structs.ACLPolicyGlobalManagementID, adminPolicy.ID},
+		QueryOptions: structs.QueryOptions{Token: admin.SecretID},
+	}, &batchResp))
+	require.Len(t, batchResp.Policies, 1)
+	require.Equal(t, adminPolicy.ID, batchResp.Policies[0].ID)
+
+	// A link to a policy of another namespace grants nothing, even if it
+	// made it into the state store.
+	linked := token
+	linked.Policies = append(linked.Policies, structs.ACLTokenPolicyLink{ID: structs.ACLPolicyGlobalManagementID})
+	_, err = s1.raftApply(structs.ACLTokenSetRequestType, &structs.ACLTokenBatchSetRequest{
+		Tokens: structs.ACLTokens{&linked},
+	})
+	require.NoError(t, err)
+	s1.acls.cache.RemoveIdentity(linked.SecretID)
+
+	authz, err := s1.ResolveToken(linked.SecretID)
+	require.NoError(t, err)
+	require.True(t, authz.ACLWrite())
+	require.False(t, authz.OperatorWrite())
+}
+
+func TestACLEndpoint_Namespaces_BoundTokenPermissions(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	endpoint := ACL{srv: s1}
+
+	policies := 0
+	policySet := func(token, rules string) error {
+		policies++
+		return endpoint.PolicySet(&structs.ACLPolicySetRequest{
+			Datacenter:   "dc1",
+			Policy:       structs.ACLPolicy{Name: fmt.Sprintf("policy-%d", policies), Namespace: "team-a", Rules: rules},
+			WriteRequest: structs.WriteRequest{Token: token},
+		}, &structs.ACLPolicy{})
+	}
+
+	// The operator rules cover the whole cluster, even for the operators.
+	err := policySet("root", `operator = "read"`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "operator and keyring rules")
+	err = policySet("root", `keyring = "write"`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "operator and keyring rules")
+
+	// The operators decide what the ACL managers of the namespace get.
+	var adminPolicy structs.ACLPolicy
+	require.NoError(t, endpoint.PolicySet(&structs.ACLPolicySetRequest{
+		Datacenter: "dc1",
+		Policy: structs.ACLPolicy{Name: "admin", Namespace: "team-a", Rules: `
+acl = "write"
+key_prefix "team-a/" { policy = "write" }
+key "team-a/secret" { policy = "deny" }
+service_prefix "" { policy = "read" }
+`},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}, &adminPolicy))
+	var admin structs.ACLToken
+	require.NoError(t, endpoint.TokenSet(&structs.ACLTokenSetRequest{
+		Datacenter: "dc1",
+		ACLToken: structs.ACLToken{
+			Namespace: "team-a",
+			Policies:  []structs.ACLTokenPolicyLink{{ID: adminPolicy.ID}},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}, &admin))
+
+	// The ACL managers can only grant what they have.
+	for _, rules := range []string{
+		`acl = "write"`,
+		`key_prefix "team-a/app/" { policy = "read" }`,
+		`key "team-a/app" { policy = "write" }`,
+		`service "web" { policy = "read" }`,
+	} {
+		require.NoError(t, policySet(admin.SecretID, rules), "rules: %s", rules)
+	}
+	for _, rules := range []string{
+		`key_prefix "" { policy = "write" }`,
+		`key_prefix "team-a/" { policy = "read" }`,
+		`key_prefix "team-a/" { policy = "write" } key "team-a/secret" { policy = "read" }`,
+		`key_prefix "team-" { policy = "read" }`,
+		`service "web" { policy = "write" }`,
+		`node_prefix "" { policy = "read" }`,
+	} {
+		err := policySet(admin.SecretID, rules)
+		require.True(t, acl.IsErrPermissionDenied(err), "rules: %s, err: %v", rules, err)
+	}
+
+	// The permissions over the whole cluster are denied to the tokens of
+	// the namespace.
+	authz, err := s1.ResolveToken(admin.SecretID)
+	require.NoError(t, err)
+	require.True(t, authz.ACLWrite())
+	require.False(t, authz.Snapshot())
+	require.False(t, authz.OperatorRead())
+	require.False(t, authz.KeyringRead())
+
+	// And so are the legacy ACLs.
+	var id string
+	err = endpoint.Apply(&structs.ACLRequest{
+		Datacenter:   "dc1",
+		Op:           structs.ACLSet,
+		ACL:          structs.ACL{Type: structs.ACLTokenTypeManagement},
+		WriteRequest: structs.WriteRequest{Token: admin.SecretID},
+	}, &id)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	err = endpoint.List(&structs.DCSpecificRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{Token: admin.SecretID},
+	}, &structs.IndexedACLs{})
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+}
+
 func TestACLEndpoint_PolicyList(t *testing.T) {
 	t.Parallel()
 
@@ -1428,6 +1840,151 @@ func TestACLEndpoint_PolicyList(t *testing.T) {
 	require.Subset(t, retrievedPolicies, policies)
 }
 
+func TestACLEndpoint_PolicyList_expiresWithin(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	acl := ACL{srv: s1}
+	setPolicy := func(name string, deleteAfter *time.Time) error {
+		req := structs.ACLPolicySetRequest{
+			Datacenter: "dc1",
+			Policy: structs.ACLPolicy{
+				Name:        name,
+				DeleteAfter: deleteAfter,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		return acl.PolicySet(&req, &structs.ACLPolicy{})
+	}
+
+	// The deletion time must be in the future
+	past := time.Now().Add(-time.Minute)
+	require.Error(t, setPolicy("past", &past))
+
+	soon := time.Now().Add(time.Hour)
+	later := time.Now().Add(48 * time.Hour)
+	require.NoError(t, setPolicy("soon", &soon))
+	require.NoError(t, setPolicy("later", &later))
+	require.NoError(t, setPolicy("never", nil))
+
+	list := func(within time.Duration) []string {
+		req := structs.ACLPolicyListRequest{
+			Datacenter:    "dc1",
+			ExpiresWithin: within,
+			QueryOptions:  structs.QueryOptions{Token: "root"},
+		}
+		var resp structs.ACLPolicyListResponse
+		require.NoError(t, acl.PolicyList(&req, &resp))
+
+		var names []string
+		for _, policy := range resp.Policies {
+			names = append(names, policy.Name)
+		}
+		return names
+	}
+
+	require.ElementsMatch(t, []string{"global-management", "soon", "later", "never"}, list(0))
+	require.ElementsMatch(t, []string{"soon"}, list(24*time.Hour))
+	require.ElementsMatch(t, []string{"soon", "later"}, list(72*time.Hour))
+}
+
+func TestACLEndpoint_List_labelSelector(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	acl := ACL{srv: s1}
+	setPolicy := func(name string, labels map[string]string) error {
+		req := structs.ACLPolicySetRequest{
+			Datacenter: "dc1",
+			Policy: structs.ACLPolicy{
+				Name:   name,
+				Labels: labels,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		return acl.PolicySet(&req, &structs.ACLPolicy{})
+	}
+	setToken := func(description string, labels map[string]string) error {
+		req := structs.ACLTokenSetRequest{
+			Datacenter: "dc1",
+			ACLToken: structs.ACLToken{
+				Description: description,
+				Labels:      labels,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		return acl.TokenSet(&req, &structs.ACLToken{})
+	}
+
+	require.NoError(t, setPolicy("payments-prod", map[string]string{"team": "payments", "env": "prod"}))
+	require.NoError(t, setPolicy("payments-dev", map[string]string{"team": "payments", "env": "dev"}))
+	require.Error(t, setPolicy("invalid", map[string]string{"team": "pay ments"}))
+	require.NoError(t, setToken("payments-prod", map[string]string{"team": "payments", "env": "prod"}))
+	require.NoError(t, setToken("payments-dev", map[string]string{"team": "payments", "env": "dev"}))
+	require.Error(t, setToken("invalid", map[string]string{"": "x"}))
+
+	listPolicies := func(selector string) []string {
+		req := structs.ACLPolicyListRequest{
+			Datacenter:   "dc1",
+			QueryOptions: structs.QueryOptions{Token: "root", LabelSelector: selector},
+		}
+		var resp structs.ACLPolicyListResponse
+		require.NoError(t, acl.PolicyList(&req, &resp))
+
+		var names []string
+		for _, policy := range resp.Policies {
+			names = append(names, policy.Name)
+		}
+		return names
+	}
+	listTokens := func(selector string) []string {
+		req := structs.ACLTokenListRequest{
+			Datacenter:    "dc1",
+			IncludeLocal:  true,
+			IncludeGlobal: true,
+			QueryOptions:  structs.QueryOptions{Token: "root", LabelSelector: selector},
+		}
+		var resp structs.ACLTokenListResponse
+		require.NoError(t, acl.TokenList(&req, &resp))
+
+		var descriptions []string
+		for _, token := range resp.Tokens {
+			descriptions = append(descriptions, token.Description)
+		}
+		return descriptions
+	}
+
+	require.ElementsMatch(t, []string{"payments-prod"}, listPolicies("team=payments,env!=dev"))
+	require.ElementsMatch(t, []string{"payments-prod", "payments-dev"}, listPolicies("team"))
+	require.ElementsMatch(t, []string{"global-management"}, listPolicies("!team"))
+	require.ElementsMatch(t, []string{"payments-prod"}, listTokens("team=payments,env!=dev"))
+	require.ElementsMatch(t, []string{"payments-dev"}, listTokens("env=dev"))
+
+	req := structs.ACLTokenListRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{Token: "root", LabelSelector: "team=pay ments"},
+	}
+	require.Error(t, acl.TokenList(&req, &structs.ACLTokenListResponse{}))
+}
+
 func TestACLEndpoint_PolicyResolve(t *testing.T) {
 	t.Parallel()
 
@@ -1490,6 +2047,418 @@ func TestACLEndpoint_PolicyResolve(t *testing.T) {
 	require.EqualValues(t, retrievedPolicies, policies)
 }
 
+func TestACLEndpoint_ServiceTokenCreate(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Make a token that can only register "web".
+	var webToken structs.ACLToken
+	{
+		req := structs.ACLPolicySetRequest{
+			Datacenter: "dc1",
+			Policy: structs.ACLPolicy{
+				Name:  "web-write",
+				Rules: `service "web" { policy = "write" }`,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var policy structs.ACLPolicy
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.PolicySet", &req, &policy))
+
+		tokenReq := structs.ACLTokenSetRequest{
+			Datacenter: "dc1",
+			ACLToken: structs.ACLToken{
+				Policies: []structs.ACLTokenPolicyLink{{ID: policy.ID}},
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.TokenSet", &tokenReq, &webToken))
+	}
+
+	endpoint := ACL{srv: s1}
+
+	t.Run("creates a token", func(t *testing.T) {
+		req := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "web",
+			WriteRequest: structs.WriteRequest{Token: webToken.SecretID},
+		}
+		var token structs.ACLToken
+		require.NoError(t, endpoint.ServiceTokenCreate(&req, &token))
+		require.NotEmpty(t, token.SecretID)
+		require.Len(t, token.Policies, 2)
+
+		policy, err := retrieveTestPolicy(codec, "root", "dc1", token.Policies[0].ID)
+		require.NoError(t, err)
+		require.Equal(t, "service-token-web", policy.Policy.Name)
+
+		// The token can register the service but nothing else, not even
+		// the sidecar since the request token can't.
+		rule, err := s1.ResolveToken(token.SecretID)
+		require.NoError(t, err)
+		require.True(t, rule.ServiceWrite("web", nil))
+		require.False(t, rule.ServiceWrite("web-sidecar-proxy", nil))
+		require.False(t, rule.ServiceWrite("db", nil))
+		require.False(t, rule.ServiceRead("db"))
+		require.False(t, rule.NodeRead("node1"))
+		require.False(t, rule.ACLWrite())
+
+		// A second token reuses the policy.
+		var token2 structs.ACLToken
+		require.NoError(t, endpoint.ServiceTokenCreate(&req, &token2))
+		require.NotEqual(t, token.AccessorID, token2.AccessorID)
+		require.Equal(t, token.Policies[0].ID, token2.Policies[0].ID)
+	})
+
+	t.Run("requires service write", func(t *testing.T) {
+		req := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "db",
+			WriteRequest: structs.WriteRequest{Token: webToken.SecretID},
+		}
+		var token structs.ACLToken
+		err := endpoint.ServiceTokenCreate(&req, &token)
+		require.True(t, acl.IsErrPermissionDenied(err), "unexpected error: %v", err)
+	})
+
+	t.Run("includes the sidecar the request token can register", func(t *testing.T) {
+		req := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "api",
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var token structs.ACLToken
+		require.NoError(t, endpoint.ServiceTokenCreate(&req, &token))
+		require.Len(t, token.Policies, 3)
+
+		rule, err := s1.ResolveToken(token.SecretID)
+		require.NoError(t, err)
+		require.True(t, rule.ServiceWrite("api", nil))
+		require.True(t, rule.ServiceWrite("api-sidecar-proxy", nil))
+		require.False(t, rule.ServiceWrite("db", nil))
+	})
+
+	t.Run("grants the reads of the upstreams the request token has", func(t *testing.T) {
+		// The upstreams run on other nodes.
+		for _, reg := range []structs.RegisterRequest{
+			{Node: "node2", Address: "127.0.0.2", Service: &structs.NodeService{Service: "db"}},
+			{Node: "node3", Address: "127.0.0.3", Service: &structs.NodeService{Service: "secret"}},
+		} {
+			reg.Datacenter = "dc1"
+			reg.WriteRequest = structs.WriteRequest{Token: "root"}
+			var out struct{}
+			require.NoError(t, msgpackrpc.CallWithCodec(codec, "Catalog.Register", &reg, &out))
+		}
+
+		req := structs.ACLPolicySetRequest{
+			Datacenter: "dc1",
+			Policy: structs.ACLPolicy{
+				Name: "proxy-write",
+				Rules: `
+service "proxy" { policy = "write" }
+service_prefix "" { policy = "read" }
+service "secret" { policy = "deny" }
+node_prefix "" { policy = "read" }
+node "node3" { policy = "deny" }
+`,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var policy structs.ACLPolicy
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.PolicySet", &req, &policy))
+
+		tokenReq := structs.ACLTokenSetRequest{
+			Datacenter: "dc1",
+			ACLToken: structs.ACLToken{
+				Policies: []structs.ACLTokenPolicyLink{{ID: policy.ID}},
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var proxyToken structs.ACLToken
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.TokenSet", &tokenReq, &proxyToken))
+
+		serviceReq := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "proxy",
+			Node:         "node1",
+			Upstreams:    []string{"db", "secret"},
+			WriteRequest: structs.WriteRequest{Token: proxyToken.SecretID},
+		}
+		var token structs.ACLToken
+		require.NoError(t, endpoint.ServiceTokenCreate(&serviceReq, &token))
+
+		// The sidecar proxy using the token can discover its upstreams
+		// and look up their intentions, but can't register them. It
+		// doesn't get the reads the request token is denied, nor the
+		// reads of the other services. Since the request token can't read
+		// all the nodes, it only gets the read of its own node.
+		rule, err := s1.ResolveToken(token.SecretID)
+		require.NoError(t, err)
+		require.True(t, rule.ServiceWrite("proxy", nil))
+		require.True(t, rule.ServiceRead("db"))
+		require.True(t, rule.IntentionRead("db"))
+		require.True(t, rule.NodeRead("node1"))
+		require.False(t, rule.NodeRead("node2"))
+		require.False(t, rule.ServiceRead("secret"))
+		require.False(t, rule.NodeRead("node3"))
+		require.False(t, rule.ServiceRead("other"))
+		require.False(t, rule.NodeRead("node4"))
+		require.False(t, rule.ServiceWrite("db", nil))
+		require.False(t, rule.NodeWrite("node1", nil))
+		require.False(t, rule.IntentionWrite("db"))
+	})
+
+	t.Run("grants the read of all the nodes if the request token has it", func(t *testing.T) {
+		req := structs.ACLPolicySetRequest{
+			Datacenter: "dc1",
+			Policy: structs.ACLPolicy{
+				Name: "cache-write",
+				Rules: `
+service "cache" { policy = "write" }
+service "db" { policy = "read" }
+node_prefix "" { policy = "read" }
+`,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var policy structs.ACLPolicy
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.PolicySet", &req, &policy))
+
+		tokenReq := structs.ACLTokenSetRequest{
+			Datacenter: "dc1",
+			ACLToken: structs.ACLToken{
+				Policies: []structs.ACLTokenPolicyLink{{ID: policy.ID}},
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var cacheToken structs.ACLToken
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.TokenSet", &tokenReq, &cacheToken))
+
+		serviceReq := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "cache",
+			Node:         "node1",
+			Upstreams:    []string{"db"},
+			WriteRequest: structs.WriteRequest{Token: cacheToken.SecretID},
+		}
+		var token structs.ACLToken
+		require.NoError(t, endpoint.ServiceTokenCreate(&serviceReq, &token))
+
+		// The token keeps finding the upstreams wherever they move,
+		// including nodes registered after it was created.
+		rule, err := s1.ResolveToken(token.SecretID)
+		require.NoError(t, err)
+		require.True(t, rule.ServiceRead("db"))
+		require.True(t, rule.NodeRead("node1"))
+		require.True(t, rule.NodeRead("node2"))
+		require.True(t, rule.NodeRead("node5"))
+		require.False(t, rule.NodeWrite("node1", nil))
+
+		_, nodesPolicy, err := s1.fsm.State().ACLPolicyGetByName(nil, "", serviceTokenNodesReadPolicy)
+		require.NoError(t, err)
+		require.NotNil(t, nodesPolicy)
+		require.Equal(t, serviceTokenNodesReadRules, nodesPolicy.Rules)
+	})
+
+	t.Run("concurrent requests share the policy", func(t *testing.T) {
+		req := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "concurrent",
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+
+		const n = 5
+		tokens := make([]structs.ACLToken, n)
+		errCh := make(chan error, n)
+		for i := 0; i < n; i++ {
+			go func(i int) {
+				req := req
+				errCh <- endpoint.ServiceTokenCreate(&req, &tokens[i])
+			}(i)
+		}
+		for i := 0; i < n; i++ {
+			require.NoError(t, <-errCh)
+		}
+		for _, token := range tokens[1:] {
+			require.Equal(t, tokens[0].Policies[0].ID, token.Policies[0].ID)
+		}
+	})
+
+	t.Run("creates the token in the namespace of the request token", func(t *testing.T) {
+		req := structs.ACLPolicySetRequest{
+			Datacenter: "dc1",
+			Policy: structs.ACLPolicy{
+				Name:      "team-web",
+				Namespace: "team-a",
+				Rules:     `service "team-web" { policy = "write" }`,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var policy structs.ACLPolicy
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.PolicySet", &req, &policy))
+
+		tokenReq := structs.ACLTokenSetRequest{
+			Datacenter: "dc1",
+			ACLToken: structs.ACLToken{
+				Namespace: "team-a",
+				Policies:  []structs.ACLTokenPolicyLink{{ID: policy.ID}},
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var teamToken structs.ACLToken
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.TokenSet", &tokenReq, &teamToken))
+
+		serviceReq := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "team-web",
+			WriteRequest: structs.WriteRequest{Token: teamToken.SecretID},
+		}
+		var token structs.ACLToken
+		require.NoError(t, endpoint.ServiceTokenCreate(&serviceReq, &token))
+		require.Equal(t, "team-a", token.Namespace)
+
+		rule, err := s1.ResolveToken(token.SecretID)
+		require.NoError(t, err)
+		require.True(t, rule.ServiceWrite("team-web", nil))
+
+		for _, link := range token.Policies {
+			_, policy, err := s1.fsm.State().ACLPolicyGetByID(nil, link.ID)
+			require.NoError(t, err)
+			require.Equal(t, "team-a", policy.Namespace)
+		}
+
+		// The token can be deleted with itself.
+		deleteReq := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "team-web",
+			WriteRequest: structs.WriteRequest{Token: token.SecretID},
+		}
+		var accessor string
+		require.NoError(t, endpoint.ServiceTokenDelete(&deleteReq, &accessor))
+		require.Equal(t, token.AccessorID, accessor)
+	})
+
+	t.Run("service names that aren't valid policy names", func(t *testing.T) {
+		req := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "web.v2",
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var token structs.ACLToken
+		require.NoError(t, endpoint.ServiceTokenCreate(&req, &token))
+
+		rule, err := s1.ResolveToken(token.SecretID)
+		require.NoError(t, err)
+		require.True(t, rule.ServiceWrite("web.v2", nil))
+		require.False(t, rule.ServiceWrite("web", nil))
+
+		name := serviceTokenPolicyName("web.v2")
+		require.Regexp(t, `^service-token-web-v2-[0-9a-f]{8}$`, name)
+		require.NotEqual(t, name, serviceTokenPolicyName("web_v2"))
+	})
+
+	t.Run("policy name conflict", func(t *testing.T) {
+		req := structs.ACLPolicySetRequest{
+			Datacenter: "dc1",
+			Policy: structs.ACLPolicy{
+				Name:  "service-token-conflict",
+				Rules: `service_prefix "" { policy = "write" }`,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var policy structs.ACLPolicy
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.PolicySet", &req, &policy))
+
+		tokenReq := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "conflict",
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var token structs.ACLToken
+		err := endpoint.ServiceTokenCreate(&tokenReq, &token)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "different rules")
+	})
+}
+
+func TestACLEndpoint_ServiceTokenDelete(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	endpoint := ACL{srv: s1}
+
+	req := structs.ACLServiceTokenRequest{
+		Datacenter:   "dc1",
+		ServiceName:  "web",
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var token structs.ACLToken
+	require.NoError(t, endpoint.ServiceTokenCreate(&req, &token))
+
+	t.Run("only deletes service tokens", func(t *testing.T) {
+		userToken, err := upsertTestToken(codec, "root", "dc1")
+		require.NoError(t, err)
+
+		req := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "web",
+			WriteRequest: structs.WriteRequest{Token: userToken.SecretID},
+		}
+		var resp string
+		err = endpoint.ServiceTokenDelete(&req, &resp)
+		require.Equal(t, ErrNotServiceToken, err)
+	})
+
+	t.Run("only deletes tokens of the service", func(t *testing.T) {
+		req := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "db",
+			WriteRequest: structs.WriteRequest{Token: token.SecretID},
+		}
+		var resp string
+		err := endpoint.ServiceTokenDelete(&req, &resp)
+		require.Equal(t, ErrNotServiceToken, err)
+	})
+
+	t.Run("deletes the token", func(t *testing.T) {
+		req := structs.ACLServiceTokenRequest{
+			Datacenter:   "dc1",
+			ServiceName:  "web",
+			WriteRequest: structs.WriteRequest{Token: token.SecretID},
+		}
+		var resp string
+		require.NoError(t, endpoint.ServiceTokenDelete(&req, &resp))
+		require.Equal(t, token.AccessorID, resp)
+
+		tokenResp, err := retrieveTestToken(codec, "root", "dc1", token.AccessorID)
+		require.NoError(t, err)
+		require.Nil(t, tokenResp.Token)
+	})
+}
+
 // upsertTestToken creates a token for testing purposes
 func upsertTestToken(codec rpc.ClientCodec, masterToken string, datacenter string) (*structs.ACLToken, error) {
 	arg := structs.ACLTokenSetRequest{
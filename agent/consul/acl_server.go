@@ -152,6 +152,22 @@ func (s *Server) ResolveIdentityFromToken(token string) (bool, structs.ACLIdenti
 	return s.InACLDatacenter() || index > 0, nil, acl.ErrNotFound
 }
 
+// tokenAccessorID returns the accessor ID of the given token, or an empty
+// string if ACLs are disabled or the token can't be resolved locally.
+func (s *Server) tokenAccessorID(token string) string {
+	if !s.ACLsEnabled() {
+		return ""
+	}
+	if token == "" {
+		token = anonymousToken
+	}
+	_, identity, err := s.ResolveIdentityFromToken(token)
+	if err != nil || identity == nil {
+		return ""
+	}
+	return identity.ID()
+}
+
 func (s *Server) ResolvePolicyFromID(policyID string) (bool, *structs.ACLPolicy, error) {
 	index, policy, err := s.fsm.State().ACLPolicyGetByID(nil, policyID)
 	if err != nil {
@@ -177,3 +193,11 @@ func (s *Server) filterACL(token string, subj interface{}) error {
 func (s *Server) filterACLWithAuthorizer(authorizer acl.Authorizer, subj interface{}) error {
 	return s.acls.filterACLWithAuthorizer(authorizer, subj)
 }
+
+func (s *Server) bindNamespace(token, requested string) (string, error) {
+	return s.acls.bindNamespace(token, requested)
+}
+
+func (s *Server) vetNamespacePolicy(token, namespace string, authz acl.Authorizer, rules *acl.Policy) error {
+	return s.acls.vetNamespacePolicy(token, namespace, authz, rules)
+}
@@ -73,6 +73,20 @@ func testIdentityForToken(token string) (bool, structs.ACLIdentity, error) {
 			Type:       structs.ACLTokenTypeClient,
 			Rules:      `service "" { policy = "read" }`,
 		}, nil
+	case "team-a-acl-ro":
+		return true, &structs.ACLToken{
+			AccessorID: "0a2fb8b5-0a9c-4a49-b4c9-41e6ab2a1bd6",
+			SecretID:   "e2b5a3b1-8b86-4b5f-8c39-4c1f1c6a5b3e",
+			Namespace:  "team-a",
+			Policies: []structs.ACLTokenPolicyLink{
+				structs.ACLTokenPolicyLink{
+					ID: "team-a-acl-ro",
+				},
+				structs.ACLTokenPolicyLink{
+					ID: "acl-wr",
+				},
+			},
+		}, nil
 	case "found":
 		return true, &structs.ACLToken{
 			AccessorID: "5f57c1f6-6a89-4186-9445-531b316e01df",
@@ -86,6 +100,19 @@ func testIdentityForToken(token string) (bool, structs.ACLIdentity, error) {
 				},
 			},
 		}, nil
+	case "expired-policy":
+		return true, &structs.ACLToken{
+			AccessorID: "9a8ef2c6-8d2a-4e8f-b5c4-2e9e5e1c3a51",
+			SecretID:   "1c3fe4d8-0a0c-4b53-9f37-67a3e1a4ad3b",
+			Policies: []structs.ACLTokenPolicyLink{
+				structs.ACLTokenPolicyLink{
+					ID: "node-wr",
+				},
+				structs.ACLTokenPolicyLink{
+					ID: "expired-key-wr",
+				},
+			},
+		}, nil
 	case "acl-ro":
 		return true, &structs.ACLToken{
 			AccessorID: "435a75af-1763-4980-89f4-f0951dda53b4",
@@ -168,6 +195,16 @@ func testPolicyForID(policyID string) (bool, *structs.ACLPolicy, error) {
 			Syntax:      acl.SyntaxCurrent,
 			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
 		}, nil
+	case "team-a-acl-ro":
+		return true, &structs.ACLPolicy{
+			ID:          "team-a-acl-ro",
+			Name:        "acl-ro",
+			Namespace:   "team-a",
+			Description: "acl-ro",
+			Rules:       `acl = "read"`,
+			Syntax:      acl.SyntaxCurrent,
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		}, nil
 	case "acl-wr":
 		return true, &structs.ACLPolicy{
 			ID:          "acl-wr",
@@ -187,6 +224,17 @@ func testPolicyForID(policyID string) (bool, *structs.ACLPolicy, error) {
 			Datacenters: []string{"dc1"},
 			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
 		}, nil
+	case "expired-key-wr":
+		deleteAfter := time.Now().Add(-time.Minute)
+		return true, &structs.ACLPolicy{
+			ID:          "expired-key-wr",
+			Name:        "expired-key-wr",
+			Description: "expired-key-wr",
+			Rules:       `key_prefix "" { policy = "write"}`,
+			Syntax:      acl.SyntaxCurrent,
+			DeleteAfter: &deleteAfter,
+			RaftIndex:   structs.RaftIndex{CreateIndex: 1, ModifyIndex: 2},
+		}, nil
 	case "dc2-key-wr":
 		return true, &structs.ACLPolicy{
 			ID:          "dc2-key-wr",
@@ -898,6 +946,26 @@ func TestACLResolver_DatacenterScoping(t *testing.T) {
 	})
 }
 
+func TestACLResolver_ExpiredPolicy(t *testing.T) {
+	t.Parallel()
+	delegate := &ACLResolverTestDelegate{
+		enabled:       true,
+		datacenter:    "dc1",
+		legacy:        false,
+		localTokens:   true,
+		localPolicies: true,
+		// No need to provide any of the RPC callbacks
+	}
+	r := newTestACLResolver(t, delegate, nil)
+
+	// The expired policy doesn't grant anything even if it wasn't deleted
+	authz, err := r.ResolveToken("expired-policy")
+	require.NotNil(t, authz)
+	require.NoError(t, err)
+	require.True(t, authz.NodeWrite("foo", nil))
+	require.False(t, authz.KeyWrite("foo", nil))
+}
+
 func TestACLResolver_Client(t *testing.T) {
 	t.Parallel()
 
@@ -1776,6 +1844,82 @@ service "foo" {
 	}
 }
 
+func TestACL_filterConnectChanges(t *testing.T) {
+	t.Parallel()
+	assert := assert.New(t)
+
+	fill := func() structs.ConnectChanges {
+		return structs.ConnectChanges{
+			&structs.ConnectChange{
+				Index:           3,
+				Kind:            structs.ConnectChangeIntention,
+				DestinationName: "bar",
+			},
+			&structs.ConnectChange{
+				Index:           2,
+				Kind:            structs.ConnectChangeIntention,
+				DestinationName: "foo",
+			},
+			&structs.ConnectChange{
+				Index: 1,
+				Kind:  structs.ServiceDefaults,
+				Name:  "foo",
+			},
+		}
+	}
+
+	// Try permissive filtering.
+	{
+		changes := fill()
+		filt := newACLFilter(acl.AllowAll(), nil, false)
+		filt.filterConnectChanges(&changes)
+		assert.Len(changes, 3)
+	}
+
+	// Try restrictive filtering.
+	{
+		changes := fill()
+		filt := newACLFilter(acl.DenyAll(), nil, false)
+		filt.filterConnectChanges(&changes)
+		assert.Len(changes, 0)
+	}
+
+	// Policy to see the changes of one intention
+	policy, err := acl.NewPolicyFromSource("", 0, `
+service "foo" {
+  policy = "read"
+}
+`, acl.SyntaxLegacy, nil)
+	assert.Nil(err)
+	perms, err := acl.NewPolicyAuthorizer(acl.DenyAll(), []*acl.Policy{policy}, nil)
+	assert.Nil(err)
+
+	// Filter
+	{
+		changes := fill()
+		filt := newACLFilter(perms, nil, false)
+		filt.filterConnectChanges(&changes)
+		assert.Len(changes, 1)
+		assert.Equal(uint64(2), changes[0].Index)
+	}
+
+	// Operator read grants the changes of config entries
+	policy, err = acl.NewPolicyFromSource("", 0, `
+operator = "read"
+`, acl.SyntaxLegacy, nil)
+	assert.Nil(err)
+	perms, err = acl.NewPolicyAuthorizer(acl.DenyAll(), []*acl.Policy{policy}, nil)
+	assert.Nil(err)
+
+	{
+		changes := fill()
+		filt := newACLFilter(perms, nil, false)
+		filt.filterConnectChanges(&changes)
+		assert.Len(changes, 1)
+		assert.Equal(uint64(1), changes[0].Index)
+	}
+}
+
 func TestACL_filterServices(t *testing.T) {
 	t.Parallel()
 	// Create some services
@@ -2443,6 +2587,67 @@ func TestACL_redactTokenSecrets(t *testing.T) {
 	require.Equal(t, redactedToken, tokens[0].SecretID)
 }
 
+func TestACL_filterACLNamespace(t *testing.T) {
+	t.Parallel()
+	delegate := &ACLResolverTestDelegate{
+		enabled:       true,
+		datacenter:    "dc1",
+		legacy:        false,
+		localTokens:   true,
+		localPolicies: true,
+		// No need to provide any of the RPC callbacks
+	}
+	r := newTestACLResolver(t, delegate, nil)
+
+	// The policy of the default namespace linked to the token is ignored
+	authz, err := r.ResolveToken("team-a-acl-ro")
+	require.NoError(t, err)
+	require.True(t, authz.ACLRead())
+	require.False(t, authz.ACLWrite())
+
+	policies := structs.ACLPolicyListStubs{
+		&structs.ACLPolicyListStub{ID: "default-policy"},
+		&structs.ACLPolicyListStub{ID: "team-a-policy", Namespace: "team-a"},
+		&structs.ACLPolicyListStub{ID: "team-b-policy", Namespace: "team-b"},
+	}
+	tokens := structs.ACLTokens{
+		&structs.ACLToken{AccessorID: "default-token", SecretID: "default-token"},
+		&structs.ACLToken{AccessorID: "team-a-token", SecretID: "team-a-token", Namespace: "team-a"},
+	}
+
+	// Tokens of the default namespace see every namespace
+	filtered := policies
+	require.NoError(t, r.filterACL("acl-ro", &filtered))
+	require.Len(t, filtered, 3)
+
+	// The others only see their own
+	require.NoError(t, r.filterACL("team-a-acl-ro", &filtered))
+	require.Len(t, filtered, 1)
+	require.Equal(t, "team-a-policy", filtered[0].ID)
+
+	require.NoError(t, r.filterACL("team-a-acl-ro", &tokens))
+	require.Len(t, tokens, 1)
+	require.Equal(t, "team-a-token", tokens[0].AccessorID)
+	require.Equal(t, redactedToken, tokens[0].SecretID)
+
+	policy := &structs.ACLPolicy{ID: "default-policy"}
+	require.NoError(t, r.filterACL("team-a-acl-ro", &policy))
+	require.Nil(t, policy)
+
+	// Requests are bound to the namespace of the token
+	ns, err := r.bindNamespace("team-a-acl-ro", "")
+	require.NoError(t, err)
+	require.Equal(t, "team-a", ns)
+	ns, err = r.bindNamespace("team-a-acl-ro", structs.ACLWildcardNamespace)
+	require.NoError(t, err)
+	require.Equal(t, "team-a", ns)
+	_, err = r.bindNamespace("team-a-acl-ro", "team-b")
+	require.True(t, acl.IsErrPermissionDenied(err))
+	ns, err = r.bindNamespace("acl-ro", structs.ACLWildcardNamespace)
+	require.NoError(t, err)
+	require.Equal(t, structs.ACLWildcardNamespace, ns)
+}
+
 func TestACL_filterPreparedQueries(t *testing.T) {
 	t.Parallel()
 	queries := structs.PreparedQueries{
@@ -0,0 +1,40 @@
+package consul
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/tlsutil"
+)
+
+// RequestAutoConfig requests the configuration of a client agent from one of
+// the given servers with auto-config, presenting the JWT of the request. The
+// servers are tried in turn until one of them answers, or until interruptCh
+// is closed. It returns the configuration along with the PEM encoded private
+// key of the certificate, if the servers issued one.
+//
+// The request is sent before the agent is set up, so it takes the TLS
+// configurator verifying the servers rather than a client.
+func RequestAutoConfig(logger *log.Logger, tlsConfigurator *tlsutil.Configurator, servers []string, port int,
+	args *structs.AutoConfigRequest, interruptCh chan struct{}) (*structs.AutoConfigResponse, string, error) {
+	if len(servers) == 0 {
+		return nil, "", fmt.Errorf("No servers to request AutoConfig.InitialConfiguration")
+	}
+
+	csr, pkPEM, err := agentCSR(args.Datacenter, args.Node)
+	if err != nil {
+		return nil, "", err
+	}
+	args.CSR = csr
+
+	var reply structs.AutoConfigResponse
+	err = insecureRPCRetry(logger, tlsConfigurator, servers, port, "AutoConfig.InitialConfiguration", args, &reply, interruptCh)
+	if err != nil {
+		return nil, "", err
+	}
+	if reply.TLS == nil {
+		pkPEM = ""
+	}
+	return &reply, pkPEM, nil
+}
@@ -0,0 +1,293 @@
+package consul
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// jwtClockSkewLeeway is the clock skew tolerated between the issuer of
+	// the JWTs and the servers when validating the expiration claims.
+	jwtClockSkewLeeway = time.Minute
+
+	// jwtMaxLifetime is the longest a JWT can be valid for, from the time
+	// it was issued, or became valid, until it expires. The JWTs can be
+	// replayed until they expire so they must be short-lived.
+	jwtMaxLifetime = 24 * time.Hour
+)
+
+// jwtAlgorithms maps the supported JWS algorithms to their hash function.
+var jwtAlgorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"PS256": crypto.SHA256,
+	"PS384": crypto.SHA384,
+	"PS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// errJWTInvalidSignature is returned when none of the keys verifies the
+// signature of a JWT.
+var errJWTInvalidSignature = errors.New("failed to verify the JWT signature")
+
+// jwtAuthorizer validates the JWTs presented by the client agents to
+// request their configuration with auto-config. The JWTs must be signed by
+// one of the configured keys, and their claims must match the configured
+// issuer, audiences and assertions.
+type jwtAuthorizer struct {
+	keys            []crypto.PublicKey
+	boundIssuer     string
+	boundAudiences  []string
+	claimAssertions map[string]string
+}
+
+// newJWTAuthorizer returns the authorizer of the auto-config requests
+// configured for the server.
+func newJWTAuthorizer(config *Config) (*jwtAuthorizer, error) {
+	if len(config.AutoConfigAuthzJWTValidationPubKeys) == 0 {
+		return nil, fmt.Errorf("at least one JWT validation public key is required")
+	}
+
+	a := &jwtAuthorizer{
+		boundIssuer:     config.AutoConfigAuthzBoundIssuer,
+		boundAudiences:  config.AutoConfigAuthzBoundAudiences,
+		claimAssertions: config.AutoConfigAuthzClaimAssertions,
+	}
+	for _, k := range config.AutoConfigAuthzJWTValidationPubKeys {
+		key, err := parseJWTPublicKey(k)
+		if err != nil {
+			return nil, err
+		}
+		a.keys = append(a.keys, key)
+	}
+	return a, nil
+}
+
+// parseJWTPublicKey parses a PEM encoded RSA or ECDSA public key.
+func parseJWTPublicKey(s string) (crypto.PublicKey, error) {
+	block, _ := pem.Decode([]byte(s))
+	if block == nil {
+		return nil, fmt.Errorf("failed to decode the JWT validation public key")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the JWT validation public key: %v", err)
+	}
+	switch key.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported JWT validation public key type: %T", key)
+	}
+}
+
+// authorize validates the JWT presented by the given node.
+func (a *jwtAuthorizer) authorize(token, node, segment string) error {
+	claims, err := a.verify(token)
+	if err != nil {
+		return err
+	}
+
+	if err := checkJWTLifetime(claims, time.Now()); err != nil {
+		return err
+	}
+
+	if a.boundIssuer != "" {
+		if iss, _ := claims["iss"].(string); iss != a.boundIssuer {
+			return fmt.Errorf("the JWT issuer isn't allowed")
+		}
+	}
+	if len(a.boundAudiences) > 0 && !jwtAudienceMatches(claims["aud"], a.boundAudiences) {
+		return fmt.Errorf("the JWT audience isn't allowed")
+	}
+
+	replacer := strings.NewReplacer("${node}", node, "${segment}", segment)
+	for claim, expected := range a.claimAssertions {
+		if v, _ := claims[claim].(string); v != replacer.Replace(expected) {
+			return fmt.Errorf("the JWT claim %q doesn't match", claim)
+		}
+	}
+	return nil
+}
+
+// checkJWTLifetime checks the time claims of a JWT. The exp claim is
+// required, and the JWT can't be valid for longer than jwtMaxLifetime from
+// its iat or nbf claim, or from now if it has neither.
+func checkJWTLifetime(claims map[string]interface{}, now time.Time) error {
+	exp, ok, err := jwtTimeClaim(claims, "exp")
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("the JWT has no expiration")
+	}
+	if now.After(exp.Add(jwtClockSkewLeeway)) {
+		return fmt.Errorf("the JWT is expired")
+	}
+
+	start := now
+	nbf, ok, err := jwtTimeClaim(claims, "nbf")
+	if err != nil {
+		return err
+	} else if ok {
+		if now.Add(jwtClockSkewLeeway).Before(nbf) {
+			return fmt.Errorf("the JWT is not valid yet")
+		}
+		start = nbf
+	}
+	iat, ok, err := jwtTimeClaim(claims, "iat")
+	if err != nil {
+		return err
+	} else if ok {
+		if now.Add(jwtClockSkewLeeway).Before(iat) {
+			return fmt.Errorf("the JWT is issued in the future")
+		}
+		if iat.Before(start) {
+			start = iat
+		}
+	}
+
+	if exp.Sub(start) > jwtMaxLifetime {
+		return fmt.Errorf("the JWT is valid for longer than %s", jwtMaxLifetime)
+	}
+	return nil
+}
+
+// jwtTimeClaim returns the given NumericDate claim and whether it's present.
+func jwtTimeClaim(claims map[string]interface{}, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("the JWT claim %q is not a number", name)
+	}
+	t, err := n.Int64()
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("the JWT claim %q is not a number", name)
+	}
+	return time.Unix(t, 0), true, nil
+}
+
+// verify verifies the signature of the JWT and returns its claims.
+func (a *jwtAuthorizer) verify(token string) (map[string]interface{}, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed JWT")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, err
+	}
+	hash, ok := jwtAlgorithms[header.Alg]
+	if !ok {
+		return nil, fmt.Errorf("unsupported JWT algorithm %q", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed JWT signature: %v", err)
+	}
+	h := hash.New()
+	h.Write([]byte(parts[0] + "." + parts[1]))
+	digest := h.Sum(nil)
+
+	verified := false
+	for _, key := range a.keys {
+		if verifyJWTSignature(header.Alg, hash, key, digest, sig) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, errJWTInvalidSignature
+	}
+
+	var claims map[string]interface{}
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// verifyJWTSignature verifies the signature of the digest with the key, if
+// the key matches the algorithm.
+func verifyJWTSignature(alg string, hash crypto.Hash, key crypto.PublicKey, digest, sig []byte) bool {
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			return rsa.VerifyPKCS1v15(k, hash, digest, sig) == nil
+		case "PS":
+			opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+			return rsa.VerifyPSS(k, hash, digest, sig, opts) == nil
+		}
+	case *ecdsa.PublicKey:
+		if alg[:2] != "ES" {
+			return false
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return false
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		return ecdsa.Verify(k, digest, r, s)
+	}
+	return false
+}
+
+// decodeJWTPart decodes a base64 encoded JSON part of a JWT.
+func decodeJWTPart(part string, out interface{}) error {
+	raw, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("malformed JWT: %v", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(out); err != nil {
+		return fmt.Errorf("malformed JWT: %v", err)
+	}
+	return nil
+}
+
+// jwtAudienceMatches returns true if the aud claim, a string or a list of
+// strings, contains one of the bound audiences.
+func jwtAudienceMatches(aud interface{}, bound []string) bool {
+	var auds []string
+	switch v := aud.(type) {
+	case string:
+		auds = []string{v}
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				auds = append(auds, s)
+			}
+		}
+	}
+	for _, a := range auds {
+		for _, b := range bound {
+			if a == b {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package consul
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testJWTKey generates an ECDSA key pair and returns the private key with
+// the PEM encoded public key.
+func testJWTKey(t *testing.T) (*ecdsa.PrivateKey, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key, testJWTPubKeyPEM(t, &key.PublicKey)
+}
+
+func testJWTPubKeyPEM(t *testing.T, pub crypto.PublicKey) string {
+	t.Helper()
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+// testJWT returns a JWT with the given claims, signed with the key.
+func testJWT(t *testing.T, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+	alg := "ES256"
+	if _, ok := key.(*rsa.PrivateKey); ok {
+		alg = "RS256"
+	}
+
+	header, err := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." +
+		base64.RawURLEncoding.EncodeToString(payload)
+
+	h := crypto.SHA256.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	var sig []byte
+	switch k := key.(type) {
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+		require.NoError(t, err)
+		sig = make([]byte, 64)
+		rb, sb := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rb):32], rb)
+		copy(sig[64-len(sb):], sb)
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest)
+		require.NoError(t, err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestJWTAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	key, pubKey := testJWTKey(t)
+	otherKey, _ := testJWTKey(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	authz, err := newJWTAuthorizer(&Config{
+		AutoConfigAuthzJWTValidationPubKeys: []string{pubKey, testJWTPubKeyPEM(t, &rsaKey.PublicKey)},
+		AutoConfigAuthzBoundIssuer:          "consul",
+		AutoConfigAuthzBoundAudiences:       []string{"consul-cluster"},
+		AutoConfigAuthzClaimAssertions:      map[string]string{"sub": "${node}"},
+	})
+	require.NoError(t, err)
+
+	claims := func(modify func(map[string]interface{})) map[string]interface{} {
+		c := map[string]interface{}{
+			"iss": "consul",
+			"aud": []string{"other", "consul-cluster"},
+			"sub": "node1",
+			"exp": time.Now().Add(time.Hour).Unix(),
+			"nbf": time.Now().Add(-time.Hour).Unix(),
+		}
+		if modify != nil {
+			modify(c)
+		}
+		return c
+	}
+
+	cases := []struct {
+		name string
+		key  crypto.Signer
+		jwt  string
+		node string
+		err  string
+	}{
+		{
+			name: "valid",
+			key:  key,
+			node: "node1",
+		},
+		{
+			name: "valid rsa",
+			key:  rsaKey,
+			node: "node1",
+		},
+		{
+			name: "unknown key",
+			key:  otherKey,
+			node: "node1",
+			err:  "failed to verify the JWT signature",
+		},
+		{
+			name: "malformed",
+			jwt:  "not-a-jwt",
+			node: "node1",
+			err:  "malformed JWT",
+		},
+		{
+			name: "other node",
+			key:  key,
+			node: "node2",
+			err:  `the JWT claim "sub" doesn't match`,
+		},
+		{
+			name: "expired",
+			key:  key,
+			jwt: testJWT(t, key, claims(func(c map[string]interface{}) {
+				c["exp"] = time.Now().Add(-time.Hour).Unix()
+			})),
+			node: "node1",
+			err:  "the JWT is expired",
+		},
+		{
+			name: "no expiration",
+			key:  key,
+			jwt: testJWT(t, key, claims(func(c map[string]interface{}) {
+				delete(c, "exp")
+			})),
+			node: "node1",
+			err:  "the JWT has no expiration",
+		},
+		{
+			name: "too long lifetime",
+			key:  key,
+			jwt: testJWT(t, key, claims(func(c map[string]interface{}) {
+				c["exp"] = time.Now().Add(30 * 24 * time.Hour).Unix()
+			})),
+			node: "node1",
+			err:  "the JWT is valid for longer than",
+		},
+		{
+			name: "too long lifetime from iat",
+			key:  key,
+			jwt: testJWT(t, key, claims(func(c map[string]interface{}) {
+				delete(c, "nbf")
+				c["iat"] = time.Now().Add(-48 * time.Hour).Unix()
+			})),
+			node: "node1",
+			err:  "the JWT is valid for longer than",
+		},
+		{
+			name: "issued in the future",
+			key:  key,
+			jwt: testJWT(t, key, claims(func(c map[string]interface{}) {
+				c["iat"] = time.Now().Add(time.Hour).Unix()
+			})),
+			node: "node1",
+			err:  "the JWT is issued in the future",
+		},
+		{
+			name: "not valid yet",
+			key:  key,
+			jwt: testJWT(t, key, claims(func(c map[string]interface{}) {
+				c["nbf"] = time.Now().Add(time.Hour).Unix()
+			})),
+			node: "node1",
+			err:  "the JWT is not valid yet",
+		},
+		{
+			name: "wrong issuer",
+			key:  key,
+			jwt: testJWT(t, key, claims(func(c map[string]interface{}) {
+				c["iss"] = "other"
+			})),
+			node: "node1",
+			err:  "the JWT issuer isn't allowed",
+		},
+		{
+			name: "wrong audience",
+			key:  key,
+			jwt: testJWT(t, key, claims(func(c map[string]interface{}) {
+				c["aud"] = "other"
+			})),
+			node: "node1",
+			err:  "the JWT audience isn't allowed",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			jwt := tc.jwt
+			if jwt == "" {
+				jwt = testJWT(t, tc.key, claims(nil))
+			}
+			err := authz.authorize(jwt, tc.node, "")
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.True(t, strings.Contains(err.Error(), tc.err), err.Error())
+		})
+	}
+}
+
+func TestJWTAuthorizer_noKeys(t *testing.T) {
+	t.Parallel()
+
+	_, err := newJWTAuthorizer(&Config{})
+	require.Error(t, err)
+
+	_, err = newJWTAuthorizer(&Config{
+		AutoConfigAuthzJWTValidationPubKeys: []string{"not a key"},
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,155 @@
+package consul
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+var (
+	ErrAutoConfigNotEnabled = errors.New("AutoConfig.Authorization must be enabled in order to use this endpoint")
+)
+
+// autoConfigTokenDescription is the description of the agent tokens created
+// with auto-config.
+const autoConfigTokenDescription = "Agent token of node %q created with auto-config"
+
+// AutoConfig hands their configuration to the client agents which present a
+// valid JWT. The client agents reach this endpoint on the insecure RPC
+// listener, since they don't have a certificate yet.
+type AutoConfig struct {
+	srv *Server
+}
+
+// InitialConfiguration returns the gossip encryption key, the ACL settings
+// along with an agent token and the RPC certificate of a client agent.
+func (a *AutoConfig) InitialConfiguration(
+	args *structs.AutoConfigRequest,
+	reply *structs.AutoConfigResponse) error {
+	// Exit early if auto-config hasn't been enabled.
+	if !a.srv.config.AutoConfigAuthzEnabled || a.srv.autoConfigAuthorizer == nil {
+		return ErrAutoConfigNotEnabled
+	}
+
+	// The agent tokens are created by the leader.
+	if done, err := a.srv.forward("AutoConfig.InitialConfiguration", args, args, reply); done {
+		return err
+	}
+
+	if args.Node == "" {
+		return fmt.Errorf("Node is required")
+	}
+	if err := a.srv.autoConfigAuthorizer.authorize(args.JWT, args.Node, args.Segment); err != nil {
+		a.srv.logger.Printf("[WARN] consul: AutoConfig request of node %q denied: %v", args.Node, err)
+		return acl.ErrPermissionDenied
+	}
+
+	if keyring := a.srv.config.SerfLANConfig.MemberlistConfig.Keyring; keyring != nil {
+		reply.GossipEncryptionKey = base64.StdEncoding.EncodeToString(keyring.GetPrimaryKey())
+	}
+
+	if a.srv.ACLsEnabled() {
+		token, err := a.srv.autoConfigAgentToken(args.Node)
+		if err != nil {
+			return err
+		}
+		reply.ACL = structs.AutoConfigACL{
+			Enabled:             true,
+			PrimaryDatacenter:   a.srv.config.ACLDatacenter,
+			DefaultPolicy:       a.srv.config.ACLDefaultPolicy,
+			DownPolicy:          a.srv.config.ACLDownPolicy,
+			TokenTTL:            a.srv.config.ACLTokenTTL,
+			PolicyTTL:           a.srv.config.ACLPolicyTTL,
+			EnableKeyListPolicy: a.srv.config.ACLEnableKeyListPolicy,
+			AgentToken:          token,
+		}
+	}
+
+	if a.srv.config.ConnectEnabled && args.CSR != "" {
+		// The certificate can only be requested for the authorized node.
+		csr, err := connect.ParseCSR(args.CSR)
+		if err != nil {
+			return err
+		}
+		if len(csr.URIs) != 1 {
+			return fmt.Errorf("CSR must contain exactly one URI SAN")
+		}
+		spiffeID, err := connect.ParseCertURI(csr.URIs[0])
+		if err != nil {
+			return err
+		}
+		if id, ok := spiffeID.(*connect.SpiffeIDAgent); !ok || id.Agent != args.Node {
+			return fmt.Errorf("SPIFFE ID in CSR must be the agent ID of node %q", args.Node)
+		}
+
+		signArgs := structs.CASignRequest{
+			Datacenter:   a.srv.config.Datacenter,
+			CSR:          args.CSR,
+			WriteRequest: structs.WriteRequest{Token: reply.ACL.AgentToken},
+		}
+		reply.TLS = &structs.SignedResponse{}
+		if err := a.srv.signAgentCert(&signArgs, reply.TLS); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// autoConfigAgentToken creates a new agent token for the node, as a local
+// token linked to the configured policies. A new token is created for every
+// request, the secret of an existing token is never handed out again. The
+// tokens previously created for the node are deleted so that replaying a JWT
+// doesn't pile up tokens.
+func (s *Server) autoConfigAgentToken(node string) (string, error) {
+	description := fmt.Sprintf(autoConfigTokenDescription, node)
+	_, existing, err := s.fsm.State().ACLTokenList(nil, true, false, "")
+	if err != nil {
+		return "", err
+	}
+	var previous []*structs.ACLToken
+	for _, token := range existing {
+		if token.Local && token.Description == description {
+			previous = append(previous, token)
+		}
+	}
+
+	args := structs.ACLTokenSetRequest{
+		Datacenter: s.config.Datacenter,
+		ACLToken: structs.ACLToken{
+			Description: description,
+			Local:       true,
+		},
+	}
+	for _, name := range s.config.AutoConfigAuthzAgentTokenPolicies {
+		args.ACLToken.Policies = append(args.ACLToken.Policies, structs.ACLTokenPolicyLink{Name: name})
+	}
+
+	var reply structs.ACLToken
+	if err := (&ACL{srv: s}).tokenSetInternal(&args, &reply, false); err != nil {
+		return "", fmt.Errorf("Failed to create the agent token: %v", err)
+	}
+
+	if len(previous) > 0 {
+		req := &structs.ACLTokenBatchDeleteRequest{}
+		for _, token := range previous {
+			req.TokenIDs = append(req.TokenIDs, token.AccessorID)
+		}
+		resp, err := s.raftApply(structs.ACLTokenDeleteRequestType, req)
+		if err == nil {
+			if respErr, ok := resp.(error); ok {
+				err = respErr
+			}
+		}
+		if err != nil {
+			return "", fmt.Errorf("Failed to delete the previous agent tokens: %v", err)
+		}
+		for _, token := range previous {
+			s.acls.cache.RemoveIdentity(token.SecretID)
+		}
+	}
+	return reply.SecretID, nil
+}
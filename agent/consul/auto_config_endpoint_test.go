@@ -0,0 +1,160 @@
+package consul
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/tlsutil"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoConfig_InitialConfiguration(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	key, pubKey := testJWTKey(t)
+	gossipKey := []byte("0123456789abcdef")
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+		c.ConnectEnabled = true
+		c.AutoConfigAuthzEnabled = true
+		c.AutoConfigAuthzJWTValidationPubKeys = []string{pubKey}
+		c.AutoConfigAuthzClaimAssertions = map[string]string{"sub": "${node}"}
+		c.AutoConfigAuthzAgentTokenPolicies = []string{"agent"}
+		c.SerfLANConfig.MemberlistConfig.SecretKey = gossipKey
+		configureTLS(c)
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// The agent tokens get the permissions of the policy.
+	policyArgs := structs.ACLPolicySetRequest{
+		Datacenter: "dc1",
+		Policy: structs.ACLPolicy{
+			Name:  "agent",
+			Rules: `node_prefix "" { policy = "write" }`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var policy structs.ACLPolicy
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.PolicySet", &policyArgs, &policy))
+
+	tlsConf, err := tlsutil.NewConfigurator(tlsutil.Config{AutoEncryptTLS: true}, nil)
+	require.NoError(err)
+
+	request := func() (*structs.AutoConfigResponse, string) {
+		args := structs.AutoConfigRequest{
+			Datacenter: "dc1",
+			Node:       "node1",
+			JWT: testJWT(t, key, map[string]interface{}{
+				"sub": "node1",
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}),
+		}
+		servers := []string{s1.config.SerfLANConfig.MemberlistConfig.BindAddr}
+		reply, keyPEM, err := RequestAutoConfig(s1.logger, tlsConf, servers, s1.config.RPCAddr.Port, &args, nil)
+		require.NoError(err)
+		return reply, keyPEM
+	}
+
+	reply, keyPEM := request()
+	require.Equal(base64.StdEncoding.EncodeToString(gossipKey), reply.GossipEncryptionKey)
+
+	require.True(reply.ACL.Enabled)
+	require.Equal("dc1", reply.ACL.PrimaryDatacenter)
+	require.Equal("deny", reply.ACL.DefaultPolicy)
+	require.NotEmpty(reply.ACL.AgentToken)
+
+	_, token, err := s1.fsm.State().ACLTokenGetBySecret(nil, reply.ACL.AgentToken)
+	require.NoError(err)
+	require.True(token.Local)
+	require.Len(token.Policies, 1)
+	require.Equal(policy.ID, token.Policies[0].ID)
+
+	require.NotNil(reply.TLS)
+	require.NotEmpty(keyPEM)
+	require.Equal("node1", reply.TLS.IssuedCert.Agent)
+	require.Len(reply.TLS.ConnectCARoots.Roots, 1)
+
+	// Every request gets its own token, and the previous one is deleted.
+	reply2, _ := request()
+	require.NotEmpty(reply2.ACL.AgentToken)
+	require.NotEqual(reply.ACL.AgentToken, reply2.ACL.AgentToken)
+
+	_, token, err = s1.fsm.State().ACLTokenGetBySecret(nil, reply.ACL.AgentToken)
+	require.NoError(err)
+	require.Nil(token)
+	_, tokens, err := s1.fsm.State().ACLTokenList(nil, true, false, "")
+	require.NoError(err)
+	var nodeTokens int
+	for _, token := range tokens {
+		if token.Description == fmt.Sprintf(autoConfigTokenDescription, "node1") {
+			nodeTokens++
+		}
+	}
+	require.Equal(1, nodeTokens)
+}
+
+func TestAutoConfig_InitialConfiguration_denied(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	key, pubKey := testJWTKey(t)
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ConnectEnabled = true
+		c.AutoConfigAuthzEnabled = true
+		c.AutoConfigAuthzJWTValidationPubKeys = []string{pubKey}
+		c.AutoConfigAuthzClaimAssertions = map[string]string{"sub": "${node}"}
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// The JWT was issued for another node.
+	args := structs.AutoConfigRequest{
+		Datacenter: "dc1",
+		Node:       "node1",
+		JWT: testJWT(t, key, map[string]interface{}{
+			"sub": "node2",
+			"exp": time.Now().Add(time.Hour).Unix(),
+		}),
+	}
+	var reply structs.AutoConfigResponse
+	err := (&AutoConfig{srv: s1}).InitialConfiguration(&args, &reply)
+	require.Equal(acl.ErrPermissionDenied, err)
+}
+
+func TestAutoConfig_InitialConfiguration_notEnabled(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ConnectEnabled = true
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	args := structs.AutoConfigRequest{
+		Datacenter: "dc1",
+		Node:       "node1",
+	}
+	var reply structs.AutoConfigResponse
+	err := (&AutoConfig{srv: s1}).InitialConfiguration(&args, &reply)
+	require.Equal(ErrAutoConfigNotEnabled, err)
+}
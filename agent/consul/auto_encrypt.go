@@ -0,0 +1,148 @@
+package consul
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/pool"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/consul/tlsutil"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+)
+
+const (
+	// dummyTrustDomain is the trust domain of the CSR sent by the client
+	// agents, which don't know the real one before their first request. The
+	// servers replace it with the trust domain of the cluster.
+	dummyTrustDomain = "dummy.trustdomain"
+
+	// autoEncryptDialTimeout is the timeout of the connections to the
+	// servers when requesting a certificate.
+	autoEncryptDialTimeout = 10 * time.Second
+
+	// autoEncryptRetryBase and autoEncryptRetryMax bound the exponential
+	// backoff between the attempts to request a certificate.
+	autoEncryptRetryBase = 2 * time.Second
+	autoEncryptRetryMax  = time.Minute
+)
+
+// RequestAutoEncryptCerts requests a certificate for the RPC connections of
+// the agent from one of the given servers, over the insecure RPC listener of
+// the servers. The servers are tried in turn until one of them answers, or
+// until interruptCh is closed. It returns the signed certificate along with
+// the PEM encoded private key.
+func (c *Client) RequestAutoEncryptCerts(servers []string, port int, token string, interruptCh chan struct{}) (*structs.SignedResponse, string, error) {
+	errFn := func(err error) (*structs.SignedResponse, string, error) {
+		return nil, "", err
+	}
+
+	if len(servers) == 0 {
+		return errFn(fmt.Errorf("No servers to request AutoEncrypt.Sign"))
+	}
+
+	csr, pkPEM, err := agentCSR(c.config.Datacenter, c.config.NodeName)
+	if err != nil {
+		return errFn(err)
+	}
+
+	args := structs.CASignRequest{
+		WriteRequest: structs.WriteRequest{Token: token},
+		Datacenter:   c.config.Datacenter,
+		CSR:          csr,
+	}
+	var reply structs.SignedResponse
+	err = insecureRPCRetry(c.logger, c.tlsConfigurator, servers, port, "AutoEncrypt.Sign", &args, &reply, interruptCh)
+	if err != nil {
+		return errFn(err)
+	}
+	return &reply, pkPEM, nil
+}
+
+// agentCSR generates a private key and the CSR of the certificate of the RPC
+// connections of an agent. The trust domain is filled in by the servers.
+func agentCSR(dc, node string) (string, string, error) {
+	pk, pkPEM, err := connect.GeneratePrivateKey()
+	if err != nil {
+		return "", "", err
+	}
+
+	id := &connect.SpiffeIDAgent{
+		Host:       dummyTrustDomain,
+		Datacenter: dc,
+		Agent:      node,
+	}
+	csr, err := connect.CreateCSR(id, pk, nil)
+	if err != nil {
+		return "", "", err
+	}
+	return csr, pkPEM, nil
+}
+
+// insecureRPCRetry sends a request to the insecure RPC listener of the given
+// servers in turn, backing off between the rounds, until one of them answers
+// or until interruptCh is closed.
+func insecureRPCRetry(logger *log.Logger, tlsConfigurator *tlsutil.Configurator, servers []string, port int,
+	method string, args interface{}, reply interface{}, interruptCh chan struct{}) error {
+	for attempt := uint(0); ; attempt++ {
+		for _, s := range servers {
+			addr := autoEncryptServerAddr(s, port)
+			err := insecureRPC(tlsConfigurator, addr, method, args, reply)
+			if err == nil {
+				return nil
+			}
+			logger.Printf("[WARN] agent: %s request to %s failed: %v", method, addr, err)
+		}
+
+		wait := lib.RandomStagger(autoEncryptRetryBase) + autoEncryptRetryBase<<attempt
+		if wait > autoEncryptRetryMax || wait <= 0 {
+			wait = autoEncryptRetryMax
+		}
+		select {
+		case <-time.After(wait):
+		case <-interruptCh:
+			return fmt.Errorf("aborting %s because interrupted", method)
+		}
+	}
+}
+
+// insecureRPC sends a request to the insecure RPC listener of the server at
+// the given address, on a dedicated connection.
+func insecureRPC(tlsConfigurator *tlsutil.Configurator, addr, method string, args interface{}, reply interface{}) error {
+	conn, err := net.DialTimeout("tcp", addr, autoEncryptDialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte{byte(pool.RPCTLSInsecure)}); err != nil {
+		return err
+	}
+
+	tlsConn := tls.Client(conn, tlsConfigurator.OutgoingAutoEncryptConfig())
+	if err := tlsConn.Handshake(); err != nil {
+		return err
+	}
+
+	codec := msgpackrpc.NewClientCodec(tlsConn)
+	defer codec.Close()
+	return msgpackrpc.CallWithCodec(codec, method, args, reply)
+}
+
+// autoEncryptServerAddr returns the address of the RPC listener of a server,
+// given the address it's joined with, which may include the port of the LAN
+// pool.
+func autoEncryptServerAddr(server string, port int) string {
+	host := server
+	if h, _, err := net.SplitHostPort(server); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
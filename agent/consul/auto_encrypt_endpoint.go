@@ -0,0 +1,81 @@
+package consul
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+var (
+	ErrAutoEncryptAllowTLSNotEnabled = errors.New("AutoEncrypt.AllowTLS must be enabled in order to use this endpoint")
+)
+
+// AutoEncrypt issues the RPC TLS certificates of the client agents which
+// enabled auto-encrypt. The client agents reach this endpoint on the insecure
+// RPC listener before they have a certificate, so it's the only endpoint
+// registered there.
+type AutoEncrypt struct {
+	srv *Server
+}
+
+// Sign signs a certificate for a client agent and returns it with the CA
+// certificates the agent needs to verify the servers.
+func (a *AutoEncrypt) Sign(
+	args *structs.CASignRequest,
+	reply *structs.SignedResponse) error {
+	// Exit early if Connect hasn't been enabled.
+	if !a.srv.config.ConnectEnabled {
+		return ErrConnectNotEnabled
+	}
+	// The client agents configured with auto-config renew their certificate
+	// with this endpoint too.
+	if !a.srv.config.AutoEncryptAllowTLS && !a.srv.config.AutoConfigAuthzEnabled {
+		return ErrAutoEncryptAllowTLSNotEnabled
+	}
+
+	if done, err := a.srv.forward("AutoEncrypt.Sign", args, args, reply); done {
+		return err
+	}
+
+	return a.srv.signAgentCert(args, reply)
+}
+
+// signAgentCert signs the certificate of the RPC connections of a client
+// agent and returns it with the CA certificates the agent needs to verify the
+// servers.
+func (s *Server) signAgentCert(args *structs.CASignRequest, reply *structs.SignedResponse) error {
+	// Only agent certificates can be requested through this endpoint.
+	csr, err := connect.ParseCSR(args.CSR)
+	if err != nil {
+		return err
+	}
+	if len(csr.URIs) != 1 {
+		return fmt.Errorf("CSR must contain exactly one URI SAN")
+	}
+	spiffeID, err := connect.ParseCertURI(csr.URIs[0])
+	if err != nil {
+		return err
+	}
+	if _, ok := spiffeID.(*connect.SpiffeIDAgent); !ok {
+		return fmt.Errorf("SPIFFE ID in CSR must be an agent ID")
+	}
+
+	c := &ConnectCA{srv: s}
+	if err := c.Sign(args, &reply.IssuedCert); err != nil {
+		return err
+	}
+
+	rootsArgs := structs.DCSpecificRequest{
+		Datacenter:   args.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: args.Token},
+	}
+	if err := c.Roots(&rootsArgs, &reply.ConnectCARoots); err != nil {
+		return err
+	}
+
+	reply.ManualCARoots = s.tlsConfigurator.ManualCAPems()
+	reply.VerifyServerHostname = s.config.VerifyServerHostname
+	return nil
+}
@@ -0,0 +1,124 @@
+package consul
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/connect"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/tlsutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoEncrypt_RequestAutoEncryptCerts(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ConnectEnabled = true
+		c.AutoEncryptAllowTLS = true
+		c.VerifyIncoming = true
+		c.VerifyOutgoing = true
+		configureTLS(c)
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	dir2, conf2 := testClientConfig(t)
+	tlsConf, err := tlsutil.NewConfigurator(tlsutil.Config{AutoEncryptTLS: true}, nil)
+	require.NoError(err)
+	c1, err := NewClientLogger(conf2, nil, tlsConf)
+	require.NoError(err)
+	defer os.RemoveAll(dir2)
+	defer c1.Shutdown()
+
+	// The client requests its certificate on the RPC port of the server.
+	servers := []string{s1.config.SerfLANConfig.MemberlistConfig.BindAddr}
+	reply, key, err := c1.RequestAutoEncryptCerts(servers, s1.config.RPCAddr.Port, "", nil)
+	require.NoError(err)
+	require.NotEmpty(key)
+	require.Equal(conf2.NodeName, reply.IssuedCert.Agent)
+	require.NotEmpty(reply.ManualCARoots)
+	require.Len(reply.ConnectCARoots.Roots, 1)
+
+	// The trust domain of the CSR is replaced with the one of the cluster.
+	id := &connect.SpiffeIDAgent{
+		Host:       reply.ConnectCARoots.TrustDomain,
+		Datacenter: "dc1",
+		Agent:      conf2.NodeName,
+	}
+	require.Equal(id.URI().String(), reply.IssuedCert.AgentURI)
+
+	var connectCAPems []string
+	for _, r := range reply.ConnectCARoots.Roots {
+		connectCAPems = append(connectCAPems, r.RootCert)
+	}
+	require.NoError(tlsConf.UpdateAutoEncrypt(reply.ManualCARoots, connectCAPems,
+		reply.IssuedCert.CertPEM, key, reply.VerifyServerHostname))
+	require.NoError(s1.tlsConfigurator.UpdateAutoEncryptCA(connectCAPems))
+
+	// The certificate is accepted by the server, which verifies the clients.
+	joinLAN(t, c1, s1)
+	retry.Run(t, func(r *retry.R) {
+		var out struct{}
+		if err := c1.RPC("Status.Ping", struct{}{}, &out); err != nil {
+			r.Fatal("ping failed", err)
+		}
+	})
+}
+
+func TestAutoEncrypt_Sign_notAllowed(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ConnectEnabled = true
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	csr, _ := connect.TestCSR(t, &connect.SpiffeIDAgent{
+		Host:       dummyTrustDomain,
+		Datacenter: "dc1",
+		Agent:      "node1",
+	})
+	args := structs.CASignRequest{
+		Datacenter: "dc1",
+		CSR:        csr,
+	}
+	var reply structs.SignedResponse
+	err := (&AutoEncrypt{srv: s1}).Sign(&args, &reply)
+	require.Equal(ErrAutoEncryptAllowTLSNotEnabled, err)
+}
+
+func TestAutoEncrypt_Sign_serviceID(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ConnectEnabled = true
+		c.AutoEncryptAllowTLS = true
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	csr, _ := connect.TestCSR(t, &connect.SpiffeIDService{
+		Host:       dummyTrustDomain,
+		Namespace:  "default",
+		Datacenter: "dc1",
+		Service:    "web",
+	})
+	args := structs.CASignRequest{
+		Datacenter: "dc1",
+		CSR:        csr,
+	}
+	var reply structs.SignedResponse
+	err := (&AutoEncrypt{srv: s1}).Sign(&args, &reply)
+	require.Error(err)
+	require.Contains(err.Error(), "must be an agent ID")
+}
@@ -58,6 +58,15 @@ func servicePreApply(service *structs.NodeService, rule acl.Authorizer) error {
 		return fmt.Errorf("Invalid service address")
 	}
 
+	// Unset weights are sent as zero values by the API client, use the
+	// same defaults as the agent for them.
+	if service.Weights != nil && *service.Weights == (structs.Weights{}) {
+		service.Weights = &structs.Weights{Passing: 1, Warning: 1}
+	}
+	if err := structs.ValidateWeights(service.Weights); err != nil {
+		return fmt.Errorf("Invalid service weights: %v", err)
+	}
+
 	// Apply the ACL policy if any. The 'consul' service is excluded
 	// since it is managed automatically internally (that behavior
 	// is going away after version 0.8). We check this same policy
@@ -80,10 +89,14 @@ func servicePreApply(service *structs.NodeService, rule acl.Authorizer) error {
 }
 
 // checkPreApply does the verification of a check before it is applied to Raft.
-func checkPreApply(check *structs.HealthCheck) {
+func checkPreApply(check *structs.HealthCheck) error {
 	if check.CheckID == "" && check.Name != "" {
 		check.CheckID = types.CheckID(check.Name)
 	}
+	if err := check.Definition.Validate(); err != nil {
+		return fmt.Errorf("Invalid check %q: %v", check.CheckID, err)
+	}
+	return nil
 }
 
 // Register is used register that a node is providing a given service.
@@ -123,7 +136,9 @@ func (c *Catalog) Register(args *structs.RegisterRequest, reply *struct{}) error
 		if check.Node == "" {
 			check.Node = args.Node
 		}
-		checkPreApply(check)
+		if err := checkPreApply(check); err != nil {
+			return err
+		}
 	}
 
 	// Check the complete register request against the given ACL policy.
@@ -219,6 +234,14 @@ func (c *Catalog) ListNodes(args *structs.DCSpecificRequest, reply *structs.Inde
 		return err
 	}
 
+	var filter *structs.Filter
+	if args.Filter != "" {
+		var err error
+		if filter, err = structs.CreateFilter(args.Filter, structs.Nodes(nil)); err != nil {
+			return err
+		}
+	}
+
 	return c.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
@@ -236,9 +259,17 @@ func (c *Catalog) ListNodes(args *structs.DCSpecificRequest, reply *structs.Inde
 			}
 
 			reply.Index, reply.Nodes = index, nodes
-			if err := c.srv.filterACL(args.Token, reply); err != nil {
+			query := filterMemoQuery("Catalog.ListNodes", args.Source, args)
+			if err := c.srv.filterACLMemoized(query, args.Token, reply); err != nil {
 				return err
 			}
+			if filter != nil {
+				raw, err := filter.Execute(reply.Nodes)
+				if err != nil {
+					return err
+				}
+				reply.Nodes = raw.(structs.Nodes)
+			}
 			return c.srv.sortNodesByDistanceFrom(args.Source, reply.Nodes)
 		})
 }
@@ -249,6 +280,20 @@ func (c *Catalog) ListServices(args *structs.DCSpecificRequest, reply *structs.I
 		return err
 	}
 
+	// The filter and the label selector select the service instances the
+	// services and their tags are gathered from.
+	var filter *structs.Filter
+	if args.Filter != "" {
+		var err error
+		if filter, err = structs.CreateFilter(args.Filter, structs.ServiceNodes(nil)); err != nil {
+			return err
+		}
+	}
+	selector, err := structs.ParseLabelSelector(args.LabelSelector)
+	if err != nil {
+		return err
+	}
+
 	return c.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
@@ -256,7 +301,12 @@ func (c *Catalog) ListServices(args *structs.DCSpecificRequest, reply *structs.I
 			var index uint64
 			var services structs.Services
 			var err error
-			if len(args.NodeMetaFilters) > 0 {
+			if filter != nil || len(selector) > 0 {
+				var instances structs.ServiceNodes
+				if index, instances, err = state.ServiceInstances(ws); err == nil {
+					services, err = filterServices(instances, args.NodeMetaFilters, filter, selector)
+				}
+			} else if len(args.NodeMetaFilters) > 0 {
 				index, services, err = state.ServicesByNodeMeta(ws, args.NodeMetaFilters)
 			} else {
 				index, services, err = state.Services(ws)
@@ -266,10 +316,47 @@ func (c *Catalog) ListServices(args *structs.DCSpecificRequest, reply *structs.I
 			}
 
 			reply.Index, reply.Services = index, services
-			return c.srv.filterACL(args.Token, reply)
+			query := filterMemoQuery("Catalog.ListServices", args.Source, args)
+			return c.srv.filterACLMemoized(query, args.Token, reply)
 		})
 }
 
+// filterServices returns the services and the tags of their instances
+// matching the node metadata, the filter and the label selector.
+func filterServices(instances structs.ServiceNodes, nodeMeta map[string]string, filter *structs.Filter, selector structs.LabelSelector) (structs.Services, error) {
+	if filter != nil {
+		raw, err := filter.Execute(instances)
+		if err != nil {
+			return nil, err
+		}
+		instances = raw.(structs.ServiceNodes)
+	}
+
+	unique := make(map[string]map[string]struct{})
+	for _, svc := range selectServiceNodes(selector, instances) {
+		if len(nodeMeta) > 0 && !structs.SatisfiesMetaFilters(svc.NodeMeta, nodeMeta) {
+			continue
+		}
+		tags, ok := unique[svc.ServiceName]
+		if !ok {
+			tags = make(map[string]struct{})
+			unique[svc.ServiceName] = tags
+		}
+		for _, tag := range svc.ServiceTags {
+			tags[tag] = struct{}{}
+		}
+	}
+
+	services := make(structs.Services)
+	for service, tags := range unique {
+		services[service] = make([]string, 0, len(tags))
+		for tag := range tags {
+			services[service] = append(services[service], tag)
+		}
+	}
+	return services, nil
+}
+
 // ServiceNodes returns all the nodes registered as part of a service
 func (c *Catalog) ServiceNodes(args *structs.ServiceSpecificRequest, reply *structs.IndexedServiceNodes) error {
 	if done, err := c.srv.forward("Catalog.ServiceNodes", args, args, reply); done {
@@ -280,6 +367,10 @@ func (c *Catalog) ServiceNodes(args *structs.ServiceSpecificRequest, reply *stru
 	if args.ServiceName == "" && args.ServiceAddress == "" {
 		return fmt.Errorf("Must provide service name")
 	}
+	selector, err := structs.ParseLabelSelector(args.LabelSelector)
+	if err != nil {
+		return err
+	}
 
 	// Determine the function we'll call
 	var f func(memdb.WatchSet, *state.Store) (uint64, structs.ServiceNodes, error)
@@ -327,7 +418,7 @@ func (c *Catalog) ServiceNodes(args *structs.ServiceSpecificRequest, reply *stru
 		}
 	}
 
-	err := c.srv.blockingQuery(
+	err = c.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -346,9 +437,11 @@ func (c *Catalog) ServiceNodes(args *structs.ServiceSpecificRequest, reply *stru
 				}
 				reply.ServiceNodes = filtered
 			}
-			if err := c.srv.filterACL(args.Token, reply); err != nil {
+			query := filterMemoQuery("Catalog.ServiceNodes", args.Source, args)
+			if err := c.srv.filterACLMemoized(query, args.Token, reply); err != nil {
 				return err
 			}
+			reply.ServiceNodes = selectServiceNodes(selector, reply.ServiceNodes)
 			return c.srv.sortNodesByDistanceFrom(args.Source, reply.ServiceNodes)
 		})
 
@@ -389,6 +482,40 @@ func (c *Catalog) ServiceNodes(args *structs.ServiceSpecificRequest, reply *stru
 	return err
 }
 
+// GatewayServices returns the services fronted by a gateway
+func (c *Catalog) GatewayServices(args *structs.ServiceSpecificRequest, reply *structs.IndexedGatewayServices) error {
+	if done, err := c.srv.forward("Catalog.GatewayServices", args, args, reply); done {
+		return err
+	}
+
+	// Verify the arguments
+	if args.ServiceName == "" {
+		return fmt.Errorf("Must provide gateway name")
+	}
+
+	// Fetch the ACL token, if any.
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.ServiceRead(args.ServiceName) {
+		return acl.ErrPermissionDenied
+	}
+
+	return c.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, services, err := state.GatewayServices(ws, args.ServiceName)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Services = index, services
+			return c.srv.filterACL(args.Token, reply)
+		})
+}
+
 // NodeServices returns all the services registered as part of a node
 func (c *Catalog) NodeServices(args *structs.NodeSpecificRequest, reply *structs.IndexedNodeServices) error {
 	if done, err := c.srv.forward("Catalog.NodeServices", args, args, reply); done {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/rpc"
 	"os"
+	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -80,6 +81,83 @@ func TestCatalog_RegisterService_InvalidAddress(t *testing.T) {
 	}
 }
 
+func TestCatalog_RegisterService_Weights(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	arg := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "db",
+			Port:    8000,
+			Weights: &structs.Weights{Passing: 0, Warning: 0},
+		},
+	}
+	var out struct{}
+
+	// Zero weights are replaced by the defaults
+	if err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, services, err := s1.fsm.State().NodeServices(nil, "foo")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if w := services.Services["db"].Weights; w == nil || *w != (structs.Weights{Passing: 1, Warning: 1}) {
+		t.Fatalf("bad: %#v", w)
+	}
+
+	// Invalid weights are rejected
+	arg.Service.Weights = &structs.Weights{Passing: 0, Warning: 3}
+	err = msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out)
+	if err == nil || !strings.Contains(err.Error(), "Invalid service weights") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestCatalog_Register_InvalidCheckDefinition(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	for name, def := range map[string]structs.HealthCheckDefinition{
+		"several kinds":     {HTTP: "http://127.0.0.1:8000", TTL: 10 * time.Second},
+		"negative interval": {TCP: "127.0.0.1:8000", Interval: -time.Second},
+	} {
+		t.Run(name, func(t *testing.T) {
+			arg := structs.RegisterRequest{
+				Datacenter: "dc1",
+				Node:       "foo",
+				Address:    "127.0.0.1",
+				Checks: structs.HealthChecks{
+					&structs.HealthCheck{
+						Name:       "check",
+						Definition: def,
+					},
+				},
+			}
+			var out struct{}
+			err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out)
+			if err == nil || !strings.Contains(err.Error(), `Invalid check "check"`) {
+				t.Fatalf("err: %v", err)
+			}
+		})
+	}
+}
+
 func TestCatalog_RegisterService_SkipNodeUpdate(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -926,6 +1004,43 @@ func TestCatalog_ListNodes_NodeMetaFilter(t *testing.T) {
 	})
 }
 
+func TestCatalog_ListNodes_Filter(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	node := &structs.Node{Node: "foo", Address: "127.0.0.1", Meta: map[string]string{"env": "prod"}}
+	if err := s1.fsm.State().EnsureNode(1, node); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	args := structs.DCSpecificRequest{
+		Datacenter: "dc1",
+	}
+	args.Filter = "Meta.env == prod"
+	var out structs.IndexedNodes
+	retry.Run(t, func(r *retry.R) {
+		if err := msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out); err != nil {
+			r.Fatal(err)
+		}
+		if len(out.Nodes) != 1 || out.Nodes[0].Node != "foo" {
+			r.Fatalf("bad: %v", out.Nodes)
+		}
+	})
+
+	// Invalid filters are rejected
+	args.Filter = "Missing == prod"
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.ListNodes", &args, &out)
+	if err == nil || !strings.Contains(err.Error(), `"Missing"`) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestCatalog_ListNodes_StaleRead(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -1401,6 +1516,136 @@ func TestCatalog_ListServices_NodeMetaFilter(t *testing.T) {
 	}
 }
 
+func TestCatalog_ListServices_Filter(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	state := s1.fsm.State()
+	for i, name := range []string{"foo", "bar"} {
+		node := &structs.Node{Node: name, Address: "127.0.0.1", Meta: map[string]string{"env": name}}
+		if err := state.EnsureNode(uint64(10+i), node); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	services := []struct {
+		node string
+		svc  *structs.NodeService
+	}{
+		{"foo", &structs.NodeService{ID: "db1", Service: "db", Tags: []string{"primary"}, Meta: map[string]string{"version": "2"}}},
+		{"bar", &structs.NodeService{ID: "db2", Service: "db", Tags: []string{"replica"}, Meta: map[string]string{"version": "1"}}},
+		{"bar", &structs.NodeService{ID: "web", Service: "web", Tags: []string{"v1"}}},
+	}
+	for i, s := range services {
+		if err := state.EnsureService(uint64(20+i), s.node, s.svc); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	cases := []struct {
+		filter   string
+		nodeMeta map[string]string
+		expected structs.Services
+	}{
+		{"ServiceMeta.version == 2", nil, structs.Services{"db": {"primary"}}},
+		{"replica in ServiceTags", nil, structs.Services{"db": {"replica"}}},
+		{"NodeMeta.env == bar", nil, structs.Services{"db": {"replica"}, "web": {"v1"}}},
+		{"ServiceName == db", map[string]string{"env": "foo"}, structs.Services{"db": {"primary"}}},
+		{"ServiceName == missing", nil, structs.Services{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.filter, func(t *testing.T) {
+			args := structs.DCSpecificRequest{
+				Datacenter:      "dc1",
+				NodeMetaFilters: tc.nodeMeta,
+			}
+			args.Filter = tc.filter
+			var out structs.IndexedServices
+			if err := msgpackrpc.CallWithCodec(codec, "Catalog.ListServices", &args, &out); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			require.Equal(t, tc.expected, out.Services)
+		})
+	}
+}
+
+func TestCatalog_LabelSelector(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	state := s1.fsm.State()
+	if err := state.EnsureNode(10, &structs.Node{Node: "foo", Address: "127.0.0.1"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	services := []*structs.NodeService{
+		{ID: "db1", Service: "db", Tags: []string{"primary"}, Labels: map[string]string{"team": "payments", "env": "prod"}},
+		{ID: "db2", Service: "db", Tags: []string{"replica"}, Labels: map[string]string{"team": "payments", "env": "dev"}},
+		{ID: "web", Service: "web", Tags: []string{"v1"}},
+	}
+	for i, svc := range services {
+		if err := state.EnsureService(uint64(20+i), "foo", svc); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	cases := []struct {
+		selector  string
+		services  structs.Services
+		instances []string
+	}{
+		{"", structs.Services{"db": {"primary", "replica"}, "web": {"v1"}}, []string{"db1", "db2"}},
+		{"team=payments,env!=dev", structs.Services{"db": {"primary"}}, []string{"db1"}},
+		{"env=dev", structs.Services{"db": {"replica"}}, []string{"db2"}},
+		{"!team", structs.Services{"web": {"v1"}}, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.selector, func(t *testing.T) {
+			args := structs.DCSpecificRequest{Datacenter: "dc1"}
+			args.LabelSelector = tc.selector
+			var out structs.IndexedServices
+			if err := msgpackrpc.CallWithCodec(codec, "Catalog.ListServices", &args, &out); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			delete(out.Services, "consul")
+			for _, tags := range out.Services {
+				sort.Strings(tags)
+			}
+			require.Equal(t, tc.services, out.Services)
+
+			nodesArgs := structs.ServiceSpecificRequest{Datacenter: "dc1", ServiceName: "db"}
+			nodesArgs.LabelSelector = tc.selector
+			var nodes structs.IndexedServiceNodes
+			if err := msgpackrpc.CallWithCodec(codec, "Catalog.ServiceNodes", &nodesArgs, &nodes); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			var ids []string
+			for _, node := range nodes.ServiceNodes {
+				ids = append(ids, node.ServiceID)
+			}
+			sort.Strings(ids)
+			require.Equal(t, tc.instances, ids)
+		})
+	}
+
+	// An invalid selector is rejected.
+	args := structs.ServiceSpecificRequest{Datacenter: "dc1", ServiceName: "db"}
+	args.LabelSelector = "team=pay ments"
+	var out structs.IndexedServiceNodes
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.ServiceNodes", &args, &out)
+	require.Error(t, err)
+}
+
 func TestCatalog_ListServices_Blocking(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -2266,6 +2511,67 @@ func TestCatalog_NodeServices_ConnectNative(t *testing.T) {
 	assert.Equal(args.Service.Connect.Native, v.Connect.Native)
 }
 
+func TestCatalog_GatewayServices(t *testing.T) {
+	t.Parallel()
+
+	assert := assert.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForTestAgent(t, s1.RPC, "dc1")
+
+	// Gateways can't have a destination
+	args := structs.TestRegisterRequestGateway(t)
+	args.Service.Proxy.DestinationServiceName = "web"
+	var out struct{}
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", args, &out)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "Proxy.DestinationServiceName cannot be set")
+
+	// Configure the gateway
+	entry := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Op:         structs.ConfigEntryUpsert,
+		Entry: &structs.IngressGatewayConfigEntry{
+			Name: "ingress",
+			Listeners: []structs.IngressListener{
+				{
+					Port:     9191,
+					Protocol: structs.IngressListenerProtocolTCP,
+					Services: []structs.IngressService{{Name: "db"}},
+				},
+				{
+					Port:     8080,
+					Protocol: structs.IngressListenerProtocolHTTP,
+					Services: []structs.IngressService{{Name: "web"}},
+				},
+			},
+		},
+	}
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &entry, &out))
+
+	// List
+	req := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "ingress",
+	}
+	var resp structs.IndexedGatewayServices
+	assert.Nil(msgpackrpc.CallWithCodec(codec, "Catalog.GatewayServices", &req, &resp))
+	assert.Equal(structs.GatewayServices{
+		{Gateway: "ingress", GatewayKind: structs.ServiceKindIngressGateway, Service: "db", Port: 9191},
+		{Gateway: "ingress", GatewayKind: structs.ServiceKindIngressGateway, Service: "web", Port: 8080},
+	}, resp.Services)
+
+	// The gateway name is required
+	req.ServiceName = ""
+	err = msgpackrpc.CallWithCodec(codec, "Catalog.GatewayServices", &req, &resp)
+	assert.NotNil(err)
+	assert.Contains(err.Error(), "Must provide gateway name")
+}
+
 // Used to check for a regression against a known bug
 func TestCatalog_Register_FailedCase1(t *testing.T) {
 	t.Parallel()
@@ -2539,6 +2845,51 @@ node "%s" {
 	}
 }
 
+func TestCatalog_GatewayServices_FilterACL(t *testing.T) {
+	t.Parallel()
+	dir, token, srv, codec := testACLFilterServer(t)
+	defer os.RemoveAll(dir)
+	defer srv.Shutdown()
+	defer codec.Close()
+
+	// Configure gateways fronting both services
+	for _, name := range []string{"foo", "bar"} {
+		args := structs.ConfigEntryRequest{
+			Datacenter: "dc1",
+			Op:         structs.ConfigEntryUpsert,
+			Entry: &structs.TerminatingGatewayConfigEntry{
+				Name:     name,
+				Services: []structs.LinkedService{{Name: "foo"}, {Name: "bar"}},
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		if err := msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// The services the token can't read are filtered
+	opt := structs.ServiceSpecificRequest{
+		Datacenter:   "dc1",
+		ServiceName:  "foo",
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	reply := structs.IndexedGatewayServices{}
+	if err := msgpackrpc.CallWithCodec(codec, "Catalog.GatewayServices", &opt, &reply); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(reply.Services) != 1 || reply.Services[0].Service != "foo" {
+		t.Fatalf("bad: %#v", reply.Services)
+	}
+
+	// The gateway must be readable
+	opt.ServiceName = "bar"
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.GatewayServices", &opt, &reply)
+	if !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestCatalog_NodeServices_FilterACL(t *testing.T) {
 	t.Parallel()
 	dir, token, srv, codec := testACLFilterServer(t)
@@ -59,6 +59,10 @@ type Client struct {
 	// Connection pool to consul servers
 	connPool *pool.ConnPool
 
+	// grpcConns holds the gRPC connections to the servers, which are
+	// shared by the subscriptions to their event streams.
+	grpcConns *grpcConnPool
+
 	// routers is responsible for the selection and maintenance of
 	// Consul servers this agent uses for RPC requests
 	routers *router.Manager
@@ -82,6 +86,10 @@ type Client struct {
 	shutdownCh   chan struct{}
 	shutdownLock sync.Mutex
 
+	// tlsConfigurator holds the TLS configuration of the agent, which is
+	// used to request the certificates of auto-encrypt.
+	tlsConfigurator *tlsutil.Configurator
+
 	// embedded struct to hold all the enterprise specific data
 	EnterpriseClient
 }
@@ -135,13 +143,15 @@ func NewClientLogger(config *Config, logger *log.Logger, tlsConfigurator *tlsuti
 
 	// Create client
 	c := &Client{
-		config:     config,
-		connPool:   connPool,
-		eventCh:    make(chan serf.Event, serfEventBacklog),
-		logger:     logger,
-		shutdownCh: make(chan struct{}),
+		config:          config,
+		connPool:        connPool,
+		eventCh:         make(chan serf.Event, serfEventBacklog),
+		logger:          logger,
+		shutdownCh:      make(chan struct{}),
+		tlsConfigurator: tlsConfigurator,
 	}
 
+	c.grpcConns = newGRPCConnPool(c.dialGRPC)
 	c.rpcLimiter.Store(rate.NewLimiter(config.RPCRate, config.RPCMaxBurst))
 
 	if err := c.initEnterprise(); err != nil {
@@ -209,7 +219,8 @@ func (c *Client) Shutdown() error {
 		c.serf.Shutdown()
 	}
 
-	// Close the connection pool
+	// Close the connection pools
+	c.grpcConns.Shutdown()
 	c.connPool.Shutdown()
 	return nil
 }
@@ -392,6 +403,7 @@ func (c *Client) Stats() map[string]map[string]string {
 		} else {
 			stats["consul"]["acl"] = "enabled"
 		}
+		stats["acl_cache"] = c.acls.Stats()
 	} else {
 		stats["consul"]["acl"] = "disabled"
 	}
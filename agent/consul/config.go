@@ -33,6 +33,10 @@ const (
 	// MaxRaftMultiplier is a fairly arbitrary upper bound that limits the
 	// amount of performance detuning that's possible.
 	MaxRaftMultiplier uint = 10
+
+	// MaxRaftApplyBatchSize is the largest number of log entries the Raft
+	// library accepts to append in a single batch.
+	MaxRaftApplyBatchSize = 1024
 )
 
 var (
@@ -343,6 +347,28 @@ type Config struct {
 	// place, and a small jitter is applied to avoid a thundering herd.
 	RPCHoldTimeout time.Duration
 
+	// RaftApplyBatchMaxLatency is how long a write may be held to batch it
+	// with the concurrent ones before applying it through Raft. Writes are
+	// applied right away when zero. The maximum size of the batches is the
+	// MaxAppendEntries of the RaftConfig.
+	RaftApplyBatchMaxLatency time.Duration
+
+	// RaftApplyBatchAdaptive only holds the writes when they are applied
+	// concurrently, for a duration growing with the load up to
+	// RaftApplyBatchMaxLatency.
+	RaftApplyBatchAdaptive bool
+
+	// KeyringRotationInterval is the interval at which the leader rotates
+	// the gossip encryption key of the LAN pool. The rotation is disabled
+	// when zero.
+	KeyringRotationInterval time.Duration
+
+	// ACLFilterMemoSize is the maximum number of ACL filtered results of the
+	// catalog and health list queries the server memoizes, to share them
+	// between the requests with tokens linked to the same policies. The
+	// memoization is disabled when zero.
+	ACLFilterMemoSize int
+
 	// RPCRate and RPCMaxBurst control how frequently RPC calls are allowed
 	// to happen. In any large enough time interval, rate limiter limits the
 	// rate to RPCRate tokens per second, with a maximum burst size of
@@ -374,6 +400,34 @@ type Config struct {
 	// ConnectEnabled is whether to enable Connect features such as the CA.
 	ConnectEnabled bool
 
+	// AutoEncryptAllowTLS is whether the server signs the certificates
+	// requested by the client agents with auto-encrypt, using the Connect
+	// CA.
+	AutoEncryptAllowTLS bool
+
+	// AutoConfigAuthzEnabled is whether the server hands their configuration
+	// to the client agents which request it with auto-config and present a
+	// JWT validated with the settings below.
+	AutoConfigAuthzEnabled bool
+
+	// AutoConfigAuthzJWTValidationPubKeys are the PEM-encoded public keys
+	// verifying the signature of the JWTs.
+	AutoConfigAuthzJWTValidationPubKeys []string
+
+	// AutoConfigAuthzBoundIssuer and AutoConfigAuthzBoundAudiences are the
+	// issuer and the audiences the JWTs must have, when set.
+	AutoConfigAuthzBoundIssuer    string
+	AutoConfigAuthzBoundAudiences []string
+
+	// AutoConfigAuthzClaimAssertions maps the claims of the JWTs to the
+	// values they must have, which can refer to the node name and segment
+	// of the agent with ${node} and ${segment}.
+	AutoConfigAuthzClaimAssertions map[string]string
+
+	// AutoConfigAuthzAgentTokenPolicies are the names of the policies of the
+	// agent tokens created for the client agents.
+	AutoConfigAuthzAgentTokenPolicies []string
+
 	// CAConfig is used to apply the initial Connect CA configuration when
 	// bootstrapping.
 	CAConfig *structs.CAConfiguration
@@ -460,6 +514,8 @@ func DefaultConfig() *Config {
 		CoordinateUpdateBatchSize:  128,
 		CoordinateUpdateMaxBatches: 5,
 
+		ACLFilterMemoSize: 1024,
+
 		RPCRate:     rate.Inf,
 		RPCMaxBurst: 1000,
 
@@ -0,0 +1,217 @@
+package consul
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+// ConfigEntry manages the centralized config entries. Reading and writing
+// them requires operator read and write privileges respectively, except that
+// the entries of the gateways can also be read with service read privileges
+// on their name, so that the gateways can read their own.
+type ConfigEntry struct {
+	// srv is a pointer back to the server.
+	srv *Server
+}
+
+// Apply creates, updates or deletes a config entry.
+func (c *ConfigEntry) Apply(
+	args *structs.ConfigEntryRequest,
+	reply *struct{}) error {
+	// Forward this request to the leader if necessary
+	if done, err := c.srv.forward("ConfigEntry.Apply", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"config_entry", "apply"}, time.Now())
+
+	if args.Entry == nil {
+		return fmt.Errorf("Must provide a config entry")
+	}
+
+	// Perform the ACL check
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.OperatorWrite() {
+		return acl.ErrPermissionDenied
+	}
+
+	switch args.Op {
+	case structs.ConfigEntryUpsert:
+		if err := args.Entry.Normalize(); err != nil {
+			return err
+		}
+		if err := args.Entry.Validate(); err != nil {
+			return err
+		}
+	case structs.ConfigEntryDelete:
+		// Only the kind and name are used
+	default:
+		return fmt.Errorf("Invalid config entry operation '%s'", args.Op)
+	}
+
+	args.Actor = c.srv.tokenAccessorID(args.Token)
+	args.Timestamp = time.Now().UTC()
+
+	// Commit
+	resp, err := c.srv.raftApply(structs.ConfigEntryRequestType, args)
+	if err != nil {
+		c.srv.logger.Printf("[ERR] consul.config_entry: Apply failed %v", err)
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+
+	return nil
+}
+
+// Get returns a single config entry by kind and name.
+func (c *ConfigEntry) Get(
+	args *structs.ConfigEntryQuery,
+	reply *structs.ConfigEntryResponse) error {
+	// Forward if necessary
+	if done, err := c.srv.forward("ConfigEntry.Get", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"config_entry", "get"}, time.Now())
+
+	if _, err := structs.MakeConfigEntry(args.Kind, args.Name); err != nil {
+		return err
+	}
+
+	// Perform the ACL check
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.OperatorRead() &&
+		!(isGatewayConfigEntry(args.Kind) && rule.ServiceRead(args.Name)) {
+		return acl.ErrPermissionDenied
+	}
+
+	return c.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, entry, err := state.ConfigEntry(ws, args.Kind, args.Name)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Entry = index, entry
+			return nil
+		},
+	)
+}
+
+// List returns all the config entries of the given kind.
+func (c *ConfigEntry) List(
+	args *structs.ConfigEntryQuery,
+	reply *structs.IndexedConfigEntries) error {
+	// Forward if necessary
+	if done, err := c.srv.forward("ConfigEntry.List", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"config_entry", "list"}, time.Now())
+
+	if _, err := structs.MakeConfigEntry(args.Kind, ""); err != nil {
+		return err
+	}
+
+	// Perform the ACL check
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.OperatorRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	return c.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, entries, err := state.ConfigEntriesByKind(ws, args.Kind)
+			if err != nil {
+				return err
+			}
+
+			reply.Kind = args.Kind
+			reply.Index, reply.Entries = index, entries
+			if reply.Entries == nil {
+				reply.Entries = make([]structs.ConfigEntry, 0)
+			}
+			return nil
+		},
+	)
+}
+
+// ResolveServiceConfig returns the centrally managed config of a service,
+// merged from the global proxy-defaults and its service-defaults.
+func (c *ConfigEntry) ResolveServiceConfig(
+	args *structs.ServiceConfigRequest,
+	reply *structs.ServiceConfigResponse) error {
+	// Forward if necessary
+	if done, err := c.srv.forward("ConfigEntry.ResolveServiceConfig", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"config_entry", "resolve_service_config"}, time.Now())
+
+	if args.Name == "" {
+		return fmt.Errorf("Must provide a service name")
+	}
+
+	// Perform the ACL check
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.ServiceRead(args.Name) {
+		return acl.ErrPermissionDenied
+	}
+
+	return c.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, proxyEntry, err := state.ConfigEntry(ws, structs.ProxyDefaults, structs.ProxyConfigGlobal)
+			if err != nil {
+				return err
+			}
+			serviceIndex, serviceEntry, err := state.ConfigEntry(ws, structs.ServiceDefaults, args.Name)
+			if err != nil {
+				return err
+			}
+			if serviceIndex > index {
+				index = serviceIndex
+			}
+
+			config := make(map[string]interface{})
+			if proxyConf, ok := proxyEntry.(*structs.ProxyConfigEntry); ok {
+				for k, v := range proxyConf.Config {
+					config[k] = v
+				}
+			}
+			if serviceConf, ok := serviceEntry.(*structs.ServiceConfigEntry); ok && serviceConf.Protocol != "" {
+				config["protocol"] = serviceConf.Protocol
+			}
+
+			reply.Index, reply.ProxyConfig = index, config
+			return nil
+		},
+	)
+}
+
+// isGatewayConfigEntry returns whether the config entries of the kind
+// configure the gateways with the same service name.
+func isGatewayConfigEntry(kind string) bool {
+	return kind == structs.IngressGateway || kind == structs.TerminatingGateway
+}
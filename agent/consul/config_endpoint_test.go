@@ -0,0 +1,406 @@
+package consul
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigEntry_Apply(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	args := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Op:         structs.ConfigEntryUpsert,
+		Entry: &structs.ServiceConfigEntry{
+			Name:     "web",
+			Protocol: "HTTP",
+		},
+	}
+	var out struct{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out))
+
+	// The entry is normalized
+	state := s1.fsm.State()
+	_, entry, err := state.ConfigEntry(nil, structs.ServiceDefaults, "web")
+	require.NoError(err)
+	serviceConf, ok := entry.(*structs.ServiceConfigEntry)
+	require.True(ok)
+	require.Equal(structs.ServiceDefaults, serviceConf.Kind)
+	require.Equal("http", serviceConf.Protocol)
+
+	// The change is recorded in the changelog
+	_, changes, err := state.ConnectChanges(nil, structs.ServiceDefaults, "web")
+	require.NoError(err)
+	require.Len(changes, 1)
+	require.Equal(structs.ConnectChangeCreate, changes[0].Op)
+
+	// Only the global proxy defaults are allowed
+	args.Entry = &structs.ProxyConfigEntry{Name: "foo"}
+	err = msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out)
+	require.Error(err)
+	require.Contains(err.Error(), `invalid name ("foo")`)
+
+	// Delete the entry
+	args.Op = structs.ConfigEntryDelete
+	args.Entry = &structs.ServiceConfigEntry{Name: "web"}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out))
+	_, entry, err = state.ConfigEntry(nil, structs.ServiceDefaults, "web")
+	require.NoError(err)
+	require.Nil(entry)
+}
+
+func TestConfigEntry_Apply_ACLDeny(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Create a token with operator read privileges only
+	var token string
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.Apply", &structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:  "User token",
+			Type:  structs.ACLTokenTypeClient,
+			Rules: `operator = "read"`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}, &token))
+
+	args := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Op:         structs.ConfigEntryUpsert,
+		Entry: &structs.ProxyConfigEntry{
+			Name: structs.ProxyConfigGlobal,
+		},
+		WriteRequest: structs.WriteRequest{Token: token},
+	}
+	var out struct{}
+	err := msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out)
+	require.True(acl.IsErrPermissionDenied(err))
+
+	// The master token is allowed
+	args.Token = "root"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &args, &out))
+
+	// And the entry can be read with the operator read token
+	get := structs.ConfigEntryQuery{
+		Datacenter:   "dc1",
+		Kind:         structs.ProxyDefaults,
+		Name:         structs.ProxyConfigGlobal,
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	var resp structs.ConfigEntryResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &get, &resp))
+	require.Equal(structs.ProxyConfigGlobal, resp.Entry.GetName())
+
+	// But not anonymously
+	get.Token = ""
+	err = msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &get, &resp)
+	require.True(acl.IsErrPermissionDenied(err))
+}
+
+func TestConfigEntry_Get_GatewayACL(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	require.NoError(s1.fsm.State().EnsureConfigEntry(1, &structs.IngressGatewayConfigEntry{
+		Kind: structs.IngressGateway,
+		Name: "ingress",
+		Listeners: []structs.IngressListener{
+			{Port: 8080, Protocol: "tcp", Services: []structs.IngressService{{Name: "db"}}},
+		},
+	}))
+
+	// Create a token with the privileges of the gateway
+	var token string
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.Apply", &structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:  "Gateway token",
+			Type:  structs.ACLTokenTypeClient,
+			Rules: `service "ingress" { policy = "write" }`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}, &token))
+
+	// The gateway can read its own entry
+	get := structs.ConfigEntryQuery{
+		Datacenter:   "dc1",
+		Kind:         structs.IngressGateway,
+		Name:         "ingress",
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	var resp structs.ConfigEntryResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &get, &resp))
+	entry, ok := resp.Entry.(*structs.IngressGatewayConfigEntry)
+	require.True(ok)
+	require.Len(entry.Listeners, 1)
+
+	// But not the entries of other gateways
+	get.Name = "other"
+	err := msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &get, &resp)
+	require.True(acl.IsErrPermissionDenied(err))
+
+	// The same goes for the terminating gateways
+	require.NoError(s1.fsm.State().EnsureConfigEntry(2, &structs.TerminatingGatewayConfigEntry{
+		Kind:     structs.TerminatingGateway,
+		Name:     "ingress",
+		Services: []structs.LinkedService{{Name: "legacy"}},
+	}))
+	get.Kind = structs.TerminatingGateway
+	get.Name = "ingress"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &get, &resp))
+	require.Equal(structs.TerminatingGateway, resp.Entry.GetKind())
+
+	// But not the other kinds
+	get.Kind = structs.ServiceDefaults
+	get.Name = "ingress"
+	err = msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &get, &resp)
+	require.True(acl.IsErrPermissionDenied(err))
+}
+
+func TestConfigEntry_Get(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	entry := &structs.ProxyConfigEntry{
+		Kind: structs.ProxyDefaults,
+		Name: structs.ProxyConfigGlobal,
+		Config: map[string]interface{}{
+			"foo": "bar",
+		},
+	}
+	require.NoError(s1.fsm.State().EnsureConfigEntry(1, entry))
+
+	args := structs.ConfigEntryQuery{
+		Datacenter: "dc1",
+		Kind:       structs.ProxyDefaults,
+		Name:       structs.ProxyConfigGlobal,
+	}
+	var out structs.ConfigEntryResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &args, &out))
+	require.Equal(uint64(1), out.Index)
+	proxyConf, ok := out.Entry.(*structs.ProxyConfigEntry)
+	require.True(ok)
+	require.Equal(structs.ProxyConfigGlobal, proxyConf.Name)
+	require.Contains(proxyConf.Config, "foo")
+
+	// A missing entry is nil
+	args.Kind = structs.ServiceDefaults
+	args.Name = "web"
+	out = structs.ConfigEntryResponse{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &args, &out))
+	require.Nil(out.Entry)
+
+	// The kind must be valid
+	args.Kind = "foo"
+	err := msgpackrpc.CallWithCodec(codec, "ConfigEntry.Get", &args, &out)
+	require.Error(err)
+	require.Contains(err.Error(), "invalid config entry kind: foo")
+}
+
+func TestConfigEntry_ResolveServiceConfig(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Nothing is configured
+	args := structs.ServiceConfigRequest{
+		Datacenter: "dc1",
+		Name:       "web",
+	}
+	var out structs.ServiceConfigResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.ResolveServiceConfig", &args, &out))
+	require.Empty(out.ProxyConfig)
+
+	// The protocol of the service defaults overrides the proxy defaults
+	state := s1.fsm.State()
+	require.NoError(state.EnsureConfigEntry(1, &structs.ProxyConfigEntry{
+		Kind: structs.ProxyDefaults,
+		Name: structs.ProxyConfigGlobal,
+		Config: map[string]interface{}{
+			"protocol":              "http",
+			"local_connect_timeout": 5000,
+		},
+	}))
+	require.NoError(state.EnsureConfigEntry(2, &structs.ServiceConfigEntry{
+		Kind:     structs.ServiceDefaults,
+		Name:     "web",
+		Protocol: "grpc",
+	}))
+	require.NoError(state.EnsureConfigEntry(3, &structs.ServiceConfigEntry{
+		Kind:     structs.ServiceDefaults,
+		Name:     "api",
+		Protocol: "http2",
+	}))
+
+	out = structs.ServiceConfigResponse{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.ResolveServiceConfig", &args, &out))
+	require.Equal(uint64(3), out.Index)
+	require.Len(out.ProxyConfig, 2)
+	require.EqualValues("grpc", out.ProxyConfig["protocol"])
+	require.EqualValues(5000, out.ProxyConfig["local_connect_timeout"])
+
+	// Services without defaults get the proxy defaults
+	args.Name = "db"
+	out = structs.ServiceConfigResponse{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.ResolveServiceConfig", &args, &out))
+	require.EqualValues("http", out.ProxyConfig["protocol"])
+
+	// The service name is required
+	args.Name = ""
+	err := msgpackrpc.CallWithCodec(codec, "ConfigEntry.ResolveServiceConfig", &args, &out)
+	require.Error(err)
+	require.Contains(err.Error(), "Must provide a service name")
+}
+
+func TestConfigEntry_ResolveServiceConfig_ACLDeny(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Create a token that can read the web service only
+	var token string
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.Apply", &structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:  "User token",
+			Type:  structs.ACLTokenTypeClient,
+			Rules: `service "web" { policy = "read" }`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}, &token))
+
+	args := structs.ServiceConfigRequest{
+		Datacenter:   "dc1",
+		Name:         "web",
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	var out structs.ServiceConfigResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.ResolveServiceConfig", &args, &out))
+
+	args.Name = "db"
+	err := msgpackrpc.CallWithCodec(codec, "ConfigEntry.ResolveServiceConfig", &args, &out)
+	require.True(acl.IsErrPermissionDenied(err), "err: %v", err)
+}
+
+func TestConfigEntry_List(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	state := s1.fsm.State()
+	require.NoError(state.EnsureConfigEntry(1, &structs.ServiceConfigEntry{
+		Kind: structs.ServiceDefaults,
+		Name: "db",
+	}))
+	require.NoError(state.EnsureConfigEntry(2, &structs.ServiceConfigEntry{
+		Kind:     structs.ServiceDefaults,
+		Name:     "web",
+		Protocol: "http",
+	}))
+
+	args := structs.ConfigEntryQuery{
+		Datacenter: "dc1",
+		Kind:       structs.ServiceDefaults,
+	}
+	var out structs.IndexedConfigEntries
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.List", &args, &out))
+	require.Equal(uint64(2), out.Index)
+	require.Equal(structs.ServiceDefaults, out.Kind)
+	require.Len(out.Entries, 2)
+	require.Equal("db", out.Entries[0].GetName())
+	require.Equal("web", out.Entries[1].GetName())
+
+	// A blocking query returns when an entry changes
+	start := time.Now()
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		state.DeleteConfigEntry(3, structs.ServiceDefaults, "db")
+	}()
+	args.MinQueryIndex = out.Index
+	out = structs.IndexedConfigEntries{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.List", &args, &out))
+	require.True(time.Since(start) >= 100*time.Millisecond)
+	require.Equal(uint64(3), out.Index)
+	require.Len(out.Entries, 1)
+	require.Equal("web", out.Entries[0].GetName())
+}
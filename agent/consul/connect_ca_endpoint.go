@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
 	"strings"
 	"sync"
@@ -123,15 +124,75 @@ func (s *ConnectCA) ConfigurationGet(
 	}
 
 	state := s.srv.fsm.State()
-	_, config, err := state.CAConfig()
+	_, config, err := state.CAConfig(nil)
 	if err != nil {
 		return err
 	}
-	*reply = *config
+	*reply = *redactCAConfig(config)
 
 	return nil
 }
 
+// caSecretKeys are the keys of the provider config never returned by
+// ConfigurationGet, by provider.
+var caSecretKeys = map[string][]string{
+	structs.ConsulCAProvider: {"PrivateKey"},
+	structs.VaultCAProvider:  {"Token"},
+}
+
+// lookupConfigKey returns the key of the config matching the given one
+// regardless of the case, since the config is decoded case-insensitively.
+func lookupConfigKey(config map[string]interface{}, key string) (string, bool) {
+	for k := range config {
+		if strings.EqualFold(k, key) {
+			return k, true
+		}
+	}
+	return "", false
+}
+
+// redactCAConfig returns a copy of the CA config without the secrets of its
+// provider.
+func redactCAConfig(config *structs.CAConfiguration) *structs.CAConfiguration {
+	secrets := caSecretKeys[config.Provider]
+	if len(secrets) == 0 {
+		return config
+	}
+
+	redacted := *config
+	redacted.Config = make(map[string]interface{}, len(config.Config))
+	for k, v := range config.Config {
+		redacted.Config[k] = v
+	}
+	for _, secret := range secrets {
+		if k, ok := lookupConfigKey(redacted.Config, secret); ok {
+			delete(redacted.Config, k)
+		}
+	}
+	return &redacted
+}
+
+// preserveCASecrets copies the secrets of the current CA config missing from
+// the new config, when the provider doesn't change.
+func preserveCASecrets(newConfig, config *structs.CAConfiguration) {
+	if newConfig.Provider != config.Provider {
+		return
+	}
+	for _, secret := range caSecretKeys[config.Provider] {
+		k, ok := lookupConfigKey(config.Config, secret)
+		if !ok {
+			continue
+		}
+		if _, ok := lookupConfigKey(newConfig.Config, secret); ok {
+			continue
+		}
+		if newConfig.Config == nil {
+			newConfig.Config = make(map[string]interface{})
+		}
+		newConfig.Config[k] = config.Config[k]
+	}
+}
+
 // ConfigurationSet updates the configuration for the CA.
 func (s *ConnectCA) ConfigurationSet(
 	args *structs.CARequest,
@@ -156,13 +217,16 @@ func (s *ConnectCA) ConfigurationSet(
 
 	// Exit early if it's a no-op change
 	state := s.srv.fsm.State()
-	confIdx, config, err := state.CAConfig()
+	confIdx, config, err := state.CAConfig(nil)
 	if err != nil {
 		return err
 	}
 
 	// Don't allow users to change the ClusterID.
 	args.Config.ClusterID = config.ClusterID
+	// The secrets aren't returned when reading the config, so keep the
+	// current ones when they are left out of the new config.
+	preserveCASecrets(args.Config, config)
 	if args.Config.Provider == config.Provider && reflect.DeepEqual(args.Config.Config, config.Config) {
 		return nil
 	}
@@ -315,41 +379,44 @@ func (s *ConnectCA) Roots(
 		return ErrConnectNotEnabled
 	}
 
-	// Load the ClusterID to generate TrustDomain. We do this outside the loop
-	// since by definition this value should be immutable once set for lifetime of
-	// the cluster so we don't need to look it up more than once. We also don't
-	// have to worry about non-atomicity between the config fetch transaction and
-	// the CARoots transaction below since this field must remain immutable. Do
-	// not re-use this state/config for other logic that might care about changes
-	// of config during the blocking query below.
-	{
-		state := s.srv.fsm.State()
-		_, config, err := state.CAConfig()
-		if err != nil {
-			return err
-		}
-
-		// Check CA is actually bootstrapped...
-		if config != nil {
-			// Build TrustDomain based on the ClusterID stored.
-			signingID := connect.SpiffeIDSigningForCluster(config)
-			if signingID == nil {
-				// If CA is bootstrapped at all then this should never happen but be
-				// defensive.
-				return errors.New("no cluster trust domain setup")
-			}
-			reply.TrustDomain = signingID.Host()
-		}
-	}
-
 	return s.srv.blockingQuery(
 		&args.QueryOptions, &reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
+			// Load the CA config to generate TrustDomain. The config is watched
+			// too since its TrustDomain and LeafCertDNSDomains can change
+			// without a root rotation.
+			confIdx, config, err := state.CAConfig(ws)
+			if err != nil {
+				return err
+			}
+
+			// Check CA is actually bootstrapped...
+			if config != nil {
+				// Build TrustDomain based on the ClusterID stored, unless the
+				// config overrides it.
+				signingID := connect.SpiffeIDSigningForCluster(config)
+				if signingID == nil {
+					// If CA is bootstrapped at all then this should never happen but be
+					// defensive.
+					return errors.New("no cluster trust domain setup")
+				}
+				reply.TrustDomain = signingID.Host()
+
+				commonCfg, err := config.GetCommonConfig()
+				if err != nil {
+					return err
+				}
+				reply.LeafCertDNSDomains = commonCfg.LeafCertDNSDomains
+			}
+
 			index, roots, err := state.CARoots(ws)
 			if err != nil {
 				return err
 			}
 
+			if confIdx > index {
+				index = confIdx
+			}
 			reply.Index, reply.Roots = index, roots
 			if reply.Roots == nil {
 				reply.Roots = make(structs.CARoots, 0)
@@ -374,6 +441,7 @@ func (s *ConnectCA) Roots(
 					IntermediateCerts:   r.IntermediateCerts,
 					RaftIndex:           r.RaftIndex,
 					Active:              r.Active,
+					RotatedOutAt:        r.RotatedOutAt,
 				}
 
 				if r.Active {
@@ -386,7 +454,8 @@ func (s *ConnectCA) Roots(
 	)
 }
 
-// Sign signs a certificate for a service.
+// Sign signs a certificate for a service or, for auto-encrypt, a client
+// agent.
 func (s *ConnectCA) Sign(
 	args *structs.CASignRequest,
 	reply *structs.IssuedCert) error {
@@ -410,9 +479,10 @@ func (s *ConnectCA) Sign(
 	if err != nil {
 		return err
 	}
-	serviceID, ok := spiffeID.(*connect.SpiffeIDService)
-	if !ok {
-		return fmt.Errorf("SPIFFE ID in CSR must be a service ID")
+	serviceID, isService := spiffeID.(*connect.SpiffeIDService)
+	agentID, isAgent := spiffeID.(*connect.SpiffeIDAgent)
+	if !isService && !isAgent {
+		return fmt.Errorf("SPIFFE ID in CSR must be a service or agent ID")
 	}
 
 	provider, caRoot := s.srv.getCAProvider()
@@ -420,38 +490,77 @@ func (s *ConnectCA) Sign(
 		return fmt.Errorf("internal error: CA provider is nil")
 	}
 
-	// Verify that the CSR entity is in the cluster's trust domain
 	state := s.srv.fsm.State()
-	_, config, err := state.CAConfig()
+	_, config, err := state.CAConfig(nil)
 	if err != nil {
 		return err
 	}
 	signingID := connect.SpiffeIDSigningForCluster(config)
-	if !signingID.CanSign(serviceID) {
-		return fmt.Errorf("SPIFFE ID in CSR from a different trust domain: %s, "+
-			"we are %s", serviceID.Host, signingID.Host())
-	}
 
-	// Verify that the ACL token provided has permission to act as this service
 	rule, err := s.srv.ResolveToken(args.Token)
 	if err != nil {
 		return err
 	}
-	if rule != nil && !rule.ServiceWrite(serviceID.Service, nil) {
-		return acl.ErrPermissionDenied
-	}
-
-	// Verify that the DC in the service URI matches us. We might relax this
-	// requirement later but being restrictive for now is safer.
-	if serviceID.Datacenter != s.srv.config.Datacenter {
-		return fmt.Errorf("SPIFFE ID in CSR from a different datacenter: %s, "+
-			"we are %s", serviceID.Datacenter, s.srv.config.Datacenter)
-	}
 
 	commonCfg, err := config.GetCommonConfig()
 	if err != nil {
 		return err
 	}
+
+	if isService {
+		// Verify that the CSR entity is in the cluster's trust domain
+		if !signingID.CanSign(serviceID) {
+			return fmt.Errorf("SPIFFE ID in CSR from a different trust domain: %s, "+
+				"we are %s", serviceID.Host, signingID.Host())
+		}
+
+		// Verify that the ACL token provided has permission to act as this service
+		if rule != nil && !rule.ServiceWrite(serviceID.Service, nil) {
+			return acl.ErrPermissionDenied
+		}
+
+		// Verify that the DC in the service URI matches us. We might relax this
+		// requirement later but being restrictive for now is safer.
+		if serviceID.Datacenter != s.srv.config.Datacenter {
+			return fmt.Errorf("SPIFFE ID in CSR from a different datacenter: %s, "+
+				"we are %s", serviceID.Datacenter, s.srv.config.Datacenter)
+		}
+
+		// Verify that the DNS SANs are the ones of the service in the configured
+		// domains.
+		allowedDNSNames := make(map[string]bool)
+		for _, name := range connect.ServiceDNSNames(serviceID.Service, commonCfg.LeafCertDNSDomains) {
+			allowedDNSNames[name] = true
+		}
+		for _, name := range csr.DNSNames {
+			if !allowedDNSNames[strings.ToLower(name)] {
+				return fmt.Errorf("DNS SAN %q in CSR is not allowed for service %q",
+					name, serviceID.Service)
+			}
+		}
+	} else {
+		// Agents request their certificates before they know the trust
+		// domain, so it's filled in here.
+		if !signingID.CanSign(agentID) {
+			agentID.Host = signingID.Host()
+			csr.URIs = []*url.URL{agentID.URI()}
+		}
+
+		// Verify that the ACL token provided has permission to act as this agent
+		if rule != nil && !rule.NodeWrite(agentID.Agent, nil) {
+			return acl.ErrPermissionDenied
+		}
+
+		if agentID.Datacenter != s.srv.config.Datacenter {
+			return fmt.Errorf("SPIFFE ID in CSR from a different datacenter: %s, "+
+				"we are %s", agentID.Datacenter, s.srv.config.Datacenter)
+		}
+
+		if len(csr.DNSNames) > 0 || len(csr.IPAddresses) > 0 {
+			return fmt.Errorf("SANs in CSR are not allowed for agent %q", agentID.Agent)
+		}
+	}
+
 	if commonCfg.CSRMaxPerSecond > 0 {
 		lim := s.getCSRRateLimiterWithLimit(rate.Limit(commonCfg.CSRMaxPerSecond))
 		// Wait up to the small threshold we allow for a token.
@@ -529,8 +638,6 @@ func (s *ConnectCA) Sign(
 	*reply = structs.IssuedCert{
 		SerialNumber: connect.HexString(cert.SerialNumber.Bytes()),
 		CertPEM:      pem,
-		Service:      serviceID.Service,
-		ServiceURI:   cert.URIs[0].String(),
 		ValidAfter:   cert.NotBefore,
 		ValidBefore:  cert.NotAfter,
 		RaftIndex: structs.RaftIndex{
@@ -538,6 +645,13 @@ func (s *ConnectCA) Sign(
 			CreateIndex: modIdx,
 		},
 	}
+	if isService {
+		reply.Service = serviceID.Service
+		reply.ServiceURI = cert.URIs[0].String()
+	} else {
+		reply.Agent = agentID.Agent
+		reply.AgentURI = cert.URIs[0].String()
+	}
 
 	return nil
 }
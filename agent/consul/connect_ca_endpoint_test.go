@@ -47,12 +47,13 @@ func TestConnectCARoots(t *testing.T) {
 	ca1 := connect.TestCA(t, nil)
 	ca2 := connect.TestCA(t, nil)
 	ca2.Active = false
+	ca2.RotatedOutAt = time.Now().UTC().Truncate(time.Second)
 	idx, _, err := state.CARoots(nil)
 	require.NoError(err)
 	ok, err := state.CARootSetCAS(idx, idx, []*structs.CARoot{ca1, ca2})
 	assert.True(ok)
 	require.NoError(err)
-	_, caCfg, err := state.CAConfig()
+	_, caCfg, err := state.CAConfig(nil)
 	require.NoError(err)
 
 	// Request
@@ -69,6 +70,9 @@ func TestConnectCARoots(t *testing.T) {
 		// These must never be set, for security
 		assert.Equal("", r.SigningCert)
 		assert.Equal("", r.SigningKey)
+		if r.ID == ca2.ID {
+			assert.True(ca2.RotatedOutAt.Equal(r.RotatedOutAt))
+		}
 	}
 	assert.Equal(fmt.Sprintf("%s.consul", caCfg.ClusterID), reply.TrustDomain)
 }
@@ -138,6 +142,62 @@ func TestConnectCAConfig_GetSet(t *testing.T) {
 	}
 }
 
+func TestConnectCAConfig_Secrets(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+
+	vault := &structs.CAConfiguration{
+		Provider: structs.VaultCAProvider,
+		Config: map[string]interface{}{
+			"Address": "http://127.0.0.1:8200",
+			"token":   "secret",
+		},
+	}
+
+	// The token is redacted from a copy of the config.
+	redacted := redactCAConfig(vault)
+	require.Equal(map[string]interface{}{"Address": "http://127.0.0.1:8200"}, redacted.Config)
+	require.Equal("secret", vault.Config["token"])
+
+	// So is the private key of the consul provider.
+	consul := &structs.CAConfiguration{
+		Provider: structs.ConsulCAProvider,
+		Config: map[string]interface{}{
+			"PrivateKey":     "key",
+			"RotationPeriod": "2160h",
+		},
+	}
+	redacted = redactCAConfig(consul)
+	require.Equal(map[string]interface{}{"RotationPeriod": "2160h"}, redacted.Config)
+	require.Equal("key", consul.Config["PrivateKey"])
+
+	// The configs of the other providers are returned as-is.
+	other := &structs.CAConfiguration{
+		Provider: "other",
+		Config:   map[string]interface{}{"Token": "secret"},
+	}
+	require.Equal(other, redactCAConfig(other))
+
+	// A config read back without the token keeps the current one.
+	update := &structs.CAConfiguration{
+		Provider: structs.VaultCAProvider,
+		Config:   map[string]interface{}{"Address": "https://vault:8200"},
+	}
+	preserveCASecrets(update, vault)
+	require.Equal("secret", update.Config["token"])
+
+	// A new token replaces the current one.
+	update.Config = map[string]interface{}{"Token": "other"}
+	preserveCASecrets(update, vault)
+	require.Equal(map[string]interface{}{"Token": "other"}, update.Config)
+
+	// Nothing is kept when switching providers.
+	update = &structs.CAConfiguration{Provider: structs.ConsulCAProvider}
+	preserveCASecrets(update, vault)
+	require.Empty(update.Config)
+}
+
 func TestConnectCAConfig_TriggerRotation(t *testing.T) {
 	t.Parallel()
 
@@ -239,6 +299,10 @@ func TestConnectCAConfig_TriggerRotation(t *testing.T) {
 		expected, err := ca.ParseConsulCAConfig(newConfig.Config)
 		require.NoError(err)
 		assert.Equal(reply.Provider, newConfig.Provider)
+
+		// The private key is never returned.
+		assert.NotContains(reply.Config, "PrivateKey")
+		expected.PrivateKey = ""
 		assert.Equal(actual, expected)
 	}
 
@@ -349,6 +413,75 @@ func TestConnectCASign(t *testing.T) {
 	assert.Equal(spiffeId.URI().String(), reply.ServiceURI)
 }
 
+func TestConnectCASign_SANs(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.CAConfig.Config["TrustDomain"] = "example.org"
+		c.CAConfig.Config["LeafCertDNSDomains"] = []string{"svc.example.org", "service.consul"}
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// The roots report the trust domain and the DNS domains.
+	var roots structs.IndexedCARoots
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConnectCA.Roots",
+		&structs.DCSpecificRequest{Datacenter: "dc1"}, &roots))
+	require.Equal("example.org", roots.TrustDomain)
+	require.Equal([]string{"svc.example.org", "service.consul"}, roots.LeafCertDNSDomains)
+
+	// The root carries the trust domain.
+	root, err := connect.ParseCert(roots.Roots[0].RootCert)
+	require.NoError(err)
+	require.Len(root.URIs, 1)
+	require.Equal("spiffe://example.org", root.URIs[0].String())
+
+	sign := func(host string, dnsNames []string) (*structs.IssuedCert, error) {
+		spiffeID := &connect.SpiffeIDService{
+			Host:       host,
+			Namespace:  "default",
+			Datacenter: "dc1",
+			Service:    "web",
+		}
+		pk, _, err := connect.GeneratePrivateKey()
+		require.NoError(err)
+		csr, err := connect.CreateCSR(spiffeID, pk, dnsNames)
+		require.NoError(err)
+
+		var reply structs.IssuedCert
+		err = msgpackrpc.CallWithCodec(codec, "ConnectCA.Sign", &structs.CASignRequest{
+			Datacenter: "dc1",
+			CSR:        csr,
+		}, &reply)
+		return &reply, err
+	}
+
+	// A leaf in the trust domain with the DNS SANs of the service is signed.
+	dnsNames := connect.ServiceDNSNames("web", roots.LeafCertDNSDomains)
+	require.Equal([]string{"web.svc.example.org", "web.service.consul"}, dnsNames)
+	reply, err := sign("example.org", dnsNames)
+	require.NoError(err)
+	leaf, err := connect.ParseCert(reply.CertPEM)
+	require.NoError(err)
+	require.Equal("spiffe://example.org/ns/default/dc/dc1/svc/web", leaf.URIs[0].String())
+	require.Equal(dnsNames, leaf.DNSNames)
+
+	// The DNS SANs of another service are refused.
+	_, err = sign("example.org", []string{"db.svc.example.org"})
+	require.Error(err)
+	require.Contains(err.Error(), "not allowed for service")
+
+	// So are the SPIFFE IDs of the default trust domain.
+	_, err = sign(connect.TestClusterID+".consul", nil)
+	require.Error(err)
+	require.Contains(err.Error(), "different trust domain")
+}
+
 // Bench how long Signing RPC takes. This was used to ballpark reasonable
 // default rate limit to protect servers from thundering herds of signing
 // requests on root rotation.
@@ -0,0 +1,41 @@
+package consul
+
+import (
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+// ConnectChange manages the changelog of the intentions and config entries.
+type ConnectChange struct {
+	// srv is a pointer back to the server.
+	srv *Server
+}
+
+// List returns the changes of the intentions and config entries, from the
+// newest.
+func (c *ConnectChange) List(
+	args *structs.ConnectChangesRequest,
+	reply *structs.IndexedConnectChanges) error {
+	// Forward if necessary
+	if done, err := c.srv.forward("ConnectChange.List", args, args, reply); done {
+		return err
+	}
+
+	return c.srv.blockingQuery(
+		&args.QueryOptions, &reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, changes, err := state.ConnectChanges(ws, args.Kind, args.Name)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Changes = index, changes
+			if reply.Changes == nil {
+				reply.Changes = make(structs.ConnectChanges, 0)
+			}
+
+			return c.srv.filterACL(args.Token, reply)
+		},
+	)
+}
@@ -0,0 +1,100 @@
+package consul
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectChangeList(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Create and update an intention
+	ixn := structs.IntentionRequest{
+		Datacenter: "dc1",
+		Op:         structs.IntentionOpCreate,
+		Intention:  structs.TestIntention(t),
+	}
+	var id string
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Intention.Apply", &ixn, &id))
+	ixn.Op = structs.IntentionOpUpdate
+	ixn.Intention.ID = id
+	ixn.Intention.Action = structs.IntentionActionDeny
+	var reply string
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Intention.Apply", &ixn, &reply))
+
+	req := &structs.ConnectChangesRequest{
+		Datacenter: "dc1",
+		Kind:       structs.ConnectChangeIntention,
+		Name:       id,
+	}
+	var resp structs.IndexedConnectChanges
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConnectChange.List", req, &resp))
+	require.Len(resp.Changes, 2)
+	require.Equal(resp.Index, resp.Changes[0].Index)
+	require.Equal(structs.ConnectChangeUpdate, resp.Changes[0].Op)
+	require.Equal(structs.ConnectChangeCreate, resp.Changes[1].Op)
+	require.Empty(resp.Changes[0].Actor)
+	require.False(resp.Changes[0].Time.IsZero())
+}
+
+func TestConnectChangeList_acl(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Create an intention with the master token
+	ixn := structs.IntentionRequest{
+		Datacenter: "dc1",
+		Op:         structs.IntentionOpCreate,
+		Intention:  structs.TestIntention(t),
+	}
+	ixn.Intention.DestinationName = "foobar"
+	ixn.WriteRequest.Token = "root"
+	var id string
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Intention.Apply", &ixn, &id))
+
+	// The changes are hidden without a token
+	req := &structs.ConnectChangesRequest{
+		Datacenter: "dc1",
+	}
+	var resp structs.IndexedConnectChanges
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConnectChange.List", req, &resp))
+	require.Len(resp.Changes, 0)
+
+	// The actor is the accessor ID of the master token
+	_, token, err := s1.fsm.State().ACLTokenGetBySecret(nil, "root")
+	require.NoError(err)
+	require.NotNil(token)
+
+	req.Token = "root"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConnectChange.List", req, &resp))
+	require.Len(resp.Changes, 1)
+	require.Equal(id, resp.Changes[0].Name)
+	require.Equal(token.AccessorID, resp.Changes[0].Actor)
+}
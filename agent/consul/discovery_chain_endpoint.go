@@ -0,0 +1,102 @@
+package consul
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/discoverychain"
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+// DiscoveryChain compiles the discovery chains of the services.
+type DiscoveryChain struct {
+	// srv is a pointer back to the server.
+	srv *Server
+}
+
+// Get returns the compiled discovery chain of a service. Reading it requires
+// service read privileges on the service.
+func (c *DiscoveryChain) Get(
+	args *structs.DiscoveryChainRequest,
+	reply *structs.DiscoveryChainResponse) error {
+	// Forward if necessary
+	if done, err := c.srv.forward("DiscoveryChain.Get", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"discovery_chain", "get"}, time.Now())
+
+	if args.Name == "" {
+		return fmt.Errorf("Must provide a service name")
+	}
+
+	// Perform the ACL check
+	rule, err := c.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.ServiceRead(args.Name) {
+		return acl.ErrPermissionDenied
+	}
+
+	evalDC := args.EvaluateInDatacenter
+	if evalDC == "" {
+		evalDC = c.srv.config.Datacenter
+	}
+
+	return c.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, serviceEntry, err := state.ConfigEntry(ws, structs.ServiceDefaults, args.Name)
+			if err != nil {
+				return err
+			}
+			_, proxyEntry, err := state.ConfigEntry(ws, structs.ProxyDefaults, structs.ProxyConfigGlobal)
+			if err != nil {
+				return err
+			}
+			_, resolverEntry, err := state.ConfigEntry(ws, structs.ServiceResolver, args.Name)
+			if err != nil {
+				return err
+			}
+
+			req := discoverychain.CompileRequest{
+				ServiceName:            args.Name,
+				Datacenter:             evalDC,
+				OverrideProtocol:       args.OverrideProtocol,
+				OverrideConnectTimeout: args.OverrideConnectTimeout,
+			}
+			if entry, ok := serviceEntry.(*structs.ServiceConfigEntry); ok {
+				req.ServiceDefaults = entry
+			}
+			if entry, ok := proxyEntry.(*structs.ProxyConfigEntry); ok {
+				req.ProxyDefaults = entry
+			}
+			if entry, ok := resolverEntry.(*structs.ServiceResolverConfigEntry); ok {
+				req.ServiceResolver = entry
+
+				// The nearest datacenters are only known from the
+				// coordinates of the servers of this datacenter.
+				if f := entry.Failover; f != nil && (f.NearestN > 0 || f.AllDatacenters) {
+					dcs, err := c.srv.router.GetDatacentersByDistance()
+					if err != nil {
+						return err
+					}
+					req.DatacentersByDistance = dcs
+				}
+			}
+
+			chain, err := discoverychain.Compile(req)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Chain = index, chain
+			return nil
+		},
+	)
+}
@@ -0,0 +1,158 @@
+package consul
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoveryChain_Get(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	args := structs.DiscoveryChainRequest{
+		Datacenter: "dc1",
+		Name:       "web",
+	}
+	var resp structs.DiscoveryChainResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "DiscoveryChain.Get", &args, &resp))
+	require.Equal("web", resp.Chain.ServiceName)
+	require.Equal("dc1", resp.Chain.Datacenter)
+	require.Equal("tcp", resp.Chain.Protocol)
+	require.Empty(resp.Chain.CustomizationHash)
+	require.Contains(resp.Chain.Targets, "web.dc1")
+	require.Equal("web.dc1", resp.Chain.Nodes[resp.Chain.StartNode].Resolver.Target)
+
+	// Set the protocol with the proxy defaults
+	apply := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Op:         structs.ConfigEntryUpsert,
+		Entry: &structs.ProxyConfigEntry{
+			Name:   structs.ProxyConfigGlobal,
+			Config: map[string]interface{}{"protocol": "http"},
+		},
+	}
+	var out struct{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &apply, &out))
+
+	require.NoError(msgpackrpc.CallWithCodec(codec, "DiscoveryChain.Get", &args, &resp))
+	require.Equal("http", resp.Chain.Protocol)
+	require.True(resp.Index > 0)
+
+	// Compile with overrides for another datacenter
+	args.EvaluateInDatacenter = "dc2"
+	args.OverrideProtocol = "grpc"
+	args.OverrideConnectTimeout = 10 * time.Second
+	require.NoError(msgpackrpc.CallWithCodec(codec, "DiscoveryChain.Get", &args, &resp))
+	require.Equal("grpc", resp.Chain.Protocol)
+	require.NotEmpty(resp.Chain.CustomizationHash)
+	require.Contains(resp.Chain.Targets, "web.dc2")
+	resolver := resp.Chain.Nodes[resp.Chain.StartNode].Resolver
+	require.False(resolver.Default)
+	require.Equal(10*time.Second, resolver.ConnectTimeout)
+
+	// The name is required
+	args.Name = ""
+	require.Error(msgpackrpc.CallWithCodec(codec, "DiscoveryChain.Get", &args, &resp))
+}
+
+func TestDiscoveryChain_Get_Failover(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	apply := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Op:         structs.ConfigEntryUpsert,
+		Entry: &structs.ServiceResolverConfigEntry{
+			Name:           "web",
+			ConnectTimeout: 2 * time.Second,
+			Failover: &structs.ServiceResolverFailover{
+				NearestN:    1,
+				Datacenters: []string{"dc3"},
+			},
+		},
+	}
+	var out struct{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ConfigEntry.Apply", &apply, &out))
+
+	// The only datacenter known to the servers is their own, so only the
+	// fixed list is failed over to.
+	args := structs.DiscoveryChainRequest{
+		Datacenter: "dc1",
+		Name:       "web",
+	}
+	var resp structs.DiscoveryChainResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "DiscoveryChain.Get", &args, &resp))
+	resolver := resp.Chain.Nodes[resp.Chain.StartNode].Resolver
+	require.False(resolver.Default)
+	require.Equal(2*time.Second, resolver.ConnectTimeout)
+	require.NotNil(resolver.Failover)
+	require.Equal([]string{"web.dc3"}, resolver.Failover.Targets)
+	require.Contains(resp.Chain.Targets, "web.dc3")
+}
+
+func TestDiscoveryChain_Get_ACLDeny(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Create a token with read privileges on the web service only
+	var token string
+	require.NoError(msgpackrpc.CallWithCodec(codec, "ACL.Apply", &structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:  "User token",
+			Type:  structs.ACLTokenTypeClient,
+			Rules: `service "web" { policy = "read" }`,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}, &token))
+
+	args := structs.DiscoveryChainRequest{
+		Datacenter:   "dc1",
+		Name:         "web",
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	var resp structs.DiscoveryChainResponse
+	require.NoError(msgpackrpc.CallWithCodec(codec, "DiscoveryChain.Get", &args, &resp))
+	require.Equal("web", resp.Chain.ServiceName)
+
+	args.Name = "db"
+	err := msgpackrpc.CallWithCodec(codec, "DiscoveryChain.Get", &args, &resp)
+	require.True(acl.IsErrPermissionDenied(err))
+}
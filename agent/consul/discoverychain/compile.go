@@ -0,0 +1,189 @@
+// Package discoverychain compiles the config entries of a service into the
+// graph describing how the traffic sent to it is routed and resolved.
+package discoverychain
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// CompileRequest holds the inputs of Compile.
+type CompileRequest struct {
+	ServiceName string
+
+	// Datacenter is the datacenter the chain is evaluated in.
+	Datacenter string
+
+	// OverrideProtocol and OverrideConnectTimeout replace the values set by
+	// the config entries when set.
+	OverrideProtocol       string
+	OverrideConnectTimeout time.Duration
+
+	// ServiceDefaults and ProxyDefaults are the config entries of the
+	// service and the global proxy defaults. Either may be nil.
+	ServiceDefaults *structs.ServiceConfigEntry
+	ProxyDefaults   *structs.ProxyConfigEntry
+
+	// ServiceResolver is the resolver config entry of the service, if any.
+	ServiceResolver *structs.ServiceResolverConfigEntry
+
+	// DatacentersByDistance are the federated datacenters, the nearest
+	// first. They are only needed to fail over to the nearest datacenters.
+	DatacentersByDistance []string
+}
+
+// Compile returns the discovery chain of the service of the request.
+//
+// The chain is always a single resolver sending the traffic to the service in
+// the evaluated datacenter, failing over to the service in the datacenters of
+// the service resolver, if any.
+func Compile(req CompileRequest) (*structs.CompiledDiscoveryChain, error) {
+	if req.ServiceName == "" {
+		return nil, fmt.Errorf("ServiceName is required")
+	}
+	if req.Datacenter == "" {
+		return nil, fmt.Errorf("Datacenter is required")
+	}
+	if req.OverrideConnectTimeout < 0 {
+		return nil, fmt.Errorf("OverrideConnectTimeout must not be negative")
+	}
+
+	protocol := structs.DefaultServiceProtocol
+	if p, ok := proxyDefaultsProtocol(req.ProxyDefaults); ok {
+		protocol = p
+	}
+	if req.ServiceDefaults != nil && req.ServiceDefaults.Protocol != "" {
+		protocol = req.ServiceDefaults.Protocol
+	}
+	if req.OverrideProtocol != "" {
+		protocol = req.OverrideProtocol
+	}
+	protocol = strings.ToLower(protocol)
+
+	resolver := &structs.DiscoveryResolver{
+		Default:        true,
+		ConnectTimeout: structs.DefaultConnectTimeout,
+	}
+	if req.ServiceResolver != nil {
+		resolver.Default = false
+		if req.ServiceResolver.ConnectTimeout > 0 {
+			resolver.ConnectTimeout = req.ServiceResolver.ConnectTimeout
+		}
+	}
+	if req.OverrideConnectTimeout > 0 {
+		resolver.Default = false
+		resolver.ConnectTimeout = req.OverrideConnectTimeout
+	}
+
+	// The mode of the service defaults takes precedence over the one of the
+	// proxy defaults.
+	var meshGateway structs.MeshGatewayConfig
+	if req.ServiceDefaults != nil && !req.ServiceDefaults.MeshGateway.IsZero() {
+		meshGateway = req.ServiceDefaults.MeshGateway
+	} else if req.ProxyDefaults != nil {
+		meshGateway = req.ProxyDefaults.MeshGateway
+	}
+
+	target := structs.NewDiscoveryTarget(req.ServiceName, req.Datacenter)
+	target.MeshGateway = meshGateway
+	resolver.Target = target.ID
+	targets := map[string]*structs.DiscoveryTarget{
+		target.ID: target,
+	}
+
+	if req.ServiceResolver != nil && !req.ServiceResolver.Failover.IsZero() {
+		failover := &structs.DiscoveryFailover{}
+		for _, dc := range failoverDatacenters(req.ServiceResolver.Failover, req.Datacenter, req.DatacentersByDistance) {
+			t := structs.NewDiscoveryTarget(req.ServiceName, dc)
+			t.MeshGateway = meshGateway
+			targets[t.ID] = t
+			failover.Targets = append(failover.Targets, t.ID)
+		}
+		if len(failover.Targets) > 0 {
+			resolver.Failover = failover
+		}
+	}
+
+	node := &structs.DiscoveryGraphNode{
+		Type:     structs.DiscoveryGraphNodeTypeResolver,
+		Name:     structs.DiscoveryGraphNodeTypeResolver + ":" + target.ID,
+		Resolver: resolver,
+	}
+
+	return &structs.CompiledDiscoveryChain{
+		ServiceName:       req.ServiceName,
+		Datacenter:        req.Datacenter,
+		CustomizationHash: customizationHash(req),
+		Protocol:          protocol,
+		StartNode:         node.Name,
+		Nodes: map[string]*structs.DiscoveryGraphNode{
+			node.Name: node,
+		},
+		Targets: targets,
+	}, nil
+}
+
+// failoverDatacenters returns the datacenters to fail over to, in order,
+// never including the datacenter of the chain nor a datacenter more than
+// once. Like the failover of the prepared queries, the NearestN datacenters
+// come first, then the fixed list.
+func failoverDatacenters(f *structs.ServiceResolverFailover, local string, byDistance []string) []string {
+	var dcs []string
+	seen := map[string]bool{local: true}
+	add := func(dc string) {
+		if !seen[dc] {
+			seen[dc] = true
+			dcs = append(dcs, dc)
+		}
+	}
+
+	nearest := 0
+	for _, dc := range byDistance {
+		if !f.AllDatacenters && nearest >= f.NearestN {
+			break
+		}
+		if !seen[dc] {
+			add(dc)
+			nearest++
+		}
+	}
+	for _, dc := range f.Datacenters {
+		add(dc)
+	}
+	return dcs
+}
+
+// proxyDefaultsProtocol returns the protocol set in the opaque config of the
+// proxy defaults, if any.
+func proxyDefaultsProtocol(entry *structs.ProxyConfigEntry) (string, bool) {
+	if entry == nil {
+		return "", false
+	}
+
+	// The values decoded from msgpack are raw bytes rather than strings.
+	switch v := entry.Config["protocol"].(type) {
+	case string:
+		return v, v != ""
+	case []byte:
+		return string(v), len(v) > 0
+	default:
+		return "", false
+	}
+}
+
+// customizationHash returns a short hash of the overrides of the request, or
+// an empty string if there are none.
+func customizationHash(req CompileRequest) string {
+	if req.OverrideProtocol == "" && req.OverrideConnectTimeout == 0 {
+		return ""
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "protocol=%s\n", strings.ToLower(req.OverrideProtocol))
+	fmt.Fprintf(h, "connect-timeout=%s\n", req.OverrideConnectTimeout)
+	return fmt.Sprintf("%x", h.Sum(nil))[:8]
+}
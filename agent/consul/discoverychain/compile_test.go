@@ -0,0 +1,278 @@
+package discoverychain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	t.Parallel()
+
+	defaultChain := func(protocol string) *structs.CompiledDiscoveryChain {
+		return &structs.CompiledDiscoveryChain{
+			ServiceName: "web",
+			Datacenter:  "dc1",
+			Protocol:    protocol,
+			StartNode:   "resolver:web.dc1",
+			Nodes: map[string]*structs.DiscoveryGraphNode{
+				"resolver:web.dc1": &structs.DiscoveryGraphNode{
+					Type: structs.DiscoveryGraphNodeTypeResolver,
+					Name: "resolver:web.dc1",
+					Resolver: &structs.DiscoveryResolver{
+						Default:        true,
+						ConnectTimeout: structs.DefaultConnectTimeout,
+						Target:         "web.dc1",
+					},
+				},
+			},
+			Targets: map[string]*structs.DiscoveryTarget{
+				"web.dc1": &structs.DiscoveryTarget{
+					ID:         "web.dc1",
+					Service:    "web",
+					Datacenter: "dc1",
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		name   string
+		req    CompileRequest
+		expect func() *structs.CompiledDiscoveryChain
+		err    string
+	}{
+		{
+			name:   "no config entries",
+			req:    CompileRequest{ServiceName: "web", Datacenter: "dc1"},
+			expect: func() *structs.CompiledDiscoveryChain { return defaultChain("tcp") },
+		},
+		{
+			name: "proxy defaults protocol",
+			req: CompileRequest{
+				ServiceName: "web",
+				Datacenter:  "dc1",
+				ProxyDefaults: &structs.ProxyConfigEntry{
+					Kind:   structs.ProxyDefaults,
+					Name:   structs.ProxyConfigGlobal,
+					Config: map[string]interface{}{"protocol": []byte("http")},
+				},
+			},
+			expect: func() *structs.CompiledDiscoveryChain { return defaultChain("http") },
+		},
+		{
+			name: "service defaults protocol wins",
+			req: CompileRequest{
+				ServiceName: "web",
+				Datacenter:  "dc1",
+				ServiceDefaults: &structs.ServiceConfigEntry{
+					Kind:     structs.ServiceDefaults,
+					Name:     "web",
+					Protocol: "GRPC",
+				},
+				ProxyDefaults: &structs.ProxyConfigEntry{
+					Kind:   structs.ProxyDefaults,
+					Name:   structs.ProxyConfigGlobal,
+					Config: map[string]interface{}{"protocol": "http"},
+				},
+			},
+			expect: func() *structs.CompiledDiscoveryChain { return defaultChain("grpc") },
+		},
+		{
+			name: "overrides",
+			req: CompileRequest{
+				ServiceName: "web",
+				Datacenter:  "dc1",
+				ServiceDefaults: &structs.ServiceConfigEntry{
+					Kind:     structs.ServiceDefaults,
+					Name:     "web",
+					Protocol: "http",
+				},
+				OverrideProtocol:       "http2",
+				OverrideConnectTimeout: 33 * time.Second,
+			},
+			expect: func() *structs.CompiledDiscoveryChain {
+				chain := defaultChain("http2")
+				chain.CustomizationHash = customizationHash(CompileRequest{
+					OverrideProtocol:       "http2",
+					OverrideConnectTimeout: 33 * time.Second,
+				})
+				resolver := chain.Nodes["resolver:web.dc1"].Resolver
+				resolver.Default = false
+				resolver.ConnectTimeout = 33 * time.Second
+				return chain
+			},
+		},
+		{
+			name: "proxy defaults mesh gateway mode",
+			req: CompileRequest{
+				ServiceName: "web",
+				Datacenter:  "dc1",
+				ProxyDefaults: &structs.ProxyConfigEntry{
+					Kind:        structs.ProxyDefaults,
+					Name:        structs.ProxyConfigGlobal,
+					MeshGateway: structs.MeshGatewayConfig{Mode: structs.MeshGatewayModeRemote},
+				},
+			},
+			expect: func() *structs.CompiledDiscoveryChain {
+				chain := defaultChain("tcp")
+				chain.Targets["web.dc1"].MeshGateway.Mode = structs.MeshGatewayModeRemote
+				return chain
+			},
+		},
+		{
+			name: "service defaults mesh gateway mode wins",
+			req: CompileRequest{
+				ServiceName: "web",
+				Datacenter:  "dc1",
+				ServiceDefaults: &structs.ServiceConfigEntry{
+					Kind:        structs.ServiceDefaults,
+					Name:        "web",
+					MeshGateway: structs.MeshGatewayConfig{Mode: structs.MeshGatewayModeLocal},
+				},
+				ProxyDefaults: &structs.ProxyConfigEntry{
+					Kind:        structs.ProxyDefaults,
+					Name:        structs.ProxyConfigGlobal,
+					MeshGateway: structs.MeshGatewayConfig{Mode: structs.MeshGatewayModeRemote},
+				},
+			},
+			expect: func() *structs.CompiledDiscoveryChain {
+				chain := defaultChain("tcp")
+				chain.Targets["web.dc1"].MeshGateway.Mode = structs.MeshGatewayModeLocal
+				return chain
+			},
+		},
+		{
+			name: "service resolver connect timeout",
+			req: CompileRequest{
+				ServiceName: "web",
+				Datacenter:  "dc1",
+				ServiceResolver: &structs.ServiceResolverConfigEntry{
+					Kind:           structs.ServiceResolver,
+					Name:           "web",
+					ConnectTimeout: 2 * time.Second,
+				},
+			},
+			expect: func() *structs.CompiledDiscoveryChain {
+				chain := defaultChain("tcp")
+				chain.Nodes["resolver:web.dc1"].Resolver.Default = false
+				chain.Nodes["resolver:web.dc1"].Resolver.ConnectTimeout = 2 * time.Second
+				return chain
+			},
+		},
+		{
+			name: "service resolver failover",
+			req: CompileRequest{
+				ServiceName: "web",
+				Datacenter:  "dc1",
+				ServiceDefaults: &structs.ServiceConfigEntry{
+					Kind:        structs.ServiceDefaults,
+					Name:        "web",
+					MeshGateway: structs.MeshGatewayConfig{Mode: structs.MeshGatewayModeRemote},
+				},
+				ServiceResolver: &structs.ServiceResolverConfigEntry{
+					Kind: structs.ServiceResolver,
+					Name: "web",
+					Failover: &structs.ServiceResolverFailover{
+						NearestN:    1,
+						Datacenters: []string{"dc4", "dc2", "dc1"},
+					},
+				},
+				DatacentersByDistance: []string{"dc1", "dc2", "dc3", "dc4"},
+			},
+			expect: func() *structs.CompiledDiscoveryChain {
+				chain := defaultChain("tcp")
+				resolver := chain.Nodes["resolver:web.dc1"].Resolver
+				resolver.Default = false
+				resolver.Failover = &structs.DiscoveryFailover{
+					Targets: []string{"web.dc2", "web.dc4"},
+				}
+				for _, dc := range []string{"dc1", "dc2", "dc4"} {
+					target := structs.NewDiscoveryTarget("web", dc)
+					target.MeshGateway.Mode = structs.MeshGatewayModeRemote
+					chain.Targets[target.ID] = target
+				}
+				return chain
+			},
+		},
+		{
+			name: "service resolver failover to all datacenters",
+			req: CompileRequest{
+				ServiceName: "web",
+				Datacenter:  "dc2",
+				ServiceResolver: &structs.ServiceResolverConfigEntry{
+					Kind:     structs.ServiceResolver,
+					Name:     "web",
+					Failover: &structs.ServiceResolverFailover{AllDatacenters: true},
+				},
+				DatacentersByDistance: []string{"dc1", "dc2", "dc3"},
+			},
+			expect: func() *structs.CompiledDiscoveryChain {
+				target := structs.NewDiscoveryTarget("web", "dc2")
+				return &structs.CompiledDiscoveryChain{
+					ServiceName: "web",
+					Datacenter:  "dc2",
+					Protocol:    "tcp",
+					StartNode:   "resolver:web.dc2",
+					Nodes: map[string]*structs.DiscoveryGraphNode{
+						"resolver:web.dc2": &structs.DiscoveryGraphNode{
+							Type: structs.DiscoveryGraphNodeTypeResolver,
+							Name: "resolver:web.dc2",
+							Resolver: &structs.DiscoveryResolver{
+								ConnectTimeout: structs.DefaultConnectTimeout,
+								Target:         target.ID,
+								Failover: &structs.DiscoveryFailover{
+									Targets: []string{"web.dc1", "web.dc3"},
+								},
+							},
+						},
+					},
+					Targets: map[string]*structs.DiscoveryTarget{
+						"web.dc1": structs.NewDiscoveryTarget("web", "dc1"),
+						"web.dc2": target,
+						"web.dc3": structs.NewDiscoveryTarget("web", "dc3"),
+					},
+				}
+			},
+		},
+		{
+			name: "missing service name",
+			req:  CompileRequest{Datacenter: "dc1"},
+			err:  "ServiceName is required",
+		},
+		{
+			name: "missing datacenter",
+			req:  CompileRequest{ServiceName: "web"},
+			err:  "Datacenter is required",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			chain, err := Compile(tc.req)
+			if tc.err != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect(), chain)
+		})
+	}
+}
+
+func TestCompile_customizationHash(t *testing.T) {
+	t.Parallel()
+
+	require.Empty(t, customizationHash(CompileRequest{}))
+
+	h1 := customizationHash(CompileRequest{OverrideProtocol: "http"})
+	h2 := customizationHash(CompileRequest{OverrideProtocol: "HTTP"})
+	h3 := customizationHash(CompileRequest{OverrideConnectTimeout: time.Second})
+	require.Len(t, h1, 8)
+	require.Equal(t, h1, h2)
+	require.NotEqual(t, h1, h3)
+}
@@ -0,0 +1,182 @@
+package consul
+
+import (
+	"fmt"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/golang-lru"
+)
+
+// filterMemoKey identifies a memoized result. The index of the result is
+// part of the key, so the memoized results never have to be invalidated:
+// the results of older indexes are just evicted once they stop being used.
+type filterMemoKey struct {
+	// query identifies the endpoint and the arguments of the query, other
+	// than the token.
+	query string
+
+	// index is the index of the result.
+	index uint64
+
+	// scope identifies the read permissions of the token the result was
+	// filtered for.
+	scope string
+
+	// enforceVersion8 is the ACL enforcement the result was filtered with.
+	enforceVersion8 bool
+}
+
+// filterMemo memoizes the results of the catalog and health list queries
+// once filtered by the ACLs, so the many blocking queries watching the same
+// result with tokens linked to the same policies filter it only once for
+// each change.
+//
+// The elements of the memoized results are shared between the requests and
+// must not be modified, like the results returned by the state store.
+type filterMemo struct {
+	results *lru.Cache
+}
+
+// newFilterMemo returns a memo holding up to the given number of results.
+func newFilterMemo(size int) (*filterMemo, error) {
+	results, err := lru.NewWithEvict(size, func(interface{}, interface{}) {
+		metrics.IncrCounter([]string{"acl", "filter_memo", "evict"}, 1)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &filterMemo{results: results}, nil
+}
+
+// filterACLMemoized applies the ACL policy of the token to the result of a
+// list query like filterACL, but reuses the result memoized for the same
+// query at the same index with the same ACL scope, if any. The query string
+// must identify both the endpoint and all the arguments affecting the
+// result. An empty query
+// skips the memo, as when the result is sorted for the source of the query.
+func (s *Server) filterACLMemoized(query string, token string, reply interface{}) error {
+	if s.filterMemo == nil || query == "" {
+		return s.filterACL(token, reply)
+	}
+
+	authorizer, scope, err := s.acls.ResolveTokenToScope(token)
+	if err != nil {
+		return err
+	}
+
+	// There is nothing to filter or share when ACLs are not enabled.
+	if authorizer == nil {
+		return nil
+	}
+	if scope == "" {
+		return s.filterACLWithAuthorizer(authorizer, reply)
+	}
+
+	key, err := newFilterMemoKey(query, scope, s.config.ACLEnforceVersion8, reply)
+	if err != nil {
+		return err
+	}
+
+	if result, ok := s.filterMemo.results.Get(key); ok {
+		metrics.IncrCounter([]string{"acl", "filter_memo", "hit"}, 1)
+		return setFilterMemoResult(reply, result)
+	}
+	metrics.IncrCounter([]string{"acl", "filter_memo", "miss"}, 1)
+
+	if err := s.filterACLWithAuthorizer(authorizer, reply); err != nil {
+		return err
+	}
+	result := filterMemoResult(reply)
+	s.filterMemo.results.Add(key, result)
+	return setFilterMemoResult(reply, result)
+}
+
+// filterMemoQuery returns the memo query string of a request to the given
+// endpoint, or an empty string if its result can't be memoized. The results
+// sorted by distance from the source node differ for each source and so are
+// never memoized.
+func filterMemoQuery(method string, source structs.QuerySource, req cache.Request) string {
+	if source.Node != "" {
+		return ""
+	}
+
+	// The cache key covers all the arguments but the datacenter and the
+	// token. The query is always answered in the local datacenter here, and
+	// the token is accounted for by the scope.
+	info := req.CacheInfo()
+	if info.Key == "" {
+		return ""
+	}
+	return method + "/" + info.Key
+}
+
+// newFilterMemoKey returns the memo key of the given reply.
+func newFilterMemoKey(query, scope string, enforceVersion8 bool, reply interface{}) (filterMemoKey, error) {
+	key := filterMemoKey{query: query, scope: scope, enforceVersion8: enforceVersion8}
+	switch v := reply.(type) {
+	case *structs.IndexedCheckServiceNodes:
+		key.index = v.Index
+	case *structs.IndexedHealthChecks:
+		key.index = v.Index
+	case *structs.IndexedNodes:
+		key.index = v.Index
+	case *structs.IndexedServiceNodes:
+		key.index = v.Index
+	case *structs.IndexedServices:
+		key.index = v.Index
+	default:
+		return key, fmt.Errorf("Unhandled type passed to ACL filter memo: %#v", reply)
+	}
+	return key, nil
+}
+
+// filterMemoResult returns the result held by the reply.
+func filterMemoResult(reply interface{}) interface{} {
+	switch v := reply.(type) {
+	case *structs.IndexedCheckServiceNodes:
+		return v.Nodes
+	case *structs.IndexedHealthChecks:
+		return v.HealthChecks
+	case *structs.IndexedNodes:
+		return v.Nodes
+	case *structs.IndexedServiceNodes:
+		return v.ServiceNodes
+	case *structs.IndexedServices:
+		return v.Services
+	}
+	return nil
+}
+
+// setFilterMemoResult replaces the result held by the reply with a copy of
+// the given memoized one. Only the list itself is copied, since the callers
+// commonly filter and shuffle it in place, but not its elements.
+func setFilterMemoResult(reply interface{}, result interface{}) error {
+	switch v := reply.(type) {
+	case *structs.IndexedCheckServiceNodes:
+		nodes := result.(structs.CheckServiceNodes)
+		v.Nodes = append(structs.CheckServiceNodes(nil), nodes...)
+	case *structs.IndexedHealthChecks:
+		checks := result.(structs.HealthChecks)
+		v.HealthChecks = append(structs.HealthChecks(nil), checks...)
+	case *structs.IndexedNodes:
+		nodes := result.(structs.Nodes)
+		v.Nodes = append(structs.Nodes(nil), nodes...)
+	case *structs.IndexedServiceNodes:
+		nodes := result.(structs.ServiceNodes)
+		v.ServiceNodes = append(structs.ServiceNodes(nil), nodes...)
+	case *structs.IndexedServices:
+		services := result.(structs.Services)
+		v.Services = nil
+		if services != nil {
+			v.Services = make(structs.Services, len(services))
+			for name, tags := range services {
+				v.Services[name] = tags
+			}
+		}
+	default:
+		return fmt.Errorf("Unhandled type passed to ACL filter memo: %#v", reply)
+	}
+	return nil
+}
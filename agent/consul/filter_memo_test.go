@@ -0,0 +1,176 @@
+package consul
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+)
+
+func TestServer_FilterACLMemoized(t *testing.T) {
+	t.Parallel()
+	dir, token, srv, codec := testACLFilterServer(t)
+	defer os.RemoveAll(dir)
+	defer srv.Shutdown()
+	defer codec.Close()
+	testrpc.WaitForTestAgent(t, srv.RPC, "dc1")
+
+	// Create a token with the same rules, and one with different rules
+	createToken := func(rules string) string {
+		arg := structs.ACLRequest{
+			Datacenter: "dc1",
+			Op:         structs.ACLSet,
+			ACL: structs.ACL{
+				Name:  "User token",
+				Type:  structs.ACLTokenTypeClient,
+				Rules: rules,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out string
+		if err := msgpackrpc.CallWithCodec(codec, "ACL.Apply", &arg, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return out
+	}
+	same := createToken(`
+service "foo" {
+	policy = "write"
+}
+`)
+	other := createToken(`
+service "bar" {
+	policy = "read"
+}
+`)
+
+	listServices := func(token string, source structs.QuerySource) structs.Services {
+		args := structs.DCSpecificRequest{
+			Datacenter:   "dc1",
+			Source:       source,
+			QueryOptions: structs.QueryOptions{Token: token},
+		}
+		// Use the in-memory RPC, which shares the replies with the server.
+		var out structs.IndexedServices
+		if err := srv.RPC("Catalog.ListServices", &args, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return out.Services
+	}
+	expectServices := func(services structs.Services, names ...string) {
+		t.Helper()
+		if len(services) != len(names) {
+			t.Fatalf("bad: %#v", services)
+		}
+		for _, name := range names {
+			if _, ok := services[name]; !ok {
+				t.Fatalf("bad: %#v", services)
+			}
+		}
+	}
+	// Waiting for the agent already memoized some results.
+	base := srv.filterMemo.results.Len()
+	expectMemoized := func(n int) {
+		t.Helper()
+		if l := srv.filterMemo.results.Len() - base; l != n {
+			t.Fatalf("bad: %d", l)
+		}
+	}
+
+	// The first request memoizes its result.
+	expectServices(listServices(token, structs.QuerySource{}), "consul", "foo")
+	expectMemoized(1)
+
+	// A token linked to the same policy shares it, and changes made to the
+	// reply don't leak into the memo.
+	services := listServices(same, structs.QuerySource{})
+	expectServices(services, "consul", "foo")
+	expectMemoized(1)
+	delete(services, "foo")
+	expectServices(listServices(token, structs.QuerySource{}), "consul", "foo")
+
+	// A token with different permissions gets its own result.
+	expectServices(listServices(other, structs.QuerySource{}), "consul", "bar")
+	expectMemoized(2)
+
+	// Results sorted for a source node are not memoized.
+	source := structs.QuerySource{Datacenter: "dc1", Node: srv.config.NodeName}
+	expectServices(listServices(token, source), "consul", "foo")
+	expectMemoized(2)
+
+	// The other endpoints are memoized separately.
+	args := structs.ServiceSpecificRequest{
+		Datacenter:   "dc1",
+		ServiceName:  "foo",
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+	var nodes structs.IndexedCheckServiceNodes
+	if err := msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &args, &nodes); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(nodes.Nodes) != 1 || nodes.Nodes[0].Service.Service != "foo" {
+		t.Fatalf("bad: %#v", nodes.Nodes)
+	}
+	expectMemoized(3)
+
+	args.Token = other
+	nodes = structs.IndexedCheckServiceNodes{}
+	if err := msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &args, &nodes); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(nodes.Nodes) != 0 {
+		t.Fatalf("bad: %#v", nodes.Nodes)
+	}
+	expectMemoized(4)
+
+	// A new index gets a new result.
+	reg := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       srv.config.NodeName,
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			ID:      "foo2",
+			Service: "foo",
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &reg, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expectServices(listServices(same, structs.QuerySource{}), "consul", "foo")
+	expectMemoized(5)
+}
+
+func TestServer_FilterACLMemoized_disabled(t *testing.T) {
+	t.Parallel()
+	dir, srv := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+		c.ACLFilterMemoSize = 0
+	})
+	defer os.RemoveAll(dir)
+	defer srv.Shutdown()
+	codec := rpcClient(t, srv)
+	defer codec.Close()
+	testrpc.WaitForLeader(t, srv.RPC, "dc1")
+
+	if srv.filterMemo != nil {
+		t.Fatalf("bad: %#v", srv.filterMemo)
+	}
+
+	args := structs.DCSpecificRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{Token: "root"},
+	}
+	var out structs.IndexedServices
+	if err := msgpackrpc.CallWithCodec(codec, "Catalog.ListServices", &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if _, ok := out.Services["consul"]; !ok {
+		t.Fatalf("bad: %#v", out.Services)
+	}
+}
@@ -30,6 +30,7 @@ func init() {
 	registerCommand(structs.ACLPolicyDeleteRequestType, (*FSM).applyACLPolicyDeleteOperation)
 	registerCommand(structs.ConnectCALeafRequestType, (*FSM).applyConnectCALeafOperation)
 	registerCommand(structs.ConfigEntryRequestType, (*FSM).applyConfigEntryOperation)
+	registerCommand(structs.PreparedQueryStatsRequestType, (*FSM).applyPreparedQueryStats)
 }
 
 func (c *FSM) applyRegister(buf []byte, index uint64) interface{} {
@@ -283,10 +284,8 @@ func (c *FSM) applyIntentionOperation(buf []byte, index uint64) interface{} {
 	defer metrics.MeasureSinceWithLabels([]string{"fsm", "intention"}, time.Now(),
 		[]metrics.Label{{Name: "op", Value: string(req.Op)}})
 	switch req.Op {
-	case structs.IntentionOpCreate, structs.IntentionOpUpdate:
-		return c.state.IntentionSet(index, req.Intention)
-	case structs.IntentionOpDelete:
-		return c.state.IntentionDelete(index, req.Intention.ID)
+	case structs.IntentionOpCreate, structs.IntentionOpUpdate, structs.IntentionOpDelete:
+		return c.state.IntentionApply(index, &req)
 	default:
 		c.logger.Printf("[WARN] consul.fsm: Invalid Intention operation '%s'", req.Op)
 		return fmt.Errorf("Invalid Intention operation '%s'", req.Op)
@@ -442,12 +441,22 @@ func (c *FSM) applyConfigEntryOperation(buf []byte, index uint64) interface{} {
 	case structs.ConfigEntryUpsert:
 		defer metrics.MeasureSinceWithLabels([]string{"fsm", "config_entry", req.Entry.GetKind()}, time.Now(),
 			[]metrics.Label{{Name: "op", Value: "upsert"}})
-		return c.state.EnsureConfigEntry(index, req.Entry)
+		return c.state.ConfigEntryApply(index, &req)
 	case structs.ConfigEntryDelete:
 		defer metrics.MeasureSinceWithLabels([]string{"fsm", "config_entry", req.Entry.GetKind()}, time.Now(),
 			[]metrics.Label{{Name: "op", Value: "delete"}})
-		return c.state.DeleteConfigEntry(index, req.Entry.GetKind(), req.Entry.GetName())
+		return c.state.ConfigEntryApply(index, &req)
 	default:
 		return fmt.Errorf("invalid config entry operation type: %v", req.Op)
 	}
 }
+
+func (c *FSM) applyPreparedQueryStats(buf []byte, index uint64) interface{} {
+	defer metrics.MeasureSince([]string{"fsm", "prepared-query", "stats"}, time.Now())
+	var req structs.PreparedQueryStatsRequest
+	if err := structs.Decode(buf, &req); err != nil {
+		panic(fmt.Errorf("failed to decode request: %v", err))
+	}
+
+	return c.state.PreparedQueryStatsApply(index, req.Stats)
+}
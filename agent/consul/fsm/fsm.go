@@ -145,48 +145,65 @@ func (c *FSM) Restore(old io.ReadCloser) error {
 	restore := stateNew.Restore()
 	defer restore.Abort()
 
+	// Populate the new state
+	var lastIndex uint64
+	handler := func(header *SnapshotHeader, msg structs.MessageType, dec *codec.Decoder) error {
+		lastIndex = header.LastIndex
+		fn := restorers[msg]
+		if fn == nil {
+			return fmt.Errorf("Unrecognized msg type %d", msg)
+		}
+		return fn(header, restore, dec)
+	}
+	if err := ReadSnapshot(old, handler); err != nil {
+		return err
+	}
+	if err := restore.KVSReset(lastIndex); err != nil {
+		return err
+	}
+	restore.Commit()
+
+	// External code might be calling State(), so we need to synchronize
+	// here to make sure we swap in the new state store atomically.
+	c.stateLock.Lock()
+	stateOld := c.state
+	c.state = stateNew
+	c.stateLock.Unlock()
+
+	// Signal that the old state store has been abandoned. This is required
+	// because we don't operate on it any more, we just throw it away, so
+	// blocking queries won't see any changes and need to be woken up.
+	stateOld.Abandon()
+	return nil
+}
+
+// ReadSnapshot decodes the records of the FSM snapshot from the reader,
+// calling the handler with the decoder positioned on each of them. The
+// handler must consume the record.
+func ReadSnapshot(r io.Reader, handler func(header *SnapshotHeader, msg structs.MessageType, dec *codec.Decoder) error) error {
 	// Create a decoder
-	dec := codec.NewDecoder(old, msgpackHandle)
+	dec := codec.NewDecoder(r, msgpackHandle)
 
 	// Read in the header
-	var header snapshotHeader
+	var header SnapshotHeader
 	if err := dec.Decode(&header); err != nil {
 		return err
 	}
 
-	// Populate the new state
 	msgType := make([]byte, 1)
 	for {
 		// Read the message type
-		_, err := old.Read(msgType)
+		_, err := r.Read(msgType)
 		if err == io.EOF {
-			break
+			return nil
 		} else if err != nil {
 			return err
 		}
 
 		// Decode
 		msg := structs.MessageType(msgType[0])
-		if fn := restorers[msg]; fn != nil {
-			if err := fn(&header, restore, dec); err != nil {
-				return err
-			}
-		} else {
-			return fmt.Errorf("Unrecognized msg type %d", msg)
+		if err := handler(&header, msg, dec); err != nil {
+			return err
 		}
 	}
-	restore.Commit()
-
-	// External code might be calling State(), so we need to synchronize
-	// here to make sure we swap in the new state store atomically.
-	c.stateLock.Lock()
-	stateOld := c.state
-	c.state = stateNew
-	c.stateLock.Unlock()
-
-	// Signal that the old state store has been abandoned. This is required
-	// because we don't operate on it any more, we just throw it away, so
-	// blocking queries won't see any changes and need to be woken up.
-	stateOld.Abandon()
-	return nil
 }
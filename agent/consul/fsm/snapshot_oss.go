@@ -28,6 +28,8 @@ func init() {
 	registerRestorer(structs.ACLTokenSetRequestType, restoreToken)
 	registerRestorer(structs.ACLPolicySetRequestType, restorePolicy)
 	registerRestorer(structs.ConfigEntryRequestType, restoreConfigEntry)
+	registerRestorer(structs.ConnectChangeType, restoreConnectChange)
+	registerRestorer(structs.PreparedQueryStatsRequestType, restorePreparedQueryStats)
 }
 
 func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) error {
@@ -49,6 +51,9 @@ func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) err
 	if err := s.persistPreparedQueries(sink, encoder); err != nil {
 		return err
 	}
+	if err := s.persistPreparedQueryStats(sink, encoder); err != nil {
+		return err
+	}
 	if err := s.persistAutopilot(sink, encoder); err != nil {
 		return err
 	}
@@ -67,6 +72,9 @@ func persistOSS(s *snapshot, sink raft.SnapshotSink, encoder *codec.Encoder) err
 	if err := s.persistConfigEntries(sink, encoder); err != nil {
 		return err
 	}
+	if err := s.persistConnectChanges(sink, encoder); err != nil {
+		return err
+	}
 	if err := s.persistIndex(sink, encoder); err != nil {
 		return err
 	}
@@ -388,6 +396,42 @@ func (s *snapshot) persistConfigEntries(sink raft.SnapshotSink,
 	return nil
 }
 
+func (s *snapshot) persistConnectChanges(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	changes, err := s.state.ConnectChanges()
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		if _, err := sink.Write([]byte{byte(structs.ConnectChangeType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(change); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *snapshot) persistPreparedQueryStats(sink raft.SnapshotSink,
+	encoder *codec.Encoder) error {
+	stats, err := s.state.PreparedQueryStats()
+	if err != nil {
+		return err
+	}
+
+	for _, stat := range stats {
+		if _, err := sink.Write([]byte{byte(structs.PreparedQueryStatsRequestType)}); err != nil {
+			return err
+		}
+		if err := encoder.Encode(stat); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *snapshot) persistIndex(sink raft.SnapshotSink, encoder *codec.Encoder) error {
 	// Get all the indexes
 	iter, err := s.state.Indexes()
@@ -408,7 +452,7 @@ func (s *snapshot) persistIndex(sink raft.SnapshotSink, encoder *codec.Encoder)
 	return nil
 }
 
-func restoreRegistration(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreRegistration(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.RegisterRequest
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -419,7 +463,7 @@ func restoreRegistration(header *snapshotHeader, restore *state.Restore, decoder
 	return nil
 }
 
-func restoreKV(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreKV(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.DirEntry
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -430,7 +474,7 @@ func restoreKV(header *snapshotHeader, restore *state.Restore, decoder *codec.De
 	return nil
 }
 
-func restoreTombstone(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreTombstone(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.DirEntry
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -449,7 +493,7 @@ func restoreTombstone(header *snapshotHeader, restore *state.Restore, decoder *c
 	return nil
 }
 
-func restoreSession(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreSession(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.Session
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -460,7 +504,7 @@ func restoreSession(header *snapshotHeader, restore *state.Restore, decoder *cod
 	return nil
 }
 
-func restoreACL(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreACL(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.ACL
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -473,7 +517,7 @@ func restoreACL(header *snapshotHeader, restore *state.Restore, decoder *codec.D
 }
 
 // DEPRECATED (ACL-Legacy-Compat) - remove once v1 acl compat is removed
-func restoreACLBootstrap(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreACLBootstrap(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.ACLBootstrap
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -484,7 +528,7 @@ func restoreACLBootstrap(header *snapshotHeader, restore *state.Restore, decoder
 	return restore.IndexRestore(&state.IndexEntry{Key: "acl-token-bootstrap", Value: req.ModifyIndex})
 }
 
-func restoreCoordinates(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreCoordinates(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.Coordinates
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -495,7 +539,7 @@ func restoreCoordinates(header *snapshotHeader, restore *state.Restore, decoder
 	return nil
 }
 
-func restorePreparedQuery(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restorePreparedQuery(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.PreparedQuery
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -506,7 +550,7 @@ func restorePreparedQuery(header *snapshotHeader, restore *state.Restore, decode
 	return nil
 }
 
-func restoreAutopilot(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreAutopilot(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req autopilot.Config
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -517,7 +561,7 @@ func restoreAutopilot(header *snapshotHeader, restore *state.Restore, decoder *c
 	return nil
 }
 
-func restoreIntention(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreIntention(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.Intention
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -528,7 +572,7 @@ func restoreIntention(header *snapshotHeader, restore *state.Restore, decoder *c
 	return nil
 }
 
-func restoreConnectCA(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreConnectCA(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.CARoot
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -539,7 +583,7 @@ func restoreConnectCA(header *snapshotHeader, restore *state.Restore, decoder *c
 	return nil
 }
 
-func restoreConnectCAProviderState(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreConnectCAProviderState(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.CAConsulProviderState
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -550,7 +594,7 @@ func restoreConnectCAProviderState(header *snapshotHeader, restore *state.Restor
 	return nil
 }
 
-func restoreConnectCAConfig(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreConnectCAConfig(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.CAConfiguration
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -561,7 +605,7 @@ func restoreConnectCAConfig(header *snapshotHeader, restore *state.Restore, deco
 	return nil
 }
 
-func restoreIndex(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreIndex(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req state.IndexEntry
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -569,7 +613,7 @@ func restoreIndex(header *snapshotHeader, restore *state.Restore, decoder *codec
 	return restore.IndexRestore(&req)
 }
 
-func restoreToken(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreToken(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.ACLToken
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -586,7 +630,7 @@ func restoreToken(header *snapshotHeader, restore *state.Restore, decoder *codec
 	return restore.ACLToken(&req)
 }
 
-func restorePolicy(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restorePolicy(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.ACLPolicy
 	if err := decoder.Decode(&req); err != nil {
 		return err
@@ -594,10 +638,26 @@ func restorePolicy(header *snapshotHeader, restore *state.Restore, decoder *code
 	return restore.ACLPolicy(&req)
 }
 
-func restoreConfigEntry(header *snapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+func restoreConfigEntry(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
 	var req structs.ConfigEntryRequest
 	if err := decoder.Decode(&req); err != nil {
 		return err
 	}
 	return restore.ConfigEntry(req.Entry)
 }
+
+func restoreConnectChange(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.ConnectChange
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	return restore.ConnectChange(&req)
+}
+
+func restorePreparedQueryStats(header *SnapshotHeader, restore *state.Restore, decoder *codec.Decoder) error {
+	var req structs.PreparedQueryStats
+	if err := decoder.Decode(&req); err != nil {
+		return err
+	}
+	return restore.PreparedQueryStats(&req)
+}
@@ -216,6 +216,26 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	require.NoError(fsm.state.EnsureConfigEntry(18, serviceConfig))
 	require.NoError(fsm.state.EnsureConfigEntry(19, proxyConfig))
 
+	// Connect changes
+	require.NoError(fsm.state.ConfigEntryApply(20, &structs.ConfigEntryRequest{
+		Op: structs.ConfigEntryUpsert,
+		Entry: &structs.ServiceConfigEntry{
+			Kind: structs.ServiceDefaults,
+			Name: "bar",
+		},
+		Actor: "accessor",
+	}))
+	_, changes, err := fsm.state.ConnectChanges(nil, "", "")
+	require.NoError(err)
+
+	// Prepared query stats
+	require.NoError(fsm.state.PreparedQueryStatsApply(21, []*structs.PreparedQueryStats{
+		{ID: query.ID, Executions: 3, Failovers: 1, LatencyTotal: time.Second},
+	}))
+	_, queryStats, err := fsm.state.PreparedQueryStatsList(nil)
+	require.NoError(err)
+	require.Len(queryStats, 1)
+
 	// Snapshot
 	snap, err := fsm.Snapshot()
 	if err != nil {
@@ -350,6 +370,11 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 		}
 	}()
 
+	// Verify the KV diffs from before the snapshot are reset
+	_, diff, err := fsm2.state.KVSListDiff(nil, "/", 1)
+	require.NoError(err)
+	require.True(diff.Reset)
+
 	// Verify coordinates are restored
 	_, coords, err := fsm2.state.Coordinates(nil)
 	if err != nil {
@@ -398,19 +423,29 @@ func TestFSM_SnapshotRestore_OSS(t *testing.T) {
 	assert.Equal("bar", state.RootCert)
 
 	// Verify CA configuration is restored.
-	_, caConf, err := fsm2.state.CAConfig()
+	_, caConf, err := fsm2.state.CAConfig(nil)
 	require.NoError(err)
 	assert.Equal(caConfig, caConf)
 
 	// Verify config entries are restored
-	_, serviceConfEntry, err := fsm2.state.ConfigEntry(structs.ServiceDefaults, "foo")
+	_, serviceConfEntry, err := fsm2.state.ConfigEntry(nil, structs.ServiceDefaults, "foo")
 	require.NoError(err)
 	assert.Equal(serviceConfig, serviceConfEntry)
 
-	_, proxyConfEntry, err := fsm2.state.ConfigEntry(structs.ProxyDefaults, "global")
+	_, proxyConfEntry, err := fsm2.state.ConfigEntry(nil, structs.ProxyDefaults, "global")
 	require.NoError(err)
 	assert.Equal(proxyConfig, proxyConfEntry)
 
+	// Verify connect changes are restored
+	_, changes2, err := fsm2.state.ConnectChanges(nil, "", "")
+	require.NoError(err)
+	assert.Equal(changes, changes2)
+
+	// Verify prepared query stats are restored
+	_, queryStats2, err := fsm2.state.PreparedQueryStatsList(nil)
+	require.NoError(err)
+	assert.Equal(queryStats, queryStats2)
+
 	// Snapshot
 	snap, err = fsm2.Snapshot()
 	if err != nil {
@@ -515,7 +550,7 @@ func TestFSM_BadSnapshot_NilCAConfig(t *testing.T) {
 
 	// Make sure there's no entry in the CA config table.
 	state := fsm2.State()
-	idx, config, err := state.CAConfig()
+	idx, config, err := state.CAConfig(nil)
 	require.NoError(err)
 	require.Equal(uint64(0), idx)
 	if config != nil {
@@ -0,0 +1,172 @@
+package consul
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/metadata"
+	"github.com/hashicorp/consul/agent/subscribe"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+)
+
+// grpcListener is a net.Listener accepting the connections handed off by
+// the RPC server with the RPCGRPC byte, so that the gRPC services of the
+// servers share the RPC port.
+type grpcListener struct {
+	addr   net.Addr
+	connCh chan net.Conn
+
+	closed    bool
+	closeCh   chan struct{}
+	closeLock sync.Mutex
+}
+
+func newGRPCListener(addr net.Addr) *grpcListener {
+	return &grpcListener{
+		addr:    addr,
+		connCh:  make(chan net.Conn),
+		closeCh: make(chan struct{}),
+	}
+}
+
+// Handoff is used to hand off a connection to the listener so it can be
+// Accept()'ed.
+func (l *grpcListener) Handoff(c net.Conn) error {
+	select {
+	case l.connCh <- c:
+		return nil
+	case <-l.closeCh:
+		return fmt.Errorf("gRPC listener closed")
+	}
+}
+
+// Accept is used to return a connection which was handed off.
+func (l *grpcListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.connCh:
+		return conn, nil
+	case <-l.closeCh:
+		return nil, fmt.Errorf("gRPC listener closed")
+	}
+}
+
+// Close is used to stop accepting connections.
+func (l *grpcListener) Close() error {
+	l.closeLock.Lock()
+	defer l.closeLock.Unlock()
+
+	if !l.closed {
+		l.closed = true
+		close(l.closeCh)
+	}
+	return nil
+}
+
+// Addr returns the address of the RPC listener.
+func (l *grpcListener) Addr() net.Addr {
+	return l.addr
+}
+
+// setupGRPC creates the gRPC server of the services of the servers, which
+// is served on the RPC port.
+func (s *Server) setupGRPC() {
+	s.grpcListener = newGRPCListener(s.Listener.Addr())
+	s.grpcServer = grpc.NewServer(
+		// Allow the keepalive pings of the subscriptions of the agents.
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             subscribeKeepaliveTime / 2,
+			PermitWithoutStream: true,
+		}))
+	subscribe.RegisterHandler(s.grpcServer, &subscribeEndpoint{srv: s})
+
+	go func() {
+		if err := s.grpcServer.Serve(s.grpcListener); err != nil {
+			select {
+			case <-s.shutdownCh:
+			default:
+				s.logger.Printf("[ERR] consul: gRPC server failed: %v", err)
+			}
+		}
+	}()
+}
+
+// grpcConnPool keeps one gRPC connection per server, which is shared by all
+// the subscriptions of the client to that server since gRPC multiplexes the
+// streams over it.
+type grpcConnPool struct {
+	// dial opens a connection to the gRPC services of a server.
+	dial func(server *metadata.Server) (net.Conn, error)
+
+	conns    map[string]*grpc.ClientConn
+	shutdown bool
+	lock     sync.Mutex
+}
+
+func newGRPCConnPool(dial func(server *metadata.Server) (net.Conn, error)) *grpcConnPool {
+	return &grpcConnPool{
+		dial:  dial,
+		conns: make(map[string]*grpc.ClientConn),
+	}
+}
+
+// Conn returns the connection to the given server, creating it if there's
+// none yet. The connection is established in the background, the streams
+// opened meanwhile waiting for it.
+func (p *grpcConnPool) Conn(server *metadata.Server) (*grpc.ClientConn, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.shutdown {
+		return nil, fmt.Errorf("gRPC connection pool is shut down")
+	}
+
+	addr := server.Addr.String()
+	if conn, ok := p.conns[addr]; ok {
+		return conn, nil
+	}
+
+	dialer := func(string, time.Duration) (net.Conn, error) {
+		return p.dial(server)
+	}
+	conn, err := grpc.Dial(addr,
+		grpc.WithInsecure(),
+		grpc.WithDialer(dialer),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:    subscribeKeepaliveTime,
+			Timeout: subscribeKeepaliveTimeout,
+		}))
+	if err != nil {
+		return nil, err
+	}
+	p.conns[addr] = conn
+	return conn, nil
+}
+
+// Remove closes the connection to the given server after it failed, so the
+// next call to Conn dials the server again. It does nothing if conn has
+// already been replaced.
+func (p *grpcConnPool) Remove(server *metadata.Server, conn *grpc.ClientConn) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	addr := server.Addr.String()
+	if p.conns[addr] == conn {
+		delete(p.conns, addr)
+		conn.Close()
+	}
+}
+
+// Shutdown closes all the connections of the pool.
+func (p *grpcConnPool) Shutdown() {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for addr, conn := range p.conns {
+		conn.Close()
+		delete(p.conns, addr)
+	}
+	p.shutdown = true
+}
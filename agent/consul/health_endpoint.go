@@ -94,7 +94,8 @@ func (h *Health) ServiceChecks(args *structs.ServiceSpecificRequest,
 				return err
 			}
 			reply.Index, reply.HealthChecks = index, checks
-			if err := h.srv.filterACL(args.Token, reply); err != nil {
+			query := filterMemoQuery("Health.ServiceChecks", args.Source, args)
+			if err := h.srv.filterACLMemoized(query, args.Token, reply); err != nil {
 				return err
 			}
 			return h.srv.sortNodesByDistanceFrom(args.Source, reply.HealthChecks)
@@ -138,7 +139,19 @@ func (h *Health) ServiceNodes(args *structs.ServiceSpecificRequest, reply *struc
 		}
 	}
 
-	err := h.srv.blockingQuery(
+	var filter *structs.Filter
+	if args.Filter != "" {
+		var err error
+		if filter, err = structs.CreateFilter(args.Filter, structs.CheckServiceNodes(nil)); err != nil {
+			return err
+		}
+	}
+	selector, err := structs.ParseLabelSelector(args.LabelSelector)
+	if err != nil {
+		return err
+	}
+
+	err = h.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
 		func(ws memdb.WatchSet, state *state.Store) error {
@@ -151,9 +164,18 @@ func (h *Health) ServiceNodes(args *structs.ServiceSpecificRequest, reply *struc
 			if len(args.NodeMetaFilters) > 0 {
 				reply.Nodes = nodeMetaFilter(args.NodeMetaFilters, reply.Nodes)
 			}
-			if err := h.srv.filterACL(args.Token, reply); err != nil {
+			query := filterMemoQuery("Health.ServiceNodes", args.Source, args)
+			if err := h.srv.filterACLMemoized(query, args.Token, reply); err != nil {
 				return err
 			}
+			if filter != nil {
+				raw, err := filter.Execute(reply.Nodes)
+				if err != nil {
+					return err
+				}
+				reply.Nodes = raw.(structs.CheckServiceNodes)
+			}
+			reply.Nodes = selectCheckServiceNodes(selector, reply.Nodes)
 			return h.srv.sortNodesByDistanceFrom(args.Source, reply.Nodes)
 		})
 
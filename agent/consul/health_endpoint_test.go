@@ -704,6 +704,71 @@ func TestHealth_ServiceNodes_MultipleServiceTags(t *testing.T) {
 	require.Equal(t, nodes[0].Checks[0].Status, api.HealthPassing)
 }
 
+func TestHealth_ServiceNodes_LabelSelector(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	for node, labels := range map[string]map[string]string{
+		"foo": {"team": "payments", "env": "prod"},
+		"bar": {"team": "payments", "env": "dev"},
+	} {
+		arg := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				ID:      "db",
+				Service: "db",
+				Labels:  labels,
+			},
+		}
+		var out struct{}
+		if err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// Invalid labels are rejected on registration.
+	arg := structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "baz",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			ID:      "db",
+			Service: "db",
+			Labels:  map[string]string{"team": "pay ments"},
+		},
+	}
+	var out struct{}
+	err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Invalid Service Labels")
+
+	req := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "db",
+	}
+	req.LabelSelector = "team=payments,env!=dev"
+	var out2 structs.IndexedCheckServiceNodes
+	if err := msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &req, &out2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	require.Len(t, out2.Nodes, 1)
+	require.Equal(t, "foo", out2.Nodes[0].Node.Node)
+
+	req.LabelSelector = "team=search"
+	if err := msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &req, &out2); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	require.Empty(t, out2.Nodes)
+}
+
 func TestHealth_ServiceNodes_NodeMetaFilter(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -835,6 +900,50 @@ func TestHealth_ServiceNodes_NodeMetaFilter(t *testing.T) {
 	}
 }
 
+func TestHealth_ServiceNodes_Filter(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	for _, name := range []string{"foo", "bar"} {
+		arg := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       name,
+			Address:    "127.0.0.1",
+			NodeMeta:   map[string]string{"env": name},
+			Service: &structs.NodeService{
+				ID:      "db",
+				Service: "db",
+				Meta:    map[string]string{"node": name},
+			},
+		}
+		var out struct{}
+		if err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	for _, filter := range []string{"Node.Meta.env == bar", "Service.Meta.node == bar"} {
+		req := structs.ServiceSpecificRequest{
+			Datacenter:  "dc1",
+			ServiceName: "db",
+		}
+		req.Filter = filter
+		var out structs.IndexedCheckServiceNodes
+		if err := msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &req, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if len(out.Nodes) != 1 || out.Nodes[0].Node.Node != "bar" {
+			t.Fatalf("bad: %v", out.Nodes)
+		}
+	}
+}
+
 func TestHealth_ServiceNodes_DistanceSort(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -114,8 +114,10 @@ func (s *Intention) Apply(
 		}
 	}
 
-	// We always update the updatedat field. This has no effect for deletion.
+	// We always update the updatedat field. This has no effect for deletion
+	// but is the time of the change in the changelog.
 	args.Intention.UpdatedAt = time.Now().UTC()
+	args.Actor = s.srv.tokenAccessorID(args.Token)
 
 	// Default source type
 	if args.Intention.SourceType == "" {
@@ -336,7 +338,9 @@ func (s *Intention) Check(
 	// Check the authorization for each match
 	for _, ixn := range matches[0] {
 		if auth, ok := uri.Authorize(ixn); ok {
-			reply.Allowed = auth
+			// The check is for a connection, which is always denied by the
+			// L7 permissions that only apply to HTTP requests.
+			reply.Allowed = auth && len(ixn.Permissions) == 0
 			return nil
 		}
 	}
@@ -362,3 +366,101 @@ func (s *Intention) Check(
 
 	return nil
 }
+
+// Observe records the connections authorized by an agent without matching
+// any intention, which are aggregated by the leader into suggestions.
+func (s *Intention) Observe(
+	args *structs.IntentionObservationRequest,
+	reply *struct{}) error {
+	if done, err := s.srv.forward("Intention.Observe", args, args, reply); done {
+		return err
+	}
+
+	// The observations are reported with the token of the agent, like the
+	// coordinates, so require write access to its node.
+	rule, err := s.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.NodeWrite(args.Node, nil) {
+		return acl.ErrPermissionDenied
+	}
+
+	// The agents authorize the connections to the services registered on
+	// their node, so only keep the observations of those.
+	_, services, err := s.srv.fsm.State().NodeServices(nil, args.Node)
+	if err != nil {
+		return err
+	}
+	local := make(map[string]struct{})
+	if services != nil {
+		for _, svc := range services.Services {
+			local[svc.Service] = struct{}{}
+		}
+	}
+	observations := make([]*structs.IntentionObservation, 0, len(args.Observations))
+	for _, o := range args.Observations {
+		if _, ok := local[o.DestinationName]; !ok {
+			s.srv.logger.Printf("[WARN] consul.intention: Dropping observation of node %q for service %q not registered on it",
+				args.Node, o.DestinationName)
+			continue
+		}
+		observations = append(observations, o)
+	}
+
+	s.srv.intentionSuggestions.observe(observations)
+	return nil
+}
+
+// Suggestions returns the connections observed between services that aren't
+// covered by any intention yet, to help writing the intentions needed before
+// denying connections by default.
+func (s *Intention) Suggestions(
+	args *structs.DCSpecificRequest,
+	reply *structs.IndexedIntentionSuggestions) error {
+	// Forward to the leader, which holds the observations
+	if done, err := s.srv.forward("Intention.Suggestions", args, args, reply); done {
+		return err
+	}
+
+	rule, err := s.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+
+	// Forget the connections that are covered by an intention by now
+	state := s.srv.fsm.State()
+	index, ixns, err := state.Intentions(nil)
+	if err != nil {
+		return err
+	}
+	covered := func(o *structs.IntentionObservation) bool {
+		uri := &connect.SpiffeIDService{Namespace: o.SourceNS, Service: o.SourceName}
+		for _, ixn := range ixns {
+			if ixn.DestinationNS != structs.IntentionWildcard && ixn.DestinationNS != o.DestinationNS {
+				continue
+			}
+			if ixn.DestinationName != structs.IntentionWildcard && ixn.DestinationName != o.DestinationName {
+				continue
+			}
+			if _, ok := uri.Authorize(ixn); ok {
+				return true
+			}
+		}
+		return false
+	}
+	suggestions := s.srv.intentionSuggestions.snapshot(func(o *structs.IntentionObservation) bool {
+		return !covered(o)
+	})
+
+	reply.Index = index
+	reply.Suggestions = make([]*structs.IntentionObservation, 0, len(suggestions))
+	for _, o := range suggestions {
+		if rule != nil && !rule.IntentionRead(o.DestinationName) {
+			continue
+		}
+		reply.Suggestions = append(reply.Suggestions, o)
+	}
+	s.srv.setQueryMeta(&reply.QueryMeta)
+	return nil
+}
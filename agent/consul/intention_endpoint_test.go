@@ -1276,3 +1276,129 @@ service "bar" {
 		require.False(resp.Allowed)
 	}
 }
+
+func TestIntentionSuggestions(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// The destinations must be registered on the node reporting them
+	state := s1.fsm.State()
+	require.NoError(state.EnsureNode(1, &structs.Node{Node: "node1", Address: "127.0.0.1"}))
+	require.NoError(state.EnsureService(2, "node1", &structs.NodeService{ID: "db", Service: "db"}))
+	require.NoError(state.EnsureService(3, "node1", &structs.NodeService{ID: "cache", Service: "cache"}))
+
+	observe := func(source, destination string, allowed, denied uint64) {
+		req := &structs.IntentionObservationRequest{
+			Datacenter: "dc1",
+			Node:       "node1",
+			Observations: []*structs.IntentionObservation{{
+				SourceNS:        structs.IntentionDefaultNamespace,
+				SourceName:      source,
+				DestinationNS:   structs.IntentionDefaultNamespace,
+				DestinationName: destination,
+				Allowed:         allowed,
+				Denied:          denied,
+				LastSeen:        time.Now().UTC(),
+			}},
+		}
+		var reply struct{}
+		require.NoError(msgpackrpc.CallWithCodec(codec, "Intention.Observe", req, &reply))
+	}
+	observe("web", "db", 2, 0)
+	observe("web", "db", 1, 1)
+	observe("api", "db", 0, 3)
+	observe("web", "cache", 1, 0)
+	observe("web", "other", 1, 0)
+
+	suggestions := func() []*structs.IntentionObservation {
+		req := &structs.DCSpecificRequest{Datacenter: "dc1"}
+		var reply structs.IndexedIntentionSuggestions
+		require.NoError(msgpackrpc.CallWithCodec(codec, "Intention.Suggestions", req, &reply))
+		return reply.Suggestions
+	}
+	actual := suggestions()
+	require.Len(actual, 3)
+	require.Equal("web", actual[0].SourceName)
+	require.Equal("cache", actual[0].DestinationName)
+	require.Equal("api", actual[1].SourceName)
+	require.Equal(uint64(3), actual[1].Denied)
+	require.Equal("web", actual[2].SourceName)
+	require.Equal("db", actual[2].DestinationName)
+	require.Equal(uint64(3), actual[2].Allowed)
+	require.Equal(uint64(1), actual[2].Denied)
+
+	// The connections covered by an intention are no longer suggested
+	ixn := structs.IntentionRequest{
+		Datacenter: "dc1",
+		Op:         structs.IntentionOpCreate,
+		Intention: &structs.Intention{
+			SourceNS:        structs.IntentionDefaultNamespace,
+			SourceName:      "*",
+			DestinationNS:   structs.IntentionDefaultNamespace,
+			DestinationName: "db",
+			SourceType:      structs.IntentionSourceConsul,
+			Action:          structs.IntentionActionAllow,
+		},
+	}
+	var reply string
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Intention.Apply", &ixn, &reply))
+
+	actual = suggestions()
+	require.Len(actual, 1)
+	require.Equal("cache", actual[0].DestinationName)
+}
+
+func TestIntentionSuggestions_ACL(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	state := s1.fsm.State()
+	require.NoError(state.EnsureNode(1, &structs.Node{Node: "node1", Address: "127.0.0.1"}))
+	require.NoError(state.EnsureService(2, "node1", &structs.NodeService{ID: "db", Service: "db"}))
+
+	// Observations require node write permissions
+	req := &structs.IntentionObservationRequest{
+		Datacenter: "dc1",
+		Node:       "node1",
+		Observations: []*structs.IntentionObservation{{
+			SourceName:      "web",
+			DestinationName: "db",
+			Denied:          1,
+		}},
+	}
+	var empty struct{}
+	err := msgpackrpc.CallWithCodec(codec, "Intention.Observe", req, &empty)
+	require.True(acl.IsErrPermissionDenied(err))
+	req.Token = "root"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Intention.Observe", req, &empty))
+
+	// Suggestions are filtered by intention read permissions
+	var reply structs.IndexedIntentionSuggestions
+	args := &structs.DCSpecificRequest{Datacenter: "dc1"}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Intention.Suggestions", args, &reply))
+	require.Empty(reply.Suggestions)
+	args.Token = "root"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Intention.Suggestions", args, &reply))
+	require.Len(reply.Suggestions, 1)
+}
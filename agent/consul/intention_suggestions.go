@@ -0,0 +1,95 @@
+package consul
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// maxIntentionSuggestions is the maximum number of source and destination
+// pairs tracked, to bound the memory used when many services connect to
+// each other. Connections between new pairs are dropped past this limit.
+const maxIntentionSuggestions = 10000
+
+// intentionPair identifies the source and destination of a connection.
+type intentionPair struct {
+	SourceNS, SourceName           string
+	DestinationNS, DestinationName string
+}
+
+// intentionSuggestions aggregates the connections reported by the agents
+// that weren't covered by any intention. Observations are reported to the
+// leader and kept in memory only, so they are reset when the leadership
+// changes.
+type intentionSuggestions struct {
+	sync.Mutex
+	pairs map[intentionPair]*structs.IntentionObservation
+}
+
+func newIntentionSuggestions() *intentionSuggestions {
+	return &intentionSuggestions{
+		pairs: make(map[intentionPair]*structs.IntentionObservation),
+	}
+}
+
+// observe adds the given observations to the aggregated ones.
+func (i *intentionSuggestions) observe(observations []*structs.IntentionObservation) {
+	i.Lock()
+	defer i.Unlock()
+
+	for _, o := range observations {
+		pair := intentionPair{
+			SourceNS:        o.SourceNS,
+			SourceName:      o.SourceName,
+			DestinationNS:   o.DestinationNS,
+			DestinationName: o.DestinationName,
+		}
+		cur, ok := i.pairs[pair]
+		if !ok {
+			if len(i.pairs) >= maxIntentionSuggestions {
+				continue
+			}
+			c := *o
+			i.pairs[pair] = &c
+			continue
+		}
+		cur.Allowed += o.Allowed
+		cur.Denied += o.Denied
+		if o.LastSeen.After(cur.LastSeen) {
+			cur.LastSeen = o.LastSeen
+		}
+	}
+}
+
+// snapshot returns a copy of the aggregated observations for which the
+// given function returns true, sorted by destination and source. The
+// observations for which it returns false are dropped, so that pairs covered
+// by an intention since they were observed are forgotten.
+func (i *intentionSuggestions) snapshot(keep func(*structs.IntentionObservation) bool) []*structs.IntentionObservation {
+	i.Lock()
+	defer i.Unlock()
+
+	out := make([]*structs.IntentionObservation, 0, len(i.pairs))
+	for pair, o := range i.pairs {
+		if !keep(o) {
+			delete(i.pairs, pair)
+			continue
+		}
+		c := *o
+		out = append(out, &c)
+	}
+	sort.Slice(out, func(a, b int) bool {
+		if out[a].DestinationNS != out[b].DestinationNS {
+			return out[a].DestinationNS < out[b].DestinationNS
+		}
+		if out[a].DestinationName != out[b].DestinationName {
+			return out[a].DestinationName < out[b].DestinationName
+		}
+		if out[a].SourceNS != out[b].SourceNS {
+			return out[a].SourceNS < out[b].SourceNS
+		}
+		return out[a].SourceName < out[b].SourceName
+	})
+	return out
+}
@@ -60,6 +60,46 @@ func (m *Internal) NodeDump(args *structs.DCSpecificRequest,
 		})
 }
 
+// ServiceDump returns the instances of all the services of a kind, such as
+// the mesh gateways of the datacenter.
+func (m *Internal) ServiceDump(args *structs.ServiceDumpRequest,
+	reply *structs.IndexedCheckServiceNodes) error {
+	if done, err := m.srv.forward("Internal.ServiceDump", args, args, reply); done {
+		return err
+	}
+
+	var filter *structs.Filter
+	if args.Filter != "" {
+		var err error
+		if filter, err = structs.CreateFilter(args.Filter, structs.CheckServiceNodes(nil)); err != nil {
+			return err
+		}
+	}
+
+	return m.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, nodes, err := state.ServiceDumpKind(ws, args.ServiceKind)
+			if err != nil {
+				return err
+			}
+
+			reply.Index, reply.Nodes = index, nodes
+			if err := m.srv.filterACL(args.Token, reply); err != nil {
+				return err
+			}
+			if filter != nil {
+				raw, err := filter.Execute(reply.Nodes)
+				if err != nil {
+					return err
+				}
+				reply.Nodes = raw.(structs.CheckServiceNodes)
+			}
+			return m.srv.sortNodesByDistanceFrom(args.Source, reply.Nodes)
+		})
+}
+
 // EventFire is a bit of an odd endpoint, but it allows for a cross-DC RPC
 // call to fire an event. The primary use case is to enable user events being
 // triggered in a remote DC.
@@ -128,8 +168,12 @@ func (m *Internal) KeyringOperation(
 		}
 	}
 
+	if args.LocalOnly && args.Operation != structs.KeyringList {
+		return fmt.Errorf("argument error: LocalOnly can only be used for List operations")
+	}
+
 	// Only perform WAN keyring querying and RPC forwarding once
-	if !args.Forwarded && m.srv.serfWAN != nil {
+	if !args.Forwarded && m.srv.serfWAN != nil && !args.LocalOnly {
 		args.Forwarded = true
 		m.executeKeyringOp(args, reply, true)
 		return m.srv.globalRPC("Internal.KeyringOperation", args, reply)
@@ -159,6 +159,74 @@ func TestInternal_NodeDump(t *testing.T) {
 	}
 }
 
+func TestInternal_ServiceDump(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	register := func(node, addr string, service *structs.NodeService) {
+		arg := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    addr,
+			Service:    service,
+		}
+		var out struct{}
+		if err := msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	register("foo", "127.0.0.1", &structs.NodeService{
+		Kind:    structs.ServiceKindMeshGateway,
+		ID:      "mesh-gateway",
+		Service: "mesh-gateway",
+		Port:    8443,
+	})
+	register("bar", "127.0.0.2", &structs.NodeService{
+		Kind:    structs.ServiceKindMeshGateway,
+		ID:      "mesh-gateway",
+		Service: "mesh-gateway",
+		Port:    8443,
+	})
+	register("bar", "127.0.0.2", &structs.NodeService{
+		ID:      "db",
+		Service: "db",
+		Port:    5000,
+	})
+
+	var out structs.IndexedCheckServiceNodes
+	req := structs.ServiceDumpRequest{
+		Datacenter:  "dc1",
+		ServiceKind: structs.ServiceKindMeshGateway,
+	}
+	if err := msgpackrpc.CallWithCodec(codec, "Internal.ServiceDump", &req, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(out.Nodes) != 2 {
+		t.Fatalf("Bad: %v", out.Nodes)
+	}
+	for _, node := range out.Nodes {
+		if node.Service.Kind != structs.ServiceKindMeshGateway {
+			t.Fatalf("Bad: %v", node.Service)
+		}
+	}
+
+	// The results can be filtered.
+	var filtered structs.IndexedCheckServiceNodes
+	req.Filter = `Node.Node == "foo"`
+	if err := msgpackrpc.CallWithCodec(codec, "Internal.ServiceDump", &req, &filtered); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(filtered.Nodes) != 1 || filtered.Nodes[0].Node.Node != "foo" {
+		t.Fatalf("Bad: %v", filtered.Nodes)
+	}
+}
+
 func TestInternal_KeyringOperation(t *testing.T) {
 	t.Parallel()
 	key1 := "H1dfkSZOVnP/JUnaBfTzXg=="
@@ -0,0 +1,202 @@
+package consul
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// keyringRotationKeySize is the size of the generated gossip encryption
+// keys, which matches the keys of the keygen command.
+const keyringRotationKeySize = 16
+
+// keyringRotation holds the state of the rotation of the gossip encryption
+// key of the LAN pool, which the leader runs periodically. The status is only
+// kept in memory and the schedule starts over when a new leader is elected.
+type keyringRotation struct {
+	lock    sync.Mutex
+	stopCh  chan struct{}
+	enabled bool
+	status  structs.KeyringRotationStatus
+}
+
+// startKeyringRotation starts a goroutine that rotates the gossip encryption
+// key of the LAN pool at the configured interval.
+func (s *Server) startKeyringRotation() {
+	interval := s.config.KeyringRotationInterval
+	if interval == 0 {
+		return
+	}
+
+	r := &s.keyringRotation
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.enabled {
+		return
+	}
+
+	r.stopCh = make(chan struct{})
+	r.enabled = true
+	r.status = structs.KeyringRotationStatus{
+		Enabled:      true,
+		Interval:     interval,
+		NextRotation: time.Now().Add(interval),
+	}
+
+	go func(stopCh chan struct{}) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		// The key made primary by the previous rotation, which is kept until
+		// the next one so the members which missed the rotation can still
+		// gossip with the others in the meantime.
+		var previous string
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				key, err := s.rotateKeyring(previous)
+				if key != "" {
+					previous = key
+				}
+				if err != nil {
+					s.logger.Printf("[ERR] consul: error rotating the gossip encryption key: %v", err)
+				} else {
+					s.logger.Printf("[INFO] consul: rotated the gossip encryption key of the LAN pool")
+				}
+				s.recordKeyringRotation(stopCh, err)
+			}
+		}
+	}(r.stopCh)
+}
+
+// stopKeyringRotation stops the rotation of the gossip encryption key.
+func (s *Server) stopKeyringRotation() {
+	r := &s.keyringRotation
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if !r.enabled {
+		return
+	}
+
+	close(r.stopCh)
+	r.enabled = false
+	r.status = structs.KeyringRotationStatus{}
+}
+
+// recordKeyringRotation updates the status with the outcome of a rotation
+// run by the goroutine with the given stop channel.
+func (s *Server) recordKeyringRotation(stopCh chan struct{}, err error) {
+	r := &s.keyringRotation
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	// Ignore the rotations which completed after we lost leadership.
+	if !r.enabled || r.stopCh != stopCh {
+		return
+	}
+
+	now := time.Now()
+	if err != nil {
+		r.status.LastError = err.Error()
+	} else {
+		r.status.LastRotation = now
+		r.status.LastError = ""
+	}
+	r.status.NextRotation = now.Add(r.status.Interval)
+}
+
+// keyringRotationStatus returns the status of the rotation of the gossip
+// encryption key.
+func (s *Server) keyringRotationStatus() structs.KeyringRotationStatus {
+	r := &s.keyringRotation
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	status := r.status
+	if !status.Enabled {
+		status.Interval = s.config.KeyringRotationInterval
+	}
+	return status
+}
+
+// rotateKeyring installs a new gossip encryption key in the LAN pool and
+// makes it the primary key once every member has installed it. The keys other
+// than the new key and the previous one, made primary by the last rotation,
+// are then removed, and no key is removed when the previous one is unknown.
+// The new key is returned once it is the primary key. The WAN pool is left
+// alone since its key is shared with the other datacenters.
+func (s *Server) rotateKeyring(previous string) (string, error) {
+	if !s.serfLAN.EncryptionEnabled() {
+		return "", fmt.Errorf("gossip encryption is not enabled")
+	}
+
+	raw := make([]byte, keyringRotationKeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate key: %v", err)
+	}
+	key := base64.StdEncoding.EncodeToString(raw)
+
+	// The install fails unless every member answered it.
+	if _, err := s.keyringOp(structs.KeyringInstall, key); err != nil {
+		return "", fmt.Errorf("failed to install key: %v", err)
+	}
+	list, err := s.keyringOp(structs.KeyringList, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to list keys: %v", err)
+	}
+	for _, resp := range list.Responses {
+		if n := resp.Keys[key]; n != resp.NumNodes {
+			return "", fmt.Errorf("new key installed on %d/%d nodes", n, resp.NumNodes)
+		}
+	}
+	if _, err := s.keyringOp(structs.KeyringUse, key); err != nil {
+		return "", fmt.Errorf("failed to use key: %v", err)
+	}
+	if previous == "" {
+		return key, nil
+	}
+
+	// Prune the older keys, including the ones left over by failed rotations.
+	old := make(map[string]struct{})
+	for _, resp := range list.Responses {
+		for k := range resp.Keys {
+			if k != key && k != previous {
+				old[k] = struct{}{}
+			}
+		}
+	}
+	for k := range old {
+		if _, err := s.keyringOp(structs.KeyringRemove, k); err != nil {
+			return key, fmt.Errorf("failed to remove old key: %v", err)
+		}
+	}
+	return key, nil
+}
+
+// keyringOp runs a keyring operation on the LAN pool and its segments, and
+// returns the first error reported by the nodes.
+func (s *Server) keyringOp(op structs.KeyringOp, key string) (*structs.KeyringResponses, error) {
+	args := structs.KeyringRequest{
+		Operation:  op,
+		Key:        key,
+		Datacenter: s.config.Datacenter,
+	}
+	var reply structs.KeyringResponses
+	(&Internal{srv: s}).executeKeyringOp(&args, &reply, false)
+
+	for _, resp := range reply.Responses {
+		if resp.Error != "" {
+			return nil, errors.New(resp.Error)
+		}
+	}
+	return &reply, nil
+}
@@ -0,0 +1,130 @@
+package consul
+
+import (
+	"encoding/base64"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeader_KeyringRotation(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	key := "H1dfkSZOVnP/JUnaBfTzXg=="
+	keyBytes, err := base64.StdEncoding.DecodeString(key)
+	require.NoError(err)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.SerfLANConfig.MemberlistConfig.SecretKey = keyBytes
+		c.SerfWANConfig.MemberlistConfig.SecretKey = keyBytes
+		c.KeyringRotationInterval = 100 * time.Millisecond
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	args := structs.DCSpecificRequest{Datacenter: "dc1"}
+	retry.Run(t, func(r *retry.R) {
+		var status structs.KeyringRotationStatus
+		if err := msgpackrpc.CallWithCodec(codec, "Operator.KeyringRotationStatus", &args, &status); err != nil {
+			r.Fatal(err)
+		}
+		if !status.Enabled || status.Interval != 100*time.Millisecond {
+			r.Fatalf("bad: %#v", status)
+		}
+		if status.LastRotation.IsZero() || status.LastError != "" {
+			r.Fatalf("bad: %#v", status)
+		}
+	})
+
+	// The WAN pool still has the original key.
+	wan, err := s1.KeyManagerWAN().ListKeys()
+	require.NoError(err)
+	require.Equal(map[string]int{key: 1}, wan.Keys)
+}
+
+func TestServer_rotateKeyring(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	key := "H1dfkSZOVnP/JUnaBfTzXg=="
+	keyBytes, err := base64.StdEncoding.DecodeString(key)
+	require.NoError(err)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.SerfLANConfig.MemberlistConfig.SecretKey = keyBytes
+		c.SerfWANConfig.MemberlistConfig.SecretKey = keyBytes
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	lanKeys := func() map[string]int {
+		lan, err := s1.keyringOp(structs.KeyringList, "")
+		require.NoError(err)
+		require.Len(lan.Responses, 1)
+		return lan.Responses[0].Keys
+	}
+
+	// Without a previous rotation no key is removed.
+	key1, err := s1.rotateKeyring("")
+	require.NoError(err)
+	require.Equal(map[string]int{key: 1, key1: 1}, lanKeys())
+
+	// The key of the previous rotation is kept until the next one.
+	key2, err := s1.rotateKeyring(key1)
+	require.NoError(err)
+	require.Equal(map[string]int{key1: 1, key2: 1}, lanKeys())
+
+	key3, err := s1.rotateKeyring(key2)
+	require.NoError(err)
+	require.Equal(map[string]int{key2: 1, key3: 1}, lanKeys())
+}
+
+func TestLeader_KeyringRotation_NoEncryption(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.KeyringRotationInterval = 100 * time.Millisecond
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	retry.Run(t, func(r *retry.R) {
+		status := s1.keyringRotationStatus()
+		if status.LastError != "gossip encryption is not enabled" {
+			r.Fatalf("bad: %#v", status)
+		}
+		if !status.LastRotation.IsZero() {
+			r.Fatalf("bad: %#v", status)
+		}
+	})
+}
+
+func TestOperator_KeyringRotationStatus_Disabled(t *testing.T) {
+	t.Parallel()
+
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	args := structs.DCSpecificRequest{Datacenter: "dc1"}
+	var status structs.KeyringRotationStatus
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Operator.KeyringRotationStatus", &args, &status))
+	require.Equal(t, structs.KeyringRotationStatus{}, status)
+}
@@ -24,14 +24,14 @@ type KVS struct {
 func kvsPreApply(srv *Server, rule acl.Authorizer, op api.KVOp, dirEnt *structs.DirEntry) (bool, error) {
 	// Verify the entry.
 
-	if dirEnt.Key == "" && op != api.KVDeleteTree {
+	if dirEnt.Key == "" && op != api.KVDeleteTree && op != api.KVDeleteTreeCAS {
 		return false, fmt.Errorf("Must provide key")
 	}
 
 	// Apply the ACL policy if any.
 	if rule != nil {
 		switch op {
-		case api.KVDeleteTree:
+		case api.KVDeleteTree, api.KVDeleteTreeCAS:
 			if !rule.KeyWritePrefix(dirEnt.Key) {
 				return false, acl.ErrPermissionDenied
 			}
@@ -57,6 +57,21 @@ func kvsPreApply(srv *Server, rule acl.Authorizer, op api.KVOp, dirEnt *structs.
 		}
 	}
 
+	// Verify the TTL, which is only tracked by the leader.
+	if dirEnt.TTL != "" {
+		ttl, err := time.ParseDuration(dirEnt.TTL)
+		if err != nil {
+			return false, fmt.Errorf("Invalid KV TTL '%s': %v", dirEnt.TTL, err)
+		}
+		if ttl < structs.KVSTTLMin {
+			return false, fmt.Errorf("Invalid KV TTL '%s': must be at least %s", dirEnt.TTL, structs.KVSTTLMin)
+		}
+	}
+
+	if err := structs.ValidateLabels(dirEnt.Labels); err != nil {
+		return false, fmt.Errorf("Invalid KV labels: %v", err)
+	}
+
 	// If this is a lock, we must check for a lock-delay. Since lock-delay
 	// is based on wall-time, each peer would expire the lock-delay at a slightly
 	// different time. This means the enforcement of lock-delay cannot be done
@@ -108,8 +123,17 @@ func (k *KVS) Apply(args *structs.KVSRequest, reply *bool) error {
 	}
 
 	// Check if the return type is a bool.
+	applied := true
 	if respBool, ok := resp.(bool); ok {
 		*reply = respBool
+		applied = respBool
+	}
+
+	// Restart the expiration of the key if it was written.
+	if applied && args.Op != api.KVDeleteTree {
+		if err := k.srv.resetKVSTimer(args.DirEnt.Key, nil); err != nil {
+			k.srv.logger.Printf("[ERR] consul.kvs: Failed to reset the TTL of %s: %v", args.DirEnt.Key, err)
+		}
 	}
 	return nil
 }
@@ -168,6 +192,17 @@ func (k *KVS) List(args *structs.KeyRequest, reply *structs.IndexedDirEntries) e
 		return acl.ErrPermissionDenied
 	}
 
+	var filter *structs.Filter
+	if args.Filter != "" {
+		if filter, err = structs.CreateFilter(args.Filter, structs.DirEntries(nil)); err != nil {
+			return err
+		}
+	}
+	selector, err := structs.ParseLabelSelector(args.LabelSelector)
+	if err != nil {
+		return err
+	}
+
 	return k.srv.blockingQuery(
 		&args.QueryOptions,
 		&reply.QueryMeta,
@@ -179,6 +214,14 @@ func (k *KVS) List(args *structs.KeyRequest, reply *structs.IndexedDirEntries) e
 			if aclToken != nil {
 				ent = FilterDirEnt(aclToken, ent)
 			}
+			if filter != nil {
+				raw, err := filter.Execute(ent)
+				if err != nil {
+					return err
+				}
+				ent = raw.(structs.DirEntries)
+			}
+			ent = selectDirEntries(selector, ent)
 
 			if len(ent) == 0 {
 				// Must provide non-zero index to prevent blocking
@@ -197,6 +240,59 @@ func (k *KVS) List(args *structs.KeyRequest, reply *structs.IndexedDirEntries) e
 		})
 }
 
+// ListDiff is used to get the changes to the keys under a prefix since the
+// index given as MinQueryIndex.
+func (k *KVS) ListDiff(args *structs.KeyRequest, reply *structs.IndexedDirEntriesDiff) error {
+	if done, err := k.srv.forward("KVS.ListDiff", args, args, reply); done {
+		return err
+	}
+
+	aclToken, err := k.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+
+	if aclToken != nil && k.srv.config.ACLEnableKeyListPolicy && !aclToken.KeyList(args.Key) {
+		return acl.ErrPermissionDenied
+	}
+
+	since := args.MinQueryIndex
+	return k.srv.blockingQuery(
+		&args.QueryOptions,
+		&reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, diff, err := state.KVSListDiff(ws, args.Key, since)
+			if err != nil {
+				return err
+			}
+
+			if aclToken != nil {
+				// Send the whole tree rather than the deleted prefixes the
+				// token can't read.
+				for _, prefix := range diff.DeletedPrefixes {
+					if !aclToken.KeyRead(prefix) {
+						_, ent, err := state.KVSList(nil, args.Key)
+						if err != nil {
+							return err
+						}
+						diff = &structs.DirEntriesDiff{Reset: true, Entries: ent}
+						break
+					}
+				}
+				diff.Entries = FilterDirEnt(aclToken, diff.Entries)
+			}
+
+			// Must provide non-zero index to prevent blocking
+			// Index 1 is impossible anyways (due to Raft internals)
+			if index == 0 {
+				index = 1
+			}
+			reply.Index = index
+			reply.DirEntriesDiff = *diff
+			return nil
+		})
+}
+
 // ListKeys is used to list all keys with a given prefix to a separator.
 func (k *KVS) ListKeys(args *structs.KeyListRequest, reply *structs.IndexedKeyList) error {
 	if done, err := k.srv.forward("KVS.ListKeys", args, args, reply); done {
@@ -2,6 +2,8 @@ package consul
 
 import (
 	"os"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -295,6 +297,151 @@ func TestKVSEndpoint_List(t *testing.T) {
 	}
 }
 
+func TestKVSEndpoint_List_Filter(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	entries := []structs.DirEntry{
+		{Key: "/test/key1", Flags: 1, Value: []byte("env=prod")},
+		{Key: "/test/key2", Flags: 2, Value: []byte("env=prod")},
+		{Key: "/test/sub/key3", Flags: 2, Value: []byte("env=dev")},
+	}
+	for _, entry := range entries {
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt:     entry,
+		}
+		var out bool
+		if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	list := func(filter string) (structs.IndexedDirEntries, error) {
+		getR := structs.KeyRequest{
+			Datacenter:   "dc1",
+			Key:          "/test",
+			QueryOptions: structs.QueryOptions{Filter: filter},
+		}
+		var dirent structs.IndexedDirEntries
+		err := msgpackrpc.CallWithCodec(codec, "KVS.List", &getR, &dirent)
+		return dirent, err
+	}
+
+	dirent, err := list(`Flags == 2 and Value contains prod`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if dirent.Index == 0 {
+		t.Fatalf("Bad: %v", dirent)
+	}
+	if len(dirent.Entries) != 1 || dirent.Entries[0].Key != "/test/key2" {
+		t.Fatalf("Bad: %v", dirent.Entries)
+	}
+
+	dirent, err = list(`Key matches "^/test/sub/" or Flags == 1`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(dirent.Entries) != 2 ||
+		dirent.Entries[0].Key != "/test/key1" || dirent.Entries[1].Key != "/test/sub/key3" {
+		t.Fatalf("Bad: %v", dirent.Entries)
+	}
+
+	// Nothing matching still returns an index to block on.
+	dirent, err = list(`Flags == 3`)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if dirent.Index == 0 || len(dirent.Entries) != 0 {
+		t.Fatalf("Bad: %v", dirent)
+	}
+
+	// Invalid filters are rejected.
+	if _, err := list(`Flags == prod`); err == nil || !strings.Contains(err.Error(), "Invalid filter expression") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestKVSEndpoint_List_LabelSelector(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	entries := []structs.DirEntry{
+		{Key: "/test/key1", Labels: map[string]string{"team": "payments", "env": "prod"}},
+		{Key: "/test/key2", Labels: map[string]string{"team": "payments", "env": "dev"}},
+		{Key: "/test/key3"},
+	}
+	for _, entry := range entries {
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         api.KVSet,
+			DirEnt:     entry,
+		}
+		var out bool
+		if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	list := func(selector string) (structs.IndexedDirEntries, error) {
+		getR := structs.KeyRequest{
+			Datacenter:   "dc1",
+			Key:          "/test",
+			QueryOptions: structs.QueryOptions{LabelSelector: selector},
+		}
+		var dirent structs.IndexedDirEntries
+		err := msgpackrpc.CallWithCodec(codec, "KVS.List", &getR, &dirent)
+		return dirent, err
+	}
+
+	dirent, err := list("team=payments,env!=dev")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(dirent.Entries) != 1 || dirent.Entries[0].Key != "/test/key1" {
+		t.Fatalf("Bad: %v", dirent.Entries)
+	}
+	if !reflect.DeepEqual(dirent.Entries[0].Labels, entries[0].Labels) {
+		t.Fatalf("Bad: %v", dirent.Entries[0].Labels)
+	}
+
+	dirent, err = list("!team")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(dirent.Entries) != 1 || dirent.Entries[0].Key != "/test/key3" {
+		t.Fatalf("Bad: %v", dirent.Entries)
+	}
+
+	// Invalid selectors and labels are rejected.
+	if _, err := list("team=pay ments"); err == nil || !strings.Contains(err.Error(), "invalid label value") {
+		t.Fatalf("err: %v", err)
+	}
+	arg := structs.KVSRequest{
+		Datacenter: "dc1",
+		Op:         api.KVSet,
+		DirEnt:     structs.DirEntry{Key: "/test/key4", Labels: map[string]string{"": "x"}},
+	}
+	var out bool
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err == nil || !strings.Contains(err.Error(), "Invalid KV labels") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestKVSEndpoint_List_Blocking(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -606,6 +753,91 @@ key "zip" {
 
 }
 
+func TestKVSEndpoint_ListDiff_ACLDeny(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	apply := func(op api.KVOp, key string) {
+		arg := structs.KVSRequest{
+			Datacenter:   "dc1",
+			Op:           op,
+			DirEnt:       structs.DirEntry{Key: key},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out bool
+		if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	for _, key := range []string{"bar", "foo", "test"} {
+		apply(api.KVSet, key)
+	}
+
+	arg := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			Name:  "User token",
+			Type:  structs.ACLTokenTypeClient,
+			Rules: testListRules,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var id string
+	if err := msgpackrpc.CallWithCodec(codec, "ACL.Apply", &arg, &id); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	listDiff := func(since uint64) structs.IndexedDirEntriesDiff {
+		getR := structs.KeyRequest{
+			Datacenter: "dc1",
+			Key:        "",
+			QueryOptions: structs.QueryOptions{
+				Token:         id,
+				MinQueryIndex: since,
+				MaxQueryTime:  time.Second,
+			},
+		}
+		var out structs.IndexedDirEntriesDiff
+		if err := msgpackrpc.CallWithCodec(codec, "KVS.ListDiff", &getR, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return out
+	}
+
+	// The first query returns the readable keys.
+	diff := listDiff(0)
+	if !diff.Reset || len(diff.Entries) != 2 || diff.Entries[0].Key != "foo" || diff.Entries[1].Key != "test" {
+		t.Fatalf("bad: %#v", diff)
+	}
+	since := diff.Index
+
+	// A readable deleted key is reported.
+	apply(api.KVDelete, "test")
+	diff = listDiff(since)
+	if diff.Reset || len(diff.Entries) != 0 || len(diff.DeletedPrefixes) != 1 || diff.DeletedPrefixes[0] != "test" {
+		t.Fatalf("bad: %#v", diff)
+	}
+
+	// An unreadable one turns the diff into a full listing.
+	apply(api.KVDelete, "bar")
+	diff = listDiff(since)
+	if !diff.Reset || len(diff.DeletedPrefixes) != 0 || len(diff.Entries) != 1 || diff.Entries[0].Key != "foo" {
+		t.Fatalf("bad: %#v", diff)
+	}
+}
+
 func TestKVSEndpoint_ListKeys(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -0,0 +1,120 @@
+package consul
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+)
+
+// initializeKVSTimers is used when a leader is newly elected to reset the
+// expiration timers of all the keys having a TTL.
+func (s *Server) initializeKVSTimers() error {
+	state := s.fsm.State()
+	_, entries, err := state.KVSListTTL(nil)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := s.resetKVSTimer(entry.Key, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetKVSTimer is used to restart the TTL of a key after it was written.
+// The timer is stopped if the key was deleted or no longer has a TTL. The
+// entry will be faulted in if not given.
+func (s *Server) resetKVSTimer(key string, entry *structs.DirEntry) error {
+	// Fault the entry in if not given
+	if entry == nil {
+		state := s.fsm.State()
+		_, e, err := state.KVSGet(nil, key)
+		if err != nil {
+			return err
+		}
+		entry = e
+	}
+
+	// The timer of the previous write of the key must not fire, since it
+	// would try to delete an older version of the key.
+	s.kvsTimers.Stop(key)
+	if entry == nil || entry.TTL == "" {
+		return nil
+	}
+
+	ttl, err := time.ParseDuration(entry.TTL)
+	if err != nil {
+		return fmt.Errorf("Invalid KV TTL '%s': %v", entry.TTL, err)
+	}
+	index := entry.ModifyIndex
+	s.kvsTimers.ResetOrCreate(key, ttl, func() { s.expireKVS(key, index) })
+	return nil
+}
+
+// expireKVS is invoked when the TTL of a key is reached. The key is only
+// deleted if it wasn't modified since the given index, which leaves a
+// tombstone reaped by the tombstone GC like any other delete. Otherwise the
+// timer is armed again for the current version of the key, since it may have
+// been modified without going through KVS.Apply, e.g. when the session
+// holding its lock was invalidated.
+func (s *Server) expireKVS(key string, index uint64) {
+	defer metrics.MeasureSince([]string{"kvs_ttl", "expire"}, time.Now())
+
+	// Clear the timer
+	s.kvsTimers.Del(key)
+
+	args := structs.KVSRequest{
+		Datacenter: s.config.Datacenter,
+		Op:         api.KVDeleteCAS,
+		DirEnt: structs.DirEntry{
+			Key: key,
+			RaftIndex: structs.RaftIndex{
+				ModifyIndex: index,
+			},
+		},
+	}
+
+	// Retry with exponential backoff to delete the key
+	for attempt := uint(0); attempt < maxInvalidateAttempts; attempt++ {
+		resp, err := s.raftApply(structs.KVSRequestType, args)
+		if err == nil {
+			if deleted, ok := resp.(bool); ok && deleted {
+				s.logger.Printf("[DEBUG] consul.kvs: Key %s TTL expired", key)
+				return
+			}
+			if err := s.resetKVSTimer(key, nil); err != nil {
+				s.logger.Printf("[ERR] consul.kvs: Failed to reset the TTL of %s: %v", key, err)
+			}
+			return
+		}
+
+		s.logger.Printf("[ERR] consul.kvs: Expiration failed: %v", err)
+		time.Sleep((1 << attempt) * invalidateRetryBase)
+	}
+	s.logger.Printf("[ERR] consul.kvs: maximum expiration attempts reached for key: %s", key)
+}
+
+// clearAllKVSTimers is used when a leader is stepping down and we no longer
+// need to track any key expirations.
+func (s *Server) clearAllKVSTimers() error {
+	s.kvsTimers.StopAll()
+	return nil
+}
+
+// kvsStats is a long running routine used to capture the number of keys
+// having a TTL tracked by the leader.
+func (s *Server) kvsStats() {
+	for {
+		select {
+		case <-time.After(5 * time.Second):
+			metrics.SetGauge([]string{"kvs_ttl", "active"}, float32(s.kvsTimers.Len()))
+
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package consul
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+)
+
+func TestInitializeKVSTimers(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	state := s1.fsm.State()
+	if err := state.KVSSet(100, &structs.DirEntry{Key: "foo", TTL: "10s"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := state.KVSSet(101, &structs.DirEntry{Key: "bar"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Reset the key timers
+	if err := s1.initializeKVSTimers(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Check that only the key with a TTL has a timer
+	if s1.kvsTimers.Get("foo") == nil {
+		t.Fatalf("missing key timer")
+	}
+	if s1.kvsTimers.Get("bar") != nil {
+		t.Fatalf("unexpected key timer")
+	}
+
+	// Stepping down clears the timers
+	if err := s1.clearAllKVSTimers(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s1.kvsTimers.Len() != 0 {
+		t.Fatalf("bad: %d", s1.kvsTimers.Len())
+	}
+}
+
+func TestKVS_Apply_TTL(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	apply := func(op api.KVOp, ent structs.DirEntry) (bool, error) {
+		arg := structs.KVSRequest{
+			Datacenter: "dc1",
+			Op:         op,
+			DirEnt:     ent,
+		}
+		var out bool
+		err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out)
+		return out, err
+	}
+
+	// Invalid TTLs are rejected
+	for _, ttl := range []string{"foo", "500ms", "-1s"} {
+		_, err := apply(api.KVSet, structs.DirEntry{Key: "test", TTL: ttl})
+		if err == nil || !strings.Contains(err.Error(), "Invalid KV TTL") {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	// A key written again without a TTL is kept
+	if _, err := apply(api.KVSet, structs.DirEntry{Key: "kept", TTL: "1s"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s1.kvsTimers.Get("kept") == nil {
+		t.Fatalf("missing key timer")
+	}
+	if _, err := apply(api.KVSet, structs.DirEntry{Key: "kept"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if s1.kvsTimers.Get("kept") != nil {
+		t.Fatalf("unexpected key timer")
+	}
+
+	// A failed CAS doesn't restart the TTL
+	if _, err := apply(api.KVSet, structs.DirEntry{Key: "test", TTL: "1s"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	tm := s1.kvsTimers.Get("test")
+	if tm == nil {
+		t.Fatalf("missing key timer")
+	}
+	ok, err := apply(api.KVCAS, structs.DirEntry{Key: "test", TTL: "1h", RaftIndex: structs.RaftIndex{ModifyIndex: 1}})
+	if err != nil || ok {
+		t.Fatalf("bad: %v %v", ok, err)
+	}
+	if s1.kvsTimers.Get("test") != tm {
+		t.Fatalf("key timer changed")
+	}
+
+	// The key expires and leaves a tombstone
+	store := s1.fsm.State()
+	retry.Run(t, func(r *retry.R) {
+		_, ent, err := store.KVSGet(nil, "test")
+		if err != nil {
+			r.Fatal(err)
+		}
+		if ent != nil {
+			r.Fatalf("bad: %#v", ent)
+		}
+	})
+	if s1.kvsTimers.Get("test") != nil {
+		t.Fatalf("unexpected key timer")
+	}
+	if _, ent, err := store.KVSGet(nil, "kept"); err != nil || ent == nil {
+		t.Fatalf("bad: %#v %v", ent, err)
+	}
+	snap := store.Snapshot()
+	defer snap.Close()
+	stones, err := snap.Tombstones()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if stone := stones.Next(); stone == nil || stone.(*state.Tombstone).Key != "test" {
+		t.Fatalf("bad: %#v", stone)
+	}
+}
+
+func TestKVS_TTL_SessionInvalidated(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	store := s1.fsm.State()
+	if err := store.EnsureNode(1000, &structs.Node{Node: "foo", Address: "127.0.0.1"}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	session := &structs.Session{ID: generateUUID(), Node: "foo"}
+	if err := store.SessionCreate(1001, session); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Lock a key with a TTL
+	arg := structs.KVSRequest{
+		Datacenter: "dc1",
+		Op:         api.KVLock,
+		DirEnt:     structs.DirEntry{Key: "locked", TTL: "1s", Session: session.ID},
+	}
+	var out bool
+	if err := msgpackrpc.CallWithCodec(codec, "KVS.Apply", &arg, &out); err != nil || !out {
+		t.Fatalf("bad: %v %v", out, err)
+	}
+
+	// Invalidating the session releases the lock, which modifies the key
+	// behind the back of the timer
+	if err := store.SessionDestroy(1002, session.ID); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	_, ent, err := store.KVSGet(nil, "locked")
+	if err != nil || ent == nil || ent.Session != "" {
+		t.Fatalf("bad: %#v %v", ent, err)
+	}
+
+	// The key still expires
+	retry.Run(t, func(r *retry.R) {
+		_, ent, err := store.KVSGet(nil, "locked")
+		if err != nil {
+			r.Fatal(err)
+		}
+		if ent != nil {
+			r.Fatalf("bad: %#v", ent)
+		}
+	})
+	if s1.kvsTimers.Get("locked") != nil {
+		t.Fatalf("unexpected key timer")
+	}
+}
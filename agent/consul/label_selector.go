@@ -0,0 +1,54 @@
+package consul
+
+import (
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// The helpers below keep the results of the list endpoints matching the
+// label selector of the request. They are applied after the ACL filtering,
+// like the filter expressions, and return the results as-is when the
+// selector is empty.
+
+// selectServiceNodes returns the service instances whose labels match the
+// selector.
+func selectServiceNodes(selector structs.LabelSelector, nodes structs.ServiceNodes) structs.ServiceNodes {
+	if len(selector) == 0 {
+		return nodes
+	}
+	var selected structs.ServiceNodes
+	for _, node := range nodes {
+		if selector.Matches(node.ServiceLabels) {
+			selected = append(selected, node)
+		}
+	}
+	return selected
+}
+
+// selectCheckServiceNodes returns the service instances and their checks
+// whose service labels match the selector.
+func selectCheckServiceNodes(selector structs.LabelSelector, nodes structs.CheckServiceNodes) structs.CheckServiceNodes {
+	if len(selector) == 0 {
+		return nodes
+	}
+	var selected structs.CheckServiceNodes
+	for _, node := range nodes {
+		if node.Service != nil && selector.Matches(node.Service.Labels) {
+			selected = append(selected, node)
+		}
+	}
+	return selected
+}
+
+// selectDirEntries returns the KV entries whose labels match the selector.
+func selectDirEntries(selector structs.LabelSelector, ents structs.DirEntries) structs.DirEntries {
+	if len(selector) == 0 {
+		return ents
+	}
+	var selected structs.DirEntries
+	for _, ent := range ents {
+		if selector.Matches(ent.Labels) {
+			selected = append(selected, ent)
+		}
+	}
+	return selected
+}
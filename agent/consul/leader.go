@@ -37,6 +37,10 @@ var (
 	// caRootPruneInterval is how often we check for stale CARoots to remove.
 	caRootPruneInterval = time.Hour
 
+	// aclPolicyReapInterval is how often we check for expired ACL policies
+	// to delete. They stop granting access when they expire regardless.
+	aclPolicyReapInterval = time.Minute
+
 	// minAutopilotVersion is the minimum Consul version in which Autopilot features
 	// are supported.
 	minAutopilotVersion = version.Must(version.NewVersion("0.8.0"))
@@ -261,6 +265,12 @@ func (s *Server) establishLeadership() error {
 		return err
 	}
 
+	// Setup the key expiration timers in the same way as the session timers,
+	// keys with a TTL are not expired before it.
+	if err := s.initializeKVSTimers(); err != nil {
+		return err
+	}
+
 	s.getOrCreateAutopilotConfig()
 	s.autopilot.Start()
 
@@ -273,6 +283,8 @@ func (s *Server) establishLeadership() error {
 
 	s.startCARootPruning()
 
+	s.startKeyringRotation()
+
 	s.setConsistentReadReady()
 	return nil
 }
@@ -289,14 +301,23 @@ func (s *Server) revokeLeadership() error {
 		return err
 	}
 
+	// Clear the key expiration timers for the same reason.
+	if err := s.clearAllKVSTimers(); err != nil {
+		return err
+	}
+
 	s.stopEnterpriseLeader()
 
 	s.stopCARootPruning()
 
+	s.stopKeyringRotation()
+
 	s.setCAProvider(nil, nil)
 
 	s.stopACLUpgrade()
 
+	s.stopACLPolicyReaping()
+
 	s.resetConsistentReadReady()
 	s.autopilot.Stop()
 	return nil
@@ -560,6 +581,7 @@ func (s *Server) initializeACLs(upgrade bool) error {
 			}
 		}
 		s.startACLUpgrade()
+		s.startACLPolicyReaping()
 	} else {
 		if s.UseLegacyACLs() && !upgrade {
 			if s.IsACLReplicationEnabled() {
@@ -678,6 +700,90 @@ func (s *Server) stopACLUpgrade() {
 	s.aclUpgradeEnabled = false
 }
 
+// startACLPolicyReaping starts a goroutine that deletes the ACL policies
+// whose DeleteAfter time has passed.
+func (s *Server) startACLPolicyReaping() {
+	s.aclPolicyReapingLock.Lock()
+	defer s.aclPolicyReapingLock.Unlock()
+
+	if s.aclPolicyReapingEnabled {
+		return
+	}
+
+	s.aclPolicyReapingCh = make(chan struct{})
+
+	go func(stopCh chan struct{}) {
+		ticker := time.NewTicker(aclPolicyReapInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				if err := s.reapExpiredACLPolicies(); err != nil {
+					s.logger.Printf("[ERR] acl: error deleting expired policies: %v", err)
+				}
+			}
+		}
+	}(s.aclPolicyReapingCh)
+
+	s.aclPolicyReapingEnabled = true
+}
+
+// reapExpiredACLPolicies deletes the ACL policies that have expired.
+func (s *Server) reapExpiredACLPolicies() error {
+	_, policies, err := s.fsm.State().ACLPolicyList(nil)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var ids []string
+	for _, policy := range policies {
+		if policy.ID == structs.ACLPolicyGlobalManagementID || !policy.IsExpired(now) {
+			continue
+		}
+		s.logger.Printf("[INFO] acl: deleting expired policy %q (ID: %s)", policy.Name, policy.ID)
+		ids = append(ids, policy.ID)
+	}
+
+	// Return early if there's nothing to delete.
+	if len(ids) == 0 {
+		return nil
+	}
+
+	req := structs.ACLPolicyBatchDeleteRequest{
+		PolicyIDs: ids,
+	}
+	resp, err := s.raftApply(structs.ACLPolicyDeleteRequestType, &req)
+	if err != nil {
+		return fmt.Errorf("Failed to apply policy delete request: %v", err)
+	}
+
+	for _, id := range ids {
+		s.acls.cache.RemovePolicy(id)
+	}
+
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+	return nil
+}
+
+// stopACLPolicyReaping stops the expired ACL policies deletion.
+func (s *Server) stopACLPolicyReaping() {
+	s.aclPolicyReapingLock.Lock()
+	defer s.aclPolicyReapingLock.Unlock()
+
+	if !s.aclPolicyReapingEnabled {
+		return
+	}
+
+	close(s.aclPolicyReapingCh)
+	s.aclPolicyReapingEnabled = false
+}
+
 func (s *Server) startLegacyACLReplication() {
 	s.aclReplicationLock.Lock()
 	defer s.aclReplicationLock.Unlock()
@@ -879,7 +985,7 @@ func (s *Server) getOrCreateAutopilotConfig() *autopilot.Config {
 // when setting up the CA during establishLeadership
 func (s *Server) initializeCAConfig() (*structs.CAConfiguration, error) {
 	state := s.fsm.State()
-	_, config, err := state.CAConfig()
+	_, config, err := state.CAConfig(nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1087,7 +1193,7 @@ func (s *Server) pruneCARoots() error {
 		return err
 	}
 
-	_, caConf, err := state.CAConfig()
+	_, caConf, err := state.CAConfig(nil)
 	if err != nil {
 		return err
 	}
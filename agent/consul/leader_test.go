@@ -1003,6 +1003,52 @@ func TestLeader_ACL_Initialization(t *testing.T) {
 	}
 }
 
+func TestLeader_ACLPolicyReaping(t *testing.T) {
+	t.Parallel()
+
+	aclPolicyReapInterval = 50 * time.Millisecond
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	deleteAfter := time.Now().Add(500 * time.Millisecond)
+	req := structs.ACLPolicySetRequest{
+		Datacenter: "dc1",
+		Policy: structs.ACLPolicy{
+			Name:        "temporary",
+			Rules:       `key_prefix "" { policy = "write" }`,
+			DeleteAfter: &deleteAfter,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var policy structs.ACLPolicy
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "ACL.PolicySet", &req, &policy))
+
+	kept, err := upsertTestPolicy(codec, "root", "dc1")
+	require.NoError(t, err)
+
+	retry.Run(t, func(r *retry.R) {
+		_, p, err := s1.fsm.State().ACLPolicyGetByID(nil, policy.ID)
+		require.NoError(r, err)
+		if p != nil {
+			r.Fatal("policy not deleted yet")
+		}
+	})
+
+	_, p, err := s1.fsm.State().ACLPolicyGetByID(nil, kept.ID)
+	require.NoError(t, err)
+	require.NotNil(t, p)
+}
+
 func TestLeader_CARootPruning(t *testing.T) {
 	t.Parallel()
 
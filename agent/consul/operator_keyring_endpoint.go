@@ -0,0 +1,30 @@
+package consul
+
+import (
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// KeyringRotationStatus returns the status of the automatic rotation of the
+// gossip encryption key, which is run by the leader.
+func (op *Operator) KeyringRotationStatus(args *structs.DCSpecificRequest, reply *structs.KeyringRotationStatus) error {
+	// This must be sent to the leader, so we fix the args since we are
+	// re-using a structure where we don't support all the options.
+	args.RequireConsistent = true
+	args.AllowStale = false
+	if done, err := op.srv.forward("Operator.KeyringRotationStatus", args, args, reply); done {
+		return err
+	}
+
+	// This action requires keyring read access.
+	rule, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.KeyringRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	*reply = op.srv.keyringRotationStatus()
+	return nil
+}
@@ -0,0 +1,42 @@
+package consul
+
+import (
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+// Usage returns the number of entries of each kind in the state of the
+// datacenter, for capacity planning. This supports blocking queries and the
+// stale query mode.
+func (op *Operator) Usage(args *structs.DCSpecificRequest, reply *structs.UsageResponse) error {
+	if done, err := op.srv.forward("Operator.Usage", args, args, reply); done {
+		return err
+	}
+
+	// This action requires operator read access.
+	rule, err := op.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.OperatorRead() {
+		return acl.ErrPermissionDenied
+	}
+
+	return op.srv.blockingQuery(
+		&args.QueryOptions, &reply.QueryMeta,
+		func(ws memdb.WatchSet, state *state.Store) error {
+			index, usage, err := state.Usage(ws)
+			if err != nil {
+				return err
+			}
+
+			reply.Index = index
+			reply.Usage = map[string]structs.Usage{
+				op.srv.config.Datacenter: *usage,
+			}
+			return nil
+		},
+	)
+}
@@ -0,0 +1,61 @@
+package consul
+
+import (
+	"os"
+	"testing"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperator_Usage(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// Register a service.
+	reg := structs.RegisterRequest{
+		Datacenter:   "dc1",
+		Node:         "foo",
+		Address:      "127.0.0.1",
+		Service:      &structs.NodeService{ID: "web", Service: "web"},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var out struct{}
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.Register", &reg, &out))
+
+	// The usage requires operator read permissions.
+	arg := structs.DCSpecificRequest{Datacenter: "dc1"}
+	var reply structs.UsageResponse
+	err := msgpackrpc.CallWithCodec(codec, "Operator.Usage", &arg, &reply)
+	require.True(acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	arg.Token = "root"
+	require.NoError(msgpackrpc.CallWithCodec(codec, "Operator.Usage", &arg, &reply))
+	usage, ok := reply.Usage["dc1"]
+	require.True(ok)
+	require.NotZero(reply.Index)
+
+	// The server and the registered node, along with the consul service of
+	// the server.
+	require.Equal(2, usage.Nodes)
+	require.Equal(2, usage.Services)
+	require.Equal(2, usage.ServiceInstances)
+
+	// The master and anonymous tokens.
+	require.Equal(2, usage.ACLTokens)
+}
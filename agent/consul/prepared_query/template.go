@@ -190,6 +190,11 @@ func (ct *CompiledTemplate) Render(name string, source structs.QuerySource) (*st
 		return nil, err
 	}
 
+	// Drop the metadata filters whose key rendered empty, so templates can
+	// filter on metadata keyed by a part of the name only when it's given.
+	delete(query.Service.NodeMeta, "")
+	delete(query.Service.ServiceMeta, "")
+
 	if ct.removeEmptyTags {
 		tags := make([]string, 0, len(query.Service.Tags))
 		for _, tag := range query.Service.Tags {
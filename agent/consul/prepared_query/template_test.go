@@ -397,3 +397,44 @@ func TestTemplate_Render(t *testing.T) {
 		}
 	}
 }
+
+func TestTemplate_Render_MetaKeys(t *testing.T) {
+	// Filter on the service meta named in the query name, if any.
+	query := &structs.PreparedQuery{
+		Name: "",
+		Template: structs.QueryTemplateOptions{
+			Type:   structs.QueryTemplateTypeNamePrefixMatch,
+			Regexp: "^(?:([^-]+)-([^.]+)\\.)?(.+)$",
+		},
+		Service: structs.ServiceQuery{
+			Service: "${match(3)}",
+			ServiceMeta: map[string]string{
+				"${match(1)}": "${match(2)}",
+				"env":         "prod",
+			},
+		},
+	}
+	ct, err := Compile(query)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	actual, err := ct.Render("version-v2.web", structs.QuerySource{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected := map[string]string{"version": "v2", "env": "prod"}
+	if actual.Service.Service != "web" || !reflect.DeepEqual(actual.Service.ServiceMeta, expected) {
+		t.Fatalf("bad: %#v", actual.Service)
+	}
+
+	// The filter is dropped when the name doesn't specify any meta.
+	actual, err = ct.Render("web", structs.QuerySource{})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	expected = map[string]string{"env": "prod"}
+	if actual.Service.Service != "web" || !reflect.DeepEqual(actual.Service.ServiceMeta, expected) {
+		t.Fatalf("bad: %#v", actual.Service)
+	}
+}
@@ -35,18 +35,36 @@ func visit(path string, v reflect.Value, t reflect.Type, fn visitor) error {
 			}
 		}
 	case reflect.Map:
-		for _, key := range v.MapKeys() {
+		// The keys are visited too, with a path in braces. The entries are
+		// put back once all of them are visited since the callback may
+		// change a key into another key of the map.
+		keys := v.MapKeys()
+		newKeys := make([]reflect.Value, len(keys))
+		newValues := make([]reflect.Value, len(keys))
+		for i, key := range keys {
 			value := v.MapIndex(key)
 
+			newKey := reflect.New(key.Type()).Elem()
+			newKey.SetString(key.String())
+			if err := visit(fmt.Sprintf("%s{%s}", path, key.String()), newKey, newKey.Type(), fn); err != nil {
+				return err
+			}
+
 			newValue := reflect.New(value.Type()).Elem()
 			newValue.SetString(value.String())
-
 			if err := visit(fmt.Sprintf("%s[%s]", path, key.String()), newValue, newValue.Type(), fn); err != nil {
 				return err
 			}
 
-			// overwrite the entry in case it was modified by the callback
-			v.SetMapIndex(key, newValue)
+			newKeys[i], newValues[i] = newKey, newValue
+		}
+
+		// overwrite the entries in case they were modified by the callback
+		for _, key := range keys {
+			v.SetMapIndex(key, reflect.Value{})
+		}
+		for i, key := range newKeys {
+			v.SetMapIndex(key, newValues[i])
 		}
 	}
 	return nil
@@ -36,6 +36,8 @@ func TestWalk_ServiceQuery(t *testing.T) {
 		".Near:_agent",
 		".NodeMeta[foo]:bar",
 		".NodeMeta[role]:server",
+		".NodeMeta{foo}:foo",
+		".NodeMeta{role}:role",
 		".Service:the-service",
 		".Tags[0]:tag1",
 		".Tags[1]:tag2",
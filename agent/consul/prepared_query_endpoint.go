@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"time"
 
@@ -277,6 +278,133 @@ func (p *PreparedQuery) List(args *structs.DCSpecificRequest, reply *structs.Ind
 		})
 }
 
+// Stats returns the execution stats of the prepared queries: the stats stored
+// in the state store plus the executions not yet written to it by each server
+// in the datacenter. Queries that were never executed are included so unused
+// queries can be found.
+func (p *PreparedQuery) Stats(args *structs.DCSpecificRequest, reply *structs.PreparedQueryStatsResponse) error {
+	if done, err := p.srv.forward("PreparedQuery.Stats", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"prepared-query", "stats"}, time.Now())
+
+	p.srv.setQueryMeta(&reply.QueryMeta)
+
+	state := p.srv.fsm.State()
+	index, queries, err := state.PreparedQueryList(nil)
+	if err != nil {
+		return err
+	}
+	statsIndex, stored, err := state.PreparedQueryStatsList(nil)
+	if err != nil {
+		return err
+	}
+	if statsIndex > index {
+		index = statsIndex
+	}
+
+	merged := make(map[string]*structs.PreparedQueryStats)
+	mergePreparedQueryStats(merged, stored)
+	mergePreparedQueryStats(merged, p.srv.preparedQueryStats.pending())
+
+	// Queries can be executed on any server when stale reads are allowed,
+	// so ask the other servers for the executions they haven't written yet.
+	for _, server := range p.srv.otherLANServers() {
+		var out structs.PreparedQueryStatsResponse
+		err := p.srv.connPool.RPC(p.srv.config.Datacenter, server.Addr, server.Version,
+			"PreparedQuery.LocalStats", server.UseTLS, args, &out)
+		if err != nil {
+			p.srv.logger.Printf("[WARN] consul.prepared_query: Failed to get stats from server %q: %v", server.Name, err)
+			continue
+		}
+		mergePreparedQueryStats(merged, out.Stats)
+	}
+
+	// Only return the stats of the queries the token can read.
+	readable := &structs.IndexedPreparedQueries{Queries: queries}
+	if err := p.srv.filterACL(args.Token, readable); err != nil {
+		return err
+	}
+
+	reply.Index = index
+	reply.Stats = make([]*structs.PreparedQueryStats, 0, len(readable.Queries))
+	for _, query := range readable.Queries {
+		s := &structs.PreparedQueryStats{ID: query.ID}
+		if cur, ok := merged[query.ID]; ok {
+			*s = *cur
+		}
+		s.Name = query.Name
+		reply.Stats = append(reply.Stats, s)
+	}
+	sort.Slice(reply.Stats, func(i, j int) bool { return reply.Stats[i].ID < reply.Stats[j].ID })
+	return nil
+}
+
+// LocalStats returns the executions of the prepared queries recorded by this
+// server which aren't written to the state store yet. It's used by Stats to
+// collect the executions of each server and is never forwarded.
+func (p *PreparedQuery) LocalStats(args *structs.DCSpecificRequest, reply *structs.PreparedQueryStatsResponse) error {
+	p.srv.setQueryMeta(&reply.QueryMeta)
+
+	_, queries, err := p.srv.fsm.State().PreparedQueryList(nil)
+	if err != nil {
+		return err
+	}
+
+	// Only return the stats of the queries the token can read.
+	readable := &structs.IndexedPreparedQueries{Queries: queries}
+	if err := p.srv.filterACL(args.Token, readable); err != nil {
+		return err
+	}
+	allowed := make(map[string]struct{}, len(readable.Queries))
+	for _, query := range readable.Queries {
+		allowed[query.ID] = struct{}{}
+	}
+
+	reply.Stats = make([]*structs.PreparedQueryStats, 0, len(allowed))
+	for _, s := range p.srv.preparedQueryStats.pending() {
+		if _, ok := allowed[s.ID]; ok {
+			reply.Stats = append(reply.Stats, s)
+		}
+	}
+	return nil
+}
+
+// ApplyStats adds the executions recorded by a server of the datacenter to
+// the stored stats of the prepared queries. Only servers write the stats,
+// using the agent token, and the method is refused over the connections
+// which don't come from a server, see serverOnlyMethods.
+func (p *PreparedQuery) ApplyStats(args *structs.PreparedQueryStatsRequest, reply *struct{}) error {
+	if done, err := p.srv.forward("PreparedQuery.ApplyStats", args, args, reply); done {
+		return err
+	}
+	defer metrics.MeasureSince([]string{"prepared-query", "apply_stats"}, time.Now())
+
+	rule, err := p.srv.ResolveToken(args.Token)
+	if err != nil {
+		return err
+	}
+	if rule != nil && !rule.NodeWrite(args.Node, nil) {
+		return acl.ErrPermissionDenied
+	}
+
+	// The executions must come from a server of this datacenter.
+	if !p.srv.isLANServer(args.Node) {
+		return fmt.Errorf("Node %q is not a server of datacenter %q", args.Node, p.srv.config.Datacenter)
+	}
+
+	// We set the "safe to ignore" flag on this update type so old servers
+	// don't crash if they see one of these during an upgrade.
+	resp, err := p.srv.raftApply(structs.PreparedQueryStatsRequestType|structs.IgnoreUnknownTypeFlag, args)
+	if err != nil {
+		return err
+	}
+	if respErr, ok := resp.(error); ok {
+		return respErr
+	}
+	return nil
+}
+
 // Explain resolves a prepared query and returns the (possibly rendered template)
 // to the caller. This is useful for letting operators figure out which query is
 // picking up a given name. We can also add additional info about how the query
@@ -333,7 +461,8 @@ func (p *PreparedQuery) Execute(args *structs.PreparedQueryExecuteRequest,
 	if done, err := p.srv.forward("PreparedQuery.Execute", args, args, reply); done {
 		return err
 	}
-	defer metrics.MeasureSince([]string{"prepared-query", "execute"}, time.Now())
+	start := time.Now()
+	defer metrics.MeasureSince([]string{"prepared-query", "execute"}, start)
 
 	// We have to do this ourselves since we are not doing a blocking RPC.
 	p.srv.setQueryMeta(&reply.QueryMeta)
@@ -455,6 +584,7 @@ func (p *PreparedQuery) Execute(args *structs.PreparedQueryExecuteRequest,
 		}
 	}
 
+	p.srv.preparedQueryStats.record(query, reply, time.Since(start))
 	return nil
 }
 
@@ -2691,6 +2691,244 @@ func TestPreparedQuery_Execute_ForwardLeader(t *testing.T) {
 	}
 }
 
+func TestPreparedQuery_Stats(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec1 := rpcClient(t, s1)
+	defer codec1.Close()
+
+	dir2, s2 := testServerDCBootstrap(t, "dc1", false)
+	defer os.RemoveAll(dir2)
+	defer s2.Shutdown()
+	codec2 := rpcClient(t, s2)
+	defer codec2.Close()
+
+	joinLAN(t, s2, s1)
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	testrpc.WaitForLeader(t, s2.RPC, "dc1")
+
+	// Set up a node and service in the catalog.
+	{
+		req := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "foo",
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				Service: "redis",
+				Port:    8000,
+			},
+		}
+		var reply struct{}
+		require.NoError(t, msgpackrpc.CallWithCodec(codec1, "Catalog.Register", &req, &reply))
+	}
+
+	// Set up a query that returns results and one that doesn't.
+	create := func(name, service string) string {
+		req := structs.PreparedQueryRequest{
+			Datacenter: "dc1",
+			Op:         structs.PreparedQueryCreate,
+			Query: &structs.PreparedQuery{
+				Name: name,
+				Service: structs.ServiceQuery{
+					Service: service,
+				},
+			},
+		}
+		var reply string
+		require.NoError(t, msgpackrpc.CallWithCodec(codec1, "PreparedQuery.Apply", &req, &reply))
+		return reply
+	}
+	redis := create("redis", "redis")
+	unused := create("unused", "nope")
+	empty := create("empty", "nope")
+
+	// Execute the queries on both servers using stale reads so each server
+	// records some of the executions. Failed executions aren't recorded so
+	// we can retry until the follower has caught up.
+	execute := func(codec rpc.ClientCodec, id string) {
+		retry.Run(t, func(r *retry.R) {
+			req := structs.PreparedQueryExecuteRequest{
+				Datacenter:    "dc1",
+				QueryIDOrName: id,
+				QueryOptions:  structs.QueryOptions{AllowStale: true},
+			}
+			var reply structs.PreparedQueryExecuteResponse
+			if err := msgpackrpc.CallWithCodec(codec, "PreparedQuery.Execute", &req, &reply); err != nil {
+				r.Fatal(err)
+			}
+		})
+	}
+	execute(codec1, redis)
+	execute(codec2, redis)
+	execute(codec2, redis)
+	execute(codec1, empty)
+
+	checkStats := func(codec rpc.ClientCodec) {
+		t.Helper()
+		req := structs.DCSpecificRequest{Datacenter: "dc1"}
+		var reply structs.PreparedQueryStatsResponse
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "PreparedQuery.Stats", &req, &reply))
+		require.Len(t, reply.Stats, 3)
+
+		stats := make(map[string]*structs.PreparedQueryStats)
+		for _, s := range reply.Stats {
+			stats[s.ID] = s
+		}
+
+		require.Equal(t, "redis", stats[redis].Name)
+		require.Equal(t, uint64(3), stats[redis].Executions)
+		require.Equal(t, uint64(0), stats[redis].EmptyResults)
+		require.True(t, stats[redis].LatencyTotal >= stats[redis].LatencyMax)
+		require.False(t, stats[redis].LastExecuted.IsZero())
+
+		require.Equal(t, uint64(1), stats[empty].Executions)
+		require.Equal(t, uint64(1), stats[empty].EmptyResults)
+
+		require.Equal(t, "unused", stats[unused].Name)
+		require.Equal(t, uint64(0), stats[unused].Executions)
+		require.True(t, stats[unused].LastExecuted.IsZero())
+	}
+
+	// The executions pending on each server are included.
+	checkStats(codec1)
+	checkStats(codec2)
+
+	// Once written to the state store, the executions are counted once and
+	// kept by the servers' state.
+	require.NoError(t, s1.flushPreparedQueryStats())
+	require.NoError(t, s2.flushPreparedQueryStats())
+	require.Empty(t, s1.preparedQueryStats.pending())
+	require.Empty(t, s2.preparedQueryStats.pending())
+	retry.Run(t, func(r *retry.R) {
+		_, stored, err := s2.fsm.State().PreparedQueryStatsList(nil)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(stored) != 2 {
+			r.Fatalf("bad: %v", stored)
+		}
+	})
+	checkStats(codec1)
+	checkStats(codec2)
+
+	// Stats of deleted queries are dropped.
+	{
+		req := structs.PreparedQueryRequest{
+			Datacenter: "dc1",
+			Op:         structs.PreparedQueryDelete,
+			Query:      &structs.PreparedQuery{ID: redis},
+		}
+		var reply string
+		require.NoError(t, msgpackrpc.CallWithCodec(codec1, "PreparedQuery.Apply", &req, &reply))
+	}
+	retry.Run(t, func(r *retry.R) {
+		req := structs.DCSpecificRequest{Datacenter: "dc1"}
+		var reply structs.PreparedQueryStatsResponse
+		if err := msgpackrpc.CallWithCodec(codec2, "PreparedQuery.Stats", &req, &reply); err != nil {
+			r.Fatal(err)
+		}
+		if len(reply.Stats) != 2 {
+			r.Fatalf("bad: %v", reply.Stats)
+		}
+		_, stored, err := s2.fsm.State().PreparedQueryStatsList(nil)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(stored) != 1 {
+			r.Fatalf("bad: %v", stored)
+		}
+	})
+}
+
+func TestPreparedQuery_ApplyStats_ACLDeny(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// An anonymous token can't write stats.
+	req := structs.PreparedQueryStatsRequest{
+		Datacenter: "dc1",
+		Node:       s1.config.NodeName,
+		Stats:      []*structs.PreparedQueryStats{{ID: "9bdd3a92-0b0c-4fb1-b1b7-c4c4b3d5e1ab", Executions: 1}},
+	}
+	var reply struct{}
+	err := msgpackrpc.CallWithCodec(codec, "PreparedQuery.ApplyStats", &req, &reply)
+	require.True(t, acl.IsErrPermissionDenied(err), "err: %v", err)
+
+	// Only servers of the datacenter can write stats.
+	req.Node = "not-a-server"
+	req.Token = "root"
+	err = msgpackrpc.CallWithCodec(codec, "PreparedQuery.ApplyStats", &req, &reply)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not a server")
+
+	req.Node = s1.config.NodeName
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "PreparedQuery.ApplyStats", &req, &reply))
+}
+
+func TestPreparedQuery_Stats_ACLDeny(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	req := structs.PreparedQueryRequest{
+		Datacenter: "dc1",
+		Op:         structs.PreparedQueryCreate,
+		Query: &structs.PreparedQuery{
+			Name: "redis-master",
+			Service: structs.ServiceQuery{
+				Service: "redis",
+			},
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var id string
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "PreparedQuery.Apply", &req, &id))
+
+	// An anonymous token can't see the query.
+	{
+		req := structs.DCSpecificRequest{Datacenter: "dc1"}
+		var reply structs.PreparedQueryStatsResponse
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "PreparedQuery.Stats", &req, &reply))
+		require.Len(t, reply.Stats, 0)
+	}
+
+	// The management token can.
+	{
+		req := structs.DCSpecificRequest{
+			Datacenter:   "dc1",
+			QueryOptions: structs.QueryOptions{Token: "root"},
+		}
+		var reply structs.PreparedQueryStatsResponse
+		require.NoError(t, msgpackrpc.CallWithCodec(codec, "PreparedQuery.Stats", &req, &reply))
+		require.Len(t, reply.Stats, 1)
+		require.Equal(t, id, reply.Stats[0].ID)
+	}
+}
+
 func TestPreparedQuery_Execute_ConnectExact(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,180 @@
+package consul
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/metadata"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/serf/serf"
+)
+
+// preparedQueryStatsFlushInterval is how often a server writes the executions
+// it recorded to the state store.
+var preparedQueryStatsFlushInterval = 30 * time.Second
+
+// preparedQueryStats tracks the executions of prepared queries handled by
+// this server since they were last written to the state store. The
+// PreparedQuery.Stats endpoint adds the executions still pending on all the
+// servers in the datacenter to the stored stats.
+type preparedQueryStats struct {
+	sync.Mutex
+	queries map[string]*structs.PreparedQueryStats
+}
+
+func newPreparedQueryStats() *preparedQueryStats {
+	return &preparedQueryStats{
+		queries: make(map[string]*structs.PreparedQueryStats),
+	}
+}
+
+// record adds a single execution of the given query.
+func (p *preparedQueryStats) record(query *structs.PreparedQuery, reply *structs.PreparedQueryExecuteResponse, latency time.Duration) {
+	p.Lock()
+	defer p.Unlock()
+
+	s, ok := p.queries[query.ID]
+	if !ok {
+		s = &structs.PreparedQueryStats{ID: query.ID}
+		p.queries[query.ID] = s
+	}
+	s.Executions++
+	if reply.Failovers > 0 {
+		s.Failovers++
+	}
+	if len(reply.Nodes) == 0 {
+		s.EmptyResults++
+	}
+	s.LatencyTotal += latency
+	if latency > s.LatencyMax {
+		s.LatencyMax = latency
+	}
+	s.LastExecuted = time.Now().UTC()
+}
+
+// pending returns a copy of the executions not yet written to the state
+// store.
+func (p *preparedQueryStats) pending() []*structs.PreparedQueryStats {
+	p.Lock()
+	defer p.Unlock()
+
+	out := make([]*structs.PreparedQueryStats, 0, len(p.queries))
+	for _, s := range p.queries {
+		c := *s
+		out = append(out, &c)
+	}
+	return out
+}
+
+// drain returns the pending executions and resets them.
+func (p *preparedQueryStats) drain() []*structs.PreparedQueryStats {
+	p.Lock()
+	defer p.Unlock()
+
+	out := make([]*structs.PreparedQueryStats, 0, len(p.queries))
+	for _, s := range p.queries {
+		out = append(out, s)
+	}
+	p.queries = make(map[string]*structs.PreparedQueryStats)
+	return out
+}
+
+// restore adds back executions which couldn't be written to the state store.
+func (p *preparedQueryStats) restore(stats []*structs.PreparedQueryStats) {
+	p.Lock()
+	defer p.Unlock()
+
+	for _, s := range stats {
+		if cur, ok := p.queries[s.ID]; ok {
+			cur.Add(s)
+		} else {
+			p.queries[s.ID] = s
+		}
+	}
+}
+
+// mergePreparedQueryStats adds the stats in src to the ones in dst, keyed by
+// query ID.
+func mergePreparedQueryStats(dst map[string]*structs.PreparedQueryStats, src []*structs.PreparedQueryStats) {
+	for _, s := range src {
+		cur, ok := dst[s.ID]
+		if !ok {
+			c := *s
+			dst[s.ID] = &c
+			continue
+		}
+		cur.Add(s)
+	}
+}
+
+// flushPreparedQueryStats writes the executions recorded by this server to
+// the state store. They're kept for the next flush if the write fails.
+func (s *Server) flushPreparedQueryStats() error {
+	stats := s.preparedQueryStats.drain()
+	if len(stats) == 0 {
+		return nil
+	}
+
+	args := structs.PreparedQueryStatsRequest{
+		Datacenter:   s.config.Datacenter,
+		Node:         s.config.NodeName,
+		Stats:        stats,
+		WriteRequest: structs.WriteRequest{Token: s.tokens.AgentToken()},
+	}
+	var reply struct{}
+	if err := s.RPC("PreparedQuery.ApplyStats", &args, &reply); err != nil {
+		s.preparedQueryStats.restore(stats)
+		return err
+	}
+	return nil
+}
+
+// preparedQueryStatsFlushLoop periodically writes the executions recorded by
+// this server to the state store until the server shuts down.
+func (s *Server) preparedQueryStatsFlushLoop() {
+	ticker := time.NewTicker(preparedQueryStatsFlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.flushPreparedQueryStats(); err != nil {
+				s.logger.Printf("[WARN] consul.prepared_query: Failed to write query stats: %v", err)
+			}
+		case <-s.shutdownCh:
+			return
+		}
+	}
+}
+
+// otherLANServers returns the alive servers in this server's datacenter,
+// other than this one.
+func (s *Server) otherLANServers() []*metadata.Server {
+	var servers []*metadata.Server
+	for _, member := range s.LANMembers() {
+		ok, parts := metadata.IsConsulServer(member)
+		if !ok || member.Status != serf.StatusAlive {
+			continue
+		}
+		if parts.Datacenter != s.config.Datacenter || parts.Name == s.config.NodeName {
+			continue
+		}
+		servers = append(servers, parts)
+	}
+	return servers
+}
+
+// isLANServer returns true if the given node is an alive server in this
+// server's datacenter.
+func (s *Server) isLANServer(node string) bool {
+	for _, member := range s.LANMembers() {
+		ok, parts := metadata.IsConsulServer(member)
+		if !ok || member.Status != serf.StatusAlive {
+			continue
+		}
+		if parts.Datacenter == s.config.Datacenter && parts.Name == node {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package consul
+
+import (
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+// adaptiveBatchSteps is the number of steps the adaptive batching window
+// takes to grow from its smallest size to the max latency, doubling at
+// each step.
+const adaptiveBatchSteps = 4
+
+// applyBatcher holds the concurrent Raft writes for a short while and
+// releases them together, so the leader picks them up in a single batch
+// when it appends them to its log and replicates them. This trades some
+// latency for a higher write throughput, for example during registration
+// storms.
+type applyBatcher struct {
+	// maxSize is the number of writes after which a batch is released
+	// without waiting for the window to elapse.
+	maxSize int
+
+	// maxLatency is the longest a write is held.
+	maxLatency time.Duration
+
+	// adaptive only holds the writes when some are already in flight, for
+	// a window growing when the batches fill up and shrinking when they
+	// don't.
+	adaptive bool
+
+	lock     sync.Mutex
+	batch    *applyBatch
+	window   time.Duration
+	inflight int
+}
+
+// applyBatch is a group of writes released together.
+type applyBatch struct {
+	size  int
+	ready chan struct{}
+	timer *time.Timer
+}
+
+// newApplyBatcher returns a batcher releasing batches of up to maxSize
+// writes and holding them for at most maxLatency.
+func newApplyBatcher(maxSize int, maxLatency time.Duration, adaptive bool) *applyBatcher {
+	b := &applyBatcher{
+		maxSize:    maxSize,
+		maxLatency: maxLatency,
+		adaptive:   adaptive,
+	}
+	if !adaptive {
+		b.window = maxLatency
+	}
+	return b
+}
+
+// minWindow is the smallest window used by the adaptive batching.
+func (b *applyBatcher) minWindow() time.Duration {
+	return b.maxLatency >> adaptiveBatchSteps
+}
+
+// wait blocks until the batch of the write is released. The returned
+// function must be called once the write has been applied.
+func (b *applyBatcher) wait() func() {
+	b.lock.Lock()
+	if b.adaptive && b.window == 0 && b.inflight > 0 {
+		b.window = b.minWindow()
+	}
+	b.inflight++
+
+	if b.window == 0 {
+		b.lock.Unlock()
+		return b.done
+	}
+
+	batch := b.batch
+	if batch == nil {
+		batch = &applyBatch{ready: make(chan struct{})}
+		batch.timer = time.AfterFunc(b.window, func() {
+			b.lock.Lock()
+			b.releaseLocked(batch)
+			b.lock.Unlock()
+		})
+		b.batch = batch
+	}
+	batch.size++
+	if batch.size >= b.maxSize {
+		b.releaseLocked(batch)
+	}
+	b.lock.Unlock()
+
+	<-batch.ready
+	return b.done
+}
+
+// done records the end of a write.
+func (b *applyBatcher) done() {
+	b.lock.Lock()
+	b.inflight--
+	b.lock.Unlock()
+}
+
+// releaseLocked releases the writes of the given batch if it hasn't been
+// already, and adapts the window to its size. The lock must be held.
+func (b *applyBatcher) releaseLocked(batch *applyBatch) {
+	if b.batch != batch {
+		return
+	}
+	b.batch = nil
+	batch.timer.Stop()
+	close(batch.ready)
+
+	metrics.AddSample([]string{"raft", "apply_batch", "size"}, float32(batch.size))
+
+	if !b.adaptive {
+		return
+	}
+	switch {
+	case batch.size >= b.maxSize:
+		b.window *= 2
+		if b.window > b.maxLatency {
+			b.window = b.maxLatency
+		}
+	case batch.size < b.maxSize/2:
+		b.window /= 2
+		if b.window < b.minWindow() {
+			b.window = 0
+		}
+	}
+	metrics.SetGauge([]string{"raft", "apply_batch", "window"}, float32(b.window.Seconds()*1000))
+}
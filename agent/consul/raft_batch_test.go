@@ -0,0 +1,143 @@
+package consul
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyBatcher_releaseOnSize(t *testing.T) {
+	t.Parallel()
+
+	// The window is long enough that the batch can only be released by
+	// reaching its max size.
+	b := newApplyBatcher(3, time.Hour, false)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.wait()()
+		}()
+	}
+
+	doneCh := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(doneCh)
+	}()
+	select {
+	case <-doneCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("batch was not released")
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	require.Nil(t, b.batch)
+	require.Equal(t, 0, b.inflight)
+}
+
+func TestApplyBatcher_releaseOnLatency(t *testing.T) {
+	t.Parallel()
+	b := newApplyBatcher(64, 20*time.Millisecond, false)
+
+	start := time.Now()
+	b.wait()()
+	require.True(t, time.Since(start) >= 20*time.Millisecond)
+}
+
+func TestApplyBatcher_adaptive(t *testing.T) {
+	t.Parallel()
+	b := newApplyBatcher(4, 160*time.Millisecond, true)
+
+	// A single write is not held.
+	start := time.Now()
+	b.wait()()
+	require.True(t, time.Since(start) < 10*time.Millisecond)
+	require.Equal(t, time.Duration(0), b.window)
+
+	// A write arriving while another is in flight is held, and the window
+	// shrinks back since it was alone in its batch.
+	held := b.wait()
+	start = time.Now()
+	b.wait()()
+	require.True(t, time.Since(start) >= 10*time.Millisecond)
+	require.Equal(t, time.Duration(0), b.window)
+
+	// Full batches grow the window up to the max latency.
+	for i := 0; i < 10; i++ {
+		var wg sync.WaitGroup
+		for j := 0; j < 4; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				b.wait()()
+			}()
+		}
+		wg.Wait()
+	}
+	held()
+	b.lock.Lock()
+	require.Equal(t, 160*time.Millisecond, b.window)
+	b.lock.Unlock()
+
+	// Batches released by the timer with a single write shrink it back.
+	for i := 0; i < 10; i++ {
+		b.wait()()
+	}
+	b.lock.Lock()
+	require.Equal(t, time.Duration(0), b.window)
+	b.lock.Unlock()
+}
+
+func TestServer_RaftApplyBatch(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.RaftConfig.MaxAppendEntries = 8
+		c.RaftApplyBatchMaxLatency = 5 * time.Millisecond
+		c.RaftApplyBatchAdaptive = true
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	require.NotNil(t, s1.raftApplyBatcher)
+
+	// Apply concurrent writes and make sure they all land.
+	var wg sync.WaitGroup
+	errCh := make(chan error, 32)
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			arg := structs.KVSRequest{
+				Datacenter: "dc1",
+				Op:         api.KVSet,
+				DirEnt: structs.DirEntry{
+					Key:   fmt.Sprintf("test/%d", i),
+					Value: []byte("test"),
+				},
+			}
+			var out bool
+			errCh <- s1.RPC("KVS.Apply", &arg, &out)
+		}(i)
+	}
+	wg.Wait()
+	close(errCh)
+	for err := range errCh {
+		require.NoError(t, err)
+	}
+
+	_, entries, err := s1.fsm.State().KVSList(nil, "test/")
+	require.NoError(t, err)
+	require.Len(t, entries, 32)
+}
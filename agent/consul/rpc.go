@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"net/rpc"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -84,7 +86,7 @@ func (s *Server) handleConn(conn net.Conn, isTLS bool) {
 	typ := pool.RPCType(buf[0])
 
 	// Enforce TLS if VerifyIncoming is set
-	if s.config.VerifyIncoming && !isTLS && typ != pool.RPCTLS {
+	if s.config.VerifyIncoming && !isTLS && typ != pool.RPCTLS && typ != pool.RPCTLSInsecure {
 		s.logger.Printf("[WARN] consul.rpc: Non-TLS connection attempted with VerifyIncoming set %s", logConn(conn))
 		conn.Close()
 		return
@@ -93,7 +95,7 @@ func (s *Server) handleConn(conn net.Conn, isTLS bool) {
 	// Switch on the byte
 	switch typ {
 	case pool.RPCConsul:
-		s.handleConsulConn(conn)
+		s.handleConsulConn(conn, s.isServerConn(conn))
 
 	case pool.RPCRaft:
 		metrics.IncrCounter([]string{"rpc", "raft_handoff"}, 1)
@@ -114,6 +116,20 @@ func (s *Server) handleConn(conn net.Conn, isTLS bool) {
 	case pool.RPCSnapshot:
 		s.handleSnapshotConn(conn)
 
+	case pool.RPCGRPC:
+		if err := s.grpcListener.Handoff(conn); err != nil {
+			conn.Close()
+		}
+
+	case pool.RPCTLSInsecure:
+		if isTLS || s.rpcTLS == nil {
+			s.logger.Printf("[WARN] consul.rpc: insecure TLS connection attempted, server not configured for TLS %s", logConn(conn))
+			conn.Close()
+			return
+		}
+		conn = tls.Server(conn, s.tlsConfigurator.IncomingInsecureRPCConfig())
+		s.handleInsecureConsulConn(conn)
+
 	default:
 		if !s.handleEnterpriseRPCConn(typ, conn, isTLS) {
 			s.logger.Printf("[ERR] consul.rpc: unrecognized RPC byte: %v %s", typ, logConn(conn))
@@ -126,6 +142,7 @@ func (s *Server) handleConn(conn net.Conn, isTLS bool) {
 // using the Yamux multiplexer
 func (s *Server) handleMultiplexV2(conn net.Conn) {
 	defer conn.Close()
+	fromServer := s.isServerConn(conn)
 	conf := yamux.DefaultConfig()
 	conf.LogOutput = s.config.LogOutput
 	server, _ := yamux.Server(conn, conf)
@@ -137,14 +154,18 @@ func (s *Server) handleMultiplexV2(conn net.Conn) {
 			}
 			return
 		}
-		go s.handleConsulConn(sub)
+		go s.handleConsulConn(sub, fromServer)
 	}
 }
 
-// handleConsulConn is used to service a single Consul RPC connection
-func (s *Server) handleConsulConn(conn net.Conn) {
+// handleConsulConn is used to service a single Consul RPC connection. The
+// server-only methods are refused unless the connection comes from a server.
+func (s *Server) handleConsulConn(conn net.Conn, fromServer bool) {
 	defer conn.Close()
 	rpcCodec := msgpackrpc.NewServerCodec(conn)
+	if !fromServer {
+		rpcCodec = &serverOnlyCodec{ServerCodec: rpcCodec}
+	}
 	for {
 		select {
 		case <-s.shutdownCh:
@@ -163,6 +184,94 @@ func (s *Server) handleConsulConn(conn net.Conn) {
 	}
 }
 
+// serverOnlyMethods are the RPC methods only served to the servers of the
+// datacenter, either over their connections or in-process.
+var serverOnlyMethods = map[string]struct{}{
+	"PreparedQuery.ApplyStats": {},
+}
+
+// errServerOnly is returned for the server-only methods called over a
+// connection which doesn't come from a server.
+var errServerOnly = fmt.Errorf("RPC method is only allowed from the servers of the datacenter")
+
+// serverOnlyCodec wraps the codec of a connection which doesn't come from a
+// server, replying to the requests of the server-only methods with an error
+// without handing them to the RPC server.
+type serverOnlyCodec struct {
+	rpc.ServerCodec
+}
+
+func (c *serverOnlyCodec) ReadRequestHeader(r *rpc.Request) error {
+	for {
+		if err := c.ServerCodec.ReadRequestHeader(r); err != nil {
+			return err
+		}
+		if _, ok := serverOnlyMethods[r.ServiceMethod]; !ok {
+			return nil
+		}
+
+		// Discard the arguments and refuse the request, then move on to
+		// the next one.
+		if err := c.ServerCodec.ReadRequestBody(nil); err != nil {
+			return err
+		}
+		resp := &rpc.Response{
+			ServiceMethod: r.ServiceMethod,
+			Seq:           r.Seq,
+			Error:         errServerOnly.Error(),
+		}
+		if err := c.ServerCodec.WriteResponse(resp, struct{}{}); err != nil {
+			return err
+		}
+		metrics.IncrCounter([]string{"rpc", "request_error"}, 1)
+	}
+}
+
+// isServerConn returns whether the connection comes from a server of this
+// datacenter. When the server hostnames are verified the connection must
+// present a certificate for the server name of the datacenter, otherwise it
+// must come from the IP of an alive server, as tracked from the LAN Serf
+// events. The latter is weaker since any process running on the host of a
+// server passes it, so the server-only methods are only fully protected
+// with verify_incoming and verify_server_hostname.
+func (s *Server) isServerConn(conn net.Conn) bool {
+	if s.config.VerifyIncoming && s.config.VerifyServerHostname {
+		tlsConn, ok := conn.(*tls.Conn)
+		if !ok {
+			return false
+		}
+		state := tlsConn.ConnectionState()
+		if len(state.VerifiedChains) == 0 {
+			return false
+		}
+		domain := strings.TrimSuffix(s.config.Domain, ".")
+		name := "server." + s.config.Datacenter + "." + domain
+		return state.PeerCertificates[0].VerifyHostname(name) == nil
+	}
+
+	addr, ok := conn.RemoteAddr().(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	return s.serverLookup.HasServerIP(addr.IP)
+}
+
+// handleInsecureConsulConn is used to service the RPC connections of the
+// client agents requesting their certificate with auto-encrypt, which only
+// have access to the AutoEncrypt endpoint.
+func (s *Server) handleInsecureConsulConn(conn net.Conn) {
+	defer conn.Close()
+	rpcCodec := msgpackrpc.NewServerCodec(conn)
+	if err := s.insecureRPCServer.ServeRequest(rpcCodec); err != nil {
+		if err != io.EOF && !strings.Contains(err.Error(), "closed") {
+			s.logger.Printf("[ERR] consul.rpc: INSECURERPC error: %v %s", err, logConn(conn))
+			metrics.IncrCounter([]string{"rpc", "request_error"}, 1)
+		}
+		return
+	}
+	metrics.IncrCounter([]string{"rpc", "request"}, 1)
+}
+
 // handleSnapshotConn is used to dispatch snapshot saves and restores, which
 // stream so don't use the normal RPC mechanism.
 func (s *Server) handleSnapshotConn(conn net.Conn) {
@@ -345,6 +454,12 @@ func (s *Server) raftApply(t structs.MessageType, msg interface{}) (interface{},
 		s.logger.Printf("[WARN] consul: Attempting to apply large raft entry (%d bytes)", n)
 	}
 
+	// Hold the write to batch it with the concurrent ones if configured.
+	if s.raftApplyBatcher != nil {
+		done := s.raftApplyBatcher.wait()
+		defer done()
+	}
+
 	future := s.raft.Apply(buf, enqueueLimit)
 	if err := future.Error(); err != nil {
 		return nil, err
@@ -387,6 +502,7 @@ func (s *Server) blockingQuery(queryOpts *structs.QueryOptions, queryMeta *struc
 RUN_QUERY:
 	// Update the query metadata.
 	s.setQueryMeta(queryMeta)
+	queryMeta.ConsistencyLevel = queryOpts.ConsistencyLevel()
 
 	// If the read must be consistent we verify that we are still the leader.
 	if queryOpts.RequireConsistent {
@@ -397,6 +513,7 @@ RUN_QUERY:
 
 	// Run the query.
 	metrics.IncrCounter([]string{"rpc", "query"}, 1)
+	atomic.AddUint64(&s.queriesTotal, 1)
 
 	// Operate on a consistent set of state. This makes sure that the
 	// abandon channel goes with the state that the caller is using to
@@ -427,7 +544,10 @@ RUN_QUERY:
 		queryMeta.Index = 1
 	}
 	if err == nil && queryOpts.MinQueryIndex > 0 && queryMeta.Index <= queryOpts.MinQueryIndex {
-		if expired := ws.Watch(timeout.C); !expired {
+		atomic.AddUint64(&s.queriesBlocking, 1)
+		expired := ws.Watch(timeout.C)
+		atomic.AddUint64(&s.queriesBlocking, ^uint64(0))
+		if !expired {
 			// If a restore may have woken us up then bail out from
 			// the query immediately. This is slightly race-ey since
 			// this might have been interrupted for other reasons,
@@ -445,12 +565,16 @@ RUN_QUERY:
 
 // setQueryMeta is used to populate the QueryMeta data for an RPC call
 func (s *Server) setQueryMeta(m *structs.QueryMeta) {
+	m.ServedBy = s.config.NodeName
+	m.ServedByID = s.config.NodeID
 	if s.IsLeader() {
 		m.LastContact = 0
 		m.KnownLeader = true
+		m.ServedByRole = structs.QueryServedByLeader
 	} else {
 		m.LastContact = time.Since(s.raft.LastContact())
 		m.KnownLeader = (s.raft.Leader() != "")
+		m.ServedByRole = structs.QueryServedByFollower
 	}
 }
 
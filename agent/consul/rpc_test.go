@@ -2,11 +2,20 @@ package consul
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
 	"os"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/pool"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
@@ -315,3 +324,122 @@ func TestRPC_ReadyForConsistentReads(t *testing.T) {
 		}
 	})
 }
+
+func TestRPC_ServerOnlyMethods(t *testing.T) {
+	t.Parallel()
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	// The connection doesn't come from the address of a server, even with
+	// the name of a server the stats are refused.
+	d := net.Dialer{
+		LocalAddr: &net.TCPAddr{IP: net.ParseIP("127.0.0.2")},
+		Timeout:   time.Second,
+	}
+	conn, err := d.Dial("tcp", s1.config.RPCAdvertise.String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte{byte(pool.RPCConsul)})
+	require.NoError(t, err)
+	codec := msgpackrpc.NewClientCodec(conn)
+	defer codec.Close()
+
+	req := structs.PreparedQueryStatsRequest{
+		Datacenter: "dc1",
+		Node:       s1.config.NodeName,
+		Stats:      []*structs.PreparedQueryStats{{ID: "9bdd3a92-0b0c-4fb1-b1b7-c4c4b3d5e1ab", Executions: 1}},
+	}
+	var reply struct{}
+	err = msgpackrpc.CallWithCodec(codec, "PreparedQuery.ApplyStats", &req, &reply)
+	require.EqualError(t, err, errServerOnly.Error())
+
+	// The other methods are still served on the connection.
+	var out struct{}
+	require.NoError(t, msgpackrpc.CallWithCodec(codec, "Status.Ping", struct{}{}, &out))
+
+	// From the address of the server they're applied.
+	codec2 := rpcClient(t, s1)
+	defer codec2.Close()
+	require.NoError(t, msgpackrpc.CallWithCodec(codec2, "PreparedQuery.ApplyStats", &req, &reply))
+}
+
+func TestServer_isServerConn_TLS(t *testing.T) {
+	t.Parallel()
+
+	// Issue a certificate for server.dc1.consul.
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Consul CA"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	caPool := x509.NewCertPool()
+	caPool.AddCert(caCert)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "server.dc1.consul"},
+		DNSNames:     []string{"server.dc1.consul"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+
+	// The certificate is valid for server.dc1.consul.
+	check := func(datacenter string) bool {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		defer ln.Close()
+
+		errCh := make(chan error, 1)
+		go func() {
+			conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{
+				Certificates:       []tls.Certificate{cert},
+				InsecureSkipVerify: true,
+			})
+			if err == nil {
+				defer conn.Close()
+				_, err = conn.Write([]byte{0})
+			}
+			errCh <- err
+		}()
+
+		conn, err := ln.Accept()
+		require.NoError(t, err)
+		defer conn.Close()
+		tlsServer := tls.Server(conn, &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			ClientCAs:    caPool,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+		})
+		_, err = tlsServer.Read(make([]byte, 1))
+		require.NoError(t, err)
+		require.NoError(t, <-errCh)
+
+		s := &Server{config: &Config{
+			Datacenter:           datacenter,
+			Domain:               "consul.",
+			VerifyIncoming:       true,
+			VerifyServerHostname: true,
+		}}
+		return s.isServerConn(tlsServer)
+	}
+	require.True(t, check("dc1"))
+	require.False(t, check("dc2"))
+}
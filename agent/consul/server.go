@@ -22,6 +22,7 @@ import (
 	"github.com/hashicorp/consul/agent/consul/autopilot"
 	"github.com/hashicorp/consul/agent/consul/fsm"
 	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/consul/stream"
 	"github.com/hashicorp/consul/agent/metadata"
 	"github.com/hashicorp/consul/agent/pool"
 	"github.com/hashicorp/consul/agent/router"
@@ -34,6 +35,7 @@ import (
 	"github.com/hashicorp/raft"
 	raftboltdb "github.com/hashicorp/raft-boltdb"
 	"github.com/hashicorp/serf/serf"
+	"google.golang.org/grpc"
 )
 
 // These are the protocol versions that Consul can _understand_. These are
@@ -91,18 +93,35 @@ var (
 // Server is Consul server which manages the service discovery,
 // health checking, DC forwarding, Raft, and multiple Serf pools.
 type Server struct {
+	// queriesBlocking is the number of blocking queries currently waiting
+	// for a change, and queriesTotal the number of queries run. They are
+	// only accessed atomically and are kept first in the struct to ensure
+	// 64-bit alignment.
+	queriesBlocking uint64
+	queriesTotal    uint64
+
 	// sentinel is the Sentinel code engine (can be nil).
 	sentinel sentinel.Evaluator
 
 	// acls is used to resolve tokens to effective policies
 	acls *ACLResolver
 
+	// filterMemo memoizes the ACL filtered results of the list queries. It
+	// is nil when the memoization is disabled.
+	filterMemo *filterMemo
+
 	// aclUpgradeCancel is used to cancel the ACL upgrade goroutine when we
 	// lose leadership
 	aclUpgradeCancel  context.CancelFunc
 	aclUpgradeLock    sync.RWMutex
 	aclUpgradeEnabled bool
 
+	// aclPolicyReapingCh is used to shut down the goroutine deleting the
+	// expired ACL policies when we lose leadership.
+	aclPolicyReapingCh      chan struct{}
+	aclPolicyReapingLock    sync.RWMutex
+	aclPolicyReapingEnabled bool
+
 	// aclReplicationCancel is used to shut down the ACL replication goroutine
 	// when we lose leadership
 	aclReplicationCancel  context.CancelFunc
@@ -135,6 +154,10 @@ type Server struct {
 	caPruningLock    sync.RWMutex
 	caPruningEnabled bool
 
+	// keyringRotation rotates the gossip encryption key of the LAN pool
+	// while we are the leader.
+	keyringRotation keyringRotation
+
 	// Consul configuration
 	config *Config
 
@@ -174,6 +197,10 @@ type Server struct {
 	// transition notifications from the Raft layer.
 	raftNotifyCh <-chan bool
 
+	// raftApplyBatcher holds the writes to batch them before applying them
+	// through Raft. It is nil when the writes are applied right away.
+	raftApplyBatcher *applyBatcher
+
 	// reconcileCh is used to pass events from the serf handler
 	// into the leader manager, so that the strong state can be
 	// updated
@@ -197,9 +224,30 @@ type Server struct {
 	Listener  net.Listener
 	rpcServer *rpc.Server
 
+	// insecureRPCServer serves the RPC connections of the client agents
+	// requesting their certificate with auto-encrypt, which can't be
+	// verified. It only has the AutoEncrypt endpoint.
+	insecureRPCServer *rpc.Server
+
+	// autoConfigAuthorizer validates the JWTs of the auto-config requests
+	// of the client agents, when auto-config is enabled.
+	autoConfigAuthorizer *jwtAuthorizer
+
+	// grpcServer serves the gRPC services of the server, using the
+	// connections handed off by the RPC listener to grpcListener.
+	grpcServer   *grpc.Server
+	grpcListener *grpcListener
+
+	// publisher publishes the health events of the services to the
+	// subscriptions of the agents.
+	publisher *stream.EventPublisher
+
 	// rpcTLS is the TLS config for incoming TLS requests
 	rpcTLS *tls.Config
 
+	// tlsConfigurator holds the TLS configuration of the agent.
+	tlsConfigurator *tlsutil.Configurator
+
 	// serfLAN is the Serf cluster maintained inside the DC
 	// which contains all the DC nodes
 	serfLAN *serf.Serf
@@ -224,6 +272,18 @@ type Server struct {
 	// destroy the session via standard session destroy processing
 	sessionTimers *SessionTimers
 
+	// kvsTimers track the expiration time of each key that has a TTL. On
+	// expiration, the key is deleted unless it was written again since.
+	kvsTimers *SessionTimers
+
+	// preparedQueryStats tracks the executions of prepared queries handled
+	// by this server.
+	preparedQueryStats *preparedQueryStats
+
+	// intentionSuggestions aggregates the connections reported by the
+	// agents that weren't covered by any intention, on the leader.
+	intentionSuggestions *intentionSuggestions
+
 	// statsFetcher is used by autopilot to check the status of the other
 	// Consul router.
 	statsFetcher *StatsFetcher
@@ -333,12 +393,22 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 		router:           router.NewRouter(logger, config.Datacenter),
 		rpcServer:        rpc.NewServer(),
 		rpcTLS:           tlsConfigurator.IncomingRPCConfig(),
+		tlsConfigurator:  tlsConfigurator,
 		reassertLeaderCh: make(chan chan error),
 		segmentLAN:       make(map[string]*serf.Serf, len(config.Segments)),
 		sessionTimers:    NewSessionTimers(),
+		kvsTimers:        NewSessionTimers(),
 		tombstoneGC:      gc,
 		serverLookup:     NewServerLookup(),
 		shutdownCh:       shutdownCh,
+
+		preparedQueryStats:   newPreparedQueryStats(),
+		intentionSuggestions: newIntentionSuggestions(),
+	}
+
+	if config.RaftApplyBatchMaxLatency > 0 {
+		s.raftApplyBatcher = newApplyBatcher(config.RaftConfig.MaxAppendEntries,
+			config.RaftApplyBatchMaxLatency, config.RaftApplyBatchAdaptive)
 	}
 
 	// Initialize enterprise specific server functionality
@@ -365,6 +435,19 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 		s.Shutdown()
 		return nil, fmt.Errorf("Failed to create ACL resolver: %v", err)
 	}
+	if config.ACLFilterMemoSize > 0 {
+		if s.filterMemo, err = newFilterMemo(config.ACLFilterMemoSize); err != nil {
+			s.Shutdown()
+			return nil, fmt.Errorf("Failed to create ACL filter memo: %v", err)
+		}
+	}
+
+	if config.AutoConfigAuthzEnabled {
+		if s.autoConfigAuthorizer, err = newJWTAuthorizer(config); err != nil {
+			s.Shutdown()
+			return nil, fmt.Errorf("Failed to set up the auto-config authorizer: %v", err)
+		}
+	}
 
 	// Initialize the RPC layer.
 	if err := s.setupRPC(tlsConfigurator.OutgoingRPCWrapper()); err != nil {
@@ -463,6 +546,11 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 	// since it can fire events when leadership is obtained.
 	go s.monitorLeadership()
 
+	// Start the event publisher and the gRPC server streaming its events.
+	s.publisher = stream.NewEventPublisher(s.logger, func() *state.Store { return s.fsm.State() }, 0)
+	go s.publisher.Run(s.shutdownCh)
+	s.setupGRPC()
+
 	// Start listening for RPC requests.
 	go s.listen(s.Listener)
 
@@ -473,6 +561,10 @@ func NewServerLogger(config *Config, logger *log.Logger, tokens *token.Store, tl
 
 	// Start the metrics handlers.
 	go s.sessionStats()
+	go s.kvsStats()
+
+	// Start writing the prepared query stats to the state store.
+	go s.preparedQueryStatsFlushLoop()
 
 	return s, nil
 }
@@ -667,6 +759,9 @@ func (s *Server) setupRPC(tlsWrap tlsutil.DCWrapper) error {
 	for _, fn := range endpoints {
 		s.rpcServer.Register(fn(s))
 	}
+	s.insecureRPCServer = rpc.NewServer()
+	s.insecureRPCServer.Register(&AutoEncrypt{srv: s})
+	s.insecureRPCServer.Register(&AutoConfig{srv: s})
 
 	ln, err := net.ListenTCP("tcp", s.config.RPCAddr)
 	if err != nil {
@@ -750,6 +845,11 @@ func (s *Server) Shutdown() error {
 		s.Listener.Close()
 	}
 
+	if s.grpcServer != nil {
+		s.grpcServer.Stop()
+		s.grpcListener.Close()
+	}
+
 	// Close the connection pool
 	s.connPool.Shutdown()
 
@@ -1065,6 +1165,10 @@ func (s *Server) Stats() map[string]map[string]string {
 		"raft":     s.raft.Stats(),
 		"serf_lan": s.serfLAN.Stats(),
 		"runtime":  runtimeStats(),
+		"rpc": map[string]string{
+			"queries":          toString(atomic.LoadUint64(&s.queriesTotal)),
+			"queries_blocking": toString(atomic.LoadUint64(&s.queriesBlocking)),
+		},
 	}
 
 	if s.ACLsEnabled() {
@@ -1073,6 +1177,7 @@ func (s *Server) Stats() map[string]map[string]string {
 		} else {
 			stats["consul"]["acl"] = "enabled"
 		}
+		stats["acl_cache"] = s.acls.Stats()
 	} else {
 		stats["consul"]["acl"] = "disabled"
 	}
@@ -2,6 +2,7 @@ package consul
 
 import (
 	"fmt"
+	"net"
 	"sync"
 
 	"github.com/hashicorp/consul/agent/metadata"
@@ -13,29 +14,70 @@ type ServerLookup struct {
 	lock            sync.RWMutex
 	addressToServer map[raft.ServerAddress]*metadata.Server
 	idToServer      map[raft.ServerID]*metadata.Server
+
+	// ipToServers counts the servers at each IP, several servers can share
+	// one with different ports.
+	ipToServers map[string]int
 }
 
 func NewServerLookup() *ServerLookup {
 	return &ServerLookup{
 		addressToServer: make(map[raft.ServerAddress]*metadata.Server),
 		idToServer:      make(map[raft.ServerID]*metadata.Server),
+		ipToServers:     make(map[string]int),
 	}
 }
 
 func (sl *ServerLookup) AddServer(server *metadata.Server) {
 	sl.lock.Lock()
 	defer sl.lock.Unlock()
-	sl.addressToServer[raft.ServerAddress(server.Addr.String())] = server
+	addr := raft.ServerAddress(server.Addr.String())
+	if old, ok := sl.addressToServer[addr]; ok {
+		sl.removeIP(old)
+	}
+	sl.addressToServer[addr] = server
 	sl.idToServer[raft.ServerID(server.ID)] = server
+	sl.ipToServers[serverIP(server)]++
 }
 
 func (sl *ServerLookup) RemoveServer(server *metadata.Server) {
 	sl.lock.Lock()
 	defer sl.lock.Unlock()
-	delete(sl.addressToServer, raft.ServerAddress(server.Addr.String()))
+	addr := raft.ServerAddress(server.Addr.String())
+	if old, ok := sl.addressToServer[addr]; ok {
+		sl.removeIP(old)
+	}
+	delete(sl.addressToServer, addr)
 	delete(sl.idToServer, raft.ServerID(server.ID))
 }
 
+// removeIP stops counting the given server at its IP. The lock must be held.
+func (sl *ServerLookup) removeIP(server *metadata.Server) {
+	ip := serverIP(server)
+	if sl.ipToServers[ip]--; sl.ipToServers[ip] <= 0 {
+		delete(sl.ipToServers, ip)
+	}
+}
+
+// serverIP returns the IP of the server's address in its canonical form.
+func serverIP(server *metadata.Server) string {
+	host, _, err := net.SplitHostPort(server.Addr.String())
+	if err != nil {
+		host = server.Addr.String()
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return host
+}
+
+// HasServerIP returns whether a server is known at the given IP.
+func (sl *ServerLookup) HasServerIP(ip net.IP) bool {
+	sl.lock.RLock()
+	defer sl.lock.RUnlock()
+	return sl.ipToServers[ip.String()] > 0
+}
+
 // Implements the ServerAddressProvider interface
 func (sl *ServerLookup) ServerAddr(id raft.ServerID) (raft.ServerAddress, error) {
 	sl.lock.RLock()
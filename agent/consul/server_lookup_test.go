@@ -2,6 +2,7 @@ package consul
 
 import (
 	"fmt"
+	"net"
 	"testing"
 
 	"github.com/hashicorp/consul/agent/metadata"
@@ -56,3 +57,30 @@ func TestServerLookup(t *testing.T) {
 	lookup.RemoveServer(svr2)
 
 }
+
+func TestServerLookup_HasServerIP(t *testing.T) {
+	lookup := NewServerLookup()
+
+	// Two servers share the same IP.
+	svr1 := &metadata.Server{ID: "1", Addr: &testAddr{"127.0.0.1:8300"}}
+	svr2 := &metadata.Server{ID: "2", Addr: &testAddr{"127.0.0.1:8301"}}
+	lookup.AddServer(svr1)
+	lookup.AddServer(svr1)
+	lookup.AddServer(svr2)
+	if !lookup.HasServerIP(net.ParseIP("127.0.0.1")) {
+		t.Fatalf("Expected a server at 127.0.0.1")
+	}
+	if lookup.HasServerIP(net.ParseIP("127.0.0.2")) {
+		t.Fatalf("Expected no server at 127.0.0.2")
+	}
+
+	lookup.RemoveServer(svr1)
+	if !lookup.HasServerIP(net.ParseIP("127.0.0.1")) {
+		t.Fatalf("Expected a server at 127.0.0.1")
+	}
+	lookup.RemoveServer(svr2)
+	lookup.RemoveServer(svr2)
+	if lookup.HasServerIP(net.ParseIP("127.0.0.1")) {
+		t.Fatalf("Expected no server at 127.0.0.1")
+	}
+}
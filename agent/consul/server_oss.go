@@ -2,9 +2,14 @@ package consul
 
 func init() {
 	registerEndpoint(func(s *Server) interface{} { return &ACL{s} })
+	registerEndpoint(func(s *Server) interface{} { return &AutoEncrypt{s} })
+	registerEndpoint(func(s *Server) interface{} { return &AutoConfig{s} })
 	registerEndpoint(func(s *Server) interface{} { return &Catalog{s} })
+	registerEndpoint(func(s *Server) interface{} { return &ConfigEntry{s} })
 	registerEndpoint(func(s *Server) interface{} { return NewCoordinate(s) })
 	registerEndpoint(func(s *Server) interface{} { return &ConnectCA{srv: s} })
+	registerEndpoint(func(s *Server) interface{} { return &ConnectChange{s} })
+	registerEndpoint(func(s *Server) interface{} { return &DiscoveryChain{s} })
 	registerEndpoint(func(s *Server) interface{} { return &Health{s} })
 	registerEndpoint(func(s *Server) interface{} { return &Intention{s} })
 	registerEndpoint(func(s *Server) interface{} { return &Internal{s} })
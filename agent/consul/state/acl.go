@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/go-memdb"
@@ -58,6 +59,42 @@ func (s *TokenPoliciesIndex) PrefixFromArgs(args ...interface{}) ([]byte, error)
 	return val, nil
 }
 
+// PolicyNameIndex indexes policies by namespace and lowercased name, policies
+// without a namespace are indexed in the default namespace.
+type PolicyNameIndex struct {
+}
+
+func (s *PolicyNameIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	policy, ok := obj.(*structs.ACLPolicy)
+	if !ok {
+		return false, nil, fmt.Errorf("object is not an ACLPolicy")
+	}
+	if policy.Name == "" {
+		return false, nil, nil
+	}
+	return true, policyNameKey(policy.Namespace, policy.Name), nil
+}
+
+func (s *PolicyNameIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 2 {
+		return nil, fmt.Errorf("must provide the namespace and the name")
+	}
+	ns, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("namespace must be a string: %#v", args[0])
+	}
+	name, ok := args[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("name must be a string: %#v", args[1])
+	}
+	return policyNameKey(ns, name), nil
+}
+
+func policyNameKey(ns, name string) []byte {
+	ns = strings.ToLower(structs.ACLNamespaceOrDefault(ns))
+	return []byte(ns + "\x00" + strings.ToLower(name) + "\x00")
+}
+
 func tokensTableSchema() *memdb.TableSchema {
 	return &memdb.TableSchema{
 		Name: "acl-tokens",
@@ -136,11 +173,8 @@ func policiesTableSchema() *memdb.TableSchema {
 				Name:         "name",
 				AllowMissing: false,
 				Unique:       true,
-				Indexer: &memdb.StringFieldIndex{
-					Field: "Name",
-					// TODO (ACL-V2) - should we coerce to lowercase?
-					Lowercase: true,
-				},
+				// TODO (ACL-V2) - should we coerce to lowercase?
+				Indexer: &PolicyNameIndex{},
 			},
 		},
 	}
@@ -247,7 +281,7 @@ func (s *Store) CanBootstrapACLToken() (bool, uint64, error) {
 func (s *Store) resolveTokenPolicyLinks(tx *memdb.Txn, token *structs.ACLToken, allowMissing bool) error {
 	for linkIndex, link := range token.Policies {
 		if link.ID != "" {
-			policy, err := s.getPolicyWithTxn(tx, nil, link.ID, "id")
+			policy, err := s.getPolicyWithTxn(tx, nil, "id", link.ID)
 
 			if err != nil {
 				return err
@@ -286,7 +320,7 @@ func (s *Store) fixupTokenPolicyLinks(tx *memdb.Txn, original *structs.ACLToken)
 			return nil, fmt.Errorf("Detected corrupted token within the state store - missing policy link ID")
 		}
 
-		policy, err := s.getPolicyWithTxn(tx, nil, link.ID, "id")
+		policy, err := s.getPolicyWithTxn(tx, nil, "id", link.ID)
 
 		if err != nil {
 			return nil, err
@@ -712,7 +746,7 @@ func (s *Store) aclPolicySetTxn(tx *memdb.Txn, idx uint64, policy *structs.ACLPo
 	}
 
 	// ensure the name is unique (cannot conflict with another policy with a different ID)
-	nameMatch, err := tx.First("acl-policies", "name", policy.Name)
+	nameMatch, err := tx.First("acl-policies", "name", policy.Namespace, policy.Name)
 	if err != nil {
 		return fmt.Errorf("failed acl policy lookup: %v", err)
 	}
@@ -737,11 +771,12 @@ func (s *Store) aclPolicySetTxn(tx *memdb.Txn, idx uint64, policy *structs.ACLPo
 }
 
 func (s *Store) ACLPolicyGetByID(ws memdb.WatchSet, id string) (uint64, *structs.ACLPolicy, error) {
-	return s.aclPolicyGet(ws, id, "id")
+	return s.aclPolicyGet(ws, "id", id)
 }
 
-func (s *Store) ACLPolicyGetByName(ws memdb.WatchSet, name string) (uint64, *structs.ACLPolicy, error) {
-	return s.aclPolicyGet(ws, name, "name")
+// ACLPolicyGetByName returns the policy with the given name in namespace ns.
+func (s *Store) ACLPolicyGetByName(ws memdb.WatchSet, ns, name string) (uint64, *structs.ACLPolicy, error) {
+	return s.aclPolicyGet(ws, "name", ns, name)
 }
 
 func (s *Store) ACLPolicyBatchGet(ws memdb.WatchSet, ids []string) (uint64, structs.ACLPolicies, error) {
@@ -750,7 +785,7 @@ func (s *Store) ACLPolicyBatchGet(ws memdb.WatchSet, ids []string) (uint64, stru
 
 	policies := make(structs.ACLPolicies, 0)
 	for _, pid := range ids {
-		policy, err := s.getPolicyWithTxn(tx, ws, pid, "id")
+		policy, err := s.getPolicyWithTxn(tx, ws, "id", pid)
 		if err != nil {
 			return 0, nil, err
 		}
@@ -765,8 +800,8 @@ func (s *Store) ACLPolicyBatchGet(ws memdb.WatchSet, ids []string) (uint64, stru
 	return idx, policies, nil
 }
 
-func (s *Store) getPolicyWithTxn(tx *memdb.Txn, ws memdb.WatchSet, value, index string) (*structs.ACLPolicy, error) {
-	watchCh, policy, err := tx.FirstWatch("acl-policies", index, value)
+func (s *Store) getPolicyWithTxn(tx *memdb.Txn, ws memdb.WatchSet, index string, args ...interface{}) (*structs.ACLPolicy, error) {
+	watchCh, policy, err := tx.FirstWatch("acl-policies", index, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed acl policy lookup: %v", err)
 	}
@@ -779,11 +814,11 @@ func (s *Store) getPolicyWithTxn(tx *memdb.Txn, ws memdb.WatchSet, value, index
 	return policy.(*structs.ACLPolicy), nil
 }
 
-func (s *Store) aclPolicyGet(ws memdb.WatchSet, value, index string) (uint64, *structs.ACLPolicy, error) {
+func (s *Store) aclPolicyGet(ws memdb.WatchSet, index string, args ...interface{}) (uint64, *structs.ACLPolicy, error) {
 	tx := s.db.Txn(false)
 	defer tx.Abort()
 
-	policy, err := s.getPolicyWithTxn(tx, ws, value, index)
+	policy, err := s.getPolicyWithTxn(tx, ws, index, args...)
 	if err != nil {
 		return 0, nil, err
 	}
@@ -815,11 +850,12 @@ func (s *Store) ACLPolicyList(ws memdb.WatchSet) (uint64, structs.ACLPolicies, e
 }
 
 func (s *Store) ACLPolicyDeleteByID(idx uint64, id string) error {
-	return s.aclPolicyDelete(idx, id, "id")
+	return s.aclPolicyDelete(idx, "id", id)
 }
 
-func (s *Store) ACLPolicyDeleteByName(idx uint64, name string) error {
-	return s.aclPolicyDelete(idx, name, "name")
+// ACLPolicyDeleteByName deletes the policy with the given name in namespace ns.
+func (s *Store) ACLPolicyDeleteByName(idx uint64, ns, name string) error {
+	return s.aclPolicyDelete(idx, "name", ns, name)
 }
 
 func (s *Store) ACLPolicyBatchDelete(idx uint64, policyIDs []string) error {
@@ -827,7 +863,7 @@ func (s *Store) ACLPolicyBatchDelete(idx uint64, policyIDs []string) error {
 	defer tx.Abort()
 
 	for _, policyID := range policyIDs {
-		if err := s.aclPolicyDeleteTxn(tx, idx, policyID, "id"); err != nil {
+		if err := s.aclPolicyDeleteTxn(tx, idx, "id", policyID); err != nil {
 			return err
 		}
 	}
@@ -839,11 +875,11 @@ func (s *Store) ACLPolicyBatchDelete(idx uint64, policyIDs []string) error {
 	return nil
 }
 
-func (s *Store) aclPolicyDelete(idx uint64, value, index string) error {
+func (s *Store) aclPolicyDelete(idx uint64, index string, args ...interface{}) error {
 	tx := s.db.Txn(true)
 	defer tx.Abort()
 
-	if err := s.aclPolicyDeleteTxn(tx, idx, value, index); err != nil {
+	if err := s.aclPolicyDeleteTxn(tx, idx, index, args...); err != nil {
 		return err
 	}
 	if err := indexUpdateMaxTxn(tx, idx, "acl-policies"); err != nil {
@@ -854,9 +890,9 @@ func (s *Store) aclPolicyDelete(idx uint64, value, index string) error {
 	return nil
 }
 
-func (s *Store) aclPolicyDeleteTxn(tx *memdb.Txn, idx uint64, value, index string) error {
+func (s *Store) aclPolicyDeleteTxn(tx *memdb.Txn, idx uint64, index string, args ...interface{}) error {
 	// Look up the existing token
-	rawPolicy, err := tx.First("acl-policies", index, value)
+	rawPolicy, err := tx.First("acl-policies", index, args...)
 	if err != nil {
 		return fmt.Errorf("failed acl policy lookup: %v", err)
 	}
@@ -1124,7 +1124,7 @@ func TestStateStore_ACLPolicy_SetGet(t *testing.T) {
 
 			require.NoError(t, s.ACLPolicySet(3, &policy))
 
-			_, rpolicy, err := s.ACLPolicyGetByName(nil, "management")
+			_, rpolicy, err := s.ACLPolicyGetByName(nil, "", "management")
 			require.NoError(t, err)
 			require.NotNil(t, rpolicy)
 			require.Equal(t, structs.ACLPolicyGlobalManagementID, rpolicy.ID)
@@ -1208,6 +1208,55 @@ func TestStateStore_ACLPolicy_SetGet(t *testing.T) {
 	})
 }
 
+func TestStateStore_ACLPolicy_Namespaces(t *testing.T) {
+	t.Parallel()
+	s := testACLStateStore(t)
+
+	// The same name can be used in different namespaces.
+	policies := structs.ACLPolicies{
+		&structs.ACLPolicy{
+			ID:    "a4f68bd6-3af5-4f56-b764-3c6f20247879",
+			Name:  "service-read",
+			Rules: `service_prefix "" { policy = "read" }`,
+		},
+		&structs.ACLPolicy{
+			ID:        "a2719052-40b3-4a4b-baeb-f3df1831a217",
+			Name:      "service-read",
+			Namespace: "team-a",
+			Rules:     `service_prefix "" { policy = "read" }`,
+		},
+	}
+	require.NoError(t, s.ACLPolicyBatchSet(2, policies))
+
+	_, rpolicy, err := s.ACLPolicyGetByName(nil, "", "service-read")
+	require.NoError(t, err)
+	require.Equal(t, "a4f68bd6-3af5-4f56-b764-3c6f20247879", rpolicy.ID)
+
+	_, rpolicy, err = s.ACLPolicyGetByName(nil, structs.ACLDefaultNamespace, "service-read")
+	require.NoError(t, err)
+	require.Equal(t, "a4f68bd6-3af5-4f56-b764-3c6f20247879", rpolicy.ID)
+
+	_, rpolicy, err = s.ACLPolicyGetByName(nil, "team-a", "service-read")
+	require.NoError(t, err)
+	require.Equal(t, "a2719052-40b3-4a4b-baeb-f3df1831a217", rpolicy.ID)
+
+	_, rpolicy, err = s.ACLPolicyGetByName(nil, "team-b", "service-read")
+	require.NoError(t, err)
+	require.Nil(t, rpolicy)
+
+	// Names are still unique within a namespace.
+	require.Error(t, s.ACLPolicySet(3, &structs.ACLPolicy{
+		ID:        "e2f2ae4b-8e7d-4a4e-9bd4-4a0a9bb3f2a1",
+		Name:      "service-read",
+		Namespace: "team-a",
+	}))
+
+	require.NoError(t, s.ACLPolicyDeleteByName(3, "team-a", "service-read"))
+	_, rpolicy, err = s.ACLPolicyGetByName(nil, "", "service-read")
+	require.NoError(t, err)
+	require.NotNil(t, rpolicy)
+}
+
 func TestStateStore_ACLPolicy_UpsertBatchRead(t *testing.T) {
 	t.Parallel()
 
@@ -1399,14 +1448,14 @@ func TestStateStore_ACLPolicy_Delete(t *testing.T) {
 
 		require.NoError(t, s.ACLPolicySet(2, policy))
 
-		_, rpolicy, err := s.ACLPolicyGetByName(nil, "test-policy")
+		_, rpolicy, err := s.ACLPolicyGetByName(nil, "", "test-policy")
 		require.NoError(t, err)
 		require.NotNil(t, rpolicy)
 
-		require.NoError(t, s.ACLPolicyDeleteByName(3, "test-policy"))
+		require.NoError(t, s.ACLPolicyDeleteByName(3, "", "test-policy"))
 		require.NoError(t, err)
 
-		_, rpolicy, err = s.ACLPolicyGetByName(nil, "test-policy")
+		_, rpolicy, err = s.ACLPolicyGetByName(nil, "", "test-policy")
 		require.NoError(t, err)
 		require.Nil(t, rpolicy)
 	})
@@ -1454,7 +1503,7 @@ func TestStateStore_ACLPolicy_Delete(t *testing.T) {
 		s := testACLStateStore(t)
 
 		require.Error(t, s.ACLPolicyDeleteByID(5, structs.ACLPolicyGlobalManagementID))
-		require.Error(t, s.ACLPolicyDeleteByName(5, "global-management"))
+		require.Error(t, s.ACLPolicyDeleteByName(5, "", "global-management"))
 	})
 
 	t.Run("Not Found", func(t *testing.T) {
@@ -1462,7 +1511,7 @@ func TestStateStore_ACLPolicy_Delete(t *testing.T) {
 		s := testACLStateStore(t)
 
 		// deletion of non-existent policies is not an error
-		require.NoError(t, s.ACLPolicyDeleteByName(3, "not-found"))
+		require.NoError(t, s.ACLPolicyDeleteByName(3, "", "not-found"))
 		require.NoError(t, s.ACLPolicyDeleteByID(3, "376d0cae-dd50-4213-9668-2c7797a7fb2d"))
 	})
 }
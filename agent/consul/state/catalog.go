@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/hashicorp/consul/agent/structs"
@@ -793,6 +794,9 @@ func (s *Store) ensureServiceTxn(tx *memdb.Txn, idx uint64, node string, svc *st
 	if err = structs.ValidateMetadata(svc.Meta, false); err != nil {
 		return fmt.Errorf("Invalid Service Meta for node %s and serviceID %s: %v", node, svc.ID, err)
 	}
+	if err = structs.ValidateLabels(svc.Labels); err != nil {
+		return fmt.Errorf("Invalid Service Labels for node %s and serviceID %s: %v", node, svc.ID, err)
+	}
 	// Create the service node entry and populate the indexes. Note that
 	// conversion doesn't populate any of the node-specific information.
 	// That's always populated when we read from the state store.
@@ -876,6 +880,35 @@ func (s *Store) Services(ws memdb.WatchSet) (uint64, structs.Services, error) {
 	return idx, results, nil
 }
 
+// ServiceInstances returns all the service instances, including the details
+// of their nodes.
+func (s *Store) ServiceInstances(ws memdb.WatchSet) (uint64, structs.ServiceNodes, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, "nodes", "services")
+
+	// List all the service instances.
+	services, err := tx.Get("services", "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed querying services: %s", err)
+	}
+	ws.Add(services.WatchCh())
+
+	var results structs.ServiceNodes
+	for service := services.Next(); service != nil; service = services.Next() {
+		results = append(results, service.(*structs.ServiceNode))
+	}
+
+	// Fill in the node details.
+	results, err = s.parseServiceNodes(tx, ws, results)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed parsing service nodes: %s", err)
+	}
+	return idx, results, nil
+}
+
 // ServicesByNodeMeta returns all services, filtered by the given node metadata.
 func (s *Store) ServicesByNodeMeta(ws memdb.WatchSet, filters map[string]string) (uint64, structs.Services, error) {
 	tx := s.db.Txn(false)
@@ -1001,6 +1034,52 @@ func (s *Store) ConnectServiceNodes(ws memdb.WatchSet, serviceName string) (uint
 	return s.serviceNodes(ws, serviceName, true)
 }
 
+// GatewayServices returns the services fronted by the given gateway, as
+// listed in its ingress-gateway or terminating-gateway config entry.
+func (s *Store) GatewayServices(ws memdb.WatchSet, gateway string) (uint64, structs.GatewayServices, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	var results structs.GatewayServices
+	for _, kind := range []string{structs.IngressGateway, structs.TerminatingGateway} {
+		watchCh, entry, err := tx.FirstWatch(configTableName, "id", kind, gateway)
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed config entry lookup: %s", err)
+		}
+		ws.Add(watchCh)
+
+		switch e := entry.(type) {
+		case *structs.IngressGatewayConfigEntry:
+			for _, l := range e.Listeners {
+				for _, svc := range l.Services {
+					results = append(results, &structs.GatewayService{
+						Gateway:     gateway,
+						GatewayKind: structs.ServiceKindIngressGateway,
+						Service:     svc.Name,
+						Port:        l.Port,
+					})
+				}
+			}
+		case *structs.TerminatingGatewayConfigEntry:
+			for _, svc := range e.Services {
+				results = append(results, &structs.GatewayService{
+					Gateway:     gateway,
+					GatewayKind: structs.ServiceKindTerminatingGateway,
+					Service:     svc.Name,
+				})
+			}
+		}
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Service != results[j].Service {
+			return results[i].Service < results[j].Service
+		}
+		return results[i].Port < results[j].Port
+	})
+
+	return maxIndexTxn(tx, configTableName), results, nil
+}
+
 // ServiceNodes returns the nodes associated with a given service name.
 func (s *Store) ServiceNodes(ws memdb.WatchSet, serviceName string) (uint64, structs.ServiceNodes, error) {
 	return s.serviceNodes(ws, serviceName, false)
@@ -1034,6 +1113,18 @@ func (s *Store) serviceNodes(ws memdb.WatchSet, serviceName string, connect bool
 		results = append(results, service.(*structs.ServiceNode))
 	}
 
+	// The terminating gateways linked to the service are Connect endpoints
+	// of the service too.
+	var gateways structs.ServiceNodes
+	var linked bool
+	if connect {
+		gateways, linked, err = terminatingGatewayServices(tx, ws, serviceName)
+		if err != nil {
+			return 0, nil, err
+		}
+		results = append(results, gateways...)
+	}
+
 	// Fill in the node details.
 	results, err = s.parseServiceNodes(tx, ws, results)
 	if err != nil {
@@ -1042,10 +1133,58 @@ func (s *Store) serviceNodes(ws memdb.WatchSet, serviceName string, connect bool
 
 	// Get the table index.
 	idx := maxIndexForService(tx, serviceName, len(results) > 0, false)
+	if linked {
+		if configIdx := maxIndexTxn(tx, configTableName); configIdx > idx {
+			idx = configIdx
+		}
+		for _, gw := range gateways {
+			if gwIdx := maxIndexForService(tx, gw.ServiceName, true, false); gwIdx > idx {
+				idx = gwIdx
+			}
+		}
+	}
 
 	return idx, results, nil
 }
 
+// terminatingGatewayServices returns the instances of the terminating
+// gateways linked to the given service by their config entries, and whether
+// there is any terminating gateway config entry whose changes may link or
+// unlink the service.
+func terminatingGatewayServices(tx *memdb.Txn, ws memdb.WatchSet, serviceName string) (structs.ServiceNodes, bool, error) {
+	entries, err := tx.Get(configTableName, "kind", structs.TerminatingGateway)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed config entry lookup: %s", err)
+	}
+	ws.Add(entries.WatchCh())
+
+	var results structs.ServiceNodes
+	found := false
+	for raw := entries.Next(); raw != nil; raw = entries.Next() {
+		found = true
+		entry, ok := raw.(*structs.TerminatingGatewayConfigEntry)
+		if !ok {
+			continue
+		}
+		if _, ok := entry.LinkedService(serviceName); !ok {
+			continue
+		}
+
+		services, err := tx.Get("services", "service", entry.Name)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed service lookup: %s", err)
+		}
+		ws.Add(services.WatchCh())
+		for service := services.Next(); service != nil; service = services.Next() {
+			sn := service.(*structs.ServiceNode)
+			if sn.ServiceKind == structs.ServiceKindTerminatingGateway {
+				results = append(results, sn)
+			}
+		}
+	}
+	return results, found, nil
+}
+
 // ServiceTagNodes returns the nodes associated with a given service, filtering
 // out services that don't contain the given tags.
 func (s *Store) ServiceTagNodes(ws memdb.WatchSet, service string, tags []string) (uint64, structs.ServiceNodes, error) {
@@ -1913,6 +2052,22 @@ func (s *Store) checkServiceNodes(ws memdb.WatchSet, serviceName string, connect
 		serviceNames[sn.ServiceName] = struct{}{}
 	}
 
+	// The terminating gateways linked to the service are Connect endpoints
+	// of the service too. Their service names are watched like the ones of
+	// the proxies, and the config entries linking them are always watched.
+	linked := false
+	if connect {
+		var gateways structs.ServiceNodes
+		gateways, linked, err = terminatingGatewayServices(tx, ws, serviceName)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, sn := range gateways {
+			results = append(results, sn)
+			serviceNames[sn.ServiceName] = struct{}{}
+		}
+	}
+
 	// watchOptimized tracks if we meet the necessary condition to optimize
 	// WatchSet size. That is that every service name represented in the result
 	// set must have a service-specific index we can watch instead of many radix
@@ -1958,6 +2113,11 @@ func (s *Store) checkServiceNodes(ws memdb.WatchSet, serviceName string, connect
 		// to as there is only one chan to watch anyway).
 		idx, _ = maxIndexAndWatchChForService(tx, serviceName, false, true)
 	}
+	if linked {
+		if configIdx := maxIndexTxn(tx, configTableName); configIdx > idx {
+			idx = configIdx
+		}
+	}
 
 	// Create a nil watchset to pass below, we'll only pass the real one if we
 	// need to. Nil watchers are safe/allowed and saves some allocation too.
@@ -2131,6 +2291,33 @@ func (s *Store) NodeDump(ws memdb.WatchSet) (uint64, structs.NodeDump, error) {
 	return s.parseNodes(tx, ws, idx, nodes)
 }
 
+// ServiceDumpKind returns the instances of all the services of the given
+// kind, along with their health checks.
+func (s *Store) ServiceDumpKind(ws memdb.WatchSet, kind structs.ServiceKind) (uint64, structs.CheckServiceNodes, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, "nodes", "services", "checks")
+
+	// There is no index on the kind of the services so all of them are
+	// scanned.
+	services, err := tx.Get("services", "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed services lookup: %s", err)
+	}
+	ws.Add(services.WatchCh())
+
+	var results structs.ServiceNodes
+	for service := services.Next(); service != nil; service = services.Next() {
+		sn := service.(*structs.ServiceNode)
+		if sn.ServiceKind == kind {
+			results = append(results, sn)
+		}
+	}
+	return s.parseCheckServiceNodes(tx, ws, idx, "", results, nil)
+}
+
 // parseNodes takes an iterator over a set of nodes and returns a struct
 // containing the nodes along with all of their associated services
 // and/or health checks.
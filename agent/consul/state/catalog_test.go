@@ -1557,6 +1557,55 @@ func TestStateStore_Services(t *testing.T) {
 	}
 }
 
+func TestStateStore_ServiceInstances(t *testing.T) {
+	s := testStateStore(t)
+
+	// Listing with no results returns an empty list.
+	ws := memdb.NewWatchSet()
+	idx, instances, err := s.ServiceInstances(ws)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 0 || len(instances) != 0 {
+		t.Fatalf("bad: %d %v", idx, instances)
+	}
+
+	// Register a node with metadata and two services.
+	node := &structs.Node{Node: "node1", Address: "1.2.3.4", Meta: map[string]string{"env": "prod"}}
+	if err := s.EnsureNode(1, node); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	testRegisterService(t, s, 2, "node1", "redis")
+	testRegisterService(t, s, 3, "node1", "dogs")
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+
+	// The instances include the details of their node.
+	ws = memdb.NewWatchSet()
+	idx, instances, err = s.ServiceInstances(ws)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 3 || len(instances) != 2 {
+		t.Fatalf("bad: %d %v", idx, instances)
+	}
+	for _, instance := range instances {
+		if instance.Address != "1.2.3.4" || instance.NodeMeta["env"] != "prod" {
+			t.Fatalf("bad: %#v", instance)
+		}
+	}
+
+	// Updating the node fires the watch.
+	node = &structs.Node{Node: "node1", Address: "1.2.3.4", Meta: map[string]string{"env": "dev"}}
+	if err := s.EnsureNode(4, node); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+}
+
 func TestStateStore_ServicesByNodeMeta(t *testing.T) {
 	s := testStateStore(t)
 
@@ -2062,6 +2111,136 @@ func TestStateStore_ConnectServiceNodes(t *testing.T) {
 	assert.True(watchFired(ws))
 }
 
+func TestStateStore_GatewayServices(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	// Listing with no results returns an empty list.
+	ws := memdb.NewWatchSet()
+	idx, services, err := s.GatewayServices(ws, "ingress")
+	require.NoError(err)
+	require.Equal(uint64(0), idx)
+	require.Len(services, 0)
+
+	// Configure the gateway and some unrelated entry.
+	ingress := &structs.IngressGatewayConfigEntry{
+		Kind: structs.IngressGateway,
+		Name: "ingress",
+		Listeners: []structs.IngressListener{
+			{
+				Port:     8080,
+				Protocol: structs.IngressListenerProtocolHTTP,
+				Services: []structs.IngressService{{Name: "web"}, {Name: "api"}},
+			},
+			{
+				Port:     9191,
+				Protocol: structs.IngressListenerProtocolTCP,
+				Services: []structs.IngressService{{Name: "web"}},
+			},
+		},
+	}
+	require.NoError(s.EnsureConfigEntry(10, ingress))
+	require.True(watchFired(ws))
+	require.NoError(s.EnsureConfigEntry(11, &structs.ServiceConfigEntry{
+		Kind: structs.ServiceDefaults,
+		Name: "web",
+	}))
+
+	// Read everything back.
+	ws = memdb.NewWatchSet()
+	idx, services, err = s.GatewayServices(ws, "ingress")
+	require.NoError(err)
+	require.Equal(uint64(11), idx)
+	require.Equal(structs.GatewayServices{
+		{Gateway: "ingress", GatewayKind: structs.ServiceKindIngressGateway, Service: "api", Port: 8080},
+		{Gateway: "ingress", GatewayKind: structs.ServiceKindIngressGateway, Service: "web", Port: 8080},
+		{Gateway: "ingress", GatewayKind: structs.ServiceKindIngressGateway, Service: "web", Port: 9191},
+	}, services)
+
+	// Unconfigured gateways front no services.
+	_, services, err = s.GatewayServices(nil, "web")
+	require.NoError(err)
+	require.Len(services, 0)
+
+	// Updating the gateway should fire the watch.
+	ingress.Listeners = ingress.Listeners[1:]
+	require.NoError(s.EnsureConfigEntry(13, ingress))
+	require.True(watchFired(ws))
+	ws = memdb.NewWatchSet()
+	idx, services, err = s.GatewayServices(ws, "ingress")
+	require.NoError(err)
+	require.Equal(uint64(13), idx)
+	require.Len(services, 1)
+	require.Equal("web", services[0].Service)
+
+	// So should configuring a terminating gateway of the same name.
+	require.NoError(s.EnsureConfigEntry(14, &structs.TerminatingGatewayConfigEntry{
+		Kind:     structs.TerminatingGateway,
+		Name:     "ingress",
+		Services: []structs.LinkedService{{Name: "billing"}},
+	}))
+	require.True(watchFired(ws))
+	ws = memdb.NewWatchSet()
+	_, services, err = s.GatewayServices(ws, "ingress")
+	require.NoError(err)
+	require.Equal(structs.GatewayServices{
+		{Gateway: "ingress", GatewayKind: structs.ServiceKindTerminatingGateway, Service: "billing"},
+		{Gateway: "ingress", GatewayKind: structs.ServiceKindIngressGateway, Service: "web", Port: 9191},
+	}, services)
+
+	// And deleting the gateway's entry.
+	require.NoError(s.DeleteConfigEntry(15, structs.IngressGateway, "ingress"))
+	require.True(watchFired(ws))
+	idx, services, err = s.GatewayServices(nil, "ingress")
+	require.NoError(err)
+	require.Equal(uint64(15), idx)
+	require.Len(services, 1)
+	require.Equal("billing", services[0].Service)
+}
+
+func TestStateStore_ServiceDumpKind(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	// Listing with no results returns an empty list.
+	ws := memdb.NewWatchSet()
+	_, nodes, err := s.ServiceDumpKind(ws, structs.ServiceKindMeshGateway)
+	require.NoError(err)
+	require.Len(nodes, 0)
+
+	// Register two mesh gateways, a check and a typical service.
+	require.NoError(s.EnsureNode(10, &structs.Node{Node: "foo", Address: "127.0.0.1"}))
+	require.NoError(s.EnsureNode(11, &structs.Node{Node: "bar", Address: "127.0.0.2"}))
+	require.NoError(s.EnsureService(12, "foo", &structs.NodeService{
+		Kind: structs.ServiceKindMeshGateway, ID: "mesh-gateway", Service: "mesh-gateway", Port: 8443}))
+	require.NoError(s.EnsureService(13, "bar", &structs.NodeService{
+		Kind: structs.ServiceKindMeshGateway, ID: "mesh-gateway", Service: "mesh-gateway", Port: 8443}))
+	require.NoError(s.EnsureService(14, "bar", &structs.NodeService{ID: "web", Service: "web", Port: 5000}))
+	require.NoError(s.EnsureCheck(15, &structs.HealthCheck{
+		Node: "bar", CheckID: "gateway", ServiceID: "mesh-gateway", Status: api.HealthPassing}))
+	require.True(watchFired(ws))
+
+	ws = memdb.NewWatchSet()
+	idx, nodes, err := s.ServiceDumpKind(ws, structs.ServiceKindMeshGateway)
+	require.NoError(err)
+	require.Equal(uint64(15), idx)
+	require.Len(nodes, 2)
+	for _, node := range nodes {
+		require.Equal(structs.ServiceKindMeshGateway, node.Service.Kind)
+		if node.Node.Node == "bar" {
+			require.Len(node.Checks, 1)
+		}
+	}
+
+	// Registering another gateway fires the watch.
+	require.NoError(s.EnsureService(16, "foo", &structs.NodeService{
+		Kind: structs.ServiceKindMeshGateway, ID: "mesh-gateway-2", Service: "mesh-gateway", Port: 8444}))
+	require.True(watchFired(ws))
+	_, nodes, err = s.ServiceDumpKind(nil, structs.ServiceKindMeshGateway)
+	require.NoError(err)
+	require.Len(nodes, 3)
+}
+
 func TestStateStore_Service_Snapshot(t *testing.T) {
 	s := testStateStore(t)
 
@@ -3221,12 +3400,17 @@ func TestStateStore_ConnectQueryBlocking(t *testing.T) {
 
 			require := require.New(t)
 
+			// The terminating gateway config entries, which may link the
+			// service to gateways, are always watched on top of the chans
+			// expected below.
+			const configEntriesWatch = 1
+
 			// Run the query
 			ws := memdb.NewWatchSet()
 			idx, res, err := s.CheckConnectServiceNodes(ws, tt.svc)
 			require.NoError(err)
 			require.Len(res, tt.wantBeforeResLen)
-			require.Len(ws, tt.wantBeforeWatchSetSize)
+			require.Len(ws, tt.wantBeforeWatchSetSize+configEntriesWatch)
 
 			// Mutate the state store
 			if tt.updateFn != nil {
@@ -3246,7 +3430,7 @@ func TestStateStore_ConnectQueryBlocking(t *testing.T) {
 			require.NoError(err)
 			require.Len(res, tt.wantAfterResLen)
 			require.Equal(tt.wantAfterIndex, idx)
-			require.Len(ws, tt.wantAfterWatchSetSize)
+			require.Len(ws, tt.wantAfterWatchSetSize+configEntriesWatch)
 		})
 	}
 }
@@ -3409,6 +3593,60 @@ func TestStateStore_CheckConnectServiceNodes(t *testing.T) {
 	}
 }
 
+func TestStateStore_CheckConnectServiceNodes_TerminatingGateway(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	// A legacy service outside the mesh and a terminating gateway.
+	require.NoError(s.EnsureNode(10, &structs.Node{Node: "foo", Address: "127.0.0.1"}))
+	require.NoError(s.EnsureService(11, "foo", &structs.NodeService{ID: "legacy", Service: "legacy", Port: 5000}))
+	require.NoError(s.EnsureService(12, "foo", &structs.NodeService{
+		Kind:    structs.ServiceKindTerminatingGateway,
+		ID:      "terminating",
+		Service: "terminating",
+		Port:    8443,
+	}))
+
+	// The gateway isn't an endpoint of the service until it's linked.
+	ws := memdb.NewWatchSet()
+	_, nodes, err := s.CheckConnectServiceNodes(ws, "legacy")
+	require.NoError(err)
+	require.Len(nodes, 0)
+
+	require.NoError(s.EnsureConfigEntry(13, &structs.TerminatingGatewayConfigEntry{
+		Kind:     structs.TerminatingGateway,
+		Name:     "terminating",
+		Services: []structs.LinkedService{{Name: "legacy"}},
+	}))
+	require.True(watchFired(ws))
+
+	ws = memdb.NewWatchSet()
+	idx, nodes, err := s.CheckConnectServiceNodes(ws, "legacy")
+	require.NoError(err)
+	require.Equal(uint64(13), idx)
+	require.Len(nodes, 1)
+	require.Equal(structs.ServiceKindTerminatingGateway, nodes[0].Service.Kind)
+	require.Equal("terminating", nodes[0].Service.Service)
+
+	// The catalog endpoints return it too.
+	_, sns, err := s.ConnectServiceNodes(nil, "legacy")
+	require.NoError(err)
+	require.Len(sns, 1)
+	require.Equal("terminating", sns[0].ServiceName)
+
+	// Unlinking the service removes the gateway.
+	require.NoError(s.EnsureConfigEntry(14, &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "terminating",
+	}))
+	require.True(watchFired(ws))
+
+	idx, nodes, err = s.CheckConnectServiceNodes(nil, "legacy")
+	require.NoError(err)
+	require.Equal(uint64(14), idx)
+	require.Len(nodes, 0)
+}
+
 func BenchmarkCheckServiceNodes(b *testing.B) {
 	s, err := NewStateStore(nil)
 	if err != nil {
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/consul/agent/structs"
+	memdb "github.com/hashicorp/go-memdb"
 	"github.com/stretchr/testify/require"
 )
 
@@ -22,7 +23,7 @@ func TestStore_ConfigEntry(t *testing.T) {
 	// Create
 	require.NoError(s.EnsureConfigEntry(0, expected))
 
-	idx, config, err := s.ConfigEntry(structs.ProxyDefaults, "global")
+	idx, config, err := s.ConfigEntry(nil, structs.ProxyDefaults, "global")
 	require.NoError(err)
 	require.Equal(uint64(0), idx)
 	require.Equal(expected, config)
@@ -37,7 +38,7 @@ func TestStore_ConfigEntry(t *testing.T) {
 	}
 	require.NoError(s.EnsureConfigEntry(1, updated))
 
-	idx, config, err = s.ConfigEntry(structs.ProxyDefaults, "global")
+	idx, config, err = s.ConfigEntry(nil, structs.ProxyDefaults, "global")
 	require.NoError(err)
 	require.Equal(uint64(1), idx)
 	require.Equal(updated, config)
@@ -45,7 +46,7 @@ func TestStore_ConfigEntry(t *testing.T) {
 	// Delete
 	require.NoError(s.DeleteConfigEntry(2, structs.ProxyDefaults, "global"))
 
-	idx, config, err = s.ConfigEntry(structs.ProxyDefaults, "global")
+	idx, config, err = s.ConfigEntry(nil, structs.ProxyDefaults, "global")
 	require.NoError(err)
 	require.Equal(uint64(2), idx)
 	require.Nil(config)
@@ -66,7 +67,7 @@ func TestStore_ConfigEntryCAS(t *testing.T) {
 	// Create
 	require.NoError(s.EnsureConfigEntry(1, expected))
 
-	idx, config, err := s.ConfigEntry(structs.ProxyDefaults, "global")
+	idx, config, err := s.ConfigEntry(nil, structs.ProxyDefaults, "global")
 	require.NoError(err)
 	require.Equal(uint64(1), idx)
 	require.Equal(expected, config)
@@ -84,7 +85,7 @@ func TestStore_ConfigEntryCAS(t *testing.T) {
 	require.NoError(err)
 
 	// Entry should not be changed
-	idx, config, err = s.ConfigEntry(structs.ProxyDefaults, "global")
+	idx, config, err = s.ConfigEntry(nil, structs.ProxyDefaults, "global")
 	require.NoError(err)
 	require.Equal(uint64(1), idx)
 	require.Equal(expected, config)
@@ -95,7 +96,7 @@ func TestStore_ConfigEntryCAS(t *testing.T) {
 	require.NoError(err)
 
 	// Entry should be updated
-	idx, config, err = s.ConfigEntry(structs.ProxyDefaults, "global")
+	idx, config, err = s.ConfigEntry(nil, structs.ProxyDefaults, "global")
 	require.NoError(err)
 	require.Equal(uint64(2), idx)
 	require.Equal(updated, config)
@@ -119,20 +120,63 @@ func TestStore_ConfigEntries(t *testing.T) {
 	require.NoError(s.EnsureConfigEntry(1, entry2))
 
 	// Get all entries
-	idx, entries, err := s.ConfigEntries()
+	idx, entries, err := s.ConfigEntries(nil)
 	require.NoError(err)
 	require.Equal(uint64(1), idx)
 	require.Equal([]structs.ConfigEntry{entry1, entry2}, entries)
 
 	// Get all proxy entries
-	idx, entries, err = s.ConfigEntriesByKind(structs.ProxyDefaults)
+	idx, entries, err = s.ConfigEntriesByKind(nil, structs.ProxyDefaults)
 	require.NoError(err)
 	require.Equal(uint64(1), idx)
 	require.Equal([]structs.ConfigEntry{entry1}, entries)
 
 	// Get all service entries
-	idx, entries, err = s.ConfigEntriesByKind(structs.ServiceDefaults)
+	idx, entries, err = s.ConfigEntriesByKind(nil, structs.ServiceDefaults)
 	require.NoError(err)
 	require.Equal(uint64(1), idx)
 	require.Equal([]structs.ConfigEntry{entry2}, entries)
 }
+
+func TestStore_ConfigEntry_Watches(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	entry := &structs.ServiceConfigEntry{
+		Kind: structs.ServiceDefaults,
+		Name: "test",
+	}
+	require.NoError(s.EnsureConfigEntry(0, entry))
+
+	// Watch the entry and the entries of its kind.
+	ws := memdb.NewWatchSet()
+	_, _, err := s.ConfigEntry(ws, structs.ServiceDefaults, "test")
+	require.NoError(err)
+	kindWS := memdb.NewWatchSet()
+	_, _, err = s.ConfigEntriesByKind(kindWS, structs.ServiceDefaults)
+	require.NoError(err)
+
+	// Adding an entry of another kind doesn't fire them.
+	require.NoError(s.EnsureConfigEntry(1, &structs.ProxyConfigEntry{
+		Kind: structs.ProxyDefaults,
+		Name: "global",
+	}))
+	require.False(watchFired(ws))
+	require.False(watchFired(kindWS))
+
+	// Updating the entry fires both.
+	require.NoError(s.EnsureConfigEntry(2, &structs.ServiceConfigEntry{
+		Kind:     structs.ServiceDefaults,
+		Name:     "test",
+		Protocol: "http",
+	}))
+	require.True(watchFired(ws))
+	require.True(watchFired(kindWS))
+
+	// Deleting it fires the watch of the entry.
+	ws = memdb.NewWatchSet()
+	_, _, err = s.ConfigEntry(ws, structs.ServiceDefaults, "test")
+	require.NoError(err)
+	require.NoError(s.DeleteConfigEntry(3, structs.ServiceDefaults, "test"))
+	require.True(watchFired(ws))
+}
@@ -0,0 +1,276 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+const (
+	connectChangesTableName = "connect-changes"
+
+	// maxConnectChanges is the number of changes kept in the changelog of
+	// the intentions and config entries, the oldest ones being dropped.
+	maxConnectChanges = 1024
+)
+
+// connectChangesTableSchema returns a new table schema used to store the
+// changelog of the intentions and config entries.
+func connectChangesTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: connectChangesTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UintFieldIndex{
+					Field: "Index",
+				},
+			},
+		},
+	}
+}
+
+func init() {
+	registerSchema(connectChangesTableSchema)
+}
+
+// ConnectChanges is used to pull the changelog for the snapshot.
+func (s *Snapshot) ConnectChanges() (structs.ConnectChanges, error) {
+	changes, err := s.tx.Get(connectChangesTableName, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var ret structs.ConnectChanges
+	for wrapped := changes.Next(); wrapped != nil; wrapped = changes.Next() {
+		ret = append(ret, wrapped.(*structs.ConnectChange))
+	}
+	return ret, nil
+}
+
+// ConnectChange is used when restoring from a snapshot.
+func (s *Restore) ConnectChange(change *structs.ConnectChange) error {
+	if err := s.tx.Insert(connectChangesTableName, change); err != nil {
+		return fmt.Errorf("failed restoring connect change: %s", err)
+	}
+	if err := indexUpdateMaxTxn(s.tx, change.Index, connectChangesTableName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
+// ConnectChanges returns the changes of the intentions and config entries,
+// from the newest. The changes are restricted to the given kind and name if
+// not empty.
+func (s *Store) ConnectChanges(ws memdb.WatchSet, kind, name string) (uint64, structs.ConnectChanges, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the index
+	idx := maxIndexTxn(tx, connectChangesTableName)
+
+	changes, err := tx.Get(connectChangesTableName, "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed connect change lookup: %s", err)
+	}
+	ws.Add(changes.WatchCh())
+
+	var results structs.ConnectChanges
+	for wrapped := changes.Next(); wrapped != nil; wrapped = changes.Next() {
+		change := wrapped.(*structs.ConnectChange)
+		if kind != "" && change.Kind != kind {
+			continue
+		}
+		if name != "" && change.Name != name {
+			continue
+		}
+		results = append(results, change)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Index > results[j].Index
+	})
+	return idx, results, nil
+}
+
+// IntentionApply applies the intention operation of the request and records
+// it in the changelog.
+func (s *Store) IntentionApply(idx uint64, req *structs.IntentionRequest) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	if err := s.intentionApplyTxn(tx, idx, req.Op, req.Intention, req.Actor); err != nil {
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// intentionApplyTxn is the inner method used to apply an intention operation
+// and record it in the changelog.
+func (s *Store) intentionApplyTxn(tx *memdb.Txn, idx uint64, op structs.IntentionOp, ixn *structs.Intention, actor string) error {
+	before, err := tx.First(intentionsTableName, "id", ixn.ID)
+	if err != nil {
+		return fmt.Errorf("failed intention lookup: %s", err)
+	}
+
+	switch op {
+	case structs.IntentionOpCreate, structs.IntentionOpUpdate:
+		if err := s.intentionSetTxn(tx, idx, ixn); err != nil {
+			return err
+		}
+	case structs.IntentionOpDelete:
+		if err := s.intentionDeleteTxn(tx, idx, ixn.ID); err != nil {
+			return fmt.Errorf("failed intention delete: %s", err)
+		}
+	default:
+		return fmt.Errorf("Invalid Intention operation '%s'", op)
+	}
+
+	after, err := tx.First(intentionsTableName, "id", ixn.ID)
+	if err != nil {
+		return fmt.Errorf("failed intention lookup: %s", err)
+	}
+
+	change := &structs.ConnectChange{
+		Kind:  structs.ConnectChangeIntention,
+		Name:  ixn.ID,
+		Actor: actor,
+		Time:  ixn.UpdatedAt,
+	}
+	for _, v := range []interface{}{before, after} {
+		if v != nil {
+			change.SourceName = v.(*structs.Intention).SourceName
+			change.DestinationName = v.(*structs.Intention).DestinationName
+		}
+	}
+	return connectChangeInsertTxn(tx, idx, change, before, after)
+}
+
+// ConfigEntryApply applies the config entry operation of the request and
+// records it in the changelog.
+func (s *Store) ConfigEntryApply(idx uint64, req *structs.ConfigEntryRequest) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	kind, name := req.Entry.GetKind(), req.Entry.GetName()
+	before, err := tx.First(configTableName, "id", kind, name)
+	if err != nil {
+		return fmt.Errorf("failed config entry lookup: %s", err)
+	}
+
+	switch req.Op {
+	case structs.ConfigEntryUpsert:
+		if err := s.ensureConfigEntryTxn(tx, idx, req.Entry); err != nil {
+			return err
+		}
+	case structs.ConfigEntryDelete:
+		if err := s.deleteConfigEntryTxn(tx, idx, kind, name); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid config entry operation type: %v", req.Op)
+	}
+
+	after, err := tx.First(configTableName, "id", kind, name)
+	if err != nil {
+		return fmt.Errorf("failed config entry lookup: %s", err)
+	}
+
+	change := &structs.ConnectChange{
+		Kind:  kind,
+		Name:  name,
+		Actor: req.Actor,
+		Time:  req.Timestamp,
+	}
+	if err := connectChangeInsertTxn(tx, idx, change, before, after); err != nil {
+		return err
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// connectChangeInsertTxn records a change with the given values before and
+// after it, nil if missing, and drops the oldest changes beyond
+// maxConnectChanges. Nothing is recorded if both values are missing.
+func connectChangeInsertTxn(tx *memdb.Txn, idx uint64, change *structs.ConnectChange, before, after interface{}) error {
+	if before == nil && after == nil {
+		return nil
+	}
+	change.Index = idx
+	switch {
+	case before == nil:
+		change.Op = structs.ConnectChangeCreate
+	case after == nil:
+		change.Op = structs.ConnectChangeDelete
+	default:
+		change.Op = structs.ConnectChangeUpdate
+	}
+
+	var err error
+	if change.Before, err = encodeConnectChangeValue(before); err != nil {
+		return err
+	}
+	if change.After, err = encodeConnectChangeValue(after); err != nil {
+		return err
+	}
+
+	if err := tx.Insert(connectChangesTableName, change); err != nil {
+		return fmt.Errorf("failed inserting connect change: %s", err)
+	}
+	if err := indexUpdateMaxTxn(tx, idx, connectChangesTableName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	// Drop the oldest changes. The index doesn't sort the changes so they
+	// are sorted here, which is cheap since the changelog is bounded.
+	changes, err := tx.Get(connectChangesTableName, "id")
+	if err != nil {
+		return fmt.Errorf("failed connect change lookup: %s", err)
+	}
+	var all structs.ConnectChanges
+	for wrapped := changes.Next(); wrapped != nil; wrapped = changes.Next() {
+		all = append(all, wrapped.(*structs.ConnectChange))
+	}
+	if len(all) <= maxConnectChanges {
+		return nil
+	}
+	sort.Slice(all, func(i, j int) bool {
+		return all[i].Index < all[j].Index
+	})
+	for _, old := range all[:len(all)-maxConnectChanges] {
+		if err := tx.Delete(connectChangesTableName, old); err != nil {
+			return fmt.Errorf("failed deleting connect change: %s", err)
+		}
+	}
+	return nil
+}
+
+// encodeConnectChangeValue returns the JSON encoded value without its Raft
+// indexes, which are the same as the index of the change, or an empty string
+// if it is nil.
+func encodeConnectChangeValue(v interface{}) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("failed encoding connect change: %s", err)
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return "", fmt.Errorf("failed encoding connect change: %s", err)
+	}
+	delete(m, "CreateIndex")
+	delete(m, "ModifyIndex")
+	if buf, err = json.Marshal(m); err != nil {
+		return "", fmt.Errorf("failed encoding connect change: %s", err)
+	}
+	return string(buf), nil
+}
@@ -0,0 +1,207 @@
+package state
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_ConnectChanges_Intention(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	ws := memdb.NewWatchSet()
+	idx, changes, err := s.ConnectChanges(ws, "", "")
+	require.NoError(err)
+	require.Equal(uint64(0), idx)
+	require.Len(changes, 0)
+
+	now := time.Now().UTC()
+	ixn := &structs.Intention{
+		ID:              testUUID(),
+		SourceNS:        "default",
+		SourceName:      "web",
+		DestinationNS:   "default",
+		DestinationName: "db",
+		Action:          structs.IntentionActionAllow,
+		UpdatedAt:       now,
+	}
+	require.NoError(s.IntentionApply(1, &structs.IntentionRequest{
+		Op:        structs.IntentionOpCreate,
+		Intention: ixn,
+		Actor:     "accessor",
+	}))
+	require.True(watchFired(ws))
+
+	updated := *ixn
+	updated.Action = structs.IntentionActionDeny
+	updated.UpdatedAt = now.Add(time.Second)
+	require.NoError(s.IntentionApply(2, &structs.IntentionRequest{
+		Op:        structs.IntentionOpUpdate,
+		Intention: &updated,
+	}))
+
+	require.NoError(s.IntentionApply(3, &structs.IntentionRequest{
+		Op:        structs.IntentionOpDelete,
+		Intention: &structs.Intention{ID: ixn.ID},
+	}))
+
+	// Deleting a missing intention records nothing.
+	require.NoError(s.IntentionApply(4, &structs.IntentionRequest{
+		Op:        structs.IntentionOpDelete,
+		Intention: &structs.Intention{ID: testUUID()},
+	}))
+
+	idx, changes, err = s.ConnectChanges(nil, structs.ConnectChangeIntention, ixn.ID)
+	require.NoError(err)
+	require.Equal(uint64(3), idx)
+	require.Len(changes, 3)
+
+	del, upd, create := changes[0], changes[1], changes[2]
+	require.Equal(uint64(3), del.Index)
+	require.Equal(structs.ConnectChangeDelete, del.Op)
+	require.Equal("web", del.SourceName)
+	require.Equal("db", del.DestinationName)
+	require.Empty(del.After)
+	require.Equal(upd.After, del.Before)
+
+	require.Equal(structs.ConnectChangeUpdate, upd.Op)
+	require.Equal(now.Add(time.Second), upd.Time)
+	require.Empty(upd.Actor)
+	require.Equal(create.After, upd.Before)
+
+	require.Equal(uint64(1), create.Index)
+	require.Equal(structs.ConnectChangeCreate, create.Op)
+	require.Equal("accessor", create.Actor)
+	require.Equal(now, create.Time)
+	require.Empty(create.Before)
+
+	// The values are encoded without their indexes.
+	var before, after map[string]interface{}
+	require.NoError(json.Unmarshal([]byte(upd.Before), &before))
+	require.NoError(json.Unmarshal([]byte(upd.After), &after))
+	require.Equal(string(structs.IntentionActionAllow), before["Action"])
+	require.Equal(string(structs.IntentionActionDeny), after["Action"])
+	require.NotContains(after, "CreateIndex")
+	require.NotContains(after, "ModifyIndex")
+
+	// Filters on other names return nothing.
+	_, changes, err = s.ConnectChanges(nil, structs.ConnectChangeIntention, testUUID())
+	require.NoError(err)
+	require.Len(changes, 0)
+}
+
+func TestStore_ConnectChanges_ConfigEntry(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	now := time.Now().UTC()
+	require.NoError(s.ConfigEntryApply(1, &structs.ConfigEntryRequest{
+		Op: structs.ConfigEntryUpsert,
+		Entry: &structs.ServiceConfigEntry{
+			Kind:     structs.ServiceDefaults,
+			Name:     "web",
+			Protocol: "http",
+		},
+		Actor:     "accessor",
+		Timestamp: now,
+	}))
+	require.NoError(s.ConfigEntryApply(2, &structs.ConfigEntryRequest{
+		Op: structs.ConfigEntryUpsert,
+		Entry: &structs.ProxyConfigEntry{
+			Kind: structs.ProxyDefaults,
+			Name: structs.ProxyConfigGlobal,
+		},
+	}))
+	require.NoError(s.ConfigEntryApply(3, &structs.ConfigEntryRequest{
+		Op: structs.ConfigEntryDelete,
+		Entry: &structs.ServiceConfigEntry{
+			Kind: structs.ServiceDefaults,
+			Name: "web",
+		},
+	}))
+
+	// Deleting a missing entry records nothing.
+	require.NoError(s.ConfigEntryApply(4, &structs.ConfigEntryRequest{
+		Op: structs.ConfigEntryDelete,
+		Entry: &structs.ServiceConfigEntry{
+			Kind: structs.ServiceDefaults,
+			Name: "missing",
+		},
+	}))
+
+	idx, changes, err := s.ConnectChanges(nil, "", "")
+	require.NoError(err)
+	require.Equal(uint64(3), idx)
+	require.Len(changes, 3)
+
+	_, changes, err = s.ConnectChanges(nil, structs.ServiceDefaults, "web")
+	require.NoError(err)
+	require.Len(changes, 2)
+	require.Equal(structs.ConnectChangeDelete, changes[0].Op)
+	require.Equal(structs.ConnectChangeCreate, changes[1].Op)
+	require.Equal("accessor", changes[1].Actor)
+	require.Equal(now, changes[1].Time)
+	require.Contains(changes[1].After, `"Protocol":"http"`)
+}
+
+func TestStore_ConnectChanges_Prune(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	entry := &structs.ServiceConfigEntry{
+		Kind: structs.ServiceDefaults,
+		Name: "web",
+	}
+	for i := 1; i <= maxConnectChanges+10; i++ {
+		require.NoError(s.ConfigEntryApply(uint64(i), &structs.ConfigEntryRequest{
+			Op:    structs.ConfigEntryUpsert,
+			Entry: entry,
+		}))
+	}
+
+	_, changes, err := s.ConnectChanges(nil, "", "")
+	require.NoError(err)
+	require.Len(changes, maxConnectChanges)
+	require.Equal(uint64(maxConnectChanges+10), changes[0].Index)
+	require.Equal(uint64(11), changes[len(changes)-1].Index)
+}
+
+func TestStore_ConnectChanges_Snapshot_Restore(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	for i, name := range []string{"web", "db"} {
+		require.NoError(s.ConfigEntryApply(uint64(i+1), &structs.ConfigEntryRequest{
+			Op: structs.ConfigEntryUpsert,
+			Entry: &structs.ServiceConfigEntry{
+				Kind: structs.ServiceDefaults,
+				Name: name,
+			},
+		}))
+	}
+	_, expected, err := s.ConnectChanges(nil, "", "")
+	require.NoError(err)
+
+	snap := s.Snapshot()
+	defer snap.Close()
+	dump, err := snap.ConnectChanges()
+	require.NoError(err)
+	require.Len(dump, 2)
+
+	s2 := testStateStore(t)
+	restore := s2.Restore()
+	for _, change := range dump {
+		require.NoError(restore.ConnectChange(change))
+	}
+	restore.Commit()
+
+	idx, actual, err := s2.ConnectChanges(nil, "", "")
+	require.NoError(err)
+	require.Equal(uint64(2), idx)
+	require.Equal(expected, actual)
+}
@@ -2,6 +2,7 @@ package state
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -9,6 +10,12 @@ import (
 	"github.com/hashicorp/go-memdb"
 )
 
+// kvsLastResetIndexName keeps track of the last raft index at which KV
+// deletions stopped being tracked by tombstones, either because tombstones
+// were reaped or because the whole tree was deleted. Diffs can't be computed
+// from an older index.
+const kvsLastResetIndexName = "kvs_last_reset"
+
 // kvsTableSchema returns a new table schema used for storing key/value data for
 // Consul's kv store.
 func kvsTableSchema() *memdb.TableSchema {
@@ -32,6 +39,15 @@ func kvsTableSchema() *memdb.TableSchema {
 					Field: "Session",
 				},
 			},
+			"ttl": &memdb.IndexSchema{
+				Name:         "ttl",
+				AllowMissing: true,
+				Unique:       false,
+				Indexer: &memdb.StringFieldIndex{
+					Field:     "TTL",
+					Lowercase: false,
+				},
+			},
 		},
 	}
 }
@@ -95,6 +111,16 @@ func (s *Restore) Tombstone(stone *Tombstone) error {
 	return nil
 }
 
+// KVSReset is used when restoring from a snapshot to make the clients
+// tracking the KV store since an older index fetch the whole tree again,
+// since the deletions before the snapshot are gone.
+func (s *Restore) KVSReset(idx uint64) error {
+	if err := indexUpdateMaxTxn(s.tx, idx, kvsLastResetIndexName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
+
 // ReapTombstones is used to delete all the tombstones with an index
 // less than or equal to the given index. This is used to prevent
 // unbounded storage growth of the tombstones.
@@ -105,6 +131,9 @@ func (s *Store) ReapTombstones(index uint64) error {
 	if err := s.kvsGraveyard.ReapTxn(tx, index); err != nil {
 		return fmt.Errorf("failed to reap kvs tombstones: %s", err)
 	}
+	if err := indexUpdateMaxTxn(tx, index, kvsLastResetIndexName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
 
 	tx.Commit()
 	return nil
@@ -248,6 +277,100 @@ func (s *Store) kvsListTxn(tx *memdb.Txn, ws memdb.WatchSet, prefix string) (uin
 	return idx, ents, nil
 }
 
+// KVSListDiff returns the changes to the keys under the given prefix since
+// the given index. See structs.DirEntriesDiff for how the changes are
+// applied.
+func (s *Store) KVSListDiff(ws memdb.WatchSet, prefix string, since uint64) (uint64, *structs.DirEntriesDiff, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	idx, ents, err := s.kvsListTxn(tx, ws, prefix)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// The index is ahead of the store when an older snapshot was restored
+	// since it was returned, the deletions since then aren't known either.
+	resetIdx := maxIndexTxn(tx, kvsLastResetIndexName)
+	if since == 0 || since < resetIdx || since > maxIndexTxn(tx, "kvs", "tombstones") {
+		return idx, &structs.DirEntriesDiff{Reset: true, Entries: ents}, nil
+	}
+
+	// Gather the deletions under the prefix.
+	deleted := make(map[string]struct{})
+	stones, err := tx.Get("tombstones", "id_prefix", prefix)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed querying tombstones: %s", err)
+	}
+	ws.Add(stones.WatchCh())
+	for stone := stones.Next(); stone != nil; stone = stones.Next() {
+		if t := stone.(*Tombstone); t.Index > since {
+			deleted[t.Key] = struct{}{}
+		}
+	}
+
+	// A deletion of a parent of the prefix deletes the whole prefix. Its
+	// index must be taken into account too, the tombstones under the prefix
+	// may be older.
+	for i := 1; i < len(prefix); i++ {
+		stone, err := tx.First("tombstones", "id", prefix[:i])
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed querying tombstones: %s", err)
+		}
+		if stone == nil {
+			continue
+		}
+		if t := stone.(*Tombstone); t.Index > since {
+			deleted[prefix] = struct{}{}
+			if t.Index > idx {
+				idx = t.Index
+			}
+		}
+	}
+
+	diff := &structs.DirEntriesDiff{}
+	for p := range deleted {
+		diff.DeletedPrefixes = append(diff.DeletedPrefixes, p)
+	}
+	sort.Strings(diff.DeletedPrefixes)
+
+	for _, e := range ents {
+		if e.ModifyIndex > since {
+			diff.Entries = append(diff.Entries, e)
+			continue
+		}
+		for _, p := range diff.DeletedPrefixes {
+			if strings.HasPrefix(e.Key, p) {
+				diff.Entries = append(diff.Entries, e)
+				break
+			}
+		}
+	}
+	return idx, diff, nil
+}
+
+// KVSListTTL returns the key/value pairs having a TTL, which are tracked by
+// the leader to expire them.
+func (s *Store) KVSListTTL(ws memdb.WatchSet) (uint64, structs.DirEntries, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	// Get the table index.
+	idx := maxIndexTxn(tx, "kvs")
+
+	entries, err := tx.Get("kvs", "ttl_prefix", "")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed kvs lookup: %s", err)
+	}
+	ws.Add(entries.WatchCh())
+
+	var results structs.DirEntries
+	for entry := entries.Next(); entry != nil; entry = entries.Next() {
+		results = append(results, entry.(*structs.DirEntry))
+	}
+	return idx, results, nil
+}
+
 // KVSListKeys is used to query the KV store for keys matching the given prefix.
 // An optional separator may be specified, which can be used to slice off a part
 // of the response so that only a subset of the prefix is returned. In this
@@ -485,6 +608,8 @@ func (s *Store) kvsDeleteTreeTxn(tx *memdb.Txn, idx uint64, prefix string) error
 			if err := s.kvsGraveyard.InsertTxn(tx, prefix, idx); err != nil {
 				return fmt.Errorf("failed adding to graveyard: %s", err)
 			}
+		} else if err := indexUpdateMaxTxn(tx, idx, kvsLastResetIndexName); err != nil {
+			return fmt.Errorf("failed updating index: %s", err)
 		}
 		if err := tx.Insert("index", &IndexEntry{"kvs", idx}); err != nil {
 			return fmt.Errorf("failed updating index: %s", err)
@@ -493,6 +618,24 @@ func (s *Store) kvsDeleteTreeTxn(tx *memdb.Txn, idx uint64, prefix string) error
 	return nil
 }
 
+// kvsDeleteTreeCASTxn is used to do a recursive delete with check-and-set
+// semantics inside an existing transaction. The index of the tree, as
+// returned by a list of the prefix, must match the given cidx.
+func (s *Store) kvsDeleteTreeCASTxn(tx *memdb.Txn, idx, cidx uint64, prefix string) (bool, error) {
+	tidx, _, err := s.kvsListTxn(tx, nil, prefix)
+	if err != nil {
+		return false, err
+	}
+	if tidx != cidx {
+		return false, nil
+	}
+
+	if err := s.kvsDeleteTreeTxn(tx, idx, prefix); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // KVSLockDelay returns the expiration time for any lock delay associated with
 // the given key.
 func (s *Store) KVSLockDelay(key string) time.Time {
@@ -470,6 +470,204 @@ func TestStateStore_KVSList(t *testing.T) {
 	}
 }
 
+func TestStateStore_KVSListDiff(t *testing.T) {
+	s := testStateStore(t)
+
+	keys := func(diff *structs.DirEntriesDiff) []string {
+		var out []string
+		for _, e := range diff.Entries {
+			out = append(out, e.Key)
+		}
+		return out
+	}
+
+	testSetKey(t, s, 1, "foo/a", "a")
+	testSetKey(t, s, 2, "foo/ab", "ab")
+	testSetKey(t, s, 3, "foo/b/c", "c")
+	testSetKey(t, s, 4, "bar", "bar")
+
+	// Without an index the whole tree is returned.
+	idx, diff, err := s.KVSListDiff(nil, "foo/", 0)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 3 || !diff.Reset || !reflect.DeepEqual(keys(diff), []string{"foo/a", "foo/ab", "foo/b/c"}) {
+		t.Fatalf("bad: %d %#v", idx, diff)
+	}
+
+	// Only the modified keys are returned.
+	ws := memdb.NewWatchSet()
+	idx, diff, err = s.KVSListDiff(ws, "foo/", 3)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 3 || diff.Reset || len(diff.Entries) != 0 || len(diff.DeletedPrefixes) != 0 {
+		t.Fatalf("bad: %d %#v", idx, diff)
+	}
+	testSetKey(t, s, 5, "foo/b/c", "c2")
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+	idx, diff, err = s.KVSListDiff(nil, "foo/", 3)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 5 || diff.Reset || !reflect.DeepEqual(keys(diff), []string{"foo/b/c"}) {
+		t.Fatalf("bad: %d %#v", idx, diff)
+	}
+
+	// A deleted key is reported as a deleted prefix along with the
+	// remaining keys under it.
+	if err := s.KVSDelete(6, "foo/a"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	idx, diff, err = s.KVSListDiff(nil, "foo/", 5)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 6 || !reflect.DeepEqual(diff.DeletedPrefixes, []string{"foo/a"}) ||
+		!reflect.DeepEqual(keys(diff), []string{"foo/ab"}) {
+		t.Fatalf("bad: %d %#v", idx, diff)
+	}
+
+	// Deleting a parent of the prefix deletes the whole prefix.
+	if err := s.KVSDeleteTree(7, "fo"); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	idx, diff, err = s.KVSListDiff(nil, "foo/", 6)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 7 || !reflect.DeepEqual(diff.DeletedPrefixes, []string{"foo/"}) || len(diff.Entries) != 0 {
+		t.Fatalf("bad: %d %#v", idx, diff)
+	}
+
+	// Reaping the tombstones requires a reset for the older indexes.
+	testSetKey(t, s, 8, "foo/d", "d")
+	if err := s.ReapTombstones(7); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	idx, diff, err = s.KVSListDiff(nil, "foo/", 6)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 8 || !diff.Reset || !reflect.DeepEqual(keys(diff), []string{"foo/d"}) {
+		t.Fatalf("bad: %d %#v", idx, diff)
+	}
+	idx, diff, err = s.KVSListDiff(nil, "foo/", 7)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 8 || diff.Reset || !reflect.DeepEqual(keys(diff), []string{"foo/d"}) {
+		t.Fatalf("bad: %d %#v", idx, diff)
+	}
+
+	// So does deleting the whole tree, which leaves no tombstone.
+	if err := s.KVSDeleteTree(9, ""); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	_, diff, err = s.KVSListDiff(nil, "foo/", 8)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !diff.Reset || len(diff.Entries) != 0 {
+		t.Fatalf("bad: %#v", diff)
+	}
+}
+
+func TestStateStore_KVSListDiff_Restore(t *testing.T) {
+	s := testStateStore(t)
+
+	// Restore a snapshot taken at index 5 with a single key.
+	restore := s.Restore()
+	if err := restore.KVS(&structs.DirEntry{
+		Key:       "foo/a",
+		Value:     []byte("a"),
+		RaftIndex: structs.RaftIndex{CreateIndex: 3, ModifyIndex: 3},
+	}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := restore.KVSReset(5); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	restore.Commit()
+
+	// The clients tracking an index before the snapshot can't know which
+	// keys were deleted in between.
+	idx, diff, err := s.KVSListDiff(nil, "foo/", 4)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 3 || !diff.Reset || len(diff.Entries) != 1 {
+		t.Fatalf("bad: %d %#v", idx, diff)
+	}
+
+	// Neither can the ones tracking an index ahead of the snapshot.
+	_, diff, err = s.KVSListDiff(nil, "foo/", 10)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !diff.Reset || len(diff.Entries) != 1 {
+		t.Fatalf("bad: %#v", diff)
+	}
+
+	// The changes after the snapshot are diffed.
+	testSetKey(t, s, 6, "foo/b", "b")
+	idx, diff, err = s.KVSListDiff(nil, "foo/", 5)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 6 || diff.Reset || len(diff.Entries) != 1 || diff.Entries[0].Key != "foo/b" {
+		t.Fatalf("bad: %d %#v", idx, diff)
+	}
+}
+
+func TestStateStore_KVSListTTL(t *testing.T) {
+	s := testStateStore(t)
+
+	// Listing an empty KVS returns nothing
+	ws := memdb.NewWatchSet()
+	idx, entries, err := s.KVSListTTL(ws)
+	if idx != 0 || entries != nil || err != nil {
+		t.Fatalf("expected (0, nil, nil), got: (%d, %#v, %#v)", idx, entries, err)
+	}
+
+	// Create some KVS entries, only some of them with a TTL
+	testSetKey(t, s, 1, "foo", "foo")
+	if err := s.KVSSet(2, &structs.DirEntry{Key: "bar", TTL: "10s"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := s.KVSSet(3, &structs.DirEntry{Key: "baz", TTL: "1m0s"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !watchFired(ws) {
+		t.Fatalf("bad")
+	}
+
+	idx, entries, err = s.KVSListTTL(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if idx != 3 {
+		t.Fatalf("bad index: %d", idx)
+	}
+	if len(entries) != 2 || entries[0].Key != "bar" || entries[1].Key != "baz" {
+		t.Fatalf("bad: %#v", entries)
+	}
+
+	// Clearing the TTL of a key removes it
+	if err := s.KVSSet(4, &structs.DirEntry{Key: "bar"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	_, entries, err = s.KVSListTTL(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(entries) != 1 || entries[0].Key != "baz" {
+		t.Fatalf("bad: %#v", entries)
+	}
+}
+
 func TestStateStore_KVSListKeys(t *testing.T) {
 	s := testStateStore(t)
 
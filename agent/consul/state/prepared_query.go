@@ -278,6 +278,11 @@ func (s *Store) preparedQueryDeleteTxn(tx *memdb.Txn, idx uint64, queryID string
 		return fmt.Errorf("failed updating index: %s", err)
 	}
 
+	// Delete the stats of the query.
+	if err := s.preparedQueryStatsDeleteTxn(tx, idx, queryID); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -0,0 +1,134 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+const preparedQueryStatsTableName = "prepared-query-stats"
+
+// preparedQueryStatsTableSchema returns a new table schema used to store the
+// execution stats of the prepared queries.
+func preparedQueryStatsTableSchema() *memdb.TableSchema {
+	return &memdb.TableSchema{
+		Name: preparedQueryStatsTableName,
+		Indexes: map[string]*memdb.IndexSchema{
+			"id": &memdb.IndexSchema{
+				Name:         "id",
+				AllowMissing: false,
+				Unique:       true,
+				Indexer: &memdb.UUIDFieldIndex{
+					Field: "ID",
+				},
+			},
+		},
+	}
+}
+
+func init() {
+	registerSchema(preparedQueryStatsTableSchema)
+}
+
+// PreparedQueryStats is used to pull the prepared query stats for the
+// snapshot.
+func (s *Snapshot) PreparedQueryStats() ([]*structs.PreparedQueryStats, error) {
+	iter, err := s.tx.Get(preparedQueryStatsTableName, "id")
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*structs.PreparedQueryStats
+	for wrapped := iter.Next(); wrapped != nil; wrapped = iter.Next() {
+		ret = append(ret, wrapped.(*structs.PreparedQueryStats))
+	}
+	return ret, nil
+}
+
+// PreparedQueryStats is used when restoring from a snapshot.
+func (s *Restore) PreparedQueryStats(stats *structs.PreparedQueryStats) error {
+	if err := s.tx.Insert(preparedQueryStatsTableName, stats); err != nil {
+		return fmt.Errorf("failed restoring prepared query stats: %s", err)
+	}
+	return nil
+}
+
+// PreparedQueryStatsApply adds the executions recorded by a server to the
+// stored stats of the queries. Executions of queries which no longer exist
+// are dropped.
+func (s *Store) PreparedQueryStatsApply(idx uint64, stats []*structs.PreparedQueryStats) error {
+	tx := s.db.Txn(true)
+	defer tx.Abort()
+
+	for _, delta := range stats {
+		query, err := tx.First("prepared-queries", "id", delta.ID)
+		if err != nil {
+			return fmt.Errorf("failed prepared query lookup: %s", err)
+		}
+		if query == nil {
+			continue
+		}
+
+		existing, err := tx.First(preparedQueryStatsTableName, "id", delta.ID)
+		if err != nil {
+			return fmt.Errorf("failed prepared query stats lookup: %s", err)
+		}
+
+		// Never modify the stored object in place.
+		updated := &structs.PreparedQueryStats{ID: delta.ID}
+		if existing != nil {
+			*updated = *existing.(*structs.PreparedQueryStats)
+		}
+		updated.Name = toPreparedQuery(query).Name
+		updated.Add(delta)
+
+		if err := tx.Insert(preparedQueryStatsTableName, updated); err != nil {
+			return fmt.Errorf("failed inserting prepared query stats: %s", err)
+		}
+	}
+	if err := indexUpdateMaxTxn(tx, idx, preparedQueryStatsTableName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+
+	tx.Commit()
+	return nil
+}
+
+// PreparedQueryStatsList returns the stored stats of the prepared queries.
+func (s *Store) PreparedQueryStatsList(ws memdb.WatchSet) (uint64, []*structs.PreparedQueryStats, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	idx := maxIndexTxn(tx, preparedQueryStatsTableName)
+
+	iter, err := tx.Get(preparedQueryStatsTableName, "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed prepared query stats lookup: %s", err)
+	}
+	ws.Add(iter.WatchCh())
+
+	var result []*structs.PreparedQueryStats
+	for wrapped := iter.Next(); wrapped != nil; wrapped = iter.Next() {
+		result = append(result, wrapped.(*structs.PreparedQueryStats))
+	}
+	return idx, result, nil
+}
+
+// preparedQueryStatsDeleteTxn deletes the stats of a deleted query.
+func (s *Store) preparedQueryStatsDeleteTxn(tx *memdb.Txn, idx uint64, queryID string) error {
+	existing, err := tx.First(preparedQueryStatsTableName, "id", queryID)
+	if err != nil {
+		return fmt.Errorf("failed prepared query stats lookup: %s", err)
+	}
+	if existing == nil {
+		return nil
+	}
+	if err := tx.Delete(preparedQueryStatsTableName, existing); err != nil {
+		return fmt.Errorf("failed deleting prepared query stats: %s", err)
+	}
+	if err := indexUpdateMaxTxn(tx, idx, preparedQueryStatsTableName); err != nil {
+		return fmt.Errorf("failed updating index: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore_PreparedQueryStatsApply(t *testing.T) {
+	require := require.New(t)
+	s := testStateStore(t)
+
+	query := &structs.PreparedQuery{
+		ID:   testUUID(),
+		Name: "redis",
+		Service: structs.ServiceQuery{
+			Service: "redis",
+		},
+	}
+	require.NoError(s.PreparedQuerySet(1, query))
+
+	ws := memdb.NewWatchSet()
+	idx, stats, err := s.PreparedQueryStatsList(ws)
+	require.NoError(err)
+	require.Equal(uint64(0), idx)
+	require.Empty(stats)
+
+	// Executions of unknown queries are dropped.
+	now := time.Now().UTC()
+	require.NoError(s.PreparedQueryStatsApply(2, []*structs.PreparedQueryStats{
+		{ID: query.ID, Executions: 2, LatencyTotal: 3 * time.Millisecond, LatencyMax: 2 * time.Millisecond, LastExecuted: now},
+		{ID: testUUID(), Executions: 5},
+	}))
+	require.True(watchFired(ws))
+
+	// The executions of the servers add up.
+	require.NoError(s.PreparedQueryStatsApply(3, []*structs.PreparedQueryStats{
+		{ID: query.ID, Executions: 1, Failovers: 1, EmptyResults: 1, LatencyTotal: time.Millisecond, LatencyMax: time.Millisecond},
+	}))
+
+	idx, stats, err = s.PreparedQueryStatsList(nil)
+	require.NoError(err)
+	require.Equal(uint64(3), idx)
+	require.Equal([]*structs.PreparedQueryStats{
+		{
+			ID:           query.ID,
+			Name:         "redis",
+			Executions:   3,
+			Failovers:    1,
+			EmptyResults: 1,
+			LatencyTotal: 4 * time.Millisecond,
+			LatencyMax:   2 * time.Millisecond,
+			LastExecuted: now,
+		},
+	}, stats)
+
+	// Deleting the query deletes its stats.
+	ws = memdb.NewWatchSet()
+	_, _, err = s.PreparedQueryStatsList(ws)
+	require.NoError(err)
+	require.NoError(s.PreparedQueryDelete(4, query.ID))
+	require.True(watchFired(ws))
+
+	idx, stats, err = s.PreparedQueryStatsList(nil)
+	require.NoError(err)
+	require.Equal(uint64(4), idx)
+	require.Empty(stats)
+}
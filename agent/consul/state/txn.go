@@ -31,6 +31,13 @@ func (s *Store) txnKVS(tx *memdb.Txn, idx uint64, op *structs.TxnKVOp) (structs.
 	case api.KVDeleteTree:
 		err = s.kvsDeleteTreeTxn(tx, idx, op.DirEnt.Key)
 
+	case api.KVDeleteTreeCAS:
+		var ok bool
+		ok, err = s.kvsDeleteTreeCASTxn(tx, idx, op.DirEnt.ModifyIndex, op.DirEnt.Key)
+		if !ok && err == nil {
+			err = fmt.Errorf("failed to delete tree %q, index is stale", op.DirEnt.Key)
+		}
+
 	case api.KVCAS:
 		var ok bool
 		entry = &op.DirEnt
@@ -113,10 +120,10 @@ func (s *Store) txnKVS(tx *memdb.Txn, idx uint64, op *structs.TxnKVOp) (structs.
 // txnIntention handles all Intention-related operations.
 func (s *Store) txnIntention(tx *memdb.Txn, idx uint64, op *structs.TxnIntentionOp) error {
 	switch op.Op {
-	case structs.IntentionOpCreate, structs.IntentionOpUpdate:
-		return s.intentionSetTxn(tx, idx, op.Intention)
-	case structs.IntentionOpDelete:
-		return s.intentionDeleteTxn(tx, idx, op.Intention.ID)
+	case structs.IntentionOpCreate, structs.IntentionOpUpdate, structs.IntentionOpDelete:
+		// The operations come from the replication of the intentions so
+		// they are recorded in the changelog without actor.
+		return s.intentionApplyTxn(tx, idx, op.Op, op.Intention, "")
 	default:
 		return fmt.Errorf("unknown Intention op %q", op.Op)
 	}
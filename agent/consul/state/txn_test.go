@@ -1023,6 +1023,57 @@ func TestStateStore_Txn_KVS_Rollback(t *testing.T) {
 	}
 }
 
+func TestStateStore_Txn_KVS_DeleteTreeCAS(t *testing.T) {
+	s := testStateStore(t)
+
+	// Create KV entries in the state store.
+	testSetKey(t, s, 1, "foo/bar", "bar")
+	testSetKey(t, s, 2, "foo/baz", "baz")
+	testSetKey(t, s, 3, "zip", "zap")
+
+	deleteTree := func(cidx uint64) structs.TxnOps {
+		return structs.TxnOps{
+			&structs.TxnOp{
+				KV: &structs.TxnKVOp{
+					Verb: api.KVDeleteTreeCAS,
+					DirEnt: structs.DirEntry{
+						Key: "foo/",
+						RaftIndex: structs.RaftIndex{
+							ModifyIndex: cidx,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	// A stale index should fail and leave the tree alone.
+	results, errors := s.TxnRW(4, deleteTree(1))
+	require.Len(t, results, 0)
+	require.Len(t, errors, 1)
+	require.Contains(t, errors[0].Error(), `failed to delete tree "foo/", index is stale`)
+
+	idx, entries, err := s.KVSList(nil, "foo/")
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), idx)
+	require.Len(t, entries, 2)
+
+	// The index of the tree should delete it.
+	results, errors = s.TxnRW(5, deleteTree(idx))
+	require.Len(t, errors, 0)
+	require.Len(t, results, 0)
+
+	idx, entries, err = s.KVSList(nil, "foo/")
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), idx)
+	require.Len(t, entries, 0)
+
+	// Keys outside of the prefix are untouched.
+	_, entry, err := s.KVSGet(nil, "zip")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+}
+
 func TestStateStore_Txn_KVS_RO(t *testing.T) {
 	s := testStateStore(t)
 
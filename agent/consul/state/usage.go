@@ -0,0 +1,55 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+// Usage returns the number of entries of each kind in the state store. It
+// scans the tables, so it's meant for occasional queries such as capacity
+// planning rather than for watching.
+func (s *Store) Usage(ws memdb.WatchSet) (uint64, *structs.Usage, error) {
+	tx := s.db.Txn(false)
+	defer tx.Abort()
+
+	idx := maxIndexTxn(tx, "nodes", "services", "kvs", "acl-tokens", intentionsTableName, configTableName)
+
+	var usage structs.Usage
+	counts := []struct {
+		table string
+		count *int
+	}{
+		{"nodes", &usage.Nodes},
+		{"kvs", &usage.KVEntries},
+		{"acl-tokens", &usage.ACLTokens},
+		{intentionsTableName, &usage.Intentions},
+		{configTableName, &usage.ConfigEntries},
+	}
+	for _, c := range counts {
+		iter, err := tx.Get(c.table, "id")
+		if err != nil {
+			return 0, nil, fmt.Errorf("failed %s lookup: %s", c.table, err)
+		}
+		ws.Add(iter.WatchCh())
+		for raw := iter.Next(); raw != nil; raw = iter.Next() {
+			*c.count++
+		}
+	}
+
+	// The services are counted by name as well as by instance.
+	iter, err := tx.Get("services", "id")
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed services lookup: %s", err)
+	}
+	ws.Add(iter.WatchCh())
+	names := make(map[string]struct{})
+	for raw := iter.Next(); raw != nil; raw = iter.Next() {
+		names[raw.(*structs.ServiceNode).ServiceName] = struct{}{}
+		usage.ServiceInstances++
+	}
+	usage.Services = len(names)
+
+	return idx, &usage, nil
+}
@@ -0,0 +1,60 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateStore_Usage(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	s := testStateStore(t)
+
+	// An empty state store has no usage.
+	idx, usage, err := s.Usage(nil)
+	require.NoError(err)
+	require.Equal(uint64(0), idx)
+	require.Equal(&structs.Usage{}, usage)
+
+	ws := memdb.NewWatchSet()
+	_, _, err = s.Usage(ws)
+	require.NoError(err)
+
+	testRegisterNode(t, s, 1, "node1")
+	testRegisterNode(t, s, 2, "node2")
+	testRegisterService(t, s, 3, "node1", "web")
+	testRegisterService(t, s, 4, "node2", "web")
+	testRegisterService(t, s, 5, "node2", "db")
+	testSetKey(t, s, 6, "foo", "bar")
+	require.NoError(s.IntentionSet(7, &structs.Intention{
+		ID:              testUUID(),
+		SourceName:      "web",
+		DestinationName: "db",
+		Action:          structs.IntentionActionAllow,
+	}))
+	require.NoError(s.EnsureConfigEntry(8, &structs.ProxyConfigEntry{
+		Kind: structs.ProxyDefaults,
+		Name: structs.ProxyConfigGlobal,
+	}))
+	require.NoError(s.ACLTokenSet(9, &structs.ACLToken{
+		AccessorID: "c8d0378c-566a-4535-8fc9-c883a8cc9849",
+		SecretID:   "6d48ce91-2558-4098-bdab-8737e4e57d5f",
+	}, false))
+	require.True(watchFired(ws))
+
+	idx, usage, err = s.Usage(nil)
+	require.NoError(err)
+	require.Equal(uint64(9), idx)
+	require.Equal(&structs.Usage{
+		Nodes:            2,
+		Services:         2,
+		ServiceInstances: 3,
+		KVEntries:        1,
+		ACLTokens:        1,
+		Intentions:       1,
+		ConfigEntries:    1,
+	}, usage)
+}
@@ -0,0 +1,269 @@
+// Package stream implements the server side event publisher which streams
+// the changes of the health of the services to subscribers, so that agents
+// can maintain materialized views of the catalog instead of running their
+// own blocking queries against the servers.
+//
+// A single goroutine watches the state store for all the subscribed
+// services and computes the events of each change once, no matter how many
+// subscribers there are.
+package stream
+
+import (
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/go-memdb"
+)
+
+const (
+	// OpUpsert and OpDelete are the operations an event can describe.
+	OpUpsert = "upsert"
+	OpDelete = "delete"
+
+	// DefaultSubscriptionBuffer is the number of events which can be
+	// queued for a subscriber before it is considered too slow and its
+	// subscription is reset.
+	DefaultSubscriptionBuffer = 4096
+)
+
+// Event is a change of an instance of the service named by Key.
+type Event struct {
+	Key   string
+	Index uint64
+
+	// EndOfSnapshot is set on the event following the initial set of
+	// upserts that describe the state at the time of the subscription.
+	EndOfSnapshot bool
+
+	// Op is either OpUpsert or OpDelete.
+	Op string
+
+	// ServiceHealth is the instance and its checks. For deletes only the
+	// node name and service ID and name are populated.
+	ServiceHealth *structs.CheckServiceNode
+}
+
+// EventPublisher publishes the events of the health topic of the services
+// which have at least one subscriber.
+type EventPublisher struct {
+	logger *log.Logger
+
+	// state returns the current state store, which changes when a
+	// snapshot is restored.
+	state func() *state.Store
+
+	// bufferSize is the number of events queued for a subscriber before
+	// its subscription is reset.
+	bufferSize int
+
+	// kickCh wakes up the watch loop when a new topic is added.
+	kickCh chan struct{}
+
+	lock   sync.Mutex
+	topics map[string]*topic
+}
+
+// topic is the last known state of a subscribed service.
+type topic struct {
+	index     uint64
+	instances map[string]*structs.CheckServiceNode
+	subs      map[*Subscription]struct{}
+}
+
+// NewEventPublisher returns a publisher reading from the store returned by
+// the given function. A bufferSize of 0 uses DefaultSubscriptionBuffer.
+func NewEventPublisher(logger *log.Logger, state func() *state.Store, bufferSize int) *EventPublisher {
+	if bufferSize <= 0 {
+		bufferSize = DefaultSubscriptionBuffer
+	}
+	return &EventPublisher{
+		logger:     logger,
+		state:      state,
+		bufferSize: bufferSize,
+		kickCh:     make(chan struct{}, 1),
+		topics:     make(map[string]*topic),
+	}
+}
+
+// Run watches the state store and publishes the events of the subscribed
+// services until stopCh is closed.
+func (p *EventPublisher) Run(stopCh <-chan struct{}) {
+	for {
+		store := p.state()
+		ws := memdb.NewWatchSet()
+		ws.Add(store.AbandonCh())
+		ws.Add(p.kickCh)
+		ws.Add(stopCh)
+
+		p.lock.Lock()
+		for key, t := range p.topics {
+			idx, nodes, err := store.CheckServiceNodes(ws, key)
+			if err != nil {
+				p.logger.Printf("[ERR] consul.stream: failed to read the health of service %q: %v", key, err)
+				continue
+			}
+			if idx == t.index {
+				continue
+			}
+			events := t.update(key, idx, nodes)
+			for sub := range t.subs {
+				if !sub.push(events...) {
+					p.logger.Printf("[WARN] consul.stream: resetting slow subscription to service %q", key)
+					delete(t.subs, sub)
+				}
+			}
+			p.gc(key, t)
+		}
+		p.lock.Unlock()
+
+		ws.Watch(nil)
+
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+	}
+}
+
+// Subscribe returns a subscription to the health of the given service. The
+// subscription starts with the current instances of the service followed by
+// an event with EndOfSnapshot set.
+func (p *EventPublisher) Subscribe(key string) (*Subscription, error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	t, ok := p.topics[key]
+	if !ok {
+		idx, nodes, err := p.state().CheckServiceNodes(nil, key)
+		if err != nil {
+			return nil, err
+		}
+		t = &topic{subs: make(map[*Subscription]struct{})}
+		t.update(key, idx, nodes)
+		p.topics[key] = t
+
+		// Have the watch loop pick up the new topic.
+		select {
+		case p.kickCh <- struct{}{}:
+		default:
+		}
+	}
+
+	sub := newSubscription(p, key)
+	events := make([]*Event, 0, len(t.instances)+1)
+	for _, id := range t.sortedIDs() {
+		events = append(events, &Event{
+			Key:           key,
+			Index:         t.index,
+			Op:            OpUpsert,
+			ServiceHealth: t.instances[id],
+		})
+	}
+	events = append(events, &Event{Key: key, Index: t.index, EndOfSnapshot: true})
+
+	// The snapshot isn't subject to the buffer limit.
+	sub.queue = events
+	t.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// unsubscribe removes the given subscription.
+func (p *EventPublisher) unsubscribe(sub *Subscription) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	t, ok := p.topics[sub.key]
+	if !ok {
+		return
+	}
+	delete(t.subs, sub)
+	p.gc(sub.key, t)
+}
+
+// gc drops the state of a topic once it has no subscribers left. The lock
+// must be held.
+func (p *EventPublisher) gc(key string, t *topic) {
+	if len(t.subs) == 0 {
+		delete(p.topics, key)
+	}
+}
+
+// update replaces the state of the topic and returns the events describing
+// the changes, in a stable order.
+func (t *topic) update(key string, idx uint64, nodes structs.CheckServiceNodes) []*Event {
+	cur := make(map[string]*structs.CheckServiceNode, len(nodes))
+	for i := range nodes {
+		n := nodes[i]
+		cur[instanceID(&n)] = &n
+	}
+
+	prev := t.instances
+	t.index, t.instances = idx, cur
+
+	var events []*Event
+	for _, id := range t.sortedIDs() {
+		old, ok := prev[id]
+		if ok && old.Node.ModifyIndex == cur[id].Node.ModifyIndex &&
+			old.Service.ModifyIndex == cur[id].Service.ModifyIndex &&
+			checksModifyIndex(old.Checks) == checksModifyIndex(cur[id].Checks) &&
+			len(old.Checks) == len(cur[id].Checks) {
+			continue
+		}
+		events = append(events, &Event{
+			Key:           key,
+			Index:         idx,
+			Op:            OpUpsert,
+			ServiceHealth: cur[id],
+		})
+	}
+
+	var deleted []string
+	for id := range prev {
+		if _, ok := cur[id]; !ok {
+			deleted = append(deleted, id)
+		}
+	}
+	sort.Strings(deleted)
+	for _, id := range deleted {
+		n := prev[id]
+		events = append(events, &Event{
+			Key:   key,
+			Index: idx,
+			Op:    OpDelete,
+			ServiceHealth: &structs.CheckServiceNode{
+				Node:    &structs.Node{Node: n.Node.Node},
+				Service: &structs.NodeService{ID: n.Service.ID, Service: n.Service.Service},
+			},
+		})
+	}
+	return events
+}
+
+func (t *topic) sortedIDs() []string {
+	ids := make([]string, 0, len(t.instances))
+	for id := range t.instances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// instanceID returns the key of an instance, unique within a service.
+func instanceID(n *structs.CheckServiceNode) string {
+	return n.Node.Node + "/" + n.Service.ID
+}
+
+// checksModifyIndex returns the highest modify index of the given checks.
+func checksModifyIndex(checks structs.HealthChecks) uint64 {
+	var idx uint64
+	for _, c := range checks {
+		if c.ModifyIndex > idx {
+			idx = c.ModifyIndex
+		}
+	}
+	return idx
+}
@@ -0,0 +1,146 @@
+package stream
+
+import (
+	"context"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func testRegister(t *testing.T, s *state.Store, idx uint64, node, status string) {
+	t.Helper()
+	require.NoError(t, s.EnsureRegistration(idx, &structs.RegisterRequest{
+		Node:    node,
+		Address: "127.0.0.1",
+		Service: &structs.NodeService{ID: "web", Service: "web", Port: 8080},
+		Check: &structs.HealthCheck{
+			Node:      node,
+			CheckID:   "web-check",
+			Name:      "web",
+			Status:    status,
+			ServiceID: "web",
+		},
+	}))
+}
+
+func testNext(t *testing.T, sub *Subscription) []*Event {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events, err := sub.Next(ctx)
+	require.NoError(t, err)
+	return events
+}
+
+func TestEventPublisher_Subscribe(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	s, err := state.NewStateStore(nil)
+	require.NoError(err)
+	testRegister(t, s, 1, "foo", api.HealthPassing)
+
+	p := NewEventPublisher(log.New(os.Stderr, "", log.LstdFlags),
+		func() *state.Store { return s }, 0)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go p.Run(stopCh)
+
+	sub, err := p.Subscribe("web")
+	require.NoError(err)
+	defer sub.Unsubscribe()
+
+	// The snapshot.
+	events := testNext(t, sub)
+	require.Len(events, 2)
+	require.Equal(OpUpsert, events[0].Op)
+	require.Equal("foo", events[0].ServiceHealth.Node.Node)
+	require.Equal(uint64(1), events[0].Index)
+	require.True(events[1].EndOfSnapshot)
+
+	// A new instance.
+	testRegister(t, s, 2, "bar", api.HealthPassing)
+	events = testNext(t, sub)
+	require.Len(events, 1)
+	require.Equal(OpUpsert, events[0].Op)
+	require.Equal("bar", events[0].ServiceHealth.Node.Node)
+	require.Equal(uint64(2), events[0].Index)
+
+	// A change of the health of an existing instance.
+	testRegister(t, s, 3, "foo", api.HealthCritical)
+	events = testNext(t, sub)
+	require.Len(events, 1)
+	require.Equal("foo", events[0].ServiceHealth.Node.Node)
+	require.Equal(api.HealthCritical, events[0].ServiceHealth.Checks[0].Status)
+
+	// A deleted instance.
+	require.NoError(s.DeleteService(4, "bar", "web"))
+	events = testNext(t, sub)
+	require.Len(events, 1)
+	require.Equal(OpDelete, events[0].Op)
+	require.Equal("bar", events[0].ServiceHealth.Node.Node)
+	require.Equal("web", events[0].ServiceHealth.Service.ID)
+	require.Empty(events[0].ServiceHealth.Checks)
+}
+
+func TestEventPublisher_Unsubscribe(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	s, err := state.NewStateStore(nil)
+	require.NoError(err)
+
+	p := NewEventPublisher(log.New(os.Stderr, "", log.LstdFlags),
+		func() *state.Store { return s }, 0)
+
+	sub1, err := p.Subscribe("web")
+	require.NoError(err)
+	sub2, err := p.Subscribe("web")
+	require.NoError(err)
+	require.Len(p.topics, 1)
+
+	sub1.Unsubscribe()
+	require.Len(p.topics, 1)
+	sub2.Unsubscribe()
+	require.Len(p.topics, 0)
+}
+
+func TestEventPublisher_SlowSubscriber(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	s, err := state.NewStateStore(nil)
+	require.NoError(err)
+
+	p := NewEventPublisher(log.New(os.Stderr, "", log.LstdFlags),
+		func() *state.Store { return s }, 2)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go p.Run(stopCh)
+
+	sub, err := p.Subscribe("web")
+	require.NoError(err)
+	testNext(t, sub)
+
+	// Queue more events than the buffer allows without reading them.
+	for i, node := range []string{"a", "b", "c"} {
+		testRegister(t, s, uint64(i+1), node, api.HealthPassing)
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for {
+		_, err := sub.Next(ctx)
+		if err != nil {
+			require.Equal(ErrSubscriptionReset, err)
+			break
+		}
+	}
+}
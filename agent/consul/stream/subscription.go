@@ -0,0 +1,92 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrSubscriptionReset is returned by Next when the subscriber fell too far
+// behind and must subscribe again, starting from a new snapshot.
+var ErrSubscriptionReset = errors.New("subscription reset because the subscriber is too slow")
+
+// Subscription is a subscription to the health of a service.
+type Subscription struct {
+	publisher *EventPublisher
+	key       string
+
+	// notifyCh is signaled when events are queued or the subscription is
+	// reset.
+	notifyCh chan struct{}
+
+	lock  sync.Mutex
+	queue []*Event
+	reset bool
+}
+
+func newSubscription(p *EventPublisher, key string) *Subscription {
+	return &Subscription{
+		publisher: p,
+		key:       key,
+		notifyCh:  make(chan struct{}, 1),
+	}
+}
+
+// Key returns the name of the subscribed service.
+func (s *Subscription) Key() string {
+	return s.key
+}
+
+// Next blocks until events are available and returns them, in order. It
+// returns ErrSubscriptionReset if the subscriber fell too far behind, and
+// the error of ctx when it's done.
+func (s *Subscription) Next(ctx context.Context) ([]*Event, error) {
+	for {
+		s.lock.Lock()
+		events, reset := s.queue, s.reset
+		s.queue = nil
+		s.lock.Unlock()
+
+		if len(events) > 0 {
+			return events, nil
+		}
+		if reset {
+			return nil, ErrSubscriptionReset
+		}
+
+		select {
+		case <-s.notifyCh:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Unsubscribe ends the subscription.
+func (s *Subscription) Unsubscribe() {
+	s.publisher.unsubscribe(s)
+}
+
+// push queues the given events. It returns false and resets the
+// subscription if this would exceed the buffer of the publisher, in which
+// case the subscription must be removed from the publisher.
+func (s *Subscription) push(events ...*Event) bool {
+	if len(events) == 0 {
+		return true
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.queue)+len(events) > s.publisher.bufferSize {
+		s.queue, s.reset = nil, true
+	} else {
+		s.queue = append(s.queue, events...)
+	}
+
+	select {
+	case s.notifyCh <- struct{}{}:
+	default:
+	}
+	return !s.reset
+}
@@ -0,0 +1,154 @@
+package consul
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/metadata"
+	"github.com/hashicorp/consul/agent/pool"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/subscribe"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// subscribeDialTimeout is the timeout to connect to a server to
+	// subscribe to its event streams.
+	subscribeDialTimeout = 10 * time.Second
+
+	// subscribeKeepaliveTime and subscribeKeepaliveTimeout detect the
+	// servers which stopped responding while a subscription is idle.
+	subscribeKeepaliveTime    = 30 * time.Second
+	subscribeKeepaliveTimeout = 10 * time.Second
+)
+
+// subscribeEndpoint serves the event streams of the server to the agents
+// over gRPC.
+type subscribeEndpoint struct {
+	srv *Server
+}
+
+func (e *subscribeEndpoint) Subscribe(req *subscribe.Request, ss grpc.ServerStream) error {
+	err := e.srv.Subscribe(ss.Context(), req, func(ev *subscribe.Event) error {
+		return ss.SendMsg(ev)
+	})
+	if acl.IsErrPermissionDenied(err) || acl.IsErrNotFound(err) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return err
+}
+
+// Subscribe runs a subscription to the event publisher of the server,
+// passing the events to fn until ctx is done. Only the health topic of the
+// local datacenter is supported. The ACL token is resolved again for each
+// batch of events so that the stream ends when it's revoked.
+func (s *Server) Subscribe(ctx context.Context, req *subscribe.Request, fn func(*subscribe.Event) error) error {
+	if req.Topic != subscribe.TopicHealth {
+		return fmt.Errorf("streaming isn't supported for topic %q", req.Topic)
+	}
+	if req.Key == "" {
+		return fmt.Errorf("a service name must be given as the key for the health topic")
+	}
+	if req.Datacenter != "" && req.Datacenter != s.config.Datacenter {
+		return fmt.Errorf("streaming is only supported in the local datacenter")
+	}
+
+	filter, err := s.subscribeFilter(req)
+	if err != nil {
+		return err
+	}
+
+	sub, err := s.publisher.Subscribe(req.Key)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		events, err := sub.Next(ctx)
+		if err == stream.ErrSubscriptionReset {
+			return err
+		}
+		if err != nil {
+			return nil
+		}
+
+		if filter, err = s.subscribeFilter(req); err != nil {
+			return err
+		}
+		for _, e := range events {
+			if !e.EndOfSnapshot && !filter.allowNode(e.ServiceHealth.Node.Node) {
+				continue
+			}
+			err := fn(&subscribe.Event{
+				Topic:         req.Topic,
+				Key:           req.Key,
+				Index:         e.Index,
+				EndOfSnapshot: e.EndOfSnapshot,
+				Op:            e.Op,
+				ServiceHealth: e.ServiceHealth,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// subscribeFilter resolves the token of the request and returns the filter
+// to apply to its events. It returns a permission denied error if the token
+// can't read the subscribed service.
+func (s *Server) subscribeFilter(req *subscribe.Request) (*aclFilter, error) {
+	authz, err := s.ResolveToken(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	if authz == nil {
+		authz = acl.AllowAll()
+	}
+	if !authz.ServiceRead(req.Key) {
+		return nil, acl.ErrPermissionDenied
+	}
+	return newACLFilter(authz, s.logger, s.config.ACLEnforceVersion8), nil
+}
+
+// Subscribe runs a subscription against the event publisher of a server,
+// passing the events to fn until ctx is done or the stream fails. The
+// subscriptions to a server share a single connection.
+func (c *Client) Subscribe(ctx context.Context, req *subscribe.Request, fn func(*subscribe.Event) error) error {
+	server := c.routers.FindServer()
+	if server == nil {
+		return structs.ErrNoServers
+	}
+
+	conn, err := c.grpcConns.Conn(server)
+	if err != nil {
+		return err
+	}
+	err = subscribe.Consume(ctx, conn, req, fn)
+	if status.Code(err) == codes.Unavailable {
+		c.routers.NotifyFailedServer(server)
+		c.grpcConns.Remove(server, conn)
+	}
+	return err
+}
+
+// dialGRPC opens a connection to the gRPC services of the given server,
+// which are served on its RPC port.
+func (c *Client) dialGRPC(server *metadata.Server) (net.Conn, error) {
+	conn, _, err := c.connPool.DialTimeout(c.config.Datacenter, server.Addr, subscribeDialTimeout, server.UseTLS)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := conn.Write([]byte{byte(pool.RPCGRPC)}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
@@ -0,0 +1,163 @@
+package consul
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/subscribe"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/net-rpc-msgpackrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func testSubscribeNext(t *testing.T, eventCh <-chan *subscribe.Event) *subscribe.Event {
+	t.Helper()
+	select {
+	case e := <-eventCh:
+		return e
+	case <-time.After(10 * time.Second):
+		t.Fatalf("timed out waiting for an event")
+		return nil
+	}
+}
+
+func TestSubscribe_Client(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir1, s1 := testServer(t)
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+	codec := rpcClient(t, s1)
+	defer codec.Close()
+
+	dir2, c1 := testClient(t)
+	defer os.RemoveAll(dir2)
+	defer c1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+	joinLAN(t, c1, s1)
+	testrpc.WaitForTestAgent(t, c1.RPC, "dc1")
+
+	register := func(node, status string) {
+		arg := structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    "127.0.0.1",
+			Service:    &structs.NodeService{ID: "web", Service: "web"},
+			Check: &structs.HealthCheck{
+				CheckID:   "web",
+				Name:      "web",
+				ServiceID: "web",
+				Status:    status,
+			},
+		}
+		var out struct{}
+		require.NoError(msgpackrpc.CallWithCodec(codec, "Catalog.Register", &arg, &out))
+	}
+	register("foo", api.HealthPassing)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	eventCh := make(chan *subscribe.Event, 10)
+	errCh := make(chan error, 1)
+	go func() {
+		req := &subscribe.Request{Topic: subscribe.TopicHealth, Key: "web"}
+		errCh <- c1.Subscribe(ctx, req, func(e *subscribe.Event) error {
+			eventCh <- e
+			return nil
+		})
+	}()
+
+	e := testSubscribeNext(t, eventCh)
+	require.Equal(subscribe.OpUpsert, e.Op)
+	require.Equal("foo", e.ServiceHealth.Node.Node)
+	require.True(testSubscribeNext(t, eventCh).EndOfSnapshot)
+
+	register("bar", api.HealthCritical)
+	e = testSubscribeNext(t, eventCh)
+	require.Equal(subscribe.OpUpsert, e.Op)
+	require.Equal("bar", e.ServiceHealth.Node.Node)
+	require.Equal(api.HealthCritical, e.ServiceHealth.Checks[0].Status)
+
+	// A second subscription reuses the connection to the server.
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	defer cancel2()
+	eventCh2 := make(chan *subscribe.Event, 10)
+	errCh2 := make(chan error, 1)
+	go func() {
+		req := &subscribe.Request{Topic: subscribe.TopicHealth, Key: "web"}
+		errCh2 <- c1.Subscribe(ctx2, req, func(e *subscribe.Event) error {
+			eventCh2 <- e
+			return nil
+		})
+	}()
+	for !testSubscribeNext(t, eventCh2).EndOfSnapshot {
+	}
+	c1.grpcConns.lock.Lock()
+	require.Len(c1.grpcConns.conns, 1)
+	c1.grpcConns.lock.Unlock()
+
+	cancel()
+	select {
+	case err := <-errCh:
+		require.NoError(err)
+	case <-time.After(10 * time.Second):
+		t.Fatalf("subscription didn't end")
+	}
+
+	// The other subscription is still running on the shared connection.
+	register("baz", api.HealthPassing)
+	require.Equal("baz", testSubscribeNext(t, eventCh2).ServiceHealth.Node.Node)
+
+	// Shutting the client down closes the connection and ends it.
+	require.NoError(c1.Shutdown())
+	select {
+	case <-errCh2:
+	case <-time.After(10 * time.Second):
+		t.Fatalf("subscription didn't end")
+	}
+	c1.grpcConns.lock.Lock()
+	require.Empty(c1.grpcConns.conns)
+	c1.grpcConns.lock.Unlock()
+}
+
+func TestSubscribe_FilterACL(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	dir, token, srv, codec := testACLFilterServer(t)
+	defer os.RemoveAll(dir)
+	defer srv.Shutdown()
+	defer codec.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// The token can read the foo service.
+	var events []*subscribe.Event
+	req := &subscribe.Request{Topic: subscribe.TopicHealth, Key: "foo", Token: token}
+	err := srv.Subscribe(ctx, req, func(e *subscribe.Event) error {
+		events = append(events, e)
+		if e.EndOfSnapshot {
+			cancel()
+		}
+		return nil
+	})
+	require.NoError(err)
+	require.Len(events, 2)
+	require.Equal("foo", events[0].ServiceHealth.Service.Service)
+
+	// But not the bar service.
+	req = &subscribe.Request{Topic: subscribe.TopicHealth, Key: "bar", Token: token}
+	err = srv.Subscribe(context.Background(), req, func(e *subscribe.Event) error {
+		t.Fatalf("unexpected event: %#v", e)
+		return nil
+	})
+	require.True(acl.IsErrPermissionDenied(err), "err: %v", err)
+}
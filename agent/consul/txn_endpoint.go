@@ -87,7 +87,13 @@ func (t *Txn) preCheck(authorizer acl.Authorizer, ops structs.TxnOps) structs.Tx
 				break
 			}
 
-			checkPreApply(&op.Check.Check)
+			if err := checkPreApply(&op.Check.Check); err != nil {
+				errors = append(errors, &structs.TxnError{
+					OpIndex: i,
+					What:    err.Error(),
+				})
+				break
+			}
 
 			// Check that the token has permissions for the given operation.
 			if err := vetCheckTxnOp(op.Check, authorizer); err != nil {
@@ -132,6 +138,23 @@ func (t *Txn) Apply(args *structs.TxnRequest, reply *structs.TxnResponse) error
 	// Convert the return type. This should be a cheap copy since we are
 	// just taking the two slices.
 	if txnResp, ok := resp.(structs.TxnResponse); ok {
+		// Restart the expiration of the written keys.
+		if len(txnResp.Errors) == 0 {
+			for _, op := range args.Ops {
+				if op.KV == nil {
+					continue
+				}
+				switch op.KV.Verb {
+				case api.KVGet, api.KVGetTree, api.KVCheckSession, api.KVCheckIndex,
+					api.KVCheckNotExists, api.KVDeleteTree:
+					continue
+				}
+				if err := t.srv.resetKVSTimer(op.KV.DirEnt.Key, nil); err != nil {
+					t.srv.logger.Printf("[ERR] consul.txn: Failed to reset the TTL of %s: %v", op.KV.DirEnt.Key, err)
+				}
+			}
+		}
+
 		if authorizer != nil {
 			txnResp.Results = FilterTxnResults(authorizer, txnResp.Results)
 		}
@@ -154,6 +177,7 @@ func (t *Txn) Read(args *structs.TxnReadRequest, reply *structs.TxnReadResponse)
 
 	// We have to do this ourselves since we are not doing a blocking RPC.
 	t.srv.setQueryMeta(&reply.QueryMeta)
+	reply.ConsistencyLevel = args.QueryOptions.ConsistencyLevel()
 	if args.RequireConsistent {
 		if err := t.srv.consistentRead(); err != nil {
 			return err
@@ -612,6 +612,86 @@ func TestTxn_Apply_ACLDeny(t *testing.T) {
 	verify.Values(t, "", out, expected)
 }
 
+func TestTxn_Apply_ACLDeny_ProxyDestination(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+
+	dir1, s1 := testServerWithConfig(t, func(c *Config) {
+		c.ACLDatacenter = "dc1"
+		c.ACLsEnabled = true
+		c.ACLMasterToken = "root"
+		c.ACLDefaultPolicy = "deny"
+	})
+	defer os.RemoveAll(dir1)
+	defer s1.Shutdown()
+
+	testrpc.WaitForLeader(t, s1.RPC, "dc1")
+
+	node := &structs.Node{
+		ID:      types.NodeID(testNodeID),
+		Node:    "test-node",
+		Address: "127.0.0.1",
+	}
+	require.NoError(s1.fsm.State().EnsureNode(1, node))
+
+	// Create the ACL.
+	var id string
+	{
+		arg := structs.ACLRequest{
+			Datacenter: "dc1",
+			Op:         structs.ACLSet,
+			ACL: structs.ACL{
+				Name:  "User token",
+				Type:  structs.ACLTokenTypeClient,
+				Rules: testTxnRules,
+			},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		require.NoError(s1.RPC("ACL.Apply", &arg, &id))
+	}
+
+	apply := func(destination string) (*structs.TxnResponse, error) {
+		arg := structs.TxnRequest{
+			Datacenter: "dc1",
+			Ops: structs.TxnOps{
+				&structs.TxnOp{
+					Service: &structs.TxnServiceOp{
+						Verb: api.ServiceSet,
+						Node: "test-node",
+						Service: structs.NodeService{
+							Kind:    structs.ServiceKindConnectProxy,
+							ID:      "test-svc",
+							Service: "test-svc",
+							Port:    20000,
+							Proxy: structs.ConnectProxyConfig{
+								DestinationServiceName: destination,
+							},
+						},
+					},
+				},
+			},
+			WriteRequest: structs.WriteRequest{Token: id},
+		}
+		var out structs.TxnResponse
+		err := s1.RPC("Txn.Apply", &arg, &out)
+		return &out, err
+	}
+
+	// The token can write the proxy but not its destination.
+	out, err := apply("db")
+	require.NoError(err)
+	require.Len(out.Errors, 1)
+	require.Equal(acl.ErrPermissionDenied.Error(), out.Errors[0].What)
+	require.Empty(out.Results)
+
+	// The token can write both.
+	out, err = apply("test-svc")
+	require.NoError(err)
+	require.Empty(out.Errors)
+	require.Len(out.Results, 1)
+}
+
 func TestTxn_Apply_LockDelay(t *testing.T) {
 	t.Parallel()
 	dir1, s1 := testServer(t)
@@ -802,7 +882,11 @@ func TestTxn_Read(t *testing.T) {
 			},
 		},
 		QueryMeta: structs.QueryMeta{
-			KnownLeader: true,
+			KnownLeader:      true,
+			ConsistencyLevel: "leader",
+			ServedBy:         s1.config.NodeName,
+			ServedByID:       s1.config.NodeID,
+			ServedByRole:     structs.QueryServedByLeader,
 		},
 	}
 	verify.Values(t, "", out, expected)
@@ -936,7 +1020,11 @@ func TestTxn_Read_ACLDeny(t *testing.T) {
 	// Verify the transaction's return value.
 	expected := structs.TxnReadResponse{
 		QueryMeta: structs.QueryMeta{
-			KnownLeader: true,
+			KnownLeader:      true,
+			ConsistencyLevel: "leader",
+			ServedBy:         s1.config.NodeName,
+			ServedByID:       s1.config.NodeID,
+			ServedByRole:     structs.QueryServedByLeader,
 		},
 	}
 	for i, op := range arg.Ops {
@@ -0,0 +1,62 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// discoveryChainReadRequest is the optional body of the POST requests, to
+// compile the chain with overrides.
+type discoveryChainReadRequest struct {
+	OverrideProtocol       string
+	OverrideConnectTimeout time.Duration
+}
+
+// discoveryChainReadResponse is the response of the discovery chain
+// endpoint.
+type discoveryChainReadResponse struct {
+	Chain *structs.CompiledDiscoveryChain
+}
+
+// GET /v1/discovery-chain/:service
+// POST /v1/discovery-chain/:service
+func (s *HTTPServer) DiscoveryChainRead(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.DiscoveryChainRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	args.Name = strings.TrimPrefix(req.URL.Path, "/v1/discovery-chain/")
+	if args.Name == "" {
+		return nil, BadRequestError{Reason: "Missing chain name"}
+	}
+
+	args.EvaluateInDatacenter = req.URL.Query().Get("compile-dc")
+
+	if req.Method == "POST" {
+		var overrides discoveryChainReadRequest
+		// An empty body means no overrides
+		if err := decodeBody(req, &overrides, nil); err != nil && err != io.EOF {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Request decoding failed: %v", err)}
+		}
+		if overrides.OverrideConnectTimeout < 0 {
+			return nil, BadRequestError{Reason: "OverrideConnectTimeout must not be negative"}
+		}
+
+		args.OverrideProtocol = overrides.OverrideProtocol
+		args.OverrideConnectTimeout = overrides.OverrideConnectTimeout
+	}
+
+	var out structs.DiscoveryChainResponse
+	defer setMeta(resp, &out.QueryMeta)
+	if err := s.agent.RPC("DiscoveryChain.Get", &args, &out); err != nil {
+		return nil, err
+	}
+
+	return discoveryChainReadResponse{Chain: out.Chain}, nil
+}
@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiscoveryChainRead(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Write the service defaults
+	body := bytes.NewBufferString(`
+	{
+		"Kind": "service-defaults",
+		"Name": "web",
+		"Protocol": "http"
+	}`)
+	req, _ := http.NewRequest("PUT", "/v1/config", body)
+	_, err := a.srv.ConfigApply(httptest.NewRecorder(), req)
+	require.NoError(err)
+
+	req, _ = http.NewRequest("GET", "/v1/discovery-chain/web", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.DiscoveryChainRead(resp, req)
+	require.NoError(err)
+	require.NotEmpty(resp.Header().Get("X-Consul-Index"))
+
+	chain := obj.(discoveryChainReadResponse).Chain
+	require.Equal("web", chain.ServiceName)
+	require.Equal("dc1", chain.Datacenter)
+	require.Equal("http", chain.Protocol)
+	require.Empty(chain.CustomizationHash)
+
+	// Compile with overrides for another datacenter
+	body = bytes.NewBufferString(`
+	{
+		"OverrideProtocol": "grpc",
+		"OverrideConnectTimeout": "7s"
+	}`)
+	req, _ = http.NewRequest("POST", "/v1/discovery-chain/web?compile-dc=dc2", body)
+	obj, err = a.srv.DiscoveryChainRead(httptest.NewRecorder(), req)
+	require.NoError(err)
+
+	chain = obj.(discoveryChainReadResponse).Chain
+	require.Equal("dc2", chain.Datacenter)
+	require.Equal("grpc", chain.Protocol)
+	require.NotEmpty(chain.CustomizationHash)
+	require.Equal(7*time.Second, chain.Nodes[chain.StartNode].Resolver.ConnectTimeout)
+
+	// An empty body means no overrides
+	req, _ = http.NewRequest("POST", "/v1/discovery-chain/web", nil)
+	obj, err = a.srv.DiscoveryChainRead(httptest.NewRecorder(), req)
+	require.NoError(err)
+	require.Empty(obj.(discoveryChainReadResponse).Chain.CustomizationHash)
+
+	// The service name is required
+	req, _ = http.NewRequest("GET", "/v1/discovery-chain/", nil)
+	_, err = a.srv.DiscoveryChainRead(httptest.NewRecorder(), req)
+	require.Error(err)
+	require.IsType(BadRequestError{}, err)
+}
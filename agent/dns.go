@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"sort"
 	"strings"
 	"sync/atomic"
 	"time"
@@ -75,7 +76,7 @@ type DNSServer struct {
 	agent     *Agent
 	config    *dnsConfig
 	domain    string
-	recursors []string
+	recursors *dnsRecursors
 	logger    *log.Logger
 	// Those are handling prefix lookups
 	ttlRadix  *radix.Tree
@@ -88,13 +89,13 @@ type DNSServer struct {
 }
 
 func NewDNSServer(a *Agent) (*DNSServer, error) {
-	var recursors []string
-	for _, r := range a.config.DNSRecursors {
-		ra, err := recursorAddr(r)
+	// The recursors and their health are shared by the DNS servers.
+	if a.dnsRecursors == nil {
+		recursors, err := newDNSRecursors(a.config.DNSRecursors)
 		if err != nil {
-			return nil, fmt.Errorf("Invalid recursor address: %v", err)
+			return nil, err
 		}
-		recursors = append(recursors, ra)
+		a.dnsRecursors = recursors
 	}
 
 	// Make sure domain is FQDN, make it case insensitive for ServeMux
@@ -106,7 +107,7 @@ func NewDNSServer(a *Agent) (*DNSServer, error) {
 		config:    dnscfg,
 		domain:    domain,
 		logger:    a.logger,
-		recursors: recursors,
+		recursors: a.dnsRecursors,
 		ttlRadix:  radix.New(),
 		ttlStrict: make(map[string]time.Duration),
 	}
@@ -174,9 +175,8 @@ func (d *DNSServer) ListenAndServe(network, addr string, notif func()) error {
 	mux := dns.NewServeMux()
 	mux.HandleFunc("arpa.", d.handlePtr)
 	mux.HandleFunc(d.domain, d.handleQuery)
-	if len(d.recursors) > 0 {
-		mux.HandleFunc(".", d.handleRecurse)
-	}
+	// The recursors can be added on reload so the handler is always set.
+	mux.HandleFunc(".", d.handleRecurse)
 
 	d.Server = &dns.Server{
 		Addr:              addr,
@@ -263,7 +263,7 @@ func (d *DNSServer) handlePtr(resp dns.ResponseWriter, req *dns.Msg) {
 	m.SetReply(req)
 	m.Compress = !d.disableCompression.Load().(bool)
 	m.Authoritative = true
-	m.RecursionAvailable = (len(d.recursors) > 0)
+	m.RecursionAvailable = (d.recursors.len() > 0)
 
 	// Only add the SOA if requested
 	if req.Question[0].Qtype == dns.TypeSOA {
@@ -365,7 +365,7 @@ func (d *DNSServer) handleQuery(resp dns.ResponseWriter, req *dns.Msg) {
 	m.SetReply(req)
 	m.Compress = !d.disableCompression.Load().(bool)
 	m.Authoritative = true
-	m.RecursionAvailable = (len(d.recursors) > 0)
+	m.RecursionAvailable = (d.recursors.len() > 0)
 
 	ecsGlobal := true
 
@@ -554,6 +554,18 @@ PARSE:
 		// name.connect.consul
 		d.serviceLookup(network, datacenter, labels[n-2], "", true, req, resp, maxRecursionLevel)
 
+	case "upstream":
+		if n != 2 {
+			goto INVALID
+		}
+
+		// name.upstream.consul matches the upstreams in any datacenter
+		upstreamDC := ""
+		if dcParsed {
+			upstreamDC = datacenter
+		}
+		d.upstreamLookup(upstreamDC, labels[n-2], req, resp)
+
 	case "node":
 		if n == 1 {
 			goto INVALID
@@ -643,6 +655,134 @@ INVALID:
 	return
 }
 
+// upstreamLookup is used to handle an upstream query. It answers with the
+// local listeners of the sidecar proxies registered with this agent for the
+// given destination, so applications can reach the upstreams of the mesh
+// through DNS. An empty datacenter matches the upstreams in any datacenter.
+func (d *DNSServer) upstreamLookup(datacenter, name string, req, resp *dns.Msg) {
+	qType := req.Question[0].Qtype
+	if qType != dns.TypeANY && qType != dns.TypeA && qType != dns.TypeAAAA && qType != dns.TypeSRV {
+		return
+	}
+
+	// Upstreams are only listed if the destination is visible to the
+	// token, like the services looked up through the catalog.
+	rule, err := d.agent.resolveToken(d.agent.tokens.UserToken())
+	if err != nil {
+		d.logger.Printf("[ERR] dns: failed to resolve token: %v", err)
+		resp.SetRcode(req, dns.RcodeServerFailure)
+		return
+	}
+	if rule != nil && !rule.ServiceRead(name) {
+		d.addSOA(resp)
+		resp.SetRcode(req, dns.RcodeNameError)
+		return
+	}
+
+	listeners := d.upstreamListeners(datacenter, name)
+	if len(listeners) == 0 {
+		d.addSOA(resp)
+		resp.SetRcode(req, dns.RcodeNameError)
+		return
+	}
+
+	// The listeners are local to the agent and change with the proxy
+	// registrations so they aren't cached.
+	qName := req.Question[0].Name
+	for _, l := range listeners {
+		ip := net.ParseIP(l.addr)
+		if ip == nil {
+			continue
+		}
+
+		var record dns.RR
+		var target string
+		if ip4 := ip.To4(); ip4 != nil {
+			record = &dns.A{
+				Hdr: dns.RR_Header{Rrtype: dns.TypeA, Class: dns.ClassINET},
+				A:   ip4,
+			}
+			target = fmt.Sprintf("%s.addr.%s", hex.EncodeToString(ip4), d.domain)
+		} else {
+			record = &dns.AAAA{
+				Hdr:  dns.RR_Header{Rrtype: dns.TypeAAAA, Class: dns.ClassINET},
+				AAAA: ip,
+			}
+			target = fmt.Sprintf("%s.addr.%s", hex.EncodeToString(ip), d.domain)
+		}
+
+		switch {
+		case qType == dns.TypeSRV:
+			resp.Answer = append(resp.Answer, &dns.SRV{
+				Hdr: dns.RR_Header{
+					Name:   qName,
+					Rrtype: dns.TypeSRV,
+					Class:  dns.ClassINET,
+				},
+				Priority: 1,
+				Weight:   1,
+				Port:     uint16(l.port),
+				Target:   target,
+			})
+			record.Header().Name = target
+			resp.Extra = append(resp.Extra, record)
+
+		case qType == dns.TypeANY || qType == record.Header().Rrtype:
+			record.Header().Name = qName
+			resp.Answer = append(resp.Answer, record)
+		}
+	}
+}
+
+// upstreamListener is the local listener of a sidecar proxy for an
+// upstream.
+type upstreamListener struct {
+	addr string
+	port int
+}
+
+// upstreamListeners returns the distinct local listeners of the proxies
+// registered with this agent for the upstreams with the given destination,
+// ordered by proxy service ID.
+func (d *DNSServer) upstreamListeners(datacenter, name string) []upstreamListener {
+	services := d.agent.State.Services()
+	ids := make([]string, 0, len(services))
+	for id, svc := range services {
+		if svc.Kind == structs.ServiceKindConnectProxy {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var listeners []upstreamListener
+	seen := make(map[upstreamListener]struct{})
+	for _, id := range ids {
+		for _, u := range services[id].Proxy.Upstreams {
+			if !strings.EqualFold(u.DestinationName, name) {
+				continue
+			}
+			dc := u.Datacenter
+			if dc == "" {
+				dc = d.agent.config.Datacenter
+			}
+			if datacenter != "" && !strings.EqualFold(dc, datacenter) {
+				continue
+			}
+
+			l := upstreamListener{addr: u.LocalBindAddress, port: u.LocalBindPort}
+			if l.addr == "" {
+				l.addr = "127.0.0.1"
+			}
+			if _, ok := seen[l]; ok {
+				continue
+			}
+			seen[l] = struct{}{}
+			listeners = append(listeners, l)
+		}
+	}
+	return listeners
+}
+
 // nodeLookup is used to handle a node query
 func (d *DNSServer) nodeLookup(network, datacenter, node string, req, resp *dns.Msg, maxRecursionLevel int) {
 	// Only handle ANY, A, AAAA, and TXT type requests
@@ -1313,8 +1453,8 @@ func (d *DNSServer) serviceNodeRecords(dc string, nodes structs.CheckServiceNode
 		// Start with the translated address but use the service address,
 		// if specified.
 		addr := d.agent.TranslateAddress(dc, node.Node.Address, node.Node.TaggedAddresses)
-		if node.Service.Address != "" {
-			addr = node.Service.Address
+		if serviceAddr, _ := d.agent.TranslateServiceAddress(dc, node.Service.Address, node.Service.Port, node.Service.TaggedAddresses); serviceAddr != "" {
+			addr = serviceAddr
 		}
 
 		// If the service address is a CNAME for the service we are looking
@@ -1436,6 +1576,10 @@ func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes
 		}
 		handled[tuple] = struct{}{}
 
+		// Use the WAN address and port of the service for other datacenters,
+		// if specified.
+		serviceAddr, port := d.agent.TranslateServiceAddress(dc, node.Service.Address, node.Service.Port, node.Service.TaggedAddresses)
+
 		weight := findWeight(node)
 		// Add the SRV record
 		srvRec := &dns.SRV{
@@ -1447,7 +1591,7 @@ func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes
 			},
 			Priority: 1,
 			Weight:   uint16(weight),
-			Port:     uint16(node.Service.Port),
+			Port:     uint16(port),
 			Target:   fmt.Sprintf("%s.node.%s.%s", node.Node.Node, dc, d.domain),
 		}
 		resp.Answer = append(resp.Answer, srvRec)
@@ -1455,8 +1599,8 @@ func (d *DNSServer) serviceSRVRecords(dc string, nodes structs.CheckServiceNodes
 		// Start with the translated address but use the service address,
 		// if specified.
 		addr := d.agent.TranslateAddress(dc, node.Node.Address, node.Node.TaggedAddresses)
-		if node.Service.Address != "" {
-			addr = node.Service.Address
+		if serviceAddr != "" {
+			addr = serviceAddr
 		}
 
 		// Add the extra record
@@ -1513,20 +1657,30 @@ func (d *DNSServer) handleRecurse(resp dns.ResponseWriter, req *dns.Msg) {
 		network = "tcp"
 	}
 
+	// Without recursors the query fails as if there was no handler
+	recursors := d.recursors.order()
+	if len(recursors) == 0 {
+		dns.HandleFailed(resp, req)
+		return
+	}
+
 	// Recursively resolve
 	c := &dns.Client{Net: network, Timeout: d.config.RecursorTimeout}
 	var r *dns.Msg
 	var rtt time.Duration
 	var err error
-	for _, recursor := range d.recursors {
+	for _, recursor := range recursors {
 		r, rtt, err = c.Exchange(req, recursor)
 		// Check if the response is valid and has the desired Response code
 		if r != nil && (r.Rcode != dns.RcodeSuccess && r.Rcode != dns.RcodeNameError) {
 			d.logger.Printf("[DEBUG] dns: recurse RTT for %v (%v) Recursor queried: %v Status returned: %v", q, rtt, recursor, dns.RcodeToString[r.Rcode])
+			d.recursors.failure(recursor, fmt.Errorf("status returned: %v", dns.RcodeToString[r.Rcode]))
 			// If we still have recursors to forward the query to,
 			// we move forward onto the next one else the loop ends
 			continue
 		} else if err == nil || err == dns.ErrTruncated {
+			d.recursors.success(recursor, rtt)
+
 			// Compress the response; we don't know if the incoming
 			// response was compressed or not, so by not compressing
 			// we might generate an invalid packet on the way out.
@@ -1540,6 +1694,7 @@ func (d *DNSServer) handleRecurse(resp dns.ResponseWriter, req *dns.Msg) {
 			return
 		}
 		d.logger.Printf("[ERR] dns: recurse failed: %v", err)
+		d.recursors.failure(recursor, err)
 	}
 
 	// If all resolvers fail, return a SERVFAIL message
@@ -1577,7 +1732,8 @@ func (d *DNSServer) resolveCNAME(name string, maxRecursionLevel int) []dns.RR {
 	}
 
 	// Do nothing if we don't have a recursor
-	if len(d.recursors) == 0 {
+	recursors := d.recursors.order()
+	if len(recursors) == 0 {
 		return nil
 	}
 
@@ -1590,13 +1746,15 @@ func (d *DNSServer) resolveCNAME(name string, maxRecursionLevel int) []dns.RR {
 	var r *dns.Msg
 	var rtt time.Duration
 	var err error
-	for _, recursor := range d.recursors {
+	for _, recursor := range recursors {
 		r, rtt, err = c.Exchange(m, recursor)
 		if err == nil {
 			d.logger.Printf("[DEBUG] dns: cname recurse RTT for %v (%v)", name, rtt)
+			d.recursors.success(recursor, rtt)
 			return r.Answer
 		}
 		d.logger.Printf("[ERR] dns: cname recurse failed for %v: %v", name, err)
+		d.recursors.failure(recursor, err)
 	}
 	d.logger.Printf("[ERR] dns: all resolvers failed for %v", name)
 	return nil
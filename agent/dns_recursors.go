@@ -0,0 +1,193 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	// recursorFailureThreshold is the number of consecutive failures after
+	// which a recursor is considered unhealthy and only queried once the
+	// healthy ones failed.
+	recursorFailureThreshold = 3
+
+	// recursorRetryInterval is how long an unhealthy recursor is kept at the
+	// end of the list before it's queried in its configured position again
+	// to check whether it recovered.
+	recursorRetryInterval = 30 * time.Second
+
+	// recursorRTTWeight is the weight of the last round trip time in the
+	// moving average of a recursor.
+	recursorRTTWeight = 0.2
+)
+
+// dnsRecursor tracks the health of a recursor.
+type dnsRecursor struct {
+	addr                string
+	successes           uint64
+	failures            uint64
+	consecutiveFailures uint64
+	averageRTT          time.Duration
+	lastSuccess         time.Time
+	lastFailure         time.Time
+	lastError           string
+}
+
+// unhealthy returns whether the recursor failed too many times in a row
+// recently.
+func (r *dnsRecursor) unhealthy(now time.Time) bool {
+	return r.consecutiveFailures >= recursorFailureThreshold &&
+		now.Sub(r.lastFailure) < recursorRetryInterval
+}
+
+// dnsRecursors is the list of recursors shared by the DNS servers of the
+// agent. It tracks the health of each recursor so that the recursors that
+// keep failing are queried last instead of adding their timeout to every
+// recursive query. The list can be replaced on reload.
+type dnsRecursors struct {
+	l         sync.Mutex
+	recursors []*dnsRecursor
+	now       func() time.Time
+}
+
+// newDNSRecursors returns the recursors with the given addresses, adding
+// the default port if missing.
+func newDNSRecursors(addrs []string) (*dnsRecursors, error) {
+	r := &dnsRecursors{now: time.Now}
+	if err := r.update(addrs); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// update replaces the list of recursors, keeping the health of the ones
+// still present.
+func (r *dnsRecursors) update(addrs []string) error {
+	var recursors []*dnsRecursor
+	for _, a := range addrs {
+		ra, err := recursorAddr(a)
+		if err != nil {
+			return fmt.Errorf("Invalid recursor address: %v", err)
+		}
+		recursors = append(recursors, &dnsRecursor{addr: ra})
+	}
+
+	r.l.Lock()
+	defer r.l.Unlock()
+	existing := make(map[string]*dnsRecursor, len(r.recursors))
+	for _, rec := range r.recursors {
+		existing[rec.addr] = rec
+	}
+	for i, rec := range recursors {
+		if old, ok := existing[rec.addr]; ok {
+			recursors[i] = old
+		}
+	}
+	r.recursors = recursors
+	return nil
+}
+
+// len returns the number of recursors.
+func (r *dnsRecursors) len() int {
+	r.l.Lock()
+	defer r.l.Unlock()
+	return len(r.recursors)
+}
+
+// order returns the addresses of the recursors in the order they should be
+// queried: the healthy ones in their configured order followed by the
+// unhealthy ones, from the one that failed the longest time ago.
+func (r *dnsRecursors) order() []string {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	now := r.now()
+	var healthy []string
+	var unhealthy []*dnsRecursor
+	for _, rec := range r.recursors {
+		if rec.unhealthy(now) {
+			unhealthy = append(unhealthy, rec)
+		} else {
+			healthy = append(healthy, rec.addr)
+		}
+	}
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		return unhealthy[i].lastFailure.Before(unhealthy[j].lastFailure)
+	})
+	for _, rec := range unhealthy {
+		healthy = append(healthy, rec.addr)
+	}
+	return healthy
+}
+
+// success records a successful query of the recursor.
+func (r *dnsRecursors) success(addr string, rtt time.Duration) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	rec := r.find(addr)
+	if rec == nil {
+		return
+	}
+	rec.successes++
+	rec.consecutiveFailures = 0
+	rec.lastSuccess = r.now()
+	if rec.averageRTT == 0 {
+		rec.averageRTT = rtt
+	} else {
+		rec.averageRTT = time.Duration(recursorRTTWeight*float64(rtt) + (1-recursorRTTWeight)*float64(rec.averageRTT))
+	}
+}
+
+// failure records a failed query of the recursor.
+func (r *dnsRecursors) failure(addr string, err error) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	rec := r.find(addr)
+	if rec == nil {
+		return
+	}
+	rec.failures++
+	rec.consecutiveFailures++
+	rec.lastFailure = r.now()
+	rec.lastError = err.Error()
+}
+
+// find returns the recursor with the given address, or nil if it was
+// removed by a reload. The lock must be held.
+func (r *dnsRecursors) find(addr string) *dnsRecursor {
+	for _, rec := range r.recursors {
+		if rec.addr == addr {
+			return rec
+		}
+	}
+	return nil
+}
+
+// health returns the health of the recursors in their configured order.
+func (r *dnsRecursors) health() []*api.AgentDNSRecursor {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	now := r.now()
+	out := make([]*api.AgentDNSRecursor, 0, len(r.recursors))
+	for _, rec := range r.recursors {
+		out = append(out, &api.AgentDNSRecursor{
+			Address:             rec.addr,
+			Healthy:             !rec.unhealthy(now),
+			Successes:           rec.successes,
+			Failures:            rec.failures,
+			ConsecutiveFailures: rec.consecutiveFailures,
+			AverageRTT:          rec.averageRTT,
+			LastSuccess:         rec.lastSuccess,
+			LastFailure:         rec.lastFailure,
+			LastError:           rec.lastError,
+		})
+	}
+	return out
+}
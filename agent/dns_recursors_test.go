@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSRecursors_Order(t *testing.T) {
+	t.Parallel()
+
+	r, err := newDNSRecursors([]string{"127.0.0.1", "127.0.0.2", "127.0.0.3:5353"})
+	require.NoError(t, err)
+	now := time.Now()
+	r.now = func() time.Time { return now }
+
+	a, b, c := "127.0.0.1:53", "127.0.0.2:53", "127.0.0.3:5353"
+	require.Equal(t, []string{a, b, c}, r.order())
+
+	// A few failures don't change the order.
+	for i := 0; i < recursorFailureThreshold-1; i++ {
+		r.failure(a, fmt.Errorf("timeout"))
+	}
+	require.Equal(t, []string{a, b, c}, r.order())
+
+	// Unhealthy recursors go last, from the oldest failure.
+	r.failure(a, fmt.Errorf("timeout"))
+	require.Equal(t, []string{b, c, a}, r.order())
+	now = now.Add(time.Second)
+	for i := 0; i < recursorFailureThreshold; i++ {
+		r.failure(b, fmt.Errorf("refused"))
+	}
+	require.Equal(t, []string{c, a, b}, r.order())
+
+	// They get their position back once the retry interval elapsed.
+	now = now.Add(recursorRetryInterval)
+	require.Equal(t, []string{a, b, c}, r.order())
+
+	// A success resets the failures.
+	r.failure(a, fmt.Errorf("timeout"))
+	require.Equal(t, []string{b, c, a}, r.order())
+	r.success(a, 10*time.Millisecond)
+	require.Equal(t, []string{a, b, c}, r.order())
+
+	health := r.health()
+	require.Len(t, health, 3)
+	require.Equal(t, a, health[0].Address)
+	require.True(t, health[0].Healthy)
+	require.Equal(t, uint64(1), health[0].Successes)
+	require.Equal(t, uint64(recursorFailureThreshold+1), health[0].Failures)
+	require.Zero(t, health[0].ConsecutiveFailures)
+	require.Equal(t, "timeout", health[0].LastError)
+	require.Equal(t, 10*time.Millisecond, health[0].AverageRTT)
+	require.Equal(t, now, health[0].LastSuccess)
+	require.Equal(t, uint64(recursorFailureThreshold), health[1].ConsecutiveFailures)
+}
+
+func TestDNSRecursors_AverageRTT(t *testing.T) {
+	t.Parallel()
+
+	r, err := newDNSRecursors([]string{"127.0.0.1"})
+	require.NoError(t, err)
+
+	r.success("127.0.0.1:53", 10*time.Millisecond)
+	r.success("127.0.0.1:53", 20*time.Millisecond)
+	require.Equal(t, 12*time.Millisecond, r.health()[0].AverageRTT)
+
+	// Unknown recursors are ignored.
+	r.success("127.0.0.2:53", time.Second)
+	r.failure("127.0.0.2:53", fmt.Errorf("timeout"))
+	require.Len(t, r.health(), 1)
+}
+
+func TestDNSRecursors_Update(t *testing.T) {
+	t.Parallel()
+
+	r, err := newDNSRecursors([]string{"127.0.0.1", "127.0.0.2"})
+	require.NoError(t, err)
+	r.success("127.0.0.2:53", time.Millisecond)
+
+	// The health of the remaining recursors is kept.
+	require.NoError(t, r.update([]string{"127.0.0.2", "127.0.0.3"}))
+	require.Equal(t, []string{"127.0.0.2:53", "127.0.0.3:53"}, r.order())
+	health := r.health()
+	require.Equal(t, uint64(1), health[0].Successes)
+	require.Zero(t, health[1].Successes)
+
+	// Invalid addresses are rejected and the list is left untouched.
+	require.Error(t, r.update([]string{"127.0.0.4:port"}))
+	require.Equal(t, 2, r.len())
+
+	require.NoError(t, r.update(nil))
+	require.Empty(t, r.order())
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"net/http"
 	"reflect"
 	"strings"
 	"testing"
@@ -1611,6 +1612,85 @@ func TestDNS_ConnectServiceLookup(t *testing.T) {
 	}
 }
 
+func TestDNS_UpstreamLookup(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	proxy := &structs.NodeService{
+		Kind:    structs.ServiceKindConnectProxy,
+		ID:      "web-sidecar-proxy",
+		Service: "web-sidecar-proxy",
+		Port:    21000,
+		Proxy: structs.ConnectProxyConfig{
+			DestinationServiceName: "web",
+			Upstreams: structs.Upstreams{
+				{
+					DestinationType: structs.UpstreamDestTypeService,
+					DestinationName: "db",
+					LocalBindPort:   9191,
+				},
+				{
+					DestinationType:  structs.UpstreamDestTypeService,
+					DestinationName:  "db",
+					Datacenter:       "dc2",
+					LocalBindAddress: "127.10.0.1",
+					LocalBindPort:    9192,
+				},
+				{
+					DestinationType:  structs.UpstreamDestTypePreparedQuery,
+					DestinationName:  "cache",
+					LocalBindAddress: "::1",
+					LocalBindPort:    9193,
+				},
+			},
+		},
+	}
+	require.NoError(a.AddService(proxy, nil, false, "", ConfigSourceLocal))
+
+	exchange := func(question string, qType uint16) *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion(question, qType)
+		c := new(dns.Client)
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		require.NoError(err)
+		return in
+	}
+
+	// The upstreams in all the datacenters are listed
+	in := exchange("db.upstream.consul.", dns.TypeSRV)
+	require.Len(in.Answer, 2)
+	require.Len(in.Extra, 2)
+	srvRec := in.Answer[0].(*dns.SRV)
+	require.Equal(uint16(9191), srvRec.Port)
+	require.Equal("7f000001.addr.consul.", srvRec.Target)
+	aRec := in.Extra[0].(*dns.A)
+	require.Equal("7f000001.addr.consul.", aRec.Hdr.Name)
+	require.Equal("127.0.0.1", aRec.A.String())
+	require.Equal(uint16(9192), in.Answer[1].(*dns.SRV).Port)
+
+	// The datacenter label restricts the upstreams
+	in = exchange("db.upstream.dc2.consul.", dns.TypeA)
+	require.Len(in.Answer, 1)
+	aRec = in.Answer[0].(*dns.A)
+	require.Equal("db.upstream.dc2.consul.", aRec.Hdr.Name)
+	require.Equal("127.10.0.1", aRec.A.String())
+
+	in = exchange("cache.upstream.consul.", dns.TypeAAAA)
+	require.Len(in.Answer, 1)
+	require.Equal("::1", in.Answer[0].(*dns.AAAA).AAAA.String())
+
+	// Missing upstreams aren't found
+	for _, question := range []string{"web.upstream.consul.", "db.upstream.dc3.consul.", "db.foo.upstream.consul."} {
+		in = exchange(question, dns.TypeA)
+		require.Equal(dns.RcodeNameError, in.Rcode, question)
+		require.Empty(in.Answer, question)
+	}
+}
+
 func TestDNS_ExternalServiceLookup(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -2344,6 +2424,100 @@ func TestDNS_ServiceLookup_ServiceAddressIPV6(t *testing.T) {
 	}
 }
 
+func TestDNS_ServiceLookup_ServiceWanAddress(t *testing.T) {
+	t.Parallel()
+	a1 := NewTestAgent(t, t.Name(), `
+		datacenter = "dc1"
+		translate_wan_addrs = true
+		acl_datacenter = ""
+	`)
+	defer a1.Shutdown()
+
+	a2 := NewTestAgent(t, t.Name(), `
+		datacenter = "dc2"
+		translate_wan_addrs = true
+		acl_datacenter = ""
+	`)
+	defer a2.Shutdown()
+
+	// Join WAN cluster
+	addr := fmt.Sprintf("127.0.0.1:%d", a1.Config.SerfPortWAN)
+	if _, err := a2.JoinWAN([]string{addr}); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	retry.Run(t, func(r *retry.R) {
+		if got, want := len(a1.WANMembers()), 2; got < want {
+			r.Fatalf("got %d WAN members want at least %d", got, want)
+		}
+		if got, want := len(a2.WANMembers()), 2; got < want {
+			r.Fatalf("got %d WAN members want at least %d", got, want)
+		}
+	})
+
+	// Register a remote service behind a NAT
+	retry.Run(t, func(r *retry.R) {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc2",
+			Node:       "foo",
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				Service: "db",
+				Address: "127.0.0.2",
+				Port:    8080,
+				TaggedAddresses: map[string]structs.ServiceAddress{
+					"wan": {Address: "127.0.0.3", Port: 9090},
+				},
+			},
+		}
+
+		var out struct{}
+		if err := a2.RPC("Catalog.Register", args, &out); err != nil {
+			r.Fatalf("err: %v", err)
+		}
+	})
+
+	cases := []struct {
+		agent *TestAgent
+		port  uint16
+		name  string
+		addr  string
+	}{
+		// Other datacenters get the WAN address of the service
+		{a1, 9090, "7f000003.addr.dc2.consul.", "127.0.0.3"},
+		// The local datacenter gets its address
+		{a2, 8080, "7f000002.addr.dc2.consul.", "127.0.0.2"},
+	}
+	for _, tc := range cases {
+		m := new(dns.Msg)
+		m.SetQuestion("db.service.dc2.consul.", dns.TypeSRV)
+
+		c := new(dns.Client)
+		in, _, err := c.Exchange(m, tc.agent.Config.DNSAddrs[0].String())
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if len(in.Answer) != 1 {
+			t.Fatalf("Bad: %#v", in)
+		}
+		srvRec, ok := in.Answer[0].(*dns.SRV)
+		if !ok {
+			t.Fatalf("Bad: %#v", in.Answer[0])
+		}
+		if srvRec.Port != tc.port || srvRec.Target != tc.name {
+			t.Fatalf("Bad: %#v", srvRec)
+		}
+
+		aRec, ok := in.Extra[0].(*dns.A)
+		if !ok {
+			t.Fatalf("Bad: %#v", in.Extra[0])
+		}
+		if aRec.Hdr.Name != tc.name || aRec.A.String() != tc.addr {
+			t.Fatalf("Bad: %#v", in.Extra[0])
+		}
+	}
+}
+
 func TestDNS_ServiceLookup_WanAddress(t *testing.T) {
 	t.Parallel()
 	a1 := NewTestAgent(t, t.Name(), `
@@ -3348,6 +3522,102 @@ func TestDNS_RecursorTimeout(t *testing.T) {
 
 }
 
+func TestDNS_Recurse_Failover(t *testing.T) {
+	t.Parallel()
+	failing := makeRecursor(t, dns.Msg{
+		MsgHdr: dns.MsgHdr{Rcode: dns.RcodeServerFailure},
+	})
+	defer failing.Shutdown()
+	working := makeRecursor(t, dns.Msg{
+		Answer: []dns.RR{dnsA("apple.com", "1.2.3.4")},
+	})
+	defer working.Shutdown()
+
+	a := NewTestAgent(t, t.Name(), `
+		recursors = ["`+failing.Addr+`", "`+working.Addr+`"]
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	query := func() {
+		m := new(dns.Msg)
+		m.SetQuestion("apple.com.", dns.TypeANY)
+		c := new(dns.Client)
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+		require.Equal(t, dns.RcodeSuccess, in.Rcode)
+		require.Len(t, in.Answer, 1)
+	}
+
+	// The failing recursor is queried first until it's unhealthy.
+	for i := 0; i < recursorFailureThreshold+2; i++ {
+		query()
+	}
+
+	req, _ := http.NewRequest("GET", "/v1/agent/dns/recursors", nil)
+	obj, err := a.srv.AgentDNSRecursors(nil, req)
+	require.NoError(t, err)
+	health := obj.([]*api.AgentDNSRecursor)
+	require.Len(t, health, 2)
+	require.Equal(t, failing.Addr, health[0].Address)
+	require.False(t, health[0].Healthy)
+	require.Equal(t, uint64(recursorFailureThreshold), health[0].Failures)
+	require.Equal(t, "status returned: SERVFAIL", health[0].LastError)
+	require.Equal(t, working.Addr, health[1].Address)
+	require.True(t, health[1].Healthy)
+	require.Equal(t, uint64(recursorFailureThreshold+2), health[1].Successes)
+	require.NotZero(t, health[1].AverageRTT)
+
+	// The recursors can be replaced on reload.
+	c := TestConfig(config.Source{Name: t.Name(), Format: "hcl", Data: `
+		data_dir = "` + a.Config.DataDir + `"
+		recursors = ["` + working.Addr + `"]
+	`})
+	require.NoError(t, a.ReloadConfig(c))
+	obj, err = a.srv.AgentDNSRecursors(nil, req)
+	require.NoError(t, err)
+	health = obj.([]*api.AgentDNSRecursor)
+	require.Len(t, health, 1)
+	require.Equal(t, uint64(recursorFailureThreshold+2), health[0].Successes)
+	query()
+}
+
+func TestDNS_Recurse_Reload(t *testing.T) {
+	t.Parallel()
+	recursor := makeRecursor(t, dns.Msg{
+		Answer: []dns.RR{dnsA("apple.com", "1.2.3.4")},
+	})
+	defer recursor.Shutdown()
+
+	a := NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	query := func() *dns.Msg {
+		m := new(dns.Msg)
+		m.SetQuestion("apple.com.", dns.TypeANY)
+		c := new(dns.Client)
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		require.NoError(t, err)
+		return in
+	}
+
+	// Without recursors the query fails.
+	in := query()
+	require.Equal(t, dns.RcodeServerFailure, in.Rcode)
+	require.False(t, in.RecursionAvailable)
+
+	c := TestConfig(config.Source{Name: t.Name(), Format: "hcl", Data: `
+		data_dir = "` + a.Config.DataDir + `"
+		recursors = ["` + recursor.Addr + `"]
+	`})
+	require.NoError(t, a.ReloadConfig(c))
+
+	in = query()
+	require.Equal(t, dns.RcodeSuccess, in.Rcode)
+	require.Len(t, in.Answer, 1)
+}
+
 func TestDNS_ServiceLookup_FilterCritical(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -0,0 +1,201 @@
+package agent
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/hashicorp/consul/agent/checks"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/types"
+)
+
+// exposeChecksLocked exposes the paths of the HTTP checks of the service
+// through its local Connect proxy when the proxy is configured to expose
+// checks, and points the checks at the exposed paths so they can reach a
+// service only listening on localhost. Otherwise the checks run against
+// their own URL and the paths previously exposed for them are removed.
+//
+// It must be called whenever the HTTP checks of a service or its proxy
+// change. This assumes that the agent's stateLock is already held.
+func (a *Agent) exposeChecksLocked(serviceID string) error {
+	var ids []types.CheckID
+	for id := range a.checkHTTPs {
+		if c := a.State.Check(id); c != nil && c.ServiceID == serviceID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	proxyHTTPs := make(map[types.CheckID]string)
+	if proxy := a.connectProxyLocked(serviceID); proxy != nil {
+		// Keep the paths that were exposed explicitly
+		var paths []structs.ExposePath
+		for _, p := range proxy.Proxy.Expose.Paths {
+			if !p.ParsedFromCheck {
+				paths = append(paths, p)
+			}
+		}
+
+		if proxy.Proxy.Expose.Checks {
+			for _, id := range ids {
+				var proxyHTTP string
+				var err error
+				proxyHTTP, paths, err = a.exposeCheckPath(proxy, paths, a.checkHTTPs[id].HTTP)
+				if err != nil {
+					return fmt.Errorf("Failed to expose check %q: %v", id, err)
+				}
+				proxyHTTPs[id] = proxyHTTP
+			}
+		}
+
+		if !reflect.DeepEqual(paths, proxy.Proxy.Expose.Paths) {
+			// Don't modify the proxy in the local state
+			updated := *proxy
+			updated.Proxy.Expose.Paths = paths
+			if err := a.State.AddService(&updated, a.State.ServiceToken(proxy.ID)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, id := range ids {
+		existing := a.checkHTTPs[id]
+		if existing.ProxyHTTP == proxyHTTPs[id] {
+			continue
+		}
+
+		// Restart the check against its new target
+		existing.Stop()
+		http := &checks.CheckHTTP{
+			Notify:          existing.Notify,
+			CheckID:         existing.CheckID,
+			HTTP:            existing.HTTP,
+			Header:          existing.Header,
+			Method:          existing.Method,
+			Interval:        existing.Interval,
+			Timeout:         existing.Timeout,
+			Logger:          existing.Logger,
+			TLSClientConfig: existing.TLSClientConfig,
+			ProxyHTTP:       proxyHTTPs[id],
+		}
+		http.Start()
+		a.checkHTTPs[id] = http
+	}
+	return nil
+}
+
+// connectProxyLocked returns the local Connect proxy of the service, or nil
+// if there is none. The first one by ID is picked when there are several.
+func (a *Agent) connectProxyLocked(serviceID string) *structs.NodeService {
+	var proxy *structs.NodeService
+	for _, s := range a.State.Services() {
+		if s.Kind != structs.ServiceKindConnectProxy || s.Proxy.DestinationServiceID != serviceID {
+			continue
+		}
+		if proxy == nil || s.ID < proxy.ID {
+			proxy = s
+		}
+	}
+	return proxy
+}
+
+// exposeCheckPath adds the path of the check URL to the paths exposed by
+// the proxy, reusing the listener port it was already exposed on if any or
+// allocating a new one from the configured range. It returns the URL of the
+// exposed path along with the updated paths.
+func (a *Agent) exposeCheckPath(proxy *structs.NodeService, paths []structs.ExposePath, rawURL string) (string, []structs.ExposePath, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid URL %q: %v", rawURL, err)
+	}
+	localPort := 80
+	if u.Scheme == "https" {
+		localPort = 443
+	}
+	if port := u.Port(); port != "" {
+		if localPort, err = strconv.Atoi(port); err != nil {
+			return "", nil, fmt.Errorf("invalid port in URL %q", rawURL)
+		}
+	}
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	listenerPort := 0
+	for _, p := range paths {
+		if p.ParsedFromCheck && p.Path == path && p.LocalPathPort == localPort {
+			listenerPort = p.ListenerPort
+		}
+	}
+	if listenerPort == 0 {
+		// Keep the port the path was exposed on before if it's still free
+		used := a.exposedListenerPorts(proxy.ID, paths)
+		for _, p := range proxy.Proxy.Expose.Paths {
+			if p.ParsedFromCheck && p.Path == path && p.LocalPathPort == localPort && !used[p.ListenerPort] {
+				listenerPort = p.ListenerPort
+			}
+		}
+		if listenerPort == 0 {
+			if listenerPort, err = a.allocateExposePort(used); err != nil {
+				return "", nil, err
+			}
+		}
+		paths = append(paths, structs.ExposePath{
+			ListenerPort:    listenerPort,
+			Path:            path,
+			LocalPathPort:   localPort,
+			Protocol:        structs.ExposePathProtocolHTTP,
+			ParsedFromCheck: true,
+		})
+	}
+
+	addr := proxy.Address
+	if addr == "" {
+		addr = a.config.AdvertiseAddrLAN.String()
+	}
+	exposed := url.URL{
+		Scheme:   "http",
+		Host:     net.JoinHostPort(addr, strconv.Itoa(listenerPort)),
+		Path:     u.Path,
+		RawQuery: u.RawQuery,
+	}
+	return exposed.String(), paths, nil
+}
+
+// exposedListenerPorts returns the listener ports used by the given paths
+// of a proxy and by the exposed paths of the other local proxies.
+func (a *Agent) exposedListenerPorts(proxyID string, paths []structs.ExposePath) map[int]bool {
+	used := make(map[int]bool)
+	for _, p := range paths {
+		used[p.ListenerPort] = true
+	}
+	for id, s := range a.State.Services() {
+		if id == proxyID || s.Kind != structs.ServiceKindConnectProxy {
+			continue
+		}
+		for _, p := range s.Proxy.Expose.Paths {
+			used[p.ListenerPort] = true
+		}
+	}
+	return used
+}
+
+// allocateExposePort returns the lowest port of the configured range that
+// isn't used yet.
+func (a *Agent) allocateExposePort(used map[int]bool) (int, error) {
+	if a.config.ExposeMinPort < 1 {
+		return 0, fmt.Errorf("no expose port range is configured")
+	}
+	for port := a.config.ExposeMinPort; port <= a.config.ExposeMaxPort; port++ {
+		if !used[port] {
+			return port, nil
+		}
+	}
+	return 0, fmt.Errorf("no port left in the expose port range %d-%d",
+		a.config.ExposeMinPort, a.config.ExposeMaxPort)
+}
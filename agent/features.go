@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"sort"
+
+	"github.com/hashicorp/consul/agent/metadata"
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/serf/serf"
+)
+
+// Feature flags reported by /v1/agent/features. Names are namespaced by area
+// and never reused with a different meaning, so clients can check for them
+// instead of probing endpoints that may not exist on older agents.
+const (
+	FeatureACLNamePrefix          = "acl.name_prefix"
+	FeatureACLNamespaces          = "acl.namespaces"
+	FeatureACLServiceTokens       = "acl.service_tokens"
+	FeatureAgentCache             = "agent.cache"
+	FeatureChecksComposite        = "checks.composite"
+	FeatureConfigEntries          = "config_entries"
+	FeatureConnect                = "connect"
+	FeatureConnectDiscoveryChain  = "connect.discovery_chain"
+	FeatureConnectIngressGateways = "connect.ingress_gateways"
+	FeatureConnectL7Intentions    = "connect.l7_intentions"
+	FeatureConnectMeshGateways    = "connect.mesh_gateways"
+	FeatureHealthStream           = "health.stream"
+	FeatureKVChunked              = "kv.chunked"
+	FeatureKVDeleteTreeCAS        = "kv.delete_tree_cas"
+	FeatureKVFilter               = "kv.filter"
+	FeatureKVTTL                  = "kv.ttl"
+	FeatureLabels                 = "labels"
+	FeaturePreparedQueryStats     = "prepared_query.stats"
+	FeatureStreaming              = "streaming"
+	FeatureTxnCatalogConnect      = "txn.catalog_connect"
+)
+
+// version150 is the first release with the features added after 1.4.4.
+var version150 = version.Must(version.NewVersion("1.5.0"))
+
+// serverFeatures maps the features that are implemented by the servers to
+// the first server version supporting them. They are only reported when all
+// the alive servers of the datacenter are at least on that version, since
+// the request may be forwarded to any of them.
+var serverFeatures = map[string]*version.Version{
+	FeatureACLNamePrefix:          version150,
+	FeatureACLNamespaces:          version150,
+	FeatureACLServiceTokens:       version150,
+	FeatureConfigEntries:          version150,
+	FeatureConnectDiscoveryChain:  version150,
+	FeatureConnectIngressGateways: version150,
+	FeatureConnectL7Intentions:    version150,
+	FeatureConnectMeshGateways:    version150,
+	FeatureKVDeleteTreeCAS:        version150,
+	FeatureKVFilter:               version150,
+	FeatureKVTTL:                  version150,
+	FeatureLabels:                 version150,
+	FeaturePreparedQueryStats:     version150,
+	FeatureStreaming:              version150,
+}
+
+// Features is the response of /v1/agent/features.
+type Features struct {
+	// Version is the version of the agent.
+	Version string
+
+	// Features is the sorted list of the features supported by the agent
+	// with its current configuration and by the servers of its datacenter.
+	Features []string
+}
+
+// features returns the features supported by the agent. Features that
+// depend on the configuration are only included when they are enabled, and
+// features implemented by the servers only when all the servers support
+// them.
+func (a *Agent) features() []string {
+	candidates := []string{
+		FeatureAgentCache,
+		FeatureChecksComposite,
+		FeatureConfigEntries,
+		FeatureHealthStream,
+		FeatureKVChunked,
+		FeatureKVDeleteTreeCAS,
+		FeatureKVFilter,
+		FeatureKVTTL,
+		FeatureLabels,
+		FeaturePreparedQueryStats,
+		FeatureTxnCatalogConnect,
+	}
+	if a.config.ACLsEnabled {
+		candidates = append(candidates, FeatureACLNamePrefix, FeatureACLNamespaces)
+		if a.config.ACLEnableServiceTokens {
+			candidates = append(candidates, FeatureACLServiceTokens)
+		}
+	}
+	if a.config.ConnectEnabled {
+		candidates = append(candidates,
+			FeatureConnect,
+			FeatureConnectDiscoveryChain,
+			FeatureConnectIngressGateways,
+			FeatureConnectL7Intentions,
+			FeatureConnectMeshGateways,
+		)
+	}
+	if a.config.GRPCPort > 0 {
+		candidates = append(candidates, FeatureStreaming)
+	}
+
+	builds := serverBuilds(a.LANMembers(), a.config.Datacenter)
+	features := make([]string, 0, len(candidates))
+	for _, feature := range candidates {
+		if minVersion, ok := serverFeatures[feature]; ok && !buildsMeetMinimumVersion(builds, minVersion) {
+			continue
+		}
+		features = append(features, feature)
+	}
+	sort.Strings(features)
+	return features
+}
+
+// serverBuilds returns the build versions reported by the alive servers of
+// the given datacenter.
+func serverBuilds(members []serf.Member, datacenter string) []*version.Version {
+	var builds []*version.Version
+	for _, member := range members {
+		ok, parts := metadata.IsConsulServer(member)
+		if !ok || parts.Status != serf.StatusAlive || parts.Datacenter != datacenter {
+			continue
+		}
+		build := parts.Build
+		builds = append(builds, &build)
+	}
+	return builds
+}
+
+// buildsMeetMinimumVersion returns whether there is at least one server and
+// all of them are at least on the given version.
+func buildsMeetMinimumVersion(builds []*version.Version, minVersion *version.Version) bool {
+	if len(builds) == 0 {
+		return false
+	}
+	for _, build := range builds {
+		if build.LessThan(minVersion) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/serf/serf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerBuilds(t *testing.T) {
+	t.Parallel()
+
+	member := func(name, role, dc, build string, status serf.MemberStatus) serf.Member {
+		return serf.Member{
+			Name:   name,
+			Status: status,
+			Tags: map[string]string{
+				"role":  role,
+				"dc":    dc,
+				"port":  "8300",
+				"vsn":   "2",
+				"build": build,
+			},
+		}
+	}
+	members := []serf.Member{
+		member("s1", "consul", "dc1", "1.5.0dev:abcdef", serf.StatusAlive),
+		member("s2", "consul", "dc1", "1.4.4:abcdef", serf.StatusAlive),
+		member("s3", "consul", "dc1", "1.2.0:abcdef", serf.StatusLeft),
+		member("s4", "consul", "dc2", "1.2.0:abcdef", serf.StatusAlive),
+		member("c1", "node", "dc1", "1.2.0:abcdef", serf.StatusAlive),
+	}
+
+	builds := serverBuilds(members, "dc1")
+	require.Len(t, builds, 2)
+	require.True(t, buildsMeetMinimumVersion(builds, version.Must(version.NewVersion("1.4.4"))))
+
+	// A server still on 1.4.4 doesn't have the features added since, so
+	// none of them is reported until it's upgraded.
+	for feature, minVersion := range serverFeatures {
+		require.False(t, buildsMeetMinimumVersion(builds, minVersion), feature)
+	}
+	builds = serverBuilds(members[:1], "dc1")
+	for feature, minVersion := range serverFeatures {
+		require.True(t, buildsMeetMinimumVersion(builds, minVersion), feature)
+	}
+
+	// Without any known server nothing implemented by the servers can be
+	// reported.
+	require.False(t, buildsMeetMinimumVersion(serverBuilds(nil, "dc1"), version.Must(version.NewVersion("1.0.0"))))
+}
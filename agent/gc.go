@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/consul/api"
+)
+
+// gcMetricsInterval is how often the garbage collector metrics are emitted.
+const gcMetricsInterval = 10 * time.Second
+
+// defaultGCPercent is the GC percentage the process started with, set from
+// the GOGC environment variable by the runtime.
+var defaultGCPercent = func() int {
+	percent := debug.SetGCPercent(100)
+	debug.SetGCPercent(percent)
+	return percent
+}()
+
+// gcTuner applies the garbage collector tuning of the agent.
+type gcTuner struct {
+	lock    sync.Mutex
+	config  api.GCConfiguration
+	ballast []byte
+}
+
+// newGCTuner returns a tuner leaving the runtime defaults alone until a
+// tuning is applied.
+func newGCTuner() *gcTuner {
+	return &gcTuner{}
+}
+
+// Config returns the current tuning.
+func (t *gcTuner) Config() api.GCConfiguration {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.config
+}
+
+// Apply validates and applies the given tuning.
+func (t *gcTuner) Apply(conf api.GCConfiguration) error {
+	if conf.Percent < -1 {
+		return fmt.Errorf("GC percent cannot be %d. Must be -1 or greater", conf.Percent)
+	}
+	if conf.BallastBytes < 0 {
+		return fmt.Errorf("GC ballast cannot be %d. Must be greater than or equal to zero", conf.BallastBytes)
+	}
+	if conf.MemoryLimitBytes < 0 {
+		return fmt.Errorf("GC memory limit cannot be %d. Must be greater than or equal to zero", conf.MemoryLimitBytes)
+	}
+	if conf.Percent == -1 && conf.MemoryLimitBytes == 0 {
+		return fmt.Errorf("GC percent can only be -1 with a memory limit")
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Set the limit first since it is the one that can fail.
+	if err := setMemoryLimit(conf.MemoryLimitBytes); err != nil {
+		return err
+	}
+
+	percent := conf.Percent
+	if percent == 0 {
+		percent = defaultGCPercent
+	}
+	debug.SetGCPercent(percent)
+
+	if int64(len(t.ballast)) != conf.BallastBytes {
+		t.ballast = nil
+		if conf.BallastBytes > 0 {
+			t.ballast = make([]byte, conf.BallastBytes)
+		}
+	}
+
+	t.config = conf
+	return nil
+}
+
+// emitMetrics periodically emits the GC tuning and the share of CPU time
+// used by the collections until the stop channel is closed.
+func (t *gcTuner) emitMetrics(stopCh <-chan struct{}) {
+	ticker := time.NewTicker(gcMetricsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-stopCh:
+			return
+		}
+
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		metrics.SetGauge([]string{"runtime", "gc_cpu_fraction"}, float32(stats.GCCPUFraction))
+
+		conf := t.Config()
+		metrics.SetGauge([]string{"runtime", "gc_percent"}, float32(conf.Percent))
+		metrics.SetGauge([]string{"runtime", "gc_ballast_bytes"}, float32(conf.BallastBytes))
+		metrics.SetGauge([]string{"runtime", "gc_memory_limit_bytes"}, float32(conf.MemoryLimitBytes))
+	}
+}
@@ -0,0 +1,18 @@
+// +build go1.19
+
+package agent
+
+import (
+	"math"
+	"runtime/debug"
+)
+
+// setMemoryLimit sets the soft memory limit of the runtime, or removes it
+// when zero.
+func setMemoryLimit(limit int64) error {
+	if limit == 0 {
+		limit = math.MaxInt64
+	}
+	debug.SetMemoryLimit(limit)
+	return nil
+}
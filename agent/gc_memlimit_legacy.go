@@ -0,0 +1,16 @@
+// +build !go1.19
+
+package agent
+
+import (
+	"fmt"
+)
+
+// setMemoryLimit fails for any limit since the runtime only supports soft
+// memory limits as of Go 1.19.
+func setMemoryLimit(limit int64) error {
+	if limit != 0 {
+		return fmt.Errorf("GC memory limit requires Consul to be built with Go 1.19 or later")
+	}
+	return nil
+}
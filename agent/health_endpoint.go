@@ -1,6 +1,7 @@
 package agent
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -8,6 +9,7 @@ import (
 
 	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/subscribe"
 	"github.com/hashicorp/consul/api"
 )
 
@@ -160,6 +162,12 @@ func (s *HTTPServer) healthServiceNodes(resp http.ResponseWriter, req *http.Requ
 	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
+	if err := parseFilter(req, &args.QueryOptions, structs.CheckServiceNodes(nil)); err != nil {
+		return nil, err
+	}
+	if err := parseLabelSelector(req, &args.QueryOptions); err != nil {
+		return nil, err
+	}
 
 	// Check for tags
 	params := req.URL.Query()
@@ -186,7 +194,26 @@ func (s *HTTPServer) healthServiceNodes(resp http.ResponseWriter, req *http.Requ
 	var out structs.IndexedCheckServiceNodes
 	defer setMeta(resp, &out.QueryMeta)
 
-	if args.QueryOptions.UseCache {
+	if s.useStreamingBackend(&args) {
+		// Serve the request from the materialized view of the service,
+		// which waits for its next change for blocking queries.
+		if args.MaxQueryTime == 0 || args.MaxQueryTime > maxQueryTime {
+			args.MaxQueryTime = maxQueryTime
+		}
+		raw, m, err := s.agent.cache.Get(cachetype.StreamingHealthServicesName, &args)
+		if err != nil {
+			return nil, err
+		}
+		if args.QueryOptions.UseCache {
+			defer setCacheMeta(resp, &m)
+		}
+		reply, ok := raw.(*structs.IndexedCheckServiceNodes)
+		if !ok {
+			// This should never happen, but we want to protect against panics
+			return nil, fmt.Errorf("internal error: response type not correct")
+		}
+		out = *reply
+	} else if args.QueryOptions.UseCache {
 		raw, m, err := s.agent.cache.Get(cachetype.HealthServicesName, &args)
 		if err != nil {
 			return nil, err
@@ -278,3 +305,85 @@ OUTER:
 	}
 	return nodes[:n]
 }
+
+// useStreamingBackend returns true if the given request can be served from
+// the materialized views of the streaming backend, which only support the
+// services of the local datacenter without any filter.
+func (s *HTTPServer) useStreamingBackend(args *structs.ServiceSpecificRequest) bool {
+	return s.agent.config.UseStreamingBackend &&
+		args.Datacenter == s.agent.config.Datacenter &&
+		!args.Connect &&
+		!args.TagFilter &&
+		len(args.NodeMetaFilters) == 0 &&
+		args.Filter == "" &&
+		args.LabelSelector == "" &&
+		args.Source.Node == "" &&
+		!args.RequireConsistent
+}
+
+// HealthServiceStream streams the health events of the instances of a
+// service as server-sent events, starting with the current state. Each event
+// is a JSON encoded subscribe.Event with the index of the change as its ID.
+func (s *HTTPServer) HealthServiceStream(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	args := subscribe.Request{
+		Topic: subscribe.TopicHealth,
+		Key:   strings.TrimPrefix(req.URL.Path, "/v1/health/stream/service/"),
+	}
+	if args.Key == "" {
+		return nil, BadRequestError{Reason: "Missing service name"}
+	}
+
+	return s.streamSubscription(resp, req, &args)
+}
+
+// streamSubscription runs the subscription of the given topic and key and
+// streams its events as server-sent events until the client goes away. The
+// datacenter, token and index of the subscription are parsed from the
+// request.
+func (s *HTTPServer) streamSubscription(resp http.ResponseWriter, req *http.Request, args *subscribe.Request) (interface{}, error) {
+	s.parseDC(req, &args.Datacenter)
+	s.parseToken(req, &args.Token)
+	if index := req.URL.Query().Get("index"); index != "" {
+		var err error
+		if args.Index, err = strconv.ParseUint(index, 10, 64); err != nil {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Invalid index: %v", err)}
+		}
+	}
+
+	flusher, ok := resp.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("Streaming not supported")
+	}
+
+	srv := &subscribe.Server{
+		Logger:     s.agent.logger,
+		RPC:        s.agent,
+		Datacenter: s.agent.config.Datacenter,
+		Streamer:   s.agent.streamer(),
+	}
+
+	// The response starts with the first event so that errors of the
+	// initial query are reported with the right status code.
+	started := false
+	err := srv.Stream(req.Context(), args, func(e *subscribe.Event) error {
+		buf, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if !started {
+			resp.Header().Set("Content-Type", "text/event-stream")
+			resp.Header().Set("Cache-Control", "no-cache")
+			resp.WriteHeader(http.StatusOK)
+			started = true
+		}
+		if _, err := fmt.Fprintf(resp, "id: %d\ndata: %s\n\n", e.Index, buf); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	})
+	if err != nil && !started {
+		return nil, err
+	}
+	return nil, nil
+}
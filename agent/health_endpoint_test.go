@@ -1,15 +1,21 @@
 package agent
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/subscribe"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
@@ -527,6 +533,77 @@ func TestHealthServiceNodes(t *testing.T) {
 	}
 }
 
+func TestHealthServiceNodes_StreamingBackend(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `use_streaming_backend = true`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	require := require.New(t)
+
+	register := func(node string) {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				ID:      "test",
+				Service: "test",
+			},
+		}
+		var out struct{}
+		require.NoError(a.RPC("Catalog.Register", args, &out))
+	}
+	register("bar")
+
+	req, _ := http.NewRequest("GET", "/v1/health/service/test", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.HealthServiceNodes(resp, req)
+	require.NoError(err)
+	assertIndex(t, resp)
+	nodes := obj.(structs.CheckServiceNodes)
+	require.Len(nodes, 1)
+	require.Equal("bar", nodes[0].Node.Node)
+	require.NotNil(nodes[0].Checks)
+
+	// A blocking query returns once the view is updated.
+	index := resp.Header().Get("X-Consul-Index")
+	doneCh := make(chan structs.CheckServiceNodes, 1)
+	go func() {
+		req, _ := http.NewRequest("GET", "/v1/health/service/test?wait=5s&index="+index, nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.HealthServiceNodes(resp, req)
+		if err != nil {
+			doneCh <- nil
+			return
+		}
+		doneCh <- obj.(structs.CheckServiceNodes)
+	}()
+
+	time.Sleep(100 * time.Millisecond)
+	register("baz")
+
+	select {
+	case nodes := <-doneCh:
+		require.Len(nodes, 2)
+		require.Equal("bar", nodes[0].Node.Node)
+		require.Equal("baz", nodes[1].Node.Node)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("blocking query didn't return")
+	}
+
+	// Filtered requests don't use the streaming backend.
+	req, _ = http.NewRequest("GET", "/v1/health/service/test?tag=nope", nil)
+	require.False(a.srv.useStreamingBackend(&structs.ServiceSpecificRequest{
+		Datacenter: "dc1",
+		TagFilter:  true,
+	}))
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.HealthServiceNodes(resp, req)
+	require.NoError(err)
+	require.Len(obj.(structs.CheckServiceNodes), 0)
+}
+
 func TestHealthServiceNodes_NodeMetaFilter(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -580,6 +657,46 @@ func TestHealthServiceNodes_NodeMetaFilter(t *testing.T) {
 	}
 }
 
+func TestHealthServiceNodes_ServiceMetaFilter(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	for _, version := range []string{"v1", "v2"} {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "node-" + version,
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				ID:      "web-" + version,
+				Service: "web",
+				Meta:    map[string]string{"version": version},
+			},
+		}
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+
+	filter := url.QueryEscape(`Service.Meta.version == v2`)
+	req, _ := http.NewRequest("GET", "/v1/health/service/web?filter="+filter, nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.HealthServiceNodes(resp, req)
+	require.NoError(t, err)
+	assertIndex(t, resp)
+
+	nodes := obj.(structs.CheckServiceNodes)
+	require.Len(t, nodes, 1)
+	require.Equal(t, "web-v2", nodes[0].Service.ID)
+
+	// Instances without the meta key don't match
+	filter = url.QueryEscape(`Service.Meta.canary == true`)
+	req, _ = http.NewRequest("GET", "/v1/health/service/web?filter="+filter, nil)
+	obj, err = a.srv.HealthServiceNodes(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	require.Empty(t, obj.(structs.CheckServiceNodes))
+}
+
 func TestHealthServiceNodes_DistanceSort(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -977,3 +1094,87 @@ func TestFilterNonPassing(t *testing.T) {
 		t.Fatalf("bad: %v", out)
 	}
 }
+
+func TestHealthServiceStream(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// The service name is required.
+	req, _ := http.NewRequest("GET", "/v1/health/stream/service/", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.HealthServiceStream(resp, req)
+	require.Error(t, err)
+	require.IsType(t, BadRequestError{}, err)
+
+	register := func(status string) {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "bar",
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				ID:      "web1",
+				Service: "web",
+			},
+			Check: &structs.HealthCheck{
+				Node:      "bar",
+				Name:      "web check",
+				CheckID:   "web",
+				ServiceID: "web1",
+				Status:    status,
+			},
+		}
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+	register(api.HealthPassing)
+
+	httpResp, err := http.Get("http://" + a.HTTPAddr() + "/v1/health/stream/service/web")
+	require.NoError(t, err)
+	defer httpResp.Body.Close()
+	require.Equal(t, http.StatusOK, httpResp.StatusCode)
+	require.Equal(t, "text/event-stream", httpResp.Header.Get("Content-Type"))
+
+	events := make(chan *subscribe.Event, 10)
+	go func() {
+		defer close(events)
+		scanner := bufio.NewScanner(httpResp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data: ") {
+				continue
+			}
+			var e subscribe.Event
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &e); err != nil {
+				return
+			}
+			events <- &e
+		}
+	}()
+	next := func() *subscribe.Event {
+		select {
+		case e := <-events:
+			require.NotNil(t, e)
+			return e
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event")
+		}
+		return nil
+	}
+
+	// The initial state is sent first.
+	e := next()
+	require.Equal(t, subscribe.OpUpsert, e.Op)
+	require.Equal(t, "web1", e.ServiceHealth.Service.ID)
+	require.Equal(t, api.HealthPassing, e.Status)
+	require.Empty(t, e.PreviousStatus)
+	require.True(t, next().EndOfSnapshot)
+
+	// Followed by the transitions.
+	register(api.HealthCritical)
+	e = next()
+	require.Equal(t, subscribe.OpUpsert, e.Op)
+	require.Equal(t, api.HealthCritical, e.Status)
+	require.Equal(t, api.HealthPassing, e.PreviousStatus)
+}
@@ -18,6 +18,7 @@ import (
 	metrics "github.com/armon/go-metrics"
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
@@ -116,6 +117,58 @@ func registerEndpoint(pattern string, methods []string, fn unboundEndpoint) {
 	allowedMethods[pattern] = methods
 }
 
+// allowedEndpoints returns the endpoint patterns allowed by the
+// configuration mapped to the methods they allow, nil meaning all of them.
+// The whole map is nil if all the endpoints are allowed. Allowed patterns
+// that aren't served by the agent are logged since they are most likely
+// typos.
+func (s *HTTPServer) allowedEndpoints() map[string][]string {
+	if len(s.agent.config.HTTPAllowedEndpoints) == 0 {
+		return nil
+	}
+	allowed := make(map[string][]string)
+	for _, entry := range s.agent.config.HTTPAllowedEndpoints {
+		methods, pattern := config.ParseHTTPAllowedEndpoint(entry)
+		if endpoints[pattern] == nil && pattern != "/debug/pprof/" && pattern != "/ui/" {
+			s.agent.logger.Printf("[WARN] agent: Allowed HTTP endpoint %q is not served by the agent", pattern)
+		}
+
+		// An entry allowing all the methods wins over the others.
+		cur, ok := allowed[pattern]
+		switch {
+		case !ok:
+			allowed[pattern] = methods
+		case cur == nil || methods == nil:
+			allowed[pattern] = nil
+		default:
+			allowed[pattern] = append(cur, methods...)
+		}
+	}
+	return allowed
+}
+
+// restrictMethods returns the methods of an endpoint which are allowed, and
+// false if none of them is. An endpoint registered without methods handles
+// them itself, so it gets the allowed ones.
+func restrictMethods(methods, allowed []string) ([]string, bool) {
+	if allowed == nil {
+		return methods, true
+	}
+	if len(methods) == 0 {
+		return allowed, true
+	}
+	var restricted []string
+	for _, method := range methods {
+		for _, a := range allowed {
+			if method == a {
+				restricted = append(restricted, method)
+				break
+			}
+		}
+	}
+	return restricted, len(restricted) > 0
+}
+
 // wrappedMux hangs on to the underlying mux for unit tests.
 type wrappedMux struct {
 	mux     *http.ServeMux
@@ -199,10 +252,24 @@ func (s *HTTPServer) handler(enableDebug bool) http.Handler {
 		handleFuncMetrics(pattern, http.HandlerFunc(wrapper))
 	}
 
+	// With an allowlist, only the allowed endpoints are registered so any
+	// other request is answered with a 404 by the index before reaching a
+	// handler.
+	allowed := s.allowedEndpoints()
+
 	mux.HandleFunc("/", s.Index)
 	for pattern, fn := range endpoints {
-		thisFn := fn
 		methods, _ := allowedMethods[pattern]
+		if allowed != nil {
+			patternMethods, ok := allowed[pattern]
+			if !ok {
+				continue
+			}
+			if methods, ok = restrictMethods(methods, patternMethods); !ok {
+				continue
+			}
+		}
+		thisFn := fn
 		bound := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 			return thisFn(s, resp, req)
 		}
@@ -210,13 +277,15 @@ func (s *HTTPServer) handler(enableDebug bool) http.Handler {
 	}
 
 	// Register wrapped pprof handlers
-	handlePProf("/debug/pprof/", pprof.Index)
-	handlePProf("/debug/pprof/cmdline", pprof.Cmdline)
-	handlePProf("/debug/pprof/profile", pprof.Profile)
-	handlePProf("/debug/pprof/symbol", pprof.Symbol)
-	handlePProf("/debug/pprof/trace", pprof.Trace)
+	if _, ok := allowed["/debug/pprof/"]; allowed == nil || ok {
+		handlePProf("/debug/pprof/", pprof.Index)
+		handlePProf("/debug/pprof/cmdline", pprof.Cmdline)
+		handlePProf("/debug/pprof/profile", pprof.Profile)
+		handlePProf("/debug/pprof/symbol", pprof.Symbol)
+		handlePProf("/debug/pprof/trace", pprof.Trace)
+	}
 
-	if s.IsUIEnabled() {
+	if _, ok := allowed["/ui/"]; s.IsUIEnabled() && (allowed == nil || ok) {
 		var uifs http.FileSystem
 
 		// Use the custom UI dir if provided.
@@ -276,7 +345,7 @@ var (
 // wrap is used to wrap functions to make them more convenient
 func (s *HTTPServer) wrap(handler endpoint, methods []string) http.HandlerFunc {
 	return func(resp http.ResponseWriter, req *http.Request) {
-		setHeaders(resp, s.agent.config.HTTPResponseHeaders)
+		setHeaders(resp, s.agent.currentConfig().HTTPResponseHeaders)
 		setTranslateAddr(resp, s.agent.config.TranslateWANAddrs)
 
 		// Obfuscate any tokens from appearing in the logs
@@ -368,6 +437,11 @@ func (s *HTTPServer) wrap(handler endpoint, methods []string) http.HandlerFunc {
 
 		var obj interface{}
 
+		// CORS preflight requests are answered here for all the endpoints.
+		if s.setCORSHeaders(resp, req, methods) {
+			return
+		}
+
 		// if this endpoint has declared methods, respond appropriately to OPTIONS requests. Otherwise let the endpoint handle that.
 		if req.Method == "OPTIONS" && len(methods) > 0 {
 			addAllowHeader(append([]string{"OPTIONS"}, methods...))
@@ -575,6 +649,21 @@ func setConsistency(resp http.ResponseWriter, consistency string) {
 	}
 }
 
+// setServedBy is used to set the headers of the server that served the
+// query, if known
+func setServedBy(resp http.ResponseWriter, m *structs.QueryMeta) {
+	if m.ServedBy == "" {
+		return
+	}
+	resp.Header().Set("X-Consul-Served-By", m.ServedBy)
+	if m.ServedByID != "" {
+		resp.Header().Set("X-Consul-Served-By-ID", string(m.ServedByID))
+	}
+	if m.ServedByRole != "" {
+		resp.Header().Set("X-Consul-Served-By-Role", m.ServedByRole)
+	}
+}
+
 // setLastContact is used to set the last contact header
 func setLastContact(resp http.ResponseWriter, last time.Duration) {
 	if last < 0 {
@@ -590,6 +679,7 @@ func setMeta(resp http.ResponseWriter, m *structs.QueryMeta) {
 	setLastContact(resp, m.LastContact)
 	setKnownLeader(resp, m.KnownLeader)
 	setConsistency(resp, m.ConsistencyLevel)
+	setServedBy(resp, m)
 }
 
 // setCacheMeta sets http response headers to indicate cache status.
@@ -607,6 +697,73 @@ func setCacheMeta(resp http.ResponseWriter, m *cache.ResultMeta) {
 	}
 }
 
+// corsDefaultHeaders are the request headers always allowed in cross-origin
+// requests.
+var corsDefaultHeaders = []string{"Content-Type", "X-Consul-Token"}
+
+// corsExposedHeaders are the response headers browsers let cross-origin
+// callers read.
+var corsExposedHeaders = []string{
+	"Age",
+	"X-Cache",
+	"X-Consul-ContentHash",
+	"X-Consul-Effective-Consistency",
+	"X-Consul-Index",
+	"X-Consul-KnownLeader",
+	"X-Consul-LastContact",
+	"X-Consul-Reason",
+	"X-Consul-Translate-Addresses",
+}
+
+// setCORSHeaders sets the CORS response headers when the request comes from
+// one of the allowed origins. It returns true if the request is a preflight
+// request, which is fully answered by the headers.
+func (s *HTTPServer) setCORSHeaders(resp http.ResponseWriter, req *http.Request, methods []string) bool {
+	origins := s.agent.config.HTTPCORSAllowedOrigins
+	origin := req.Header.Get("Origin")
+	if len(origins) == 0 || origin == "" {
+		return false
+	}
+
+	// The response depends on the origin even when it's not allowed so
+	// caches must not share it across origins.
+	resp.Header().Add("Vary", "Origin")
+	allowed := false
+	for _, o := range origins {
+		if o == "*" || o == origin {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return false
+	}
+
+	resp.Header().Set("Access-Control-Allow-Origin", origin)
+	if s.agent.config.HTTPCORSAllowCredentials {
+		resp.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+
+	requestMethod := req.Header.Get("Access-Control-Request-Method")
+	if req.Method != "OPTIONS" || requestMethod == "" {
+		resp.Header().Set("Access-Control-Expose-Headers", strings.Join(corsExposedHeaders, ", "))
+		return false
+	}
+
+	if len(methods) > 0 {
+		resp.Header().Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+	} else {
+		resp.Header().Set("Access-Control-Allow-Methods", requestMethod)
+	}
+	headers := append([]string{}, corsDefaultHeaders...)
+	headers = append(headers, s.agent.config.HTTPCORSAllowedHeaders...)
+	resp.Header().Set("Access-Control-Allow-Headers", strings.Join(headers, ", "))
+	if maxAge := s.agent.config.HTTPCORSMaxAge; maxAge > 0 {
+		resp.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge/time.Second)))
+	}
+	return true
+}
+
 // setHeaders is used to set canonical response header fields
 func setHeaders(resp http.ResponseWriter, headers map[string]string) {
 	for field, value := range headers {
@@ -762,6 +919,15 @@ func (s *HTTPServer) parseDC(req *http.Request, dc *string) {
 	}
 }
 
+// parseNamespace is used to parse the ?ns query param. A namespace that's
+// already set, by the request body for example, takes precedence. The servers
+// bind the namespace to the token of the request.
+func (s *HTTPServer) parseNamespace(req *http.Request, ns *string) {
+	if *ns == "" {
+		*ns = req.URL.Query().Get("ns")
+	}
+}
+
 // parseTokenInternal is used to parse the ?token query param or the X-Consul-Token header or
 // Authorization Bearer token (RFC6750) and
 // optionally resolve proxy tokens to real ACL tokens. If the token is invalid or not specified it will populate
@@ -873,6 +1039,30 @@ func (s *HTTPServer) parseMetaFilter(req *http.Request) map[string]string {
 	return nil
 }
 
+// parseFilter is used to parse the ?filter query parameter, checking the
+// expression against the type of the filtered results so invalid expressions
+// are rejected before making the RPC
+func parseFilter(req *http.Request, b *structs.QueryOptions, dataType interface{}) error {
+	b.Filter = req.URL.Query().Get("filter")
+	if b.Filter == "" {
+		return nil
+	}
+	if _, err := structs.CreateFilter(b.Filter, dataType); err != nil {
+		return BadRequestError{Reason: fmt.Sprintf("Invalid filter: %v", err)}
+	}
+	return nil
+}
+
+// parseLabelSelector is used to parse the ?label-selector query param of the
+// endpoints listing resources with labels.
+func parseLabelSelector(req *http.Request, b *structs.QueryOptions) error {
+	b.LabelSelector = req.URL.Query().Get("label-selector")
+	if _, err := structs.ParseLabelSelector(b.LabelSelector); err != nil {
+		return BadRequestError{Reason: fmt.Sprintf("Invalid label selector: %v", err)}
+	}
+	return nil
+}
+
 // parseInternal is a convenience method for endpoints that need
 // to use both parseWait and parseDC.
 func (s *HTTPServer) parseInternal(resp http.ResponseWriter, req *http.Request, dc *string, b *structs.QueryOptions, resolveProxyToken bool) bool {
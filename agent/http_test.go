@@ -18,6 +18,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/structs"
 	tokenStore "github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/api"
@@ -264,9 +265,12 @@ func TestSetLastContact(t *testing.T) {
 func TestSetMeta(t *testing.T) {
 	t.Parallel()
 	meta := structs.QueryMeta{
-		Index:       1000,
-		KnownLeader: true,
-		LastContact: 123456 * time.Microsecond,
+		Index:        1000,
+		KnownLeader:  true,
+		LastContact:  123456 * time.Microsecond,
+		ServedBy:     "node1",
+		ServedByID:   "e8f4b1f4-6a58-4bd5-9f3c-02cbeb4f1b0e",
+		ServedByRole: structs.QueryServedByLeader,
 	}
 	resp := httptest.NewRecorder()
 	setMeta(resp, &meta)
@@ -282,6 +286,25 @@ func TestSetMeta(t *testing.T) {
 	if header != "123" {
 		t.Fatalf("Bad: %v", header)
 	}
+	header = resp.Header().Get("X-Consul-Served-By")
+	if header != "node1" {
+		t.Fatalf("Bad: %v", header)
+	}
+	header = resp.Header().Get("X-Consul-Served-By-ID")
+	if header != "e8f4b1f4-6a58-4bd5-9f3c-02cbeb4f1b0e" {
+		t.Fatalf("Bad: %v", header)
+	}
+	header = resp.Header().Get("X-Consul-Served-By-Role")
+	if header != "leader" {
+		t.Fatalf("Bad: %v", header)
+	}
+
+	// Nothing is set when the server is unknown
+	resp = httptest.NewRecorder()
+	setMeta(resp, &structs.QueryMeta{})
+	if header := resp.Header().Get("X-Consul-Served-By"); header != "" {
+		t.Fatalf("Bad: %v", header)
+	}
 }
 
 func TestHTTPAPI_BlockEndpoints(t *testing.T) {
@@ -319,6 +342,84 @@ func TestHTTPAPI_BlockEndpoints(t *testing.T) {
 	}
 }
 
+func TestHTTPAPI_AllowedEndpoints(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), `
+		http_config {
+			endpoint_profile = "discovery-only"
+			allowed_endpoints = ["/v1/kv/"]
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	cases := map[string]int{
+		"/v1/catalog/nodes":    http.StatusOK,
+		"/v1/health/service/x": http.StatusOK,
+		"/v1/kv/missing":       http.StatusNotFound,
+		"/v1/agent/self":       http.StatusNotFound,
+		"/v1/acl/tokens":       http.StatusNotFound,
+		"/debug/pprof/":        http.StatusNotFound,
+	}
+	for path, code := range cases {
+		t.Run(path, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", path, nil)
+			resp := httptest.NewRecorder()
+			a.srv.Handler.ServeHTTP(resp, req)
+			require.Equal(t, code, resp.Code)
+		})
+	}
+
+	// The profile only allows reads, even on the endpoints which also
+	// write, while the endpoint allowed explicitly takes all the methods.
+	for _, method := range []string{"PUT", "DELETE"} {
+		req, _ := http.NewRequest(method, "/v1/query/foo", nil)
+		resp := httptest.NewRecorder()
+		a.srv.Handler.ServeHTTP(resp, req)
+		require.Equal(t, http.StatusMethodNotAllowed, resp.Code)
+	}
+	req, _ := http.NewRequest("PUT", "/v1/kv/foo", strings.NewReader("bar"))
+	resp := httptest.NewRecorder()
+	a.srv.Handler.ServeHTTP(resp, req)
+	require.Equal(t, http.StatusOK, resp.Code)
+}
+
+func TestHTTPAPI_EndpointProfiles(t *testing.T) {
+	t.Parallel()
+
+	for name, entries := range config.HTTPEndpointProfiles {
+		for _, entry := range entries {
+			methods, pattern := config.ParseHTTPAllowedEndpoint(entry)
+			if endpoints[pattern] == nil {
+				t.Fatalf("profile %q allows unknown endpoint %q", name, pattern)
+			}
+			if _, ok := restrictMethods(allowedMethods[pattern], methods); !ok {
+				t.Fatalf("profile %q allows none of the methods of endpoint %q", name, pattern)
+			}
+		}
+	}
+}
+
+func TestRestrictMethods(t *testing.T) {
+	t.Parallel()
+
+	methods, ok := restrictMethods([]string{"GET", "PUT"}, nil)
+	require.True(t, ok)
+	require.Equal(t, []string{"GET", "PUT"}, methods)
+
+	methods, ok = restrictMethods([]string{"GET", "PUT"}, []string{"GET", "HEAD"})
+	require.True(t, ok)
+	require.Equal(t, []string{"GET"}, methods)
+
+	methods, ok = restrictMethods([]string{}, []string{"GET"})
+	require.True(t, ok)
+	require.Equal(t, []string{"GET"}, methods)
+
+	_, ok = restrictMethods([]string{"PUT"}, []string{"GET"})
+	require.False(t, ok)
+}
+
 func TestHTTPAPI_Ban_Nonprintable_Characters(t *testing.T) {
 	a := NewTestAgent(t, t.Name(), "")
 	defer a.Shutdown()
@@ -432,6 +533,84 @@ func TestHTTPAPIResponseHeaders(t *testing.T) {
 	}
 }
 
+func TestHTTPAPI_CORS(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		http_config {
+			cors_allowed_origins = ["https://tools.example.com"]
+			cors_allowed_headers = ["X-Request-ID"]
+			cors_allow_credentials = true
+			cors_max_age = "10m"
+		}
+	`)
+	defer a.Shutdown()
+
+	called := false
+	handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+
+	t.Run("allowed origin", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/agent/self", nil)
+		req.Header.Set("Origin", "https://tools.example.com")
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+
+		require.Equal(t, "https://tools.example.com", resp.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "true", resp.Header().Get("Access-Control-Allow-Credentials"))
+		require.Contains(t, resp.Header().Get("Access-Control-Expose-Headers"), "X-Consul-Index")
+		require.Equal(t, "Origin", resp.Header().Get("Vary"))
+	})
+
+	t.Run("other origin", func(t *testing.T) {
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/agent/self", nil)
+		req.Header.Set("Origin", "https://evil.example.com")
+		a.srv.wrap(handler, []string{"GET"})(resp, req)
+
+		require.Empty(t, resp.Header().Get("Access-Control-Allow-Origin"))
+		require.Empty(t, resp.Header().Get("Access-Control-Allow-Credentials"))
+		require.Equal(t, "Origin", resp.Header().Get("Vary"))
+	})
+
+	t.Run("preflight", func(t *testing.T) {
+		called = false
+		resp := httptest.NewRecorder()
+		req, _ := http.NewRequest("OPTIONS", "/v1/kv/foo", nil)
+		req.Header.Set("Origin", "https://tools.example.com")
+		req.Header.Set("Access-Control-Request-Method", "PUT")
+		a.srv.wrap(handler, nil)(resp, req)
+
+		require.False(t, called)
+		require.Equal(t, http.StatusOK, resp.Code)
+		require.Equal(t, "https://tools.example.com", resp.Header().Get("Access-Control-Allow-Origin"))
+		require.Equal(t, "PUT", resp.Header().Get("Access-Control-Allow-Methods"))
+		require.Equal(t, "Content-Type, X-Consul-Token, X-Request-ID", resp.Header().Get("Access-Control-Allow-Headers"))
+		require.Equal(t, "600", resp.Header().Get("Access-Control-Max-Age"))
+	})
+}
+
+func TestHTTPAPI_CORSDisabled(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		return nil, nil
+	}
+
+	resp := httptest.NewRecorder()
+	req, _ := http.NewRequest("OPTIONS", "/v1/agent/self", nil)
+	req.Header.Set("Origin", "https://tools.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	a.srv.wrap(handler, []string{"GET"})(resp, req)
+
+	require.Empty(t, resp.Header().Get("Access-Control-Allow-Origin"))
+	require.Empty(t, resp.Header().Get("Vary"))
+	require.Equal(t, "OPTIONS,GET", resp.Header().Get("Allow"))
+}
+
 func TestContentTypeIsJSON(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -0,0 +1,100 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// intentionObservationInterval is how often the connections authorized
+// without matching any intention are reported to the servers.
+const intentionObservationInterval = 30 * time.Second
+
+// intentionObservations aggregates the connections authorized by the agent
+// without matching any intention until they are reported to the servers.
+type intentionObservations struct {
+	l     sync.Mutex
+	pairs map[string]*structs.IntentionObservation
+}
+
+// observe records a connection from the source to the destination that was
+// allowed or denied by the default behavior.
+func (o *intentionObservations) observe(sourceNS, source, destinationNS, destination string, allowed bool) {
+	o.l.Lock()
+	defer o.l.Unlock()
+
+	if o.pairs == nil {
+		o.pairs = make(map[string]*structs.IntentionObservation)
+	}
+	key := sourceNS + "/" + source + "/" + destinationNS + "/" + destination
+	cur, ok := o.pairs[key]
+	if !ok {
+		cur = &structs.IntentionObservation{
+			SourceNS:        sourceNS,
+			SourceName:      source,
+			DestinationNS:   destinationNS,
+			DestinationName: destination,
+		}
+		o.pairs[key] = cur
+	}
+	if allowed {
+		cur.Allowed++
+	} else {
+		cur.Denied++
+	}
+	cur.LastSeen = time.Now().UTC()
+}
+
+// drain returns the observations recorded since the last drain.
+func (o *intentionObservations) drain() []*structs.IntentionObservation {
+	o.l.Lock()
+	defer o.l.Unlock()
+
+	out := make([]*structs.IntentionObservation, 0, len(o.pairs))
+	for _, cur := range o.pairs {
+		out = append(out, cur)
+	}
+	o.pairs = nil
+	return out
+}
+
+// sendIntentionObservations is a long running routine that periodically
+// reports the connections authorized without matching any intention to the
+// servers.
+func (a *Agent) sendIntentionObservations() {
+	for {
+		select {
+		case <-time.After(intentionObservationInterval):
+			a.flushIntentionObservations()
+		case <-a.shutdownCh:
+			return
+		}
+	}
+}
+
+// flushIntentionObservations reports the pending observations to the
+// servers. They are dropped if the report fails, since they are only used
+// to suggest intentions.
+func (a *Agent) flushIntentionObservations() {
+	observations := a.intentionObservations.drain()
+	if len(observations) == 0 {
+		return
+	}
+
+	req := structs.IntentionObservationRequest{
+		Datacenter:   a.config.Datacenter,
+		Node:         a.config.NodeName,
+		Observations: observations,
+		WriteRequest: structs.WriteRequest{Token: a.tokens.AgentToken()},
+	}
+	var reply struct{}
+	if err := a.RPC("Intention.Observe", &req, &reply); err != nil {
+		if acl.IsErrPermissionDenied(err) {
+			a.logger.Printf("[WARN] agent: Intention observations blocked by ACLs")
+		} else {
+			a.logger.Printf("[ERR] agent: Failed to report intention observations: %v", err)
+		}
+	}
+}
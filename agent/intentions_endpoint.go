@@ -103,6 +103,7 @@ func (s *HTTPServer) IntentionMatch(resp http.ResponseWriter, req *http.Request)
 	}
 
 	var reply structs.IndexedIntentionMatches
+	defer setMeta(resp, &reply.QueryMeta)
 	if err := s.agent.RPC("Intention.Match", args, &reply); err != nil {
 		return nil, err
 	}
@@ -174,6 +175,22 @@ func (s *HTTPServer) IntentionCheck(resp http.ResponseWriter, req *http.Request)
 	return &reply, nil
 }
 
+// GET /v1/connect/intentions/suggestions
+func (s *HTTPServer) IntentionSuggestions(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.DCSpecificRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.IndexedIntentionSuggestions
+	defer setMeta(resp, &reply.QueryMeta)
+	if err := s.agent.RPC("Intention.Suggestions", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return reply.Suggestions, nil
+}
+
 // IntentionSpecific handles the endpoint for /v1/connection/intentions/:id
 func (s *HTTPServer) IntentionSpecific(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	id := strings.TrimPrefix(req.URL.Path, "/v1/connect/intentions/")
@@ -205,6 +222,7 @@ func (s *HTTPServer) IntentionSpecificGet(id string, resp http.ResponseWriter, r
 	}
 
 	var reply structs.IndexedIntentions
+	defer setMeta(resp, &reply.QueryMeta)
 	if err := s.agent.RPC("Intention.Get", &args, &reply); err != nil {
 		// We have to check the string since the RPC sheds the error type
 		if err.Error() == consul.ErrIntentionNotFound.Error() {
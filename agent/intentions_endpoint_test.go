@@ -110,6 +110,8 @@ func TestIntentionsMatch_basic(t *testing.T) {
 	resp := httptest.NewRecorder()
 	obj, err := a.srv.IntentionMatch(resp, req)
 	assert.Nil(err)
+	assert.Equal("leader", resp.Header().Get("X-Consul-Effective-Consistency"))
+	assert.Equal("true", resp.Header().Get("X-Consul-KnownLeader"))
 
 	value := obj.(map[string]structs.Intentions)
 	assert.Len(value, 1)
@@ -345,6 +347,8 @@ func TestIntentionsSpecificGet_good(t *testing.T) {
 	resp := httptest.NewRecorder()
 	obj, err := a.srv.IntentionSpecific(resp, req)
 	assert.Nil(err)
+	assert.Equal("leader", resp.Header().Get("X-Consul-Effective-Consistency"))
+	assert.NotEmpty(resp.Header().Get("X-Consul-Index"))
 
 	value := obj.(*structs.Intention)
 	assert.Equal(reply, value.ID)
@@ -131,9 +131,10 @@ func ParseRelayFactor(n int) (uint8, error) {
 }
 
 // ListKeys lists out all keys installed on the collective Consul cluster. This
-// includes both servers and clients in all DC's.
-func (a *Agent) ListKeys(token string, relayFactor uint8) (*structs.KeyringResponses, error) {
-	args := structs.KeyringRequest{Operation: structs.KeyringList}
+// includes both servers and clients in all DC's, unless localOnly restricts it
+// to the LAN of the local DC.
+func (a *Agent) ListKeys(token string, localOnly bool, relayFactor uint8) (*structs.KeyringResponses, error) {
+	args := structs.KeyringRequest{Operation: structs.KeyringList, LocalOnly: localOnly}
 	parseKeyringRequest(&args, token, relayFactor)
 	return a.keyringProcess(&args)
 }
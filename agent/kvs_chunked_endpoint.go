@@ -0,0 +1,459 @@
+package agent
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/go-uuid"
+)
+
+const (
+	// maxKVChunkedSize is used to limit the maximum payload length of the
+	// values written with ?chunked, which are split into chunks of
+	// maxKVSize stored as separate keys.
+	maxKVChunkedSize = 64 * maxKVSize
+
+	// kvChunksDir is appended to a key to get the prefix of its chunks.
+	kvChunksDir = "/.chunks/"
+
+	// kvChunkManifestPrefix starts the value of the keys whose value is
+	// split into chunks, and is followed by the JSON encoded manifest.
+	kvChunkManifestPrefix = "consul-kv-chunked-v1\n"
+
+	// kvChunkedReadAttempts is the number of times a chunked value is read
+	// when its chunks are replaced by a concurrent write.
+	kvChunkedReadAttempts = 3
+
+	// kvChunkedWriteAttempts is the number of times a chunked value is
+	// written when the key is replaced by a concurrent write.
+	kvChunkedWriteAttempts = 3
+)
+
+// kvChunkKeyRe matches the chunks of a key, and the prefixes of their
+// generations returned by listings with a separator.
+var kvChunkKeyRe = regexp.MustCompile(`/\.chunks/([0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}/([0-9]{6})?)?$`)
+
+// kvChunkManifest describes the chunks of a value. The chunks are written
+// under a new generation on each write so the manifest always refers to a
+// complete set of chunks.
+type kvChunkManifest struct {
+	Generation string
+	Chunks     int
+	Size       int
+	SHA256     string
+}
+
+// decodeKVChunkManifest returns the manifest stored in the given value, or
+// nil if the value is a plain one.
+func decodeKVChunkManifest(value []byte) (*kvChunkManifest, error) {
+	if !bytes.HasPrefix(value, []byte(kvChunkManifestPrefix)) {
+		return nil, nil
+	}
+	var manifest kvChunkManifest
+	if err := json.Unmarshal(value[len(kvChunkManifestPrefix):], &manifest); err != nil {
+		return nil, fmt.Errorf("invalid chunk manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// kvChunkPrefix returns the prefix of the chunks of the given generation of
+// a key.
+func kvChunkPrefix(key, generation string) string {
+	return key + kvChunksDir + generation + "/"
+}
+
+// KVSGetChunked handles a GET request for a single key, reassembling the
+// value if it was split into chunks
+func (s *HTTPServer) KVSGetChunked(resp http.ResponseWriter, req *http.Request, args *structs.KeyRequest) (interface{}, error) {
+	params := req.URL.Query()
+	if _, ok := params["recurse"]; ok {
+		return nil, BadRequestError{Reason: "Chunked reads require a single key"}
+	}
+	if missingKey(resp, args) {
+		return nil, nil
+	}
+
+	var ent *structs.DirEntry
+	for attempt := 0; ent == nil; attempt++ {
+		// Only the first read blocks, the next ones follow a concurrent
+		// write of the key.
+		getArgs := *args
+		if attempt > 0 {
+			getArgs.MinQueryIndex = 0
+		}
+		var out structs.IndexedDirEntries
+		if err := s.agent.RPC("KVS.Get", &getArgs, &out); err != nil {
+			return nil, err
+		}
+		setMeta(resp, &out.QueryMeta)
+
+		// Check if we get a not found
+		if len(out.Entries) == 0 {
+			resp.WriteHeader(http.StatusNotFound)
+			return nil, nil
+		}
+
+		manifest, err := decodeKVChunkManifest(out.Entries[0].Value)
+		if err != nil {
+			return nil, err
+		}
+		if manifest == nil {
+			ent = out.Entries[0]
+			break
+		}
+
+		value, err := s.kvsReadChunks(args, manifest)
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			if attempt+1 >= kvChunkedReadAttempts {
+				return nil, fmt.Errorf("Chunks of key %q are incomplete", args.Key)
+			}
+			continue
+		}
+		ent = out.Entries[0].Clone()
+		ent.Value = value
+	}
+
+	// Check if we are in raw mode, write out the raw body
+	if _, ok := params["raw"]; ok {
+		resp.Header().Set("Content-Length", strconv.FormatInt(int64(len(ent.Value)), 10))
+		resp.Write(ent.Value)
+		return nil, nil
+	}
+	return structs.DirEntries{ent}, nil
+}
+
+// kvsReadChunks reads and reassembles the chunks of the given manifest. A
+// nil value is returned when the chunks don't match the manifest, which
+// happens when they were replaced since the manifest was read.
+func (s *HTTPServer) kvsReadChunks(args *structs.KeyRequest, manifest *kvChunkManifest) ([]byte, error) {
+	listArgs := *args
+	listArgs.Key = kvChunkPrefix(args.Key, manifest.Generation)
+	listArgs.MinQueryIndex = 0
+	var out structs.IndexedDirEntries
+	if err := s.agent.RPC("KVS.List", &listArgs, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Entries) != manifest.Chunks {
+		return nil, nil
+	}
+
+	// The chunks are listed in order since their index is zero padded.
+	value := make([]byte, 0, manifest.Size)
+	for _, chunk := range out.Entries {
+		value = append(value, chunk.Value...)
+	}
+	sum := sha256.Sum256(value)
+	if len(value) != manifest.Size || hex.EncodeToString(sum[:]) != manifest.SHA256 {
+		return nil, nil
+	}
+	return value, nil
+}
+
+// KVSPutChunked handles a PUT request, splitting values larger than
+// maxKVSize into chunks. Each chunk is written in its own request under a new
+// generation, so no raft entry is larger than a chunk, and none of them is
+// visible until the manifest refers to it. The manifest replacing the value of
+// the key and the deletion of the chunks it replaces are then applied in a
+// single transaction, with a check-and-set on the key so a concurrent write
+// can't orphan or delete the chunks in use. The chunks of a write which
+// doesn't commit are deleted
+func (s *HTTPServer) KVSPutChunked(resp http.ResponseWriter, req *http.Request, args *structs.KeyRequest) (interface{}, error) {
+	if missingKey(resp, args) {
+		return nil, nil
+	}
+	params := req.URL.Query()
+	for _, param := range []string{"acquire", "release", "ttl"} {
+		if _, ok := params[param]; ok {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Chunked writes don't support %q", param)}
+		}
+	}
+
+	dirEnt := structs.DirEntry{
+		Key: args.Key,
+	}
+
+	// Check for flags
+	if _, ok := params["flags"]; ok {
+		flagVal, err := strconv.ParseUint(params.Get("flags"), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		dirEnt.Flags = flagVal
+	}
+
+	// Check for cas value
+	var cas *uint64
+	if _, ok := params["cas"]; ok {
+		casVal, err := strconv.ParseUint(params.Get("cas"), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		cas = &casVal
+	}
+
+	// Check the content-length
+	if req.ContentLength > maxKVChunkedSize {
+		resp.WriteHeader(http.StatusRequestEntityTooLarge)
+		fmt.Fprintf(resp, "Value exceeds %d byte limit", maxKVChunkedSize)
+		return nil, nil
+	}
+
+	// Copy the value
+	buf := bytes.NewBuffer(nil)
+	if _, err := io.Copy(buf, io.LimitReader(req.Body, maxKVChunkedSize+1)); err != nil {
+		return nil, err
+	}
+	if buf.Len() > maxKVChunkedSize {
+		resp.WriteHeader(http.StatusRequestEntityTooLarge)
+		fmt.Fprintf(resp, "Value exceeds %d byte limit", maxKVChunkedSize)
+		return nil, nil
+	}
+	value := buf.Bytes()
+
+	// Split the value, values fitting in a single key are written as is.
+	if len(value) <= maxKVSize {
+		dirEnt.Value = value
+		return s.kvsCommitChunked(args, &dirEnt, cas)
+	}
+
+	generation, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	manifest := kvChunkManifest{
+		Generation: generation,
+		Chunks:     (len(value) + maxKVSize - 1) / maxKVSize,
+		Size:       len(value),
+	}
+	sum := sha256.Sum256(value)
+	manifest.SHA256 = hex.EncodeToString(sum[:])
+	encoded, err := json.Marshal(&manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.kvsWriteChunks(args, generation, value); err != nil {
+		s.kvsDeleteChunks(args, generation)
+		return nil, err
+	}
+	dirEnt.Value = append([]byte(kvChunkManifestPrefix), encoded...)
+	ok, err := s.kvsCommitChunked(args, &dirEnt, cas)
+	if err != nil || !ok {
+		s.kvsDeleteChunks(args, generation)
+	}
+	return ok, err
+}
+
+// kvsWriteChunks writes the chunks of the given value under the given
+// generation, one chunk per request.
+func (s *HTTPServer) kvsWriteChunks(args *structs.KeyRequest, generation string, value []byte) error {
+	prefix := kvChunkPrefix(args.Key, generation)
+	for i := 0; i < len(value); i += maxKVSize {
+		end := i + maxKVSize
+		if end > len(value) {
+			end = len(value)
+		}
+		applyReq := structs.KVSRequest{
+			Datacenter: args.Datacenter,
+			Op:         api.KVSet,
+			DirEnt: structs.DirEntry{
+				Key:   fmt.Sprintf("%s%06d", prefix, i/maxKVSize),
+				Value: value[i:end],
+			},
+		}
+		applyReq.Token = args.Token
+		var out bool
+		if err := s.agent.RPC("KVS.Apply", &applyReq, &out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kvsDeleteChunks deletes the chunks of the given generation of a key. This
+// is best effort, the chunks left behind are hidden from the listings and
+// deleted along with the key.
+func (s *HTTPServer) kvsDeleteChunks(args *structs.KeyRequest, generation string) {
+	applyReq := structs.KVSRequest{
+		Datacenter: args.Datacenter,
+		Op:         api.KVDeleteTree,
+		DirEnt: structs.DirEntry{
+			Key: kvChunkPrefix(args.Key, generation),
+		},
+	}
+	applyReq.Token = args.Token
+	var out bool
+	if err := s.agent.RPC("KVS.Apply", &applyReq, &out); err != nil {
+		s.agent.logger.Printf("[WARN] agent: failed to delete the chunks of key %q: %v", args.Key, err)
+	}
+}
+
+// kvsCommitChunked replaces the value of the key by the given entry, and
+// deletes the chunks of the value it replaces. False is returned if the
+// check-and-set on the key failed.
+func (s *HTTPServer) kvsCommitChunked(args *structs.KeyRequest, dirEnt *structs.DirEntry, cas *uint64) (bool, error) {
+	for attempt := 0; ; attempt++ {
+		// Look up the current manifest to delete its chunks along with
+		// the write.
+		getArgs := structs.KeyRequest{
+			Datacenter: args.Datacenter,
+			Key:        args.Key,
+		}
+		getArgs.Token = args.Token
+		var existing structs.IndexedDirEntries
+		if err := s.agent.RPC("KVS.Get", &getArgs, &existing); err != nil {
+			return false, err
+		}
+		var index uint64
+		var previous *kvChunkManifest
+		if len(existing.Entries) > 0 {
+			index = existing.Entries[0].ModifyIndex
+			// A corrupted manifest is simply replaced.
+			previous, _ = decodeKVChunkManifest(existing.Entries[0].Value)
+		}
+
+		// Without a cas value the write is checked against the value read
+		// above, and retried if it was replaced in the meantime.
+		setEnt := *dirEnt
+		setEnt.ModifyIndex = index
+		if cas != nil {
+			setEnt.ModifyIndex = *cas
+		}
+		ops := structs.TxnOps{
+			&structs.TxnOp{
+				KV: &structs.TxnKVOp{
+					Verb:   api.KVCAS,
+					DirEnt: setEnt,
+				},
+			},
+		}
+		if previous != nil && previous.Generation != "" {
+			ops = append(ops, &structs.TxnOp{
+				KV: &structs.TxnKVOp{
+					Verb: api.KVDeleteTree,
+					DirEnt: structs.DirEntry{
+						Key: kvChunkPrefix(args.Key, previous.Generation),
+					},
+				},
+			})
+		}
+
+		ok, err := s.kvsApplyTxn(args, ops, 0)
+		if err != nil || ok || cas != nil {
+			return ok, err
+		}
+		if attempt+1 >= kvChunkedWriteAttempts {
+			return false, fmt.Errorf("Key %q was modified concurrently", args.Key)
+		}
+	}
+}
+
+// kvsApplyTxn applies the given KV operations in a single transaction. False
+// is returned if the check-and-set operation at casIndex failed.
+func (s *HTTPServer) kvsApplyTxn(args *structs.KeyRequest, ops structs.TxnOps, casIndex int) (bool, error) {
+	txnReq := structs.TxnRequest{
+		Datacenter: args.Datacenter,
+		Ops:        ops,
+	}
+	txnReq.Token = args.Token
+	var out structs.TxnResponse
+	if err := s.agent.RPC("Txn.Apply", &txnReq, &out); err != nil {
+		return false, err
+	}
+	if len(out.Errors) == 0 {
+		return true, nil
+	}
+	for _, txnErr := range out.Errors {
+		if acl.IsErrPermissionDenied(errors.New(txnErr.What)) {
+			return false, acl.ErrPermissionDenied
+		}
+	}
+	if len(out.Errors) == 1 && out.Errors[0].OpIndex == casIndex {
+		return false, nil
+	}
+	return false, out.Error()
+}
+
+// KVSDeleteChunked handles a DELETE request for a single key, deleting its
+// chunks in the same transaction
+func (s *HTTPServer) KVSDeleteChunked(resp http.ResponseWriter, req *http.Request, args *structs.KeyRequest) (interface{}, error) {
+	params := req.URL.Query()
+	if _, ok := params["recurse"]; ok {
+		return nil, BadRequestError{Reason: "Chunked deletes require a single key, recursive deletes already remove the chunks"}
+	}
+	if missingKey(resp, args) {
+		return nil, nil
+	}
+
+	deleteOp := &structs.TxnKVOp{
+		Verb: api.KVDelete,
+		DirEnt: structs.DirEntry{
+			Key: args.Key,
+		},
+	}
+
+	// Check for cas value
+	if _, ok := params["cas"]; ok {
+		casVal, err := strconv.ParseUint(params.Get("cas"), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		deleteOp.DirEnt.ModifyIndex = casVal
+		deleteOp.Verb = api.KVDeleteCAS
+	}
+
+	ops := structs.TxnOps{
+		&structs.TxnOp{KV: deleteOp},
+		&structs.TxnOp{
+			KV: &structs.TxnKVOp{
+				Verb: api.KVDeleteTree,
+				DirEnt: structs.DirEntry{
+					Key: args.Key + kvChunksDir,
+				},
+			},
+		},
+	}
+	return s.kvsApplyTxn(args, ops, 0)
+}
+
+// isKVChunkKey returns whether the given key, or the prefix returned for it
+// by a listing with a separator, belongs to the chunks of a key.
+func isKVChunkKey(key string) bool {
+	return kvChunkKeyRe.MatchString(key)
+}
+
+// filterKVChunkEntries removes the chunks of the keys from the given entries.
+func filterKVChunkEntries(entries structs.DirEntries) structs.DirEntries {
+	out := entries[:0]
+	for _, ent := range entries {
+		if !isKVChunkKey(ent.Key) {
+			out = append(out, ent)
+		}
+	}
+	return out
+}
+
+// filterKVChunkKeys removes the chunks of the keys from the given keys.
+func filterKVChunkKeys(keys []string) []string {
+	out := keys[:0]
+	for _, key := range keys {
+		if !isKVChunkKey(key) {
+			out = append(out, key)
+		}
+	}
+	return out
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
@@ -36,16 +37,31 @@ func (s *HTTPServer) KVSEndpoint(resp http.ResponseWriter, req *http.Request) (i
 		keyList = true
 	}
 
+	// Check for a value split into chunks
+	_, chunked := params["chunked"]
+
 	// Switch on the method
 	switch req.Method {
 	case "GET":
 		if keyList {
 			return s.KVSGetKeys(resp, req, &args)
 		}
+		if _, ok := params["diff"]; ok {
+			return s.KVSGetDiff(resp, req, &args)
+		}
+		if chunked {
+			return s.KVSGetChunked(resp, req, &args)
+		}
 		return s.KVSGet(resp, req, &args)
 	case "PUT":
+		if chunked {
+			return s.KVSPutChunked(resp, req, &args)
+		}
 		return s.KVSPut(resp, req, &args)
 	case "DELETE":
+		if chunked {
+			return s.KVSDeleteChunked(resp, req, &args)
+		}
 		return s.KVSDelete(resp, req, &args)
 	default:
 		return nil, MethodNotAllowedError{req.Method, []string{"GET", "PUT", "DELETE"}}
@@ -63,6 +79,24 @@ func (s *HTTPServer) KVSGet(resp http.ResponseWriter, req *http.Request, args *s
 		return nil, nil
 	}
 
+	// Check for a filter, which is evaluated by the servers
+	if _, ok := params["filter"]; ok {
+		if method != "KVS.List" {
+			return nil, BadRequestError{Reason: "Filtering requires recurse"}
+		}
+		if err := parseFilter(req, &args.QueryOptions, structs.DirEntries(nil)); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := params["label-selector"]; ok {
+		if method != "KVS.List" {
+			return nil, BadRequestError{Reason: "Selecting labels requires recurse"}
+		}
+		if err := parseLabelSelector(req, &args.QueryOptions); err != nil {
+			return nil, err
+		}
+	}
+
 	// Make the RPC
 	var out structs.IndexedDirEntries
 	if err := s.agent.RPC(method, &args, &out); err != nil {
@@ -70,6 +104,11 @@ func (s *HTTPServer) KVSGet(resp http.ResponseWriter, req *http.Request, args *s
 	}
 	setMeta(resp, &out.QueryMeta)
 
+	// Hide the chunks of the values written with ?chunked
+	if method == "KVS.List" {
+		out.Entries = filterKVChunkEntries(out.Entries)
+	}
+
 	// Check if we get a not found
 	if len(out.Entries) == 0 {
 		resp.WriteHeader(http.StatusNotFound)
@@ -88,6 +127,26 @@ func (s *HTTPServer) KVSGet(resp http.ResponseWriter, req *http.Request, args *s
 	return out.Entries, nil
 }
 
+// KVSGetDiff handles a GET request for the changes under a prefix since the
+// index of the blocking query
+func (s *HTTPServer) KVSGetDiff(resp http.ResponseWriter, req *http.Request, args *structs.KeyRequest) (interface{}, error) {
+	// Make the RPC
+	var out structs.IndexedDirEntriesDiff
+	if err := s.agent.RPC("KVS.ListDiff", &args, &out); err != nil {
+		return nil, err
+	}
+	setMeta(resp, &out.QueryMeta)
+
+	// Use empty lists instead of null
+	if out.DeletedPrefixes == nil {
+		out.DeletedPrefixes = []string{}
+	}
+	if out.Entries == nil {
+		out.Entries = structs.DirEntries{}
+	}
+	return out.DirEntriesDiff, nil
+}
+
 // KVSGetKeys handles a GET request for keys
 func (s *HTTPServer) KVSGetKeys(resp http.ResponseWriter, req *http.Request, args *structs.KeyRequest) (interface{}, error) {
 	// Check for a separator, due to historic spelling error,
@@ -116,6 +175,9 @@ func (s *HTTPServer) KVSGetKeys(resp http.ResponseWriter, req *http.Request, arg
 	}
 	setMeta(resp, &out.QueryMeta)
 
+	// Hide the chunks of the values written with ?chunked
+	out.Keys = filterKVChunkKeys(out.Keys)
+
 	// Check if we get a not found. We do not generate
 	// not found for the root, but just provide the empty list
 	if len(out.Keys) == 0 && listArgs.Prefix != "" {
@@ -159,6 +221,24 @@ func (s *HTTPServer) KVSPut(resp http.ResponseWriter, req *http.Request, args *s
 		applyReq.DirEnt.Flags = flagVal
 	}
 
+	// Check for a TTL
+	if _, ok := params["ttl"]; ok {
+		ttl, err := time.ParseDuration(params.Get("ttl"))
+		if err != nil {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Invalid TTL: %v", err)}
+		}
+		applyReq.DirEnt.TTL = ttl.String()
+	}
+
+	// Check for labels
+	if _, ok := params["labels"]; ok {
+		labels, err := structs.ParseLabels(params.Get("labels"))
+		if err != nil {
+			return nil, BadRequestError{Reason: fmt.Sprintf("Invalid labels: %v", err)}
+		}
+		applyReq.DirEnt.Labels = labels
+	}
+
 	// Check for cas value
 	if _, ok := params["cas"]; ok {
 		casVal, err := strconv.ParseUint(params.Get("cas"), 10, 64)
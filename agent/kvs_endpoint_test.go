@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/hashicorp/consul/testrpc"
@@ -148,6 +150,364 @@ func TestKVSEndpoint_Recurse(t *testing.T) {
 	}
 }
 
+func TestKVSEndpoint_Recurse_Filter(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	for key, flags := range map[string]int{"bar": 1, "baz": 2, "foo/sub1": 2} {
+		buf := bytes.NewBuffer([]byte("test"))
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/v1/kv/%s?flags=%d", key, flags), buf)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	{
+		req, _ := http.NewRequest("GET", "/v1/kv/?recurse&filter="+url.QueryEscape(`Flags == 2 and Key not matches "/"`), nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		assertIndex(t, resp)
+
+		res := obj.(structs.DirEntries)
+		if len(res) != 1 || res[0].Key != "baz" {
+			t.Fatalf("bad: %v", res)
+		}
+	}
+
+	// Invalid filters and filters without recurse are rejected
+	for _, path := range []string{
+		"/v1/kv/?recurse&filter=" + url.QueryEscape(`Flags ==`),
+		"/v1/kv/?recurse&filter=" + url.QueryEscape(`Missing == 1`),
+		"/v1/kv/bar?filter=" + url.QueryEscape(`Flags == 1`),
+	} {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.KVSEndpoint(resp, req)
+		if _, ok := err.(BadRequestError); !ok {
+			t.Fatalf("%s: err: %v", path, err)
+		}
+	}
+}
+
+func TestKVSEndpoint_Diff(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	put := func(key string) {
+		buf := bytes.NewBuffer([]byte("test"))
+		req, _ := http.NewRequest("PUT", "/v1/kv/"+key, buf)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	get := func(url string) (structs.DirEntriesDiff, string) {
+		req, _ := http.NewRequest("GET", url, nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		assertIndex(t, resp)
+		return obj.(structs.DirEntriesDiff), resp.Header().Get("X-Consul-Index")
+	}
+
+	put("foo/a")
+	put("foo/b")
+	put("zip")
+
+	// The first query returns the whole prefix.
+	diff, index := get("/v1/kv/foo/?diff")
+	if !diff.Reset || len(diff.Entries) != 2 {
+		t.Fatalf("bad: %#v", diff)
+	}
+
+	// The next ones only return the changes.
+	put("foo/b")
+	req, _ := http.NewRequest("DELETE", "/v1/kv/foo/a", nil)
+	if _, err := a.srv.KVSEndpoint(httptest.NewRecorder(), req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	diff, _ = get("/v1/kv/foo/?diff&index=" + index)
+	if diff.Reset || len(diff.Entries) != 1 || diff.Entries[0].Key != "foo/b" ||
+		!reflect.DeepEqual(diff.DeletedPrefixes, []string{"foo/a"}) {
+		t.Fatalf("bad: %#v", diff)
+	}
+}
+
+func TestKVSEndpoint_PUT_TTL(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	{
+		buf := bytes.NewBuffer([]byte("test"))
+		req, _ := http.NewRequest("PUT", "/v1/kv/test?ttl=60s", buf)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if res := obj.(bool); !res {
+			t.Fatalf("should work")
+		}
+	}
+
+	{
+		req, _ := http.NewRequest("GET", "/v1/kv/test", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		res := obj.(structs.DirEntries)
+		if len(res) != 1 || res[0].TTL != "1m0s" {
+			t.Fatalf("bad: %v", res)
+		}
+	}
+
+	// Invalid TTLs are rejected
+	{
+		buf := bytes.NewBuffer([]byte("test"))
+		req, _ := http.NewRequest("PUT", "/v1/kv/test?ttl=foo", buf)
+		resp := httptest.NewRecorder()
+		_, err := a.srv.KVSEndpoint(resp, req)
+		if _, ok := err.(BadRequestError); !ok {
+			t.Fatalf("err: %v", err)
+		}
+	}
+}
+
+func TestKVSEndpoint_Labels(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	for key, labels := range map[string]string{
+		"test/key1": "team=payments,env=prod",
+		"test/key2": "team=payments,env=dev",
+		"test/key3": "",
+	} {
+		buf := bytes.NewBuffer([]byte("test"))
+		req, _ := http.NewRequest("PUT", "/v1/kv/"+key+"?labels="+url.QueryEscape(labels), buf)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if res := obj.(bool); !res {
+			t.Fatalf("should work")
+		}
+	}
+
+	{
+		req, _ := http.NewRequest("GET", "/v1/kv/test?recurse&label-selector="+url.QueryEscape("team=payments,env!=dev"), nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		res := obj.(structs.DirEntries)
+		if len(res) != 1 || res[0].Key != "test/key1" ||
+			!reflect.DeepEqual(res[0].Labels, map[string]string{"team": "payments", "env": "prod"}) {
+			t.Fatalf("bad: %v", res)
+		}
+	}
+
+	// Invalid labels and selectors are rejected, and selecting requires
+	// recurse.
+	for _, path := range []string{
+		"/v1/kv/test/key4?labels=team",
+		"/v1/kv/test?recurse&label-selector=team%3Dpay+ments",
+		"/v1/kv/test/key1?label-selector=team",
+	} {
+		method := "GET"
+		if strings.Contains(path, "labels=") {
+			method = "PUT"
+		}
+		req, _ := http.NewRequest(method, path, bytes.NewBuffer([]byte("test")))
+		resp := httptest.NewRecorder()
+		_, err := a.srv.KVSEndpoint(resp, req)
+		if _, ok := err.(BadRequestError); !ok {
+			t.Fatalf("%s: err: %v", path, err)
+		}
+	}
+}
+
+func TestKVSEndpoint_Chunked(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	put := func(path string, value []byte) interface{} {
+		req, _ := http.NewRequest("PUT", path, bytes.NewReader(value))
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return obj
+	}
+	get := func(path string) *structs.DirEntry {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if obj == nil {
+			return nil
+		}
+		return obj.(structs.DirEntries)[0]
+	}
+	chunks := func() []string {
+		args := structs.KeyListRequest{
+			Datacenter: "dc1",
+			Prefix:     "test/.chunks/",
+		}
+		var out structs.IndexedKeyList
+		if err := a.RPC("KVS.ListKeys", &args, &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return out.Keys
+	}
+
+	large := bytes.Repeat([]byte("0123456789abcdef"), (2*maxKVSize+100)/16)
+	if res := put("/v1/kv/test?chunked&flags=7", large); res != true {
+		t.Fatalf("bad: %v", res)
+	}
+	if keys := chunks(); len(keys) != 3 {
+		t.Fatalf("bad: %v", keys)
+	}
+
+	// The value is reassembled, and plain reads return the manifest
+	if ent := get("/v1/kv/test?chunked"); !bytes.Equal(ent.Value, large) || ent.Flags != 7 {
+		t.Fatalf("bad: %d %d", len(ent.Value), ent.Flags)
+	}
+	ent := get("/v1/kv/test")
+	if !bytes.HasPrefix(ent.Value, []byte(kvChunkManifestPrefix)) {
+		t.Fatalf("bad: %q", ent.Value)
+	}
+
+	// The chunks are hidden from the listings
+	{
+		req, _ := http.NewRequest("GET", "/v1/kv/?recurse", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if ents := obj.(structs.DirEntries); len(ents) != 1 || ents[0].Key != "test" {
+			t.Fatalf("bad: %v", ents)
+		}
+	}
+	for path, expected := range map[string][]string{
+		"/v1/kv/?keys":                          {"test"},
+		"/v1/kv/test/?keys&separator=/":         {},
+		"/v1/kv/test/.chunks/?keys&separator=/": {},
+	} {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		var keys []string
+		if obj != nil {
+			keys = obj.([]string)
+		}
+		if len(keys) != len(expected) || (len(keys) > 0 && keys[0] != expected[0]) {
+			t.Fatalf("%s: bad: %v", path, keys)
+		}
+	}
+
+	// Replacing the value deletes the previous chunks
+	if res := put("/v1/kv/test?chunked&flags=7", large); res != true {
+		t.Fatalf("bad: %v", res)
+	}
+	if keys := chunks(); len(keys) != 3 {
+		t.Fatalf("bad: %v", keys)
+	}
+	ent = get("/v1/kv/test")
+
+	// A cas of 0 only creates the key
+	if res := put("/v1/kv/test?chunked&cas=0", large); res != false {
+		t.Fatalf("bad: %v", res)
+	}
+
+	// A failed CAS doesn't leave chunks behind
+	if res := put(fmt.Sprintf("/v1/kv/test?chunked&cas=%d", ent.ModifyIndex-1), large); res != false {
+		t.Fatalf("bad: %v", res)
+	}
+	if keys := chunks(); len(keys) != 3 {
+		t.Fatalf("bad: %v", keys)
+	}
+
+	// Small values are written as is, replacing the chunks
+	if res := put(fmt.Sprintf("/v1/kv/test?chunked&cas=%d", ent.ModifyIndex), []byte("small")); res != true {
+		t.Fatalf("bad: %v", res)
+	}
+	if keys := chunks(); len(keys) != 0 {
+		t.Fatalf("bad: %v", keys)
+	}
+	for _, path := range []string{"/v1/kv/test", "/v1/kv/test?chunked"} {
+		if ent := get(path); string(ent.Value) != "small" {
+			t.Fatalf("bad: %q", ent.Value)
+		}
+	}
+
+	// Deleting the key deletes its chunks
+	put("/v1/kv/test?chunked", large)
+	{
+		req, _ := http.NewRequest("DELETE", "/v1/kv/test?chunked", nil)
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+	if keys := chunks(); len(keys) != 0 {
+		t.Fatalf("bad: %v", keys)
+	}
+	if ent := get("/v1/kv/test?chunked"); ent != nil {
+		t.Fatalf("bad: %v", ent)
+	}
+
+	// Values at the limit are written, each chunk in its own raft entry
+	max := bytes.Repeat([]byte("0123456789abcdef"), maxKVChunkedSize/16)
+	if res := put("/v1/kv/test?chunked", max); res != true {
+		t.Fatalf("bad: %v", res)
+	}
+	if keys := chunks(); len(keys) != maxKVChunkedSize/maxKVSize {
+		t.Fatalf("bad: %d", len(keys))
+	}
+	if ent := get("/v1/kv/test?chunked"); !bytes.Equal(ent.Value, max) {
+		t.Fatalf("bad: %d", len(ent.Value))
+	}
+
+	// Values above the limit are rejected
+	{
+		req, _ := http.NewRequest("PUT", "/v1/kv/test?chunked", bytes.NewReader(make([]byte, maxKVChunkedSize+1)))
+		resp := httptest.NewRecorder()
+		if _, err := a.srv.KVSEndpoint(resp, req); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Code != http.StatusRequestEntityTooLarge {
+			t.Fatalf("bad: %d", resp.Code)
+		}
+	}
+}
+
 func TestKVSEndpoint_DELETE_CAS(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
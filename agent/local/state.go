@@ -177,8 +177,11 @@ type State struct {
 	services map[string]*ServiceState
 
 	// Checks tracks the local checks. checkAliases are aliased checks.
-	checks       map[types.CheckID]*CheckState
-	checkAliases map[string]map[types.CheckID]chan<- struct{}
+	// checkComposites maps a check ID to the composite checks whose
+	// expression references it.
+	checks          map[types.CheckID]*CheckState
+	checkAliases    map[string]map[types.CheckID]chan<- struct{}
+	checkComposites map[types.CheckID]map[types.CheckID]chan<- struct{}
 
 	// metadata tracks the node metadata fields
 	metadata map[string]string
@@ -224,6 +227,7 @@ func NewState(c Config, lg *log.Logger, tokens *token.Store) *State {
 		services:             make(map[string]*ServiceState),
 		checks:               make(map[types.CheckID]*CheckState),
 		checkAliases:         make(map[string]map[types.CheckID]chan<- struct{}),
+		checkComposites:      make(map[types.CheckID]map[types.CheckID]chan<- struct{}),
 		metadata:             make(map[string]string),
 		tokens:               tokens,
 		notifyHandlers:       make(map[chan<- struct{}]struct{}),
@@ -495,6 +499,7 @@ func (l *State) addCheckLocked(check *structs.HealthCheck, token string) error {
 		Check: check,
 		Token: token,
 	})
+	l.notifyCompositeChecksLocked(check.CheckID)
 	return nil
 }
 
@@ -532,6 +537,55 @@ func (l *State) RemoveAliasCheck(checkID types.CheckID, srcServiceID string) {
 	}
 }
 
+// AddCompositeCheck registers a composite check. When any of the checks in
+// srcCheckIDs is added, removed or changes status, notifyCh is notified so
+// that checkID can re-evaluate its expression. The composite check is also
+// notified when checkID itself is added since it is usually started before
+// it is part of the local state.
+func (l *State) AddCompositeCheck(checkID types.CheckID, srcCheckIDs []types.CheckID, notifyCh chan<- struct{}) error {
+	l.Lock()
+	defer l.Unlock()
+
+	for _, srcID := range append([]types.CheckID{checkID}, srcCheckIDs...) {
+		m, ok := l.checkComposites[srcID]
+		if !ok {
+			m = make(map[types.CheckID]chan<- struct{})
+			l.checkComposites[srcID] = m
+		}
+		m[checkID] = notifyCh
+	}
+
+	return nil
+}
+
+// RemoveCompositeCheck removes the mappings for the composite check.
+func (l *State) RemoveCompositeCheck(checkID types.CheckID, srcCheckIDs []types.CheckID) {
+	l.Lock()
+	defer l.Unlock()
+
+	for _, srcID := range append([]types.CheckID{checkID}, srcCheckIDs...) {
+		if m, ok := l.checkComposites[srcID]; ok {
+			delete(m, checkID)
+			if len(m) == 0 {
+				delete(l.checkComposites, srcID)
+			}
+		}
+	}
+}
+
+// notifyCompositeChecksLocked notifies all composite checks that reference
+// the given check. This must be called with the lock held.
+func (l *State) notifyCompositeChecksLocked(id types.CheckID) {
+	for _, notifyCh := range l.checkComposites[id] {
+		// Do not block, see the comment on the alias notification in
+		// UpdateCheck for why this is safe.
+		select {
+		case notifyCh <- struct{}{}:
+		default:
+		}
+	}
+}
+
 // RemoveCheck is used to remove a health check from the local state.
 // The agent will make a best effort to ensure it is deregistered
 // todo(fs): RemoveService returns an error for a non-existent service. RemoveCheck should as well.
@@ -553,6 +607,7 @@ func (l *State) removeCheckLocked(id types.CheckID) error {
 	// entry around until it is actually removed.
 	c.InSync = false
 	c.Deleted = true
+	l.notifyCompositeChecksLocked(id)
 	l.TriggerSyncChanges()
 
 	return nil
@@ -630,9 +685,13 @@ func (l *State) UpdateCheck(id types.CheckID, status, output string) {
 	}
 
 	// Update status and mark out of sync
+	previousStatus := c.Check.Status
 	c.Check.Status = status
 	c.Check.Output = output
 	c.InSync = false
+	if status != previousStatus {
+		l.notifyCompositeChecksLocked(id)
+	}
 	l.TriggerSyncChanges()
 }
 
@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+)
+
+// memberTombstoneTTL is how long the removed members are kept so the clients
+// tracking the changes learn about the removal. The clients tracking the
+// changes since an index older than a reaped removal are reset.
+const memberTombstoneTTL = 72 * time.Hour
+
+// memberChange is the last known state of a member of a gossip pool along
+// with the change index at which it was last seen changing.
+type memberChange struct {
+	member serf.Member
+	index  uint64
+
+	// removed is when the member was seen removed from the pool.
+	removed time.Time
+}
+
+// memberPool is the tracked state of a gossip pool.
+type memberPool struct {
+	members map[string]*memberChange
+
+	// reset is the index before which the changes of the pool can't be
+	// computed anymore, since removed members were reaped after it.
+	reset uint64
+}
+
+// memberChanges tracks the changes of the members of the gossip pools so
+// that the members endpoint can return the members changed since a given
+// index instead of the whole pool, which is expensive for large pools.
+//
+// Serf doesn't expose the Lamport time of the members, so the changes are
+// detected by comparing the members with their previous state each time a
+// pool is listed, and the changes detected together share a new index from
+// a counter local to the agent. The indexes can't be compared across agents.
+// The counter starts from the current time so that the indexes returned
+// before a restart are older than the start index, which resets the clients
+// using them.
+type memberChanges struct {
+	l     sync.Mutex
+	start uint64
+	index uint64
+	pools map[string]*memberPool
+
+	// tombstoneTTL is how long the removed members are kept.
+	tombstoneTTL time.Duration
+}
+
+func newMemberChanges() *memberChanges {
+	start := uint64(time.Now().UnixNano())
+	return &memberChanges{
+		start:        start,
+		index:        start,
+		pools:        make(map[string]*memberPool),
+		tombstoneTTL: memberTombstoneTTL,
+	}
+}
+
+// update records the changes of the given members of the pool and returns
+// the current change index. Members missing from the list are kept as
+// removed members with a status of serf.StatusNone until they are reaped.
+func (c *memberChanges) update(pool string, members []serf.Member) uint64 {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	p, ok := c.pools[pool]
+	if !ok {
+		p = &memberPool{
+			members: make(map[string]*memberChange),
+			reset:   c.start,
+		}
+		c.pools[pool] = p
+	}
+
+	now := time.Now()
+	next := c.index + 1
+	changed := false
+	seen := make(map[string]struct{}, len(members))
+	for _, m := range members {
+		seen[m.Name] = struct{}{}
+		if prev, ok := p.members[m.Name]; ok && reflect.DeepEqual(prev.member, m) {
+			continue
+		}
+		p.members[m.Name] = &memberChange{member: m, index: next}
+		changed = true
+	}
+	for name, prev := range p.members {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		if prev.member.Status == serf.StatusNone {
+			// Reap the removed members once they expired.
+			if now.Sub(prev.removed) > c.tombstoneTTL {
+				delete(p.members, name)
+				if prev.index > p.reset {
+					p.reset = prev.index
+				}
+			}
+			continue
+		}
+		removed := prev.member
+		removed.Status = serf.StatusNone
+		p.members[name] = &memberChange{member: removed, index: next, removed: now}
+		changed = true
+	}
+
+	if changed {
+		c.index = next
+	}
+	return c.index
+}
+
+// since returns the members of the pool that changed after the given index,
+// including the removed ones. When the changes since the index can't be
+// computed, because the index was returned before a restart of the agent, by
+// another agent, or before removed members were reaped, all the current
+// members are returned instead and reset is true.
+func (c *memberChanges) since(pool string, index uint64) (members []serf.Member, reset bool) {
+	c.l.Lock()
+	defer c.l.Unlock()
+
+	p, ok := c.pools[pool]
+	if !ok {
+		return nil, true
+	}
+
+	reset = index < p.reset || index > c.index
+	for _, m := range p.members {
+		if reset {
+			if m.member.Status != serf.StatusNone {
+				members = append(members, m.member)
+			}
+			continue
+		}
+		if m.index > index {
+			members = append(members, m.member)
+		}
+	}
+	return members, reset
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/serf/serf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemberChanges(t *testing.T) {
+	t.Parallel()
+
+	names := func(members []serf.Member) []string {
+		var out []string
+		for _, m := range members {
+			out = append(out, m.Name)
+		}
+		sort.Strings(out)
+		return out
+	}
+
+	c := newMemberChanges()
+	a := serf.Member{Name: "a", Status: serf.StatusAlive}
+	b := serf.Member{Name: "b", Status: serf.StatusAlive}
+
+	since := func(index uint64) ([]string, bool) {
+		members, reset := c.since("lan:", index)
+		return names(members), reset
+	}
+
+	start := c.index
+	first := c.update("lan:", []serf.Member{a, b})
+	require.Equal(t, start+1, first)
+	got, reset := since(start)
+	require.Equal(t, []string{"a", "b"}, got)
+	require.False(t, reset)
+	got, reset = since(first)
+	require.Empty(t, got)
+	require.False(t, reset)
+
+	// Listing the same members doesn't bump the index.
+	require.Equal(t, first, c.update("lan:", []serf.Member{a, b}))
+
+	// Changed members are returned.
+	b.Status = serf.StatusFailed
+	second := c.update("lan:", []serf.Member{a, b})
+	require.Equal(t, first+1, second)
+	got, _ = since(first)
+	require.Equal(t, []string{"b"}, got)
+
+	// Removed members are returned with no status.
+	third := c.update("lan:", []serf.Member{a})
+	removed, reset := c.since("lan:", second)
+	require.False(t, reset)
+	require.Len(t, removed, 1)
+	require.Equal(t, "b", removed[0].Name)
+	require.Equal(t, serf.StatusNone, removed[0].Status)
+	require.Equal(t, third, c.update("lan:", []serf.Member{a}))
+
+	// Pools are tracked separately, unknown pools are reset.
+	members, reset := c.since("wan", 0)
+	require.Empty(t, members)
+	require.True(t, reset)
+
+	// Indexes from before a restart reset the members, without the removed
+	// ones.
+	restarted := newMemberChanges()
+	restarted.update("lan:", []serf.Member{a})
+	members, reset = restarted.since("lan:", third)
+	require.Equal(t, []string{"a"}, names(members))
+	require.True(t, reset)
+
+	// So do indexes ahead of the agent.
+	got, reset = since(third + 10)
+	require.Equal(t, []string{"a"}, got)
+	require.True(t, reset)
+
+	// The removed members are reaped once expired, which resets the
+	// indexes from before their removal.
+	c.tombstoneTTL = 0
+	time.Sleep(time.Millisecond)
+	require.Equal(t, third, c.update("lan:", []serf.Member{a}))
+	require.Len(t, c.pools["lan:"].members, 1)
+	got, reset = since(second)
+	require.Equal(t, []string{"a"}, got)
+	require.True(t, reset)
+	got, reset = since(third)
+	require.Empty(t, got)
+	require.False(t, reset)
+}
@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/consul/autopilot"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
@@ -73,6 +74,7 @@ type keyringArgs struct {
 	Key         string
 	Token       string
 	RelayFactor uint8
+	LocalOnly   bool // Only used for receiving keys from the local DC
 }
 
 // OperatorKeyringEndpoint handles keyring operations (install, list, use, remove)
@@ -104,6 +106,22 @@ func (s *HTTPServer) OperatorKeyringEndpoint(resp http.ResponseWriter, req *http
 		}
 	}
 
+	// Parse local-only. Only list queries are restricted to the local DC.
+	if localOnly := req.URL.Query().Get("local-only"); localOnly != "" {
+		var err error
+		args.LocalOnly, err = strconv.ParseBool(localOnly)
+		if err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Error parsing local-only: %v", err)
+			return nil, nil
+		}
+		if args.LocalOnly && req.Method != "GET" {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(resp, "local-only can only be used for list operations")
+			return nil, nil
+		}
+	}
+
 	// Switch on the method
 	switch req.Method {
 	case "GET":
@@ -131,7 +149,7 @@ func (s *HTTPServer) KeyringInstall(resp http.ResponseWriter, req *http.Request,
 
 // KeyringList is used to list the keys installed in the cluster
 func (s *HTTPServer) KeyringList(resp http.ResponseWriter, req *http.Request, args *keyringArgs) (interface{}, error) {
-	responses, err := s.agent.ListKeys(args.Token, args.RelayFactor)
+	responses, err := s.agent.ListKeys(args.Token, args.LocalOnly, args.RelayFactor)
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +194,28 @@ func keyringErrorsOrNil(responses []*structs.KeyringResponse) error {
 	return errs
 }
 
+// OperatorKeyringRotation is used to inspect the status of the automatic
+// rotation of the gossip encryption key, which is reported by the leader.
+func (s *HTTPServer) OperatorKeyringRotation(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.DCSpecificRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.KeyringRotationStatus
+	if err := s.agent.RPC("Operator.KeyringRotationStatus", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	return &api.KeyringRotationStatus{
+		Enabled:      reply.Enabled,
+		Interval:     api.NewReadableDuration(reply.Interval),
+		LastRotation: reply.LastRotation,
+		NextRotation: reply.NextRotation,
+		LastError:    reply.LastError,
+	}, nil
+}
+
 // OperatorAutopilotConfiguration is used to inspect the current Autopilot configuration.
 // This supports the stale query mode in case the cluster doesn't have a leader.
 func (s *HTTPServer) OperatorAutopilotConfiguration(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
@@ -298,3 +338,91 @@ func (s *HTTPServer) OperatorServerHealth(resp http.ResponseWriter, req *http.Re
 
 	return out, nil
 }
+
+// OperatorGCConfiguration is used to inspect and update the garbage collector
+// tuning of the agent. The changes only apply to the agent serving the request
+// and are lost when it restarts.
+func (s *HTTPServer) OperatorGCConfiguration(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var token string
+	s.parseToken(req, &token)
+	rule, err := s.agent.resolveToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	switch req.Method {
+	case "GET":
+		if rule != nil && !rule.OperatorRead() {
+			return nil, acl.ErrPermissionDenied
+		}
+		return s.agent.gcTuner.Config(), nil
+
+	case "PUT":
+		if rule != nil && !rule.OperatorWrite() {
+			return nil, acl.ErrPermissionDenied
+		}
+
+		var conf api.GCConfiguration
+		if err := decodeBody(req, &conf, nil); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Error parsing GC config: %v", err)
+			return nil, nil
+		}
+		if err := s.agent.gcTuner.Apply(conf); err != nil {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(resp, err.Error())
+			return nil, nil
+		}
+		s.agent.logger.Printf("[INFO] agent: Updated the GC tuning: percent=%d ballast=%d memory_limit=%d",
+			conf.Percent, conf.BallastBytes, conf.MemoryLimitBytes)
+		return true, nil
+
+	default:
+		return nil, MethodNotAllowedError{req.Method, []string{"GET", "PUT"}}
+	}
+}
+
+// OperatorUsage returns the number of entries of each kind in the state of
+// the datacenter, or of all the known datacenters with ?global. Blocking
+// queries only apply to the datacenter of the request.
+func (s *HTTPServer) OperatorUsage(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.DCSpecificRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.UsageResponse
+	defer setMeta(resp, &reply.QueryMeta)
+	if err := s.agent.RPC("Operator.Usage", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	if _, ok := req.URL.Query()["global"]; ok {
+		var dcs []string
+		if err := s.agent.RPC("Catalog.ListDatacenters", struct{}{}, &dcs); err != nil {
+			return nil, err
+		}
+		for _, dc := range dcs {
+			if _, ok := reply.Usage[dc]; ok {
+				continue
+			}
+			remote := structs.DCSpecificRequest{
+				Datacenter: dc,
+				QueryOptions: structs.QueryOptions{
+					Token:             args.Token,
+					AllowStale:        args.AllowStale,
+					RequireConsistent: args.RequireConsistent,
+				},
+			}
+			var remoteReply structs.UsageResponse
+			if err := s.agent.RPC("Operator.Usage", &remote, &remoteReply); err != nil {
+				return nil, fmt.Errorf("failed to query the usage of datacenter %q: %v", dc, err)
+			}
+			for name, usage := range remoteReply.Usage {
+				reply.Usage[name] = usage
+			}
+		}
+	}
+
+	return reply.Usage, nil
+}
@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"runtime/debug"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/testrpc"
 
+	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/consul/autopilot"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
@@ -94,7 +97,7 @@ func TestOperator_KeyringInstall(t *testing.T) {
 		t.Fatalf("err: %s", err)
 	}
 
-	listResponse, err := a.ListKeys("", 0)
+	listResponse, err := a.ListKeys("", false, 0)
 	if err != nil {
 		t.Fatalf("err: %s", err)
 	}
@@ -176,7 +179,7 @@ func TestOperator_KeyringRemove(t *testing.T) {
 	}
 
 	// Make sure the temp key is installed
-	list, err := a.ListKeys("", 0)
+	list, err := a.ListKeys("", false, 0)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -201,7 +204,7 @@ func TestOperator_KeyringRemove(t *testing.T) {
 	}
 
 	// Make sure the temp key has been removed
-	list, err = a.ListKeys("", 0)
+	list, err = a.ListKeys("", false, 0)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -245,7 +248,7 @@ func TestOperator_KeyringUse(t *testing.T) {
 	}
 
 	// Make sure only the new key remains
-	list, err := a.ListKeys("", 0)
+	list, err := a.ListKeys("", false, 0)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -289,6 +292,72 @@ func TestOperator_Keyring_InvalidRelayFactor(t *testing.T) {
 	}
 }
 
+func TestOperator_Keyring_LocalOnly(t *testing.T) {
+	t.Parallel()
+	key := "H3/9gBxcKKRf45CaI2DlRg=="
+	a := NewTestAgent(t, t.Name(), `
+		encrypt = "`+key+`"
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Only the LAN keyring is listed.
+	req, _ := http.NewRequest("GET", "/v1/operator/keyring?local-only=true", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.OperatorKeyringEndpoint(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	responses, ok := obj.([]*structs.KeyringResponse)
+	if !ok {
+		t.Fatalf("unexpected: %T", obj)
+	}
+	if len(responses) != 1 || responses[0].WAN {
+		t.Fatalf("bad: %v", responses)
+	}
+	if _, ok := responses[0].Keys[key]; !ok {
+		t.Fatalf("bad: %v", responses[0].Keys)
+	}
+
+	// It's only supported for list operations.
+	body := bytes.NewBufferString(fmt.Sprintf("{\"Key\":\"%s\"}", key))
+	req, _ = http.NewRequest("PUT", "/v1/operator/keyring?local-only=true", body)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.OperatorKeyringEndpoint(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != http.StatusBadRequest {
+		t.Fatalf("bad: %d", resp.Code)
+	}
+}
+
+func TestOperator_KeyringRotation(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		encrypt = "H3/9gBxcKKRf45CaI2DlRg=="
+		encrypt_rotation_interval = "1h"
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/operator/keyring/rotation", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.OperatorKeyringRotation(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	status, ok := obj.(*api.KeyringRotationStatus)
+	if !ok {
+		t.Fatalf("unexpected: %T", obj)
+	}
+	if !status.Enabled || status.Interval.Duration() != time.Hour {
+		t.Fatalf("bad: %#v", status)
+	}
+	if status.NextRotation.IsZero() || !status.LastRotation.IsZero() {
+		t.Fatalf("bad: %#v", status)
+	}
+}
+
 func TestOperator_AutopilotGetConfiguration(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -474,3 +543,111 @@ func TestOperator_ServerHealth_Unhealthy(t *testing.T) {
 		}
 	})
 }
+
+func TestOperator_GCConfiguration(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), `
+		performance {
+			gc_percent = 150
+		}
+	`)
+	defer a.Shutdown()
+
+	// Restore the runtime defaults for the other tests.
+	defer a.gcTuner.Apply(api.GCConfiguration{})
+
+	get := func() api.GCConfiguration {
+		req, _ := http.NewRequest("GET", "/v1/operator/gc/configuration", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.OperatorGCConfiguration(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		return obj.(api.GCConfiguration)
+	}
+
+	// The configured tuning is applied on start.
+	if out := get(); out.Percent != 150 {
+		t.Fatalf("bad: %#v", out)
+	}
+	if percent := debug.SetGCPercent(150); percent != 150 {
+		t.Fatalf("bad: %d", percent)
+	}
+
+	body := bytes.NewBuffer([]byte(`{"Percent": -1, "BallastBytes": 1024, "MemoryLimitBytes": 1099511627776}`))
+	req, _ := http.NewRequest("PUT", "/v1/operator/gc/configuration", body)
+	resp := httptest.NewRecorder()
+	if _, err := a.srv.OperatorGCConfiguration(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 200 {
+		t.Fatalf("bad code: %d %s", resp.Code, resp.Body.String())
+	}
+
+	expected := api.GCConfiguration{
+		Percent:          -1,
+		BallastBytes:     1024,
+		MemoryLimitBytes: 1 << 40,
+	}
+	if out := get(); out != expected {
+		t.Fatalf("bad: %#v", out)
+	}
+	if len(a.gcTuner.ballast) != 1024 {
+		t.Fatalf("bad: %d", len(a.gcTuner.ballast))
+	}
+
+	// Invalid tunings are rejected.
+	body = bytes.NewBuffer([]byte(`{"Percent": -1}`))
+	req, _ = http.NewRequest("PUT", "/v1/operator/gc/configuration", body)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.OperatorGCConfiguration(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 400 || !strings.Contains(resp.Body.String(), "memory limit") {
+		t.Fatalf("bad: %d %s", resp.Code, resp.Body.String())
+	}
+	if out := get(); out != expected {
+		t.Fatalf("bad: %#v", out)
+	}
+}
+
+func TestOperator_GCConfiguration_ACLDeny(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), TestACLConfig())
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	body := bytes.NewBuffer([]byte(`{"Percent": 200}`))
+	req, _ := http.NewRequest("PUT", "/v1/operator/gc/configuration", body)
+	resp := httptest.NewRecorder()
+	if _, err := a.srv.OperatorGCConfiguration(resp, req); !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+func TestOperator_Usage(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	for _, path := range []string{"/v1/operator/usage", "/v1/operator/usage?global"} {
+		req, _ := http.NewRequest("GET", path, nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.OperatorUsage(resp, req)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if resp.Header().Get("X-Consul-Index") == "" {
+			t.Fatalf("missing index")
+		}
+		out, ok := obj.(map[string]structs.Usage)
+		if !ok {
+			t.Fatalf("unexpected: %T", obj)
+		}
+		usage, ok := out["dc1"]
+		if len(out) != 1 || !ok || usage.Nodes != 1 || usage.Services != 1 {
+			t.Fatalf("bad: %v", out)
+		}
+	}
+}
@@ -0,0 +1,16 @@
+// +build !ent
+
+package agent
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// OperatorLicense manages the license of Consul Enterprise. The open source
+// agents respond with 501 so that tooling can tell them apart.
+func (s *HTTPServer) OperatorLicense(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	resp.WriteHeader(http.StatusNotImplemented)
+	fmt.Fprint(resp, "Licenses are only supported by Consul Enterprise")
+	return nil, nil
+}
@@ -0,0 +1,24 @@
+// +build !ent
+
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOperator_License(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+
+	for _, method := range []string{"GET", "PUT", "DELETE"} {
+		req, _ := http.NewRequest(method, "/v1/operator/license", nil)
+		resp := httptest.NewRecorder()
+		a.srv.Handler.ServeHTTP(resp, req)
+		if resp.Code != http.StatusNotImplemented {
+			t.Fatalf("%s: bad code: %d", method, resp.Code)
+		}
+	}
+}
@@ -12,4 +12,6 @@ const (
 	RPCMultiplexV2         = 4
 	RPCSnapshot            = 5
 	RPCGossip              = 6
+	RPCGRPC                = 7
+	RPCTLSInsecure         = 8
 )
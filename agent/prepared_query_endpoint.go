@@ -63,6 +63,26 @@ RETRY_ONCE:
 	return reply.Queries, nil
 }
 
+// PreparedQueryStats returns the execution stats of all the prepared queries.
+func (s *HTTPServer) PreparedQueryStats(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args structs.DCSpecificRequest
+	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
+		return nil, nil
+	}
+
+	var reply structs.PreparedQueryStatsResponse
+	defer setMeta(resp, &reply.QueryMeta)
+	if err := s.agent.RPC("PreparedQuery.Stats", &args, &reply); err != nil {
+		return nil, err
+	}
+
+	// Use empty list instead of nil.
+	if reply.Stats == nil {
+		reply.Stats = make([]*structs.PreparedQueryStats, 0)
+	}
+	return reply.Stats, nil
+}
+
 // PreparedQueryGeneral handles all the general prepared query requests.
 func (s *HTTPServer) PreparedQueryGeneral(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
 	switch req.Method {
@@ -31,6 +31,7 @@ type MockPreparedQuery struct {
 	listFn    func(*structs.DCSpecificRequest, *structs.IndexedPreparedQueries) error
 	executeFn func(*structs.PreparedQueryExecuteRequest, *structs.PreparedQueryExecuteResponse) error
 	explainFn func(*structs.PreparedQueryExecuteRequest, *structs.PreparedQueryExplainResponse) error
+	statsFn   func(*structs.DCSpecificRequest, *structs.PreparedQueryStatsResponse) error
 }
 
 func (m *MockPreparedQuery) Apply(args *structs.PreparedQueryRequest,
@@ -73,6 +74,14 @@ func (m *MockPreparedQuery) Explain(args *structs.PreparedQueryExecuteRequest,
 	return fmt.Errorf("should not have called Explain")
 }
 
+func (m *MockPreparedQuery) Stats(args *structs.DCSpecificRequest,
+	reply *structs.PreparedQueryStatsResponse) error {
+	if m.statsFn != nil {
+		return m.statsFn(args, reply)
+	}
+	return fmt.Errorf("should not have called Stats")
+}
+
 func TestPreparedQuery_Create(t *testing.T) {
 	t.Parallel()
 	a := NewTestAgent(t, t.Name(), "")
@@ -957,6 +966,67 @@ func TestPreparedQuery_Delete(t *testing.T) {
 	}
 }
 
+func TestPreparedQuery_Stats(t *testing.T) {
+	t.Parallel()
+	t.Run("", func(t *testing.T) {
+		a := NewTestAgent(t, t.Name(), "")
+		defer a.Shutdown()
+
+		m := MockPreparedQuery{
+			statsFn: func(args *structs.DCSpecificRequest, reply *structs.PreparedQueryStatsResponse) error {
+				// Return an empty response.
+				return nil
+			},
+		}
+		require.NoError(t, a.registerEndpoint("PreparedQuery", &m))
+
+		req, _ := http.NewRequest("GET", "/v1/query/stats", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.PreparedQueryStats(resp, req)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.Code)
+		r, ok := obj.([]*structs.PreparedQueryStats)
+		require.True(t, ok, "unexpected: %T", obj)
+		require.NotNil(t, r)
+		require.Len(t, r, 0)
+	})
+
+	t.Run("", func(t *testing.T) {
+		a := NewTestAgent(t, t.Name(), "")
+		defer a.Shutdown()
+
+		m := MockPreparedQuery{
+			statsFn: func(args *structs.DCSpecificRequest, reply *structs.PreparedQueryStatsResponse) error {
+				expected := &structs.DCSpecificRequest{
+					Datacenter: "dc1",
+					QueryOptions: structs.QueryOptions{
+						Token: "my-token",
+					},
+				}
+				require.Equal(t, expected, args)
+
+				reply.Stats = append(reply.Stats, &structs.PreparedQueryStats{
+					ID:         "my-id",
+					Executions: 3,
+				})
+				return nil
+			},
+		}
+		require.NoError(t, a.registerEndpoint("PreparedQuery", &m))
+
+		req, _ := http.NewRequest("GET", "/v1/query/stats?token=my-token", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.PreparedQueryStats(resp, req)
+		require.NoError(t, err)
+		require.Equal(t, 200, resp.Code)
+		r, ok := obj.([]*structs.PreparedQueryStats)
+		require.True(t, ok, "unexpected: %T", obj)
+		require.Len(t, r, 1)
+		require.Equal(t, "my-id", r[0].ID)
+		require.Equal(t, uint64(3), r[0].Executions)
+	})
+}
+
 func TestPreparedQuery_parseLimit(t *testing.T) {
 	t.Parallel()
 	body := bytes.NewBuffer(nil)
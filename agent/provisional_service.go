@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+)
+
+const (
+	// provisionalServiceInterval is how often the provisional services are
+	// checked against their deadline.
+	provisionalServiceInterval = time.Second
+
+	// provisionalRollbacksMax is the number of rolled back registrations
+	// kept to be reported by the HTTP API.
+	provisionalRollbacksMax = 64
+)
+
+// provisionalServices tracks the services registered with a deadline for
+// their checks to pass. A service whose checks all pass before the deadline
+// is confirmed and no longer tracked, otherwise it's deregistered along with
+// its sidecar and the rollback is kept to be reported.
+type provisionalServices struct {
+	l         sync.Mutex
+	pending   map[string]*api.AgentProvisionalService
+	rollbacks []*api.AgentProvisionalService
+}
+
+// add starts tracking the given service registration.
+func (p *provisionalServices) add(svc *structs.NodeService, sidecarID string, deadline time.Time) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if p.pending == nil {
+		p.pending = make(map[string]*api.AgentProvisionalService)
+	}
+	p.pending[svc.ID] = &api.AgentProvisionalService{
+		ServiceID:   svc.ID,
+		ServiceName: svc.Service,
+		SidecarID:   sidecarID,
+		Deadline:    deadline,
+		Status:      api.ProvisionalServicePending,
+	}
+}
+
+// confirm stops tracking the given service.
+func (p *provisionalServices) confirm(serviceID string) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	delete(p.pending, serviceID)
+}
+
+// rollback moves the given service to the rolled back registrations.
+func (p *provisionalServices) rollback(serviceID, output string) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	svc, ok := p.pending[serviceID]
+	if !ok {
+		return
+	}
+	delete(p.pending, serviceID)
+
+	svc.Status = api.ProvisionalServiceRolledBack
+	svc.Output = output
+	p.rollbacks = append(p.rollbacks, svc)
+	if len(p.rollbacks) > provisionalRollbacksMax {
+		p.rollbacks = p.rollbacks[len(p.rollbacks)-provisionalRollbacksMax:]
+	}
+}
+
+// list returns copies of the pending and rolled back registrations, ordered
+// by deadline.
+func (p *provisionalServices) list() []*api.AgentProvisionalService {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	out := make([]*api.AgentProvisionalService, 0, len(p.pending)+len(p.rollbacks))
+	for _, svc := range p.pending {
+		cp := *svc
+		out = append(out, &cp)
+	}
+	for _, svc := range p.rollbacks {
+		cp := *svc
+		out = append(out, &cp)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Deadline.Before(out[j].Deadline)
+	})
+	return out
+}
+
+// pendingServices returns copies of the registrations still waiting for
+// their checks to pass, by service ID.
+func (p *provisionalServices) pendingServices() map[string]api.AgentProvisionalService {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	out := make(map[string]api.AgentProvisionalService, len(p.pending))
+	for id, svc := range p.pending {
+		out[id] = *svc
+	}
+	return out
+}
+
+// watchProvisionalServices is a long running routine that confirms or rolls
+// back the provisional services.
+func (a *Agent) watchProvisionalServices() {
+	for {
+		select {
+		case <-time.After(provisionalServiceInterval):
+			a.checkProvisionalServices(time.Now())
+		case <-a.shutdownCh:
+			return
+		}
+	}
+}
+
+// checkProvisionalServices confirms the provisional services whose checks
+// all pass, and deregisters the ones whose deadline has passed.
+func (a *Agent) checkProvisionalServices(now time.Time) {
+	pending := a.provisionalServices.pendingServices()
+	if len(pending) == 0 {
+		return
+	}
+
+	checks := a.State.Checks()
+	for id, svc := range pending {
+		// The service was deregistered in the meantime.
+		if a.State.Service(id) == nil {
+			a.provisionalServices.confirm(id)
+			continue
+		}
+
+		var failing []string
+		for _, chk := range checks {
+			if chk.ServiceID != id && (svc.SidecarID == "" || chk.ServiceID != svc.SidecarID) {
+				continue
+			}
+			if chk.Status != api.HealthPassing {
+				failing = append(failing, fmt.Sprintf("%q is %s", chk.CheckID, chk.Status))
+			}
+		}
+
+		if len(failing) == 0 {
+			a.provisionalServices.confirm(id)
+			a.logger.Printf("[INFO] agent: Provisional service %q confirmed, all its checks are passing", id)
+			continue
+		}
+		if now.Before(svc.Deadline) {
+			continue
+		}
+
+		sort.Strings(failing)
+		output := "Checks not passing before the deadline: " + strings.Join(failing, ", ")
+		if err := a.removeServiceWithToken(id); err != nil {
+			a.logger.Printf("[ERR] agent: Unable to roll back provisional service %q: %v", id, err)
+			continue
+		}
+		if svc.SidecarID != "" {
+			if err := a.RemoveService(svc.SidecarID, true); err != nil {
+				a.logger.Printf("[ERR] agent: Unable to roll back the sidecar %q of provisional service %q: %v",
+					svc.SidecarID, id, err)
+			}
+		}
+		a.provisionalServices.rollback(id, output)
+		a.logger.Printf("[WARN] agent: Provisional service %q rolled back: %s", id, output)
+	}
+}
@@ -27,7 +27,8 @@ type CancelFunc func()
 // proxy configuration state. This should not be confused with the deprecated
 // "managed proxy" concept where the agent supervises the actual proxy process.
 // proxycfg.Manager is oblivious to the distinction and manages state for any
-// service registered with Kind == connect-proxy.
+// service registered with Kind == connect-proxy, mesh-gateway,
+// ingress-gateway or terminating-gateway.
 //
 // The Manager ensures that any Connect proxy registered on the agent has all
 // the state it needs cached locally via the agent cache. State includes
@@ -131,7 +132,10 @@ func (m *Manager) syncState() {
 	// Traverse the local state and ensure all proxy services are registered
 	services := m.State.Services()
 	for svcID, svc := range services {
-		if svc.Kind != structs.ServiceKindConnectProxy {
+		switch svc.Kind {
+		case structs.ServiceKindConnectProxy, structs.ServiceKindMeshGateway,
+			structs.ServiceKindIngressGateway, structs.ServiceKindTerminatingGateway:
+		default:
 			continue
 		}
 		// TODO(banks): need to work out when to default some stuff. For example
@@ -55,6 +55,10 @@ func TestManager_BasicLifecycle(t *testing.T) {
 		&structs.IndexedCheckServiceNodes{
 			Nodes: TestUpstreamNodes(t),
 		})
+	types.chain.value.Store(
+		&structs.DiscoveryChainResponse{
+			Chain: TestDiscoveryChain(t, "db", "tcp"),
+		})
 
 	logger := log.New(os.Stderr, "", log.LstdFlags)
 	state := local.NewState(local.Config{}, logger, &token.Store{})
@@ -106,15 +110,23 @@ func TestManager_BasicLifecycle(t *testing.T) {
 	// We should see the initial config delivered but not until after the
 	// coalesce timeout
 	expectSnap := &ConfigSnapshot{
-		ProxyID: webProxy.ID,
-		Address: webProxy.Address,
-		Port:    webProxy.Port,
-		Proxy:   webProxy.Proxy,
-		Roots:   roots,
-		Leaf:    leaf,
+		Kind:       structs.ServiceKindConnectProxy,
+		Service:    webProxy.Service,
+		ProxyID:    webProxy.ID,
+		Address:    webProxy.Address,
+		Port:       webProxy.Port,
+		Proxy:      webProxy.Proxy,
+		Datacenter: "dc1",
+		Roots:      roots,
+		Leaf:       leaf,
 		UpstreamEndpoints: map[string]structs.CheckServiceNodes{
 			"service:db": TestUpstreamNodes(t),
 		},
+		DiscoveryChain: map[string]*structs.CompiledDiscoveryChain{
+			"service:db": TestDiscoveryChain(t, "db", "tcp"),
+		},
+		MeshGatewayEndpoints: map[string]structs.CheckServiceNodes{},
+		ServiceGroups:        map[string]structs.CheckServiceNodes{},
 	}
 	start := time.Now()
 	assertWatchChanRecvs(t, wCh, expectSnap)
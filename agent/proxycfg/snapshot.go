@@ -1,6 +1,8 @@
 package proxycfg
 
 import (
+	"sort"
+
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/mitchellh/copystructure"
 )
@@ -9,22 +11,136 @@ import (
 // It is meant to be point-in-time coherent and is used to deliver the current
 // config state to observers who need it to be pushed in (e.g. XDS server).
 type ConfigSnapshot struct {
+	Kind              structs.ServiceKind
+	Service           string
 	ProxyID           string
 	Address           string
 	Port              int
+	TaggedAddresses   map[string]structs.ServiceAddress
 	Proxy             structs.ConnectProxyConfig
+	Datacenter        string
 	Roots             *structs.IndexedCARoots
 	Leaf              *structs.IssuedCert
 	UpstreamEndpoints map[string]structs.CheckServiceNodes
 
+	// DiscoveryChain holds the compiled discovery chains of the service
+	// upstreams, by upstream identifier. A chain may be missing, such as when
+	// the servers don't support compiling them, in which case the upstream is
+	// proxied at L4.
+	DiscoveryChain map[string]*structs.CompiledDiscoveryChain
+
+	// MeshGatewayEndpoints are the mesh gateways by datacenter. A proxy
+	// watches the gateways of the local datacenter and of the datacenters of
+	// its upstreams, a mesh gateway the gateways of the other datacenters.
+	MeshGatewayEndpoints map[string]structs.CheckServiceNodes
+
+	// ServiceGroups are the instances of services by service name. A mesh
+	// gateway watches the Connect capable instances of the services of the
+	// local datacenter, a terminating gateway the instances of its linked
+	// services.
+	ServiceGroups map[string]structs.CheckServiceNodes
+
+	// ServiceLeaves are the leaf certificates of the linked services of a
+	// terminating gateway by service name, which it terminates the Connect
+	// TLS connections for them with.
+	ServiceLeaves map[string]*structs.IssuedCert
+
+	// TerminatingGateway is the config entry of a terminating gateway, nil
+	// until it is written.
+	TerminatingGateway *structs.TerminatingGatewayConfigEntry
+
+	// IngressGateway is the config entry of an ingress gateway, nil until it
+	// is written. The endpoints and discovery chains of the services of its
+	// listeners are stored like the ones of the upstreams of a proxy, see
+	// IngressUpstreams.
+	IngressGateway *structs.IngressGatewayConfigEntry
+
 	// Skip intentions for now as we don't push those down yet, just pre-warm them.
 }
 
 // Valid returns whether or not the snapshot has all required fields filled yet.
 func (s *ConfigSnapshot) Valid() bool {
+	// Mesh gateways don't terminate the TLS connections so they don't need a
+	// leaf certificate, the roots are only needed for the trust domain.
+	// The terminating gateways have a leaf certificate for each linked
+	// service, the ones not issued yet are skipped.
+	if s.Kind == structs.ServiceKindMeshGateway || s.Kind == structs.ServiceKindTerminatingGateway {
+		return s.Roots != nil
+	}
 	return s.Roots != nil && s.Leaf != nil
 }
 
+// IngressUpstreams returns the services of the listeners of an ingress
+// gateway as upstreams, sorted by name. A service exposed on more than one
+// listener is only returned once, bound to the port of the first one.
+func (s *ConfigSnapshot) IngressUpstreams() []structs.Upstream {
+	if s.IngressGateway == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var upstreams []structs.Upstream
+	for _, l := range s.IngressGateway.Listeners {
+		for _, svc := range l.Services {
+			if seen[svc.Name] {
+				continue
+			}
+			seen[svc.Name] = true
+			upstreams = append(upstreams, structs.Upstream{
+				DestinationType: structs.UpstreamDestTypeService,
+				DestinationName: svc.Name,
+				LocalBindPort:   l.Port,
+			})
+		}
+	}
+	sort.Slice(upstreams, func(i, j int) bool {
+		return upstreams[i].DestinationName < upstreams[j].DestinationName
+	})
+	return upstreams
+}
+
+// UpstreamMeshGatewayMode returns the mesh gateway mode of the upstream: the
+// one it sets, else the one of the proxy, else the one set by the config
+// entries of its service.
+func (s *ConfigSnapshot) UpstreamMeshGatewayMode(u *structs.Upstream) structs.MeshGatewayMode {
+	if !u.MeshGateway.IsZero() {
+		return u.MeshGateway.Mode
+	}
+	if !s.Proxy.MeshGateway.IsZero() {
+		return s.Proxy.MeshGateway.Mode
+	}
+	chain := s.DiscoveryChain[u.Identifier()]
+	if chain == nil {
+		return structs.MeshGatewayModeDefault
+	}
+	if node := chain.Nodes[chain.StartNode]; node != nil && node.Resolver != nil {
+		if target := chain.Targets[node.Resolver.Target]; target != nil {
+			return target.MeshGateway.Mode
+		}
+	}
+	return structs.MeshGatewayModeDefault
+}
+
+// UpstreamMeshGateways returns the mesh gateways the connections to the
+// upstream are routed through, and whether they are. Only the upstream
+// services of other datacenters are routed through the gateways, unless
+// their mode is none.
+func (s *ConfigSnapshot) UpstreamMeshGateways(u *structs.Upstream) (structs.CheckServiceNodes, bool) {
+	if u.DestinationType != "" && u.DestinationType != structs.UpstreamDestTypeService {
+		return nil, false
+	}
+	if u.Datacenter == "" || u.Datacenter == s.Datacenter {
+		return nil, false
+	}
+
+	switch s.UpstreamMeshGatewayMode(u) {
+	case structs.MeshGatewayModeLocal:
+		return s.MeshGatewayEndpoints[s.Datacenter], true
+	case structs.MeshGatewayModeRemote:
+		return s.MeshGatewayEndpoints[u.Datacenter], true
+	}
+	return nil, false
+}
+
 // Clone makes a deep copy of the snapshot we can send to other goroutines
 // without worrying that they will racily read or mutate shared maps etc.
 func (s *ConfigSnapshot) Clone() (*ConfigSnapshot, error) {
@@ -22,12 +22,22 @@ const (
 	intentionsWatchID                = "intentions"
 	serviceIDPrefix                  = string(structs.UpstreamDestTypeService) + ":"
 	preparedQueryIDPrefix            = string(structs.UpstreamDestTypePreparedQuery) + ":"
+	discoveryChainIDPrefix           = "discovery-chain:"
+	meshGatewayIDPrefix              = "mesh-gateway:"
+	connectServiceIDPrefix           = "connect-service:"
+	servicesListWatchID              = "services-list"
+	datacentersWatchID               = "datacenters"
+	ingressGatewayConfigWatchID      = "ingress-gateway-config"
+	terminatingGatewayConfigWatchID  = "terminating-gateway-config"
+	linkedServiceIDPrefix            = "linked-service:"
+	serviceLeafIDPrefix              = "service-leaf:"
 	defaultPreparedQueryPollInterval = 30 * time.Second
+	defaultDatacentersPollInterval   = 30 * time.Second
 )
 
 // state holds all the state needed to maintain the config for a registered
-// connect-proxy service. When a proxy registration is changed, the entire state
-// is discarded and a new one created.
+// connect-proxy or gateway service. When a proxy registration is changed,
+// the entire state is discarded and a new one created.
 type state struct {
 	// logger, source and cache are required to be set before calling Watch.
 	logger *log.Logger
@@ -38,11 +48,23 @@ type state struct {
 	ctx    context.Context
 	cancel func()
 
-	proxyID  string
-	address  string
-	port     int
-	proxyCfg structs.ConnectProxyConfig
-	token    string
+	kind            structs.ServiceKind
+	service         string
+	proxyID         string
+	address         string
+	port            int
+	taggedAddresses map[string]structs.ServiceAddress
+	proxyCfg        structs.ConnectProxyConfig
+	token           string
+
+	// watchedServices and watchedDatacenters hold the cancel functions of
+	// the watches a mesh gateway starts and stops as the services and
+	// datacenters come and go. An ingress gateway uses watchedServices for
+	// the services of its listeners, by upstream identifier, and a
+	// terminating gateway for its linked services. They are only used by the
+	// run goroutine.
+	watchedServices    map[string]context.CancelFunc
+	watchedDatacenters map[string]context.CancelFunc
 
 	ch     chan cache.UpdateEvent
 	snapCh chan ConfigSnapshot
@@ -57,8 +79,11 @@ type state struct {
 // The returned state needs it's required dependencies to be set before Watch
 // can be called.
 func newState(ns *structs.NodeService, token string) (*state, error) {
-	if ns.Kind != structs.ServiceKindConnectProxy {
-		return nil, errors.New("not a connect-proxy")
+	switch ns.Kind {
+	case structs.ServiceKindConnectProxy, structs.ServiceKindMeshGateway,
+		structs.ServiceKindIngressGateway, structs.ServiceKindTerminatingGateway:
+	default:
+		return nil, errors.New("not a connect-proxy nor a gateway")
 	}
 
 	// Copy the config map
@@ -71,12 +96,21 @@ func newState(ns *structs.NodeService, token string) (*state, error) {
 		return nil, errors.New("failed to copy proxy config")
 	}
 
+	taggedAddressesRaw, err := copystructure.Copy(ns.TaggedAddresses)
+	if err != nil {
+		return nil, err
+	}
+	taggedAddresses, _ := taggedAddressesRaw.(map[string]structs.ServiceAddress)
+
 	return &state{
-		proxyID:  ns.ID,
-		address:  ns.Address,
-		port:     ns.Port,
-		proxyCfg: proxyCfg,
-		token:    token,
+		kind:            ns.Kind,
+		service:         ns.Service,
+		proxyID:         ns.ID,
+		address:         ns.Address,
+		port:            ns.Port,
+		taggedAddresses: taggedAddresses,
+		proxyCfg:        proxyCfg,
+		token:           token,
 		// 10 is fairly arbitrary here but allow for the 3 mandatory and a
 		// reasonable number of upstream watches to all deliver their initial
 		// messages in parallel without blocking the cache.Notify loops. It's not a
@@ -119,6 +153,15 @@ func (s *state) Close() error {
 // initWatches sets up the watches needed based on current proxy registration
 // state.
 func (s *state) initWatches() error {
+	switch s.kind {
+	case structs.ServiceKindMeshGateway:
+		return s.initWatchesMeshGateway()
+	case structs.ServiceKindIngressGateway:
+		return s.initWatchesIngressGateway()
+	case structs.ServiceKindTerminatingGateway:
+		return s.initWatchesTerminatingGateway()
+	}
+
 	// Watch for root changes
 	err := s.cache.Notify(s.ctx, cachetype.ConnectCARootName, &structs.DCSpecificRequest{
 		Datacenter:   s.source.Datacenter,
@@ -185,13 +228,159 @@ func (s *state) initWatches() error {
 				return err
 			}
 
+			// Watch the discovery chain of the service, compiled as if the
+			// upstream was requested from its datacenter.
+			err = s.cache.Notify(s.ctx, cachetype.CompiledDiscoveryChainName, &structs.DiscoveryChainRequest{
+				Datacenter:           s.source.Datacenter,
+				QueryOptions:         structs.QueryOptions{Token: s.token},
+				Name:                 u.DestinationName,
+				EvaluateInDatacenter: dc,
+			}, discoveryChainIDPrefix+u.Identifier(), s.ch)
+
+			if err != nil {
+				return err
+			}
+
 		default:
 			return fmt.Errorf("unknown upstream type: %q", u.DestinationType)
 		}
 	}
+
+	// Watch the mesh gateways the upstream services of other datacenters may
+	// be reached through. Their mode may be set by the config entries, only
+	// known once their discovery chain is compiled, so the gateways of both
+	// the local and the upstream datacenters are watched.
+	gatewayDCs := make(map[string]bool)
+	for _, u := range s.proxyCfg.Upstreams {
+		if u.DestinationType != "" && u.DestinationType != structs.UpstreamDestTypeService {
+			continue
+		}
+		if u.Datacenter == "" || u.Datacenter == s.source.Datacenter {
+			continue
+		}
+		gatewayDCs[s.source.Datacenter] = true
+		gatewayDCs[u.Datacenter] = true
+	}
+	for dc := range gatewayDCs {
+		if err := s.watchMeshGateways(s.ctx, dc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// initWatchesMeshGateway sets up the watches of a mesh gateway: the services
+// of the local datacenter and the other datacenters. The watches of their
+// instances and gateways are started as they are discovered.
+func (s *state) initWatchesMeshGateway() error {
+	// Watch for root changes
+	err := s.cache.Notify(s.ctx, cachetype.ConnectCARootName, &structs.DCSpecificRequest{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+	}, rootsWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+
+	// Watch the list of services
+	err = s.cache.Notify(s.ctx, cachetype.CatalogListServicesName, &structs.DCSpecificRequest{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+	}, servicesListWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+
+	// Poll the list of datacenters, which doesn't support blocking
+	err = s.cache.Notify(s.ctx, cachetype.CatalogDatacentersName, &structs.DCSpecificRequest{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token, MaxAge: defaultDatacentersPollInterval},
+	}, datacentersWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+
+	s.watchedServices = make(map[string]context.CancelFunc)
+	s.watchedDatacenters = make(map[string]context.CancelFunc)
+	return nil
+}
+
+// initWatchesIngressGateway sets up the watches of an ingress gateway: its
+// leaf certificate to connect to the services, and its config entry. The
+// watches of the services of its listeners are started as they are added.
+func (s *state) initWatchesIngressGateway() error {
+	// Watch for root changes
+	err := s.cache.Notify(s.ctx, cachetype.ConnectCARootName, &structs.DCSpecificRequest{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+	}, rootsWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+
+	// Watch the leaf cert of the gateway service
+	err = s.cache.Notify(s.ctx, cachetype.ConnectCALeafName, &cachetype.ConnectCALeafRequest{
+		Datacenter: s.source.Datacenter,
+		Token:      s.token,
+		Service:    s.service,
+	}, leafWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+
+	// Watch the config entry of the gateway
+	err = s.cache.Notify(s.ctx, cachetype.ConfigEntryName, &structs.ConfigEntryQuery{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+		Kind:         structs.IngressGateway,
+		Name:         s.service,
+	}, ingressGatewayConfigWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+
+	s.watchedServices = make(map[string]context.CancelFunc)
+	return nil
+}
+
+// initWatchesTerminatingGateway sets up the watches of a terminating gateway:
+// its config entry. The watches of the instances and leaf certificates of
+// its linked services are started as they are linked.
+func (s *state) initWatchesTerminatingGateway() error {
+	// Watch for root changes
+	err := s.cache.Notify(s.ctx, cachetype.ConnectCARootName, &structs.DCSpecificRequest{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+	}, rootsWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+
+	// Watch the config entry of the gateway
+	err = s.cache.Notify(s.ctx, cachetype.ConfigEntryName, &structs.ConfigEntryQuery{
+		Datacenter:   s.source.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+		Kind:         structs.TerminatingGateway,
+		Name:         s.service,
+	}, terminatingGatewayConfigWatchID, s.ch)
+	if err != nil {
+		return err
+	}
+
+	s.watchedServices = make(map[string]context.CancelFunc)
 	return nil
 }
 
+// watchMeshGateways watches the mesh gateways of the given datacenter.
+func (s *state) watchMeshGateways(ctx context.Context, dc string) error {
+	return s.cache.Notify(ctx, cachetype.InternalServiceDumpName, &structs.ServiceDumpRequest{
+		Datacenter:   dc,
+		QueryOptions: structs.QueryOptions{Token: s.token},
+		ServiceKind:  structs.ServiceKindMeshGateway,
+		Source:       *s.source,
+	}, meshGatewayIDPrefix+dc, s.ch)
+}
+
 func (s *state) run() {
 	// Close the channel we return from Watch when we stop so consumers can stop
 	// watching and clean up their goroutines. It's important we do this here and
@@ -200,11 +389,23 @@ func (s *state) run() {
 	defer close(s.snapCh)
 
 	snap := ConfigSnapshot{
-		ProxyID:           s.proxyID,
-		Address:           s.address,
-		Port:              s.port,
-		Proxy:             s.proxyCfg,
-		UpstreamEndpoints: make(map[string]structs.CheckServiceNodes),
+		Kind:                 s.kind,
+		Service:              s.service,
+		ProxyID:              s.proxyID,
+		Address:              s.address,
+		Port:                 s.port,
+		TaggedAddresses:      s.taggedAddresses,
+		Proxy:                s.proxyCfg,
+		Datacenter:           s.source.Datacenter,
+		UpstreamEndpoints:    make(map[string]structs.CheckServiceNodes),
+		DiscoveryChain:       make(map[string]*structs.CompiledDiscoveryChain),
+		MeshGatewayEndpoints: make(map[string]structs.CheckServiceNodes),
+		ServiceGroups:        make(map[string]structs.CheckServiceNodes),
+	}
+	// Only terminating gateways present the certificates of the services
+	// they are linked to.
+	if s.kind == structs.ServiceKindTerminatingGateway {
+		snap.ServiceLeaves = make(map[string]*structs.IssuedCert)
 	}
 	// This turns out to be really fiddly/painful by just using time.Timer.C
 	// directly in the code below since you can't detect when a timer is stopped
@@ -294,6 +495,46 @@ func (s *state) handleUpdate(u cache.UpdateEvent, snap *ConfigSnapshot) error {
 		snap.Leaf = leaf
 	case intentionsWatchID:
 		// Not in snapshot currently, no op
+	case servicesListWatchID:
+		services, ok := u.Result.(*structs.IndexedServices)
+		if !ok {
+			return fmt.Errorf("invalid type for services response: %T", u.Result)
+		}
+		return s.handleServicesList(services, snap)
+	case datacentersWatchID:
+		dcs, ok := u.Result.(*[]string)
+		if !ok {
+			return fmt.Errorf("invalid type for datacenters response: %T", u.Result)
+		}
+		return s.handleDatacenters(*dcs, snap)
+	case ingressGatewayConfigWatchID:
+		resp, ok := u.Result.(*structs.ConfigEntryResponse)
+		if !ok {
+			return fmt.Errorf("invalid type for config entry response: %T", u.Result)
+		}
+		snap.IngressGateway = nil
+		if resp.Entry != nil {
+			entry, ok := resp.Entry.(*structs.IngressGatewayConfigEntry)
+			if !ok {
+				return fmt.Errorf("invalid type for ingress gateway config entry: %T", resp.Entry)
+			}
+			snap.IngressGateway = entry
+		}
+		return s.handleIngressServices(snap)
+	case terminatingGatewayConfigWatchID:
+		resp, ok := u.Result.(*structs.ConfigEntryResponse)
+		if !ok {
+			return fmt.Errorf("invalid type for config entry response: %T", u.Result)
+		}
+		snap.TerminatingGateway = nil
+		if resp.Entry != nil {
+			entry, ok := resp.Entry.(*structs.TerminatingGatewayConfigEntry)
+			if !ok {
+				return fmt.Errorf("invalid type for terminating gateway config entry: %T", resp.Entry)
+			}
+			snap.TerminatingGateway = entry
+		}
+		return s.handleLinkedServices(snap)
 	default:
 		// Service discovery result, figure out which type
 		switch {
@@ -302,6 +543,10 @@ func (s *state) handleUpdate(u cache.UpdateEvent, snap *ConfigSnapshot) error {
 			if !ok {
 				return fmt.Errorf("invalid type for service response: %T", u.Result)
 			}
+			// Ignore the late updates of a service no longer watched.
+			if _, ok := s.watchedServices[u.CorrelationID]; s.kind == structs.ServiceKindIngressGateway && !ok {
+				return nil
+			}
 			snap.UpstreamEndpoints[u.CorrelationID] = resp.Nodes
 
 		case strings.HasPrefix(u.CorrelationID, preparedQueryIDPrefix):
@@ -311,6 +556,64 @@ func (s *state) handleUpdate(u cache.UpdateEvent, snap *ConfigSnapshot) error {
 			}
 			snap.UpstreamEndpoints[u.CorrelationID] = resp.Nodes
 
+		case strings.HasPrefix(u.CorrelationID, discoveryChainIDPrefix):
+			resp, ok := u.Result.(*structs.DiscoveryChainResponse)
+			if !ok {
+				return fmt.Errorf("invalid type for discovery chain response: %T", u.Result)
+			}
+			id := strings.TrimPrefix(u.CorrelationID, discoveryChainIDPrefix)
+			if _, ok := s.watchedServices[id]; s.kind == structs.ServiceKindIngressGateway && !ok {
+				return nil
+			}
+			snap.DiscoveryChain[id] = resp.Chain
+
+		case strings.HasPrefix(u.CorrelationID, meshGatewayIDPrefix):
+			resp, ok := u.Result.(*structs.IndexedCheckServiceNodes)
+			if !ok {
+				return fmt.Errorf("invalid type for mesh gateways response: %T", u.Result)
+			}
+			dc := strings.TrimPrefix(u.CorrelationID, meshGatewayIDPrefix)
+			// Ignore the late updates of a datacenter no longer watched.
+			if _, ok := s.watchedDatacenters[dc]; s.kind == structs.ServiceKindMeshGateway && !ok {
+				return nil
+			}
+			snap.MeshGatewayEndpoints[dc] = resp.Nodes
+
+		case strings.HasPrefix(u.CorrelationID, connectServiceIDPrefix):
+			resp, ok := u.Result.(*structs.IndexedCheckServiceNodes)
+			if !ok {
+				return fmt.Errorf("invalid type for service response: %T", u.Result)
+			}
+			name := strings.TrimPrefix(u.CorrelationID, connectServiceIDPrefix)
+			// Ignore the late updates of a service no longer watched.
+			if _, ok := s.watchedServices[name]; !ok {
+				return nil
+			}
+			snap.ServiceGroups[name] = resp.Nodes
+
+		case strings.HasPrefix(u.CorrelationID, linkedServiceIDPrefix):
+			resp, ok := u.Result.(*structs.IndexedCheckServiceNodes)
+			if !ok {
+				return fmt.Errorf("invalid type for service response: %T", u.Result)
+			}
+			name := strings.TrimPrefix(u.CorrelationID, linkedServiceIDPrefix)
+			// Ignore the late updates of a service no longer linked.
+			if _, ok := s.watchedServices[name]; !ok {
+				return nil
+			}
+			snap.ServiceGroups[name] = resp.Nodes
+
+		case strings.HasPrefix(u.CorrelationID, serviceLeafIDPrefix):
+			leaf, ok := u.Result.(*structs.IssuedCert)
+			if !ok {
+				return fmt.Errorf("invalid type for leaf response: %T", u.Result)
+			}
+			name := strings.TrimPrefix(u.CorrelationID, serviceLeafIDPrefix)
+			if _, ok := s.watchedServices[name]; !ok {
+				return nil
+			}
+			snap.ServiceLeaves[name] = leaf
+
 		default:
 			return errors.New("unknown correlation ID")
 		}
@@ -318,6 +621,161 @@ func (s *state) handleUpdate(u cache.UpdateEvent, snap *ConfigSnapshot) error {
 	return nil
 }
 
+// handleServicesList starts watching the Connect capable instances of the
+// new services of a mesh gateway and stops watching the removed ones.
+func (s *state) handleServicesList(services *structs.IndexedServices, snap *ConfigSnapshot) error {
+	for name := range services.Services {
+		if _, ok := s.watchedServices[name]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(s.ctx)
+		err := s.cache.Notify(ctx, cachetype.HealthServicesName, &structs.ServiceSpecificRequest{
+			Datacenter:   s.source.Datacenter,
+			QueryOptions: structs.QueryOptions{Token: s.token},
+			ServiceName:  name,
+			Connect:      true,
+		}, connectServiceIDPrefix+name, s.ch)
+		if err != nil {
+			cancel()
+			return err
+		}
+		s.watchedServices[name] = cancel
+	}
+
+	for name, cancel := range s.watchedServices {
+		if _, ok := services.Services[name]; !ok {
+			cancel()
+			delete(s.watchedServices, name)
+			delete(snap.ServiceGroups, name)
+		}
+	}
+	return nil
+}
+
+// handleIngressServices starts watching the instances and discovery chains
+// of the services added to the listeners of an ingress gateway and stops
+// watching the removed ones.
+func (s *state) handleIngressServices(snap *ConfigSnapshot) error {
+	known := make(map[string]bool)
+	for _, u := range snap.IngressUpstreams() {
+		id := u.Identifier()
+		known[id] = true
+		if _, ok := s.watchedServices[id]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(s.ctx)
+		err := s.cache.Notify(ctx, cachetype.HealthServicesName, &structs.ServiceSpecificRequest{
+			Datacenter:   s.source.Datacenter,
+			QueryOptions: structs.QueryOptions{Token: s.token},
+			ServiceName:  u.DestinationName,
+			Connect:      true,
+		}, id, s.ch)
+		if err != nil {
+			cancel()
+			return err
+		}
+		err = s.cache.Notify(ctx, cachetype.CompiledDiscoveryChainName, &structs.DiscoveryChainRequest{
+			Datacenter:           s.source.Datacenter,
+			QueryOptions:         structs.QueryOptions{Token: s.token},
+			Name:                 u.DestinationName,
+			EvaluateInDatacenter: s.source.Datacenter,
+		}, discoveryChainIDPrefix+id, s.ch)
+		if err != nil {
+			cancel()
+			return err
+		}
+		s.watchedServices[id] = cancel
+	}
+
+	for id, cancel := range s.watchedServices {
+		if !known[id] {
+			cancel()
+			delete(s.watchedServices, id)
+			delete(snap.UpstreamEndpoints, id)
+			delete(snap.DiscoveryChain, id)
+		}
+	}
+	return nil
+}
+
+// handleLinkedServices starts watching the instances and leaf certificates
+// of the services linked to a terminating gateway and stops watching the
+// unlinked ones.
+func (s *state) handleLinkedServices(snap *ConfigSnapshot) error {
+	linked := make(map[string]bool)
+	if snap.TerminatingGateway != nil {
+		for _, svc := range snap.TerminatingGateway.Services {
+			linked[svc.Name] = true
+		}
+	}
+
+	for name := range linked {
+		if _, ok := s.watchedServices[name]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(s.ctx)
+		err := s.cache.Notify(ctx, cachetype.HealthServicesName, &structs.ServiceSpecificRequest{
+			Datacenter:   s.source.Datacenter,
+			QueryOptions: structs.QueryOptions{Token: s.token},
+			ServiceName:  name,
+		}, linkedServiceIDPrefix+name, s.ch)
+		if err != nil {
+			cancel()
+			return err
+		}
+		err = s.cache.Notify(ctx, cachetype.ConnectCALeafName, &cachetype.ConnectCALeafRequest{
+			Datacenter: s.source.Datacenter,
+			Token:      s.token,
+			Service:    name,
+		}, serviceLeafIDPrefix+name, s.ch)
+		if err != nil {
+			cancel()
+			return err
+		}
+		s.watchedServices[name] = cancel
+	}
+
+	for name, cancel := range s.watchedServices {
+		if !linked[name] {
+			cancel()
+			delete(s.watchedServices, name)
+			delete(snap.ServiceGroups, name)
+			delete(snap.ServiceLeaves, name)
+		}
+	}
+	return nil
+}
+
+// handleDatacenters starts watching the mesh gateways of the new
+// datacenters and stops watching the removed ones.
+func (s *state) handleDatacenters(dcs []string, snap *ConfigSnapshot) error {
+	known := make(map[string]bool, len(dcs))
+	for _, dc := range dcs {
+		if dc == s.source.Datacenter {
+			continue
+		}
+		known[dc] = true
+		if _, ok := s.watchedDatacenters[dc]; ok {
+			continue
+		}
+		ctx, cancel := context.WithCancel(s.ctx)
+		if err := s.watchMeshGateways(ctx, dc); err != nil {
+			cancel()
+			return err
+		}
+		s.watchedDatacenters[dc] = cancel
+	}
+
+	for dc, cancel := range s.watchedDatacenters {
+		if !known[dc] {
+			cancel()
+			delete(s.watchedDatacenters, dc)
+			delete(snap.MeshGatewayEndpoints, dc)
+		}
+	}
+	return nil
+}
+
 // CurrentSnapshot synchronously returns the current ConfigSnapshot if there is
 // one ready. If we don't have one yet because not all necessary parts have been
 // returned (i.e. both roots and leaf cert), nil is returned.
@@ -335,10 +793,12 @@ func (s *state) Changed(ns *structs.NodeService, token string) bool {
 	if ns == nil {
 		return true
 	}
-	return ns.Kind != structs.ServiceKindConnectProxy ||
+	return ns.Kind != s.kind ||
 		s.proxyID != ns.ID ||
+		s.service != ns.Service ||
 		s.address != ns.Address ||
 		s.port != ns.Port ||
+		!reflect.DeepEqual(s.taggedAddresses, ns.TaggedAddresses) ||
 		!reflect.DeepEqual(s.proxyCfg, ns.Proxy) ||
 		s.token != token
 }
@@ -1,11 +1,16 @@
 package proxycfg
 
 import (
+	"log"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
 )
 
 func TestStateChanged(t *testing.T) {
@@ -100,6 +105,25 @@ func TestStateChanged(t *testing.T) {
 			},
 			want: true,
 		},
+		{
+			name: "same mesh gateway",
+			ns:   structs.TestNodeServiceMeshGateway(t),
+			mutate: func(ns structs.NodeService, token string) (*structs.NodeService, string) {
+				return &ns, token
+			},
+			want: false,
+		},
+		{
+			name: "different mesh gateway tagged addresses",
+			ns:   structs.TestNodeServiceMeshGateway(t),
+			mutate: func(ns structs.NodeService, token string) (*structs.NodeService, string) {
+				ns.TaggedAddresses = map[string]structs.ServiceAddress{
+					"wan": structs.ServiceAddress{Address: "198.18.4.6", Port: 443},
+				}
+				return &ns, token
+			},
+			want: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -112,3 +136,198 @@ func TestStateChanged(t *testing.T) {
 		})
 	}
 }
+
+func TestState_MeshGatewayWatches(t *testing.T) {
+	require := require.New(t)
+
+	types := NewTestCacheTypes(t)
+	c := TestCacheWithTypes(t, types)
+
+	roots, _ := TestCerts(t)
+	types.roots.value.Store(roots)
+	types.servicesList.value.Store(&structs.IndexedServices{
+		Services: structs.Services{"db": nil},
+	})
+	types.datacenters.value.Store(&[]string{"dc1", "dc2"})
+	types.health.value.Store(&structs.IndexedCheckServiceNodes{
+		Nodes: TestUpstreamNodes(t),
+	})
+	types.serviceDump.value.Store(&structs.IndexedCheckServiceNodes{
+		Nodes: TestMeshGatewayNodes(t, "dc2"),
+	})
+
+	state, err := newState(structs.TestNodeServiceMeshGateway(t), "my-token")
+	require.NoError(err)
+	state.logger = log.New(os.Stderr, "", log.LstdFlags)
+	state.source = &structs.QuerySource{Node: "node1", Datacenter: "dc1"}
+	state.cache = c
+
+	snapCh, err := state.Watch()
+	require.NoError(err)
+	defer state.Close()
+
+	retry.Run(t, func(r *retry.R) {
+		select {
+		case snap := <-snapCh:
+			if len(snap.ServiceGroups) != 1 || len(snap.MeshGatewayEndpoints) != 1 {
+				r.Fatalf("snapshot not complete: %#v", snap)
+			}
+			require.Equal(structs.ServiceKindMeshGateway, snap.Kind)
+			require.Equal(TestUpstreamNodes(t), snap.ServiceGroups["db"])
+			require.Equal(TestMeshGatewayNodes(t, "dc2"), snap.MeshGatewayEndpoints["dc2"])
+		case <-time.After(50*time.Millisecond + coalesceTimeout):
+			r.Fatal("no snapshot")
+		}
+	})
+
+	// The gateways are only watched in the other datacenters.
+	dumpReq := types.serviceDump.lastReq.Load().(*structs.ServiceDumpRequest)
+	require.Equal("dc2", dumpReq.Datacenter)
+	require.Equal(structs.ServiceKindMeshGateway, dumpReq.ServiceKind)
+	require.Equal("my-token", dumpReq.Token)
+
+	healthReq := types.health.lastReq.Load().(*structs.ServiceSpecificRequest)
+	require.Equal("db", healthReq.ServiceName)
+	require.True(healthReq.Connect)
+}
+
+func TestState_IngressGatewayWatches(t *testing.T) {
+	require := require.New(t)
+
+	types := NewTestCacheTypes(t)
+	c := TestCacheWithTypes(t, types)
+
+	roots, leaf := TestCerts(t)
+	types.roots.value.Store(roots)
+	types.leaf.value.Store(leaf)
+	types.configEntry.value.Store(&structs.ConfigEntryResponse{
+		Entry: TestIngressGatewayConfigEntry(t),
+	})
+	types.health.value.Store(&structs.IndexedCheckServiceNodes{
+		Nodes: TestUpstreamNodes(t),
+	})
+	types.chain.value.Store(&structs.DiscoveryChainResponse{
+		Chain: TestDiscoveryChain(t, "web", "http"),
+	})
+
+	ns := structs.TestNodeServiceGateway(t)
+	state, err := newState(ns, "my-token")
+	require.NoError(err)
+	state.logger = log.New(os.Stderr, "", log.LstdFlags)
+	state.source = &structs.QuerySource{Node: "node1", Datacenter: "dc1"}
+	state.cache = c
+
+	snapCh, err := state.Watch()
+	require.NoError(err)
+	defer state.Close()
+
+	retry.Run(t, func(r *retry.R) {
+		select {
+		case snap := <-snapCh:
+			if len(snap.UpstreamEndpoints) != 3 || len(snap.DiscoveryChain) != 3 {
+				r.Fatalf("snapshot not complete: %#v", snap)
+			}
+			require.Equal(structs.ServiceKindIngressGateway, snap.Kind)
+			require.Equal(TestIngressGatewayConfigEntry(t), snap.IngressGateway)
+			require.Equal(TestUpstreamNodes(t), snap.UpstreamEndpoints["service:web"])
+		case <-time.After(50*time.Millisecond + coalesceTimeout):
+			r.Fatal("no snapshot")
+		}
+	})
+
+	// The gateway reads its own config entry and gets a leaf for its own
+	// service.
+	entryReq := types.configEntry.lastReq.Load().(*structs.ConfigEntryQuery)
+	require.Equal(structs.IngressGateway, entryReq.Kind)
+	require.Equal("ingress", entryReq.Name)
+	require.Equal("my-token", entryReq.Token)
+	leafReq := types.leaf.lastReq.Load().(*cachetype.ConnectCALeafRequest)
+	require.Equal("ingress", leafReq.Service)
+
+	// The services removed from the listeners are no longer watched.
+	entry := TestIngressGatewayConfigEntry(t)
+	entry.Listeners = entry.Listeners[:1]
+	types.configEntry.Set(&structs.ConfigEntryResponse{Entry: entry})
+
+	retry.Run(t, func(r *retry.R) {
+		select {
+		case snap := <-snapCh:
+			if len(snap.UpstreamEndpoints) != 1 || len(snap.DiscoveryChain) != 1 {
+				r.Fatalf("services not removed: %#v", snap)
+			}
+			require.Contains(snap.UpstreamEndpoints, "service:db")
+			require.Contains(snap.DiscoveryChain, "service:db")
+		case <-time.After(50*time.Millisecond + coalesceTimeout):
+			r.Fatal("no snapshot")
+		}
+	})
+}
+
+func TestState_TerminatingGatewayWatches(t *testing.T) {
+	require := require.New(t)
+
+	types := NewTestCacheTypes(t)
+	c := TestCacheWithTypes(t, types)
+
+	roots, leaf := TestCerts(t)
+	types.roots.value.Store(roots)
+	types.leaf.value.Store(leaf)
+	types.configEntry.value.Store(&structs.ConfigEntryResponse{
+		Entry: TestTerminatingGatewayConfigEntry(t),
+	})
+	types.health.value.Store(&structs.IndexedCheckServiceNodes{
+		Nodes: TestUpstreamNodes(t),
+	})
+
+	ns := structs.TestNodeServiceTerminatingGateway(t)
+	state, err := newState(ns, "my-token")
+	require.NoError(err)
+	state.logger = log.New(os.Stderr, "", log.LstdFlags)
+	state.source = &structs.QuerySource{Node: "node1", Datacenter: "dc1"}
+	state.cache = c
+
+	snapCh, err := state.Watch()
+	require.NoError(err)
+	defer state.Close()
+
+	retry.Run(t, func(r *retry.R) {
+		select {
+		case snap := <-snapCh:
+			if len(snap.ServiceGroups) != 2 || len(snap.ServiceLeaves) != 2 {
+				r.Fatalf("snapshot not complete: %#v", snap)
+			}
+			require.Equal(structs.ServiceKindTerminatingGateway, snap.Kind)
+			require.Equal(TestTerminatingGatewayConfigEntry(t), snap.TerminatingGateway)
+			require.Equal(TestUpstreamNodes(t), snap.ServiceGroups["legacy"])
+			require.Equal(leaf, snap.ServiceLeaves["billing"])
+		case <-time.After(50*time.Millisecond + coalesceTimeout):
+			r.Fatal("no snapshot")
+		}
+	})
+
+	// The gateway reads its own config entry and watches the plain instances
+	// of its linked services.
+	entryReq := types.configEntry.lastReq.Load().(*structs.ConfigEntryQuery)
+	require.Equal(structs.TerminatingGateway, entryReq.Kind)
+	require.Equal("terminating", entryReq.Name)
+	healthReq := types.health.lastReq.Load().(*structs.ServiceSpecificRequest)
+	require.False(healthReq.Connect)
+
+	// The unlinked services are no longer watched.
+	entry := TestTerminatingGatewayConfigEntry(t)
+	entry.Services = entry.Services[:1]
+	types.configEntry.Set(&structs.ConfigEntryResponse{Entry: entry})
+
+	retry.Run(t, func(r *retry.R) {
+		select {
+		case snap := <-snapCh:
+			if len(snap.ServiceGroups) != 1 || len(snap.ServiceLeaves) != 1 {
+				r.Fatalf("services not removed: %#v", snap)
+			}
+			require.Contains(snap.ServiceGroups, "legacy")
+			require.Contains(snap.ServiceLeaves, "legacy")
+		case <-time.After(50*time.Millisecond + coalesceTimeout):
+			r.Fatal("no snapshot")
+		}
+	})
+}
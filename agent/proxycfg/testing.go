@@ -9,6 +9,7 @@ import (
 	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/types"
 	"github.com/mitchellh/go-testing-interface"
 	"github.com/stretchr/testify/require"
 )
@@ -16,11 +17,16 @@ import (
 // TestCacheTypes encapsulates all the different cache types proxycfg.State will
 // watch/request for controlling one during testing.
 type TestCacheTypes struct {
-	roots      *ControllableCacheType
-	leaf       *ControllableCacheType
-	intentions *ControllableCacheType
-	health     *ControllableCacheType
-	query      *ControllableCacheType
+	roots        *ControllableCacheType
+	leaf         *ControllableCacheType
+	intentions   *ControllableCacheType
+	health       *ControllableCacheType
+	query        *ControllableCacheType
+	chain        *ControllableCacheType
+	serviceDump  *ControllableCacheType
+	servicesList *ControllableCacheType
+	datacenters  *ControllableCacheType
+	configEntry  *ControllableCacheType
 }
 
 // NewTestCacheTypes creates a set of ControllableCacheTypes for all types that
@@ -33,8 +39,15 @@ func NewTestCacheTypes(t testing.T) *TestCacheTypes {
 		intentions: NewControllableCacheType(t),
 		health:     NewControllableCacheType(t),
 		query:      NewControllableCacheType(t),
+		chain:      NewControllableCacheType(t),
+
+		serviceDump:  NewControllableCacheType(t),
+		servicesList: NewControllableCacheType(t),
+		datacenters:  NewControllableCacheType(t),
+		configEntry:  NewControllableCacheType(t),
 	}
 	ct.query.blocking = false
+	ct.datacenters.blocking = false
 	return ct
 }
 
@@ -65,6 +78,29 @@ func TestCacheWithTypes(t testing.T, types *TestCacheTypes) *cache.Cache {
 	c.RegisterType(cachetype.PreparedQueryName, types.query, &cache.RegisterOptions{
 		Refresh: false,
 	})
+	c.RegisterType(cachetype.CompiledDiscoveryChainName, types.chain, &cache.RegisterOptions{
+		Refresh:        true,
+		RefreshTimer:   0,
+		RefreshTimeout: 10 * time.Minute,
+	})
+	c.RegisterType(cachetype.InternalServiceDumpName, types.serviceDump, &cache.RegisterOptions{
+		Refresh:        true,
+		RefreshTimer:   0,
+		RefreshTimeout: 10 * time.Minute,
+	})
+	c.RegisterType(cachetype.CatalogListServicesName, types.servicesList, &cache.RegisterOptions{
+		Refresh:        true,
+		RefreshTimer:   0,
+		RefreshTimeout: 10 * time.Minute,
+	})
+	c.RegisterType(cachetype.CatalogDatacentersName, types.datacenters, &cache.RegisterOptions{
+		Refresh: false,
+	})
+	c.RegisterType(cachetype.ConfigEntryName, types.configEntry, &cache.RegisterOptions{
+		Refresh:        true,
+		RefreshTimer:   0,
+		RefreshTimeout: 10 * time.Minute,
+	})
 	return c
 }
 
@@ -145,6 +181,29 @@ func TestUpstreamNodes(t testing.T) structs.CheckServiceNodes {
 	}
 }
 
+// TestDiscoveryChain returns the default compiled discovery chain of the
+// service speaking the given protocol.
+func TestDiscoveryChain(t testing.T, service, protocol string) *structs.CompiledDiscoveryChain {
+	target := structs.NewDiscoveryTarget(service, "dc1")
+	node := &structs.DiscoveryGraphNode{
+		Type: structs.DiscoveryGraphNodeTypeResolver,
+		Name: service,
+		Resolver: &structs.DiscoveryResolver{
+			Default:        true,
+			ConnectTimeout: structs.DefaultConnectTimeout,
+			Target:         target.ID,
+		},
+	}
+	return &structs.CompiledDiscoveryChain{
+		ServiceName: service,
+		Datacenter:  "dc1",
+		Protocol:    protocol,
+		StartNode:   node.Name,
+		Nodes:       map[string]*structs.DiscoveryGraphNode{node.Name: node},
+		Targets:     map[string]*structs.DiscoveryTarget{target.ID: target},
+	}
+}
+
 // TestConfigSnapshot returns a fully populated snapshot
 func TestConfigSnapshot(t testing.T) *ConfigSnapshot {
 	roots, leaf := TestCerts(t)
@@ -167,6 +226,187 @@ func TestConfigSnapshot(t testing.T) *ConfigSnapshot {
 		UpstreamEndpoints: map[string]structs.CheckServiceNodes{
 			"service:db": TestUpstreamNodes(t),
 		},
+		DiscoveryChain: map[string]*structs.CompiledDiscoveryChain{
+			"service:db": TestDiscoveryChain(t, "db", "tcp"),
+		},
+	}
+}
+
+// TestMeshGatewayNodes returns a sample discovery result of the mesh gateways
+// of the given datacenter.
+func TestMeshGatewayNodes(t testing.T, dc string) structs.CheckServiceNodes {
+	gateway := func(node, lan, wan string) structs.CheckServiceNode {
+		return structs.CheckServiceNode{
+			Node: &structs.Node{
+				ID:         types.NodeID(node),
+				Node:       node,
+				Address:    lan,
+				Datacenter: dc,
+			},
+			Service: &structs.NodeService{
+				Kind:    structs.ServiceKindMeshGateway,
+				ID:      "mesh-gateway",
+				Service: "mesh-gateway",
+				Address: lan,
+				Port:    8443,
+				TaggedAddresses: map[string]structs.ServiceAddress{
+					"lan": structs.ServiceAddress{Address: lan, Port: 8443},
+					"wan": structs.ServiceAddress{Address: wan, Port: 443},
+				},
+			},
+		}
+	}
+	switch dc {
+	case "dc1":
+		return structs.CheckServiceNodes{
+			gateway("mesh-gateway-1", "10.10.0.1", "198.18.0.1"),
+			gateway("mesh-gateway-2", "10.10.0.2", "198.18.0.2"),
+		}
+	default:
+		return structs.CheckServiceNodes{
+			gateway(dc+"-mesh-gateway-1", "10.20.0.1", "198.19.0.1"),
+		}
+	}
+}
+
+// TestConfigSnapshotMeshGatewayUpstream returns a snapshot of a proxy whose
+// upstream of another datacenter is routed through the mesh gateways with
+// the given mode.
+func TestConfigSnapshotMeshGatewayUpstream(t testing.T, mode structs.MeshGatewayMode) *ConfigSnapshot {
+	snap := TestConfigSnapshot(t)
+	snap.Datacenter = "dc1"
+	snap.Proxy.Upstreams = append(snap.Proxy.Upstreams, structs.Upstream{
+		DestinationName: "api",
+		Datacenter:      "dc2",
+		LocalBindPort:   9393,
+		MeshGateway:     structs.MeshGatewayConfig{Mode: mode},
+	})
+	snap.UpstreamEndpoints["service:api?dc=dc2"] = TestUpstreamNodes(t)
+	snap.MeshGatewayEndpoints = map[string]structs.CheckServiceNodes{
+		"dc1": TestMeshGatewayNodes(t, "dc1"),
+		"dc2": TestMeshGatewayNodes(t, "dc2"),
+	}
+	return snap
+}
+
+// TestConfigSnapshotMeshGateway returns a fully populated snapshot of a mesh
+// gateway of dc1 knowing the services of dc1 and the gateways of dc2.
+func TestConfigSnapshotMeshGateway(t testing.T) *ConfigSnapshot {
+	roots, _ := TestCerts(t)
+	return &ConfigSnapshot{
+		Kind:    structs.ServiceKindMeshGateway,
+		Service: "mesh-gateway",
+		ProxyID: "mesh-gateway",
+		Address: "1.2.3.4",
+		Port:    8443,
+		TaggedAddresses: map[string]structs.ServiceAddress{
+			"wan": structs.ServiceAddress{Address: "198.18.0.1", Port: 443},
+		},
+		Datacenter: "dc1",
+		Roots:      roots,
+		MeshGatewayEndpoints: map[string]structs.CheckServiceNodes{
+			"dc2": TestMeshGatewayNodes(t, "dc2"),
+		},
+		ServiceGroups: map[string]structs.CheckServiceNodes{
+			"db":  TestUpstreamNodes(t),
+			"web": TestUpstreamNodes(t),
+		},
+	}
+}
+
+// TestIngressGatewayConfigEntry returns the config entry of an ingress
+// gateway exposing db on a TCP listener and web and api by their hosts on an
+// HTTP listener.
+func TestIngressGatewayConfigEntry(t testing.T) *structs.IngressGatewayConfigEntry {
+	return &structs.IngressGatewayConfigEntry{
+		Kind: structs.IngressGateway,
+		Name: "ingress",
+		Listeners: []structs.IngressListener{
+			{
+				Port:     9191,
+				Protocol: structs.IngressListenerProtocolTCP,
+				Services: []structs.IngressService{{Name: "db"}},
+			},
+			{
+				Port:     8080,
+				Protocol: structs.IngressListenerProtocolHTTP,
+				Services: []structs.IngressService{
+					{Name: "web", Hosts: []string{"web.example.com", "www.example.com"}},
+					{Name: "api"},
+				},
+			},
+		},
+	}
+}
+
+// TestConfigSnapshotIngressGateway returns a fully populated snapshot of an
+// ingress gateway configured with TestIngressGatewayConfigEntry.
+func TestConfigSnapshotIngressGateway(t testing.T) *ConfigSnapshot {
+	roots, leaf := TestCerts(t)
+	return &ConfigSnapshot{
+		Kind:           structs.ServiceKindIngressGateway,
+		Service:        "ingress",
+		ProxyID:        "ingress",
+		Address:        "1.2.3.4",
+		Port:           8443,
+		Datacenter:     "dc1",
+		Roots:          roots,
+		Leaf:           leaf,
+		IngressGateway: TestIngressGatewayConfigEntry(t),
+		UpstreamEndpoints: map[string]structs.CheckServiceNodes{
+			"service:api": TestUpstreamNodes(t),
+			"service:db":  TestUpstreamNodes(t),
+			"service:web": TestUpstreamNodes(t),
+		},
+		DiscoveryChain: map[string]*structs.CompiledDiscoveryChain{
+			"service:api": TestDiscoveryChain(t, "api", "http"),
+			"service:db":  TestDiscoveryChain(t, "db", "tcp"),
+			"service:web": TestDiscoveryChain(t, "web", "http"),
+		},
+	}
+}
+
+// TestTerminatingGatewayConfigEntry returns the config entry of a terminating
+// gateway linking a plain-text service, legacy, and a service reached over
+// TLS, billing.
+func TestTerminatingGatewayConfigEntry(t testing.T) *structs.TerminatingGatewayConfigEntry {
+	return &structs.TerminatingGatewayConfigEntry{
+		Kind: structs.TerminatingGateway,
+		Name: "terminating",
+		Services: []structs.LinkedService{
+			{Name: "legacy"},
+			{
+				Name:     "billing",
+				CAFile:   "/etc/certs/ca.pem",
+				CertFile: "/etc/certs/client.pem",
+				KeyFile:  "/etc/certs/client.key",
+				SNI:      "billing.example.com",
+			},
+		},
+	}
+}
+
+// TestConfigSnapshotTerminatingGateway returns a fully populated snapshot of
+// a terminating gateway configured with TestTerminatingGatewayConfigEntry.
+func TestConfigSnapshotTerminatingGateway(t testing.T) *ConfigSnapshot {
+	roots, leaf := TestCerts(t)
+	return &ConfigSnapshot{
+		Kind:               structs.ServiceKindTerminatingGateway,
+		Service:            "terminating",
+		ProxyID:            "terminating",
+		Address:            "1.2.3.4",
+		Port:               8443,
+		Datacenter:         "dc1",
+		Roots:              roots,
+		TerminatingGateway: TestTerminatingGatewayConfigEntry(t),
+		ServiceGroups: map[string]structs.CheckServiceNodes{
+			"billing": TestUpstreamNodes(t),
+			"legacy":  TestUpstreamNodes(t),
+		},
+		ServiceLeaves: map[string]*structs.IssuedCert{
+			"billing": leaf,
+			"legacy":  leaf,
+		},
 	}
 }
 
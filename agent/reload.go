@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/hashicorp/consul/agent/config"
+)
+
+// reloadableFields are the fields of the runtime configuration which a
+// configuration reload applies to the running agent. The changes to the other
+// fields only take effect when the agent restarts.
+var reloadableFields = map[string]bool{
+	// Services, checks and watches, including the ones of the config dir.
+	"Services": true,
+	"Checks":   true,
+	"Watches":  true,
+	"NodeMeta": true,
+
+	// ACL tokens.
+	"ACLToken":            true,
+	"ACLAgentToken":       true,
+	"ACLAgentMasterToken": true,
+	"ACLReplicationToken": true,
+
+	// TLS configuration.
+	"CAFile":                      true,
+	"CAPath":                      true,
+	"CertFile":                    true,
+	"KeyFile":                     true,
+	"VerifyIncoming":              true,
+	"VerifyIncomingHTTPS":         true,
+	"VerifyIncomingRPC":           true,
+	"VerifyOutgoing":              true,
+	"VerifyServerHostname":        true,
+	"TLSMinVersion":               true,
+	"TLSCipherSuites":             true,
+	"TLSPreferServerCipherSuites": true,
+	"EnableAgentTLSForChecks":     true,
+
+	// Runtime settings of the agent.
+	"DNSRecursors":        true,
+	"DiscardCheckOutput":  true,
+	"HTTPResponseHeaders": true,
+	"LogLevel":            true,
+	"LogSubsystemLevels":  true,
+	"RPCMaxBurst":         true,
+	"RPCRateLimit":        true,
+	"Telemetry":           true,
+}
+
+// ReloadResult reports the fields of the runtime configuration which changed
+// with a configuration reload.
+type ReloadResult struct {
+	// Applied are the changed fields the reload applied to the agent.
+	Applied []string
+
+	// RestartRequired are the changed fields which only take effect when
+	// the agent restarts, and which the agent keeps running with the value
+	// it was started with until then.
+	RestartRequired []string
+}
+
+// reloadResult compares the configuration the agent runs with to the
+// reloaded configuration.
+func reloadResult(current, reloaded *config.RuntimeConfig) *ReloadResult {
+	result := &ReloadResult{
+		Applied:         []string{},
+		RestartRequired: []string{},
+	}
+
+	cur := reflect.ValueOf(current).Elem()
+	rel := reflect.ValueOf(reloaded).Elem()
+	for i := 0; i < cur.NumField(); i++ {
+		if reflect.DeepEqual(cur.Field(i).Interface(), rel.Field(i).Interface()) {
+			continue
+		}
+		name := cur.Type().Field(i).Name
+		if reloadableFields[name] {
+			result.Applied = append(result.Applied, name)
+		} else {
+			result.RestartRequired = append(result.RestartRequired, name)
+		}
+	}
+
+	sort.Strings(result.Applied)
+	sort.Strings(result.RestartRequired)
+	return result
+}
+
+// reloadedConfig returns a copy of the current configuration with the fields
+// the reload applied, so that the next reload is compared with them. The
+// current configuration is left untouched since it is read concurrently.
+func reloadedConfig(current, reloaded *config.RuntimeConfig) *config.RuntimeConfig {
+	cfg := *current
+	cur := reflect.ValueOf(&cfg).Elem()
+	rel := reflect.ValueOf(reloaded).Elem()
+	for name := range reloadableFields {
+		cur.FieldByName(name).Set(rel.FieldByName(name))
+	}
+	return &cfg
+}
@@ -0,0 +1,164 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/config"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgent_ReloadConfig_result(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	a := NewTestAgent(t, t.Name(), `
+		http_config {
+			response_headers {
+				"X-Reloaded" = "before"
+			}
+		}
+	`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	reload := func(hcl string) *ReloadResult {
+		t.Helper()
+		cfg := TestConfig(config.Source{
+			Name:   "reload",
+			Format: "hcl",
+			Data: `
+				data_dir = "` + a.Config.DataDir + `"
+				node_id = "` + string(a.Config.NodeID) + `"
+				node_name = "` + a.Config.NodeName + `"
+			` + hcl,
+		})
+		require.NoError(a.ReloadConfig(cfg))
+		return a.lastReloadResult()
+	}
+
+	// The response headers and the checks are applied, the DNS node TTL
+	// requires a restart.
+	result := reload(`
+		http_config {
+			response_headers {
+				"X-Reloaded" = "after"
+			}
+		}
+		check {
+			id = "reloaded"
+			name = "reloaded"
+			ttl = "30s"
+		}
+		dns_config {
+			node_ttl = "10s"
+		}
+	`)
+	require.Equal([]string{"Checks", "HTTPResponseHeaders"}, result.Applied)
+	require.Contains(result.RestartRequired, "DNSNodeTTL")
+	require.NotNil(a.State.Check("reloaded"))
+
+	handler := func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		return nil, nil
+	}
+	req, _ := http.NewRequest("GET", "/v1/agent/self", nil)
+	resp := httptest.NewRecorder()
+	a.srv.wrap(handler, []string{"GET"})(resp, req)
+	require.Equal("after", resp.Header().Get("X-Reloaded"))
+
+	// The configuration the agent was started with is read without locking,
+	// so it isn't replaced by the reload.
+	require.Equal("before", a.config.HTTPResponseHeaders["X-Reloaded"])
+	require.Equal("after", a.currentConfig().HTTPResponseHeaders["X-Reloaded"])
+
+	// Reloading the same configuration again still reports the DNS node TTL,
+	// which the agent runs with the value it was started with.
+	result = reload(`
+		http_config {
+			response_headers {
+				"X-Reloaded" = "after"
+			}
+		}
+		check {
+			id = "reloaded"
+			name = "reloaded"
+			ttl = "30s"
+		}
+		dns_config {
+			node_ttl = "10s"
+		}
+	`)
+	require.Empty(result.Applied)
+	require.Contains(result.RestartRequired, "DNSNodeTTL")
+}
+
+func TestReloadedConfig(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	current := &config.RuntimeConfig{
+		LogLevel:   "INFO",
+		DNSNodeTTL: 10,
+	}
+	reloaded := &config.RuntimeConfig{
+		LogLevel:   "DEBUG",
+		DNSNodeTTL: 20,
+	}
+
+	// Only the reloadable fields are taken from the reloaded configuration,
+	// and the current one isn't modified.
+	cfg := reloadedConfig(current, reloaded)
+	require.Equal("DEBUG", cfg.LogLevel)
+	require.EqualValues(10, cfg.DNSNodeTTL)
+	require.Equal("INFO", current.LogLevel)
+}
+
+func TestAgent_ReloadConfig_concurrentReads(t *testing.T) {
+	t.Parallel()
+
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// The HTTP handlers keep reading the configuration during the reloads.
+	handler := a.srv.wrap(func(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+		return nil, nil
+	}, []string{"GET"})
+	doneCh := make(chan struct{})
+	readsCh := make(chan struct{})
+	go func() {
+		defer close(readsCh)
+		for {
+			select {
+			case <-doneCh:
+				return
+			default:
+			}
+			req, _ := http.NewRequest("GET", "/v1/agent/self", nil)
+			handler(httptest.NewRecorder(), req)
+		}
+	}()
+
+	for _, value := range []string{"one", "two"} {
+		cfg := TestConfig(config.Source{
+			Name:   "reload",
+			Format: "hcl",
+			Data: `
+				data_dir = "` + a.Config.DataDir + `"
+				node_id = "` + string(a.Config.NodeID) + `"
+				node_name = "` + a.Config.NodeName + `"
+				http_config {
+					response_headers {
+						"X-Reloaded" = "` + value + `"
+					}
+				}
+			`,
+		})
+		require.NoError(t, a.ReloadConfig(cfg))
+	}
+	close(doneCh)
+	<-readsCh
+	require.Equal(t, "two", a.currentConfig().HTTPResponseHeaders["X-Reloaded"])
+}
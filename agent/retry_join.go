@@ -69,17 +69,7 @@ func (r *retryJoiner) retryJoin() error {
 		return nil
 	}
 
-	// Copy the default providers, and then add the non-default
-	providers := make(map[string]discover.Provider)
-	for k, v := range discover.Providers {
-		providers[k] = v
-	}
-	providers["k8s"] = &discoverk8s.Provider{}
-
-	disco, err := discover.New(
-		discover.WithUserAgent(lib.UserAgent()),
-		discover.WithProviders(providers),
-	)
+	disco, err := newDiscover()
 	if err != nil {
 		return err
 	}
@@ -128,3 +118,19 @@ func (r *retryJoiner) retryJoin() error {
 		time.Sleep(r.interval)
 	}
 }
+
+// newDiscover returns the go-discover instance used to look up the servers
+// configured with go-discover configurations.
+func newDiscover() (*discover.Discover, error) {
+	// Copy the default providers, and then add the non-default
+	providers := make(map[string]discover.Provider)
+	for k, v := range discover.Providers {
+		providers[k] = v
+	}
+	providers["k8s"] = &discoverk8s.Provider{}
+
+	return discover.New(
+		discover.WithUserAgent(lib.UserAgent()),
+		discover.WithProviders(providers),
+	)
+}
@@ -0,0 +1,167 @@
+package agent
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"github.com/hashicorp/consul/agent/cache"
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// serviceManager merges the centrally managed config of the registered
+// connect proxies, resolved from the proxy-defaults and service-defaults
+// config entries, into their local state. The values set at registration
+// always take precedence over the central ones.
+type serviceManager struct {
+	agent *Agent
+
+	lock    sync.Mutex
+	watches map[string]*serviceConfigWatch
+}
+
+// serviceConfigWatch is the watch of the central config of a single
+// registered service.
+type serviceConfigWatch struct {
+	registered *structs.NodeService
+	cancel     context.CancelFunc
+}
+
+func newServiceManager(agent *Agent) *serviceManager {
+	return &serviceManager{
+		agent:   agent,
+		watches: make(map[string]*serviceConfigWatch),
+	}
+}
+
+// AddService starts watching the central config of the service, replacing any
+// previous watch for the same service ID. It must be called with the agent's
+// stateLock held, after the service has been added to the local state.
+func (s *serviceManager) AddService(service *structs.NodeService, token string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.removeServiceLocked(service.ID)
+
+	if service.Kind != structs.ServiceKindConnectProxy {
+		return nil
+	}
+
+	if token == "" {
+		token = s.agent.tokens.UserToken()
+	}
+	req := &structs.ServiceConfigRequest{
+		Name:         service.Proxy.DestinationServiceName,
+		Datacenter:   s.agent.config.Datacenter,
+		QueryOptions: structs.QueryOptions{Token: token},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan cache.UpdateEvent, 1)
+	if err := s.agent.cache.Notify(ctx, cachetype.ResolvedServiceConfigName, req, service.ID, ch); err != nil {
+		cancel()
+		return err
+	}
+
+	watch := &serviceConfigWatch{
+		registered: service,
+		cancel:     cancel,
+	}
+	s.watches[service.ID] = watch
+	go s.run(ctx, watch, ch)
+	return nil
+}
+
+// RemoveService stops watching the central config of the service.
+func (s *serviceManager) RemoveService(serviceID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.removeServiceLocked(serviceID)
+}
+
+func (s *serviceManager) removeServiceLocked(serviceID string) {
+	if watch, ok := s.watches[serviceID]; ok {
+		watch.cancel()
+		delete(s.watches, serviceID)
+	}
+}
+
+// Stop stops all the watches.
+func (s *serviceManager) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for id := range s.watches {
+		s.removeServiceLocked(id)
+	}
+}
+
+func (s *serviceManager) run(ctx context.Context, watch *serviceConfigWatch, ch <-chan cache.UpdateEvent) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case u := <-ch:
+			if u.Err != nil {
+				s.agent.logger.Printf("[WARN] agent: failed to resolve the central config of service %q: %s",
+					u.CorrelationID, u.Err)
+				continue
+			}
+			resp, ok := u.Result.(*structs.ServiceConfigResponse)
+			if !ok {
+				s.agent.logger.Printf("[ERR] agent: invalid type for the central config of service %q: %T",
+					u.CorrelationID, u.Result)
+				continue
+			}
+			s.apply(watch, resp)
+		}
+	}
+}
+
+// apply merges the resolved config into the local state of the service, as
+// long as the watch is still the current one for it.
+func (s *serviceManager) apply(watch *serviceConfigWatch, resp *structs.ServiceConfigResponse) {
+	s.agent.stateLock.Lock()
+	defer s.agent.stateLock.Unlock()
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	id := watch.registered.ID
+	if s.watches[id] != watch {
+		return
+	}
+
+	cur := s.agent.State.Service(id)
+	if cur == nil {
+		return
+	}
+	merged := mergeServiceConfig(resp, watch.registered)
+	if reflect.DeepEqual(cur.Proxy.Config, merged.Proxy.Config) {
+		return
+	}
+	if err := s.agent.State.AddService(merged, s.agent.State.ServiceToken(id)); err != nil {
+		s.agent.logger.Printf("[WARN] agent: failed to merge the central config of service %q: %s", id, err)
+	}
+}
+
+// mergeServiceConfig returns a copy of the registered service with the keys of
+// the resolved proxy config it does not set itself.
+func mergeServiceConfig(resp *structs.ServiceConfigResponse, registered *structs.NodeService) *structs.NodeService {
+	merged := *registered
+	merged.Proxy.Config = make(map[string]interface{})
+	for k, v := range resp.ProxyConfig {
+		// The RPC codec decodes the strings of an interface{} as []byte.
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		merged.Proxy.Config[k] = v
+	}
+	for k, v := range registered.Proxy.Config {
+		merged.Proxy.Config[k] = v
+	}
+	if len(merged.Proxy.Config) == 0 {
+		merged.Proxy.Config = registered.Proxy.Config
+	}
+	return &merged
+}
@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceManager_CentralConfig(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	// Configure the proxy defaults and the protocol of the service.
+	reqs := []structs.ConfigEntryRequest{
+		{
+			Datacenter: "dc1",
+			Op:         structs.ConfigEntryUpsert,
+			Entry: &structs.ProxyConfigEntry{
+				Kind: structs.ProxyDefaults,
+				Name: structs.ProxyConfigGlobal,
+				Config: map[string]interface{}{
+					"foo":      "central",
+					"protocol": "tcp",
+				},
+			},
+		},
+		{
+			Datacenter: "dc1",
+			Op:         structs.ConfigEntryUpsert,
+			Entry: &structs.ServiceConfigEntry{
+				Kind:     structs.ServiceDefaults,
+				Name:     "web",
+				Protocol: "http",
+			},
+		},
+	}
+	for _, req := range reqs {
+		var out struct{}
+		require.NoError(a.RPC("ConfigEntry.Apply", &req, &out))
+	}
+
+	// Register a proxy overriding one of the central keys.
+	svc := &structs.NodeService{
+		Kind:    structs.ServiceKindConnectProxy,
+		ID:      "web-proxy",
+		Service: "web-proxy",
+		Port:    20000,
+		Proxy: structs.ConnectProxyConfig{
+			DestinationServiceName: "web",
+			DestinationServiceID:   "web",
+			Config: map[string]interface{}{
+				"foo": "local",
+			},
+		},
+	}
+	require.NoError(a.AddService(svc, nil, false, "", ConfigSourceLocal))
+
+	retry.Run(t, func(r *retry.R) {
+		req, _ := http.NewRequest("GET", "/v1/agent/service/web-proxy", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.AgentService(resp, req)
+		if err != nil {
+			r.Fatal(err)
+		}
+		got := obj.(*api.AgentService).Proxy.Config
+		if got["protocol"] != "http" || got["foo"] != "local" {
+			r.Fatalf("bad: %#v", got)
+		}
+	})
+
+	// The registration itself is left untouched.
+	require.Equal(map[string]interface{}{"foo": "local"}, svc.Proxy.Config)
+
+	// Removing the service defaults falls back to the proxy defaults.
+	del := structs.ConfigEntryRequest{
+		Datacenter: "dc1",
+		Op:         structs.ConfigEntryDelete,
+		Entry: &structs.ServiceConfigEntry{
+			Kind: structs.ServiceDefaults,
+			Name: "web",
+		},
+	}
+	var out struct{}
+	require.NoError(a.RPC("ConfigEntry.Apply", &del, &out))
+
+	retry.Run(t, func(r *retry.R) {
+		got := a.State.Service("web-proxy").Proxy.Config
+		if got["protocol"] != "tcp" {
+			r.Fatalf("bad: %#v", got)
+		}
+	})
+
+	// Deregistering the service stops the watch.
+	require.NoError(a.RemoveService("web-proxy", false))
+	a.serviceManager.lock.Lock()
+	require.Len(a.serviceManager.watches, 0)
+	a.serviceManager.lock.Unlock()
+}
+
+func TestServiceManager_mergeServiceConfig(t *testing.T) {
+	t.Parallel()
+
+	registered := &structs.NodeService{
+		Kind: structs.ServiceKindConnectProxy,
+		ID:   "web-proxy",
+		Proxy: structs.ConnectProxyConfig{
+			DestinationServiceName: "web",
+			Config: map[string]interface{}{
+				"protocol": "grpc",
+			},
+		},
+	}
+	resp := &structs.ServiceConfigResponse{
+		ProxyConfig: map[string]interface{}{
+			"protocol": "http",
+			"foo":      []byte("bar"),
+		},
+	}
+
+	merged := mergeServiceConfig(resp, registered)
+	require.Equal(t, map[string]interface{}{
+		"protocol": "grpc",
+		"foo":      "bar",
+	}, merged.Proxy.Config)
+	require.Equal(t, map[string]interface{}{"protocol": "grpc"}, registered.Proxy.Config)
+
+	// Nothing to merge keeps the registered config as is.
+	merged = mergeServiceConfig(&structs.ServiceConfigResponse{}, &structs.NodeService{ID: "web-proxy"})
+	require.Nil(t, merged.Proxy.Config)
+}
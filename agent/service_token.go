@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"github.com/hashicorp/consul/agent/consul"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// serviceTokenEnabled returns whether a token should be requested for the
+// given service when it's registered. This is only done for Connect-enabled
+// services, either native or with a sidecar.
+func (a *Agent) serviceTokenEnabled(service *structs.NodeService, hasSidecar bool) bool {
+	if !a.config.ACLsEnabled || !a.config.ACLEnableServiceTokens {
+		return false
+	}
+	return service.Connect.Native || hasSidecar
+}
+
+// provisionServiceToken returns a new token scoped to the given service,
+// which is requested from the servers using the registration token. The
+// servers only grant the rules the registration token already covers. The
+// token can read the upstreams of the service, or of its sidecar if any.
+func (a *Agent) provisionServiceToken(service, sidecar *structs.NodeService, token string) (string, error) {
+	args := structs.ACLServiceTokenRequest{
+		Datacenter:   a.config.Datacenter,
+		ServiceName:  service.Service,
+		Node:         a.config.NodeName,
+		Upstreams:    serviceTokenUpstreams(service),
+		WriteRequest: structs.WriteRequest{Token: token},
+	}
+	if sidecar != nil {
+		args.Upstreams = append(args.Upstreams, serviceTokenUpstreams(sidecar)...)
+	}
+	var reply structs.ACLToken
+	if err := a.RPC("ACL.ServiceTokenCreate", &args, &reply); err != nil {
+		return "", err
+	}
+	a.logger.Printf("[DEBUG] agent: provisioned token %q for service %q", reply.AccessorID, service.ID)
+	return reply.SecretID, nil
+}
+
+// removeServiceWithToken removes a service and deletes the token provisioned
+// for it, if any. The token is still needed to deregister the service from
+// the catalog so it's only deleted once the removal was synced.
+func (a *Agent) removeServiceWithToken(serviceID string) error {
+	service := a.State.Service(serviceID)
+	token := a.State.ServiceToken(serviceID)
+
+	if err := a.RemoveService(serviceID, true); err != nil {
+		return err
+	}
+	if service == nil || !a.config.ACLEnableServiceTokens {
+		return nil
+	}
+	if err := a.State.SyncChanges(); err != nil {
+		a.logger.Printf("[ERR] agent: failed to sync changes: %v", err)
+		return nil
+	}
+	a.deleteServiceToken(service.Service, token)
+	return nil
+}
+
+// deleteServiceToken deletes the given token if it was provisioned for the
+// service. Tokens that weren't provisioned by an agent are left alone by the
+// servers.
+func (a *Agent) deleteServiceToken(service, token string) {
+	if token == "" || token == a.tokens.UserToken() {
+		return
+	}
+
+	args := structs.ACLServiceTokenRequest{
+		Datacenter:   a.config.Datacenter,
+		ServiceName:  service,
+		WriteRequest: structs.WriteRequest{Token: token},
+	}
+	var accessorID string
+	if err := a.RPC("ACL.ServiceTokenDelete", &args, &accessorID); err != nil {
+		if err.Error() != consul.ErrNotServiceToken.Error() {
+			a.logger.Printf("[WARN] agent: failed to delete the token of service %q: %v", service, err)
+		}
+		return
+	}
+	a.logger.Printf("[DEBUG] agent: deleted token %q of service %q", accessorID, service)
+}
+
+// serviceTokenUpstreams returns the names of the upstream services of the
+// given proxy. Prepared query upstreams are left out since the services they
+// resolve to aren't known ahead.
+func serviceTokenUpstreams(service *structs.NodeService) []string {
+	var names []string
+	for _, upstream := range service.Proxy.Upstreams {
+		switch upstream.DestinationType {
+		case "", structs.UpstreamDestTypeService:
+			names = append(names, upstream.DestinationName)
+		}
+	}
+	return names
+}
@@ -177,6 +177,14 @@ func (s *HTTPServer) SessionRenew(resp http.ResponseWriter, req *http.Request) (
 		return nil, nil
 	}
 
+	// Recommend renewing the session halfway through its TTL, leaving time
+	// to retry through a leader election.
+	if len(out.Sessions) > 0 {
+		if ttl, err := time.ParseDuration(out.Sessions[0].TTL); err == nil && ttl > 0 {
+			resp.Header().Set("X-Consul-Renew-Interval", (ttl / 2).String())
+		}
+	}
+
 	return out.Sessions, nil
 }
 
@@ -412,6 +412,9 @@ func TestSessionTTLRenew(t *testing.T) {
 	if len(respObj) != 1 {
 		t.Fatalf("bad: %v", respObj)
 	}
+	if interval := resp.Header().Get("X-Consul-Renew-Interval"); interval != (ttl / 2).String() {
+		t.Fatalf("bad: %q", interval)
+	}
 
 	// Sleep for ttl * TTL Multiplier
 	time.Sleep(ttl * structs.SessionTTLMultiplier)
@@ -2,8 +2,13 @@ package agent
 
 import (
 	"bytes"
+	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"strconv"
 
+	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
@@ -20,18 +25,47 @@ func (s *HTTPServer) Snapshot(resp http.ResponseWriter, req *http.Request) (inte
 
 	switch req.Method {
 	case "GET":
+		// Resume the download of a spooled snapshot.
+		if id := req.URL.Query().Get("id"); id != "" {
+			return s.snapshotResume(resp, req, id, args.Token)
+		}
+
 		args.Op = structs.SnapshotSave
 
+		// Spool the snapshot while streaming it, so that its download can
+		// be resumed if it gets interrupted.
+		snap, file, err := s.agent.snapshots.create(args.Token)
+		if err != nil {
+			return nil, err
+		}
+		out := &snapshotSpoolWriter{file: file, client: resp}
+
 		// Headers need to go out before we stream the body.
 		replyFn := func(reply *structs.SnapshotResponse) error {
+			snap.meta = reply.QueryMeta
 			setMeta(resp, &reply.QueryMeta)
+			resp.Header().Set("X-Consul-Snapshot-Id", snap.id)
 			return nil
 		}
 
 		// Don't bother sending any request body through since it will
 		// be ignored.
 		var null bytes.Buffer
-		if err := s.agent.SnapshotRPC(&args, &null, resp, replyFn); err != nil {
+		err = s.agent.SnapshotRPC(&args, &null, out, replyFn)
+
+		// A snapshot which couldn't be spooled was still streamed, only
+		// its download can't be resumed.
+		spoolErr := out.fileErr
+		if closeErr := file.Close(); spoolErr == nil {
+			spoolErr = closeErr
+		}
+		if err == nil && spoolErr != nil {
+			s.agent.logger.Printf("[WARN] agent: failed to spool snapshot: %v", spoolErr)
+			s.agent.snapshots.finish(snap, out.size, spoolErr)
+			return nil, nil
+		}
+		s.agent.snapshots.finish(snap, out.size, err)
+		if err != nil {
 			return nil, err
 		}
 		return nil, nil
@@ -47,3 +81,64 @@ func (s *HTTPServer) Snapshot(resp http.ResponseWriter, req *http.Request) (inte
 		return nil, MethodNotAllowedError{req.Method, []string{"GET", "PUT"}}
 	}
 }
+
+// snapshotResume streams a spooled snapshot from the offset given in the
+// request.
+func (s *HTTPServer) snapshotResume(resp http.ResponseWriter, req *http.Request, id, token string) (interface{}, error) {
+	var offset int64
+	if raw := req.URL.Query().Get("offset"); raw != "" {
+		var err error
+		if offset, err = strconv.ParseInt(raw, 10, 64); err != nil || offset < 0 {
+			resp.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(resp, "Invalid offset: %q", raw)
+			return nil, nil
+		}
+	}
+
+	// The token must still be allowed to save snapshots, since it may have
+	// been revoked or changed since the snapshot was spooled.
+	rule, err := s.agent.resolveToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if rule != nil && !rule.Snapshot() {
+		return nil, acl.ErrPermissionDenied
+	}
+
+	snap, err := s.agent.snapshots.get(id, token)
+	if err != nil {
+		return nil, err
+	}
+	if snap == nil {
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(resp, "Snapshot %q not found, it may have expired", id)
+		return nil, nil
+	}
+	if offset > snap.size {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(resp, "Offset %d is past the end of the snapshot (%d bytes)", offset, snap.size)
+		return nil, nil
+	}
+
+	f, err := os.Open(snap.path)
+	if os.IsNotExist(err) {
+		resp.WriteHeader(http.StatusNotFound)
+		fmt.Fprintf(resp, "Snapshot %q not found, it may have expired", id)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	setMeta(resp, &snap.meta)
+	resp.Header().Set("X-Consul-Snapshot-Id", snap.id)
+	resp.Header().Set("Content-Length", strconv.FormatInt(snap.size-offset, 10))
+	if _, err := io.Copy(resp, f); err != nil {
+		s.agent.logger.Printf("[WARN] agent: failed to resume snapshot download: %v", err)
+	}
+	return nil, nil
+}
@@ -2,13 +2,17 @@ package agent
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/testrpc"
 )
 
@@ -104,3 +108,120 @@ func TestSnapshot_Options(t *testing.T) {
 		})
 	}
 }
+
+func TestSnapshot_Resume(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), TestACLConfig())
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/snapshot?token=root", nil)
+	resp := httptest.NewRecorder()
+	if _, err := a.srv.Snapshot(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	full := resp.Body.Bytes()
+	id := resp.Header().Get("X-Consul-Snapshot-Id")
+	if id == "" || len(full) < 10 {
+		t.Fatalf("bad: %q %d", id, len(full))
+	}
+
+	// The download resumes from the offset.
+	req, _ = http.NewRequest("GET", "/v1/snapshot?token=root&id="+id+"&offset=10", nil)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.Snapshot(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 200 || !bytes.Equal(resp.Body.Bytes(), full[10:]) {
+		t.Fatalf("bad: %d %d", resp.Code, resp.Body.Len())
+	}
+	if resp.Header().Get("X-Consul-Index") == "" {
+		t.Fatalf("missing index")
+	}
+
+	// The snapshot is spooled under the data directory.
+	files, err := ioutil.ReadDir(filepath.Join(a.config.DataDir, snapshotSpoolDir))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("bad: %v %v", files, err)
+	}
+
+	// The token must be allowed to save snapshots.
+	req, _ = http.NewRequest("GET", "/v1/snapshot?token=anonymous&id="+id, nil)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.Snapshot(resp, req); !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The snapshot can only be resumed with the token it was saved with.
+	token := createSnapshotToken(t, a, "")
+	req, _ = http.NewRequest("GET", "/v1/snapshot?token="+token+"&id="+id, nil)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.Snapshot(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 404 {
+		t.Fatalf("bad: %d", resp.Code)
+	}
+
+	// Offsets past the end are rejected.
+	req, _ = http.NewRequest("GET", fmt.Sprintf("/v1/snapshot?token=root&id=%s&offset=%d", id, len(full)+1), nil)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.Snapshot(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 400 {
+		t.Fatalf("bad: %d", resp.Code)
+	}
+}
+
+func TestSnapshot_Resume_RevokedToken(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), TestACLConfig())
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	token := createSnapshotToken(t, a, "")
+	req, _ := http.NewRequest("GET", "/v1/snapshot?token="+token, nil)
+	resp := httptest.NewRecorder()
+	if _, err := a.srv.Snapshot(resp, req); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	id := resp.Header().Get("X-Consul-Snapshot-Id")
+	if id == "" {
+		t.Fatalf("missing id")
+	}
+
+	// Once the token can't save snapshots anymore, it can't resume their
+	// downloads either.
+	createSnapshotToken(t, a, token)
+	req, _ = http.NewRequest("GET", "/v1/snapshot?token="+token+"&id="+id, nil)
+	resp = httptest.NewRecorder()
+	if _, err := a.srv.Snapshot(resp, req); !acl.IsErrPermissionDenied(err) {
+		t.Fatalf("err: %v", err)
+	}
+}
+
+// createSnapshotToken creates a token allowed to save snapshots, or revokes
+// this permission from the given token.
+func createSnapshotToken(t *testing.T, a *TestAgent, id string) string {
+	t.Helper()
+	tokenType := structs.ACLTokenTypeManagement
+	if id != "" {
+		tokenType = structs.ACLTokenTypeClient
+	}
+	args := structs.ACLRequest{
+		Datacenter: "dc1",
+		Op:         structs.ACLSet,
+		ACL: structs.ACL{
+			ID:   id,
+			Name: "Snapshot token",
+			Type: tokenType,
+		},
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var token string
+	if err := a.RPC("ACL.Apply", &args, &token); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	return token
+}
@@ -0,0 +1,191 @@
+package agent
+
+import (
+	"crypto/subtle"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	uuid "github.com/hashicorp/go-uuid"
+)
+
+const (
+	// snapshotSpoolTTL is how long a spooled snapshot is kept after it was
+	// last downloaded, so that an interrupted download can be resumed.
+	snapshotSpoolTTL = 10 * time.Minute
+
+	// snapshotSpoolMax is the number of spooled snapshots kept on disk at
+	// once. The oldest ones are removed first.
+	snapshotSpoolMax = 2
+)
+
+// snapshotSpool keeps the snapshots saved through the agent on disk for a
+// while, so that their downloads can be resumed from an offset instead of
+// taking a new snapshot, which matters for large states.
+type snapshotSpool struct {
+	dir    string
+	logger *log.Logger
+
+	lock      sync.Mutex
+	snapshots map[string]*spooledSnapshot
+}
+
+// spooledSnapshot is a snapshot written to a file of the spool directory.
+type spooledSnapshot struct {
+	id      string
+	token   string
+	path    string
+	created time.Time
+	expiry  *time.Timer
+
+	// doneCh is closed once the snapshot is fully spooled. The fields
+	// below are only valid after that.
+	doneCh chan struct{}
+	meta   structs.QueryMeta
+	size   int64
+	err    error
+}
+
+// newSnapshotSpool returns a spool keeping the snapshots in the given
+// directory. The snapshots left behind by a previous run can't be resumed
+// anymore, so they are removed.
+func newSnapshotSpool(dir string, logger *log.Logger) *snapshotSpool {
+	if err := os.RemoveAll(dir); err != nil {
+		logger.Printf("[WARN] agent: failed to remove spooled snapshots: %v", err)
+	}
+	return &snapshotSpool{
+		dir:       dir,
+		logger:    logger,
+		snapshots: make(map[string]*spooledSnapshot),
+	}
+}
+
+// create starts spooling a new snapshot, which can only be downloaded with
+// the given token, and returns the file to write it to.
+func (s *snapshotSpool) create(token string) (*spooledSnapshot, *os.File, error) {
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return nil, nil, err
+	}
+	f, err := ioutil.TempFile(s.dir, "snapshot")
+	if err != nil {
+		return nil, nil, err
+	}
+	snap := &spooledSnapshot{
+		id:      id,
+		token:   token,
+		path:    f.Name(),
+		created: time.Now(),
+		doneCh:  make(chan struct{}),
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for len(s.snapshots) >= snapshotSpoolMax {
+		var oldest *spooledSnapshot
+		for _, other := range s.snapshots {
+			if oldest == nil || other.created.Before(oldest.created) {
+				oldest = other
+			}
+		}
+		s.removeLocked(oldest.id)
+	}
+	s.snapshots[id] = snap
+	snap.expiry = time.AfterFunc(snapshotSpoolTTL, func() { s.remove(id) })
+	return snap, f, nil
+}
+
+// finish records the outcome of spooling the snapshot. The snapshots which
+// failed to save or to spool are removed.
+func (s *snapshotSpool) finish(snap *spooledSnapshot, size int64, err error) {
+	snap.size, snap.err = size, err
+	close(snap.doneCh)
+	if err != nil {
+		s.remove(snap.id)
+	}
+}
+
+// get returns the spooled snapshot with the given ID once it's fully
+// spooled, and extends its expiry. It returns nil if the snapshot expired or
+// if the token isn't the one it was saved with. The caller must check that
+// the token is still allowed to save snapshots.
+func (s *snapshotSpool) get(id, token string) (*spooledSnapshot, error) {
+	s.lock.Lock()
+	snap := s.snapshots[id]
+	s.lock.Unlock()
+
+	if snap == nil || subtle.ConstantTimeCompare([]byte(snap.token), []byte(token)) != 1 {
+		return nil, nil
+	}
+	<-snap.doneCh
+	if snap.err != nil {
+		return nil, snap.err
+	}
+	snap.expiry.Reset(snapshotSpoolTTL)
+	return snap, nil
+}
+
+// remove deletes the spooled snapshot with the given ID.
+func (s *snapshotSpool) remove(id string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.removeLocked(id)
+}
+
+func (s *snapshotSpool) removeLocked(id string) {
+	snap, ok := s.snapshots[id]
+	if !ok {
+		return
+	}
+	delete(s.snapshots, id)
+	snap.expiry.Stop()
+	if err := os.Remove(snap.path); err != nil && !os.IsNotExist(err) {
+		s.logger.Printf("[WARN] agent: failed to remove spooled snapshot: %v", err)
+	}
+}
+
+// Close removes all the spooled snapshots.
+func (s *snapshotSpool) Close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for id := range s.snapshots {
+		s.removeLocked(id)
+	}
+}
+
+// snapshotSpoolWriter writes a snapshot to its spool file and to the client
+// downloading it. Errors writing to either of them are only recorded, so that
+// the snapshot is fully spooled even if the download is interrupted, and
+// fully downloaded even if it can't be spooled. The write only fails once
+// both of them failed.
+type snapshotSpoolWriter struct {
+	file   io.Writer
+	client io.Writer
+	size   int64
+
+	fileErr   error
+	clientErr error
+}
+
+func (w *snapshotSpoolWriter) Write(p []byte) (int, error) {
+	if w.fileErr == nil {
+		var n int
+		n, w.fileErr = w.file.Write(p)
+		w.size += int64(n)
+	}
+	if w.clientErr == nil {
+		_, w.clientErr = w.client.Write(p)
+	}
+	if w.fileErr != nil && w.clientErr != nil {
+		return 0, w.clientErr
+	}
+	return len(p), nil
+}
@@ -84,12 +84,38 @@ session_prefix "" {
 	// This is the policy ID for anonymous access. This is configurable by the
 	// user.
 	ACLTokenAnonymousID = "00000000-0000-0000-0000-000000000002"
+
+	// ACLDefaultNamespace is the namespace of the tokens and policies that
+	// were created without one.
+	ACLDefaultNamespace = "default"
+
+	// ACLWildcardNamespace can be used when listing tokens and policies to
+	// return the ones of all the namespaces.
+	ACLWildcardNamespace = "*"
 )
 
 func ACLIDReserved(id string) bool {
 	return strings.HasPrefix(id, "00000000-0000-0000-0000-0000000000")
 }
 
+// ACLNamespaceOrDefault returns the given namespace, or ACLDefaultNamespace
+// if it is empty.
+func ACLNamespaceOrDefault(ns string) string {
+	if ns == "" {
+		return ACLDefaultNamespace
+	}
+	return ns
+}
+
+// ACLNamespaceMatches returns whether an object in namespace ns is matched
+// by the namespace filter of a request.
+func ACLNamespaceMatches(filter, ns string) bool {
+	if filter == ACLWildcardNamespace {
+		return true
+	}
+	return strings.EqualFold(ACLNamespaceOrDefault(filter), ACLNamespaceOrDefault(ns))
+}
+
 const (
 	// ACLSet creates or updates a token.
 	ACLSet ACLOp = "set"
@@ -130,6 +156,15 @@ type ACLToken struct {
 	// Human readable string to display for the token (Optional)
 	Description string
 
+	// Namespace the token belongs to. Tokens can only be linked to policies
+	// of their own namespace. Empty means ACLDefaultNamespace.
+	Namespace string `json:",omitempty"`
+
+	// NamePrefix restricts the KV writes and the service registrations
+	// allowed by the policies of the token to the keys and service names
+	// starting with it. Empty means no restriction.
+	NamePrefix string `json:",omitempty"`
+
 	// List of policy links - nil/empty for legacy tokens
 	// Note this is the list of IDs and not the names. Prior to token creation
 	// the list of policy names gets validated and the policy IDs get stored herein
@@ -150,6 +185,10 @@ type ACLToken struct {
 	// to the ACL datacenter and replicated to others.
 	Local bool
 
+	// Labels are arbitrary key/value pairs organizing the tokens, which can
+	// be listed with a label selector.
+	Labels map[string]string `json:",omitempty"`
+
 	// The time when this token was created
 	CreateTime time.Time `json:",omitempty"`
 
@@ -234,6 +273,16 @@ func (t *ACLToken) SetHash(force bool) []byte {
 		hash.Write([]byte(t.Type))
 		hash.Write([]byte(t.Rules))
 
+		// The default namespace isn't hashed so the hashes of the tokens
+		// created before namespaces existed don't change.
+		if ns := ACLNamespaceOrDefault(t.Namespace); ns != ACLDefaultNamespace {
+			hash.Write([]byte(ns))
+		}
+
+		if t.NamePrefix != "" {
+			hash.Write([]byte(t.NamePrefix))
+		}
+
 		if t.Local {
 			hash.Write([]byte("local"))
 		} else {
@@ -244,6 +293,8 @@ func (t *ACLToken) SetHash(force bool) []byte {
 			hash.Write([]byte(link.ID))
 		}
 
+		writeLabelsHash(hash, t.Labels)
+
 		// Finalize the hash
 		hashVal := hash.Sum(nil)
 
@@ -255,10 +306,13 @@ func (t *ACLToken) SetHash(force bool) []byte {
 
 func (t *ACLToken) EstimateSize() int {
 	// 33 = 16 (RaftIndex) + 8 (Hash) + 8 (CreateTime) + 1 (Local)
-	size := 33 + len(t.AccessorID) + len(t.SecretID) + len(t.Description) + len(t.Type) + len(t.Rules)
+	size := 33 + len(t.AccessorID) + len(t.SecretID) + len(t.Description) + len(t.Namespace) + len(t.NamePrefix) + len(t.Type) + len(t.Rules)
 	for _, link := range t.Policies {
 		size += len(link.ID) + len(link.Name)
 	}
+	for k, v := range t.Labels {
+		size += len(k) + len(v)
+	}
 	return size
 }
 
@@ -268,9 +322,12 @@ type ACLTokens []*ACLToken
 type ACLTokenListStub struct {
 	AccessorID  string
 	Description string
+	Namespace   string `json:",omitempty"`
+	NamePrefix  string `json:",omitempty"`
 	Policies    []ACLTokenPolicyLink
 	Local       bool
-	CreateTime  time.Time `json:",omitempty"`
+	Labels      map[string]string `json:",omitempty"`
+	CreateTime  time.Time         `json:",omitempty"`
 	Hash        []byte
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -283,8 +340,11 @@ func (token *ACLToken) Stub() *ACLTokenListStub {
 	return &ACLTokenListStub{
 		AccessorID:  token.AccessorID,
 		Description: token.Description,
+		Namespace:   token.Namespace,
+		NamePrefix:  token.NamePrefix,
 		Policies:    token.Policies,
 		Local:       token.Local,
+		Labels:      token.Labels,
 		CreateTime:  token.CreateTime,
 		Hash:        token.Hash,
 		CreateIndex: token.CreateIndex,
@@ -317,6 +377,10 @@ type ACLPolicy struct {
 	// Human readable description (Optional)
 	Description string
 
+	// Namespace the policy belongs to, names are unique within a namespace.
+	// Empty means ACLDefaultNamespace.
+	Namespace string `json:",omitempty"`
+
 	// The rule set (using the updated rule syntax)
 	Rules string
 
@@ -328,6 +392,15 @@ type ACLPolicy struct {
 	//   - If empty then the policy is valid within all datacenters
 	Datacenters []string `json:",omitempty"`
 
+	// DeleteAfter is the time after which the policy is deleted, for
+	// temporary grants. The policy stops granting access at that time,
+	// even before the leader gets to delete it. Nil means never.
+	DeleteAfter *time.Time `json:",omitempty"`
+
+	// Labels are arbitrary key/value pairs organizing the policies, which
+	// can be listed with a label selector.
+	Labels map[string]string `json:",omitempty"`
+
 	// Hash of the contents of the policy
 	// This does not take into account the ID (which is immutable)
 	// nor the raft metadata.
@@ -348,14 +421,32 @@ func (p *ACLPolicy) Clone() *ACLPolicy {
 		p2.Datacenters = make([]string, len(p.Datacenters))
 		copy(p2.Datacenters, p.Datacenters)
 	}
+	if p.DeleteAfter != nil {
+		deleteAfter := *p.DeleteAfter
+		p2.DeleteAfter = &deleteAfter
+	}
+	if p.Labels != nil {
+		p2.Labels = make(map[string]string, len(p.Labels))
+		for k, v := range p.Labels {
+			p2.Labels[k] = v
+		}
+	}
 	return &p2
 }
 
+// IsExpired returns true if the DeleteAfter time of the policy has passed.
+func (p *ACLPolicy) IsExpired(now time.Time) bool {
+	return p.DeleteAfter != nil && !now.Before(*p.DeleteAfter)
+}
+
 type ACLPolicyListStub struct {
 	ID          string
 	Name        string
 	Description string
+	Namespace   string `json:",omitempty"`
 	Datacenters []string
+	DeleteAfter *time.Time        `json:",omitempty"`
+	Labels      map[string]string `json:",omitempty"`
 	Hash        []byte
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -366,7 +457,10 @@ func (p *ACLPolicy) Stub() *ACLPolicyListStub {
 		ID:          p.ID,
 		Name:        p.Name,
 		Description: p.Description,
+		Namespace:   p.Namespace,
 		Datacenters: p.Datacenters,
+		DeleteAfter: p.DeleteAfter,
+		Labels:      p.Labels,
 		Hash:        p.Hash,
 		CreateIndex: p.CreateIndex,
 		ModifyIndex: p.ModifyIndex,
@@ -388,9 +482,16 @@ func (p *ACLPolicy) SetHash(force bool) []byte {
 		hash.Write([]byte(p.Name))
 		hash.Write([]byte(p.Description))
 		hash.Write([]byte(p.Rules))
+		if ns := ACLNamespaceOrDefault(p.Namespace); ns != ACLDefaultNamespace {
+			hash.Write([]byte(ns))
+		}
 		for _, dc := range p.Datacenters {
 			hash.Write([]byte(dc))
 		}
+		if p.DeleteAfter != nil {
+			hash.Write([]byte(p.DeleteAfter.UTC().Format(time.RFC3339Nano)))
+		}
+		writeLabelsHash(hash, p.Labels)
 
 		// Finalize the hash
 		hashVal := hash.Sum(nil)
@@ -406,10 +507,13 @@ func (p *ACLPolicy) EstimateSize() int {
 	// pointers etc that this does not account for.
 
 	// 64 = 36 (uuid) + 16 (RaftIndex) + 8 (Hash) + 4 (Syntax)
-	size := 64 + len(p.Name) + len(p.Description) + len(p.Rules)
+	size := 64 + len(p.Name) + len(p.Description) + len(p.Namespace) + len(p.Rules)
 	for _, dc := range p.Datacenters {
 		size += len(dc)
 	}
+	for k, v := range p.Labels {
+		size += len(k) + len(v)
+	}
 
 	return size
 }
@@ -537,6 +641,7 @@ func (r *ACLTokenSetRequest) RequestDatacenter() string {
 type ACLTokenGetRequest struct {
 	TokenID     string         // id used for the token lookup
 	TokenIDType ACLTokenIDType // The Type of ID used to lookup the token
+	Namespace   string         // The namespace of the token
 	Datacenter  string         // The datacenter to perform the request within
 	QueryOptions
 }
@@ -548,6 +653,7 @@ func (r *ACLTokenGetRequest) RequestDatacenter() string {
 // ACLTokenDeleteRequest is used for token deletion operations at the RPC layer
 type ACLTokenDeleteRequest struct {
 	TokenID    string // ID of the token to delete
+	Namespace  string // The namespace of the token
 	Datacenter string // The datacenter to perform the request within
 	WriteRequest
 }
@@ -556,11 +662,26 @@ func (r *ACLTokenDeleteRequest) RequestDatacenter() string {
 	return r.Datacenter
 }
 
+// ACLServiceTokenRequest is used to create or delete the token of a service
+// at the RPC layer
+type ACLServiceTokenRequest struct {
+	ServiceName string   // Name of the service the token is for
+	Node        string   // Name of the node the service is registered on
+	Upstreams   []string // Names of the upstream services of the service
+	Datacenter  string   // The datacenter to perform the request within
+	WriteRequest
+}
+
+func (r *ACLServiceTokenRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
 // ACLTokenListRequest is used for token listing operations at the RPC layer
 type ACLTokenListRequest struct {
 	IncludeLocal  bool   // Whether local tokens should be included
 	IncludeGlobal bool   // Whether global tokens should be included
 	Policy        string // Policy filter
+	Namespace     string // Namespace filter, ACLWildcardNamespace for all
 	Datacenter    string // The datacenter to perform the request within
 	QueryOptions
 }
@@ -647,6 +768,7 @@ func (r *ACLPolicySetRequest) RequestDatacenter() string {
 // ACLPolicyDeleteRequest is used at the RPC layer deletion requests
 type ACLPolicyDeleteRequest struct {
 	PolicyID   string // The id of the policy to delete
+	Namespace  string // The namespace of the policy
 	Datacenter string // The datacenter to perform the request within
 	WriteRequest
 }
@@ -658,6 +780,7 @@ func (r *ACLPolicyDeleteRequest) RequestDatacenter() string {
 // ACLPolicyGetRequest is used at the RPC layer to perform policy read operations
 type ACLPolicyGetRequest struct {
 	PolicyID   string // id used for the policy lookup
+	Namespace  string // The namespace of the policy
 	Datacenter string // The datacenter to perform the request within
 	QueryOptions
 }
@@ -668,7 +791,13 @@ func (r *ACLPolicyGetRequest) RequestDatacenter() string {
 
 // ACLPolicyListRequest is used at the RPC layer to request a listing of policies
 type ACLPolicyListRequest struct {
+	Namespace  string // Namespace filter, ACLWildcardNamespace for all
 	Datacenter string // The datacenter to perform the request within
+
+	// ExpiresWithin filters the policies to the ones deleted within this
+	// duration, when set.
+	ExpiresWithin time.Duration
+
 	QueryOptions
 }
 
@@ -1,6 +1,7 @@
 package structs
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/hashicorp/consul/acl"
@@ -221,3 +222,24 @@ func (c *ACLCaches) Purge() {
 		}
 	}
 }
+
+// Stats returns the number of entries held by each of the caches, used by
+// the agent's stats output.
+func (c *ACLCaches) Stats() map[string]string {
+	size := func(cache *lru.TwoQueueCache) string {
+		if cache == nil {
+			return "0"
+		}
+		return strconv.Itoa(cache.Len())
+	}
+
+	if c == nil {
+		c = &ACLCaches{}
+	}
+	return map[string]string{
+		"identities":      size(c.identities),
+		"policies":        size(c.policies),
+		"parsed_policies": size(c.parsedPolicies),
+		"authorizers":     size(c.authorizers),
+	}
+}
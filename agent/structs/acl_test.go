@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/acl"
 
@@ -341,6 +342,33 @@ func TestStructs_ACLPolicy_Stub(t *testing.T) {
 	require.Equal(t, policy.ModifyIndex, stub.ModifyIndex)
 }
 
+func TestStructs_ACLPolicy_IsExpired(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	policy := &ACLPolicy{Name: "test"}
+	require.False(t, policy.IsExpired(now))
+
+	deleteAfter := now.Add(time.Hour)
+	policy.DeleteAfter = &deleteAfter
+	require.False(t, policy.IsExpired(now))
+	require.True(t, policy.IsExpired(deleteAfter))
+	require.True(t, policy.IsExpired(now.Add(2*time.Hour)))
+
+	// The stub and the clone keep the deletion time
+	require.Equal(t, policy.DeleteAfter, policy.Stub().DeleteAfter)
+	clone := policy.Clone()
+	require.Equal(t, deleteAfter, *clone.DeleteAfter)
+
+	// Which is part of the hash
+	hash := policy.SetHash(true)
+	later := deleteAfter.Add(time.Hour)
+	clone.DeleteAfter = &later
+	require.NotEqual(t, hash, clone.SetHash(true))
+	clone.DeleteAfter = nil
+	require.NotEqual(t, hash, clone.SetHash(true))
+}
+
 func TestStructs_ACLPolicy_SetHash(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,59 @@
+package structs
+
+import (
+	"time"
+)
+
+// AutoConfigRequest is the request of a client agent for its configuration
+// with auto-config.
+type AutoConfigRequest struct {
+	// Datacenter, Node and Segment identify the agent.
+	Datacenter string
+	Node       string
+	Segment    string
+
+	// JWT is the introduction token which authorizes the request.
+	JWT string
+
+	// CSR is the PEM-encoded CSR of the certificate of the RPC connections
+	// of the agent. The certificate is only issued when Connect is enabled
+	// on the servers.
+	CSR string
+
+	WriteRequest
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (r *AutoConfigRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+// AutoConfigResponse is the configuration handed to a client agent with
+// auto-config.
+type AutoConfigResponse struct {
+	// GossipEncryptionKey is the primary key of the LAN pool, empty if
+	// gossip encryption isn't enabled.
+	GossipEncryptionKey string
+
+	// ACL holds the ACL settings of the servers and the agent token.
+	ACL AutoConfigACL
+
+	// TLS is the certificate of the RPC connections of the agent, nil when
+	// Connect isn't enabled.
+	TLS *SignedResponse
+}
+
+// AutoConfigACL is the ACL configuration handed to a client agent with
+// auto-config.
+type AutoConfigACL struct {
+	Enabled             bool
+	PrimaryDatacenter   string
+	DefaultPolicy       string
+	DownPolicy          string
+	TokenTTL            time.Duration
+	PolicyTTL           time.Duration
+	EnableKeyListPolicy bool
+
+	// AgentToken is the token of the agent, created for its node.
+	AgentToken string
+}
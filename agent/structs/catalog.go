@@ -13,6 +13,17 @@ const (
 	SerfCheckFailedOutput               = "Agent not live or unreachable"
 )
 
+// These are used to manage the built-in check reporting the health of the
+// ACL replication on the servers of the secondary datacenters. The check is
+// attached to a dedicated service so a failing replication doesn't mark the
+// node and all its services critical.
+const (
+	ACLReplicationCheckID     types.CheckID = "aclReplication"
+	ACLReplicationCheckName                 = "ACL Replication Status"
+	ACLReplicationServiceID                 = "acl-replication"
+	ACLReplicationServiceName               = "acl-replication"
+)
+
 const (
 	// These are used to manage the "consul" service that's attached to every
 	// Consul server node in the catalog.
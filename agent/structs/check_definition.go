@@ -26,14 +26,20 @@ type CheckDefinition struct {
 	Header                         map[string][]string
 	Method                         string
 	TCP                            string
+	TCPUseTLS                      bool
 	Interval                       time.Duration
 	DockerContainerID              string
 	Shell                          string
 	GRPC                           string
 	GRPCUseTLS                     bool
 	TLSSkipVerify                  bool
+	TLSServerName                  string
+	TLSCAFile                      string
+	TLSCertFile                    string
+	TLSKeyFile                     string
 	AliasNode                      string
 	AliasService                   string
+	Composite                      string
 	Timeout                        time.Duration
 	TTL                            time.Duration
 	DeregisterCriticalServiceAfter time.Duration
@@ -67,16 +73,22 @@ func (c *CheckDefinition) CheckType() *CheckType {
 		ScriptArgs:                     c.ScriptArgs,
 		AliasNode:                      c.AliasNode,
 		AliasService:                   c.AliasService,
+		Composite:                      c.Composite,
 		HTTP:                           c.HTTP,
 		GRPC:                           c.GRPC,
 		GRPCUseTLS:                     c.GRPCUseTLS,
 		Header:                         c.Header,
 		Method:                         c.Method,
 		TCP:                            c.TCP,
+		TCPUseTLS:                      c.TCPUseTLS,
 		Interval:                       c.Interval,
 		DockerContainerID:              c.DockerContainerID,
 		Shell:                          c.Shell,
 		TLSSkipVerify:                  c.TLSSkipVerify,
+		TLSServerName:                  c.TLSServerName,
+		TLSCAFile:                      c.TLSCAFile,
+		TLSCertFile:                    c.TLSCertFile,
+		TLSKeyFile:                     c.TLSKeyFile,
 		Timeout:                        c.Timeout,
 		TTL:                            c.TTL,
 		DeregisterCriticalServiceAfter: c.DeregisterCriticalServiceAfter,
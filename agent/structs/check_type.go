@@ -9,10 +9,10 @@ import (
 )
 
 // CheckType is used to create either the CheckMonitor or the CheckTTL.
-// The following types are supported: Script, HTTP, TCP, Docker, TTL, GRPC, Alias. Script,
-// HTTP, Docker, TCP and GRPC all require Interval. Only one of the types may
-// to be provided: TTL or Script/Interval or HTTP/Interval or TCP/Interval or
-// Docker/Interval or GRPC/Interval or AliasService.
+// The following types are supported: Script, HTTP, TCP, Docker, TTL, GRPC, Alias,
+// Composite. Script, HTTP, Docker, TCP and GRPC all require Interval. Only one of
+// the types may to be provided: TTL or Script/Interval or HTTP/Interval or
+// TCP/Interval or Docker/Interval or GRPC/Interval or AliasService or Composite.
 type CheckType struct {
 	// fields already embedded in CheckDefinition
 	// Note: CheckType.CheckID == CheckDefinition.ID
@@ -30,14 +30,20 @@ type CheckType struct {
 	Header            map[string][]string
 	Method            string
 	TCP               string
+	TCPUseTLS         bool
 	Interval          time.Duration
 	AliasNode         string
 	AliasService      string
+	Composite         string
 	DockerContainerID string
 	Shell             string
 	GRPC              string
 	GRPCUseTLS        bool
 	TLSSkipVerify     bool
+	TLSServerName     string
+	TLSCAFile         string
+	TLSCertFile       string
+	TLSKeyFile        string
 	Timeout           time.Duration
 	TTL               time.Duration
 
@@ -64,7 +70,13 @@ func (c *CheckType) Validate() error {
 	if c.IsAlias() && c.TTL > 0 {
 		return fmt.Errorf("TTL must be not be set for Alias checks")
 	}
-	if !intervalCheck && !c.IsAlias() && c.TTL <= 0 {
+	if c.IsComposite() && (intervalCheck || c.IsAlias()) {
+		return fmt.Errorf("Composite checks cannot be combined with other check types")
+	}
+	if c.IsComposite() && (c.Interval > 0 || c.TTL > 0) {
+		return fmt.Errorf("Interval and TTL must not be set for Composite checks")
+	}
+	if !intervalCheck && !c.IsAlias() && !c.IsComposite() && c.TTL <= 0 {
 		return fmt.Errorf("TTL must be > 0 for TTL checks")
 	}
 	return nil
@@ -80,6 +92,11 @@ func (c *CheckType) IsAlias() bool {
 	return c.AliasNode != "" || c.AliasService != ""
 }
 
+// IsComposite checks if this is a composite check.
+func (c *CheckType) IsComposite() bool {
+	return c.Composite != ""
+}
+
 // IsScript checks if this is a check that execs some kind of script.
 func (c *CheckType) IsScript() bool {
 	return len(c.ScriptArgs) > 0
@@ -1,22 +1,33 @@
 package structs
 
 import (
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/go-msgpack/codec"
+	"github.com/mitchellh/copystructure"
+	"github.com/mitchellh/hashstructure"
+	"github.com/mitchellh/reflectwalk"
 )
 
 const (
-	ServiceDefaults string = "service-defaults"
-	ProxyDefaults   string = "proxy-defaults"
+	ServiceDefaults    string = "service-defaults"
+	ProxyDefaults      string = "proxy-defaults"
+	ServiceResolver    string = "service-resolver"
+	IngressGateway     string = "ingress-gateway"
+	TerminatingGateway string = "terminating-gateway"
 
 	ProxyConfigGlobal string = "global"
 
 	DefaultServiceProtocol = "tcp"
 )
 
-// ConfigEntry is the
+// ConfigEntry is the interface implemented by the config entries of all
+// kinds.
 type ConfigEntry interface {
 	GetKind() string
 	GetName() string
@@ -37,6 +48,10 @@ type ServiceConfigEntry struct {
 	Connect                   ConnectConfiguration
 	ServiceDefinitionDefaults ServiceDefinitionDefaults
 
+	// MeshGateway defines how the proxies reach the service when it is in
+	// another datacenter, unless their upstream sets its own mode.
+	MeshGateway MeshGatewayConfig `json:",omitempty"`
+
 	RaftIndex
 }
 
@@ -68,7 +83,15 @@ func (e *ServiceConfigEntry) Normalize() error {
 }
 
 func (e *ServiceConfigEntry) Validate() error {
-	return nil
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	if e.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+
+	return e.MeshGateway.Validate()
 }
 
 func (e *ServiceConfigEntry) GetRaftIndex() *RaftIndex {
@@ -109,6 +132,11 @@ type ProxyConfigEntry struct {
 	Name   string
 	Config map[string]interface{}
 
+	// MeshGateway defines how the proxies reach the upstreams in other
+	// datacenters when neither the upstream nor its service defaults set a
+	// mode.
+	MeshGateway MeshGatewayConfig `json:",omitempty"`
+
 	RaftIndex
 }
 
@@ -143,7 +171,7 @@ func (e *ProxyConfigEntry) Validate() error {
 		return fmt.Errorf("invalid name (%q), only %q is supported", e.Name, ProxyConfigGlobal)
 	}
 
-	return nil
+	return e.MeshGateway.Validate()
 }
 
 func (e *ProxyConfigEntry) GetRaftIndex() *RaftIndex {
@@ -154,6 +182,32 @@ func (e *ProxyConfigEntry) GetRaftIndex() *RaftIndex {
 	return &e.RaftIndex
 }
 
+func (e *ProxyConfigEntry) MarshalJSON() ([]byte, error) {
+	type typeCopy ProxyConfigEntry
+	copy := typeCopy(*e)
+
+	// The config may have been decoded from msgpack, so it's run through
+	// the proxyConfigWalker to make it safe for JSON like the config of
+	// the proxies in service definitions.
+	if copy.Config != nil {
+		configCopyRaw, err := copystructure.Copy(copy.Config)
+		if err != nil {
+			return nil, err
+		}
+		configCopy, ok := configCopyRaw.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("internal error: config copy is not right type")
+		}
+		if err := reflectwalk.Walk(configCopy, &proxyConfigWalker{}); err != nil {
+			return nil, err
+		}
+
+		copy.Config = configCopy
+	}
+
+	return json.Marshal(&copy)
+}
+
 type ConfigEntryOp string
 
 const (
@@ -161,9 +215,27 @@ const (
 	ConfigEntryDelete ConfigEntryOp = "delete"
 )
 
+// ConfigEntryRequest is used to create, update or delete a config entry.
 type ConfigEntryRequest struct {
+	// Datacenter is the target for this request.
+	Datacenter string
+
 	Op    ConfigEntryOp
 	Entry ConfigEntry
+
+	// Actor and Timestamp are who made the request and when, recorded in
+	// the changelog.
+	Actor     string
+	Timestamp time.Time
+
+	// WriteRequest is a common struct containing ACL tokens and other
+	// write-related common elements for requests.
+	WriteRequest
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (r *ConfigEntryRequest) RequestDatacenter() string {
+	return r.Datacenter
 }
 
 func (r *ConfigEntryRequest) MarshalBinary() (data []byte, err error) {
@@ -198,7 +270,7 @@ func (r *ConfigEntryRequest) UnmarshalBinary(data []byte) error {
 	}
 
 	// Then decode the real thing with appropriate kind of ConfigEntry
-	entry, err := makeConfigEntry(kind)
+	entry, err := MakeConfigEntry(kind, "")
 	if err != nil {
 		return err
 	}
@@ -218,13 +290,252 @@ func (r *ConfigEntryRequest) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
-func makeConfigEntry(kind string) (ConfigEntry, error) {
+// MakeConfigEntry returns an empty config entry of the given kind with the
+// given name.
+func MakeConfigEntry(kind, name string) (ConfigEntry, error) {
 	switch kind {
 	case ServiceDefaults:
-		return &ServiceConfigEntry{}, nil
+		return &ServiceConfigEntry{Name: name}, nil
 	case ProxyDefaults:
-		return &ProxyConfigEntry{}, nil
+		return &ProxyConfigEntry{Name: name}, nil
+	case ServiceResolver:
+		return &ServiceResolverConfigEntry{Name: name}, nil
+	case IngressGateway:
+		return &IngressGatewayConfigEntry{Name: name}, nil
+	case TerminatingGateway:
+		return &TerminatingGatewayConfigEntry{Name: name}, nil
 	default:
 		return nil, fmt.Errorf("invalid config entry kind: %s", kind)
 	}
 }
+
+// DecodeConfigEntryJSON decodes a JSON encoded config entry, the type of
+// the entry depending on its Kind field.
+func DecodeConfigEntryJSON(data []byte) (ConfigEntry, error) {
+	var header struct {
+		Kind string
+	}
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, err
+	}
+	entry, err := MakeConfigEntry(header.Kind, "")
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// ConfigEntryQuery is used to read a single config entry, or to list the
+// config entries of a kind if the name is empty.
+type ConfigEntryQuery struct {
+	Kind string
+	Name string
+
+	// Datacenter is the target for this request.
+	Datacenter string
+
+	QueryOptions
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (r *ConfigEntryQuery) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+func (r *ConfigEntryQuery) CacheInfo() cache.RequestInfo {
+	info := cache.RequestInfo{
+		Token:          r.Token,
+		Datacenter:     r.Datacenter,
+		MinIndex:       r.MinQueryIndex,
+		Timeout:        r.MaxQueryTime,
+		MaxAge:         r.MaxAge,
+		MustRevalidate: r.MustRevalidate,
+	}
+
+	v, err := hashstructure.Hash([]interface{}{
+		r.Kind,
+		r.Name,
+	}, nil)
+	if err == nil {
+		// If there is an error, we don't set the key. A blank key forces
+		// no cache for this request so the request is forwarded directly
+		// to the server.
+		info.Key = strconv.FormatUint(v, 10)
+	}
+
+	return info
+}
+
+// ConfigEntryResponse is the response to reading a single config entry.
+// Entry is nil if it doesn't exist.
+type ConfigEntryResponse struct {
+	Entry ConfigEntry
+	QueryMeta
+}
+
+func (r *ConfigEntryResponse) MarshalBinary() (data []byte, err error) {
+	// bs will grow if needed but allocate enough to avoid reallocation in common
+	// case.
+	bs := make([]byte, 128)
+	enc := codec.NewEncoderBytes(&bs, msgpackHandle)
+	// Encode the kind first, empty if there's no entry
+	kind := ""
+	if r.Entry != nil {
+		kind = r.Entry.GetKind()
+	}
+	if err := enc.Encode(kind); err != nil {
+		return nil, err
+	}
+	// Then actual value using alias trick to avoid infinite recursion
+	type Alias ConfigEntryResponse
+	if err := enc.Encode(struct {
+		*Alias
+	}{
+		Alias: (*Alias)(r),
+	}); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (r *ConfigEntryResponse) UnmarshalBinary(data []byte) error {
+	// First decode the kind prefix
+	var kind string
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	if err := dec.Decode(&kind); err != nil {
+		return err
+	}
+
+	// Then decode the real thing with appropriate kind of ConfigEntry
+	r.Entry = nil
+	if kind != "" {
+		entry, err := MakeConfigEntry(kind, "")
+		if err != nil {
+			return err
+		}
+		r.Entry = entry
+	}
+
+	// Alias juggling to prevent infinite recursive calls back to this decode
+	// method.
+	type Alias ConfigEntryResponse
+	as := struct {
+		*Alias
+	}{
+		Alias: (*Alias)(r),
+	}
+	return dec.Decode(&as)
+}
+
+// IndexedConfigEntries is the response to listing the config entries of a
+// kind.
+type IndexedConfigEntries struct {
+	Kind    string
+	Entries []ConfigEntry
+	QueryMeta
+}
+
+func (c *IndexedConfigEntries) MarshalBinary() (data []byte, err error) {
+	// bs will grow if needed but allocate enough to avoid reallocation in common
+	// case.
+	bs := make([]byte, 128)
+	enc := codec.NewEncoderBytes(&bs, msgpackHandle)
+	// Encode the number of entries and their kind first
+	if err := enc.Encode(len(c.Entries)); err != nil {
+		return nil, err
+	}
+	if err := enc.Encode(c.Kind); err != nil {
+		return nil, err
+	}
+	// Then actual value using alias trick to avoid infinite recursion
+	type Alias IndexedConfigEntries
+	if err := enc.Encode(struct {
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}); err != nil {
+		return nil, err
+	}
+	return bs, nil
+}
+
+func (c *IndexedConfigEntries) UnmarshalBinary(data []byte) error {
+	// First decode the number of entries and their kind
+	var numEntries int
+	dec := codec.NewDecoderBytes(data, msgpackHandle)
+	if err := dec.Decode(&numEntries); err != nil {
+		return err
+	}
+	var kind string
+	if err := dec.Decode(&kind); err != nil {
+		return err
+	}
+
+	// Then decode the entries into config entries of the appropriate kind
+	c.Entries = make([]ConfigEntry, numEntries)
+	for i := range c.Entries {
+		entry, err := MakeConfigEntry(kind, "")
+		if err != nil {
+			return err
+		}
+		c.Entries[i] = entry
+	}
+
+	// Alias juggling to prevent infinite recursive calls back to this decode
+	// method.
+	type Alias IndexedConfigEntries
+	as := struct {
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+	return dec.Decode(&as)
+}
+
+// ServiceConfigRequest is used to resolve the centrally managed config of a
+// service.
+type ServiceConfigRequest struct {
+	Name       string
+	Datacenter string
+
+	QueryOptions
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (r *ServiceConfigRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+func (r *ServiceConfigRequest) CacheInfo() cache.RequestInfo {
+	info := cache.RequestInfo{
+		Token:          r.Token,
+		Datacenter:     r.Datacenter,
+		MinIndex:       r.MinQueryIndex,
+		Timeout:        r.MaxQueryTime,
+		MaxAge:         r.MaxAge,
+		MustRevalidate: r.MustRevalidate,
+	}
+
+	v, err := hashstructure.Hash([]interface{}{
+		r.Name,
+	}, nil)
+	if err == nil {
+		// If there is an error, we don't set the key. A blank key forces
+		// no cache for this request so the request is forwarded directly
+		// to the server.
+		info.Key = strconv.FormatUint(v, 10)
+	}
+
+	return info
+}
+
+// ServiceConfigResponse is the centrally managed config of a service. The
+// ProxyConfig is the config of the global proxy-defaults, with the protocol
+// of the service-defaults of the service, if set.
+type ServiceConfigResponse struct {
+	ProxyConfig map[string]interface{}
+	QueryMeta
+}
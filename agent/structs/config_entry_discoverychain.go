@@ -0,0 +1,141 @@
+package structs
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ServiceResolverConfigEntry configures how the traffic sent to a service is
+// resolved to its healthy instances, and where it fails over to when there
+// are none in the datacenter of the discovery chain.
+type ServiceResolverConfigEntry struct {
+	Kind string
+
+	// Name is the name of the service.
+	Name string
+
+	// ConnectTimeout is the timeout of the connections to the instances of
+	// the service. Defaults to DefaultConnectTimeout.
+	ConnectTimeout time.Duration `json:",omitempty"`
+
+	// Failover defines the datacenters the traffic fails over to, if any.
+	Failover *ServiceResolverFailover `json:",omitempty"`
+
+	RaftIndex
+}
+
+// ServiceResolverFailover defines the datacenters the traffic sent to a
+// service fails over to, in order, when it has no healthy instances. Either
+// AllDatacenters or NearestN and Datacenters can be set.
+type ServiceResolverFailover struct {
+	// NearestN is the number of datacenters to fail over to first, the
+	// nearest first by the network coordinates of the servers.
+	NearestN int `json:",omitempty"`
+
+	// Datacenters is a fixed list of datacenters to fail over to after the
+	// NearestN ones. A datacenter is never listed more than once, so the
+	// NearestN ones are subtracted from this list.
+	Datacenters []string `json:",omitempty"`
+
+	// AllDatacenters fails over to all the federated datacenters, the
+	// nearest first.
+	AllDatacenters bool `json:",omitempty"`
+}
+
+// IsZero returns true if the failover doesn't fail over to any datacenter.
+func (f *ServiceResolverFailover) IsZero() bool {
+	return f == nil || (f.NearestN == 0 && len(f.Datacenters) == 0 && !f.AllDatacenters)
+}
+
+func (e *ServiceResolverConfigEntry) GetKind() string {
+	return ServiceResolver
+}
+
+func (e *ServiceResolverConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Name
+}
+
+func (e *ServiceResolverConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	e.Kind = ServiceResolver
+
+	return nil
+}
+
+func (e *ServiceResolverConfigEntry) Validate() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	if e.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+
+	if e.ConnectTimeout < 0 {
+		return fmt.Errorf("ConnectTimeout must not be negative")
+	}
+
+	if f := e.Failover; f != nil {
+		if f.NearestN < 0 {
+			return fmt.Errorf("Failover.NearestN must not be negative")
+		}
+		if f.AllDatacenters && (f.NearestN > 0 || len(f.Datacenters) > 0) {
+			return fmt.Errorf("Failover.AllDatacenters cannot be set with Failover.NearestN or Failover.Datacenters")
+		}
+		for _, dc := range f.Datacenters {
+			if dc == "" {
+				return fmt.Errorf("Failover.Datacenters cannot contain an empty datacenter")
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *ServiceResolverConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+
+	return &e.RaftIndex
+}
+
+// UnmarshalJSON accepts the ConnectTimeout as a duration string, such as
+// "5s", as well as a number of nanoseconds.
+func (e *ServiceResolverConfigEntry) UnmarshalJSON(data []byte) error {
+	type Alias ServiceResolverConfigEntry
+	aux := &struct {
+		ConnectTimeout interface{}
+		*Alias
+	}{
+		Alias: (*Alias)(e),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	switch v := aux.ConnectTimeout.(type) {
+	case nil:
+	case string:
+		if v == "" {
+			break
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("invalid ConnectTimeout: %v", err)
+		}
+		e.ConnectTimeout = d
+	case float64:
+		e.ConnectTimeout = time.Duration(v)
+	default:
+		return fmt.Errorf("invalid ConnectTimeout: %v", v)
+	}
+	return nil
+}
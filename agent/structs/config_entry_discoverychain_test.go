@@ -0,0 +1,121 @@
+package structs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceResolverConfigEntry_Validate(t *testing.T) {
+	cases := []struct {
+		name  string
+		entry *ServiceResolverConfigEntry
+		err   string
+	}{
+		{
+			name: "valid",
+			entry: &ServiceResolverConfigEntry{
+				Name:           "web",
+				ConnectTimeout: time.Second,
+				Failover: &ServiceResolverFailover{
+					NearestN:    2,
+					Datacenters: []string{"dc3", "dc4"},
+				},
+			},
+		},
+		{
+			name: "all datacenters",
+			entry: &ServiceResolverConfigEntry{
+				Name:     "web",
+				Failover: &ServiceResolverFailover{AllDatacenters: true},
+			},
+		},
+		{
+			name:  "missing name",
+			entry: &ServiceResolverConfigEntry{},
+			err:   "missing name",
+		},
+		{
+			name:  "negative connect timeout",
+			entry: &ServiceResolverConfigEntry{Name: "web", ConnectTimeout: -time.Second},
+			err:   "ConnectTimeout must not be negative",
+		},
+		{
+			name: "negative nearest",
+			entry: &ServiceResolverConfigEntry{
+				Name:     "web",
+				Failover: &ServiceResolverFailover{NearestN: -1},
+			},
+			err: "NearestN must not be negative",
+		},
+		{
+			name: "all datacenters with a list",
+			entry: &ServiceResolverConfigEntry{
+				Name: "web",
+				Failover: &ServiceResolverFailover{
+					AllDatacenters: true,
+					Datacenters:    []string{"dc2"},
+				},
+			},
+			err: "AllDatacenters cannot be set",
+		},
+		{
+			name: "empty datacenter",
+			entry: &ServiceResolverConfigEntry{
+				Name:     "web",
+				Failover: &ServiceResolverFailover{Datacenters: []string{""}},
+			},
+			err: "empty datacenter",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.entry.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestServiceResolverConfigEntry_UnmarshalJSON(t *testing.T) {
+	require := require.New(t)
+
+	entry, err := DecodeConfigEntryJSON([]byte(`{
+		"Kind": "service-resolver",
+		"Name": "web",
+		"ConnectTimeout": "2s",
+		"Failover": {"NearestN": 1, "Datacenters": ["dc3"]}
+	}`))
+	require.NoError(err)
+	require.Equal(&ServiceResolverConfigEntry{
+		Kind:           ServiceResolver,
+		Name:           "web",
+		ConnectTimeout: 2 * time.Second,
+		Failover: &ServiceResolverFailover{
+			NearestN:    1,
+			Datacenters: []string{"dc3"},
+		},
+	}, entry)
+
+	// The api client sends the durations as nanoseconds.
+	entry, err = DecodeConfigEntryJSON([]byte(`{"Kind": "service-resolver", "Name": "web", "ConnectTimeout": 2000000000}`))
+	require.NoError(err)
+	require.Equal(2*time.Second, entry.(*ServiceResolverConfigEntry).ConnectTimeout)
+
+	_, err = DecodeConfigEntryJSON([]byte(`{"Kind": "service-resolver", "Name": "web", "ConnectTimeout": "soon"}`))
+	require.Error(err)
+}
+
+func TestServiceResolverFailover_IsZero(t *testing.T) {
+	var f *ServiceResolverFailover
+	require.True(t, f.IsZero())
+	require.True(t, (&ServiceResolverFailover{}).IsZero())
+	require.False(t, (&ServiceResolverFailover{NearestN: 1}).IsZero())
+	require.False(t, (&ServiceResolverFailover{AllDatacenters: true}).IsZero())
+}
@@ -0,0 +1,282 @@
+package structs
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// IngressListenerProtocolTCP and IngressListenerProtocolHTTP are the
+	// protocols of the listeners of an ingress gateway.
+	IngressListenerProtocolTCP  = "tcp"
+	IngressListenerProtocolHTTP = "http"
+
+	// IngressWildcardHost is the host matching the requests not matched by
+	// the hosts of the other services of an HTTP listener.
+	IngressWildcardHost = "*"
+)
+
+// IngressGatewayConfigEntry configures the listeners of the ingress gateways
+// with the same service name, letting the traffic from outside the mesh
+// reach the Connect services.
+type IngressGatewayConfigEntry struct {
+	Kind string
+
+	// Name is the service name of the ingress gateways.
+	Name string
+
+	// Listeners are the ports the gateways listen on and the services they
+	// route the traffic received on them to.
+	Listeners []IngressListener
+
+	RaftIndex
+}
+
+// IngressListener is a port of an ingress gateway.
+type IngressListener struct {
+	// Port is the port the gateway listens on.
+	Port int
+
+	// Protocol is the protocol of the listener, "tcp" or "http". A TCP
+	// listener routes the connections to its only service, an HTTP listener
+	// routes the requests to its services by their Host header.
+	Protocol string
+
+	// Services are the services the traffic of the listener is routed to.
+	Services []IngressService
+}
+
+// IngressService is a service exposed by a listener of an ingress gateway.
+type IngressService struct {
+	// Name is the name of the service.
+	Name string
+
+	// Hosts are the hosts of the requests routed to the service by an HTTP
+	// listener. A service without hosts receives the requests not matching
+	// the hosts of the other services.
+	Hosts []string `json:",omitempty"`
+}
+
+// ServiceHosts returns the hosts of the requests routed to the service by an
+// HTTP listener, the wildcard host when it has none.
+func (s *IngressService) ServiceHosts() []string {
+	if len(s.Hosts) == 0 {
+		return []string{IngressWildcardHost}
+	}
+	return s.Hosts
+}
+
+func (e *IngressGatewayConfigEntry) GetKind() string {
+	return IngressGateway
+}
+
+func (e *IngressGatewayConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Name
+}
+
+func (e *IngressGatewayConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	e.Kind = IngressGateway
+	for i := range e.Listeners {
+		l := &e.Listeners[i]
+		if l.Protocol == "" {
+			l.Protocol = IngressListenerProtocolTCP
+		} else {
+			l.Protocol = strings.ToLower(l.Protocol)
+		}
+		for j := range l.Services {
+			for k, host := range l.Services[j].Hosts {
+				l.Services[j].Hosts[k] = strings.ToLower(host)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (e *IngressGatewayConfigEntry) Validate() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	if e.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+
+	ports := make(map[int]bool)
+	for _, l := range e.Listeners {
+		if l.Port < 1 || l.Port > 65535 {
+			return fmt.Errorf("invalid listener port %d", l.Port)
+		}
+		if ports[l.Port] {
+			return fmt.Errorf("port %d is used by more than one listener", l.Port)
+		}
+		ports[l.Port] = true
+
+		if len(l.Services) == 0 {
+			return fmt.Errorf("listener on port %d has no services", l.Port)
+		}
+
+		services := make(map[string]bool)
+		for _, s := range l.Services {
+			if s.Name == "" {
+				return fmt.Errorf("listener on port %d has a service without a name", l.Port)
+			}
+			if services[s.Name] {
+				return fmt.Errorf("service %q is listed more than once on port %d", s.Name, l.Port)
+			}
+			services[s.Name] = true
+		}
+
+		switch l.Protocol {
+		case IngressListenerProtocolTCP:
+			if len(l.Services) > 1 {
+				return fmt.Errorf("tcp listener on port %d can only have one service", l.Port)
+			}
+			if len(l.Services[0].Hosts) > 0 {
+				return fmt.Errorf("hosts cannot be set for the service of the tcp listener on port %d", l.Port)
+			}
+
+		case IngressListenerProtocolHTTP:
+			hosts := make(map[string]bool)
+			for _, s := range l.Services {
+				for _, host := range s.ServiceHosts() {
+					if host == "" {
+						return fmt.Errorf("service %q on port %d has an empty host", s.Name, l.Port)
+					}
+					if hosts[host] {
+						return fmt.Errorf("host %q is routed to more than one service on port %d", host, l.Port)
+					}
+					hosts[host] = true
+				}
+			}
+
+		default:
+			return fmt.Errorf("listener on port %d has an unsupported protocol %q", l.Port, l.Protocol)
+		}
+	}
+
+	return nil
+}
+
+func (e *IngressGatewayConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+
+	return &e.RaftIndex
+}
+
+// TerminatingGatewayConfigEntry configures the services linked to the
+// terminating gateways with the same service name. The Connect services reach
+// the linked services, which are outside the mesh, through the gateways.
+type TerminatingGatewayConfigEntry struct {
+	Kind string
+
+	// Name is the service name of the terminating gateways.
+	Name string
+
+	// Services are the services linked to the gateways.
+	Services []LinkedService
+
+	RaftIndex
+}
+
+// LinkedService is a service outside the mesh linked to a terminating
+// gateway.
+type LinkedService struct {
+	// Name is the name of the service, registered in the catalog.
+	Name string
+
+	// CAFile is the path of the CA certificates the gateway verifies the
+	// certificates of the service instances with. The gateway connects to
+	// the instances over TLS only when it is set.
+	CAFile string `json:",omitempty"`
+
+	// CertFile and KeyFile are the paths of the client certificate and key
+	// the gateway presents to the service instances, if they require one.
+	CertFile string `json:",omitempty"`
+	KeyFile  string `json:",omitempty"`
+
+	// SNI is the server name the gateway sends to the service instances.
+	SNI string `json:",omitempty"`
+}
+
+// LinkedService returns the linked service of the given name and whether it
+// is linked to the gateway.
+func (e *TerminatingGatewayConfigEntry) LinkedService(name string) (LinkedService, bool) {
+	for _, s := range e.Services {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return LinkedService{}, false
+}
+
+func (e *TerminatingGatewayConfigEntry) GetKind() string {
+	return TerminatingGateway
+}
+
+func (e *TerminatingGatewayConfigEntry) GetName() string {
+	if e == nil {
+		return ""
+	}
+
+	return e.Name
+}
+
+func (e *TerminatingGatewayConfigEntry) Normalize() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	e.Kind = TerminatingGateway
+
+	return nil
+}
+
+func (e *TerminatingGatewayConfigEntry) Validate() error {
+	if e == nil {
+		return fmt.Errorf("config entry is nil")
+	}
+
+	if e.Name == "" {
+		return fmt.Errorf("missing name")
+	}
+
+	seen := make(map[string]bool)
+	for _, s := range e.Services {
+		if s.Name == "" {
+			return fmt.Errorf("linked service without a name")
+		}
+		name := strings.ToLower(s.Name)
+		if seen[name] {
+			return fmt.Errorf("service %q is linked more than once", s.Name)
+		}
+		seen[name] = true
+
+		if (s.CertFile == "") != (s.KeyFile == "") {
+			return fmt.Errorf("service %q must have both a CertFile and a KeyFile, or neither", s.Name)
+		}
+		if s.CAFile == "" && (s.CertFile != "" || s.SNI != "") {
+			return fmt.Errorf("service %q must have a CAFile to set a CertFile or an SNI", s.Name)
+		}
+	}
+
+	return nil
+}
+
+func (e *TerminatingGatewayConfigEntry) GetRaftIndex() *RaftIndex {
+	if e == nil {
+		return &RaftIndex{}
+	}
+
+	return &e.RaftIndex
+}
@@ -0,0 +1,274 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngressGatewayConfigEntry_Normalize(t *testing.T) {
+	require := require.New(t)
+
+	entry := &IngressGatewayConfigEntry{
+		Name: "ingress",
+		Listeners: []IngressListener{
+			{Port: 8080, Services: []IngressService{{Name: "db"}}},
+			{
+				Port:     8443,
+				Protocol: "HTTP",
+				Services: []IngressService{{Name: "web", Hosts: []string{"Web.Example.com"}}},
+			},
+		},
+	}
+	require.NoError(entry.Normalize())
+	require.Equal(IngressGateway, entry.Kind)
+	require.Equal(IngressListenerProtocolTCP, entry.Listeners[0].Protocol)
+	require.Equal(IngressListenerProtocolHTTP, entry.Listeners[1].Protocol)
+	require.Equal([]string{"web.example.com"}, entry.Listeners[1].Services[0].Hosts)
+}
+
+func TestIngressGatewayConfigEntry_Validate(t *testing.T) {
+	cases := []struct {
+		name      string
+		listeners []IngressListener
+		err       string
+	}{
+		{
+			name: "valid",
+			listeners: []IngressListener{
+				{Port: 8080, Protocol: "tcp", Services: []IngressService{{Name: "db"}}},
+				{
+					Port:     8443,
+					Protocol: "http",
+					Services: []IngressService{
+						{Name: "web", Hosts: []string{"web.example.com", "www.example.com"}},
+						{Name: "api", Hosts: []string{"api.example.com"}},
+						{Name: "default"},
+					},
+				},
+			},
+		},
+		{
+			name:      "no listeners",
+			listeners: nil,
+		},
+		{
+			name:      "invalid port",
+			listeners: []IngressListener{{Port: 0, Protocol: "tcp", Services: []IngressService{{Name: "db"}}}},
+			err:       "invalid listener port",
+		},
+		{
+			name: "duplicate port",
+			listeners: []IngressListener{
+				{Port: 8080, Protocol: "tcp", Services: []IngressService{{Name: "db"}}},
+				{Port: 8080, Protocol: "tcp", Services: []IngressService{{Name: "cache"}}},
+			},
+			err: "more than one listener",
+		},
+		{
+			name:      "no services",
+			listeners: []IngressListener{{Port: 8080, Protocol: "tcp"}},
+			err:       "has no services",
+		},
+		{
+			name:      "service without name",
+			listeners: []IngressListener{{Port: 8080, Protocol: "tcp", Services: []IngressService{{}}}},
+			err:       "without a name",
+		},
+		{
+			name: "duplicate service",
+			listeners: []IngressListener{{
+				Port:     8080,
+				Protocol: "http",
+				Services: []IngressService{
+					{Name: "web", Hosts: []string{"a.example.com"}},
+					{Name: "web", Hosts: []string{"b.example.com"}},
+				},
+			}},
+			err: "listed more than once",
+		},
+		{
+			name: "tcp with many services",
+			listeners: []IngressListener{{
+				Port:     8080,
+				Protocol: "tcp",
+				Services: []IngressService{{Name: "db"}, {Name: "cache"}},
+			}},
+			err: "can only have one service",
+		},
+		{
+			name: "tcp with hosts",
+			listeners: []IngressListener{{
+				Port:     8080,
+				Protocol: "tcp",
+				Services: []IngressService{{Name: "db", Hosts: []string{"db.example.com"}}},
+			}},
+			err: "hosts cannot be set",
+		},
+		{
+			name: "duplicate host",
+			listeners: []IngressListener{{
+				Port:     8080,
+				Protocol: "http",
+				Services: []IngressService{
+					{Name: "web", Hosts: []string{"example.com"}},
+					{Name: "api", Hosts: []string{"example.com"}},
+				},
+			}},
+			err: "more than one service",
+		},
+		{
+			name: "many services without hosts",
+			listeners: []IngressListener{{
+				Port:     8080,
+				Protocol: "http",
+				Services: []IngressService{{Name: "web"}, {Name: "api"}},
+			}},
+			err: `host "*" is routed to more than one service`,
+		},
+		{
+			name: "unsupported protocol",
+			listeners: []IngressListener{{
+				Port:     8080,
+				Protocol: "grpc",
+				Services: []IngressService{{Name: "web"}},
+			}},
+			err: "unsupported protocol",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			entry := &IngressGatewayConfigEntry{
+				Kind:      IngressGateway,
+				Name:      "ingress",
+				Listeners: tc.listeners,
+			}
+			err := entry.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+
+	require.EqualError(t, (&IngressGatewayConfigEntry{}).Validate(), "missing name")
+}
+
+func TestIngressGatewayConfigEntry_MsgpackEncodeDecode(t *testing.T) {
+	require := require.New(t)
+
+	in := &ConfigEntryRequest{
+		Datacenter: "dc1",
+		Op:         ConfigEntryUpsert,
+		Entry: &IngressGatewayConfigEntry{
+			Kind: IngressGateway,
+			Name: "ingress",
+			Listeners: []IngressListener{
+				{
+					Port:     8080,
+					Protocol: "http",
+					Services: []IngressService{{Name: "web", Hosts: []string{"web.example.com"}}},
+				},
+			},
+		},
+	}
+	data, err := in.MarshalBinary()
+	require.NoError(err)
+
+	var out ConfigEntryRequest
+	require.NoError(out.UnmarshalBinary(data))
+	require.Equal(in.Entry, out.Entry)
+}
+
+func TestTerminatingGatewayConfigEntry_Validate(t *testing.T) {
+	cases := []struct {
+		name     string
+		services []LinkedService
+		err      string
+	}{
+		{
+			name: "valid",
+			services: []LinkedService{
+				{Name: "legacy"},
+				{Name: "billing", CAFile: "/etc/certs/ca.pem"},
+				{
+					Name:     "payments",
+					CAFile:   "/etc/certs/ca.pem",
+					CertFile: "/etc/certs/client.pem",
+					KeyFile:  "/etc/certs/client.key",
+					SNI:      "payments.example.com",
+				},
+			},
+		},
+		{
+			name:     "no services",
+			services: nil,
+		},
+		{
+			name:     "service without name",
+			services: []LinkedService{{CAFile: "/etc/certs/ca.pem"}},
+			err:      "without a name",
+		},
+		{
+			name:     "duplicate service",
+			services: []LinkedService{{Name: "legacy"}, {Name: "Legacy"}},
+			err:      "linked more than once",
+		},
+		{
+			name: "cert without key",
+			services: []LinkedService{
+				{Name: "legacy", CAFile: "/etc/certs/ca.pem", CertFile: "/etc/certs/client.pem"},
+			},
+			err: "both a CertFile and a KeyFile",
+		},
+		{
+			name: "cert without CA",
+			services: []LinkedService{
+				{Name: "legacy", CertFile: "/etc/certs/client.pem", KeyFile: "/etc/certs/client.key"},
+			},
+			err: "must have a CAFile",
+		},
+		{
+			name:     "SNI without CA",
+			services: []LinkedService{{Name: "legacy", SNI: "legacy.example.com"}},
+			err:      "must have a CAFile",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			entry := &TerminatingGatewayConfigEntry{
+				Kind:     TerminatingGateway,
+				Name:     "terminating",
+				Services: tc.services,
+			}
+			err := entry.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+
+	require.EqualError(t, (&TerminatingGatewayConfigEntry{}).Validate(), "missing name")
+}
+
+func TestTerminatingGatewayConfigEntry_LinkedService(t *testing.T) {
+	entry := &TerminatingGatewayConfigEntry{
+		Kind:     TerminatingGateway,
+		Name:     "terminating",
+		Services: []LinkedService{{Name: "Legacy", SNI: "legacy.example.com"}},
+	}
+
+	svc, ok := entry.LinkedService("legacy")
+	require.True(t, ok)
+	require.Equal(t, "legacy.example.com", svc.SNI)
+
+	_, ok = entry.LinkedService("billing")
+	require.False(t, ok)
+}
@@ -0,0 +1,108 @@
+package structs
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigEntryResponse_MsgpackEncodeDecode(t *testing.T) {
+	require := require.New(t)
+
+	in := &ConfigEntryResponse{
+		Entry: &ServiceConfigEntry{
+			Kind:     ServiceDefaults,
+			Name:     "web",
+			Protocol: "http",
+		},
+		QueryMeta: QueryMeta{Index: 5},
+	}
+	data, err := in.MarshalBinary()
+	require.NoError(err)
+
+	var out ConfigEntryResponse
+	require.NoError(out.UnmarshalBinary(data))
+	require.Equal(in, &out)
+
+	// A missing entry stays nil
+	in = &ConfigEntryResponse{QueryMeta: QueryMeta{Index: 6}}
+	data, err = in.MarshalBinary()
+	require.NoError(err)
+
+	out = ConfigEntryResponse{}
+	require.NoError(out.UnmarshalBinary(data))
+	require.Nil(out.Entry)
+	require.Equal(uint64(6), out.Index)
+}
+
+func TestIndexedConfigEntries_MsgpackEncodeDecode(t *testing.T) {
+	require := require.New(t)
+
+	in := &IndexedConfigEntries{
+		Kind: ServiceDefaults,
+		Entries: []ConfigEntry{
+			&ServiceConfigEntry{Kind: ServiceDefaults, Name: "db", Protocol: "tcp"},
+			&ServiceConfigEntry{Kind: ServiceDefaults, Name: "web", Protocol: "http"},
+		},
+		QueryMeta: QueryMeta{Index: 5},
+	}
+	data, err := in.MarshalBinary()
+	require.NoError(err)
+
+	var out IndexedConfigEntries
+	require.NoError(out.UnmarshalBinary(data))
+	require.Equal(in, &out)
+}
+
+func TestDecodeConfigEntryJSON(t *testing.T) {
+	require := require.New(t)
+
+	entry, err := DecodeConfigEntryJSON([]byte(`{
+		"Kind": "proxy-defaults",
+		"Name": "global",
+		"Config": {
+			"local_connect_timeout_ms": 1000
+		}
+	}`))
+	require.NoError(err)
+	require.Equal(&ProxyConfigEntry{
+		Kind: ProxyDefaults,
+		Name: ProxyConfigGlobal,
+		Config: map[string]interface{}{
+			"local_connect_timeout_ms": float64(1000),
+		},
+	}, entry)
+
+	_, err = DecodeConfigEntryJSON([]byte(`{ "Kind": "foo", "Name": "bar" }`))
+	require.EqualError(err, "invalid config entry kind: foo")
+}
+
+func TestProxyConfigEntry_MarshalJSON(t *testing.T) {
+	require := require.New(t)
+
+	// Decoding from msgpack leaves the nested strings as byte slices
+	entry := &ProxyConfigEntry{
+		Kind: ProxyDefaults,
+		Name: ProxyConfigGlobal,
+		Config: map[string]interface{}{
+			"envoy": map[interface{}]interface{}{
+				"cluster": []byte("local"),
+			},
+		},
+	}
+	data, err := json.Marshal(entry)
+	require.NoError(err)
+	require.JSONEq(`{
+		"Kind": "proxy-defaults",
+		"Name": "global",
+		"Config": {
+			"envoy": {
+				"cluster": "local"
+			}
+		},
+		"MeshGateway": {},
+		"CreateIndex": 0,
+		"ModifyIndex": 0
+	}`, string(data))
+}
@@ -20,6 +20,11 @@ type ConnectAuthorizeRequest struct {
 	// lists.
 	ClientCertURI    string
 	ClientCertSerial string
+
+	// HTTP holds the attributes of the request when authorizing an HTTP
+	// request rather than a connection. It's required to be allowed by an
+	// intention with L7 permissions.
+	HTTP *ConnectAuthorizeHTTPRequest `json:",omitempty"`
 }
 
 // ProxyExecMode encodes the mode for running a managed connect proxy.
@@ -3,6 +3,8 @@ package structs
 import (
 	"fmt"
 	"reflect"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/mitchellh/mapstructure"
@@ -37,8 +39,17 @@ type IndexedCARoots struct {
 	// enforce that Consul's CA can only validly sign or trust certs within the
 	// same trust-domain. Name constraints as enforced by TLS handshake also allow
 	// seamless rotation between trust domains thanks to cross-signing.
+	//
+	// It is derived from the ClusterID unless the TrustDomain of the CA config
+	// overrides it, for example to match the trust domain of SPIFFE workloads
+	// outside of Consul.
 	TrustDomain string
 
+	// LeafCertDNSDomains are the DNS domains the leaf certificates have a DNS
+	// SAN in, "<service>.<domain>" for each of them. Agents add these SANs to
+	// the CSRs of their services.
+	LeafCertDNSDomains []string `json:",omitempty"`
+
 	// Roots is a list of root CA certs to trust.
 	Roots []*CARoot
 
@@ -99,8 +110,9 @@ type CARoot struct {
 
 	// RotatedOutAt is the time at which this CA was removed from the state.
 	// This will only be set on roots that have been rotated out from being the
-	// active root.
-	RotatedOutAt time.Time `json:"-"`
+	// active root. It is returned by the API to report the progress of the
+	// rotations.
+	RotatedOutAt time.Time
 
 	RaftIndex
 }
@@ -143,6 +155,11 @@ type IssuedCert struct {
 	Service    string
 	ServiceURI string
 
+	// Agent is the name of the agent for which the cert was issued with
+	// auto-encrypt. AgentURI is the cert URI value.
+	Agent    string `json:",omitempty"`
+	AgentURI string `json:",omitempty"`
+
 	// ValidAfter and ValidBefore are the validity periods for the
 	// certificate.
 	ValidAfter  time.Time
@@ -151,6 +168,22 @@ type IssuedCert struct {
 	RaftIndex
 }
 
+// SignedResponse is returned to the client agents requesting a certificate
+// with auto-encrypt. Besides the certificate, it has what they need to
+// verify the servers.
+type SignedResponse struct {
+	IssuedCert     IssuedCert
+	ConnectCARoots IndexedCARoots
+
+	// ManualCARoots are the PEM-encoded CA certificates configured on the
+	// server, which signed the certificates of the servers.
+	ManualCARoots []string
+
+	// VerifyServerHostname is whether the clients should verify the
+	// hostname of the certificates of the servers.
+	VerifyServerHostname bool
+}
+
 // CAOp is the operation for a request related to intentions.
 type CAOp string
 
@@ -272,9 +305,38 @@ type CommonCAProviderConfig struct {
 	// immediately in the RPC goroutine. This is 0 by default and CSRMaxPerSecond
 	// is used. This is ignored if CSRMaxPerSecond is non-zero.
 	CSRMaxConcurrent int
+
+	// TrustDomain overrides the trust domain of the cluster, which is
+	// "<cluster id>.consul" by default. It is the host of the SPIFFE IDs in the
+	// URI SANs of the certificates, and must be a DNS name of at least two
+	// labels. The roots generated by the Consul provider carry it, so changing
+	// it rotates them.
+	TrustDomain string
+
+	// LeafCertDNSDomains are the DNS domains the leaf certificates get a DNS
+	// SAN in. The certificate of a service has the SAN "<service>.<domain>"
+	// for each of them, as long as the service name is a valid DNS label.
+	LeafCertDNSDomains []string
 }
 
+var (
+	// caDNSNameRe matches a DNS name made of one or more labels.
+	caDNSNameRe = regexp.MustCompile(`^(?i)[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?(\.[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?)*$`)
+)
+
 func (c CommonCAProviderConfig) Validate() error {
+	if c.TrustDomain != "" {
+		if !caDNSNameRe.MatchString(c.TrustDomain) || !strings.Contains(c.TrustDomain, ".") {
+			return fmt.Errorf("trust domain %q must be a DNS name of at least two labels", c.TrustDomain)
+		}
+	}
+
+	for _, domain := range c.LeafCertDNSDomains {
+		if !caDNSNameRe.MatchString(domain) {
+			return fmt.Errorf("leaf cert DNS domain %q is not a valid DNS name", domain)
+		}
+	}
+
 	if c.SkipValidate {
 		return nil
 	}
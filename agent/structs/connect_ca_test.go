@@ -47,6 +47,22 @@ func TestCAConfiguration_GetCommonConfig(t *testing.T) {
 				CSRMaxPerSecond: 50, // The default value
 			},
 		},
+		{
+			name: "SANs after encoding fun",
+			cfg: &CAConfiguration{
+				Config: map[string]interface{}{
+					"LeafCertTTL":        []uint8("72h"),
+					"TrustDomain":        []uint8("example.org"),
+					"LeafCertDNSDomains": []interface{}{[]uint8("svc.example.org")},
+				},
+			},
+			want: &CommonCAProviderConfig{
+				LeafCertTTL:        72 * time.Hour,
+				CSRMaxPerSecond:    50,
+				TrustDomain:        "example.org",
+				LeafCertDNSDomains: []string{"svc.example.org"},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -59,3 +75,65 @@ func TestCAConfiguration_GetCommonConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestCommonCAProviderConfig_Validate(t *testing.T) {
+	cases := []struct {
+		name   string
+		modify func(*CommonCAProviderConfig)
+		err    string
+	}{
+		{
+			name:   "defaults",
+			modify: func(c *CommonCAProviderConfig) {},
+		},
+		{
+			name: "SANs",
+			modify: func(c *CommonCAProviderConfig) {
+				c.TrustDomain = "Example.org"
+				c.LeafCertDNSDomains = []string{"svc.example.org", "consul"}
+			},
+		},
+		{
+			name:   "single label trust domain",
+			modify: func(c *CommonCAProviderConfig) { c.TrustDomain = "example" },
+			err:    "at least two labels",
+		},
+		{
+			name:   "invalid trust domain",
+			modify: func(c *CommonCAProviderConfig) { c.TrustDomain = "example.org/ns" },
+			err:    "at least two labels",
+		},
+		{
+			name:   "invalid DNS domain",
+			modify: func(c *CommonCAProviderConfig) { c.LeafCertDNSDomains = []string{"*.example.org"} },
+			err:    "not a valid DNS name",
+		},
+		{
+			name: "invalid DNS domain with SkipValidate",
+			modify: func(c *CommonCAProviderConfig) {
+				c.SkipValidate = true
+				c.LeafCertDNSDomains = []string{"example..org"}
+			},
+			err: "not a valid DNS name",
+		},
+		{
+			name:   "short TTL",
+			modify: func(c *CommonCAProviderConfig) { c.LeafCertTTL = time.Minute },
+			err:    "greater than 1h",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := CommonCAProviderConfig{LeafCertTTL: 72 * time.Hour}
+			tc.modify(&config)
+			err := config.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package structs
+
+import (
+	"time"
+)
+
+// ConnectChangeOp is the operation of a change in the changelog.
+type ConnectChangeOp string
+
+const (
+	ConnectChangeCreate ConnectChangeOp = "create"
+	ConnectChangeUpdate ConnectChangeOp = "update"
+	ConnectChangeDelete ConnectChangeOp = "delete"
+)
+
+// ConnectChangeIntention is the kind of the changes of intentions, the
+// changes of config entries having the kind of the entry.
+const ConnectChangeIntention = "intention"
+
+// ConnectChange is an entry of the changelog of the intentions and config
+// entries kept by the servers.
+type ConnectChange struct {
+	// Index is the Raft index the change was applied at, which identifies
+	// the change.
+	Index uint64
+
+	// Kind is ConnectChangeIntention or the kind of the config entry.
+	Kind string
+
+	// Name is the ID of the intention or the name of the config entry.
+	Name string
+
+	// SourceName and DestinationName are the services of the intention.
+	SourceName      string `json:",omitempty"`
+	DestinationName string `json:",omitempty"`
+
+	// Op is the operation of the change.
+	Op ConnectChangeOp
+
+	// Actor is the accessor ID of the token that made the change. It is
+	// empty when ACLs are disabled and for the changes replicated from the
+	// primary datacenter.
+	Actor string
+
+	// Time is when the change was requested.
+	Time time.Time
+
+	// Before and After are the JSON encoded values before and after the
+	// change, empty when it was created and deleted respectively.
+	Before string `json:",omitempty"`
+	After  string `json:",omitempty"`
+}
+
+// ConnectChanges is a list of changes, ordered from the newest.
+type ConnectChanges []*ConnectChange
+
+// IndexedConnectChanges represents a list of changes.
+type IndexedConnectChanges struct {
+	Changes ConnectChanges
+	QueryMeta
+}
+
+// ConnectChangesRequest is used to list the changes, optionally of the
+// given kind and name.
+type ConnectChangesRequest struct {
+	// Datacenter is the target for this request.
+	Datacenter string
+
+	// Kind and Name restrict the changes to those of the given kind and
+	// name, if set.
+	Kind string
+	Name string
+
+	QueryOptions
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (r *ConnectChangesRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
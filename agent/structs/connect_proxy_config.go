@@ -2,10 +2,68 @@ package structs
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/consul/api"
+	multierror "github.com/hashicorp/go-multierror"
 )
 
+// MeshGatewayMode is how a proxy reaches the upstreams in other datacenters.
+type MeshGatewayMode string
+
+const (
+	// MeshGatewayModeDefault defers the mode to the next level of
+	// configuration, the proxy defaulting to MeshGatewayModeNone.
+	MeshGatewayModeDefault MeshGatewayMode = ""
+
+	// MeshGatewayModeNone connects directly to the instances of the upstream
+	// in the other datacenter, which requires a flat network.
+	MeshGatewayModeNone MeshGatewayMode = "none"
+
+	// MeshGatewayModeLocal connects to a mesh gateway of the local
+	// datacenter, which forwards the traffic to a mesh gateway of the
+	// upstream datacenter.
+	MeshGatewayModeLocal MeshGatewayMode = "local"
+
+	// MeshGatewayModeRemote connects to a mesh gateway of the upstream
+	// datacenter.
+	MeshGatewayModeRemote MeshGatewayMode = "remote"
+)
+
+// MeshGatewayConfig controls how the upstreams in other datacenters are
+// reached through the mesh gateways.
+type MeshGatewayConfig struct {
+	// Mode is the mode to use for the mesh gateways.
+	Mode MeshGatewayMode `json:",omitempty"`
+}
+
+// IsZero returns whether the config defers to the next level of
+// configuration.
+func (c *MeshGatewayConfig) IsZero() bool {
+	return c.Mode == MeshGatewayModeDefault
+}
+
+// Validate sanity checks the mode.
+func (c *MeshGatewayConfig) Validate() error {
+	switch c.Mode {
+	case MeshGatewayModeDefault, MeshGatewayModeNone, MeshGatewayModeLocal, MeshGatewayModeRemote:
+		return nil
+	}
+	return fmt.Errorf("Invalid MeshGateway.Mode %q, must be one of %q, %q or %q",
+		c.Mode, MeshGatewayModeNone, MeshGatewayModeLocal, MeshGatewayModeRemote)
+}
+
+// ToAPI returns the api struct with the same fields.
+func (c *MeshGatewayConfig) ToAPI() api.MeshGatewayConfig {
+	return api.MeshGatewayConfig{Mode: api.MeshGatewayMode(c.Mode)}
+}
+
+// MeshGatewayConfigFromAPI is a helper for converting api.MeshGatewayConfig
+// to MeshGatewayConfig.
+func MeshGatewayConfigFromAPI(c api.MeshGatewayConfig) MeshGatewayConfig {
+	return MeshGatewayConfig{Mode: MeshGatewayMode(c.Mode)}
+}
+
 // ConnectProxyConfig describes the configuration needed for any proxy managed
 // or unmanaged. It describes a single logical service's listener and optionally
 // upstreams and sidecar-related config for a single instance. To describe a
@@ -43,6 +101,13 @@ type ConnectProxyConfig struct {
 	// Upstreams describes any upstream dependencies the proxy instance should
 	// setup.
 	Upstreams Upstreams `json:",omitempty"`
+
+	// Expose defines whether checks or paths are exposed through the proxy.
+	Expose ExposeConfig `json:",omitempty"`
+
+	// MeshGateway defines how the upstreams in other datacenters are reached,
+	// unless the upstream sets its own mode.
+	MeshGateway MeshGatewayConfig `json:",omitempty"`
 }
 
 // ToAPI returns the api struct with the same fields. We have duplicates to
@@ -57,6 +122,8 @@ func (c *ConnectProxyConfig) ToAPI() *api.AgentServiceConnectProxyConfig {
 		LocalServicePort:       c.LocalServicePort,
 		Config:                 c.Config,
 		Upstreams:              c.Upstreams.ToAPI(),
+		Expose:                 c.Expose.ToAPI(),
+		MeshGateway:            c.MeshGateway.ToAPI(),
 	}
 }
 
@@ -122,6 +189,10 @@ type Upstream struct {
 	// It can be used to pass arbitrary configuration for this specific upstream
 	// to the proxy.
 	Config map[string]interface{}
+
+	// MeshGateway defines how the upstream is reached when it is in another
+	// datacenter, overriding the mode of the proxy.
+	MeshGateway MeshGatewayConfig `json:",omitempty"`
 }
 
 // Validate sanity checks the struct is valid
@@ -154,6 +225,7 @@ func (u *Upstream) ToAPI() api.Upstream {
 		LocalBindAddress:     u.LocalBindAddress,
 		LocalBindPort:        u.LocalBindPort,
 		Config:               u.Config,
+		MeshGateway:          u.MeshGateway.ToAPI(),
 	}
 }
 
@@ -189,5 +261,112 @@ func UpstreamFromAPI(u api.Upstream) Upstream {
 		LocalBindAddress:     u.LocalBindAddress,
 		LocalBindPort:        u.LocalBindPort,
 		Config:               u.Config,
+		MeshGateway:          MeshGatewayConfigFromAPI(u.MeshGateway),
+	}
+}
+
+const (
+	ExposePathProtocolHTTP  = "http"
+	ExposePathProtocolHTTP2 = "http2"
+)
+
+// ExposeConfig describes the HTTP paths of the local service instance that
+// are exposed through the proxy without Connect, so that they can be reached
+// by clients that can't reach the service instance itself, like the agent
+// running its health checks or a metrics scraper.
+type ExposeConfig struct {
+	// Checks defines whether the paths of the HTTP checks of the service
+	// instance are exposed. The checks are then run through the proxy.
+	Checks bool `json:",omitempty"`
+
+	// Paths are the paths exposed through the proxy.
+	Paths []ExposePath `json:",omitempty"`
+}
+
+// ExposePath is a path of the local service instance exposed through the
+// proxy.
+type ExposePath struct {
+	// ListenerPort is the port the proxy listens on for the path.
+	ListenerPort int `json:",omitempty"`
+
+	// Path is the exact path to expose, such as "/metrics".
+	Path string `json:",omitempty"`
+
+	// LocalPathPort is the port the local service instance serves the path
+	// on.
+	LocalPathPort int `json:",omitempty"`
+
+	// Protocol is the protocol of the listener, http or http2. Defaults to
+	// http.
+	Protocol string `json:",omitempty"`
+
+	// ParsedFromCheck is set if the path was exposed from an HTTP check.
+	ParsedFromCheck bool `json:",omitempty"`
+}
+
+// Validate sanity checks the exposed paths.
+func (e *ExposeConfig) Validate() error {
+	var result error
+	ports := make(map[int]struct{})
+	for _, p := range e.Paths {
+		if !strings.HasPrefix(p.Path, "/") {
+			result = multierror.Append(result, fmt.Errorf(
+				"Exposed path %q must begin with a '/'", p.Path))
+		}
+		if p.ListenerPort <= 0 || p.ListenerPort > 65535 {
+			result = multierror.Append(result, fmt.Errorf(
+				"Exposed path %q must have a valid listener port", p.Path))
+		}
+		if p.LocalPathPort <= 0 || p.LocalPathPort > 65535 {
+			result = multierror.Append(result, fmt.Errorf(
+				"Exposed path %q must have a valid local path port", p.Path))
+		}
+		if p.Protocol != "" && p.Protocol != ExposePathProtocolHTTP && p.Protocol != ExposePathProtocolHTTP2 {
+			result = multierror.Append(result, fmt.Errorf(
+				"Exposed path %q has an unsupported protocol %q", p.Path, p.Protocol))
+		}
+		if _, ok := ports[p.ListenerPort]; ok {
+			result = multierror.Append(result, fmt.Errorf(
+				"Exposed paths must have distinct listener ports, %d is duplicated", p.ListenerPort))
+		}
+		ports[p.ListenerPort] = struct{}{}
+	}
+	return result
+}
+
+// ToAPI returns the api struct with the same fields.
+func (e *ExposeConfig) ToAPI() api.ExposeConfig {
+	var paths []api.ExposePath
+	for _, p := range e.Paths {
+		paths = append(paths, api.ExposePath{
+			ListenerPort:    p.ListenerPort,
+			Path:            p.Path,
+			LocalPathPort:   p.LocalPathPort,
+			Protocol:        p.Protocol,
+			ParsedFromCheck: p.ParsedFromCheck,
+		})
+	}
+	return api.ExposeConfig{
+		Checks: e.Checks,
+		Paths:  paths,
+	}
+}
+
+// ExposeConfigFromAPI is a helper for converting api.ExposeConfig to
+// ExposeConfig.
+func ExposeConfigFromAPI(e api.ExposeConfig) ExposeConfig {
+	var paths []ExposePath
+	for _, p := range e.Paths {
+		paths = append(paths, ExposePath{
+			ListenerPort:    p.ListenerPort,
+			Path:            p.Path,
+			LocalPathPort:   p.LocalPathPort,
+			Protocol:        p.Protocol,
+			ParsedFromCheck: p.ParsedFromCheck,
+		})
+	}
+	return ExposeConfig{
+		Checks: e.Checks,
+		Paths:  paths,
 	}
 }
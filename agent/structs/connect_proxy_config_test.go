@@ -73,6 +73,67 @@ func TestConnectProxyConfig_ToAPI(t *testing.T) {
 	}
 }
 
+func TestExposeConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      ExposeConfig
+		wantErr string
+	}{
+		{
+			name: "valid",
+			in: ExposeConfig{
+				Checks: true,
+				Paths: []ExposePath{
+					{ListenerPort: 21500, Path: "/health", LocalPathPort: 8080, Protocol: "http"},
+					{ListenerPort: 21501, Path: "/grpc.health.v1.Health/Check", LocalPathPort: 8081, Protocol: "http2"},
+				},
+			},
+		},
+		{
+			name: "relative path",
+			in: ExposeConfig{
+				Paths: []ExposePath{{ListenerPort: 21500, Path: "health", LocalPathPort: 8080}},
+			},
+			wantErr: "must begin with a '/'",
+		},
+		{
+			name: "missing listener port",
+			in: ExposeConfig{
+				Paths: []ExposePath{{Path: "/health", LocalPathPort: 8080}},
+			},
+			wantErr: "valid listener port",
+		},
+		{
+			name: "invalid protocol",
+			in: ExposeConfig{
+				Paths: []ExposePath{{ListenerPort: 21500, Path: "/health", LocalPathPort: 8080, Protocol: "tcp"}},
+			},
+			wantErr: "unsupported protocol",
+		},
+		{
+			name: "duplicate listener port",
+			in: ExposeConfig{
+				Paths: []ExposePath{
+					{ListenerPort: 21500, Path: "/health", LocalPathPort: 8080},
+					{ListenerPort: 21500, Path: "/metrics", LocalPathPort: 8080},
+				},
+			},
+			wantErr: "distinct listener ports",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.in.Validate()
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
 func TestUpstream_MarshalJSON(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -93,7 +154,8 @@ func TestUpstream_MarshalJSON(t *testing.T) {
 				"DestinationName": "foo",
 				"Datacenter": "dc1",
 				"LocalBindPort": 1234,
-				"Config": null
+				"Config": null,
+				"MeshGateway": {}
 			}`,
 			wantErr: false,
 		},
@@ -110,7 +172,8 @@ func TestUpstream_MarshalJSON(t *testing.T) {
 				"DestinationName": "foo",
 				"Datacenter": "dc1",
 				"LocalBindPort": 1234,
-				"Config": null
+				"Config": null,
+				"MeshGateway": {}
 			}`,
 			wantErr: false,
 		},
@@ -0,0 +1,148 @@
+package structs
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/mitchellh/hashstructure"
+)
+
+const (
+	// DiscoveryGraphNodeTypeResolver is the type of the nodes resolving a
+	// target to healthy instances.
+	DiscoveryGraphNodeTypeResolver = "resolver"
+
+	// DefaultConnectTimeout is the connect timeout of the resolvers when the
+	// config entries don't set one.
+	DefaultConnectTimeout = 5 * time.Second
+)
+
+// DiscoveryChainRequest is used to compile the discovery chain of a service.
+type DiscoveryChainRequest struct {
+	// Name is the name of the service to compile the chain of.
+	Name string
+
+	// EvaluateInDatacenter is the datacenter the chain is compiled for, as
+	// if the upstream was requested from there. Defaults to the datacenter
+	// of the request.
+	EvaluateInDatacenter string
+
+	// OverrideProtocol and OverrideConnectTimeout replace the values set by
+	// the config entries, to test changes before writing them.
+	OverrideProtocol       string
+	OverrideConnectTimeout time.Duration
+
+	Datacenter string
+	QueryOptions
+}
+
+func (r *DiscoveryChainRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+func (r *DiscoveryChainRequest) CacheInfo() cache.RequestInfo {
+	info := cache.RequestInfo{
+		Token:          r.Token,
+		Datacenter:     r.Datacenter,
+		MinIndex:       r.MinQueryIndex,
+		Timeout:        r.MaxQueryTime,
+		MaxAge:         r.MaxAge,
+		MustRevalidate: r.MustRevalidate,
+	}
+
+	v, err := hashstructure.Hash([]interface{}{
+		r.Name,
+		r.EvaluateInDatacenter,
+		r.OverrideProtocol,
+		r.OverrideConnectTimeout,
+	}, nil)
+	if err == nil {
+		// If there is an error, we don't set the key. A blank key forces
+		// no cache for this request so the request is forwarded directly
+		// to the server.
+		info.Key = strconv.FormatUint(v, 10)
+	}
+
+	return info
+}
+
+// DiscoveryChainResponse is the response to a DiscoveryChainRequest.
+type DiscoveryChainResponse struct {
+	Chain *CompiledDiscoveryChain
+	QueryMeta
+}
+
+// CompiledDiscoveryChain is the graph describing how the traffic sent to a
+// service is routed and resolved, compiled from the config entries.
+type CompiledDiscoveryChain struct {
+	ServiceName string
+	Datacenter  string
+
+	// CustomizationHash is set when the chain was compiled with overrides,
+	// to distinguish it from the chain built from the config entries only.
+	CustomizationHash string `json:",omitempty"`
+
+	// Protocol is the protocol spoken by the service, such as "tcp" or
+	// "http".
+	Protocol string
+
+	// StartNode is the name of the node the traffic enters the graph at.
+	StartNode string
+
+	// Nodes are the nodes of the graph by name.
+	Nodes map[string]*DiscoveryGraphNode
+
+	// Targets are the targets of the resolvers by ID.
+	Targets map[string]*DiscoveryTarget
+}
+
+// DiscoveryGraphNode is a single node of a compiled discovery chain.
+type DiscoveryGraphNode struct {
+	Type string
+	Name string
+
+	// Resolver is set for the nodes of type resolver.
+	Resolver *DiscoveryResolver `json:",omitempty"`
+}
+
+// DiscoveryResolver resolves a target to its healthy instances.
+type DiscoveryResolver struct {
+	// Default is true when no config entry customized the resolver.
+	Default        bool
+	ConnectTimeout time.Duration
+	Target         string
+
+	// Failover is set when the service resolver fails over to other
+	// datacenters.
+	Failover *DiscoveryFailover `json:",omitempty"`
+}
+
+// DiscoveryFailover is the plan of a resolver to fail over to when its target
+// has no healthy instances.
+type DiscoveryFailover struct {
+	// Targets are the IDs of the targets to fail over to, in order.
+	Targets []string
+}
+
+// DiscoveryTarget is the set of instances a resolver sends the traffic to.
+type DiscoveryTarget struct {
+	ID         string
+	Service    string
+	Datacenter string
+
+	// MeshGateway is the mesh gateway mode set by the config entries of the
+	// service, or by the proxy defaults.
+	MeshGateway MeshGatewayConfig `json:",omitempty"`
+}
+
+// NewDiscoveryTarget returns the target of the given service in the given
+// datacenter.
+func NewDiscoveryTarget(service, datacenter string) *DiscoveryTarget {
+	return &DiscoveryTarget{
+		ID:         fmt.Sprintf("%s.%s", service, datacenter),
+		Service:    service,
+		Datacenter: datacenter,
+	}
+}
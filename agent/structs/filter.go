@@ -0,0 +1,376 @@
+package structs
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-bexpr"
+)
+
+// Filter keeps the elements of a slice or a map matching a ?filter
+// expression. The expression is parsed by go-bexpr, but evaluated here so
+// that the fields of the embedded structs are promoted like in Go, byte
+// slices are matched as strings, and selecting through nil pointers doesn't
+// fail.
+type Filter struct {
+	match predicate
+	typ   reflect.Type
+}
+
+// predicate evaluates an expression against a value. The values are never
+// pointers, these are dereferenced first.
+type predicate func(v reflect.Value) bool
+
+// CreateFilter parses the expression of a filter and checks its selectors
+// against the type of the elements of dataType, which must be a slice, an
+// array or a map, so unknown fields are rejected up front.
+func CreateFilter(expression string, dataType interface{}) (*Filter, error) {
+	typ := reflect.TypeOf(dataType)
+	if typ == nil {
+		return nil, fmt.Errorf("Cannot filter values of type %T", dataType)
+	}
+	switch typ.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Map:
+	default:
+		return nil, fmt.Errorf("Cannot filter values of type %s, only slices, arrays and maps", typ)
+	}
+
+	ast, err := bexpr.Parse("", []byte(expression))
+	if err != nil {
+		return nil, fmt.Errorf("Failed to parse the filter expression: %v", err)
+	}
+	match, err := compileFilter(ast.(bexpr.Expression), derefType(typ.Elem()))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid filter expression: %v", err)
+	}
+	return &Filter{match: match, typ: typ}, nil
+}
+
+// Execute returns the elements of the data matching the expression. The
+// data must have the type the filter was created for, and the result has
+// the same type, or is a slice of the elements for arrays. Nil elements
+// never match.
+func (f *Filter) Execute(data interface{}) (interface{}, error) {
+	v := reflect.ValueOf(data)
+	if !v.IsValid() || v.Type() != f.typ {
+		return nil, fmt.Errorf("Cannot filter values of type %T, expected %s", data, f.typ)
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return data, nil
+		}
+		out := reflect.MakeMap(f.typ)
+		for _, key := range v.MapKeys() {
+			if elem := v.MapIndex(key); f.matchElem(elem) {
+				out.SetMapIndex(key, elem)
+			}
+		}
+		return out.Interface(), nil
+
+	default:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return data, nil
+		}
+		out := reflect.MakeSlice(reflect.SliceOf(f.typ.Elem()), 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if elem := v.Index(i); f.matchElem(elem) {
+				out = reflect.Append(out, elem)
+			}
+		}
+		if v.Kind() == reflect.Slice {
+			out = out.Convert(f.typ)
+		}
+		return out.Interface(), nil
+	}
+}
+
+func (f *Filter) matchElem(elem reflect.Value) bool {
+	if elem = derefValue(elem); !elem.IsValid() {
+		return false
+	}
+	return f.match(elem)
+}
+
+// compileFilter returns the predicate of the expression for values of the
+// given type.
+func compileFilter(expr bexpr.Expression, typ reflect.Type) (predicate, error) {
+	switch e := expr.(type) {
+	case *bexpr.UnaryExpression:
+		operand, err := compileFilter(e.Operand, typ)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) bool { return !operand(v) }, nil
+
+	case *bexpr.BinaryExpression:
+		left, err := compileFilter(e.Left, typ)
+		if err != nil {
+			return nil, err
+		}
+		right, err := compileFilter(e.Right, typ)
+		if err != nil {
+			return nil, err
+		}
+		if e.Operator == bexpr.BinaryOpAnd {
+			return func(v reflect.Value) bool { return left(v) && right(v) }, nil
+		}
+		return func(v reflect.Value) bool { return left(v) || right(v) }, nil
+
+	case *bexpr.MatchExpression:
+		return compileMatch(e, typ)
+	}
+	return nil, fmt.Errorf("unknown expression %T", expr)
+}
+
+// selectStep moves from a value to one of its fields or map values.
+type selectStep func(v reflect.Value) reflect.Value
+
+// compileMatch returns the predicate of a match expression.
+func compileMatch(e *bexpr.MatchExpression, typ reflect.Type) (predicate, error) {
+	// Resolve the selector to the steps reaching the selected value.
+	var steps []selectStep
+	for i, name := range e.Selector {
+		switch typ.Kind() {
+		case reflect.Struct:
+			index, ok := filterFieldIndex(typ, name)
+			if !ok {
+				return nil, fmt.Errorf("selector %q is not valid: no field %q", e.Selector, name)
+			}
+			steps = append(steps, func(v reflect.Value) reflect.Value {
+				return filterFieldByIndex(v, index)
+			})
+			typ = typ.FieldByIndex(index).Type
+
+		case reflect.Map:
+			if typ.Key().Kind() != reflect.String {
+				return nil, fmt.Errorf("selector %q is not valid: map keys are not strings", e.Selector[:i+1])
+			}
+			key := reflect.ValueOf(name).Convert(typ.Key())
+			steps = append(steps, func(v reflect.Value) reflect.Value {
+				return v.MapIndex(key)
+			})
+			typ = typ.Elem()
+
+		default:
+			return nil, fmt.Errorf("selector %q is not valid: cannot select %q from %s", e.Selector, name, typ)
+		}
+		typ = derefType(typ)
+	}
+
+	// Build the match on the selected value. The negated operators are
+	// evaluated as the negation of their counterpart.
+	match, err := compileMatchValue(e, typ)
+	if err != nil {
+		return nil, err
+	}
+	negate := false
+	switch e.Operator {
+	case bexpr.MatchNotEqual, bexpr.MatchIsNotEmpty, bexpr.MatchNotIn, bexpr.MatchNotMatches:
+		negate = true
+	}
+	empty := e.Operator == bexpr.MatchIsEmpty || e.Operator == bexpr.MatchIsNotEmpty
+
+	return func(v reflect.Value) bool {
+		for _, step := range steps {
+			if v = derefValue(step(v)); !v.IsValid() {
+				// Missing values are empty and match nothing else.
+				return empty != negate
+			}
+		}
+		return match(v) != negate
+	}, nil
+}
+
+// compileMatchValue returns the predicate of the match expression for the
+// selected value of the given type, ignoring the negation of the operator.
+func compileMatchValue(e *bexpr.MatchExpression, typ reflect.Type) (predicate, error) {
+	unsupported := fmt.Errorf("operator %q is not supported for selector %q of type %s",
+		e.Operator, e.Selector, typ)
+
+	var value string
+	if e.Value != nil {
+		value = e.Value.Raw
+	}
+
+	switch e.Operator {
+	case bexpr.MatchIsEmpty, bexpr.MatchIsNotEmpty:
+		switch typ.Kind() {
+		case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+			return func(v reflect.Value) bool { return v.Len() == 0 }, nil
+		}
+		return nil, unsupported
+
+	case bexpr.MatchMatches, bexpr.MatchNotMatches:
+		if typ.Kind() != reflect.String && !isBytes(typ) {
+			return nil, unsupported
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %q: %v", value, err)
+		}
+		return func(v reflect.Value) bool { return re.MatchString(stringOf(v)) }, nil
+
+	case bexpr.MatchEqual, bexpr.MatchNotEqual:
+		if isBytes(typ) {
+			return func(v reflect.Value) bool { return stringOf(v) == value }, nil
+		}
+		want, err := coerceFilterValue(value, typ)
+		if err != nil {
+			return nil, err
+		}
+		return func(v reflect.Value) bool { return filterValuesEqual(v, want) }, nil
+
+	case bexpr.MatchIn, bexpr.MatchNotIn:
+		switch {
+		case typ.Kind() == reflect.String || isBytes(typ):
+			return func(v reflect.Value) bool { return strings.Contains(stringOf(v), value) }, nil
+
+		case typ.Kind() == reflect.Map:
+			key, err := coerceFilterValue(value, typ.Key())
+			if err != nil {
+				return nil, err
+			}
+			return func(v reflect.Value) bool { return v.MapIndex(key).IsValid() }, nil
+
+		case typ.Kind() == reflect.Slice || typ.Kind() == reflect.Array:
+			want, err := coerceFilterValue(value, derefType(typ.Elem()))
+			if err != nil {
+				return nil, err
+			}
+			return func(v reflect.Value) bool {
+				for i := 0; i < v.Len(); i++ {
+					if elem := derefValue(v.Index(i)); elem.IsValid() && filterValuesEqual(elem, want) {
+						return true
+					}
+				}
+				return false
+			}, nil
+		}
+		return nil, unsupported
+	}
+	return nil, unsupported
+}
+
+// coerceFilterValue converts the value of the expression to the given
+// scalar type.
+func coerceFilterValue(value string, typ reflect.Type) (reflect.Value, error) {
+	var v interface{}
+	var err error
+	switch typ.Kind() {
+	case reflect.String:
+		v = value
+	case reflect.Bool:
+		v, err = strconv.ParseBool(value)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err = strconv.ParseInt(value, 0, typ.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err = strconv.ParseUint(value, 0, typ.Bits())
+	case reflect.Float32, reflect.Float64:
+		v, err = strconv.ParseFloat(value, typ.Bits())
+	default:
+		return reflect.Value{}, fmt.Errorf("cannot compare values of type %s", typ)
+	}
+	if err != nil {
+		return reflect.Value{}, fmt.Errorf("value %q is not a valid %s", value, typ)
+	}
+	return reflect.ValueOf(v).Convert(typ), nil
+}
+
+// filterValuesEqual compares a value to a coerced one of the same kind.
+func filterValuesEqual(v, want reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String() == want.String()
+	case reflect.Bool:
+		return v.Bool() == want.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == want.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint() == want.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == want.Float()
+	}
+	return false
+}
+
+// filterFieldIndex returns the index of the struct field with the given
+// name, which can be set with a bexpr tag. The fields of embedded structs
+// are promoted, and the fields tagged with "-" are skipped.
+func filterFieldIndex(typ reflect.Type, name string) ([]int, bool) {
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" && !field.Anonymous {
+			continue
+		}
+
+		fieldName := field.Name
+		if tag := field.Tag.Get("bexpr"); tag == "-" {
+			continue
+		} else if tag != "" {
+			fieldName = tag
+		}
+		if fieldName == name && field.PkgPath == "" {
+			return []int{i}, true
+		}
+
+		if embedded := derefType(field.Type); field.Anonymous && embedded.Kind() == reflect.Struct {
+			if index, ok := filterFieldIndex(embedded, name); ok {
+				return append([]int{i}, index...), true
+			}
+		}
+	}
+	return nil, false
+}
+
+// filterFieldByIndex is reflect.Value.FieldByIndex returning an invalid
+// value instead of panicking on nil embedded pointers.
+func filterFieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 {
+			if v = derefValue(v); !v.IsValid() {
+				return v
+			}
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// derefType returns the type pointed to by pointer types.
+func derefType(typ reflect.Type) reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+// derefValue returns the value pointed to by pointers and interfaces, or
+// an invalid value for nil ones.
+func derefValue(v reflect.Value) reflect.Value {
+	for v.IsValid() && (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+// isBytes returns whether the type is a byte slice, which is matched as a
+// string.
+func isBytes(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Slice && typ.Elem().Kind() == reflect.Uint8
+}
+
+// stringOf returns the string or byte slice value as a string.
+func stringOf(v reflect.Value) string {
+	if v.Kind() == reflect.String {
+		return v.String()
+	}
+	return string(v.Bytes())
+}
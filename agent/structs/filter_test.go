@@ -0,0 +1,166 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testFilterIndex struct {
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+type testFilterStruct struct {
+	Name    string
+	Port    int
+	Healthy bool
+	Tags    []string
+	Meta    map[string]string
+	Value   []byte
+	Nested  *testFilterStruct
+	Renamed string `bexpr:"Alias"`
+
+	testFilterIndex
+}
+
+func TestFilter_Evaluate(t *testing.T) {
+	t.Parallel()
+
+	data := []*testFilterStruct{{
+		Name:    "web",
+		Port:    8080,
+		Healthy: true,
+		Tags:    []string{"primary", "v2"},
+		Meta:    map[string]string{"env": "prod"},
+		Value:   []byte("hello world"),
+		Nested:  &testFilterStruct{Name: "sidecar"},
+		Renamed: "alias",
+		testFilterIndex: testFilterIndex{
+			CreateIndex: 3,
+			ModifyIndex: 7,
+		},
+	}}
+
+	cases := []struct {
+		expr  string
+		match bool
+	}{
+		{`Name == web`, true},
+		{`Name == "web"`, true},
+		{`Name != web`, false},
+		{`Port == 8080`, true},
+		{`Healthy == true`, true},
+		{`ModifyIndex == 7`, true},
+		{`CreateIndex == 7`, false},
+		{`primary in Tags`, true},
+		{`Tags contains v3`, false},
+		{`Tags is not empty`, true},
+		{`env in Meta`, true},
+		{`Meta.env == prod`, true},
+		{`Meta.missing == prod`, false},
+		{`Value == "hello world"`, true},
+		{`Value contains world`, true},
+		{`Value matches "^hello"`, true},
+		{`Nested.Name == sidecar`, true},
+		{`Nested.Nested.Name == sidecar`, false},
+		{`Nested.Nested.Name is empty`, true},
+		{`Alias == alias`, true},
+		{`Name == web and Port == 80`, false},
+		{`Name == api or Port == 8080`, true},
+		{`not (Name == web or Port == 80)`, false},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			f, err := CreateFilter(tc.expr, data)
+			require.NoError(t, err)
+			out, err := f.Execute(data)
+			require.NoError(t, err)
+			require.Equal(t, tc.match, len(out.([]*testFilterStruct)) == 1)
+		})
+	}
+}
+
+func TestFilter_Invalid(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		expr string
+		err  string
+	}{
+		{`Name ==`, "Failed to parse"},
+		{`Name == "web`, "Failed to parse"},
+		{`Missing == web`, `"Missing"`},
+		{`Nested.Missing == web`, `"Missing"`},
+		{`Renamed == web`, `"Renamed"`},
+		{`Port == web`, "Invalid filter expression"},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.expr, func(t *testing.T) {
+			_, err := CreateFilter(tc.expr, []testFilterStruct(nil))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+
+	_, err := CreateFilter(`Name == web`, testFilterStruct{})
+	require.Error(t, err)
+}
+
+func TestFilter_Execute(t *testing.T) {
+	t.Parallel()
+
+	t.Run("slice", func(t *testing.T) {
+		data := []*testFilterStruct{
+			{Name: "web", Port: 80},
+			nil,
+			{Name: "api", Port: 8080},
+			{Name: "db", Port: 8080},
+		}
+		f, err := CreateFilter(`Port == 8080`, data)
+		require.NoError(t, err)
+		out, err := f.Execute(data)
+		require.NoError(t, err)
+		require.Equal(t, []*testFilterStruct{data[2], data[3]}, out)
+	})
+
+	t.Run("named slice", func(t *testing.T) {
+		type structs []testFilterStruct
+		data := structs{{Name: "web"}, {Name: "api"}}
+		f, err := CreateFilter(`Name == api`, data)
+		require.NoError(t, err)
+		out, err := f.Execute(data)
+		require.NoError(t, err)
+		require.Equal(t, structs{{Name: "api"}}, out)
+
+		out, err = f.Execute(structs(nil))
+		require.NoError(t, err)
+		require.Nil(t, out)
+	})
+
+	t.Run("map", func(t *testing.T) {
+		data := map[string]*testFilterStruct{
+			"web": {Meta: map[string]string{"env": "prod"}},
+			"api": {Meta: map[string]string{"env": "dev"}},
+		}
+		f, err := CreateFilter(`Meta.env == prod`, data)
+		require.NoError(t, err)
+		out, err := f.Execute(data)
+		require.NoError(t, err)
+		require.Equal(t, map[string]*testFilterStruct{"web": data["web"]}, out)
+	})
+
+	t.Run("DirEntries", func(t *testing.T) {
+		data := DirEntries{
+			{Key: "foo", Value: []byte("prod"), RaftIndex: RaftIndex{ModifyIndex: 3}},
+			{Key: "bar", Value: []byte("dev"), RaftIndex: RaftIndex{ModifyIndex: 5}},
+		}
+		f, err := CreateFilter(`ModifyIndex == 5 or Value == prod`, data)
+		require.NoError(t, err)
+		out, err := f.Execute(data)
+		require.NoError(t, err)
+		require.Equal(t, data, out)
+	})
+}
@@ -2,6 +2,7 @@ package structs
 
 import (
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -52,6 +53,13 @@ type Intention struct {
 	// Action is whether this is a whitelist or blacklist intention.
 	Action IntentionAction
 
+	// Permissions is an ordered list of L7 permissions applied to the HTTP
+	// requests of the connections matching this intention. The action of the
+	// first permission matching a request is applied, and Action is applied
+	// to the requests matching none of them. Connections that are not HTTP
+	// are always denied by an intention with permissions.
+	Permissions []*IntentionPermission
+
 	// DefaultAddr, DefaultPort of the local listening proxy (if any) to
 	// make this connection.
 	DefaultAddr string
@@ -162,9 +170,26 @@ func (x *Intention) Validate() error {
 			"SourceType must be set to 'consul'"))
 	}
 
+	for i, perm := range x.Permissions {
+		if err := perm.Validate(); err != nil {
+			result = multierror.Append(result, fmt.Errorf("Permissions[%d]: %v", i, err))
+		}
+	}
+
 	return result
 }
 
+// HTTPAction returns the action of the intention for the given HTTP request,
+// along with the permission that matched it or nil if none did.
+func (x *Intention) HTTPAction(req *ConnectAuthorizeHTTPRequest) (IntentionAction, *IntentionPermission) {
+	for _, perm := range x.Permissions {
+		if perm.Matches(req) {
+			return perm.Action, perm
+		}
+	}
+	return x.Action, nil
+}
+
 // UpdatePrecedence sets the Precedence value based on the fields of this
 // structure.
 func (x *Intention) UpdatePrecedence() {
@@ -234,6 +259,10 @@ func (x *Intention) EstimateSize() int {
 		size += len(k) + len(v)
 	}
 
+	for _, perm := range x.Permissions {
+		size += perm.estimateSize()
+	}
+
 	return size
 }
 
@@ -254,6 +283,268 @@ const (
 	IntentionSourceConsul IntentionSourceType = "consul"
 )
 
+// IntentionPermission is an L7 permission of an intention, applying its action
+// to the requests it matches.
+type IntentionPermission struct {
+	// Action is the action applied to the matching requests.
+	Action IntentionAction
+
+	// HTTP are the attributes of the HTTP requests this permission matches.
+	HTTP *IntentionHTTPPermission
+}
+
+// IntentionHTTPPermission matches HTTP requests on their path, headers and
+// method. A request must match all of the set attributes.
+type IntentionHTTPPermission struct {
+	// PathExact, PathPrefix and PathRegex match the path of the request,
+	// only one of them can be set.
+	PathExact  string
+	PathPrefix string
+	PathRegex  string
+
+	// Header is a list of matches on the request headers, all of them must
+	// match.
+	Header []IntentionHTTPHeaderPermission
+
+	// Methods is a list of upper case HTTP methods, one of them must match.
+	Methods []string
+}
+
+// IntentionHTTPHeaderPermission matches a header of an HTTP request. Only one
+// of Present, Exact, Prefix, Suffix and Regex can be set.
+type IntentionHTTPHeaderPermission struct {
+	Name    string
+	Present bool
+	Exact   string
+	Prefix  string
+	Suffix  string
+	Regex   string
+
+	// Invert inverts the result of the match.
+	Invert bool
+}
+
+// ConnectAuthorizeHTTPRequest holds the attributes of an HTTP request being
+// authorized, matched against the permissions of the intentions.
+type ConnectAuthorizeHTTPRequest struct {
+	Method string
+	Path   string
+
+	// Header holds the request headers, names are matched case-insensitively.
+	Header map[string]string
+}
+
+// header returns the value of the given header and whether it's present.
+func (r *ConnectAuthorizeHTTPRequest) header(name string) (string, bool) {
+	if v, ok := r.Header[name]; ok {
+		return v, true
+	}
+	for k, v := range r.Header {
+		if strings.EqualFold(k, name) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Validate returns an error if the permission is invalid.
+func (p *IntentionPermission) Validate() error {
+	if p == nil {
+		return fmt.Errorf("permission cannot be empty")
+	}
+
+	var result error
+	switch p.Action {
+	case IntentionActionAllow, IntentionActionDeny:
+	default:
+		result = multierror.Append(result, fmt.Errorf(
+			"Action must be set to 'allow' or 'deny'"))
+	}
+
+	if p.HTTP == nil {
+		return multierror.Append(result, fmt.Errorf("HTTP must be set"))
+	}
+	h := p.HTTP
+
+	paths := 0
+	for _, path := range []string{h.PathExact, h.PathPrefix, h.PathRegex} {
+		if path != "" {
+			paths++
+		}
+	}
+	if paths > 1 {
+		result = multierror.Append(result, fmt.Errorf(
+			"HTTP: only one of PathExact, PathPrefix and PathRegex can be set"))
+	}
+	if h.PathExact != "" && !strings.HasPrefix(h.PathExact, "/") {
+		result = multierror.Append(result, fmt.Errorf(
+			"HTTP: PathExact must start with '/'"))
+	}
+	if h.PathPrefix != "" && !strings.HasPrefix(h.PathPrefix, "/") {
+		result = multierror.Append(result, fmt.Errorf(
+			"HTTP: PathPrefix must start with '/'"))
+	}
+	if h.PathRegex != "" {
+		if _, err := regexp.Compile(h.PathRegex); err != nil {
+			result = multierror.Append(result, fmt.Errorf(
+				"HTTP: PathRegex is invalid: %v", err))
+		}
+	}
+
+	for i, hdr := range h.Header {
+		if hdr.Name == "" {
+			result = multierror.Append(result, fmt.Errorf(
+				"HTTP: Header[%d]: Name must be set", i))
+		}
+		matches := 0
+		if hdr.Present {
+			matches++
+		}
+		for _, v := range []string{hdr.Exact, hdr.Prefix, hdr.Suffix, hdr.Regex} {
+			if v != "" {
+				matches++
+			}
+		}
+		if matches != 1 {
+			result = multierror.Append(result, fmt.Errorf(
+				"HTTP: Header[%d]: exactly one of Present, Exact, Prefix, Suffix and Regex must be set", i))
+		}
+		if hdr.Regex != "" {
+			if _, err := regexp.Compile(hdr.Regex); err != nil {
+				result = multierror.Append(result, fmt.Errorf(
+					"HTTP: Header[%d]: Regex is invalid: %v", i, err))
+			}
+		}
+	}
+
+	for _, m := range h.Methods {
+		if m == "" || m != strings.ToUpper(m) {
+			result = multierror.Append(result, fmt.Errorf(
+				"HTTP: method %q must be upper case", m))
+		}
+	}
+
+	if paths == 0 && len(h.Header) == 0 && len(h.Methods) == 0 {
+		result = multierror.Append(result, fmt.Errorf(
+			"HTTP: at least one of a path, a header or a method must be matched"))
+	}
+
+	return result
+}
+
+// Matches returns whether the permission matches the given HTTP request. A
+// permission never matches a request that is not HTTP.
+func (p *IntentionPermission) Matches(req *ConnectAuthorizeHTTPRequest) bool {
+	if p.HTTP == nil || req == nil {
+		return false
+	}
+	h := p.HTTP
+
+	switch {
+	case h.PathExact != "":
+		if req.Path != h.PathExact {
+			return false
+		}
+	case h.PathPrefix != "":
+		if !strings.HasPrefix(req.Path, h.PathPrefix) {
+			return false
+		}
+	case h.PathRegex != "":
+		if !matchesFullRegex(h.PathRegex, req.Path) {
+			return false
+		}
+	}
+
+	for _, hdr := range h.Header {
+		if hdr.matches(req) == hdr.Invert {
+			return false
+		}
+	}
+
+	if len(h.Methods) > 0 {
+		found := false
+		for _, m := range h.Methods {
+			if m == req.Method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matches returns whether the header matches the request, not accounting for
+// Invert.
+func (h IntentionHTTPHeaderPermission) matches(req *ConnectAuthorizeHTTPRequest) bool {
+	v, ok := req.header(h.Name)
+	if !ok {
+		return false
+	}
+	switch {
+	case h.Present:
+		return true
+	case h.Exact != "":
+		return v == h.Exact
+	case h.Prefix != "":
+		return strings.HasPrefix(v, h.Prefix)
+	case h.Suffix != "":
+		return strings.HasSuffix(v, h.Suffix)
+	case h.Regex != "":
+		return matchesFullRegex(h.Regex, v)
+	}
+	return false
+}
+
+// matchesFullRegex returns whether the regular expression matches the whole
+// value, the same way Envoy does. An invalid expression never matches.
+func matchesFullRegex(expr, v string) bool {
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return false
+	}
+	return re.MatchString(v)
+}
+
+// String returns a human-friendly string for this permission.
+func (p *IntentionPermission) String() string {
+	parts := []string{strings.ToUpper(string(p.Action))}
+	if h := p.HTTP; h != nil {
+		if len(h.Methods) > 0 {
+			parts = append(parts, strings.Join(h.Methods, ","))
+		}
+		switch {
+		case h.PathExact != "":
+			parts = append(parts, h.PathExact)
+		case h.PathPrefix != "":
+			parts = append(parts, h.PathPrefix+"*")
+		case h.PathRegex != "":
+			parts = append(parts, "~"+h.PathRegex)
+		}
+		for _, hdr := range h.Header {
+			parts = append(parts, "header:"+hdr.Name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func (p *IntentionPermission) estimateSize() int {
+	size := len(p.Action)
+	if h := p.HTTP; h != nil {
+		size += len(h.PathExact) + len(h.PathPrefix) + len(h.PathRegex)
+		for _, hdr := range h.Header {
+			size += len(hdr.Name) + len(hdr.Exact) + len(hdr.Prefix) + len(hdr.Suffix) + len(hdr.Regex) + 2
+		}
+		for _, m := range h.Methods {
+			size += len(m)
+		}
+	}
+	return size
+}
+
 // Intentions is a list of intentions.
 type Intentions []*Intention
 
@@ -289,6 +580,10 @@ type IntentionRequest struct {
 	// Intention is the intention.
 	Intention *Intention
 
+	// Actor is the accessor ID of the token making the request, recorded
+	// in the changelog. It is set by the leader.
+	Actor string
+
 	// WriteRequest is a common struct containing ACL tokens and other
 	// write-related common elements for requests.
 	WriteRequest
@@ -402,6 +697,50 @@ type IntentionQueryCheckResponse struct {
 	Allowed bool
 }
 
+// IntentionObservation counts the connections between two services that
+// were authorized by an agent without matching any intention, so by the
+// default behavior.
+type IntentionObservation struct {
+	SourceNS, SourceName           string
+	DestinationNS, DestinationName string
+
+	// Allowed and Denied are the number of connections allowed and denied.
+	Allowed uint64
+	Denied  uint64
+
+	// LastSeen is the time of the last connection.
+	LastSeen time.Time
+}
+
+// IntentionObservationRequest is used by agents to report the connections
+// they authorized without matching any intention.
+type IntentionObservationRequest struct {
+	// Datacenter is the target for this request.
+	Datacenter string
+
+	// Node is the node of the agent reporting the observations.
+	Node string
+
+	// Observations are the connections authorized since the last report.
+	Observations []*IntentionObservation
+
+	// WriteRequest is a common struct containing ACL tokens and other
+	// write-related common elements for requests.
+	WriteRequest
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (q *IntentionObservationRequest) RequestDatacenter() string {
+	return q.Datacenter
+}
+
+// IndexedIntentionSuggestions represents the connections observed between
+// services that aren't covered by any intention yet.
+type IndexedIntentionSuggestions struct {
+	Suggestions []*IntentionObservation
+	QueryMeta
+}
+
 // IntentionPrecedenceSorter takes a list of intentions and sorts them
 // based on the match precedence rules for intentions. The intentions
 // closer to the head of the list have higher precedence. i.e. index 0 has
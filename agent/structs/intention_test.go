@@ -142,6 +142,109 @@ func TestIntentionValidate(t *testing.T) {
 			func(x *Intention) { x.SourceType = IntentionSourceType("other") },
 			"SourceType must",
 		},
+
+		{
+			"valid permissions",
+			func(x *Intention) {
+				x.Permissions = []*IntentionPermission{
+					{
+						Action: IntentionActionAllow,
+						HTTP: &IntentionHTTPPermission{
+							PathRegex: "/v1/users/[0-9]+",
+							Header:    []IntentionHTTPHeaderPermission{{Name: "x-debug", Present: true, Invert: true}},
+							Methods:   []string{"GET"},
+						},
+					},
+				}
+			},
+			"",
+		},
+
+		{
+			"permission without action",
+			func(x *Intention) {
+				x.Permissions = []*IntentionPermission{
+					{HTTP: &IntentionHTTPPermission{Methods: []string{"GET"}}},
+				}
+			},
+			"action must be set",
+		},
+
+		{
+			"permission without HTTP",
+			func(x *Intention) {
+				x.Permissions = []*IntentionPermission{{Action: IntentionActionAllow}}
+			},
+			"HTTP must be set",
+		},
+
+		{
+			"permission matching everything",
+			func(x *Intention) {
+				x.Permissions = []*IntentionPermission{
+					{Action: IntentionActionAllow, HTTP: &IntentionHTTPPermission{}},
+				}
+			},
+			"at least one of a path",
+		},
+
+		{
+			"permission with several paths",
+			func(x *Intention) {
+				x.Permissions = []*IntentionPermission{
+					{
+						Action: IntentionActionAllow,
+						HTTP:   &IntentionHTTPPermission{PathExact: "/a", PathPrefix: "/b"},
+					},
+				}
+			},
+			"only one of PathExact",
+		},
+
+		{
+			"permission with relative path",
+			func(x *Intention) {
+				x.Permissions = []*IntentionPermission{
+					{Action: IntentionActionAllow, HTTP: &IntentionHTTPPermission{PathPrefix: "v1"}},
+				}
+			},
+			"PathPrefix must start with '/'",
+		},
+
+		{
+			"permission with invalid regex",
+			func(x *Intention) {
+				x.Permissions = []*IntentionPermission{
+					{Action: IntentionActionAllow, HTTP: &IntentionHTTPPermission{PathRegex: "/v1/("}},
+				}
+			},
+			"PathRegex is invalid",
+		},
+
+		{
+			"permission with lower case method",
+			func(x *Intention) {
+				x.Permissions = []*IntentionPermission{
+					{Action: IntentionActionAllow, HTTP: &IntentionHTTPPermission{Methods: []string{"get"}}},
+				}
+			},
+			"must be upper case",
+		},
+
+		{
+			"permission with ambiguous header",
+			func(x *Intention) {
+				x.Permissions = []*IntentionPermission{
+					{
+						Action: IntentionActionAllow,
+						HTTP: &IntentionHTTPPermission{
+							Header: []IntentionHTTPHeaderPermission{{Name: "x-a", Exact: "a", Prefix: "b"}},
+						},
+					},
+				}
+			},
+			"exactly one of Present",
+		},
 	}
 
 	for _, tc := range cases {
@@ -161,6 +264,72 @@ func TestIntentionValidate(t *testing.T) {
 	}
 }
 
+func TestIntentionHTTPAction(t *testing.T) {
+	ixn := &Intention{
+		Action: IntentionActionDeny,
+		Permissions: []*IntentionPermission{
+			{
+				Action: IntentionActionDeny,
+				HTTP: &IntentionHTTPPermission{
+					PathPrefix: "/admin",
+				},
+			},
+			{
+				Action: IntentionActionAllow,
+				HTTP: &IntentionHTTPPermission{
+					PathRegex: "/users/[0-9]+",
+					Methods:   []string{"GET", "HEAD"},
+				},
+			},
+			{
+				Action: IntentionActionAllow,
+				HTTP: &IntentionHTTPPermission{
+					PathExact: "/login",
+					Header: []IntentionHTTPHeaderPermission{
+						{Name: "X-Tenant", Suffix: ".example.com"},
+						{Name: "X-Debug", Present: true, Invert: true},
+					},
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		Name       string
+		Req        *ConnectAuthorizeHTTPRequest
+		Action     IntentionAction
+		Permission int
+	}{
+		{"denied prefix", &ConnectAuthorizeHTTPRequest{Method: "GET", Path: "/admin/users/1"}, IntentionActionDeny, 0},
+		{"allowed method", &ConnectAuthorizeHTTPRequest{Method: "GET", Path: "/users/1"}, IntentionActionAllow, 1},
+		{"other method", &ConnectAuthorizeHTTPRequest{Method: "DELETE", Path: "/users/1"}, IntentionActionDeny, -1},
+		{"partial regex", &ConnectAuthorizeHTTPRequest{Method: "GET", Path: "/users/1/friends"}, IntentionActionDeny, -1},
+		{
+			"allowed headers",
+			&ConnectAuthorizeHTTPRequest{Path: "/login", Header: map[string]string{"x-tenant": "a.example.com"}},
+			IntentionActionAllow, 2,
+		},
+		{
+			"inverted header",
+			&ConnectAuthorizeHTTPRequest{Path: "/login", Header: map[string]string{"x-tenant": "a.example.com", "x-debug": "1"}},
+			IntentionActionDeny, -1,
+		},
+		{"missing header", &ConnectAuthorizeHTTPRequest{Path: "/login"}, IntentionActionDeny, -1},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			action, perm := ixn.HTTPAction(tc.Req)
+			assert.Equal(t, tc.Action, action)
+			if tc.Permission < 0 {
+				assert.Nil(t, perm)
+			} else {
+				assert.Equal(t, ixn.Permissions[tc.Permission], perm)
+			}
+		})
+	}
+}
+
 func TestIntentionPrecedenceSorter(t *testing.T) {
 	cases := []struct {
 		Name     string
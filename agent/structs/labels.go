@@ -0,0 +1,209 @@
+package structs
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// labelsMaxPairs is the maximum number of labels of a resource.
+	labelsMaxPairs = 64
+
+	// labelKeyMaxLength and labelValueMaxLength are the maximum lengths of
+	// the label keys and values.
+	labelKeyMaxLength   = 128
+	labelValueMaxLength = 256
+)
+
+// labelKeyFormat and labelValueFormat restrict the labels to the characters
+// that can't be confused with the label selector syntax.
+var (
+	labelKeyFormat   = regexp.MustCompile(`^[a-zA-Z0-9_./-]+$`).MatchString
+	labelValueFormat = regexp.MustCompile(`^[a-zA-Z0-9_./-]*$`).MatchString
+)
+
+// ValidateLabels validates the labels of a resource, such as a token, a
+// policy, a service or a KV entry.
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > labelsMaxPairs {
+		return fmt.Errorf("Labels cannot contain more than %d key/value pairs", labelsMaxPairs)
+	}
+
+	for key, value := range labels {
+		switch {
+		case key == "":
+			return fmt.Errorf("Label key cannot be blank")
+		case len(key) > labelKeyMaxLength:
+			return fmt.Errorf("Label key %q is too long (limit: %d characters)", key, labelKeyMaxLength)
+		case !labelKeyFormat(key):
+			return fmt.Errorf("Label key %q contains invalid characters", key)
+		case len(value) > labelValueMaxLength:
+			return fmt.Errorf("Label value for key %q is too long (limit: %d characters)", key, labelValueMaxLength)
+		case !labelValueFormat(value):
+			return fmt.Errorf("Label value for key %q contains invalid characters", key)
+		}
+	}
+
+	return nil
+}
+
+// ParseLabels parses a comma separated list of key=value labels, as given
+// in the query params of the endpoints writing resources with labels.
+func ParseLabels(s string) (map[string]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	labels := make(map[string]string)
+	for _, part := range strings.Split(s, ",") {
+		idx := strings.Index(part, "=")
+		if idx < 0 {
+			return nil, fmt.Errorf("label %q is not of the form key=value", part)
+		}
+		labels[strings.TrimSpace(part[:idx])] = strings.TrimSpace(part[idx+1:])
+	}
+	if err := ValidateLabels(labels); err != nil {
+		return nil, err
+	}
+	return labels, nil
+}
+
+// writeLabelsHash writes the labels sorted by key to the hash of a resource.
+// Nothing is written without labels so the hashes of the resources created
+// before labels existed don't change.
+func writeLabelsHash(w io.Writer, labels map[string]string) {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		w.Write([]byte(k))
+		w.Write([]byte(labels[k]))
+	}
+}
+
+// LabelOperator is the operator of a label requirement.
+type LabelOperator string
+
+const (
+	LabelEquals    LabelOperator = "="
+	LabelNotEquals LabelOperator = "!="
+	LabelExists    LabelOperator = "exists"
+	LabelNotExists LabelOperator = "!exists"
+)
+
+// LabelRequirement is a single requirement of a label selector.
+type LabelRequirement struct {
+	Key      string
+	Operator LabelOperator
+	Value    string
+}
+
+// Matches returns whether the labels satisfy the requirement. A missing label
+// satisfies a != requirement.
+func (r LabelRequirement) Matches(labels map[string]string) bool {
+	value, ok := labels[r.Key]
+	switch r.Operator {
+	case LabelEquals:
+		return ok && value == r.Value
+	case LabelNotEquals:
+		return !ok || value != r.Value
+	case LabelExists:
+		return ok
+	case LabelNotExists:
+		return !ok
+	}
+	return false
+}
+
+// String returns the requirement in the label selector syntax.
+func (r LabelRequirement) String() string {
+	switch r.Operator {
+	case LabelExists:
+		return r.Key
+	case LabelNotExists:
+		return "!" + r.Key
+	}
+	return r.Key + string(r.Operator) + r.Value
+}
+
+// LabelSelector selects the resources whose labels satisfy all of its
+// requirements. The empty selector selects everything.
+type LabelSelector []LabelRequirement
+
+// ParseLabelSelector parses a comma separated list of label requirements,
+// each being one of:
+//
+//	key=value, key==value  the label is set to the value
+//	key!=value             the label is missing or set to another value
+//	key                    the label is set
+//	!key                   the label is missing
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	var selector LabelSelector
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			return nil, fmt.Errorf("empty requirement in label selector %q", s)
+		}
+
+		var req LabelRequirement
+		switch {
+		case strings.Contains(part, "!="):
+			idx := strings.Index(part, "!=")
+			req = LabelRequirement{Key: part[:idx], Operator: LabelNotEquals, Value: part[idx+2:]}
+		case strings.Contains(part, "=="):
+			idx := strings.Index(part, "==")
+			req = LabelRequirement{Key: part[:idx], Operator: LabelEquals, Value: part[idx+2:]}
+		case strings.Contains(part, "="):
+			idx := strings.Index(part, "=")
+			req = LabelRequirement{Key: part[:idx], Operator: LabelEquals, Value: part[idx+1:]}
+		case strings.HasPrefix(part, "!"):
+			req = LabelRequirement{Key: part[1:], Operator: LabelNotExists}
+		default:
+			req = LabelRequirement{Key: part, Operator: LabelExists}
+		}
+
+		req.Key = strings.TrimSpace(req.Key)
+		req.Value = strings.TrimSpace(req.Value)
+		if req.Key == "" || !labelKeyFormat(req.Key) {
+			return nil, fmt.Errorf("invalid label key in requirement %q", part)
+		}
+		if !labelValueFormat(req.Value) {
+			return nil, fmt.Errorf("invalid label value in requirement %q", part)
+		}
+		selector = append(selector, req)
+	}
+
+	// Sort the requirements so equivalent selectors have the same string.
+	sort.Slice(selector, func(i, j int) bool {
+		return selector[i].String() < selector[j].String()
+	})
+	return selector, nil
+}
+
+// Matches returns whether the labels satisfy all the requirements.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s {
+		if !req.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the selector in the label selector syntax.
+func (s LabelSelector) String() string {
+	parts := make([]string, len(s))
+	for i, req := range s {
+		parts[i] = req.String()
+	}
+	return strings.Join(parts, ",")
+}
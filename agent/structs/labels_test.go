@@ -0,0 +1,148 @@
+package structs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLabels(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, ValidateLabels(nil))
+	require.NoError(t, ValidateLabels(map[string]string{
+		"team":               "payments",
+		"example.com/tier":   "gold",
+		"empty":              "",
+		"with_underscore-42": "a.b/c",
+	}))
+
+	cases := []struct {
+		name   string
+		labels map[string]string
+		err    string
+	}{
+		{"blank key", map[string]string{"": "x"}, "cannot be blank"},
+		{"key too long", map[string]string{strings.Repeat("k", labelKeyMaxLength+1): "x"}, "too long"},
+		{"invalid key", map[string]string{"team name": "x"}, "invalid characters"},
+		{"key with operator", map[string]string{"a=b": "x"}, "invalid characters"},
+		{"value too long", map[string]string{"k": strings.Repeat("v", labelValueMaxLength+1)}, "too long"},
+		{"invalid value", map[string]string{"k": "a,b"}, "invalid characters"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateLabels(tc.labels)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.err)
+		})
+	}
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= labelsMaxPairs; i++ {
+		tooMany[strings.Repeat("k", i+1)] = "v"
+	}
+	require.Error(t, ValidateLabels(tooMany))
+}
+
+func TestParseLabels(t *testing.T) {
+	t.Parallel()
+
+	labels, err := ParseLabels("")
+	require.NoError(t, err)
+	require.Nil(t, labels)
+
+	labels, err = ParseLabels("team=payments, env=prod,empty=")
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"team": "payments", "env": "prod", "empty": ""}, labels)
+
+	_, err = ParseLabels("team")
+	require.Error(t, err)
+	_, err = ParseLabels("team=pay ments")
+	require.Error(t, err)
+}
+
+func TestParseLabelSelector(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		selector string
+		expected LabelSelector
+		str      string
+	}{
+		{"", nil, ""},
+		{
+			"team=payments",
+			LabelSelector{{Key: "team", Operator: LabelEquals, Value: "payments"}},
+			"team=payments",
+		},
+		{
+			"team==payments, env!=dev",
+			LabelSelector{
+				{Key: "env", Operator: LabelNotEquals, Value: "dev"},
+				{Key: "team", Operator: LabelEquals, Value: "payments"},
+			},
+			"env!=dev,team=payments",
+		},
+		{
+			"tier,!deprecated",
+			LabelSelector{
+				{Key: "deprecated", Operator: LabelNotExists},
+				{Key: "tier", Operator: LabelExists},
+			},
+			"!deprecated,tier",
+		},
+		{
+			"team=",
+			LabelSelector{{Key: "team", Operator: LabelEquals, Value: ""}},
+			"team=",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.selector, func(t *testing.T) {
+			selector, err := ParseLabelSelector(tc.selector)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, selector)
+			require.Equal(t, tc.str, selector.String())
+		})
+	}
+
+	for _, invalid := range []string{"team=payments,", "=payments", "!", "team=pay ments", "te am"} {
+		_, err := ParseLabelSelector(invalid)
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestLabelSelector_Matches(t *testing.T) {
+	t.Parallel()
+
+	labels := map[string]string{"team": "payments", "env": "prod", "tier": ""}
+	cases := []struct {
+		selector string
+		matches  bool
+	}{
+		{"", true},
+		{"team=payments", true},
+		{"team=search", false},
+		{"team=payments,env!=dev", true},
+		{"team=payments,env!=prod", false},
+		{"owner!=bob", true},
+		{"tier", true},
+		{"tier=", true},
+		{"owner", false},
+		{"!owner", true},
+		{"!team", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.selector, func(t *testing.T) {
+			selector, err := ParseLabelSelector(tc.selector)
+			require.NoError(t, err)
+			require.Equal(t, tc.matches, selector.Matches(labels))
+		})
+	}
+
+	// Resources without labels only match the negative requirements.
+	selector, err := ParseLabelSelector("!team,env!=prod")
+	require.NoError(t, err)
+	require.True(t, selector.Matches(nil))
+}
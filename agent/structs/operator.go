@@ -2,6 +2,7 @@ package structs
 
 import (
 	"net"
+	"time"
 
 	"github.com/hashicorp/consul/agent/consul/autopilot"
 	"github.com/hashicorp/raft"
@@ -102,3 +103,43 @@ type NetworkSegment struct {
 	// for this segment.
 	RPCListener bool
 }
+
+// Usage is the number of entries of each kind in the state of a datacenter,
+// used for capacity planning.
+type Usage struct {
+	Nodes            int
+	Services         int
+	ServiceInstances int
+	KVEntries        int
+	ACLTokens        int
+	Intentions       int
+	ConfigEntries    int
+}
+
+// UsageResponse is returned when querying the usage of the datacenters. It's
+// keyed by datacenter.
+type UsageResponse struct {
+	Usage map[string]Usage
+	QueryMeta
+}
+
+// KeyringRotationStatus is the status of the automatic rotation of the
+// gossip encryption key of the LAN pool of a datacenter, as reported by its
+// leader.
+type KeyringRotationStatus struct {
+	// Enabled is whether the leader rotates the key.
+	Enabled bool
+
+	// Interval is the interval between the rotations.
+	Interval time.Duration
+
+	// LastRotation is the time of the last successful rotation since the
+	// server became the leader, if any.
+	LastRotation time.Time
+
+	// NextRotation is the time of the next rotation.
+	NextRotation time.Time
+
+	// LastError is the error of the last rotation attempt, if it failed.
+	LastError string
+}
@@ -2,6 +2,7 @@ package structs
 
 import (
 	"strconv"
+	"time"
 
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/types"
@@ -319,6 +320,77 @@ type PreparedQueryExecuteResponse struct {
 	QueryMeta
 }
 
+// PreparedQueryStats has the execution stats of a prepared query. Stats are
+// recorded by the servers executing the query and periodically written to the
+// state store, so they persist across restarts and leader changes.
+type PreparedQueryStats struct {
+	// ID and Name identify the query.
+	ID   string
+	Name string
+
+	// Executions is the number of times the query was executed.
+	Executions uint64
+
+	// Failovers is the number of executions that had to fail over to a
+	// remote datacenter.
+	Failovers uint64
+
+	// EmptyResults is the number of executions that didn't return any
+	// nodes, even after failing over.
+	EmptyResults uint64
+
+	// LatencyTotal and LatencyMax are the total and the highest time spent
+	// executing the query, including failovers.
+	LatencyTotal time.Duration
+	LatencyMax   time.Duration
+
+	// LastExecuted is the time of the last execution, it is zero if the
+	// query was never executed.
+	LastExecuted time.Time
+}
+
+// Add adds the executions recorded in other to the stats.
+func (s *PreparedQueryStats) Add(other *PreparedQueryStats) {
+	s.Executions += other.Executions
+	s.Failovers += other.Failovers
+	s.EmptyResults += other.EmptyResults
+	s.LatencyTotal += other.LatencyTotal
+	if other.LatencyMax > s.LatencyMax {
+		s.LatencyMax = other.LatencyMax
+	}
+	if other.LastExecuted.After(s.LastExecuted) {
+		s.LastExecuted = other.LastExecuted
+	}
+}
+
+// PreparedQueryStatsRequest is used by a server to write the executions it
+// recorded since its last request to the state store.
+type PreparedQueryStatsRequest struct {
+	Datacenter string
+
+	// Node is the name of the server which recorded the executions.
+	Node string
+
+	// Stats are the executions recorded since the last request, which are
+	// added to the stored stats of the queries.
+	Stats []*PreparedQueryStats
+
+	WriteRequest
+}
+
+// RequestDatacenter returns the datacenter for a given request.
+func (q *PreparedQueryStatsRequest) RequestDatacenter() string {
+	return q.Datacenter
+}
+
+// PreparedQueryStatsResponse has the stats of the prepared queries.
+type PreparedQueryStatsResponse struct {
+	Stats []*PreparedQueryStats
+
+	// QueryMeta has freshness information about the query.
+	QueryMeta
+}
+
 // PreparedQueryExplainResponse has the results when explaining a query/
 type PreparedQueryExplainResponse struct {
 	// Query has the fully-rendered query.
@@ -19,7 +19,9 @@ type ServiceDefinition struct {
 	Name              string
 	Tags              []string
 	Address           string
+	TaggedAddresses   map[string]ServiceAddress `json:",omitempty"`
 	Meta              map[string]string
+	Labels            map[string]string `json:",omitempty"`
 	Port              int
 	Check             CheckType
 	Checks            CheckTypes
@@ -52,7 +54,9 @@ func (s *ServiceDefinition) NodeService() *NodeService {
 		Service:           s.Name,
 		Tags:              s.Tags,
 		Address:           s.Address,
+		TaggedAddresses:   s.TaggedAddresses,
 		Meta:              s.Meta,
+		Labels:            s.Labels,
 		Port:              s.Port,
 		Weights:           s.Weights,
 		EnableTagOverride: s.EnableTagOverride,
@@ -33,31 +33,63 @@ type RaftIndex struct {
 // These are serialized between Consul servers and stored in Consul snapshots,
 // so entries must only ever be added.
 const (
-	RegisterRequestType        MessageType = 0
-	DeregisterRequestType                  = 1
-	KVSRequestType                         = 2
-	SessionRequestType                     = 3
-	ACLRequestType                         = 4 // DEPRECATED (ACL-Legacy-Compat)
-	TombstoneRequestType                   = 5
-	CoordinateBatchUpdateType              = 6
-	PreparedQueryRequestType               = 7
-	TxnRequestType                         = 8
-	AutopilotRequestType                   = 9
-	AreaRequestType                        = 10
-	ACLBootstrapRequestType                = 11
-	IntentionRequestType                   = 12
-	ConnectCARequestType                   = 13
-	ConnectCAProviderStateType             = 14
-	ConnectCAConfigType                    = 15 // FSM snapshots only.
-	IndexRequestType                       = 16 // FSM snapshots only.
-	ACLTokenSetRequestType                 = 17
-	ACLTokenDeleteRequestType              = 18
-	ACLPolicySetRequestType                = 19
-	ACLPolicyDeleteRequestType             = 20
-	ConnectCALeafRequestType               = 21
-	ConfigEntryRequestType                 = 22
+	RegisterRequestType           MessageType = 0
+	DeregisterRequestType                     = 1
+	KVSRequestType                            = 2
+	SessionRequestType                        = 3
+	ACLRequestType                            = 4 // DEPRECATED (ACL-Legacy-Compat)
+	TombstoneRequestType                      = 5
+	CoordinateBatchUpdateType                 = 6
+	PreparedQueryRequestType                  = 7
+	TxnRequestType                            = 8
+	AutopilotRequestType                      = 9
+	AreaRequestType                           = 10
+	ACLBootstrapRequestType                   = 11
+	IntentionRequestType                      = 12
+	ConnectCARequestType                      = 13
+	ConnectCAProviderStateType                = 14
+	ConnectCAConfigType                       = 15 // FSM snapshots only.
+	IndexRequestType                          = 16 // FSM snapshots only.
+	ACLTokenSetRequestType                    = 17
+	ACLTokenDeleteRequestType                 = 18
+	ACLPolicySetRequestType                   = 19
+	ACLPolicyDeleteRequestType                = 20
+	ConnectCALeafRequestType                  = 21
+	ConfigEntryRequestType                    = 22
+	ConnectChangeType                         = 23 // FSM snapshots only.
+	PreparedQueryStatsRequestType             = 24
 )
 
+// MessageTypeNames maps the message types to human readable names, used to
+// describe the records of snapshots.
+var MessageTypeNames = map[MessageType]string{
+	RegisterRequestType:           "Register",
+	DeregisterRequestType:         "Deregister",
+	KVSRequestType:                "KVS",
+	SessionRequestType:            "Session",
+	ACLRequestType:                "ACL",
+	TombstoneRequestType:          "Tombstone",
+	CoordinateBatchUpdateType:     "CoordinateBatchUpdate",
+	PreparedQueryRequestType:      "PreparedQuery",
+	TxnRequestType:                "Txn",
+	AutopilotRequestType:          "Autopilot",
+	AreaRequestType:               "Area",
+	ACLBootstrapRequestType:       "ACLBootstrap",
+	IntentionRequestType:          "Intention",
+	ConnectCARequestType:          "ConnectCA",
+	ConnectCAProviderStateType:    "ConnectCAProviderState",
+	ConnectCAConfigType:           "ConnectCAConfig",
+	IndexRequestType:              "Index",
+	ACLTokenSetRequestType:        "ACLToken",
+	ACLTokenDeleteRequestType:     "ACLTokenDelete",
+	ACLPolicySetRequestType:       "ACLPolicy",
+	ACLPolicyDeleteRequestType:    "ACLPolicyDelete",
+	ConnectCALeafRequestType:      "ConnectCALeaf",
+	ConfigEntryRequestType:        "ConfigEntry",
+	ConnectChangeType:             "ConnectChange",
+	PreparedQueryStatsRequestType: "PreparedQueryStats",
+}
+
 const (
 	// IgnoreUnknownTypeFlag is set along with a MessageType
 	// to indicate that the message type can be safely ignored
@@ -164,6 +196,17 @@ type QueryOptions struct {
 	// ignored if the endpoint supports background refresh caching. See
 	// https://www.consul.io/api/index.html#agent-caching for more details.
 	StaleIfError time.Duration
+
+	// Filter is an expression selecting the results to return, with the
+	// syntax of go-bexpr, see CreateFilter. It is only supported by some
+	// endpoints, which evaluate it on the servers so the results it
+	// discards are never sent over the network.
+	Filter string
+
+	// LabelSelector selects the results by their labels, with the syntax of
+	// ParseLabelSelector. It is only supported by the endpoints listing
+	// resources with labels, and is evaluated on the servers like Filter.
+	LabelSelector string
 }
 
 // IsRead is always true for QueryOption.
@@ -227,8 +270,22 @@ type QueryMeta struct {
 	// Having `discovery_max_stale` on the agent can affect whether
 	// the request was served by a leader.
 	ConsistencyLevel string
+
+	// ServedBy and ServedByID are the node name and ID of the server that
+	// served the query, and ServedByRole is whether it was the leader or a
+	// follower at the time.
+	ServedBy     string
+	ServedByID   types.NodeID
+	ServedByRole string
 }
 
+const (
+	// QueryServedByLeader and QueryServedByFollower are the roles of the
+	// servers reported in QueryMeta.ServedByRole.
+	QueryServedByLeader   = "leader"
+	QueryServedByFollower = "follower"
+)
+
 // RegisterRequest is used for the Catalog.Register endpoint
 // to register a node as providing a service. If no service
 // is provided, the node is registered.
@@ -332,10 +389,14 @@ func (r *DCSpecificRequest) CacheInfo() cache.RequestInfo {
 		MustRevalidate: r.MustRevalidate,
 	}
 
-	// To calculate the cache key we only hash the node filters. The
-	// datacenter is handled by the cache framework. The other fields are
-	// not, but should not be used in any cache types.
-	v, err := hashstructure.Hash(r.NodeMetaFilters, nil)
+	// To calculate the cache key we only hash the node filters, the filter
+	// expression and the label selector. The datacenter is handled by the cache framework.
+	// The other fields are not, but should not be used in any cache types.
+	v, err := hashstructure.Hash([]interface{}{
+		r.NodeMetaFilters,
+		r.Filter,
+		r.LabelSelector,
+	}, nil)
 	if err == nil {
 		// If there is an error, we don't set the key. A blank key forces
 		// no cache for this request so the request is forwarded directly
@@ -406,6 +467,8 @@ func (r *ServiceSpecificRequest) CacheInfo() cache.RequestInfo {
 		r.ServiceAddress,
 		r.TagFilter,
 		r.Connect,
+		r.Filter,
+		r.LabelSelector,
 	}, nil)
 	if err == nil {
 		// If there is an error, we don't set the key. A blank key forces
@@ -421,6 +484,49 @@ func (r *ServiceSpecificRequest) CacheMinIndex() uint64 {
 	return r.QueryOptions.MinQueryIndex
 }
 
+// ServiceDumpRequest is used to query the instances of all the services of
+// a kind, such as the mesh gateways of a datacenter.
+type ServiceDumpRequest struct {
+	Datacenter  string
+	ServiceKind ServiceKind
+	Source      QuerySource
+	QueryOptions
+}
+
+func (r *ServiceDumpRequest) RequestDatacenter() string {
+	return r.Datacenter
+}
+
+func (r *ServiceDumpRequest) CacheInfo() cache.RequestInfo {
+	info := cache.RequestInfo{
+		Token:          r.Token,
+		Datacenter:     r.Datacenter,
+		MinIndex:       r.MinQueryIndex,
+		Timeout:        r.MaxQueryTime,
+		MaxAge:         r.MaxAge,
+		MustRevalidate: r.MustRevalidate,
+	}
+
+	// The kind and filter expression are the only fields affecting the
+	// output other than the datacenter and token handled by the cache.
+	v, err := hashstructure.Hash([]interface{}{
+		r.ServiceKind,
+		r.Filter,
+	}, nil)
+	if err == nil {
+		// If there is an error, we don't set the key. A blank key forces
+		// no cache for this request so the request is forwarded directly
+		// to the server.
+		info.Key = strconv.FormatUint(v, 10)
+	}
+
+	return info
+}
+
+func (r *ServiceDumpRequest) CacheMinIndex() uint64 {
+	return r.QueryOptions.MinQueryIndex
+}
+
 // NodeSpecificRequest is used to request the information about a single node
 type NodeSpecificRequest struct {
 	Datacenter string
@@ -575,8 +681,10 @@ type ServiceNode struct {
 	ServiceName              string
 	ServiceTags              []string
 	ServiceAddress           string
+	ServiceTaggedAddresses   map[string]ServiceAddress `json:",omitempty"`
 	ServiceWeights           Weights
 	ServiceMeta              map[string]string
+	ServiceLabels            map[string]string `json:",omitempty"`
 	ServicePort              int
 	ServiceEnableTagOverride bool
 	// DEPRECATED (ProxyDestination) - remove this when removing ProxyDestination
@@ -596,6 +704,20 @@ func (s *ServiceNode) PartialClone() *ServiceNode {
 	for k, v := range s.ServiceMeta {
 		nsmeta[k] = v
 	}
+	var taggedAddrs map[string]ServiceAddress
+	if len(s.ServiceTaggedAddresses) > 0 {
+		taggedAddrs = make(map[string]ServiceAddress)
+		for k, v := range s.ServiceTaggedAddresses {
+			taggedAddrs[k] = v
+		}
+	}
+	var labels map[string]string
+	if len(s.ServiceLabels) > 0 {
+		labels = make(map[string]string, len(s.ServiceLabels))
+		for k, v := range s.ServiceLabels {
+			labels[k] = v
+		}
+	}
 
 	return &ServiceNode{
 		// Skip ID, see above.
@@ -607,8 +729,10 @@ func (s *ServiceNode) PartialClone() *ServiceNode {
 		ServiceName:              s.ServiceName,
 		ServiceTags:              tags,
 		ServiceAddress:           s.ServiceAddress,
+		ServiceTaggedAddresses:   taggedAddrs,
 		ServicePort:              s.ServicePort,
 		ServiceMeta:              nsmeta,
+		ServiceLabels:            labels,
 		ServiceWeights:           s.ServiceWeights,
 		ServiceEnableTagOverride: s.ServiceEnableTagOverride,
 		// DEPRECATED (ProxyDestination) - remove this when removing ProxyDestination
@@ -630,8 +754,10 @@ func (s *ServiceNode) ToNodeService() *NodeService {
 		Service:           s.ServiceName,
 		Tags:              s.ServiceTags,
 		Address:           s.ServiceAddress,
+		TaggedAddresses:   s.ServiceTaggedAddresses,
 		Port:              s.ServicePort,
 		Meta:              s.ServiceMeta,
+		Labels:            s.ServiceLabels,
 		Weights:           &s.ServiceWeights,
 		EnableTagOverride: s.ServiceEnableTagOverride,
 		Proxy:             s.ServiceProxy,
@@ -643,6 +769,39 @@ func (s *ServiceNode) ToNodeService() *NodeService {
 	}
 }
 
+// ServiceAddress is an address of a service, such as its WAN address in the
+// TaggedAddresses of the service.
+type ServiceAddress struct {
+	Address string
+	Port    int
+}
+
+// ServiceAddressesToAPI converts the tagged addresses of a service to their
+// api representation.
+func ServiceAddressesToAPI(addrs map[string]ServiceAddress) map[string]api.ServiceAddress {
+	if len(addrs) == 0 {
+		return nil
+	}
+	out := make(map[string]api.ServiceAddress, len(addrs))
+	for tag, addr := range addrs {
+		out[tag] = api.ServiceAddress{Address: addr.Address, Port: addr.Port}
+	}
+	return out
+}
+
+// ServiceAddressesFromAPI converts the tagged addresses of a service from
+// their api representation.
+func ServiceAddressesFromAPI(addrs map[string]api.ServiceAddress) map[string]ServiceAddress {
+	if len(addrs) == 0 {
+		return nil
+	}
+	out := make(map[string]ServiceAddress, len(addrs))
+	for tag, addr := range addrs {
+		out[tag] = ServiceAddress{Address: addr.Address, Port: addr.Port}
+	}
+	return out
+}
+
 // Weights represent the weight used by DNS for a given status
 type Weights struct {
 	Passing int
@@ -665,8 +824,28 @@ const (
 	// service proxies another service within Consul and speaks the connect
 	// protocol.
 	ServiceKindConnectProxy ServiceKind = "connect-proxy"
+
+	// ServiceKindIngressGateway is a gateway letting the traffic from
+	// outside the mesh reach the Connect services listed as its upstreams.
+	ServiceKindIngressGateway ServiceKind = "ingress-gateway"
+
+	// ServiceKindTerminatingGateway is a gateway letting the Connect
+	// services reach the services outside the mesh listed as its upstreams.
+	ServiceKindTerminatingGateway ServiceKind = "terminating-gateway"
+
+	// ServiceKindMeshGateway is a gateway routing the Connect traffic between
+	// datacenters. It doesn't front any specific service, the traffic is
+	// routed by the SNI of the TLS connections without terminating them.
+	ServiceKindMeshGateway ServiceKind = "mesh-gateway"
 )
 
+// IsGateway returns true if the kind is one of the gateway kinds fronting
+// the services listed as their upstreams. Mesh gateways aren't included since
+// they route the traffic to any service.
+func (k ServiceKind) IsGateway() bool {
+	return k == ServiceKindIngressGateway || k == ServiceKindTerminatingGateway
+}
+
 // NodeService is a service provided by a node
 type NodeService struct {
 	// Kind is the kind of service this is. Different kinds of services may
@@ -683,6 +862,17 @@ type NodeService struct {
 	Weights           *Weights
 	EnableTagOverride bool
 
+	// Labels are arbitrary key/value pairs organizing the services, which
+	// can be selected with the label selector of the agent, catalog and
+	// health endpoints.
+	Labels map[string]string `json:",omitempty"`
+
+	// TaggedAddresses are the addresses of the service for specific
+	// consumers, such as "lan" and "wan" for a service behind a NAT. The WAN
+	// address is used instead of the service address when the addresses of
+	// other datacenters are translated.
+	TaggedAddresses map[string]ServiceAddress `json:",omitempty"`
+
 	// ProxyDestination is DEPRECATED in favor of Proxy.DestinationServiceName.
 	// It's retained since this struct is used to parse input for
 	// /catalog/register but nothing else internal should use it - once
@@ -789,6 +979,74 @@ func (s *NodeService) Validate() error {
 			result = multierror.Append(result, fmt.Errorf(
 				"A Proxy cannot also be Connect Native, only typical services"))
 		}
+
+		if err := s.Proxy.Expose.Validate(); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	// Gateway validation
+	if s.Kind.IsGateway() {
+		if s.Proxy.DestinationServiceName != "" || s.ProxyDestination != "" {
+			result = multierror.Append(result, fmt.Errorf(
+				"Proxy.DestinationServiceName cannot be set for a %s, the fronted "+
+					"services are listed as Proxy.Upstreams", s.Kind))
+		}
+
+		for _, u := range s.Proxy.Upstreams {
+			if u.DestinationType != "" && u.DestinationType != UpstreamDestTypeService {
+				result = multierror.Append(result, fmt.Errorf(
+					"Upstream %q of a %s must be a service", u.DestinationName, s.Kind))
+			}
+			if strings.TrimSpace(u.DestinationName) == "" {
+				result = multierror.Append(result, fmt.Errorf(
+					"Upstreams of a %s must have a DestinationName", s.Kind))
+			}
+		}
+
+		if s.Connect.Native {
+			result = multierror.Append(result, fmt.Errorf(
+				"A %s cannot also be Connect Native, only typical services", s.Kind))
+		}
+	}
+
+	// MeshGateway validation
+	if s.Kind == ServiceKindMeshGateway {
+		if s.Proxy.DestinationServiceName != "" || s.ProxyDestination != "" {
+			result = multierror.Append(result, fmt.Errorf(
+				"Proxy.DestinationServiceName cannot be set for a Mesh Gateway"))
+		}
+
+		if len(s.Proxy.Upstreams) > 0 {
+			result = multierror.Append(result, fmt.Errorf(
+				"Mesh Gateways cannot have upstreams"))
+		}
+
+		if s.Port == 0 {
+			result = multierror.Append(result, fmt.Errorf(
+				"Port must be set for a Mesh Gateway"))
+		}
+
+		if s.Connect.Native {
+			result = multierror.Append(result, fmt.Errorf(
+				"A Mesh Gateway cannot also be Connect Native"))
+		}
+
+		if s.Connect.SidecarService != nil {
+			result = multierror.Append(result, fmt.Errorf(
+				"A Mesh Gateway cannot have a SidecarService"))
+		}
+	}
+
+	// Proxy mesh gateway mode validation
+	if err := s.Proxy.MeshGateway.Validate(); err != nil {
+		result = multierror.Append(result, err)
+	}
+	for _, u := range s.Proxy.Upstreams {
+		if err := u.MeshGateway.Validate(); err != nil {
+			result = multierror.Append(result, fmt.Errorf(
+				"Upstream %q: %v", u.DestinationName, err))
+		}
 	}
 
 	// Nested sidecar validation
@@ -822,9 +1080,11 @@ func (s *NodeService) IsSame(other *NodeService) bool {
 		s.Service != other.Service ||
 		!reflect.DeepEqual(s.Tags, other.Tags) ||
 		s.Address != other.Address ||
+		!reflect.DeepEqual(s.TaggedAddresses, other.TaggedAddresses) ||
 		s.Port != other.Port ||
 		!reflect.DeepEqual(s.Weights, other.Weights) ||
 		!reflect.DeepEqual(s.Meta, other.Meta) ||
+		!reflect.DeepEqual(s.Labels, other.Labels) ||
 		s.EnableTagOverride != other.EnableTagOverride ||
 		s.Kind != other.Kind ||
 		!reflect.DeepEqual(s.Proxy, other.Proxy) ||
@@ -855,8 +1115,10 @@ func (s *ServiceNode) IsSameService(other *ServiceNode) bool {
 		s.ServiceName != other.ServiceName ||
 		!reflect.DeepEqual(s.ServiceTags, other.ServiceTags) ||
 		s.ServiceAddress != other.ServiceAddress ||
+		!reflect.DeepEqual(s.ServiceTaggedAddresses, other.ServiceTaggedAddresses) ||
 		s.ServicePort != other.ServicePort ||
 		!reflect.DeepEqual(s.ServiceMeta, other.ServiceMeta) ||
+		!reflect.DeepEqual(s.ServiceLabels, other.ServiceLabels) ||
 		!reflect.DeepEqual(s.ServiceWeights, other.ServiceWeights) ||
 		s.ServiceEnableTagOverride != other.ServiceEnableTagOverride ||
 		s.ServiceProxyDestination != other.ServiceProxyDestination ||
@@ -894,8 +1156,10 @@ func (s *NodeService) ToServiceNode(node string) *ServiceNode {
 		ServiceName:              s.Service,
 		ServiceTags:              s.Tags,
 		ServiceAddress:           s.Address,
+		ServiceTaggedAddresses:   s.TaggedAddresses,
 		ServicePort:              s.Port,
 		ServiceMeta:              s.Meta,
+		ServiceLabels:            s.Labels,
 		ServiceWeights:           theWeights,
 		ServiceEnableTagOverride: s.EnableTagOverride,
 		ServiceProxy:             s.Proxy,
@@ -936,24 +1200,51 @@ type HealthCheckDefinition struct {
 	Header                         map[string][]string `json:",omitempty"`
 	Method                         string              `json:",omitempty"`
 	TCP                            string              `json:",omitempty"`
+	GRPC                           string              `json:",omitempty"`
+	GRPCUseTLS                     bool                `json:",omitempty"`
+	ScriptArgs                     []string            `json:",omitempty"`
+	TTL                            time.Duration       `json:",omitempty"`
 	Interval                       time.Duration       `json:",omitempty"`
 	Timeout                        time.Duration       `json:",omitempty"`
 	DeregisterCriticalServiceAfter time.Duration       `json:",omitempty"`
 }
 
+// Validate checks the definition describes a single kind of check with
+// valid durations.
+func (d *HealthCheckDefinition) Validate() error {
+	kinds := 0
+	for _, set := range []bool{d.HTTP != "", d.TCP != "", d.GRPC != "", len(d.ScriptArgs) > 0, d.TTL != 0} {
+		if set {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return fmt.Errorf("Check definition must have only one of HTTP, TCP, GRPC, ScriptArgs or TTL")
+	}
+	if d.TTL < 0 || d.Interval < 0 || d.Timeout < 0 || d.DeregisterCriticalServiceAfter < 0 {
+		return fmt.Errorf("Check definition durations must not be negative")
+	}
+	return nil
+}
+
 func (d *HealthCheckDefinition) MarshalJSON() ([]byte, error) {
 	type Alias HealthCheckDefinition
 	exported := &struct {
+		TTL                            string `json:",omitempty"`
 		Interval                       string `json:",omitempty"`
 		Timeout                        string `json:",omitempty"`
 		DeregisterCriticalServiceAfter string `json:",omitempty"`
 		*Alias
 	}{
+		TTL:                            d.TTL.String(),
 		Interval:                       d.Interval.String(),
 		Timeout:                        d.Timeout.String(),
 		DeregisterCriticalServiceAfter: d.DeregisterCriticalServiceAfter.String(),
 		Alias:                          (*Alias)(d),
 	}
+	if d.TTL == 0 {
+		exported.TTL = ""
+	}
 	if d.Interval == 0 {
 		exported.Interval = ""
 	}
@@ -970,6 +1261,7 @@ func (d *HealthCheckDefinition) MarshalJSON() ([]byte, error) {
 func (d *HealthCheckDefinition) UnmarshalJSON(data []byte) error {
 	type Alias HealthCheckDefinition
 	aux := &struct {
+		TTL                            string
 		Interval                       string
 		Timeout                        string
 		DeregisterCriticalServiceAfter string
@@ -981,6 +1273,11 @@ func (d *HealthCheckDefinition) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	var err error
+	if aux.TTL != "" {
+		if d.TTL, err = time.ParseDuration(aux.TTL); err != nil {
+			return err
+		}
+	}
 	if aux.Interval != "" {
 		if d.Interval, err = time.ParseDuration(aux.Interval); err != nil {
 			return err
@@ -1118,6 +1415,29 @@ type IndexedServiceNodes struct {
 	QueryMeta
 }
 
+// GatewayService is a service fronted by a gateway.
+type GatewayService struct {
+	// Gateway is the name of the gateway.
+	Gateway string
+
+	// GatewayKind is the kind of the gateway.
+	GatewayKind ServiceKind
+
+	// Service is the name of the fronted service.
+	Service string
+
+	// Port is the port of the listener routing to the service, for an
+	// ingress gateway.
+	Port int `json:",omitempty"`
+}
+
+type GatewayServices []*GatewayService
+
+type IndexedGatewayServices struct {
+	Services GatewayServices
+	QueryMeta
+}
+
 type IndexedNodeServices struct {
 	// TODO: This should not be a pointer, see comments in
 	// agent/catalog_endpoint.go.
@@ -1149,6 +1469,14 @@ type DirEntry struct {
 	Value     []byte
 	Session   string `json:",omitempty"`
 
+	// TTL is the optional duration after which the key is deleted, unless
+	// it is written again before.
+	TTL string `json:",omitempty"`
+
+	// Labels are arbitrary key/value pairs organizing the entries, which can
+	// be selected with the label selector of a recursive read.
+	Labels map[string]string `json:",omitempty"`
+
 	RaftIndex
 }
 
@@ -1160,6 +1488,8 @@ func (d *DirEntry) Clone() *DirEntry {
 		Flags:     d.Flags,
 		Value:     d.Value,
 		Session:   d.Session,
+		TTL:       d.TTL,
+		Labels:    d.Labels,
 		RaftIndex: RaftIndex{
 			CreateIndex: d.CreateIndex,
 			ModifyIndex: d.ModifyIndex,
@@ -1169,6 +1499,11 @@ func (d *DirEntry) Clone() *DirEntry {
 
 type DirEntries []*DirEntry
 
+const (
+	// KVSTTLMin is the shortest TTL of a key.
+	KVSTTLMin = time.Second
+)
+
 // KVSRequest is used to operate on the Key-Value store
 type KVSRequest struct {
 	Datacenter string
@@ -1209,6 +1544,28 @@ type IndexedDirEntries struct {
 	QueryMeta
 }
 
+// DirEntriesDiff holds the changes to the keys under a prefix since a given
+// index. They are applied by first removing all the keys under the
+// DeletedPrefixes, then storing the Entries. Entries includes the keys
+// created or modified since the index as well as the remaining keys under
+// the DeletedPrefixes, since a tombstone doesn't tell whether a single key
+// or a whole tree was deleted.
+type DirEntriesDiff struct {
+	// Reset is set when the changes can't be computed, because no index was
+	// given or the tombstones tracking the deletions since the index have
+	// been reaped. Entries then holds all the keys under the prefix and the
+	// previous ones must be discarded.
+	Reset bool
+
+	DeletedPrefixes []string
+	Entries         DirEntries
+}
+
+type IndexedDirEntriesDiff struct {
+	DirEntriesDiff
+	QueryMeta
+}
+
 type IndexedKeyList struct {
 	Keys []string
 	QueryMeta
@@ -1409,6 +1766,11 @@ type KeyringRequest struct {
 	Datacenter  string
 	Forwarded   bool
 	RelayFactor uint8
+
+	// LocalOnly restricts a list operation to the LAN pool of the local
+	// datacenter, without querying the WAN pool or forwarding the request
+	// to the other datacenters.
+	LocalOnly bool
 	QueryOptions
 }
 
@@ -144,7 +144,10 @@ func testServiceNode(t *testing.T) *ServiceNode {
 		ServiceName:    "dogs",
 		ServiceTags:    []string{"prod", "v1"},
 		ServiceAddress: "127.0.0.2",
-		ServicePort:    8080,
+		ServiceTaggedAddresses: map[string]ServiceAddress{
+			"wan": {Address: "198.18.0.2", Port: 80},
+		},
+		ServicePort: 8080,
 		ServiceMeta: map[string]string{
 			"service": "metadata",
 		},
@@ -228,6 +231,7 @@ func TestStructs_ServiceNode_IsSameService(t *testing.T) {
 	node := "node1"
 	serviceID := sn.ServiceID
 	serviceAddress := sn.ServiceAddress
+	serviceTaggedAddresses := sn.ServiceTaggedAddresses
 	serviceEnableTagOverride := sn.ServiceEnableTagOverride
 	serviceMeta := make(map[string]string)
 	for k, v := range sn.ServiceMeta {
@@ -265,6 +269,7 @@ func TestStructs_ServiceNode_IsSameService(t *testing.T) {
 	check(func() { other.ServiceID = "66fb695a-c782-472f-8d36-4f3edd754b37" }, func() { other.ServiceID = serviceID })
 	check(func() { other.Node = "other" }, func() { other.Node = node })
 	check(func() { other.ServiceAddress = "1.2.3.4" }, func() { other.ServiceAddress = serviceAddress })
+	check(func() { other.ServiceTaggedAddresses = map[string]ServiceAddress{"wan": {Address: "1.2.3.4"}} }, func() { other.ServiceTaggedAddresses = serviceTaggedAddresses })
 	check(func() { other.ServiceEnableTagOverride = !serviceEnableTagOverride }, func() { other.ServiceEnableTagOverride = serviceEnableTagOverride })
 	check(func() { other.ServiceKind = "newKind" }, func() { other.ServiceKind = "" })
 	check(func() { other.ServiceMeta = map[string]string{"my": "meta"} }, func() { other.ServiceMeta = serviceMeta })
@@ -410,6 +415,152 @@ func TestStructs_NodeService_ValidateConnectProxy(t *testing.T) {
 	}
 }
 
+func TestStructs_NodeService_ValidateGateway(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Modify func(*NodeService)
+		Err    string
+	}{
+		{
+			"valid",
+			func(x *NodeService) {},
+			"",
+		},
+
+		{
+			"terminating gateway",
+			func(x *NodeService) { x.Kind = ServiceKindTerminatingGateway },
+			"",
+		},
+
+		{
+			"no upstreams",
+			func(x *NodeService) { x.Proxy.Upstreams = nil },
+			"",
+		},
+
+		{
+			"ProxyDestination set",
+			func(x *NodeService) { x.Proxy.DestinationServiceName = "web" },
+			"Proxy.DestinationServiceName cannot be set",
+		},
+
+		{
+			"prepared query upstream",
+			func(x *NodeService) { x.Proxy.Upstreams[0].DestinationType = UpstreamDestTypePreparedQuery },
+			"must be a service",
+		},
+
+		{
+			"upstream without name",
+			func(x *NodeService) { x.Proxy.Upstreams[1].DestinationName = " " },
+			"must have a DestinationName",
+		},
+
+		{
+			"ConnectNative set",
+			func(x *NodeService) { x.Connect.Native = true },
+			"cannot also be",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			ns := TestNodeServiceGateway(t)
+			tc.Modify(ns)
+
+			err := ns.Validate()
+			assert.Equal(err != nil, tc.Err != "", err)
+			if err == nil {
+				return
+			}
+
+			assert.Contains(strings.ToLower(err.Error()), strings.ToLower(tc.Err))
+		})
+	}
+}
+
+func TestStructs_NodeService_ValidateMeshGateway(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Modify func(*NodeService)
+		Err    string
+	}{
+		{
+			"valid",
+			func(x *NodeService) {},
+			"",
+		},
+
+		{
+			"no port set",
+			func(x *NodeService) { x.Port = 0 },
+			"Port must",
+		},
+
+		{
+			"ProxyDestination set",
+			func(x *NodeService) { x.Proxy.DestinationServiceName = "web" },
+			"Proxy.DestinationServiceName cannot be set",
+		},
+
+		{
+			"upstreams set",
+			func(x *NodeService) {
+				x.Proxy.Upstreams = Upstreams{{DestinationName: "web", LocalBindPort: 8080}}
+			},
+			"cannot have upstreams",
+		},
+
+		{
+			"ConnectNative set",
+			func(x *NodeService) { x.Connect.Native = true },
+			"cannot also be",
+		},
+
+		{
+			"SidecarService set",
+			func(x *NodeService) { x.Connect.SidecarService = &ServiceDefinition{} },
+			"cannot have a SidecarService",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.Name, func(t *testing.T) {
+			assert := assert.New(t)
+			ns := TestNodeServiceMeshGateway(t)
+			tc.Modify(ns)
+
+			err := ns.Validate()
+			assert.Equal(err != nil, tc.Err != "", err)
+			if err == nil {
+				return
+			}
+
+			assert.Contains(strings.ToLower(err.Error()), strings.ToLower(tc.Err))
+		})
+	}
+}
+
+func TestStructs_NodeService_ValidateMeshGatewayMode(t *testing.T) {
+	ns := TestNodeServiceProxy(t)
+	ns.Proxy.MeshGateway.Mode = MeshGatewayModeLocal
+	ns.Proxy.Upstreams[0].MeshGateway.Mode = MeshGatewayModeRemote
+	require.NoError(t, ns.Validate())
+
+	ns.Proxy.MeshGateway.Mode = "nearby"
+	err := ns.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Invalid MeshGateway.Mode "nearby"`)
+
+	ns.Proxy.MeshGateway.Mode = MeshGatewayModeNone
+	ns.Proxy.Upstreams[0].MeshGateway.Mode = "nearby"
+	err = ns.Validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `Upstream "db"`)
+}
+
 func TestStructs_NodeService_ValidateSidecarService(t *testing.T) {
 	cases := []struct {
 		Name   string
@@ -540,6 +691,7 @@ func TestStructs_NodeService_IsSame(t *testing.T) {
 	check(func() { other.Tags = nil }, func() { other.Tags = []string{"foo", "bar"} })
 	check(func() { other.Tags = []string{"foo"} }, func() { other.Tags = []string{"foo", "bar"} })
 	check(func() { other.Address = "XXX" }, func() { other.Address = "127.0.0.1" })
+	check(func() { other.TaggedAddresses = map[string]ServiceAddress{"wan": {Address: "1.2.3.4", Port: 80}} }, func() { other.TaggedAddresses = nil })
 	check(func() { other.Port = 9999 }, func() { other.Port = 1234 })
 	check(func() { other.Meta["meta2"] = "wrongValue" }, func() { other.Meta["meta2"] = "value2" })
 	check(func() { other.EnableTagOverride = false }, func() { other.EnableTagOverride = true })
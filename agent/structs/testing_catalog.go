@@ -29,6 +29,17 @@ func TestRegisterRequestProxy(t testing.T) *RegisterRequest {
 	}
 }
 
+// TestRegisterRequestGateway returns a RegisterRequest for registering an
+// ingress gateway.
+func TestRegisterRequestGateway(t testing.T) *RegisterRequest {
+	return &RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service:    TestNodeServiceGateway(t),
+	}
+}
+
 // TestNodeService returns a *NodeService representing a valid regular service.
 func TestNodeService(t testing.T) *NodeService {
 	return &NodeService{
@@ -49,6 +60,57 @@ func TestNodeServiceProxy(t testing.T) *NodeService {
 	}
 }
 
+// TestNodeServiceGateway returns a *NodeService representing a valid
+// ingress gateway fronting the web and db services.
+func TestNodeServiceGateway(t testing.T) *NodeService {
+	return &NodeService{
+		Kind:    ServiceKindIngressGateway,
+		Service: "ingress",
+		Address: "127.0.0.3",
+		Port:    8443,
+		Proxy: ConnectProxyConfig{
+			Upstreams: Upstreams{
+				{
+					DestinationType: UpstreamDestTypeService,
+					DestinationName: "web",
+					LocalBindPort:   8080,
+				},
+				{
+					DestinationType: UpstreamDestTypeService,
+					DestinationName: "db",
+					LocalBindPort:   9191,
+				},
+			},
+		},
+	}
+}
+
+// TestNodeServiceMeshGateway returns a *NodeService representing a valid
+// mesh gateway, reachable from the other datacenters on its WAN address.
+func TestNodeServiceMeshGateway(t testing.T) *NodeService {
+	return &NodeService{
+		Kind:    ServiceKindMeshGateway,
+		Service: "mesh-gateway",
+		Address: "10.1.2.3",
+		Port:    8443,
+		TaggedAddresses: map[string]ServiceAddress{
+			"lan": ServiceAddress{Address: "10.1.2.3", Port: 8443},
+			"wan": ServiceAddress{Address: "198.18.4.5", Port: 443},
+		},
+	}
+}
+
+// TestNodeServiceTerminatingGateway returns a *NodeService representing a
+// valid terminating gateway.
+func TestNodeServiceTerminatingGateway(t testing.T) *NodeService {
+	return &NodeService{
+		Kind:    ServiceKindTerminatingGateway,
+		Service: "terminating",
+		Address: "127.0.0.4",
+		Port:    8443,
+	}
+}
+
 // TestNodeServiceSidecar returns a *NodeService representing a service
 // registration with a nested Sidecar registration.
 func TestNodeServiceSidecar(t testing.T) *NodeService {
@@ -0,0 +1,576 @@
+// Package subscribe implements the agent gRPC Subscribe service which
+// streams incremental health and catalog events to clients so they don't
+// need to run their own blocking queries. The same streams are served over
+// HTTP by the agent using Server.Stream.
+//
+// The service doesn't use protobuf. Messages are encoded as JSON using a
+// codec registered for its own content-subtype, so that the api package can
+// consume the stream without generated code and without replacing a "json"
+// codec other packages of the process may register.
+package subscribe
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ServiceName is the fully qualified name of the gRPC service.
+	ServiceName = "consul.subscribe.Subscribe"
+
+	// CodecName is the content-subtype clients must use when calling the
+	// service.
+	CodecName = "consul-json"
+
+	// TopicHealth streams the health of the instances of the service
+	// named by the request key.
+	TopicHealth = "health"
+
+	// TopicCatalog streams the services registered in the catalog along
+	// with their tags. The request key is ignored.
+	TopicCatalog = "catalog"
+
+	// OpUpsert and OpDelete are the operations an event can describe.
+	OpUpsert = "upsert"
+	OpDelete = "delete"
+
+	// maxQueryTime is the blocking query time used for each round trip to
+	// the servers while a subscription is active. The stream ends as soon
+	// as the subscriber goes away, but the query in flight keeps running
+	// on the servers until it returns, so this is kept short.
+	maxQueryTime = time.Minute
+
+	// retryWait is how long to wait before retrying a failed query.
+	retryWait = 3 * time.Second
+)
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// Request is sent by the client to start a subscription.
+type Request struct {
+	Topic      string
+	Key        string
+	Datacenter string
+	Token      string
+
+	// Index, if set, is the index of the last event the client has seen.
+	// The initial snapshot is still sent but no events are sent until the
+	// servers are past this index.
+	Index uint64
+}
+
+// Event is a single change to the subscribed topic.
+type Event struct {
+	Topic string
+	Key   string
+	Index uint64
+
+	// EndOfSnapshot is set on the event following the initial set of
+	// upserts that describe the state at the time of the subscription.
+	EndOfSnapshot bool `json:",omitempty"`
+
+	// Op is either OpUpsert or OpDelete.
+	Op string `json:",omitempty"`
+
+	// ServiceHealth is set for events of TopicHealth. For deletes only the
+	// node and service ID are populated.
+	ServiceHealth *structs.CheckServiceNode `json:",omitempty"`
+
+	// Status is the aggregated status of the checks of the instance for
+	// events of TopicHealth, empty for deletes. PreviousStatus is its
+	// status in the previous event of the instance, empty for the events
+	// of the initial snapshot and for new instances.
+	Status         string `json:",omitempty"`
+	PreviousStatus string `json:",omitempty"`
+
+	// CatalogService is set for events of TopicCatalog.
+	CatalogService *CatalogService `json:",omitempty"`
+}
+
+// CatalogService describes a service name and the union of its tags.
+type CatalogService struct {
+	Name string
+	Tags []string
+}
+
+// RPC is the interface used to run queries against the servers.
+type RPC interface {
+	RPC(method string, args interface{}, reply interface{}) error
+}
+
+// Server implements the Subscribe gRPC service.
+type Server struct {
+	Logger *log.Logger
+	RPC    RPC
+
+	// Datacenter is the agent's datacenter, used when the request doesn't
+	// specify one.
+	Datacenter string
+
+	// UserToken returns the agent's default token, used when the request
+	// doesn't carry one.
+	UserToken func() string
+
+	// Streamer, if set, serves the health topic of the local datacenter
+	// from the event streams of the servers instead of blocking queries.
+	Streamer Streamer
+}
+
+// Streamer subscribes to the event streams of the servers.
+type Streamer interface {
+	// Subscribe runs the given subscription, passing the events to fn,
+	// until ctx is done or an error occurs. The stream starts with a
+	// snapshot of the topic, followed by an event with EndOfSnapshot set.
+	Subscribe(ctx context.Context, req *Request, fn func(*Event) error) error
+}
+
+// Register registers the service with the given gRPC server.
+func (s *Server) Register(srv *grpc.Server) {
+	RegisterHandler(srv, s)
+}
+
+// Handler implements the Subscribe gRPC service. It is implemented by the
+// agents, and by the servers for the streams of their event publisher.
+type Handler interface {
+	Subscribe(*Request, grpc.ServerStream) error
+}
+
+// RegisterHandler registers the given implementation of the service with
+// the gRPC server.
+func RegisterHandler(srv *grpc.Server, h Handler) {
+	srv.RegisterService(&serviceDesc, h)
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*Handler)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       subscribeHandler,
+			ServerStreams: true,
+		},
+	},
+}
+
+func subscribeHandler(srv interface{}, stream grpc.ServerStream) error {
+	req := new(Request)
+	if err := stream.RecvMsg(req); err != nil {
+		return err
+	}
+	return srv.(Handler).Subscribe(req, stream)
+}
+
+// Consume runs the given subscription against the Subscribe service of the
+// given connection, passing the events to fn until the stream ends or ctx is
+// done.
+func Consume(ctx context.Context, conn *grpc.ClientConn, req *Request, fn func(*Event) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	desc := &grpc.StreamDesc{StreamName: "Subscribe", ServerStreams: true}
+	stream, err := conn.NewStream(ctx, desc, "/"+ServiceName+"/Subscribe",
+		grpc.CallContentSubtype(CodecName))
+	if err != nil {
+		return err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return err
+	}
+
+	for {
+		e := new(Event)
+		if err := stream.RecvMsg(e); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+}
+
+// Validate returns an error if the request isn't valid.
+func (r *Request) Validate() error {
+	switch r.Topic {
+	case TopicHealth:
+		if r.Key == "" {
+			return fmt.Errorf("a service name must be given as the key for the health topic")
+		}
+	case TopicCatalog:
+	default:
+		return fmt.Errorf("unknown topic %q", r.Topic)
+	}
+	return nil
+}
+
+// Subscribe runs a subscription until the client goes away.
+func (s *Server) Subscribe(req *Request, stream grpc.ServerStream) error {
+	if err := req.Validate(); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	err := s.Stream(stream.Context(), req, func(e *Event) error {
+		return stream.SendMsg(e)
+	})
+	if acl.IsErrPermissionDenied(err) || acl.IsErrNotFound(err) {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return err
+}
+
+// Stream runs a validated subscription until ctx is done, passing the
+// events to send. Each topic is backed by a blocking query against the
+// servers and events are computed by diffing consecutive results. ACL
+// errors end the stream, other errors are retried.
+func (s *Server) Stream(ctx context.Context, req *Request, send func(*Event) error) error {
+	var snap snapshotter = s.catalogSnapshot
+	if req.Topic == TopicHealth {
+		snap = s.healthSnapshot
+	}
+
+	q := structs.QueryOptions{
+		Token:        req.Token,
+		AllowStale:   true,
+		MaxQueryTime: maxQueryTime,
+	}
+	if q.Token == "" && s.UserToken != nil {
+		q.Token = s.UserToken()
+	}
+	dc := req.Datacenter
+	if dc == "" {
+		dc = s.Datacenter
+	}
+	if req.Topic == TopicHealth && s.Streamer != nil && dc == s.Datacenter {
+		return s.streamFromServers(ctx, req, q.Token, send)
+	}
+
+	var prev map[string]*Event
+	for {
+		index, cur, err := snap.query(ctx, dc, req.Key, q)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			if acl.IsErrPermissionDenied(err) || acl.IsErrNotFound(err) {
+				return err
+			}
+			s.Logger.Printf("[WARN] agent: subscription to %q failed: %v", req.Topic, err)
+			select {
+			case <-time.After(retryWait):
+				continue
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		// Reset the index if it goes backwards so we don't block on an
+		// index the servers will never reach, but always block on the
+		// following queries to avoid a hot loop.
+		switch {
+		case index < q.MinQueryIndex:
+			q.MinQueryIndex = 0
+		case index < 1:
+			q.MinQueryIndex = 1
+		default:
+			q.MinQueryIndex = index
+		}
+
+		// The changes up to the requested index are skipped, prev only
+		// moves forward once they are sent so that the following changes
+		// are diffed against what the subscriber last got.
+		var events []*Event
+		if prev == nil {
+			events = snapshotEvents(cur)
+			events = append(events, &Event{EndOfSnapshot: true})
+			prev = cur
+		} else if index > req.Index {
+			events = diffEvents(prev, cur)
+			prev = cur
+		}
+
+		for _, e := range events {
+			out := *e
+			out.Topic, out.Key, out.Index = req.Topic, req.Key, index
+			if err := send(&out); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+	}
+}
+
+// streamFromServers runs a health subscription against the servers. The
+// instances are materialized by the agent so that resubscribing after an
+// error, which starts from a new snapshot, only sends the changes to the
+// client. ACL errors end the stream, other errors are retried.
+func (s *Server) streamFromServers(ctx context.Context, req *Request, token string, send func(*Event) error) error {
+	var view map[string]*Event
+	for {
+		var sendErr error
+		emit := func(events []*Event, index uint64) error {
+			for _, e := range events {
+				out := *e
+				out.Topic, out.Key, out.Index = req.Topic, req.Key, index
+				if err := send(&out); err != nil {
+					sendErr = err
+					return err
+				}
+			}
+			return nil
+		}
+
+		// The instances of the snapshot of the current subscription, nil
+		// once the snapshot is complete.
+		snapshot := make(map[string]*Event)
+		r := &Request{Topic: TopicHealth, Key: req.Key, Token: token}
+		err := s.Streamer.Subscribe(ctx, r, func(e *Event) error {
+			if snapshot != nil {
+				if !e.EndOfSnapshot {
+					snapshot[instanceKey(e.ServiceHealth)] = upsertEvent(e.ServiceHealth)
+					return nil
+				}
+
+				var events []*Event
+				if view == nil {
+					events = snapshotEvents(snapshot)
+					events = append(events, &Event{EndOfSnapshot: true})
+				} else if e.Index > req.Index {
+					events = diffEvents(view, snapshot)
+				}
+				view, snapshot = snapshot, nil
+				return emit(events, e.Index)
+			}
+
+			k := instanceKey(e.ServiceHealth)
+			old, ok := view[k]
+			var out *Event
+			if e.Op == OpDelete {
+				if !ok {
+					return nil
+				}
+				delete(view, k)
+				out = deleteEvent(old)
+			} else {
+				cur := upsertEvent(e.ServiceHealth)
+				view[k] = cur
+				out = cur
+				if ok {
+					out = &Event{}
+					*out = *cur
+					out.PreviousStatus = old.Status
+				}
+			}
+			if e.Index <= req.Index {
+				return nil
+			}
+			return emit([]*Event{out}, e.Index)
+		})
+		if sendErr != nil {
+			return sendErr
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if acl.IsErrPermissionDenied(err) || acl.IsErrNotFound(err) {
+			return err
+		}
+		if err != nil {
+			s.Logger.Printf("[WARN] agent: streaming subscription to %q failed: %v", req.Key, err)
+		}
+		select {
+		case <-time.After(retryWait):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// upsertEvent returns the upsert event of the given instance.
+func upsertEvent(n *structs.CheckServiceNode) *Event {
+	return &Event{
+		Op:            OpUpsert,
+		ServiceHealth: n,
+		Status:        aggregatedStatus(n.Checks),
+	}
+}
+
+// instanceKey returns the key of an instance, unique within a service.
+func instanceKey(n *structs.CheckServiceNode) string {
+	return n.Node.Node + "/" + n.Service.ID
+}
+
+// snapshotter runs a blocking query and returns the current state of the
+// topic as a set of upsert events keyed by a unique ID.
+type snapshotter func(dc, key string, q structs.QueryOptions) (uint64, map[string]*Event, error)
+
+// query runs the blocking query, returning early with the error of ctx once
+// it's done. The RPCs can't be cancelled, so the query is left to finish in
+// the background.
+func (snap snapshotter) query(ctx context.Context, dc, key string, q structs.QueryOptions) (uint64, map[string]*Event, error) {
+	type result struct {
+		index uint64
+		cur   map[string]*Event
+		err   error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		index, cur, err := snap(dc, key, q)
+		ch <- result{index, cur, err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.index, r.cur, r.err
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	}
+}
+
+func (s *Server) healthSnapshot(dc, key string, q structs.QueryOptions) (uint64, map[string]*Event, error) {
+	args := structs.ServiceSpecificRequest{
+		Datacenter:   dc,
+		ServiceName:  key,
+		QueryOptions: q,
+	}
+	var out structs.IndexedCheckServiceNodes
+	if err := s.RPC.RPC("Health.ServiceNodes", &args, &out); err != nil {
+		return 0, nil, err
+	}
+
+	m := make(map[string]*Event, len(out.Nodes))
+	for _, n := range out.Nodes {
+		n := n
+		m[instanceKey(&n)] = upsertEvent(&n)
+	}
+	return out.Index, m, nil
+}
+
+func (s *Server) catalogSnapshot(dc, key string, q structs.QueryOptions) (uint64, map[string]*Event, error) {
+	args := structs.DCSpecificRequest{
+		Datacenter:   dc,
+		QueryOptions: q,
+	}
+	var out structs.IndexedServices
+	if err := s.RPC.RPC("Catalog.ListServices", &args, &out); err != nil {
+		return 0, nil, err
+	}
+
+	m := make(map[string]*Event, len(out.Services))
+	for name, tags := range out.Services {
+		m[name] = &Event{Op: OpUpsert, CatalogService: &CatalogService{Name: name, Tags: tags}}
+	}
+	return out.Index, m, nil
+}
+
+// aggregatedStatus returns the status representing the given checks, as
+// reported by the agent health endpoints.
+func aggregatedStatus(checks structs.HealthChecks) string {
+	apiChecks := make(api.HealthChecks, 0, len(checks))
+	for _, c := range checks {
+		apiChecks = append(apiChecks, &api.HealthCheck{
+			CheckID: string(c.CheckID),
+			Status:  c.Status,
+		})
+	}
+	return apiChecks.AggregatedStatus()
+}
+
+// snapshotEvents returns the events of a snapshot in a stable order.
+func snapshotEvents(cur map[string]*Event) []*Event {
+	events := make([]*Event, 0, len(cur))
+	for _, k := range sortedKeys(cur) {
+		events = append(events, cur[k])
+	}
+	return events
+}
+
+// diffEvents returns the upserts for entries that are new or changed in cur
+// and deletes for entries that are no longer present.
+func diffEvents(prev, cur map[string]*Event) []*Event {
+	var events []*Event
+	for _, k := range sortedKeys(cur) {
+		old, ok := prev[k]
+		if !ok {
+			events = append(events, cur[k])
+		} else if !reflect.DeepEqual(old, cur[k]) {
+			e := *cur[k]
+			e.PreviousStatus = old.Status
+			events = append(events, &e)
+		}
+	}
+	for _, k := range sortedKeys(prev) {
+		if _, ok := cur[k]; !ok {
+			events = append(events, deleteEvent(prev[k]))
+		}
+	}
+	return events
+}
+
+func sortedKeys(m map[string]*Event) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// deleteEvent returns the delete event for the given upsert, only keeping
+// the identifying fields.
+func deleteEvent(e *Event) *Event {
+	d := &Event{Op: OpDelete, PreviousStatus: e.Status}
+	switch {
+	case e.ServiceHealth != nil:
+		d.ServiceHealth = &structs.CheckServiceNode{
+			Node:    &structs.Node{Node: e.ServiceHealth.Node.Node},
+			Service: &structs.NodeService{ID: e.ServiceHealth.Service.ID, Service: e.ServiceHealth.Service.Service},
+		}
+	case e.CatalogService != nil:
+		d.CatalogService = &CatalogService{Name: e.CatalogService.Name}
+	}
+	return d
+}
+
+// jsonCodec encodes the messages of the service as JSON.
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode message: %v", err)
+	}
+	return nil
+}
+
+func (jsonCodec) Name() string {
+	return CodecName
+}
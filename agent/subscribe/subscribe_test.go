@@ -0,0 +1,296 @@
+package subscribe
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffEvents(t *testing.T) {
+	t.Parallel()
+
+	health := func(node, id, status string) *Event {
+		return &Event{
+			Op: OpUpsert,
+			ServiceHealth: &structs.CheckServiceNode{
+				Node:    &structs.Node{Node: node},
+				Service: &structs.NodeService{ID: id, Service: "web"},
+				Checks: structs.HealthChecks{
+					&structs.HealthCheck{Node: node, CheckID: "check", Status: status},
+				},
+			},
+			Status: status,
+		}
+	}
+
+	prev := map[string]*Event{
+		"n1/web1": health("n1", "web1", api.HealthPassing),
+		"n2/web2": health("n2", "web2", api.HealthPassing),
+		"n3/web3": health("n3", "web3", api.HealthPassing),
+	}
+	cur := map[string]*Event{
+		"n1/web1": health("n1", "web1", api.HealthPassing),
+		"n2/web2": health("n2", "web2", api.HealthCritical),
+		"n4/web4": health("n4", "web4", api.HealthPassing),
+	}
+
+	events := diffEvents(prev, cur)
+	require.Len(t, events, 3)
+
+	require.Equal(t, OpUpsert, events[0].Op)
+	require.Equal(t, "web2", events[0].ServiceHealth.Service.ID)
+	require.Equal(t, api.HealthCritical, events[0].ServiceHealth.Checks[0].Status)
+	require.Equal(t, api.HealthCritical, events[0].Status)
+	require.Equal(t, api.HealthPassing, events[0].PreviousStatus)
+
+	require.Equal(t, OpUpsert, events[1].Op)
+	require.Equal(t, "web4", events[1].ServiceHealth.Service.ID)
+	require.Empty(t, events[1].PreviousStatus)
+
+	require.Equal(t, OpDelete, events[2].Op)
+	require.Equal(t, "n3", events[2].ServiceHealth.Node.Node)
+	require.Equal(t, "web3", events[2].ServiceHealth.Service.ID)
+	require.Empty(t, events[2].ServiceHealth.Checks)
+	require.Empty(t, events[2].Status)
+	require.Equal(t, api.HealthPassing, events[2].PreviousStatus)
+
+	// The entries of the previous state are left untouched.
+	require.Empty(t, cur["n2/web2"].PreviousStatus)
+}
+
+func TestSnapshotEvents_sorted(t *testing.T) {
+	t.Parallel()
+
+	cur := map[string]*Event{
+		"redis":  &Event{Op: OpUpsert, CatalogService: &CatalogService{Name: "redis"}},
+		"consul": &Event{Op: OpUpsert, CatalogService: &CatalogService{Name: "consul"}},
+		"web":    &Event{Op: OpUpsert, CatalogService: &CatalogService{Name: "web"}},
+	}
+
+	var names []string
+	for _, e := range snapshotEvents(cur) {
+		names = append(names, e.CatalogService.Name)
+	}
+	require.Equal(t, []string{"consul", "redis", "web"}, names)
+}
+
+func TestAggregatedStatus(t *testing.T) {
+	t.Parallel()
+
+	checks := structs.HealthChecks{
+		&structs.HealthCheck{CheckID: "a", Status: api.HealthPassing},
+		&structs.HealthCheck{CheckID: "b", Status: api.HealthWarning},
+	}
+	require.Equal(t, api.HealthWarning, aggregatedStatus(checks))
+
+	checks = append(checks, &structs.HealthCheck{CheckID: api.ServiceMaintPrefix + "web", Status: api.HealthCritical})
+	require.Equal(t, api.HealthMaint, aggregatedStatus(checks))
+
+	require.Equal(t, api.HealthPassing, aggregatedStatus(nil))
+}
+
+// testStreamer replays a list of subscriptions, each ending with the given
+// error.
+type testStreamer struct {
+	streams [][]*Event
+	errs    []error
+}
+
+func (s *testStreamer) Subscribe(ctx context.Context, req *Request, fn func(*Event) error) error {
+	if len(s.streams) == 0 {
+		<-ctx.Done()
+		return nil
+	}
+	events, err := s.streams[0], s.errs[0]
+	s.streams, s.errs = s.streams[1:], s.errs[1:]
+	for _, e := range events {
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return err
+}
+
+func TestServer_Stream_streamer(t *testing.T) {
+	t.Parallel()
+
+	health := func(index uint64, node, status string) *Event {
+		return &Event{
+			Index: index,
+			Op:    OpUpsert,
+			ServiceHealth: &structs.CheckServiceNode{
+				Node:    &structs.Node{Node: node},
+				Service: &structs.NodeService{ID: "web", Service: "web"},
+				Checks: structs.HealthChecks{
+					&structs.HealthCheck{Node: node, CheckID: "check", Status: status},
+				},
+			},
+		}
+	}
+
+	streamer := &testStreamer{
+		streams: [][]*Event{
+			{
+				health(5, "n1", api.HealthPassing),
+				health(5, "n2", api.HealthPassing),
+				{Index: 5, EndOfSnapshot: true},
+				health(6, "n1", api.HealthCritical),
+			},
+			// The subscription is reset and starts from a new snapshot.
+			{
+				health(8, "n1", api.HealthCritical),
+				health(8, "n3", api.HealthPassing),
+				{Index: 8, EndOfSnapshot: true},
+			},
+		},
+		errs: []error{errors.New("reset"), nil},
+	}
+	srv := &Server{
+		Logger:     log.New(os.Stderr, "", log.LstdFlags),
+		Datacenter: "dc1",
+		Streamer:   streamer,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []*Event
+	req := &Request{Topic: TopicHealth, Key: "web"}
+	err := srv.Stream(ctx, req, func(e *Event) error {
+		events = append(events, e)
+		if len(events) == 6 {
+			cancel()
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 6)
+
+	// The initial snapshot.
+	require.Equal(t, "n1", events[0].ServiceHealth.Node.Node)
+	require.Equal(t, "n2", events[1].ServiceHealth.Node.Node)
+	require.True(t, events[2].EndOfSnapshot)
+	require.Equal(t, uint64(5), events[2].Index)
+
+	// The change streamed by the servers.
+	require.Equal(t, "n1", events[3].ServiceHealth.Node.Node)
+	require.Equal(t, api.HealthCritical, events[3].Status)
+	require.Equal(t, api.HealthPassing, events[3].PreviousStatus)
+	require.Equal(t, uint64(6), events[3].Index)
+
+	// Only the differences of the new snapshot are sent.
+	require.Equal(t, OpUpsert, events[4].Op)
+	require.Equal(t, "n3", events[4].ServiceHealth.Node.Node)
+	require.Equal(t, uint64(8), events[4].Index)
+	require.Equal(t, OpDelete, events[5].Op)
+	require.Equal(t, "n2", events[5].ServiceHealth.Node.Node)
+	require.Equal(t, api.HealthPassing, events[5].PreviousStatus)
+}
+
+// testCatalogRPC answers the catalog queries with a list of results.
+type testCatalogRPC struct {
+	results []structs.IndexedServices
+}
+
+func (r *testCatalogRPC) RPC(method string, args interface{}, reply interface{}) error {
+	if len(r.results) == 0 {
+		return errors.New("no more results")
+	}
+	*reply.(*structs.IndexedServices) = r.results[0]
+	r.results = r.results[1:]
+	return nil
+}
+
+func TestServer_Stream_skippedIndex(t *testing.T) {
+	t.Parallel()
+
+	result := func(index uint64, names ...string) structs.IndexedServices {
+		out := structs.IndexedServices{Services: make(structs.Services)}
+		out.Index = index
+		for _, name := range names {
+			out.Services[name] = nil
+		}
+		return out
+	}
+	srv := &Server{
+		Logger:     log.New(os.Stderr, "", log.LstdFlags),
+		Datacenter: "dc1",
+		RPC: &testCatalogRPC{
+			results: []structs.IndexedServices{
+				result(5, "web"),
+				// The change at index 7 is up to the requested index.
+				result(7, "web", "db"),
+				result(9, "web", "db", "redis"),
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var events []*Event
+	req := &Request{Topic: TopicCatalog, Index: 8}
+	err := srv.Stream(ctx, req, func(e *Event) error {
+		events = append(events, e)
+		if len(events) == 4 {
+			cancel()
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+
+	require.Equal(t, "web", events[0].CatalogService.Name)
+	require.True(t, events[1].EndOfSnapshot)
+
+	// The change skipped at index 7 is still sent with the next one.
+	require.Equal(t, "db", events[2].CatalogService.Name)
+	require.Equal(t, uint64(9), events[2].Index)
+	require.Equal(t, "redis", events[3].CatalogService.Name)
+	require.Equal(t, uint64(9), events[3].Index)
+}
+
+// testBlockingRPC blocks every query until it's closed.
+type testBlockingRPC struct {
+	doneCh chan struct{}
+}
+
+func (r *testBlockingRPC) RPC(method string, args interface{}, reply interface{}) error {
+	<-r.doneCh
+	return errors.New("closed")
+}
+
+func TestServer_Stream_cancelDuringQuery(t *testing.T) {
+	t.Parallel()
+
+	rpc := &testBlockingRPC{doneCh: make(chan struct{})}
+	defer close(rpc.doneCh)
+	srv := &Server{
+		Logger:     log.New(os.Stderr, "", log.LstdFlags),
+		Datacenter: "dc1",
+		RPC:        rpc,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Stream(ctx, &Request{Topic: TopicCatalog}, func(*Event) error {
+			return nil
+		})
+	}()
+
+	// The stream ends as soon as the subscriber goes away, without
+	// waiting for the blocking query to return.
+	cancel()
+	select {
+	case err := <-errCh:
+		require.NoError(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream didn't end")
+	}
+}
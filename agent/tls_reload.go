@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+// watchTLSFiles periodically reloads the certificate, key and CA files when
+// they change, so that the certificates can be rotated without reloading the
+// agent, until the agent shuts down.
+func (a *Agent) watchTLSFiles(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-a.shutdownCh:
+			return
+		}
+
+		reloaded, err := a.tlsConfigurator.ReloadChangedFiles()
+		switch {
+		case err != nil:
+			metrics.IncrCounter([]string{"agent", "tls", "reload_failed"}, 1)
+			a.logger.Printf("[WARN] agent: Failed to reload the changed TLS files, keeping the previous ones: %v", err)
+		case reloaded:
+			metrics.IncrCounter([]string{"agent", "tls", "reload"}, 1)
+			a.logger.Printf("[INFO] agent: Reloaded the changed TLS files")
+		}
+	}
+}
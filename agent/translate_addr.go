@@ -19,6 +19,22 @@ func (a *Agent) TranslateAddress(dc string, addr string, taggedAddresses map[str
 	return addr
 }
 
+// TranslateServiceAddress is used to provide the final, translated address
+// and port of a service, using its WAN tagged address if the agent translates
+// the addresses of other datacenters. The dc parameter is the datacenter this
+// service is from.
+func (a *Agent) TranslateServiceAddress(dc string, addr string, port int, taggedAddresses map[string]structs.ServiceAddress) (string, int) {
+	if a.config.TranslateWANAddrs && (a.config.Datacenter != dc) {
+		if wanAddr, ok := taggedAddresses["wan"]; ok && wanAddr.Address != "" {
+			addr = wanAddr.Address
+			if wanAddr.Port != 0 {
+				port = wanAddr.Port
+			}
+		}
+	}
+	return addr, port
+}
+
 // TranslateAddresses translates addresses in the given structure into the
 // final, translated address, depending on how the agent and the other node are
 // configured. The dc parameter is the datacenter this structure is from.
@@ -45,6 +61,10 @@ func (a *Agent) TranslateAddresses(dc string, subj interface{}) {
 	case structs.CheckServiceNodes:
 		for _, entry := range v {
 			entry.Node.Address = a.TranslateAddress(dc, entry.Node.Address, entry.Node.TaggedAddresses)
+			if entry.Service != nil {
+				entry.Service.Address, entry.Service.Port = a.TranslateServiceAddress(dc,
+					entry.Service.Address, entry.Service.Port, entry.Service.TaggedAddresses)
+			}
 		}
 	case *structs.Node:
 		v.Address = a.TranslateAddress(dc, v.Address, v.TaggedAddresses)
@@ -55,6 +75,8 @@ func (a *Agent) TranslateAddresses(dc string, subj interface{}) {
 	case structs.ServiceNodes:
 		for _, entry := range v {
 			entry.Address = a.TranslateAddress(dc, entry.Address, entry.TaggedAddresses)
+			entry.ServiceAddress, entry.ServicePort = a.TranslateServiceAddress(dc,
+				entry.ServiceAddress, entry.ServicePort, entry.ServiceTaggedAddresses)
 		}
 	default:
 		panic(fmt.Errorf("Unhandled type passed to address translator: %#v", subj))
@@ -87,7 +87,7 @@ func fixupTxnOps(raw interface{}) error {
 // isWrite returns true if the given operation alters the state store.
 func isWrite(op api.KVOp) bool {
 	switch op {
-	case api.KVSet, api.KVDelete, api.KVDeleteCAS, api.KVDeleteTree, api.KVCAS, api.KVLock, api.KVUnlock:
+	case api.KVSet, api.KVDelete, api.KVDeleteCAS, api.KVDeleteTree, api.KVDeleteTreeCAS, api.KVCAS, api.KVLock, api.KVUnlock:
 		return true
 	}
 	return false
@@ -196,12 +196,15 @@ func (s *HTTPServer) convertOps(resp http.ResponseWriter, req *http.Request) (st
 					Verb: in.Service.Verb,
 					Node: in.Service.Node,
 					Service: structs.NodeService{
-						ID:      svc.ID,
-						Service: svc.Service,
-						Tags:    svc.Tags,
-						Address: svc.Address,
-						Meta:    svc.Meta,
-						Port:    svc.Port,
+						Kind:            structs.ServiceKind(svc.Kind),
+						ID:              svc.ID,
+						Service:         svc.Service,
+						Tags:            svc.Tags,
+						Address:         svc.Address,
+						TaggedAddresses: structs.ServiceAddressesFromAPI(svc.TaggedAddresses),
+						Meta:            svc.Meta,
+						Labels:          svc.Labels,
+						Port:            svc.Port,
 						Weights: &structs.Weights{
 							Passing: svc.Weights.Passing,
 							Warning: svc.Weights.Warning,
@@ -213,6 +216,20 @@ func (s *HTTPServer) convertOps(resp http.ResponseWriter, req *http.Request) (st
 					},
 				},
 			}
+			if svc.Proxy != nil {
+				out.Service.Service.Proxy = structs.ConnectProxyConfig{
+					DestinationServiceName: svc.Proxy.DestinationServiceName,
+					DestinationServiceID:   svc.Proxy.DestinationServiceID,
+					LocalServiceAddress:    svc.Proxy.LocalServiceAddress,
+					LocalServicePort:       svc.Proxy.LocalServicePort,
+					Config:                 svc.Proxy.Config,
+					Upstreams:              structs.UpstreamsFromAPI(svc.Proxy.Upstreams),
+					Expose:                 structs.ExposeConfigFromAPI(svc.Proxy.Expose),
+				}
+			}
+			if svc.Connect != nil {
+				out.Service.Service.Connect.Native = svc.Connect.Native
+			}
 			opsRPC = append(opsRPC, out)
 
 		case in.Check != nil:
@@ -258,6 +275,10 @@ func (s *HTTPServer) convertOps(resp http.ResponseWriter, req *http.Request) (st
 							Header:        check.Definition.Header,
 							Method:        check.Definition.Method,
 							TCP:           check.Definition.TCP,
+							GRPC:          check.Definition.GRPC,
+							GRPCUseTLS:    check.Definition.GRPCUseTLS,
+							ScriptArgs:    check.Definition.ScriptArgs,
+							TTL:           check.Definition.TTL,
 							Interval:      interval,
 							Timeout:       timeout,
 							DeregisterCriticalServiceAfter: deregisterCriticalServiceAfter,
@@ -314,6 +335,7 @@ func (s *HTTPServer) Txn(resp http.ResponseWriter, req *http.Request) (interface
 		// for metadata.
 		setLastContact(resp, reply.LastContact)
 		setKnownLeader(resp, reply.KnownLeader)
+		setServedBy(resp, &reply.QueryMeta)
 
 		ret, conflict = reply, len(reply.Errors) > 0
 	} else {
@@ -288,7 +288,11 @@ func TestTxnEndpoint_KV_Actions(t *testing.T) {
 					},
 				},
 				QueryMeta: structs.QueryMeta{
-					KnownLeader: true,
+					KnownLeader:      true,
+					ConsistencyLevel: "leader",
+					ServedBy:         a.Config.NodeName,
+					ServedByID:       a.Config.NodeID,
+					ServedByRole:     structs.QueryServedByLeader,
 				},
 			}
 			if !reflect.DeepEqual(txnResp, expected) {
@@ -568,3 +572,116 @@ func TestTxnEndpoint_UpdateCheck(t *testing.T) {
 	}
 	verify.Values(t, "", txnResp, expected)
 }
+
+func TestTxnEndpoint_NodeServiceCheck(t *testing.T) {
+	t.Parallel()
+	a := NewTestAgent(t, t.Name(), "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// Register a node along with a service, its proxy and a check in a
+	// single transaction.
+	buf := bytes.NewBuffer([]byte(`
+[
+	{
+		"Node": {
+			"Verb": "set",
+			"Node": {
+				"Node": "foo",
+				"Address": "127.0.0.2"
+			}
+		}
+	},
+	{
+		"Service": {
+			"Verb": "set",
+			"Node": "foo",
+			"Service": {
+				"ID": "web",
+				"Service": "web",
+				"Port": 8080
+			}
+		}
+	},
+	{
+		"Service": {
+			"Verb": "set",
+			"Node": "foo",
+			"Service": {
+				"Kind": "connect-proxy",
+				"ID": "web-proxy",
+				"Service": "web-proxy",
+				"Port": 21000,
+				"Proxy": {
+					"DestinationServiceName": "web",
+					"LocalServicePort": 8080
+				}
+			}
+		}
+	},
+	{
+		"Check": {
+			"Verb": "set",
+			"Check": {
+				"Node": "foo",
+				"CheckID": "web:alive",
+				"Name": "Web alive",
+				"Status": "passing",
+				"ServiceID": "web"
+			}
+		}
+	}
+]
+`))
+	req, _ := http.NewRequest("PUT", "/v1/txn", buf)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.Txn(resp, req)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if resp.Code != 200 {
+		t.Fatalf("expected 200, got %d: %s", resp.Code, resp.Body.String())
+	}
+	txnResp, ok := obj.(structs.TxnResponse)
+	if !ok {
+		t.Fatalf("bad type: %T", obj)
+	}
+	if len(txnResp.Results) != 4 {
+		t.Fatalf("bad: %v", txnResp)
+	}
+
+	// Make sure everything landed in the catalog.
+	args := structs.NodeSpecificRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+	}
+	var out structs.IndexedNodeServices
+	if err := a.RPC("Catalog.NodeServices", &args, &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if out.NodeServices == nil || out.NodeServices.Node.Address != "127.0.0.2" {
+		t.Fatalf("bad: %v", out.NodeServices)
+	}
+	if len(out.NodeServices.Services) != 2 {
+		t.Fatalf("bad: %v", out.NodeServices.Services)
+	}
+	proxy := out.NodeServices.Services["web-proxy"]
+	if proxy == nil || proxy.Kind != structs.ServiceKindConnectProxy {
+		t.Fatalf("bad: %v", proxy)
+	}
+	if proxy.Proxy.DestinationServiceName != "web" || proxy.Proxy.LocalServicePort != 8080 {
+		t.Fatalf("bad: %v", proxy.Proxy)
+	}
+
+	checkArgs := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "web",
+	}
+	var checks structs.IndexedHealthChecks
+	if err := a.RPC("Health.ServiceChecks", &checkArgs, &checks); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(checks.HealthChecks) != 1 || checks.HealthChecks[0].CheckID != "web:alive" {
+		t.Fatalf("bad: %v", checks.HealthChecks)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 )
@@ -25,6 +27,15 @@ func clustersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pro
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
+	switch cfgSnap.Kind {
+	case structs.ServiceKindMeshGateway:
+		return clustersFromSnapshotMeshGateway(cfgSnap)
+	case structs.ServiceKindIngressGateway:
+		return clustersFromSnapshotIngressGateway(cfgSnap)
+	case structs.ServiceKindTerminatingGateway:
+		return clustersFromSnapshotTerminatingGateway(cfgSnap)
+	}
+
 	// Include the "app" cluster for the public listener
 	clusters := make([]proto.Message, len(cfgSnap.Proxy.Upstreams)+1)
 
@@ -41,9 +52,170 @@ func clustersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pro
 		}
 	}
 
+	// One cluster for each local port with exposed paths
+	seen := make(map[int]bool)
+	for _, path := range cfgSnap.Proxy.Expose.Paths {
+		if seen[path.LocalPathPort] {
+			continue
+		}
+		seen[path.LocalPathPort] = true
+		clusters = append(clusters, makeExposedCluster(cfgSnap, path))
+	}
+
+	return clusters, nil
+}
+
+// clustersFromSnapshotMeshGateway returns the clusters of a mesh gateway: one
+// for each service of the local datacenter and one for the mesh gateways of
+// each other datacenter, named after the SNI they are routed by.
+func clustersFromSnapshotMeshGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	if cfgSnap.Roots == nil {
+		return nil, errors.New("no CA roots in config snapshot")
+	}
+	trustDomain := cfgSnap.Roots.TrustDomain
+
+	var names []string
+	for service := range cfgSnap.ServiceGroups {
+		names = append(names, connect.ServiceSNI(service, "", cfgSnap.Datacenter, trustDomain))
+	}
+	for dc := range cfgSnap.MeshGatewayEndpoints {
+		if dc == cfgSnap.Datacenter {
+			continue
+		}
+		names = append(names, connect.DatacenterSNI(dc, trustDomain))
+	}
+	sort.Strings(names)
+
+	clusters := make([]proto.Message, 0, len(names))
+	for _, name := range names {
+		clusters = append(clusters, makeMeshGatewayCluster(name))
+	}
 	return clusters, nil
 }
 
+// clustersFromSnapshotIngressGateway returns the clusters of an ingress
+// gateway: one for each service of its listeners, connected to over Connect
+// like the upstreams of a proxy.
+func clustersFromSnapshotIngressGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	upstreams := cfgSnap.IngressUpstreams()
+	clusters := make([]proto.Message, 0, len(upstreams))
+	for _, u := range upstreams {
+		c, err := makeUpstreamCluster(u, cfgSnap)
+		if err != nil {
+			return nil, err
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, nil
+}
+
+// clustersFromSnapshotTerminatingGateway returns the clusters of a
+// terminating gateway: one for each linked service, named after the SNI it is
+// routed by. The instances are connected to over TLS when the linked service
+// has a CA file.
+func clustersFromSnapshotTerminatingGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	if cfgSnap.Roots == nil {
+		return nil, errors.New("no CA roots in config snapshot")
+	}
+	if cfgSnap.TerminatingGateway == nil {
+		return nil, nil
+	}
+	trustDomain := cfgSnap.Roots.TrustDomain
+
+	services := make([]structs.LinkedService, len(cfgSnap.TerminatingGateway.Services))
+	copy(services, cfgSnap.TerminatingGateway.Services)
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name < services[j].Name
+	})
+
+	clusters := make([]proto.Message, 0, len(services))
+	for _, svc := range services {
+		c := makeMeshGatewayCluster(connect.ServiceSNI(svc.Name, "", cfgSnap.Datacenter, trustDomain))
+		if svc.CAFile != "" {
+			c.TlsContext = makeLinkedServiceTLSContext(svc)
+		}
+		clusters = append(clusters, c)
+	}
+	return clusters, nil
+}
+
+// makeLinkedServiceTLSContext returns the TLS context a terminating gateway
+// connects to the instances of a linked service with, from the files of the
+// linked service.
+func makeLinkedServiceTLSContext(svc structs.LinkedService) *envoyauth.UpstreamTlsContext {
+	fileSource := func(path string) *envoycore.DataSource {
+		return &envoycore.DataSource{
+			Specifier: &envoycore.DataSource_Filename{
+				Filename: path,
+			},
+		}
+	}
+
+	ctx := &envoyauth.UpstreamTlsContext{
+		CommonTlsContext: &envoyauth.CommonTlsContext{
+			TlsParams: &envoyauth.TlsParameters{},
+			ValidationContextType: &envoyauth.CommonTlsContext_ValidationContext{
+				ValidationContext: &envoyauth.CertificateValidationContext{
+					TrustedCa: fileSource(svc.CAFile),
+				},
+			},
+		},
+		Sni: svc.SNI,
+	}
+	if svc.CertFile != "" {
+		ctx.CommonTlsContext.TlsCertificates = []*envoyauth.TlsCertificate{
+			{
+				CertificateChain: fileSource(svc.CertFile),
+				PrivateKey:       fileSource(svc.KeyFile),
+			},
+		}
+	}
+	return ctx
+}
+
+// makeMeshGatewayCluster returns an EDS cluster of a mesh gateway. The mesh
+// gateways don't terminate the TLS connections so the cluster has no TLS
+// context.
+func makeMeshGatewayCluster(name string) *envoy.Cluster {
+	return &envoy.Cluster{
+		Name:           name,
+		ConnectTimeout: 5 * time.Second,
+		Type:           envoy.Cluster_EDS,
+		EdsClusterConfig: &envoy.Cluster_EdsClusterConfig{
+			EdsConfig: &envoycore.ConfigSource{
+				ConfigSourceSpecifier: &envoycore.ConfigSource_Ads{
+					Ads: &envoycore.AggregatedConfigSource{},
+				},
+			},
+		},
+		// Having an empty config enables outlier detection with default config.
+		OutlierDetection: &envoycluster.OutlierDetection{},
+	}
+}
+
+// exposedClusterName returns the name of the cluster of the exposed paths
+// served on the local port.
+func exposedClusterName(port int) string {
+	return fmt.Sprintf("exposed_cluster_%d", port)
+}
+
+func makeExposedCluster(cfgSnap *proxycfg.ConfigSnapshot, path structs.ExposePath) *envoy.Cluster {
+	addr := cfgSnap.Proxy.LocalServiceAddress
+	if addr == "" {
+		addr = "127.0.0.1"
+	}
+	c := &envoy.Cluster{
+		Name:           exposedClusterName(path.LocalPathPort),
+		ConnectTimeout: 5 * time.Second,
+		Type:           envoy.Cluster_STATIC,
+		Hosts:          []*envoycore.Address{makeAddressPtr(addr, path.LocalPathPort)},
+	}
+	if path.Protocol == structs.ExposePathProtocolHTTP2 {
+		c.Http2ProtocolOptions = &envoycore.Http2ProtocolOptions{}
+	}
+	return c
+}
+
 func makeAppCluster(cfgSnap *proxycfg.ConfigSnapshot) (*envoy.Cluster, error) {
 	var c *envoy.Cluster
 	var err error
@@ -83,6 +255,12 @@ func makeAppCluster(cfgSnap *proxycfg.ConfigSnapshot) (*envoy.Cluster, error) {
 			//  },
 			// },
 		}
+
+		// The local app speaks HTTP/2 to the proxy when the requests are
+		// proxied at the HTTP level with the HTTP/2 codec.
+		if protocol, _ := publicListenerProtocol(cfgSnap); protocol == "http2" || protocol == "grpc" {
+			c.Http2ProtocolOptions = &envoycore.Http2ProtocolOptions{}
+		}
 	}
 
 	return c, err
@@ -122,7 +300,15 @@ func makeUpstreamCluster(upstream structs.Upstream, cfgSnap *proxycfg.ConfigSnap
 	}
 
 	if c == nil {
+		// The connect timeout of the discovery chain resolver is used unless
+		// the upstream config overrides it.
 		conTimeout := 5 * time.Second
+		chain := cfgSnap.DiscoveryChain[upstream.Identifier()]
+		if chain != nil {
+			if node := chain.Nodes[chain.StartNode]; node != nil && node.Resolver != nil && node.Resolver.ConnectTimeout > 0 {
+				conTimeout = node.Resolver.ConnectTimeout
+			}
+		}
 		if toRaw, ok := upstream.Config["connect_timeout_ms"]; ok {
 			if ms, err := parseTimeMillis(toRaw); err == nil {
 				conTimeout = ms
@@ -142,16 +328,50 @@ func makeUpstreamCluster(upstream structs.Upstream, cfgSnap *proxycfg.ConfigSnap
 			// Having an empty config enables outlier detection with default config.
 			OutlierDetection: &envoycluster.OutlierDetection{},
 		}
+		if protocol := upstreamProtocol(cfgSnap, &upstream); protocol == "http2" || protocol == "grpc" {
+			c.Http2ProtocolOptions = &envoycore.Http2ProtocolOptions{}
+		}
 	}
 
 	// Enable TLS upstream with the configured client certificate.
 	c.TlsContext = &envoyauth.UpstreamTlsContext{
 		CommonTlsContext: makeCommonTLSContext(cfgSnap),
 	}
+	if c.TlsContext.CommonTlsContext != nil {
+		c.TlsContext.CommonTlsContext.AlpnProtocols = alpnProtocols(upstreamProtocol(cfgSnap, &upstream))
+	}
+
+	// The mesh gateways route the connections by the SNI of the upstream
+	// service, and so do the terminating gateways of the services outside
+	// the mesh.
+	if cfgSnap.Roots != nil {
+		if _, ok := cfgSnap.UpstreamMeshGateways(&upstream); ok {
+			c.TlsContext.Sni = connect.ServiceSNI(upstream.DestinationName, upstream.DestinationNamespace,
+				upstream.Datacenter, cfgSnap.Roots.TrustDomain)
+		} else if hasTerminatingGateway(cfgSnap.UpstreamEndpoints[upstream.Identifier()]) {
+			dc := upstream.Datacenter
+			if dc == "" {
+				dc = cfgSnap.Datacenter
+			}
+			c.TlsContext.Sni = connect.ServiceSNI(upstream.DestinationName, upstream.DestinationNamespace,
+				dc, cfgSnap.Roots.TrustDomain)
+		}
+	}
 
 	return c, nil
 }
 
+// hasTerminatingGateway returns whether the upstream instances include a
+// terminating gateway.
+func hasTerminatingGateway(nodes structs.CheckServiceNodes) bool {
+	for _, node := range nodes {
+		if node.Service != nil && node.Service.Kind == structs.ServiceKindTerminatingGateway {
+			return true
+		}
+	}
+	return false
+}
+
 // makeClusterFromUserConfig returns the listener config decoded from an
 // arbitrary proto3 json format string or an error if it's invalid.
 //
@@ -63,6 +63,39 @@ func Test_makeUpstreamCluster(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "discovery chain",
+			snap: proxycfg.ConfigSnapshot{
+				DiscoveryChain: map[string]*structs.CompiledDiscoveryChain{
+					"service:db": func() *structs.CompiledDiscoveryChain {
+						chain := proxycfg.TestDiscoveryChain(t, "db", "grpc")
+						chain.Nodes[chain.StartNode].Resolver.ConnectTimeout = 2 * time.Second
+						return chain
+					}(),
+				},
+			},
+			upstream: structs.Upstream{
+				DestinationType: structs.UpstreamDestTypeService,
+				DestinationName: "db",
+			},
+			want: &envoy.Cluster{
+				Name: "service:db",
+				Type: envoy.Cluster_EDS,
+				EdsClusterConfig: &envoy.Cluster_EdsClusterConfig{
+					EdsConfig: &envoycore.ConfigSource{
+						ConfigSourceSpecifier: &envoycore.ConfigSource_Ads{
+							Ads: &envoycore.AggregatedConfigSource{},
+						},
+					},
+				},
+				ConnectTimeout:       2 * time.Second, // From the resolver
+				OutlierDetection:     &cluster.OutlierDetection{},
+				Http2ProtocolOptions: &envoycore.Http2ProtocolOptions{},
+				TlsContext: &envoyauth.UpstreamTlsContext{
+					CommonTlsContext: makeCommonTLSContext(&proxycfg.ConfigSnapshot{}),
+				},
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -74,3 +107,162 @@ func Test_makeUpstreamCluster(t *testing.T) {
 		})
 	}
 }
+
+func Test_clustersFromSnapshot_ExposedPaths(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshot(t)
+	snap.Proxy.Expose.Paths = []structs.ExposePath{
+		{ListenerPort: 21500, Path: "/health", LocalPathPort: 8080, Protocol: "http"},
+		{ListenerPort: 21501, Path: "/metrics", LocalPathPort: 8080, Protocol: "http"},
+		{ListenerPort: 21502, Path: "/grpc.health.v1.Health/Check", LocalPathPort: 8081, Protocol: "http2"},
+	}
+	clusters, err := clustersFromSnapshot(snap, "")
+	require.NoError(err)
+
+	// The paths served on the same local port share a cluster
+	require.Len(clusters, len(snap.Proxy.Upstreams)+3)
+	require.Equal(&envoy.Cluster{
+		Name:           "exposed_cluster_8080",
+		ConnectTimeout: 5 * time.Second,
+		Type:           envoy.Cluster_STATIC,
+		Hosts:          []*envoycore.Address{makeAddressPtr("127.0.0.1", 8080)},
+	}, clusters[len(clusters)-2])
+	require.Equal(&envoy.Cluster{
+		Name:                 "exposed_cluster_8081",
+		ConnectTimeout:       5 * time.Second,
+		Type:                 envoy.Cluster_STATIC,
+		Hosts:                []*envoycore.Address{makeAddressPtr("127.0.0.1", 8081)},
+		Http2ProtocolOptions: &envoycore.Http2ProtocolOptions{},
+	}, clusters[len(clusters)-1])
+}
+
+func Test_clustersFromSnapshot_MeshGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotMeshGateway(t)
+	td := snap.Roots.TrustDomain
+	clusters, err := clustersFromSnapshot(snap, "my-token")
+	require.NoError(err)
+
+	// The clusters don't terminate TLS so there is no app cluster nor TLS
+	// context.
+	var names []string
+	for _, c := range clusters {
+		cluster := c.(*envoy.Cluster)
+		require.Equal(envoy.Cluster_EDS, cluster.Type)
+		require.Nil(cluster.TlsContext)
+		names = append(names, cluster.Name)
+	}
+	require.Equal([]string{
+		"db.default.dc1.internal." + td,
+		"dc2.internal." + td,
+		"web.default.dc1.internal." + td,
+	}, names)
+}
+
+func Test_makeUpstreamCluster_MeshGateway(t *testing.T) {
+	cases := []struct {
+		mode structs.MeshGatewayMode
+		sni  bool
+	}{
+		{structs.MeshGatewayModeDefault, false},
+		{structs.MeshGatewayModeNone, false},
+		{structs.MeshGatewayModeLocal, true},
+		{structs.MeshGatewayModeRemote, true},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			require := require.New(t)
+
+			snap := proxycfg.TestConfigSnapshotMeshGatewayUpstream(t, tc.mode)
+			upstream := snap.Proxy.Upstreams[len(snap.Proxy.Upstreams)-1]
+			c, err := makeUpstreamCluster(upstream, snap)
+			require.NoError(err)
+
+			// The connections are routed by the gateways with the SNI of the
+			// upstream service.
+			if tc.sni {
+				require.Equal("api.default.dc2.internal."+snap.Roots.TrustDomain, c.TlsContext.Sni)
+			} else {
+				require.Empty(c.TlsContext.Sni)
+			}
+
+			// The upstreams of the local datacenter are always reached directly.
+			local, err := makeUpstreamCluster(snap.Proxy.Upstreams[0], snap)
+			require.NoError(err)
+			require.Empty(local.TlsContext.Sni)
+		})
+	}
+}
+
+func Test_clustersFromSnapshot_IngressGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotIngressGateway(t)
+	clusters, err := clustersFromSnapshot(snap, "my-token")
+	require.NoError(err)
+
+	// The services are connected to over Connect like the upstreams of a
+	// proxy, there is no app cluster.
+	var names []string
+	for _, c := range clusters {
+		cluster := c.(*envoy.Cluster)
+		require.Equal(envoy.Cluster_EDS, cluster.Type)
+		require.NotNil(cluster.TlsContext)
+		names = append(names, cluster.Name)
+	}
+	require.Equal([]string{"service:api", "service:db", "service:web"}, names)
+}
+
+func Test_clustersFromSnapshot_TerminatingGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotTerminatingGateway(t)
+	td := snap.Roots.TrustDomain
+	clusters, err := clustersFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Len(clusters, 2)
+
+	// The instances of billing are connected to with its TLS files.
+	billing := clusters[0].(*envoy.Cluster)
+	require.Equal("billing.default.dc1.internal."+td, billing.Name)
+	require.Equal(envoy.Cluster_EDS, billing.Type)
+	require.NotNil(billing.TlsContext)
+	require.Equal("billing.example.com", billing.TlsContext.Sni)
+	validation := billing.TlsContext.CommonTlsContext.GetValidationContext()
+	require.Equal("/etc/certs/ca.pem", validation.TrustedCa.GetFilename())
+	require.Len(billing.TlsContext.CommonTlsContext.TlsCertificates, 1)
+	cert := billing.TlsContext.CommonTlsContext.TlsCertificates[0]
+	require.Equal("/etc/certs/client.pem", cert.CertificateChain.GetFilename())
+	require.Equal("/etc/certs/client.key", cert.PrivateKey.GetFilename())
+
+	// The ones of legacy in plain text.
+	legacy := clusters[1].(*envoy.Cluster)
+	require.Equal("legacy.default.dc1.internal."+td, legacy.Name)
+	require.Nil(legacy.TlsContext)
+}
+
+func Test_makeUpstreamCluster_TerminatingGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshot(t)
+	snap.Datacenter = "dc1"
+	upstream := snap.Proxy.Upstreams[0]
+	c, err := makeUpstreamCluster(upstream, snap)
+	require.NoError(err)
+	require.Empty(c.TlsContext.Sni)
+
+	// The terminating gateways route the connections by the SNI of the
+	// linked service.
+	gateway := proxycfg.TestUpstreamNodes(t)[0]
+	gateway.Service = &structs.NodeService{
+		Kind:    structs.ServiceKindTerminatingGateway,
+		Service: "terminating",
+		Port:    8443,
+	}
+	snap.UpstreamEndpoints[upstream.Identifier()] = structs.CheckServiceNodes{gateway}
+	c, err = makeUpstreamCluster(upstream, snap)
+	require.NoError(err)
+	require.Equal(upstream.DestinationName+".default.dc1.internal."+snap.Roots.TrustDomain, c.TlsContext.Sni)
+}
@@ -8,6 +8,7 @@ import (
 	envoyendpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
 	"github.com/gogo/protobuf/proto"
 
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
@@ -19,14 +20,97 @@ func endpointsFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pr
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
+	switch cfgSnap.Kind {
+	case structs.ServiceKindMeshGateway:
+		return endpointsFromSnapshotMeshGateway(cfgSnap)
+	case structs.ServiceKindTerminatingGateway:
+		return endpointsFromSnapshotTerminatingGateway(cfgSnap)
+	}
+
+	// The upstreams routed through the mesh gateways are sent to the
+	// gateways instead of the upstream instances.
+	gateways := make(map[string]*envoy.ClusterLoadAssignment)
+	for i := range cfgSnap.Proxy.Upstreams {
+		u := &cfgSnap.Proxy.Upstreams[i]
+		nodes, ok := cfgSnap.UpstreamMeshGateways(u)
+		if !ok {
+			continue
+		}
+		taggedAddr := "lan"
+		if cfgSnap.UpstreamMeshGatewayMode(u) == structs.MeshGatewayModeRemote {
+			taggedAddr = "wan"
+		}
+		gateways[u.Identifier()] = makeMeshGatewayLoadAssignment(u.Identifier(), nodes, taggedAddr)
+	}
+
 	resources := make([]proto.Message, 0, len(cfgSnap.UpstreamEndpoints))
 	for id, endpoints := range cfgSnap.UpstreamEndpoints {
+		if la, ok := gateways[id]; ok {
+			resources = append(resources, la)
+			continue
+		}
 		la := makeLoadAssignment(id, endpoints)
 		resources = append(resources, la)
 	}
 	return resources, nil
 }
 
+// endpointsFromSnapshotMeshGateway returns the endpoints of the clusters of a
+// mesh gateway: the instances of the local services and the mesh gateways of
+// the other datacenters, reached through their WAN address.
+func endpointsFromSnapshotMeshGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	if cfgSnap.Roots == nil {
+		return nil, errors.New("no CA roots in config snapshot")
+	}
+	trustDomain := cfgSnap.Roots.TrustDomain
+
+	resources := make([]proto.Message, 0, len(cfgSnap.ServiceGroups)+len(cfgSnap.MeshGatewayEndpoints))
+	for service, endpoints := range cfgSnap.ServiceGroups {
+		name := connect.ServiceSNI(service, "", cfgSnap.Datacenter, trustDomain)
+		resources = append(resources, makeLoadAssignment(name, endpoints))
+	}
+	for dc, endpoints := range cfgSnap.MeshGatewayEndpoints {
+		if dc == cfgSnap.Datacenter {
+			continue
+		}
+		name := connect.DatacenterSNI(dc, trustDomain)
+		resources = append(resources, makeMeshGatewayLoadAssignment(name, endpoints, "wan"))
+	}
+	return resources, nil
+}
+
+// endpointsFromSnapshotTerminatingGateway returns the endpoints of the
+// clusters of a terminating gateway: the instances of the linked services.
+func endpointsFromSnapshotTerminatingGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	if cfgSnap.Roots == nil {
+		return nil, errors.New("no CA roots in config snapshot")
+	}
+	trustDomain := cfgSnap.Roots.TrustDomain
+
+	resources := make([]proto.Message, 0, len(cfgSnap.ServiceGroups))
+	for service, endpoints := range cfgSnap.ServiceGroups {
+		name := connect.ServiceSNI(service, "", cfgSnap.Datacenter, trustDomain)
+		resources = append(resources, makeLoadAssignment(name, endpoints))
+	}
+	return resources, nil
+}
+
+// makeMeshGatewayLoadAssignment returns the load assignment of the mesh
+// gateways, reached through the given tagged address when they have it.
+func makeMeshGatewayLoadAssignment(clusterName string, endpoints structs.CheckServiceNodes, taggedAddr string) *envoy.ClusterLoadAssignment {
+	gateways := make(structs.CheckServiceNodes, 0, len(endpoints))
+	for _, ep := range endpoints {
+		if addr, ok := ep.Service.TaggedAddresses[taggedAddr]; ok {
+			svc := *ep.Service
+			svc.Address = addr.Address
+			svc.Port = addr.Port
+			ep.Service = &svc
+		}
+		gateways = append(gateways, ep)
+	}
+	return makeLoadAssignment(clusterName, gateways)
+}
+
 func makeEndpoint(clusterName, host string, port int) envoyendpoint.LbEndpoint {
 	return envoyendpoint.LbEndpoint{
 		Endpoint: &envoyendpoint.Endpoint{
@@ -10,6 +10,7 @@ import (
 	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	"github.com/envoyproxy/go-control-plane/envoy/api/v2/core"
 	envoyendpoint "github.com/envoyproxy/go-control-plane/envoy/api/v2/endpoint"
+	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
@@ -191,3 +192,93 @@ func Test_makeLoadAssignment(t *testing.T) {
 		})
 	}
 }
+
+func Test_endpointsFromSnapshot_MeshGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotMeshGateway(t)
+	td := snap.Roots.TrustDomain
+	resources, err := endpointsFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Len(resources, 3)
+
+	assignments := make(map[string]*envoy.ClusterLoadAssignment)
+	for _, res := range resources {
+		la := res.(*envoy.ClusterLoadAssignment)
+		assignments[la.ClusterName] = la
+	}
+	require.Equal(makeLoadAssignment("db.default.dc1.internal."+td, proxycfg.TestUpstreamNodes(t)),
+		assignments["db.default.dc1.internal."+td])
+
+	// The gateways of the other datacenters are reached through their WAN
+	// address.
+	remote := assignments["dc2.internal."+td]
+	require.NotNil(remote)
+	require.Len(remote.Endpoints[0].LbEndpoints, 1)
+	require.Equal(makeAddressPtr("198.19.0.1", 443), remote.Endpoints[0].LbEndpoints[0].Endpoint.Address)
+}
+
+func Test_endpointsFromSnapshot_MeshGatewayUpstream(t *testing.T) {
+	cases := []struct {
+		mode      structs.MeshGatewayMode
+		addresses []string
+	}{
+		{structs.MeshGatewayModeDefault, []string{"10.10.1.1", "10.10.1.2"}},
+		{structs.MeshGatewayModeNone, []string{"10.10.1.1", "10.10.1.2"}},
+		{structs.MeshGatewayModeLocal, []string{"10.10.0.1", "10.10.0.2"}},
+		{structs.MeshGatewayModeRemote, []string{"198.19.0.1"}},
+	}
+	for _, tc := range cases {
+		t.Run(string(tc.mode), func(t *testing.T) {
+			require := require.New(t)
+
+			snap := proxycfg.TestConfigSnapshotMeshGatewayUpstream(t, tc.mode)
+			resources, err := endpointsFromSnapshot(snap, "my-token")
+			require.NoError(err)
+
+			var addresses []string
+			for _, res := range resources {
+				la := res.(*envoy.ClusterLoadAssignment)
+				if la.ClusterName != "service:api?dc=dc2" {
+					continue
+				}
+				for _, ep := range la.Endpoints[0].LbEndpoints {
+					addresses = append(addresses, ep.Endpoint.Address.GetSocketAddress().Address)
+				}
+			}
+			require.Equal(tc.addresses, addresses)
+		})
+	}
+}
+
+func Test_endpointsFromSnapshot_IngressGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotIngressGateway(t)
+	resources, err := endpointsFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Len(resources, 3)
+
+	for _, res := range resources {
+		la := res.(*envoy.ClusterLoadAssignment)
+		require.Equal(makeLoadAssignment(la.ClusterName, proxycfg.TestUpstreamNodes(t)), la)
+	}
+}
+
+func Test_endpointsFromSnapshot_TerminatingGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotTerminatingGateway(t)
+	td := snap.Roots.TrustDomain
+	resources, err := endpointsFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Len(resources, 2)
+
+	assignments := make(map[string]*envoy.ClusterLoadAssignment)
+	for _, res := range resources {
+		la := res.(*envoy.ClusterLoadAssignment)
+		assignments[la.ClusterName] = la
+	}
+	require.Equal(makeLoadAssignment("legacy.default.dc1.internal."+td, proxycfg.TestUpstreamNodes(t)),
+		assignments["legacy.default.dc1.internal."+td])
+}
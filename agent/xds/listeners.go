@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 
 	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
 	envoyauth "github.com/envoyproxy/go-control-plane/envoy/api/v2/auth"
@@ -16,6 +19,7 @@ import (
 	"github.com/gogo/protobuf/proto"
 	"github.com/gogo/protobuf/types"
 
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/proxycfg"
 	"github.com/hashicorp/consul/agent/structs"
 )
@@ -26,9 +30,18 @@ func listenersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pr
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
+	switch cfgSnap.Kind {
+	case structs.ServiceKindMeshGateway:
+		return listenersFromSnapshotMeshGateway(cfgSnap)
+	case structs.ServiceKindIngressGateway:
+		return listenersFromSnapshotIngressGateway(cfgSnap)
+	case structs.ServiceKindTerminatingGateway:
+		return listenersFromSnapshotTerminatingGateway(cfgSnap, token)
+	}
 
-	// One listener for each upstream plus the public one
-	resources := make([]proto.Message, len(cfgSnap.Proxy.Upstreams)+1)
+	// One listener for each upstream and exposed path plus the public one
+	resources := make([]proto.Message, len(cfgSnap.Proxy.Upstreams)+1,
+		len(cfgSnap.Proxy.Upstreams)+len(cfgSnap.Proxy.Expose.Paths)+1)
 
 	// Configure public listener
 	var err error
@@ -37,14 +50,194 @@ func listenersFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]pr
 		return nil, err
 	}
 	for i, u := range cfgSnap.Proxy.Upstreams {
-		resources[i+1], err = makeUpstreamListener(&u)
+		resources[i+1], err = makeUpstreamListener(cfgSnap, &u)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, path := range cfgSnap.Proxy.Expose.Paths {
+		l, err := makeExposedPathListener(cfgSnap, path)
+		if err != nil {
+			return nil, err
+		}
+		resources = append(resources, l)
+	}
+	return resources, nil
+}
+
+// listenersFromSnapshotMeshGateway returns the listener of a mesh gateway. It
+// inspects the SNI of the TLS connections without terminating them and
+// forwards them to the cluster of the service or datacenter they are for.
+func listenersFromSnapshotMeshGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	if cfgSnap.Roots == nil {
+		return nil, errors.New("no CA roots in config snapshot")
+	}
+	trustDomain := cfgSnap.Roots.TrustDomain
+
+	// The services of the local datacenter are matched by their exact SNI,
+	// the ones of the other datacenters by the suffix of the datacenter.
+	serverNames := make(map[string]string)
+	for service := range cfgSnap.ServiceGroups {
+		name := connect.ServiceSNI(service, "", cfgSnap.Datacenter, trustDomain)
+		serverNames[name] = name
+	}
+	for dc := range cfgSnap.MeshGatewayEndpoints {
+		if dc == cfgSnap.Datacenter {
+			continue
+		}
+		name := connect.DatacenterSNI(dc, trustDomain)
+		serverNames["*."+name] = name
+	}
+	// Envoy rejects the listeners without filter chains.
+	if len(serverNames) == 0 {
+		return nil, nil
+	}
+
+	names := make([]string, 0, len(serverNames))
+	for name := range serverNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	addr := cfgSnap.Address
+	if addr == "" {
+		addr = "0.0.0.0"
+	}
+	l := makeListener(MeshGatewayListenerName, addr, cfgSnap.Port)
+	l.ListenerFilters = []envoylistener.ListenerFilter{
+		{Name: "envoy.listener.tls_inspector"},
+	}
+	for _, name := range names {
+		cluster := serverNames[name]
+		filter, err := makeTCPProxyFilter(cluster, cluster)
 		if err != nil {
 			return nil, err
 		}
+		l.FilterChains = append(l.FilterChains, envoylistener.FilterChain{
+			FilterChainMatch: &envoylistener.FilterChainMatch{
+				ServerNames: []string{name},
+			},
+			Filters: []envoylistener.Filter{
+				filter,
+			},
+		})
+	}
+	return []proto.Message{l}, nil
+}
+
+// listenersFromSnapshotIngressGateway returns one listener for each listener
+// of the config entry of an ingress gateway. A TCP listener proxies the
+// connections to the cluster of its service, an HTTP listener routes the
+// requests with the route config of its port.
+func listenersFromSnapshotIngressGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	if cfgSnap.IngressGateway == nil {
+		return nil, nil
+	}
+
+	addr := cfgSnap.Address
+	if addr == "" {
+		addr = "0.0.0.0"
+	}
+	resources := make([]proto.Message, 0, len(cfgSnap.IngressGateway.Listeners))
+	for _, listener := range cfgSnap.IngressGateway.Listeners {
+		l := makeListener(IngressListenerName, addr, listener.Port)
+
+		var filter envoylistener.Filter
+		var err error
+		if listener.Protocol == structs.IngressListenerProtocolHTTP {
+			filter, err = makeUpstreamHTTPFilter(ingressRouteName(listener.Port), listener.Protocol)
+		} else {
+			id := ingressUpstreamID(listener.Services[0].Name)
+			filter, err = makeTCPProxyFilter(id, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		l.FilterChains = []envoylistener.FilterChain{
+			{
+				Filters: []envoylistener.Filter{
+					filter,
+				},
+			},
+		}
+		resources = append(resources, l)
 	}
 	return resources, nil
 }
 
+// listenersFromSnapshotTerminatingGateway returns the listener of a
+// terminating gateway. It terminates the Connect TLS connections for each
+// linked service with the leaf certificate of the service, matched by the
+// SNI the proxies present, authorizes them like a proxy would and forwards
+// them to the cluster of the service.
+func listenersFromSnapshotTerminatingGateway(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]proto.Message, error) {
+	if cfgSnap.Roots == nil {
+		return nil, errors.New("no CA roots in config snapshot")
+	}
+	trustDomain := cfgSnap.Roots.TrustDomain
+
+	// The services whose leaf certificate isn't issued yet are skipped.
+	var services []string
+	for service := range cfgSnap.ServiceLeaves {
+		services = append(services, service)
+	}
+	// Envoy rejects the listeners without filter chains.
+	if len(services) == 0 {
+		return nil, nil
+	}
+	sort.Strings(services)
+
+	addr := cfgSnap.Address
+	if addr == "" {
+		addr = "0.0.0.0"
+	}
+	l := makeListener(TerminatingGatewayListenerName, addr, cfgSnap.Port)
+	l.ListenerFilters = []envoylistener.ListenerFilter{
+		{Name: "envoy.listener.tls_inspector"},
+	}
+	for _, service := range services {
+		name := connect.ServiceSNI(service, "", cfgSnap.Datacenter, trustDomain)
+		authFilter, err := makeExtAuthFilter(token)
+		if err != nil {
+			return nil, err
+		}
+		tcpFilter, err := makeTCPProxyFilter(name, name)
+		if err != nil {
+			return nil, err
+		}
+		l.FilterChains = append(l.FilterChains, envoylistener.FilterChain{
+			FilterChainMatch: &envoylistener.FilterChainMatch{
+				ServerNames: []string{name},
+			},
+			TlsContext: &envoyauth.DownstreamTlsContext{
+				CommonTlsContext:         makeCommonTLSContextFromLeaf(cfgSnap, cfgSnap.ServiceLeaves[service]),
+				RequireClientCertificate: &types.BoolValue{Value: true},
+			},
+			Filters: []envoylistener.Filter{
+				authFilter,
+				tcpFilter,
+			},
+		})
+	}
+	return []proto.Message{l}, nil
+}
+
+// ingressRouteName returns the name of the route config of the HTTP listener
+// of an ingress gateway on the given port.
+func ingressRouteName(port int) string {
+	return fmt.Sprintf("%s_%d", IngressListenerName, port)
+}
+
+// ingressUpstreamID returns the identifier of the upstream cluster of a
+// service exposed by an ingress gateway, see ConfigSnapshot.IngressUpstreams.
+func ingressUpstreamID(service string) string {
+	u := structs.Upstream{
+		DestinationType: structs.UpstreamDestTypeService,
+		DestinationName: service,
+	}
+	return u.Identifier()
+}
+
 // makeListener returns a listener with name and bind details set. Filters must
 // be added before it's useful.
 //
@@ -127,14 +320,35 @@ func injectConnectFilters(cfgSnap *proxycfg.ConfigSnapshot, token string, listen
 		// Insert our authz filter before any others
 		listener.FilterChains[idx].Filters =
 			append([]envoylistener.Filter{authFilter}, listener.FilterChains[idx].Filters...)
+	}
+	injectConnectTLS(cfgSnap, listener)
+	return nil
+}
 
-		// Force our TLS for all filter chains on a public listener
+// injectConnectTLS forces our TLS for all filter chains on a public listener.
+func injectConnectTLS(cfgSnap *proxycfg.ConfigSnapshot, listener *envoy.Listener) {
+	for idx := range listener.FilterChains {
 		listener.FilterChains[idx].TlsContext = &envoyauth.DownstreamTlsContext{
 			CommonTlsContext:         makeCommonTLSContext(cfgSnap),
 			RequireClientCertificate: &types.BoolValue{Value: true},
 		}
 	}
-	return nil
+}
+
+// publicListenerProtocol returns the protocol of the local service, set with
+// the "protocol" key of the proxy config and "tcp" by default.
+func publicListenerProtocol(cfgSnap *proxycfg.ConfigSnapshot) (string, error) {
+	protocol := "tcp"
+	if raw, ok := cfgSnap.Proxy.Config["protocol"]; ok {
+		if p, ok := raw.(string); ok {
+			protocol = strings.ToLower(p)
+		}
+	}
+	switch protocol {
+	case "tcp", "http", "http2", "grpc":
+		return protocol, nil
+	}
+	return "", fmt.Errorf("unsupported protocol %q", protocol)
 }
 
 func makePublicListener(cfgSnap *proxycfg.ConfigSnapshot, token string) (proto.Message, error) {
@@ -157,6 +371,35 @@ func makePublicListener(cfgSnap *proxycfg.ConfigSnapshot, token string) (proto.M
 			addr = "0.0.0.0"
 		}
 		l = makeListener(PublicListenerName, addr, cfgSnap.Port)
+
+		protocol, err := publicListenerProtocol(cfgSnap)
+		if err != nil {
+			return l, err
+		}
+		if protocol != "tcp" {
+			// The HTTP connection manager authorizes each request with the
+			// HTTP authz filter so that the L7 permissions of the intentions
+			// are enforced, only TLS needs to be injected.
+			filter, err := makePublicHTTPFilter("public_listener", LocalAppClusterName, protocol, token)
+			if err != nil {
+				return l, err
+			}
+			l.FilterChains = []envoylistener.FilterChain{
+				{
+					Filters: []envoylistener.Filter{
+						filter,
+					},
+				},
+			}
+			injectConnectTLS(cfgSnap, l)
+
+			// The downstream proxies negotiate the protocol of the HTTP codec.
+			if tlsContext := l.FilterChains[0].TlsContext; tlsContext.CommonTlsContext != nil {
+				tlsContext.CommonTlsContext.AlpnProtocols = alpnProtocols(protocol)
+			}
+			return l, nil
+		}
+
 		tcpProxy, err := makeTCPProxyFilter("public_listener", LocalAppClusterName)
 		if err != nil {
 			return l, err
@@ -176,7 +419,7 @@ func makePublicListener(cfgSnap *proxycfg.ConfigSnapshot, token string) (proto.M
 	return l, err
 }
 
-func makeUpstreamListener(u *structs.Upstream) (proto.Message, error) {
+func makeUpstreamListener(cfgSnap *proxycfg.ConfigSnapshot, u *structs.Upstream) (proto.Message, error) {
 	if listenerJSONRaw, ok := u.Config["envoy_listener_json"]; ok {
 		if listenerJSON, ok := listenerJSONRaw.(string); ok {
 			return makeListenerFromUserConfig(listenerJSON)
@@ -187,20 +430,224 @@ func makeUpstreamListener(u *structs.Upstream) (proto.Message, error) {
 		addr = "127.0.0.1"
 	}
 	l := makeListener(u.Identifier(), addr, u.LocalBindPort)
-	tcpProxy, err := makeTCPProxyFilter(u.Identifier(), u.Identifier())
+
+	var filter envoylistener.Filter
+	var err error
+	if protocol := upstreamProtocol(cfgSnap, u); protocol != "tcp" {
+		// The requests are routed with the route config of the upstream.
+		filter, err = makeUpstreamHTTPFilter(u.Identifier(), protocol)
+	} else {
+		filter, err = makeTCPProxyFilter(u.Identifier(), u.Identifier())
+	}
+	if err != nil {
+		return l, err
+	}
+	l.FilterChains = []envoylistener.FilterChain{
+		{
+			Filters: []envoylistener.Filter{
+				filter,
+			},
+		},
+	}
+	return l, nil
+}
+
+// upstreamProtocol returns the protocol of the upstream, set with the
+// "protocol" key of the upstream config, else by its discovery chain. It is
+// "tcp" when neither sets it or the protocol isn't supported at L7.
+func upstreamProtocol(cfgSnap *proxycfg.ConfigSnapshot, u *structs.Upstream) string {
+	var protocol string
+	if raw, ok := u.Config["protocol"]; ok {
+		if p, ok := raw.(string); ok {
+			protocol = p
+		}
+	}
+	if protocol == "" {
+		if chain := cfgSnap.DiscoveryChain[u.Identifier()]; chain != nil {
+			protocol = chain.Protocol
+		}
+	}
+	switch protocol = strings.ToLower(protocol); protocol {
+	case "http", "http2", "grpc":
+		return protocol
+	}
+	return "tcp"
+}
+
+// alpnProtocols returns the ALPN protocols negotiated over the Connect TLS
+// between the proxies for the given protocol, none for TCP. The HTTP codec of
+// the proxies is set by the protocol so only its own ALPN protocol is offered.
+func alpnProtocols(protocol string) []string {
+	switch protocol {
+	case "http2", "grpc":
+		return []string{"h2"}
+	case "http":
+		return []string{"http/1.1"}
+	}
+	return nil
+}
+
+// makeHTTPRouterFilters returns the HTTP filters ending the chain of an HTTP
+// connection manager of the given protocol. The router is preceded for gRPC
+// by the gRPC HTTP/1.1 bridge, which also emits the stats of each gRPC
+// method.
+func makeHTTPRouterFilters(protocol string) []interface{} {
+	var filters []interface{}
+	if protocol == "grpc" {
+		filters = append(filters, map[string]interface{}{"name": "envoy.grpc_http1_bridge"})
+	}
+	return append(filters, map[string]interface{}{"name": "envoy.router"})
+}
+
+// makeUpstreamHTTPFilter returns an HTTP connection manager filter routing the
+// requests with the route config of the given name, delivered over ADS.
+func makeUpstreamHTTPFilter(name, protocol string) (envoylistener.Filter, error) {
+	cfg := map[string]interface{}{
+		"stat_prefix": name,
+		"rds": map[string]interface{}{
+			"route_config_name": name,
+			"config_source":     map[string]interface{}{"ads": map[string]interface{}{}},
+		},
+		"http_filters": makeHTTPRouterFilters(protocol),
+	}
+	if protocol == "http2" || protocol == "grpc" {
+		cfg["codec_type"] = "HTTP2"
+	}
+	return makeHTTPConnectionManagerFilter(cfg)
+}
+
+// makeExposedPathListener returns a listener serving a single HTTP path of
+// the local service without Connect TLS nor authorization, such as for the
+// health checks of a service only listening on localhost.
+func makeExposedPathListener(cfgSnap *proxycfg.ConfigSnapshot, path structs.ExposePath) (proto.Message, error) {
+	addr := cfgSnap.Address
+	if addr == "" {
+		addr = "0.0.0.0"
+	}
+	name := exposedPathName(path.Path)
+	l := makeListener(name, addr, path.ListenerPort)
+	filter, err := makeExposedPathFilter(name, exposedClusterName(path.LocalPathPort), path)
 	if err != nil {
 		return l, err
 	}
 	l.FilterChains = []envoylistener.FilterChain{
 		{
 			Filters: []envoylistener.Filter{
-				tcpProxy,
+				filter,
 			},
 		},
 	}
 	return l, nil
 }
 
+// exposedPathName returns the name of the listener of an exposed path.
+func exposedPathName(path string) string {
+	return "exposed_path_" + exposedPathNameRe.ReplaceAllString(path, "")
+}
+
+var exposedPathNameRe = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// makeExposedPathFilter returns an HTTP connection manager filter routing the
+// exact path to the cluster.
+func makeExposedPathFilter(name, cluster string, path structs.ExposePath) (envoylistener.Filter, error) {
+	cfg := map[string]interface{}{
+		"stat_prefix": name,
+		"route_config": map[string]interface{}{
+			"name": name,
+			"virtual_hosts": []interface{}{
+				map[string]interface{}{
+					"name":    name,
+					"domains": []string{"*"},
+					"routes": []interface{}{
+						map[string]interface{}{
+							"match": map[string]interface{}{"path": path.Path},
+							"route": map[string]interface{}{"cluster": cluster},
+						},
+					},
+				},
+			},
+		},
+		"http_filters": []interface{}{
+			map[string]interface{}{"name": "envoy.router"},
+		},
+	}
+	if path.Protocol == structs.ExposePathProtocolHTTP2 {
+		cfg["codec_type"] = "HTTP2"
+	}
+
+	return makeHTTPConnectionManagerFilter(cfg)
+}
+
+// makePublicHTTPFilter returns an HTTP connection manager filter authorizing
+// each request before routing it to the cluster.
+func makePublicHTTPFilter(name, cluster, protocol, token string) (envoylistener.Filter, error) {
+	// Reuse the gRPC service of the network authz filter, converted to JSON.
+	grpcServiceJSON, err := (&jsonpb.Marshaler{OrigName: true}).MarshalToString(makeExtAuthGrpcService(token))
+	if err != nil {
+		return envoylistener.Filter{}, err
+	}
+	var grpcService interface{}
+	if err := json.Unmarshal([]byte(grpcServiceJSON), &grpcService); err != nil {
+		return envoylistener.Filter{}, err
+	}
+
+	cfg := map[string]interface{}{
+		"stat_prefix": name,
+		// The paths are normalized before the L7 permissions of the
+		// intentions are matched, so that paths like "/a/../admin" or
+		// "//admin" can't get around them.
+		"normalize_path": true,
+		"merge_slashes":  true,
+		"route_config": map[string]interface{}{
+			"name": name,
+			"virtual_hosts": []interface{}{
+				map[string]interface{}{
+					"name":    name,
+					"domains": []string{"*"},
+					"routes": []interface{}{
+						map[string]interface{}{
+							"match": map[string]interface{}{"prefix": "/"},
+							"route": map[string]interface{}{"cluster": cluster},
+						},
+					},
+				},
+			},
+		},
+		"http_filters": append([]interface{}{
+			map[string]interface{}{
+				"name": "envoy.ext_authz",
+				"config": map[string]interface{}{
+					"grpc_service":       grpcService,
+					"failure_mode_allow": false,
+				},
+			},
+		}, makeHTTPRouterFilters(protocol)...),
+	}
+	if protocol == "http2" || protocol == "grpc" {
+		cfg["codec_type"] = "HTTP2"
+	}
+
+	return makeHTTPConnectionManagerFilter(cfg)
+}
+
+// makeHTTPConnectionManagerFilter returns an HTTP connection manager filter
+// with the given config. There is no vendored type for the HTTP connection
+// manager config so it's built directly in its JSON form.
+func makeHTTPConnectionManagerFilter(cfg map[string]interface{}) (envoylistener.Filter, error) {
+	cfgJSON, err := json.Marshal(cfg)
+	if err != nil {
+		return envoylistener.Filter{}, err
+	}
+	var cfgStruct types.Struct
+	if err := jsonpb.UnmarshalString(string(cfgJSON), &cfgStruct); err != nil {
+		return envoylistener.Filter{}, err
+	}
+	return envoylistener.Filter{
+		Name:   "envoy.http_connection_manager",
+		Config: &cfgStruct,
+	}, nil
+}
+
 func makeTCPProxyFilter(name, cluster string) (envoylistener.Filter, error) {
 	cfg := &envoytcp.TcpProxy{
 		StatPrefix: name,
@@ -211,27 +658,33 @@ func makeTCPProxyFilter(name, cluster string) (envoylistener.Filter, error) {
 
 func makeExtAuthFilter(token string) (envoylistener.Filter, error) {
 	cfg := &extauthz.ExtAuthz{
-		StatPrefix: "connect_authz",
-		GrpcService: &envoycore.GrpcService{
-			// Attach token header so we can authorize the callbacks. Technically
-			// authorize is not really protected data but we locked down the HTTP
-			// implementation to need service:write and since we have the token that
-			// has that it's pretty reasonable to set it up here.
-			InitialMetadata: []*envoycore.HeaderValue{
-				&envoycore.HeaderValue{
-					Key:   "x-consul-token",
-					Value: token,
-				},
+		StatPrefix:       "connect_authz",
+		GrpcService:      makeExtAuthGrpcService(token),
+		FailureModeAllow: false,
+	}
+	return makeFilter("envoy.ext_authz", cfg)
+}
+
+// makeExtAuthGrpcService returns the gRPC service of the local agent called
+// by the authz filters.
+func makeExtAuthGrpcService(token string) *envoycore.GrpcService {
+	return &envoycore.GrpcService{
+		// Attach token header so we can authorize the callbacks. Technically
+		// authorize is not really protected data but we locked down the HTTP
+		// implementation to need service:write and since we have the token that
+		// has that it's pretty reasonable to set it up here.
+		InitialMetadata: []*envoycore.HeaderValue{
+			&envoycore.HeaderValue{
+				Key:   "x-consul-token",
+				Value: token,
 			},
-			TargetSpecifier: &envoycore.GrpcService_EnvoyGrpc_{
-				EnvoyGrpc: &envoycore.GrpcService_EnvoyGrpc{
-					ClusterName: LocalAgentClusterName,
-				},
+		},
+		TargetSpecifier: &envoycore.GrpcService_EnvoyGrpc_{
+			EnvoyGrpc: &envoycore.GrpcService_EnvoyGrpc{
+				ClusterName: LocalAgentClusterName,
 			},
 		},
-		FailureModeAllow: false,
 	}
-	return makeFilter("envoy.ext_authz", cfg)
 }
 
 func makeFilter(name string, cfg proto.Message) (envoylistener.Filter, error) {
@@ -249,6 +702,12 @@ func makeFilter(name string, cfg proto.Message) (envoylistener.Filter, error) {
 }
 
 func makeCommonTLSContext(cfgSnap *proxycfg.ConfigSnapshot) *envoyauth.CommonTlsContext {
+	return makeCommonTLSContextFromLeaf(cfgSnap, cfgSnap.Leaf)
+}
+
+// makeCommonTLSContextFromLeaf returns the TLS context presenting the given
+// leaf certificate and trusting the CA roots of the snapshot.
+func makeCommonTLSContextFromLeaf(cfgSnap *proxycfg.ConfigSnapshot, leaf *structs.IssuedCert) *envoyauth.CommonTlsContext {
 	// Concatenate all the root PEMs into one.
 	// TODO(banks): verify this actually works with Envoy (docs are not clear).
 	rootPEMS := ""
@@ -265,19 +724,18 @@ func makeCommonTLSContext(cfgSnap *proxycfg.ConfigSnapshot) *envoyauth.CommonTls
 			&envoyauth.TlsCertificate{
 				CertificateChain: &envoycore.DataSource{
 					Specifier: &envoycore.DataSource_InlineString{
-						InlineString: cfgSnap.Leaf.CertPEM,
+						InlineString: leaf.CertPEM,
 					},
 				},
 				PrivateKey: &envoycore.DataSource{
 					Specifier: &envoycore.DataSource_InlineString{
-						InlineString: cfgSnap.Leaf.PrivateKeyPEM,
+						InlineString: leaf.PrivateKeyPEM,
 					},
 				},
 			},
 		},
 		ValidationContextType: &envoyauth.CommonTlsContext_ValidationContext{
 			ValidationContext: &envoyauth.CertificateValidationContext{
-				// TODO(banks): later for L7 support we may need to configure ALPN here.
 				TrustedCa: &envoycore.DataSource{
 					Specifier: &envoycore.DataSource_InlineString{
 						InlineString: rootPEMS,
@@ -0,0 +1,311 @@
+package xds
+
+import (
+	"testing"
+
+	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoyroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func Test_makeExposedPathListener(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshot(t)
+	l, err := makeExposedPathListener(snap, structs.ExposePath{
+		ListenerPort:  21500,
+		Path:          "/v1/health",
+		LocalPathPort: 8080,
+		Protocol:      structs.ExposePathProtocolHTTP2,
+	})
+	require.NoError(err)
+
+	listener := l.(*envoy.Listener)
+	require.Equal("exposed_path_v1health:0.0.0.0:21500", listener.Name)
+	require.Equal(makeAddress("0.0.0.0", 21500), listener.Address)
+
+	// No TLS nor authz filter
+	require.Len(listener.FilterChains, 1)
+	require.Nil(listener.FilterChains[0].TlsContext)
+	require.Len(listener.FilterChains[0].Filters, 1)
+
+	filter := listener.FilterChains[0].Filters[0]
+	require.Equal("envoy.http_connection_manager", filter.Name)
+	require.Equal("HTTP2", filter.Config.Fields["codec_type"].GetStringValue())
+
+	vhosts := filter.Config.Fields["route_config"].GetStructValue().Fields["virtual_hosts"].GetListValue().Values
+	require.Len(vhosts, 1)
+	routes := vhosts[0].GetStructValue().Fields["routes"].GetListValue().Values
+	require.Len(routes, 1)
+	route := routes[0].GetStructValue()
+	require.Equal("/v1/health", route.Fields["match"].GetStructValue().Fields["path"].GetStringValue())
+	require.Equal("exposed_cluster_8080", route.Fields["route"].GetStructValue().Fields["cluster"].GetStringValue())
+}
+
+func Test_makePublicListener_http(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshot(t)
+	snap.Proxy.Config["protocol"] = "gRPC"
+	l, err := makePublicListener(snap, "my-token")
+	require.NoError(err)
+
+	// The HTTP connection manager authorizes the requests itself, there's no
+	// network authz filter.
+	listener := l.(*envoy.Listener)
+	require.Len(listener.FilterChains, 1)
+	require.NotNil(listener.FilterChains[0].TlsContext)
+	require.Equal([]string{"h2"}, listener.FilterChains[0].TlsContext.CommonTlsContext.AlpnProtocols)
+	require.Len(listener.FilterChains[0].Filters, 1)
+
+	filter := listener.FilterChains[0].Filters[0]
+	require.Equal("envoy.http_connection_manager", filter.Name)
+	require.Equal("HTTP2", filter.Config.Fields["codec_type"].GetStringValue())
+	require.True(filter.Config.Fields["normalize_path"].GetBoolValue())
+	require.True(filter.Config.Fields["merge_slashes"].GetBoolValue())
+
+	// The gRPC bridge emits the stats of the gRPC methods.
+	httpFilters := filter.Config.Fields["http_filters"].GetListValue().Values
+	require.Len(httpFilters, 3)
+	authz := httpFilters[0].GetStructValue()
+	require.Equal("envoy.ext_authz", authz.Fields["name"].GetStringValue())
+	grpcService := authz.Fields["config"].GetStructValue().Fields["grpc_service"].GetStructValue()
+	require.Equal(LocalAgentClusterName,
+		grpcService.Fields["envoy_grpc"].GetStructValue().Fields["cluster_name"].GetStringValue())
+	metadata := grpcService.Fields["initial_metadata"].GetListValue().Values
+	require.Len(metadata, 1)
+	require.Equal("my-token", metadata[0].GetStructValue().Fields["value"].GetStringValue())
+	require.Equal("envoy.grpc_http1_bridge", httpFilters[1].GetStructValue().Fields["name"].GetStringValue())
+	require.Equal("envoy.router", httpFilters[2].GetStructValue().Fields["name"].GetStringValue())
+
+	vhosts := filter.Config.Fields["route_config"].GetStructValue().Fields["virtual_hosts"].GetListValue().Values
+	require.Len(vhosts, 1)
+	routes := vhosts[0].GetStructValue().Fields["routes"].GetListValue().Values
+	require.Len(routes, 1)
+	route := routes[0].GetStructValue()
+	require.Equal("/", route.Fields["match"].GetStructValue().Fields["prefix"].GetStringValue())
+	require.Equal(LocalAppClusterName, route.Fields["route"].GetStructValue().Fields["cluster"].GetStringValue())
+
+	// The local app is reached with HTTP/2
+	c, err := makeAppCluster(snap)
+	require.NoError(err)
+	require.NotNil(c.Http2ProtocolOptions)
+
+	// Unknown protocols are rejected
+	snap.Proxy.Config["protocol"] = "mongo"
+	_, err = makePublicListener(snap, "my-token")
+	require.Error(err)
+	require.Contains(err.Error(), `unsupported protocol "mongo"`)
+}
+
+func Test_makeUpstreamListener_http(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshot(t)
+	upstream := snap.Proxy.Upstreams[0]
+
+	// A TCP chain is proxied at L4
+	l, err := makeUpstreamListener(snap, &upstream)
+	require.NoError(err)
+	require.Equal("envoy.tcp_proxy", l.(*envoy.Listener).FilterChains[0].Filters[0].Name)
+
+	snap.DiscoveryChain["service:db"] = proxycfg.TestDiscoveryChain(t, "db", "http")
+	l, err = makeUpstreamListener(snap, &upstream)
+	require.NoError(err)
+
+	listener := l.(*envoy.Listener)
+	require.Len(listener.FilterChains, 1)
+	require.Len(listener.FilterChains[0].Filters, 1)
+	filter := listener.FilterChains[0].Filters[0]
+	require.Equal("envoy.http_connection_manager", filter.Name)
+	require.Empty(filter.Config.Fields["codec_type"].GetStringValue())
+
+	rds := filter.Config.Fields["rds"].GetStructValue()
+	require.Equal("service:db", rds.Fields["route_config_name"].GetStringValue())
+	require.NotNil(rds.Fields["config_source"].GetStructValue().Fields["ads"].GetStructValue())
+
+	// The route config sends everything to the upstream cluster
+	routes, err := routesFromSnapshot(snap, "")
+	require.NoError(err)
+	require.Len(routes, 1)
+	route := routes[0].(*envoy.RouteConfiguration)
+	require.Equal("service:db", route.Name)
+	require.Len(route.VirtualHosts, 1)
+	require.Len(route.VirtualHosts[0].Routes, 1)
+	action := route.VirtualHosts[0].Routes[0].Action.(*envoyroute.Route_Route)
+	require.Equal("service:db", action.Route.GetCluster())
+
+	// The HTTP/1.1 codec is negotiated with the upstream proxies.
+	c, err := makeUpstreamCluster(upstream, snap)
+	require.NoError(err)
+	require.Equal([]string{"http/1.1"}, c.TlsContext.CommonTlsContext.AlpnProtocols)
+	require.Nil(c.Http2ProtocolOptions)
+}
+
+func Test_makeUpstreamListener_protocolOverride(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshot(t)
+	snap.DiscoveryChain["service:db"] = proxycfg.TestDiscoveryChain(t, "db", "http")
+	upstream := snap.Proxy.Upstreams[0]
+	upstream.Config = map[string]interface{}{"protocol": "grpc"}
+
+	// The protocol of the upstream config wins over the one of the chain.
+	l, err := makeUpstreamListener(snap, &upstream)
+	require.NoError(err)
+	filter := l.(*envoy.Listener).FilterChains[0].Filters[0]
+	require.Equal("envoy.http_connection_manager", filter.Name)
+	require.Equal("HTTP2", filter.Config.Fields["codec_type"].GetStringValue())
+	httpFilters := filter.Config.Fields["http_filters"].GetListValue().Values
+	require.Len(httpFilters, 2)
+	require.Equal("envoy.grpc_http1_bridge", httpFilters[0].GetStructValue().Fields["name"].GetStringValue())
+	require.Equal("envoy.router", httpFilters[1].GetStructValue().Fields["name"].GetStringValue())
+
+	c, err := makeUpstreamCluster(upstream, snap)
+	require.NoError(err)
+	require.Equal([]string{"h2"}, c.TlsContext.CommonTlsContext.AlpnProtocols)
+	require.NotNil(c.Http2ProtocolOptions)
+
+	// A TCP upstream negotiates no protocol.
+	upstream.Config["protocol"] = "tcp"
+	l, err = makeUpstreamListener(snap, &upstream)
+	require.NoError(err)
+	require.Equal("envoy.tcp_proxy", l.(*envoy.Listener).FilterChains[0].Filters[0].Name)
+	c, err = makeUpstreamCluster(upstream, snap)
+	require.NoError(err)
+	require.Empty(c.TlsContext.CommonTlsContext.AlpnProtocols)
+}
+
+func Test_listenersFromSnapshot_MeshGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotMeshGateway(t)
+	td := snap.Roots.TrustDomain
+	resources, err := listenersFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Len(resources, 1)
+
+	listener := resources[0].(*envoy.Listener)
+	require.Equal("mesh_gateway:1.2.3.4:8443", listener.Name)
+	require.Len(listener.ListenerFilters, 1)
+	require.Equal("envoy.listener.tls_inspector", listener.ListenerFilters[0].Name)
+
+	// The connections are routed by SNI without terminating TLS nor
+	// authorizing them.
+	require.Len(listener.FilterChains, 3)
+	expected := []struct {
+		serverName string
+		cluster    string
+	}{
+		{"*.dc2.internal." + td, "dc2.internal." + td},
+		{"db.default.dc1.internal." + td, "db.default.dc1.internal." + td},
+		{"web.default.dc1.internal." + td, "web.default.dc1.internal." + td},
+	}
+	for i, exp := range expected {
+		chain := listener.FilterChains[i]
+		require.Nil(chain.TlsContext)
+		require.Equal([]string{exp.serverName}, chain.FilterChainMatch.ServerNames)
+		require.Len(chain.Filters, 1)
+		require.Equal("envoy.tcp_proxy", chain.Filters[0].Name)
+		require.Equal(exp.cluster, chain.Filters[0].Config.Fields["cluster"].GetStringValue())
+	}
+
+	// Without services nor other datacenters there is nothing to listen for.
+	snap.ServiceGroups = nil
+	snap.MeshGatewayEndpoints = nil
+	resources, err = listenersFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Empty(resources)
+}
+
+func Test_listenersFromSnapshot_IngressGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotIngressGateway(t)
+	resources, err := listenersFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Len(resources, 2)
+
+	// The TCP listener proxies the connections to its service, without
+	// Connect TLS nor authorization since the clients are outside the mesh.
+	tcp := resources[0].(*envoy.Listener)
+	require.Equal("ingress_upstream:1.2.3.4:9191", tcp.Name)
+	require.Len(tcp.FilterChains, 1)
+	require.Nil(tcp.FilterChains[0].TlsContext)
+	require.Len(tcp.FilterChains[0].Filters, 1)
+	require.Equal("envoy.tcp_proxy", tcp.FilterChains[0].Filters[0].Name)
+	require.Equal("service:db", tcp.FilterChains[0].Filters[0].Config.Fields["cluster"].GetStringValue())
+
+	// The HTTP listener routes the requests with the route config of its port.
+	http := resources[1].(*envoy.Listener)
+	require.Equal("ingress_upstream:1.2.3.4:8080", http.Name)
+	require.Len(http.FilterChains, 1)
+	filter := http.FilterChains[0].Filters[0]
+	require.Equal("envoy.http_connection_manager", filter.Name)
+	rds := filter.Config.Fields["rds"].GetStructValue()
+	require.Equal("ingress_upstream_8080", rds.Fields["route_config_name"].GetStringValue())
+
+	// The requests are routed by their host.
+	routes, err := routesFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Len(routes, 1)
+	route := routes[0].(*envoy.RouteConfiguration)
+	require.Equal("ingress_upstream_8080", route.Name)
+	require.Len(route.VirtualHosts, 2)
+	require.Equal([]string{
+		"web.example.com", "web.example.com:8080", "www.example.com", "www.example.com:8080",
+	}, route.VirtualHosts[0].Domains)
+	action := route.VirtualHosts[0].Routes[0].Action.(*envoyroute.Route_Route)
+	require.Equal("service:web", action.Route.GetCluster())
+	require.Equal([]string{"*"}, route.VirtualHosts[1].Domains)
+	action = route.VirtualHosts[1].Routes[0].Action.(*envoyroute.Route_Route)
+	require.Equal("service:api", action.Route.GetCluster())
+
+	// Without config entry there is nothing to listen on.
+	snap.IngressGateway = nil
+	resources, err = listenersFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Empty(resources)
+	routes, err = routesFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Empty(routes)
+}
+
+func Test_listenersFromSnapshot_TerminatingGateway(t *testing.T) {
+	require := require.New(t)
+
+	snap := proxycfg.TestConfigSnapshotTerminatingGateway(t)
+	td := snap.Roots.TrustDomain
+	resources, err := listenersFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Len(resources, 1)
+
+	listener := resources[0].(*envoy.Listener)
+	require.Equal("terminating_gateway:1.2.3.4:8443", listener.Name)
+	require.Len(listener.ListenerFilters, 1)
+	require.Equal("envoy.listener.tls_inspector", listener.ListenerFilters[0].Name)
+
+	// The connections are terminated with the leaf of the linked service
+	// they are for and authorized before being proxied to the service.
+	require.Len(listener.FilterChains, 2)
+	for i, service := range []string{"billing", "legacy"} {
+		name := service + ".default.dc1.internal." + td
+		chain := listener.FilterChains[i]
+		require.Equal([]string{name}, chain.FilterChainMatch.ServerNames)
+		require.NotNil(chain.TlsContext)
+		require.True(chain.TlsContext.RequireClientCertificate.Value)
+		require.Len(chain.Filters, 2)
+		require.Equal("envoy.ext_authz", chain.Filters[0].Name)
+		require.Equal("envoy.tcp_proxy", chain.Filters[1].Name)
+		require.Equal(name, chain.Filters[1].Config.Fields["cluster"].GetStringValue())
+	}
+
+	// Without leaf certificates there is nothing to listen for.
+	snap.ServiceLeaves = nil
+	resources, err = listenersFromSnapshot(snap, "my-token")
+	require.NoError(err)
+	require.Empty(resources)
+}
@@ -2,10 +2,14 @@ package xds
 
 import (
 	"errors"
+	"fmt"
 
+	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoyroute "github.com/envoyproxy/go-control-plane/envoy/api/v2/route"
 	"github.com/gogo/protobuf/proto"
 
 	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/structs"
 )
 
 // routesFromSnapshot returns the xDS API representation of the "routes"
@@ -14,6 +18,91 @@ func routesFromSnapshot(cfgSnap *proxycfg.ConfigSnapshot, token string) ([]proto
 	if cfgSnap == nil {
 		return nil, errors.New("nil config given")
 	}
-	// We don't support routes yet but probably will later
-	return nil, nil
+	if cfgSnap.Kind == structs.ServiceKindIngressGateway {
+		return routesFromSnapshotIngressGateway(cfgSnap)
+	}
+
+	// One route config for each upstream proxied at L7
+	var resources []proto.Message
+	for i := range cfgSnap.Proxy.Upstreams {
+		u := &cfgSnap.Proxy.Upstreams[i]
+		if _, ok := u.Config["envoy_listener_json"]; ok {
+			continue
+		}
+		if upstreamProtocol(cfgSnap, u) == "tcp" {
+			continue
+		}
+		resources = append(resources, makeUpstreamRouteConfig(u))
+	}
+	return resources, nil
+}
+
+// routesFromSnapshotIngressGateway returns one route config for each HTTP
+// listener of an ingress gateway, routing the requests to the services by
+// their Host header.
+func routesFromSnapshotIngressGateway(cfgSnap *proxycfg.ConfigSnapshot) ([]proto.Message, error) {
+	if cfgSnap.IngressGateway == nil {
+		return nil, nil
+	}
+
+	var resources []proto.Message
+	for _, listener := range cfgSnap.IngressGateway.Listeners {
+		if listener.Protocol != structs.IngressListenerProtocolHTTP {
+			continue
+		}
+		rc := &envoy.RouteConfiguration{
+			Name: ingressRouteName(listener.Port),
+		}
+		for _, svc := range listener.Services {
+			// The Host header may include the port of the listener.
+			var domains []string
+			for _, host := range svc.ServiceHosts() {
+				domains = append(domains, host)
+				if host != structs.IngressWildcardHost {
+					domains = append(domains, fmt.Sprintf("%s:%d", host, listener.Port))
+				}
+			}
+			rc.VirtualHosts = append(rc.VirtualHosts,
+				makePrefixVirtualHost(svc.Name, domains, ingressUpstreamID(svc.Name)))
+		}
+		resources = append(resources, rc)
+	}
+	return resources, nil
+}
+
+// makeUpstreamRouteConfig returns the route config sending all the requests of
+// the upstream listener to the upstream cluster.
+func makeUpstreamRouteConfig(u *structs.Upstream) *envoy.RouteConfiguration {
+	name := u.Identifier()
+	return &envoy.RouteConfiguration{
+		Name: name,
+		VirtualHosts: []envoyroute.VirtualHost{
+			makePrefixVirtualHost(name, []string{"*"}, name),
+		},
+	}
+}
+
+// makePrefixVirtualHost returns a virtual host sending all the requests for
+// the domains to the cluster.
+func makePrefixVirtualHost(name string, domains []string, cluster string) envoyroute.VirtualHost {
+	return envoyroute.VirtualHost{
+		Name:    name,
+		Domains: domains,
+		Routes: []envoyroute.Route{
+			{
+				Match: envoyroute.RouteMatch{
+					PathSpecifier: &envoyroute.RouteMatch_Prefix{
+						Prefix: "/",
+					},
+				},
+				Action: &envoyroute.Route_Route{
+					Route: &envoyroute.RouteAction{
+						ClusterSpecifier: &envoyroute.RouteAction_Cluster{
+							Cluster: cluster,
+						},
+					},
+				},
+			},
+		},
+	}
 }
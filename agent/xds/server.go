@@ -2,9 +2,12 @@ package xds
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"log"
+	"path"
+	"strings"
 	"sync/atomic"
 	"time"
 
@@ -50,6 +53,19 @@ const (
 	// PublicListenerName is the name we give the public listener in Envoy config.
 	PublicListenerName = "public_listener"
 
+	// MeshGatewayListenerName is the name we give the listener of a mesh
+	// gateway in Envoy config.
+	MeshGatewayListenerName = "mesh_gateway"
+
+	// IngressListenerName is the name we give the listeners of an ingress
+	// gateway in Envoy config, and the prefix of the names of the route
+	// configs of its HTTP listeners.
+	IngressListenerName = "ingress_upstream"
+
+	// TerminatingGatewayListenerName is the name we give the listener of a
+	// terminating gateway in Envoy config.
+	TerminatingGatewayListenerName = "terminating_gateway"
+
 	// LocalAppClusterName is the name we give the local application "cluster" in
 	// Envoy config.
 	LocalAppClusterName = "local_app"
@@ -214,7 +230,13 @@ func (s *Server) process(stream ADSStream, reqCh <-chan *envoy.DiscoveryRequest)
 			return err
 		}
 
-		if rule != nil && !rule.ServiceWrite(cfgSnap.Proxy.DestinationServiceName, nil) {
+		// A gateway isn't the proxy of another service, it's authorized with
+		// its own service name.
+		service := cfgSnap.Proxy.DestinationServiceName
+		if cfgSnap.Kind == structs.ServiceKindMeshGateway || cfgSnap.Kind.IsGateway() {
+			service = cfgSnap.Service
+		}
+		if rule != nil && !rule.ServiceWrite(service, nil) {
 			return status.Errorf(codes.PermissionDenied, "permission denied")
 		}
 
@@ -448,6 +470,19 @@ func (s *Server) Check(ctx context.Context, r *envoyauthz.CheckRequest) (*envoya
 		// TODO(banks): need Envoy to support sending cert serial/hash to enforce
 		// revocation later.
 	}
+	// The HTTP authz filter also sends the request, allowing the L7
+	// permissions of the intentions to be enforced.
+	if httpReq := r.Attributes.GetRequest().GetHttp(); httpReq != nil {
+		path := httpReq.Path
+		if idx := strings.IndexByte(path, '?'); idx >= 0 {
+			path = path[:idx]
+		}
+		req.HTTP = &structs.ConnectAuthorizeHTTPRequest{
+			Method: httpReq.Method,
+			Path:   normalizeHTTPPath(path),
+			Header: httpReq.Headers,
+		}
+	}
 	token := tokenFromContext(ctx)
 	authed, reason, _, err := s.Authz.ConnectAuthorize(token, req)
 	if err != nil {
@@ -468,18 +503,79 @@ func (s *Server) Check(ctx context.Context, r *envoyauthz.CheckRequest) (*envoya
 	}, nil
 }
 
+// normalizeHTTPPath normalizes the path of a request before the L7
+// permissions of the intentions are matched against it, in case Envoy didn't
+// already: the percent-encoded unreserved characters are decoded, backslashes
+// are turned into slashes, consecutive slashes are merged and the dot
+// segments are removed.
+func normalizeHTTPPath(p string) string {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		if c == '%' && i+2 < len(p) {
+			if d, ok := unhex(p[i+1], p[i+2]); ok && isUnreserved(d) {
+				c = d
+				i += 2
+			}
+		}
+		if c == '\\' {
+			c = '/'
+		}
+		b.WriteByte(c)
+	}
+
+	trailing := strings.HasSuffix(b.String(), "/")
+	cleaned := path.Clean("/" + b.String())
+	if trailing && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// unhex decodes the two given hex digits.
+func unhex(hi, lo byte) (byte, bool) {
+	h, ok := hexDigit(hi)
+	if !ok {
+		return 0, false
+	}
+	l, ok := hexDigit(lo)
+	if !ok {
+		return 0, false
+	}
+	return h<<4 | l, true
+}
+
+func hexDigit(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// isUnreserved returns whether c is an unreserved character of RFC 3986,
+// whose percent-encoding is equivalent to the character itself.
+func isUnreserved(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return c == '-' || c == '.' || c == '_' || c == '~'
+}
+
 // GRPCServer returns a server instance that can handle XDS and ext_authz
-// requests.
-func (s *Server) GRPCServer(certFile, keyFile string) (*grpc.Server, error) {
+// requests. The connections are served over TLS when a TLS configuration is
+// given.
+func (s *Server) GRPCServer(tlsConfig *tls.Config) (*grpc.Server, error) {
 	opts := []grpc.ServerOption{
 		grpc.MaxConcurrentStreams(2048),
 	}
-	if certFile != "" && keyFile != "" {
-		creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
-		if err != nil {
-			return nil, err
-		}
-		opts = append(opts, grpc.Creds(creds))
+	if tlsConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
 	}
 	srv := grpc.NewServer(opts...)
 	envoydisco.RegisterAggregatedDiscoveryServiceServer(srv, s)
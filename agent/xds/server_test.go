@@ -15,6 +15,7 @@ import (
 	"time"
 
 	envoy "github.com/envoyproxy/go-control-plane/envoy/api/v2"
+	envoyauthz "github.com/envoyproxy/go-control-plane/envoy/service/auth/v2alpha"
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc/codes"
@@ -1013,6 +1014,104 @@ func TestServer_Check(t *testing.T) {
 	}
 }
 
+// connectAuthzFunc implements ConnectAuthz with a function.
+type connectAuthzFunc func(token string, req *structs.ConnectAuthorizeRequest) (bool, string, *cache.ResultMeta, error)
+
+func (f connectAuthzFunc) ConnectAuthorize(token string, req *structs.ConnectAuthorizeRequest) (bool, string, *cache.ResultMeta, error) {
+	return f(token, req)
+}
+
+func TestServer_Check_HTTP(t *testing.T) {
+	var got *structs.ConnectAuthorizeRequest
+	s := Server{
+		Logger: log.New(os.Stderr, "", log.LstdFlags),
+		Authz: connectAuthzFunc(func(token string, req *structs.ConnectAuthorizeRequest) (bool, string, *cache.ResultMeta, error) {
+			got = req
+			return true, "allowed", nil, nil
+		}),
+	}
+
+	r := TestCheckRequest(t, "web", "db")
+	r.Attributes.Request = &envoyauthz.AttributeContext_Request{
+		Http: &envoyauthz.AttributeContext_HttpRequest{
+			Method:  "GET",
+			Path:    "/v1/users?limit=10",
+			Headers: map[string]string{"x-tenant": "a"},
+		},
+	}
+	resp, err := s.Check(context.Background(), r)
+	require.NoError(t, err)
+	require.Equal(t, int32(codes.OK), resp.Status.Code)
+
+	require.Equal(t, "db", got.Target)
+	require.Equal(t, &structs.ConnectAuthorizeHTTPRequest{
+		Method: "GET",
+		Path:   "/v1/users",
+		Header: map[string]string{"x-tenant": "a"},
+	}, got.HTTP)
+}
+
+func TestServer_Check_HTTPPathTraversal(t *testing.T) {
+	// Deny everything under /admin, allow the rest.
+	perms := []*structs.IntentionPermission{
+		{
+			Action: structs.IntentionActionDeny,
+			HTTP:   &structs.IntentionHTTPPermission{PathPrefix: "/admin"},
+		},
+	}
+	s := Server{
+		Logger: log.New(os.Stderr, "", log.LstdFlags),
+		Authz: connectAuthzFunc(func(token string, req *structs.ConnectAuthorizeRequest) (bool, string, *cache.ResultMeta, error) {
+			for _, perm := range perms {
+				if perm.Matches(req.HTTP) {
+					return false, "denied", nil, nil
+				}
+			}
+			return true, "allowed", nil, nil
+		}),
+	}
+
+	for _, path := range []string{
+		"/admin",
+		"/public/../admin",
+		"/public/%2e%2e/admin",
+		"//admin",
+		"/./admin/",
+		"/%61dmin",
+		"/public\\..\\admin",
+	} {
+		t.Run(path, func(t *testing.T) {
+			r := TestCheckRequest(t, "web", "db")
+			r.Attributes.Request = &envoyauthz.AttributeContext_Request{
+				Http: &envoyauthz.AttributeContext_HttpRequest{Method: "GET", Path: path},
+			}
+			resp, err := s.Check(context.Background(), r)
+			require.NoError(t, err)
+			require.Equal(t, int32(codes.PermissionDenied), resp.Status.Code)
+		})
+	}
+}
+
+func TestNormalizeHTTPPath(t *testing.T) {
+	cases := map[string]string{
+		"":              "/",
+		"/":             "/",
+		"/a/b":          "/a/b",
+		"/a/b/":         "/a/b/",
+		"/a/../b":       "/b",
+		"/../../b":      "/b",
+		"a//b///c":      "/a/b/c",
+		"/%7Euser/%2Fx": "/~user/%2Fx",
+		"/a%2E%2e/b":    "/a../b",
+		"/a\\b":         "/a/b",
+		"/trailing%":    "/trailing%",
+		"/bad%zzescape": "/bad%zzescape",
+	}
+	for in, want := range cases {
+		require.Equal(t, want, normalizeHTTPPath(in), "path %q", in)
+	}
+}
+
 func TestServer_ConfigOverridesListeners(t *testing.T) {
 
 	tests := []struct {
@@ -27,10 +27,14 @@ type ACLToken struct {
 	AccessorID  string
 	SecretID    string
 	Description string
+	Namespace   string `json:",omitempty"`
+	Partition   string `json:",omitempty"`
+	NamePrefix  string `json:",omitempty"`
 	Policies    []*ACLTokenPolicyLink
 	Local       bool
-	CreateTime  time.Time `json:",omitempty"`
-	Hash        []byte    `json:",omitempty"`
+	Labels      map[string]string `json:",omitempty"`
+	CreateTime  time.Time         `json:",omitempty"`
+	Hash        []byte            `json:",omitempty"`
 
 	// DEPRECATED (ACL-Legacy-Compat)
 	// Rules will only be present for legacy tokens returned via the new APIs
@@ -42,8 +46,12 @@ type ACLTokenListEntry struct {
 	ModifyIndex uint64
 	AccessorID  string
 	Description string
+	Namespace   string `json:",omitempty"`
+	Partition   string `json:",omitempty"`
+	NamePrefix  string `json:",omitempty"`
 	Policies    []*ACLTokenPolicyLink
 	Local       bool
+	Labels      map[string]string `json:",omitempty"`
 	CreateTime  time.Time
 	Hash        []byte
 	Legacy      bool
@@ -77,8 +85,18 @@ type ACLPolicy struct {
 	ID          string
 	Name        string
 	Description string
+	Namespace   string `json:",omitempty"`
+	Partition   string `json:",omitempty"`
 	Rules       string
 	Datacenters []string
+
+	// DeleteAfter is the time after which the policy is deleted. The
+	// policy stops granting access at that time. Nil means never.
+	DeleteAfter *time.Time `json:",omitempty"`
+
+	// Labels are arbitrary key/value pairs organizing the policies.
+	Labels map[string]string `json:",omitempty"`
+
 	Hash        []byte
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -88,7 +106,11 @@ type ACLPolicyListEntry struct {
 	ID          string
 	Name        string
 	Description string
+	Namespace   string `json:",omitempty"`
+	Partition   string `json:",omitempty"`
 	Datacenters []string
+	DeleteAfter *time.Time        `json:",omitempty"`
+	Labels      map[string]string `json:",omitempty"`
 	Hash        []byte
 	CreateIndex uint64
 	ModifyIndex uint64
@@ -520,8 +542,25 @@ func (a *ACL) PolicyRead(policyID string, q *QueryOptions) (*ACLPolicy, *QueryMe
 // PolicyList retrieves a listing of all policies. The listing does not include the
 // rules for any policy as those should be retrieved by subsequent calls to PolicyRead.
 func (a *ACL) PolicyList(q *QueryOptions) ([]*ACLPolicyListEntry, *QueryMeta, error) {
+	return a.policyList(0, q)
+}
+
+// PolicyListExpiring retrieves a listing of the policies that will be deleted
+// within the given duration, including the expired ones that were not deleted
+// yet.
+func (a *ACL) PolicyListExpiring(within time.Duration, q *QueryOptions) ([]*ACLPolicyListEntry, *QueryMeta, error) {
+	if within <= 0 {
+		return nil, nil, fmt.Errorf("The duration must be positive")
+	}
+	return a.policyList(within, q)
+}
+
+func (a *ACL) policyList(expiresWithin time.Duration, q *QueryOptions) ([]*ACLPolicyListEntry, *QueryMeta, error) {
 	r := a.c.newRequest("GET", "/v1/acl/policies")
 	r.setQueryOptions(q)
+	if expiresWithin > 0 {
+		r.params.Set("expires-within", expiresWithin.String())
+	}
 	rtt, resp, err := requireOK(a.c.doRequest(r))
 	if err != nil {
 		return nil, nil, err
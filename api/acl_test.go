@@ -3,6 +3,7 @@ package api
 import (
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 
@@ -376,6 +377,48 @@ func prepTokenPolicies(t *testing.T, acl *ACL) (policies []*ACLPolicy) {
 	return
 }
 
+func TestAPI_ACLPolicy_DeleteAfter(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+
+	deleteAfter := time.Now().Add(time.Hour)
+	created, _, err := acl.PolicyCreate(&ACLPolicy{
+		Name:        "temporary",
+		Rules:       `key_prefix "" { policy = "write" }`,
+		DeleteAfter: &deleteAfter,
+	}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, created.DeleteAfter)
+	require.True(t, deleteAfter.Equal(*created.DeleteAfter))
+
+	_, _, err = acl.PolicyCreate(&ACLPolicy{
+		Name:  "permanent",
+		Rules: `key_prefix "" { policy = "read" }`,
+	}, nil)
+	require.NoError(t, err)
+
+	policies, _, err := acl.PolicyListExpiring(2*time.Hour, nil)
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	require.Equal(t, created.ID, policies[0].ID)
+	require.NotNil(t, policies[0].DeleteAfter)
+
+	policies, _, err = acl.PolicyListExpiring(time.Minute, nil)
+	require.NoError(t, err)
+	require.Empty(t, policies)
+
+	// The deletion time can't be in the past
+	past := time.Now().Add(-time.Hour)
+	_, _, err = acl.PolicyCreate(&ACLPolicy{
+		Name:        "expired",
+		DeleteAfter: &past,
+	}, nil)
+	require.Error(t, err)
+}
+
 func TestAPI_ACLToken_CreateReadDelete(t *testing.T) {
 	t.Parallel()
 	c, s := makeACLClient(t)
@@ -622,6 +665,84 @@ func TestAPI_ACLToken_Clone(t *testing.T) {
 	require.Equal(t, cloned, read)
 }
 
+func TestAPI_ACL_Namespaces(t *testing.T) {
+	t.Parallel()
+	c, s := makeACLClient(t)
+	defer s.Stop()
+
+	acl := c.ACL()
+	teamA := &WriteOptions{Namespace: "team-a"}
+
+	// The same policy name can be used in different namespaces.
+	defaultPolicy, _, err := acl.PolicyCreate(&ACLPolicy{
+		Name:  "reader",
+		Rules: `node_prefix "" { policy = "read" }`,
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, "default", defaultPolicy.Namespace)
+
+	teamPolicy, _, err := acl.PolicyCreate(&ACLPolicy{
+		Name:  "reader",
+		Rules: `service_prefix "" { policy = "read" }`,
+	}, teamA)
+	require.NoError(t, err)
+	require.Equal(t, "team-a", teamPolicy.Namespace)
+
+	// Policy names are resolved within the namespace of the token.
+	token, _, err := acl.TokenCreate(&ACLToken{
+		Policies: []*ACLTokenPolicyLink{&ACLTokenPolicyLink{Name: "reader"}},
+	}, teamA)
+	require.NoError(t, err)
+	require.Equal(t, "team-a", token.Namespace)
+	require.Len(t, token.Policies, 1)
+	require.Equal(t, teamPolicy.ID, token.Policies[0].ID)
+
+	// Policies of other namespaces can't be linked.
+	_, _, err = acl.TokenCreate(&ACLToken{
+		Policies: []*ACLTokenPolicyLink{&ACLTokenPolicyLink{ID: defaultPolicy.ID}},
+	}, teamA)
+	require.Error(t, err)
+
+	// Objects of other namespaces aren't visible.
+	_, _, err = acl.TokenRead(token.AccessorID, nil)
+	require.Error(t, err)
+	read, _, err := acl.TokenRead(token.AccessorID, &QueryOptions{Namespace: "team-a"})
+	require.NoError(t, err)
+	require.Equal(t, token.AccessorID, read.AccessorID)
+
+	policies, _, err := acl.PolicyList(&QueryOptions{Namespace: "team-a"})
+	require.NoError(t, err)
+	require.Len(t, policies, 1)
+	require.Equal(t, teamPolicy.ID, policies[0].ID)
+
+	tokens, _, err := acl.TokenList(&QueryOptions{Namespace: "team-a"})
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	require.Equal(t, token.AccessorID, tokens[0].AccessorID)
+
+	tokens, _, err = acl.TokenList(nil)
+	require.NoError(t, err)
+	for _, entry := range tokens {
+		require.NotEqual(t, token.AccessorID, entry.AccessorID)
+	}
+
+	policies, _, err = acl.PolicyList(&QueryOptions{Namespace: "*"})
+	require.NoError(t, err)
+	require.Len(t, policies, 3)
+
+	// The namespace of the client config is used by default.
+	nsClient, err := NewClient(&Config{
+		Address:   s.HTTPAddr,
+		Token:     "root",
+		Namespace: "team-a",
+	})
+	require.NoError(t, err)
+	_, err = nsClient.ACL().TokenDelete(token.AccessorID, nil)
+	require.NoError(t, err)
+	_, _, err = acl.TokenRead(token.AccessorID, &QueryOptions{Namespace: "team-a"})
+	require.Error(t, err)
+}
+
 func TestAPI_RulesTranslate_FromToken(t *testing.T) {
 	t.Parallel()
 	c, s := makeACLClient(t)
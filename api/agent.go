@@ -7,6 +7,8 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"time"
 )
 
 // ServiceKind is the kind of service being registered.
@@ -23,8 +25,46 @@ const (
 	// service proxies another service within Consul and speaks the connect
 	// protocol.
 	ServiceKindConnectProxy ServiceKind = "connect-proxy"
+
+	// ServiceKindIngressGateway is a gateway letting the traffic from
+	// outside the mesh reach the Connect services listed as its upstreams.
+	ServiceKindIngressGateway ServiceKind = "ingress-gateway"
+
+	// ServiceKindTerminatingGateway is a gateway letting the Connect
+	// services reach the services outside the mesh listed as its upstreams.
+	ServiceKindTerminatingGateway ServiceKind = "terminating-gateway"
+
+	// ServiceKindMeshGateway is a gateway routing the Connect traffic between
+	// datacenters.
+	ServiceKindMeshGateway ServiceKind = "mesh-gateway"
 )
 
+// MeshGatewayMode is how a proxy reaches the upstreams in other datacenters.
+type MeshGatewayMode string
+
+const (
+	// MeshGatewayModeDefault defers the mode to the next level of
+	// configuration.
+	MeshGatewayModeDefault MeshGatewayMode = ""
+
+	// MeshGatewayModeNone connects directly to the instances of the upstream.
+	MeshGatewayModeNone MeshGatewayMode = "none"
+
+	// MeshGatewayModeLocal connects through a mesh gateway of the local
+	// datacenter.
+	MeshGatewayModeLocal MeshGatewayMode = "local"
+
+	// MeshGatewayModeRemote connects through a mesh gateway of the upstream
+	// datacenter.
+	MeshGatewayModeRemote MeshGatewayMode = "remote"
+)
+
+// MeshGatewayConfig controls how the upstreams in other datacenters are
+// reached through the mesh gateways.
+type MeshGatewayConfig struct {
+	Mode MeshGatewayMode `json:",omitempty"`
+}
+
 // ProxyExecMode is the execution mode for a managed Connect proxy.
 type ProxyExecMode string
 
@@ -80,19 +120,29 @@ type AgentService struct {
 	Service           string
 	Tags              []string
 	Meta              map[string]string
+	Labels            map[string]string `json:",omitempty"`
 	Port              int
 	Address           string
+	TaggedAddresses   map[string]ServiceAddress `json:",omitempty"`
 	Weights           AgentWeights
 	EnableTagOverride bool
 	CreateIndex       uint64 `json:",omitempty"`
 	ModifyIndex       uint64 `json:",omitempty"`
 	ContentHash       string `json:",omitempty"`
+	Partition         string `json:",omitempty"`
 	// DEPRECATED (ProxyDestination) - remove this field
 	ProxyDestination string                          `json:",omitempty"`
 	Proxy            *AgentServiceConnectProxyConfig `json:",omitempty"`
 	Connect          *AgentServiceConnect            `json:",omitempty"`
 }
 
+// ServiceAddress is an address of a service, such as its WAN address in the
+// TaggedAddresses of the service.
+type ServiceAddress struct {
+	Address string
+	Port    int
+}
+
 // AgentServiceChecksInfo returns information about a Service and its checks
 type AgentServiceChecksInfo struct {
 	AggregatedStatus string
@@ -125,6 +175,37 @@ type AgentServiceConnectProxyConfig struct {
 	LocalServicePort       int                    `json:",omitempty"`
 	Config                 map[string]interface{} `json:",omitempty"`
 	Upstreams              []Upstream
+	Expose                 ExposeConfig      `json:",omitempty"`
+	MeshGateway            MeshGatewayConfig `json:",omitempty"`
+}
+
+// ExposeConfig describes the HTTP paths of a service instance exposed
+// through its proxy without Connect.
+type ExposeConfig struct {
+	// Checks defines whether the paths of the HTTP checks of the service
+	// instance are exposed.
+	Checks bool `json:",omitempty"`
+
+	// Paths are the paths exposed through the proxy.
+	Paths []ExposePath `json:",omitempty"`
+}
+
+// ExposePath is a path of a service instance exposed through its proxy.
+type ExposePath struct {
+	// ListenerPort is the port the proxy listens on for the path.
+	ListenerPort int `json:",omitempty"`
+
+	// Path is the exact path to expose, such as "/metrics".
+	Path string `json:",omitempty"`
+
+	// LocalPathPort is the port the service instance serves the path on.
+	LocalPathPort int `json:",omitempty"`
+
+	// Protocol is the protocol of the listener, http or http2.
+	Protocol string `json:",omitempty"`
+
+	// ParsedFromCheck is set if the path was exposed from an HTTP check.
+	ParsedFromCheck bool `json:",omitempty"`
 }
 
 // AgentMember represents a cluster member known to the agent
@@ -153,19 +234,34 @@ type MembersOpts struct {
 	// Segment is the LAN segment to show members for. Setting this to the
 	// AllSegments value above will show members in all segments.
 	Segment string
+
+	// Since only returns the members that changed after the given members
+	// index, as returned by MembersPage. Removed members are returned with
+	// a status of 0. All the current members are returned instead when the
+	// changes since the index are no longer known, see MembersMeta.Reset.
+	Since uint64
+
+	// Limit is the maximum number of members to return, sorted by name.
+	Limit int
+
+	// After only returns the members with a name sorted after the given
+	// one, to page through the members along with Limit.
+	After string
 }
 
 // AgentServiceRegistration is used to register a new service
 type AgentServiceRegistration struct {
-	Kind              ServiceKind       `json:",omitempty"`
-	ID                string            `json:",omitempty"`
-	Name              string            `json:",omitempty"`
-	Tags              []string          `json:",omitempty"`
-	Port              int               `json:",omitempty"`
-	Address           string            `json:",omitempty"`
-	EnableTagOverride bool              `json:",omitempty"`
-	Meta              map[string]string `json:",omitempty"`
-	Weights           *AgentWeights     `json:",omitempty"`
+	Kind              ServiceKind               `json:",omitempty"`
+	ID                string                    `json:",omitempty"`
+	Name              string                    `json:",omitempty"`
+	Tags              []string                  `json:",omitempty"`
+	Port              int                       `json:",omitempty"`
+	Address           string                    `json:",omitempty"`
+	TaggedAddresses   map[string]ServiceAddress `json:",omitempty"`
+	EnableTagOverride bool                      `json:",omitempty"`
+	Meta              map[string]string         `json:",omitempty"`
+	Labels            map[string]string         `json:",omitempty"`
+	Weights           *AgentWeights             `json:",omitempty"`
 	Check             *AgentServiceCheck
 	Checks            AgentServiceChecks
 	// DEPRECATED (ProxyDestination) - remove this field
@@ -174,6 +270,39 @@ type AgentServiceRegistration struct {
 	Connect          *AgentServiceConnect            `json:",omitempty"`
 }
 
+// ServiceRegisterOpts are the options of ServiceRegisterOpts.
+type ServiceRegisterOpts struct {
+	// Deadline makes the registration provisional: if the checks of the
+	// service don't all pass within the deadline, the agent deregisters the
+	// service and its sidecar, and reports the rollback through
+	// ProvisionalServices. The service must have at least one check.
+	Deadline time.Duration
+}
+
+const (
+	// ProvisionalServicePending is the status of the provisional services
+	// whose checks didn't all pass yet.
+	ProvisionalServicePending = "pending"
+
+	// ProvisionalServiceRolledBack is the status of the provisional services
+	// deregistered since their checks didn't pass before the deadline.
+	ProvisionalServiceRolledBack = "rolled-back"
+)
+
+// AgentProvisionalService is a service registered with a deadline for its
+// checks to pass, either still pending or rolled back.
+type AgentProvisionalService struct {
+	ServiceID   string
+	ServiceName string
+	SidecarID   string `json:",omitempty"`
+	Deadline    time.Time
+	Status      string
+
+	// Output describes the checks that were not passing when the service
+	// was rolled back.
+	Output string `json:",omitempty"`
+}
+
 // AgentCheckRegistration is used to register a new check
 type AgentCheckRegistration struct {
 	ID        string `json:",omitempty"`
@@ -197,13 +326,19 @@ type AgentServiceCheck struct {
 	Header            map[string][]string `json:",omitempty"`
 	Method            string              `json:",omitempty"`
 	TCP               string              `json:",omitempty"`
+	TCPUseTLS         bool                `json:",omitempty"`
 	Status            string              `json:",omitempty"`
 	Notes             string              `json:",omitempty"`
 	TLSSkipVerify     bool                `json:",omitempty"`
+	TLSServerName     string              `json:",omitempty"`
+	TLSCAFile         string              `json:",omitempty"`
+	TLSCertFile       string              `json:",omitempty"`
+	TLSKeyFile        string              `json:",omitempty"`
 	GRPC              string              `json:",omitempty"`
 	GRPCUseTLS        bool                `json:",omitempty"`
 	AliasNode         string              `json:",omitempty"`
 	AliasService      string              `json:",omitempty"`
+	Composite         string              `json:",omitempty"`
 
 	// In Consul 0.7 and later, checks that are associated with a service
 	// may also contain this optional DeregisterCriticalServiceAfter field,
@@ -220,6 +355,56 @@ type AgentToken struct {
 	Token string
 }
 
+// AgentLogLevels are the log levels of an agent: the default level, and the
+// levels of the subsystems which don't log at the default level.
+type AgentLogLevels struct {
+	Level      string
+	Subsystems map[string]string
+}
+
+// AgentLogLevelRequest is used when changing the log level of an agent, or
+// the level of one of its subsystems when Subsystem is set.
+type AgentLogLevelRequest struct {
+	Subsystem string
+	Level     string
+}
+
+// AgentDNSRecursor is the health of a recursor of the agent's DNS interface.
+type AgentDNSRecursor struct {
+	Address string
+
+	// Healthy is false when the recursor failed several times in a row
+	// recently, in which case it's only queried after the healthy ones.
+	Healthy bool
+
+	Successes           uint64
+	Failures            uint64
+	ConsecutiveFailures uint64
+
+	// AverageRTT is the moving average of the round trip time of the
+	// successful queries.
+	AverageRTT time.Duration
+
+	LastSuccess time.Time
+	LastFailure time.Time
+	LastError   string
+}
+
+// AutoEncryptStatus is the status of the certificate of the RPC connections
+// which a client agent requests from the servers with auto-encrypt.
+type AutoEncryptStatus struct {
+	Enabled bool
+
+	// SerialNumber, ValidAfter and ValidBefore describe the current
+	// certificate. They are empty until the first request succeeded.
+	SerialNumber string
+	ValidAfter   time.Time
+	ValidBefore  time.Time
+
+	// LastError is the error of the last request, if it failed.
+	LastError string
+}
+
 // Metrics info is used to store different types of metric values from the agent.
 type MetricsInfo struct {
 	Timestamp string
@@ -261,6 +446,18 @@ type AgentAuthorizeParams struct {
 	Target           string
 	ClientCertURI    string
 	ClientCertSerial string
+
+	// HTTP holds the attributes of the request when authorizing an HTTP
+	// request, required to be allowed by an intention with permissions.
+	HTTP *AgentAuthorizeHTTPParams `json:",omitempty"`
+}
+
+// AgentAuthorizeHTTPParams are the attributes of an HTTP request being
+// authorized.
+type AgentAuthorizeHTTPParams struct {
+	Method string
+	Path   string
+	Header map[string]string `json:",omitempty"`
 }
 
 // AgentAuthorize is the response structure for Connect authorization.
@@ -293,6 +490,7 @@ type Upstream struct {
 	LocalBindAddress     string                 `json:",omitempty"`
 	LocalBindPort        int                    `json:",omitempty"`
 	Config               map[string]interface{} `json:",omitempty"`
+	MeshGateway          MeshGatewayConfig      `json:",omitempty"`
 }
 
 // Agent can be used to query the Agent endpoints
@@ -360,6 +558,40 @@ func (a *Agent) Metrics() (*MetricsInfo, error) {
 	return out, nil
 }
 
+// DNSRecursors returns the health of the recursors of the agent's DNS
+// interface, in their configured order.
+func (a *Agent) DNSRecursors() ([]*AgentDNSRecursor, error) {
+	r := a.c.newRequest("GET", "/v1/agent/dns/recursors")
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out []*AgentDNSRecursor
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AutoEncryptStatus returns the status of the certificate of the RPC
+// connections of the agent issued with auto-encrypt.
+func (a *Agent) AutoEncryptStatus() (*AutoEncryptStatus, error) {
+	r := a.c.newRequest("GET", "/v1/agent/auto-encrypt")
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out AutoEncryptStatus
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 // Reload triggers a configuration reload for the agent we are connected to.
 func (a *Agent) Reload() error {
 	r := a.c.newRequest("PUT", "/v1/agent/reload")
@@ -371,6 +603,71 @@ func (a *Agent) Reload() error {
 	return nil
 }
 
+// ReloadResult reports the fields of the agent's configuration which changed
+// with a configuration reload.
+type ReloadResult struct {
+	// Applied are the changed fields the reload applied to the agent.
+	Applied []string
+
+	// RestartRequired are the changed fields which only take effect when
+	// the agent restarts.
+	RestartRequired []string
+}
+
+// ReloadWithResult triggers a configuration reload for the agent we are
+// connected to, and returns which changes were applied and which require a
+// restart of the agent.
+func (a *Agent) ReloadWithResult() (*ReloadResult, error) {
+	r := a.c.newRequest("PUT", "/v1/agent/reload")
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out ReloadResult
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// LogLevels returns the log levels of the agent we are connected to.
+func (a *Agent) LogLevels() (*AgentLogLevels, error) {
+	r := a.c.newRequest("GET", "/v1/agent/loglevel")
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out AgentLogLevels
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetLogLevel changes the log level of the agent we are connected to, or the
+// level of one of its subsystems, such as "raft", when subsystem isn't empty.
+// An empty level makes the subsystem log at the default level again. The
+// levels are reset to the ones of the configuration when it's reloaded.
+func (a *Agent) SetLogLevel(subsystem, level string) (*AgentLogLevels, error) {
+	r := a.c.newRequest("PUT", "/v1/agent/loglevel")
+	r.obj = &AgentLogLevelRequest{Subsystem: subsystem, Level: level}
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out AgentLogLevels
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
 // NodeName is used to get the node name of the agent
 func (a *Agent) NodeName() (string, error) {
 	if a.nodeName != "" {
@@ -418,6 +715,45 @@ func (a *Agent) Services() (map[string]*AgentService, error) {
 	return out, nil
 }
 
+// ServicesWithLabels returns the locally registered services whose labels
+// match the label selector, such as `team=payments,env!=dev`.
+func (a *Agent) ServicesWithLabels(selector string) (map[string]*AgentService, error) {
+	r := a.c.newRequest("GET", "/v1/agent/services")
+	r.params.Set("label-selector", selector)
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out map[string]*AgentService
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// ServicesFiltered returns the locally registered services matching the
+// filter expression, such as `Kind == "connect-proxy"`. The filter is
+// evaluated by the agent so the other services are never sent.
+func (a *Agent) ServicesFiltered(filter string) (map[string]*AgentService, error) {
+	r := a.c.newRequest("GET", "/v1/agent/services")
+	r.params.Set("filter", filter)
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out map[string]*AgentService
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
 // AgentHealthServiceByID returns for a given serviceID: the aggregated health status, the service definition or an error if any
 // - If the service is not found, will return status (critical, nil, nil)
 // - If the service is found, will return (critical|passing|warning), AgentServiceChecksInfo, nil)
@@ -535,23 +871,66 @@ func (a *Agent) Members(wan bool) ([]*AgentMember, error) {
 // MembersOpts returns the known gossip members and can be passed
 // additional options for WAN/segment filtering.
 func (a *Agent) MembersOpts(opts MembersOpts) ([]*AgentMember, error) {
+	out, _, err := a.MembersPage(opts)
+	return out, err
+}
+
+// MembersMeta is returned along with the members listed by MembersPage.
+type MembersMeta struct {
+	// Index is the members index of the agent, which can be passed as the
+	// Since option to only fetch the members that changed afterwards. The
+	// index is local to the agent.
+	Index uint64
+
+	// Reset is true when all the current members were returned rather than
+	// the changes since the given index, either because no index was given
+	// or because the changes since then are no longer known. The members
+	// known from previous listings must then be replaced.
+	Reset bool
+}
+
+// MembersPage returns the known gossip members along with the members index
+// of the agent, which can be passed as the Since option to only fetch the
+// members that changed afterwards.
+func (a *Agent) MembersPage(opts MembersOpts) ([]*AgentMember, *MembersMeta, error) {
 	r := a.c.newRequest("GET", "/v1/agent/members")
 	r.params.Set("segment", opts.Segment)
 	if opts.WAN {
 		r.params.Set("wan", "1")
 	}
+	if opts.Since > 0 {
+		r.params.Set("since", strconv.FormatUint(opts.Since, 10))
+	}
+	if opts.Limit > 0 {
+		r.params.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.After != "" {
+		r.params.Set("after", opts.After)
+	}
 
 	_, resp, err := requireOK(a.c.doRequest(r))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer resp.Body.Close()
 
+	meta := &MembersMeta{Reset: true}
+	if raw := resp.Header.Get("X-Consul-Members-Index"); raw != "" {
+		if meta.Index, err = strconv.ParseUint(raw, 10, 64); err != nil {
+			return nil, nil, fmt.Errorf("Failed to parse X-Consul-Members-Index: %v", err)
+		}
+	}
+	if raw := resp.Header.Get("X-Consul-Members-Reset"); raw != "" {
+		if meta.Reset, err = strconv.ParseBool(raw); err != nil {
+			return nil, nil, fmt.Errorf("Failed to parse X-Consul-Members-Reset: %v", err)
+		}
+	}
+
 	var out []*AgentMember
 	if err := decodeBody(resp, &out); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return out, nil
+	return out, meta, nil
 }
 
 // ServiceRegister is used to register a new service with
@@ -567,6 +946,40 @@ func (a *Agent) ServiceRegister(service *AgentServiceRegistration) error {
 	return nil
 }
 
+// ServiceRegisterOpts is used to register a new service with the local agent
+// like ServiceRegister, with additional options.
+func (a *Agent) ServiceRegisterOpts(service *AgentServiceRegistration, opts ServiceRegisterOpts) error {
+	r := a.c.newRequest("PUT", "/v1/agent/service/register")
+	r.obj = service
+	if opts.Deadline != 0 {
+		r.params.Set("deadline", opts.Deadline.String())
+	}
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// ProvisionalServices returns the services registered with a deadline that
+// are still waiting for their checks to pass, and the recent ones that were
+// rolled back, ordered by deadline.
+func (a *Agent) ProvisionalServices() ([]*AgentProvisionalService, error) {
+	r := a.c.newRequest("GET", "/v1/agent/service/provisional")
+	_, resp, err := requireOK(a.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out []*AgentProvisionalService
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ServiceDeregister is used to deregister a service with
 // the local agent
 func (a *Agent) ServiceDeregister(serviceID string) error {
@@ -780,9 +1193,12 @@ func (a *Agent) ConnectCARoots(q *QueryOptions) (*CARootList, *QueryMeta, error)
 	return &out, qm, nil
 }
 
-// ConnectCALeaf gets the leaf certificate for the given service ID.
-func (a *Agent) ConnectCALeaf(serviceID string, q *QueryOptions) (*LeafCert, *QueryMeta, error) {
-	r := a.c.newRequest("GET", "/v1/agent/connect/ca/leaf/"+serviceID)
+// ConnectCALeaf gets the leaf certificate for the given service name. The
+// agent caches it and renews it in the background. With a WaitIndex set, the
+// query blocks until the certificate is renewed, so a proxy can reload it on
+// rotation without querying the servers.
+func (a *Agent) ConnectCALeaf(serviceName string, q *QueryOptions) (*LeafCert, *QueryMeta, error) {
+	r := a.c.newRequest("GET", "/v1/agent/connect/ca/leaf/"+serviceName)
 	r.setQueryOptions(q)
 	rtt, resp, err := requireOK(a.c.doRequest(r))
 	if err != nil {
@@ -880,15 +1296,56 @@ func (a *Agent) DisableNodeMaintenance() error {
 	return nil
 }
 
+// MonitorOptions are the options of a log stream from the agent.
+type MonitorOptions struct {
+	// LogLevel is the minimum level of the streamed logs, "info" if empty.
+	LogLevel string
+
+	// LogJSON streams the logs as JSON objects, one per line, which can be
+	// decoded into an AgentLogEvent.
+	LogJSON bool
+
+	// Subsystems restricts the stream to the logs of the given subsystems of
+	// the agent, such as "raft" or "dns".
+	Subsystems []string
+}
+
+// AgentLogEvent is a log line streamed by the agent with LogJSON set.
+type AgentLogEvent struct {
+	Timestamp string `json:"@timestamp"`
+	Level     string `json:"@level"`
+	Module    string `json:"@module,omitempty"`
+	Message   string `json:"@message"`
+}
+
 // Monitor returns a channel which will receive streaming logs from the agent
 // Providing a non-nil stopCh can be used to close the connection and stop the
 // log stream. An empty string will be sent down the given channel when there's
 // nothing left to stream, after which the caller should close the stopCh.
 func (a *Agent) Monitor(loglevel string, stopCh <-chan struct{}, q *QueryOptions) (chan string, error) {
+	return a.MonitorWithOptions(&MonitorOptions{LogLevel: loglevel}, stopCh, q)
+}
+
+// MonitorJSON is like Monitor except it streams the logs as JSON objects.
+func (a *Agent) MonitorJSON(loglevel string, stopCh <-chan struct{}, q *QueryOptions) (chan string, error) {
+	return a.MonitorWithOptions(&MonitorOptions{LogLevel: loglevel, LogJSON: true}, stopCh, q)
+}
+
+// MonitorWithOptions is like Monitor except it takes the options of the log
+// stream, such as its format and the subsystems to stream the logs of.
+func (a *Agent) MonitorWithOptions(opts *MonitorOptions, stopCh <-chan struct{}, q *QueryOptions) (chan string, error) {
 	r := a.c.newRequest("GET", "/v1/agent/monitor")
 	r.setQueryOptions(q)
-	if loglevel != "" {
-		r.params.Add("loglevel", loglevel)
+	if opts != nil {
+		if opts.LogLevel != "" {
+			r.params.Add("loglevel", opts.LogLevel)
+		}
+		if opts.LogJSON {
+			r.params.Set("logjson", "true")
+		}
+		for _, subsystem := range opts.Subsystems {
+			r.params.Add("subsystem", subsystem)
+		}
 	}
 	_, resp, err := requireOK(a.c.doRequest(r))
 	if err != nil {
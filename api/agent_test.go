@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -59,6 +60,30 @@ func TestAPI_AgentMetrics(t *testing.T) {
 	})
 }
 
+func TestAPI_AgentDNSRecursors(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	// The test server has no recursors.
+	recursors, err := c.Agent().DNSRecursors()
+	require.NoError(t, err)
+	require.NotNil(t, recursors)
+	require.Len(t, recursors, 0)
+}
+
+func TestAPI_AgentAutoEncryptStatus(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	// The test server doesn't request a certificate.
+	status, err := c.Agent().AutoEncryptStatus()
+	require.NoError(t, err)
+	require.False(t, status.Enabled)
+	require.Empty(t, status.SerialNumber)
+}
+
 func TestAPI_AgentHost(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -127,6 +152,64 @@ func TestAPI_AgentReload(t *testing.T) {
 	}
 }
 
+func TestAPI_AgentReloadWithResult(t *testing.T) {
+	t.Parallel()
+
+	cfgDir := testutil.TempDir(t, "consul-config")
+	defer os.RemoveAll(cfgDir)
+
+	cfgFilePath := filepath.Join(cfgDir, "reload.json")
+	configFile, err := os.Create(cfgFilePath)
+	if err != nil {
+		t.Fatalf("Unable to create file %v, got error:%v", cfgFilePath, err)
+	}
+
+	c, s := makeClientWithConfig(t, nil, func(conf *testutil.TestServerConfig) {
+		conf.Args = []string{"-config-file", configFile.Name()}
+	})
+	defer s.Stop()
+
+	agent := c.Agent()
+
+	// Add a service, which is applied, and change the DNS node TTL, which
+	// requires a restart.
+	config := `{"service":{"name":"redis", "port":1234}, "dns_config":{"node_ttl":"10s"}}`
+	if err := ioutil.WriteFile(configFile.Name(), []byte(config), 0644); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	result, err := agent.ReloadWithResult()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	require.Contains(t, result.Applied, "Services")
+	require.Contains(t, result.RestartRequired, "DNSNodeTTL")
+}
+
+func TestAPI_AgentLogLevels(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+
+	levels, err := agent.SetLogLevel("raft", "trace")
+	require.NoError(t, err)
+	require.Equal(t, "TRACE", levels.Subsystems["raft"])
+
+	levels, err = agent.LogLevels()
+	require.NoError(t, err)
+	require.Equal(t, "TRACE", levels.Subsystems["raft"])
+
+	// An empty level resets the level of the subsystem.
+	levels, err = agent.SetLogLevel("raft", "")
+	require.NoError(t, err)
+	require.Empty(t, levels.Subsystems)
+
+	_, err = agent.SetLogLevel("", "bogus")
+	require.Error(t, err)
+}
+
 func TestAPI_AgentMembersOpts(t *testing.T) {
 	t.Parallel()
 	c, s1 := makeClient(t)
@@ -150,6 +233,69 @@ func TestAPI_AgentMembersOpts(t *testing.T) {
 	}
 }
 
+func TestAPI_AgentMembersPage(t *testing.T) {
+	t.Parallel()
+	c, s1 := makeClient(t)
+	_, s2 := makeClientWithConfig(t, nil, func(c *testutil.TestServerConfig) {
+		c.Datacenter = "dc2"
+	})
+	defer s1.Stop()
+	defer s2.Stop()
+
+	agent := c.Agent()
+
+	s2.JoinWAN(t, s1.WANAddr)
+
+	var index uint64
+	retry.Run(t, func(r *retry.R) {
+		members, meta, err := agent.MembersPage(MembersOpts{WAN: true})
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(members) != 2 || !meta.Reset {
+			r.Fatalf("bad: %v %v", members, meta)
+		}
+		index = meta.Index
+	})
+	if index == 0 {
+		t.Fatalf("bad index")
+	}
+
+	// Page through the members by name
+	first, _, err := agent.MembersPage(MembersOpts{WAN: true, Limit: 1})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(first) != 1 {
+		t.Fatalf("bad: %v", first)
+	}
+	second, _, err := agent.MembersPage(MembersOpts{WAN: true, Limit: 1, After: first[0].Name})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(second) != 1 || second[0].Name <= first[0].Name {
+		t.Fatalf("bad: %v", second)
+	}
+
+	// Nothing changed since the last index
+	changed, meta, err := agent.MembersPage(MembersOpts{WAN: true, Since: index})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(changed) != 0 || meta.Reset {
+		t.Fatalf("bad: %v %v", changed, meta)
+	}
+
+	// An index from before the agent started resets the members
+	changed, meta, err = agent.MembersPage(MembersOpts{WAN: true, Since: 1})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(changed) != 2 || !meta.Reset {
+		t.Fatalf("bad: %v %v", changed, meta)
+	}
+}
+
 func TestAPI_AgentMembers(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -167,6 +313,48 @@ func TestAPI_AgentMembers(t *testing.T) {
 	}
 }
 
+func TestAPI_AgentServiceRegisterOpts_Deadline(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+	s.WaitForSerfCheck(t)
+
+	reg := &AgentServiceRegistration{
+		Name: "foo",
+		Port: 8000,
+		Check: &AgentServiceCheck{
+			TTL: "15s",
+		},
+	}
+	err := agent.ServiceRegisterOpts(reg, ServiceRegisterOpts{Deadline: 100 * time.Millisecond})
+	require.NoError(t, err)
+
+	// The TTL check never passes so the service is rolled back
+	retry.Run(t, func(r *retry.R) {
+		services, err := agent.Services()
+		if err != nil {
+			r.Fatal(err)
+		}
+		if _, ok := services["foo"]; ok {
+			r.Fatalf("service should be deregistered")
+		}
+	})
+
+	list, err := agent.ProvisionalServices()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, "foo", list[0].ServiceID)
+	require.Equal(t, ProvisionalServiceRolledBack, list[0].Status)
+	require.Contains(t, list[0].Output, "service:foo")
+
+	// A deadline requires checks
+	reg.Check = nil
+	err = agent.ServiceRegisterOpts(reg, ServiceRegisterOpts{Deadline: time.Minute})
+	require.Error(t, err)
+}
+
 func TestAPI_AgentServices(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -180,6 +368,9 @@ func TestAPI_AgentServices(t *testing.T) {
 		ID:   "foo",
 		Tags: []string{"bar", "baz"},
 		Port: 8000,
+		TaggedAddresses: map[string]ServiceAddress{
+			"wan": {Address: "198.18.0.1", Port: 80},
+		},
 		Check: &AgentServiceCheck{
 			TTL: "15s",
 		},
@@ -195,6 +386,7 @@ func TestAPI_AgentServices(t *testing.T) {
 	if _, ok := services["foo"]; !ok {
 		t.Fatalf("missing service: %#v", services)
 	}
+	require.Equal(t, reg.TaggedAddresses, services["foo"].TaggedAddresses)
 	checks, err := agent.Checks()
 	if err != nil {
 		t.Fatalf("err: %v", err)
@@ -231,6 +423,58 @@ func TestAPI_AgentServices(t *testing.T) {
 	}
 }
 
+func TestAPI_AgentServicesFiltered(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+	s.WaitForSerfCheck(t)
+
+	for _, reg := range []*AgentServiceRegistration{
+		{Name: "foo", Tags: []string{"bar"}, Port: 8000},
+		{Name: "baz", Tags: []string{"qux"}, Port: 9000},
+	} {
+		require.NoError(t, agent.ServiceRegister(reg))
+	}
+
+	services, err := agent.ServicesFiltered(`"bar" in Tags`)
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	require.Equal(t, 8000, services["foo"].Port)
+
+	_, err = agent.ServicesFiltered(`Unknown == "x"`)
+	require.Error(t, err)
+}
+
+func TestAPI_AgentServicesWithLabels(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+	s.WaitForSerfCheck(t)
+
+	for _, reg := range []*AgentServiceRegistration{
+		{Name: "foo", Port: 8000, Labels: map[string]string{"team": "payments"}},
+		{Name: "baz", Port: 9000, Labels: map[string]string{"team": "search"}},
+	} {
+		require.NoError(t, agent.ServiceRegister(reg))
+	}
+
+	services, err := agent.ServicesWithLabels("team=payments")
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	require.Equal(t, 8000, services["foo"].Port)
+	require.Equal(t, map[string]string{"team": "payments"}, services["foo"].Labels)
+
+	_, err = agent.ServicesWithLabels("team=pay ments")
+	require.Error(t, err)
+
+	// Invalid labels are rejected on registration
+	err = agent.ServiceRegister(&AgentServiceRegistration{Name: "bad", Labels: map[string]string{"": "x"}})
+	require.Error(t, err)
+}
 func TestAPI_AgentServices_ManagedConnectProxy(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -719,7 +963,7 @@ func TestAPI_AgentService(t *testing.T) {
 		ID:          "foo",
 		Service:     "foo",
 		Tags:        []string{"bar", "baz"},
-		ContentHash: "ad8c7a278470d1e8",
+		ContentHash: "def3173cb913ac3a",
 		Port:        8000,
 		Weights: AgentWeights{
 			Passing: 1,
@@ -1145,6 +1389,39 @@ func TestAPI_AgentMonitor(t *testing.T) {
 	}
 }
 
+func TestAPI_AgentMonitorWithOptions(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	logCh, err := agent.MonitorWithOptions(&MonitorOptions{
+		LogLevel:   "debug",
+		LogJSON:    true,
+		Subsystems: []string{"raft"},
+	}, stopCh, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Wait for the first log message and validate it
+	select {
+	case log := <-logCh:
+		var event AgentLogEvent
+		if err := json.Unmarshal([]byte(log), &event); err != nil {
+			t.Fatalf("bad: %q: %v", log, err)
+		}
+		if event.Module != "raft" || event.Level == "" || event.Timestamp == "" {
+			t.Fatalf("bad: %#v", event)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("failed to get a log message")
+	}
+}
+
 func TestAPI_ServiceMaintenance(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -1489,7 +1766,7 @@ func TestAPI_AgentConnectProxyConfig(t *testing.T) {
 		ProxyServiceID:    "foo-proxy",
 		TargetServiceID:   "foo",
 		TargetServiceName: "foo",
-		ContentHash:       "acdf5eb6f5794a14",
+		ContentHash:       "b58a7e24130d3058",
 		ExecMode:          "daemon",
 		Command:           []string{"consul", "connect", "proxy"},
 		Config: map[string]interface{}{
@@ -5,6 +5,7 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -67,6 +68,20 @@ const (
 	// client in this package but is defined here for consistency with all the
 	// other ENV names we use.
 	GRPCAddrEnvName = "CONSUL_GRPC_ADDR"
+
+	// HTTPNamespaceEnvName defines an environment variable name which sets
+	// the ACL namespace used by default for the requests.
+	HTTPNamespaceEnvName = "CONSUL_NAMESPACE"
+
+	// HTTPPartitionEnvName defines an environment variable name which sets
+	// the admin partition used by default for the requests.
+	HTTPPartitionEnvName = "CONSUL_PARTITION"
+
+	// APIBootstrapFileEnvName defines an environment variable name which sets
+	// the path of a bootstrap file written by the local agent. The settings
+	// it holds are used as defaults and can be overridden by the other
+	// environment variables.
+	APIBootstrapFileEnvName = "CONSUL_API_BOOTSTRAP_FILE"
 )
 
 // QueryOptions are used to parameterize a query
@@ -75,6 +90,13 @@ type QueryOptions struct {
 	// by the Config
 	Datacenter string
 
+	// Namespace overwrites the ACL namespace provided by the Config. It is
+	// used by the ACL token and policy endpoints.
+	Namespace string
+
+	// Partition overwrites the admin partition provided by the Config.
+	Partition string
+
 	// AllowStale allows any Consul server (non-leader) to service
 	// a read. This allows for lower latency and higher throughput
 	AllowStale bool
@@ -84,6 +106,12 @@ type QueryOptions struct {
 	// read.
 	RequireConsistent bool
 
+	// RequireKnownLeader fails the read with ErrNoKnownLeader when the
+	// server that served it reports that there is no known leader, instead
+	// of returning a possibly arbitrarily stale result. This is mostly
+	// useful along with AllowStale.
+	RequireKnownLeader bool
+
 	// UseCache requests that the agent cache results locally. See
 	// https://www.consul.io/api/index.html#agent-caching for more details on the
 	// semantics.
@@ -105,6 +133,14 @@ type QueryOptions struct {
 	// https://www.consul.io/api/index.html#agent-caching for more details.
 	StaleIfError time.Duration
 
+	// UseClientCache serves the read from a cache kept in the client, which is
+	// refreshed in the background with blocking queries so that repeated reads
+	// don't have to wait on the agent. Results may be slightly stale, and
+	// QueryMeta.CacheHit and CacheAge are set like for UseCache. It is ignored
+	// for blocking queries, if RequireConsistent is set or if the endpoint
+	// doesn't support blocking queries. See Config.ClientCacheTTL.
+	UseClientCache bool
+
 	// WaitIndex is used to enable a blocking query. Waits
 	// until the timeout or the next index is reached
 	WaitIndex uint64
@@ -139,10 +175,27 @@ type QueryOptions struct {
 	// a value from 0 to 5 (inclusive).
 	RelayFactor uint8
 
+	// LocalOnly is used in keyring list operations to only query the LAN
+	// pool of the local datacenter, without any WAN traffic.
+	LocalOnly bool
+
 	// Connect filters prepared query execution to only include Connect-capable
 	// services. This currently affects prepared query execution.
 	Connect bool
 
+	// Filter is a boolean expression selecting the results to return, such
+	// as `Flags == 42 and Value contains "prod"`. It is evaluated by the
+	// servers so the other results are never sent over the network. This
+	// currently affects recursive KV reads with KV.List.
+	Filter string
+
+	// LabelSelector selects the results by their labels, such as
+	// `team=payments,env!=dev`. It is evaluated by the servers like Filter.
+	// This currently affects the listing of the ACL tokens and policies, the
+	// services of the agent, the service instances of the catalog and health
+	// endpoints, and recursive KV reads with KV.List.
+	LabelSelector string
+
 	// ctx is an optional context pass through to the underlying HTTP
 	// request layer. Use Context() and WithContext() to manage this.
 	ctx context.Context
@@ -170,6 +223,13 @@ type WriteOptions struct {
 	// by the Config
 	Datacenter string
 
+	// Namespace overwrites the ACL namespace provided by the Config. It is
+	// used by the ACL token and policy endpoints.
+	Namespace string
+
+	// Partition overwrites the admin partition provided by the Config.
+	Partition string
+
 	// Token is used to provide a per-request ACL token
 	// which overrides the agent's default token.
 	Token string
@@ -218,6 +278,11 @@ type QueryMeta struct {
 	// Is there a known leader
 	KnownLeader bool
 
+	// EffectiveConsistency is the consistency mode the read was served
+	// with, "leader", "consistent" or "stale". It is empty for endpoints
+	// that aren't served by the servers.
+	EffectiveConsistency string
+
 	// How long did the request take
 	RequestTime time.Duration
 
@@ -230,8 +295,27 @@ type QueryMeta struct {
 	// CacheAge is set if request was ?cached and indicates how stale the cached
 	// response is.
 	CacheAge time.Duration
+
+	// ServedBy and ServedByID are the node name and ID of the server that
+	// served the request. For results served from the agent cache, this is
+	// the server that served the cached result.
+	ServedBy   string
+	ServedByID string
+
+	// ServedByRole is QueryServedByLeader or QueryServedByFollower depending
+	// on the role of the server that served the request, or
+	// QueryServedByCache if the result was served from the agent cache.
+	ServedByRole string
 }
 
+const (
+	// QueryServedByLeader, QueryServedByFollower and QueryServedByCache are
+	// the values of QueryMeta.ServedByRole.
+	QueryServedByLeader   = "leader"
+	QueryServedByFollower = "follower"
+	QueryServedByCache    = "cache"
+)
+
 // WriteMeta is used to return meta data about a write
 type WriteMeta struct {
 	// How long did the request take
@@ -258,6 +342,15 @@ type Config struct {
 	// Datacenter to use. If not provided, the default agent datacenter is used.
 	Datacenter string
 
+	// Namespace is the ACL namespace the ACL token and policy requests
+	// operate on. If not provided, the default namespace is used.
+	Namespace string
+
+	// Partition is the admin partition to target in partitioned clusters.
+	// If not provided, the partition of the agent is used. Agents without
+	// partition support ignore it.
+	Partition string
+
 	// Transport is the Transport to use for the http client.
 	Transport *http.Transport
 
@@ -265,6 +358,34 @@ type Config struct {
 	// used if not provided.
 	HttpClient *http.Client
 
+	// DialContext, if set, is used by the default Transport to open the
+	// connections to the agent instead of dialing Address over TCP, for
+	// example to use an in-memory listener in tests. Address is still used
+	// to build the request URLs. It is ignored if HttpClient is set or if
+	// Address uses the unix:// scheme.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// ClientCacheTTL is how long a response cached with
+	// QueryOptions.UseClientCache keeps being refreshed in the background
+	// after it was last read. Defaults to DefaultClientCacheTTL.
+	ClientCacheTTL time.Duration
+
+	// MaxConcurrentRequests caps the number of requests the client sends to
+	// the agent at the same time, extra requests wait for one to complete.
+	// Blocking queries and streamed responses hold their slot until the
+	// response body is closed. Zero means no limit.
+	MaxConcurrentRequests int
+
+	// RateLimit caps the number of requests per second sent to the agent,
+	// with bursts of up to RateBurst requests. Zero means no limit.
+	RateLimit float64
+	RateBurst int
+
+	// MaxQueuedRequests caps the number of requests waiting on
+	// MaxConcurrentRequests and RateLimit, extra requests fail with
+	// ErrRequestDropped. Zero means no limit.
+	MaxQueuedRequests int
+
 	// HttpAuth is the auth info to use for http access.
 	HttpAuth *HttpBasicAuth
 
@@ -330,8 +451,6 @@ func DefaultNonPooledConfig() *Config {
 // given function to make the transport.
 func defaultConfig(transportFn func() *http.Transport) *Config {
 	config := &Config{
-		Address:   "127.0.0.1:8500",
-		Scheme:    "http",
 		Transport: transportFn(),
 	}
 
@@ -343,6 +462,14 @@ func defaultConfig(transportFn func() *http.Transport) *Config {
 		config.Token = token
 	}
 
+	if ns := os.Getenv(HTTPNamespaceEnvName); ns != "" {
+		config.Namespace = ns
+	}
+
+	if partition := os.Getenv(HTTPPartitionEnvName); partition != "" {
+		config.Partition = partition
+	}
+
 	if auth := os.Getenv(HTTPAuthEnvName); auth != "" {
 		var username, password string
 		if strings.Contains(auth, ":") {
@@ -395,6 +522,23 @@ func defaultConfig(transportFn func() *http.Transport) *Config {
 		}
 	}
 
+	if path := os.Getenv(APIBootstrapFileEnvName); path != "" {
+		bf, err := ReadBootstrapFile(path)
+		if err == nil {
+			err = bf.apply(config)
+		}
+		if err != nil {
+			log.Printf("[WARN] client: could not load %s: %s", APIBootstrapFileEnvName, err)
+		}
+	}
+
+	if config.Address == "" {
+		config.Address = "127.0.0.1:8500"
+	}
+	if config.Scheme == "" {
+		config.Scheme = "http"
+	}
+
 	return config
 }
 
@@ -465,6 +609,14 @@ func (c *Config) GenerateEnv() []string {
 // Client provides a client to the Consul API
 type Client struct {
 	config Config
+
+	// clientCache holds the responses of reads made with
+	// QueryOptions.UseClientCache.
+	clientCache *clientCache
+
+	// limiter enforces the request limits of the config, it is nil if
+	// there are none.
+	limiter *requestLimiter
 }
 
 // NewClient returns a new client
@@ -509,6 +661,13 @@ func NewClient(config *Config) (*Client, error) {
 	}
 
 	if config.HttpClient == nil {
+		if config.DialContext != nil {
+			// The transport may be shared with other clients, so the
+			// dialer is only set on a copy of it.
+			config.Transport = cloneTransport(config.Transport)
+			config.Transport.DialContext = config.DialContext
+		}
+
 		var err error
 		config.HttpClient, err = NewHttpClient(config.Transport, config.TLSConfig)
 		if err != nil {
@@ -524,9 +683,11 @@ func NewClient(config *Config) (*Client, error) {
 		case "https":
 			config.Scheme = "https"
 		case "unix":
+			socket := parts[1]
 			trans := cleanhttp.DefaultTransport()
-			trans.DialContext = func(_ context.Context, _, _ string) (net.Conn, error) {
-				return net.Dial("unix", parts[1])
+			trans.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
 			}
 			config.HttpClient = &http.Client{
 				Transport: trans,
@@ -541,7 +702,44 @@ func NewClient(config *Config) (*Client, error) {
 		config.Token = defConfig.Token
 	}
 
-	return &Client{config: *config}, nil
+	return &Client{
+		config:      *config,
+		clientCache: newClientCache(),
+		limiter:     newRequestLimiter(config),
+	}, nil
+}
+
+// cloneTransport returns a copy of the transport, without its idle
+// connections. The fields are copied by hand since http.Transport.Clone
+// requires Go 1.13.
+func cloneTransport(t *http.Transport) *http.Transport {
+	clone := &http.Transport{
+		Proxy:                  t.Proxy,
+		DialContext:            t.DialContext,
+		Dial:                   t.Dial,
+		DialTLS:                t.DialTLS,
+		TLSHandshakeTimeout:    t.TLSHandshakeTimeout,
+		DisableKeepAlives:      t.DisableKeepAlives,
+		DisableCompression:     t.DisableCompression,
+		MaxIdleConns:           t.MaxIdleConns,
+		MaxIdleConnsPerHost:    t.MaxIdleConnsPerHost,
+		MaxConnsPerHost:        t.MaxConnsPerHost,
+		IdleConnTimeout:        t.IdleConnTimeout,
+		ResponseHeaderTimeout:  t.ResponseHeaderTimeout,
+		ExpectContinueTimeout:  t.ExpectContinueTimeout,
+		ProxyConnectHeader:     t.ProxyConnectHeader,
+		MaxResponseHeaderBytes: t.MaxResponseHeaderBytes,
+	}
+	if t.TLSClientConfig != nil {
+		clone.TLSClientConfig = t.TLSClientConfig.Clone()
+	}
+	if t.TLSNextProto != nil {
+		clone.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper, len(t.TLSNextProto))
+		for proto, fn := range t.TLSNextProto {
+			clone.TLSNextProto[proto] = fn
+		}
+	}
+	return clone
 }
 
 // NewHttpClient returns an http client configured with the given Transport and TLS
@@ -582,6 +780,14 @@ type request struct {
 	header http.Header
 	obj    interface{}
 	ctx    context.Context
+
+	// useClientCache is set when the response can be served from the
+	// client cache.
+	useClientCache bool
+
+	// requireKnownLeader is set when the response must come from a server
+	// with a known leader.
+	requireKnownLeader bool
 }
 
 // setQueryOptions is used to annotate the request with
@@ -593,6 +799,12 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.Datacenter != "" {
 		r.params.Set("dc", q.Datacenter)
 	}
+	if q.Namespace != "" {
+		r.params.Set("ns", q.Namespace)
+	}
+	if q.Partition != "" {
+		r.params.Set("partition", q.Partition)
+	}
 	if q.AllowStale {
 		r.params.Set("stale", "")
 	}
@@ -622,9 +834,18 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 	if q.RelayFactor != 0 {
 		r.params.Set("relay-factor", strconv.Itoa(int(q.RelayFactor)))
 	}
+	if q.LocalOnly {
+		r.params.Set("local-only", "true")
+	}
 	if q.Connect {
 		r.params.Set("connect", "true")
 	}
+	if q.Filter != "" {
+		r.params.Set("filter", q.Filter)
+	}
+	if q.LabelSelector != "" {
+		r.params.Set("label-selector", q.LabelSelector)
+	}
 	if q.UseCache && !q.RequireConsistent {
 		r.params.Set("cached", "")
 
@@ -639,6 +860,10 @@ func (r *request) setQueryOptions(q *QueryOptions) {
 			r.header.Set("Cache-Control", strings.Join(cc, ", "))
 		}
 	}
+	if q.UseClientCache && q.WaitIndex == 0 && q.WaitHash == "" && !q.RequireConsistent {
+		r.useClientCache = true
+	}
+	r.requireKnownLeader = q.RequireKnownLeader
 	r.ctx = q.ctx
 }
 
@@ -654,11 +879,16 @@ func durToMsec(dur time.Duration) string {
 	return fmt.Sprintf("%dms", ms)
 }
 
+// ErrNoKnownLeader is returned for the reads made with
+// QueryOptions.RequireKnownLeader when the server that served them has no
+// known leader.
+var ErrNoKnownLeader = errors.New("no known leader")
+
 // serverError is a string we look for to detect 500 errors.
 const serverError = "Unexpected response code: 500"
 
-// IsRetryableError returns true for 500 errors from the Consul servers, and
-// network connection errors. These are usually retryable at a later time.
+// IsRetryableError returns true for 500 errors from the Consul servers,
+// ErrNoKnownLeader and network connection errors. These are usually retryable at a later time.
 // This applies to reads but NOT to writes. This may return true for errors
 // on writes that may have still gone through, so do not use this to retry
 // any write operations.
@@ -671,6 +901,11 @@ func IsRetryableError(err error) bool {
 		return true
 	}
 
+	// A leader is usually elected again shortly.
+	if err == ErrNoKnownLeader {
+		return true
+	}
+
 	// TODO (slackpad) - Make a real error type here instead of using
 	// a string check.
 	return strings.Contains(err.Error(), serverError)
@@ -685,6 +920,12 @@ func (r *request) setWriteOptions(q *WriteOptions) {
 	if q.Datacenter != "" {
 		r.params.Set("dc", q.Datacenter)
 	}
+	if q.Namespace != "" {
+		r.params.Set("ns", q.Namespace)
+	}
+	if q.Partition != "" {
+		r.params.Set("partition", q.Partition)
+	}
 	if q.Token != "" {
 		r.header.Set("X-Consul-Token", q.Token)
 	}
@@ -746,6 +987,12 @@ func (c *Client) newRequest(method, path string) *request {
 	if c.config.Datacenter != "" {
 		r.params.Set("dc", c.config.Datacenter)
 	}
+	if c.config.Namespace != "" {
+		r.params.Set("ns", c.config.Namespace)
+	}
+	if c.config.Partition != "" {
+		r.params.Set("partition", c.config.Partition)
+	}
 	if c.config.WaitTime != 0 {
 		r.params.Set("wait", durToMsec(r.config.WaitTime))
 	}
@@ -757,16 +1004,48 @@ func (c *Client) newRequest(method, path string) *request {
 
 // doRequest runs a request with our client
 func (c *Client) doRequest(r *request) (time.Duration, *http.Response, error) {
+	var rtt time.Duration
+	var resp *http.Response
+	var err error
+	if r.useClientCache && r.method == "GET" && c.clientCache != nil {
+		rtt, resp, err = c.clientCache.get(c, r)
+	} else {
+		rtt, resp, err = c.sendRequest(r)
+	}
+	if err == nil && r.requireKnownLeader && resp.Header.Get("X-Consul-KnownLeader") == "false" {
+		resp.Body.Close()
+		return rtt, nil, ErrNoKnownLeader
+	}
+	return rtt, resp, err
+}
+
+// sendRequest runs the request against the agent, bypassing the client cache.
+func (c *Client) sendRequest(r *request) (time.Duration, *http.Response, error) {
 	req, err := r.toHTTP()
 	if err != nil {
 		return 0, nil, err
 	}
+	release, err := c.limiter.acquire(r.ctx)
+	if err != nil {
+		return 0, nil, err
+	}
 	start := time.Now()
 	resp, err := c.config.HttpClient.Do(req)
 	diff := time.Since(start)
+	if err != nil {
+		release()
+		return diff, resp, err
+	}
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
 	return diff, resp, err
 }
 
+// RequestStats returns the counters of the requests limited by
+// Config.MaxConcurrentRequests and Config.RateLimit.
+func (c *Client) RequestStats() RequestStats {
+	return c.limiter.stats()
+}
+
 // Query is used to do a GET request against an endpoint
 // and deserialize the response into an interface using
 // standard Consul conventions.
@@ -842,6 +1121,8 @@ func parseQueryMeta(resp *http.Response, q *QueryMeta) error {
 		q.KnownLeader = false
 	}
 
+	q.EffectiveConsistency = header.Get("X-Consul-Effective-Consistency")
+
 	// Parse X-Consul-Translate-Addresses
 	switch header.Get("X-Consul-Translate-Addresses") {
 	case "true":
@@ -862,6 +1143,14 @@ func parseQueryMeta(resp *http.Response, q *QueryMeta) error {
 		q.CacheAge = time.Duration(age) * time.Second
 	}
 
+	// Parse the server that served the request
+	q.ServedBy = header.Get("X-Consul-Served-By")
+	q.ServedByID = header.Get("X-Consul-Served-By-ID")
+	q.ServedByRole = header.Get("X-Consul-Served-By-Role")
+	if q.CacheHit {
+		q.ServedByRole = QueryServedByCache
+	}
+
 	return nil
 }
 
@@ -1,20 +1,25 @@
 package api
 
 import (
+	"context"
 	crand "crypto/rand"
 	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/go-cleanhttp"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -113,6 +118,8 @@ func TestAPI_DefaultConfig_env(t *testing.T) {
 	defer os.Setenv(HTTPTLSServerName, "")
 	os.Setenv(HTTPSSLVerifyEnvName, "0")
 	defer os.Setenv(HTTPSSLVerifyEnvName, "")
+	os.Setenv(HTTPPartitionEnvName, "part1")
+	defer os.Setenv(HTTPPartitionEnvName, "")
 
 	for i, config := range []*Config{DefaultConfig(), DefaultNonPooledConfig()} {
 		if config.Address != addr {
@@ -121,6 +128,9 @@ func TestAPI_DefaultConfig_env(t *testing.T) {
 		if config.Token != token {
 			t.Errorf("expected %q to be %q", config.Token, token)
 		}
+		if config.Partition != "part1" {
+			t.Errorf("expected %q to be %q", config.Partition, "part1")
+		}
 		if config.HttpAuth == nil {
 			t.Fatalf("expected HttpAuth to be enabled")
 		}
@@ -165,6 +175,62 @@ func TestAPI_DefaultConfig_env(t *testing.T) {
 	}
 }
 
+func TestAPI_DefaultConfig_bootstrapFile(t *testing.T) {
+	// t.Parallel() // DO NOT ENABLE !!!
+	// do not enable t.Parallel for this test since it modifies global state
+	// (environment) which has non-deterministic effects on the other tests
+	// which derive their default configuration from the environment
+
+	dir := testutil.TempDir(t, "bootstrap")
+	defer os.RemoveAll(dir)
+
+	tokenFile := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(tokenFile, []byte("abcd1234\n"), 0600))
+	bootstrapFile := filepath.Join(dir, "bootstrap.json")
+	require.NoError(t, ioutil.WriteFile(bootstrapFile, []byte(`{
+		"address": "127.0.0.1:8501",
+		"scheme": "https",
+		"datacenter": "dc2",
+		"ca_file": "ca.pem",
+		"token_file": "`+tokenFile+`"
+	}`), 0644))
+
+	os.Setenv(APIBootstrapFileEnvName, bootstrapFile)
+	defer os.Setenv(APIBootstrapFileEnvName, "")
+
+	config := DefaultConfig()
+	require.Equal(t, "127.0.0.1:8501", config.Address)
+	require.Equal(t, "https", config.Scheme)
+	require.Equal(t, "dc2", config.Datacenter)
+	require.Equal(t, "ca.pem", config.TLSConfig.CAFile)
+	require.Equal(t, "abcd1234", config.Token)
+
+	// The other environment variables take precedence.
+	os.Setenv(HTTPAddrEnvName, "1.2.3.4:5678")
+	defer os.Setenv(HTTPAddrEnvName, "")
+	os.Setenv(HTTPTokenEnvName, "efgh5678")
+	defer os.Setenv(HTTPTokenEnvName, "")
+
+	os.Setenv(HTTPCAPath, "/etc/ssl/certs")
+	defer os.Setenv(HTTPCAPath, "")
+
+	config = DefaultConfig()
+	require.Equal(t, "1.2.3.4:5678", config.Address)
+	require.Equal(t, "https", config.Scheme)
+	require.Equal(t, "efgh5678", config.Token)
+	require.Equal(t, "", config.TLSConfig.CAFile)
+	require.Equal(t, "/etc/ssl/certs", config.TLSConfig.CAPath)
+	require.False(t, config.TLSConfig.InsecureSkipVerify)
+
+	// A missing file falls back to the defaults.
+	os.Setenv(HTTPAddrEnvName, "")
+	os.Setenv(HTTPCAPath, "")
+	os.Setenv(APIBootstrapFileEnvName, filepath.Join(dir, "missing.json"))
+	config = DefaultConfig()
+	require.Equal(t, "127.0.0.1:8500", config.Address)
+	require.Equal(t, "http", config.Scheme)
+}
+
 func TestAPI_SetupTLSConfig(t *testing.T) {
 	t.Parallel()
 	// A default config should result in a clean default client config.
@@ -394,6 +460,7 @@ func TestAPI_SetQueryOptions(t *testing.T) {
 		WaitTime:          100 * time.Second,
 		Token:             "12345",
 		Near:              "nodex",
+		Partition:         "part1",
 	}
 	r.setQueryOptions(q)
 
@@ -418,6 +485,9 @@ func TestAPI_SetQueryOptions(t *testing.T) {
 	if r.params.Get("near") != "nodex" {
 		t.Fatalf("bad: %v", r.params)
 	}
+	if r.params.Get("partition") != "part1" {
+		t.Fatalf("bad: %v", r.params)
+	}
 	assert.Equal("", r.header.Get("Cache-Control"))
 
 	r = c.newRequest("GET", "/v1/kv/foo")
@@ -442,12 +512,16 @@ func TestAPI_SetWriteOptions(t *testing.T) {
 	q := &WriteOptions{
 		Datacenter: "foo",
 		Token:      "23456",
+		Partition:  "part1",
 	}
 	r.setWriteOptions(q)
 
 	if r.params.Get("dc") != "foo" {
 		t.Fatalf("bad: %v", r.params)
 	}
+	if r.params.Get("partition") != "part1" {
+		t.Fatalf("bad: %v", r.params)
+	}
 	if r.header.Get("X-Consul-Token") != "23456" {
 		t.Fatalf("bad: %v", r.header)
 	}
@@ -484,7 +558,11 @@ func TestAPI_ParseQueryMeta(t *testing.T) {
 	resp.Header.Set("X-Consul-Index", "12345")
 	resp.Header.Set("X-Consul-LastContact", "80")
 	resp.Header.Set("X-Consul-KnownLeader", "true")
+	resp.Header.Set("X-Consul-Effective-Consistency", "stale")
 	resp.Header.Set("X-Consul-Translate-Addresses", "true")
+	resp.Header.Set("X-Consul-Served-By", "node1")
+	resp.Header.Set("X-Consul-Served-By-ID", "e8f4b1f4-6a58-4bd5-9f3c-02cbeb4f1b0e")
+	resp.Header.Set("X-Consul-Served-By-Role", "follower")
 
 	qm := &QueryMeta{}
 	if err := parseQueryMeta(resp, qm); err != nil {
@@ -500,9 +578,101 @@ func TestAPI_ParseQueryMeta(t *testing.T) {
 	if !qm.KnownLeader {
 		t.Fatalf("Bad: %v", qm)
 	}
+	if qm.EffectiveConsistency != "stale" {
+		t.Fatalf("Bad: %v", qm)
+	}
 	if !qm.AddressTranslationEnabled {
 		t.Fatalf("Bad: %v", qm)
 	}
+	if qm.ServedBy != "node1" || qm.ServedByID != "e8f4b1f4-6a58-4bd5-9f3c-02cbeb4f1b0e" {
+		t.Fatalf("Bad: %v", qm)
+	}
+	if qm.ServedByRole != QueryServedByFollower {
+		t.Fatalf("Bad: %v", qm)
+	}
+
+	// Results served from the agent cache are reported as such
+	resp.Header.Set("X-Cache", "HIT")
+	resp.Header.Set("Age", "5")
+	if err := parseQueryMeta(resp, qm); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if qm.ServedBy != "node1" || qm.ServedByRole != QueryServedByCache {
+		t.Fatalf("Bad: %v", qm)
+	}
+}
+
+func TestAPI_QueryMetaServedBy(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+	_, meta, err := c.Catalog().Nodes(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if meta.ServedBy != s.Config.NodeName || meta.ServedByID != s.Config.NodeID {
+		t.Fatalf("Bad: %v", meta)
+	}
+	if meta.ServedByRole != QueryServedByLeader {
+		t.Fatalf("Bad: %v", meta)
+	}
+}
+
+func TestAPI_QueryMetaEffectiveConsistency(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+	for _, tc := range []struct {
+		opts *QueryOptions
+		want string
+	}{
+		{nil, "leader"},
+		{&QueryOptions{AllowStale: true}, "stale"},
+		{&QueryOptions{RequireConsistent: true}, "consistent"},
+	} {
+		_, meta, err := c.Catalog().Nodes(tc.opts)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, meta.EffectiveConsistency)
+		require.True(t, meta.KnownLeader)
+
+		// Endpoints that don't set the consistency themselves report it too
+		_, meta, err = c.KV().List("", tc.opts)
+		require.NoError(t, err)
+		require.Equal(t, tc.want, meta.EffectiveConsistency)
+	}
+}
+
+func TestAPI_RequireKnownLeader(t *testing.T) {
+	t.Parallel()
+
+	knownLeader := "false"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Consul-KnownLeader", knownLeader)
+		w.Header().Set("X-Consul-LastContact", "0")
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(&Config{Address: srv.Listener.Addr().String()})
+	require.NoError(t, err)
+
+	// Without the option the stale result is returned
+	_, meta, err := c.Catalog().Nodes(&QueryOptions{AllowStale: true})
+	require.NoError(t, err)
+	require.False(t, meta.KnownLeader)
+
+	_, _, err = c.Catalog().Nodes(&QueryOptions{AllowStale: true, RequireKnownLeader: true})
+	require.Equal(t, ErrNoKnownLeader, err)
+	require.True(t, IsRetryableError(err))
+
+	knownLeader = "true"
+	_, meta, err = c.Catalog().Nodes(&QueryOptions{AllowStale: true, RequireKnownLeader: true})
+	require.NoError(t, err)
+	require.True(t, meta.KnownLeader)
 }
 
 func TestAPI_UnixSocket(t *testing.T) {
@@ -535,6 +705,72 @@ func TestAPI_UnixSocket(t *testing.T) {
 	}
 }
 
+func TestAPI_DialContext(t *testing.T) {
+	t.Parallel()
+	_, s := makeClient(t)
+	defer s.Stop()
+
+	// The address doesn't resolve, all the connections must go through
+	// the custom dialer.
+	var dials int32
+	c, err := NewClient(&Config{
+		Address: "consul.invalid:8500",
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			atomic.AddInt32(&dials, 1)
+			var d net.Dialer
+			return d.DialContext(ctx, network, s.HTTPAddr)
+		},
+	})
+	require.NoError(t, err)
+
+	info, err := c.Agent().Self()
+	require.NoError(t, err)
+	require.NotEmpty(t, info["Config"]["NodeName"])
+	require.True(t, atomic.LoadInt32(&dials) > 0)
+}
+
+func TestAPI_DialContext_SharedTransport(t *testing.T) {
+	t.Parallel()
+
+	transport := cleanhttp.DefaultPooledTransport()
+	_, err := NewClient(&Config{
+		Address:   "consul.invalid:8500",
+		Transport: transport,
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return nil, fmt.Errorf("unexpected dial")
+		},
+	})
+	require.NoError(t, err)
+	require.Nil(t, transport.TLSClientConfig)
+
+	// The transport of the caller keeps its own dialer.
+	conn, err := transport.DialContext(context.Background(), "tcp", "127.0.0.1:0")
+	if conn != nil {
+		conn.Close()
+	}
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), "unexpected dial")
+}
+
+func TestAPI_cloneTransport(t *testing.T) {
+	t.Parallel()
+
+	transport := cleanhttp.DefaultPooledTransport()
+	transport.TLSClientConfig = &tls.Config{ServerName: "consul"}
+	clone := cloneTransport(transport)
+
+	require.Equal(t, transport.MaxIdleConnsPerHost, clone.MaxIdleConnsPerHost)
+	require.Equal(t, transport.IdleConnTimeout, clone.IdleConnTimeout)
+	require.Equal(t, transport.TLSHandshakeTimeout, clone.TLSHandshakeTimeout)
+	require.NotNil(t, clone.DialContext)
+
+	// The TLS config is copied so that changing it doesn't affect the
+	// original transport.
+	require.Equal(t, "consul", clone.TLSClientConfig.ServerName)
+	clone.TLSClientConfig.ServerName = "other"
+	require.Equal(t, "consul", transport.TLSClientConfig.ServerName)
+}
+
 func TestAPI_durToMsec(t *testing.T) {
 	t.Parallel()
 	if ms := durToMsec(0); ms != "0ms" {
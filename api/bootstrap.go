@@ -0,0 +1,77 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// BootstrapFile is the content of the file written by an agent configured
+// with api_bootstrap_file. It holds the settings API clients on the same
+// host need to talk to the local agent and is loaded by DefaultConfig when
+// the APIBootstrapFileEnvName environment variable points to it.
+type BootstrapFile struct {
+	// Address and Scheme are the address of the local agent HTTP API and
+	// the scheme to use to reach it. Address uses the unix:// prefix when
+	// the agent only listens on a Unix domain socket.
+	Address string `json:"address"`
+	Scheme  string `json:"scheme"`
+
+	// Datacenter is the datacenter of the agent.
+	Datacenter string `json:"datacenter,omitempty"`
+
+	// CAFile and CAPath are the CA certificates to verify the agent
+	// certificate with when Scheme is https.
+	CAFile string `json:"ca_file,omitempty"`
+	CAPath string `json:"ca_path,omitempty"`
+
+	// TokenFile is the path of a file holding the ACL token to use. The
+	// file is read when the configuration is loaded.
+	TokenFile string `json:"token_file,omitempty"`
+}
+
+// ReadBootstrapFile reads and decodes the bootstrap file at the given path.
+func ReadBootstrapFile(path string) (*BootstrapFile, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var bf BootstrapFile
+	if err := json.Unmarshal(raw, &bf); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %v", path, err)
+	}
+	if bf.Address == "" {
+		return nil, fmt.Errorf("missing address in %s", path)
+	}
+	return &bf, nil
+}
+
+// apply sets the settings of the bootstrap file on the given config. Only
+// the settings which are still empty are set, so the ones given with the
+// other environment variables take precedence.
+func (bf *BootstrapFile) apply(config *Config) error {
+	if config.Address == "" {
+		config.Address = bf.Address
+	}
+	if config.Scheme == "" {
+		config.Scheme = bf.Scheme
+	}
+	if config.Datacenter == "" {
+		config.Datacenter = bf.Datacenter
+	}
+	if config.TLSConfig.CAFile == "" && config.TLSConfig.CAPath == "" {
+		config.TLSConfig.CAFile = bf.CAFile
+		config.TLSConfig.CAPath = bf.CAPath
+	}
+
+	if config.Token == "" && bf.TokenFile != "" {
+		token, err := ioutil.ReadFile(bf.TokenFile)
+		if err != nil {
+			return fmt.Errorf("failed to read token file: %v", err)
+		}
+		config.Token = strings.TrimSpace(string(token))
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ type Node struct {
 	Node            string
 	Address         string
 	Datacenter      string
+	Partition       string `json:",omitempty"`
 	TaggedAddresses map[string]string
 	Meta            map[string]string
 	CreateIndex     uint64
@@ -21,13 +22,16 @@ type CatalogService struct {
 	Node                     string
 	Address                  string
 	Datacenter               string
+	Partition                string `json:",omitempty"`
 	TaggedAddresses          map[string]string
 	NodeMeta                 map[string]string
 	ServiceID                string
 	ServiceName              string
 	ServiceAddress           string
+	ServiceTaggedAddresses   map[string]ServiceAddress `json:",omitempty"`
 	ServiceTags              []string
 	ServiceMeta              map[string]string
+	ServiceLabels            map[string]string `json:",omitempty"`
 	ServicePort              int
 	ServiceWeights           Weights
 	ServiceEnableTagOverride bool
@@ -44,6 +48,14 @@ type CatalogNode struct {
 	Services map[string]*AgentService
 }
 
+// CatalogGatewayService is a service fronted by a gateway.
+type CatalogGatewayService struct {
+	Gateway     string
+	GatewayKind ServiceKind
+	Service     string
+	Port        int
+}
+
 type CatalogRegistration struct {
 	ID              string
 	Node            string
@@ -51,6 +63,7 @@ type CatalogRegistration struct {
 	TaggedAddresses map[string]string
 	NodeMeta        map[string]string
 	Datacenter      string
+	Partition       string `json:",omitempty"`
 	Service         *AgentService
 	Check           *AgentCheck
 	Checks          HealthChecks
@@ -61,6 +74,7 @@ type CatalogDeregistration struct {
 	Node       string
 	Address    string // Obsolete.
 	Datacenter string
+	Partition  string `json:",omitempty"`
 	ServiceID  string
 	CheckID    string
 }
@@ -242,3 +256,25 @@ func (c *Catalog) Node(node string, q *QueryOptions) (*CatalogNode, *QueryMeta,
 	}
 	return out, qm, nil
 }
+
+// GatewayServices is used to query for the services fronted by a gateway,
+// which are listed as the upstreams of its instances.
+func (c *Catalog) GatewayServices(gateway string, q *QueryOptions) ([]*CatalogGatewayService, *QueryMeta, error) {
+	r := c.c.newRequest("GET", "/v1/catalog/gateway-services/"+gateway)
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(c.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []*CatalogGatewayService
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
@@ -842,6 +842,104 @@ func TestAPI_CatalogRegistration(t *testing.T) {
 	})
 }
 
+func TestAPI_CatalogRegistration_CheckDefinitions(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	catalog := c.Catalog()
+	reg := &CatalogRegistration{
+		Datacenter:      "dc1",
+		Node:            "external",
+		Address:         "192.168.10.10",
+		TaggedAddresses: map[string]string{"wan": "10.0.0.1"},
+		Service: &AgentService{
+			ID:      "web1",
+			Service: "web",
+			Port:    8080,
+			Weights: AgentWeights{Passing: 10, Warning: 1},
+		},
+		Checks: HealthChecks{
+			&HealthCheck{
+				CheckID:   "web-http",
+				Name:      "HTTP check",
+				Status:    HealthPassing,
+				ServiceID: "web1",
+				Definition: HealthCheckDefinition{
+					HTTP:             "http://192.168.10.10:8080/health",
+					Method:           "GET",
+					Header:           map[string][]string{"X-Check": {"1"}},
+					IntervalDuration: 10 * time.Second,
+					TimeoutDuration:  time.Second,
+				},
+			},
+			&HealthCheck{
+				CheckID:   "web-ttl",
+				Name:      "TTL check",
+				Status:    HealthCritical,
+				ServiceID: "web1",
+				Definition: HealthCheckDefinition{
+					TTL: 30 * time.Second,
+				},
+			},
+			&HealthCheck{
+				CheckID: "node-script",
+				Name:    "Script check",
+				Status:  HealthPassing,
+				Definition: HealthCheckDefinition{
+					ScriptArgs:       []string{"/bin/check", "-v"},
+					IntervalDuration: time.Minute,
+				},
+			},
+		},
+	}
+	_, err := catalog.Register(reg, nil)
+	require.NoError(t, err)
+
+	node, _, err := catalog.Node("external", nil)
+	require.NoError(t, err)
+	require.Equal(t, "10.0.0.1", node.Node.TaggedAddresses["wan"])
+	require.Equal(t, AgentWeights{Passing: 10, Warning: 1}, node.Services["web1"].Weights)
+
+	checks, _, err := c.Health().Node("external", nil)
+	require.NoError(t, err)
+	require.Len(t, checks, 3)
+	byID := make(map[string]*HealthCheck)
+	for _, check := range checks {
+		byID[check.CheckID] = check
+	}
+	require.Equal(t, "http://192.168.10.10:8080/health", byID["web-http"].Definition.HTTP)
+	require.Equal(t, []string{"1"}, byID["web-http"].Definition.Header["X-Check"])
+	require.Equal(t, 10*time.Second, byID["web-http"].Definition.IntervalDuration)
+	require.Equal(t, 30*time.Second, byID["web-ttl"].Definition.TTL)
+	require.Equal(t, []string{"/bin/check", "-v"}, byID["node-script"].Definition.ScriptArgs)
+	require.Equal(t, time.Minute, byID["node-script"].Definition.IntervalDuration)
+
+	// Unset weights get the defaults
+	reg.Service.Weights = AgentWeights{}
+	reg.Checks = nil
+	_, err = catalog.Register(reg, nil)
+	require.NoError(t, err)
+	node, _, err = catalog.Node("external", nil)
+	require.NoError(t, err)
+	require.Equal(t, AgentWeights{Passing: 1, Warning: 1}, node.Services["web1"].Weights)
+
+	// Definitions mixing several kinds of checks are rejected
+	reg.Checks = HealthChecks{
+		&HealthCheck{
+			CheckID: "mixed",
+			Name:    "Mixed check",
+			Definition: HealthCheckDefinition{
+				TCP: "192.168.10.10:8080",
+				TTL: 30 * time.Second,
+			},
+		},
+	}
+	_, err = catalog.Register(reg, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "only one of")
+}
+
 func TestAPI_CatalogEnableTagOverride(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -926,3 +1024,93 @@ func TestAPI_CatalogEnableTagOverride(t *testing.T) {
 		}
 	})
 }
+
+func TestAPI_CatalogFilter(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	catalog := c.Catalog()
+	for _, name := range []string{"foo", "bar"} {
+		reg := &CatalogRegistration{
+			Datacenter: "dc1",
+			Node:       name,
+			Address:    "192.168.10.10",
+			NodeMeta:   map[string]string{"env": name},
+			Service: &AgentService{
+				ID:      "redis",
+				Service: "redis",
+				Tags:    []string{name},
+				Meta:    map[string]string{"node": name},
+			},
+		}
+		_, err := catalog.Register(reg, nil)
+		require.NoError(t, err)
+	}
+
+	services, _, err := catalog.Services(&QueryOptions{Filter: "ServiceMeta.node == foo"})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]string{"redis": {"foo"}}, services)
+
+	nodes, _, err := catalog.Nodes(&QueryOptions{Filter: "Meta.env == bar"})
+	require.NoError(t, err)
+	require.Len(t, nodes, 1)
+	require.Equal(t, "bar", nodes[0].Node)
+
+	entries, _, err := c.Health().Service("redis", "", false, &QueryOptions{Filter: "foo in Service.Tags"})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, "foo", entries[0].Node.Node)
+
+	// Invalid filters are rejected
+	_, _, err = catalog.Nodes(&QueryOptions{Filter: "Missing == bar"})
+	require.Error(t, err)
+}
+
+func TestAPI_CatalogGatewayServices(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	catalog := c.Catalog()
+	reg := &CatalogRegistration{
+		Datacenter: "dc1",
+		Node:       "foobar",
+		Address:    "192.168.10.10",
+		Service: &AgentService{
+			Kind:    ServiceKindTerminatingGateway,
+			ID:      "egress",
+			Service: "egress",
+			Port:    8443,
+		},
+	}
+	if _, err := catalog.Register(reg, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The linked services come from the config entry of the gateway
+	entry := &TerminatingGatewayConfigEntry{
+		Kind: TerminatingGateway,
+		Name: "egress",
+		Services: []LinkedService{
+			{Name: "billing"},
+			{Name: "api"},
+		},
+	}
+	if _, err := c.ConfigEntries().Set(entry, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	services, meta, err := catalog.GatewayServices("egress", nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if meta.LastIndex == 0 {
+		t.Fatalf("bad: %v", meta)
+	}
+	expected := []*CatalogGatewayService{
+		{Gateway: "egress", GatewayKind: ServiceKindTerminatingGateway, Service: "api"},
+		{Gateway: "egress", GatewayKind: ServiceKindTerminatingGateway, Service: "billing"},
+	}
+	require.Equal(t, expected, services)
+}
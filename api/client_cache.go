@@ -0,0 +1,209 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultClientCacheTTL is how long an entry of the client cache keeps
+	// being refreshed after it was last read, see Config.ClientCacheTTL.
+	DefaultClientCacheTTL = 3 * time.Minute
+)
+
+// clientCache holds the responses of the read requests made with
+// QueryOptions.UseClientCache. Each entry is kept fresh by running blocking
+// queries in the background so reads are served from memory, similarly to
+// the agent's background refresh caching.
+type clientCache struct {
+	lock    sync.Mutex
+	entries map[string]*clientCacheEntry
+}
+
+// clientCacheEntry is a single cached response. Fields other than ready are
+// protected by the cache lock.
+type clientCacheEntry struct {
+	// ready is closed once the first fetch completed.
+	ready chan struct{}
+
+	status    int
+	header    http.Header
+	body      []byte
+	err       error
+	fetchedAt time.Time
+
+	// lastRead is used to stop refreshing entries nobody reads anymore.
+	lastRead time.Time
+}
+
+func newClientCache() *clientCache {
+	return &clientCache{
+		entries: make(map[string]*clientCacheEntry),
+	}
+}
+
+// clientCacheKey returns the key of the request, which includes the token
+// since it affects the results.
+func clientCacheKey(r *request) string {
+	return r.url.Path + "?" + r.params.Encode() + "#" + r.header.Get("X-Consul-Token")
+}
+
+// get returns the cached response for the request, fetching it and starting
+// the background refresh on first use.
+func (cc *clientCache) get(c *Client, r *request) (time.Duration, *http.Response, error) {
+	start := time.Now()
+	key := clientCacheKey(r)
+
+	cc.lock.Lock()
+	e, ok := cc.entries[key]
+	if !ok {
+		e = &clientCacheEntry{ready: make(chan struct{})}
+		cc.entries[key] = e
+		go cc.refresh(c, key, r.clone(), e)
+	}
+	e.lastRead = time.Now()
+	cc.lock.Unlock()
+
+	ctx := r.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	select {
+	case <-e.ready:
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	}
+
+	cc.lock.Lock()
+	defer cc.lock.Unlock()
+
+	if e.body == nil {
+		return 0, nil, e.err
+	}
+
+	header := make(http.Header, len(e.header)+2)
+	for k, v := range e.header {
+		header[k] = v
+	}
+	if ok {
+		header.Set("X-Cache", "HIT")
+	} else {
+		header.Set("X-Cache", "MISS")
+	}
+	header.Set("Age", strconv.Itoa(int(time.Since(e.fetchedAt).Seconds())))
+
+	resp := &http.Response{
+		Status:     strconv.Itoa(e.status) + " " + http.StatusText(e.status),
+		StatusCode: e.status,
+		Header:     header,
+		Body:       ioutil.NopCloser(bytes.NewReader(e.body)),
+	}
+	return time.Since(start), resp, nil
+}
+
+// refresh runs blocking queries for the entry until it wasn't read for the
+// TTL. Errors keep the last response around and are retried with a backoff.
+func (cc *clientCache) refresh(c *Client, key string, r *request, e *clientCacheEntry) {
+	ttl := c.config.ClientCacheTTL
+	if ttl <= 0 {
+		ttl = DefaultClientCacheTTL
+	}
+
+	var index uint64
+	var failures uint
+	first := true
+	for {
+		if index > 0 {
+			r.params.Set("index", strconv.FormatUint(index, 10))
+		} else {
+			r.params.Del("index")
+		}
+		status, header, body, err := c.fetch(r)
+
+		cc.lock.Lock()
+		if err == nil {
+			e.status, e.header, e.body, e.err = status, header, body, nil
+			e.fetchedAt = time.Now()
+		} else if e.body == nil {
+			e.err = err
+		}
+		if first {
+			close(e.ready)
+			first = false
+		}
+
+		// Only successful responses of endpoints supporting blocking
+		// queries can be refreshed, anything else is dropped so the next
+		// read fetches it again.
+		var newIndex uint64
+		if err == nil {
+			newIndex, _ = strconv.ParseUint(header.Get("X-Consul-Index"), 10, 64)
+		}
+		if (err == nil && (status != http.StatusOK || newIndex == 0)) ||
+			(err != nil && e.body == nil) ||
+			time.Since(e.lastRead) > ttl {
+			delete(cc.entries, key)
+			cc.lock.Unlock()
+			return
+		}
+		cc.lock.Unlock()
+
+		if err != nil {
+			time.Sleep(watchBackoff(failures, DefaultWatchRetryMin, DefaultWatchRetryMax))
+			failures++
+			continue
+		}
+		failures = 0
+
+		// Reset the index if it goes backwards so we don't block on an
+		// index the servers will never reach.
+		switch {
+		case newIndex < index:
+			index = 0
+		default:
+			index = newIndex
+		}
+	}
+}
+
+// fetch sends the request bypassing the cache and reads the whole response.
+func (c *Client) fetch(r *request) (int, http.Header, []byte, error) {
+	_, resp, err := c.sendRequest(r)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return resp.StatusCode, resp.Header, body, nil
+}
+
+// clone returns a copy of the request that can be sent again in the
+// background, detached from the context of the original request.
+func (r *request) clone() *request {
+	u := *r.url
+	params := make(url.Values, len(r.params))
+	for k, v := range r.params {
+		params[k] = append([]string(nil), v...)
+	}
+	header := make(http.Header, len(r.header))
+	for k, v := range r.header {
+		header[k] = append([]string(nil), v...)
+	}
+	return &request{
+		config: r.config,
+		method: r.method,
+		url:    &u,
+		params: params,
+		header: header,
+	}
+}
@@ -0,0 +1,140 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_ClientCache(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	agent := c.Agent()
+	health := c.Health()
+
+	require.NoError(t, agent.ServiceRegister(&AgentServiceRegistration{
+		ID:   "web1",
+		Name: "web",
+	}))
+
+	opts := &QueryOptions{UseClientCache: true}
+	retry.Run(t, func(r *retry.R) {
+		services, _, err := health.Service("web", "", false, opts)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(services) != 1 {
+			r.Fatalf("bad: %v", services)
+		}
+	})
+
+	// The entry is now served from the cache.
+	services, meta, err := health.Service("web", "", false, opts)
+	require.NoError(t, err)
+	require.Len(t, services, 1)
+	require.True(t, meta.CacheHit)
+	require.NotZero(t, meta.LastIndex)
+
+	// The background refresh picks up the new instance.
+	require.NoError(t, agent.ServiceRegister(&AgentServiceRegistration{
+		ID:   "web2",
+		Name: "web",
+	}))
+	retry.Run(t, func(r *retry.R) {
+		services, meta, err := health.Service("web", "", false, opts)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if !meta.CacheHit {
+			r.Fatalf("expected a cache hit")
+		}
+		if len(services) != 2 {
+			r.Fatalf("bad: %v", services)
+		}
+	})
+
+	// Reads without the option aren't cached.
+	_, meta, err = health.Service("web", "", false, nil)
+	require.NoError(t, err)
+	require.False(t, meta.CacheHit)
+}
+
+func TestAPI_ClientCache_NoIndex(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	// Responses without an index can't be refreshed so they are never
+	// served from the cache.
+	opts := &QueryOptions{UseClientCache: true}
+	for i := 0; i < 2; i++ {
+		var out map[string]map[string]interface{}
+		meta, err := c.query("/v1/agent/self", &out, opts)
+		require.NoError(t, err)
+		require.False(t, meta.CacheHit)
+	}
+
+	retry.Run(t, func(r *retry.R) {
+		c.clientCache.lock.Lock()
+		defer c.clientCache.lock.Unlock()
+		if len(c.clientCache.entries) != 0 {
+			r.Fatalf("bad: %v", c.clientCache.entries)
+		}
+	})
+}
+
+func TestAPI_ClientCache_IndexBackwards(t *testing.T) {
+	t.Parallel()
+
+	var lock sync.Mutex
+	var indexes []string
+	doneCh := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		indexes = append(indexes, r.URL.Query().Get("index"))
+		n := len(indexes)
+		lock.Unlock()
+
+		// The index goes backwards on the second response, and the
+		// next queries block until the test is done.
+		index := 10
+		if n > 1 {
+			index = 5
+		}
+		if n > 2 {
+			<-doneCh
+		}
+		w.Header().Set("X-Consul-Index", fmt.Sprint(index))
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+	defer close(doneCh)
+
+	c, err := NewClient(&Config{Address: srv.Listener.Addr().String()})
+	require.NoError(t, err)
+
+	var out []interface{}
+	_, err = c.query("/v1/health/service/web", &out, &QueryOptions{UseClientCache: true})
+	require.NoError(t, err)
+
+	// The query following the reset doesn't block on the previous index.
+	retry.Run(t, func(r *retry.R) {
+		lock.Lock()
+		defer lock.Unlock()
+		if len(indexes) < 3 {
+			r.Fatalf("bad: %v", indexes)
+		}
+	})
+	lock.Lock()
+	defer lock.Unlock()
+	require.Equal(t, []string{"", "10", ""}, indexes[:3])
+}
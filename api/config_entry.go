@@ -0,0 +1,303 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	ServiceDefaults    string = "service-defaults"
+	ProxyDefaults      string = "proxy-defaults"
+	ServiceResolver    string = "service-resolver"
+	IngressGateway     string = "ingress-gateway"
+	TerminatingGateway string = "terminating-gateway"
+
+	// ProxyConfigGlobal is the only name supported for the proxy defaults.
+	ProxyConfigGlobal string = "global"
+)
+
+// ConfigEntry is a centralized config entry of any kind.
+type ConfigEntry interface {
+	GetKind() string
+	GetName() string
+	GetCreateIndex() uint64
+	GetModifyIndex() uint64
+}
+
+// ServiceConfigEntry holds the defaults of a service across the cluster.
+type ServiceConfigEntry struct {
+	Kind        string
+	Name        string
+	Protocol    string
+	MeshGateway MeshGatewayConfig `json:",omitempty"`
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+func (s *ServiceConfigEntry) GetKind() string {
+	return s.Kind
+}
+
+func (s *ServiceConfigEntry) GetName() string {
+	return s.Name
+}
+
+func (s *ServiceConfigEntry) GetCreateIndex() uint64 {
+	return s.CreateIndex
+}
+
+func (s *ServiceConfigEntry) GetModifyIndex() uint64 {
+	return s.ModifyIndex
+}
+
+// ProxyConfigEntry holds the defaults of the proxies across the cluster.
+type ProxyConfigEntry struct {
+	Kind        string
+	Name        string
+	Config      map[string]interface{}
+	MeshGateway MeshGatewayConfig `json:",omitempty"`
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+func (p *ProxyConfigEntry) GetKind() string {
+	return p.Kind
+}
+
+func (p *ProxyConfigEntry) GetName() string {
+	return p.Name
+}
+
+func (p *ProxyConfigEntry) GetCreateIndex() uint64 {
+	return p.CreateIndex
+}
+
+func (p *ProxyConfigEntry) GetModifyIndex() uint64 {
+	return p.ModifyIndex
+}
+
+// MakeConfigEntry returns an empty config entry of the given kind with the
+// given name.
+func MakeConfigEntry(kind, name string) (ConfigEntry, error) {
+	switch kind {
+	case ServiceDefaults:
+		return &ServiceConfigEntry{Kind: kind, Name: name}, nil
+	case ProxyDefaults:
+		return &ProxyConfigEntry{Kind: kind, Name: name}, nil
+	case ServiceResolver:
+		return &ServiceResolverConfigEntry{Kind: kind, Name: name}, nil
+	case IngressGateway:
+		return &IngressGatewayConfigEntry{Kind: kind, Name: name}, nil
+	case TerminatingGateway:
+		return &TerminatingGatewayConfigEntry{Kind: kind, Name: name}, nil
+	default:
+		return nil, fmt.Errorf("invalid config entry kind: %s", kind)
+	}
+}
+
+// DecodeConfigEntry decodes a config entry from a generic map, such as the
+// result of parsing a HCL or JSON file, the type of the entry depending on
+// its Kind field. The keys can be in either CamelCase or snake_case.
+func DecodeConfigEntry(raw map[string]interface{}) (ConfigEntry, error) {
+	// Strip the underscores of the keys so that the snake_case keys match
+	// the fields, which mapstructure matches regardless of the case. The
+	// proxy config is opaque and kept as-is.
+	normalized := normalizeConfigEntryKeys(raw)
+
+	var kind string
+	for k, v := range normalized {
+		if strings.ToLower(k) == "kind" {
+			kind, _ = v.(string)
+		}
+	}
+	entry, err := MakeConfigEntry(kind, "")
+	if err != nil {
+		return nil, err
+	}
+
+	decodeConf := &mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.ComposeDecodeHookFunc(
+			mapstructure.StringToTimeDurationHookFunc(),
+			hclBlockToStructHookFunc(),
+		),
+		Result:           entry,
+		WeaklyTypedInput: true,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConf)
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(normalized); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// normalizeConfigEntryKeys returns the map with the underscores of its keys
+// stripped, recursing into the nested blocks but not into the opaque proxy
+// config.
+func normalizeConfigEntryKeys(raw map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		if strings.ToLower(k) != "config" {
+			v = normalizeConfigEntryValue(v)
+		}
+		normalized[strings.Replace(k, "_", "", -1)] = v
+	}
+	return normalized
+}
+
+func normalizeConfigEntryValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return normalizeConfigEntryKeys(v)
+	case []map[string]interface{}:
+		out := make([]map[string]interface{}, len(v))
+		for i, m := range v {
+			out[i] = normalizeConfigEntryKeys(m)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, e := range v {
+			out[i] = normalizeConfigEntryValue(e)
+		}
+		return out
+	}
+	return v
+}
+
+// hclBlockToStructHookFunc returns a decode hook unwrapping the single map of
+// the lists of maps HCL decodes the blocks into, such as the MeshGateway
+// block, when they are decoded into a struct.
+func hclBlockToStructHookFunc() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.Slice || to.Kind() != reflect.Struct {
+			return data, nil
+		}
+		if blocks, ok := data.([]map[string]interface{}); ok && len(blocks) == 1 {
+			return blocks[0], nil
+		}
+		return data, nil
+	}
+}
+
+// decodeConfigEntryJSON decodes a JSON encoded config entry.
+func decodeConfigEntryJSON(data []byte) (ConfigEntry, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	return DecodeConfigEntry(raw)
+}
+
+// ConfigEntries can be used to manage the centralized config entries.
+type ConfigEntries struct {
+	c *Client
+}
+
+// ConfigEntries returns a handle to the config entries endpoints.
+func (c *Client) ConfigEntries() *ConfigEntries {
+	return &ConfigEntries{c}
+}
+
+// Get returns the config entry of the given kind and name.
+func (conf *ConfigEntries) Get(kind, name string, q *QueryOptions) (ConfigEntry, *QueryMeta, error) {
+	if kind == "" || name == "" {
+		return nil, nil, fmt.Errorf("Both kind and name parameters must not be empty")
+	}
+
+	r := conf.c.newRequest("GET", fmt.Sprintf("/v1/config/%s/%s", kind, name))
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(conf.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	entry, err := decodeConfigEntryJSON(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return entry, qm, nil
+}
+
+// List returns the config entries of the given kind.
+func (conf *ConfigEntries) List(kind string, q *QueryOptions) ([]ConfigEntry, *QueryMeta, error) {
+	if kind == "" {
+		return nil, nil, fmt.Errorf("The kind parameter must not be empty")
+	}
+
+	r := conf.c.newRequest("GET", fmt.Sprintf("/v1/config/%s", kind))
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(conf.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var raw []map[string]interface{}
+	if err := decodeBody(resp, &raw); err != nil {
+		return nil, nil, err
+	}
+	entries := make([]ConfigEntry, 0, len(raw))
+	for _, r := range raw {
+		entry, err := DecodeConfigEntry(r)
+		if err != nil {
+			return nil, nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, qm, nil
+}
+
+// Set creates or updates the given config entry. The Kind of the entry must
+// be set.
+func (conf *ConfigEntries) Set(entry ConfigEntry, w *WriteOptions) (*WriteMeta, error) {
+	r := conf.c.newRequest("PUT", "/v1/config")
+	r.setWriteOptions(w)
+	r.obj = entry
+	rtt, resp, err := requireOK(conf.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	return wm, nil
+}
+
+// Delete deletes the config entry of the given kind and name.
+func (conf *ConfigEntries) Delete(kind string, name string, w *WriteOptions) (*WriteMeta, error) {
+	if kind == "" || name == "" {
+		return nil, fmt.Errorf("Both kind and name parameters must not be empty")
+	}
+
+	r := conf.c.newRequest("DELETE", fmt.Sprintf("/v1/config/%s/%s", kind, name))
+	r.setWriteOptions(w)
+	rtt, resp, err := requireOK(conf.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	wm := &WriteMeta{RequestTime: rtt}
+	return wm, nil
+}
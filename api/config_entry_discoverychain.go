@@ -0,0 +1,56 @@
+package api
+
+import "time"
+
+// ServiceResolverConfigEntry configures how the traffic sent to a service is
+// resolved to its healthy instances, and where it fails over to when there
+// are none in the datacenter of the discovery chain.
+type ServiceResolverConfigEntry struct {
+	Kind string
+
+	// Name is the name of the service.
+	Name string
+
+	// ConnectTimeout is the timeout of the connections to the instances of
+	// the service.
+	ConnectTimeout time.Duration `json:",omitempty"`
+
+	// Failover defines the datacenters the traffic fails over to, if any.
+	Failover *ServiceResolverFailover `json:",omitempty"`
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// ServiceResolverFailover defines the datacenters the traffic sent to a
+// service fails over to, in order, when it has no healthy instances. Either
+// AllDatacenters or NearestN and Datacenters can be set.
+type ServiceResolverFailover struct {
+	// NearestN is the number of datacenters to fail over to first, the
+	// nearest first by the network coordinates of the servers.
+	NearestN int `json:",omitempty"`
+
+	// Datacenters is a fixed list of datacenters to fail over to after the
+	// NearestN ones.
+	Datacenters []string `json:",omitempty"`
+
+	// AllDatacenters fails over to all the federated datacenters, the
+	// nearest first.
+	AllDatacenters bool `json:",omitempty"`
+}
+
+func (e *ServiceResolverConfigEntry) GetKind() string {
+	return e.Kind
+}
+
+func (e *ServiceResolverConfigEntry) GetName() string {
+	return e.Name
+}
+
+func (e *ServiceResolverConfigEntry) GetCreateIndex() uint64 {
+	return e.CreateIndex
+}
+
+func (e *ServiceResolverConfigEntry) GetModifyIndex() uint64 {
+	return e.ModifyIndex
+}
@@ -0,0 +1,111 @@
+package api
+
+// IngressGatewayConfigEntry configures the listeners of the ingress gateways
+// with the same service name, letting the traffic from outside the mesh
+// reach the Connect services.
+type IngressGatewayConfigEntry struct {
+	Kind string
+
+	// Name is the service name of the ingress gateways.
+	Name string
+
+	// Listeners are the ports the gateways listen on and the services they
+	// route the traffic received on them to.
+	Listeners []IngressListener
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// IngressListener is a port of an ingress gateway.
+type IngressListener struct {
+	// Port is the port the gateway listens on.
+	Port int
+
+	// Protocol is the protocol of the listener, "tcp" by default or "http".
+	// A TCP listener routes the connections to its only service, an HTTP
+	// listener routes the requests to its services by their Host header.
+	Protocol string
+
+	// Services are the services the traffic of the listener is routed to.
+	Services []IngressService
+}
+
+// IngressService is a service exposed by a listener of an ingress gateway.
+type IngressService struct {
+	// Name is the name of the service.
+	Name string
+
+	// Hosts are the hosts of the requests routed to the service by an HTTP
+	// listener. A service without hosts receives the requests not matching
+	// the hosts of the other services.
+	Hosts []string `json:",omitempty"`
+}
+
+func (i *IngressGatewayConfigEntry) GetKind() string {
+	return i.Kind
+}
+
+func (i *IngressGatewayConfigEntry) GetName() string {
+	return i.Name
+}
+
+func (i *IngressGatewayConfigEntry) GetCreateIndex() uint64 {
+	return i.CreateIndex
+}
+
+func (i *IngressGatewayConfigEntry) GetModifyIndex() uint64 {
+	return i.ModifyIndex
+}
+
+// TerminatingGatewayConfigEntry configures the services linked to the
+// terminating gateways with the same service name. The Connect services reach
+// the linked services, which are outside the mesh, through the gateways.
+type TerminatingGatewayConfigEntry struct {
+	Kind string
+
+	// Name is the service name of the terminating gateways.
+	Name string
+
+	// Services are the services linked to the gateways.
+	Services []LinkedService
+
+	CreateIndex uint64
+	ModifyIndex uint64
+}
+
+// LinkedService is a service outside the mesh linked to a terminating
+// gateway.
+type LinkedService struct {
+	// Name is the name of the service, registered in the catalog.
+	Name string
+
+	// CAFile is the path of the CA certificates the gateway verifies the
+	// certificates of the service instances with. The gateway connects to
+	// the instances over TLS only when it is set.
+	CAFile string `json:",omitempty"`
+
+	// CertFile and KeyFile are the paths of the client certificate and key
+	// the gateway presents to the service instances, if they require one.
+	CertFile string `json:",omitempty"`
+	KeyFile  string `json:",omitempty"`
+
+	// SNI is the server name the gateway sends to the service instances.
+	SNI string `json:",omitempty"`
+}
+
+func (t *TerminatingGatewayConfigEntry) GetKind() string {
+	return t.Kind
+}
+
+func (t *TerminatingGatewayConfigEntry) GetName() string {
+	return t.Name
+}
+
+func (t *TerminatingGatewayConfigEntry) GetCreateIndex() uint64 {
+	return t.CreateIndex
+}
+
+func (t *TerminatingGatewayConfigEntry) GetModifyIndex() uint64 {
+	return t.ModifyIndex
+}
@@ -0,0 +1,264 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_ConfigEntries(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	config := c.ConfigEntries()
+
+	// Set the global proxy defaults
+	global := &ProxyConfigEntry{
+		Kind: ProxyDefaults,
+		Name: ProxyConfigGlobal,
+		Config: map[string]interface{}{
+			"foo": "bar",
+			"bar": 1.0,
+		},
+	}
+	_, err := config.Set(global, nil)
+	require.NoError(err)
+
+	entry, qm, err := config.Get(ProxyDefaults, ProxyConfigGlobal, nil)
+	require.NoError(err)
+	require.NotZero(qm.LastIndex)
+	readGlobal, ok := entry.(*ProxyConfigEntry)
+	require.True(ok)
+	require.Equal(global.Config, readGlobal.Config)
+	require.NotZero(readGlobal.ModifyIndex)
+
+	// Set two service defaults
+	for _, name := range []string{"foo", "bar"} {
+		_, err := config.Set(&ServiceConfigEntry{
+			Kind:     ServiceDefaults,
+			Name:     name,
+			Protocol: "http",
+		}, nil)
+		require.NoError(err)
+	}
+
+	entries, _, err := config.List(ServiceDefaults, nil)
+	require.NoError(err)
+	require.Len(entries, 2)
+	for _, entry := range entries {
+		service, ok := entry.(*ServiceConfigEntry)
+		require.True(ok)
+		require.Equal("http", service.Protocol)
+	}
+
+	// Delete one of them
+	_, err = config.Delete(ServiceDefaults, "foo", nil)
+	require.NoError(err)
+	_, _, err = config.Get(ServiceDefaults, "foo", nil)
+	require.Error(err)
+	require.Contains(err.Error(), "Unexpected response code: 404")
+
+	entries, _, err = config.List(ServiceDefaults, nil)
+	require.NoError(err)
+	require.Len(entries, 1)
+	require.Equal("bar", entries[0].GetName())
+
+	// Set an ingress gateway, its listeners default to TCP
+	ingress := &IngressGatewayConfigEntry{
+		Kind: IngressGateway,
+		Name: "ingress",
+		Listeners: []IngressListener{
+			{Port: 9191, Services: []IngressService{{Name: "db"}}},
+			{
+				Port:     8080,
+				Protocol: "http",
+				Services: []IngressService{{Name: "web", Hosts: []string{"web.example.com"}}},
+			},
+		},
+	}
+	_, err = config.Set(ingress, nil)
+	require.NoError(err)
+
+	entry, _, err = config.Get(IngressGateway, "ingress", nil)
+	require.NoError(err)
+	readIngress, ok := entry.(*IngressGatewayConfigEntry)
+	require.True(ok)
+	require.Len(readIngress.Listeners, 2)
+	require.Equal("tcp", readIngress.Listeners[0].Protocol)
+	require.Equal(ingress.Listeners[1], readIngress.Listeners[1])
+
+	// Invalid listeners are rejected
+	ingress.Listeners[1].Port = 9191
+	_, err = config.Set(ingress, nil)
+	require.Error(err)
+	require.Contains(err.Error(), "more than one listener")
+
+	// Set a terminating gateway
+	terminating := &TerminatingGatewayConfigEntry{
+		Kind: TerminatingGateway,
+		Name: "terminating",
+		Services: []LinkedService{
+			{Name: "legacy"},
+			{Name: "billing", CAFile: "/etc/certs/ca.pem", SNI: "billing.example.com"},
+		},
+	}
+	_, err = config.Set(terminating, nil)
+	require.NoError(err)
+
+	entry, _, err = config.Get(TerminatingGateway, "terminating", nil)
+	require.NoError(err)
+	readTerminating, ok := entry.(*TerminatingGatewayConfigEntry)
+	require.True(ok)
+	require.Equal(terminating.Services, readTerminating.Services)
+
+	// An SNI requires a CA file
+	terminating.Services[1].CAFile = ""
+	_, err = config.Set(terminating, nil)
+	require.Error(err)
+	require.Contains(err.Error(), "must have a CAFile")
+
+	resolver := &ServiceResolverConfigEntry{
+		Kind:           ServiceResolver,
+		Name:           "web",
+		ConnectTimeout: 2 * time.Second,
+		Failover: &ServiceResolverFailover{
+			NearestN:    2,
+			Datacenters: []string{"dc3"},
+		},
+	}
+	_, err = config.Set(resolver, nil)
+	require.NoError(err)
+
+	entry, _, err = config.Get(ServiceResolver, "web", nil)
+	require.NoError(err)
+	readResolver, ok := entry.(*ServiceResolverConfigEntry)
+	require.True(ok)
+	require.Equal(resolver.ConnectTimeout, readResolver.ConnectTimeout)
+	require.Equal(resolver.Failover, readResolver.Failover)
+
+	// All the datacenters can't be combined with a list
+	resolver.Failover.AllDatacenters = true
+	_, err = config.Set(resolver, nil)
+	require.Error(err)
+	require.Contains(err.Error(), "AllDatacenters cannot be set")
+}
+
+func TestAPI_DecodeConfigEntry(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+
+	entry, err := DecodeConfigEntry(map[string]interface{}{
+		"kind": "proxy-defaults",
+		"name": "global",
+		"config": map[string]interface{}{
+			"local_connect_timeout_ms": 1000,
+		},
+		"modify_index": 5,
+	})
+	require.NoError(err)
+	require.Equal(&ProxyConfigEntry{
+		Kind: ProxyDefaults,
+		Name: ProxyConfigGlobal,
+		Config: map[string]interface{}{
+			"local_connect_timeout_ms": 1000,
+		},
+		ModifyIndex: 5,
+	}, entry)
+
+	// The blocks decoded from HCL are lists of maps.
+	entry, err = DecodeConfigEntry(map[string]interface{}{
+		"kind":     "service-defaults",
+		"name":     "web",
+		"protocol": "http",
+		"mesh_gateway": []map[string]interface{}{
+			{"mode": "remote"},
+		},
+	})
+	require.NoError(err)
+	require.Equal(&ServiceConfigEntry{
+		Kind:        ServiceDefaults,
+		Name:        "web",
+		Protocol:    "http",
+		MeshGateway: MeshGatewayConfig{Mode: MeshGatewayModeRemote},
+	}, entry)
+
+	// The listeners of an ingress gateway, as decoded from HCL.
+	entry, err = DecodeConfigEntry(map[string]interface{}{
+		"kind": "ingress-gateway",
+		"name": "ingress",
+		"listeners": []map[string]interface{}{
+			{
+				"port":     8080,
+				"protocol": "http",
+				"services": []map[string]interface{}{
+					{"name": "web", "hosts": []interface{}{"web.example.com"}},
+					{"name": "api"},
+				},
+			},
+		},
+	})
+	require.NoError(err)
+	require.Equal(&IngressGatewayConfigEntry{
+		Kind: IngressGateway,
+		Name: "ingress",
+		Listeners: []IngressListener{
+			{
+				Port:     8080,
+				Protocol: "http",
+				Services: []IngressService{
+					{Name: "web", Hosts: []string{"web.example.com"}},
+					{Name: "api"},
+				},
+			},
+		},
+	}, entry)
+
+	// The linked services of a terminating gateway.
+	entry, err = DecodeConfigEntry(map[string]interface{}{
+		"kind": "terminating-gateway",
+		"name": "terminating",
+		"services": []map[string]interface{}{
+			{"name": "legacy"},
+			{"name": "billing", "ca_file": "/etc/certs/ca.pem", "sni": "billing.example.com"},
+		},
+	})
+	require.NoError(err)
+	require.Equal(&TerminatingGatewayConfigEntry{
+		Kind: TerminatingGateway,
+		Name: "terminating",
+		Services: []LinkedService{
+			{Name: "legacy"},
+			{Name: "billing", CAFile: "/etc/certs/ca.pem", SNI: "billing.example.com"},
+		},
+	}, entry)
+
+	// The failover of a service resolver, as decoded from HCL.
+	entry, err = DecodeConfigEntry(map[string]interface{}{
+		"kind":            "service-resolver",
+		"name":            "web",
+		"connect_timeout": "2s",
+		"failover": []map[string]interface{}{
+			{"nearest_n": 1, "datacenters": []interface{}{"dc3"}},
+		},
+	})
+	require.NoError(err)
+	require.Equal(&ServiceResolverConfigEntry{
+		Kind:           ServiceResolver,
+		Name:           "web",
+		ConnectTimeout: 2 * time.Second,
+		Failover: &ServiceResolverFailover{
+			NearestN:    1,
+			Datacenters: []string{"dc3"},
+		},
+	}, entry)
+
+	_, err = DecodeConfigEntry(map[string]interface{}{
+		"kind": "foo",
+	})
+	require.EqualError(err, "invalid config entry kind: foo")
+}
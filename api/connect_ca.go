@@ -27,6 +27,14 @@ type CommonCAProviderConfig struct {
 	SkipValidate     bool
 	CSRMaxPerSecond  float32
 	CSRMaxConcurrent int
+
+	// TrustDomain overrides the trust domain of the SPIFFE IDs in the URI
+	// SANs of the certificates, "<cluster id>.consul" by default.
+	TrustDomain string
+
+	// LeafCertDNSDomains are the DNS domains the leaf certificates get a
+	// "<service>.<domain>" DNS SAN in.
+	LeafCertDNSDomains []string
 }
 
 // ConsulCAProviderConfig is the config for the built-in Consul CA provider.
@@ -38,33 +46,78 @@ type ConsulCAProviderConfig struct {
 	RotationPeriod time.Duration
 }
 
+// VaultCAProviderConfig is the config for the Vault CA provider, which
+// uses the PKI secrets engine of Vault to sign the certificates.
+type VaultCAProviderConfig struct {
+	CommonCAProviderConfig `mapstructure:",squash"`
+
+	Address             string
+	Token               string
+	RootPKIPath         string
+	IntermediatePKIPath string
+
+	CAFile        string
+	CAPath        string
+	CertFile      string
+	KeyFile       string
+	TLSServerName string
+	TLSSkipVerify bool
+}
+
 // ParseConsulCAConfig takes a raw config map and returns a parsed
 // ConsulCAProviderConfig.
 func ParseConsulCAConfig(raw map[string]interface{}) (*ConsulCAProviderConfig, error) {
 	var config ConsulCAProviderConfig
+	if err := decodeCAConfig(raw, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// ParseVaultCAConfig takes a raw config map and returns a parsed
+// VaultCAProviderConfig. The token is never returned when reading the CA
+// configuration, so it is empty for a config read from the servers.
+func ParseVaultCAConfig(raw map[string]interface{}) (*VaultCAProviderConfig, error) {
+	var config VaultCAProviderConfig
+	if err := decodeCAConfig(raw, &config); err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// decodeCAConfig decodes a raw config map into the config of a provider.
+func decodeCAConfig(raw map[string]interface{}, config interface{}) error {
 	decodeConf := &mapstructure.DecoderConfig{
 		DecodeHook:       mapstructure.StringToTimeDurationHookFunc(),
-		Result:           &config,
+		Result:           config,
 		WeaklyTypedInput: true,
 	}
 
 	decoder, err := mapstructure.NewDecoder(decodeConf)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := decoder.Decode(raw); err != nil {
-		return nil, fmt.Errorf("error decoding config: %s", err)
+		return fmt.Errorf("error decoding config: %s", err)
 	}
 
-	return &config, nil
+	return nil
 }
 
 // CARootList is the structure for the results of listing roots.
 type CARootList struct {
 	ActiveRootID string
-	TrustDomain  string
-	Roots        []*CARoot
+
+	// TrustDomain is the trust domain of the SPIFFE IDs in the URI SANs of
+	// the certificates signed by the roots.
+	TrustDomain string
+
+	// LeafCertDNSDomains are the DNS domains the leaf certificates have a
+	// "<service>.<domain>" DNS SAN in.
+	LeafCertDNSDomains []string
+
+	Roots []*CARoot
 }
 
 // CARoot represents a root CA certificate that is trusted.
@@ -85,6 +138,17 @@ type CARoot struct {
 	// cannot be active.
 	Active bool
 
+	// IntermediateCerts are the PEM-encoded intermediate certificates
+	// attached to the leaf certificates signed by this CA. After a rotation
+	// they include the certificate of the new root cross-signed by the
+	// previous one.
+	IntermediateCerts []string
+
+	// RotatedOutAt is the time at which this CA stopped being the active
+	// one, zero for the active root. A rotated out root stays trusted until
+	// the leaf certificates it signed expire.
+	RotatedOutAt time.Time
+
 	CreateIndex uint64
 	ModifyIndex uint64
 }
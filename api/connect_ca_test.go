@@ -54,6 +54,31 @@ func TestAPI_ConnectCARoots_list(t *testing.T) {
 
 }
 
+func TestAPI_ConnectCARoots_trustDomain(t *testing.T) {
+	t.Parallel()
+
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+	connect := c.Connect()
+
+	retry.Run(t, func(r *retry.R) {
+		conf, _, err := connect.CAGetConfig(nil)
+		r.Check(err)
+
+		conf.Config["TrustDomain"] = "example.org"
+		conf.Config["LeafCertDNSDomains"] = []string{"svc.example.org"}
+		_, err = connect.CASetConfig(conf, nil)
+		r.Check(err)
+	})
+
+	list, _, err := connect.CARoots(nil)
+	require.NoError(t, err)
+	require.Equal(t, "example.org", list.TrustDomain)
+	require.Equal(t, []string{"svc.example.org"}, list.LeafCertDNSDomains)
+}
+
 func TestAPI_ConnectCAConfig_get_set(t *testing.T) {
 	t.Parallel()
 
@@ -94,3 +119,25 @@ func TestAPI_ConnectCAConfig_get_set(t *testing.T) {
 		require.Equal(r, expected, parsed)
 	})
 }
+
+func TestAPI_ParseVaultCAConfig(t *testing.T) {
+	t.Parallel()
+
+	parsed, err := ParseVaultCAConfig(map[string]interface{}{
+		"Address":             "http://127.0.0.1:8200",
+		"RootPKIPath":         "connect-root",
+		"IntermediatePKIPath": "connect-intermediate",
+		"LeafCertTTL":         "72h",
+		"TLSSkipVerify":       "true",
+	})
+	require.NoError(t, err)
+
+	expected := &VaultCAProviderConfig{
+		Address:             "http://127.0.0.1:8200",
+		RootPKIPath:         "connect-root",
+		IntermediatePKIPath: "connect-intermediate",
+		TLSSkipVerify:       true,
+	}
+	expected.LeafCertTTL = 72 * time.Hour
+	require.Equal(t, expected, parsed)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"time"
+)
+
+// ConnectChangeIntention is the kind of the changes of intentions, the
+// changes of config entries having the kind of the entry.
+const ConnectChangeIntention = "intention"
+
+// ConnectChange is an entry of the changelog of the intentions and config
+// entries kept by the servers.
+type ConnectChange struct {
+	// Index is the Raft index the change was applied at.
+	Index uint64
+
+	// Kind is ConnectChangeIntention or the kind of the config entry.
+	Kind string
+
+	// Name is the ID of the intention or the name of the config entry.
+	Name string
+
+	// SourceName and DestinationName are the services of the intention.
+	SourceName      string
+	DestinationName string
+
+	// Op is the operation of the change: "create", "update" or "delete".
+	Op string
+
+	// Actor is the accessor ID of the token that made the change, if any.
+	Actor string
+
+	// Time is when the change was requested.
+	Time time.Time
+
+	// Before and After are the JSON encoded values before and after the
+	// change, empty when it was created and deleted respectively.
+	Before string
+	After  string
+}
+
+// Changes returns the changelog of the intentions and config entries,
+// ordered from the newest. The kind and name restrict the changes returned
+// if not empty.
+func (h *Connect) Changes(kind, name string, q *QueryOptions) ([]*ConnectChange, *QueryMeta, error) {
+	r := h.c.newRequest("GET", "/v1/connect/changes")
+	r.setQueryOptions(q)
+	if kind != "" {
+		r.params.Set("kind", kind)
+	}
+	if name != "" {
+		r.params.Set("name", name)
+	}
+	rtt, resp, err := requireOK(h.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []*ConnectChange
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
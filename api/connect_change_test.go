@@ -0,0 +1,51 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_ConnectChanges(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	connect := c.Connect()
+
+	// Create, update and delete an intention
+	ixn := testIntention()
+	id, _, err := connect.IntentionCreate(ixn, nil)
+	require.NoError(err)
+	ixn.ID = id
+	ixn.Action = IntentionActionDeny
+	_, err = connect.IntentionUpdate(ixn, nil)
+	require.NoError(err)
+	_, err = connect.IntentionDelete(id, nil)
+	require.NoError(err)
+
+	changes, qm, err := connect.Changes(ConnectChangeIntention, id, nil)
+	require.NoError(err)
+	require.NotZero(qm.LastIndex)
+	require.Len(changes, 3)
+
+	var ops []string
+	for _, change := range changes {
+		require.Equal(ConnectChangeIntention, change.Kind)
+		require.Equal(id, change.Name)
+		require.Equal(ixn.SourceName, change.SourceName)
+		require.Equal(ixn.DestinationName, change.DestinationName)
+		ops = append(ops, change.Op)
+	}
+	require.Equal([]string{"delete", "update", "create"}, ops)
+	require.Empty(changes[0].After)
+	require.Contains(changes[1].After, `"Action":"deny"`)
+	require.Empty(changes[2].Before)
+
+	// Other kinds have no changes
+	changes, _, err = connect.Changes("service-defaults", "", nil)
+	require.NoError(err)
+	require.Len(changes, 0)
+}
@@ -36,6 +36,12 @@ type Intention struct {
 	// Action is whether this is a whitelist or blacklist intention.
 	Action IntentionAction
 
+	// Permissions is an ordered list of L7 permissions applied to the HTTP
+	// requests of the connections matching this intention. The action of the
+	// first permission matching a request is applied, and Action is applied
+	// to the requests matching none of them.
+	Permissions []*IntentionPermission `json:",omitempty"`
+
 	// DefaultAddr, DefaultPort of the local listening proxy (if any) to
 	// make this connection.
 	DefaultAddr string
@@ -58,6 +64,39 @@ type Intention struct {
 	ModifyIndex uint64
 }
 
+// IntentionPermission is an L7 permission of an intention, applying its action
+// to the requests it matches.
+type IntentionPermission struct {
+	Action IntentionAction
+	HTTP   *IntentionHTTPPermission
+}
+
+// IntentionHTTPPermission matches HTTP requests on their path, headers and
+// method. A request must match all of the set attributes, and only one of
+// the path attributes can be set.
+type IntentionHTTPPermission struct {
+	PathExact  string `json:",omitempty"`
+	PathPrefix string `json:",omitempty"`
+	PathRegex  string `json:",omitempty"`
+
+	Header []IntentionHTTPHeaderPermission `json:",omitempty"`
+
+	// Methods is a list of upper case HTTP methods, one of them must match.
+	Methods []string `json:",omitempty"`
+}
+
+// IntentionHTTPHeaderPermission matches a header of an HTTP request. Only one
+// of Present, Exact, Prefix, Suffix and Regex can be set.
+type IntentionHTTPHeaderPermission struct {
+	Name    string
+	Present bool   `json:",omitempty"`
+	Exact   string `json:",omitempty"`
+	Prefix  string `json:",omitempty"`
+	Suffix  string `json:",omitempty"`
+	Regex   string `json:",omitempty"`
+	Invert  bool   `json:",omitempty"`
+}
+
 // String returns human-friendly output describing ths intention.
 func (i *Intention) String() string {
 	return fmt.Sprintf("%s => %s (%s)",
@@ -136,6 +175,34 @@ type IntentionCheck struct {
 	SourceType IntentionSourceType
 }
 
+// IntentionSuggestion is a source and destination that connected without
+// matching any intention, as reported by the agents with intention
+// suggestions enabled.
+type IntentionSuggestion struct {
+	SourceNS, SourceName           string
+	DestinationNS, DestinationName string
+
+	// Allowed and Denied are the number of connections allowed and denied
+	// by the default behavior.
+	Allowed uint64
+	Denied  uint64
+
+	// LastSeen is the time of the last connection.
+	LastSeen time.Time
+}
+
+// Intention returns an intention allowing the suggested connection.
+func (s *IntentionSuggestion) Intention() *Intention {
+	return &Intention{
+		SourceNS:        s.SourceNS,
+		SourceName:      s.SourceName,
+		DestinationNS:   s.DestinationNS,
+		DestinationName: s.DestinationName,
+		SourceType:      IntentionSourceConsul,
+		Action:          IntentionActionAllow,
+	}
+}
+
 // Intentions returns the list of intentions.
 func (h *Connect) Intentions(q *QueryOptions) ([]*Intention, *QueryMeta, error) {
 	r := h.c.newRequest("GET", "/v1/connect/intentions")
@@ -261,6 +328,29 @@ func (h *Connect) IntentionCheck(args *IntentionCheck, q *QueryOptions) (bool, *
 	return out.Allowed, qm, nil
 }
 
+// IntentionSuggestions returns the connections observed between services
+// that aren't covered by any intention yet. The observations are held in
+// memory by the leader and are reset when the leadership changes.
+func (h *Connect) IntentionSuggestions(q *QueryOptions) ([]*IntentionSuggestion, *QueryMeta, error) {
+	r := h.c.newRequest("GET", "/v1/connect/intentions/suggestions")
+	r.setQueryOptions(q)
+	rtt, resp, err := requireOK(h.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out []*IntentionSuggestion
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
+
 // IntentionCreate will create a new intention. The ID in the given
 // structure must be empty and a generate ID will be returned on
 // success.
@@ -173,6 +173,76 @@ func TestAPI_ConnectIntentionCheck(t *testing.T) {
 	}
 }
 
+func TestAPI_ConnectIntentionSuggestions(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	// No agent reports the connections by default
+	suggestions, qm, err := c.Connect().IntentionSuggestions(nil)
+	require.NoError(err)
+	require.Empty(suggestions)
+	require.True(qm.KnownLeader)
+
+	suggestion := &IntentionSuggestion{SourceName: "web", DestinationName: "db"}
+	require.Equal("web => db (allow)", suggestion.Intention().String())
+}
+
+func TestAPI_ConnectIntentionPermissions(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	// Only allow the read requests from web to db
+	ixn := &Intention{
+		SourceNS:        "default",
+		SourceName:      "web",
+		DestinationNS:   "default",
+		DestinationName: "db",
+		Action:          IntentionActionDeny,
+		SourceType:      IntentionSourceConsul,
+		Permissions: []*IntentionPermission{
+			{
+				Action: IntentionActionAllow,
+				HTTP: &IntentionHTTPPermission{
+					PathPrefix: "/",
+					Methods:    []string{"GET", "HEAD"},
+				},
+			},
+		},
+	}
+	id, _, err := c.Connect().IntentionCreate(ixn, nil)
+	require.NoError(err)
+
+	actual, _, err := c.Connect().IntentionGet(id, nil)
+	require.NoError(err)
+	require.Equal(ixn.Permissions, actual.Permissions)
+
+	params := &AgentAuthorizeParams{
+		Target:        "db",
+		ClientCertURI: "spiffe://11111111-2222-3333-4444-555555555555.consul/ns/default/dc/dc1/svc/web",
+		HTTP:          &AgentAuthorizeHTTPParams{Method: "GET", Path: "/users"},
+	}
+	auth, err := c.Agent().ConnectAuthorize(params)
+	require.NoError(err)
+	require.True(auth.Authorized)
+
+	params.HTTP.Method = "DELETE"
+	auth, err = c.Agent().ConnectAuthorize(params)
+	require.NoError(err)
+	require.False(auth.Authorized)
+
+	// Invalid permissions are rejected
+	ixn.Permissions[0].HTTP.Methods = []string{"get"}
+	_, _, err = c.Connect().IntentionCreate(ixn, nil)
+	require.Error(err)
+	require.Contains(err.Error(), "must be upper case")
+}
+
 func testIntention() *Intention {
 	return &Intention{
 		SourceNS:        "eng",
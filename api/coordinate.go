@@ -1,6 +1,9 @@
 package api
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/hashicorp/serf/coordinate"
 )
 
@@ -104,3 +107,79 @@ func (c *Coordinate) Node(node string, q *QueryOptions) ([]*CoordinateEntry, *Qu
 	}
 	return out, qm, nil
 }
+
+// RTT estimates the round trip time between two nodes of the LAN pool from
+// their network coordinates.
+func (c *Coordinate) RTT(nodeA, nodeB string, q *QueryOptions) (time.Duration, error) {
+	matrix, err := c.RTTMatrix([]string{nodeA, nodeB}, q)
+	if err != nil {
+		return 0, err
+	}
+	rtt, ok := matrix[nodeA][nodeB]
+	if !ok {
+		return 0, fmt.Errorf("nodes %q and %q have no coordinates in a common network segment", nodeA, nodeB)
+	}
+	return rtt, nil
+}
+
+// RTTMatrix estimates the round trip times between all the pairs of the given
+// nodes of the LAN pool, from their network coordinates fetched with a single
+// request. The estimate between nodes a and b is at matrix[a][b]. The pairs of
+// nodes without coordinates in a common network segment are left out.
+func (c *Coordinate) RTTMatrix(nodes []string, q *QueryOptions) (map[string]map[string]time.Duration, error) {
+	entries, _, err := c.Nodes(q)
+	if err != nil {
+		return nil, err
+	}
+
+	// Index the coordinates of the requested nodes by segment.
+	sets := make(map[string]map[string]*coordinate.Coordinate, len(nodes))
+	for _, node := range nodes {
+		sets[node] = nil
+	}
+	for _, entry := range entries {
+		if set, ok := sets[entry.Node]; ok && entry.Coord != nil {
+			if set == nil {
+				set = make(map[string]*coordinate.Coordinate)
+				sets[entry.Node] = set
+			}
+			set[entry.Segment] = entry.Coord
+		}
+	}
+	for _, node := range nodes {
+		if sets[node] == nil {
+			return nil, fmt.Errorf("could not find a coordinate for node %q", node)
+		}
+	}
+
+	matrix := make(map[string]map[string]time.Duration, len(nodes))
+	for _, a := range nodes {
+		matrix[a] = make(map[string]time.Duration, len(nodes))
+		for _, b := range nodes {
+			coordA, coordB := intersectCoordinates(sets[a], sets[b])
+			if coordA != nil && coordB != nil {
+				matrix[a][b] = coordA.DistanceTo(coordB)
+			}
+		}
+	}
+	return matrix, nil
+}
+
+// intersectCoordinates returns the coordinates of two nodes in a common
+// network segment, or nil if there is none. A node with a single segment is
+// possibly a client and its segment takes priority, a node with more than one
+// segment can only be a server, which is in all the segments.
+func intersectCoordinates(a, b map[string]*coordinate.Coordinate) (*coordinate.Coordinate, *coordinate.Coordinate) {
+	segment := ""
+	if len(a) == 1 {
+		for s := range a {
+			segment = s
+		}
+	}
+	if len(b) == 1 {
+		for s := range b {
+			segment = s
+		}
+	}
+	return a[segment], b[segment]
+}
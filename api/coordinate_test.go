@@ -105,3 +105,44 @@ func TestAPI_CoordinateUpdate(t *testing.T) {
 		require.Equal(r, entry, coords[0])
 	})
 }
+
+func TestAPI_CoordinateRTT(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+	coord := c.Coordinate()
+	for i, node := range []string{"foo", "bar"} {
+		_, err := c.Catalog().Register(&CatalogRegistration{
+			Node:    node,
+			Address: "1.1.1.1",
+		}, nil)
+		require.NoError(t, err)
+
+		newCoord := coordinate.NewCoordinate(coordinate.DefaultConfig())
+		newCoord.Vec[0] = float64(i) * 0.1
+		_, err = coord.Update(&CoordinateEntry{Node: node, Coord: newCoord}, nil)
+		require.NoError(t, err)
+	}
+
+	retryer := &retry.Timer{Timeout: 5 * time.Second, Wait: 1 * time.Second}
+	retry.RunWith(retryer, t, func(r *retry.R) {
+		rtt, err := coord.RTT("foo", "bar", nil)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if rtt < 100*time.Millisecond {
+			r.Fatalf("bad: %v", rtt)
+		}
+	})
+
+	matrix, err := coord.RTTMatrix([]string{"foo", "bar"}, nil)
+	require.NoError(t, err)
+	require.Equal(t, matrix["foo"]["bar"], matrix["bar"]["foo"])
+	require.Contains(t, matrix["foo"], "foo")
+
+	_, err = coord.RTT("foo", "nope", nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `"nope"`)
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	// DiscoveryGraphNodeTypeResolver is the type of the nodes resolving a
+	// target to healthy instances.
+	DiscoveryGraphNodeTypeResolver = "resolver"
+)
+
+// DiscoveryChain can be used to query the discovery chain endpoints.
+type DiscoveryChain struct {
+	c *Client
+}
+
+// DiscoveryChain returns a handle to the discovery chain endpoints.
+func (c *Client) DiscoveryChain() *DiscoveryChain {
+	return &DiscoveryChain{c}
+}
+
+// DiscoveryChainOptions are the optional parameters of the compilation of a
+// discovery chain.
+type DiscoveryChainOptions struct {
+	// EvaluateInDatacenter is the datacenter the chain is compiled for.
+	// Defaults to the datacenter of the agent.
+	EvaluateInDatacenter string `json:"-"`
+
+	// OverrideProtocol and OverrideConnectTimeout replace the values set by
+	// the config entries, to test changes before writing them.
+	OverrideProtocol       string        `json:",omitempty"`
+	OverrideConnectTimeout time.Duration `json:",omitempty"`
+}
+
+func (o *DiscoveryChainOptions) requiresPOST() bool {
+	if o == nil {
+		return false
+	}
+	return o.OverrideProtocol != "" || o.OverrideConnectTimeout != 0
+}
+
+// DiscoveryChainResponse is the response of DiscoveryChain.Get.
+type DiscoveryChainResponse struct {
+	Chain *CompiledDiscoveryChain
+}
+
+// CompiledDiscoveryChain is the graph describing how the traffic sent to a
+// service is routed and resolved, compiled from the config entries.
+type CompiledDiscoveryChain struct {
+	ServiceName string
+	Datacenter  string
+
+	// CustomizationHash is set when the chain was compiled with overrides.
+	CustomizationHash string
+
+	// Protocol is the protocol spoken by the service.
+	Protocol string
+
+	// StartNode is the name of the node the traffic enters the graph at.
+	StartNode string
+
+	// Nodes are the nodes of the graph by name.
+	Nodes map[string]*DiscoveryGraphNode
+
+	// Targets are the targets of the resolvers by ID.
+	Targets map[string]*DiscoveryTarget
+}
+
+// DiscoveryGraphNode is a single node of a compiled discovery chain.
+type DiscoveryGraphNode struct {
+	Type string
+	Name string
+
+	// Resolver is set for the nodes of type resolver.
+	Resolver *DiscoveryResolver
+}
+
+// DiscoveryResolver resolves a target to its healthy instances.
+type DiscoveryResolver struct {
+	// Default is true when no config entry customized the resolver.
+	Default        bool
+	ConnectTimeout time.Duration
+	Target         string
+
+	// Failover is set when the service resolver fails over to other
+	// datacenters.
+	Failover *DiscoveryFailover
+}
+
+// DiscoveryFailover is the plan of a resolver to fail over to when its target
+// has no healthy instances.
+type DiscoveryFailover struct {
+	// Targets are the IDs of the targets to fail over to, in order.
+	Targets []string
+}
+
+// DiscoveryTarget is the set of instances a resolver sends the traffic to.
+type DiscoveryTarget struct {
+	ID          string
+	Service     string
+	Datacenter  string
+	MeshGateway MeshGatewayConfig `json:",omitempty"`
+}
+
+// Get returns the compiled discovery chain of the given service. The chain
+// is compiled with the overrides of the options, if any.
+func (d *DiscoveryChain) Get(name string, opts *DiscoveryChainOptions, q *QueryOptions) (*DiscoveryChainResponse, *QueryMeta, error) {
+	if name == "" {
+		return nil, nil, fmt.Errorf("Name parameter must not be empty")
+	}
+
+	method := "GET"
+	if opts.requiresPOST() {
+		method = "POST"
+	}
+
+	r := d.c.newRequest(method, "/v1/discovery-chain/"+name)
+	r.setQueryOptions(q)
+	if opts != nil {
+		if opts.EvaluateInDatacenter != "" {
+			r.params.Set("compile-dc", opts.EvaluateInDatacenter)
+		}
+		if method == "POST" {
+			r.obj = opts
+		}
+	}
+
+	rtt, resp, err := requireOK(d.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out DiscoveryChainResponse
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return &out, qm, nil
+}
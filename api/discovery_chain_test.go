@@ -0,0 +1,75 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_DiscoveryChain_Get(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	chains := c.DiscoveryChain()
+
+	_, err := c.ConfigEntries().Set(&ServiceConfigEntry{
+		Kind:     ServiceDefaults,
+		Name:     "web",
+		Protocol: "http",
+	}, nil)
+	require.NoError(t, err)
+
+	resp, qm, err := chains.Get("web", nil, nil)
+	require.NoError(t, err)
+	require.NotZero(t, qm.LastIndex)
+
+	chain := resp.Chain
+	require.Equal(t, "web", chain.ServiceName)
+	require.Equal(t, "http", chain.Protocol)
+	require.Empty(t, chain.CustomizationHash)
+
+	node := chain.Nodes[chain.StartNode]
+	require.NotNil(t, node)
+	require.Equal(t, DiscoveryGraphNodeTypeResolver, node.Type)
+	require.True(t, node.Resolver.Default)
+	require.Contains(t, chain.Targets, node.Resolver.Target)
+
+	// With overrides
+	resp, _, err = chains.Get("web", &DiscoveryChainOptions{
+		EvaluateInDatacenter:   "dc2",
+		OverrideProtocol:       "grpc",
+		OverrideConnectTimeout: 2 * time.Second,
+	}, nil)
+	require.NoError(t, err)
+
+	chain = resp.Chain
+	require.Equal(t, "dc2", chain.Datacenter)
+	require.Equal(t, "grpc", chain.Protocol)
+	require.NotEmpty(t, chain.CustomizationHash)
+	require.Equal(t, 2*time.Second, chain.Nodes[chain.StartNode].Resolver.ConnectTimeout)
+
+	// With a service resolver failing over to another datacenter
+	_, err = c.ConfigEntries().Set(&ServiceResolverConfigEntry{
+		Kind:           ServiceResolver,
+		Name:           "web",
+		ConnectTimeout: 3 * time.Second,
+		Failover:       &ServiceResolverFailover{Datacenters: []string{"dc2"}},
+	}, nil)
+	require.NoError(t, err)
+
+	resp, _, err = chains.Get("web", nil, nil)
+	require.NoError(t, err)
+
+	chain = resp.Chain
+	resolver := chain.Nodes[chain.StartNode].Resolver
+	require.False(t, resolver.Default)
+	require.Equal(t, 3*time.Second, resolver.ConnectTimeout)
+	require.NotNil(t, resolver.Failover)
+	require.Equal(t, []string{"web.dc2"}, resolver.Failover.Targets)
+	require.Equal(t, "dc2", chain.Targets["web.dc2"].Datacenter)
+
+	_, _, err = chains.Get("", nil, nil)
+	require.Error(t, err)
+}
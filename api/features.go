@@ -0,0 +1,80 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Feature flags that can be reported by Client.Features.
+const (
+	FeatureACLNamePrefix          = "acl.name_prefix"
+	FeatureACLNamespaces          = "acl.namespaces"
+	FeatureACLServiceTokens       = "acl.service_tokens"
+	FeatureAgentCache             = "agent.cache"
+	FeatureChecksComposite        = "checks.composite"
+	FeatureConfigEntries          = "config_entries"
+	FeatureConnect                = "connect"
+	FeatureConnectDiscoveryChain  = "connect.discovery_chain"
+	FeatureConnectIngressGateways = "connect.ingress_gateways"
+	FeatureConnectL7Intentions    = "connect.l7_intentions"
+	FeatureConnectMeshGateways    = "connect.mesh_gateways"
+	FeatureHealthStream           = "health.stream"
+	FeatureKVChunked              = "kv.chunked"
+	FeatureKVDeleteTreeCAS        = "kv.delete_tree_cas"
+	FeatureKVFilter               = "kv.filter"
+	FeatureKVTTL                  = "kv.ttl"
+	FeatureLabels                 = "labels"
+	FeaturePreparedQueryStats     = "prepared_query.stats"
+	FeatureStreaming              = "streaming"
+	FeatureTxnCatalogConnect      = "txn.catalog_connect"
+)
+
+// Features is the set of API features supported by the agent.
+type Features struct {
+	// Version is the version of the agent, it is empty if the agent doesn't
+	// support the features endpoint.
+	Version string
+
+	// Features is the sorted list of the supported features.
+	Features []string
+}
+
+// Has returns whether the given feature is supported.
+func (f *Features) Has(feature string) bool {
+	if f == nil {
+		return false
+	}
+	for _, supported := range f.Features {
+		if supported == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Features returns the API features supported by the agent, so callers can
+// degrade gracefully on older agents instead of probing endpoints. Agents that
+// predate the features endpoint are reported with no features.
+func (c *Client) Features() (*Features, error) {
+	r := c.newRequest("GET", "/v1/agent/features")
+	_, resp, err := c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return &Features{}, nil
+	} else if resp.StatusCode != 200 {
+		var buf bytes.Buffer
+		io.Copy(&buf, resp.Body)
+		return nil, fmt.Errorf("Unexpected response code: %d (%s)", resp.StatusCode, buf.Bytes())
+	}
+
+	var out Features
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_Features(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	features, err := c.Features()
+	require.NoError(t, err)
+	require.NotEmpty(t, features.Version)
+	require.True(t, features.Has(FeatureAgentCache))
+	require.False(t, features.Has("not-a-feature"))
+}
+
+func TestAPI_Features_OldAgent(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+
+	c, err := NewClient(&Config{Address: srv.Listener.Addr().String()})
+	require.NoError(t, err)
+
+	features, err := c.Features()
+	require.NoError(t, err)
+	require.Empty(t, features.Features)
+	require.False(t, features.Has(FeatureStreaming))
+}
@@ -51,6 +51,10 @@ type HealthCheckDefinition struct {
 	Method                                 string
 	TLSSkipVerify                          bool
 	TCP                                    string
+	GRPC                                   string
+	GRPCUseTLS                             bool
+	ScriptArgs                             []string
+	TTL                                    time.Duration `json:"-"`
 	IntervalDuration                       time.Duration `json:"-"`
 	TimeoutDuration                        time.Duration `json:"-"`
 	DeregisterCriticalServiceAfterDuration time.Duration `json:"-"`
@@ -64,6 +68,7 @@ type HealthCheckDefinition struct {
 func (d *HealthCheckDefinition) MarshalJSON() ([]byte, error) {
 	type Alias HealthCheckDefinition
 	out := &struct {
+		TTL                            string `json:",omitempty"`
 		Interval                       string
 		Timeout                        string
 		DeregisterCriticalServiceAfter string
@@ -75,6 +80,9 @@ func (d *HealthCheckDefinition) MarshalJSON() ([]byte, error) {
 		Alias:                          (*Alias)(d),
 	}
 
+	if d.TTL != 0 {
+		out.TTL = d.TTL.String()
+	}
 	if d.IntervalDuration != 0 {
 		out.Interval = d.IntervalDuration.String()
 	} else if d.Interval != 0 {
@@ -97,6 +105,7 @@ func (d *HealthCheckDefinition) MarshalJSON() ([]byte, error) {
 func (d *HealthCheckDefinition) UnmarshalJSON(data []byte) error {
 	type Alias HealthCheckDefinition
 	aux := &struct {
+		TTL                            string
 		Interval                       string
 		Timeout                        string
 		DeregisterCriticalServiceAfter string
@@ -110,6 +119,11 @@ func (d *HealthCheckDefinition) UnmarshalJSON(data []byte) error {
 
 	// Parse the values into both the time.Duration and old ReadableDuration fields.
 	var err error
+	if aux.TTL != "" {
+		if d.TTL, err = time.ParseDuration(aux.TTL); err != nil {
+			return err
+		}
+	}
 	if aux.Interval != "" {
 		if d.IntervalDuration, err = time.ParseDuration(aux.Interval); err != nil {
 			return err
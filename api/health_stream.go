@@ -0,0 +1,41 @@
+package api
+
+import (
+	"fmt"
+)
+
+// HealthStream is an active stream of the health events of the instances of
+// a service, served by the agent over HTTP as server-sent events.
+type HealthStream struct {
+	stream *eventStream
+}
+
+// ServiceStream streams the health events of the instances of the given
+// service, as the Subscribe().Health stream does. The first events describe
+// the current state of the instances, up to the one with EndOfSnapshot set,
+// and the following ones the changes, with their previous status. The
+// Datacenter, Token and WaitIndex fields of q are honored, events up to
+// WaitIndex are skipped after the initial snapshot. The stream holds a
+// request slot of the client until it's closed.
+func (h *Health) ServiceStream(service string, q *QueryOptions) (*HealthStream, error) {
+	if service == "" {
+		return nil, fmt.Errorf("missing service name")
+	}
+
+	stream, err := h.c.openEventStream("/v1/health/stream/service/"+service, q)
+	if err != nil {
+		return nil, err
+	}
+	return &HealthStream{stream: stream}, nil
+}
+
+// Next blocks until the next event is received. It returns io.EOF once the
+// agent ends the stream and an error once the stream is closed.
+func (s *HealthStream) Next() (*SubscribeEvent, error) {
+	return s.stream.next()
+}
+
+// Close ends the stream.
+func (s *HealthStream) Close() {
+	s.stream.close()
+}
@@ -0,0 +1,78 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_HealthServiceStream(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	_, err := c.Health().ServiceStream("", nil)
+	require.Error(t, err)
+
+	require.NoError(t, c.Agent().ServiceRegister(&AgentServiceRegistration{
+		ID:   "web1",
+		Name: "web",
+		Port: 8080,
+		Check: &AgentServiceCheck{
+			TTL: "10m",
+		},
+	}))
+
+	var stream *HealthStream
+	// The service registration is synced to the catalog asynchronously.
+	for i := 0; i < 50; i++ {
+		stream, err = c.Health().ServiceStream("web", nil)
+		require.NoError(t, err)
+
+		e, err := stream.Next()
+		require.NoError(t, err)
+		if !e.EndOfSnapshot {
+			require.Equal(t, SubscribeOpUpsert, e.Op)
+			require.Equal(t, "web1", e.ServiceHealth.Service.ID)
+			require.Equal(t, HealthCritical, e.Status)
+			require.Empty(t, e.PreviousStatus)
+			break
+		}
+		stream.Close()
+		stream = nil
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NotNil(t, stream, "service never showed up")
+	defer stream.Close()
+
+	e, err := stream.Next()
+	require.NoError(t, err)
+	require.True(t, e.EndOfSnapshot)
+
+	// The transitions carry the previous status.
+	require.NoError(t, c.Agent().PassTTL("service:web1", ""))
+	e, err = stream.Next()
+	require.NoError(t, err)
+	require.Equal(t, SubscribeOpUpsert, e.Op)
+	require.Equal(t, HealthPassing, e.Status)
+	require.Equal(t, HealthCritical, e.PreviousStatus)
+
+	require.NoError(t, c.Agent().ServiceDeregister("web1"))
+	for {
+		e, err := stream.Next()
+		require.NoError(t, err)
+		if e.Op == SubscribeOpDelete {
+			require.Equal(t, "web1", e.ServiceHealth.Service.ID)
+			require.Equal(t, HealthPassing, e.PreviousStatus)
+			break
+		}
+	}
+
+	// Reads fail once closed.
+	stream.Close()
+	_, err = stream.Next()
+	require.Error(t, err)
+}
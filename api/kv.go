@@ -2,11 +2,14 @@ package api
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // KVPair is used to represent a single K/V entry
@@ -40,6 +43,16 @@ type KVPair struct {
 	// interactions with this key over the same session must specify the same
 	// session ID.
 	Session string
+
+	// TTL is the optional duration after which the key is deleted by the
+	// servers, unless it is written again before. Each write sets the TTL
+	// of the key, or clears it when empty.
+	TTL string `json:",omitempty"`
+
+	// Labels are arbitrary key/value pairs organizing the keys, which can be
+	// selected with the LabelSelector of the QueryOptions of List. Each
+	// write sets the labels of the key, or clears them when empty.
+	Labels map[string]string `json:",omitempty"`
 }
 
 // KVPairs is a list of KVPair objects
@@ -58,7 +71,19 @@ func (c *Client) KV() *KV {
 // Get is used to lookup a single key. The returned pointer
 // to the KVPair will be nil if the key does not exist.
 func (k *KV) Get(key string, q *QueryOptions) (*KVPair, *QueryMeta, error) {
-	resp, qm, err := k.getInternal(key, nil, q)
+	return k.getOne(key, nil, q)
+}
+
+// GetLarge is used to lookup a single key written with PutLarge, whose value
+// is reassembled from its chunks by the agent. Keys written with Put are
+// returned as is. The returned pointer to the KVPair will be nil if the key
+// does not exist.
+func (k *KV) GetLarge(key string, q *QueryOptions) (*KVPair, *QueryMeta, error) {
+	return k.getOne(key, map[string]string{"chunked": ""}, q)
+}
+
+func (k *KV) getOne(key string, params map[string]string, q *QueryOptions) (*KVPair, *QueryMeta, error) {
+	resp, qm, err := k.getInternal(key, params, q)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -95,6 +120,41 @@ func (k *KV) List(prefix string, q *QueryOptions) (KVPairs, *QueryMeta, error) {
 	return entries, qm, nil
 }
 
+// ListFunc is used to lookup all the keys under a prefix like List, but
+// calls fn with each pair as it is decoded from the response instead of
+// buffering them, so that huge prefixes can be processed with a bounded
+// memory usage. It stops at the first error returned by fn.
+func (k *KV) ListFunc(prefix string, q *QueryOptions, fn func(*KVPair) error) (*QueryMeta, error) {
+	resp, qm, err := k.getInternal(prefix, map[string]string{"recurse": ""}, q)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return qm, nil
+	}
+	defer resp.Body.Close()
+
+	dec := json.NewDecoder(resp.Body)
+	if tok, err := dec.Token(); err != nil {
+		return nil, err
+	} else if tok != json.Delim('[') {
+		return nil, fmt.Errorf("Unexpected response, expected a list of pairs")
+	}
+	for dec.More() {
+		var pair KVPair
+		if err := dec.Decode(&pair); err != nil {
+			return nil, err
+		}
+		if err := fn(&pair); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return qm, nil
+}
+
 // Keys is used to list all the keys under a prefix. Optionally,
 // a separator can be used to limit the responses.
 func (k *KV) Keys(prefix, separator string, q *QueryOptions) ([]string, *QueryMeta, error) {
@@ -150,10 +210,42 @@ func (k *KV) Put(p *KVPair, q *WriteOptions) (*WriteMeta, error) {
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != "" {
+		params["ttl"] = p.TTL
+	}
+	if len(p.Labels) > 0 {
+		params["labels"] = encodeLabels(p.Labels)
+	}
 	_, wm, err := k.put(p.Key, params, p.Value, q)
 	return wm, err
 }
 
+// PutLarge is used to write a key/value pair whose value can exceed the size
+// limit of a single key. The agent splits such values into chunks stored
+// under the "<key>/.chunks/" prefix, hidden from the listings, and writes
+// them along with a manifest referring to them as the value of the key in a
+// single transaction. Such values must be read with GetLarge and deleted with
+// DeleteLarge. The ModifyIndex is used for a Check-And-Set operation when
+// non-zero.
+func (k *KV) PutLarge(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
+	params := map[string]string{"chunked": ""}
+	if p.Flags != 0 {
+		params["flags"] = strconv.FormatUint(p.Flags, 10)
+	}
+	if p.ModifyIndex != 0 {
+		params["cas"] = strconv.FormatUint(p.ModifyIndex, 10)
+	}
+	return k.put(p.Key, params, p.Value, q)
+}
+
+// SetWithTTL is used to write a key/value pair which is deleted by the
+// servers once the given TTL is reached, unless it is written again before.
+func (k *KV) SetWithTTL(p *KVPair, ttl time.Duration, q *WriteOptions) (*WriteMeta, error) {
+	pair := *p
+	pair.TTL = ttl.String()
+	return k.Put(&pair, q)
+}
+
 // CAS is used for a Check-And-Set operation. The Key,
 // ModifyIndex, Flags and Value are respected. Returns true
 // on success or false on failures.
@@ -162,6 +254,12 @@ func (k *KV) CAS(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != "" {
+		params["ttl"] = p.TTL
+	}
+	if len(p.Labels) > 0 {
+		params["labels"] = encodeLabels(p.Labels)
+	}
 	params["cas"] = strconv.FormatUint(p.ModifyIndex, 10)
 	return k.put(p.Key, params, p.Value, q)
 }
@@ -174,6 +272,12 @@ func (k *KV) Acquire(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != "" {
+		params["ttl"] = p.TTL
+	}
+	if len(p.Labels) > 0 {
+		params["labels"] = encodeLabels(p.Labels)
+	}
 	params["acquire"] = p.Session
 	return k.put(p.Key, params, p.Value, q)
 }
@@ -186,6 +290,12 @@ func (k *KV) Release(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
 	if p.Flags != 0 {
 		params["flags"] = strconv.FormatUint(p.Flags, 10)
 	}
+	if p.TTL != "" {
+		params["ttl"] = p.TTL
+	}
+	if len(p.Labels) > 0 {
+		params["labels"] = encodeLabels(p.Labels)
+	}
 	params["release"] = p.Session
 	return k.put(p.Key, params, p.Value, q)
 }
@@ -218,12 +328,30 @@ func (k *KV) put(key string, params map[string]string, body []byte, q *WriteOpti
 	return res, qm, nil
 }
 
+// encodeLabels encodes the labels of a key as the comma separated list of
+// key=value pairs expected by the ?labels param, sorted by key.
+func encodeLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
 // Delete is used to delete a single key
 func (k *KV) Delete(key string, w *WriteOptions) (*WriteMeta, error) {
 	_, qm, err := k.deleteInternal(key, nil, w)
 	return qm, err
 }
 
+// DeleteLarge is used to delete a single key written with PutLarge along
+// with its chunks.
+func (k *KV) DeleteLarge(key string, w *WriteOptions) (*WriteMeta, error) {
+	_, qm, err := k.deleteInternal(key, map[string]string{"chunked": ""}, w)
+	return qm, err
+}
+
 // DeleteCAS is used for a Delete Check-And-Set operation. The Key
 // and ModifyIndex are respected. Returns true on success or false on failures.
 func (k *KV) DeleteCAS(p *KVPair, q *WriteOptions) (bool, *WriteMeta, error) {
@@ -2,10 +2,13 @@ package api
 
 import (
 	"bytes"
+	"fmt"
 	"path"
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
 )
 
 func TestAPI_ClientPutGetDelete(t *testing.T) {
@@ -74,6 +77,217 @@ func TestAPI_ClientPutGetDelete(t *testing.T) {
 	}
 }
 
+func TestAPI_ClientPutGetDeleteLarge(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+
+	key := testKey()
+	value := bytes.Repeat([]byte("test"), 300*1024)
+	ok, _, err := kv.PutLarge(&KVPair{Key: key, Flags: 42, Value: value}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !ok {
+		t.Fatalf("should work")
+	}
+
+	pair, _, err := kv.GetLarge(key, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair == nil || !bytes.Equal(pair.Value, value) || pair.Flags != 42 {
+		t.Fatalf("unexpected value: %#v", pair)
+	}
+
+	// A CAS with a stale index fails
+	ok, _, err = kv.PutLarge(&KVPair{Key: key, Value: value, ModifyIndex: pair.ModifyIndex - 1}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if ok {
+		t.Fatalf("should fail")
+	}
+
+	if _, err := kv.DeleteLarge(key, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	pairs, _, err := kv.List(key, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(pairs) != 0 {
+		t.Fatalf("unexpected value: %#v", pairs)
+	}
+}
+
+func TestAPI_ClientSetWithTTL(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+
+	key := testKey()
+	p := &KVPair{Key: key, Flags: 42, Value: []byte("test")}
+	if _, err := kv.SetWithTTL(p, time.Second, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if p.TTL != "" {
+		t.Fatalf("should not modify the pair: %#v", p)
+	}
+
+	pair, _, err := kv.Get(key, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if pair == nil || pair.TTL != "1s" || pair.Flags != 42 {
+		t.Fatalf("unexpected value: %#v", pair)
+	}
+
+	// The key expires
+	retry.Run(t, func(r *retry.R) {
+		pair, _, err := kv.Get(key, nil)
+		if err != nil {
+			r.Fatalf("err: %v", err)
+		}
+		if pair != nil {
+			r.Fatalf("unexpected value: %#v", pair)
+		}
+	})
+}
+
+func TestAPI_ClientList_Filter(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+
+	prefix := testKey()
+	for i := 0; i < 10; i++ {
+		p := &KVPair{Key: path.Join(prefix, testKey()), Flags: uint64(i % 2), Value: []byte("test")}
+		if _, err := kv.Put(p, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	pairs, _, err := kv.List(prefix, &QueryOptions{Filter: `Flags == 1 and Value == test`})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(pairs) != 5 {
+		t.Fatalf("got %d keys", len(pairs))
+	}
+	for _, pair := range pairs {
+		if pair.Flags != 1 {
+			t.Fatalf("unexpected value: %#v", pair)
+		}
+	}
+
+	// Invalid filters are rejected
+	if _, _, err := kv.List(prefix, &QueryOptions{Filter: `Flags == test`}); err == nil {
+		t.Fatalf("should have failed")
+	}
+}
+
+func TestAPI_ClientList_LabelSelector(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+
+	prefix := testKey()
+	for i := 0; i < 10; i++ {
+		env := "prod"
+		if i%2 == 0 {
+			env = "dev"
+		}
+		p := &KVPair{
+			Key:    path.Join(prefix, testKey()),
+			Value:  []byte("test"),
+			Labels: map[string]string{"team": "payments", "env": env},
+		}
+		if _, err := kv.Put(p, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	pairs, _, err := kv.List(prefix, &QueryOptions{LabelSelector: "team=payments,env!=dev"})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(pairs) != 5 {
+		t.Fatalf("got %d keys", len(pairs))
+	}
+	for _, pair := range pairs {
+		if pair.Labels["env"] != "prod" {
+			t.Fatalf("unexpected value: %#v", pair)
+		}
+	}
+
+	// Invalid selectors are rejected
+	if _, _, err := kv.List(prefix, &QueryOptions{LabelSelector: "env=pro d"}); err == nil {
+		t.Fatalf("should have failed")
+	}
+}
+
+func TestAPI_ClientListFunc(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+
+	prefix := testKey()
+	for i := 0; i < 10; i++ {
+		p := &KVPair{Key: path.Join(prefix, fmt.Sprintf("key%d", i)), Value: []byte("test")}
+		if _, err := kv.Put(p, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	var keys []string
+	meta, err := kv.ListFunc(prefix, nil, func(pair *KVPair) error {
+		if string(pair.Value) != "test" {
+			t.Fatalf("unexpected value: %#v", pair)
+		}
+		keys = append(keys, pair.Key)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if meta.LastIndex == 0 {
+		t.Fatalf("unexpected value: %#v", meta)
+	}
+	if len(keys) != 10 || keys[0] != path.Join(prefix, "key0") {
+		t.Fatalf("bad: %v", keys)
+	}
+
+	// The errors of the callback stop the listing
+	calls := 0
+	_, err = kv.ListFunc(prefix, nil, func(pair *KVPair) error {
+		calls++
+		return fmt.Errorf("stop")
+	})
+	if err == nil || err.Error() != "stop" || calls != 1 {
+		t.Fatalf("err: %v calls: %d", err, calls)
+	}
+
+	// Missing prefixes are not an error
+	_, err = kv.ListFunc(testKey(), nil, func(pair *KVPair) error {
+		t.Fatalf("unexpected pair: %#v", pair)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestAPI_ClientList_DeleteRecurse(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
@@ -574,3 +788,78 @@ func TestAPI_KVClientTxn(t *testing.T) {
 		t.Fatalf("unexpected value: %#v", meta)
 	}
 }
+
+func TestAPI_ClientWatchTree(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	kv := c.KV()
+
+	s.WaitForSerfCheck(t)
+	prefix := testKey() + "/"
+	for _, key := range []string{"a", "b", "c/d"} {
+		if _, err := kv.Put(&KVPair{Key: prefix + key, Value: []byte(key)}, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	}
+
+	w := kv.WatchTree(prefix, &QueryOptions{WaitTime: 5 * time.Second})
+
+	// The first events create the existing keys.
+	events, _, err := w.Next()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("bad: %v", events)
+	}
+	for _, e := range events {
+		if e.Type != KVEventCreate {
+			t.Fatalf("bad: %v", e)
+		}
+	}
+
+	// Change the tree in the background.
+	errCh := make(chan error, 1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		ops := KVTxnOps{
+			&KVTxnOp{Verb: KVSet, Key: prefix + "a", Value: []byte("a2")},
+			&KVTxnOp{Verb: KVDeleteTree, Key: prefix + "c/"},
+			&KVTxnOp{Verb: KVSet, Key: prefix + "e", Value: []byte("e")},
+		}
+		ok, _, _, err := kv.Txn(ops, nil)
+		if err == nil && !ok {
+			err = fmt.Errorf("transaction rolled back")
+		}
+		errCh <- err
+	}()
+
+	events, _, err = w.Next()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if err := <-errCh; err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("bad: %v", events)
+	}
+	expected := []struct {
+		typ KVEventType
+		key string
+	}{
+		{KVEventUpdate, prefix + "a"},
+		{KVEventDelete, prefix + "c/d"},
+		{KVEventCreate, prefix + "e"},
+	}
+	for i, e := range expected {
+		if events[i].Type != e.typ || events[i].Key != e.key {
+			t.Fatalf("bad: %d %v", i, events[i])
+		}
+	}
+	if string(events[0].Pair.Value) != "a2" || events[1].Pair != nil {
+		t.Fatalf("bad: %v", events)
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KVDiff holds the changes to the keys under a prefix since the WaitIndex
+// of the query. They are applied by first removing all the keys under the
+// DeletedPrefixes, then storing the Entries. Entries includes the keys
+// created or modified since the WaitIndex as well as the remaining keys
+// under the DeletedPrefixes.
+type KVDiff struct {
+	// Reset is set when the changes can't be computed, because no WaitIndex
+	// was given or it is too old. Entries then holds all the keys under the
+	// prefix and the previous ones must be discarded.
+	Reset bool
+
+	DeletedPrefixes []string
+	Entries         KVPairs
+}
+
+// ListDiff is used to get the changes to the keys under a prefix since the
+// WaitIndex of the query options, as a blocking query. Only the keys that
+// changed are returned, instead of the whole tree as with List.
+func (k *KV) ListDiff(prefix string, q *QueryOptions) (*KVDiff, *QueryMeta, error) {
+	resp, qm, err := k.getInternal(prefix, map[string]string{"diff": ""}, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp == nil {
+		return nil, nil, fmt.Errorf("Unexpected response code: 404")
+	}
+	defer resp.Body.Close()
+
+	var diff KVDiff
+	if err := decodeBody(resp, &diff); err != nil {
+		return nil, nil, err
+	}
+	return &diff, qm, nil
+}
+
+// KVEventType is the type of change of a KVEvent.
+type KVEventType string
+
+const (
+	KVEventCreate KVEventType = "create"
+	KVEventUpdate KVEventType = "update"
+	KVEventDelete KVEventType = "delete"
+)
+
+// KVEvent is a change to a key reported by KVTreeWatch.
+type KVEvent struct {
+	Type KVEventType
+	Key  string
+
+	// Pair is the new entry of the key. It is nil for deletions.
+	Pair *KVPair
+}
+
+// KVTreeWatch reports the changes to the keys under a prefix as events,
+// only fetching the keys that changed between each blocking query. It only
+// keeps track of the modify index of each key. It is not safe for
+// concurrent use.
+type KVTreeWatch struct {
+	kv     *KV
+	prefix string
+	q      QueryOptions
+
+	index uint64
+	keys  map[string]uint64
+}
+
+// WatchTree returns a KVTreeWatch for the keys under the given prefix. The
+// query options are used for each of the blocking queries, with their
+// WaitIndex managed by the watch.
+func (k *KV) WatchTree(prefix string, q *QueryOptions) *KVTreeWatch {
+	w := &KVTreeWatch{
+		kv:     k,
+		prefix: prefix,
+		keys:   make(map[string]uint64),
+	}
+	if q != nil {
+		w.q = *q
+	}
+	return w
+}
+
+// Next blocks until some keys under the prefix change and returns the
+// changes sorted by key. The first call returns a create event for each of
+// the existing keys. Errors are returned as is and Next can be called again
+// to retry.
+func (w *KVTreeWatch) Next() ([]*KVEvent, *QueryMeta, error) {
+	for {
+		q := w.q
+		q.WaitIndex = w.index
+		diff, qm, err := w.kv.ListDiff(w.prefix, &q)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// Start over if the index went backwards, for example after the
+		// servers restored a snapshot.
+		if qm.LastIndex < w.index {
+			w.index = 0
+			continue
+		}
+		w.index = qm.LastIndex
+
+		if events := w.apply(diff); len(events) > 0 {
+			return events, qm, nil
+		}
+	}
+}
+
+// apply updates the known keys with the diff and returns the resulting
+// events.
+func (w *KVTreeWatch) apply(diff *KVDiff) []*KVEvent {
+	removed := make(map[string]uint64)
+	if diff.Reset {
+		removed, w.keys = w.keys, make(map[string]uint64)
+	} else {
+		for key, index := range w.keys {
+			for _, prefix := range diff.DeletedPrefixes {
+				if strings.HasPrefix(key, prefix) {
+					removed[key] = index
+					delete(w.keys, key)
+					break
+				}
+			}
+		}
+	}
+
+	var events []*KVEvent
+	for _, pair := range diff.Entries {
+		index, ok := removed[pair.Key]
+		if !ok {
+			index, ok = w.keys[pair.Key]
+		}
+		delete(removed, pair.Key)
+		w.keys[pair.Key] = pair.ModifyIndex
+
+		switch {
+		case !ok:
+			events = append(events, &KVEvent{Type: KVEventCreate, Key: pair.Key, Pair: pair})
+		case index != pair.ModifyIndex:
+			events = append(events, &KVEvent{Type: KVEventUpdate, Key: pair.Key, Pair: pair})
+		}
+	}
+	for key := range removed {
+		events = append(events, &KVEvent{Type: KVEventDelete, Key: key})
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Key < events[j].Key
+	})
+	return events
+}
@@ -0,0 +1,302 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLeaderElectionSessionName is the Session Name we assign if none is provided
+	DefaultLeaderElectionSessionName = "Consul API Leader Election"
+
+	// DefaultLeaderElectionSessionTTL is the default session TTL if no Session is
+	// provided when creating a new LeaderElection.
+	DefaultLeaderElectionSessionTTL = "15s"
+
+	// DefaultLeaderElectionKey is the key used within the prefix for the lock
+	// held by the leader.
+	DefaultLeaderElectionKey = ".lock"
+
+	// LeaderElectionFlagValue is a magic flag we set to indicate a key is
+	// being used by a contender of a leader election.
+	LeaderElectionFlagValue = 0x6d1f3c8e2b0a4475
+)
+
+var (
+	// ErrLeaderElectionCampaigning is returned if we attempt to campaign
+	// twice.
+	ErrLeaderElectionCampaigning = fmt.Errorf("Already campaigning")
+
+	// ErrLeaderElectionNotCampaigning is returned if we attempt to resign
+	// without campaigning.
+	ErrLeaderElectionNotCampaigning = fmt.Errorf("Not campaigning")
+)
+
+// LeaderElection is used to elect a leader among contenders using the Consul
+// KV primitives. Each contender registers itself with its metadata under the
+// prefix, and the leader holds a lock within the prefix, so the leader and
+// the contenders can be looked up and watched by any client.
+type LeaderElection struct {
+	c    *Client
+	opts *LeaderElectionOptions
+
+	lock         *Lock
+	session      string
+	sessionRenew chan struct{}
+	l            sync.Mutex
+}
+
+// LeaderElectionOptions is used to parameterize the LeaderElection behavior.
+type LeaderElectionOptions struct {
+	Prefix           string        // Must be set and have write permissions
+	Value            []byte        // Optional, metadata of the contender
+	Session          string        // Optional, created if not specified
+	SessionName      string        // Optional, defaults to DefaultLeaderElectionSessionName
+	SessionTTL       string        // Optional, defaults to DefaultLeaderElectionSessionTTL
+	MonitorRetries   int           // Optional, defaults to 0 which means no retries
+	MonitorRetryTime time.Duration // Optional, defaults to DefaultMonitorRetryTime
+	LockWaitTime     time.Duration // Optional, defaults to DefaultLockWaitTime
+}
+
+// LeaderElectionContender is a contender of a leader election.
+type LeaderElectionContender struct {
+	// Session is the session of the contender.
+	Session string
+
+	// Value is the metadata of the contender.
+	Value []byte
+}
+
+// LeaderElection returns a handle to a leader election using the given
+// prefix, which must have write permissions.
+func (c *Client) LeaderElection(prefix string, value []byte) (*LeaderElection, error) {
+	opts := &LeaderElectionOptions{
+		Prefix: prefix,
+		Value:  value,
+	}
+	return c.LeaderElectionOpts(opts)
+}
+
+// LeaderElectionOpts returns a handle to a leader election using the given
+// options.
+func (c *Client) LeaderElectionOpts(opts *LeaderElectionOptions) (*LeaderElection, error) {
+	if opts.Prefix == "" {
+		return nil, fmt.Errorf("missing prefix")
+	}
+	if opts.SessionName == "" {
+		opts.SessionName = DefaultLeaderElectionSessionName
+	}
+	if opts.SessionTTL == "" {
+		opts.SessionTTL = DefaultLeaderElectionSessionTTL
+	} else {
+		if _, err := time.ParseDuration(opts.SessionTTL); err != nil {
+			return nil, fmt.Errorf("invalid SessionTTL: %v", err)
+		}
+	}
+	if opts.MonitorRetryTime == 0 {
+		opts.MonitorRetryTime = DefaultMonitorRetryTime
+	}
+	if opts.LockWaitTime == 0 {
+		opts.LockWaitTime = DefaultLockWaitTime
+	}
+
+	// Ensure we have a slash suffix
+	if !strings.HasSuffix(opts.Prefix, "/") {
+		opts.Prefix += "/"
+	}
+	e := &LeaderElection{
+		c:    c,
+		opts: opts,
+	}
+	return e, nil
+}
+
+// Campaign registers as a contender and blocks until elected. Providing a
+// non-nil stopCh can be used to abort the campaign, in which case nil is
+// returned. Returns a channel that is closed if the leadership is lost. Like
+// for a Lock, this can happen at any time and the application must be able
+// to handle it. Resign must be called once done, even if the leadership was
+// lost.
+func (e *LeaderElection) Campaign(stopCh <-chan struct{}) (<-chan struct{}, error) {
+	e.l.Lock()
+	defer e.l.Unlock()
+
+	if e.lock != nil {
+		return nil, ErrLeaderElectionCampaigning
+	}
+
+	// Check if we need to create a session first
+	e.session = e.opts.Session
+	if e.session == "" {
+		se := &SessionEntry{
+			Name: e.opts.SessionName,
+			TTL:  e.opts.SessionTTL,
+		}
+		s, _, err := e.c.Session().Create(se, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create session: %v", err)
+		}
+
+		e.sessionRenew = make(chan struct{})
+		e.session = s
+		go e.c.Session().RenewPeriodic(e.opts.SessionTTL, s, nil, e.sessionRenew)
+	}
+
+	// Register as a contender
+	kv := e.c.KV()
+	registered, _, err := kv.Acquire(e.contenderEntry(), nil)
+	if err == nil && !registered {
+		err = fmt.Errorf("session %s is not valid", e.session)
+	}
+	if err != nil {
+		e.cleanup()
+		return nil, fmt.Errorf("failed to register contender: %v", err)
+	}
+
+	// Wait for the lock of the leader
+	lock, err := e.c.LockOpts(&LockOptions{
+		Key:              e.opts.Prefix + DefaultLeaderElectionKey,
+		Value:            e.opts.Value,
+		Session:          e.session,
+		MonitorRetries:   e.opts.MonitorRetries,
+		MonitorRetryTime: e.opts.MonitorRetryTime,
+		LockWaitTime:     e.opts.LockWaitTime,
+	})
+	if err != nil {
+		e.cleanup()
+		return nil, err
+	}
+	leaderCh, err := lock.Lock(stopCh)
+	if err != nil || leaderCh == nil {
+		e.cleanup()
+		return nil, err
+	}
+	e.lock = lock
+	return leaderCh, nil
+}
+
+// Resign gives up the leadership and unregisters the contender. It is an
+// error to call this if Campaign didn't succeed.
+func (e *LeaderElection) Resign() error {
+	e.l.Lock()
+	defer e.l.Unlock()
+
+	if e.lock == nil {
+		return ErrLeaderElectionNotCampaigning
+	}
+	lock := e.lock
+	e.lock = nil
+	defer e.cleanup()
+
+	if err := lock.Unlock(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// cleanup unregisters the contender and stops renewing the session, which
+// destroys it.
+func (e *LeaderElection) cleanup() {
+	e.c.KV().Delete(e.contenderEntry().Key, nil)
+	if e.sessionRenew != nil {
+		close(e.sessionRenew)
+		e.sessionRenew = nil
+	}
+	e.session = ""
+}
+
+// contenderEntry returns a formatted KVPair for the contender
+func (e *LeaderElection) contenderEntry() *KVPair {
+	return &KVPair{
+		Key:     path.Join(e.opts.Prefix, e.session),
+		Value:   e.opts.Value,
+		Session: e.session,
+		Flags:   LeaderElectionFlagValue,
+	}
+}
+
+// Leader returns the current leader, or nil if there is none.
+func (e *LeaderElection) Leader(q *QueryOptions) (*LeaderElectionContender, *QueryMeta, error) {
+	pair, meta, err := e.c.KV().Get(e.opts.Prefix+DefaultLeaderElectionKey, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	if pair == nil || pair.Session == "" {
+		return nil, meta, nil
+	}
+	if pair.Flags != LockFlagValue {
+		return nil, nil, ErrLockConflict
+	}
+	return &LeaderElectionContender{Session: pair.Session, Value: pair.Value}, meta, nil
+}
+
+// Contenders returns the registered contenders, including the leader.
+func (e *LeaderElection) Contenders(q *QueryOptions) ([]*LeaderElectionContender, *QueryMeta, error) {
+	pairs, meta, err := e.c.KV().List(e.opts.Prefix, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	var contenders []*LeaderElectionContender
+	for _, pair := range pairs {
+		if pair.Flags != LeaderElectionFlagValue || pair.Session == "" {
+			continue
+		}
+		contenders = append(contenders, &LeaderElectionContender{Session: pair.Session, Value: pair.Value})
+	}
+	return contenders, meta, nil
+}
+
+// Watch notifies the changes of leader, starting with the current one, until
+// the stopCh is closed. Nil is sent when there is no leader. Errors are
+// retried after the MonitorRetryTime.
+func (e *LeaderElection) Watch(stopCh <-chan struct{}) <-chan *LeaderElectionContender {
+	leaderCh := make(chan *LeaderElectionContender, 1)
+	// Cancel the blocking queries once stopped
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	go func() {
+		defer close(leaderCh)
+		opts := (&QueryOptions{WaitTime: e.opts.LockWaitTime}).WithContext(ctx)
+		first, last := true, ""
+		for {
+			leader, meta, err := e.Leader(opts)
+			if err != nil {
+				select {
+				case <-time.After(e.opts.MonitorRetryTime):
+					continue
+				case <-stopCh:
+					return
+				}
+			}
+			opts.WaitIndex = meta.LastIndex
+
+			session := ""
+			if leader != nil {
+				session = leader.Session
+			}
+			if first || session != last {
+				first, last = false, session
+				select {
+				case leaderCh <- leader:
+				case <-stopCh:
+					return
+				}
+			}
+
+			select {
+			case <-stopCh:
+				return
+			default:
+			}
+		}
+	}()
+	return leaderCh
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/sdk/testutil/retry"
+)
+
+func TestAPI_LeaderElection(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	first, err := c.LeaderElection("test/election", []byte("first"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	second, err := c.LeaderElection("test/election", []byte("second"))
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Resigning without campaigning should fail
+	if err := first.Resign(); err != ErrLeaderElectionNotCampaigning {
+		t.Fatalf("err: %v", err)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	watchCh := second.Watch(stopCh)
+	if leader := <-watchCh; leader != nil {
+		t.Fatalf("unexpected leader: %#v", leader)
+	}
+
+	// The first contender is elected
+	leaderCh, err := first.Campaign(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if leaderCh == nil {
+		t.Fatalf("not elected")
+	}
+	if _, err := first.Campaign(nil); err != ErrLeaderElectionCampaigning {
+		t.Fatalf("err: %v", err)
+	}
+
+	select {
+	case leader := <-watchCh:
+		if leader == nil || string(leader.Value) != "first" {
+			t.Fatalf("bad: %#v", leader)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("leader not watched")
+	}
+
+	// The second contender waits for the leadership
+	electedCh := make(chan error, 1)
+	go func() {
+		_, err := second.Campaign(nil)
+		electedCh <- err
+	}()
+
+	retry.Run(t, func(r *retry.R) {
+		contenders, _, err := first.Contenders(nil)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if len(contenders) != 2 {
+			r.Fatalf("bad: %#v", contenders)
+		}
+	})
+
+	leader, _, err := second.Leader(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if leader == nil || string(leader.Value) != "first" {
+		t.Fatalf("bad: %#v", leader)
+	}
+
+	// Resigning hands the leadership over
+	if err := first.Resign(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	select {
+	case err := <-electedCh:
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("not elected")
+	}
+
+	// The watch may report the vacancy before the new leader
+	for {
+		select {
+		case leader := <-watchCh:
+			if leader == nil {
+				continue
+			}
+			if string(leader.Value) != "second" {
+				t.Fatalf("bad: %#v", leader)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("leader not watched")
+		}
+		break
+	}
+
+	contenders, _, err := second.Contenders(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if len(contenders) != 1 || string(contenders[0].Value) != "second" {
+		t.Fatalf("bad: %#v", contenders)
+	}
+	if err := second.Resign(); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRequestDropped is returned when a request is dropped by the client
+// because too many requests are already waiting on the limits set by
+// Config.MaxConcurrentRequests and Config.RateLimit.
+var ErrRequestDropped = errors.New("request dropped: too many queued requests")
+
+// RequestStats are the counters of the client request limiter, see
+// Client.RequestStats.
+type RequestStats struct {
+	// InFlight is the number of requests currently sent to the agent. A
+	// request is in flight until its response body is closed.
+	InFlight int
+
+	// Waiting is the number of requests currently waiting on the limits.
+	Waiting int
+
+	// Queued is the total number of requests that had to wait on the
+	// limits before being sent.
+	Queued uint64
+
+	// Dropped is the total number of requests that were never sent, either
+	// because MaxQueuedRequests was reached or because their context ended
+	// while waiting.
+	Dropped uint64
+}
+
+// requestLimiter caps the number of concurrent requests and the rate at
+// which they are sent. A nil limiter doesn't limit anything.
+type requestLimiter struct {
+	// slots holds a value for each request in flight, it is nil if the
+	// concurrency is unlimited.
+	slots chan struct{}
+
+	// bucket is nil if the rate is unlimited.
+	bucket *tokenBucket
+
+	maxQueued int64
+
+	waiting int64
+	queued  uint64
+	dropped uint64
+}
+
+// newRequestLimiter returns the limiter for the given config, or nil if no
+// limit is set.
+func newRequestLimiter(config *Config) *requestLimiter {
+	if config.MaxConcurrentRequests <= 0 && config.RateLimit <= 0 {
+		return nil
+	}
+
+	l := &requestLimiter{
+		maxQueued: int64(config.MaxQueuedRequests),
+	}
+	if config.MaxConcurrentRequests > 0 {
+		l.slots = make(chan struct{}, config.MaxConcurrentRequests)
+	}
+	if config.RateLimit > 0 {
+		l.bucket = newTokenBucket(config.RateLimit, config.RateBurst)
+	}
+	return l
+}
+
+// acquire waits until the request can be sent. The returned function must be
+// called once the request is done.
+func (l *requestLimiter) acquire(ctx context.Context) (func(), error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	// Try the fast path first so only the requests that actually wait are
+	// accounted as queued.
+	delay := time.Duration(0)
+	if l.bucket != nil {
+		delay = l.bucket.reserve()
+	}
+	if delay == 0 && l.trySlot() {
+		return l.release, nil
+	}
+
+	waiting := atomic.AddInt64(&l.waiting, 1)
+	defer atomic.AddInt64(&l.waiting, -1)
+	if l.maxQueued > 0 && waiting > l.maxQueued {
+		l.drop()
+		return nil, ErrRequestDropped
+	}
+	atomic.AddUint64(&l.queued, 1)
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			l.drop()
+			return nil, ctx.Err()
+		}
+	}
+
+	if l.slots == nil {
+		return l.release, nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return l.release, nil
+	case <-ctx.Done():
+		l.drop()
+		return nil, ctx.Err()
+	}
+}
+
+// trySlot takes a concurrency slot if one is free.
+func (l *requestLimiter) trySlot() bool {
+	if l.slots == nil {
+		return true
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// drop accounts for a dropped request and gives back its rate token.
+func (l *requestLimiter) drop() {
+	atomic.AddUint64(&l.dropped, 1)
+	if l.bucket != nil {
+		l.bucket.cancel()
+	}
+}
+
+func (l *requestLimiter) release() {
+	if l.slots != nil {
+		<-l.slots
+	}
+}
+
+func (l *requestLimiter) stats() RequestStats {
+	if l == nil {
+		return RequestStats{}
+	}
+	return RequestStats{
+		InFlight: len(l.slots),
+		Waiting:  int(atomic.LoadInt64(&l.waiting)),
+		Queued:   atomic.LoadUint64(&l.queued),
+		Dropped:  atomic.LoadUint64(&l.dropped),
+	}
+}
+
+// tokenBucket is a token bucket rate limiter. Tokens are reserved ahead of
+// time so waiting requests are served in order.
+type tokenBucket struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int) *tokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &tokenBucket{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it.
+func (b *tokenBucket) reserve() time.Duration {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// cancel gives back a token that was reserved but not used.
+func (b *tokenBucket) cancel() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.tokens++
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+}
+
+// releaseOnClose releases the concurrency slot of a request once its
+// response body is closed, so streamed responses count as in flight.
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *releaseOnClose) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
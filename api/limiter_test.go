@@ -0,0 +1,109 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_RequestLimiter_Concurrency(t *testing.T) {
+	t.Parallel()
+
+	l := newRequestLimiter(&Config{MaxConcurrentRequests: 1, MaxQueuedRequests: 1})
+
+	release, err := l.acquire(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, l.stats().InFlight)
+
+	// The second request waits for the first one.
+	acquired := make(chan func(), 1)
+	go func() {
+		r, err := l.acquire(context.Background())
+		if err == nil {
+			acquired <- r
+		}
+	}()
+	for l.stats().Waiting != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The third one is dropped since the queue is full.
+	_, err = l.acquire(context.Background())
+	require.Equal(t, ErrRequestDropped, err)
+
+	release()
+	select {
+	case r := <-acquired:
+		r()
+	case <-time.After(time.Second):
+		t.Fatalf("request was never sent")
+	}
+
+	// A request that gives up waiting is dropped too.
+	release, err = l.acquire(context.Background())
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = l.acquire(ctx)
+	require.Equal(t, context.DeadlineExceeded, err)
+	release()
+
+	stats := l.stats()
+	require.Equal(t, 0, stats.InFlight)
+	require.Equal(t, 0, stats.Waiting)
+	require.Equal(t, uint64(2), stats.Queued)
+	require.Equal(t, uint64(2), stats.Dropped)
+}
+
+func TestAPI_RequestLimiter_Rate(t *testing.T) {
+	t.Parallel()
+
+	l := newRequestLimiter(&Config{RateLimit: 20, RateBurst: 2})
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		release, err := l.acquire(context.Background())
+		require.NoError(t, err)
+		release()
+	}
+
+	// The burst goes through, the next two requests wait 50ms each.
+	require.True(t, time.Since(start) >= 90*time.Millisecond)
+	require.Equal(t, uint64(2), l.stats().Queued)
+	require.Equal(t, uint64(0), l.stats().Dropped)
+}
+
+func TestAPI_ClientMaxConcurrentRequests(t *testing.T) {
+	t.Parallel()
+	c, s := makeClientWithConfig(t, func(conf *Config) {
+		conf.MaxConcurrentRequests = 1
+	}, nil)
+	defer s.Stop()
+
+	kv := c.KV()
+	_, err := kv.Put(&KVPair{Key: "foo", Value: []byte("bar")}, nil)
+	require.NoError(t, err)
+
+	// The slot is held by the blocking query until it returns.
+	_, meta, err := kv.Get("foo", nil)
+	require.NoError(t, err)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		kv.Get("foo", &QueryOptions{WaitIndex: meta.LastIndex, WaitTime: 200 * time.Millisecond})
+	}()
+	for c.RequestStats().InFlight != 1 {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	pair, _, err := kv.Get("foo", nil)
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), pair.Value)
+	<-done
+
+	stats := c.RequestStats()
+	require.Equal(t, 0, stats.InFlight)
+	require.Equal(t, uint64(1), stats.Queued)
+}
@@ -0,0 +1,54 @@
+package api
+
+// GCConfiguration is the garbage collector tuning of an agent. It only
+// applies to the agent serving the request and is reset to the agent
+// configuration when it restarts.
+type GCConfiguration struct {
+	// Percent is the garbage collection target percentage, as with the
+	// GOGC environment variable. Zero restores the value the agent started
+	// with and -1 turns off the collections triggered by the heap growth,
+	// which requires MemoryLimitBytes to be set.
+	Percent int
+
+	// BallastBytes is the size of an allocation kept alive to raise the
+	// heap size the next collection is triggered at, so smaller heaps are
+	// collected less often. Zero disables the ballast.
+	BallastBytes int64
+
+	// MemoryLimitBytes is a soft limit on the memory used by the agent. The
+	// collections are triggered more often when it is approached. Zero
+	// disables the limit.
+	MemoryLimitBytes int64
+}
+
+// GCGetConfiguration is used to query the garbage collector tuning of the
+// agent.
+func (op *Operator) GCGetConfiguration(q *QueryOptions) (*GCConfiguration, error) {
+	r := op.c.newRequest("GET", "/v1/operator/gc/configuration")
+	r.setQueryOptions(q)
+	_, resp, err := requireOK(op.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out GCConfiguration
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GCSetConfiguration is used to update the garbage collector tuning of the
+// agent.
+func (op *Operator) GCSetConfiguration(conf *GCConfiguration, q *WriteOptions) error {
+	r := op.c.newRequest("PUT", "/v1/operator/gc/configuration")
+	r.setWriteOptions(q)
+	r.obj = conf
+	_, resp, err := requireOK(op.c.doRequest(r))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAPI_OperatorGCGetSetConfiguration(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	operator := c.Operator()
+	config, err := operator.GCGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if config.Percent != 0 || config.BallastBytes != 0 || config.MemoryLimitBytes != 0 {
+		t.Fatalf("bad: %v", config)
+	}
+
+	// Change the tuning
+	newConf := &GCConfiguration{Percent: 200, BallastBytes: 4096}
+	if err := operator.GCSetConfiguration(newConf, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	config, err = operator.GCGetConfiguration(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if *config != *newConf {
+		t.Fatalf("bad: %v", config)
+	}
+
+	// An invalid tuning is rejected
+	if err := operator.GCSetConfiguration(&GCConfiguration{Percent: -1}, nil); err == nil {
+		t.Fatalf("should have failed")
+	}
+}
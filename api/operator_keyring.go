@@ -1,5 +1,7 @@
 package api
 
+import "time"
+
 // keyringRequest is used for performing Keyring operations
 type keyringRequest struct {
 	Key string
@@ -26,6 +28,27 @@ type KeyringResponse struct {
 	NumNodes int
 }
 
+// KeyringRotationStatus is the status of the automatic rotation of the
+// gossip encryption key of the LAN pool of a datacenter, as reported by its
+// leader.
+type KeyringRotationStatus struct {
+	// Enabled is whether the leader rotates the key.
+	Enabled bool
+
+	// Interval is the interval between the rotations.
+	Interval *ReadableDuration
+
+	// LastRotation is the time of the last successful rotation since the
+	// server became the leader, if any.
+	LastRotation time.Time
+
+	// NextRotation is the time of the next rotation.
+	NextRotation time.Time
+
+	// LastError is the error of the last rotation attempt, if it failed.
+	LastError string
+}
+
 // KeyringInstall is used to install a new gossip encryption key into the cluster
 func (op *Operator) KeyringInstall(key string, q *WriteOptions) error {
 	r := op.c.newRequest("POST", "/v1/operator/keyring")
@@ -87,3 +110,21 @@ func (op *Operator) KeyringUse(key string, q *WriteOptions) error {
 	resp.Body.Close()
 	return nil
 }
+
+// KeyringRotationStatus is used to query the status of the automatic
+// rotation of the gossip encryption key.
+func (op *Operator) KeyringRotationStatus(q *QueryOptions) (*KeyringRotationStatus, error) {
+	r := op.c.newRequest("GET", "/v1/operator/keyring/rotation")
+	r.setQueryOptions(q)
+	_, resp, err := requireOK(op.c.doRequest(r))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out KeyringRotationStatus
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
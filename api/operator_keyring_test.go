@@ -41,6 +41,15 @@ func TestAPI_OperatorKeyringInstallListPutRemove(t *testing.T) {
 		}
 	}
 
+	// Only the LAN keyring is listed with local-only
+	localResponses, err := operator.KeyringList(&QueryOptions{LocalOnly: true})
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+	if len(localResponses) != 1 || localResponses[0].WAN || len(localResponses[0].Keys) != 2 {
+		t.Fatalf("bad: %v", localResponses)
+	}
+
 	// Switch the primary to the new key
 	if err := operator.KeyringUse(newKey, nil); err != nil {
 		t.Fatalf("err: %v", err)
@@ -71,3 +80,20 @@ func TestAPI_OperatorKeyringInstallListPutRemove(t *testing.T) {
 		}
 	}
 }
+
+func TestAPI_OperatorKeyringRotationStatus(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	// The rotation is disabled by default.
+	status, err := c.Operator().KeyringRotationStatus(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if status.Enabled || status.Interval.Duration() != 0 || status.LastError != "" {
+		t.Fatalf("bad: %#v", status)
+	}
+}
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrLicenseNotSupported is returned by the license methods when the agent
+// isn't running Consul Enterprise, so that tooling can degrade gracefully.
+var ErrLicenseNotSupported = errors.New("licenses are only supported by Consul Enterprise")
+
+// License is the decoded content of a Consul Enterprise license.
+type License struct {
+	// The unique identifier of the license
+	LicenseID string `json:"license_id"`
+
+	// The customer ID associated with the license
+	CustomerID string `json:"customer_id"`
+
+	// If set, an identifier that should be used to lock the license to a
+	// particular site, cluster, etc.
+	InstallationID string `json:"installation_id"`
+
+	// The time at which the license was issued
+	IssueTime time.Time `json:"issue_time"`
+
+	// The time at which the license starts being valid
+	StartTime time.Time `json:"start_time"`
+
+	// The time after which the license expires
+	ExpirationTime time.Time `json:"expiration_time"`
+
+	// The product the license is valid for
+	Product string `json:"product"`
+
+	// License Specific Flags
+	Flags map[string]interface{} `json:"flags"`
+
+	// List of features enabled by the license
+	Features []string `json:"features"`
+}
+
+// LicenseReply is the license of a datacenter along with its validity.
+type LicenseReply struct {
+	Valid    bool
+	License  *License
+	Warnings []string
+}
+
+// LicenseGetDetail returns the decoded license of the datacenter.
+func (op *Operator) LicenseGetDetail(q *QueryOptions) (*LicenseReply, error) {
+	r := op.c.newRequest("GET", "/v1/operator/license")
+	r.setQueryOptions(q)
+	return op.licenseRequest(r)
+}
+
+// LicenseGet returns the signed license blob of the datacenter.
+func (op *Operator) LicenseGet(q *QueryOptions) (string, error) {
+	r := op.c.newRequest("GET", "/v1/operator/license")
+	r.params.Set("signed", "1")
+	r.setQueryOptions(q)
+	resp, err := op.doLicenseRequest(r)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// LicensePut updates the license of the datacenter with the given signed
+// license blob.
+func (op *Operator) LicensePut(license string, opts *WriteOptions) (*LicenseReply, error) {
+	r := op.c.newRequest("PUT", "/v1/operator/license")
+	r.setWriteOptions(opts)
+	r.body = strings.NewReader(license)
+	return op.licenseRequest(r)
+}
+
+// LicenseReset resets the license of the datacenter to the one it was
+// started with.
+func (op *Operator) LicenseReset(opts *WriteOptions) (*LicenseReply, error) {
+	r := op.c.newRequest("DELETE", "/v1/operator/license")
+	r.setWriteOptions(opts)
+	return op.licenseRequest(r)
+}
+
+// licenseRequest runs a license request and decodes its reply.
+func (op *Operator) licenseRequest(r *request) (*LicenseReply, error) {
+	resp, err := op.doLicenseRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var reply LicenseReply
+	if err := decodeBody(resp, &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// doLicenseRequest runs a license request, returning ErrLicenseNotSupported
+// for the open source agents, which respond with 501, or with 404 for the
+// versions without the endpoint.
+func (op *Operator) doLicenseRequest(r *request) (*http.Response, error) {
+	rtt, resp, err := op.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotImplemented || resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, ErrLicenseNotSupported
+	}
+	_, resp, err = requireOK(rtt, resp, nil)
+	return resp, err
+}
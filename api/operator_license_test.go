@@ -0,0 +1,25 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAPI_OperatorLicense_NotSupported(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	operator := c.Operator()
+	if _, err := operator.LicenseGetDetail(nil); err != ErrLicenseNotSupported {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := operator.LicenseGet(nil); err != ErrLicenseNotSupported {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := operator.LicensePut("license", nil); err != ErrLicenseNotSupported {
+		t.Fatalf("err: %v", err)
+	}
+	if _, err := operator.LicenseReset(nil); err != ErrLicenseNotSupported {
+		t.Fatalf("err: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package api
+
+// Usage is the number of entries of each kind in the state of a datacenter.
+type Usage struct {
+	Nodes            int
+	Services         int
+	ServiceInstances int
+	KVEntries        int
+	ACLTokens        int
+	Intentions       int
+	ConfigEntries    int
+}
+
+// Usage returns the usage of the datacenter, keyed by its name. If global is
+// set, the usage of all the known datacenters is returned instead.
+func (op *Operator) Usage(global bool, q *QueryOptions) (map[string]Usage, *QueryMeta, error) {
+	r := op.c.newRequest("GET", "/v1/operator/usage")
+	r.setQueryOptions(q)
+	if global {
+		r.params.Set("global", "")
+	}
+	rtt, resp, err := requireOK(op.c.doRequest(r))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	qm := &QueryMeta{}
+	parseQueryMeta(resp, qm)
+	qm.RequestTime = rtt
+
+	var out map[string]Usage
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
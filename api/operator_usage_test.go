@@ -0,0 +1,32 @@
+package api
+
+import (
+	"testing"
+)
+
+func TestAPI_OperatorUsage(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	if _, err := c.KV().Put(&KVPair{Key: "foo", Value: []byte("bar")}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	operator := c.Operator()
+	for _, global := range []bool{false, true} {
+		usage, qm, err := operator.Usage(global, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if qm.LastIndex == 0 {
+			t.Fatalf("bad: %v", qm)
+		}
+		dc1, ok := usage["dc1"]
+		if len(usage) != 1 || !ok || dc1.Nodes != 1 || dc1.KVEntries != 1 {
+			t.Fatalf("bad: %v", usage)
+		}
+	}
+}
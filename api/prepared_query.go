@@ -1,5 +1,9 @@
 package api
 
+import (
+	"time"
+)
+
 // QueryDatacenterOptions sets options about how we fail over if there are no
 // healthy nodes in the local datacenter.
 type QueryDatacenterOptions struct {
@@ -133,6 +137,34 @@ type PreparedQueryExecuteResponse struct {
 	Failovers int
 }
 
+// PreparedQueryStats has the execution stats of a prepared query, as
+// recorded by the servers since they last restarted.
+type PreparedQueryStats struct {
+	// ID and Name identify the query.
+	ID   string
+	Name string
+
+	// Executions is the number of times the query was executed.
+	Executions uint64
+
+	// Failovers is the number of executions that had to fail over to a
+	// remote datacenter.
+	Failovers uint64
+
+	// EmptyResults is the number of executions that didn't return any
+	// nodes, even after failing over.
+	EmptyResults uint64
+
+	// LatencyTotal and LatencyMax are the total and the highest time spent
+	// executing the query, including failovers.
+	LatencyTotal time.Duration
+	LatencyMax   time.Duration
+
+	// LastExecuted is the time of the last execution, it is zero if the
+	// query was never executed.
+	LastExecuted time.Time
+}
+
 // PreparedQuery can be used to query the prepared query endpoints.
 type PreparedQuery struct {
 	c *Client
@@ -215,3 +247,14 @@ func (c *PreparedQuery) Execute(queryIDOrName string, q *QueryOptions) (*Prepare
 	}
 	return out, qm, nil
 }
+
+// Stats is used to fetch the execution stats of all the prepared queries
+// readable with the given token.
+func (c *PreparedQuery) Stats(q *QueryOptions) ([]*PreparedQueryStats, *QueryMeta, error) {
+	var out []*PreparedQueryStats
+	qm, err := c.c.query("/v1/query/stats", &out, q)
+	if err != nil {
+		return nil, nil, err
+	}
+	return out, qm, nil
+}
@@ -165,6 +165,18 @@ func TestAPI_PreparedQuery(t *testing.T) {
 		t.Fatalf("got %d nodes, want 2", len(results.Nodes))
 	}
 
+	// Check the stats recorded for the executions.
+	stats, _, err := query.Stats(nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(stats) != 1 || stats[0].ID != def.ID || stats[0].Name != "my-query" {
+		t.Fatalf("bad: %v", stats)
+	}
+	if stats[0].Executions == 0 || stats[0].LastExecuted.IsZero() {
+		t.Fatalf("bad: %v", stats[0])
+	}
+
 	// Delete it.
 	_, err = query.Delete(def.ID, nil)
 	if err != nil {
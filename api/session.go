@@ -1,8 +1,10 @@
 package api
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math/rand"
 	"time"
 )
 
@@ -116,30 +118,38 @@ func (s *Session) Destroy(id string, q *WriteOptions) (*WriteMeta, error) {
 
 // Renew renews the TTL on a given session
 func (s *Session) Renew(id string, q *WriteOptions) (*SessionEntry, *WriteMeta, error) {
+	entry, _, wm, err := s.renew(id, q)
+	return entry, wm, err
+}
+
+// renew is Renew also returning the renew interval recommended by the agent,
+// or zero if none was returned.
+func (s *Session) renew(id string, q *WriteOptions) (*SessionEntry, time.Duration, *WriteMeta, error) {
 	r := s.c.newRequest("PUT", "/v1/session/renew/"+id)
 	r.setWriteOptions(q)
 	rtt, resp, err := s.c.doRequest(r)
 	if err != nil {
-		return nil, nil, err
+		return nil, 0, nil, err
 	}
 	defer resp.Body.Close()
 
 	wm := &WriteMeta{RequestTime: rtt}
 
 	if resp.StatusCode == 404 {
-		return nil, wm, nil
+		return nil, 0, wm, nil
 	} else if resp.StatusCode != 200 {
-		return nil, nil, fmt.Errorf("Unexpected response code: %d", resp.StatusCode)
+		return nil, 0, nil, fmt.Errorf("Unexpected response code: %d", resp.StatusCode)
 	}
 
 	var entries []*SessionEntry
 	if err := decodeBody(resp, &entries); err != nil {
-		return nil, nil, fmt.Errorf("Failed to read response: %v", err)
+		return nil, 0, nil, fmt.Errorf("Failed to read response: %v", err)
 	}
+	interval, _ := time.ParseDuration(resp.Header.Get("X-Consul-Renew-Interval"))
 	if len(entries) > 0 {
-		return entries[0], wm, nil
+		return entries[0], interval, wm, nil
 	}
-	return nil, wm, nil
+	return nil, 0, wm, nil
 }
 
 // RenewPeriodic is used to periodically invoke Session.Renew on a
@@ -190,6 +200,86 @@ func (s *Session) RenewPeriodic(initialTTL string, id string, q *WriteOptions, d
 	}
 }
 
+// KeepAlive renews the session in the background until the context is
+// canceled, at the interval recommended by the agent with some jitter. Failed
+// renewals, such as during a leader election, are retried until the TTL of
+// the session passes since the last successful renewal. The returned channel
+// receives the error once the session is lost, ErrSessionExpired if it was
+// invalidated, and is closed once the renewals stop. The session is not
+// destroyed when the context is canceled.
+func (s *Session) KeepAlive(ctx context.Context, id string) <-chan error {
+	lostCh := make(chan error, 1)
+	go func() {
+		defer close(lostCh)
+		if err := s.keepAlive(ctx, id); err != nil && ctx.Err() == nil {
+			lostCh <- err
+		}
+	}()
+	return lostCh
+}
+
+func (s *Session) keepAlive(ctx context.Context, id string) error {
+	q := (&WriteOptions{}).WithContext(ctx)
+
+	// Renew the session right away to learn its TTL.
+	entry, interval, _, err := s.renew(id, q)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		return ErrSessionExpired
+	}
+
+	lastRenewTime := time.Now()
+	var lastErr error
+	for {
+		ttl, _ := time.ParseDuration(entry.TTL)
+		if ttl <= 0 {
+			// The session doesn't expire, there is nothing to renew.
+			<-ctx.Done()
+			return nil
+		}
+		if interval <= 0 || interval > ttl {
+			interval = ttl / 2
+		}
+
+		wait := interval - sessionStagger(interval/10)
+		if lastErr != nil {
+			if time.Since(lastRenewTime) > ttl {
+				return lastErr
+			}
+			wait = time.Second + sessionStagger(time.Second)
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil
+		}
+
+		renewed, renewInterval, _, err := s.renew(id, q)
+		switch {
+		case err != nil:
+			lastErr = err
+		case renewed == nil:
+			return ErrSessionExpired
+		default:
+			// Handle the server updating the TTL
+			entry, interval, lastErr = renewed, renewInterval, nil
+			lastRenewTime = time.Now()
+		}
+	}
+}
+
+// sessionStagger returns a random duration below the given one, used to
+// spread the renewals of sessions.
+func sessionStagger(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(d)))
+}
+
 // Info looks up a single session
 func (s *Session) Info(id string, q *QueryOptions) (*SessionEntry, *QueryMeta, error) {
 	var entries []*SessionEntry
@@ -284,6 +284,81 @@ func TestAPI_SessionRenewPeriodic_Cancel(t *testing.T) {
 	})
 }
 
+func TestAPI_SessionKeepAlive(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	session := c.Session()
+	entry := &SessionEntry{
+		Behavior: SessionBehaviorDelete,
+		TTL:      "10s",
+	}
+
+	t.Run("unknown session", func(t *testing.T) {
+		select {
+		case <-time.After(5 * time.Second):
+			t.Fatal("session loss wasn't reported")
+		case err := <-session.KeepAlive(context.Background(), "00000000-0000-0000-0000-000000000000"):
+			if err != ErrSessionExpired {
+				t.Fatalf("err: %v", err)
+			}
+		}
+	})
+
+	t.Run("destroyed session", func(t *testing.T) {
+		id, _, err := session.Create(entry, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		lostCh := session.KeepAlive(context.Background(), id)
+		if _, err := session.Destroy(id, nil); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		// The loss is noticed on the next renewal, halfway through the TTL
+		select {
+		case <-time.After(10 * time.Second):
+			t.Fatal("session loss wasn't reported")
+		case err := <-lostCh:
+			if err != ErrSessionExpired {
+				t.Fatalf("err: %v", err)
+			}
+		}
+	})
+
+	t.Run("context", func(t *testing.T) {
+		id, _, err := session.Create(entry, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		lostCh := session.KeepAlive(ctx, id)
+		cancel()
+
+		select {
+		case <-time.After(1 * time.Second):
+			t.Fatal("renewal loop didn't terminate")
+		case err, ok := <-lostCh:
+			if ok {
+				t.Fatalf("err: %v", err)
+			}
+		}
+
+		sess, _, err := session.Info(id, nil)
+		if err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if sess == nil {
+			t.Fatalf("session should not be destroyed")
+		}
+	})
+}
+
 func TestAPI_SessionInfo(t *testing.T) {
 	t.Parallel()
 	c, s := makeClient(t)
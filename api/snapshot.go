@@ -1,9 +1,15 @@
 package api
 
 import (
+	"fmt"
 	"io"
+	"strconv"
 )
 
+// snapshotResumeAttempts is the number of times the download of a snapshot
+// is resumed after it gets interrupted.
+const snapshotResumeAttempts = 3
+
 // Snapshot can be used to query the /v1/snapshot endpoint to take snapshots of
 // Consul's internal state and restore snapshots for disaster recovery.
 type Snapshot struct {
@@ -19,21 +25,92 @@ func (c *Client) Snapshot() *Snapshot {
 // data to save. If this doesn't return an error, then it's the responsibility
 // of the caller to close it. Only a subset of the QueryOptions are supported:
 // Datacenter, AllowStale, and Token.
+//
+// The agent spools the snapshot while it's downloaded, so if the download
+// gets interrupted the reader transparently resumes it from the last offset
+// received, instead of failing.
 func (s *Snapshot) Save(q *QueryOptions) (io.ReadCloser, *QueryMeta, error) {
+	body, id, qm, err := s.save(q, "", 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Older agents don't spool the snapshots.
+	if id == "" {
+		return body, qm, nil
+	}
+	return &snapshotReader{s: s, q: q, id: id, body: body}, qm, nil
+}
+
+// save requests a snapshot, or the rest of the spooled snapshot with the
+// given ID past the offset, and returns its body along with its ID.
+func (s *Snapshot) save(q *QueryOptions, id string, offset int64) (io.ReadCloser, string, *QueryMeta, error) {
 	r := s.c.newRequest("GET", "/v1/snapshot")
 	r.setQueryOptions(q)
+	if id != "" {
+		r.params.Set("id", id)
+		r.params.Set("offset", strconv.FormatInt(offset, 10))
+	}
 
 	rtt, resp, err := requireOK(s.c.doRequest(r))
 	if err != nil {
-		return nil, nil, err
+		return nil, "", nil, err
 	}
 
 	qm := &QueryMeta{}
 	parseQueryMeta(resp, qm)
 	qm.RequestTime = rtt
-	return resp.Body, qm, nil
+	return resp.Body, resp.Header.Get("X-Consul-Snapshot-Id"), qm, nil
 }
 
+// snapshotReader reads a spooled snapshot, resuming the download from the
+// last offset received when it fails.
+type snapshotReader struct {
+	s    *Snapshot
+	q    *QueryOptions
+	id   string
+	body io.ReadCloser
+
+	offset   int64
+	attempts int
+}
+
+func (r *snapshotReader) Read(p []byte) (int, error) {
+	for {
+		n, err := r.body.Read(p)
+		r.offset += int64(n)
+		if err == nil || err == io.EOF || r.attempts >= snapshotResumeAttempts {
+			return n, err
+		}
+
+		// Hand out what was read, the error will come up again.
+		if n > 0 {
+			return n, nil
+		}
+
+		r.attempts++
+		r.body.Close()
+		body, _, _, resumeErr := r.s.save(r.q, r.id, r.offset)
+		if resumeErr != nil {
+			r.body = failedBody{err}
+			return 0, fmt.Errorf("failed to resume snapshot download after %v: %v", err, resumeErr)
+		}
+		r.body = body
+	}
+}
+
+func (r *snapshotReader) Close() error {
+	return r.body.Close()
+}
+
+// failedBody is the body of a download which couldn't be resumed.
+type failedBody struct {
+	err error
+}
+
+func (b failedBody) Read([]byte) (int, error) { return 0, b.err }
+func (b failedBody) Close() error             { return nil }
+
 // Restore streams in an existing snapshot and attempts to restore it.
 func (s *Snapshot) Restore(q *WriteOptions, in io.Reader) error {
 	r := s.c.newRequest("PUT", "/v1/snapshot")
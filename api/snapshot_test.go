@@ -2,6 +2,8 @@ package api
 
 import (
 	"bytes"
+	"io"
+	"io/ioutil"
 	"strings"
 	"testing"
 )
@@ -81,6 +83,67 @@ func TestAPI_Snapshot(t *testing.T) {
 	}
 }
 
+// interruptedBody fails after returning the first bytes of a body.
+type interruptedBody struct {
+	r io.Reader
+}
+
+func (b *interruptedBody) Read(p []byte) (int, error) {
+	n, err := b.r.Read(p)
+	if err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b *interruptedBody) Close() error { return nil }
+
+func TestAPI_Snapshot_Resume(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	snapshot := c.Snapshot()
+	snap, _, err := snapshot.Save(nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	full, err := ioutil.ReadAll(snap)
+	snap.Close()
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// Interrupt the download of the spooled snapshot after a few bytes, it
+	// gets resumed from there.
+	id := snap.(*snapshotReader).id
+	r := &snapshotReader{
+		s:    snapshot,
+		id:   id,
+		body: &interruptedBody{r: bytes.NewReader(full[:10])},
+	}
+	resumed, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if !bytes.Equal(resumed, full) {
+		t.Fatalf("bad: %d bytes, expected %d", len(resumed), len(full))
+	}
+
+	// The download fails once the snapshot can't be resumed.
+	r = &snapshotReader{
+		s:    snapshot,
+		id:   "nope",
+		body: &interruptedBody{r: bytes.NewReader(full[:10])},
+	}
+	_, err = ioutil.ReadAll(r)
+	if err == nil || !strings.Contains(err.Error(), "failed to resume") {
+		t.Fatalf("err: %v", err)
+	}
+}
+
 func TestAPI_Snapshot_Options(t *testing.T) {
 	t.Parallel()
 	c, s := makeACLClient(t)
@@ -0,0 +1,183 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// SubscribeTopicHealth streams the health of the instances of a
+	// service.
+	SubscribeTopicHealth = "health"
+
+	// SubscribeTopicCatalog streams the services registered in the
+	// catalog along with their tags.
+	SubscribeTopicCatalog = "catalog"
+
+	// SubscribeOpUpsert and SubscribeOpDelete are the operations an event
+	// can describe.
+	SubscribeOpUpsert = "upsert"
+	SubscribeOpDelete = "delete"
+)
+
+// SubscribeEvent is a single change streamed by a Subscription.
+type SubscribeEvent struct {
+	Topic string
+	Key   string
+	Index uint64
+
+	// EndOfSnapshot is set on the event following the initial set of
+	// upserts that describe the state at the time of the subscription.
+	EndOfSnapshot bool
+
+	// Op is either SubscribeOpUpsert or SubscribeOpDelete.
+	Op string
+
+	// ServiceHealth is set for events of SubscribeTopicHealth. For deletes
+	// only the node name and service ID and name are populated.
+	ServiceHealth *ServiceEntry
+
+	// Status is the aggregated status of the checks of the instance for
+	// events of SubscribeTopicHealth, empty for deletes. PreviousStatus is
+	// its status in the previous event of the instance, empty for the
+	// events of the initial snapshot and for new instances.
+	Status         string
+	PreviousStatus string
+
+	// CatalogService is set for events of SubscribeTopicCatalog.
+	CatalogService *SubscribeCatalogService
+}
+
+// SubscribeCatalogService describes a service name and the union of its tags.
+type SubscribeCatalogService struct {
+	Name string
+	Tags []string
+}
+
+// Subscribe is used to stream events from the agent instead of running
+// repeated blocking queries. The events are streamed over HTTP as
+// server-sent events.
+type Subscribe struct {
+	c *Client
+}
+
+// Subscribe returns a handle to the streaming endpoints.
+func (c *Client) Subscribe() *Subscribe {
+	return &Subscribe{c}
+}
+
+// Health subscribes to health events for the instances of the given
+// service. The Datacenter, Token and WaitIndex fields of q are honored,
+// events up to WaitIndex are skipped after the initial snapshot.
+func (s *Subscribe) Health(ctx context.Context, service string, q *QueryOptions) (*Subscription, error) {
+	if service == "" {
+		return nil, fmt.Errorf("missing service name")
+	}
+	return s.subscribe(ctx, "/v1/health/stream/service/"+service, q)
+}
+
+// Catalog subscribes to changes to the set of services in the catalog.
+func (s *Subscribe) Catalog(ctx context.Context, q *QueryOptions) (*Subscription, error) {
+	return s.subscribe(ctx, "/v1/catalog/stream/services", q)
+}
+
+func (s *Subscribe) subscribe(ctx context.Context, path string, q *QueryOptions) (*Subscription, error) {
+	stream, err := s.c.openEventStream(path, q.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return &Subscription{stream: stream}, nil
+}
+
+// Subscription is an active event stream.
+type Subscription struct {
+	stream *eventStream
+}
+
+// Next blocks until the next event is received. It returns io.EOF once the
+// agent ends the stream and an error once the subscription is closed.
+func (s *Subscription) Next() (*SubscribeEvent, error) {
+	return s.stream.next()
+}
+
+// Close ends the subscription.
+func (s *Subscription) Close() {
+	s.stream.close()
+}
+
+// eventStream reads the events streamed by the agent as server-sent events.
+// The stream holds a request slot of the client until it's closed.
+type eventStream struct {
+	body   io.ReadCloser
+	reader *bufio.Reader
+	cancel context.CancelFunc
+}
+
+// openEventStream starts streaming the events of the given endpoint.
+func (c *Client) openEventStream(path string, q *QueryOptions) (*eventStream, error) {
+	ctx, cancel := context.WithCancel(q.Context())
+	r := c.newRequest("GET", path)
+	r.setQueryOptions(q.WithContext(ctx))
+	r.header.Set("Accept", "text/event-stream")
+	_, resp, err := c.sendRequest(r)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var buf bytes.Buffer
+		io.Copy(&buf, resp.Body)
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("Unexpected response code: %d (%s)", resp.StatusCode, buf.Bytes())
+	}
+
+	return &eventStream{
+		body:   resp.Body,
+		reader: bufio.NewReader(resp.Body),
+		cancel: cancel,
+	}, nil
+}
+
+// next blocks until the next event is received.
+func (s *eventStream) next() (*SubscribeEvent, error) {
+	var data []byte
+	for {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		line = bytes.TrimRight(line, "\r\n")
+
+		// A blank line ends the event.
+		if len(line) == 0 {
+			if data == nil {
+				continue
+			}
+			e := new(SubscribeEvent)
+			if err := json.Unmarshal(data, e); err != nil {
+				return nil, fmt.Errorf("failed to decode event: %v", err)
+			}
+			return e, nil
+		}
+
+		// Only the data field is used, the ID being the index of the event.
+		if bytes.HasPrefix(line, []byte("data:")) {
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))...)
+		}
+	}
+}
+
+// close ends the stream.
+func (s *eventStream) close() {
+	s.cancel()
+	s.body.Close()
+}
@@ -0,0 +1,121 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_SubscribeHealth(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	require.NoError(t, c.Agent().ServiceRegister(&AgentServiceRegistration{
+		ID:   "web1",
+		Name: "web",
+		Port: 8080,
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var sub *Subscription
+	var err error
+	// The service registration is synced to the catalog asynchronously.
+	for i := 0; i < 50; i++ {
+		sub, err = c.Subscribe().Health(ctx, "web", nil)
+		require.NoError(t, err)
+
+		e, err := sub.Next()
+		require.NoError(t, err)
+		if !e.EndOfSnapshot {
+			require.Equal(t, SubscribeOpUpsert, e.Op)
+			require.Equal(t, "web1", e.ServiceHealth.Service.ID)
+			break
+		}
+		sub.Close()
+		sub = nil
+		time.Sleep(100 * time.Millisecond)
+	}
+	require.NotNil(t, sub, "service never showed up")
+	defer sub.Close()
+
+	e, err := sub.Next()
+	require.NoError(t, err)
+	require.True(t, e.EndOfSnapshot)
+
+	require.NoError(t, c.Agent().ServiceDeregister("web1"))
+
+	for {
+		e, err := sub.Next()
+		require.NoError(t, err)
+		if e.Op == SubscribeOpDelete {
+			require.Equal(t, "web1", e.ServiceHealth.Service.ID)
+			break
+		}
+	}
+}
+
+func TestAPI_SubscribeCatalog(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sub, err := c.Subscribe().Catalog(ctx, nil)
+	require.NoError(t, err)
+	defer sub.Close()
+
+	// Read the initial snapshot which contains the consul service.
+	for {
+		e, err := sub.Next()
+		require.NoError(t, err)
+		if e.EndOfSnapshot {
+			break
+		}
+		require.Equal(t, SubscribeTopicCatalog, e.Topic)
+	}
+
+	require.NoError(t, c.Agent().ServiceRegister(&AgentServiceRegistration{
+		Name: "redis",
+		Tags: []string{"primary"},
+	}))
+
+	e, err := sub.Next()
+	require.NoError(t, err)
+	require.Equal(t, SubscribeOpUpsert, e.Op)
+	require.Equal(t, "redis", e.CatalogService.Name)
+	require.Equal(t, []string{"primary"}, e.CatalogService.Tags)
+}
+
+func TestAPI_SubscribeClose(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	sub, err := c.Subscribe().Catalog(context.Background(), nil)
+	require.NoError(t, err)
+	for {
+		e, err := sub.Next()
+		require.NoError(t, err)
+		if e.EndOfSnapshot {
+			break
+		}
+	}
+
+	// Closing the subscription ends the request.
+	sub.Close()
+	_, err = sub.Next()
+	require.Error(t, err)
+}
@@ -0,0 +1,122 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+// TokenSink writes tokens to a file for the processes running alongside, such
+// as the ACL tokens of Connect proxies. The file is replaced atomically so
+// readers never see a partially written token, and the processes can be
+// notified when it changes.
+type TokenSink struct {
+	// Path is the path of the file the token is written to. Its directory
+	// must exist.
+	Path string
+
+	// Mode is the mode of the file, 0600 if not set.
+	Mode os.FileMode
+
+	// PID and Signal set the process signaled when the token changes, such
+	// as with syscall.SIGHUP to make it reload the token.
+	PID    int
+	Signal os.Signal
+
+	// Command is run when the token changes, if set. The path of the file
+	// is passed in the CONSUL_TOKEN_SINK environment variable.
+	Command []string
+
+	l sync.Mutex
+}
+
+// WriteToken writes the secret ID of the given token.
+func (s *TokenSink) WriteToken(token *ACLToken) (bool, error) {
+	return s.Write(token.SecretID)
+}
+
+// Write writes the token to the file and notifies the process if the token
+// changed. It returns whether the token changed, which is the case when the
+// file was written even if notifying the process failed.
+func (s *TokenSink) Write(token string) (bool, error) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	if s.Path == "" {
+		return false, fmt.Errorf("Missing token sink path")
+	}
+	existing, err := ioutil.ReadFile(s.Path)
+	if err == nil && bytes.Equal(existing, []byte(token)) {
+		return false, nil
+	}
+
+	if err := s.writeAtomic([]byte(token)); err != nil {
+		return false, fmt.Errorf("Failed to write token sink %q: %v", s.Path, err)
+	}
+	return true, s.notify()
+}
+
+// writeAtomic writes the contents to a temporary file in the same directory
+// and renames it to the path of the sink once synced.
+func (s *TokenSink) writeAtomic(contents []byte) error {
+	mode := s.Mode
+	if mode == 0 {
+		mode = 0600
+	}
+
+	fh, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tempPath := fh.Name()
+	if err := fh.Chmod(mode); err != nil {
+		fh.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if _, err := fh.Write(contents); err != nil {
+		fh.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := fh.Sync(); err != nil {
+		fh.Close()
+		os.Remove(tempPath)
+		return err
+	}
+	if err := fh.Close(); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	if err := os.Rename(tempPath, s.Path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+	return nil
+}
+
+// notify signals the process and runs the command, if set.
+func (s *TokenSink) notify() error {
+	if s.PID != 0 && s.Signal != nil {
+		proc, err := os.FindProcess(s.PID)
+		if err != nil {
+			return fmt.Errorf("Failed to find process %d: %v", s.PID, err)
+		}
+		if err := proc.Signal(s.Signal); err != nil {
+			return fmt.Errorf("Failed to signal process %d: %v", s.PID, err)
+		}
+	}
+
+	if len(s.Command) > 0 {
+		cmd := exec.Command(s.Command[0], s.Command[1:]...)
+		cmd.Env = append(os.Environ(), "CONSUL_TOKEN_SINK="+s.Path)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("Failed to run %q: %v: %s", s.Command[0], err, out)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_TokenSink(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "consul")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sink := &TokenSink{Path: filepath.Join(dir, "token")}
+
+	changed, err := sink.WriteToken(&ACLToken{SecretID: "secret"})
+	require.NoError(t, err)
+	require.True(t, changed)
+
+	contents, err := ioutil.ReadFile(sink.Path)
+	require.NoError(t, err)
+	require.Equal(t, "secret", string(contents))
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(sink.Path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0600), info.Mode().Perm())
+	}
+
+	// Writing the same token is a no-op
+	changed, err = sink.Write("secret")
+	require.NoError(t, err)
+	require.False(t, changed)
+
+	// No temporary files are left behind
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	// Missing directories are reported
+	_, err = (&TokenSink{Path: filepath.Join(dir, "missing", "token")}).Write("secret")
+	require.Error(t, err)
+}
+
+func TestAPI_TokenSink_Notify(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals and shell commands are not supported on windows")
+	}
+
+	dir, err := ioutil.TempDir("", "consul")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGUSR1)
+	defer signal.Stop(sigCh)
+
+	sink := &TokenSink{
+		Path:    filepath.Join(dir, "token"),
+		PID:     os.Getpid(),
+		Signal:  syscall.SIGUSR1,
+		Command: []string{"/bin/sh", "-c", `cp "$CONSUL_TOKEN_SINK" "$0"`, filepath.Join(dir, "copy")},
+	}
+	_, err = sink.Write("secret")
+	require.NoError(t, err)
+
+	select {
+	case <-sigCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("process wasn't signaled")
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "copy"))
+	require.NoError(t, err)
+	require.Equal(t, "secret", string(contents))
+
+	// Failed commands are reported once the token is written
+	sink.Signal = nil
+	sink.Command = []string{"/bin/sh", "-c", "exit 1"}
+	changed, err := sink.Write("other")
+	require.Error(t, err)
+	require.True(t, changed)
+}
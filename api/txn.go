@@ -17,8 +17,8 @@ func (c *Client) Txn() *Txn {
 	return &Txn{c}
 }
 
-// TxnOp is the internal format we send to Consul. Currently only K/V and
-// check operations are supported.
+// TxnOp is the internal format we send to Consul. Each op holds exactly one
+// of the K/V, node, service or check operations.
 type TxnOp struct {
 	KV      *KVTxnOp
 	Node    *NodeTxnOp
@@ -63,6 +63,7 @@ const (
 	KVDelete         KVOp = "delete"
 	KVDeleteCAS      KVOp = "delete-cas"
 	KVDeleteTree     KVOp = "delete-tree"
+	KVDeleteTreeCAS  KVOp = "delete-tree-cas"
 	KVCAS            KVOp = "cas"
 	KVLock           KVOp = "lock"
 	KVUnlock         KVOp = "unlock"
@@ -162,34 +163,49 @@ type CheckTxnOp struct {
 //
 // Here's an example:
 //
-//	   ops := KVTxnOps{
-//		   &KVTxnOp{
-//			   Verb:    KVLock,
-//			   Key:     "test/lock",
-//			   Session: "adf4238a-882b-9ddc-4a9d-5b6758e4159e",
-//			   Value:   []byte("hello"),
+//	   ops := TxnOps{
+//		   &TxnOp{
+//			   KV: &KVTxnOp{
+//				   Verb:    KVLock,
+//				   Key:     "test/lock",
+//				   Session: "adf4238a-882b-9ddc-4a9d-5b6758e4159e",
+//				   Value:   []byte("hello"),
+//			   },
 //		   },
-//		   &KVTxnOp{
-//			   Verb:    KVGet,
-//			   Key:     "another/key",
+//		   &TxnOp{
+//			   Node: &NodeTxnOp{
+//				   Verb: NodeSet,
+//				   Node: Node{Node: "foo", Address: "127.0.0.1"},
+//			   },
 //		   },
-//		   &CheckTxnOp{
-//			   Verb:        CheckSet,
-//			   HealthCheck: HealthCheck{
+//		   &TxnOp{
+//			   Service: &ServiceTxnOp{
+//				   Verb:    ServiceSet,
 //				   Node:    "foo",
-//				   CheckID: "redis:a",
-//				   Name:    "Redis Health Check",
-//				   Status:  "passing",
+//				   Service: AgentService{ID: "redis", Service: "redis", Port: 6379},
 //			   },
-//		   }
+//		   },
+//		   &TxnOp{
+//			   Check: &CheckTxnOp{
+//				   Verb: CheckSet,
+//				   Check: HealthCheck{
+//					   Node:      "foo",
+//					   CheckID:   "redis:a",
+//					   Name:      "Redis Health Check",
+//					   Status:    "passing",
+//					   ServiceID: "redis",
+//				   },
+//			   },
+//		   },
 //	   }
-//	   ok, response, _, err := kv.Txn(&ops, nil)
+//	   ok, response, _, err := client.Txn().Txn(ops, nil)
 //
 // If there is a problem making the transaction request then an error will be
 // returned. Otherwise, the ok value will be true if the transaction succeeded
 // or false if it was rolled back. The response is a structured return value which
 // will have the outcome of the transaction. Its Results member will have entries
-// for each operation. For KV operations, Deleted keys will have a nil entry in the
+// for each operation, holding the node, service or check for the catalog
+// operations. Deleted nodes, services and checks have no entry. For KV operations, Deleted keys will have a nil entry in the
 // results, and to save space, the Value of each key in the Results will be nil
 // unless the operation is a KVGet. If the transaction was rolled back, the Errors
 // member will have entries referencing the index of the operation that failed
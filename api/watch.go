@@ -0,0 +1,274 @@
+package api
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+const (
+	// DefaultWatchRetryMin is the initial amount of time a watch waits
+	// before retrying a failed blocking query.
+	DefaultWatchRetryMin = 1 * time.Second
+
+	// DefaultWatchRetryMax is the maximum amount of time a watch waits
+	// before retrying a failed blocking query.
+	DefaultWatchRetryMax = 1 * time.Minute
+)
+
+// WatchOptions is used to parameterize the blocking query loop run by the
+// Watch helpers.
+type WatchOptions struct {
+	// QueryOptions is used as the base for every blocking query. WaitIndex
+	// is managed by the watch and is used as the starting index. The
+	// context set on the options is ignored in favor of the one passed to
+	// the Watch helper.
+	QueryOptions *QueryOptions
+
+	// RetryMin is the initial wait time after a failed query, doubled on
+	// every consecutive failure. Defaults to DefaultWatchRetryMin.
+	RetryMin time.Duration
+
+	// RetryMax caps the wait time between failed queries. Defaults to
+	// DefaultWatchRetryMax.
+	RetryMax time.Duration
+
+	// Stats is called with the statistics of the watch after every
+	// blocking query, if set. It is called from the goroutine of the watch
+	// so it must not block.
+	Stats func(WatchStats)
+}
+
+// WatchStats holds the statistics of a watch, which can be used to detect a
+// watch stuck retrying a failing query.
+type WatchStats struct {
+	// Iterations is the number of blocking queries made.
+	Iterations uint64
+
+	// Errors is the number of failed queries and ErrorStreak the number of
+	// consecutive ones, reset once a query succeeds.
+	Errors      uint64
+	ErrorStreak uint64
+
+	// LastError is the error of the last query, nil if it succeeded.
+	LastError error
+
+	// IndexResets is the number of times the index went backwards.
+	IndexResets uint64
+
+	// LastIndex is the index returned by the last successful query.
+	LastIndex uint64
+
+	// LastSuccess is when the last successful query returned.
+	LastSuccess time.Time
+
+	// AverageWait is the average duration of the successful queries.
+	AverageWait time.Duration
+}
+
+// KVWatchResult is delivered by WatchKV. Either Err is set or Pairs and Meta
+// reflect the current state of the watched prefix.
+type KVWatchResult struct {
+	Pairs KVPairs
+	Meta  *QueryMeta
+	Err   error
+}
+
+// ServiceWatchResult is delivered by WatchService. Either Err is set or
+// Entries and Meta reflect the current health of the watched service.
+type ServiceWatchResult struct {
+	Entries []*ServiceEntry
+	Meta    *QueryMeta
+	Err     error
+}
+
+// ACLTokensWatchResult is delivered by WatchACLTokens. Either Err is set or
+// Tokens and Meta reflect the current list of ACL tokens.
+type ACLTokensWatchResult struct {
+	Tokens []*ACLTokenListEntry
+	Meta   *QueryMeta
+	Err    error
+}
+
+// WatchKV watches all keys under the given prefix. A result is delivered on
+// the returned channel once initially and then every time the prefix
+// changes. Errors are delivered as results and the query is retried with a
+// jittered backoff. The channel is closed once ctx is done.
+func WatchKV(ctx context.Context, c *Client, prefix string, opts *WatchOptions) <-chan *KVWatchResult {
+	ch := make(chan *KVWatchResult, 1)
+	go func() {
+		defer close(ch)
+		runWatch(ctx, opts, func(q *QueryOptions) (interface{}, *QueryMeta, error) {
+			return c.KV().List(prefix, q)
+		}, func(v interface{}, meta *QueryMeta, err error) bool {
+			r := &KVWatchResult{Meta: meta, Err: err}
+			r.Pairs, _ = v.(KVPairs)
+			select {
+			case ch <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
+// WatchService watches the health of the instances of the given service,
+// optionally filtered by tag and passing status. It follows the same
+// delivery semantics as WatchKV.
+func WatchService(ctx context.Context, c *Client, service, tag string, passingOnly bool, opts *WatchOptions) <-chan *ServiceWatchResult {
+	ch := make(chan *ServiceWatchResult, 1)
+	go func() {
+		defer close(ch)
+		runWatch(ctx, opts, func(q *QueryOptions) (interface{}, *QueryMeta, error) {
+			return c.Health().Service(service, tag, passingOnly, q)
+		}, func(v interface{}, meta *QueryMeta, err error) bool {
+			r := &ServiceWatchResult{Meta: meta, Err: err}
+			r.Entries, _ = v.([]*ServiceEntry)
+			select {
+			case ch <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
+// WatchACLTokens watches the list of ACL tokens. It follows the same
+// delivery semantics as WatchKV.
+func WatchACLTokens(ctx context.Context, c *Client, opts *WatchOptions) <-chan *ACLTokensWatchResult {
+	ch := make(chan *ACLTokensWatchResult, 1)
+	go func() {
+		defer close(ch)
+		runWatch(ctx, opts, func(q *QueryOptions) (interface{}, *QueryMeta, error) {
+			return c.ACL().TokenList(q)
+		}, func(v interface{}, meta *QueryMeta, err error) bool {
+			r := &ACLTokensWatchResult{Meta: meta, Err: err}
+			r.Tokens, _ = v.([]*ACLTokenListEntry)
+			select {
+			case ch <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return ch
+}
+
+// watchQueryFunc runs a single blocking query with the given options.
+type watchQueryFunc func(q *QueryOptions) (interface{}, *QueryMeta, error)
+
+// watchDeliverFunc hands a result or an error to the consumer. It returns
+// false if the watch was stopped before the result could be delivered.
+type watchDeliverFunc func(v interface{}, meta *QueryMeta, err error) bool
+
+// runWatch runs the blocking query state machine until ctx is done. Results
+// are only delivered when the index changed, errors are delivered as they
+// happen and followed by a jittered backoff.
+func runWatch(ctx context.Context, opts *WatchOptions, query watchQueryFunc, deliver watchDeliverFunc) {
+	if opts == nil {
+		opts = &WatchOptions{}
+	}
+	retryMin, retryMax := opts.RetryMin, opts.RetryMax
+	if retryMin <= 0 {
+		retryMin = DefaultWatchRetryMin
+	}
+	if retryMax <= 0 {
+		retryMax = DefaultWatchRetryMax
+	}
+	if retryMax < retryMin {
+		retryMax = retryMin
+	}
+
+	q := opts.QueryOptions.WithContext(ctx)
+	delivered := false
+	var failures uint
+	var stats WatchStats
+	var totalWait time.Duration
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		start := time.Now()
+		v, meta, err := query(q)
+		if ctx.Err() != nil {
+			return
+		}
+
+		stats.Iterations++
+		stats.LastError = err
+		if err != nil {
+			stats.Errors++
+			stats.ErrorStreak++
+		} else {
+			stats.ErrorStreak = 0
+			stats.LastIndex = meta.LastIndex
+			stats.LastSuccess = time.Now()
+			totalWait += stats.LastSuccess.Sub(start)
+			stats.AverageWait = totalWait / time.Duration(stats.Iterations-stats.Errors)
+			if meta.LastIndex < q.WaitIndex {
+				stats.IndexResets++
+			}
+		}
+		if opts.Stats != nil {
+			opts.Stats(stats)
+		}
+
+		if err != nil {
+			if !deliver(nil, nil, err) {
+				return
+			}
+
+			wait := watchBackoff(failures, retryMin, retryMax)
+			failures++
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+		failures = 0
+
+		// A blocking query that times out returns the same index, in
+		// which case there is nothing new to deliver.
+		if !delivered || meta.LastIndex != q.WaitIndex {
+			if !deliver(v, meta, nil) {
+				return
+			}
+			delivered = true
+		}
+
+		// Reset the index if it goes backwards, which happens after a
+		// snapshot restore or when talking to a different server, and
+		// make sure we always block on subsequent requests to avoid a
+		// hot loop since index 0 returns immediately.
+		switch {
+		case meta.LastIndex < q.WaitIndex:
+			q.WaitIndex = 0
+		case meta.LastIndex < 1:
+			q.WaitIndex = 1
+		default:
+			q.WaitIndex = meta.LastIndex
+		}
+	}
+}
+
+// watchBackoff returns an exponential backoff with jitter in the upper half
+// of the interval so consumers restarting together don't synchronize.
+func watchBackoff(failures uint, min, max time.Duration) time.Duration {
+	if failures > 31 {
+		failures = 31 // so we don't overflow
+	}
+	wait := min << failures
+	if wait <= 0 || wait > max {
+		wait = max
+	}
+	half := wait / 2
+	return half + time.Duration(rand.Int63n(int64(half)+1))
+}
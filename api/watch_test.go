@@ -0,0 +1,195 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPI_WatchKV(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := WatchKV(ctx, c, "watch/", &WatchOptions{
+		QueryOptions: &QueryOptions{WaitTime: 100 * time.Millisecond},
+	})
+
+	next := func() *KVWatchResult {
+		select {
+		case r := <-ch:
+			require.NotNil(t, r)
+			require.NoError(t, r.Err)
+			return r
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for result")
+		}
+		return nil
+	}
+
+	// The initial state is delivered right away.
+	r := next()
+	require.Len(t, r.Pairs, 0)
+
+	// Timeouts of the blocking query should not be delivered.
+	select {
+	case r := <-ch:
+		t.Fatalf("unexpected result: %#v", r)
+	case <-time.After(300 * time.Millisecond):
+	}
+
+	_, err := c.KV().Put(&KVPair{Key: "watch/foo", Value: []byte("bar")}, nil)
+	require.NoError(t, err)
+
+	r = next()
+	require.Len(t, r.Pairs, 1)
+	require.Equal(t, "watch/foo", r.Pairs[0].Key)
+
+	// The channel is closed once the context is cancelled.
+	cancel()
+	for range ch {
+	}
+}
+
+func TestAPI_WatchService(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := WatchService(ctx, c, "web", "", false, nil)
+
+	select {
+	case r := <-ch:
+		require.NoError(t, r.Err)
+		require.Len(t, r.Entries, 0)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for result")
+	}
+
+	require.NoError(t, c.Agent().ServiceRegister(&AgentServiceRegistration{Name: "web", Port: 8080}))
+
+	select {
+	case r := <-ch:
+		require.NoError(t, r.Err)
+		require.Len(t, r.Entries, 1)
+		require.Equal(t, "web", r.Entries[0].Service.Service)
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for result")
+	}
+}
+
+func TestAPI_WatchACLTokens_error(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// ACLs are disabled so listing tokens fails, the error should be
+	// delivered and the query retried.
+	ch := WatchACLTokens(ctx, c, &WatchOptions{
+		RetryMin: 10 * time.Millisecond,
+		RetryMax: 20 * time.Millisecond,
+	})
+
+	for i := 0; i < 2; i++ {
+		select {
+		case r := <-ch:
+			require.Error(t, r.Err)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for result")
+		}
+	}
+}
+
+func TestAPI_WatchStats(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	s.WaitForSerfCheck(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	statsCh := make(chan WatchStats, 10)
+	opts := &WatchOptions{
+		QueryOptions: &QueryOptions{WaitTime: 50 * time.Millisecond},
+		RetryMin:     10 * time.Millisecond,
+		RetryMax:     20 * time.Millisecond,
+		Stats: func(stats WatchStats) {
+			select {
+			case statsCh <- stats:
+			default:
+			}
+		},
+	}
+	nextStats := func() WatchStats {
+		select {
+		case stats := <-statsCh:
+			return stats
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for stats")
+		}
+		return WatchStats{}
+	}
+
+	// Successful queries reset the error streak and track the index.
+	kvCh := WatchKV(ctx, c, "watch/", opts)
+	<-kvCh
+	stats := nextStats()
+	require.Equal(t, uint64(1), stats.Iterations)
+	require.Zero(t, stats.Errors)
+	require.NoError(t, stats.LastError)
+	require.NotZero(t, stats.LastIndex)
+	require.False(t, stats.LastSuccess.IsZero())
+
+	// The blocking queries wait for the wait time.
+	stats = nextStats()
+	require.Equal(t, uint64(2), stats.Iterations)
+	require.True(t, stats.AverageWait > 0, "%s", stats.AverageWait)
+
+	cancel()
+	for range kvCh {
+	}
+
+	// Failed queries increase the error streak. ACLs are disabled so
+	// listing tokens fails.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	statsCh = make(chan WatchStats, 10)
+	aclCh := WatchACLTokens(ctx, c, opts)
+	for i := 1; i <= 2; i++ {
+		<-aclCh
+		stats = nextStats()
+		require.Equal(t, uint64(i), stats.Iterations)
+		require.Equal(t, uint64(i), stats.Errors)
+		require.Equal(t, uint64(i), stats.ErrorStreak)
+		require.Error(t, stats.LastError)
+		require.True(t, stats.LastSuccess.IsZero())
+	}
+}
+
+func TestAPI_WatchBackoff(t *testing.T) {
+	t.Parallel()
+
+	min, max := time.Second, 8*time.Second
+	for i, want := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second} {
+		got := watchBackoff(uint(i), min, max)
+		require.True(t, got >= want/2 && got <= want, "attempt %d: %s not in [%s, %s]", i, got, want/2, want)
+	}
+	require.True(t, watchBackoff(100, min, max) <= max)
+}
@@ -13,6 +13,12 @@ func PrintToken(token *api.ACLToken, ui cli.Ui, showMeta bool) {
 	ui.Info(fmt.Sprintf("AccessorID:   %s", token.AccessorID))
 	ui.Info(fmt.Sprintf("SecretID:     %s", token.SecretID))
 	ui.Info(fmt.Sprintf("Description:  %s", token.Description))
+	if token.Namespace != "" {
+		ui.Info(fmt.Sprintf("Namespace:    %s", token.Namespace))
+	}
+	if token.NamePrefix != "" {
+		ui.Info(fmt.Sprintf("Name Prefix:  %s", token.NamePrefix))
+	}
 	ui.Info(fmt.Sprintf("Local:        %t", token.Local))
 	ui.Info(fmt.Sprintf("Create Time:  %v", token.CreateTime))
 	if showMeta {
@@ -33,6 +39,12 @@ func PrintToken(token *api.ACLToken, ui cli.Ui, showMeta bool) {
 func PrintTokenListEntry(token *api.ACLTokenListEntry, ui cli.Ui, showMeta bool) {
 	ui.Info(fmt.Sprintf("AccessorID:   %s", token.AccessorID))
 	ui.Info(fmt.Sprintf("Description:  %s", token.Description))
+	if token.Namespace != "" {
+		ui.Info(fmt.Sprintf("Namespace:    %s", token.Namespace))
+	}
+	if token.NamePrefix != "" {
+		ui.Info(fmt.Sprintf("Name Prefix:  %s", token.NamePrefix))
+	}
 	ui.Info(fmt.Sprintf("Local:        %t", token.Local))
 	ui.Info(fmt.Sprintf("Create Time:  %v", token.CreateTime))
 	ui.Info(fmt.Sprintf("Legacy:       %t", token.Legacy))
@@ -51,7 +63,13 @@ func PrintPolicy(policy *api.ACLPolicy, ui cli.Ui, showMeta bool) {
 	ui.Info(fmt.Sprintf("ID:           %s", policy.ID))
 	ui.Info(fmt.Sprintf("Name:         %s", policy.Name))
 	ui.Info(fmt.Sprintf("Description:  %s", policy.Description))
+	if policy.Namespace != "" {
+		ui.Info(fmt.Sprintf("Namespace:    %s", policy.Namespace))
+	}
 	ui.Info(fmt.Sprintf("Datacenters:  %s", strings.Join(policy.Datacenters, ", ")))
+	if policy.DeleteAfter != nil {
+		ui.Info(fmt.Sprintf("Delete After: %v", *policy.DeleteAfter))
+	}
 	if showMeta {
 		ui.Info(fmt.Sprintf("Hash:         %x", policy.Hash))
 		ui.Info(fmt.Sprintf("Create Index: %d", policy.CreateIndex))
@@ -65,7 +83,13 @@ func PrintPolicyListEntry(policy *api.ACLPolicyListEntry, ui cli.Ui, showMeta bo
 	ui.Info(fmt.Sprintf("%s:", policy.Name))
 	ui.Info(fmt.Sprintf("   ID:           %s", policy.ID))
 	ui.Info(fmt.Sprintf("   Description:  %s", policy.Description))
+	if policy.Namespace != "" {
+		ui.Info(fmt.Sprintf("   Namespace:    %s", policy.Namespace))
+	}
 	ui.Info(fmt.Sprintf("   Datacenters:  %s", strings.Join(policy.Datacenters, ", ")))
+	if policy.DeleteAfter != nil {
+		ui.Info(fmt.Sprintf("   Delete After: %v", *policy.DeleteAfter))
+	}
 	if showMeta {
 		ui.Info(fmt.Sprintf("   Hash:         %x", policy.Hash))
 		ui.Info(fmt.Sprintf("   Create Index: %d", policy.CreateIndex))
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/api"
@@ -29,6 +30,7 @@ type cmd struct {
 	description string
 	datacenters []string
 	rules       string
+	deleteAfter time.Duration
 
 	fromToken     string
 	tokenIsSecret bool
@@ -48,6 +50,9 @@ func (c *cmd) init() {
 	c.flags.StringVar(&c.rules, "rules", "", "The policy rules. May be prefixed with '@' "+
 		"to indicate that the value is a file path to load the rules from. '-' may also be "+
 		"given to indicate that the rules are available on stdin")
+	c.flags.DurationVar(&c.deleteAfter, "delete-after", 0, "Duration after which the "+
+		"policy is deleted, such as '4h', for temporary grants. The policy is kept "+
+		"when this is not set")
 	c.flags.StringVar(&c.fromToken, "from-token", "", "The legacy token to retrieve the rules "+
 		"for when creating this policy. When this is specified no other rules should be given. "+
 		"Similar to the -rules option the token to use can be loaded from stdin or from a file")
@@ -57,6 +62,7 @@ func (c *cmd) init() {
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
+	flags.Merge(c.flags, c.http.NamespaceFlags())
 	c.help = flags.Usage(help, c.flags)
 }
 
@@ -112,6 +118,10 @@ func (c *cmd) Run(args []string) int {
 		Datacenters: c.datacenters,
 		Rules:       rules,
 	}
+	if c.deleteAfter > 0 {
+		deleteAfter := time.Now().Add(c.deleteAfter)
+		newPolicy.DeleteAfter = &deleteAfter
+	}
 
 	policy, _, err := client.ACL().PolicyCreate(newPolicy, nil)
 	if err != nil {
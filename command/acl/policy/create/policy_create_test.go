@@ -60,4 +60,18 @@ func TestPolicyCreateCommand(t *testing.T) {
 	code := cmd.Run(args)
 	assert.Equal(code, 0)
 	assert.Empty(ui.ErrorWriter.String())
+
+	// Temporary policy
+	ui = cli.NewMockUi()
+	cmd = New(ui)
+	code = cmd.Run([]string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-token=root",
+		"-name=temporary",
+		"-rules=@" + testDir + "/rules.hcl",
+		"-delete-after=1h",
+	})
+	assert.Equal(code, 0)
+	assert.Empty(ui.ErrorWriter.String())
+	assert.Contains(ui.OutputWriter.String(), "Delete After:")
 }
@@ -3,7 +3,9 @@ package policylist
 import (
 	"flag"
 	"fmt"
+	"time"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/acl"
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/mitchellh/cli"
@@ -21,17 +23,21 @@ type cmd struct {
 	http  *flags.HTTPFlags
 	help  string
 
-	showMeta bool
+	showMeta      bool
+	expiresWithin time.Duration
 }
 
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.BoolVar(&c.showMeta, "meta", false, "Indicates that policy metadata such "+
 		"as the content hash and raft indices should be shown for each entry")
+	c.flags.DurationVar(&c.expiresWithin, "expires-within", 0, "Only list the policies "+
+		"that are deleted within this duration, such as '24h'")
 
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
+	flags.Merge(c.flags, c.http.NamespaceFlags())
 	c.help = flags.Usage(help, c.flags)
 }
 
@@ -46,7 +52,12 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
-	policies, _, err := client.ACL().PolicyList(nil)
+	var policies []*api.ACLPolicyListEntry
+	if c.expiresWithin > 0 {
+		policies, _, err = client.ACL().PolicyListExpiring(c.expiresWithin, nil)
+	} else {
+		policies, _, err = client.ACL().PolicyList(nil)
+	}
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Failed to retrieve the policy list: %v", err))
 		return 1
@@ -76,4 +87,8 @@ Usage: consul acl policy list [options]
     Example:
 
         $ consul acl policy list
+
+    List the policies deleted within the next day:
+
+        $ consul acl policy list -expires-within 24h
 `
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/agent"
 	"github.com/hashicorp/consul/api"
@@ -77,4 +78,22 @@ func TestPolicyListCommand(t *testing.T) {
 		assert.Contains(output, fmt.Sprintf("test-policy-%d", i))
 		assert.Contains(output, v)
 	}
+
+	// Only list the temporary policies
+	deleteAfter := time.Now().Add(time.Hour)
+	_, _, err := client.ACL().PolicyCreate(
+		&api.ACLPolicy{Name: "temporary", DeleteAfter: &deleteAfter},
+		&api.WriteOptions{Token: "root"},
+	)
+	assert.NoError(err)
+
+	ui = cli.NewMockUi()
+	cmd = New(ui)
+	code = cmd.Run(append(args, "-expires-within=2h"))
+	assert.Equal(code, 0)
+	assert.Empty(ui.ErrorWriter.String())
+	output = ui.OutputWriter.String()
+	assert.Contains(output, "temporary")
+	assert.Contains(output, "Delete After")
+	assert.NotContains(output, "test-policy-0")
 }
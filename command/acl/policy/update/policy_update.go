@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/acl"
@@ -32,6 +33,7 @@ type cmd struct {
 	datacenters    []string
 	rulesSet       bool
 	rules          string
+	deleteAfter    time.Duration
 	noMerge        bool
 	showMeta       bool
 	testStdin      io.Reader
@@ -51,12 +53,16 @@ func (c *cmd) init() {
 	c.flags.StringVar(&c.rules, "rules", "", "The policy rules. May be prefixed with '@' "+
 		"to indicate that the value is a file path to load the rules from. '-' may also be "+
 		"given to indicate that the rules are available on stdin")
+	c.flags.DurationVar(&c.deleteAfter, "delete-after", 0, "Duration from now after "+
+		"which the policy is deleted, such as '4h'. This replaces the current deletion "+
+		"time of the policy, if any")
 	c.flags.BoolVar(&c.noMerge, "no-merge", false, "Do not merge the current policy "+
 		"information with what is provided to the command. Instead overwrite all fields "+
 		"with the exception of the policy ID which is immutable.")
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
+	flags.Merge(c.flags, c.http.NamespaceFlags())
 	c.help = flags.Usage(help, c.flags)
 }
 
@@ -124,6 +130,7 @@ func (c *cmd) Run(args []string) int {
 			Description: policy.Description,
 			Datacenters: policy.Datacenters,
 			Rules:       policy.Rules,
+			DeleteAfter: policy.DeleteAfter,
 		}
 
 		if c.nameSet {
@@ -140,6 +147,11 @@ func (c *cmd) Run(args []string) int {
 		}
 	}
 
+	if c.deleteAfter > 0 {
+		deleteAfter := time.Now().Add(c.deleteAfter)
+		updated.DeleteAfter = &deleteAfter
+	}
+
 	policy, _, err := client.ACL().PolicyUpdate(updated, nil)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error updating policy %q: %v", policyID, err))
@@ -22,6 +22,7 @@ func parseCloneOutput(t *testing.T, output string) *api.ACLToken {
 		"AccessorID:   ([a-zA-Z0-9\\-]{36})\n" +
 		"SecretID:     ([a-zA-Z0-9\\-]{36})\n" +
 		"Description:  ([^\n]*)\n" +
+		"(?:Namespace:    [^\n]*\n)?" +
 		"Local:        (true|false)\n" +
 		"Create Time:  ([^\n]+)\n" +
 		"Policies:\n" +
@@ -25,6 +25,7 @@ type cmd struct {
 	policyIDs   []string
 	policyNames []string
 	description string
+	namePrefix  string
 	local       bool
 	showMeta    bool
 }
@@ -35,6 +36,9 @@ func (c *cmd) init() {
 		"as the content hash and raft indices should be shown for each entry")
 	c.flags.BoolVar(&c.local, "local", false, "Create this as a datacenter local token")
 	c.flags.StringVar(&c.description, "description", "", "A description of the token")
+	c.flags.StringVar(&c.namePrefix, "name-prefix", "", "Restrict the KV writes and "+
+		"service registrations of the token to the keys and service names starting "+
+		"with this prefix")
 	c.flags.Var((*flags.AppendSliceValue)(&c.policyIDs), "policy-id", "ID of a "+
 		"policy to use for this token. May be specified multiple times")
 	c.flags.Var((*flags.AppendSliceValue)(&c.policyNames), "policy-name", "Name of a "+
@@ -42,6 +46,7 @@ func (c *cmd) init() {
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
+	flags.Merge(c.flags, c.http.NamespaceFlags())
 	c.help = flags.Usage(help, c.flags)
 }
 
@@ -63,6 +68,7 @@ func (c *cmd) Run(args []string) int {
 
 	newToken := &api.ACLToken{
 		Description: c.description,
+		NamePrefix:  c.namePrefix,
 		Local:       c.local,
 	}
 
@@ -26,6 +26,7 @@ type cmd struct {
 	policyIDs     []string
 	policyNames   []string
 	description   string
+	namePrefix    string
 	mergePolicies bool
 	showMeta      bool
 	upgradeLegacy bool
@@ -41,6 +42,9 @@ func (c *cmd) init() {
 		"It may be specified as a unique ID prefix but will error if the prefix "+
 		"matches multiple token Accessor IDs")
 	c.flags.StringVar(&c.description, "description", "", "A description of the token")
+	c.flags.StringVar(&c.namePrefix, "name-prefix", "", "Restrict the KV writes and "+
+		"service registrations of the token to the keys and service names starting "+
+		"with this prefix")
 	c.flags.Var((*flags.AppendSliceValue)(&c.policyIDs), "policy-id", "ID of a "+
 		"policy to use for this token. May be specified multiple times")
 	c.flags.Var((*flags.AppendSliceValue)(&c.policyNames), "policy-name", "Name of a "+
@@ -54,6 +58,7 @@ func (c *cmd) init() {
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
+	flags.Merge(c.flags, c.http.NamespaceFlags())
 	c.help = flags.Usage(help, c.flags)
 }
 
@@ -107,6 +112,11 @@ func (c *cmd) Run(args []string) int {
 		token.Description = c.description
 	}
 
+	if c.namePrefix != "" {
+		// Like the description the prefix is only updated when specified.
+		token.NamePrefix = c.namePrefix
+	}
+
 	if c.mergePolicies {
 		for _, policyName := range c.policyNames {
 			found := false
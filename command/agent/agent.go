@@ -20,7 +20,6 @@ import (
 	"github.com/hashicorp/consul/service_os"
 	"github.com/hashicorp/go-checkpoint"
 	multierror "github.com/hashicorp/go-multierror"
-	"github.com/hashicorp/logutils"
 	"github.com/mitchellh/cli"
 	"google.golang.org/grpc/grpclog"
 )
@@ -60,7 +59,7 @@ type cmd struct {
 	versionHuman      string
 	shutdownCh        <-chan struct{}
 	flagArgs          config.Flags
-	logFilter         *logutils.LevelFilter
+	logFilter         *logger.SubsystemFilter
 	logOutput         io.Writer
 	logger            *log.Logger
 }
@@ -189,6 +188,8 @@ func (c *cmd) run(args []string) int {
 	// Setup the log outputs
 	logConfig := &logger.Config{
 		LogLevel:          config.LogLevel,
+		SubsystemLevels:   config.LogSubsystemLevels,
+		LogJSON:           config.LogJSON,
 		EnableSyslog:      config.EnableSyslog,
 		SyslogFacility:    config.SyslogFacility,
 		LogFilePath:       config.LogFile,
@@ -220,6 +221,7 @@ func (c *cmd) run(args []string) int {
 		return 1
 	}
 	agent.LogOutput = logOutput
+	agent.LogFilter = logFilter
 	agent.LogWriter = logWriter
 	agent.MemSink = memSink
 
@@ -362,17 +364,18 @@ func (c *cmd) handleReload(agent *agent.Agent, cfg *config.RuntimeConfig) (*conf
 	}
 
 	// Change the log level
-	minLevel := logutils.LogLevel(strings.ToUpper(newCfg.LogLevel))
-	if logger.ValidateLevelFilter(minLevel, c.logFilter) {
-		c.logFilter.SetMinLevel(minLevel)
-	} else {
-		errs = multierror.Append(fmt.Errorf(
-			"Invalid log level: %s. Valid log levels are: %v",
-			minLevel, c.logFilter.Levels))
+	if err := c.logFilter.SetLevel(newCfg.LogLevel); err != nil {
+		errs = multierror.Append(errs, err)
 
 		// Keep the current log level
 		newCfg.LogLevel = cfg.LogLevel
 	}
+	if err := c.logFilter.SetSubsystemLevels(newCfg.LogSubsystemLevels); err != nil {
+		errs = multierror.Append(errs, err)
+
+		// Keep the current levels of the subsystems
+		newCfg.LogSubsystemLevels = cfg.LogSubsystemLevels
+	}
 
 	if err := agent.ReloadConfig(newCfg); err != nil {
 		errs = multierror.Append(fmt.Errorf(
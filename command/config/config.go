@@ -0,0 +1,51 @@
+package config
+
+import (
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+)
+
+func New() *cmd {
+	return &cmd{}
+}
+
+type cmd struct{}
+
+func (c *cmd) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return flags.Usage(help, nil)
+}
+
+const synopsis = "Interact with Consul's centralized configurations"
+const help = `
+Usage: consul config <subcommand> [options] [args]
+
+  This command has subcommands for interacting with Consul's centralized
+  configuration entries. Here are some simple examples, and more detailed
+  examples are available in the subcommands or the documentation.
+
+  Create or update the defaults of the "web" service from a file:
+
+      $ consul config write web.hcl
+
+  Read the defaults of the "web" service back:
+
+      $ consul config read -kind service-defaults -name web
+
+  List the names of all the services with defaults:
+
+      $ consul config list -kind service-defaults
+
+  Delete the defaults of the "web" service:
+
+      $ consul config delete -kind service-defaults -name web
+
+  For more examples, ask for subcommand help or view the documentation.
+`
@@ -0,0 +1,13 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New().Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
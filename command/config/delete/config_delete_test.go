@@ -0,0 +1,48 @@
+package delete
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigDelete_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestConfigDelete(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	_, err := client.ConfigEntries().Set(&api.ServiceConfigEntry{
+		Kind: api.ServiceDefaults,
+		Name: "web",
+	}, nil)
+	require.NoError(err)
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-kind=" + api.ServiceDefaults,
+		"-name=web",
+	}
+	require.Equal(0, c.Run(args), ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "Config entry deleted: service-defaults/web")
+
+	entries, _, err := client.ConfigEntries().List(api.ServiceDefaults, nil)
+	require.NoError(err)
+	require.Len(entries, 0)
+}
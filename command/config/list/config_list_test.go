@@ -0,0 +1,46 @@
+package list
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigList_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestConfigList(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	for _, name := range []string{"web", "db", "api"} {
+		_, err := client.ConfigEntries().Set(&api.ServiceConfigEntry{
+			Kind:     api.ServiceDefaults,
+			Name:     name,
+			Protocol: "tcp",
+		}, nil)
+		require.NoError(err)
+	}
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-kind=" + api.ServiceDefaults,
+	}
+	require.Equal(0, c.Run(args), ui.ErrorWriter.String())
+	require.Equal("api\ndb\nweb\n", ui.OutputWriter.String())
+}
@@ -0,0 +1,54 @@
+package read
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigRead_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestConfigRead(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	_, err := client.ConfigEntries().Set(&api.ServiceConfigEntry{
+		Kind:     api.ServiceDefaults,
+		Name:     "web",
+		Protocol: "tcp",
+	}, nil)
+	require.NoError(err)
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-kind=" + api.ServiceDefaults,
+		"-name=web",
+	}
+	require.Equal(0, c.Run(args), ui.ErrorWriter.String())
+	output := ui.OutputWriter.String()
+	require.Contains(output, `"Kind": "service-defaults"`)
+	require.Contains(output, `"Name": "web"`)
+	require.Contains(output, `"Protocol": "tcp"`)
+
+	// The name is required
+	ui = cli.NewMockUi()
+	c = New(ui)
+	require.Equal(1, c.Run([]string{"-kind=" + api.ServiceDefaults}))
+	require.Contains(ui.ErrorWriter.String(), "Must specify the -name parameter")
+}
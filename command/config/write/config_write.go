@@ -0,0 +1,131 @@
+package write
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/hcl"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// testStdin is the input for testing.
+	testStdin io.Reader
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+	if len(args) != 1 {
+		c.UI.Error("Must provide exactly one positional argument to specify the config entry to write")
+		return 1
+	}
+
+	data, err := c.loadData(args[0])
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to load data: %v", err))
+		return 1
+	}
+
+	// HCL is a superset of JSON, so both formats are parsed here
+	var raw map[string]interface{}
+	if err := hcl.Decode(&raw, data); err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to decode config entry input: %v", err))
+		return 1
+	}
+	entry, err := api.DecodeConfigEntry(raw)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to decode config entry input: %v", err))
+		return 1
+	}
+
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	if _, err := client.ConfigEntries().Set(entry, nil); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing config entry %s/%s: %v", entry.GetKind(), entry.GetName(), err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Config entry written: %s/%s", entry.GetKind(), entry.GetName()))
+	return 0
+}
+
+// loadData reads the config entry from the given file, or from stdin if
+// the path is "-".
+func (c *cmd) loadData(path string) (string, error) {
+	if path != "-" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	var stdin io.Reader = os.Stdin
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+	var b bytes.Buffer
+	if _, err := io.Copy(&b, stdin); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Create or update a centralized config entry"
+const help = `
+Usage: consul config write [options] <configuration>
+
+  Request a config entry to be created or updated. The configuration
+  argument is either a file path or '-' to indicate that the config
+  should be read from stdin. The data should be either in HCL or
+  JSON form.
+
+  Example (from file):
+
+    $ consul config write web.service.hcl
+
+  Example (from stdin):
+
+    $ consul config write -
+`
@@ -0,0 +1,102 @@
+package write
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigWrite_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestConfigWrite(t *testing.T) {
+	t.Parallel()
+
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	t.Run("HCL from stdin", func(t *testing.T) {
+		require := require.New(t)
+		ui := cli.NewMockUi()
+		c := New(ui)
+		c.testStdin = strings.NewReader(`
+			kind = "service-defaults"
+			name = "web"
+			protocol = "http"
+			mesh_gateway {
+				mode = "local"
+			}
+		`)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-",
+		}
+		require.Equal(0, c.Run(args), ui.ErrorWriter.String())
+		require.Contains(ui.OutputWriter.String(), "Config entry written: service-defaults/web")
+
+		entry, _, err := client.ConfigEntries().Get(api.ServiceDefaults, "web", nil)
+		require.NoError(err)
+		service, ok := entry.(*api.ServiceConfigEntry)
+		require.True(ok)
+		require.Equal("http", service.Protocol)
+		require.Equal(api.MeshGatewayModeLocal, service.MeshGateway.Mode)
+	})
+
+	t.Run("JSON from stdin", func(t *testing.T) {
+		require := require.New(t)
+		ui := cli.NewMockUi()
+		c := New(ui)
+		c.testStdin = strings.NewReader(`{
+			"Kind": "proxy-defaults",
+			"Name": "global",
+			"Config": {
+				"foo": "bar"
+			}
+		}`)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-",
+		}
+		require.Equal(0, c.Run(args), ui.ErrorWriter.String())
+
+		entry, _, err := client.ConfigEntries().Get(api.ProxyDefaults, api.ProxyConfigGlobal, nil)
+		require.NoError(err)
+		proxy, ok := entry.(*api.ProxyConfigEntry)
+		require.True(ok)
+		require.Equal("bar", proxy.Config["foo"])
+	})
+
+	t.Run("invalid kind", func(t *testing.T) {
+		require := require.New(t)
+		ui := cli.NewMockUi()
+		c := New(ui)
+		c.testStdin = strings.NewReader(`kind = "foo"`)
+
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-",
+		}
+		require.Equal(1, c.Run(args))
+		require.Contains(ui.ErrorWriter.String(), "invalid config entry kind: foo")
+	})
+
+	t.Run("no arguments", func(t *testing.T) {
+		require := require.New(t)
+		ui := cli.NewMockUi()
+		c := New(ui)
+
+		require.Equal(1, c.Run([]string{"-http-addr=" + a.HTTPAddr()}))
+		require.Contains(ui.ErrorWriter.String(), "Must provide exactly one positional argument")
+	})
+}
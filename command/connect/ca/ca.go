@@ -41,5 +41,9 @@ Usage: consul connect ca <subcommand> [options] [args]
 
       $ consul connect ca set-config -config-file ca.json
 
+  Follow the rotation of the roots:
+
+      $ consul connect ca status
+
   For more examples, ask for subcommand help or view the documentation.
 `
@@ -71,12 +71,29 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	// Remember the active root to report whether the change rotated it.
+	before, _, err := client.Connect().CARoots(nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying CA roots: %s", err))
+		return 1
+	}
+
 	// Set the new configuration.
 	if _, err := client.Connect().CASetConfig(&config, nil); err != nil {
 		c.UI.Error(fmt.Sprintf("Error setting CA configuration: %s", err))
 		return 1
 	}
 	c.UI.Output("Configuration updated!")
+
+	after, _, err := client.Connect().CARoots(nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying CA roots: %s", err))
+		return 1
+	}
+	if after.ActiveRootID != before.ActiveRootID {
+		c.UI.Output(fmt.Sprintf("The CA root was rotated to %s, cross-signed by the previous root. "+
+			"Run \"consul connect ca status\" to follow the rotation.", after.ActiveRootID))
+	}
 	return 0
 }
 
@@ -92,5 +109,10 @@ const synopsis = "Modify the current Connect CA configuration"
 const help = `
 Usage: consul connect ca set-config [options]
 
-  Modifies the current Connect Certificate Authority (CA) configuration.
+  Modifies the current Connect Certificate Authority (CA) configuration. A
+  change of the provider or of its root certificate rotates the root: the new
+  root is cross-signed by the previous one, which stays trusted until the leaf
+  certificates it signed expire.
+
+      $ consul connect ca set-config -config-file ca.json
 `
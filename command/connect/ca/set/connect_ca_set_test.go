@@ -1,6 +1,8 @@
 package set
 
 import (
+	"encoding/json"
+	"os"
 	"strings"
 	"testing"
 	"time"
@@ -8,8 +10,10 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/connect/ca"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/sdk/testutil"
 	"github.com/hashicorp/consul/testrpc"
 	"github.com/mitchellh/cli"
 )
@@ -50,4 +54,44 @@ func TestConnectCASetConfigCommand(t *testing.T) {
 	parsed, err := ca.ParseConsulCAConfig(reply.Config)
 	require.NoError(err)
 	require.Equal(24*time.Hour, parsed.RotationPeriod)
+
+	// The root didn't change.
+	require.NotContains(ui.OutputWriter.String(), "rotated")
+}
+
+func TestConnectCASetConfigCommand_rotation(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// A new root certificate rotates the root.
+	root := connect.TestCA(t, nil)
+	config, err := json.Marshal(map[string]interface{}{
+		"Provider": "consul",
+		"Config": map[string]interface{}{
+			"PrivateKey": root.SigningKey,
+			"RootCert":   root.RootCert,
+		},
+	})
+	require.NoError(err)
+	f := testutil.TempFile(t, "ca_config")
+	defer os.Remove(f.Name())
+	_, err = f.Write(config)
+	require.NoError(err)
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-config-file=" + f.Name(),
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	require.Contains(ui.OutputWriter.String(), "The CA root was rotated to "+root.ID)
 }
@@ -0,0 +1,132 @@
+package status
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		c.UI.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+
+	// Set up a client.
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	opts := &api.QueryOptions{
+		AllowStale: c.http.Stale(),
+	}
+	roots, _, err := client.Connect().CARoots(opts)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying CA roots: %s", err))
+		return 1
+	}
+	if len(roots.Roots) == 0 {
+		c.UI.Info("No CA roots found")
+		return 0
+	}
+
+	c.UI.Output(formatRoots(roots))
+	c.UI.Output("")
+	c.UI.Output(rotationStatus(roots))
+	return 0
+}
+
+// formatRoots returns the table of the trusted roots, the active one first.
+func formatRoots(roots *api.CARootList) string {
+	result := []string{"ID|Name|Active|Intermediates|Rotated Out"}
+	for _, active := range []bool{true, false} {
+		for _, root := range roots.Roots {
+			if root.Active != active {
+				continue
+			}
+			rotatedOut := "-"
+			if !root.RotatedOutAt.IsZero() {
+				rotatedOut = root.RotatedOutAt.Format(time.RFC3339)
+			}
+			result = append(result, fmt.Sprintf("%s|%s|%t|%d|%s",
+				root.ID, root.Name, root.Active, len(root.IntermediateCerts), rotatedOut))
+		}
+	}
+	return columnize.SimpleFormat(result)
+}
+
+// rotationStatus describes the progress of the rotation of the roots. A
+// rotation is in progress as long as the previous roots are still trusted.
+func rotationStatus(roots *api.CARootList) string {
+	var active *api.CARoot
+	previous := 0
+	for _, root := range roots.Roots {
+		if root.Active {
+			active = root
+		} else {
+			previous++
+		}
+	}
+
+	if previous == 0 {
+		return "No root rotation in progress."
+	}
+	status := fmt.Sprintf("Root rotation in progress: %d previous root(s) still trusted "+
+		"until the leaf certificates they signed expire.", previous)
+	if active != nil && len(active.IntermediateCerts) > 0 {
+		status += " The active root is cross-signed by the previous root, so the " +
+			"leaf certificates it signs are trusted by both."
+	}
+	return status
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Display the Connect CA roots and the progress of their rotation"
+const help = `
+Usage: consul connect ca status [options]
+
+  Displays the roots trusted by the Connect Certificate Authority (CA) and
+  the progress of their rotation. After a configuration change rotating the
+  root, the new root is cross-signed by the previous one, which stays trusted
+  until the leaf certificates it signed expire.
+
+      $ consul connect ca status
+`
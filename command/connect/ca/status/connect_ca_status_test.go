@@ -0,0 +1,62 @@
+package status
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConnectCAStatusCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestConnectCAStatusCommand(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	args := []string{"-http-addr=" + a.HTTPAddr()}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := ui.OutputWriter.String()
+	require.Contains(t, output, "Rotated Out")
+	require.Contains(t, output, "No root rotation in progress.")
+}
+
+func TestConnectCAStatus_rotation(t *testing.T) {
+	t.Parallel()
+
+	rotatedOut := time.Date(2019, 10, 1, 12, 0, 0, 0, time.UTC)
+	roots := &api.CARootList{
+		Roots: []*api.CARoot{
+			{ID: "old", Name: "Root 1", RotatedOutAt: rotatedOut},
+			{ID: "new", Name: "Root 2", Active: true, IntermediateCerts: []string{"cross-signed"}},
+		},
+	}
+
+	// The active root is listed first.
+	lines := strings.Split(formatRoots(roots), "\n")
+	require.Len(t, lines, 3)
+	require.True(t, strings.HasPrefix(lines[1], "new "))
+	require.True(t, strings.HasPrefix(lines[2], "old "))
+	require.Contains(t, lines[2], "2019-10-01T12:00:00Z")
+
+	status := rotationStatus(roots)
+	require.Contains(t, status, "1 previous root(s) still trusted")
+	require.Contains(t, status, "cross-signed by the previous root")
+}
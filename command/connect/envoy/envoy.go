@@ -48,6 +48,13 @@ type cmd struct {
 	envoyBin   string
 	bootstrap  bool
 	grpcAddr   string
+
+	// mesh gateway flags
+	meshGateway    bool
+	register       bool
+	gatewaySvcName string
+	address        string
+	wanAddress     string
 }
 
 func (c *cmd) init() {
@@ -78,6 +85,28 @@ func (c *cmd) init() {
 		"Set the agent's gRPC address and port (in http(s)://host:port format). "+
 			"Alternatively, you can specify CONSUL_GRPC_ADDR in ENV.")
 
+	c.flags.BoolVar(&c.meshGateway, "mesh-gateway", false,
+		"Configure Envoy as a mesh gateway routing the Connect traffic between "+
+			"datacenters instead of a Connect proxy. Unless -proxy-id is set, "+
+			"the mesh gateway service named by -service must be registered with "+
+			"the local agent, or -register must be set.")
+
+	c.flags.BoolVar(&c.register, "register", false,
+		"Register the mesh gateway service with the local agent before starting "+
+			"Envoy. Requires -mesh-gateway and -address.")
+
+	c.flags.StringVar(&c.gatewaySvcName, "service", "mesh-gateway",
+		"The name of the mesh gateway service. Requires -mesh-gateway.")
+
+	c.flags.StringVar(&c.address, "address", "",
+		"The address:port the mesh gateway listens on, registered as its LAN "+
+			"address. Requires -register.")
+
+	c.flags.StringVar(&c.wanAddress, "wan-address", "",
+		"The address:port the mesh gateways of the other datacenters reach this "+
+			"mesh gateway on, registered as its WAN address. Defaults to the "+
+			"-address. Requires -register.")
+
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	c.help = flags.Usage(help, c.flags)
@@ -120,6 +149,32 @@ func (c *cmd) Run(args []string) int {
 	}
 	c.client = client
 
+	if c.meshGateway {
+		if c.sidecarFor != "" {
+			c.UI.Error("-sidecar-for and -mesh-gateway cannot both be set")
+			return 1
+		}
+		if c.register {
+			proxyID, err := c.registerMeshGateway()
+			if err != nil {
+				c.UI.Error(err.Error())
+				return 1
+			}
+			c.proxyID = proxyID
+		}
+		if c.proxyID == "" {
+			proxyID, err := c.lookupMeshGatewayProxyID()
+			if err != nil {
+				c.UI.Error(err.Error())
+				return 1
+			}
+			c.proxyID = proxyID
+		}
+	} else if c.register {
+		c.UI.Error("-register requires -mesh-gateway")
+		return 1
+	}
+
 	// See if we need to lookup proxyID
 	if c.proxyID == "" && c.sidecarFor != "" {
 		proxyID, err := c.lookupProxyIDForSidecar()
@@ -235,8 +290,15 @@ func (c *cmd) templateArgs() (*templateArgs, error) {
 		return nil, fmt.Errorf("Failed to resolve admin bind address: %s", err)
 	}
 
+	// The mesh gateways are clustered by their service name, unlike the
+	// proxies which are each their own cluster.
+	cluster := c.proxyID
+	if c.meshGateway {
+		cluster = c.gatewaySvcName
+	}
+
 	return &templateArgs{
-		ProxyCluster:          c.proxyID,
+		ProxyCluster:          cluster,
 		ProxyID:               c.proxyID,
 		AgentAddress:          agentIP.String(),
 		AgentPort:             agentPort,
@@ -267,6 +329,85 @@ func (c *cmd) lookupProxyIDForSidecar() (string, error) {
 	return proxyCmd.LookupProxyIDForSidecar(c.client, c.sidecarFor)
 }
 
+// registerMeshGateway registers the mesh gateway service with the local agent
+// and returns its ID.
+func (c *cmd) registerMeshGateway() (string, error) {
+	if c.address == "" {
+		return "", errors.New("-address is required to register a mesh gateway")
+	}
+	lanAddr, lanPort, err := parseAddress(c.address)
+	if err != nil {
+		return "", fmt.Errorf("Invalid -address: %s", err)
+	}
+	wanAddr, wanPort := lanAddr, lanPort
+	if c.wanAddress != "" {
+		wanAddr, wanPort, err = parseAddress(c.wanAddress)
+		if err != nil {
+			return "", fmt.Errorf("Invalid -wan-address: %s", err)
+		}
+	}
+
+	id := c.proxyID
+	if id == "" {
+		id = c.gatewaySvcName
+	}
+	err = c.client.Agent().ServiceRegister(&api.AgentServiceRegistration{
+		Kind:    api.ServiceKindMeshGateway,
+		ID:      id,
+		Name:    c.gatewaySvcName,
+		Address: lanAddr,
+		Port:    lanPort,
+		TaggedAddresses: map[string]api.ServiceAddress{
+			"lan": api.ServiceAddress{Address: lanAddr, Port: lanPort},
+			"wan": api.ServiceAddress{Address: wanAddr, Port: wanPort},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error registering mesh gateway %q: %s", id, err)
+	}
+	c.UI.Output(fmt.Sprintf("Registered mesh gateway service: %s", id))
+	return id, nil
+}
+
+// lookupMeshGatewayProxyID returns the ID of the mesh gateway service of the
+// local agent with the -service name.
+func (c *cmd) lookupMeshGatewayProxyID() (string, error) {
+	svcs, err := c.client.Agent().Services()
+	if err != nil {
+		return "", fmt.Errorf("Failed looking up mesh gateway %s: %s", c.gatewaySvcName, err)
+	}
+
+	var proxyIDs []string
+	for _, svc := range svcs {
+		if svc.Kind == api.ServiceKindMeshGateway && svc.Service == c.gatewaySvcName {
+			proxyIDs = append(proxyIDs, svc.ID)
+		}
+	}
+
+	if len(proxyIDs) == 0 {
+		return "", fmt.Errorf("No mesh gateway registered for %s", c.gatewaySvcName)
+	}
+	if len(proxyIDs) > 1 {
+		return "", fmt.Errorf("More than one mesh gateway registered for %s.\n"+
+			"    Start proxy with -proxy-id and one of the following IDs: %s",
+			c.gatewaySvcName, strings.Join(proxyIDs, ", "))
+	}
+	return proxyIDs[0], nil
+}
+
+// parseAddress splits an address:port into the address and the port.
+func parseAddress(addrPort string) (string, int, error) {
+	addr, portStr, err := net.SplitHostPort(addrPort)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port %q", portStr)
+	}
+	return addr, port, nil
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -299,4 +440,12 @@ Usage: consul connect envoy [options]
 
     $ consul connect envoy -sidecar-for web
 
+  With -mesh-gateway, Envoy is configured as a mesh gateway routing the
+  Connect traffic between datacenters. The mesh gateway requires
+  service:write permissions for its own service. The example below registers
+  a mesh gateway and starts it:
+
+    $ consul connect envoy -mesh-gateway -register \
+        -address 10.0.0.1:8443 -wan-address 198.18.0.1:443
+
 `
@@ -8,7 +8,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/hashicorp/consul/agent"
 	"github.com/hashicorp/consul/agent/xds"
+	"github.com/hashicorp/consul/api"
 	"github.com/mitchellh/cli"
 	"github.com/stretchr/testify/require"
 )
@@ -119,6 +121,34 @@ func TestGenerateConfig(t *testing.T) {
 				LocalAgentClusterName: xds.LocalAgentClusterName,
 			},
 		},
+		{
+			Name: "mesh-gateway",
+			Flags: []string{"-mesh-gateway", "-proxy-id", "mesh-gateway-1",
+				"-service", "gateway"},
+			Env: []string{},
+			WantArgs: templateArgs{
+				// The mesh gateways are clustered by service name
+				ProxyCluster:          "gateway",
+				ProxyID:               "mesh-gateway-1",
+				AgentAddress:          "127.0.0.1",
+				AgentPort:             "8502",
+				AdminBindAddress:      "127.0.0.1",
+				AdminBindPort:         "19000",
+				LocalAgentClusterName: xds.LocalAgentClusterName,
+			},
+		},
+		{
+			Name:    "mesh-gateway-sidecar-for",
+			Flags:   []string{"-mesh-gateway", "-sidecar-for", "web"},
+			Env:     []string{},
+			WantErr: "-sidecar-for and -mesh-gateway cannot both be set",
+		},
+		{
+			Name:    "register-without-mesh-gateway",
+			Flags:   []string{"-register", "-proxy-id", "test-proxy"},
+			Env:     []string{},
+			WantErr: "-register requires -mesh-gateway",
+		},
 		// TODO(banks): all the flags/env manipulation cases
 	}
 
@@ -165,3 +195,57 @@ func TestGenerateConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestEnvoy_RegisterMeshGateway(t *testing.T) {
+	t.Parallel()
+
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	require := require.New(t)
+	ui := cli.NewMockUi()
+	c := New(ui)
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-bootstrap",
+		"-mesh-gateway",
+		"-register",
+		"-address", "10.0.0.1:8443",
+		"-wan-address", "198.18.0.1:443",
+	}
+	require.Equal(0, c.Run(args), ui.ErrorWriter.String())
+	require.Equal("mesh-gateway", c.proxyID)
+
+	svcs, err := client.Agent().Services()
+	require.NoError(err)
+	svc, ok := svcs["mesh-gateway"]
+	require.True(ok)
+	require.Equal(api.ServiceKindMeshGateway, svc.Kind)
+	require.Equal("10.0.0.1", svc.Address)
+	require.Equal(8443, svc.Port)
+	require.Equal(api.ServiceAddress{Address: "198.18.0.1", Port: 443}, svc.TaggedAddresses["wan"])
+
+	// The registered mesh gateway is looked up by service name.
+	ui = cli.NewMockUi()
+	c = New(ui)
+	args = []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-bootstrap",
+		"-mesh-gateway",
+	}
+	require.Equal(0, c.Run(args), ui.ErrorWriter.String())
+	require.Equal("mesh-gateway", c.proxyID)
+
+	// Registering requires the address.
+	ui = cli.NewMockUi()
+	c = New(ui)
+	args = []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-bootstrap",
+		"-mesh-gateway",
+		"-register",
+	}
+	require.Equal(1, c.Run(args))
+	require.Contains(ui.ErrorWriter.String(), "-address is required")
+}
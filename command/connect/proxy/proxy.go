@@ -19,7 +19,6 @@ import (
 	proxyImpl "github.com/hashicorp/consul/connect/proxy"
 
 	"github.com/hashicorp/consul/logger"
-	"github.com/hashicorp/logutils"
 	"github.com/mitchellh/cli"
 )
 
@@ -44,7 +43,7 @@ type cmd struct {
 
 	shutdownCh <-chan struct{}
 
-	logFilter *logutils.LevelFilter
+	logFilter *logger.SubsystemFilter
 	logOutput io.Writer
 	logger    *log.Logger
 
@@ -235,7 +235,7 @@ func (c *cmd) prepare() (version string, err error) {
 		}
 
 		if c.interval < debugMinInterval {
-			return "", fmt.Errorf("interval must be longer than %s", debugMinDuration)
+			return "", fmt.Errorf("interval must be longer than %s", debugMinInterval)
 		}
 
 		if c.duration < c.interval {
@@ -428,9 +428,8 @@ func (c *cmd) captureDynamic() error {
 					s = 1
 				}
 
+				wgProf.Add(2)
 				go func() {
-					wgProf.Add(1)
-
 					prof, err := c.client.Debug().Profile(int(s))
 					if err != nil {
 						errCh <- err
@@ -445,8 +444,6 @@ func (c *cmd) captureDynamic() error {
 				}()
 
 				go func() {
-					wgProf.Add(1)
-
 					trace, err := c.client.Debug().Trace(int(s))
 					if err != nil {
 						errCh <- err
@@ -19,6 +19,9 @@ type HTTPFlags struct {
 	// server flags
 	datacenter StringValue
 	stale      BoolValue
+
+	// namespace flags
+	namespace StringValue
 }
 
 func (f *HTTPFlags) ClientFlags() *flag.FlagSet {
@@ -65,6 +68,15 @@ func (f *HTTPFlags) ServerFlags() *flag.FlagSet {
 	return fs
 }
 
+func (f *HTTPFlags) NamespaceFlags() *flag.FlagSet {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.Var(&f.namespace, "namespace",
+		"Name of the ACL namespace to operate on. This can also be specified via "+
+			"the CONSUL_NAMESPACE environment variable. If unspecified, the "+
+			"default namespace is used.")
+	return fs
+}
+
 func (f *HTTPFlags) Addr() string {
 	return f.address.String()
 }
@@ -80,6 +92,10 @@ func (f *HTTPFlags) Stale() bool {
 	return *f.stale.v
 }
 
+func (f *HTTPFlags) Namespace() string {
+	return f.namespace.String()
+}
+
 func (f *HTTPFlags) Token() string {
 	return f.token.String()
 }
@@ -105,4 +121,5 @@ func (f *HTTPFlags) MergeOntoConfig(c *api.Config) {
 	f.keyFile.Merge(&c.TLSConfig.KeyFile)
 	f.tlsServerName.Merge(&c.TLSConfig.Address)
 	f.datacenter.Merge(&c.Datacenter)
+	f.namespace.Merge(&c.Namespace)
 }
@@ -1,6 +1,7 @@
 package info
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"sort"
@@ -15,15 +16,27 @@ func New(ui cli.Ui) *cmd {
 	return c
 }
 
+const (
+	// PrettyFormat prints the stats grouped by section as plain text.
+	PrettyFormat = "pretty"
+
+	// JSONFormat prints the stats as a JSON object keyed by section.
+	JSONFormat = "json"
+)
+
 type cmd struct {
 	UI    cli.Ui
 	flags *flag.FlagSet
 	http  *flags.HTTPFlags
 	help  string
+
+	format string
 }
 
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.format, "format", PrettyFormat,
+		fmt.Sprintf("Output format. Must be one of %q or %q.", PrettyFormat, JSONFormat))
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	c.help = flags.Usage(help, c.flags)
@@ -33,6 +46,10 @@ func (c *cmd) Run(args []string) int {
 	if err := c.flags.Parse(args); err != nil {
 		return 1
 	}
+	if c.format != PrettyFormat && c.format != JSONFormat {
+		c.UI.Error(fmt.Sprintf("Invalid format %q, must be one of %q or %q", c.format, PrettyFormat, JSONFormat))
+		return 1
+	}
 
 	client, err := c.http.APIClient()
 	if err != nil {
@@ -51,6 +68,16 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	if c.format == JSONFormat {
+		out, err := json.MarshalIndent(stats, "", "    ")
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error encoding stats: %s", err))
+			return 1
+		}
+		c.UI.Output(string(out))
+		return 0
+	}
+
 	// Get the keys in sorted order
 	keys := make([]string, 0, len(stats))
 	for key := range stats {
@@ -95,5 +122,12 @@ const synopsis = "Provides debugging information for operators."
 const help = `
 Usage: consul info [options]
 
-  Provides debugging information for operators.
+  Provides debugging information for operators. The stats are grouped by
+  subsystem, including the agent cache, ACL caches and, on servers, the
+  number of blocking queries currently waiting for changes.
+
+  Any agent can be queried by pointing -http-addr at it, and the output
+  can be rendered as JSON for consumption by other tools:
+
+      $ consul info -http-addr=10.0.0.5:8500 -format=json
 `
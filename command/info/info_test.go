@@ -1,6 +1,7 @@
 package info
 
 import (
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -33,3 +34,45 @@ func TestInfoCommand(t *testing.T) {
 		t.Fatalf("bad: %#v", ui.OutputWriter.String())
 	}
 }
+
+func TestInfoCommand_JSON(t *testing.T) {
+	t.Parallel()
+	a1 := agent.NewTestAgent(t, t.Name(), ``)
+	defer a1.Shutdown()
+
+	ui := cli.NewMockUi()
+	cmd := New(ui)
+	args := []string{"-http-addr=" + a1.HTTPAddr(), "-format=json"}
+
+	code := cmd.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	var stats map[string]map[string]string
+	if err := json.Unmarshal(ui.OutputWriter.Bytes(), &stats); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	for _, section := range []string{"agent", "build", "cache", "consul", "rpc"} {
+		if _, ok := stats[section]; !ok {
+			t.Fatalf("missing section %q: %v", section, stats)
+		}
+	}
+	if _, ok := stats["rpc"]["queries_blocking"]; !ok {
+		t.Fatalf("missing blocking query count: %v", stats["rpc"])
+	}
+}
+
+func TestInfoCommand_invalidFormat(t *testing.T) {
+	t.Parallel()
+
+	ui := cli.NewMockUi()
+	cmd := New(ui)
+
+	if code := cmd.Run([]string{"-format=yaml"}); code != 1 {
+		t.Fatalf("bad: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Invalid format") {
+		t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,81 @@
+package list
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestCommand_Validation(t *testing.T) {
+	t.Parallel()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	require.Equal(t, 1, c.Run([]string{"foo"}))
+	require.Contains(t, ui.ErrorWriter.String(), "takes no arguments")
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	// No intentions yet
+	{
+		ui := cli.NewMockUi()
+		c := New(ui)
+
+		require.Equal(0, c.Run([]string{"-http-addr=" + a.HTTPAddr()}), ui.ErrorWriter.String())
+		require.Contains(ui.OutputWriter.String(), "No intentions found")
+	}
+
+	// Create some intentions
+	var ids []string
+	{
+		insert := [][]string{
+			{"web", "db"},
+			{"*", "db"},
+		}
+
+		for _, v := range insert {
+			id, _, err := client.Connect().IntentionCreate(&api.Intention{
+				SourceName:      v[0],
+				DestinationName: v[1],
+				Action:          api.IntentionActionDeny,
+			}, nil)
+			require.NoError(err)
+			ids = append(ids, id)
+		}
+	}
+
+	// List them
+	{
+		ui := cli.NewMockUi()
+		c := New(ui)
+
+		require.Equal(0, c.Run([]string{"-http-addr=" + a.HTTPAddr()}), ui.ErrorWriter.String())
+		output := ui.OutputWriter.String()
+		require.Contains(output, "Precedence")
+		for _, id := range ids {
+			require.Contains(output, id)
+		}
+
+		// The most precise intention comes first
+		require.True(strings.Index(output, ids[0]) < strings.Index(output, ids[1]), output)
+	}
+}
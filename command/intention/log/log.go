@@ -0,0 +1,187 @@
+package log
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/consul/command/intention/finder"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// flags
+	flagDiff bool
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.flagDiff, "diff", true,
+		"Show the fields changed by each change.")
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// The changes are filtered by ID, or by source and destination which
+	// also matches the intentions deleted since.
+	var id, src, dst string
+	switch args := c.flags.Args(); len(args) {
+	case 0:
+	case 1:
+		id = args[0]
+	case 2:
+		src, dst = finder.StripDefaultNS(args[0]), finder.StripDefaultNS(args[1])
+	default:
+		c.UI.Error("Error: command requires at most 2 arguments")
+		return 1
+	}
+
+	// Create and test the HTTP client
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	changes, _, err := client.Connect().Changes(api.ConnectChangeIntention, id, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading the intention changes: %s", err))
+		return 1
+	}
+
+	for _, change := range changes {
+		if src != "" && (change.SourceName != src || change.DestinationName != dst) {
+			continue
+		}
+
+		actor := change.Actor
+		if actor == "" {
+			actor = "-"
+		}
+		c.UI.Output(fmt.Sprintf("%d %s %s %s => %s (%s) by %s",
+			change.Index, change.Time.Local().Format(time.RFC3339), change.Op,
+			change.SourceName, change.DestinationName, change.Name, actor))
+		if !c.flagDiff {
+			continue
+		}
+
+		lines, err := diffChange(change)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error decoding change %d: %s", change.Index, err))
+			return 1
+		}
+		for _, line := range lines {
+			c.UI.Output("    " + line)
+		}
+	}
+	return 0
+}
+
+// diffChange returns the lines describing the fields that differ between the
+// values before and after the change, sorted by field.
+func diffChange(change *api.ConnectChange) ([]string, error) {
+	before, err := decodeChangeValue(change.Before)
+	if err != nil {
+		return nil, err
+	}
+	after, err := decodeChangeValue(change.After)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]struct{})
+	for k := range before {
+		keys[k] = struct{}{}
+	}
+	for k := range after {
+		keys[k] = struct{}{}
+	}
+	var sorted []string
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var lines []string
+	for _, k := range sorted {
+		oldVal, okOld := before[k]
+		newVal, okNew := after[k]
+		if okOld && okNew && reflect.DeepEqual(oldVal, newVal) {
+			continue
+		}
+		if okOld {
+			lines = append(lines, fmt.Sprintf("- %s: %s", k, formatChangeValue(oldVal)))
+		}
+		if okNew {
+			lines = append(lines, fmt.Sprintf("+ %s: %s", k, formatChangeValue(newVal)))
+		}
+	}
+	return lines, nil
+}
+
+func decodeChangeValue(raw string) (map[string]interface{}, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var v map[string]interface{}
+	if err := json.Unmarshal([]byte(raw), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func formatChangeValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(encoded)
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Show the changes made to intentions."
+const help = `
+Usage: consul intention log [options] [SRC DST | ID]
+
+  Show the changes made to intentions, from the newest, along with the
+  token that made them and the fields they changed. The changes can be
+  restricted to an intention via its unique ID, or via its source and
+  destination which also matches the intentions deleted since.
+
+      $ consul intention log web db
+
+  The servers keep a bounded number of the most recent changes.
+`
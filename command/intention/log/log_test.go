@@ -0,0 +1,109 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestCommand_Validation(t *testing.T) {
+	t.Parallel()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	require.Equal(t, 1, c.Run([]string{"a", "b", "c"}))
+	require.Contains(t, ui.ErrorWriter.String(), "requires at most 2")
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	// Create, update and delete an intention, and create another one
+	ixn := &api.Intention{
+		SourceName:      "web",
+		DestinationName: "db",
+		Action:          api.IntentionActionAllow,
+	}
+	id, _, err := client.Connect().IntentionCreate(ixn, nil)
+	require.NoError(t, err)
+	ixn.ID = id
+	ixn.Action = api.IntentionActionDeny
+	_, err = client.Connect().IntentionUpdate(ixn, nil)
+	require.NoError(t, err)
+	_, err = client.Connect().IntentionDelete(id, nil)
+	require.NoError(t, err)
+	otherID, _, err := client.Connect().IntentionCreate(&api.Intention{
+		SourceName:      "api",
+		DestinationName: "db",
+		Action:          api.IntentionActionAllow,
+	}, nil)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		args     []string
+		contains []string
+		excludes []string
+	}{
+		"all": {
+			nil,
+			[]string{id, otherID},
+			nil,
+		},
+		"id": {
+			[]string{otherID},
+			[]string{"create api => db"},
+			[]string{id},
+		},
+		"src dst": {
+			[]string{"web", "db"},
+			[]string{
+				"delete web => db",
+				"update web => db",
+				"create web => db",
+				"- Action: allow",
+				"+ Action: deny",
+			},
+			[]string{otherID},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := New(ui)
+
+			args := append([]string{"-http-addr=" + a.HTTPAddr()}, tc.args...)
+			require.Equal(t, 0, c.Run(args), ui.ErrorWriter.String())
+			output := ui.OutputWriter.String()
+			for _, v := range tc.contains {
+				require.Contains(t, output, v)
+			}
+			for _, v := range tc.excludes {
+				require.NotContains(t, output, v)
+			}
+		})
+	}
+
+	// The diff can be omitted
+	ui := cli.NewMockUi()
+	c := New(ui)
+	args := []string{"-http-addr=" + a.HTTPAddr(), "-diff=false", "web", "db"}
+	require.Equal(t, 0, c.Run(args), ui.ErrorWriter.String())
+	require.NotContains(t, ui.OutputWriter.String(), "Action")
+}
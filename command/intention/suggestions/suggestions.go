@@ -0,0 +1,114 @@
+package suggestions
+
+import (
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// flags
+	flagCreate bool
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.flagCreate, "create", false,
+		"Create an intention allowing each suggested connection.")
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+	if len(c.flags.Args()) > 0 {
+		c.UI.Error("Error: command takes no arguments")
+		return 1
+	}
+
+	// Create and test the HTTP client
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	suggestions, _, err := client.Connect().IntentionSuggestions(nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading the intention suggestions: %s", err))
+		return 1
+	}
+	if len(suggestions) == 0 {
+		c.UI.Info("No suggestions")
+		return 0
+	}
+
+	if c.flagCreate {
+		for _, s := range suggestions {
+			ixn := s.Intention()
+			if _, _, err := client.Connect().IntentionCreate(ixn, nil); err != nil {
+				c.UI.Error(fmt.Sprintf("Error creating intention %q: %s", ixn, err))
+				return 1
+			}
+			c.UI.Output(fmt.Sprintf("Created: %s", ixn))
+		}
+		return 0
+	}
+
+	result := []string{"Source|Destination|Allowed|Denied|Last Seen"}
+	for _, s := range suggestions {
+		ixn := s.Intention()
+		result = append(result, fmt.Sprintf("%s|%s|%d|%d|%s",
+			ixn.SourceString(), ixn.DestinationString(), s.Allowed, s.Denied,
+			s.LastSeen.Local().Format(time.RFC3339)))
+	}
+	c.UI.Output(columnize.SimpleFormat(result))
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Suggest intentions from the observed connections."
+const help = `
+Usage: consul intention suggestions [options]
+
+  List the connections between services that weren't covered by any
+  intention, so were allowed or denied by the default behavior, along with
+  how many were allowed and denied. This helps writing the intentions
+  needed before denying connections by default.
+
+  The connections are reported by the agents with
+  connect.intention_suggestions enabled, and are held in memory by the
+  leader until an intention covers them.
+
+      $ consul intention suggestions
+
+  Create an intention allowing each of the suggested connections:
+
+      $ consul intention suggestions -create
+`
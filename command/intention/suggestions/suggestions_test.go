@@ -0,0 +1,96 @@
+package suggestions
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(nil).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestCommand_Validation(t *testing.T) {
+	t.Parallel()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	require.Equal(t, 1, c.Run([]string{"a"}))
+	require.Contains(t, ui.ErrorWriter.String(), "takes no arguments")
+}
+
+func TestCommand(t *testing.T) {
+	t.Parallel()
+
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+	client := a.Client()
+
+	// No suggestions yet
+	{
+		ui := cli.NewMockUi()
+		c := New(ui)
+		require.Equal(t, 0, c.Run([]string{"-http-addr=" + a.HTTPAddr()}), ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), "No suggestions")
+	}
+
+	// The observed destinations must be registered on the node
+	_, err := client.Catalog().Register(&api.CatalogRegistration{
+		Node:    a.Config.NodeName,
+		Address: "127.0.0.1",
+		Service: &api.AgentService{ID: "db", Service: "db"},
+	}, nil)
+	require.NoError(t, err)
+
+	req := &structs.IntentionObservationRequest{
+		Datacenter: "dc1",
+		Node:       a.Config.NodeName,
+		Observations: []*structs.IntentionObservation{{
+			SourceNS:        structs.IntentionDefaultNamespace,
+			SourceName:      "web",
+			DestinationNS:   structs.IntentionDefaultNamespace,
+			DestinationName: "db",
+			Allowed:         3,
+		}},
+	}
+	var reply struct{}
+	require.NoError(t, a.RPC("Intention.Observe", req, &reply))
+
+	// List the suggestions
+	{
+		ui := cli.NewMockUi()
+		c := New(ui)
+		require.Equal(t, 0, c.Run([]string{"-http-addr=" + a.HTTPAddr()}), ui.ErrorWriter.String())
+		output := ui.OutputWriter.String()
+		require.Contains(t, output, "web")
+		require.Contains(t, output, "db")
+		require.Contains(t, output, "3")
+	}
+
+	// Create the suggested intentions
+	{
+		ui := cli.NewMockUi()
+		c := New(ui)
+		require.Equal(t, 0, c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-create"}), ui.ErrorWriter.String())
+		require.Contains(t, ui.OutputWriter.String(), "Created: web => db (allow)")
+	}
+	ixns, _, err := client.Connect().Intentions(nil)
+	require.NoError(t, err)
+	require.Len(t, ixns, 1)
+
+	// Covered connections are no longer suggested
+	suggestions, _, err := client.Connect().IntentionSuggestions(nil)
+	require.NoError(t, err)
+	require.Empty(t, suggestions)
+}
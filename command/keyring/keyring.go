@@ -28,6 +28,7 @@ type cmd struct {
 	removeKey  string
 	listKeys   bool
 	relay      int
+	local      bool
 }
 
 func (c *cmd) init() {
@@ -48,6 +49,10 @@ func (c *cmd) init() {
 		"Setting this to a non-zero value will cause nodes to relay their response "+
 			"to the operation through this many randomly-chosen other nodes in the "+
 			"cluster. The maximum allowed value is 5.")
+	c.flags.BoolVar(&c.local, "local-only", false,
+		"Setting this to true will force the keyring query to only hit the LAN "+
+			"of the local datacenter, without any WAN traffic. This flag can only "+
+			"be set for list queries.")
 
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
@@ -82,6 +87,11 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	if c.local && !c.listKeys {
+		c.UI.Error("The -local-only flag can only be used with -list")
+		return 1
+	}
+
 	// Validate the relay factor
 	relayFactor, err := agent.ParseRelayFactor(c.relay)
 	if err != nil {
@@ -98,7 +108,7 @@ func (c *cmd) Run(args []string) int {
 
 	if c.listKeys {
 		c.UI.Info("Gathering installed encryption keys...")
-		responses, err := client.Operator().KeyringList(&consulapi.QueryOptions{RelayFactor: relayFactor})
+		responses, err := client.Operator().KeyringList(&consulapi.QueryOptions{RelayFactor: relayFactor, LocalOnly: c.local})
 		if err != nil {
 			c.UI.Error(fmt.Sprintf("error: %s", err))
 			return 1
@@ -107,6 +107,21 @@ func TestKeyringCommand_invalidRelayFactor(t *testing.T) {
 	}
 }
 
+func TestKeyringCommand_invalidLocalOnly(t *testing.T) {
+	t.Parallel()
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{"-install=blah", "-local-only"}
+	code := c.Run(args)
+	if code != 1 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "can only be used with -list") {
+		t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+	}
+}
+
 func listKeys(t *testing.T, addr string) string {
 	ui := cli.NewMockUi()
 	c := New(ui)
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
+	"unicode/utf8"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
@@ -22,10 +23,33 @@ type cmd struct {
 	flags *flag.FlagSet
 	http  *flags.HTTPFlags
 	help  string
+
+	// flags
+	base64         bool
+	includeFlags   bool
+	includeSession bool
+	includeIndex   bool
+	stream         bool
 }
 
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.base64, "base64", true,
+		"Base64 encode the values. When set to false the values are written "+
+			"as is, which requires them to be valid UTF-8.")
+	c.flags.BoolVar(&c.includeFlags, "include-flags", true,
+		"Export the flags of the keys. When set to false the flags are "+
+			"exported as 0.")
+	c.flags.BoolVar(&c.includeSession, "include-session", false,
+		"Export the session holding the lock on each key, if any.")
+	c.flags.BoolVar(&c.includeIndex, "include-index", false,
+		"Export the modify index of each key, so it can be used as a "+
+			"check-and-set index with \"consul kv import -cas\".")
+	c.flags.BoolVar(&c.stream, "stream", false,
+		"Write one JSON entry per line as the keys are read instead of a "+
+			"single JSON array. Only the key names are held in memory, which "+
+			"allows exporting trees that don't fit in memory. The keys are "+
+			"read one at a time so the export is not a consistent snapshot.")
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
@@ -64,9 +88,15 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
-	pairs, _, err := client.KV().List(key, &api.QueryOptions{
+	q := &api.QueryOptions{
 		AllowStale: c.http.Stale(),
-	})
+	}
+
+	if c.stream {
+		return c.exportStream(client, key, q)
+	}
+
+	pairs, _, err := client.KV().List(key, q)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
 		return 1
@@ -74,7 +104,12 @@ func (c *cmd) Run(args []string) int {
 
 	exported := make([]*impexp.Entry, len(pairs))
 	for i, pair := range pairs {
-		exported[i] = impexp.ToEntry(pair)
+		entry, err := c.toEntry(pair)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error exporting KV data: %s", err))
+			return 1
+		}
+		exported[i] = entry
 	}
 
 	marshaled, err := json.MarshalIndent(exported, "", "\t")
@@ -88,6 +123,67 @@ func (c *cmd) Run(args []string) int {
 	return 0
 }
 
+// exportStream writes the keys under the prefix one JSON entry per line,
+// fetching each key separately.
+func (c *cmd) exportStream(client *api.Client, prefix string, q *api.QueryOptions) int {
+	keys, _, err := client.KV().Keys(prefix, "", q)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
+		return 1
+	}
+
+	for _, key := range keys {
+		pair, _, err := client.KV().Get(key, q)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
+			return 1
+		}
+
+		// The key was deleted since it was listed.
+		if pair == nil {
+			continue
+		}
+
+		entry, err := c.toEntry(pair)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error exporting KV data: %s", err))
+			return 1
+		}
+
+		marshaled, err := json.Marshal(entry)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error exporting KV data: %s", err))
+			return 1
+		}
+
+		c.UI.Info(string(marshaled))
+	}
+
+	return 0
+}
+
+// toEntry converts the pair to an exported entry according to the flags.
+func (c *cmd) toEntry(pair *api.KVPair) (*impexp.Entry, error) {
+	entry := impexp.ToEntry(pair)
+	if !c.base64 {
+		if !utf8.Valid(pair.Value) {
+			return nil, fmt.Errorf("value for key %s is not valid UTF-8, use -base64", pair.Key)
+		}
+		entry.Value = string(pair.Value)
+		entry.Encoding = impexp.EncodingNone
+	}
+	if !c.includeFlags {
+		entry.Flags = 0
+	}
+	if c.includeSession {
+		entry.Session = pair.Session
+	}
+	if c.includeIndex {
+		entry.Index = pair.ModifyIndex
+	}
+	return entry, nil
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -106,5 +202,10 @@ Usage: consul kv export [KEY_OR_PREFIX]
 
       $ consul kv export vault
 
+  To export the flags, sessions and indexes of the keys as well, one entry per
+  line:
+
+      $ consul kv export -include-session -include-index -stream vault
+
   For a full list of options and examples, please see the Consul documentation.
 `
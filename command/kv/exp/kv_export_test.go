@@ -69,3 +69,64 @@ func TestKVExportCommand(t *testing.T) {
 		}
 	}
 }
+
+func TestKVExportCommand_metadataStream(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	session, _, err := client.Session().Create(&api.SessionEntry{}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if _, err := client.KV().Put(&api.KVPair{Key: "foo/a", Value: []byte("a"), Flags: 42}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	ok, _, err := client.KV().Acquire(&api.KVPair{Key: "foo/b", Value: []byte("b"), Session: session}, nil)
+	if err != nil || !ok {
+		t.Fatalf("err: %v %v", ok, err)
+	}
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-base64=false",
+		"-include-session",
+		"-include-index",
+		"-stream",
+		"foo",
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("bad: expected 2 lines, got %q", lines)
+	}
+
+	entries := make(map[string]*impexp.Entry)
+	for _, line := range lines {
+		var entry impexp.Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if entry.Encoding != impexp.EncodingNone || entry.Index == 0 {
+			t.Fatalf("bad: %#v", entry)
+		}
+		entries[entry.Key] = &entry
+	}
+
+	if e := entries["foo/a"]; e == nil || e.Value != "a" || e.Flags != 42 || e.Session != "" {
+		t.Fatalf("bad: %#v", e)
+	}
+	if e := entries["foo/b"]; e == nil || e.Value != "b" || e.Session != session {
+		t.Fatalf("bad: %#v", e)
+	}
+}
@@ -3,9 +3,11 @@ package get
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
+	"strings"
 	"text/tabwriter"
 
 	"github.com/hashicorp/consul/api"
@@ -29,6 +31,9 @@ type cmd struct {
 	keys         bool
 	recurse      bool
 	separator    string
+	depth        int
+	ndjson       bool
+	summary      bool
 }
 
 func (c *cmd) init() {
@@ -50,6 +55,16 @@ func (c *cmd) init() {
 	c.flags.StringVar(&c.separator, "separator", "/",
 		"String to use as a separator between keys. The default value is \"/\", "+
 			"but this option is only taken into account when paired with the -keys flag.")
+	c.flags.IntVar(&c.depth, "depth", 1,
+		"Number of levels below the prefix to list when paired with the -keys flag. "+
+			"The levels are delimited by the separator and each level is listed with "+
+			"a separate request. Zero lists all the levels. The default value is 1.")
+	c.flags.BoolVar(&c.ndjson, "ndjson", false,
+		"Output the pairs as newline-delimited JSON objects when paired with the "+
+			"-recurse flag. The values are base64 encoded. The default value is false.")
+	c.flags.BoolVar(&c.summary, "summary", false,
+		"Print the number of keys under the given prefix and the size of their "+
+			"values instead of the values. The default value is false.")
 
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
@@ -85,11 +100,28 @@ func (c *cmd) Run(args []string) int {
 
 	// If the key is empty and we are not doing a recursive or key-based lookup,
 	// this is an error.
-	if key == "" && !(c.recurse || c.keys) {
+	if key == "" && !(c.recurse || c.keys || c.summary) {
 		c.UI.Error("Error! Missing KEY argument")
 		return 1
 	}
 
+	if c.depth < 0 {
+		c.UI.Error("Error! The -depth must not be negative")
+		return 1
+	}
+	if c.depth != 1 && (!c.keys || c.separator == "") {
+		c.UI.Error("Error! The -depth flag requires the -keys flag and a separator")
+		return 1
+	}
+	if c.ndjson && (!c.recurse || c.keys) {
+		c.UI.Error("Error! The -ndjson flag requires the -recurse flag")
+		return 1
+	}
+	if c.summary && (c.keys || c.detailed || c.ndjson) {
+		c.UI.Error("Error! The -summary flag cannot be combined with -keys, -detailed or -ndjson")
+		return 1
+	}
+
 	// Create and test the HTTP client
 	client, err := c.http.APIClient()
 	if err != nil {
@@ -99,48 +131,67 @@ func (c *cmd) Run(args []string) int {
 
 	switch {
 	case c.keys:
-		keys, _, err := client.KV().Keys(key, c.separator, &api.QueryOptions{
-			AllowStale: c.http.Stale(),
-		})
-		if err != nil {
+		if err := c.listKeys(client.KV(), key, 1); err != nil {
 			c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
 			return 1
 		}
 
-		for _, k := range keys {
-			c.UI.Info(string(k))
-		}
-
 		return 0
-	case c.recurse:
-		pairs, _, err := client.KV().List(key, &api.QueryOptions{
+	case c.summary:
+		var sum kvSummary
+		_, err := client.KV().ListFunc(key, &api.QueryOptions{
 			AllowStale: c.http.Stale(),
+		}, func(pair *api.KVPair) error {
+			sum.add(pair)
+			return nil
 		})
 		if err != nil {
 			c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
 			return 1
 		}
 
-		for i, pair := range pairs {
-			if c.detailed {
+		var b bytes.Buffer
+		if err := sum.print(&b); err != nil {
+			c.UI.Error(fmt.Sprintf("Error rendering summary: %s", err))
+			return 1
+		}
+		c.UI.Info(b.String())
+		return 0
+	case c.recurse:
+		// The pairs are printed while the response is decoded so that huge
+		// prefixes are never held in memory.
+		first := true
+		_, err := client.KV().ListFunc(key, &api.QueryOptions{
+			AllowStale: c.http.Stale(),
+		}, func(pair *api.KVPair) error {
+			switch {
+			case c.ndjson:
+				b, err := json.Marshal(pair)
+				if err != nil {
+					return fmt.Errorf("Error rendering KV pair: %s", err)
+				}
+				c.UI.Info(string(b))
+			case c.detailed:
 				var b bytes.Buffer
 				if err := prettyKVPair(&b, pair, c.base64encode); err != nil {
-					c.UI.Error(fmt.Sprintf("Error rendering KV pair: %s", err))
-					return 1
+					return fmt.Errorf("Error rendering KV pair: %s", err)
 				}
 
-				c.UI.Info(b.String())
-
-				if i < len(pairs)-1 {
+				if !first {
 					c.UI.Info("")
 				}
-			} else {
-				if c.base64encode {
-					c.UI.Info(fmt.Sprintf("%s:%s", pair.Key, base64.StdEncoding.EncodeToString(pair.Value)))
-				} else {
-					c.UI.Info(fmt.Sprintf("%s:%s", pair.Key, pair.Value))
-				}
+				c.UI.Info(b.String())
+			case c.base64encode:
+				c.UI.Info(fmt.Sprintf("%s:%s", pair.Key, base64.StdEncoding.EncodeToString(pair.Value)))
+			default:
+				c.UI.Info(fmt.Sprintf("%s:%s", pair.Key, pair.Value))
 			}
+			first = false
+			return nil
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error querying Consul agent: %s", err))
+			return 1
 		}
 
 		return 0
@@ -178,6 +229,63 @@ func (c *cmd) Run(args []string) int {
 	}
 }
 
+// listKeys prints the keys under the prefix, and recurses into the levels
+// delimited by the separator until the depth limit is reached.
+func (c *cmd) listKeys(kv *api.KV, prefix string, depth int) error {
+	keys, _, err := kv.Keys(prefix, c.separator, &api.QueryOptions{
+		AllowStale: c.http.Stale(),
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, k := range keys {
+		c.UI.Info(k)
+
+		// The prefix itself is listed when it is a key ending with the
+		// separator, don't recurse into it again.
+		if c.separator == "" || k == prefix || !strings.HasSuffix(k, c.separator) {
+			continue
+		}
+		if c.depth == 0 || depth < c.depth {
+			if err := c.listKeys(kv, k, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// kvSummary holds the counts and sizes printed by the -summary mode.
+type kvSummary struct {
+	keys        int
+	size        int
+	largestKey  string
+	largestSize int
+}
+
+func (s *kvSummary) add(pair *api.KVPair) {
+	s.keys++
+	s.size += len(pair.Value)
+	if s.largestKey == "" || len(pair.Value) > s.largestSize {
+		s.largestKey = pair.Key
+		s.largestSize = len(pair.Value)
+	}
+}
+
+func (s *kvSummary) print(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 2, 6, ' ', 0)
+	fmt.Fprintf(tw, "Keys\t%d\n", s.keys)
+	fmt.Fprintf(tw, "TotalSize\t%d bytes\n", s.size)
+	if s.keys > 0 {
+		fmt.Fprintf(tw, "AverageSize\t%d bytes\n", s.size/s.keys)
+		fmt.Fprintf(tw, "LargestKey\t%s (%d bytes)", s.largestKey, s.largestSize)
+	} else {
+		fmt.Fprint(tw, "LargestKey\t-")
+	}
+	return tw.Flush()
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -236,5 +344,20 @@ Usage: consul kv get [options] [KEY_OR_PREFIX]
 
       $ consul kv get -keys foo
 
+  To also list the keys of the nested levels, up to two levels below the
+  prefix:
+
+      $ consul kv get -keys -depth=2 foo/
+
+  To output the pairs as newline-delimited JSON, printed as they are
+  received:
+
+      $ consul kv get -recurse -ndjson foo
+
+  To only print the number of keys under a prefix and the size of their
+  values:
+
+      $ consul kv get -summary foo
+
   For a full list of options and examples, please see the Consul documentation.
 `
@@ -2,6 +2,7 @@ package get
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"strings"
 	"testing"
 
@@ -57,6 +58,51 @@ func TestKVGetCommand_Validation(t *testing.T) {
 	}
 }
 
+func TestKVGetCommand_ValidationFlags(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		args   []string
+		output string
+	}{
+		"negative depth": {
+			[]string{"-keys", "-depth=-1", "foo"},
+			"must not be negative",
+		},
+		"depth without keys": {
+			[]string{"-recurse", "-depth=2", "foo"},
+			"requires the -keys flag",
+		},
+		"depth without separator": {
+			[]string{"-keys", "-separator=", "-depth=2", "foo"},
+			"requires the -keys flag",
+		},
+		"ndjson without recurse": {
+			[]string{"-ndjson", "foo"},
+			"requires the -recurse flag",
+		},
+		"summary with keys": {
+			[]string{"-summary", "-keys", "foo"},
+			"cannot be combined",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := New(ui)
+
+			if code := c.Run(tc.args); code == 0 {
+				t.Fatalf("expected non-zero exit")
+			}
+			output := ui.ErrorWriter.String()
+			if !strings.Contains(output, tc.output) {
+				t.Fatalf("expected %q to contain %q", output, tc.output)
+			}
+		})
+	}
+}
+
 func TestKVGetCommand(t *testing.T) {
 	t.Parallel()
 	a := agent.NewTestAgent(t, t.Name(), ``)
@@ -253,6 +299,50 @@ func TestKVGetCommand_Keys(t *testing.T) {
 	}
 }
 
+func TestKVGetCommand_KeysDepth(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	for _, key := range []string{"foo/", "foo/bar", "foo/baz/zip", "foo/baz/zap/deep"} {
+		if _, err := client.KV().Put(&api.KVPair{Key: key}, nil); err != nil {
+			t.Fatalf("err: %#v", err)
+		}
+	}
+
+	cases := []struct {
+		depth string
+		keys  []string
+	}{
+		{"1", []string{"foo/", "foo/bar", "foo/baz/"}},
+		{"2", []string{"foo/", "foo/bar", "foo/baz/", "foo/baz/zap/", "foo/baz/zip"}},
+		{"0", []string{"foo/", "foo/bar", "foo/baz/", "foo/baz/zap/", "foo/baz/zap/deep", "foo/baz/zip"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.depth, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := New(ui)
+
+			args := []string{
+				"-http-addr=" + a.HTTPAddr(),
+				"-keys",
+				"-depth=" + tc.depth,
+				"foo/",
+			}
+			if code := c.Run(args); code != 0 {
+				t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+			}
+
+			output := strings.TrimSpace(ui.OutputWriter.String())
+			if got, want := output, strings.Join(tc.keys, "\n"); got != want {
+				t.Fatalf("got %q want %q", got, want)
+			}
+		})
+	}
+}
+
 func TestKVGetCommand_Recurse(t *testing.T) {
 	t.Parallel()
 	a := agent.NewTestAgent(t, t.Name(), ``)
@@ -293,6 +383,102 @@ func TestKVGetCommand_Recurse(t *testing.T) {
 	}
 }
 
+func TestKVGetCommand_RecurseNDJSON(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	keys := []string{"foo/a", "foo/b", "foo/c"}
+	for _, k := range keys {
+		pair := &api.KVPair{Key: k, Flags: 42, Value: []byte("value of " + k)}
+		if _, err := client.KV().Put(pair, nil); err != nil {
+			t.Fatalf("err: %#v", err)
+		}
+	}
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-recurse",
+		"-ndjson",
+		"foo",
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	if len(lines) != len(keys) {
+		t.Fatalf("bad: %#v", lines)
+	}
+	for i, line := range lines {
+		var pair api.KVPair
+		if err := json.Unmarshal([]byte(line), &pair); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+		if pair.Key != keys[i] || pair.Flags != 42 || string(pair.Value) != "value of "+keys[i] {
+			t.Fatalf("bad: %#v", pair)
+		}
+	}
+}
+
+func TestKVGetCommand_Summary(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	keys := map[string]string{
+		"foo/a": "a",
+		"foo/b": "bbbbbbbbbb",
+		"foo/c": "ccc",
+		"bar":   "not counted",
+	}
+	for k, v := range keys {
+		pair := &api.KVPair{Key: k, Value: []byte(v)}
+		if _, err := client.KV().Put(pair, nil); err != nil {
+			t.Fatalf("err: %#v", err)
+		}
+	}
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-summary",
+		"foo",
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	output := strings.Join(strings.Fields(ui.OutputWriter.String()), " ")
+	want := "Keys 3 TotalSize 14 bytes AverageSize 4 bytes LargestKey foo/b (10 bytes)"
+	if output != want {
+		t.Fatalf("got %q want %q", output, want)
+	}
+
+	// An empty prefix has no largest key
+	ui = cli.NewMockUi()
+	c = New(ui)
+	args[2] = "missing"
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output = strings.Join(strings.Fields(ui.OutputWriter.String()), " ")
+	if want := "Keys 0 TotalSize 0 bytes LargestKey -"; output != want {
+		t.Fatalf("got %q want %q", output, want)
+	}
+}
+
 func TestKVGetCommand_RecurseBase64(t *testing.T) {
 	t.Parallel()
 	a := agent.NewTestAgent(t, t.Name(), ``)
@@ -1,15 +1,13 @@
 package imp
 
 import (
-	"bytes"
-	"encoding/base64"
-	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
+	"strings"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
@@ -31,10 +29,23 @@ type cmd struct {
 
 	// testStdin is the input for testing.
 	testStdin io.Reader
+
+	// flags
+	cas            bool
+	includeSession bool
 }
 
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.cas, "cas", false,
+		"Write the keys with check-and-set using the exported index. Keys "+
+			"exported without an index are only written if they don't exist. "+
+			"The import stops at the first key failing the check. This cannot "+
+			"be used with -datacenter.")
+	c.flags.BoolVar(&c.includeSession, "include-session", false,
+		"Acquire the lock on the keys exported with a session using that "+
+			"session. The sessions must exist in the target datacenter. This "+
+			"cannot be used with -cas.")
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
@@ -46,6 +57,18 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	if c.cas && c.includeSession {
+		c.UI.Error("Error! -cas and -include-session cannot be used together")
+		return 1
+	}
+
+	// The exported indexes are those of the datacenter the keys were
+	// exported from, they can't be checked against another one.
+	if c.cas && c.http.Datacenter() != "" {
+		c.UI.Error("Error! -cas and -datacenter cannot be used together")
+		return 1
+	}
+
 	// Check for arg validation
 	args = c.flags.Args()
 	data, err := c.dataFromArgs(args)
@@ -53,6 +76,7 @@ func (c *cmd) Run(args []string) int {
 		c.UI.Error(fmt.Sprintf("Error! %s", err))
 		return 1
 	}
+	defer data.Close()
 
 	// Create and test the HTTP client
 	client, err := c.http.APIClient()
@@ -61,16 +85,20 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
-	var entries []*impexp.Entry
-	if err := json.Unmarshal([]byte(data), &entries); err != nil {
-		c.UI.Error(fmt.Sprintf("Cannot unmarshal data: %s", err))
-		return 1
-	}
+	dec := impexp.NewDecoder(data)
+	for {
+		entry, err := dec.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Cannot unmarshal data: %s", err))
+			return 1
+		}
 
-	for _, entry := range entries {
-		value, err := base64.StdEncoding.DecodeString(entry.Value)
+		value, err := entry.DecodeValue()
 		if err != nil {
-			c.UI.Error(fmt.Sprintf("Error base 64 decoding value for key %s: %s", entry.Key, err))
+			c.UI.Error(fmt.Sprintf("Error decoding value for key %s: %s", entry.Key, err))
 			return 1
 		}
 
@@ -80,7 +108,7 @@ func (c *cmd) Run(args []string) int {
 			Value: value,
 		}
 
-		if _, err := client.KV().Put(pair, nil); err != nil {
+		if err := c.put(client, pair, entry); err != nil {
 			c.UI.Error(fmt.Sprintf("Error! Failed writing data for key %s: %s", pair.Key, err))
 			return 1
 		}
@@ -91,7 +119,41 @@ func (c *cmd) Run(args []string) int {
 	return 0
 }
 
-func (c *cmd) dataFromArgs(args []string) (string, error) {
+// put writes the pair, using the index or session of the entry according
+// to the flags.
+func (c *cmd) put(client *api.Client, pair *api.KVPair, entry *impexp.Entry) error {
+	switch {
+	case c.cas:
+		pair.ModifyIndex = entry.Index
+		ok, _, err := client.KV().CAS(pair, nil)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("the key was modified since index %d", entry.Index)
+		}
+		return nil
+
+	case c.includeSession && entry.Session != "":
+		pair.Session = entry.Session
+		ok, _, err := client.KV().Acquire(pair, nil)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return fmt.Errorf("failed to acquire the lock with session %s", entry.Session)
+		}
+		return nil
+
+	default:
+		_, err := client.KV().Put(pair, nil)
+		return err
+	}
+}
+
+// dataFromArgs returns a reader for the data given in the arguments, so
+// large exports can be imported without reading them in memory first.
+func (c *cmd) dataFromArgs(args []string) (io.ReadCloser, error) {
 	var stdin io.Reader = os.Stdin
 	if c.testStdin != nil {
 		stdin = c.testStdin
@@ -99,36 +161,32 @@ func (c *cmd) dataFromArgs(args []string) (string, error) {
 
 	switch len(args) {
 	case 0:
-		return "", errors.New("Missing DATA argument")
+		return nil, errors.New("Missing DATA argument")
 	case 1:
 	default:
-		return "", fmt.Errorf("Too many arguments (expected 1, got %d)", len(args))
+		return nil, fmt.Errorf("Too many arguments (expected 1, got %d)", len(args))
 	}
 
 	data := args[0]
 
 	if len(data) == 0 {
-		return "", errors.New("Empty DATA argument")
+		return nil, errors.New("Empty DATA argument")
 	}
 
 	switch data[0] {
 	case '@':
-		data, err := ioutil.ReadFile(data[1:])
+		f, err := os.Open(data[1:])
 		if err != nil {
-			return "", fmt.Errorf("Failed to read file: %s", err)
+			return nil, fmt.Errorf("Failed to read file: %s", err)
 		}
-		return string(data), nil
+		return f, nil
 	case '-':
 		if len(data) > 1 {
-			return data, nil
+			return ioutil.NopCloser(strings.NewReader(data)), nil
 		}
-		var b bytes.Buffer
-		if _, err := io.Copy(&b, stdin); err != nil {
-			return "", fmt.Errorf("Failed to read stdin: %s", err)
-		}
-		return b.String(), nil
+		return ioutil.NopCloser(stdin), nil
 	default:
-		return data, nil
+		return ioutil.NopCloser(strings.NewReader(data)), nil
 	}
 }
 
@@ -145,7 +203,9 @@ const help = `
 Usage: consul kv import [DATA]
 
   Imports key-value pairs to the key-value store from the JSON representation
-  generated by the "consul kv export" command.
+  generated by the "consul kv export" command, either as a JSON array or with
+  one JSON entry per line when exported with -stream. The entries are read
+  one at a time so exports that don't fit in memory can be imported.
 
   The data can be read from a file by prefixing the filename with the "@"
   symbol. For example:
@@ -156,6 +216,10 @@ Usage: consul kv import [DATA]
 
       $ cat filename.json | consul kv import -
 
+  To restore the locks held on the keys, using the exported sessions:
+
+      $ consul kv import -include-session @filename.json
+
   Alternatively the data may be provided as the final parameter to the command,
   though care must be taken with regards to shell escaping.
 
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
 	"github.com/mitchellh/cli"
 )
 
@@ -66,3 +67,124 @@ func TestKVImportCommand(t *testing.T) {
 		t.Fatalf("bad: expected: baz, got %s", pair.Value)
 	}
 }
+
+func TestKVImportCommand_stream(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	session, _, err := client.Session().Create(&api.SessionEntry{}, nil)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	data := `{"key": "foo", "flags": 42, "value": "bar", "encoding": "none"}
+{"key": "foo/a", "flags": 0, "value": "YmF6", "session": "` + session + `"}
+`
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	c.testStdin = strings.NewReader(data)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-include-session",
+		"-",
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	pair, _, err := client.KV().Get("foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pair.Value) != "bar" || pair.Flags != 42 {
+		t.Fatalf("bad: %#v", pair)
+	}
+
+	pair, _, err = client.KV().Get("foo/a", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pair.Value) != "baz" || pair.Session != session {
+		t.Fatalf("bad: %#v", pair)
+	}
+}
+
+func TestKVImportCommand_casDatacenter(t *testing.T) {
+	t.Parallel()
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	code := c.Run([]string{"-cas", "-datacenter=dc2", "[]"})
+	if code != 1 {
+		t.Fatalf("bad: %d. %#v", code, ui.OutputWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "-cas and -datacenter") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+}
+
+func TestKVImportCommand_cas(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	if _, err := client.KV().Put(&api.KVPair{Key: "foo", Value: []byte("old")}, nil); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	// The entries are exported without an index so they are only written
+	// if the keys don't exist yet.
+	const json = `[
+		{
+			"key": "bar",
+			"flags": 0,
+			"value": "YmFy"
+		},
+		{
+			"key": "foo",
+			"flags": 0,
+			"value": "YmFy"
+		}
+	]`
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	c.testStdin = strings.NewReader(json)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-cas",
+		"-",
+	}
+
+	code := c.Run(args)
+	if code != 1 {
+		t.Fatalf("bad: %d. %#v", code, ui.OutputWriter.String())
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "Failed writing data for key foo") {
+		t.Fatalf("bad: %s", ui.ErrorWriter.String())
+	}
+
+	pair, _, err := client.KV().Get("bar", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pair == nil || string(pair.Value) != "bar" {
+		t.Fatalf("bad: %#v", pair)
+	}
+
+	pair, _, err = client.KV().Get("foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(pair.Value) != "old" {
+		t.Fatalf("bad: %#v", pair)
+	}
+}
@@ -1,15 +1,35 @@
 package impexp
 
 import (
+	"bufio"
 	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode"
 
 	"github.com/hashicorp/consul/api"
 )
 
+// EncodingNone is the encoding of the entries whose value is written as is
+// instead of being base64 encoded.
+const EncodingNone = "none"
+
 type Entry struct {
 	Key   string `json:"key"`
 	Flags uint64 `json:"flags"`
 	Value string `json:"value"`
+
+	// Encoding is the encoding of Value. The value is base64 encoded when
+	// it is empty.
+	Encoding string `json:"encoding,omitempty"`
+
+	// Session is the session holding the lock on the key, if any.
+	Session string `json:"session,omitempty"`
+
+	// Index is the modify index of the key, used as the check-and-set
+	// index on import.
+	Index uint64 `json:"index,omitempty"`
 }
 
 func ToEntry(pair *api.KVPair) *Entry {
@@ -19,3 +39,76 @@ func ToEntry(pair *api.KVPair) *Entry {
 		Value: base64.StdEncoding.EncodeToString(pair.Value),
 	}
 }
+
+// DecodeValue returns the value of the entry according to its encoding.
+func (e *Entry) DecodeValue() ([]byte, error) {
+	switch e.Encoding {
+	case "", "base64":
+		return base64.StdEncoding.DecodeString(e.Value)
+	case EncodingNone:
+		return []byte(e.Value), nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", e.Encoding)
+	}
+}
+
+// Decoder reads the entries written by "consul kv export" one at a time so
+// the whole tree doesn't have to be held in memory. Both the JSON array
+// format and the streaming format, with one JSON entry per line, are
+// supported.
+type Decoder struct {
+	r     *bufio.Reader
+	dec   *json.Decoder
+	array bool
+}
+
+// NewDecoder returns a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// Next returns the next entry, or io.EOF once all the entries have been
+// read.
+func (d *Decoder) Next() (*Entry, error) {
+	if d.dec == nil {
+		first, err := d.peek()
+		if err != nil {
+			return nil, err
+		}
+		d.dec = json.NewDecoder(d.r)
+		if first == '[' {
+			d.array = true
+			if _, err := d.dec.Token(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if d.array && !d.dec.More() {
+		if _, err := d.dec.Token(); err != nil {
+			return nil, err
+		}
+		return nil, io.EOF
+	}
+
+	var entry Entry
+	if err := d.dec.Decode(&entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// peek skips the leading whitespace and returns the first byte of the input
+// without consuming it.
+func (d *Decoder) peek() (byte, error) {
+	for {
+		b, err := d.r.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+		if !unicode.IsSpace(rune(b[0])) {
+			return b[0], nil
+		}
+		d.r.ReadByte()
+	}
+}
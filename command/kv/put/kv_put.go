@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
@@ -32,6 +33,7 @@ type cmd struct {
 	session       string
 	acquire       bool
 	release       bool
+	ttl           time.Duration
 
 	// testStdin is the input for testing.
 	testStdin io.Reader
@@ -65,6 +67,11 @@ func (c *cmd) init() {
 			"-session flag to be set. The key must be held by the session in order to "+
 			"be unlocked. The default value is false.")
 
+	c.flags.DurationVar(&c.ttl, "ttl", 0,
+		"Duration after which the key is deleted, unless it is written again "+
+			"before. This is specified with a suffix like \"30s\" or \"1h\" and "+
+			"must be at least 1s. The default value is 0 (no TTL).")
+
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
@@ -104,6 +111,11 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
+	if c.ttl < 0 {
+		c.UI.Error("Error! -ttl must not be negative")
+		return 1
+	}
+
 	// Create and test the HTTP client
 	client, err := c.http.APIClient()
 	if err != nil {
@@ -118,6 +130,9 @@ func (c *cmd) Run(args []string) int {
 		Value:       dataBytes,
 		Session:     c.session,
 	}
+	if c.ttl > 0 {
+		pair.TTL = c.ttl.String()
+	}
 
 	switch {
 	case c.cas:
@@ -231,5 +246,10 @@ Usage: consul kv put [options] KEY [DATA]
 
       $ consul kv put -cas -modify-index=844 config/redis/maxconns 5
 
+  To delete the key once a duration is reached, unless it is written again
+  before, specify the -ttl flag:
+
+      $ consul kv put -ttl=30s service/web/leader node1
+
   Additional flags and more advanced use cases are detailed below.
 `
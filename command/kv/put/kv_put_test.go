@@ -12,6 +12,7 @@ import (
 	"github.com/hashicorp/consul/agent"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil"
+	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/mitchellh/cli"
 )
 
@@ -43,6 +44,10 @@ func TestKVPutCommand_Validation(t *testing.T) {
 			[]string{"-cas", "foo"},
 			"Must specify -modify-index",
 		},
+		"negative -ttl": {
+			[]string{"-ttl=-1s", "foo"},
+			"-ttl must not be negative",
+		},
 		"no key": {
 			[]string{},
 			"Missing KEY argument",
@@ -321,6 +326,47 @@ func TestKVPutCommand_Flags(t *testing.T) {
 	}
 }
 
+func TestKVPutCommand_TTL(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-ttl", "1s",
+		"foo",
+		"bar",
+	}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	data, _, err := client.KV().Get("foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data == nil || data.TTL != "1s" {
+		t.Fatalf("bad: %#v", data)
+	}
+
+	// The key expires
+	retry.Run(t, func(r *retry.R) {
+		data, _, err := client.KV().Get("foo", nil)
+		if err != nil {
+			r.Fatal(err)
+		}
+		if data != nil {
+			r.Fatalf("bad: %#v", data)
+		}
+	})
+}
+
 func TestKVPutCommand_CAS(t *testing.T) {
 	t.Parallel()
 	a := agent.NewTestAgent(t, t.Name(), ``)
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/mitchellh/cli"
 )
@@ -23,7 +24,9 @@ type cmd struct {
 	quitting bool
 
 	// flags
-	logLevel string
+	logLevel   string
+	logJSON    bool
+	subsystems flags.AppendSliceValue
 }
 
 func New(ui cli.Ui, shutdownCh <-chan struct{}) *cmd {
@@ -36,6 +39,12 @@ func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.StringVar(&c.logLevel, "log-level", "INFO",
 		"Log level of the agent.")
+	c.flags.BoolVar(&c.logJSON, "log-json", false,
+		"Output logs in JSON format.")
+	c.flags.Var(&c.subsystems, "subsystem",
+		"Only show the logs of the given subsystem of the agent, such as raft or "+
+			"dns. This flag may be specified multiple times to show the logs of "+
+			"several subsystems.")
 
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
@@ -54,7 +63,12 @@ func (c *cmd) Run(args []string) int {
 	}
 
 	eventDoneCh := make(chan struct{})
-	logCh, err := client.Agent().Monitor(c.logLevel, eventDoneCh, nil)
+	opts := &api.MonitorOptions{
+		LogLevel:   c.logLevel,
+		LogJSON:    c.logJSON,
+		Subsystems: c.subsystems,
+	}
+	logCh, err := client.Agent().MonitorWithOptions(opts, eventDoneCh, nil)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error starting monitor: %s", err))
 		return 1
@@ -111,4 +125,8 @@ Usage: consul monitor [options]
   listen for log levels that may be filtered out of the Consul agent. For
   example your agent may only be logging at INFO level, but with the monitor
   you can see the DEBUG level logs.
+
+  Show the DEBUG level logs of raft only, in JSON format:
+
+      $ consul monitor -log-level=debug -subsystem=raft -log-json
 `
@@ -3,7 +3,6 @@ package set
 import (
 	"flag"
 	"fmt"
-	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
@@ -30,8 +29,13 @@ type cmd struct {
 	redundancyZoneTag       flags.StringValue
 	disableUpgradeMigration flags.BoolValue
 	upgradeVersionTag       flags.StringValue
+	dryRun                  bool
 }
 
+// casAttempts is the number of times the configuration is updated when it
+// is concurrently modified.
+const casAttempts = 5
+
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.Var(&c.cleanupDeadServers, "cleanup-dead-servers",
@@ -59,6 +63,9 @@ func (c *cmd) init() {
 		"(Enterprise-only) The node_meta tag to use for version info when performing upgrade "+
 			"migrations. If left blank, the Consul version will be used.")
 
+	c.flags.BoolVar(&c.dryRun, "dry-run", false,
+		"Displays the changes to the configuration without applying them.")
+
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
 	flags.Merge(c.flags, c.http.ServerFlags())
@@ -81,44 +88,103 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
-	// Fetch the current configuration.
+	// Only the fields of the set flags are updated, so the configuration is
+	// read again and the flags merged into it when it was concurrently
+	// updated.
 	operator := client.Operator()
-	conf, err := operator.AutopilotGetConfiguration(nil)
-	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error querying Autopilot configuration: %s", err))
-		return 1
+	for attempt := 0; attempt < casAttempts; attempt++ {
+		// Fetch the current configuration.
+		conf, err := operator.AutopilotGetConfiguration(nil)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error querying Autopilot configuration: %s", err))
+			return 1
+		}
+
+		// Update the config values based on the set flags.
+		updated := c.merge(conf)
+		if err := validate(updated); err != nil {
+			c.UI.Error(fmt.Sprintf("Invalid Autopilot configuration: %s", err))
+			return 1
+		}
+
+		changes := diff(conf, updated)
+		if len(changes) == 0 {
+			c.UI.Output("Configuration unchanged")
+			return 0
+		}
+		if c.dryRun {
+			for _, change := range changes {
+				c.UI.Output(change)
+			}
+			return 0
+		}
+
+		// Check-and-set the new configuration.
+		result, err := operator.AutopilotCASConfiguration(updated, nil)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error setting Autopilot configuration: %s", err))
+			return 1
+		}
+		if result {
+			c.UI.Output("Configuration updated!")
+			return 0
+		}
 	}
+	c.UI.Output("Configuration could not be atomically updated, please try again")
+	return 1
+}
 
-	// Update the config values based on the set flags.
-	c.cleanupDeadServers.Merge(&conf.CleanupDeadServers)
-	c.redundancyZoneTag.Merge(&conf.RedundancyZoneTag)
-	c.disableUpgradeMigration.Merge(&conf.DisableUpgradeMigration)
-	c.upgradeVersionTag.Merge(&conf.UpgradeVersionTag)
+// merge returns a copy of the configuration with the values of the set flags.
+func (c *cmd) merge(conf *api.AutopilotConfiguration) *api.AutopilotConfiguration {
+	updated := *conf
+	c.cleanupDeadServers.Merge(&updated.CleanupDeadServers)
+	c.redundancyZoneTag.Merge(&updated.RedundancyZoneTag)
+	c.disableUpgradeMigration.Merge(&updated.DisableUpgradeMigration)
+	c.upgradeVersionTag.Merge(&updated.UpgradeVersionTag)
 
-	trailing := uint(conf.MaxTrailingLogs)
+	trailing := uint(updated.MaxTrailingLogs)
 	c.maxTrailingLogs.Merge(&trailing)
-	conf.MaxTrailingLogs = uint64(trailing)
+	updated.MaxTrailingLogs = uint64(trailing)
 
-	last := time.Duration(*conf.LastContactThreshold)
+	last := conf.LastContactThreshold.Duration()
 	c.lastContactThreshold.Merge(&last)
-	conf.LastContactThreshold = api.NewReadableDuration(last)
+	updated.LastContactThreshold = api.NewReadableDuration(last)
 
-	stablization := time.Duration(*conf.ServerStabilizationTime)
-	c.serverStabilizationTime.Merge(&stablization)
-	conf.ServerStabilizationTime = api.NewReadableDuration(stablization)
+	stabilization := conf.ServerStabilizationTime.Duration()
+	c.serverStabilizationTime.Merge(&stabilization)
+	updated.ServerStabilizationTime = api.NewReadableDuration(stabilization)
 
-	// Check-and-set the new configuration.
-	result, err := operator.AutopilotCASConfiguration(conf, nil)
-	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error setting Autopilot configuration: %s", err))
-		return 1
+	return &updated
+}
+
+// validate checks the values of the configuration are usable by Autopilot.
+func validate(conf *api.AutopilotConfiguration) error {
+	if conf.LastContactThreshold.Duration() <= 0 {
+		return fmt.Errorf("last-contact-threshold must be positive, got %s", conf.LastContactThreshold)
 	}
-	if result {
-		c.UI.Output("Configuration updated!")
-		return 0
+	if conf.ServerStabilizationTime.Duration() < 0 {
+		return fmt.Errorf("server-stabilization-time must not be negative, got %s", conf.ServerStabilizationTime)
 	}
-	c.UI.Output("Configuration could not be atomically updated, please try again")
-	return 1
+	return nil
+}
+
+// diff returns the changes between two configurations, formatted like the
+// output of get-config.
+func diff(old, updated *api.AutopilotConfiguration) []string {
+	var changes []string
+	add := func(name string, from, to interface{}) {
+		if from != to {
+			changes = append(changes, fmt.Sprintf("%s = %v -> %v", name, from, to))
+		}
+	}
+	add("CleanupDeadServers", old.CleanupDeadServers, updated.CleanupDeadServers)
+	add("LastContactThreshold", old.LastContactThreshold.String(), updated.LastContactThreshold.String())
+	add("MaxTrailingLogs", old.MaxTrailingLogs, updated.MaxTrailingLogs)
+	add("ServerStabilizationTime", old.ServerStabilizationTime.String(), updated.ServerStabilizationTime.String())
+	add("RedundancyZoneTag", fmt.Sprintf("%q", old.RedundancyZoneTag), fmt.Sprintf("%q", updated.RedundancyZoneTag))
+	add("DisableUpgradeMigration", old.DisableUpgradeMigration, updated.DisableUpgradeMigration)
+	add("UpgradeVersionTag", fmt.Sprintf("%q", old.UpgradeVersionTag), fmt.Sprintf("%q", updated.UpgradeVersionTag))
+	return changes
 }
 
 func (c *cmd) Synopsis() string {
@@ -133,5 +199,10 @@ const synopsis = "Modify the current Autopilot configuration"
 const help = `
 Usage: consul operator autopilot set-config [options]
 
-  Modifies the current Autopilot configuration.
+  Modifies the current Autopilot configuration. Only the values of the
+  given flags are changed, the other values are kept as they are.
+
+  Display the changes without applying them:
+
+      $ consul operator autopilot set-config -last-contact-threshold=500ms -dry-run
 `
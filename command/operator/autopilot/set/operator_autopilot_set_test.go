@@ -66,3 +66,95 @@ func TestOperatorAutopilotSetConfigCommand(t *testing.T) {
 		t.Fatalf("bad: %#v", reply)
 	}
 }
+
+func TestOperatorAutopilotSetConfigCommand_DryRun(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req := structs.DCSpecificRequest{
+		Datacenter: "dc1",
+	}
+	var before autopilot.Config
+	if err := a.RPC("Operator.AutopilotGetConfiguration", &req, &before); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	args := []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-max-trailing-logs=99",
+		"-dry-run",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output := strings.TrimSpace(ui.OutputWriter.String())
+	if output != "MaxTrailingLogs = 250 -> 99" {
+		t.Fatalf("bad: %s", output)
+	}
+
+	// Nothing was changed
+	var after autopilot.Config
+	if err := a.RPC("Operator.AutopilotGetConfiguration", &req, &after); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+	if after.ModifyIndex != before.ModifyIndex || after.MaxTrailingLogs != 250 {
+		t.Fatalf("bad: %#v", after)
+	}
+
+	// Setting the current values doesn't update the configuration
+	ui = cli.NewMockUi()
+	c = New(ui)
+	args = []string{
+		"-http-addr=" + a.HTTPAddr(),
+		"-max-trailing-logs=250",
+	}
+	if code := c.Run(args); code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+	output = strings.TrimSpace(ui.OutputWriter.String())
+	if output != "Configuration unchanged" {
+		t.Fatalf("bad: %s", output)
+	}
+}
+
+func TestOperatorAutopilotSetConfigCommand_Validation(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	cases := map[string]struct {
+		args   []string
+		errMsg string
+	}{
+		"unparsable duration": {
+			args:   []string{"-last-contact-threshold=200"},
+			errMsg: "Failed to parse args",
+		},
+		"zero last contact threshold": {
+			args:   []string{"-last-contact-threshold=0s"},
+			errMsg: "last-contact-threshold must be positive",
+		},
+		"negative server stabilization time": {
+			args:   []string{"-server-stabilization-time=-1s"},
+			errMsg: "server-stabilization-time must not be negative",
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			ui := cli.NewMockUi()
+			c := New(ui)
+			args := append([]string{"-http-addr=" + a.HTTPAddr()}, tc.args...)
+			if code := c.Run(args); code != 1 {
+				t.Fatalf("bad: %d", code)
+			}
+			if !strings.Contains(ui.ErrorWriter.String(), tc.errMsg) {
+				t.Fatalf("bad: %s", ui.ErrorWriter.String())
+			}
+		})
+	}
+}
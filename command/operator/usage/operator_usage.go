@@ -0,0 +1,109 @@
+package usage
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// flags
+	allDatacenters bool
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.allDatacenters, "all-datacenters", false,
+		"Display the usage of all the known datacenters instead of only "+
+			"the datacenter of the agent.")
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		c.UI.Error(fmt.Sprintf("Failed to parse args: %v", err))
+		return 1
+	}
+
+	// Set up a client.
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error initializing client: %s", err))
+		return 1
+	}
+
+	// Fetch the usage.
+	opts := &api.QueryOptions{
+		AllowStale: c.http.Stale(),
+	}
+	usage, _, err := client.Operator().Usage(c.allDatacenters, opts)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error querying usage: %s", err))
+		return 1
+	}
+
+	c.UI.Output(formatUsage(usage))
+	return 0
+}
+
+// formatUsage renders a table of the usage of each datacenter, sorted by
+// name.
+func formatUsage(usage map[string]api.Usage) string {
+	dcs := make([]string, 0, len(usage))
+	for dc := range usage {
+		dcs = append(dcs, dc)
+	}
+	sort.Strings(dcs)
+
+	result := []string{"Datacenter|Nodes|Services|Service Instances|KV Entries|ACL Tokens|Intentions|Config Entries"}
+	for _, dc := range dcs {
+		u := usage[dc]
+		result = append(result, fmt.Sprintf("%s|%d|%d|%d|%d|%d|%d|%d",
+			dc, u.Nodes, u.Services, u.ServiceInstances, u.KVEntries,
+			u.ACLTokens, u.Intentions, u.ConfigEntries))
+	}
+	return columnize.SimpleFormat(result)
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Display the number of entries in the state of the datacenters"
+const help = `
+Usage: consul operator usage [options]
+
+  Displays the number of nodes, services, service instances, KV entries,
+  ACL tokens, intentions and config entries in the state of the datacenter
+  of the agent, which helps with capacity planning.
+
+  To display the usage of all the known datacenters:
+
+    $ consul operator usage -all-datacenters
+`
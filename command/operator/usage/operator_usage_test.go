@@ -0,0 +1,43 @@
+package usage
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+)
+
+func TestOperatorUsageCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestOperatorUsageCommand(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	for _, args := range [][]string{
+		{"-http-addr=" + a.HTTPAddr()},
+		{"-http-addr=" + a.HTTPAddr(), "-all-datacenters"},
+	} {
+		ui := cli.NewMockUi()
+		c := New(ui)
+
+		code := c.Run(args)
+		if code != 0 {
+			t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+		}
+		output := strings.TrimSpace(ui.OutputWriter.String())
+		lines := strings.Split(output, "\n")
+		if len(lines) != 2 || !strings.Contains(lines[0], "Service Instances") ||
+			!strings.HasPrefix(lines[1], "dc1") {
+			t.Fatalf("bad: %s", output)
+		}
+	}
+}
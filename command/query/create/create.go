@@ -0,0 +1,201 @@
+package create
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/hcl"
+	"github.com/mitchellh/cli"
+	"github.com/mitchellh/mapstructure"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// testStdin is the input for testing.
+	testStdin io.Reader
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+	if len(args) != 1 {
+		c.UI.Error("Must provide exactly one positional argument to specify the prepared query to create")
+		return 1
+	}
+
+	data, err := c.loadData(args[0])
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to load data: %v", err))
+		return 1
+	}
+
+	query, err := decodeQuery(data)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to decode prepared query input: %v", err))
+		return 1
+	}
+
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	id, _, err := client.PreparedQuery().Create(query, nil)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error creating prepared query: %v", err))
+		return 1
+	}
+
+	c.UI.Info(fmt.Sprintf("Prepared query created: %s", id))
+	return 0
+}
+
+// loadData reads the prepared query from the given file, or from stdin if
+// the path is "-".
+func (c *cmd) loadData(path string) (string, error) {
+	if path != "-" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	var stdin io.Reader = os.Stdin
+	if c.testStdin != nil {
+		stdin = c.testStdin
+	}
+	var b bytes.Buffer
+	if _, err := io.Copy(&b, stdin); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// decodeQuery decodes a prepared query definition in HCL or JSON form, with
+// its keys in either CamelCase or snake_case.
+func decodeQuery(data string) (*api.PreparedQueryDefinition, error) {
+	// HCL is a superset of JSON, so both formats are parsed here
+	var raw map[string]interface{}
+	if err := hcl.Decode(&raw, data); err != nil {
+		return nil, err
+	}
+
+	var query api.PreparedQueryDefinition
+	decodeConf := &mapstructure.DecoderConfig{
+		DecodeHook:       hclBlockToStructHookFunc(),
+		Result:           &query,
+		WeaklyTypedInput: true,
+		ErrorUnused:      true,
+	}
+	decoder, err := mapstructure.NewDecoder(decodeConf)
+	if err != nil {
+		return nil, err
+	}
+	if err := decoder.Decode(normalizeKeys(raw)); err != nil {
+		return nil, err
+	}
+	return &query, nil
+}
+
+// normalizeKeys returns the map with the underscores of its keys stripped,
+// so that the snake_case keys match the fields, which mapstructure matches
+// regardless of the case. The metadata filters are kept as-is.
+func normalizeKeys(raw map[string]interface{}) map[string]interface{} {
+	normalized := make(map[string]interface{}, len(raw))
+	for k, v := range raw {
+		k = strings.Replace(k, "_", "", -1)
+		switch strings.ToLower(k) {
+		case "nodemeta", "servicemeta":
+		default:
+			v = normalizeValue(v)
+		}
+		normalized[k] = v
+	}
+	return normalized
+}
+
+func normalizeValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return normalizeKeys(v)
+	case []map[string]interface{}:
+		out := make([]map[string]interface{}, len(v))
+		for i, m := range v {
+			out[i] = normalizeKeys(m)
+		}
+		return out
+	}
+	return v
+}
+
+// hclBlockToStructHookFunc returns a decode hook unwrapping the single map of
+// the lists of maps HCL decodes the blocks into, such as the Service block,
+// when they are decoded into a struct or a map.
+func hclBlockToStructHookFunc() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+		if from.Kind() != reflect.Slice || (to.Kind() != reflect.Struct && to.Kind() != reflect.Map) {
+			return data, nil
+		}
+		if blocks, ok := data.([]map[string]interface{}); ok && len(blocks) == 1 {
+			return blocks[0], nil
+		}
+		return data, nil
+	}
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Create a prepared query"
+const help = `
+Usage: consul query create [options] <definition>
+
+  Creates a prepared query and outputs its ID. The definition argument is
+  either a file path or '-' to indicate that the definition should be read
+  from stdin. The data should be either in HCL or JSON form, in the format
+  of the prepared query HTTP API.
+
+  Example (from file):
+
+    $ consul query create web.hcl
+
+  Example (from stdin):
+
+    $ echo '{"Name": "web", "Service": {"Service": "web"}}' | consul query create -
+`
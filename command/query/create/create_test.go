@@ -0,0 +1,80 @@
+package create
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestCreateCommand(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	c.testStdin = strings.NewReader(`
+name = "web"
+service {
+  service = "web"
+  only_passing = true
+  tags = ["v1"]
+  node_meta {
+    instance_type = "m5.large"
+  }
+  failover {
+    nearest_n = 2
+  }
+}
+dns {
+  ttl = "10s"
+}
+`)
+
+	code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.OutputWriter.String(), "Prepared query created: ")
+	id := strings.TrimSpace(strings.TrimPrefix(ui.OutputWriter.String(), "Prepared query created: "))
+
+	queries, _, err := client.PreparedQuery().Get(id, nil)
+	require.NoError(err)
+	require.Len(queries, 1)
+	q := queries[0]
+	require.Equal("web", q.Name)
+	require.Equal("web", q.Service.Service)
+	require.True(q.Service.OnlyPassing)
+	require.Equal([]string{"v1"}, q.Service.Tags)
+	require.Equal(map[string]string{"instance_type": "m5.large"}, q.Service.NodeMeta)
+	require.Equal(2, q.Service.Failover.NearestN)
+	require.Equal("10s", q.DNS.TTL)
+}
+
+func TestDecodeQuery(t *testing.T) {
+	t.Parallel()
+
+	q, err := decodeQuery(`{"Name": "db", "Service": {"Service": "db", "ServiceMeta": {"db_role": "primary"}}}`)
+	require.NoError(t, err)
+	require.Equal(t, "db", q.Name)
+	require.Equal(t, "db", q.Service.Service)
+	require.Equal(t, map[string]string{"db_role": "primary"}, q.Service.ServiceMeta)
+
+	_, err = decodeQuery(`{"Name": "db", "Servise": {"Service": "db"}}`)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Servise")
+}
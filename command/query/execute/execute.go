@@ -0,0 +1,112 @@
+package execute
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// flags
+	near    string
+	connect bool
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.StringVar(&c.near, "near", "",
+		"Node name to sort the results near to, by their network round trip "+
+			"time. The magic value \"_agent\" sorts them near the agent.")
+	c.flags.BoolVar(&c.connect, "connect", false,
+		"Only return the Connect capable instances of the service.")
+
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = c.flags.Args()
+	if len(args) != 1 {
+		c.UI.Error("Must provide exactly one positional argument: the query ID or name")
+		return 1
+	}
+
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	resp, _, err := client.PreparedQuery().Execute(args[0], &api.QueryOptions{
+		AllowStale: c.http.Stale(),
+		Near:       c.near,
+		Connect:    c.connect,
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error executing prepared query: %s", err))
+		return 1
+	}
+
+	if len(resp.Nodes) == 0 {
+		c.UI.Error(fmt.Sprintf("No healthy instances of service %q found", resp.Service))
+		return 0
+	}
+
+	result := []string{"Node|Address|Service ID|Port|Datacenter"}
+	for _, n := range resp.Nodes {
+		address := n.Service.Address
+		if address == "" {
+			address = n.Node.Address
+		}
+		result = append(result, fmt.Sprintf("%s|%s|%s|%d|%s",
+			n.Node.Node, address, n.Service.ID, n.Service.Port, resp.Datacenter))
+	}
+	c.UI.Output(columnize.SimpleFormat(result))
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Execute a prepared query"
+const help = `
+Usage: consul query execute [options] <ID or name>
+
+  Executes the prepared query with the given ID or name, which can also
+  match a query template, and lists the healthy service instances it
+  returns, failing over to other datacenters as configured by the query.
+
+  Execute the "web" query:
+
+      $ consul query execute web
+
+  Sort the results by their round trip time from the agent:
+
+      $ consul query execute -near=_agent web
+`
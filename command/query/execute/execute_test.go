@@ -0,0 +1,61 @@
+package execute
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestExecuteCommand(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	require.NoError(client.Agent().ServiceRegister(&api.AgentServiceRegistration{
+		Name:    "web",
+		Address: "10.0.0.1",
+		Port:    8080,
+	}))
+	_, _, err := client.PreparedQuery().Create(&api.PreparedQueryDefinition{
+		Name:    "web",
+		Service: api.ServiceQuery{Service: "web"},
+	}, nil)
+	require.NoError(err)
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-near=_agent", "web"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+
+	lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+	require.Len(lines, 2)
+	require.Contains(lines[0], "Service ID")
+	require.Contains(lines[1], a.Config.NodeName)
+	require.Contains(lines[1], "10.0.0.1")
+	require.Contains(lines[1], "8080")
+	require.Contains(lines[1], "dc1")
+
+	// There are no Connect capable instances.
+	ui = cli.NewMockUi()
+	c = New(ui)
+	code = c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-connect", "web"})
+	require.Equal(0, code, ui.ErrorWriter.String())
+	require.Contains(ui.ErrorWriter.String(), "No healthy instances")
+}
@@ -0,0 +1,55 @@
+package finder
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/api"
+)
+
+// Finder finds prepared queries by their ID or name. There is no API to get
+// a query by name, so the queries are listed and searched in-memory.
+type Finder struct {
+	// Client is the API client to use for any requests.
+	Client *api.Client
+}
+
+// FromArgs returns the prepared query of the given CLI args, which must be
+// its ID or name. An error is returned if args is not 1 element or if the
+// query doesn't exist.
+func (f *Finder) FromArgs(args []string) (*api.PreparedQueryDefinition, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("command requires exactly 1 argument: the query ID or name")
+	}
+
+	query, err := f.Find(args[0])
+	if err != nil {
+		return nil, err
+	}
+	if query == nil {
+		return nil, fmt.Errorf("Prepared query %q not found.", args[0])
+	}
+	return query, nil
+}
+
+// Find finds the prepared query with the given ID or name. The ID is
+// matched first, as a name could look like the ID of another query. This
+// will return nil when the query is not found.
+func (f *Finder) Find(idOrName string) (*api.PreparedQueryDefinition, error) {
+	queries, _, err := f.Client.PreparedQuery().List(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, q := range queries {
+		if q.ID == idOrName {
+			return q, nil
+		}
+	}
+	for _, q := range queries {
+		if q.Name != "" && q.Name == idOrName {
+			return q, nil
+		}
+	}
+
+	return nil, nil
+}
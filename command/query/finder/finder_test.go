@@ -0,0 +1,47 @@
+package finder
+
+import (
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFinder(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	id, _, err := client.PreparedQuery().Create(&api.PreparedQueryDefinition{
+		Name:    "web",
+		Service: api.ServiceQuery{Service: "web"},
+	}, nil)
+	require.NoError(err)
+
+	finder := &Finder{Client: client}
+	q, err := finder.Find(id)
+	require.NoError(err)
+	require.Equal("web", q.Name)
+
+	q, err = finder.Find("web")
+	require.NoError(err)
+	require.Equal(id, q.ID)
+
+	q, err = finder.Find("db")
+	require.NoError(err)
+	require.Nil(q)
+
+	_, err = finder.FromArgs([]string{"db"})
+	require.Error(err)
+	require.Contains(err.Error(), "not found")
+
+	_, err = finder.FromArgs(nil)
+	require.Error(err)
+}
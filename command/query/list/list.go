@@ -0,0 +1,89 @@
+package list
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	queries, _, err := client.PreparedQuery().List(&api.QueryOptions{
+		AllowStale: c.http.Stale(),
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error listing prepared queries: %s", err))
+		return 1
+	}
+
+	if len(queries) == 0 {
+		c.UI.Error("No prepared queries found")
+		return 0
+	}
+
+	result := []string{"ID|Name|Service|Template"}
+	for _, q := range queries {
+		template := "-"
+		if q.Template.Type != "" {
+			template = q.Template.Type
+		}
+		result = append(result, fmt.Sprintf("%s|%s|%s|%s",
+			q.ID, q.Name, q.Service.Service, template))
+	}
+	c.UI.Output(columnize.SimpleFormat(result))
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "List prepared queries"
+const help = `
+Usage: consul query list [options]
+
+  Lists the ID, name, service and template type of all the prepared
+  queries.
+
+  Example:
+
+    $ consul query list
+`
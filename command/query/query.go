@@ -0,0 +1,47 @@
+package query
+
+import (
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+)
+
+func New() *cmd {
+	return &cmd{}
+}
+
+type cmd struct{}
+
+func (c *cmd) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return flags.Usage(help, nil)
+}
+
+const synopsis = "Interact with prepared queries"
+const help = `
+Usage: consul query <subcommand> [options] [args]
+
+  This command has subcommands for interacting with prepared queries. Here
+  is a simple example, and more detailed examples are available in the
+  subcommands or the documentation.
+
+  Create a prepared query from a file:
+
+      $ consul query create web.hcl
+
+  Execute a prepared query by name:
+
+      $ consul query execute web
+
+  Show the execution stats of all the prepared queries:
+
+      $ consul query stats
+
+  For more examples, ask for subcommand help or view the documentation.
+`
@@ -0,0 +1,80 @@
+package read
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/hashicorp/consul/command/query/finder"
+	"github.com/mitchellh/cli"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	f := &finder.Finder{Client: client}
+	query, err := f.FromArgs(c.flags.Args())
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading prepared query: %s", err))
+		return 1
+	}
+
+	b, err := json.MarshalIndent(query, "", "    ")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Failed to encode output data: %v", err))
+		return 1
+	}
+
+	c.UI.Info(string(b))
+	return 0
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Read a prepared query"
+const help = `
+Usage: consul query read [options] <ID or name>
+
+  Reads the prepared query with the given ID or name and outputs its JSON
+  representation.
+
+  Example:
+
+    $ consul query read web
+`
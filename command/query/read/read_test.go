@@ -0,0 +1,55 @@
+package read
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestReadCommand(t *testing.T) {
+	t.Parallel()
+
+	require := require.New(t)
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	id, _, err := client.PreparedQuery().Create(&api.PreparedQueryDefinition{
+		Name:    "web",
+		Service: api.ServiceQuery{Service: "web"},
+	}, nil)
+	require.NoError(err)
+
+	for _, arg := range []string{id, "web"} {
+		ui := cli.NewMockUi()
+		c := New(ui)
+		code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), arg})
+		require.Equal(0, code, ui.ErrorWriter.String())
+
+		var q api.PreparedQueryDefinition
+		require.NoError(json.Unmarshal(ui.OutputWriter.Bytes(), &q))
+		require.Equal(id, q.ID)
+		require.Equal("web", q.Service.Service)
+	}
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "db"})
+	require.Equal(1, code)
+	require.Contains(ui.ErrorWriter.String(), "not found")
+}
@@ -0,0 +1,128 @@
+package stats
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/command/flags"
+	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
+)
+
+func New(ui cli.Ui) *cmd {
+	c := &cmd{UI: ui}
+	c.init()
+	return c
+}
+
+type cmd struct {
+	UI    cli.Ui
+	flags *flag.FlagSet
+	http  *flags.HTTPFlags
+	help  string
+
+	// flags
+	unused bool
+}
+
+func (c *cmd) init() {
+	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
+	c.flags.BoolVar(&c.unused, "unused", false,
+		"Only show the queries that were never executed.")
+
+	c.http = &flags.HTTPFlags{}
+	flags.Merge(c.flags, c.http.ClientFlags())
+	flags.Merge(c.flags, c.http.ServerFlags())
+	c.help = flags.Usage(help, c.flags)
+}
+
+func (c *cmd) Run(args []string) int {
+	if err := c.flags.Parse(args); err != nil {
+		return 1
+	}
+
+	client, err := c.http.APIClient()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error connecting to Consul agent: %s", err))
+		return 1
+	}
+
+	stats, _, err := client.PreparedQuery().Stats(&api.QueryOptions{
+		AllowStale: c.http.Stale(),
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error retrieving prepared query stats: %s", err))
+		return 1
+	}
+
+	if c.unused {
+		var unused []*api.PreparedQueryStats
+		for _, s := range stats {
+			if s.Executions == 0 {
+				unused = append(unused, s)
+			}
+		}
+		stats = unused
+	}
+
+	if len(stats) == 0 {
+		c.UI.Error("No prepared queries found")
+		return 0
+	}
+
+	// Show the busiest queries first.
+	sort.SliceStable(stats, func(i, j int) bool {
+		return stats[i].Executions > stats[j].Executions
+	})
+
+	result := []string{"ID|Name|Executions|Failovers|Empty|Mean Latency|Max Latency|Last Executed"}
+	for _, s := range stats {
+		mean, last := "-", "never"
+		if s.Executions > 0 {
+			mean = formatLatency(s.LatencyTotal / time.Duration(s.Executions))
+			last = s.LastExecuted.Format(time.RFC3339)
+		}
+		result = append(result, fmt.Sprintf("%s|%s|%d|%d|%d|%s|%s|%s",
+			s.ID, s.Name, s.Executions, s.Failovers, s.EmptyResults,
+			mean, formatLatency(s.LatencyMax), last))
+	}
+	c.UI.Output(columnize.SimpleFormat(result))
+	return 0
+}
+
+// formatLatency rounds the latency to make it easier to read.
+func formatLatency(d time.Duration) string {
+	if d == 0 {
+		return "-"
+	}
+	return d.Round(time.Microsecond).String()
+}
+
+func (c *cmd) Synopsis() string {
+	return synopsis
+}
+
+func (c *cmd) Help() string {
+	return c.help
+}
+
+const synopsis = "Show the execution stats of prepared queries"
+const help = `
+Usage: consul query stats [options]
+
+  Shows how many times each prepared query was executed, how many of the
+  executions failed over to another datacenter or returned no results,
+  and how long they took. Stats are recorded by the servers and persisted
+  in the state store.
+
+  Show the stats of all the prepared queries, busiest first:
+
+      $ consul query stats
+
+  Find the queries that were never executed:
+
+      $ consul query stats -unused
+`
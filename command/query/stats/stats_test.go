@@ -0,0 +1,67 @@
+package stats
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/api"
+	"github.com/hashicorp/consul/testrpc"
+	"github.com/mitchellh/cli"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsCommand_noTabs(t *testing.T) {
+	t.Parallel()
+	if strings.ContainsRune(New(cli.NewMockUi()).Help(), '\t') {
+		t.Fatal("help has tabs")
+	}
+}
+
+func TestStatsCommand(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+	client := a.Client()
+
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	create := func(name string) string {
+		id, _, err := client.PreparedQuery().Create(&api.PreparedQueryDefinition{
+			Name:    name,
+			Service: api.ServiceQuery{Service: "web"},
+		}, nil)
+		require.NoError(t, err)
+		return id
+	}
+	used := create("used")
+	unused := create("unused")
+
+	_, _, err := client.PreparedQuery().Execute(used, nil)
+	require.NoError(t, err)
+
+	{
+		ui := cli.NewMockUi()
+		c := New(ui)
+		code := c.Run([]string{"-http-addr=" + a.HTTPAddr()})
+		require.Equal(t, 0, code, ui.ErrorWriter.String())
+
+		lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+		require.Len(t, lines, 3)
+		require.Contains(t, lines[0], "Executions")
+		require.Contains(t, lines[1], used)
+		require.Contains(t, lines[2], unused)
+		require.Contains(t, lines[2], "never")
+	}
+
+	{
+		ui := cli.NewMockUi()
+		c := New(ui)
+		code := c.Run([]string{"-http-addr=" + a.HTTPAddr(), "-unused"})
+		require.Equal(t, 0, code, ui.ErrorWriter.String())
+
+		output := ui.OutputWriter.String()
+		require.Contains(t, output, unused)
+		require.NotContains(t, output, used)
+	}
+}
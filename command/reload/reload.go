@@ -3,6 +3,7 @@ package reload
 import (
 	"flag"
 	"fmt"
+	"strings"
 
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/mitchellh/cli"
@@ -39,12 +40,20 @@ func (c *cmd) Run(args []string) int {
 		return 1
 	}
 
-	if err := client.Agent().Reload(); err != nil {
+	result, err := client.Agent().ReloadWithResult()
+	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error reloading: %s", err))
 		return 1
 	}
 
 	c.UI.Output("Configuration reload triggered")
+	if len(result.Applied) > 0 {
+		c.UI.Output(fmt.Sprintf("Applied changes to: %s", strings.Join(result.Applied, ", ")))
+	}
+	if len(result.RestartRequired) > 0 {
+		c.UI.Warn(fmt.Sprintf("Changes to %s require a restart of the agent",
+			strings.Join(result.RestartRequired, ", ")))
+	}
 	return 0
 }
 
@@ -61,5 +70,7 @@ const help = `
 Usage: consul reload
 
   Causes the agent to reload configurations. This can be used instead
-  of sending the SIGHUP signal to the agent.
+  of sending the SIGHUP signal to the agent. The changes which the agent
+  applied are listed, along with the ones which require a restart of the
+  agent to take effect.
 `
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/hashicorp/consul/agent"
+	"github.com/hashicorp/consul/agent/config"
 	"github.com/mitchellh/cli"
 )
 
@@ -39,3 +40,43 @@ func TestReloadCommand(t *testing.T) {
 		t.Fatalf("bad: %#v", ui.OutputWriter.String())
 	}
 }
+
+func TestReloadCommand_result(t *testing.T) {
+	t.Parallel()
+	a := agent.NewTestAgent(t, t.Name(), ``)
+	defer a.Shutdown()
+
+	// Reload a configuration adding a service and changing the DNS node
+	// TTL, which requires a restart.
+	go func() {
+		errCh := <-a.ReloadCh()
+		errCh <- a.ReloadConfig(agent.TestConfig(config.Source{
+			Name:   "reload",
+			Format: "hcl",
+			Data: `
+				data_dir = "` + a.Config.DataDir + `"
+				node_id = "` + string(a.Config.NodeID) + `"
+				node_name = "` + a.Config.NodeName + `"
+				service { name = "redis" }
+				dns_config { node_ttl = "10s" }
+			`,
+		}))
+	}()
+
+	ui := cli.NewMockUi()
+	c := New(ui)
+	args := []string{"-http-addr=" + a.HTTPAddr()}
+
+	code := c.Run(args)
+	if code != 0 {
+		t.Fatalf("bad: %d. %#v", code, ui.ErrorWriter.String())
+	}
+
+	output := ui.OutputWriter.String()
+	if !strings.Contains(output, "Applied changes to: ") || !strings.Contains(output, "Services") {
+		t.Fatalf("bad: %#v", output)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "DNSNodeTTL") {
+		t.Fatalf("bad: %#v", ui.ErrorWriter.String())
+	}
+}
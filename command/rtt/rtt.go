@@ -3,12 +3,16 @@ package rtt
 import (
 	"flag"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/serf/coordinate"
 	"github.com/mitchellh/cli"
+	"github.com/ryanuber/columnize"
 )
 
 func New(ui cli.Ui) *cmd {
@@ -24,13 +28,18 @@ type cmd struct {
 	help  string
 
 	// flags
-	wan bool
+	wan    bool
+	matrix bool
 }
 
 func (c *cmd) init() {
 	c.flags = flag.NewFlagSet("", flag.ContinueOnError)
 	c.flags.BoolVar(&c.wan, "wan", false,
 		"Use WAN coordinates instead of LAN coordinates.")
+	c.flags.BoolVar(&c.matrix, "matrix", false,
+		"Estimate the round trip times between all the pairs of the given "+
+			"nodes, or of all the nodes if none are given, and show them as "+
+			"a matrix.")
 
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
@@ -44,7 +53,7 @@ func (c *cmd) Run(args []string) int {
 
 	// They must provide at least one node.
 	nodes := c.flags.Args()
-	if len(nodes) < 1 || len(nodes) > 2 {
+	if !c.matrix && (len(nodes) < 1 || len(nodes) > 2) {
 		c.UI.Error("One or two node names must be specified")
 		c.UI.Error("")
 		c.UI.Error(c.Help())
@@ -59,6 +68,10 @@ func (c *cmd) Run(args []string) int {
 	}
 	coordClient := client.Coordinate()
 
+	if c.matrix {
+		return c.runMatrix(coordClient, nodes)
+	}
+
 	var source string
 	var coord1, coord2 *coordinate.Coordinate
 	if c.wan {
@@ -174,6 +187,112 @@ SHOW_RTT:
 	return 0
 }
 
+// runMatrix shows the estimated round trip times between all the pairs of the
+// given nodes, or of all the nodes with coordinates if none are given.
+func (c *cmd) runMatrix(coordClient *api.Coordinate, nodes []string) int {
+	var source string
+	var matrix map[string]map[string]time.Duration
+	if c.wan {
+		source = "WAN"
+
+		for _, node := range nodes {
+			if len(strings.Split(node, ".")) != 2 {
+				c.UI.Error("Node names must be specified as <node name>.<datacenter> with -wan")
+				return 1
+			}
+		}
+
+		dcs, err := coordClient.Datacenters()
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error getting coordinates: %s", err))
+			return 1
+		}
+
+		// Index the coordinates of the servers by area, as they are only
+		// compatible within the same area.
+		areas := make(map[string]map[string]*coordinate.Coordinate)
+		for _, dc := range dcs {
+			for _, entry := range dc.Coordinates {
+				node := fmt.Sprintf("%s.%s", entry.Node, dc.Datacenter)
+				if areas[node] == nil {
+					areas[node] = make(map[string]*coordinate.Coordinate)
+				}
+				areas[node][dc.AreaID] = entry.Coord
+			}
+		}
+		if len(nodes) == 0 {
+			for node := range areas {
+				nodes = append(nodes, node)
+			}
+			sort.Strings(nodes)
+		}
+
+		matrix = make(map[string]map[string]time.Duration, len(nodes))
+		for _, a := range nodes {
+			if areas[a] == nil {
+				c.UI.Error(fmt.Sprintf("Could not find a coordinate for node %q", a))
+				return 1
+			}
+			matrix[a] = make(map[string]time.Duration, len(nodes))
+			for _, b := range nodes {
+				for area, coordA := range areas[a] {
+					if coordB := areas[b][area]; coordA != nil && coordB != nil {
+						matrix[a][b] = coordA.DistanceTo(coordB)
+						break
+					}
+				}
+			}
+		}
+	} else {
+		source = "LAN"
+
+		if len(nodes) == 0 {
+			entries, _, err := coordClient.Nodes(nil)
+			if err != nil {
+				c.UI.Error(fmt.Sprintf("Error getting coordinates: %s", err))
+				return 1
+			}
+			seen := make(map[string]bool)
+			for _, entry := range entries {
+				if !seen[entry.Node] {
+					seen[entry.Node] = true
+					nodes = append(nodes, entry.Node)
+				}
+			}
+			sort.Strings(nodes)
+		}
+
+		var err error
+		matrix, err = coordClient.RTTMatrix(nodes, nil)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error estimating rtt: %s", err))
+			return 1
+		}
+	}
+
+	if len(nodes) == 0 {
+		c.UI.Error("No coordinates found")
+		return 1
+	}
+
+	// The pairs of nodes without compatible coordinates are shown as "-".
+	result := []string{"Node|" + strings.Join(nodes, "|")}
+	for _, a := range nodes {
+		row := []string{a}
+		for _, b := range nodes {
+			if rtt, ok := matrix[a][b]; ok {
+				row = append(row, fmt.Sprintf("%.3f", rtt.Seconds()*1000.0))
+			} else {
+				row = append(row, "-")
+			}
+		}
+		result = append(result, strings.Join(row, "|"))
+	}
+	c.UI.Output(fmt.Sprintf("Estimated rtt in ms (using %s coordinates):", source))
+	c.UI.Output(columnize.SimpleFormat(result))
+	return 0
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -185,6 +304,7 @@ func (c *cmd) Help() string {
 const synopsis = "Estimates network round trip time between nodes"
 const help = `
 Usage: consul rtt [options] node1 [node2]
+Usage: consul rtt -matrix [options] [node...]
 
   Estimates the round trip time between two nodes using Consul's network
   coordinate model of the cluster.
@@ -198,6 +318,11 @@ Usage: consul rtt [options] node1 [node2]
   coordinates are used, and the node names must be suffixed by a period and
   the datacenter (eg. "myserver.dc1").
 
+  With the -matrix option, the round trip times between all the pairs of any
+  number of nodes are estimated at once and shown as a matrix, in
+  milliseconds. If no node names are given, all the nodes with coordinates
+  are included.
+
   It is not possible to measure between LAN coordinates and WAN coordinates
   because they are maintained by independent Serf gossip areas, so they are
   not compatible.
@@ -151,6 +151,46 @@ func TestRTTCommand_LAN(t *testing.T) {
 			t.Fatalf("bad: %d: %#v", code, ui.ErrorWriter.String())
 		}
 	}
+
+	// Show the matrix of all the nodes.
+	{
+		ui := cli.NewMockUi()
+		c := New(ui)
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-matrix",
+		}
+		code := c.Run(args)
+		if code != 0 {
+			t.Fatalf("bad: %d: %#v", code, ui.ErrorWriter.String())
+		}
+
+		// The matrix has a title, a header and a row for each node, sorted.
+		lines := strings.Split(strings.TrimSpace(ui.OutputWriter.String()), "\n")
+		if len(lines) != 4 {
+			t.Fatalf("bad: %#v", ui.OutputWriter.String())
+		}
+		expected := strings.TrimSuffix(distStr, " ms")
+		if !strings.HasPrefix(lines[3], "dogs") || !strings.Contains(lines[3], expected) {
+			t.Fatalf("bad: %#v", ui.OutputWriter.String())
+		}
+	}
+
+	// The matrix of unknown nodes fails.
+	{
+		ui := cli.NewMockUi()
+		c := New(ui)
+		args := []string{
+			"-http-addr=" + a.HTTPAddr(),
+			"-matrix",
+			"dogs",
+			"nope",
+		}
+		code := c.Run(args)
+		if code != 1 {
+			t.Fatalf("bad: %d: %#v", code, ui.ErrorWriter.String())
+		}
+	}
 }
 
 func TestRTTCommand_WAN(t *testing.T) {
@@ -218,4 +258,22 @@ func TestRTTCommand_WAN(t *testing.T) {
 			t.Fatalf("bad: %d: %#v", code, ui.ErrorWriter.String())
 		}
 	}
+
+	// Show the matrix of all the servers.
+	{
+		ui := cli.NewMockUi()
+		c := New(ui)
+		args := []string{
+			"-wan",
+			"-matrix",
+			"-http-addr=" + a.HTTPAddr(),
+		}
+		code := c.Run(args)
+		if code != 0 {
+			t.Fatalf("bad: %d: %#v", code, ui.ErrorWriter.String())
+		}
+		if !strings.Contains(ui.OutputWriter.String(), node) {
+			t.Fatalf("bad: %#v", ui.OutputWriter.String())
+		}
+	}
 }
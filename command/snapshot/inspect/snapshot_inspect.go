@@ -4,11 +4,17 @@ import (
 	"bytes"
 	"flag"
 	"fmt"
+	"io"
+	"log"
 	"os"
+	"sort"
 	"text/tabwriter"
 
+	"github.com/hashicorp/consul/agent/consul/fsm"
+	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/command/flags"
 	"github.com/hashicorp/consul/snapshot"
+	"github.com/hashicorp/go-msgpack/codec"
 	"github.com/mitchellh/cli"
 )
 
@@ -56,11 +62,23 @@ func (c *cmd) Run(args []string) int {
 	}
 	defer f.Close()
 
-	meta, err := snapshot.Verify(f)
+	// Extract and verify the FSM state, then decode its records.
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+	state, meta, err := snapshot.Read(logger, f)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error verifying snapshot: %s", err))
 		return 1
 	}
+	defer func() {
+		state.Close()
+		os.Remove(state.Name())
+	}()
+
+	stats, totalSize, err := enhance(state)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error extracting snapshot data: %s", err))
+		return 1
+	}
 
 	var b bytes.Buffer
 	tw := tabwriter.NewWriter(&b, 0, 2, 6, ' ', 0)
@@ -69,6 +87,14 @@ func (c *cmd) Run(args []string) int {
 	fmt.Fprintf(tw, "Index\t%d\n", meta.Index)
 	fmt.Fprintf(tw, "Term\t%d\n", meta.Term)
 	fmt.Fprintf(tw, "Version\t%d\n", meta.Version)
+	fmt.Fprintf(tw, "\n")
+	fmt.Fprintf(tw, "Type\tCount\tSize\n")
+	fmt.Fprintf(tw, "----\t----\t----\n")
+	for _, s := range stats {
+		fmt.Fprintf(tw, "%s\t%d\t%s\n", s.Name, s.Count, formatBytes(s.Sum))
+	}
+	fmt.Fprintf(tw, "----\t----\t----\n")
+	fmt.Fprintf(tw, "Total\t\t%s\n", formatBytes(totalSize))
 	if err = tw.Flush(); err != nil {
 		c.UI.Error(fmt.Sprintf("Error rendering snapshot info: %s", err))
 		return 1
@@ -79,6 +105,87 @@ func (c *cmd) Run(args []string) int {
 	return 0
 }
 
+// typeStats is the number of records of a type in a snapshot and the
+// number of bytes they take.
+type typeStats struct {
+	Name  string
+	Sum   int
+	Count int
+}
+
+// countingReader counts the bytes read from the wrapped reader.
+type countingReader struct {
+	wrappedReader io.Reader
+	read          int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.wrappedReader.Read(p)
+	r.read += n
+	return n, err
+}
+
+// enhance decodes the records of the FSM state and returns the statistics of
+// each type, sorted by decreasing size, along with the total size of the
+// state.
+func enhance(file io.Reader) ([]typeStats, int, error) {
+	stats := make(map[structs.MessageType]*typeStats)
+	cr := &countingReader{wrappedReader: file}
+	handler := func(header *fsm.SnapshotHeader, msg structs.MessageType, dec *codec.Decoder) error {
+		name := structs.MessageTypeNames[msg]
+		if name == "" {
+			return fmt.Errorf("Unrecognized msg type %d", msg)
+		}
+
+		// Decode the record to find where it ends. The message type byte
+		// was already read.
+		start := cr.read - 1
+		var val interface{}
+		if err := dec.Decode(&val); err != nil {
+			return fmt.Errorf("failed to decode msg type %s: %v", name, err)
+		}
+
+		s := stats[msg]
+		if s == nil {
+			s = &typeStats{Name: name}
+			stats[msg] = s
+		}
+		s.Count++
+		s.Sum += cr.read - start
+		return nil
+	}
+
+	if err := fsm.ReadSnapshot(cr, handler); err != nil {
+		return nil, 0, err
+	}
+
+	out := make([]typeStats, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Sum != out[j].Sum {
+			return out[i].Sum > out[j].Sum
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, cr.read, nil
+}
+
+// formatBytes renders a number of bytes with a binary unit.
+func formatBytes(n int) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := unit, 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 func (c *cmd) Synopsis() string {
 	return synopsis
 }
@@ -91,7 +198,8 @@ const synopsis = "Displays information about a Consul snapshot file"
 const help = `
 Usage: consul snapshot inspect [options] FILE
 
-  Displays information about a snapshot file on disk.
+  Displays information about a snapshot file on disk, including the number
+  of records of each type in the state and the space they take.
 
   To inspect the file "backup.snap":
 
@@ -106,9 +106,30 @@ func TestSnapshotInspectCommand(t *testing.T) {
 		"Index",
 		"Term",
 		"Version",
+		"Type",
+		"Count",
+		"Total",
+		"Register",
 	} {
 		if !strings.Contains(output, key) {
 			t.Fatalf("bad %#v, missing %q", output, key)
 		}
 	}
 }
+
+func TestSnapshotInspectCommand_formatBytes(t *testing.T) {
+	t.Parallel()
+	cases := map[int]string{
+		0:               "0B",
+		1023:            "1023B",
+		1024:            "1.0KB",
+		1536:            "1.5KB",
+		5 * 1024 * 1024: "5.0MB",
+		3 << 30:         "3.0GB",
+	}
+	for n, expected := range cases {
+		if got := formatBytes(n); got != expected {
+			t.Fatalf("%d: expected %q, got %q", n, expected, got)
+		}
+	}
+}
@@ -2,12 +2,21 @@ package lib
 
 import (
 	"reflect"
+	"sync"
 	"time"
 
 	metrics "github.com/armon/go-metrics"
 	"github.com/armon/go-metrics/circonus"
 	"github.com/armon/go-metrics/datadog"
 	"github.com/armon/go-metrics/prometheus"
+	prometheusclient "github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// telemetrySinks are the sinks of the global metrics other than the
+	// in-memory sink, which ReloadTelemetry stops before replacing them.
+	telemetrySinks     metrics.FanoutSink
+	telemetrySinksLock sync.Mutex
 )
 
 // TelemetryConfig is embedded in config.RuntimeConfig and holds the
@@ -331,46 +340,88 @@ func InitTelemetry(cfg TelemetryConfig) (*metrics.InmemSink, error) {
 	// metrics over stderr when there is a SIGUSR1 received.
 	memSink := metrics.NewInmemSink(10*time.Second, time.Minute)
 	metrics.DefaultInmemSignal(memSink)
+
+	telemetrySinksLock.Lock()
+	defer telemetrySinksLock.Unlock()
+	if err := setupTelemetrySinks(cfg, memSink); err != nil {
+		return nil, err
+	}
+	return memSink, nil
+}
+
+// ReloadTelemetry replaces the sinks of the global metrics with the ones of
+// the given configuration, keeping the in-memory sink set up by
+// InitTelemetry so that the metrics it aggregated aren't lost.
+func ReloadTelemetry(cfg TelemetryConfig, memSink *metrics.InmemSink) error {
+	telemetrySinksLock.Lock()
+	defer telemetrySinksLock.Unlock()
+
+	// The previous sinks are stopped first, since the Prometheus sink can
+	// only be registered once.
+	stopTelemetrySinks()
+
+	if err := setupTelemetrySinks(cfg, memSink); err != nil {
+		// Keep the in-memory metrics going without the failed sinks.
+		metrics.NewGlobal(metrics.DefaultConfig(cfg.MetricsPrefix), memSink)
+		return err
+	}
+	return nil
+}
+
+// stopTelemetrySinks stops the sinks in telemetrySinks and clears it. It must
+// be called with telemetrySinksLock held.
+func stopTelemetrySinks() {
+	for _, s := range telemetrySinks {
+		switch sink := s.(type) {
+		case *prometheus.PrometheusSink:
+			prometheusclient.Unregister(sink)
+		case interface{ Shutdown() }:
+			sink.Shutdown()
+		}
+	}
+	telemetrySinks = nil
+}
+
+// telemetrySinkFns are the functions creating the sinks of a configuration,
+// returning a nil sink if it isn't configured.
+var telemetrySinkFns = []func(TelemetryConfig, string) (metrics.MetricSink, error){
+	statsiteSink,
+	statsdSink,
+	dogstatdSink,
+	circonusSink,
+	prometheusSink,
+}
+
+// setupTelemetrySinks creates the sinks of the configuration and sets up the
+// global metrics with them and the in-memory sink. If a sink fails the ones
+// already created are stopped. It must be called with telemetrySinksLock
+// held.
+func setupTelemetrySinks(cfg TelemetryConfig, memSink *metrics.InmemSink) error {
 	metricsConf := metrics.DefaultConfig(cfg.MetricsPrefix)
 	metricsConf.EnableHostname = !cfg.DisableHostname
 	metricsConf.FilterDefault = cfg.FilterDefault
 	metricsConf.AllowedPrefixes = cfg.AllowedPrefixes
 	metricsConf.BlockedPrefixes = cfg.BlockedPrefixes
 
-	var sinks metrics.FanoutSink
-	addSink := func(name string, fn func(TelemetryConfig, string) (metrics.MetricSink, error)) error {
-		s, err := fn(cfg, metricsConf.HostName)
+	// Each sink is recorded as soon as it's created so that it's stopped
+	// if a following one fails.
+	for _, fn := range telemetrySinkFns {
+		sink, err := fn(cfg, metricsConf.HostName)
 		if err != nil {
+			stopTelemetrySinks()
 			return err
 		}
-		if s != nil {
-			sinks = append(sinks, s)
+		if sink != nil {
+			telemetrySinks = append(telemetrySinks, sink)
 		}
-		return nil
-	}
-
-	if err := addSink("statsite", statsiteSink); err != nil {
-		return nil, err
-	}
-	if err := addSink("statsd", statsdSink); err != nil {
-		return nil, err
-	}
-	if err := addSink("dogstatd", dogstatdSink); err != nil {
-		return nil, err
-	}
-	if err := addSink("circonus", circonusSink); err != nil {
-		return nil, err
-	}
-	if err := addSink("prometheus", prometheusSink); err != nil {
-		return nil, err
 	}
+	sinks := append(metrics.FanoutSink(nil), telemetrySinks...)
 
 	if len(sinks) > 0 {
-		sinks = append(sinks, memSink)
-		metrics.NewGlobal(metricsConf, sinks)
+		metrics.NewGlobal(metricsConf, append(sinks, memSink))
 	} else {
 		metricsConf.EnableHostname = false
 		metrics.NewGlobal(metricsConf, memSink)
 	}
-	return memSink, nil
+	return nil
 }
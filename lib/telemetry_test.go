@@ -1,10 +1,12 @@
 package lib
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 	"time"
 
+	metrics "github.com/armon/go-metrics"
 	"github.com/stretchr/testify/require"
 )
 
@@ -88,3 +90,48 @@ func TestTelemetryConfig_MergeDefaults(t *testing.T) {
 		})
 	}
 }
+
+func TestReloadTelemetry(t *testing.T) {
+	cfg := TelemetryConfig{
+		PrometheusRetentionTime: time.Minute,
+	}
+	memSink, err := InitTelemetry(cfg)
+	require.NoError(t, err)
+
+	// The Prometheus sink is registered again with the reloaded
+	// configuration, which fails unless the previous one is unregistered.
+	cfg.PrometheusRetentionTime = 2 * time.Minute
+	require.NoError(t, ReloadTelemetry(cfg, memSink))
+	require.Len(t, telemetrySinks, 1)
+
+	// Removing the sinks keeps the in-memory sink only.
+	require.NoError(t, ReloadTelemetry(TelemetryConfig{}, memSink))
+	require.Empty(t, telemetrySinks)
+}
+
+func TestReloadTelemetry_failedSink(t *testing.T) {
+	memSink, err := InitTelemetry(TelemetryConfig{})
+	require.NoError(t, err)
+
+	// A sink failing after the Prometheus sink was created.
+	fns := telemetrySinkFns
+	defer func() { telemetrySinkFns = fns }()
+	telemetrySinkFns = []func(TelemetryConfig, string) (metrics.MetricSink, error){
+		prometheusSink,
+		func(TelemetryConfig, string) (metrics.MetricSink, error) {
+			return nil, errors.New("failed")
+		},
+	}
+	cfg := TelemetryConfig{
+		PrometheusRetentionTime: time.Minute,
+	}
+	require.EqualError(t, ReloadTelemetry(cfg, memSink), "failed")
+	require.Empty(t, telemetrySinks)
+
+	// The Prometheus sink created before the failure was unregistered, so
+	// it can be registered again.
+	telemetrySinkFns = fns
+	require.NoError(t, ReloadTelemetry(cfg, memSink))
+	require.Len(t, telemetrySinks, 1)
+	require.NoError(t, ReloadTelemetry(TelemetryConfig{}, memSink))
+}
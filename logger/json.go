@@ -0,0 +1,115 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+)
+
+// jsonLevels maps the log levels to the levels of the JSON log lines.
+var jsonLevels = map[string]string{
+	"TRACE": "trace",
+	"DEBUG": "debug",
+	"INFO":  "info",
+	"WARN":  "warn",
+	"ERR":   "error",
+}
+
+// ParseLine splits a log line as written by the loggers of the agent, such
+// as "2019/03/26 10:02:03 [INFO] agent: Started DNS server", into its level,
+// module and message. The level and module are empty if the line has none.
+func ParseLine(p []byte) (level, module, message string) {
+	line := strings.TrimRight(string(p), "\r\n")
+
+	x := strings.IndexByte(line, '[')
+	if x < 0 {
+		return "", "", line
+	}
+	y := strings.IndexByte(line[x:], ']')
+	if y < 0 {
+		return "", "", line
+	}
+	level = line[x+1 : x+y]
+	message = strings.TrimPrefix(line[x+y+1:], " ")
+
+	// The module is the name before the first colon, if it has no spaces.
+	if i := strings.Index(message, ": "); i > 0 && !strings.ContainsAny(message[:i], " \t") {
+		module = message[:i]
+		message = message[i+2:]
+	}
+	return level, module, message
+}
+
+// lineTimeFormats are the formats of the time of the log lines, with and
+// without microseconds.
+var lineTimeFormats = []string{
+	"2006/01/02 15:04:05.000000",
+	"2006/01/02 15:04:05",
+}
+
+// lineTime returns the time a log line was logged, or the current time if
+// the line has none.
+func lineTime(p []byte) time.Time {
+	line := string(p)
+	x := strings.IndexByte(line, '[')
+	if x < 0 {
+		return time.Now()
+	}
+	prefix := strings.TrimRight(line[:x], " ")
+	for _, format := range lineTimeFormats {
+		if len(prefix) < len(format) {
+			continue
+		}
+		t, err := time.ParseInLocation(format, prefix[len(prefix)-len(format):], time.Local)
+		if err == nil {
+			return t
+		}
+	}
+	return time.Now()
+}
+
+// FormatJSON formats a log line as a JSON object with its time, level,
+// module and message, ending with a newline:
+//
+//   {"@level":"info","@message":"Started DNS server","@module":"agent","@timestamp":"..."}
+//
+// The time is the one of the line if it has one, such as for the buffered
+// lines sent to the monitors, or else the time the line is formatted.
+func FormatJSON(p []byte) []byte {
+	level, module, message := ParseLine(p)
+
+	entry := map[string]string{
+		"@message":   message,
+		"@timestamp": lineTime(p).Format("2006-01-02T15:04:05.000000Z07:00"),
+	}
+	if l, ok := jsonLevels[level]; ok {
+		entry["@level"] = l
+	} else {
+		entry["@level"] = "info"
+	}
+	if module != "" {
+		entry["@module"] = module
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(entry)
+	return buf.Bytes()
+}
+
+// JSONWriter formats the log lines written to it as JSON objects before
+// writing them to Writer.
+type JSONWriter struct {
+	Writer io.Writer
+}
+
+// Write is used to implement io.Writer
+func (w *JSONWriter) Write(p []byte) (int, error) {
+	if _, err := w.Writer.Write(FormatJSON(p)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,69 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		line, level, module, message string
+	}{
+		{"2019/03/26 10:02:03 [INFO] agent: Started DNS server\n", "INFO", "agent", "Started DNS server"},
+		{"node - 2019/03/26 10:02:03 [ERR] consul.acl: Failed: boom\n", "ERR", "consul.acl", "Failed: boom"},
+		{"2019/03/26 10:02:03 [WARN] Not a module: here", "WARN", "", "Not a module: here"},
+		{"no level", "", "", "no level"},
+	}
+	for _, c := range cases {
+		level, module, message := ParseLine([]byte(c.line))
+		require.Equal(t, c.level, level, c.line)
+		require.Equal(t, c.module, module, c.line)
+		require.Equal(t, c.message, message, c.line)
+	}
+}
+
+func TestJSONWriter(t *testing.T) {
+	t.Parallel()
+	var buf bytes.Buffer
+	w := &JSONWriter{Writer: &buf}
+
+	line := []byte("2019/03/26 10:02:03 [ERR] raft: Failed to <contact> peer\n")
+	n, err := w.Write(line)
+	require.NoError(t, err)
+	require.Equal(t, len(line), n)
+
+	var entry map[string]string
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "error", entry["@level"])
+	require.Equal(t, "raft", entry["@module"])
+	require.Equal(t, "Failed to <contact> peer", entry["@message"])
+	require.Equal(t, "2019-03-26T10:02:03.000000", entry["@timestamp"][:26])
+	require.Equal(t, byte('\n'), buf.Bytes()[buf.Len()-1])
+}
+
+func TestFormatJSON_time(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		line, timestamp string
+	}{
+		{"2019/03/26 10:02:03 [INFO] agent: Synced", "2019-03-26T10:02:03.000000"},
+		{"node - 2019/03/26 10:02:03.123456 [INFO] agent: Synced", "2019-03-26T10:02:03.123456"},
+	}
+	for _, c := range cases {
+		var entry map[string]string
+		require.NoError(t, json.Unmarshal(FormatJSON([]byte(c.line)), &entry))
+		require.Equal(t, c.timestamp, entry["@timestamp"][:26], c.line)
+	}
+
+	// Lines without a time get the current one.
+	var entry map[string]string
+	require.NoError(t, json.Unmarshal(FormatJSON([]byte("[INFO] agent: Synced")), &entry))
+	ts, err := time.Parse("2006-01-02T15:04:05.000000Z07:00", entry["@timestamp"])
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now(), ts, time.Minute)
+}
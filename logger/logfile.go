@@ -38,6 +38,9 @@ type LogFile struct {
 	//MaxBytes is the maximum number of desired bytes for a log file
 	MaxBytes int
 
+	//json formats the logs as JSON objects
+	json bool
+
 	//BytesWritten is the number of bytes written in the current log file
 	BytesWritten int64
 
@@ -100,6 +103,15 @@ func (l *LogFile) Write(b []byte) (n int, err error) {
 	if err := l.rotate(); err != nil {
 		return 0, err
 	}
+	if l.json {
+		n := len(b)
+		b = FormatJSON(b)
+		l.BytesWritten += int64(len(b))
+		if _, err := l.FileInfo.Write(b); err != nil {
+			return 0, err
+		}
+		return n, nil
+	}
 	l.BytesWritten += int64(len(b))
 	return l.FileInfo.Write(b)
 }
@@ -17,6 +17,14 @@ type Config struct {
 	// LogLevel is the minimum level to be logged.
 	LogLevel string
 
+	// SubsystemLevels are the minimum levels to be logged for the
+	// subsystems which don't log at LogLevel.
+	SubsystemLevels map[string]string
+
+	// LogJSON formats the logs written to the console and to the log file
+	// as JSON objects.
+	LogJSON bool
+
 	// EnableSyslog controls forwarding to syslog.
 	EnableSyslog bool
 
@@ -45,7 +53,8 @@ var (
 
 // Setup is used to perform setup of several logging objects:
 //
-// * A LevelFilter is used to perform filtering by log level.
+// * A SubsystemFilter is used to perform filtering by log level, with levels
+//   which can be set for the subsystems of the agent.
 // * A GatedWriter is used to buffer logs until startup UI operations are
 //   complete. After this is flushed then logs flow directly to output
 //   destinations.
@@ -56,7 +65,7 @@ var (
 // The provided ui object will get any log messages related to setting up
 // logging itself, and will also be hooked up to the gated logger. The final bool
 // parameter indicates if logging was set up successfully.
-func Setup(config *Config, ui cli.Ui) (*logutils.LevelFilter, *GatedWriter, *LogWriter, io.Writer, bool) {
+func Setup(config *Config, ui cli.Ui) (*SubsystemFilter, *GatedWriter, *LogWriter, io.Writer, bool) {
 	// The gated writer buffers logs at startup and holds until it's flushed.
 	logGate := &GatedWriter{
 		Writer: &cli.UiWriter{Ui: ui},
@@ -66,6 +75,9 @@ func Setup(config *Config, ui cli.Ui) (*logutils.LevelFilter, *GatedWriter, *Log
 	logFilter := LevelFilter()
 	logFilter.MinLevel = logutils.LogLevel(strings.ToUpper(config.LogLevel))
 	logFilter.Writer = logGate
+	if config.LogJSON {
+		logFilter.Writer = &JSONWriter{Writer: logGate}
+	}
 	if !ValidateLevelFilter(logFilter.MinLevel, logFilter) {
 		ui.Error(fmt.Sprintf(
 			"Invalid log level: %s. Valid log levels are: %v",
@@ -98,7 +110,7 @@ func Setup(config *Config, ui cli.Ui) (*logutils.LevelFilter, *GatedWriter, *Log
 	}
 	// Create a log writer, and wrap a logOutput around it
 	logWriter := NewLogWriter(512)
-	writers := []io.Writer{logFilter, logWriter}
+	writers := []io.Writer{logFilter}
 
 	var logOutput io.Writer
 	if syslog != nil {
@@ -123,10 +135,18 @@ func Setup(config *Config, ui cli.Ui) (*logutils.LevelFilter, *GatedWriter, *Log
 		if config.LogRotateBytes != 0 {
 			logRotateBytes = config.LogRotateBytes
 		}
-		logFile := &LogFile{logFilter: logFilter, fileName: fileName, logPath: dir, duration: logRotateDuration, MaxBytes: logRotateBytes}
+		logFile := &LogFile{logFilter: logFilter, fileName: fileName, logPath: dir, duration: logRotateDuration, MaxBytes: logRotateBytes, json: config.LogJSON}
 		writers = append(writers, logFile)
 	}
 
-	logOutput = io.MultiWriter(writers...)
-	return logFilter, logGate, logWriter, logOutput, true
+	// The subsystem filter sits in front of the outputs, while the log
+	// writer gets all the logs to stream them with their own filters.
+	subsystemFilter := NewSubsystemFilter(logFilter, io.MultiWriter(writers...))
+	if err := subsystemFilter.SetSubsystemLevels(config.SubsystemLevels); err != nil {
+		ui.Error(err.Error())
+		return nil, nil, nil, nil, false
+	}
+
+	logOutput = io.MultiWriter(subsystemFilter, logWriter)
+	return subsystemFilter, logGate, logWriter, logOutput, true
 }
@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/logutils"
+)
+
+// SubsystemFilter filters the log lines by level, with levels which can be
+// set for the subsystems of the agent, such as raft, serf, acl, dns or http.
+//
+// The subsystem of a line is its module, the name following the level as in
+// "[DEBUG] raft: ...", or one of the dot separated parts of the module, so
+// that the level of "acl" applies to "consul.acl" and "acl.bootstrap" too.
+// The level of the full module wins, then the one of its last parts.
+//
+// The filter sits in front of the log outputs, and keeps the minimum level of
+// their LevelFilter to the lowest of the levels so that it doesn't drop the
+// lines of the subsystems logging below the default level.
+type SubsystemFilter struct {
+	filter *logutils.LevelFilter
+	writer io.Writer
+
+	lock       sync.RWMutex
+	level      logutils.LogLevel
+	subsystems map[string]logutils.LogLevel
+}
+
+// NewSubsystemFilter returns a filter writing to w, whose default level is
+// the minimum level of the given LevelFilter.
+func NewSubsystemFilter(filter *logutils.LevelFilter, w io.Writer) *SubsystemFilter {
+	return &SubsystemFilter{
+		filter:     filter,
+		writer:     w,
+		level:      filter.MinLevel,
+		subsystems: make(map[string]logutils.LogLevel),
+	}
+}
+
+// Levels returns the valid log levels.
+func (f *SubsystemFilter) Levels() []logutils.LogLevel {
+	return f.filter.Levels
+}
+
+// Level returns the default level of the log lines.
+func (f *SubsystemFilter) Level() string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return string(f.level)
+}
+
+// SubsystemLevels returns the levels set for the subsystems.
+func (f *SubsystemFilter) SubsystemLevels() map[string]string {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	levels := make(map[string]string, len(f.subsystems))
+	for name, level := range f.subsystems {
+		levels[name] = string(level)
+	}
+	return levels
+}
+
+// SetLevel sets the default level of the log lines.
+func (f *SubsystemFilter) SetLevel(level string) error {
+	l, err := f.parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.level = l
+	f.updateMinLevel()
+	return nil
+}
+
+// SetSubsystemLevel sets the level of a subsystem, or removes it and lets the
+// subsystem log at the default level if level is empty.
+func (f *SubsystemFilter) SetSubsystemLevel(subsystem, level string) error {
+	if subsystem == "" {
+		return fmt.Errorf("Missing subsystem")
+	}
+	var l logutils.LogLevel
+	if level != "" {
+		var err error
+		if l, err = f.parseLevel(level); err != nil {
+			return err
+		}
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if l == "" {
+		delete(f.subsystems, subsystem)
+	} else {
+		f.subsystems[subsystem] = l
+	}
+	f.updateMinLevel()
+	return nil
+}
+
+// SetSubsystemLevels replaces the levels of the subsystems, such as when the
+// configuration is reloaded.
+func (f *SubsystemFilter) SetSubsystemLevels(levels map[string]string) error {
+	subsystems := make(map[string]logutils.LogLevel, len(levels))
+	for name, level := range levels {
+		l, err := f.parseLevel(level)
+		if err != nil {
+			return fmt.Errorf("Invalid log level for subsystem %q: %v", name, err)
+		}
+		subsystems[name] = l
+	}
+
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.subsystems = subsystems
+	f.updateMinLevel()
+	return nil
+}
+
+// Check returns true if the given log line should be written.
+func (f *SubsystemFilter) Check(line []byte) bool {
+	level, module, _ := ParseLine(line)
+	pos := f.levelPos(logutils.LogLevel(level))
+	if pos < 0 {
+		// Lines with an unknown level are always written.
+		return true
+	}
+
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return pos >= f.levelPos(f.moduleLevel(module))
+}
+
+// Write is used to implement io.Writer
+func (f *SubsystemFilter) Write(p []byte) (int, error) {
+	if !f.Check(p) {
+		return len(p), nil
+	}
+	return f.writer.Write(p)
+}
+
+// moduleLevel returns the level of the lines of the module. It must be called
+// with the lock held.
+func (f *SubsystemFilter) moduleLevel(module string) logutils.LogLevel {
+	if module == "" || len(f.subsystems) == 0 {
+		return f.level
+	}
+	if l, ok := f.subsystems[module]; ok {
+		return l
+	}
+	parts := strings.Split(module, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		if l, ok := f.subsystems[parts[i]]; ok {
+			return l
+		}
+	}
+	return f.level
+}
+
+// InSubsystems returns true if the module of a log line belongs to one of the
+// given subsystems, either as the full module or as one of its dot separated
+// parts, the same way the levels of the subsystems apply.
+func InSubsystems(module string, subsystems []string) bool {
+	if module == "" {
+		return false
+	}
+	parts := strings.Split(module, ".")
+	for _, subsystem := range subsystems {
+		if subsystem == module {
+			return true
+		}
+		for _, part := range parts {
+			if subsystem == part {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// updateMinLevel sets the minimum level of the outputs' LevelFilter to the
+// lowest of the levels. It must be called with the lock held.
+func (f *SubsystemFilter) updateMinLevel() {
+	min := f.level
+	for _, l := range f.subsystems {
+		if f.levelPos(l) < f.levelPos(min) {
+			min = l
+		}
+	}
+	f.filter.SetMinLevel(min)
+}
+
+// parseLevel returns the valid log level matching the given level.
+func (f *SubsystemFilter) parseLevel(level string) (logutils.LogLevel, error) {
+	l := logutils.LogLevel(strings.ToUpper(level))
+	if !ValidateLevelFilter(l, f.filter) {
+		return "", fmt.Errorf("Invalid log level: %s. Valid log levels are: %v", l, f.filter.Levels)
+	}
+	return l, nil
+}
+
+// levelPos returns the position of the level in the valid levels, from the
+// lowest, or -1 if the level isn't valid.
+func (f *SubsystemFilter) levelPos(level logutils.LogLevel) int {
+	for i, l := range f.filter.Levels {
+		if l == level {
+			return i
+		}
+	}
+	return -1
+}
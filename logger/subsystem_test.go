@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubsystemFilter(t *testing.T) {
+	t.Parallel()
+	require := require.New(t)
+
+	filt := LevelFilter()
+	filt.MinLevel = "INFO"
+	var buf bytes.Buffer
+	filt.Writer = &buf
+	f := NewSubsystemFilter(filt, filt)
+
+	require.NoError(f.SetSubsystemLevel("raft", "trace"))
+	require.NoError(f.SetSubsystemLevel("acl", "ERR"))
+	require.Equal("INFO", f.Level())
+	require.Equal(map[string]string{"raft": "TRACE", "acl": "ERR"}, f.SubsystemLevels())
+
+	// The outputs' filter lets the lowest level through.
+	require.Equal("TRACE", string(filt.MinLevel))
+
+	f.Write([]byte("2019/03/26 10:02:03 [TRACE] raft: written\n"))
+	f.Write([]byte("2019/03/26 10:02:03 [DEBUG] agent: dropped\n"))
+	f.Write([]byte("2019/03/26 10:02:03 [INFO] agent: written\n"))
+	f.Write([]byte("2019/03/26 10:02:03 [WARN] consul.acl: dropped\n"))
+	f.Write([]byte("2019/03/26 10:02:03 [WARN] acl.bootstrap: dropped\n"))
+	f.Write([]byte("2019/03/26 10:02:03 [ERR] consul.acl: written\n"))
+	f.Write([]byte("no level is written\n"))
+	require.Equal(
+		"2019/03/26 10:02:03 [TRACE] raft: written\n"+
+			"2019/03/26 10:02:03 [INFO] agent: written\n"+
+			"2019/03/26 10:02:03 [ERR] consul.acl: written\n"+
+			"no level is written\n",
+		buf.String())
+
+	// Resetting the levels raises the outputs' filter again.
+	require.NoError(f.SetSubsystemLevel("raft", ""))
+	require.NoError(f.SetSubsystemLevels(nil))
+	require.Empty(f.SubsystemLevels())
+	require.Equal("INFO", string(filt.MinLevel))
+
+	require.NoError(f.SetLevel("debug"))
+	require.Equal("DEBUG", string(filt.MinLevel))
+
+	require.Error(f.SetLevel("bogus"))
+	require.Error(f.SetSubsystemLevel("raft", "bogus"))
+	require.Error(f.SetSubsystemLevel("", "DEBUG"))
+	require.Error(f.SetSubsystemLevels(map[string]string{"raft": "bogus"}))
+}
+
+func TestInSubsystems(t *testing.T) {
+	t.Parallel()
+	cases := []struct {
+		module     string
+		subsystems []string
+		want       bool
+	}{
+		{"raft", []string{"raft"}, true},
+		{"consul.acl", []string{"acl"}, true},
+		{"consul.acl", []string{"consul.acl"}, true},
+		{"consul.acl", []string{"raft", "dns"}, false},
+		{"", []string{"raft"}, false},
+	}
+	for _, c := range cases {
+		require.Equal(t, c.want, InSubsystems(c.module, c.subsystems), c.module)
+	}
+}
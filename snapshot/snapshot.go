@@ -140,13 +140,14 @@ func Verify(in io.Reader) (*raft.SnapshotMeta, error) {
 	return &metadata, nil
 }
 
-// Restore takes the snapshot from the reader and attempts to apply it to the
-// given Raft instance.
-func Restore(logger *log.Logger, in io.Reader, r *raft.Raft) error {
+// Read takes the snapshot from the reader and extracts the FSM state to a
+// temporary file, which is returned rewound along with the snapshot's
+// metadata. The caller is responsible for closing and removing the file.
+func Read(logger *log.Logger, in io.Reader) (*os.File, *raft.SnapshotMeta, error) {
 	// Wrap the reader in a gzip decompressor.
 	decomp, err := gzip.NewReader(in)
 	if err != nil {
-		return fmt.Errorf("failed to decompress snapshot: %v", err)
+		return nil, nil, fmt.Errorf("failed to decompress snapshot: %v", err)
 	}
 	defer func() {
 		if err := decomp.Close(); err != nil {
@@ -158,35 +159,51 @@ func Restore(logger *log.Logger, in io.Reader, r *raft.Raft) error {
 	// we can avoid buffering in memory.
 	snap, err := ioutil.TempFile("", "snapshot")
 	if err != nil {
-		return fmt.Errorf("failed to create temp snapshot file: %v", err)
+		return nil, nil, fmt.Errorf("failed to create temp snapshot file: %v", err)
 	}
-	defer func() {
-		if err := snap.Close(); err != nil {
-			logger.Printf("[ERR] snapshot: Failed to close temp snapshot: %v", err)
-		}
-		if err := os.Remove(snap.Name()); err != nil {
-			logger.Printf("[ERR] snapshot: Failed to clean up temp snapshot: %v", err)
-		}
-	}()
 
 	// Read the archive.
 	var metadata raft.SnapshotMeta
 	if err := read(decomp, &metadata, snap); err != nil {
-		return fmt.Errorf("failed to read snapshot file: %v", err)
+		cleanup(logger, snap)
+		return nil, nil, fmt.Errorf("failed to read snapshot file: %v", err)
 	}
 
 	// Sync and rewind the file so it's ready to be read again.
 	if err := snap.Sync(); err != nil {
-		return fmt.Errorf("failed to sync temp snapshot: %v", err)
+		cleanup(logger, snap)
+		return nil, nil, fmt.Errorf("failed to sync temp snapshot: %v", err)
 	}
 	if _, err := snap.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to rewind temp snapshot: %v", err)
+		cleanup(logger, snap)
+		return nil, nil, fmt.Errorf("failed to rewind temp snapshot: %v", err)
 	}
+	return snap, &metadata, nil
+}
+
+// Restore takes the snapshot from the reader and attempts to apply it to the
+// given Raft instance.
+func Restore(logger *log.Logger, in io.Reader, r *raft.Raft) error {
+	snap, metadata, err := Read(logger, in)
+	if err != nil {
+		return err
+	}
+	defer cleanup(logger, snap)
 
 	// Feed the snapshot into Raft.
-	if err := r.Restore(&metadata, snap, 0); err != nil {
+	if err := r.Restore(metadata, snap, 0); err != nil {
 		return fmt.Errorf("Raft error when restoring snapshot: %v", err)
 	}
 
 	return nil
 }
+
+// cleanup closes and removes a temp snapshot file.
+func cleanup(logger *log.Logger, snap *os.File) {
+	if err := snap.Close(); err != nil {
+		logger.Printf("[ERR] snapshot: Failed to close temp snapshot: %v", err)
+	}
+	if err := os.Remove(snap.Name()); err != nil {
+		logger.Printf("[ERR] snapshot: Failed to clean up temp snapshot: %v", err)
+	}
+}
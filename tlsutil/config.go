@@ -1,11 +1,16 @@
 package tlsutil
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"crypto/tls"
 	"crypto/x509"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -112,6 +117,11 @@ type Config struct {
 	// the server using the same TLS configuration as the agent (CA, cert,
 	// and key).
 	EnableAgentTLSForChecks bool
+
+	// AutoEncryptTLS is used on the client agents which request their
+	// certificate and the CAs of the servers with auto-encrypt, so a CA
+	// doesn't have to be configured to verify the outgoing connections.
+	AutoEncryptTLS bool
 }
 
 // KeyPair is used to open and parse a certificate and key file
@@ -139,6 +149,37 @@ type Configurator struct {
 	cas     *x509.CertPool
 	logger  *log.Logger
 	version int
+
+	// fingerprint is the hash of the content of the files of the
+	// configuration when they were loaded.
+	fingerprint []byte
+
+	// manualCAs and manualCAPems are the CAs loaded from the files of the
+	// configuration. cas also has the CAs of auto-encrypt.
+	manualCAs    *x509.CertPool
+	manualCAPems []string
+	autoEncrypt  autoEncrypt
+
+	// updateLock serializes the updates, so that the reloads of the
+	// changed files can't revert a concurrent update.
+	updateLock sync.Mutex
+}
+
+// autoEncrypt holds the certificate and CAs obtained with auto-encrypt.
+type autoEncrypt struct {
+	// cert is the certificate of a client agent, signed by the Connect CA.
+	cert *tls.Certificate
+
+	// manualCAPems are the CAs of the servers, sent to the client agents.
+	manualCAPems []string
+
+	// connectCAPems are the roots of the Connect CA, which signed the
+	// certificates of the client agents.
+	connectCAPems []string
+
+	// verifyServerHostname is whether the servers asked the client agents
+	// to verify their hostname.
+	verifyServerHostname bool
 }
 
 // NewConfigurator creates a new Configurator and sets the provided
@@ -156,6 +197,39 @@ func NewConfigurator(config Config, logger *log.Logger) (*Configurator, error) {
 // *tls.Config.
 // This function acquires a write lock because it writes the new config.
 func (c *Configurator) Update(config Config) error {
+	c.updateLock.Lock()
+	defer c.updateLock.Unlock()
+	return c.update(config)
+}
+
+// ReloadChangedFiles reloads the certificate, key and CA files of the
+// configuration if their content changed since they were loaded, so that
+// they can be rotated without reloading the agent. It returns whether they
+// were reloaded. The previous configuration is kept if the new files can't
+// be loaded, such as when they are only partially written.
+func (c *Configurator) ReloadChangedFiles() (bool, error) {
+	c.updateLock.Lock()
+	defer c.updateLock.Unlock()
+
+	c.RLock()
+	config := *c.base
+	fingerprint := c.fingerprint
+	c.RUnlock()
+
+	if bytes.Equal(fingerprint, fingerprintFiles(config)) {
+		return false, nil
+	}
+	if err := c.update(config); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (c *Configurator) update(config Config) error {
+	// The files are hashed before being loaded, so that changes made while
+	// they are loaded are picked up by the next reload.
+	fingerprint := fingerprintFiles(config)
+
 	cert, err := loadKeyPair(config.CertFile, config.KeyFile)
 	if err != nil {
 		return err
@@ -164,20 +238,122 @@ func (c *Configurator) Update(config Config) error {
 	if err != nil {
 		return err
 	}
+	pems, err := loadCAPems(config.CAFile, config.CAPath)
+	if err != nil {
+		return err
+	}
 
 	if err = c.check(config, cas, cert); err != nil {
 		return err
 	}
 	c.Lock()
+	defer c.Unlock()
 	c.base = &config
 	c.cert = cert
-	c.cas = cas
+	c.manualCAs = cas
+	c.manualCAPems = pems
+	c.fingerprint = fingerprint
+	if err := c.updateCAsLocked(); err != nil {
+		return err
+	}
 	c.version++
-	c.Unlock()
-	c.log("Update")
+	if c.logger != nil {
+		c.logger.Printf("[DEBUG] tlsutil: Update with version %d", c.version)
+	}
 	return nil
 }
 
+// UpdateAutoEncrypt sets the certificate of the client agent and the CAs
+// obtained from the servers with auto-encrypt.
+func (c *Configurator) UpdateAutoEncrypt(manualCAPems, connectCAPems []string, certPEM, keyPEM string, verifyServerHostname bool) error {
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return fmt.Errorf("Failed to load auto-encrypt cert/key pair: %v", err)
+	}
+
+	c.updateLock.Lock()
+	defer c.updateLock.Unlock()
+	c.Lock()
+	defer c.Unlock()
+
+	prev := c.autoEncrypt
+	c.autoEncrypt = autoEncrypt{
+		cert:                 &cert,
+		manualCAPems:         manualCAPems,
+		connectCAPems:        connectCAPems,
+		verifyServerHostname: verifyServerHostname,
+	}
+	if err := c.updateCAsLocked(); err != nil {
+		c.autoEncrypt = prev
+		return err
+	}
+	c.version++
+	return nil
+}
+
+// UpdateAutoEncryptCA sets the roots of the Connect CA on the servers, so
+// that they accept the certificates of the client agents obtained with
+// auto-encrypt.
+func (c *Configurator) UpdateAutoEncryptCA(connectCAPems []string) error {
+	c.updateLock.Lock()
+	defer c.updateLock.Unlock()
+	c.Lock()
+	defer c.Unlock()
+
+	prev := c.autoEncrypt.connectCAPems
+	c.autoEncrypt.connectCAPems = connectCAPems
+	if err := c.updateCAsLocked(); err != nil {
+		c.autoEncrypt.connectCAPems = prev
+		return err
+	}
+	c.version++
+	return nil
+}
+
+// updateCAsLocked sets the CAs to the ones of the configuration and of
+// auto-encrypt. The lock must be held.
+func (c *Configurator) updateCAsLocked() error {
+	if len(c.autoEncrypt.manualCAPems) == 0 && len(c.autoEncrypt.connectCAPems) == 0 {
+		c.cas = c.manualCAs
+		return nil
+	}
+
+	pool := x509.NewCertPool()
+	for _, pem := range c.manualCAPems {
+		pool.AppendCertsFromPEM([]byte(pem))
+	}
+	for _, pem := range append(c.autoEncrypt.manualCAPems, c.autoEncrypt.connectCAPems...) {
+		if !pool.AppendCertsFromPEM([]byte(pem)) {
+			return fmt.Errorf("Failed to load auto-encrypt CA")
+		}
+	}
+	c.cas = pool
+	return nil
+}
+
+// ManualCAPems returns the PEM-encoded CAs loaded from the files of the
+// configuration.
+func (c *Configurator) ManualCAPems() []string {
+	c.RLock()
+	defer c.RUnlock()
+	return append([]string(nil), c.manualCAPems...)
+}
+
+// AutoEncryptCert returns the certificate obtained with auto-encrypt, or
+// nil if there is none.
+func (c *Configurator) AutoEncryptCert() *x509.Certificate {
+	c.RLock()
+	defer c.RUnlock()
+	if c.autoEncrypt.cert == nil {
+		return nil
+	}
+	cert, err := x509.ParseCertificate(c.autoEncrypt.cert.Certificate[0])
+	if err != nil {
+		return nil
+	}
+	return cert
+}
+
 func (c *Configurator) check(config Config, cas *x509.CertPool, cert *tls.Certificate) error {
 	// Check if a minimum TLS version was set
 	if config.TLSMinVersion != "" {
@@ -186,8 +362,9 @@ func (c *Configurator) check(config Config, cas *x509.CertPool, cert *tls.Certif
 		}
 	}
 
-	// Ensure we have a CA if VerifyOutgoing is set
-	if config.VerifyOutgoing && cas == nil {
+	// Ensure we have a CA if VerifyOutgoing is set, unless it's obtained
+	// with auto-encrypt
+	if config.VerifyOutgoing && cas == nil && !config.AutoEncryptTLS {
 		return fmt.Errorf("VerifyOutgoing set, and no CA certificate provided!")
 	}
 
@@ -232,6 +409,65 @@ func loadCAs(caFile, caPath string) (*x509.CertPool, error) {
 	return nil, nil
 }
 
+// loadCAPems returns the content of the CA files, to send them to the client
+// agents with auto-encrypt.
+func loadCAPems(caFile, caPath string) ([]string, error) {
+	var pems []string
+	readFile := func(path string) error {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("Error loading CA: %v", err)
+		}
+		pems = append(pems, string(content))
+		return nil
+	}
+
+	if caFile != "" {
+		if err := readFile(caFile); err != nil {
+			return nil, err
+		}
+	} else if caPath != "" {
+		err := filepath.Walk(caPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			return readFile(path)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pems, nil
+}
+
+// fingerprintFiles returns a hash of the content of the certificate, key and
+// CA files of the configuration. Files which can't be read are hashed as
+// empty, so that they are reloaded once they can be.
+func fingerprintFiles(config Config) []byte {
+	h := sha256.New()
+	hashFile := func(path string) {
+		content, _ := ioutil.ReadFile(path)
+		fmt.Fprintf(h, "%s:%d:", path, len(content))
+		h.Write(content)
+	}
+
+	hashFile(config.CertFile)
+	hashFile(config.KeyFile)
+	hashFile(config.CAFile)
+	if config.CAPath != "" {
+		filepath.Walk(config.CAPath, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() {
+				hashFile(path)
+			}
+			return nil
+		})
+	}
+	return h.Sum(nil)
+}
+
 // commonTLSConfig generates a *tls.Config from the base configuration the
 // Configurator has. It accepts an additional flag in case a config is needed
 // for incoming TLS connections.
@@ -240,7 +476,7 @@ func (c *Configurator) commonTLSConfig(additionalVerifyIncomingFlag bool) *tls.C
 	c.RLock()
 	defer c.RUnlock()
 	tlsConfig := &tls.Config{
-		InsecureSkipVerify: !c.base.VerifyServerHostname,
+		InsecureSkipVerify: !c.verifyServerHostnameLocked(),
 	}
 
 	// Set the cipher suites
@@ -254,6 +490,9 @@ func (c *Configurator) commonTLSConfig(additionalVerifyIncomingFlag bool) *tls.C
 		return c.cert, nil
 	}
 	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		if c.cert == nil && c.autoEncrypt.cert != nil {
+			return c.autoEncrypt.cert, nil
+		}
 		return c.cert, nil
 	}
 
@@ -277,14 +516,22 @@ func (c *Configurator) commonTLSConfig(additionalVerifyIncomingFlag bool) *tls.C
 func (c *Configurator) outgoingRPCTLSDisabled() bool {
 	c.RLock()
 	defer c.RUnlock()
-	return c.cas == nil && !c.base.VerifyOutgoing
+	return c.cas == nil && !c.base.VerifyOutgoing && !c.base.AutoEncryptTLS
 }
 
-// This function acquires a read lock because it reads from the config.
+// This function acquires a read lock because it reads from the config. The
+// servers are always verified once auto-encrypt provided their CAs.
 func (c *Configurator) someValuesFromConfig() (bool, bool, string) {
 	c.RLock()
 	defer c.RUnlock()
-	return c.base.VerifyServerHostname, c.base.VerifyOutgoing, c.base.Domain
+	verifyOutgoing := c.base.VerifyOutgoing || c.autoEncrypt.cert != nil
+	return c.verifyServerHostnameLocked(), verifyOutgoing, c.base.Domain
+}
+
+// verifyServerHostnameLocked returns whether the hostname of the servers is
+// verified. The lock must be held.
+func (c *Configurator) verifyServerHostnameLocked() bool {
+	return c.base.VerifyServerHostname || c.autoEncrypt.verifyServerHostname
 }
 
 // This function acquires a read lock because it reads from the config.
@@ -339,6 +586,75 @@ func (c *Configurator) IncomingHTTPSConfig() *tls.Config {
 	return config
 }
 
+// IncomingInsecureRPCConfig generates a *tls.Config for the incoming RPC
+// connections of the client agents requesting their certificate with
+// auto-encrypt. They aren't verified since they don't have one yet.
+func (c *Configurator) IncomingInsecureRPCConfig() *tls.Config {
+	c.log("IncomingInsecureRPCConfig")
+	config := c.commonTLSConfig(false)
+	config.ClientAuth = tls.NoClientCert
+	config.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		return c.IncomingInsecureRPCConfig(), nil
+	}
+	return config
+}
+
+// OutgoingAutoEncryptConfig generates a *tls.Config for the connections of
+// the client agents requesting their certificate with auto-encrypt. The
+// servers are only verified when a CA is configured, and their hostname
+// isn't verified.
+func (c *Configurator) OutgoingAutoEncryptConfig() *tls.Config {
+	c.log("OutgoingAutoEncryptConfig")
+	config := c.commonTLSConfig(false)
+	config.InsecureSkipVerify = true
+	if roots := config.RootCAs; roots != nil {
+		config.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyChain(rawCerts, roots)
+		}
+	}
+	return config
+}
+
+// verifyChain verifies that the given certificate chain is signed by one of
+// the roots, without verifying the hostname.
+func verifyChain(rawCerts [][]byte, roots *x509.CertPool) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("no certificate presented")
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+	}
+	var leaf *x509.Certificate
+	for i, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			leaf = cert
+		} else {
+			opts.Intermediates.AddCert(cert)
+		}
+	}
+	_, err := leaf.Verify(opts)
+	return err
+}
+
+// IncomingGRPCConfig generates a *tls.Config for incoming gRPC connections.
+// The clients aren't verified, but the certificate is looked up on each
+// handshake so that it follows the updates.
+func (c *Configurator) IncomingGRPCConfig() *tls.Config {
+	c.log("IncomingGRPCConfig")
+	return &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			c.RLock()
+			defer c.RUnlock()
+			return c.cert, nil
+		},
+	}
+}
+
 // IncomingTLSConfig generates a *tls.Config for outgoing TLS connections for
 // checks. This function is separated because there is an extra flag to
 // consider for checks. EnableAgentTLSForChecks and InsecureSkipVerify has to
@@ -358,6 +674,58 @@ func (c *Configurator) OutgoingTLSConfigForCheck(skipVerify bool) *tls.Config {
 	return config
 }
 
+// CheckTLSOptions are the TLS options of a check that take precedence over
+// the TLS configuration of the agent.
+type CheckTLSOptions struct {
+	// SkipVerify disables the verification of the certificate of the
+	// checked endpoint.
+	SkipVerify bool
+
+	// ServerName is the name used for SNI and to verify the certificate of
+	// the checked endpoint.
+	ServerName string
+
+	// CAFile is the CA used to verify the certificate of the checked
+	// endpoint.
+	CAFile string
+
+	// CertFile and KeyFile are the client certificate presented to the
+	// checked endpoint.
+	CertFile string
+	KeyFile  string
+}
+
+// OutgoingTLSConfigForCheckOpts generates a *tls.Config for outgoing checks
+// like OutgoingTLSConfigForCheck, overridden by the TLS options of the
+// check.
+func (c *Configurator) OutgoingTLSConfigForCheckOpts(opts CheckTLSOptions) (*tls.Config, error) {
+	config := c.OutgoingTLSConfigForCheck(opts.SkipVerify)
+	if opts.ServerName != "" {
+		config.ServerName = opts.ServerName
+	}
+	if opts.CAFile != "" {
+		cas, err := loadCAs(opts.CAFile, "")
+		if err != nil {
+			return nil, err
+		}
+		config.RootCAs = cas
+	}
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		if opts.CertFile == "" || opts.KeyFile == "" {
+			return nil, fmt.Errorf("Both a client certificate and key must be provided")
+		}
+		cert, err := loadKeyPair(opts.CertFile, opts.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{*cert}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return cert, nil
+		}
+	}
+	return config, nil
+}
+
 // OutgoingRPCConfig generates a *tls.Config for outgoing RPC connections. If
 // there is a CA or VerifyOutgoing is set, a *tls.Config will be provided,
 // otherwise we assume that no TLS should be used.
@@ -9,6 +9,8 @@ import (
 	"io/ioutil"
 	"log"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -688,6 +690,38 @@ func TestConfigurator_OutgoingTLSConfigForChecks(t *testing.T) {
 	require.Equal(t, c.base.ServerName, tlsConf.ServerName)
 }
 
+func TestConfigurator_OutgoingTLSConfigForCheckOpts(t *testing.T) {
+	c := Configurator{base: &Config{
+		EnableAgentTLSForChecks: true,
+		ServerName:              "servername",
+	}}
+	tlsConf, err := c.OutgoingTLSConfigForCheckOpts(CheckTLSOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "servername", tlsConf.ServerName)
+	require.Empty(t, tlsConf.Certificates)
+
+	tlsConf, err = c.OutgoingTLSConfigForCheckOpts(CheckTLSOptions{
+		SkipVerify: true,
+		ServerName: "checkname",
+		CAFile:     "../test/ca/root.cer",
+		CertFile:   "../test/key/ourdomain.cer",
+		KeyFile:    "../test/key/ourdomain.key",
+	})
+	require.NoError(t, err)
+	require.True(t, tlsConf.InsecureSkipVerify)
+	require.Equal(t, "checkname", tlsConf.ServerName)
+	require.NotNil(t, tlsConf.RootCAs)
+	require.Len(t, tlsConf.Certificates, 1)
+	cert, err := tlsConf.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, &tlsConf.Certificates[0], cert)
+
+	_, err = c.OutgoingTLSConfigForCheckOpts(CheckTLSOptions{CertFile: "../test/key/ourdomain.cer"})
+	require.Error(t, err)
+	_, err = c.OutgoingTLSConfigForCheckOpts(CheckTLSOptions{CAFile: "../test/ca/missing.cer"})
+	require.Error(t, err)
+}
+
 func TestConfigurator_OutgoingRPCConfig(t *testing.T) {
 	c := Configurator{base: &Config{}}
 	require.Nil(t, c.OutgoingRPCConfig())
@@ -740,6 +774,92 @@ func TestConfigurator_UpdateSetsStuff(t *testing.T) {
 	require.Equal(t, 2, c.version)
 }
 
+func TestConfigurator_ReloadChangedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tlsutil")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	copyFile := func(src, dst string) {
+		content, err := ioutil.ReadFile(src)
+		require.NoError(t, err)
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, dst), content, 0600))
+	}
+	copyFile("../test/key/ourdomain.cer", "cert.pem")
+	copyFile("../test/key/ourdomain.key", "key.pem")
+
+	c, err := NewConfigurator(Config{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+	}, nil)
+	require.NoError(t, err)
+	cert := c.cert
+
+	// Nothing is reloaded while the files don't change.
+	reloaded, err := c.ReloadChangedFiles()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+	require.Equal(t, 1, c.version)
+
+	// The new certificate is loaded once both files changed.
+	copyFile("../test/key/ssl-cert-snakeoil.pem", "cert.pem")
+	_, err = c.ReloadChangedFiles()
+	require.Error(t, err)
+	require.Equal(t, cert, c.cert)
+
+	copyFile("../test/key/ssl-cert-snakeoil.key", "key.pem")
+	reloaded, err = c.ReloadChangedFiles()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+	require.Equal(t, 2, c.version)
+	require.NotEqual(t, cert.Certificate, c.cert.Certificate)
+
+	// The incoming gRPC connections use the new certificate.
+	grpcCert, err := c.IncomingGRPCConfig().GetCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, c.cert, grpcCert)
+}
+
+func TestConfigurator_UpdateAutoEncrypt(t *testing.T) {
+	read := func(path string) string {
+		content, err := ioutil.ReadFile(path)
+		require.NoError(t, err)
+		return string(content)
+	}
+	manualCA := read("../test/ca/root.cer")
+	connectCA := read("../test/key/ssl-cert-snakeoil.pem")
+
+	// The outgoing RPC connections use TLS before the certificate is set.
+	c, err := NewConfigurator(Config{AutoEncryptTLS: true}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, c.OutgoingRPCWrapper())
+	require.Nil(t, c.AutoEncryptCert())
+
+	err = c.UpdateAutoEncrypt([]string{manualCA}, []string{connectCA},
+		read("../test/key/ourdomain.cer"), read("../test/key/ourdomain.key"), false)
+	require.NoError(t, err)
+	require.NotNil(t, c.AutoEncryptCert())
+	require.Len(t, c.OutgoingRPCConfig().RootCAs.Subjects(), 2)
+
+	clientCert, err := c.OutgoingRPCConfig().GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.Equal(t, c.autoEncrypt.cert, clientCert)
+
+	// The Connect CA roots are replaced on their own.
+	require.NoError(t, c.UpdateAutoEncryptCA(nil))
+	require.Len(t, c.OutgoingRPCConfig().RootCAs.Subjects(), 1)
+
+	// Invalid certificates are rejected.
+	err = c.UpdateAutoEncrypt(nil, nil, "bad", "bad", false)
+	require.Error(t, err)
+}
+
+func TestConfigurator_ManualCAPems(t *testing.T) {
+	c, err := NewConfigurator(Config{CAFile: "../test/ca/root.cer"}, nil)
+	require.NoError(t, err)
+	require.Len(t, c.ManualCAPems(), 1)
+	require.Contains(t, c.ManualCAPems()[0], "BEGIN CERTIFICATE")
+}
+
 func TestConfigurator_ServerNameOrNodeName(t *testing.T) {
 	c := Configurator{base: &Config{}}
 	type variant struct {